@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/openshift/generic-admission-server/pkg/cmd"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1/validation"
 	"github.com/pingcap/tidb-operator/pkg/features"
 	"github.com/pingcap/tidb-operator/pkg/version"
 	"github.com/pingcap/tidb-operator/pkg/webhook/pod"
@@ -43,6 +44,7 @@ func init() {
 	flag.BoolVar(&printVersion, "version", false, "Show version and quit")
 	flag.StringVar(&extraServiceAccounts, "extraServiceAccounts", "", "comma-separated, extra Service Accounts the Webhook should control. The full pattern for each common service account is system:serviceaccount:<namespace>:<serviceaccount-name>")
 	flag.DurationVar(&minResyncDuration, "min-resync-duration", 12*time.Hour, "The resync period in reflectors will be random between MinResyncPeriod and 2*MinResyncPeriod.")
+	flag.IntVar(&validation.MaxMinorVersionSkip, "max-minor-version-skip", 1, "The max number of TiDB minor versions an upgrade of TidbCluster may skip without the annotation tidb.pingcap.com/force-version-skip, a negative value disables the check.")
 	features.DefaultFeatureGate.AddFlag(flag.CommandLine)
 }
 
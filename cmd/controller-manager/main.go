@@ -38,7 +38,9 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/controller/tidbmonitor"
 	"github.com/pingcap/tidb-operator/pkg/features"
 	"github.com/pingcap/tidb-operator/pkg/metrics"
+	"github.com/pingcap/tidb-operator/pkg/readiness"
 	"github.com/pingcap/tidb-operator/pkg/scheme"
+	"github.com/pingcap/tidb-operator/pkg/tracing"
 	"github.com/pingcap/tidb-operator/pkg/upgrader"
 	"github.com/pingcap/tidb-operator/pkg/version"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -102,6 +104,12 @@ func main() {
 		klog.Fatalf("failed to get config: %v", err)
 	}
 
+	if cliCfg.TracingOTLPEndpoint != "" {
+		tracing.SetTracer(tracing.NewTracer(tracing.NewOTLPExporter(cliCfg.TracingOTLPEndpoint, cliCfg.TracingExportInterval)))
+		defer tracing.Shutdown()
+		cfg.Wrap(tracing.WrapKubeTransport)
+	}
+
 	cli, err := versioned.NewForConfig(cfg)
 	if err != nil {
 		klog.Fatalf("failed to create Clientset: %v", err)
@@ -224,7 +232,7 @@ func main() {
 		})
 	}, cliCfg.WaitDuration)
 
-	srv := createHTTPServer()
+	srv := createHTTPServer(deps)
 	sc := make(chan os.Signal, 1)
 	signal.Notify(sc,
 		syscall.SIGHUP,
@@ -247,10 +255,16 @@ func main() {
 	klog.Infof("tidb-controller-manager exited")
 }
 
-func createHTTPServer() *http.Server {
+func createHTTPServer(deps *controller.Dependencies) *http.Server {
 	serverMux := http.NewServeMux()
 	// HTTP path for prometheus.
 	serverMux.Handle("/metrics", promhttp.Handler())
+	if deps.CLIConfig.ReadinessEndpointEnabled {
+		// HTTP path for the readiness of TidbClusters, only served by the
+		// leader as the informers are started after the leader is elected
+		informer := deps.InformerFactory.Pingcap().V1alpha1().TidbClusters().Informer()
+		serverMux.Handle(readiness.PathPrefix, readiness.NewHandler(deps.TiDBClusterLister, informer.HasSynced))
+	}
 
 	return &http.Server{
 		Addr:    ":6060",
@@ -14,6 +14,7 @@ require (
 	github.com/pingcap/errors v0.11.0
 	github.com/prometheus/common v0.26.0
 	github.com/prometheus/prometheus v1.8.2
+	github.com/robfig/cron v1.1.0
 	k8s.io/api v0.19.14
 	k8s.io/apiextensions-apiserver v0.19.14
 	k8s.io/apimachinery v0.19.14
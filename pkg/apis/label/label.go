@@ -46,6 +46,10 @@ const (
 	StoreIDLabelKey string = "tidb.pingcap.com/store-id"
 	// MemberIDLabelKey is member id label key
 	MemberIDLabelKey string = "tidb.pingcap.com/member-id"
+	// PDGroupLabelKey is the label key of the StatefulSets and Pods of the PD groups, its value is the group name
+	PDGroupLabelKey string = "tidb.pingcap.com/pd-group"
+	// PostScaleHookLabelKey is the label key of the Jobs of the PostScale hooks, its value is the component scaled
+	PostScaleHookLabelKey string = "tidb.pingcap.com/post-scale-hook"
 
 	// InitLabelKey is the key for TiDB initializer
 	InitLabelKey string = "tidb.pingcap.com/initializer"
@@ -81,6 +85,9 @@ const (
 	AnnPodNameKey string = "tidb.pingcap.com/pod-name"
 	// AnnPVCDeferDeleting is pvc defer deletion annotation key used in PVC for defer deleting PVC
 	AnnPVCDeferDeleting = "tidb.pingcap.com/pvc-defer-deleting"
+	// AnnPVCResizeFailedAt is PVC annotation key to record since when the expansion of the PVC fails,
+	// the PVC is not expanded to another size until the backoff elapses
+	AnnPVCResizeFailedAt = "tidb.pingcap.com/pvc-resize-failed-at"
 	// AnnPVCPodScheduling is pod scheduling annotation key, it represents whether the pod is scheduling
 	AnnPVCPodScheduling = "tidb.pingcap.com/pod-scheduling"
 	// AnnTiDBPartition is pod annotation which TiDB pod should upgrade to
@@ -89,19 +96,62 @@ const (
 	AnnTiKVPartition string = "tidb.pingcap.com/tikv-partition"
 	// AnnForceUpgradeKey is tc annotation key to indicate whether force upgrade should be done
 	AnnForceUpgradeKey = "tidb.pingcap.com/force-upgrade"
+	// AnnForceUnbalancedScaleIn is tc annotation key to indicate whether TiKV may be scaled in
+	// when the stores become unbalanced across the zones
+	AnnForceUnbalancedScaleIn = "tidb.pingcap.com/force-unbalanced-scale-in"
+	// AnnAllowEvenPDReplicas is tc annotation key to indicate whether PD is allowed to be scaled to an even number of replicas
+	AnnAllowEvenPDReplicas = "tidb.pingcap.com/allow-even-pd-replicas"
+	// AnnForceVersionSkip is tc annotation key to indicate whether the upgrade may skip more TiDB minor versions than
+	// allowed by the admission webhook
+	AnnForceVersionSkip = "tidb.pingcap.com/force-version-skip"
+	// AnnScaleDryRun is tc annotation key to indicate the scales are only planned and reported, not done
+	AnnScaleDryRun = "tidb.pingcap.com/scale-dry-run"
+	// AnnIgnoreScaleOrder is tc annotation key to indicate whether the components may be scaled without waiting
+	// for the components ordered before them, e.g. PD may be scaled in while a TiKV scale-in is stuck
+	AnnIgnoreScaleOrder = "tidb.pingcap.com/ignore-scale-order"
 	// AnnPDDeferDeleting is pd pod annotation key  in pod for defer for deleting pod
 	AnnPDDeferDeleting = "tidb.pingcap.com/pd-defer-deleting"
+	// AnnPreScaleInHookDone is pod annotation key to indicate the pre scale-in hook of the pod succeeded
+	AnnPreScaleInHookDone = "tidb.pingcap.com/pre-scale-in-hook-done"
+	// AnnPreUpgradeHookDone is pod annotation key to indicate the pre-upgrade hook of the pod succeeded,
+	// the value is the revision the pod is upgraded to
+	AnnPreUpgradeHookDone = "tidb.pingcap.com/pre-upgrade-hook-done"
+	// AnnPostUpgradeHookDone is pod annotation key to indicate the post-upgrade hook of the pod succeeded,
+	// the value is the revision the pod is upgraded to
+	AnnPostUpgradeHookDone = "tidb.pingcap.com/post-upgrade-hook-done"
+	// AnnPostUpgradeHookPods is sts annotation key to record the pods whose post-upgrade hooks are not run yet
+	AnnPostUpgradeHookPods = "tidb.pingcap.com/post-upgrade-hook-pods"
+	// AnnScaleInProtected is pod annotation key to indicate the pod must not be scaled in
+	AnnScaleInProtected = "tidb.pingcap.com/scale-in-protected"
 	// AnnSysctlInit is pod annotation key to indicate whether configuring sysctls with init container
 	AnnSysctlInit = "tidb.pingcap.com/sysctl-init"
 	// AnnEvictLeaderBeginTime is pod annotation key to indicate the begin time for evicting region leader
 	AnnEvictLeaderBeginTime = "tidb.pingcap.com/evictLeaderBeginTime"
+	// AnnDrainBeginTime is pod annotation key to indicate the begin time for draining the client connections
+	// of tidb, or for draining the tables of ticdc
+	AnnDrainBeginTime = "tidb.pingcap.com/drain-begin-time"
 	// AnnStsLastSyncTimestamp is sts annotation key to indicate the last timestamp the operator sync the sts
 	AnnStsLastSyncTimestamp = "tidb.pingcap.com/sync-timestamp"
+	// AnnRestartAt is annotation key to request a graceful restart at the RFC3339 time in the value. On a Pod,
+	// the Pod is restarted once the time is reached if it was created before. In spec.<component>.annotations,
+	// the Pods of the component are restarted by a rolling upgrade when the value is changed.
+	AnnRestartAt = "tidb.pingcap.com/restart-at"
+	// AnnReconcileNow is tidbcluster annotation key to request an immediate reconcile, the value is a nonce
+	// that is changed for every request, and the annotation is removed once the reconcile is done
+	AnnReconcileNow = "tidb.pingcap.com/reconcile-now"
 
 	// AnnForceUpgradeVal is tc annotation value to indicate whether force upgrade should be done
 	AnnForceUpgradeVal = "true"
 	// AnnSysctlInitVal is pod annotation value to indicate whether configuring sysctls with init container
 	AnnSysctlInitVal = "true"
+	// AnnScaleInProtectedVal is pod annotation value to indicate the pod must not be scaled in
+	AnnScaleInProtectedVal = "true"
+	// AnnForceUnbalancedScaleInVal is tc annotation value to indicate TiKV may be scaled in
+	// when the stores become unbalanced across the zones
+	AnnForceUnbalancedScaleInVal = "true"
+	// AnnIgnoreScaleOrderVal is tc annotation value to indicate the components may be scaled without waiting
+	// for the components ordered before them
+	AnnIgnoreScaleOrderVal = "true"
 
 	// AnnPDDeleteSlots is annotation key of pd delete slots.
 	AnnPDDeleteSlots = "pd.tidb.pingcap.com/delete-slots"
@@ -109,8 +159,18 @@ const (
 	AnnTiDBDeleteSlots = "tidb.tidb.pingcap.com/delete-slots"
 	// AnnTiKVDeleteSlots is annotation key of tikv delete slots.
 	AnnTiKVDeleteSlots = "tikv.tidb.pingcap.com/delete-slots"
+	// AnnTiKVMigrateOffNodes is annotation key of the comma separated names of
+	// the nodes to migrate the TiKV stores off one by one, e.g. "node-1,node-2".
+	AnnTiKVMigrateOffNodes = "tikv.tidb.pingcap.com/migrate-off-nodes"
+	// AnnTiKVMigrateOffNodeSelector is annotation key of the label selector of
+	// the nodes to migrate the TiKV stores off one by one, e.g. "pool=old".
+	AnnTiKVMigrateOffNodeSelector = "tikv.tidb.pingcap.com/migrate-off-node-selector"
 	// AnnTiFlashDeleteSlots is annotation key of tiflash delete slots.
 	AnnTiFlashDeleteSlots = "tiflash.tidb.pingcap.com/delete-slots"
+	// AnnTiCDCDeleteSlots is annotation key of ticdc delete slots.
+	AnnTiCDCDeleteSlots = "ticdc.tidb.pingcap.com/delete-slots"
+	// AnnPumpDeleteSlots is annotation key of pump delete slots.
+	AnnPumpDeleteSlots = "pump.tidb.pingcap.com/delete-slots"
 	// AnnDMMasterDeleteSlots is annotation key of dm-master delete slots.
 	AnnDMMasterDeleteSlots = "dm-master.tidb.pingcap.com/delete-slots"
 	// AnnDMWorkerDeleteSlots is annotation key of dm-worker delete slots.
@@ -123,6 +183,8 @@ const (
 	PDLabelVal string = "pd"
 	// TiDBLabelVal is TiDB label value
 	TiDBLabelVal string = "tidb"
+	// TiDBGreenLabelVal is the label value of the green TiDB tier of a blue/green switch
+	TiDBGreenLabelVal string = "tidb-green"
 	// TiKVLabelVal is TiKV label value
 	TiKVLabelVal string = "tikv"
 	// TiFlashLabelVal is TiFlash label value
@@ -392,6 +454,16 @@ func (l Label) TiDB() Label {
 	return l.Component(TiDBLabelVal)
 }
 
+// TiDBGreen assigns the green TiDB tier to component key in label
+func (l Label) TiDBGreen() Label {
+	return l.Component(TiDBGreenLabelVal)
+}
+
+// IsTiDBGreen returns whether label is the green TiDB tier of a blue/green switch
+func (l Label) IsTiDBGreen() bool {
+	return l[ComponentLabelKey] == TiDBGreenLabelVal
+}
+
 // IsTiDB returns whether label is a TiDB component
 func (l Label) IsTiDB() bool {
 	return l[ComponentLabelKey] == TiDBLabelVal
@@ -145,6 +145,12 @@ func IsBackupFailed(backup *Backup) bool {
 	return condition != nil && condition.Status == corev1.ConditionTrue
 }
 
+// IsBackupHoldingGCLifeTime returns true if a Backup holds the tidb_gc_life_time
+// raised by the operator
+func IsBackupHoldingGCLifeTime(backup *Backup) bool {
+	return backup.Status.GCLifeTime != nil && !backup.Status.GCLifeTime.Released
+}
+
 // IsBackupScheduled returns true if a Backup has successfully scheduled
 func IsBackupScheduled(backup *Backup) bool {
 	_, condition := GetBackupCondition(&backup.Status, BackupScheduled)
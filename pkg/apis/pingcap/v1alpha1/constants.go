@@ -22,4 +22,12 @@ const (
 
 	// DefaultTidbUser is the default tidb user for login tidb cluster
 	DefaultTidbUser = "root"
+
+	// TiDBSchedulerName is the name of the scheduler extender that implements
+	// the HA scheduling of PD, TiKV and TiFlash Pods
+	TiDBSchedulerName = "tidb-scheduler"
+
+	// TiKVWalStorageVolumeName is the name of the storage volume that keeps
+	// the TiKV WAL if Spec.TiKV.WalStorageSize is set
+	TiKVWalStorageVolumeName = "wal"
 )
@@ -53,6 +53,10 @@ const (
 	TidbClusterAutoScalerKind    = "TidbClusterAutoScaler"
 	TidbClusterAutoScalerKindKey = "tidbclusterautoscaler"
 
+	TidbClusterConfigProfileName    = "tidbclusterconfigprofiles"
+	TidbClusterConfigProfileKind    = "TidbClusterConfigProfile"
+	TidbClusterConfigProfileKindKey = "tidbclusterconfigprofile"
+
 	SpecPath = "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1."
 )
 
@@ -65,25 +69,27 @@ type CrdKind struct {
 }
 
 type CrdKinds struct {
-	KindsString           string
-	TiDBCluster           CrdKind
-	DMCluster             CrdKind
-	Backup                CrdKind
-	Restore               CrdKind
-	BackupSchedule        CrdKind
-	TiDBMonitor           CrdKind
-	TiDBInitializer       CrdKind
-	TidbClusterAutoScaler CrdKind
+	KindsString              string
+	TiDBCluster              CrdKind
+	DMCluster                CrdKind
+	Backup                   CrdKind
+	Restore                  CrdKind
+	BackupSchedule           CrdKind
+	TiDBMonitor              CrdKind
+	TiDBInitializer          CrdKind
+	TidbClusterAutoScaler    CrdKind
+	TidbClusterConfigProfile CrdKind
 }
 
 var DefaultCrdKinds = CrdKinds{
-	KindsString:           "",
-	TiDBCluster:           CrdKind{Plural: TiDBClusterName, Kind: TiDBClusterKind, ShortNames: []string{"tc"}, SpecName: SpecPath + TiDBClusterKind},
-	DMCluster:             CrdKind{Plural: DMClusterName, Kind: DMClusterKind, ShortNames: []string{"dc"}, SpecName: SpecPath + DMClusterKind},
-	Backup:                CrdKind{Plural: BackupName, Kind: BackupKind, ShortNames: []string{"bk"}, SpecName: SpecPath + BackupKind},
-	Restore:               CrdKind{Plural: RestoreName, Kind: RestoreKind, ShortNames: []string{"rt"}, SpecName: SpecPath + RestoreKind},
-	BackupSchedule:        CrdKind{Plural: BackupScheduleName, Kind: BackupScheduleKind, ShortNames: []string{"bks"}, SpecName: SpecPath + BackupScheduleKind},
-	TiDBMonitor:           CrdKind{Plural: TiDBMonitorName, Kind: TiDBMonitorKind, ShortNames: []string{"tm"}, SpecName: SpecPath + TiDBMonitorKind},
-	TiDBInitializer:       CrdKind{Plural: TiDBInitializerName, Kind: TiDBInitializerKind, ShortNames: []string{"ti"}, SpecName: SpecPath + TiDBInitializerKind},
-	TidbClusterAutoScaler: CrdKind{Plural: TidbClusterAutoScalerName, Kind: TidbClusterAutoScalerKind, ShortNames: []string{"ta"}, SpecName: SpecPath + TidbClusterAutoScalerKind},
+	KindsString:              "",
+	TiDBCluster:              CrdKind{Plural: TiDBClusterName, Kind: TiDBClusterKind, ShortNames: []string{"tc"}, SpecName: SpecPath + TiDBClusterKind},
+	DMCluster:                CrdKind{Plural: DMClusterName, Kind: DMClusterKind, ShortNames: []string{"dc"}, SpecName: SpecPath + DMClusterKind},
+	Backup:                   CrdKind{Plural: BackupName, Kind: BackupKind, ShortNames: []string{"bk"}, SpecName: SpecPath + BackupKind},
+	Restore:                  CrdKind{Plural: RestoreName, Kind: RestoreKind, ShortNames: []string{"rt"}, SpecName: SpecPath + RestoreKind},
+	BackupSchedule:           CrdKind{Plural: BackupScheduleName, Kind: BackupScheduleKind, ShortNames: []string{"bks"}, SpecName: SpecPath + BackupScheduleKind},
+	TiDBMonitor:              CrdKind{Plural: TiDBMonitorName, Kind: TiDBMonitorKind, ShortNames: []string{"tm"}, SpecName: SpecPath + TiDBMonitorKind},
+	TiDBInitializer:          CrdKind{Plural: TiDBInitializerName, Kind: TiDBInitializerKind, ShortNames: []string{"ti"}, SpecName: SpecPath + TiDBInitializerKind},
+	TidbClusterAutoScaler:    CrdKind{Plural: TidbClusterAutoScalerName, Kind: TidbClusterAutoScalerKind, ShortNames: []string{"ta"}, SpecName: SpecPath + TidbClusterAutoScalerKind},
+	TidbClusterConfigProfile: CrdKind{Plural: TidbClusterConfigProfileName, Kind: TidbClusterConfigProfileKind, ShortNames: []string{"tcp"}, SpecName: SpecPath + TidbClusterConfigProfileKind},
 }
@@ -1,3 +1,4 @@
+//go:build !ignore_autogenerated
 // +build !ignore_autogenerated
 
 // Copyright PingCAP, Inc.
@@ -46,6 +47,7 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.CommonConfig":                  schema_pkg_apis_pingcap_v1alpha1_CommonConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ComponentSpec":                 schema_pkg_apis_pingcap_v1alpha1_ComponentSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ConfigMapRef":                  schema_pkg_apis_pingcap_v1alpha1_ConfigMapRef(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.CrossComponentAntiAffinity":    schema_pkg_apis_pingcap_v1alpha1_CrossComponentAntiAffinity(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.DMCluster":                     schema_pkg_apis_pingcap_v1alpha1_DMCluster(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.DMClusterList":                 schema_pkg_apis_pingcap_v1alpha1_DMClusterList(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.DMClusterSpec":                 schema_pkg_apis_pingcap_v1alpha1_DMClusterSpec(ref),
@@ -53,9 +55,12 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.DashboardConfig":               schema_pkg_apis_pingcap_v1alpha1_DashboardConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.DiscoverySpec":                 schema_pkg_apis_pingcap_v1alpha1_DiscoverySpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.DumplingConfig":                schema_pkg_apis_pingcap_v1alpha1_DumplingConfig(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.EphemeralStorageSpec":          schema_pkg_apis_pingcap_v1alpha1_EphemeralStorageSpec(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.EvictLeaderBackoff":            schema_pkg_apis_pingcap_v1alpha1_EvictLeaderBackoff(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.Experimental":                  schema_pkg_apis_pingcap_v1alpha1_Experimental(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ExternalConfig":                schema_pkg_apis_pingcap_v1alpha1_ExternalConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ExternalEndpoint":              schema_pkg_apis_pingcap_v1alpha1_ExternalEndpoint(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.FailoverSpec":                  schema_pkg_apis_pingcap_v1alpha1_FailoverSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.FileLogConfig":                 schema_pkg_apis_pingcap_v1alpha1_FileLogConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.Flash":                         schema_pkg_apis_pingcap_v1alpha1_Flash(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.FlashCluster":                  schema_pkg_apis_pingcap_v1alpha1_FlashCluster(ref),
@@ -64,34 +69,47 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.FlashSecurity":                 schema_pkg_apis_pingcap_v1alpha1_FlashSecurity(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.FlashServerConfig":             schema_pkg_apis_pingcap_v1alpha1_FlashServerConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.GcsStorageProvider":            schema_pkg_apis_pingcap_v1alpha1_GcsStorageProvider(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.HTTPScaleHook":                 schema_pkg_apis_pingcap_v1alpha1_HTTPScaleHook(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.HelperSpec":                    schema_pkg_apis_pingcap_v1alpha1_HelperSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.IngressSpec":                   schema_pkg_apis_pingcap_v1alpha1_IngressSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.IsolationRead":                 schema_pkg_apis_pingcap_v1alpha1_IsolationRead(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.JobScaleHook":                  schema_pkg_apis_pingcap_v1alpha1_JobScaleHook(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.Log":                           schema_pkg_apis_pingcap_v1alpha1_Log(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.LogTailerSpec":                 schema_pkg_apis_pingcap_v1alpha1_LogTailerSpec(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MaintenanceWindow":             schema_pkg_apis_pingcap_v1alpha1_MaintenanceWindow(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MasterConfig":                  schema_pkg_apis_pingcap_v1alpha1_MasterConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MasterKeyFileConfig":           schema_pkg_apis_pingcap_v1alpha1_MasterKeyFileConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MasterKeyKMSConfig":            schema_pkg_apis_pingcap_v1alpha1_MasterKeyKMSConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MasterSpec":                    schema_pkg_apis_pingcap_v1alpha1_MasterSpec(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MetricStabilizationGate":       schema_pkg_apis_pingcap_v1alpha1_MetricStabilizationGate(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MonitorContainer":              schema_pkg_apis_pingcap_v1alpha1_MonitorContainer(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.OpenTracing":                   schema_pkg_apis_pingcap_v1alpha1_OpenTracing(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.OpenTracingReporter":           schema_pkg_apis_pingcap_v1alpha1_OpenTracingReporter(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.OpenTracingSampler":            schema_pkg_apis_pingcap_v1alpha1_OpenTracingSampler(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDBalanceLimits":               schema_pkg_apis_pingcap_v1alpha1_PDBalanceLimits(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDConfig":                      schema_pkg_apis_pingcap_v1alpha1_PDConfig(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDGroup":                       schema_pkg_apis_pingcap_v1alpha1_PDGroup(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDLogConfig":                   schema_pkg_apis_pingcap_v1alpha1_PDLogConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDMetricConfig":                schema_pkg_apis_pingcap_v1alpha1_PDMetricConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDNamespaceConfig":             schema_pkg_apis_pingcap_v1alpha1_PDNamespaceConfig(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDRegionSizeConfig":            schema_pkg_apis_pingcap_v1alpha1_PDRegionSizeConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDReplicationConfig":           schema_pkg_apis_pingcap_v1alpha1_PDReplicationConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDScheduleConfig":              schema_pkg_apis_pingcap_v1alpha1_PDScheduleConfig(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDScheduler":                   schema_pkg_apis_pingcap_v1alpha1_PDScheduler(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDSchedulerConfig":             schema_pkg_apis_pingcap_v1alpha1_PDSchedulerConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDSecurityConfig":              schema_pkg_apis_pingcap_v1alpha1_PDSecurityConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDServerConfig":                schema_pkg_apis_pingcap_v1alpha1_PDServerConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDSpec":                        schema_pkg_apis_pingcap_v1alpha1_PDSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDStoreLabel":                  schema_pkg_apis_pingcap_v1alpha1_PDStoreLabel(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PVCResizeFailurePolicy":        schema_pkg_apis_pingcap_v1alpha1_PVCResizeFailurePolicy(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PVCSnapshotSpec":               schema_pkg_apis_pingcap_v1alpha1_PVCSnapshotSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.Performance":                   schema_pkg_apis_pingcap_v1alpha1_Performance(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PessimisticTxn":                schema_pkg_apis_pingcap_v1alpha1_PessimisticTxn(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PlacementPolicy":               schema_pkg_apis_pingcap_v1alpha1_PlacementPolicy(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PlanCache":                     schema_pkg_apis_pingcap_v1alpha1_PlanCache(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.Plugin":                        schema_pkg_apis_pingcap_v1alpha1_Plugin(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PodRestartPolicy":              schema_pkg_apis_pingcap_v1alpha1_PodRestartPolicy(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PodTemplateWebhook":            schema_pkg_apis_pingcap_v1alpha1_PodTemplateWebhook(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PreparedPlanCache":             schema_pkg_apis_pingcap_v1alpha1_PreparedPlanCache(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PrometheusConfiguration":       schema_pkg_apis_pingcap_v1alpha1_PrometheusConfiguration(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ProxyConfig":                   schema_pkg_apis_pingcap_v1alpha1_ProxyConfig(ref),
@@ -103,26 +121,37 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.Restore":                       schema_pkg_apis_pingcap_v1alpha1_Restore(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.RestoreList":                   schema_pkg_apis_pingcap_v1alpha1_RestoreList(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.RestoreSpec":                   schema_pkg_apis_pingcap_v1alpha1_RestoreSpec(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.RolloutStallPolicy":            schema_pkg_apis_pingcap_v1alpha1_RolloutStallPolicy(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.S3StorageProvider":             schema_pkg_apis_pingcap_v1alpha1_S3StorageProvider(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.SafeTLSConfig":                 schema_pkg_apis_pingcap_v1alpha1_SafeTLSConfig(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScaleHook":                     schema_pkg_apis_pingcap_v1alpha1_ScaleHook(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScaleHooks":                    schema_pkg_apis_pingcap_v1alpha1_ScaleHooks(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScalePolicy":                   schema_pkg_apis_pingcap_v1alpha1_ScalePolicy(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScaleVelocity":                 schema_pkg_apis_pingcap_v1alpha1_ScaleVelocity(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.SecretRef":                     schema_pkg_apis_pingcap_v1alpha1_SecretRef(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.Security":                      schema_pkg_apis_pingcap_v1alpha1_Security(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ServiceSpec":                   schema_pkg_apis_pingcap_v1alpha1_ServiceSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.Status":                        schema_pkg_apis_pingcap_v1alpha1_Status(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.StmtSummary":                   schema_pkg_apis_pingcap_v1alpha1_StmtSummary(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.StorageCheckSpec":              schema_pkg_apis_pingcap_v1alpha1_StorageCheckSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.StorageClaim":                  schema_pkg_apis_pingcap_v1alpha1_StorageClaim(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.StorageProvider":               schema_pkg_apis_pingcap_v1alpha1_StorageProvider(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TLSConfig":                     schema_pkg_apis_pingcap_v1alpha1_TLSConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiCDCConfig":                   schema_pkg_apis_pingcap_v1alpha1_TiCDCConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiCDCSpec":                     schema_pkg_apis_pingcap_v1alpha1_TiCDCSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBAccessConfig":              schema_pkg_apis_pingcap_v1alpha1_TiDBAccessConfig(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBAuditLogSpec":              schema_pkg_apis_pingcap_v1alpha1_TiDBAuditLogSpec(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBCanaryPolicy":              schema_pkg_apis_pingcap_v1alpha1_TiDBCanaryPolicy(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBConfig":                    schema_pkg_apis_pingcap_v1alpha1_TiDBConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBProbe":                     schema_pkg_apis_pingcap_v1alpha1_TiDBProbe(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBServiceSpec":               schema_pkg_apis_pingcap_v1alpha1_TiDBServiceSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBSlowLogTailerSpec":         schema_pkg_apis_pingcap_v1alpha1_TiDBSlowLogTailerSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBSpec":                      schema_pkg_apis_pingcap_v1alpha1_TiDBSpec(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBUpgradePolicy":             schema_pkg_apis_pingcap_v1alpha1_TiDBUpgradePolicy(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiFlashConfig":                 schema_pkg_apis_pingcap_v1alpha1_TiFlashConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiFlashSpec":                   schema_pkg_apis_pingcap_v1alpha1_TiFlashSpec(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiFlashTableReplica":           schema_pkg_apis_pingcap_v1alpha1_TiFlashTableReplica(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiFlashUpgradeCheck":           schema_pkg_apis_pingcap_v1alpha1_TiFlashUpgradeCheck(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVBackupConfig":              schema_pkg_apis_pingcap_v1alpha1_TiKVBackupConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVBlockCacheConfig":          schema_pkg_apis_pingcap_v1alpha1_TiKVBlockCacheConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVCfConfig":                  schema_pkg_apis_pingcap_v1alpha1_TiKVCfConfig(ref),
@@ -137,9 +166,11 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVMasterKeyConfig":           schema_pkg_apis_pingcap_v1alpha1_TiKVMasterKeyConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVPDConfig":                  schema_pkg_apis_pingcap_v1alpha1_TiKVPDConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVPessimisticTxn":            schema_pkg_apis_pingcap_v1alpha1_TiKVPessimisticTxn(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVPorts":                     schema_pkg_apis_pingcap_v1alpha1_TiKVPorts(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVRaftDBConfig":              schema_pkg_apis_pingcap_v1alpha1_TiKVRaftDBConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVRaftstoreConfig":           schema_pkg_apis_pingcap_v1alpha1_TiKVRaftstoreConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVReadPoolConfig":            schema_pkg_apis_pingcap_v1alpha1_TiKVReadPoolConfig(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVScalePolicy":               schema_pkg_apis_pingcap_v1alpha1_TiKVScalePolicy(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVSecurityConfig":            schema_pkg_apis_pingcap_v1alpha1_TiKVSecurityConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVServerConfig":              schema_pkg_apis_pingcap_v1alpha1_TiKVServerConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVSpec":                      schema_pkg_apis_pingcap_v1alpha1_TiKVSpec(ref),
@@ -148,6 +179,7 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVTitanCfConfig":             schema_pkg_apis_pingcap_v1alpha1_TiKVTitanCfConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVTitanDBConfig":             schema_pkg_apis_pingcap_v1alpha1_TiKVTitanDBConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVUnifiedReadPoolConfig":     schema_pkg_apis_pingcap_v1alpha1_TiKVUnifiedReadPoolConfig(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVUpgradePolicy":             schema_pkg_apis_pingcap_v1alpha1_TiKVUpgradePolicy(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbAutoScalerSpec":            schema_pkg_apis_pingcap_v1alpha1_TidbAutoScalerSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbAutoScalerStatus":          schema_pkg_apis_pingcap_v1alpha1_TidbAutoScalerStatus(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbCluster":                   schema_pkg_apis_pingcap_v1alpha1_TidbCluster(ref),
@@ -156,6 +188,9 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbClusterAutoScalerRef":      schema_pkg_apis_pingcap_v1alpha1_TidbClusterAutoScalerRef(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbClusterAutoScalerSpec":     schema_pkg_apis_pingcap_v1alpha1_TidbClusterAutoScalerSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbClusterAutoScalerStatus":   schema_pkg_apis_pingcap_v1alpha1_TidbClusterAutoScalerStatus(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbClusterConfigProfile":      schema_pkg_apis_pingcap_v1alpha1_TidbClusterConfigProfile(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbClusterConfigProfileList":  schema_pkg_apis_pingcap_v1alpha1_TidbClusterConfigProfileList(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbClusterConfigProfileSpec":  schema_pkg_apis_pingcap_v1alpha1_TidbClusterConfigProfileSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbClusterList":               schema_pkg_apis_pingcap_v1alpha1_TidbClusterList(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbClusterRef":                schema_pkg_apis_pingcap_v1alpha1_TidbClusterRef(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbClusterSpec":               schema_pkg_apis_pingcap_v1alpha1_TidbClusterSpec(ref),
@@ -170,6 +205,12 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TikvAutoScalerSpec":            schema_pkg_apis_pingcap_v1alpha1_TikvAutoScalerSpec(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TikvAutoScalerStatus":          schema_pkg_apis_pingcap_v1alpha1_TikvAutoScalerStatus(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TxnLocalLatches":               schema_pkg_apis_pingcap_v1alpha1_TxnLocalLatches(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradeAutoRollback":           schema_pkg_apis_pingcap_v1alpha1_UpgradeAutoRollback(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradeCrashLoopPolicy":        schema_pkg_apis_pingcap_v1alpha1_UpgradeCrashLoopPolicy(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradeHook":                   schema_pkg_apis_pingcap_v1alpha1_UpgradeHook(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradeHooks":                  schema_pkg_apis_pingcap_v1alpha1_UpgradeHooks(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradePolicy":                 schema_pkg_apis_pingcap_v1alpha1_UpgradePolicy(ref),
+		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradePreChecks":              schema_pkg_apis_pingcap_v1alpha1_UpgradePreChecks(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.WorkerConfig":                  schema_pkg_apis_pingcap_v1alpha1_WorkerConfig(ref),
 		"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.WorkerSpec":                    schema_pkg_apis_pingcap_v1alpha1_WorkerSpec(ref),
 		"k8s.io/api/core/v1.AWSElasticBlockStoreVolumeSource":                                      schema_k8sio_api_core_v1_AWSElasticBlockStoreVolumeSource(ref),
@@ -1425,7 +1466,7 @@ func schema_pkg_apis_pingcap_v1alpha1_ComponentSpec(ref common.ReferenceCallback
 					},
 					"schedulerName": {
 						SchemaProps: spec.SchemaProps{
-							Description: "SchedulerName of the component. Override the cluster-level one if present Optional: Defaults to cluster-level setting",
+							Description: "SchedulerName of the component. Override the cluster-level one if present HA scheduling is only enforced for Pods scheduled by tidb-scheduler Optional: Defaults to cluster-level setting",
 							Type:        []string{"string"},
 							Format:      "",
 						},
@@ -1494,6 +1535,12 @@ func schema_pkg_apis_pingcap_v1alpha1_ComponentSpec(ref common.ReferenceCallback
 							Ref:         ref("k8s.io/api/core/v1.PodSecurityContext"),
 						},
 					},
+					"securityContext": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SecurityContext of the main container of the component, e.g. to set readOnlyRootFilesystem or drop capabilities. The privileged flag of TiKV and TiFlash defaults to the cluster-level setting if it is not set, and a writable emptyDir is mounted at /tmp if the root filesystem is read-only. It is not applied to the init containers that need the privileges, e.g. the sysctl init container.",
+							Ref:         ref("k8s.io/api/core/v1.SecurityContext"),
+						},
+					},
 					"configUpdateStrategy": {
 						SchemaProps: spec.SchemaProps{
 							Description: "ConfigUpdateStrategy of the component. Override the cluster-level updateStrategy if present Optional: Defaults to cluster-level setting",
@@ -1601,11 +1648,38 @@ func schema_pkg_apis_pingcap_v1alpha1_ComponentSpec(ref common.ReferenceCallback
 							},
 						},
 					},
+					"failoverTopologySpreadConstraints": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"topologyKey",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "FailoverTopologySpreadConstraints describes how the Pods provisioned by failover ought to spread across topology domains. The replacement Pods prefer the domains holding the fewest Pods of the component, so that they rebalance the component instead of clustering in one domain. The constraints of a replacement Pod are generated with WhenUnsatisfiable set to ScheduleAnyway, so that the failover is never blocked by the domain that failed. This field is only honored by PD, TiKV, TiFlash and TiDB, and requires the pod admission webhook. Optional: Defaults to the TopologySpreadConstraints of the component",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint"),
+									},
+								},
+							},
+						},
+					},
+					"ephemeralStorage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EphemeralStorage sets the ephemeral storage request and limit of the component container and the size limit of the emptyDir volumes created for it. It overrides the ephemeral-storage in requests and limits if present.",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.EphemeralStorageSpec"),
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Container", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount"},
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.EphemeralStorageSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Container", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.SecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount"},
 	}
 }
 
@@ -1635,6 +1709,33 @@ func schema_pkg_apis_pingcap_v1alpha1_ConfigMapRef(ref common.ReferenceCallback)
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_CrossComponentAntiAffinity(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "CrossComponentAntiAffinity configures the pod anti-affinity between two components",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"type": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Type of the anti-affinity, `preferred` or `required`. Optional: Defaults to preferred",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"topologyKey": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TopologyKey is the domain in which the Pods of the two components should not be colocated. Optional: Defaults to kubernetes.io/hostname",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_DMCluster(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -1925,11 +2026,17 @@ func schema_pkg_apis_pingcap_v1alpha1_DMClusterSpec(ref common.ReferenceCallback
 							},
 						},
 					},
+					"maintenanceWindow": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaintenanceWindow limits when the upgrades and the scales of the components may go on. Outside the window, the upgrade of the next Pod and the scales are queued and reported in the WaitingForMaintenanceWindow condition, the Pod being upgraded is not interrupted. Optional: Defaults to nil, which means the operations may start at any time",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MaintenanceWindow"),
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.DMDiscoverySpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MasterSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TLSCluster", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.WorkerSpec", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.Toleration"},
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.DMDiscoverySpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MaintenanceWindow", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MasterSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TLSCluster", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.WorkerSpec", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.Toleration"},
 	}
 }
 
@@ -2117,6 +2224,66 @@ func schema_pkg_apis_pingcap_v1alpha1_DumplingConfig(ref common.ReferenceCallbac
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_EphemeralStorageSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "EphemeralStorageSpec describes the ephemeral storage usage of a component",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"request": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Request is the ephemeral storage request of the component container",
+							Ref:         ref("k8s.io/apimachinery/pkg/api/resource.Quantity"),
+						},
+					},
+					"limit": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Limit is the ephemeral storage limit of the component container, the pod is evicted if its usage exceeds the limit",
+							Ref:         ref("k8s.io/apimachinery/pkg/api/resource.Quantity"),
+						},
+					},
+					"emptyDirSizeLimit": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EmptyDirSizeLimit is the size limit of the emptyDir volumes created by the operator for the component, e.g. the slow log volume of TiDB",
+							Ref:         ref("k8s.io/apimachinery/pkg/api/resource.Quantity"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/api/resource.Quantity"},
+	}
+}
+
+func schema_pkg_apis_pingcap_v1alpha1_EvictLeaderBackoff(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "EvictLeaderBackoff is the backoff of checking whether the leaders of a TiKV Pod are evicted. The wait before the next check is the time since the eviction began, bounded by the interval and the max interval, so it doubles at each check.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"interval": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Interval is the min wait before the next check, in the format of Go Duration. Defaults to 5s",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"maxInterval": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaxInterval is the max wait before the next check, in the format of Go Duration. Defaults to 1m",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_Experimental(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -2216,6 +2383,54 @@ func schema_pkg_apis_pingcap_v1alpha1_ExternalEndpoint(ref common.ReferenceCallb
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_FailoverSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "FailoverSpec tunes how aggressive the auto-failover of a component is",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"detectionPeriod": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DetectionPeriod is how long a member must be unhealthy before it is failed over, in the format of Go Duration. Optional: Defaults to the failover period of the component in the controller manager, e.g. `--tikv-failover-period`",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"maxFailoverCount": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaxFailoverCount limits the max replicas could be added in failover, 0 means no failover. It takes precedence over `maxFailoverCount` of the component. Optional: Defaults to `maxFailoverCount` of the component",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"recoverByDefault": {
+						SchemaProps: spec.SchemaProps{
+							Description: "RecoverByDefault removes the failure members from the status and scales in the replicas added in failover once all the desired members of the component are healthy again, e.g. after the node of a failure member returns. Optional: Defaults to true for PD and TiDB, and to `recoverFailover` for TiKV and TiFlash",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"snapshotBeforeDelete": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SnapshotBeforeDelete takes a VolumeSnapshot of the PVCs of a failure member before they are deleted, so that the old data directory can be inspected later. The VolumeSnapshotClass of `snapshotBeforeDeletingPVC` is used if it is set. It only takes effect for PD. Optional: Defaults to whether `snapshotBeforeDeletingPVC` is set",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"endpointCheck": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EndpointCheck fails over a member healthy by the health API as well if it is unreachable through the Service, i.e. its Pod is not Ready, e.g. by a readiness gate, or it is not ready in the Endpoints of the Service. It only takes effect for TiDB. Optional: Defaults to false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_FileLogConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -2761,6 +2976,41 @@ func schema_pkg_apis_pingcap_v1alpha1_GcsStorageProvider(ref common.ReferenceCal
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_HTTPScaleHook(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "HTTPScaleHook is the HTTP callback of a scale hook",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"url": {
+						SchemaProps: spec.SchemaProps{
+							Description: "URL is the URL the operator POSTs a ScaleHookReview to",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"caBundle": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CABundle is the PEM encoded CA bundle to verify the certificate of the hook, the system trust roots are used if it is not set",
+							Type:        []string{"string"},
+							Format:      "byte",
+						},
+					},
+					"timeoutSeconds": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TimeoutSeconds is the timeout of calling the hook. Defaults to 10",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+				Required: []string{"url"},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_HelperSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -2873,6 +3123,62 @@ func schema_pkg_apis_pingcap_v1alpha1_IsolationRead(ref common.ReferenceCallback
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_JobScaleHook(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "JobScaleHook is the Job of a scale hook. The scale is passed to the container by the env SCALE_NAMESPACE, SCALE_CLUSTER, SCALE_COMPONENT, SCALE_ACTION, SCALE_ORDINAL and SCALE_POD_NAME.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"image": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Image is the image of the container of the Job",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"command": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Command is the entrypoint of the container, the entrypoint of the image is used if it is not set",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"args": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Args are the arguments to the entrypoint",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"serviceAccountName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ServiceAccountName is the service account the Job runs as",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"image"},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_Log(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -3012,6 +3318,34 @@ func schema_pkg_apis_pingcap_v1alpha1_LogTailerSpec(ref common.ReferenceCallback
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_MaintenanceWindow(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "MaintenanceWindow is the recurring time window in which the disruptive operations may start",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"schedule": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Schedule is when the window opens, in the format of the standard 5-field Cron, e.g. \"0 2 * * 6\" for 02:00 every Saturday, in UTC",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"duration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Duration is how long the window stays open, in the format of Go Duration, e.g. \"4h\"",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"schedule", "duration"},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_MasterConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -3198,7 +3532,7 @@ func schema_pkg_apis_pingcap_v1alpha1_MasterSpec(ref common.ReferenceCallback) c
 					},
 					"schedulerName": {
 						SchemaProps: spec.SchemaProps{
-							Description: "SchedulerName of the component. Override the cluster-level one if present Optional: Defaults to cluster-level setting",
+							Description: "SchedulerName of the component. Override the cluster-level one if present HA scheduling is only enforced for Pods scheduled by tidb-scheduler Optional: Defaults to cluster-level setting",
 							Type:        []string{"string"},
 							Format:      "",
 						},
@@ -3267,6 +3601,12 @@ func schema_pkg_apis_pingcap_v1alpha1_MasterSpec(ref common.ReferenceCallback) c
 							Ref:         ref("k8s.io/api/core/v1.PodSecurityContext"),
 						},
 					},
+					"securityContext": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SecurityContext of the main container of the component, e.g. to set readOnlyRootFilesystem or drop capabilities. The privileged flag of TiKV and TiFlash defaults to the cluster-level setting if it is not set, and a writable emptyDir is mounted at /tmp if the root filesystem is read-only. It is not applied to the init containers that need the privileges, e.g. the sysctl init container.",
+							Ref:         ref("k8s.io/api/core/v1.SecurityContext"),
+						},
+					},
 					"configUpdateStrategy": {
 						SchemaProps: spec.SchemaProps{
 							Description: "ConfigUpdateStrategy of the component. Override the cluster-level updateStrategy if present Optional: Defaults to cluster-level setting",
@@ -3374,12 +3714,39 @@ func schema_pkg_apis_pingcap_v1alpha1_MasterSpec(ref common.ReferenceCallback) c
 							},
 						},
 					},
-					"limits": {
+					"failoverTopologySpreadConstraints": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"topologyKey",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
 						SchemaProps: spec.SchemaProps{
-							Description: "Limits describes the maximum amount of compute resources allowed. More info: https://kubernetes.io/docs/concepts/configuration/manage-compute-resources-container/",
-							Type:        []string{"object"},
-							AdditionalProperties: &spec.SchemaOrBool{
-								Allows: true,
+							Description: "FailoverTopologySpreadConstraints describes how the Pods provisioned by failover ought to spread across topology domains. The replacement Pods prefer the domains holding the fewest Pods of the component, so that they rebalance the component instead of clustering in one domain. The constraints of a replacement Pod are generated with WhenUnsatisfiable set to ScheduleAnyway, so that the failover is never blocked by the domain that failed. This field is only honored by PD, TiKV, TiFlash and TiDB, and requires the pod admission webhook. Optional: Defaults to the TopologySpreadConstraints of the component",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint"),
+									},
+								},
+							},
+						},
+					},
+					"ephemeralStorage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EphemeralStorage sets the ephemeral storage request and limit of the component container and the size limit of the emptyDir volumes created for it. It overrides the ephemeral-storage in requests and limits if present.",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.EphemeralStorageSpec"),
+						},
+					},
+					"limits": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Limits describes the maximum amount of compute resources allowed. More info: https://kubernetes.io/docs/concepts/configuration/manage-compute-resources-container/",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
 								Schema: &spec.Schema{
 									SchemaProps: spec.SchemaProps{
 										Ref: ref("k8s.io/apimachinery/pkg/api/resource.Quantity"),
@@ -3461,7 +3828,49 @@ func schema_pkg_apis_pingcap_v1alpha1_MasterSpec(ref common.ReferenceCallback) c
 			},
 		},
 		Dependencies: []string{
-			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MasterConfig", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MasterServiceSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Container", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount", "k8s.io/apimachinery/pkg/api/resource.Quantity"},
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.EphemeralStorageSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MasterConfig", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MasterServiceSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Container", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.SecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount", "k8s.io/apimachinery/pkg/api/resource.Quantity"},
+	}
+}
+
+func schema_pkg_apis_pingcap_v1alpha1_MetricStabilizationGate(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "MetricStabilizationGate is a PromQL query whose value must drop to the threshold before the next Pod is upgraded",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"prometheusURL": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PrometheusURL is the address of the Prometheus to query, e.g. http://prometheus:9090",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"query": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Query is the PromQL instant query of the key metric, e.g. the apply latency of TiKV. `$POD_NAME` and `$NAMESPACE` in the query are replaced with the name and namespace of the upgraded Pod. The value of the first sample of the result is compared with the threshold.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"threshold": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Threshold is the max value of the metric that is considered stable, e.g. \"0.05\"",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"timeout": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Timeout is how long to wait for the metric to stabilize after the upgraded Pod is ready, the upgrade proceeds after the timeout. Optional: Defaults to 10m",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"prometheusURL", "query", "threshold"},
+			},
+		},
 	}
 }
 
@@ -3644,6 +4053,68 @@ func schema_pkg_apis_pingcap_v1alpha1_OpenTracingSampler(ref common.ReferenceCal
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_PDBalanceLimits(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PDBalanceLimits are the limits of PD on the rebalancing speed, which are the `schedule` items of the PD config with the same names",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"maxPendingPeerCount": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaxPendingPeerCount is the max number of pending peers of a store, a store exceeding it is not used as the target of scheduling. It must be positive.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"maxSnapshotCount": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaxSnapshotCount is the max number of snapshots a store sends or receives at a time. It must be positive.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"leaderScheduleLimit": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LeaderScheduleLimit is the max number of concurrent leader schedules, 0 disables them",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"regionScheduleLimit": {
+						SchemaProps: spec.SchemaProps{
+							Description: "RegionScheduleLimit is the max number of concurrent Region schedules, 0 disables them",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"replicaScheduleLimit": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ReplicaScheduleLimit is the max number of concurrent replica schedules, 0 disables them",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"mergeScheduleLimit": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MergeScheduleLimit is the max number of concurrent merge schedules, 0 disables them",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"hotRegionScheduleLimit": {
+						SchemaProps: spec.SchemaProps{
+							Description: "HotRegionScheduleLimit is the max number of concurrent hot Region schedules, 0 disables them",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_PDConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -3832,6 +4303,56 @@ func schema_pkg_apis_pingcap_v1alpha1_PDConfig(ref common.ReferenceCallback) com
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_PDGroup(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PDGroup is a group of PD members run in the StatefulSet named <cluster>-pd-<name>, the members are configured as the ones of spec.pd except the fields of the group.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the group, which must be a DNS label",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"replicas": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Replicas is the number of the PD members of the group",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"nodeSelector": {
+						SchemaProps: spec.SchemaProps{
+							Description: "NodeSelector of the members of the group, merged into the nodeSelector of spec.pd",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"storageClassName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "StorageClassName of the data PVCs of the group Defaults to the storageClassName of spec.pd",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"name", "replicas"},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_PDLogConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -3987,6 +4508,33 @@ func schema_pkg_apis_pingcap_v1alpha1_PDNamespaceConfig(ref common.ReferenceCall
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_PDRegionSizeConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PDRegionSizeConfig is the size of the Regions, in the format like 96MiB. KB, MB, GB and TB are binary units like KiB as in TiKV, e.g. 96MB is 96MiB. The sizes must be within [1MiB, 10GiB].",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"regionMaxSize": {
+						SchemaProps: spec.SchemaProps{
+							Description: "RegionMaxSize is the max size of a Region, a larger Region is split. It must not be smaller than RegionSplitSize.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"regionSplitSize": {
+						SchemaProps: spec.SchemaProps{
+							Description: "RegionSplitSize is the size of the Regions split from a large Region",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_PDReplicationConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -4239,6 +4787,35 @@ func schema_pkg_apis_pingcap_v1alpha1_PDScheduleConfig(ref common.ReferenceCallb
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_PDScheduler(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PDScheduler is a scheduler of PD",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the scheduler, e.g. balance-hot-region-scheduler",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"config": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Config of the scheduler, only the items set here are updated",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/util/config.GenericConfig"),
+						},
+					},
+				},
+				Required: []string{"name"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/pingcap/tidb-operator/pkg/apis/util/config.GenericConfig"},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_PDSchedulerConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -4397,7 +4974,7 @@ func schema_pkg_apis_pingcap_v1alpha1_PDSpec(ref common.ReferenceCallback) commo
 					},
 					"schedulerName": {
 						SchemaProps: spec.SchemaProps{
-							Description: "SchedulerName of the component. Override the cluster-level one if present Optional: Defaults to cluster-level setting",
+							Description: "SchedulerName of the component. Override the cluster-level one if present HA scheduling is only enforced for Pods scheduled by tidb-scheduler Optional: Defaults to cluster-level setting",
 							Type:        []string{"string"},
 							Format:      "",
 						},
@@ -4466,6 +5043,12 @@ func schema_pkg_apis_pingcap_v1alpha1_PDSpec(ref common.ReferenceCallback) commo
 							Ref:         ref("k8s.io/api/core/v1.PodSecurityContext"),
 						},
 					},
+					"securityContext": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SecurityContext of the main container of the component, e.g. to set readOnlyRootFilesystem or drop capabilities. The privileged flag of TiKV and TiFlash defaults to the cluster-level setting if it is not set, and a writable emptyDir is mounted at /tmp if the root filesystem is read-only. It is not applied to the init containers that need the privileges, e.g. the sysctl init container.",
+							Ref:         ref("k8s.io/api/core/v1.SecurityContext"),
+						},
+					},
 					"configUpdateStrategy": {
 						SchemaProps: spec.SchemaProps{
 							Description: "ConfigUpdateStrategy of the component. Override the cluster-level updateStrategy if present Optional: Defaults to cluster-level setting",
@@ -4573,6 +5156,33 @@ func schema_pkg_apis_pingcap_v1alpha1_PDSpec(ref common.ReferenceCallback) commo
 							},
 						},
 					},
+					"failoverTopologySpreadConstraints": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"topologyKey",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "FailoverTopologySpreadConstraints describes how the Pods provisioned by failover ought to spread across topology domains. The replacement Pods prefer the domains holding the fewest Pods of the component, so that they rebalance the component instead of clustering in one domain. The constraints of a replacement Pod are generated with WhenUnsatisfiable set to ScheduleAnyway, so that the failover is never blocked by the domain that failed. This field is only honored by PD, TiKV, TiFlash and TiDB, and requires the pod admission webhook. Optional: Defaults to the TopologySpreadConstraints of the component",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint"),
+									},
+								},
+							},
+						},
+					},
+					"ephemeralStorage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EphemeralStorage sets the ephemeral storage request and limit of the component container and the size limit of the emptyDir volumes created for it. It overrides the ephemeral-storage in requests and limits if present.",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.EphemeralStorageSpec"),
+						},
+					},
 					"limits": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Limits describes the maximum amount of compute resources allowed. More info: https://kubernetes.io/docs/concepts/configuration/manage-compute-resources-container/",
@@ -4635,6 +5245,12 @@ func schema_pkg_apis_pingcap_v1alpha1_PDSpec(ref common.ReferenceCallback) commo
 							Format:      "int32",
 						},
 					},
+					"failover": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Failover tunes the auto-failover of the component",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.FailoverSpec"),
+						},
+					},
 					"storageClassName": {
 						SchemaProps: spec.SchemaProps{
 							Description: "The storageClassName of the persistent volume for PD data storage. Defaults to Kubernetes default storage class.",
@@ -4689,12 +5305,145 @@ func schema_pkg_apis_pingcap_v1alpha1_PDSpec(ref common.ReferenceCallback) commo
 							Format:      "",
 						},
 					},
+					"schedulers": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Schedulers are the desired schedulers of PD. The operator adds the missing schedulers, updates their config and removes the schedulers not listed, except the evict leader schedulers used in upgrading. Optional: Defaults to nil, which means the schedulers are not managed",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDScheduler"),
+									},
+								},
+							},
+						},
+					},
+					"regionSize": {
+						SchemaProps: spec.SchemaProps{
+							Description: "RegionSize is the size of the Regions PD schedules, which is pushed to the Up TiKV stores through their online config API and corrected once it drifts, e.g. after a change by tikv-ctl. Optional: Defaults to nil, which means the Region size is not managed",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDRegionSizeConfig"),
+						},
+					},
+					"balanceLimits": {
+						SchemaProps: spec.SchemaProps{
+							Description: "BalanceLimits are the limits of PD on the rebalancing speed, which are pushed to PD through its config API and corrected once they drift, e.g. after a change by pd-ctl. Only the limits set here are managed. Optional: Defaults to nil, which means the limits are not managed",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDBalanceLimits"),
+						},
+					},
+					"learnerTimeout": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LearnerTimeout is how long a PD member may stay a learner before it is considered stuck, in the format of Go Duration. Defaults to 10m",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"recreateStuckLearner": {
+						SchemaProps: spec.SchemaProps{
+							Description: "RecreateStuckLearner indicates whether to remove a stuck learner from PD and recreate its Pod and PVCs, so that it joins the cluster again. Optional: Defaults to false, which means the stuck learner is only reported",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"joinAsLearner": {
+						SchemaProps: spec.SchemaProps{
+							Description: "JoinAsLearner indicates whether the PD members added by scale-out join the etcd embedded in PD as learners first, which are promoted to voters once they catch up with the leader, so that a member slow to catch up does not disrupt the quorum of a busy cluster. Optional: Defaults to false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"lostPVCGracePeriod": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LostPVCGracePeriod is how long a PD member may stay without its data PVC or the PV bound to it before the member is considered to have lost its data, in the format of Go Duration. Defaults to 5m",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"recoverLostPVC": {
+						SchemaProps: spec.SchemaProps{
+							Description: "RecoverLostPVC indicates whether to remove a PD member that lost its data PVC from PD and recreate its Pod and PVC, so that it joins the cluster again as a fresh member. The member is removed only if the PD cluster keeps a quorum without it. Optional: Defaults to false, which means the lost PVC is only reported",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"failoverPVCPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "FailoverPVCPolicy decides what happens to the data PVC of a failure member when it is failed over. Recreate removes the member from PD and deletes its Pod and PVC, so that it joins the cluster again with an empty volume. Reuse keeps the member and its PVC and only recreates the Pod, so that it restarts with its intact data, e.g. on another node if the volume is not node-local. Optional: Defaults to Recreate",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"upgradeStabilizationGate": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradeStabilizationGate holds the rolling upgrade after each upgraded Pod until a key metric of the Pod returns to the baseline. Optional: Defaults to nil, which means only the readiness is waited for",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MetricStabilizationGate"),
+						},
+					},
+					"etcdDefragInterval": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EtcdDefragInterval is the interval to defragment the etcd embedded in each PD member to reclaim space, in the format of Go Duration. The members are defragmented one at a time and the PD leader is skipped. Optional: Defaults to nil, which means the etcd is not defragmented",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"scaleInLeaderTransferTimeout": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ScaleInLeaderTransferTimeout makes the scale-in of the PD leader wait for the leader transfer and delete the member in the same reconcile, for at most the duration, in the format of Go Duration. It must not exceed 1m as the reconcile of the cluster is blocked in the wait. Optional: Defaults to nil, which means the scale-in of the leader is requeued or deletes the member without waiting for the leader transfer",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"scaleHooks": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ScaleHooks are called before a member is removed and after a member is added or removed, so that external systems can react to the changes of the topology. Optional: Defaults to nil",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScaleHooks"),
+						},
+					},
+					"upgradeHooks": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradeHooks are the Jobs run before a Pod is upgraded and after it becomes healthy. Optional: Defaults to nil",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradeHooks"),
+						},
+					},
+					"groups": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Groups are the groups of PD members run in StatefulSets besides the one of spec.pd, so that the members can be placed with independent node selectors and storage classes. The members join and leave the PD cluster one at a time across all the StatefulSets, and are upgraded one at a time after the ones of spec.pd. The members of a group removed from the spec are scaled in one at a time, then its StatefulSet is deleted. Optional: Defaults to nil",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDGroup"),
+									},
+								},
+							},
+						},
+					},
+					"leaderTransferPriority": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LeaderTransferPriority is the ordered list of the Pod names or zones of the PD members the PD leader is transferred to when the leader is scaled in or upgraded. The first healthy member matching an item is chosen, the default choice is made if none matches. Optional: Defaults to nil",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"upgradePaused": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradePaused freezes the partition of the StatefulSet of PD, so that no more Pods are upgraded until it is unset. The Pod being upgraded is not interrupted. Optional: Defaults to false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
 				},
 				Required: []string{"replicas"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDConfigWraper", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ServiceSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.StorageVolume", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Container", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount", "k8s.io/apimachinery/pkg/api/resource.Quantity"},
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.EphemeralStorageSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.FailoverSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MetricStabilizationGate", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDBalanceLimits", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDConfigWraper", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDGroup", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDRegionSizeConfig", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDScheduler", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScaleHooks", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ServiceSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.StorageVolume", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradeHooks", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Container", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.SecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount", "k8s.io/apimachinery/pkg/api/resource.Quantity"},
 	}
 }
 
@@ -4723,6 +5472,53 @@ func schema_pkg_apis_pingcap_v1alpha1_PDStoreLabel(ref common.ReferenceCallback)
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_PVCResizeFailurePolicy(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PVCResizeFailurePolicy is when the expansion of the PVCs is considered failed",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"fileSystemResizeTimeout": {
+						SchemaProps: spec.SchemaProps{
+							Description: "FileSystemResizeTimeout is how long a PVC may wait for the file system resize, i.e. keep the FileSystemResizePending condition, before the expansion is considered failed, in the format of Go Duration. Defaults to 10m",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_pingcap_v1alpha1_PVCSnapshotSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PVCSnapshotSpec is the spec of the VolumeSnapshots taken before deleting PVCs",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"volumeSnapshotClassName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "VolumeSnapshotClassName is the VolumeSnapshotClass of the snapshots, the default VolumeSnapshotClass is used if it is not set",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"retention": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Retention is how long the snapshots are kept after they are taken, in the format of Go Duration, they are deleted by the operator afterwards. The snapshots are kept until they are deleted manually if it is not set",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_Performance(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -4878,6 +5674,42 @@ func schema_pkg_apis_pingcap_v1alpha1_PessimisticTxn(ref common.ReferenceCallbac
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_PlacementPolicy(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PlacementPolicy is a placement policy of TiDB",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name is the name of the placement policy",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"options": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Options are the placement options of the policy keyed by the option name, e.g. PRIMARY_REGION: us-east-1. The options of strings are quoted by the operator.",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"name", "options"},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_PlanCache(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -4934,6 +5766,82 @@ func schema_pkg_apis_pingcap_v1alpha1_Plugin(ref common.ReferenceCallback) commo
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_PodRestartPolicy(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PodRestartPolicy is how the Pods restarted too often are handled",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"threshold": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Threshold is the restart count of a container at which its Pod is considered flapping. Defaults to 5",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"window": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Window is how recent the last restart of the container must be for its Pod to be considered flapping, in the format of Go Duration. A Pod stops flapping once it is not restarted for the window. Defaults to 10m",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"pauseFailover": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PauseFailover pauses the failover of a component while some of its Pods are flapping, so the failover does not compound the churn.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_pingcap_v1alpha1_PodTemplateWebhook(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PodTemplateWebhook is the webhook to mutate the Pod templates of the components",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"url": {
+						SchemaProps: spec.SchemaProps{
+							Description: "URL is the URL the operator POSTs a PodTemplateReview to, the webhook responds with the PodTemplateReview carrying the mutated Pod template",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"caBundle": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CABundle is the PEM encoded CA bundle to verify the certificate of the webhook, the system trust roots are used if it is not set",
+							Type:        []string{"string"},
+							Format:      "byte",
+						},
+					},
+					"timeoutSeconds": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TimeoutSeconds is the timeout of calling the webhook. Defaults to 10",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"failurePolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "FailurePolicy decides what happens when the webhook can not be called. Fail fails the sync of the component until the webhook succeeds. Ignore records a Warning Event and uses the Pod template without the mutations, which rolls the Pods without them if the mutations were applied before. Optional: Defaults to Fail",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"url"},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_PreparedPlanCache(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -5188,7 +6096,7 @@ func schema_pkg_apis_pingcap_v1alpha1_PumpSpec(ref common.ReferenceCallback) com
 					},
 					"schedulerName": {
 						SchemaProps: spec.SchemaProps{
-							Description: "SchedulerName of the component. Override the cluster-level one if present Optional: Defaults to cluster-level setting",
+							Description: "SchedulerName of the component. Override the cluster-level one if present HA scheduling is only enforced for Pods scheduled by tidb-scheduler Optional: Defaults to cluster-level setting",
 							Type:        []string{"string"},
 							Format:      "",
 						},
@@ -5257,6 +6165,12 @@ func schema_pkg_apis_pingcap_v1alpha1_PumpSpec(ref common.ReferenceCallback) com
 							Ref:         ref("k8s.io/api/core/v1.PodSecurityContext"),
 						},
 					},
+					"securityContext": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SecurityContext of the main container of the component, e.g. to set readOnlyRootFilesystem or drop capabilities. The privileged flag of TiKV and TiFlash defaults to the cluster-level setting if it is not set, and a writable emptyDir is mounted at /tmp if the root filesystem is read-only. It is not applied to the init containers that need the privileges, e.g. the sysctl init container.",
+							Ref:         ref("k8s.io/api/core/v1.SecurityContext"),
+						},
+					},
 					"configUpdateStrategy": {
 						SchemaProps: spec.SchemaProps{
 							Description: "ConfigUpdateStrategy of the component. Override the cluster-level updateStrategy if present Optional: Defaults to cluster-level setting",
@@ -5343,7 +6257,28 @@ func schema_pkg_apis_pingcap_v1alpha1_PumpSpec(ref common.ReferenceCallback) com
 							Format:      "",
 						},
 					},
-					"topologySpreadConstraints": {
+					"topologySpreadConstraints": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"topologyKey",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "TopologySpreadConstraints describes how a group of pods ought to spread across topology domains. Scheduler will schedule pods in a way which abides by the constraints. This field is is only honored by clusters that enables the EvenPodsSpread feature. All topologySpreadConstraints are ANDed.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint"),
+									},
+								},
+							},
+						},
+					},
+					"failoverTopologySpreadConstraints": {
 						VendorExtensible: spec.VendorExtensible{
 							Extensions: spec.Extensions{
 								"x-kubernetes-list-map-keys": []interface{}{
@@ -5353,7 +6288,7 @@ func schema_pkg_apis_pingcap_v1alpha1_PumpSpec(ref common.ReferenceCallback) com
 							},
 						},
 						SchemaProps: spec.SchemaProps{
-							Description: "TopologySpreadConstraints describes how a group of pods ought to spread across topology domains. Scheduler will schedule pods in a way which abides by the constraints. This field is is only honored by clusters that enables the EvenPodsSpread feature. All topologySpreadConstraints are ANDed.",
+							Description: "FailoverTopologySpreadConstraints describes how the Pods provisioned by failover ought to spread across topology domains. The replacement Pods prefer the domains holding the fewest Pods of the component, so that they rebalance the component instead of clustering in one domain. The constraints of a replacement Pod are generated with WhenUnsatisfiable set to ScheduleAnyway, so that the failover is never blocked by the domain that failed. This field is only honored by PD, TiKV, TiFlash and TiDB, and requires the pod admission webhook. Optional: Defaults to the TopologySpreadConstraints of the component",
 							Type:        []string{"array"},
 							Items: &spec.SchemaOrArray{
 								Schema: &spec.Schema{
@@ -5364,6 +6299,12 @@ func schema_pkg_apis_pingcap_v1alpha1_PumpSpec(ref common.ReferenceCallback) com
 							},
 						},
 					},
+					"ephemeralStorage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EphemeralStorage sets the ephemeral storage request and limit of the component container and the size limit of the emptyDir volumes created for it. It overrides the ephemeral-storage in requests and limits if present.",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.EphemeralStorageSpec"),
+						},
+					},
 					"limits": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Limits describes the maximum amount of compute resources allowed. More info: https://kubernetes.io/docs/concepts/configuration/manage-compute-resources-container/",
@@ -5426,12 +6367,19 @@ func schema_pkg_apis_pingcap_v1alpha1_PumpSpec(ref common.ReferenceCallback) com
 							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/util/config.GenericConfig"),
 						},
 					},
+					"upgradePaused": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradePaused freezes the partition of the StatefulSet of Pump, so that no more Pods are upgraded until it is unset. The Pod being upgraded is not interrupted. Optional: Defaults to false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
 				},
 				Required: []string{"replicas"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "github.com/pingcap/tidb-operator/pkg/apis/util/config.GenericConfig", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Container", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount", "k8s.io/apimachinery/pkg/api/resource.Quantity"},
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.EphemeralStorageSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "github.com/pingcap/tidb-operator/pkg/apis/util/config.GenericConfig", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Container", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.SecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount", "k8s.io/apimachinery/pkg/api/resource.Quantity"},
 	}
 }
 
@@ -5890,6 +6838,26 @@ func schema_pkg_apis_pingcap_v1alpha1_RestoreSpec(ref common.ReferenceCallback)
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_RolloutStallPolicy(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "RolloutStallPolicy is how the stalled rollouts are detected",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"threshold": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Threshold is how long a Pod may stay Pending before the rollout is considered stalled, in the format of Go Duration. Defaults to 10m",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_S3StorageProvider(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -6035,6 +7003,109 @@ func schema_pkg_apis_pingcap_v1alpha1_SafeTLSConfig(ref common.ReferenceCallback
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_ScaleHook(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ScaleHook is an HTTP callback or a Job run for the scale of a member, exactly one of them must be set",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"http": {
+						SchemaProps: spec.SchemaProps{
+							Description: "HTTP is the HTTP callback the operator POSTs a ScaleHookReview to, the hook fails if it responds with an error status",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.HTTPScaleHook"),
+						},
+					},
+					"job": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Job is the Job run for the scale, the hook succeeds if the Job completes",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.JobScaleHook"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.HTTPScaleHook", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.JobScaleHook"},
+	}
+}
+
+func schema_pkg_apis_pingcap_v1alpha1_ScaleHooks(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ScaleHooks are the hooks called when the members of a component are scaled in or out, so that external systems, e.g. load balancers, CMDBs and capacity planners, can react to the changes of the topology",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"preScaleIn": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PreScaleIn is called before a member is removed from the cluster, and the scale-in waits until it succeeds. It is called once for each member.",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScaleHook"),
+						},
+					},
+					"postScale": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PostScale is called after a member is added to or removed from the cluster. Its failure is only reported by an Event. A Job hook is deleted once it finishes.",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScaleHook"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScaleHook"},
+	}
+}
+
+func schema_pkg_apis_pingcap_v1alpha1_ScalePolicy(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ScalePolicy is the policy of the scale of the components",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"maxScaleVelocity": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaxScaleVelocity limits how many members of PD, TiKV, TiDB, TiFlash, TiCDC and Pump may be added or removed in a time window, so that runaway automation or a flapping autoscaler can't change the cluster too fast. A scale beyond the limit waits until the window allows it, the scale of a member already begun is not blocked. Optional: Defaults to nil, which means the scale is not limited",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScaleVelocity"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScaleVelocity"},
+	}
+}
+
+func schema_pkg_apis_pingcap_v1alpha1_ScaleVelocity(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ScaleVelocity is the max number of members changed in a time window",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"members": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Members is the max number of members added or removed in the window, the members of all the components are counted together",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"window": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Window is the time window, in the format of Go Duration. Defaults to 1h",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"members"},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_SecretRef(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -6296,6 +7367,34 @@ func schema_pkg_apis_pingcap_v1alpha1_StmtSummary(ref common.ReferenceCallback)
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_StorageCheckSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "StorageCheckSpec is the check of the write throughput of a data volume",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"minWriteThroughputMB": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MinWriteThroughputMB is the floor of the sequential write throughput in MB/s",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"sizeMB": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SizeMB is the size of the data written to measure the throughput Optional: Defaults to 64",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+				Required: []string{"minWriteThroughputMB"},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_StorageClaim(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -6519,7 +7618,7 @@ func schema_pkg_apis_pingcap_v1alpha1_TiCDCSpec(ref common.ReferenceCallback) co
 					},
 					"schedulerName": {
 						SchemaProps: spec.SchemaProps{
-							Description: "SchedulerName of the component. Override the cluster-level one if present Optional: Defaults to cluster-level setting",
+							Description: "SchedulerName of the component. Override the cluster-level one if present HA scheduling is only enforced for Pods scheduled by tidb-scheduler Optional: Defaults to cluster-level setting",
 							Type:        []string{"string"},
 							Format:      "",
 						},
@@ -6588,6 +7687,12 @@ func schema_pkg_apis_pingcap_v1alpha1_TiCDCSpec(ref common.ReferenceCallback) co
 							Ref:         ref("k8s.io/api/core/v1.PodSecurityContext"),
 						},
 					},
+					"securityContext": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SecurityContext of the main container of the component, e.g. to set readOnlyRootFilesystem or drop capabilities. The privileged flag of TiKV and TiFlash defaults to the cluster-level setting if it is not set, and a writable emptyDir is mounted at /tmp if the root filesystem is read-only. It is not applied to the init containers that need the privileges, e.g. the sysctl init container.",
+							Ref:         ref("k8s.io/api/core/v1.SecurityContext"),
+						},
+					},
 					"configUpdateStrategy": {
 						SchemaProps: spec.SchemaProps{
 							Description: "ConfigUpdateStrategy of the component. Override the cluster-level updateStrategy if present Optional: Defaults to cluster-level setting",
@@ -6695,6 +7800,33 @@ func schema_pkg_apis_pingcap_v1alpha1_TiCDCSpec(ref common.ReferenceCallback) co
 							},
 						},
 					},
+					"failoverTopologySpreadConstraints": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"topologyKey",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "FailoverTopologySpreadConstraints describes how the Pods provisioned by failover ought to spread across topology domains. The replacement Pods prefer the domains holding the fewest Pods of the component, so that they rebalance the component instead of clustering in one domain. The constraints of a replacement Pod are generated with WhenUnsatisfiable set to ScheduleAnyway, so that the failover is never blocked by the domain that failed. This field is only honored by PD, TiKV, TiFlash and TiDB, and requires the pod admission webhook. Optional: Defaults to the TopologySpreadConstraints of the component",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint"),
+									},
+								},
+							},
+						},
+					},
+					"ephemeralStorage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EphemeralStorage sets the ephemeral storage request and limit of the component container and the size limit of the emptyDir volumes created for it. It overrides the ephemeral-storage in requests and limits if present.",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.EphemeralStorageSpec"),
+						},
+					},
 					"limits": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Limits describes the maximum amount of compute resources allowed. More info: https://kubernetes.io/docs/concepts/configuration/manage-compute-resources-container/",
@@ -6784,12 +7916,26 @@ func schema_pkg_apis_pingcap_v1alpha1_TiCDCSpec(ref common.ReferenceCallback) co
 							Format:      "",
 						},
 					},
+					"upgradePaused": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradePaused freezes the partition of the StatefulSet of TiCDC, so that no more Pods are upgraded until it is unset. The Pod being upgraded is not interrupted. Optional: Defaults to false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"gracefulShutdownTimeout": {
+						SchemaProps: spec.SchemaProps{
+							Description: "GracefulShutdownTimeout makes the upgrade of a TiCDC Pod wait for the capture to resign the owner and to move its tables to the other captures before the Pod is restarted, for at most the duration, in the format of Go Duration. \"0s\" restarts the Pod without waiting. Optional: Defaults to 10m",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 				},
 				Required: []string{"replicas"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.CDCConfigWraper", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.StorageVolume", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Container", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount", "k8s.io/apimachinery/pkg/api/resource.Quantity"},
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.CDCConfigWraper", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.EphemeralStorageSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.StorageVolume", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Container", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.SecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount", "k8s.io/apimachinery/pkg/api/resource.Quantity"},
 	}
 }
 
@@ -6842,6 +7988,75 @@ func schema_pkg_apis_pingcap_v1alpha1_TiDBAccessConfig(ref common.ReferenceCallb
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_TiDBAuditLogSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "TiDBAuditLogSpec is the audit log of TiDB",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"plugin": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Plugin is the audit plugin loaded by TiDB, in the format of name-version. The plugin must be present in the plugin directory of the TiDB image. Defaults to audit-1",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"file": {
+						SchemaProps: spec.SchemaProps{
+							Description: "File is the name of the audit log file in the audit log volume, which is mounted to TiDB and the sidecar at /var/log/tidb-audit. Defaults to tidb-audit.log",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"sidecar": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Sidecar ships the audit log, e.g. a fluent-bit container. The audit log volume is mounted to it and the path of the audit log is set to its AUDIT_LOG_FILE environment variable. Optional: Defaults to nil, which means the audit log is only kept in the Pod",
+							Ref:         ref("k8s.io/api/core/v1.Container"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/api/core/v1.Container"},
+	}
+}
+
+func schema_pkg_apis_pingcap_v1alpha1_TiDBCanaryPolicy(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "TiDBCanaryPolicy is the verification of the canary Pod of a TiDB upgrade",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"pauseDuration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PauseDuration is how long the rolling upgrade is paused after the canary Pod is ready before the Pod is verified, in the format of Go Duration. Defaults to 1m",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"sqlProbe": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SQLProbe is the SQL statement run on the canary Pod with the user in sqlSecretName, the verification fails if the statement fails.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"httpProbePath": {
+						SchemaProps: spec.SchemaProps{
+							Description: "HTTPProbePath is the path on the status port of the canary Pod the operator GETs, e.g. /status, the verification fails if the response status is 4xx or 5xx.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_TiDBConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -7328,7 +8543,7 @@ func schema_pkg_apis_pingcap_v1alpha1_TiDBSpec(ref common.ReferenceCallback) com
 					},
 					"schedulerName": {
 						SchemaProps: spec.SchemaProps{
-							Description: "SchedulerName of the component. Override the cluster-level one if present Optional: Defaults to cluster-level setting",
+							Description: "SchedulerName of the component. Override the cluster-level one if present HA scheduling is only enforced for Pods scheduled by tidb-scheduler Optional: Defaults to cluster-level setting",
 							Type:        []string{"string"},
 							Format:      "",
 						},
@@ -7397,6 +8612,12 @@ func schema_pkg_apis_pingcap_v1alpha1_TiDBSpec(ref common.ReferenceCallback) com
 							Ref:         ref("k8s.io/api/core/v1.PodSecurityContext"),
 						},
 					},
+					"securityContext": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SecurityContext of the main container of the component, e.g. to set readOnlyRootFilesystem or drop capabilities. The privileged flag of TiKV and TiFlash defaults to the cluster-level setting if it is not set, and a writable emptyDir is mounted at /tmp if the root filesystem is read-only. It is not applied to the init containers that need the privileges, e.g. the sysctl init container.",
+							Ref:         ref("k8s.io/api/core/v1.SecurityContext"),
+						},
+					},
 					"configUpdateStrategy": {
 						SchemaProps: spec.SchemaProps{
 							Description: "ConfigUpdateStrategy of the component. Override the cluster-level updateStrategy if present Optional: Defaults to cluster-level setting",
@@ -7469,21 +8690,42 @@ func schema_pkg_apis_pingcap_v1alpha1_TiDBSpec(ref common.ReferenceCallback) com
 							},
 						},
 					},
-					"terminationGracePeriodSeconds": {
-						SchemaProps: spec.SchemaProps{
-							Description: "Optional duration in seconds the pod needs to terminate gracefully. May be decreased in delete request. Value must be non-negative integer. The value zero indicates delete immediately. If this value is nil, the default grace period will be used instead. The grace period is the duration in seconds after the processes running in the pod are sent a termination signal and the time when the processes are forcibly halted with a kill signal. Set this value longer than the expected cleanup time for your process. Defaults to 30 seconds.",
-							Type:        []string{"integer"},
-							Format:      "int64",
-						},
-					},
-					"statefulSetUpdateStrategy": {
-						SchemaProps: spec.SchemaProps{
-							Description: "StatefulSetUpdateStrategy indicates the StatefulSetUpdateStrategy that will be employed to update Pods in the StatefulSet when a revision is made to Template.",
-							Type:        []string{"string"},
-							Format:      "",
-						},
-					},
-					"topologySpreadConstraints": {
+					"terminationGracePeriodSeconds": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Optional duration in seconds the pod needs to terminate gracefully. May be decreased in delete request. Value must be non-negative integer. The value zero indicates delete immediately. If this value is nil, the default grace period will be used instead. The grace period is the duration in seconds after the processes running in the pod are sent a termination signal and the time when the processes are forcibly halted with a kill signal. Set this value longer than the expected cleanup time for your process. Defaults to 30 seconds.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"statefulSetUpdateStrategy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "StatefulSetUpdateStrategy indicates the StatefulSetUpdateStrategy that will be employed to update Pods in the StatefulSet when a revision is made to Template.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"topologySpreadConstraints": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"topologyKey",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "TopologySpreadConstraints describes how a group of pods ought to spread across topology domains. Scheduler will schedule pods in a way which abides by the constraints. This field is is only honored by clusters that enables the EvenPodsSpread feature. All topologySpreadConstraints are ANDed.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint"),
+									},
+								},
+							},
+						},
+					},
+					"failoverTopologySpreadConstraints": {
 						VendorExtensible: spec.VendorExtensible{
 							Extensions: spec.Extensions{
 								"x-kubernetes-list-map-keys": []interface{}{
@@ -7493,7 +8735,7 @@ func schema_pkg_apis_pingcap_v1alpha1_TiDBSpec(ref common.ReferenceCallback) com
 							},
 						},
 						SchemaProps: spec.SchemaProps{
-							Description: "TopologySpreadConstraints describes how a group of pods ought to spread across topology domains. Scheduler will schedule pods in a way which abides by the constraints. This field is is only honored by clusters that enables the EvenPodsSpread feature. All topologySpreadConstraints are ANDed.",
+							Description: "FailoverTopologySpreadConstraints describes how the Pods provisioned by failover ought to spread across topology domains. The replacement Pods prefer the domains holding the fewest Pods of the component, so that they rebalance the component instead of clustering in one domain. The constraints of a replacement Pod are generated with WhenUnsatisfiable set to ScheduleAnyway, so that the failover is never blocked by the domain that failed. This field is only honored by PD, TiKV, TiFlash and TiDB, and requires the pod admission webhook. Optional: Defaults to the TopologySpreadConstraints of the component",
 							Type:        []string{"array"},
 							Items: &spec.SchemaOrArray{
 								Schema: &spec.Schema{
@@ -7504,6 +8746,12 @@ func schema_pkg_apis_pingcap_v1alpha1_TiDBSpec(ref common.ReferenceCallback) com
 							},
 						},
 					},
+					"ephemeralStorage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EphemeralStorage sets the ephemeral storage request and limit of the component container and the size limit of the emptyDir volumes created for it. It overrides the ephemeral-storage in requests and limits if present.",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.EphemeralStorageSpec"),
+						},
+					},
 					"limits": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Limits describes the maximum amount of compute resources allowed. More info: https://kubernetes.io/docs/concepts/configuration/manage-compute-resources-container/",
@@ -7573,6 +8821,12 @@ func schema_pkg_apis_pingcap_v1alpha1_TiDBSpec(ref common.ReferenceCallback) com
 							Format:      "int32",
 						},
 					},
+					"failover": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Failover tunes the auto-failover of the component",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.FailoverSpec"),
+						},
+					},
 					"separateSlowLog": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Whether output the slow log in an separate sidecar container Optional: Defaults to true",
@@ -7619,6 +8873,20 @@ func schema_pkg_apis_pingcap_v1alpha1_TiDBSpec(ref common.ReferenceCallback) com
 							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBConfigWraper"),
 						},
 					},
+					"perOrdinalConfig": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PerOrdinalConfig overrides the Configuration of the tidb-servers of some ordinals, keyed by the ordinal, e.g. to try out a change on one TiDB before rolling it out to all. The override is merged into `config`, which must be set, and the other tidb-servers keep running with `config`. Only the Pods of the ordinals whose override changes are restarted, except that setting the field the first time, even to an empty map, or unsetting it rolls all the Pods once to mount the overrides.",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBConfigWraper"),
+									},
+								},
+							},
+						},
+					},
 					"lifecycle": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Lifecycle describes actions that the management system should take in response to container lifecycle events. For the PostStart and PreStop lifecycle handlers, management of the container blocks until the action is complete, unless the container process fails, in which case the handler is aborted.",
@@ -7651,12 +8919,140 @@ func schema_pkg_apis_pingcap_v1alpha1_TiDBSpec(ref common.ReferenceCallback) com
 							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBProbe"),
 						},
 					},
+					"upgradeStabilizationGate": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradeStabilizationGate holds the rolling upgrade after each upgraded Pod until a key metric of the Pod returns to the baseline. Optional: Defaults to nil, which means only the readiness is waited for",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MetricStabilizationGate"),
+						},
+					},
+					"upgradeConcurrency": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradeConcurrency is the max number of TiDB Pods restarted at the same time in a rolling upgrade. At least one healthy Pod is kept serving, and the Pods are evicted so the PodDisruptionBudgets are respected. Defaults to 1",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"blueGreenSwitch": {
+						SchemaProps: spec.SchemaProps{
+							Description: "BlueGreenSwitch rolls out changes of the TiDB Pod template by bringing up a green TiDB StatefulSet with the new template alongside the current (blue) one and switching the TiDB Service to it once all its Pods are ready, instead of upgrading the Pods one by one. The blue StatefulSet is then torn down and recreated with the new template, and the Service is switched back to it before the green StatefulSet is deleted. Optional: Defaults to false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"placementPolicies": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PlacementPolicies are the placement policies the operator creates in TiDB over SQL once all the TiDB members are ready, and alters back if they drift from the spec. The placement policies not listed are not touched.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PlacementPolicy"),
+									},
+								},
+							},
+						},
+					},
+					"configProfile": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ConfigProfile is the name of the TidbClusterConfigProfile in the same namespace whose system variables the operator sets in TiDB over SQL once all the TiDB members are ready.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"systemVariables": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SystemVariables are the TiDB global system variables the operator sets over SQL, keyed by the variable name. They take precedence over the ones of the ConfigProfile, except for its locked system variables.",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"auditLog": {
+						SchemaProps: spec.SchemaProps{
+							Description: "AuditLog loads the audit plugin of TiDB, which writes the audit log to a volume shared with an optional log shipping sidecar. The audit log is enabled by the operator over SQL once all the TiDB members are ready. Requires TiDB v4.0.0 or later. Optional: Defaults to nil, which means the audit log is not managed",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBAuditLogSpec"),
+						},
+					},
+					"sqlSecretName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SQLSecretName is the name of the Secret holding the `user` and `password` the operator connects to TiDB with to manage the placement policies, the system variables and the TiFlash replicas of the tables. Optional: Defaults to \"\", which means none of them is synced",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"incompatibleDataPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "IncompatibleDataPolicy decides what happens when a TiDB Pod fails to start as the data is bootstrapped by an incompatible version, e.g. after a downgrade. The failure is always reported in the TiDBDataIncompatible condition and an Event. Rollback also aborts the ongoing upgrade of TiDB and rolls the failed Pod back to the previous revision. Optional: Defaults to Report",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"scaleInDrainTimeout": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ScaleInDrainTimeout makes the scale-in of a TiDB Pod wait for the client connections to the Pod to be closed before the StatefulSet is scaled in, for at most the duration, in the format of Go Duration. TiDB keeps accepting connections in the wait, the PreScaleIn hook can be used to remove the Pod from the load balancer before it. The connections of the user in sqlSecretName, which the operator keeps itself, are not counted. Optional: Defaults to nil, which means the scale-in does not wait",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"scaleHooks": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ScaleHooks are called before a member is removed and after a member is added or removed, so that external systems can react to the changes of the topology. Optional: Defaults to nil",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScaleHooks"),
+						},
+					},
+					"upgradeHooks": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradeHooks are the Jobs run before a Pod is upgraded and after it becomes healthy. Optional: Defaults to nil",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradeHooks"),
+						},
+					},
+					"upgradePolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradePolicy is the policy of the rolling upgrade of TiDB, e.g. a canary Pod verified before the other Pods are upgraded. Optional: Defaults to nil",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBUpgradePolicy"),
+						},
+					},
+					"upgradePaused": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradePaused freezes the partition of the StatefulSet of TiDB, so that no more Pods are upgraded until it is unset. The Pod being upgraded is not interrupted. Optional: Defaults to false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
 				},
 				Required: []string{"replicas"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.StorageVolume", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBConfigWraper", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBProbe", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBServiceSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBSlowLogTailerSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBTLSClient", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Container", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.Lifecycle", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount", "k8s.io/apimachinery/pkg/api/resource.Quantity"},
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.EphemeralStorageSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.FailoverSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MetricStabilizationGate", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PlacementPolicy", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScaleHooks", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.StorageVolume", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBAuditLogSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBConfigWraper", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBProbe", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBServiceSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBSlowLogTailerSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBTLSClient", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBUpgradePolicy", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradeHooks", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Container", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.Lifecycle", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.SecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount", "k8s.io/apimachinery/pkg/api/resource.Quantity"},
+	}
+}
+
+func schema_pkg_apis_pingcap_v1alpha1_TiDBUpgradePolicy(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "TiDBUpgradePolicy is the policy of the rolling upgrade of TiDB",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"canary": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Canary upgrades a single TiDB Pod first and pauses the rolling upgrade until the Pod passes the verification. The upgrade stays paused while the verification fails, so that the change can be reverted, and the failure is reported in the TiDBCanaryFailed condition. Optional: Defaults to nil, which means there is no canary",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBCanaryPolicy"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBCanaryPolicy"},
 	}
 }
 
@@ -7743,7 +9139,7 @@ func schema_pkg_apis_pingcap_v1alpha1_TiFlashSpec(ref common.ReferenceCallback)
 					},
 					"schedulerName": {
 						SchemaProps: spec.SchemaProps{
-							Description: "SchedulerName of the component. Override the cluster-level one if present Optional: Defaults to cluster-level setting",
+							Description: "SchedulerName of the component. Override the cluster-level one if present HA scheduling is only enforced for Pods scheduled by tidb-scheduler Optional: Defaults to cluster-level setting",
 							Type:        []string{"string"},
 							Format:      "",
 						},
@@ -7812,6 +9208,12 @@ func schema_pkg_apis_pingcap_v1alpha1_TiFlashSpec(ref common.ReferenceCallback)
 							Ref:         ref("k8s.io/api/core/v1.PodSecurityContext"),
 						},
 					},
+					"securityContext": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SecurityContext of the main container of the component, e.g. to set readOnlyRootFilesystem or drop capabilities. The privileged flag of TiKV and TiFlash defaults to the cluster-level setting if it is not set, and a writable emptyDir is mounted at /tmp if the root filesystem is read-only. It is not applied to the init containers that need the privileges, e.g. the sysctl init container.",
+							Ref:         ref("k8s.io/api/core/v1.SecurityContext"),
+						},
+					},
 					"configUpdateStrategy": {
 						SchemaProps: spec.SchemaProps{
 							Description: "ConfigUpdateStrategy of the component. Override the cluster-level updateStrategy if present Optional: Defaults to cluster-level setting",
@@ -7919,6 +9321,33 @@ func schema_pkg_apis_pingcap_v1alpha1_TiFlashSpec(ref common.ReferenceCallback)
 							},
 						},
 					},
+					"failoverTopologySpreadConstraints": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"topologyKey",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "FailoverTopologySpreadConstraints describes how the Pods provisioned by failover ought to spread across topology domains. The replacement Pods prefer the domains holding the fewest Pods of the component, so that they rebalance the component instead of clustering in one domain. The constraints of a replacement Pod are generated with WhenUnsatisfiable set to ScheduleAnyway, so that the failover is never blocked by the domain that failed. This field is only honored by PD, TiKV, TiFlash and TiDB, and requires the pod admission webhook. Optional: Defaults to the TopologySpreadConstraints of the component",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint"),
+									},
+								},
+							},
+						},
+					},
+					"ephemeralStorage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EphemeralStorage sets the ephemeral storage request and limit of the component container and the size limit of the emptyDir volumes created for it. It overrides the ephemeral-storage in requests and limits if present.",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.EphemeralStorageSpec"),
+						},
+					},
 					"limits": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Limits describes the maximum amount of compute resources allowed. More info: https://kubernetes.io/docs/concepts/configuration/manage-compute-resources-container/",
@@ -7982,6 +9411,12 @@ func schema_pkg_apis_pingcap_v1alpha1_TiFlashSpec(ref common.ReferenceCallback)
 							Format:      "int32",
 						},
 					},
+					"failover": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Failover tunes the auto-failover of the component",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.FailoverSpec"),
+						},
+					},
 					"storageClaims": {
 						SchemaProps: spec.SchemaProps{
 							Description: "The persistent volume claims of the TiFlash data storages. TiFlash supports multiple disks.",
@@ -8014,12 +9449,126 @@ func schema_pkg_apis_pingcap_v1alpha1_TiFlashSpec(ref common.ReferenceCallback)
 							Format:      "",
 						},
 					},
+					"failoverPVCPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "FailoverPVCPolicy decides what happens to the data PVC of a failure store when it is failed over, a failover Pod is added in both cases. Reuse keeps the store and the PVC, so that the store restarts with its intact data once it recovers. Recreate deletes the store from PD and, once it becomes tombstone, deletes its Pod and PVC, so that the Pod joins the cluster again as a new store with an empty volume. Optional: Defaults to Reuse",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"tableReplicas": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TableReplicas are the TiFlash replicas of the tables the operator sets over SQL once all the TiDB members are ready, and sets back if they drift from the spec. The tables not listed are not touched, the tables that do not exist yet are set once they are created.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiFlashTableReplica"),
+									},
+								},
+							},
+						},
+					},
+					"scaleHooks": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ScaleHooks are called before a member is removed and after a member is added or removed, so that external systems can react to the changes of the topology. Optional: Defaults to nil",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScaleHooks"),
+						},
+					},
+					"upgradeHooks": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradeHooks are the Jobs run before a Pod is upgraded and after it becomes healthy. Optional: Defaults to nil",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradeHooks"),
+						},
+					},
+					"upgradePaused": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradePaused freezes the partition of the StatefulSet of TiFlash, so that no more Pods are upgraded until it is unset. The Pod being upgraded is not interrupted. Optional: Defaults to false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"upgradeCheck": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradeCheck are the thresholds the upgraded TiFlash store must meet before the next Pod is upgraded, so that the store catches up with the Raft logs it missed while restarting. Optional: Defaults to nil, in which case only the store state is checked",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiFlashUpgradeCheck"),
+						},
+					},
 				},
 				Required: []string{"replicas", "storageClaims"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.LogTailerSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.StorageClaim", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiFlashConfigWraper", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Container", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount", "k8s.io/apimachinery/pkg/api/resource.Quantity"},
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.EphemeralStorageSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.FailoverSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.LogTailerSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScaleHooks", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.StorageClaim", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiFlashConfigWraper", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiFlashTableReplica", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiFlashUpgradeCheck", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradeHooks", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Container", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.SecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount", "k8s.io/apimachinery/pkg/api/resource.Quantity"},
+	}
+}
+
+func schema_pkg_apis_pingcap_v1alpha1_TiFlashTableReplica(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "TiFlashTableReplica is the number of TiFlash replicas of a table",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"database": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Database is the database of the table",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"table": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Table is the name of the table",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"replicas": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Replicas is the number of TiFlash replicas of the table, 0 removes the replicas",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+				Required: []string{"database", "table", "replicas"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_pingcap_v1alpha1_TiFlashUpgradeCheck(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "TiFlashUpgradeCheck are the thresholds of the checks of an upgraded TiFlash store before the next TiFlash Pod is upgraded",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"maxPendingPeerRegions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaxPendingPeerRegions is the max number of Regions with pending peers on the upgraded store, i.e. the peers that have not applied the Raft logs of their leaders Defaults to 0",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"maxApplyingSnapshots": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaxApplyingSnapshots is the max number of snapshots the upgraded store is receiving or applying Defaults to 0",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"timeout": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Timeout is how long the upgraded store may fail the checks after its Pod is ready, the next Pod is upgraded anyway after it, in the format of Go Duration. Defaults to 30m",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
 	}
 }
 
@@ -9170,6 +10719,33 @@ func schema_pkg_apis_pingcap_v1alpha1_TiKVPessimisticTxn(ref common.ReferenceCal
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_TiKVPorts(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "TiKVPorts is the ports TiKV listens on",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"server": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Server is the port of the gRPC service of TiKV Optional: Defaults to 20160",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Status is the port of the status service of TiKV Optional: Defaults to 20180",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_TiKVRaftDBConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -9732,6 +11308,33 @@ func schema_pkg_apis_pingcap_v1alpha1_TiKVReadPoolConfig(ref common.ReferenceCal
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_TiKVScalePolicy(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "TiKVScalePolicy is the policy of the scale of TiKV",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"evictLeaderBeforeScaleIn": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EvictLeaderBeforeScaleIn evicts the Region leaders from the store of a TiKV Pod before the store is deleted in the scale-in, so that the clients are not affected by the leaders removed with the store. The store is deleted once it has no leaders or `.spec.tikv.evictLeaderTimeout` passes. Optional: Defaults to false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"balanceZones": {
+						SchemaProps: spec.SchemaProps{
+							Description: "BalanceZones keeps the TiKV stores balanced across the zones. The Pods are spread across the zones by a topology spread constraint on the zone label of the nodes if there is none in `.spec.tikv.topologySpreadConstraints`. A scale-in is refused if the store removed is not in a zone with the most stores, or it is the last store of its zone, unless the TidbCluster is annotated with `tidb.pingcap.com/force-unbalanced-scale-in: \"true\"`. The zone of a store is the zone label of its node, or the `zone` label of the store in PD if the node has none. Setting or unsetting it changes the topology spread constraints of the TiKV Pods if `.spec.tikv.topologySpreadConstraints` has none on the zone label, so all the TiKV Pods are rolling updated. Optional: Defaults to false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_TiKVSecurityConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -10035,7 +11638,7 @@ func schema_pkg_apis_pingcap_v1alpha1_TiKVSpec(ref common.ReferenceCallback) com
 					},
 					"schedulerName": {
 						SchemaProps: spec.SchemaProps{
-							Description: "SchedulerName of the component. Override the cluster-level one if present Optional: Defaults to cluster-level setting",
+							Description: "SchedulerName of the component. Override the cluster-level one if present HA scheduling is only enforced for Pods scheduled by tidb-scheduler Optional: Defaults to cluster-level setting",
 							Type:        []string{"string"},
 							Format:      "",
 						},
@@ -10104,6 +11707,12 @@ func schema_pkg_apis_pingcap_v1alpha1_TiKVSpec(ref common.ReferenceCallback) com
 							Ref:         ref("k8s.io/api/core/v1.PodSecurityContext"),
 						},
 					},
+					"securityContext": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SecurityContext of the main container of the component, e.g. to set readOnlyRootFilesystem or drop capabilities. The privileged flag of TiKV and TiFlash defaults to the cluster-level setting if it is not set, and a writable emptyDir is mounted at /tmp if the root filesystem is read-only. It is not applied to the init containers that need the privileges, e.g. the sysctl init container.",
+							Ref:         ref("k8s.io/api/core/v1.SecurityContext"),
+						},
+					},
 					"configUpdateStrategy": {
 						SchemaProps: spec.SchemaProps{
 							Description: "ConfigUpdateStrategy of the component. Override the cluster-level updateStrategy if present Optional: Defaults to cluster-level setting",
@@ -10211,6 +11820,33 @@ func schema_pkg_apis_pingcap_v1alpha1_TiKVSpec(ref common.ReferenceCallback) com
 							},
 						},
 					},
+					"failoverTopologySpreadConstraints": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"topologyKey",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "FailoverTopologySpreadConstraints describes how the Pods provisioned by failover ought to spread across topology domains. The replacement Pods prefer the domains holding the fewest Pods of the component, so that they rebalance the component instead of clustering in one domain. The constraints of a replacement Pod are generated with WhenUnsatisfiable set to ScheduleAnyway, so that the failover is never blocked by the domain that failed. This field is only honored by PD, TiKV, TiFlash and TiDB, and requires the pod admission webhook. Optional: Defaults to the TopologySpreadConstraints of the component",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint"),
+									},
+								},
+							},
+						},
+					},
+					"ephemeralStorage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EphemeralStorage sets the ephemeral storage request and limit of the component container and the size limit of the emptyDir volumes created for it. It overrides the ephemeral-storage in requests and limits if present.",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.EphemeralStorageSpec"),
+						},
+					},
 					"limits": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Limits describes the maximum amount of compute resources allowed. More info: https://kubernetes.io/docs/concepts/configuration/manage-compute-resources-container/",
@@ -10274,6 +11910,12 @@ func schema_pkg_apis_pingcap_v1alpha1_TiKVSpec(ref common.ReferenceCallback) com
 							Format:      "int32",
 						},
 					},
+					"failover": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Failover tunes the auto-failover of the component",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.FailoverSpec"),
+						},
+					},
 					"separateRocksDBLog": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Whether output the RocksDB log in a separate sidecar container Optional: Defaults to false",
@@ -10314,6 +11956,20 @@ func schema_pkg_apis_pingcap_v1alpha1_TiKVSpec(ref common.ReferenceCallback) com
 							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVConfigWraper"),
 						},
 					},
+					"perOrdinalConfig": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PerOrdinalConfig overrides the Configuration of the tikv-servers of some ordinals, keyed by the ordinal, e.g. to try out a change on one TiKV before rolling it out to all. The override is merged into `config`, which must be set, and the other tikv-servers keep running with `config`. Only the Pods of the ordinals whose override changes are restarted, except that setting the field the first time, even to an empty map, or unsetting it rolls all the Pods once to mount the overrides.",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVConfigWraper"),
+									},
+								},
+							},
+						},
+					},
 					"recoverFailover": {
 						SchemaProps: spec.SchemaProps{
 							Description: "RecoverFailover indicates that Operator can recover the failed Pods",
@@ -10321,6 +11977,20 @@ func schema_pkg_apis_pingcap_v1alpha1_TiKVSpec(ref common.ReferenceCallback) com
 							Format:      "",
 						},
 					},
+					"failoverDeleteSlots": {
+						SchemaProps: spec.SchemaProps{
+							Description: "FailoverDeleteSlots replaces a failed store whose node is gone, i.e. PD has lost the heartbeats of the store and its node has been NotReady, or has been deleted since the heartbeats were lost, for the `--tikv-node-not-ready-failover-period` of the controller manager, by adding the ordinal of its Pod to the `tikv.tidb.pingcap.com/delete-slots` annotation instead of keeping the ordinal and adding a failover Pod. A new Pod with a new PVC is created on a healthy node first, then the store is deleted from PD and the failed Pod is removed once the store becomes tombstone. The stores are replaced one at a time. It requires the AdvancedStatefulSet feature, otherwise a failover Pod is added as usual. Optional: Defaults to false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"failoverPVCPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "FailoverPVCPolicy decides what happens to the data PVC of a failure store when it is failed over, a failover Pod is added in both cases. Reuse keeps the store and the PVC, so that the store restarts with its intact data once it recovers. Recreate deletes the store from PD and, once it becomes tombstone, deletes its Pod and PVC, so that the Pod joins the cluster again as a new store with an empty volume. Optional: Defaults to Reuse",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 					"mountClusterClientSecret": {
 						SchemaProps: spec.SchemaProps{
 							Description: "MountClusterClientSecret indicates whether to mount `cluster-client-secret` to the Pod",
@@ -10335,6 +12005,39 @@ func schema_pkg_apis_pingcap_v1alpha1_TiKVSpec(ref common.ReferenceCallback) com
 							Format:      "",
 						},
 					},
+					"evictLeaderBackoff": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EvictLeaderBackoff is how often the leaders of the TiKV Pod being upgraded or scaled in are checked while they are evicted. Optional: Defaults to nil, which means the check is retried with the backoff of the controller",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.EvictLeaderBackoff"),
+						},
+					},
+					"maxConcurrentEvictLeaders": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaxConcurrentEvictLeaders is the max number of stores whose region leaders are evicted at the same time, e.g. for the batches of a TiKV upgrade. It is always kept below the number of Up stores, so there are stores left to take the leaders. Defaults to the maxUnavailable of the upgradePolicy",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"autoTuneThreadPools": {
+						SchemaProps: spec.SchemaProps{
+							Description: "AutoTuneThreadPools derives the sizes of the TiKV thread pools, e.g. raftstore.store-pool-size, from the CPU limit of the TiKV container. Thread pool sizes set explicitly in the config are kept as they are. It takes no effect if the CPU limit is not set. Optional: Defaults to false",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"storageCheck": {
+						SchemaProps: spec.SchemaProps{
+							Description: "StorageCheck runs an init container to measure the write throughput of the data volume before TiKV starts, and sets the StorageSlow condition if it is below the floor. Optional: Defaults to nil, which means the check is disabled",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.StorageCheckSpec"),
+						},
+					},
+					"storeHeartbeatStaleThreshold": {
+						SchemaProps: spec.SchemaProps{
+							Description: "StoreHeartbeatStaleThreshold sets the StoreHeartbeatStale condition if the last heartbeat of some TiKV stores to PD is older than it, in the format of Go Duration. It gives an early warning before the stores are down and failed over. Optional: Defaults to nil, which means the check is disabled",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 					"storageVolumes": {
 						SchemaProps: spec.SchemaProps{
 							Description: "StorageVolumes configure additional storage for TiKV pods.",
@@ -10348,6 +12051,20 @@ func schema_pkg_apis_pingcap_v1alpha1_TiKVSpec(ref common.ReferenceCallback) com
 							},
 						},
 					},
+					"walStorageSize": {
+						SchemaProps: spec.SchemaProps{
+							Description: "WalStorageSize is the size of the separate persistent volume that keeps the WAL of TiKV, e.g. on faster storage than the data. The WAL is kept on the data volume if it is not set.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"walStorageClassName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The storageClassName of the persistent volume for the TiKV WAL. Defaults to the storageClassName of the TiKV data.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 					"storeLabels": {
 						SchemaProps: spec.SchemaProps{
 							Description: "StoreLabels configures additional labels for TiKV stores.",
@@ -10364,7 +12081,57 @@ func schema_pkg_apis_pingcap_v1alpha1_TiKVSpec(ref common.ReferenceCallback) com
 					},
 					"enableNamedStatusPort": {
 						SchemaProps: spec.SchemaProps{
-							Description: "EnableNamedStatusPort enables status port(20180) in the Pod spec. If you set it to `true` for an existing cluster, the TiKV cluster will be rolling updated.",
+							Description: "EnableNamedStatusPort enables status port(20180 by default) in the Pod spec. If you set it to `true` for an existing cluster, the TiKV cluster will be rolling updated.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"ports": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Ports overrides the ports TiKV listens on, e.g. to avoid port conflicts when hostNetwork is enabled. The server port is part of the store address, so it cannot be changed for an existing cluster.",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVPorts"),
+						},
+					},
+					"upgradeStabilizationGate": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradeStabilizationGate holds the rolling upgrade after each upgraded Pod until a key metric of the Pod returns to the baseline. Optional: Defaults to nil, which means only the readiness is waited for",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MetricStabilizationGate"),
+						},
+					},
+					"scaleInStoreEmptyTimeout": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ScaleInStoreEmptyTimeout is how long the scale-in of a TiKV Pod waits for its tombstone store to report no Regions to PD before the StatefulSet is scaled in, in the format of Go Duration. After it the scale-in completes with a warning Event, e.g. if the Region count reported for the store is stale. Defaults to 10m",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"scaleHooks": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ScaleHooks are called before a member is removed and after a member is added or removed, so that external systems can react to the changes of the topology. Optional: Defaults to nil",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScaleHooks"),
+						},
+					},
+					"upgradeHooks": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradeHooks are the Jobs run before a Pod is upgraded and after it becomes healthy. Optional: Defaults to nil",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradeHooks"),
+						},
+					},
+					"scalePolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ScalePolicy is the policy of the scale of TiKV Optional: Defaults to nil",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVScalePolicy"),
+						},
+					},
+					"upgradePolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradePolicy is the policy of the rolling upgrade of TiKV Optional: Defaults to nil",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVUpgradePolicy"),
+						},
+					},
+					"upgradePaused": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradePaused freezes the partition of the StatefulSet of TiKV, so that no more Pods are upgraded until it is unset. The Pod being upgraded is not interrupted. Optional: Defaults to false",
 							Type:        []string{"boolean"},
 							Format:      "",
 						},
@@ -10374,7 +12141,7 @@ func schema_pkg_apis_pingcap_v1alpha1_TiKVSpec(ref common.ReferenceCallback) com
 			},
 		},
 		Dependencies: []string{
-			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.LogTailerSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.StorageVolume", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVConfigWraper", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Container", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount", "k8s.io/apimachinery/pkg/api/resource.Quantity"},
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.EphemeralStorageSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.EvictLeaderBackoff", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.FailoverSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.LogTailerSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MetricStabilizationGate", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScaleHooks", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.StorageCheckSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.StorageVolume", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVConfigWraper", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVPorts", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVScalePolicy", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVUpgradePolicy", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradeHooks", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Container", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.SecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount", "k8s.io/apimachinery/pkg/api/resource.Quantity"},
 	}
 }
 
@@ -10669,6 +12436,26 @@ func schema_pkg_apis_pingcap_v1alpha1_TiKVUnifiedReadPoolConfig(ref common.Refer
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_TiKVUpgradePolicy(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "TiKVUpgradePolicy is the policy of the rolling upgrade of TiKV",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"maxUnavailable": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaxUnavailable is the max number of TiKV Pods upgraded at the same time. The Pods of a batch are in the same zone, so a batch takes down at most one peer of a Region if PD places the peers in different zones, and a batch is only started when all the upgraded Pods are up and PD reports no Region missing peers or with down peers. A store whose zone is unknown is upgraded alone. It is ignored if the pod admission webhook is enabled. The zone of a store is the zone label of its node, or the `zone` label of the store in PD if the node has none. Optional: Defaults to 1",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_TidbAutoScalerSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -10882,87 +12669,209 @@ func schema_pkg_apis_pingcap_v1alpha1_TidbClusterAutoScalerRef(ref common.Refere
 				Properties: map[string]spec.Schema{
 					"name": {
 						SchemaProps: spec.SchemaProps{
-							Type:   []string{"string"},
-							Format: "",
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"namespace": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+				},
+				Required: []string{"name", "namespace"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_pingcap_v1alpha1_TidbClusterAutoScalerSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "TidbAutoScalerSpec describes the state of the TidbClusterAutoScaler",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"cluster": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TidbClusterRef describe the target TidbCluster",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbClusterRef"),
+						},
+					},
+					"tikv": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TiKV represents the auto-scaling spec for tikv",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TikvAutoScalerSpec"),
+						},
+					},
+					"tidb": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TiDB represents the auto-scaling spec for tidb",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbAutoScalerSpec"),
+						},
+					},
+				},
+				Required: []string{"cluster"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbAutoScalerSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbClusterRef", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TikvAutoScalerSpec"},
+	}
+}
+
+func schema_pkg_apis_pingcap_v1alpha1_TidbClusterAutoScalerStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "TidbClusterAutoScalerStatus describe the whole status",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"tikv": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Tikv describes the status of each group for the tikv in the last auto-scaling reconciliation",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TikvAutoScalerStatus"),
+									},
+								},
+							},
+						},
+					},
+					"tidb": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Tidb describes the status of each group for the tidb in the last auto-scaling reconciliation",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbAutoScalerStatus"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbAutoScalerStatus", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TikvAutoScalerStatus"},
+	}
+}
+
+func schema_pkg_apis_pingcap_v1alpha1_TidbClusterConfigProfile(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "TidbClusterConfigProfile is a reusable baseline of TiDB system variables applied to the TidbClusters in the same namespace referencing it",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"namespace": {
+					"spec": {
 						SchemaProps: spec.SchemaProps{
-							Type:   []string{"string"},
-							Format: "",
+							Description: "Spec defines the baseline of the TidbClusterConfigProfile",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbClusterConfigProfileSpec"),
 						},
 					},
 				},
-				Required: []string{"name", "namespace"},
+				Required: []string{"spec"},
 			},
 		},
+		Dependencies: []string{
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbClusterConfigProfileSpec"},
 	}
 }
 
-func schema_pkg_apis_pingcap_v1alpha1_TidbClusterAutoScalerSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_pingcap_v1alpha1_TidbClusterConfigProfileList(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "TidbAutoScalerSpec describes the state of the TidbClusterAutoScaler",
+				Description: "TidbClusterConfigProfileList is TidbClusterConfigProfile list",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"cluster": {
+					"kind": {
 						SchemaProps: spec.SchemaProps{
-							Description: "TidbClusterRef describe the target TidbCluster",
-							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbClusterRef"),
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"tikv": {
+					"apiVersion": {
 						SchemaProps: spec.SchemaProps{
-							Description: "TiKV represents the auto-scaling spec for tikv",
-							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TikvAutoScalerSpec"),
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"tidb": {
+					"items": {
 						SchemaProps: spec.SchemaProps{
-							Description: "TiDB represents the auto-scaling spec for tidb",
-							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbAutoScalerSpec"),
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbClusterConfigProfile"),
+									},
+								},
+							},
 						},
 					},
 				},
-				Required: []string{"cluster"},
+				Required: []string{"items"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbAutoScalerSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbClusterRef", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TikvAutoScalerSpec"},
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbClusterConfigProfile"},
 	}
 }
 
-func schema_pkg_apis_pingcap_v1alpha1_TidbClusterAutoScalerStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_pingcap_v1alpha1_TidbClusterConfigProfileSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "TidbClusterAutoScalerStatus describe the whole status",
+				Description: "TidbClusterConfigProfileSpec is the baseline of TiDB system variables",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"tikv": {
+					"systemVariables": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Tikv describes the status of each group for the tikv in the last auto-scaling reconciliation",
+							Description: "SystemVariables are the TiDB global system variables applied to the clusters referencing the profile, keyed by the variable name",
 							Type:        []string{"object"},
 							AdditionalProperties: &spec.SchemaOrBool{
 								Allows: true,
 								Schema: &spec.Schema{
 									SchemaProps: spec.SchemaProps{
-										Ref: ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TikvAutoScalerStatus"),
+										Type:   []string{"string"},
+										Format: "",
 									},
 								},
 							},
 						},
 					},
-					"tidb": {
+					"lockedSystemVariables": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Tidb describes the status of each group for the tidb in the last auto-scaling reconciliation",
-							Type:        []string{"object"},
-							AdditionalProperties: &spec.SchemaOrBool{
-								Allows: true,
+							Description: "LockedSystemVariables are the system variables of the profile that the clusters referencing it must not override with a different value",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
 								Schema: &spec.Schema{
 									SchemaProps: spec.SchemaProps{
-										Ref: ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbAutoScalerStatus"),
+										Type:   []string{"string"},
+										Format: "",
 									},
 								},
 							},
@@ -10971,8 +12880,6 @@ func schema_pkg_apis_pingcap_v1alpha1_TidbClusterAutoScalerStatus(ref common.Ref
 				},
 			},
 		},
-		Dependencies: []string{
-			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbAutoScalerStatus", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TikvAutoScalerStatus"},
 	}
 }
 
@@ -11131,7 +13038,7 @@ func schema_pkg_apis_pingcap_v1alpha1_TidbClusterSpec(ref common.ReferenceCallba
 					},
 					"schedulerName": {
 						SchemaProps: spec.SchemaProps{
-							Description: "SchedulerName of TiDB cluster Pods",
+							Description: "SchedulerName of TiDB cluster Pods HA scheduling is only enforced for Pods scheduled by tidb-scheduler",
 							Type:        []string{"string"},
 							Format:      "",
 						},
@@ -11165,7 +13072,7 @@ func schema_pkg_apis_pingcap_v1alpha1_TidbClusterSpec(ref common.ReferenceCallba
 					},
 					"configUpdateStrategy": {
 						SchemaProps: spec.SchemaProps{
-							Description: "ConfigUpdateStrategy determines how the configuration change is applied to the cluster. UpdateStrategyInPlace will update the ConfigMap of configuration in-place and an extra rolling-update of the cluster component is needed to reload the configuration change. UpdateStrategyRollingUpdate will create a new ConfigMap with the new configuration and rolling-update the related components to use the new ConfigMap, that is, the new configuration will be applied automatically.",
+							Description: "ConfigUpdateStrategy determines how the configuration change is applied to the cluster. UpdateStrategyInPlace will update the ConfigMap of configuration in-place and an extra rolling-update of the cluster component is needed to reload the configuration change. UpdateStrategyRollingUpdate will create a new ConfigMap with the new configuration and rolling-update the related components to use the new ConfigMap, that is, the new configuration will be applied automatically. UpdateStrategyInPlacePreferred will apply the configuration change of PD, TiKV and TiDB online without rolling-update if all the changed items support online change, otherwise it works as UpdateStrategyRollingUpdate.",
 							Type:        []string{"string"},
 							Format:      "",
 						},
@@ -11336,11 +13243,85 @@ func schema_pkg_apis_pingcap_v1alpha1_TidbClusterSpec(ref common.ReferenceCallba
 							},
 						},
 					},
+					"tikvTiFlashAntiAffinity": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TiKVTiFlashAntiAffinity generates pod anti-affinity between TiKV and TiFlash, so that TiKV Pods prefer not to be colocated with TiFlash Pods and vice versa, as they compete for IO on the same node. Optional: Defaults to nil (no anti-affinity is generated)",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.CrossComponentAntiAffinity"),
+						},
+					},
+					"snapshotBeforeDeletingPVC": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SnapshotBeforeDeletingPVC takes a VolumeSnapshot of each PVC the operator is about to delete, e.g. in PD failover, and deletes the PVC only after the snapshot is cut. It is ignored if the Kubernetes cluster does not serve the VolumeSnapshot API. Optional: Defaults to nil, which means no snapshot is taken",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PVCSnapshotSpec"),
+						},
+					},
+					"stalePVCPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "StalePVCPolicy decides what happens when PD or TiKV is scaled out to an ordinal whose PVC is marked as defer deleting by a previous scale-in, so its volume may still hold the data of the removed member. Reuse deletes the PVC as usual, without checking the data left on the volume. Block holds the scale-out and reports the PVC in the StalePVC condition until it is deleted. Wipe empties the volume by a cleanup Job and keeps the PVC for the new member. Optional: Defaults to Reuse",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"upgradeCrashLoopPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradeCrashLoopPolicy aborts the upgrade of a component if an upgraded Pod stays in CrashLoopBackOff, e.g. due to a bad config or image, instead of waiting for it to be ready forever. It applies to PD, TiKV and TiDB. Optional: Defaults to nil, which means the upgrade waits for the Pod",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradeCrashLoopPolicy"),
+						},
+					},
+					"podTemplateWebhook": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PodTemplateWebhook is called with the Pod template the operator generates for each component before the StatefulSet is created or updated, and may add containers, init containers, volumes, labels and annotations to it. The other mutations are dropped, so the operator still owns the rest of the Pod template. The webhook must be deterministic, otherwise the StatefulSets are updated in each round. Optional: Defaults to nil",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PodTemplateWebhook"),
+						},
+					},
+					"pvcResizeFailurePolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PVCResizeFailurePolicy determines when the expansion of a PVC is considered failed, e.g. when its StorageClass does not support online expansion. The failure is reported in the PVCResizeFailed condition. Optional: Defaults to nil",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PVCResizeFailurePolicy"),
+						},
+					},
+					"pvcDeferDeletePeriod": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PVCDeferDeletePeriod is how long the PVCs marked as defer deleting on scale-in are kept before they are deleted by the PV reclaim, giving a window to recover the data of a mistaken scale-in, e.g. 24h. Optional: Defaults to nil, which means the PVCs are deleted as soon as their Pods are gone",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"podRestartPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PodRestartPolicy watches the restart counts of the Pods of PD, TiKV, TiDB and TiFlash, and reports the Pods restarted by the kubelet too often, e.g. due to a flapping liveness probe, in the PodRestartFlapping condition. Optional: Defaults to nil, which means the restarts are not watched",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PodRestartPolicy"),
+						},
+					},
+					"rolloutStallPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "RolloutStallPolicy determines when a rollout of PD, TiKV, TiDB or TiFlash is considered stalled, i.e. one of its Pods stays Pending, e.g. as it can not be scheduled. The stalled Pods are reported in the RolloutStalled condition with the reason taken from their events. Optional: Defaults to nil, which means the default threshold is used",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.RolloutStallPolicy"),
+						},
+					},
+					"scalePolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ScalePolicy is the policy of the scale of the components Optional: Defaults to nil",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScalePolicy"),
+						},
+					},
+					"maintenanceWindow": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaintenanceWindow limits when the upgrades and the scales of the components may go on. Outside the window, the upgrade of the next Pod and the scales are queued and reported in the WaitingForMaintenanceWindow condition, the Pod being upgraded is not interrupted. Optional: Defaults to nil, which means the operations may start at any time",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MaintenanceWindow"),
+						},
+					},
+					"upgradePolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradePolicy is the policy of the rolling upgrades of the components Optional: Defaults to nil",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradePolicy"),
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.DiscoverySpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.HelperSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PumpSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TLSCluster", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiCDCSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiFlashSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbClusterRef", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.Toleration"},
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.CrossComponentAntiAffinity", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.DiscoverySpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.HelperSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.MaintenanceWindow", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PDSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PVCResizeFailurePolicy", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PVCSnapshotSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PodRestartPolicy", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PodTemplateWebhook", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.PumpSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.RolloutStallPolicy", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.ScalePolicy", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TLSCluster", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiCDCSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiDBSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiFlashSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TiKVSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TidbClusterRef", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradeCrashLoopPolicy", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradePolicy", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.Toleration"},
 	}
 }
 
@@ -12078,6 +14059,177 @@ func schema_pkg_apis_pingcap_v1alpha1_TxnLocalLatches(ref common.ReferenceCallba
 	}
 }
 
+func schema_pkg_apis_pingcap_v1alpha1_UpgradeAutoRollback(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "UpgradeAutoRollback is when an upgrade is rolled back automatically",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"threshold": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Threshold is how long an upgraded Pod may fail the health checks before the upgrade is rolled back, in the format of Go Duration. Defaults to 10m",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_pingcap_v1alpha1_UpgradeCrashLoopPolicy(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "UpgradeCrashLoopPolicy is how an upgrade handles the upgraded Pods stuck in CrashLoopBackOff",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"threshold": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Threshold is how long an upgraded Pod may stay in CrashLoopBackOff before the upgrade is aborted, in the format of Go Duration. Defaults to 10m",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"rollback": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Rollback rolls the crash-looping Pod back to the previous revision when the upgrade is aborted. Otherwise the Pod is left as it is.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_pingcap_v1alpha1_UpgradeHook(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "UpgradeHook is a Job run for the upgrade of a Pod. The upgrade is passed to the container by the env UPGRADE_NAMESPACE, UPGRADE_CLUSTER, UPGRADE_COMPONENT, UPGRADE_ACTION, UPGRADE_ORDINAL and UPGRADE_POD_NAME.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"job": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Job is the Job run for the upgrade, the hook succeeds if the Job completes",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.JobScaleHook"),
+						},
+					},
+					"timeoutSeconds": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TimeoutSeconds is how long the upgrade waits for the Job, the hook fails if the Job does not complete in time. Defaults to 600",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"failurePolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "FailurePolicy is how the upgrade handles the failure of the hook, Fail blocks the upgrade and retries the Job, Ignore reports the failure by an Event and continues the upgrade. Defaults to Fail",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"job"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.JobScaleHook"},
+	}
+}
+
+func schema_pkg_apis_pingcap_v1alpha1_UpgradeHooks(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "UpgradeHooks are the Jobs run around the upgrade of each Pod of a component, e.g. to warm up the caches of TiDB or to reload the statistics after it is upgraded",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"preUpgrade": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PreUpgrade is run before a Pod is upgraded, and the upgrade of the Pod waits until it succeeds",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradeHook"),
+						},
+					},
+					"postUpgrade": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PostUpgrade is run after an upgraded Pod becomes healthy, and the upgrade of the next Pod waits until it succeeds",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradeHook"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradeHook"},
+	}
+}
+
+func schema_pkg_apis_pingcap_v1alpha1_UpgradePolicy(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "UpgradePolicy is the policy of the rolling upgrades of the components",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"preChecks": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PreChecks are the checks of the cluster health reported by PD before a rolling upgrade of PD, TiKV, TiFlash, TiDB or TiCDC starts. If any check fails, the upgrade waits and the UpgradeBlocked condition is set. The checks are not repeated for the following Pods of a started upgrade. Optional: Defaults to nil, which means no check",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradePreChecks"),
+						},
+					},
+					"autoRollback": {
+						SchemaProps: spec.SchemaProps{
+							Description: "AutoRollback rolls the upgrade of PD, TiKV or TiDB back to the previous revision if an upgraded Pod fails the health checks for longer than the threshold. The failing Pod is rolled back first, then the other upgraded Pods one by one, each after the previous one is ready. The upgrade is aborted until the spec is changed. Optional: Defaults to nil, which means the upgrade is not rolled back",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradeAutoRollback"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradeAutoRollback", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.UpgradePreChecks"},
+	}
+}
+
+func schema_pkg_apis_pingcap_v1alpha1_UpgradePreChecks(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "UpgradePreChecks are the thresholds of the checks before starting a rolling upgrade",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"maxDownPeerRegions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaxDownPeerRegions is the max number of Regions with down peers Defaults to 0",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"maxPendingPeerRegions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaxPendingPeerRegions is the max number of Regions with pending peers Defaults to 0",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"maxUnhealthyStores": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaxUnhealthyStores is the max number of TiKV and TiFlash stores that are not Up, and of PD members that are not healthy Defaults to 0",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_pingcap_v1alpha1_WorkerConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -12210,7 +14362,7 @@ func schema_pkg_apis_pingcap_v1alpha1_WorkerSpec(ref common.ReferenceCallback) c
 					},
 					"schedulerName": {
 						SchemaProps: spec.SchemaProps{
-							Description: "SchedulerName of the component. Override the cluster-level one if present Optional: Defaults to cluster-level setting",
+							Description: "SchedulerName of the component. Override the cluster-level one if present HA scheduling is only enforced for Pods scheduled by tidb-scheduler Optional: Defaults to cluster-level setting",
 							Type:        []string{"string"},
 							Format:      "",
 						},
@@ -12279,6 +14431,12 @@ func schema_pkg_apis_pingcap_v1alpha1_WorkerSpec(ref common.ReferenceCallback) c
 							Ref:         ref("k8s.io/api/core/v1.PodSecurityContext"),
 						},
 					},
+					"securityContext": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SecurityContext of the main container of the component, e.g. to set readOnlyRootFilesystem or drop capabilities. The privileged flag of TiKV and TiFlash defaults to the cluster-level setting if it is not set, and a writable emptyDir is mounted at /tmp if the root filesystem is read-only. It is not applied to the init containers that need the privileges, e.g. the sysctl init container.",
+							Ref:         ref("k8s.io/api/core/v1.SecurityContext"),
+						},
+					},
 					"configUpdateStrategy": {
 						SchemaProps: spec.SchemaProps{
 							Description: "ConfigUpdateStrategy of the component. Override the cluster-level updateStrategy if present Optional: Defaults to cluster-level setting",
@@ -12386,6 +14544,33 @@ func schema_pkg_apis_pingcap_v1alpha1_WorkerSpec(ref common.ReferenceCallback) c
 							},
 						},
 					},
+					"failoverTopologySpreadConstraints": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"topologyKey",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "FailoverTopologySpreadConstraints describes how the Pods provisioned by failover ought to spread across topology domains. The replacement Pods prefer the domains holding the fewest Pods of the component, so that they rebalance the component instead of clustering in one domain. The constraints of a replacement Pod are generated with WhenUnsatisfiable set to ScheduleAnyway, so that the failover is never blocked by the domain that failed. This field is only honored by PD, TiKV, TiFlash and TiDB, and requires the pod admission webhook. Optional: Defaults to the TopologySpreadConstraints of the component",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint"),
+									},
+								},
+							},
+						},
+					},
+					"ephemeralStorage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EphemeralStorage sets the ephemeral storage request and limit of the component container and the size limit of the emptyDir volumes created for it. It overrides the ephemeral-storage in requests and limits if present.",
+							Ref:         ref("github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.EphemeralStorageSpec"),
+						},
+					},
 					"limits": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Limits describes the maximum amount of compute resources allowed. More info: https://kubernetes.io/docs/concepts/configuration/manage-compute-resources-container/",
@@ -12474,7 +14659,7 @@ func schema_pkg_apis_pingcap_v1alpha1_WorkerSpec(ref common.ReferenceCallback) c
 			},
 		},
 		Dependencies: []string{
-			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.WorkerConfig", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Container", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount", "k8s.io/apimachinery/pkg/api/resource.Quantity"},
+			"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.EphemeralStorageSpec", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.TopologySpreadConstraint", "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1.WorkerConfig", "k8s.io/api/core/v1.Affinity", "k8s.io/api/core/v1.Container", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.PodSecurityContext", "k8s.io/api/core/v1.SecurityContext", "k8s.io/api/core/v1.Toleration", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount", "k8s.io/apimachinery/pkg/api/resource.Quantity"},
 	}
 }
 
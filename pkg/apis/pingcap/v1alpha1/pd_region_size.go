@@ -0,0 +1,60 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MinRegionSize is the min size of the Regions
+	MinRegionSize = uint64(1) << 20
+	// MaxRegionSize is the max size of the Regions
+	MaxRegionSize = uint64(10) << 30
+)
+
+var (
+	regionSizeRegexp = regexp.MustCompile(`^([0-9]+)\s*([KMGT]i?B|B)?$`)
+
+	regionSizeUnits = map[string]uint64{
+		"":  1,
+		"B": 1,
+		"K": 1 << 10,
+		"M": 1 << 20,
+		"G": 1 << 30,
+		"T": 1 << 40,
+	}
+)
+
+// ParseRegionSize parses a Region size in the format of PD and TiKV, e.g.
+// 96MiB, to bytes. KB, MB, GB and TB are binary units as well.
+func ParseRegionSize(size string) (uint64, error) {
+	m := regionSizeRegexp.FindStringSubmatch(strings.TrimSpace(size))
+	if m == nil {
+		return 0, fmt.Errorf("invalid size %q, must be an integer with an optional unit of B, KiB, MiB, GiB or TiB", size)
+	}
+	n, err := strconv.ParseUint(m[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %v", size, err)
+	}
+	unit := regionSizeUnits[strings.TrimSuffix(strings.TrimSuffix(m[2], "B"), "i")]
+	if n > math.MaxUint64/unit {
+		return 0, fmt.Errorf("invalid size %q: out of range", size)
+	}
+	return n * unit, nil
+}
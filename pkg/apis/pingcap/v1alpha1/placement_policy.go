@@ -0,0 +1,86 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// placementPolicyOptions are the options of TiDB placement policies, the
+// value is whether the option is a number
+var placementPolicyOptions = map[string]bool{
+	"PRIMARY_REGION":       false,
+	"REGIONS":              false,
+	"SCHEDULE":             false,
+	"CONSTRAINTS":          false,
+	"LEADER_CONSTRAINTS":   false,
+	"FOLLOWER_CONSTRAINTS": false,
+	"VOTER_CONSTRAINTS":    false,
+	"LEARNER_CONSTRAINTS":  false,
+	"SURVIVAL_PREFERENCES": false,
+	"FOLLOWERS":            true,
+	"VOTERS":               true,
+	"LEARNERS":             true,
+}
+
+// ValidatePlacementPolicyOption returns an error if the option is not a valid
+// option of TiDB placement policies
+func ValidatePlacementPolicyOption(name, value string) error {
+	isNumber, ok := placementPolicyOptions[strings.ToUpper(name)]
+	if !ok {
+		return fmt.Errorf("unknown placement option %s", name)
+	}
+	if isNumber {
+		if n, err := strconv.ParseUint(value, 10, 32); err != nil || n == 0 {
+			return fmt.Errorf("placement option %s must be a positive integer", name)
+		}
+		return nil
+	}
+	if strings.ContainsAny(value, "\"\\\n") {
+		return fmt.Errorf("placement option %s must not contain quotes, backslashes or newlines", name)
+	}
+	return nil
+}
+
+// NormalizedOptions returns the options with the option names in upper case
+func (p *PlacementPolicy) NormalizedOptions() map[string]string {
+	options := make(map[string]string, len(p.Options))
+	for name, value := range p.Options {
+		options[strings.ToUpper(name)] = value
+	}
+	return options
+}
+
+// SQLOptions returns the options in the syntax of the placement policy DDL,
+// e.g. FOLLOWERS=2 PRIMARY_REGION="us-east-1"
+func (p *PlacementPolicy) SQLOptions() string {
+	options := p.NormalizedOptions()
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	items := make([]string, 0, len(names))
+	for _, name := range names {
+		if placementPolicyOptions[name] {
+			items = append(items, fmt.Sprintf("%s=%s", name, options[name]))
+		} else {
+			items = append(items, fmt.Sprintf("%s=%q", name, options[name]))
+		}
+	}
+	return strings.Join(items, " ")
+}
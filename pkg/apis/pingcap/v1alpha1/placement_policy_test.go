@@ -0,0 +1,48 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestPlacementPolicySQLOptions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	p := &PlacementPolicy{
+		Name: "p1",
+		Options: map[string]string{
+			"regions":        "us-east-1,us-west-1",
+			"PRIMARY_REGION": "us-east-1",
+			"followers":      "2",
+		},
+	}
+	g.Expect(p.SQLOptions()).To(Equal(`FOLLOWERS=2 PRIMARY_REGION="us-east-1" REGIONS="us-east-1,us-west-1"`))
+	g.Expect(p.NormalizedOptions()).To(HaveKeyWithValue("REGIONS", "us-east-1,us-west-1"))
+}
+
+func TestValidatePlacementPolicyOption(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(ValidatePlacementPolicyOption("primary_region", "us-east-1")).To(Succeed())
+	g.Expect(ValidatePlacementPolicyOption("CONSTRAINTS", "[+disk=ssd]")).To(Succeed())
+	g.Expect(ValidatePlacementPolicyOption("FOLLOWERS", "3")).To(Succeed())
+
+	g.Expect(ValidatePlacementPolicyOption("REPLICAS", "3")).NotTo(Succeed())
+	g.Expect(ValidatePlacementPolicyOption("FOLLOWERS", "two")).NotTo(Succeed())
+	g.Expect(ValidatePlacementPolicyOption("FOLLOWERS", "0")).NotTo(Succeed())
+	g.Expect(ValidatePlacementPolicyOption("REGIONS", `us-east-1" FOLLOWERS=9`)).NotTo(Succeed())
+}
@@ -36,6 +36,52 @@ const (
 	defaultEnablePVReclaim    = false
 	// defaultEvictLeaderTimeout is the timeout limit of evict leader
 	defaultEvictLeaderTimeout = 1500 * time.Minute
+	// defaultEvictLeaderInterval is the min wait before checking the leaders being evicted again
+	defaultEvictLeaderInterval = 5 * time.Second
+	// defaultEvictLeaderMaxInterval is the max wait before checking the leaders being evicted again
+	defaultEvictLeaderMaxInterval = time.Minute
+	// defaultTiDBUpgradeConcurrency is the max number of TiDB Pods restarted at the same time in an upgrade
+	defaultTiDBUpgradeConcurrency = 1
+	// defaultUpgradeCrashLoopThreshold is how long an upgraded Pod may stay in CrashLoopBackOff
+	defaultUpgradeCrashLoopThreshold = 10 * time.Minute
+	// defaultUpgradeAutoRollbackThreshold is how long an upgraded Pod may fail the health checks before the upgrade is rolled back
+	defaultUpgradeAutoRollbackThreshold = 10 * time.Minute
+	// defaultPodTemplateWebhookTimeout is the timeout of calling the Pod template webhook
+	defaultPodTemplateWebhookTimeout = 10 * time.Second
+	// defaultScaleHookTimeout is the timeout of calling an HTTP scale hook
+	defaultScaleHookTimeout = 10 * time.Second
+	// defaultUpgradeHookTimeout is how long the upgrade waits for the Job of an upgrade hook
+	defaultUpgradeHookTimeout = 10 * time.Minute
+	// defaultTiDBCanaryPauseDuration is how long a TiDB upgrade is paused after the canary Pod is ready
+	defaultTiDBCanaryPauseDuration = time.Minute
+	// defaultPDLearnerTimeout is how long a PD member may stay a learner
+	defaultPDLearnerTimeout = 10 * time.Minute
+	// defaultPDLostPVCGracePeriod is how long a PD member may stay without its data PVC
+	defaultPDLostPVCGracePeriod = 5 * time.Minute
+	// defaultTiKVScaleInStoreEmptyTimeout is how long a TiKV scale-in waits for the store to be empty
+	defaultTiKVScaleInStoreEmptyTimeout = 10 * time.Minute
+	// defaultPVCFileSystemResizeTimeout is how long a PVC may wait for the file system resize
+	defaultPVCFileSystemResizeTimeout = 10 * time.Minute
+	// defaultPodRestartThreshold is the restart count at which a Pod is flapping
+	defaultPodRestartThreshold = int32(5)
+	// defaultPodRestartWindow is how recent the last restart of a flapping Pod must be
+	defaultPodRestartWindow = 10 * time.Minute
+	// defaultRolloutStallThreshold is how long a Pod may stay Pending before the rollout is stalled
+	defaultRolloutStallThreshold = 10 * time.Minute
+	// defaultTiFlashUpgradeCheckTimeout is how long an upgraded TiFlash store may fail the upgrade checks
+	defaultTiFlashUpgradeCheckTimeout = 30 * time.Minute
+	// defaultTiCDCGracefulShutdownTimeout is how long the upgrade waits for a TiCDC capture to be drained
+	defaultTiCDCGracefulShutdownTimeout = 10 * time.Minute
+	// defaultScaleVelocityWindow is the time window of the scale velocity limit
+	defaultScaleVelocityWindow = time.Hour
+	// defaultAuditLogPlugin is the audit plugin loaded by TiDB
+	defaultAuditLogPlugin = "audit-1"
+	// defaultAuditLogFile is the name of the audit log file of TiDB
+	defaultAuditLogFile = "tidb-audit.log"
+	// DefaultTiKVServerPort is the default port of the gRPC service of TiKV
+	DefaultTiKVServerPort = int32(20160)
+	// DefaultTiKVStatusPort is the default port of the status service of TiKV
+	DefaultTiKVStatusPort = int32(20180)
 )
 
 var (
@@ -140,6 +186,160 @@ func (tc *TidbCluster) TiKVContainerPrivilege() *bool {
 	return tc.Spec.TiKV.Privileged
 }
 
+// TiKVServerPort returns the port of the gRPC service of TiKV.
+func (tc *TidbCluster) TiKVServerPort() int32 {
+	if tc.Spec.TiKV != nil && tc.Spec.TiKV.Ports != nil && tc.Spec.TiKV.Ports.Server != nil {
+		return *tc.Spec.TiKV.Ports.Server
+	}
+	return DefaultTiKVServerPort
+}
+
+// TiKVStatusPort returns the port of the status service of TiKV.
+func (tc *TidbCluster) TiKVStatusPort() int32 {
+	if tc.Spec.TiKV != nil && tc.Spec.TiKV.Ports != nil && tc.Spec.TiKV.Ports.Status != nil {
+		return *tc.Spec.TiKV.Ports.Status
+	}
+	return DefaultTiKVStatusPort
+}
+
+// PDLearnerTimeout returns how long a PD member may stay a learner before it
+// is considered stuck.
+func (tc *TidbCluster) PDLearnerTimeout() time.Duration {
+	if tc.Spec.PD != nil && tc.Spec.PD.LearnerTimeout != nil {
+		d, err := time.ParseDuration(*tc.Spec.PD.LearnerTimeout)
+		if err == nil {
+			return d
+		}
+	}
+	return defaultPDLearnerTimeout
+}
+
+// PDEtcdDefragInterval returns the interval to defragment the etcd embedded in
+// each PD member, 0 means the etcd is not defragmented.
+func (tc *TidbCluster) PDEtcdDefragInterval() time.Duration {
+	if tc.Spec.PD == nil || tc.Spec.PD.EtcdDefragInterval == nil {
+		return 0
+	}
+	d, err := time.ParseDuration(*tc.Spec.PD.EtcdDefragInterval)
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// PDScaleInLeaderTransferTimeout returns how long the scale-in of the PD leader
+// waits for the leader transfer, 0 means it does not wait.
+func (tc *TidbCluster) PDScaleInLeaderTransferTimeout() time.Duration {
+	if tc.Spec.PD == nil || tc.Spec.PD.ScaleInLeaderTransferTimeout == nil {
+		return 0
+	}
+	d, err := time.ParseDuration(*tc.Spec.PD.ScaleInLeaderTransferTimeout)
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// PDRecreateStuckLearner returns whether to recreate the PD members stuck in learner state.
+func (tc *TidbCluster) PDRecreateStuckLearner() bool {
+	return tc.Spec.PD != nil && tc.Spec.PD.RecreateStuckLearner != nil && *tc.Spec.PD.RecreateStuckLearner
+}
+
+// PDJoinAsLearner returns whether the PD members added by scale-out join as learners.
+func (tc *TidbCluster) PDJoinAsLearner() bool {
+	return tc.Spec.PD != nil && tc.Spec.PD.JoinAsLearner != nil && *tc.Spec.PD.JoinAsLearner
+}
+
+// PDLostPVCGracePeriod returns how long a PD member may stay without its data PVC.
+func (tc *TidbCluster) PDLostPVCGracePeriod() time.Duration {
+	if tc.Spec.PD != nil && tc.Spec.PD.LostPVCGracePeriod != nil {
+		d, err := time.ParseDuration(*tc.Spec.PD.LostPVCGracePeriod)
+		if err == nil {
+			return d
+		}
+	}
+	return defaultPDLostPVCGracePeriod
+}
+
+// PDRecoverLostPVC returns whether to recreate the PD members that lost their data PVC.
+func (tc *TidbCluster) PDRecoverLostPVC() bool {
+	return tc.Spec.PD != nil && tc.Spec.PD.RecoverLostPVC != nil && *tc.Spec.PD.RecoverLostPVC
+}
+
+// PDFailoverPVCPolicy returns what happens to the PVC of a failure PD member.
+func (tc *TidbCluster) PDFailoverPVCPolicy() FailoverPVCPolicy {
+	if tc.Spec.PD != nil && tc.Spec.PD.FailoverPVCPolicy != nil {
+		return *tc.Spec.PD.FailoverPVCPolicy
+	}
+	return FailoverPVCPolicyRecreate
+}
+
+// TiKVFailoverPVCPolicy returns what happens to the PVC of a failure TiKV store.
+func (tc *TidbCluster) TiKVFailoverPVCPolicy() FailoverPVCPolicy {
+	if tc.Spec.TiKV != nil && tc.Spec.TiKV.FailoverPVCPolicy != nil {
+		return *tc.Spec.TiKV.FailoverPVCPolicy
+	}
+	return FailoverPVCPolicyReuse
+}
+
+// TiFlashFailoverPVCPolicy returns what happens to the PVC of a failure TiFlash store.
+func (tc *TidbCluster) TiFlashFailoverPVCPolicy() FailoverPVCPolicy {
+	if tc.Spec.TiFlash != nil && tc.Spec.TiFlash.FailoverPVCPolicy != nil {
+		return *tc.Spec.TiFlash.FailoverPVCPolicy
+	}
+	return FailoverPVCPolicyReuse
+}
+
+// StalePVCPolicy returns what happens to the PVC left by a previous scale-in
+// when PD or TiKV is scaled out again.
+func (tc *TidbCluster) StalePVCPolicy() StalePVCPolicy {
+	if tc.Spec.StalePVCPolicy != nil {
+		return *tc.Spec.StalePVCPolicy
+	}
+	return StalePVCPolicyReuse
+}
+
+func (tc *TidbCluster) TiDBIncompatibleDataPolicy() IncompatibleDataPolicy {
+	if tc.Spec.TiDB != nil && tc.Spec.TiDB.IncompatibleDataPolicy != nil {
+		return *tc.Spec.TiDB.IncompatibleDataPolicy
+	}
+	return IncompatibleDataPolicyReport
+}
+
+// TiDBScaleInDrainTimeout returns how long the scale-in of a TiDB Pod waits for
+// the client connections to be closed, 0 means it does not wait.
+func (tc *TidbCluster) TiDBScaleInDrainTimeout() time.Duration {
+	if tc.Spec.TiDB == nil || tc.Spec.TiDB.ScaleInDrainTimeout == nil {
+		return 0
+	}
+	d, err := time.ParseDuration(*tc.Spec.TiDB.ScaleInDrainTimeout)
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// TiDBCanaryPolicy returns the canary policy of the TiDB upgrade, or nil if
+// there is no canary
+func (tc *TidbCluster) TiDBCanaryPolicy() *TiDBCanaryPolicy {
+	if tc.Spec.TiDB == nil || tc.Spec.TiDB.UpgradePolicy == nil {
+		return nil
+	}
+	return tc.Spec.TiDB.UpgradePolicy.Canary
+}
+
+// TiDBCanaryPauseDuration returns how long the TiDB upgrade is paused after the
+// canary Pod is ready
+func (tc *TidbCluster) TiDBCanaryPauseDuration() time.Duration {
+	if canary := tc.TiDBCanaryPolicy(); canary != nil && canary.PauseDuration != nil {
+		d, err := time.ParseDuration(*canary.PauseDuration)
+		if err == nil {
+			return d
+		}
+	}
+	return defaultTiDBCanaryPauseDuration
+}
+
 func (tc *TidbCluster) TiKVEvictLeaderTimeout() time.Duration {
 	if tc.Spec.TiKV != nil && tc.Spec.TiKV.EvictLeaderTimeout != nil {
 		d, err := time.ParseDuration(*tc.Spec.TiKV.EvictLeaderTimeout)
@@ -150,6 +350,446 @@ func (tc *TidbCluster) TiKVEvictLeaderTimeout() time.Duration {
 	return defaultEvictLeaderTimeout
 }
 
+// TiKVEvictLeaderBackoff returns the min and max wait before checking the
+// leaders being evicted again, 0 means the backoff of the controller is used.
+func (tc *TidbCluster) TiKVEvictLeaderBackoff() (time.Duration, time.Duration) {
+	if tc.Spec.TiKV == nil || tc.Spec.TiKV.EvictLeaderBackoff == nil {
+		return 0, 0
+	}
+	backoff := tc.Spec.TiKV.EvictLeaderBackoff
+	interval, maxInterval := defaultEvictLeaderInterval, defaultEvictLeaderMaxInterval
+	if backoff.Interval != nil {
+		if d, err := time.ParseDuration(*backoff.Interval); err == nil {
+			interval = d
+		}
+	}
+	if backoff.MaxInterval != nil {
+		if d, err := time.ParseDuration(*backoff.MaxInterval); err == nil {
+			maxInterval = d
+		}
+	}
+	if maxInterval < interval {
+		maxInterval = interval
+	}
+	return interval, maxInterval
+}
+
+// TiKVStoreHeartbeatStaleThreshold returns the age of the last store heartbeat
+// above which the StoreHeartbeatStale condition is set, 0 means the check is disabled.
+func (tc *TidbCluster) TiKVStoreHeartbeatStaleThreshold() time.Duration {
+	if tc.Spec.TiKV != nil && tc.Spec.TiKV.StoreHeartbeatStaleThreshold != nil {
+		d, err := time.ParseDuration(*tc.Spec.TiKV.StoreHeartbeatStaleThreshold)
+		if err == nil {
+			return d
+		}
+	}
+	return 0
+}
+
+// TiKVScaleInStoreEmptyTimeout returns how long the scale-in of a TiKV Pod waits
+// for its tombstone store to report no Regions.
+func (tc *TidbCluster) TiKVScaleInStoreEmptyTimeout() time.Duration {
+	if tc.Spec.TiKV != nil && tc.Spec.TiKV.ScaleInStoreEmptyTimeout != nil {
+		d, err := time.ParseDuration(*tc.Spec.TiKV.ScaleInStoreEmptyTimeout)
+		if err == nil {
+			return d
+		}
+	}
+	return defaultTiKVScaleInStoreEmptyTimeout
+}
+
+// TiKVEvictLeaderBeforeScaleIn returns whether the Region leaders are evicted
+// from the store of a TiKV Pod before the store is deleted in the scale-in
+func (tc *TidbCluster) TiKVEvictLeaderBeforeScaleIn() bool {
+	return tc.Spec.TiKV != nil && tc.Spec.TiKV.ScalePolicy != nil && tc.Spec.TiKV.ScalePolicy.EvictLeaderBeforeScaleIn
+}
+
+// TiKVBalanceZones returns whether to keep the TiKV stores balanced across the zones.
+func (tc *TidbCluster) TiKVBalanceZones() bool {
+	return tc.Spec.TiKV != nil && tc.Spec.TiKV.ScalePolicy != nil && tc.Spec.TiKV.ScalePolicy.BalanceZones
+}
+
+// TiKVUpgradeMaxUnavailable returns the max number of TiKV Pods upgraded at the same time
+func (tc *TidbCluster) TiKVUpgradeMaxUnavailable() int32 {
+	if tc.Spec.TiKV != nil && tc.Spec.TiKV.UpgradePolicy != nil && tc.Spec.TiKV.UpgradePolicy.MaxUnavailable != nil {
+		return *tc.Spec.TiKV.UpgradePolicy.MaxUnavailable
+	}
+	return 1
+}
+
+// UpgradeCrashLoopThreshold returns how long an upgraded Pod may stay in
+// CrashLoopBackOff before the upgrade is aborted.
+func (tc *TidbCluster) UpgradeCrashLoopThreshold() time.Duration {
+	if tc.Spec.UpgradeCrashLoopPolicy != nil && tc.Spec.UpgradeCrashLoopPolicy.Threshold != nil {
+		d, err := time.ParseDuration(*tc.Spec.UpgradeCrashLoopPolicy.Threshold)
+		if err == nil {
+			return d
+		}
+	}
+	return defaultUpgradeCrashLoopThreshold
+}
+
+// UpgradeAutoRollbackThreshold returns how long an upgraded Pod may fail the
+// health checks before the upgrade is rolled back, 0 means the upgrade is not
+// rolled back automatically.
+func (tc *TidbCluster) UpgradeAutoRollbackThreshold() time.Duration {
+	if tc.Spec.UpgradePolicy == nil || tc.Spec.UpgradePolicy.AutoRollback == nil {
+		return 0
+	}
+	if threshold := tc.Spec.UpgradePolicy.AutoRollback.Threshold; threshold != nil {
+		d, err := time.ParseDuration(*threshold)
+		if err == nil {
+			return d
+		}
+	}
+	return defaultUpgradeAutoRollbackThreshold
+}
+
+// PVCDeferDeletePeriod returns how long the PVCs marked as defer deleting are
+// kept before they are deleted, 0 means they are deleted at once.
+func (tc *TidbCluster) PVCDeferDeletePeriod() time.Duration {
+	if tc.Spec.PVCDeferDeletePeriod != nil {
+		d, err := time.ParseDuration(*tc.Spec.PVCDeferDeletePeriod)
+		if err == nil {
+			return d
+		}
+	}
+	return 0
+}
+
+// PVCFileSystemResizeTimeout returns how long a PVC may wait for the file
+// system resize before its expansion is considered failed.
+func (tc *TidbCluster) PVCFileSystemResizeTimeout() time.Duration {
+	if tc.Spec.PVCResizeFailurePolicy != nil && tc.Spec.PVCResizeFailurePolicy.FileSystemResizeTimeout != nil {
+		d, err := time.ParseDuration(*tc.Spec.PVCResizeFailurePolicy.FileSystemResizeTimeout)
+		if err == nil {
+			return d
+		}
+	}
+	return defaultPVCFileSystemResizeTimeout
+}
+
+// PodRestartThreshold returns the restart count of a container at which its
+// Pod is considered flapping.
+func (tc *TidbCluster) PodRestartThreshold() int32 {
+	if tc.Spec.PodRestartPolicy != nil && tc.Spec.PodRestartPolicy.Threshold != nil {
+		return *tc.Spec.PodRestartPolicy.Threshold
+	}
+	return defaultPodRestartThreshold
+}
+
+// PodRestartWindow returns how recent the last restart of a container must be
+// for its Pod to be considered flapping.
+func (tc *TidbCluster) PodRestartWindow() time.Duration {
+	if tc.Spec.PodRestartPolicy != nil && tc.Spec.PodRestartPolicy.Window != nil {
+		d, err := time.ParseDuration(*tc.Spec.PodRestartPolicy.Window)
+		if err == nil {
+			return d
+		}
+	}
+	return defaultPodRestartWindow
+}
+
+// FailoverPausedByRestarts returns whether the failover of the component is
+// paused as some of its Pods are flapping.
+func (tc *TidbCluster) FailoverPausedByRestarts(memberType MemberType) bool {
+	return tc.Spec.PodRestartPolicy != nil && tc.Spec.PodRestartPolicy.PauseFailover && len(tc.Status.FlappingPods[memberType]) > 0
+}
+
+// RolloutStallThreshold returns how long a Pod may stay Pending before the
+// rollout of its component is considered stalled.
+func (tc *TidbCluster) RolloutStallThreshold() time.Duration {
+	if tc.Spec.RolloutStallPolicy != nil && tc.Spec.RolloutStallPolicy.Threshold != nil {
+		d, err := time.ParseDuration(*tc.Spec.RolloutStallPolicy.Threshold)
+		if err == nil {
+			return d
+		}
+	}
+	return defaultRolloutStallThreshold
+}
+
+// MaxScaleVelocity returns the max number of members added or removed in the
+// time window, 0 means the scale is not limited.
+func (tc *TidbCluster) MaxScaleVelocity() (int, time.Duration) {
+	if tc.Spec.ScalePolicy == nil || tc.Spec.ScalePolicy.MaxScaleVelocity == nil {
+		return 0, 0
+	}
+	velocity := tc.Spec.ScalePolicy.MaxScaleVelocity
+	window := defaultScaleVelocityWindow
+	if velocity.Window != nil {
+		d, err := time.ParseDuration(*velocity.Window)
+		if err == nil {
+			window = d
+		}
+	}
+	return int(velocity.Members), window
+}
+
+// PodTemplateWebhookTimeout returns the timeout of calling the Pod template webhook
+func (tc *TidbCluster) PodTemplateWebhookTimeout() time.Duration {
+	if tc.Spec.PodTemplateWebhook != nil && tc.Spec.PodTemplateWebhook.TimeoutSeconds != nil {
+		return time.Duration(*tc.Spec.PodTemplateWebhook.TimeoutSeconds) * time.Second
+	}
+	return defaultPodTemplateWebhookTimeout
+}
+
+// PodTemplateWebhookIgnoreFailure returns whether the failures to call the Pod
+// template webhook are ignored
+func (tc *TidbCluster) PodTemplateWebhookIgnoreFailure() bool {
+	webhook := tc.Spec.PodTemplateWebhook
+	return webhook != nil && webhook.FailurePolicy != nil && *webhook.FailurePolicy == PodTemplateWebhookFailurePolicyIgnore
+}
+
+// ScaleHooks returns the scale hooks of the component, nil if there is none.
+func (tc *TidbCluster) ScaleHooks(memberType MemberType) *ScaleHooks {
+	switch memberType {
+	case PDMemberType:
+		if tc.Spec.PD != nil {
+			return tc.Spec.PD.ScaleHooks
+		}
+	case TiKVMemberType:
+		if tc.Spec.TiKV != nil {
+			return tc.Spec.TiKV.ScaleHooks
+		}
+	case TiFlashMemberType:
+		if tc.Spec.TiFlash != nil {
+			return tc.Spec.TiFlash.ScaleHooks
+		}
+	case TiDBMemberType:
+		if tc.Spec.TiDB != nil {
+			return tc.Spec.TiDB.ScaleHooks
+		}
+	}
+	return nil
+}
+
+// UpgradePaused returns whether the rolling upgrade of the component is paused
+func (tc *TidbCluster) UpgradePaused(memberType MemberType) bool {
+	switch memberType {
+	case PDMemberType:
+		return tc.Spec.PD != nil && tc.Spec.PD.UpgradePaused
+	case TiKVMemberType:
+		return tc.Spec.TiKV != nil && tc.Spec.TiKV.UpgradePaused
+	case TiFlashMemberType:
+		return tc.Spec.TiFlash != nil && tc.Spec.TiFlash.UpgradePaused
+	case TiDBMemberType:
+		return tc.Spec.TiDB != nil && tc.Spec.TiDB.UpgradePaused
+	case TiCDCMemberType:
+		return tc.Spec.TiCDC != nil && tc.Spec.TiCDC.UpgradePaused
+	case PumpMemberType:
+		return tc.Spec.Pump != nil && tc.Spec.Pump.UpgradePaused
+	}
+	return false
+}
+
+// TiFlashUpgradeCheckTimeout returns how long an upgraded TiFlash store may
+// fail the checks of spec.tiflash.upgradeCheck before the next Pod is upgraded.
+func (tc *TidbCluster) TiFlashUpgradeCheckTimeout() time.Duration {
+	if tc.Spec.TiFlash != nil && tc.Spec.TiFlash.UpgradeCheck != nil && tc.Spec.TiFlash.UpgradeCheck.Timeout != nil {
+		d, err := time.ParseDuration(*tc.Spec.TiFlash.UpgradeCheck.Timeout)
+		if err == nil {
+			return d
+		}
+	}
+	return defaultTiFlashUpgradeCheckTimeout
+}
+
+// TiCDCGracefulShutdownTimeout returns how long the upgrade of a TiCDC Pod
+// waits for the capture to resign the owner and to be drained.
+func (tc *TidbCluster) TiCDCGracefulShutdownTimeout() time.Duration {
+	if tc.Spec.TiCDC != nil && tc.Spec.TiCDC.GracefulShutdownTimeout != nil {
+		d, err := time.ParseDuration(*tc.Spec.TiCDC.GracefulShutdownTimeout)
+		if err == nil {
+			return d
+		}
+	}
+	return defaultTiCDCGracefulShutdownTimeout
+}
+
+// Timeout returns the timeout of calling the HTTP scale hook
+func (h *HTTPScaleHook) Timeout() time.Duration {
+	if h.TimeoutSeconds != nil {
+		return time.Duration(*h.TimeoutSeconds) * time.Second
+	}
+	return defaultScaleHookTimeout
+}
+
+// UpgradeHooks returns the upgrade hooks of the component
+func (tc *TidbCluster) UpgradeHooks(memberType MemberType) *UpgradeHooks {
+	switch memberType {
+	case PDMemberType:
+		if tc.Spec.PD != nil {
+			return tc.Spec.PD.UpgradeHooks
+		}
+	case TiKVMemberType:
+		if tc.Spec.TiKV != nil {
+			return tc.Spec.TiKV.UpgradeHooks
+		}
+	case TiFlashMemberType:
+		if tc.Spec.TiFlash != nil {
+			return tc.Spec.TiFlash.UpgradeHooks
+		}
+	case TiDBMemberType:
+		if tc.Spec.TiDB != nil {
+			return tc.Spec.TiDB.UpgradeHooks
+		}
+	}
+	return nil
+}
+
+// failover returns the max failover count and the failover spec of the component
+func (tc *TidbCluster) failover(memberType MemberType) (*int32, *FailoverSpec) {
+	switch memberType {
+	case PDMemberType:
+		if tc.Spec.PD != nil {
+			return tc.Spec.PD.MaxFailoverCount, tc.Spec.PD.Failover
+		}
+	case TiKVMemberType:
+		if tc.Spec.TiKV != nil {
+			return tc.Spec.TiKV.MaxFailoverCount, tc.Spec.TiKV.Failover
+		}
+	case TiFlashMemberType:
+		if tc.Spec.TiFlash != nil {
+			return tc.Spec.TiFlash.MaxFailoverCount, tc.Spec.TiFlash.Failover
+		}
+	case TiDBMemberType:
+		if tc.Spec.TiDB != nil {
+			return tc.Spec.TiDB.MaxFailoverCount, tc.Spec.TiDB.Failover
+		}
+	}
+	return nil, nil
+}
+
+// FailoverPeriod returns how long a member of the component must be unhealthy
+// before it is failed over, defaultPeriod is the failover period of the
+// component in the controller manager.
+func (tc *TidbCluster) FailoverPeriod(memberType MemberType, defaultPeriod time.Duration) time.Duration {
+	if _, failover := tc.failover(memberType); failover != nil && failover.DetectionPeriod != nil {
+		d, err := time.ParseDuration(*failover.DetectionPeriod)
+		if err == nil && d > 0 {
+			return d
+		}
+	}
+	return defaultPeriod
+}
+
+// MaxFailoverCount returns the max replicas of the component could be added in
+// failover, `failover.maxFailoverCount` takes precedence over `maxFailoverCount`
+// of the component. nil means neither is set.
+func (tc *TidbCluster) MaxFailoverCount(memberType MemberType) *int32 {
+	maxFailoverCount, failover := tc.failover(memberType)
+	if failover != nil && failover.MaxFailoverCount != nil {
+		return failover.MaxFailoverCount
+	}
+	return maxFailoverCount
+}
+
+// RecoverFailover returns whether the failure members of the component are
+// recovered once all the desired members are healthy again
+func (tc *TidbCluster) RecoverFailover(memberType MemberType) bool {
+	if _, failover := tc.failover(memberType); failover != nil && failover.RecoverByDefault != nil {
+		return *failover.RecoverByDefault
+	}
+	switch memberType {
+	case TiKVMemberType:
+		return tc.Spec.TiKV != nil && tc.Spec.TiKV.RecoverFailover
+	case TiFlashMemberType:
+		return tc.Spec.TiFlash != nil && tc.Spec.TiFlash.RecoverFailover
+	default:
+		return true
+	}
+}
+
+// PDFailoverSnapshot returns the spec of the VolumeSnapshots taken before the
+// PVCs of the failure PD members are deleted, nil means no snapshot is taken
+func (tc *TidbCluster) PDFailoverSnapshot() *PVCSnapshotSpec {
+	spec := tc.Spec.SnapshotBeforeDeletingPVC
+	_, failover := tc.failover(PDMemberType)
+	if failover == nil || failover.SnapshotBeforeDelete == nil {
+		return spec
+	}
+	if !*failover.SnapshotBeforeDelete {
+		return nil
+	}
+	if spec == nil {
+		return &PVCSnapshotSpec{}
+	}
+	return spec
+}
+
+// VolumeSnapshotRetention returns how long the VolumeSnapshots taken before
+// deleting PVCs are kept, false means they are kept until deleted manually
+func (tc *TidbCluster) VolumeSnapshotRetention() (time.Duration, bool) {
+	spec := tc.Spec.SnapshotBeforeDeletingPVC
+	if spec == nil || spec.Retention == nil {
+		return 0, false
+	}
+	d, err := time.ParseDuration(*spec.Retention)
+	if err != nil {
+		return 0, false
+	}
+	return d, true
+}
+
+// TiDBEndpointCheck returns whether a TiDB member unreachable through the
+// Service is failed over even if it is healthy by the health API
+func (tc *TidbCluster) TiDBEndpointCheck() bool {
+	_, failover := tc.failover(TiDBMemberType)
+	return failover != nil && failover.EndpointCheck != nil && *failover.EndpointCheck
+}
+
+// Timeout returns how long the upgrade waits for the Job of the upgrade hook
+func (h *UpgradeHook) Timeout() time.Duration {
+	if h.TimeoutSeconds != nil {
+		return time.Duration(*h.TimeoutSeconds) * time.Second
+	}
+	return defaultUpgradeHookTimeout
+}
+
+// FailureIgnored returns whether the upgrade continues when the upgrade hook fails
+func (h *UpgradeHook) FailureIgnored() bool {
+	return h.FailurePolicy == UpgradeHookFailurePolicyIgnore
+}
+
+// TiKVMaxConcurrentEvictLeaders returns the max number of stores whose region
+// leaders can be evicted at the same time, which defaults to the max number of
+// TiKV Pods upgraded at the same time.
+func (tc *TidbCluster) TiKVMaxConcurrentEvictLeaders() int {
+	if tc.Spec.TiKV != nil && tc.Spec.TiKV.MaxConcurrentEvictLeaders != nil && *tc.Spec.TiKV.MaxConcurrentEvictLeaders > 0 {
+		return int(*tc.Spec.TiKV.MaxConcurrentEvictLeaders)
+	}
+	return int(tc.TiKVUpgradeMaxUnavailable())
+}
+
+// TiDBUpgradeConcurrency returns the max number of TiDB Pods restarted at the
+// same time in a rolling upgrade.
+func (tc *TidbCluster) TiDBUpgradeConcurrency() int {
+	if tc.Spec.TiDB != nil && tc.Spec.TiDB.UpgradeConcurrency != nil && *tc.Spec.TiDB.UpgradeConcurrency > 0 {
+		return int(*tc.Spec.TiDB.UpgradeConcurrency)
+	}
+	return defaultTiDBUpgradeConcurrency
+}
+
+// TiKVSingleStore returns whether the cluster has only one TiKV store.
+// Evicting region leaders before restarting the store is pointless then, as
+// there is no other store to move the leaders to. A cluster whose other
+// stores are down is degraded rather than single-store, and the leaders are
+// still evicted there.
+func (tc *TidbCluster) TiKVSingleStore() bool {
+	return len(tc.Status.TiKV.Stores) == 1
+}
+
+// PDSplitBrain returns whether the PD members are detected to report
+// divergent views of the cluster.
+func (tc *TidbCluster) PDSplitBrain() bool {
+	for _, cond := range tc.Status.Conditions {
+		if cond.Type == TidbClusterPDSplitBrain {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
 // TiFlashImage return the image used by TiFlash.
 //
 // If TiFlash isn't specified, return empty string.
@@ -250,6 +890,23 @@ func (tc *TidbCluster) TiDBImage() string {
 	return image
 }
 
+// TiDBVersion returns the image version used by TiDB.
+//
+// If TiDB isn't specified, return empty string.
+func (tc *TidbCluster) TiDBVersion() string {
+	if tc.Spec.TiDB == nil {
+		return ""
+	}
+
+	image := tc.TiDBImage()
+	colonIdx := strings.LastIndexByte(image, ':')
+	if colonIdx >= 0 {
+		return image[colonIdx+1:]
+	}
+
+	return "latest"
+}
+
 // PumpImage return the image used by Pump.
 //
 // If Pump isn't specified, return nil.
@@ -361,6 +1018,10 @@ func (tc *TidbCluster) getDeleteSlots(component string) (deleteSlots sets.Int32)
 		key = label.AnnTiKVDeleteSlots
 	} else if component == label.TiFlashLabelVal {
 		key = label.AnnTiFlashDeleteSlots
+	} else if component == label.TiCDCLabelVal {
+		key = label.AnnTiCDCDeleteSlots
+	} else if component == label.PumpLabelVal {
+		key = label.AnnPumpDeleteSlots
 	} else {
 		return
 	}
@@ -502,6 +1163,11 @@ func (tc *TidbCluster) TiKVStsActualReplicas() int32 {
 	return stsStatus.Replicas
 }
 
+// TiKVDeleteSlots returns the TiKV ordinals in the delete slots annotation of the TidbCluster
+func (tc *TidbCluster) TiKVDeleteSlots() sets.Int32 {
+	return tc.getDeleteSlots(label.TiKVLabelVal)
+}
+
 func (tc *TidbCluster) TiKVStsDesiredOrdinals(excludeFailover bool) sets.Int32 {
 	if tc.Spec.TiKV == nil {
 		return sets.Int32{}
@@ -779,6 +1445,25 @@ func (tidb *TiDBSpec) GetSlowLogTailerSpec() TiDBSlowLogTailerSpec {
 	return *tidb.SlowLogTailer
 }
 
+// GetAuditLogPlugin returns the audit plugin loaded by TiDB, or "" if the audit log is not managed
+func (tidb *TiDBSpec) GetAuditLogPlugin() string {
+	if tidb.AuditLog == nil {
+		return ""
+	}
+	if tidb.AuditLog.Plugin == "" {
+		return defaultAuditLogPlugin
+	}
+	return tidb.AuditLog.Plugin
+}
+
+// GetAuditLogFile returns the name of the audit log file in the audit log volume
+func (tidb *TiDBSpec) GetAuditLogFile() string {
+	if tidb.AuditLog == nil || tidb.AuditLog.File == "" {
+		return defaultAuditLogFile
+	}
+	return tidb.AuditLog.File
+}
+
 func (tikv *TiKVSpec) ShouldSeparateRocksDBLog() bool {
 	separateRocksDBLog := tikv.SeparateRocksDBLog
 	if separateRocksDBLog == nil {
@@ -839,6 +1524,27 @@ func (tc *TidbCluster) GetInstanceName() string {
 	return tc.Name
 }
 
+// AllowEvenPDReplicas returns whether PD is allowed to be scaled to an even
+// number of replicas, which tolerates no more failures than one replica less
+func (tc *TidbCluster) AllowEvenPDReplicas() bool {
+	_, ok := tc.Annotations[label.AnnAllowEvenPDReplicas]
+	return ok
+}
+
+// ForceVersionSkip returns whether the upgrade of the cluster may skip more
+// minor versions than allowed by the admission webhook
+func (tc *TidbCluster) ForceVersionSkip() bool {
+	_, ok := tc.Annotations[label.AnnForceVersionSkip]
+	return ok
+}
+
+// ScaleDryRun returns whether the scales of the components are only planned
+// and reported, the StatefulSets and the members are not changed
+func (tc *TidbCluster) ScaleDryRun() bool {
+	_, ok := tc.Annotations[label.AnnScaleDryRun]
+	return ok
+}
+
 func (tc *TidbCluster) SkipTLSWhenConnectTiDB() bool {
 	_, ok := tc.Annotations[label.AnnSkipTLSWhenConnectTiDB]
 	return ok
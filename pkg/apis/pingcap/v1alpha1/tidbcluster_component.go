@@ -37,6 +37,7 @@ type ComponentAccessor interface {
 	Annotations() map[string]string
 	Tolerations() []corev1.Toleration
 	PodSecurityContext() *corev1.PodSecurityContext
+	SecurityContext() *corev1.SecurityContext
 	SchedulerName() string
 	DnsPolicy() corev1.DNSPolicy
 	ConfigUpdateStrategy() ConfigUpdateStrategy
@@ -49,6 +50,7 @@ type ComponentAccessor interface {
 	TerminationGracePeriodSeconds() *int64
 	StatefulSetUpdateStrategy() apps.StatefulSetUpdateStrategyType
 	TopologySpreadConstraints() []corev1.TopologySpreadConstraint
+	FailoverTopologySpreadConstraints() []corev1.TopologySpreadConstraint
 }
 
 // Component defines component identity of all components
@@ -86,6 +88,7 @@ type componentAccessorImpl struct {
 	statefulSetUpdateStrategy apps.StatefulSetUpdateStrategyType
 	podSecurityContext        *corev1.PodSecurityContext
 	topologySpreadConstraints []TopologySpreadConstraint
+	tikvTiFlashAntiAffinity   *CrossComponentAntiAffinity
 
 	// ComponentSpec is the Component Spec
 	ComponentSpec *ComponentSpec
@@ -108,6 +111,14 @@ func (a *componentAccessorImpl) PodSecurityContext() *corev1.PodSecurityContext
 	return a.ComponentSpec.PodSecurityContext
 }
 
+// SecurityContext returns the SecurityContext of the main container of the component
+func (a *componentAccessorImpl) SecurityContext() *corev1.SecurityContext {
+	if a.ComponentSpec == nil {
+		return nil
+	}
+	return a.ComponentSpec.SecurityContext
+}
+
 func (a *componentAccessorImpl) ImagePullPolicy() corev1.PullPolicy {
 	if a.ComponentSpec == nil || a.ComponentSpec.ImagePullPolicy == nil {
 		return a.imagePullPolicy
@@ -133,10 +144,60 @@ func (a *componentAccessorImpl) HostNetwork() bool {
 }
 
 func (a *componentAccessorImpl) Affinity() *corev1.Affinity {
-	if a.ComponentSpec == nil || a.ComponentSpec.Affinity == nil {
-		return a.affinity
+	affinity := a.affinity
+	if a.ComponentSpec != nil && a.ComponentSpec.Affinity != nil {
+		affinity = a.ComponentSpec.Affinity
+	}
+
+	if a.tikvTiFlashAntiAffinity == nil {
+		return affinity
+	}
+	switch a.component {
+	case ComponentTiKV:
+		return a.appendCrossComponentAntiAffinity(affinity, a.tikvTiFlashAntiAffinity, ComponentTiFlash)
+	case ComponentTiFlash:
+		return a.appendCrossComponentAntiAffinity(affinity, a.tikvTiFlashAntiAffinity, ComponentTiKV)
+	}
+	return affinity
+}
+
+// appendCrossComponentAntiAffinity returns a copy of the affinity with the pod anti-affinity
+// against the Pods of the peer component appended
+func (a *componentAccessorImpl) appendCrossComponentAntiAffinity(affinity *corev1.Affinity, anti *CrossComponentAntiAffinity, peer Component) *corev1.Affinity {
+	if affinity == nil {
+		affinity = &corev1.Affinity{}
+	} else {
+		affinity = affinity.DeepCopy()
+	}
+	if affinity.PodAntiAffinity == nil {
+		affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+	}
+
+	topologyKey := anti.TopologyKey
+	if topologyKey == "" {
+		topologyKey = corev1.LabelHostname
+	}
+	l := label.New()
+	l[label.ComponentLabelKey] = getComponentLabelValue(peer)
+	l[label.InstanceLabelKey] = a.name
+	term := corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string(l),
+		},
+		TopologyKey: topologyKey,
 	}
-	return a.ComponentSpec.Affinity
+
+	if anti.Type == AntiAffinityTypeRequired {
+		affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(
+			affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, term)
+	} else {
+		affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+			affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, corev1.WeightedPodAffinityTerm{
+				Weight:          100,
+				PodAffinityTerm: term,
+			})
+	}
+	return affinity
 }
 
 func (a *componentAccessorImpl) PriorityClassName() *string {
@@ -293,7 +354,19 @@ func (a *componentAccessorImpl) TopologySpreadConstraints() []corev1.TopologySpr
 	if a.ComponentSpec != nil && len(a.ComponentSpec.TopologySpreadConstraints) > 0 {
 		tscs = a.ComponentSpec.TopologySpreadConstraints
 	}
+	return a.buildTopologySpreadConstraints(tscs)
+}
+
+// FailoverTopologySpreadConstraints returns the topology spread constraints of the Pods
+// provisioned by failover, which defaults to the TopologySpreadConstraints of the component
+func (a *componentAccessorImpl) FailoverTopologySpreadConstraints() []corev1.TopologySpreadConstraint {
+	if a.ComponentSpec != nil && len(a.ComponentSpec.FailoverTopologySpreadConstraints) > 0 {
+		return a.buildTopologySpreadConstraints(a.ComponentSpec.FailoverTopologySpreadConstraints)
+	}
+	return a.TopologySpreadConstraints()
+}
 
+func (a *componentAccessorImpl) buildTopologySpreadConstraints(tscs []TopologySpreadConstraint) []corev1.TopologySpreadConstraint {
 	if len(tscs) == 0 {
 		return nil
 	}
@@ -369,6 +442,7 @@ func buildTidbClusterComponentAccessor(c Component, tc *TidbCluster, componentSp
 		statefulSetUpdateStrategy: spec.StatefulSetUpdateStrategy,
 		podSecurityContext:        spec.PodSecurityContext,
 		topologySpreadConstraints: spec.TopologySpreadConstraints,
+		tikvTiFlashAntiAffinity:   spec.TiKVTiFlashAntiAffinity,
 
 		ComponentSpec: componentSpec,
 	}
@@ -17,6 +17,7 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -407,6 +408,69 @@ func TestComponentAccessor(t *testing.T) {
 	}
 }
 
+func TestTiKVTiFlashAntiAffinity(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tikvTerm := corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				label.NameLabelKey:      "tidb-cluster",
+				label.ManagedByLabelKey: label.TiDBOperator,
+				label.ComponentLabelKey: label.TiKVLabelVal,
+				label.InstanceLabelKey:  "test",
+			},
+		},
+		TopologyKey: corev1.LabelHostname,
+	}
+	tiflashTerm := *tikvTerm.DeepCopy()
+	tiflashTerm.LabelSelector.MatchLabels[label.ComponentLabelKey] = label.TiFlashLabelVal
+	userAffinity := &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
+				TopologyKey: "rack",
+			}},
+		},
+	}
+
+	tc := &TidbCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+		},
+		Spec: TidbClusterSpec{
+			TiKV:    &TiKVSpec{},
+			TiFlash: &TiFlashSpec{},
+			TiDB:    &TiDBSpec{},
+		},
+	}
+
+	// disabled by default
+	g.Expect(tc.BaseTiKVSpec().Affinity()).To(BeNil())
+	g.Expect(tc.BaseTiFlashSpec().Affinity()).To(BeNil())
+
+	// preferred by default
+	tc.Spec.TiKVTiFlashAntiAffinity = &CrossComponentAntiAffinity{}
+	g.Expect(tc.BaseTiKVSpec().Affinity().PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(Equal(
+		[]corev1.WeightedPodAffinityTerm{{Weight: 100, PodAffinityTerm: tiflashTerm}}))
+	g.Expect(tc.BaseTiFlashSpec().Affinity().PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(Equal(
+		[]corev1.WeightedPodAffinityTerm{{Weight: 100, PodAffinityTerm: tikvTerm}}))
+	g.Expect(tc.BaseTiDBSpec().Affinity()).To(BeNil())
+
+	// required with custom topology key, merged with user-specified affinity
+	tc.Spec.TiKVTiFlashAntiAffinity = &CrossComponentAntiAffinity{
+		Type:        AntiAffinityTypeRequired,
+		TopologyKey: "zone",
+	}
+	tc.Spec.TiKV.Affinity = userAffinity
+	zoneTerm := *tiflashTerm.DeepCopy()
+	zoneTerm.TopologyKey = "zone"
+	affinity := tc.BaseTiKVSpec().Affinity()
+	g.Expect(affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution).To(Equal(
+		[]corev1.PodAffinityTerm{{TopologyKey: "rack"}, zoneTerm}))
+	g.Expect(affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(BeEmpty())
+	// the user-specified affinity is not modified
+	g.Expect(userAffinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution).To(HaveLen(1))
+}
+
 func TestHelperImage(t *testing.T) {
 	g := NewGomegaWithT(t)
 
@@ -568,6 +632,61 @@ func TestPDVersion(t *testing.T) {
 	}
 }
 
+func TestRecoverFailover(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbCluster()
+	tc.Spec.TiFlash = &TiFlashSpec{}
+	g.Expect(tc.RecoverFailover(PDMemberType)).To(BeTrue())
+	g.Expect(tc.RecoverFailover(TiDBMemberType)).To(BeTrue())
+	g.Expect(tc.RecoverFailover(TiKVMemberType)).To(BeFalse())
+	g.Expect(tc.RecoverFailover(TiFlashMemberType)).To(BeFalse())
+
+	tc.Spec.TiKV.RecoverFailover = true
+	g.Expect(tc.RecoverFailover(TiKVMemberType)).To(BeTrue())
+
+	tc.Spec.PD.Failover = &FailoverSpec{RecoverByDefault: pointer.BoolPtr(false)}
+	tc.Spec.TiKV.Failover = &FailoverSpec{RecoverByDefault: pointer.BoolPtr(false)}
+	tc.Spec.TiFlash.Failover = &FailoverSpec{RecoverByDefault: pointer.BoolPtr(true)}
+	g.Expect(tc.RecoverFailover(PDMemberType)).To(BeFalse())
+	g.Expect(tc.RecoverFailover(TiKVMemberType)).To(BeFalse())
+	g.Expect(tc.RecoverFailover(TiFlashMemberType)).To(BeTrue())
+}
+
+func TestPDFailoverSnapshot(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbCluster()
+	g.Expect(tc.PDFailoverSnapshot()).To(BeNil())
+
+	tc.Spec.PD.Failover = &FailoverSpec{SnapshotBeforeDelete: pointer.BoolPtr(true)}
+	g.Expect(tc.PDFailoverSnapshot()).To(Equal(&PVCSnapshotSpec{}))
+
+	tc.Spec.SnapshotBeforeDeletingPVC = &PVCSnapshotSpec{VolumeSnapshotClassName: pointer.StringPtr("csi-snapclass")}
+	g.Expect(tc.PDFailoverSnapshot()).To(Equal(tc.Spec.SnapshotBeforeDeletingPVC))
+
+	tc.Spec.PD.Failover = nil
+	g.Expect(tc.PDFailoverSnapshot()).To(Equal(tc.Spec.SnapshotBeforeDeletingPVC))
+
+	tc.Spec.PD.Failover = &FailoverSpec{SnapshotBeforeDelete: pointer.BoolPtr(false)}
+	g.Expect(tc.PDFailoverSnapshot()).To(BeNil())
+}
+
+func TestTiKVSingleStore(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbCluster()
+	tc.Status.TiKV.Stores = map[string]TiKVStore{
+		"1": {ID: "1", State: TiKVStateUp},
+	}
+	g.Expect(tc.TiKVSingleStore()).To(BeTrue())
+
+	// the other stores are down, the cluster is degraded rather than single-store
+	tc.Status.TiKV.Stores["2"] = TiKVStore{ID: "2", State: TiKVStateDown}
+	tc.Status.TiKV.Stores["3"] = TiKVStore{ID: "3", State: TiKVStateDown}
+	g.Expect(tc.TiKVSingleStore()).To(BeFalse())
+}
+
 func newTidbCluster() *TidbCluster {
 	return &TidbCluster{
 		TypeMeta: metav1.TypeMeta{
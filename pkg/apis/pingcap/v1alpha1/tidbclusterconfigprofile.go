@@ -0,0 +1,75 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var systemVariableNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_]{1,64}$`)
+
+// ValidateSystemVariable returns an error if the name or the value is not
+// valid for a TiDB global system variable
+func ValidateSystemVariable(name, value string) error {
+	if !systemVariableNameRegexp.MatchString(name) {
+		return fmt.Errorf("system variable %s must consist of at most 64 letters, digits or underscores", name)
+	}
+	if strings.ContainsAny(value, "'\\\n") {
+		return fmt.Errorf("system variable %s must not contain quotes, backslashes or newlines", name)
+	}
+	return nil
+}
+
+// MergeSystemVariables returns the system variables of the profile overridden
+// by the given ones, keyed by the variable name in lower case. The profile may
+// be nil. It returns an error if a locked system variable of the profile is
+// overridden with a different value.
+func MergeSystemVariables(profile *TidbClusterConfigProfile, overrides map[string]string) (map[string]string, error) {
+	variables := map[string]string{}
+	locked := map[string]bool{}
+	if profile != nil {
+		for name, value := range profile.Spec.SystemVariables {
+			if err := ValidateSystemVariable(name, value); err != nil {
+				return nil, fmt.Errorf("config profile %s: %v", profile.Name, err)
+			}
+			variables[strings.ToLower(name)] = value
+		}
+		for _, name := range profile.Spec.LockedSystemVariables {
+			locked[strings.ToLower(name)] = true
+		}
+	}
+
+	var conflicts []string
+	for name, value := range overrides {
+		if err := ValidateSystemVariable(name, value); err != nil {
+			return nil, err
+		}
+		key := strings.ToLower(name)
+		if locked[key] {
+			if current, ok := variables[key]; !ok || !strings.EqualFold(current, value) {
+				conflicts = append(conflicts, name)
+			}
+			continue
+		}
+		variables[key] = value
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return nil, fmt.Errorf("system variables %s are locked by config profile %s", strings.Join(conflicts, ", "), profile.Name)
+	}
+	return variables, nil
+}
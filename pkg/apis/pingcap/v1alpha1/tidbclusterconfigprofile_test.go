@@ -0,0 +1,80 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMergeSystemVariables(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	profile := &TidbClusterConfigProfile{
+		ObjectMeta: metav1.ObjectMeta{Name: "baseline"},
+		Spec: TidbClusterConfigProfileSpec{
+			SystemVariables: map[string]string{
+				"tidb_mem_quota_query":     "1073741824",
+				"TiDB_Enable_Async_Commit": "ON",
+				"max_connections":          "1000",
+			},
+			LockedSystemVariables: []string{"max_connections"},
+		},
+	}
+
+	// the profile alone
+	variables, err := MergeSystemVariables(profile, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(variables).To(Equal(map[string]string{
+		"tidb_mem_quota_query":     "1073741824",
+		"tidb_enable_async_commit": "ON",
+		"max_connections":          "1000",
+	}))
+
+	// the overrides alone
+	variables, err = MergeSystemVariables(nil, map[string]string{"Max_Connections": "10"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(variables).To(Equal(map[string]string{"max_connections": "10"}))
+
+	// the overrides take precedence over the profile regardless of the case of the names
+	variables, err = MergeSystemVariables(profile, map[string]string{
+		"tidb_enable_async_commit": "OFF",
+		"tidb_txn_mode":            "pessimistic",
+		"max_connections":          "1000",
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(variables).To(Equal(map[string]string{
+		"tidb_mem_quota_query":     "1073741824",
+		"tidb_enable_async_commit": "OFF",
+		"tidb_txn_mode":            "pessimistic",
+		"max_connections":          "1000",
+	}))
+
+	// a locked system variable can not be overridden with a different value
+	_, err = MergeSystemVariables(profile, map[string]string{"MAX_CONNECTIONS": "10", "tidb_txn_mode": "optimistic"})
+	g.Expect(err).To(MatchError("system variables MAX_CONNECTIONS are locked by config profile baseline"))
+
+	profile.Spec.LockedSystemVariables = append(profile.Spec.LockedSystemVariables, "tidb_txn_mode")
+	_, err = MergeSystemVariables(profile, map[string]string{"tidb_txn_mode": "optimistic"})
+	g.Expect(err).To(MatchError("system variables tidb_txn_mode are locked by config profile baseline"))
+
+	// invalid system variables are rejected
+	_, err = MergeSystemVariables(profile, map[string]string{"tidb_txn_mode'": "optimistic"})
+	g.Expect(err).To(HaveOccurred())
+	profile.Spec.SystemVariables["sql_mode"] = "'"
+	_, err = MergeSystemVariables(profile, nil)
+	g.Expect(err).To(HaveOccurred())
+}
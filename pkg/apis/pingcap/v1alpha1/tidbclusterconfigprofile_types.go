@@ -0,0 +1,60 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// +k8s:openapi-gen=true
+// TidbClusterConfigProfile is a reusable baseline of TiDB system variables
+// applied to the TidbClusters in the same namespace referencing it
+type TidbClusterConfigProfile struct {
+	metav1.TypeMeta `json:",inline"`
+	// +k8s:openapi-gen=false
+	metav1.ObjectMeta `json:"metadata"`
+
+	// Spec defines the baseline of the TidbClusterConfigProfile
+	Spec TidbClusterConfigProfileSpec `json:"spec"`
+}
+
+// +k8s:openapi-gen=true
+// TidbClusterConfigProfileSpec is the baseline of TiDB system variables
+type TidbClusterConfigProfileSpec struct {
+	// SystemVariables are the TiDB global system variables applied to the
+	// clusters referencing the profile, keyed by the variable name
+	// +optional
+	SystemVariables map[string]string `json:"systemVariables,omitempty"`
+
+	// LockedSystemVariables are the system variables of the profile that the
+	// clusters referencing it must not override with a different value
+	// +optional
+	LockedSystemVariables []string `json:"lockedSystemVariables,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// +k8s:openapi-gen=true
+// TidbClusterConfigProfileList is TidbClusterConfigProfile list
+type TidbClusterConfigProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +k8s:openapi-gen=false
+	metav1.ListMeta `json:"metadata"`
+
+	Items []TidbClusterConfigProfile `json:"items"`
+}
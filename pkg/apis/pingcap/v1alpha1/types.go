@@ -17,6 +17,7 @@ import (
 	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
@@ -26,6 +27,9 @@ import (
 const (
 	// TiKVStateUp represents status of Up of TiKV
 	TiKVStateUp string = "Up"
+	// TiKVStateDisconnected represents status of Disconnected of TiKV, which
+	// means PD has lost the heartbeats of the store for a short while
+	TiKVStateDisconnected string = "Disconnected"
 	// TiKVStateDown represents status of Down of TiKV
 	TiKVStateDown string = "Down"
 	// TiKVStateOffline represents status of Offline of TiKV
@@ -94,6 +98,48 @@ const (
 	// ConfigUpdateStrategyRollingUpdate generate different configmap on configuration update and
 	// try to rolling-update the pod controller (e.g. statefulset) to apply updates.
 	ConfigUpdateStrategyRollingUpdate ConfigUpdateStrategy = "RollingUpdate"
+	// ConfigUpdateStrategyInPlacePreferred applies the updates online and updates the configmap
+	// without changing the name if all the changed items support online change, otherwise it
+	// falls back to ConfigUpdateStrategyRollingUpdate. Only PD, TiKV and TiDB support online change.
+	ConfigUpdateStrategyInPlacePreferred ConfigUpdateStrategy = "InPlacePreferred"
+)
+
+// FailoverPVCPolicy represents what happens to the PVC of a failure member
+type FailoverPVCPolicy string
+
+const (
+	// FailoverPVCPolicyReuse keeps the PVC of the failure member for its recreated Pod
+	FailoverPVCPolicyReuse FailoverPVCPolicy = "Reuse"
+	// FailoverPVCPolicyRecreate deletes the PVC of the failure member, so that
+	// its replacement starts with an empty volume
+	FailoverPVCPolicyRecreate FailoverPVCPolicy = "Recreate"
+)
+
+// StalePVCPolicy represents what happens to the PVC left by a previous
+// scale-in when the ordinal is scaled out again
+type StalePVCPolicy string
+
+const (
+	// StalePVCPolicyReuse deletes the PVC as usual before the scale-out
+	StalePVCPolicyReuse StalePVCPolicy = "Reuse"
+	// StalePVCPolicyBlock holds the scale-out until the PVC is deleted
+	StalePVCPolicyBlock StalePVCPolicy = "Block"
+	// StalePVCPolicyWipe empties the volume of the PVC by a cleanup Job
+	// and keeps the PVC for the new member
+	StalePVCPolicyWipe StalePVCPolicy = "Wipe"
+)
+
+// IncompatibleDataPolicy represents what happens when TiDB fails to start as
+// the data is incompatible with its version
+type IncompatibleDataPolicy string
+
+const (
+	// IncompatibleDataPolicyReport only reports the failure in the
+	// TiDBDataIncompatible condition and an Event
+	IncompatibleDataPolicyReport IncompatibleDataPolicy = "Report"
+	// IncompatibleDataPolicyRollback also aborts the ongoing upgrade of TiDB
+	// and rolls the failed Pod back to the previous revision
+	IncompatibleDataPolicyRollback IncompatibleDataPolicy = "Rollback"
 )
 
 // +genclient
@@ -174,6 +220,7 @@ type TidbClusterSpec struct {
 	// TODO: remove optional after defaulting logic introduced
 
 	// SchedulerName of TiDB cluster Pods
+	// HA scheduling is only enforced for Pods scheduled by tidb-scheduler
 	// +kubebuilder:default=tidb-scheduler
 	SchedulerName string `json:"schedulerName,omitempty"`
 
@@ -194,7 +241,9 @@ type TidbClusterSpec struct {
 	// cluster component is needed to reload the configuration change.
 	// UpdateStrategyRollingUpdate will create a new ConfigMap with the new configuration and rolling-update the
 	// related components to use the new ConfigMap, that is, the new configuration will be applied automatically.
-	// +kubebuilder:validation:Enum=InPlace,RollingUpdate
+	// UpdateStrategyInPlacePreferred will apply the configuration change of PD, TiKV and TiDB online without
+	// rolling-update if all the changed items support online change, otherwise it works as UpdateStrategyRollingUpdate.
+	// +kubebuilder:validation:Enum=InPlace,RollingUpdate,InPlacePreferred
 	// +kubebuilder:default=InPlacne
 	ConfigUpdateStrategy ConfigUpdateStrategy `json:"configUpdateStrategy,omitempty"`
 
@@ -285,6 +334,489 @@ type TidbClusterSpec struct {
 	// +listType=map
 	// +listMapKey=topologyKey
 	TopologySpreadConstraints []TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// TiKVTiFlashAntiAffinity generates pod anti-affinity between TiKV and TiFlash,
+	// so that TiKV Pods prefer not to be colocated with TiFlash Pods and vice versa,
+	// as they compete for IO on the same node.
+	// Optional: Defaults to nil (no anti-affinity is generated)
+	// +optional
+	TiKVTiFlashAntiAffinity *CrossComponentAntiAffinity `json:"tikvTiFlashAntiAffinity,omitempty"`
+
+	// SnapshotBeforeDeletingPVC takes a VolumeSnapshot of each PVC the operator
+	// is about to delete, e.g. in PD failover, and deletes the PVC only after
+	// the snapshot is cut. It is ignored if the Kubernetes cluster does not
+	// serve the VolumeSnapshot API.
+	// Optional: Defaults to nil, which means no snapshot is taken
+	// +optional
+	SnapshotBeforeDeletingPVC *PVCSnapshotSpec `json:"snapshotBeforeDeletingPVC,omitempty"`
+
+	// StalePVCPolicy decides what happens when PD or TiKV is scaled out to an
+	// ordinal whose PVC is marked as defer deleting by a previous scale-in, so
+	// its volume may still hold the data of the removed member. Reuse deletes
+	// the PVC as usual, without checking the data left on the volume. Block
+	// holds the scale-out and reports the PVC in the StalePVC condition until
+	// it is deleted. Wipe empties the volume by a cleanup Job and keeps the PVC
+	// for the new member. Optional: Defaults to Reuse
+	// +optional
+	StalePVCPolicy *StalePVCPolicy `json:"stalePVCPolicy,omitempty"`
+
+	// UpgradeCrashLoopPolicy aborts the upgrade of a component if an upgraded
+	// Pod stays in CrashLoopBackOff, e.g. due to a bad config or image, instead
+	// of waiting for it to be ready forever. It applies to PD, TiKV and TiDB.
+	// Optional: Defaults to nil, which means the upgrade waits for the Pod
+	// +optional
+	UpgradeCrashLoopPolicy *UpgradeCrashLoopPolicy `json:"upgradeCrashLoopPolicy,omitempty"`
+
+	// PodTemplateWebhook is called with the Pod template the operator generates
+	// for each component before the StatefulSet is created or updated, and may
+	// add containers, init containers, volumes, labels and annotations to it.
+	// The other mutations are dropped, so the operator still owns the rest of
+	// the Pod template. The webhook must be deterministic, otherwise the
+	// StatefulSets are updated in each round.
+	// Optional: Defaults to nil
+	// +optional
+	PodTemplateWebhook *PodTemplateWebhook `json:"podTemplateWebhook,omitempty"`
+
+	// PVCResizeFailurePolicy determines when the expansion of a PVC is
+	// considered failed, e.g. when its StorageClass does not support online
+	// expansion. The failure is reported in the PVCResizeFailed condition.
+	// Optional: Defaults to nil
+	// +optional
+	PVCResizeFailurePolicy *PVCResizeFailurePolicy `json:"pvcResizeFailurePolicy,omitempty"`
+
+	// PVCDeferDeletePeriod is how long the PVCs marked as defer deleting on
+	// scale-in are kept before they are deleted by the PV reclaim, giving a
+	// window to recover the data of a mistaken scale-in, e.g. 24h.
+	// Optional: Defaults to nil, which means the PVCs are deleted as soon as
+	// their Pods are gone
+	// +optional
+	PVCDeferDeletePeriod *string `json:"pvcDeferDeletePeriod,omitempty"`
+
+	// PodRestartPolicy watches the restart counts of the Pods of PD, TiKV, TiDB
+	// and TiFlash, and reports the Pods restarted by the kubelet too often, e.g.
+	// due to a flapping liveness probe, in the PodRestartFlapping condition.
+	// Optional: Defaults to nil, which means the restarts are not watched
+	// +optional
+	PodRestartPolicy *PodRestartPolicy `json:"podRestartPolicy,omitempty"`
+
+	// RolloutStallPolicy determines when a rollout of PD, TiKV, TiDB or TiFlash
+	// is considered stalled, i.e. one of its Pods stays Pending, e.g. as it
+	// can not be scheduled. The stalled Pods are reported in the RolloutStalled
+	// condition with the reason taken from their events.
+	// Optional: Defaults to nil, which means the default threshold is used
+	// +optional
+	RolloutStallPolicy *RolloutStallPolicy `json:"rolloutStallPolicy,omitempty"`
+
+	// ScalePolicy is the policy of the scale of the components
+	// Optional: Defaults to nil
+	// +optional
+	ScalePolicy *ScalePolicy `json:"scalePolicy,omitempty"`
+
+	// MaintenanceWindow limits when the upgrades and the scales of the
+	// components may go on. Outside the window, the upgrade of the next Pod
+	// and the scales are queued and reported in the WaitingForMaintenanceWindow
+	// condition, the Pod being upgraded is not interrupted.
+	// Optional: Defaults to nil, which means the operations may start at any time
+	// +optional
+	MaintenanceWindow *MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+
+	// UpgradePolicy is the policy of the rolling upgrades of the components
+	// Optional: Defaults to nil
+	// +optional
+	UpgradePolicy *UpgradePolicy `json:"upgradePolicy,omitempty"`
+}
+
+// UpgradePolicy is the policy of the rolling upgrades of the components
+// +k8s:openapi-gen=true
+type UpgradePolicy struct {
+	// PreChecks are the checks of the cluster health reported by PD before a
+	// rolling upgrade of PD, TiKV, TiFlash, TiDB or TiCDC starts. If any check
+	// fails, the upgrade waits and the UpgradeBlocked condition is set. The
+	// checks are not repeated for the following Pods of a started upgrade.
+	// Optional: Defaults to nil, which means no check
+	// +optional
+	PreChecks *UpgradePreChecks `json:"preChecks,omitempty"`
+
+	// AutoRollback rolls the upgrade of PD, TiKV or TiDB back to the previous
+	// revision if an upgraded Pod fails the health checks for longer than the
+	// threshold. The failing Pod is rolled back first, then the other upgraded
+	// Pods one by one, each after the previous one is ready. The upgrade is
+	// aborted until the spec is changed.
+	// Optional: Defaults to nil, which means the upgrade is not rolled back
+	// +optional
+	AutoRollback *UpgradeAutoRollback `json:"autoRollback,omitempty"`
+}
+
+// UpgradeAutoRollback is when an upgrade is rolled back automatically
+// +k8s:openapi-gen=true
+type UpgradeAutoRollback struct {
+	// Threshold is how long an upgraded Pod may fail the health checks before
+	// the upgrade is rolled back, in the format of Go Duration.
+	// Defaults to 10m
+	// +optional
+	Threshold *string `json:"threshold,omitempty"`
+}
+
+// UpgradePreChecks are the thresholds of the checks before starting a rolling upgrade
+// +k8s:openapi-gen=true
+type UpgradePreChecks struct {
+	// MaxDownPeerRegions is the max number of Regions with down peers
+	// Defaults to 0
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxDownPeerRegions *int32 `json:"maxDownPeerRegions,omitempty"`
+
+	// MaxPendingPeerRegions is the max number of Regions with pending peers
+	// Defaults to 0
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxPendingPeerRegions *int32 `json:"maxPendingPeerRegions,omitempty"`
+
+	// MaxUnhealthyStores is the max number of TiKV and TiFlash stores that are
+	// not Up, and of PD members that are not healthy
+	// Defaults to 0
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxUnhealthyStores *int32 `json:"maxUnhealthyStores,omitempty"`
+}
+
+// MaintenanceWindow is the recurring time window in which the disruptive
+// operations may start
+// +k8s:openapi-gen=true
+type MaintenanceWindow struct {
+	// Schedule is when the window opens, in the format of the standard
+	// 5-field Cron, e.g. "0 2 * * 6" for 02:00 every Saturday, in UTC
+	Schedule string `json:"schedule"`
+
+	// Duration is how long the window stays open, in the format of Go Duration,
+	// e.g. "4h"
+	Duration string `json:"duration"`
+}
+
+// RolloutStallPolicy is how the stalled rollouts are detected
+// +k8s:openapi-gen=true
+type RolloutStallPolicy struct {
+	// Threshold is how long a Pod may stay Pending before the rollout is
+	// considered stalled, in the format of Go Duration.
+	// Defaults to 10m
+	// +optional
+	Threshold *string `json:"threshold,omitempty"`
+}
+
+// ScalePolicy is the policy of the scale of the components
+// +k8s:openapi-gen=true
+type ScalePolicy struct {
+	// MaxScaleVelocity limits how many members of PD, TiKV, TiDB, TiFlash,
+	// TiCDC and Pump may be added or removed in a time window, so that runaway
+	// automation or a flapping autoscaler can't change the cluster too fast. A
+	// scale beyond the limit waits until the window allows it, the scale of a
+	// member already begun is not blocked.
+	// Optional: Defaults to nil, which means the scale is not limited
+	// +optional
+	MaxScaleVelocity *ScaleVelocity `json:"maxScaleVelocity,omitempty"`
+}
+
+// ScaleVelocity is the max number of members changed in a time window
+// +k8s:openapi-gen=true
+type ScaleVelocity struct {
+	// Members is the max number of members added or removed in the window,
+	// the members of all the components are counted together
+	// +kubebuilder:validation:Minimum=1
+	Members int32 `json:"members"`
+
+	// Window is the time window, in the format of Go Duration.
+	// Defaults to 1h
+	// +optional
+	Window *string `json:"window,omitempty"`
+}
+
+// PodRestartPolicy is how the Pods restarted too often are handled
+// +k8s:openapi-gen=true
+type PodRestartPolicy struct {
+	// Threshold is the restart count of a container at which its Pod is
+	// considered flapping.
+	// Defaults to 5
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Threshold *int32 `json:"threshold,omitempty"`
+
+	// Window is how recent the last restart of the container must be for its
+	// Pod to be considered flapping, in the format of Go Duration. A Pod stops
+	// flapping once it is not restarted for the window.
+	// Defaults to 10m
+	// +optional
+	Window *string `json:"window,omitempty"`
+
+	// PauseFailover pauses the failover of a component while some of its Pods
+	// are flapping, so the failover does not compound the churn.
+	// +optional
+	PauseFailover bool `json:"pauseFailover,omitempty"`
+}
+
+// PVCResizeFailurePolicy is when the expansion of the PVCs is considered failed
+// +k8s:openapi-gen=true
+type PVCResizeFailurePolicy struct {
+	// FileSystemResizeTimeout is how long a PVC may wait for the file system
+	// resize, i.e. keep the FileSystemResizePending condition, before the
+	// expansion is considered failed, in the format of Go Duration.
+	// Defaults to 10m
+	// +optional
+	FileSystemResizeTimeout *string `json:"fileSystemResizeTimeout,omitempty"`
+}
+
+// PodTemplateWebhook is the webhook to mutate the Pod templates of the components
+// +k8s:openapi-gen=true
+type PodTemplateWebhook struct {
+	// URL is the URL the operator POSTs a PodTemplateReview to, the webhook
+	// responds with the PodTemplateReview carrying the mutated Pod template
+	URL string `json:"url"`
+
+	// CABundle is the PEM encoded CA bundle to verify the certificate of the
+	// webhook, the system trust roots are used if it is not set
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
+
+	// TimeoutSeconds is the timeout of calling the webhook.
+	// Defaults to 10
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+
+	// FailurePolicy decides what happens when the webhook can not be called.
+	// Fail fails the sync of the component until the webhook succeeds. Ignore
+	// records a Warning Event and uses the Pod template without the mutations,
+	// which rolls the Pods without them if the mutations were applied before.
+	// Optional: Defaults to Fail
+	// +optional
+	FailurePolicy *PodTemplateWebhookFailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+// PodTemplateWebhookFailurePolicy represents what happens when the Pod
+// template webhook can not be called
+type PodTemplateWebhookFailurePolicy string
+
+const (
+	// PodTemplateWebhookFailurePolicyFail fails the sync of the component
+	PodTemplateWebhookFailurePolicyFail PodTemplateWebhookFailurePolicy = "Fail"
+	// PodTemplateWebhookFailurePolicyIgnore uses the Pod template without the
+	// mutations of the webhook
+	PodTemplateWebhookFailurePolicyIgnore PodTemplateWebhookFailurePolicy = "Ignore"
+)
+
+// ScaleHooks are the hooks called when the members of a component are scaled
+// in or out, so that external systems, e.g. load balancers, CMDBs and capacity
+// planners, can react to the changes of the topology
+// +k8s:openapi-gen=true
+type ScaleHooks struct {
+	// PreScaleIn is called before a member is removed from the cluster, and the
+	// scale-in waits until it succeeds. It is called once for each member.
+	// +optional
+	PreScaleIn *ScaleHook `json:"preScaleIn,omitempty"`
+
+	// PostScale is called after a member is added to or removed from the
+	// cluster. Its failure is only reported by an Event. A Job hook is
+	// deleted once it finishes.
+	// +optional
+	PostScale *ScaleHook `json:"postScale,omitempty"`
+}
+
+// ScaleHook is an HTTP callback or a Job run for the scale of a member,
+// exactly one of them must be set
+// +k8s:openapi-gen=true
+type ScaleHook struct {
+	// HTTP is the HTTP callback the operator POSTs a ScaleHookReview to, the
+	// hook fails if it responds with an error status
+	// +optional
+	HTTP *HTTPScaleHook `json:"http,omitempty"`
+
+	// Job is the Job run for the scale, the hook succeeds if the Job completes
+	// +optional
+	Job *JobScaleHook `json:"job,omitempty"`
+}
+
+// HTTPScaleHook is the HTTP callback of a scale hook
+// +k8s:openapi-gen=true
+type HTTPScaleHook struct {
+	// URL is the URL the operator POSTs a ScaleHookReview to
+	URL string `json:"url"`
+
+	// CABundle is the PEM encoded CA bundle to verify the certificate of the
+	// hook, the system trust roots are used if it is not set
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
+
+	// TimeoutSeconds is the timeout of calling the hook.
+	// Defaults to 10
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// JobScaleHook is the Job of a scale hook. The scale is passed to the
+// container by the env SCALE_NAMESPACE, SCALE_CLUSTER, SCALE_COMPONENT,
+// SCALE_ACTION, SCALE_ORDINAL and SCALE_POD_NAME.
+// +k8s:openapi-gen=true
+type JobScaleHook struct {
+	// Image is the image of the container of the Job
+	Image string `json:"image"`
+
+	// Command is the entrypoint of the container, the entrypoint of the image
+	// is used if it is not set
+	// +optional
+	Command []string `json:"command,omitempty"`
+
+	// Args are the arguments to the entrypoint
+	// +optional
+	Args []string `json:"args,omitempty"`
+
+	// ServiceAccountName is the service account the Job runs as
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+}
+
+// FailoverSpec tunes how aggressive the auto-failover of a component is
+// +k8s:openapi-gen=true
+type FailoverSpec struct {
+	// DetectionPeriod is how long a member must be unhealthy before it is
+	// failed over, in the format of Go Duration.
+	// Optional: Defaults to the failover period of the component in the
+	// controller manager, e.g. `--tikv-failover-period`
+	// +optional
+	DetectionPeriod *string `json:"detectionPeriod,omitempty"`
+
+	// MaxFailoverCount limits the max replicas could be added in failover, 0
+	// means no failover. It takes precedence over `maxFailoverCount` of the
+	// component.
+	// Optional: Defaults to `maxFailoverCount` of the component
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxFailoverCount *int32 `json:"maxFailoverCount,omitempty"`
+
+	// RecoverByDefault removes the failure members from the status and scales
+	// in the replicas added in failover once all the desired members of the
+	// component are healthy again, e.g. after the node of a failure member
+	// returns.
+	// Optional: Defaults to true for PD and TiDB, and to `recoverFailover` for
+	// TiKV and TiFlash
+	// +optional
+	RecoverByDefault *bool `json:"recoverByDefault,omitempty"`
+
+	// SnapshotBeforeDelete takes a VolumeSnapshot of the PVCs of a failure
+	// member before they are deleted, so that the old data directory can be
+	// inspected later. The VolumeSnapshotClass of `snapshotBeforeDeletingPVC`
+	// is used if it is set. It only takes effect for PD.
+	// Optional: Defaults to whether `snapshotBeforeDeletingPVC` is set
+	// +optional
+	SnapshotBeforeDelete *bool `json:"snapshotBeforeDelete,omitempty"`
+
+	// EndpointCheck fails over a member healthy by the health API as well if
+	// it is unreachable through the Service, i.e. its Pod is not Ready, e.g.
+	// by a readiness gate, or it is not ready in the Endpoints of the Service.
+	// It only takes effect for TiDB.
+	// Optional: Defaults to false
+	// +optional
+	EndpointCheck *bool `json:"endpointCheck,omitempty"`
+}
+
+// UpgradeHooks are the Jobs run around the upgrade of each Pod of a component,
+// e.g. to warm up the caches of TiDB or to reload the statistics after it is
+// upgraded
+// +k8s:openapi-gen=true
+type UpgradeHooks struct {
+	// PreUpgrade is run before a Pod is upgraded, and the upgrade of the Pod
+	// waits until it succeeds
+	// +optional
+	PreUpgrade *UpgradeHook `json:"preUpgrade,omitempty"`
+
+	// PostUpgrade is run after an upgraded Pod becomes healthy, and the upgrade
+	// of the next Pod waits until it succeeds
+	// +optional
+	PostUpgrade *UpgradeHook `json:"postUpgrade,omitempty"`
+}
+
+// UpgradeHookFailurePolicy is how the upgrade handles a failed upgrade hook
+type UpgradeHookFailurePolicy string
+
+const (
+	// UpgradeHookFailurePolicyFail blocks the upgrade and retries the hook
+	UpgradeHookFailurePolicyFail UpgradeHookFailurePolicy = "Fail"
+	// UpgradeHookFailurePolicyIgnore reports the failure by an Event and continues the upgrade
+	UpgradeHookFailurePolicyIgnore UpgradeHookFailurePolicy = "Ignore"
+)
+
+// UpgradeHook is a Job run for the upgrade of a Pod. The upgrade is passed to
+// the container by the env UPGRADE_NAMESPACE, UPGRADE_CLUSTER,
+// UPGRADE_COMPONENT, UPGRADE_ACTION, UPGRADE_ORDINAL and UPGRADE_POD_NAME.
+// +k8s:openapi-gen=true
+type UpgradeHook struct {
+	// Job is the Job run for the upgrade, the hook succeeds if the Job completes
+	Job *JobScaleHook `json:"job"`
+
+	// TimeoutSeconds is how long the upgrade waits for the Job, the hook fails
+	// if the Job does not complete in time.
+	// Defaults to 600
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+
+	// FailurePolicy is how the upgrade handles the failure of the hook, Fail
+	// blocks the upgrade and retries the Job, Ignore reports the failure by an
+	// Event and continues the upgrade.
+	// Defaults to Fail
+	// +optional
+	FailurePolicy UpgradeHookFailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+// UpgradeCrashLoopPolicy is how an upgrade handles the upgraded Pods stuck in CrashLoopBackOff
+// +k8s:openapi-gen=true
+type UpgradeCrashLoopPolicy struct {
+	// Threshold is how long an upgraded Pod may stay in CrashLoopBackOff before
+	// the upgrade is aborted, in the format of Go Duration.
+	// Defaults to 10m
+	// +optional
+	Threshold *string `json:"threshold,omitempty"`
+
+	// Rollback rolls the crash-looping Pod back to the previous revision when
+	// the upgrade is aborted. Otherwise the Pod is left as it is.
+	// +optional
+	Rollback bool `json:"rollback,omitempty"`
+}
+
+// PVCSnapshotSpec is the spec of the VolumeSnapshots taken before deleting PVCs
+// +k8s:openapi-gen=true
+type PVCSnapshotSpec struct {
+	// VolumeSnapshotClassName is the VolumeSnapshotClass of the snapshots,
+	// the default VolumeSnapshotClass is used if it is not set
+	// +optional
+	VolumeSnapshotClassName *string `json:"volumeSnapshotClassName,omitempty"`
+
+	// Retention is how long the snapshots are kept after they are taken, in the
+	// format of Go Duration, they are deleted by the operator afterwards.
+	// The snapshots are kept until they are deleted manually if it is not set
+	// +optional
+	Retention *string `json:"retention,omitempty"`
+}
+
+// AntiAffinityType is the type of the generated pod anti-affinity
+type AntiAffinityType string
+
+const (
+	// AntiAffinityTypePreferred generates preferredDuringSchedulingIgnoredDuringExecution anti-affinity
+	AntiAffinityTypePreferred AntiAffinityType = "preferred"
+	// AntiAffinityTypeRequired generates requiredDuringSchedulingIgnoredDuringExecution anti-affinity
+	AntiAffinityTypeRequired AntiAffinityType = "required"
+)
+
+// CrossComponentAntiAffinity configures the pod anti-affinity between two components
+// +k8s:openapi-gen=true
+type CrossComponentAntiAffinity struct {
+	// Type of the anti-affinity, `preferred` or `required`.
+	// Optional: Defaults to preferred
+	// +kubebuilder:validation:Enum=preferred,required
+	// +optional
+	Type AntiAffinityType `json:"type,omitempty"`
+
+	// TopologyKey is the domain in which the Pods of the two components should not be colocated.
+	// Optional: Defaults to kubernetes.io/hostname
+	// +optional
+	TopologyKey string `json:"topologyKey,omitempty"`
 }
 
 // TidbClusterStatus represents the current status of a tidb cluster.
@@ -300,6 +832,143 @@ type TidbClusterStatus struct {
 	// Represents the latest available observations of a tidb cluster's state.
 	// +optional
 	Conditions []TidbClusterCondition `json:"conditions,omitempty"`
+	// Topology is the placement of the component Pods, it is populated by
+	// the member managers in each reconcile.
+	// +optional
+	Topology *ClusterTopology `json:"topology,omitempty"`
+	// ConsecutiveSyncFailures is the number of the consecutive failed syncs of the
+	// cluster, the next sync is delayed exponentially with it, and it is reset
+	// once a sync succeeds or the spec of the cluster is changed.
+	// +optional
+	ConsecutiveSyncFailures int32 `json:"consecutiveSyncFailures,omitempty"`
+	// LastSyncFailureTime is the time of the last failed sync
+	// +optional
+	LastSyncFailureTime *metav1.Time `json:"lastSyncFailureTime,omitempty"`
+	// LastSyncFailureGeneration is the generation of the cluster in the last
+	// failed sync, the sync of a newer generation is not delayed
+	// +optional
+	LastSyncFailureGeneration int64 `json:"lastSyncFailureGeneration,omitempty"`
+	// VolumeSnapshots are the VolumeSnapshots taken before deleting PVCs, keyed by the snapshot name
+	// +optional
+	VolumeSnapshots map[string]PVCSnapshot `json:"volumeSnapshots,omitempty"`
+	// AbortedUpgrades are the upgrades aborted by the UpgradeCrashLoopPolicy or
+	// the autoRollback of the UpgradePolicy,
+	// keyed by the component. A component is not upgraded further until its
+	// spec is changed to another revision.
+	// +optional
+	AbortedUpgrades map[MemberType]AbortedUpgrade `json:"abortedUpgrades,omitempty"`
+	// FlappingPods are the Pods restarted too often according to the
+	// PodRestartPolicy, keyed by the component
+	// +optional
+	FlappingPods map[MemberType][]string `json:"flappingPods,omitempty"`
+	// StalledPods are the Pods staying Pending for longer than the threshold
+	// of the RolloutStallPolicy, keyed by the Pod name
+	// +optional
+	StalledPods map[string]StalledPod `json:"stalledPods,omitempty"`
+	// RecentScales are the members added or removed in the window of the
+	// MaxScaleVelocity of the ScalePolicy, in the order the scales begin
+	// +optional
+	RecentScales []ScaleRecord `json:"recentScales,omitempty"`
+	// ScaleDryRunPlans are the actions planned for the scales in the scale
+	// dry-run mode, keyed by the StatefulSet name
+	// +optional
+	ScaleDryRunPlans map[string][]string `json:"scaleDryRunPlans,omitempty"`
+}
+
+// ScaleRecord is a member added or removed by the scale of its component
+type ScaleRecord struct {
+	// Component is the component of the member
+	Component MemberType `json:"component"`
+	// PodName is the Pod of the member
+	PodName string `json:"podName"`
+	// ScaleIn indicates the member is removed, otherwise it is added
+	// +optional
+	ScaleIn bool `json:"scaleIn,omitempty"`
+	// Time is the time the scale of the member begins
+	Time metav1.Time `json:"time"`
+}
+
+// StalledPod is a Pod staying Pending, which stalls the rollout of its component
+type StalledPod struct {
+	// Component is the component of the Pod
+	Component MemberType `json:"component"`
+	// Reason is the reason of the latest warning event of the Pod, e.g.
+	// FailedScheduling, or the reason of its PodScheduled condition
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// Message is the message of the latest warning event of the Pod, or the
+	// message of its PodScheduled condition
+	// +optional
+	Message string `json:"message,omitempty"`
+	// PendingSince is the time the Pod becomes Pending
+	PendingSince metav1.Time `json:"pendingSince"`
+}
+
+// AbortedUpgrade is an upgrade aborted as an upgraded Pod is stuck in CrashLoopBackOff
+// or fails the health checks
+type AbortedUpgrade struct {
+	// PodName is the name of the crash-looping or unhealthy Pod
+	PodName string `json:"podName"`
+	// Revision is the StatefulSet revision the upgrade is aborted at
+	Revision string `json:"revision"`
+	// RolledBack indicates the Pod is rolled back to the previous revision
+	// +optional
+	RolledBack bool `json:"rolledBack,omitempty"`
+	// AbortedAt is the time the upgrade is aborted
+	AbortedAt metav1.Time `json:"abortedAt,omitempty"`
+	// TemplateHash is the hash of the Pod template of the aborted revision.
+	// After the Pods are rolled back, the template of the StatefulSet is kept
+	// at the previous revision as long as the spec renders this template.
+	// +optional
+	TemplateHash string `json:"templateHash,omitempty"`
+}
+
+// PVCSnapshot is a VolumeSnapshot taken before deleting a PVC
+type PVCSnapshot struct {
+	// SnapshotName is the name of the VolumeSnapshot
+	SnapshotName string `json:"snapshotName"`
+	// PVCUID is the UID of the PVC the snapshot is taken from
+	PVCUID types.UID `json:"pvcUID"`
+	// PVCName is the name of the PVC the snapshot is taken from
+	PVCName string `json:"pvcName,omitempty"`
+	// CreatedAt is the time the VolumeSnapshot is created
+	CreatedAt metav1.Time `json:"createdAt,omitempty"`
+	// Failed is true if the VolumeSnapshot reports an error, the PVC is
+	// deleted without the snapshot then
+	// +optional
+	Failed bool `json:"failed,omitempty"`
+}
+
+// MaxTopologyMembers is the max number of Pods recorded in the topology of a component,
+// it keeps the status of large clusters bounded.
+const MaxTopologyMembers = 256
+
+// ClusterTopology maps the Pods of each component to their nodes, zones and stores.
+type ClusterTopology struct {
+	PD      []PodTopology `json:"pd,omitempty"`
+	TiKV    []PodTopology `json:"tikv,omitempty"`
+	TiDB    []PodTopology `json:"tidb,omitempty"`
+	TiFlash []PodTopology `json:"tiflash,omitempty"`
+	// TruncatedComponents are the components that have more than MaxTopologyMembers Pods,
+	// only the first MaxTopologyMembers Pods ordered by name are recorded for them.
+	// +optional
+	TruncatedComponents []MemberType `json:"truncatedComponents,omitempty"`
+}
+
+// PodTopology describes where a component Pod is placed.
+type PodTopology struct {
+	PodName string `json:"podName"`
+	// NodeName is the node the Pod is scheduled to, empty if not scheduled yet
+	// +optional
+	NodeName string `json:"nodeName,omitempty"`
+	// Zone is the value of the zone label of the node.
+	// It is left empty if the operator has no permission to read nodes,
+	// the node cannot be found or the node has no zone label.
+	// +optional
+	Zone string `json:"zone,omitempty"`
+	// StoreID is the ID of the store running in the Pod, only for TiKV and TiFlash
+	// +optional
+	StoreID string `json:"storeID,omitempty"`
 }
 
 // TidbClusterCondition describes the state of a tidb cluster at a certain point.
@@ -333,6 +1002,59 @@ const (
 	// - All TiKV stores are up.
 	// - All TiFlash stores are up.
 	TidbClusterReady TidbClusterConditionType = "Ready"
+	// TidbClusterStorageSlow indicates that the measured write throughput of
+	// the data volumes of some TiKV Pods is below the floor in `.spec.tikv.storageCheck`.
+	TidbClusterStorageSlow TidbClusterConditionType = "StorageSlow"
+	// TidbClusterStoreHeartbeatStale indicates that the last heartbeat of some TiKV
+	// stores to PD is older than `.spec.tikv.storeHeartbeatStaleThreshold`.
+	TidbClusterStoreHeartbeatStale TidbClusterConditionType = "StoreHeartbeatStale"
+	// TidbClusterPDSplitBrain indicates that the PD members keep reporting
+	// divergent views of the cluster for longer than a grace period, scaling
+	// and PD failover are halted until it is resolved. It is Unknown while
+	// the views diverge for less than the grace period.
+	TidbClusterPDSplitBrain TidbClusterConditionType = "PDSplitBrain"
+	// TidbClusterPDLearnerStuck indicates that some PD members have stayed
+	// learners for longer than `.spec.pd.learnerTimeout`.
+	TidbClusterPDLearnerStuck TidbClusterConditionType = "PDLearnerStuck"
+	// TidbClusterUpgradeAborted indicates that the upgrade of some components
+	// is aborted as an upgraded Pod is stuck in CrashLoopBackOff.
+	TidbClusterUpgradeAborted TidbClusterConditionType = "UpgradeAborted"
+	// TidbClusterPDMemberMismatch indicates that the members reported by PD can
+	// not be mapped to the PD Pods unambiguously, PD scale-in is halted until it is resolved.
+	TidbClusterPDMemberMismatch TidbClusterConditionType = "PDMemberMismatch"
+	// TidbClusterPVCResizeFailed indicates that the expansion of some PVCs
+	// failed, or they have waited for the file system resize for longer than
+	// `.spec.pvcResizeFailurePolicy.fileSystemResizeTimeout`.
+	TidbClusterPVCResizeFailed TidbClusterConditionType = "PVCResizeFailed"
+	// TidbClusterPodRestartFlapping indicates that some Pods are restarted
+	// more than `.spec.podRestartPolicy.threshold` times and the last restart
+	// is within `.spec.podRestartPolicy.window`.
+	TidbClusterPodRestartFlapping TidbClusterConditionType = "PodRestartFlapping"
+	// TidbClusterRolloutStalled indicates that some Pods stay Pending for
+	// longer than `.spec.rolloutStallPolicy.threshold`, so the rollouts of
+	// their components are stalled.
+	TidbClusterRolloutStalled TidbClusterConditionType = "RolloutStalled"
+	// TidbClusterTiDBDataIncompatible indicates that some TiDB Pods fail to
+	// start as the data is bootstrapped by an incompatible version of TiDB.
+	TidbClusterTiDBDataIncompatible TidbClusterConditionType = "TiDBDataIncompatible"
+	// TidbClusterScaleBlocked indicates that scaling in PD to 0 replicas is
+	// blocked as other components still use PD, the reason lists the components.
+	TidbClusterScaleBlocked TidbClusterConditionType = "ScaleBlocked"
+	// TidbClusterStalePVC indicates that scaling out PD or TiKV is blocked by
+	// the PVCs left by a previous scale-in, the message lists the PVCs.
+	TidbClusterStalePVC TidbClusterConditionType = "StalePVC"
+	// TidbClusterWaitingForMaintenanceWindow indicates that an upgrade or a
+	// scale is queued until `.spec.maintenanceWindow` opens, the message
+	// tells the component and when the window opens.
+	TidbClusterWaitingForMaintenanceWindow TidbClusterConditionType = "WaitingForMaintenanceWindow"
+	// TidbClusterUpgradeBlocked indicates that a rolling upgrade can't start
+	// as the checks of `.spec.upgradePolicy.preChecks` fail, the message
+	// tells the component and the failed checks.
+	TidbClusterUpgradeBlocked TidbClusterConditionType = "UpgradeBlocked"
+	// TidbClusterTiDBCanaryFailed indicates that the TiDB upgrade is paused
+	// as the canary Pod fails the probes of `.spec.tidb.upgradePolicy.canary`,
+	// the message tells the Pod and the probe error.
+	TidbClusterTiDBCanaryFailed TidbClusterConditionType = "TiDBCanaryFailed"
 )
 
 // +k8s:openapi-gen=true
@@ -371,6 +1093,10 @@ type PDSpec struct {
 	// +optional
 	MaxFailoverCount *int32 `json:"maxFailoverCount,omitempty"`
 
+	// Failover tunes the auto-failover of the component
+	// +optional
+	Failover *FailoverSpec `json:"failover,omitempty"`
+
 	// The storageClassName of the persistent volume for PD data storage.
 	// Defaults to Kubernetes default storage class.
 	// +optional
@@ -408,6 +1134,233 @@ type PDSpec struct {
 	// MountClusterClientSecret indicates whether to mount `cluster-client-secret` to the Pod
 	// +optional
 	MountClusterClientSecret *bool `json:"mountClusterClientSecret,omitempty"`
+
+	// Schedulers are the desired schedulers of PD. The operator adds the missing
+	// schedulers, updates their config and removes the schedulers not listed,
+	// except the evict leader schedulers used in upgrading.
+	// Optional: Defaults to nil, which means the schedulers are not managed
+	// +optional
+	Schedulers []PDScheduler `json:"schedulers,omitempty"`
+
+	// RegionSize is the size of the Regions PD schedules, which is pushed to
+	// the Up TiKV stores through their online config API and corrected once
+	// it drifts, e.g. after a change by tikv-ctl.
+	// Optional: Defaults to nil, which means the Region size is not managed
+	// +optional
+	RegionSize *PDRegionSizeConfig `json:"regionSize,omitempty"`
+
+	// BalanceLimits are the limits of PD on the rebalancing speed, which are
+	// pushed to PD through its config API and corrected once they drift, e.g.
+	// after a change by pd-ctl. Only the limits set here are managed.
+	// Optional: Defaults to nil, which means the limits are not managed
+	// +optional
+	BalanceLimits *PDBalanceLimits `json:"balanceLimits,omitempty"`
+
+	// LearnerTimeout is how long a PD member may stay a learner before it is
+	// considered stuck, in the format of Go Duration.
+	// Defaults to 10m
+	// +optional
+	LearnerTimeout *string `json:"learnerTimeout,omitempty"`
+
+	// RecreateStuckLearner indicates whether to remove a stuck learner from PD
+	// and recreate its Pod and PVCs, so that it joins the cluster again.
+	// Optional: Defaults to false, which means the stuck learner is only reported
+	// +optional
+	RecreateStuckLearner *bool `json:"recreateStuckLearner,omitempty"`
+
+	// JoinAsLearner indicates whether the PD members added by scale-out join
+	// the etcd embedded in PD as learners first, which are promoted to voters
+	// once they catch up with the leader, so that a member slow to catch up
+	// does not disrupt the quorum of a busy cluster.
+	// Optional: Defaults to false
+	// +optional
+	JoinAsLearner *bool `json:"joinAsLearner,omitempty"`
+
+	// LostPVCGracePeriod is how long a PD member may stay without its data PVC or
+	// the PV bound to it before the member is considered to have lost its data,
+	// in the format of Go Duration.
+	// Defaults to 5m
+	// +optional
+	LostPVCGracePeriod *string `json:"lostPVCGracePeriod,omitempty"`
+
+	// RecoverLostPVC indicates whether to remove a PD member that lost its data
+	// PVC from PD and recreate its Pod and PVC, so that it joins the cluster again
+	// as a fresh member. The member is removed only if the PD cluster keeps a
+	// quorum without it.
+	// Optional: Defaults to false, which means the lost PVC is only reported
+	// +optional
+	RecoverLostPVC *bool `json:"recoverLostPVC,omitempty"`
+
+	// FailoverPVCPolicy decides what happens to the data PVC of a failure member
+	// when it is failed over. Recreate removes the member from PD and deletes its
+	// Pod and PVC, so that it joins the cluster again with an empty volume. Reuse
+	// keeps the member and its PVC and only recreates the Pod, so that it restarts
+	// with its intact data, e.g. on another node if the volume is not node-local.
+	// Optional: Defaults to Recreate
+	// +optional
+	FailoverPVCPolicy *FailoverPVCPolicy `json:"failoverPVCPolicy,omitempty"`
+
+	// UpgradeStabilizationGate holds the rolling upgrade after each upgraded
+	// Pod until a key metric of the Pod returns to the baseline.
+	// Optional: Defaults to nil, which means only the readiness is waited for
+	// +optional
+	UpgradeStabilizationGate *MetricStabilizationGate `json:"upgradeStabilizationGate,omitempty"`
+
+	// EtcdDefragInterval is the interval to defragment the etcd embedded in
+	// each PD member to reclaim space, in the format of Go Duration. The members
+	// are defragmented one at a time and the PD leader is skipped.
+	// Optional: Defaults to nil, which means the etcd is not defragmented
+	// +optional
+	EtcdDefragInterval *string `json:"etcdDefragInterval,omitempty"`
+
+	// ScaleInLeaderTransferTimeout makes the scale-in of the PD leader wait for
+	// the leader transfer and delete the member in the same reconcile, for at
+	// most the duration, in the format of Go Duration. It must not exceed 1m as
+	// the reconcile of the cluster is blocked in the wait.
+	// Optional: Defaults to nil, which means the scale-in of the leader is
+	// requeued or deletes the member without waiting for the leader transfer
+	// +optional
+	ScaleInLeaderTransferTimeout *string `json:"scaleInLeaderTransferTimeout,omitempty"`
+
+	// ScaleHooks are called before a member is removed and after a member is
+	// added or removed, so that external systems can react to the changes of
+	// the topology.
+	// Optional: Defaults to nil
+	// +optional
+	ScaleHooks *ScaleHooks `json:"scaleHooks,omitempty"`
+
+	// UpgradeHooks are the Jobs run before a Pod is upgraded and after it
+	// becomes healthy.
+	// Optional: Defaults to nil
+	// +optional
+	UpgradeHooks *UpgradeHooks `json:"upgradeHooks,omitempty"`
+
+	// Groups are the groups of PD members run in StatefulSets besides the one
+	// of spec.pd, so that the members can be placed with independent node
+	// selectors and storage classes. The members join and leave the PD cluster
+	// one at a time across all the StatefulSets, and are upgraded one at a
+	// time after the ones of spec.pd. The members of a group removed from the
+	// spec are scaled in one at a time, then its StatefulSet is deleted.
+	// Optional: Defaults to nil
+	// +optional
+	Groups []PDGroup `json:"groups,omitempty"`
+
+	// LeaderTransferPriority is the ordered list of the Pod names or zones of
+	// the PD members the PD leader is transferred to when the leader is scaled
+	// in or upgraded. The first healthy member matching an item is chosen, the
+	// default choice is made if none matches.
+	// Optional: Defaults to nil
+	// +optional
+	LeaderTransferPriority []string `json:"leaderTransferPriority,omitempty"`
+
+	// UpgradePaused freezes the partition of the StatefulSet of PD, so that
+	// no more Pods are upgraded until it is unset. The Pod being upgraded is
+	// not interrupted.
+	// Optional: Defaults to false
+	// +optional
+	UpgradePaused bool `json:"upgradePaused,omitempty"`
+}
+
+// PDGroup is a group of PD members run in the StatefulSet named
+// <cluster>-pd-<name>, the members are configured as the ones of spec.pd
+// except the fields of the group.
+// +k8s:openapi-gen=true
+type PDGroup struct {
+	// Name of the group, which must be a DNS label
+	Name string `json:"name"`
+
+	// Replicas is the number of the PD members of the group
+	Replicas int32 `json:"replicas"`
+
+	// NodeSelector of the members of the group, merged into the nodeSelector
+	// of spec.pd
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// StorageClassName of the data PVCs of the group
+	// Defaults to the storageClassName of spec.pd
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+}
+
+// PDRegionSizeConfig is the size of the Regions, in the format like 96MiB.
+// KB, MB, GB and TB are binary units like KiB as in TiKV, e.g. 96MB is 96MiB.
+// The sizes must be within [1MiB, 10GiB].
+// +k8s:openapi-gen=true
+type PDRegionSizeConfig struct {
+	// RegionMaxSize is the max size of a Region, a larger Region is split.
+	// It must not be smaller than RegionSplitSize.
+	// +optional
+	RegionMaxSize *string `json:"regionMaxSize,omitempty"`
+
+	// RegionSplitSize is the size of the Regions split from a large Region
+	// +optional
+	RegionSplitSize *string `json:"regionSplitSize,omitempty"`
+}
+
+// PDBalanceLimits are the limits of PD on the rebalancing speed, which are
+// the `schedule` items of the PD config with the same names
+// +k8s:openapi-gen=true
+type PDBalanceLimits struct {
+	// MaxPendingPeerCount is the max number of pending peers of a store, a store
+	// exceeding it is not used as the target of scheduling. It must be positive.
+	// +optional
+	MaxPendingPeerCount *int32 `json:"maxPendingPeerCount,omitempty"`
+
+	// MaxSnapshotCount is the max number of snapshots a store sends or receives
+	// at a time. It must be positive.
+	// +optional
+	MaxSnapshotCount *int32 `json:"maxSnapshotCount,omitempty"`
+
+	// LeaderScheduleLimit is the max number of concurrent leader schedules,
+	// 0 disables them
+	// +optional
+	LeaderScheduleLimit *int32 `json:"leaderScheduleLimit,omitempty"`
+
+	// RegionScheduleLimit is the max number of concurrent Region schedules,
+	// 0 disables them
+	// +optional
+	RegionScheduleLimit *int32 `json:"regionScheduleLimit,omitempty"`
+
+	// ReplicaScheduleLimit is the max number of concurrent replica schedules,
+	// 0 disables them
+	// +optional
+	ReplicaScheduleLimit *int32 `json:"replicaScheduleLimit,omitempty"`
+
+	// MergeScheduleLimit is the max number of concurrent merge schedules,
+	// 0 disables them
+	// +optional
+	MergeScheduleLimit *int32 `json:"mergeScheduleLimit,omitempty"`
+
+	// HotRegionScheduleLimit is the max number of concurrent hot Region
+	// schedules, 0 disables them
+	// +optional
+	HotRegionScheduleLimit *int32 `json:"hotRegionScheduleLimit,omitempty"`
+}
+
+// PDScheduler is a scheduler of PD
+// +k8s:openapi-gen=true
+type PDScheduler struct {
+	// Name of the scheduler, e.g. balance-hot-region-scheduler
+	Name string `json:"name"`
+
+	// Config of the scheduler, only the items set here are updated
+	// +optional
+	Config *config.GenericConfig `json:"config,omitempty"`
+}
+
+// StorageCheckSpec is the check of the write throughput of a data volume
+// +k8s:openapi-gen=true
+type StorageCheckSpec struct {
+	// MinWriteThroughputMB is the floor of the sequential write throughput in MB/s
+	// +kubebuilder:validation:Minimum=1
+	MinWriteThroughputMB int32 `json:"minWriteThroughputMB"`
+
+	// SizeMB is the size of the data written to measure the throughput
+	// Optional: Defaults to 64
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	SizeMB *int32 `json:"sizeMB,omitempty"`
 }
 
 // TiKVSpec contains details of TiKV members
@@ -423,81 +1376,316 @@ type TiKVSpec struct {
 	// +kubebuilder:validation:Minimum=1
 	Replicas int32 `json:"replicas"`
 
-	// Base image of the component, image tag is now allowed during validation
-	// +kubebuilder:default=pingcap/tikv
+	// Base image of the component, image tag is now allowed during validation
+	// +kubebuilder:default=pingcap/tikv
+	// +optional
+	BaseImage string `json:"baseImage"`
+
+	// Whether create the TiKV container in privileged mode, it is highly discouraged to enable this in
+	// critical environment.
+	// Optional: defaults to false
+	// +optional
+	Privileged *bool `json:"privileged,omitempty"`
+
+	// MaxFailoverCount limit the max replicas could be added in failover, 0 means no failover
+	// Optional: Defaults to 3
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxFailoverCount *int32 `json:"maxFailoverCount,omitempty"`
+
+	// Failover tunes the auto-failover of the component
+	// +optional
+	Failover *FailoverSpec `json:"failover,omitempty"`
+
+	// Whether output the RocksDB log in a separate sidecar container
+	// Optional: Defaults to false
+	// +optional
+	SeparateRocksDBLog *bool `json:"separateRocksDBLog,omitempty"`
+
+	// Whether output the Raft log in a separate sidecar container
+	// Optional: Defaults to false
+	// +optional
+	SeparateRaftLog *bool `json:"separateRaftLog,omitempty"`
+
+	// LogTailer is the configurations of the log tailers for TiKV
+	// +optional
+	LogTailer *LogTailerSpec `json:"logTailer,omitempty"`
+
+	// The storageClassName of the persistent volume for TiKV data storage.
+	// Defaults to Kubernetes default storage class.
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+
+	// Subdirectory within the volume to store TiKV Data. By default, the data
+	// is stored in the root directory of volume which is mounted at
+	// /var/lib/tikv.
+	// Specifying this will change the data directory to a subdirectory, e.g.
+	// /var/lib/tikv/data if you set the value to "data".
+	// It's dangerous to change this value for a running cluster as it will
+	// upgrade your cluster to use a new storage directory.
+	// Defaults to "" (volume's root).
+	// +optional
+	DataSubDir string `json:"dataSubDir,omitempty"`
+
+	// Config is the Configuration of tikv-servers
+	// +optional
+	Config *TiKVConfigWraper `json:"config,omitempty"`
+
+	// PerOrdinalConfig overrides the Configuration of the tikv-servers of some
+	// ordinals, keyed by the ordinal, e.g. to try out a change on one TiKV before
+	// rolling it out to all. The override is merged into `config`, which must be
+	// set, and the other tikv-servers keep running with `config`. Only the Pods
+	// of the ordinals whose override changes are restarted, except that setting
+	// the field the first time, even to an empty map, or unsetting it rolls all
+	// the Pods once to mount the overrides.
+	// +optional
+	PerOrdinalConfig map[string]*TiKVConfigWraper `json:"perOrdinalConfig,omitempty"`
+
+	// RecoverFailover indicates that Operator can recover the failed Pods
+	// +optional
+	RecoverFailover bool `json:"recoverFailover,omitempty"`
+
+	// FailoverDeleteSlots replaces a failed store whose node is gone, i.e. PD
+	// has lost the heartbeats of the store and its node has been NotReady, or
+	// has been deleted since the heartbeats were lost, for the
+	// `--tikv-node-not-ready-failover-period` of the controller manager, by
+	// adding the ordinal of its Pod to the `tikv.tidb.pingcap.com/delete-slots`
+	// annotation instead of keeping the ordinal and adding a failover Pod. A new
+	// Pod with a new PVC is created on a healthy node first, then the store is
+	// deleted from PD and the failed Pod is removed once the store becomes
+	// tombstone. The stores are replaced one at a time. It requires the
+	// AdvancedStatefulSet feature, otherwise a failover Pod is added as usual.
+	// Optional: Defaults to false
+	// +optional
+	FailoverDeleteSlots bool `json:"failoverDeleteSlots,omitempty"`
+
+	// FailoverPVCPolicy decides what happens to the data PVC of a failure store
+	// when it is failed over, a failover Pod is added in both cases. Reuse keeps
+	// the store and the PVC, so that the store restarts with its intact data once
+	// it recovers. Recreate deletes the store from PD and, once it becomes
+	// tombstone, deletes its Pod and PVC, so that the Pod joins the cluster again
+	// as a new store with an empty volume.
+	// Optional: Defaults to Reuse
+	// +optional
+	FailoverPVCPolicy *FailoverPVCPolicy `json:"failoverPVCPolicy,omitempty"`
+
+	// MountClusterClientSecret indicates whether to mount `cluster-client-secret` to the Pod
+	// +optional
+	MountClusterClientSecret *bool `json:"mountClusterClientSecret,omitempty"`
+
+	// EvictLeaderTimeout indicates the timeout to evict tikv leader, in the format of Go Duration.
+	// Defaults to 10m
+	// +optional
+	EvictLeaderTimeout *string `json:"evictLeaderTimeout,omitempty"`
+
+	// EvictLeaderBackoff is how often the leaders of the TiKV Pod being
+	// upgraded or scaled in are checked while they are evicted.
+	// Optional: Defaults to nil, which means the check is retried with the
+	// backoff of the controller
+	// +optional
+	EvictLeaderBackoff *EvictLeaderBackoff `json:"evictLeaderBackoff,omitempty"`
+
+	// MaxConcurrentEvictLeaders is the max number of stores whose region leaders
+	// are evicted at the same time, e.g. for the batches of a TiKV upgrade.
+	// It is always kept below the number of Up stores, so there are stores
+	// left to take the leaders.
+	// Defaults to the maxUnavailable of the upgradePolicy
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxConcurrentEvictLeaders *int32 `json:"maxConcurrentEvictLeaders,omitempty"`
+
+	// AutoTuneThreadPools derives the sizes of the TiKV thread pools, e.g.
+	// raftstore.store-pool-size, from the CPU limit of the TiKV container.
+	// Thread pool sizes set explicitly in the config are kept as they are.
+	// It takes no effect if the CPU limit is not set.
+	// Optional: Defaults to false
+	// +optional
+	AutoTuneThreadPools bool `json:"autoTuneThreadPools,omitempty"`
+
+	// StorageCheck runs an init container to measure the write throughput of the
+	// data volume before TiKV starts, and sets the StorageSlow condition if it is
+	// below the floor.
+	// Optional: Defaults to nil, which means the check is disabled
+	// +optional
+	StorageCheck *StorageCheckSpec `json:"storageCheck,omitempty"`
+
+	// StoreHeartbeatStaleThreshold sets the StoreHeartbeatStale condition if the
+	// last heartbeat of some TiKV stores to PD is older than it, in the format of
+	// Go Duration. It gives an early warning before the stores are down and failed over.
+	// Optional: Defaults to nil, which means the check is disabled
+	// +optional
+	StoreHeartbeatStaleThreshold *string `json:"storeHeartbeatStaleThreshold,omitempty"`
+
+	// StorageVolumes configure additional storage for TiKV pods.
+	// +optional
+	StorageVolumes []StorageVolume `json:"storageVolumes,omitempty"`
+
+	// WalStorageSize is the size of the separate persistent volume that keeps
+	// the WAL of TiKV, e.g. on faster storage than the data.
+	// The WAL is kept on the data volume if it is not set.
+	// +optional
+	WalStorageSize string `json:"walStorageSize,omitempty"`
+
+	// The storageClassName of the persistent volume for the TiKV WAL.
+	// Defaults to the storageClassName of the TiKV data.
+	// +optional
+	WalStorageClassName *string `json:"walStorageClassName,omitempty"`
+
+	// StoreLabels configures additional labels for TiKV stores.
+	// +optional
+	StoreLabels []string `json:"storeLabels,omitempty"`
+
+	// EnableNamedStatusPort enables status port(20180 by default) in the Pod spec.
+	// If you set it to `true` for an existing cluster, the TiKV cluster will be rolling updated.
+	EnableNamedStatusPort bool `json:"enableNamedStatusPort,omitempty"`
+
+	// Ports overrides the ports TiKV listens on, e.g. to avoid port conflicts
+	// when hostNetwork is enabled.
+	// The server port is part of the store address, so it cannot be changed
+	// for an existing cluster.
 	// +optional
-	BaseImage string `json:"baseImage"`
+	Ports *TiKVPorts `json:"ports,omitempty"`
 
-	// Whether create the TiKV container in privileged mode, it is highly discouraged to enable this in
-	// critical environment.
-	// Optional: defaults to false
+	// UpgradeStabilizationGate holds the rolling upgrade after each upgraded
+	// Pod until a key metric of the Pod returns to the baseline.
+	// Optional: Defaults to nil, which means only the readiness is waited for
 	// +optional
-	Privileged *bool `json:"privileged,omitempty"`
+	UpgradeStabilizationGate *MetricStabilizationGate `json:"upgradeStabilizationGate,omitempty"`
 
-	// MaxFailoverCount limit the max replicas could be added in failover, 0 means no failover
-	// Optional: Defaults to 3
-	// +kubebuilder:validation:Minimum=0
+	// ScaleInStoreEmptyTimeout is how long the scale-in of a TiKV Pod waits for
+	// its tombstone store to report no Regions to PD before the StatefulSet is
+	// scaled in, in the format of Go Duration. After it the scale-in completes
+	// with a warning Event, e.g. if the Region count reported for the store is stale.
+	// Defaults to 10m
 	// +optional
-	MaxFailoverCount *int32 `json:"maxFailoverCount,omitempty"`
+	ScaleInStoreEmptyTimeout *string `json:"scaleInStoreEmptyTimeout,omitempty"`
 
-	// Whether output the RocksDB log in a separate sidecar container
-	// Optional: Defaults to false
+	// ScaleHooks are called before a member is removed and after a member is
+	// added or removed, so that external systems can react to the changes of
+	// the topology.
+	// Optional: Defaults to nil
 	// +optional
-	SeparateRocksDBLog *bool `json:"separateRocksDBLog,omitempty"`
+	ScaleHooks *ScaleHooks `json:"scaleHooks,omitempty"`
 
-	// Whether output the Raft log in a separate sidecar container
-	// Optional: Defaults to false
+	// UpgradeHooks are the Jobs run before a Pod is upgraded and after it
+	// becomes healthy.
+	// Optional: Defaults to nil
 	// +optional
-	SeparateRaftLog *bool `json:"separateRaftLog,omitempty"`
+	UpgradeHooks *UpgradeHooks `json:"upgradeHooks,omitempty"`
 
-	// LogTailer is the configurations of the log tailers for TiKV
+	// ScalePolicy is the policy of the scale of TiKV
+	// Optional: Defaults to nil
 	// +optional
-	LogTailer *LogTailerSpec `json:"logTailer,omitempty"`
+	ScalePolicy *TiKVScalePolicy `json:"scalePolicy,omitempty"`
 
-	// The storageClassName of the persistent volume for TiKV data storage.
-	// Defaults to Kubernetes default storage class.
+	// UpgradePolicy is the policy of the rolling upgrade of TiKV
+	// Optional: Defaults to nil
 	// +optional
-	StorageClassName *string `json:"storageClassName,omitempty"`
+	UpgradePolicy *TiKVUpgradePolicy `json:"upgradePolicy,omitempty"`
 
-	// Subdirectory within the volume to store TiKV Data. By default, the data
-	// is stored in the root directory of volume which is mounted at
-	// /var/lib/tikv.
-	// Specifying this will change the data directory to a subdirectory, e.g.
-	// /var/lib/tikv/data if you set the value to "data".
-	// It's dangerous to change this value for a running cluster as it will
-	// upgrade your cluster to use a new storage directory.
-	// Defaults to "" (volume's root).
+	// UpgradePaused freezes the partition of the StatefulSet of TiKV, so that
+	// no more Pods are upgraded until it is unset. The Pod being upgraded is
+	// not interrupted.
+	// Optional: Defaults to false
 	// +optional
-	DataSubDir string `json:"dataSubDir,omitempty"`
+	UpgradePaused bool `json:"upgradePaused,omitempty"`
+}
 
-	// Config is the Configuration of tikv-servers
+// TiKVScalePolicy is the policy of the scale of TiKV
+// +k8s:openapi-gen=true
+type TiKVScalePolicy struct {
+	// EvictLeaderBeforeScaleIn evicts the Region leaders from the store of a
+	// TiKV Pod before the store is deleted in the scale-in, so that the clients
+	// are not affected by the leaders removed with the store. The store is
+	// deleted once it has no leaders or `.spec.tikv.evictLeaderTimeout` passes.
+	// Optional: Defaults to false
 	// +optional
-	Config *TiKVConfigWraper `json:"config,omitempty"`
-
-	// RecoverFailover indicates that Operator can recover the failed Pods
+	EvictLeaderBeforeScaleIn bool `json:"evictLeaderBeforeScaleIn,omitempty"`
+
+	// BalanceZones keeps the TiKV stores balanced across the zones. The Pods
+	// are spread across the zones by a topology spread constraint on the zone
+	// label of the nodes if there is none in `.spec.tikv.topologySpreadConstraints`.
+	// A scale-in is refused if the store removed is not in a zone with the most
+	// stores, or it is the last store of its zone, unless the TidbCluster is
+	// annotated with `tidb.pingcap.com/force-unbalanced-scale-in: "true"`. The
+	// zone of a store is the zone label of its node, or the `zone` label of the
+	// store in PD if the node has none.
+	// Setting or unsetting it changes the topology spread constraints of the
+	// TiKV Pods if `.spec.tikv.topologySpreadConstraints` has none on the zone
+	// label, so all the TiKV Pods are rolling updated.
+	// Optional: Defaults to false
 	// +optional
-	RecoverFailover bool `json:"recoverFailover,omitempty"`
+	BalanceZones bool `json:"balanceZones,omitempty"`
+}
 
-	// MountClusterClientSecret indicates whether to mount `cluster-client-secret` to the Pod
+// EvictLeaderBackoff is the backoff of checking whether the leaders of a TiKV
+// Pod are evicted. The wait before the next check is the time since the
+// eviction began, bounded by the interval and the max interval, so it doubles
+// at each check.
+// +k8s:openapi-gen=true
+type EvictLeaderBackoff struct {
+	// Interval is the min wait before the next check, in the format of Go Duration.
+	// Defaults to 5s
 	// +optional
-	MountClusterClientSecret *bool `json:"mountClusterClientSecret,omitempty"`
+	Interval *string `json:"interval,omitempty"`
 
-	// EvictLeaderTimeout indicates the timeout to evict tikv leader, in the format of Go Duration.
-	// Defaults to 10m
+	// MaxInterval is the max wait before the next check, in the format of Go Duration.
+	// Defaults to 1m
 	// +optional
-	EvictLeaderTimeout *string `json:"evictLeaderTimeout,omitempty"`
+	MaxInterval *string `json:"maxInterval,omitempty"`
+}
 
-	// StorageVolumes configure additional storage for TiKV pods.
+// TiKVUpgradePolicy is the policy of the rolling upgrade of TiKV
+// +k8s:openapi-gen=true
+type TiKVUpgradePolicy struct {
+	// MaxUnavailable is the max number of TiKV Pods upgraded at the same time.
+	// The Pods of a batch are in the same zone, so a batch takes down at most
+	// one peer of a Region if PD places the peers in different zones, and a
+	// batch is only started when all the upgraded Pods are up and PD reports
+	// no Region missing peers or with down peers. A store whose zone is
+	// unknown is upgraded alone. It is ignored if the pod admission webhook
+	// is enabled. The zone of a store is the zone label of its node, or the `zone`
+	// label of the store in PD if the node has none.
+	// Optional: Defaults to 1
+	// +optional
+	MaxUnavailable *int32 `json:"maxUnavailable,omitempty"`
+}
+
+// MetricStabilizationGate is a PromQL query whose value must drop to the
+// threshold before the next Pod is upgraded
+// +k8s:openapi-gen=true
+type MetricStabilizationGate struct {
+	// PrometheusURL is the address of the Prometheus to query, e.g. http://prometheus:9090
+	PrometheusURL string `json:"prometheusURL"`
+
+	// Query is the PromQL instant query of the key metric, e.g. the apply
+	// latency of TiKV. `$POD_NAME` and `$NAMESPACE` in the query are replaced
+	// with the name and namespace of the upgraded Pod. The value of the first
+	// sample of the result is compared with the threshold.
+	Query string `json:"query"`
+
+	// Threshold is the max value of the metric that is considered stable, e.g. "0.05"
+	Threshold string `json:"threshold"`
+
+	// Timeout is how long to wait for the metric to stabilize after the
+	// upgraded Pod is ready, the upgrade proceeds after the timeout.
+	// Optional: Defaults to 10m
 	// +optional
-	StorageVolumes []StorageVolume `json:"storageVolumes,omitempty"`
+	Timeout *string `json:"timeout,omitempty"`
+}
 
-	// StoreLabels configures additional labels for TiKV stores.
+// TiKVPorts is the ports TiKV listens on
+// +k8s:openapi-gen=true
+type TiKVPorts struct {
+	// Server is the port of the gRPC service of TiKV
+	// Optional: Defaults to 20160
 	// +optional
-	StoreLabels []string `json:"storeLabels,omitempty"`
+	Server *int32 `json:"server,omitempty"`
 
-	// EnableNamedStatusPort enables status port(20180) in the Pod spec.
-	// If you set it to `true` for an existing cluster, the TiKV cluster will be rolling updated.
-	EnableNamedStatusPort bool `json:"enableNamedStatusPort,omitempty"`
+	// Status is the port of the status service of TiKV
+	// Optional: Defaults to 20180
+	// +optional
+	Status *int32 `json:"status,omitempty"`
 }
 
 // TiFlashSpec contains details of TiFlash members
@@ -530,6 +1718,10 @@ type TiFlashSpec struct {
 	// +optional
 	MaxFailoverCount *int32 `json:"maxFailoverCount,omitempty"`
 
+	// Failover tunes the auto-failover of the component
+	// +optional
+	Failover *FailoverSpec `json:"failover,omitempty"`
+
 	// The persistent volume claims of the TiFlash data storages.
 	// TiFlash supports multiple disks.
 	StorageClaims []StorageClaim `json:"storageClaims"`
@@ -545,6 +1737,89 @@ type TiFlashSpec struct {
 	// RecoverFailover indicates that Operator can recover the failover Pods
 	// +optional
 	RecoverFailover bool `json:"recoverFailover,omitempty"`
+
+	// FailoverPVCPolicy decides what happens to the data PVC of a failure store
+	// when it is failed over, a failover Pod is added in both cases. Reuse keeps
+	// the store and the PVC, so that the store restarts with its intact data once
+	// it recovers. Recreate deletes the store from PD and, once it becomes
+	// tombstone, deletes its Pod and PVC, so that the Pod joins the cluster again
+	// as a new store with an empty volume.
+	// Optional: Defaults to Reuse
+	// +optional
+	FailoverPVCPolicy *FailoverPVCPolicy `json:"failoverPVCPolicy,omitempty"`
+
+	// TableReplicas are the TiFlash replicas of the tables the operator sets
+	// over SQL once all the TiDB members are ready, and sets back if they
+	// drift from the spec. The tables not listed are not touched, the tables
+	// that do not exist yet are set once they are created.
+	// +optional
+	TableReplicas []TiFlashTableReplica `json:"tableReplicas,omitempty"`
+
+	// ScaleHooks are called before a member is removed and after a member is
+	// added or removed, so that external systems can react to the changes of
+	// the topology.
+	// Optional: Defaults to nil
+	// +optional
+	ScaleHooks *ScaleHooks `json:"scaleHooks,omitempty"`
+
+	// UpgradeHooks are the Jobs run before a Pod is upgraded and after it
+	// becomes healthy.
+	// Optional: Defaults to nil
+	// +optional
+	UpgradeHooks *UpgradeHooks `json:"upgradeHooks,omitempty"`
+
+	// UpgradePaused freezes the partition of the StatefulSet of TiFlash, so that
+	// no more Pods are upgraded until it is unset. The Pod being upgraded is
+	// not interrupted.
+	// Optional: Defaults to false
+	// +optional
+	UpgradePaused bool `json:"upgradePaused,omitempty"`
+
+	// UpgradeCheck are the thresholds the upgraded TiFlash store must meet
+	// before the next Pod is upgraded, so that the store catches up with the
+	// Raft logs it missed while restarting.
+	// Optional: Defaults to nil, in which case only the store state is checked
+	// +optional
+	UpgradeCheck *TiFlashUpgradeCheck `json:"upgradeCheck,omitempty"`
+}
+
+// TiFlashTableReplica is the number of TiFlash replicas of a table
+// +k8s:openapi-gen=true
+type TiFlashTableReplica struct {
+	// Database is the database of the table
+	Database string `json:"database"`
+	// Table is the name of the table
+	Table string `json:"table"`
+	// Replicas is the number of TiFlash replicas of the table, 0 removes the replicas
+	// +kubebuilder:validation:Minimum=0
+	Replicas int32 `json:"replicas"`
+}
+
+// TiFlashUpgradeCheck are the thresholds of the checks of an upgraded TiFlash
+// store before the next TiFlash Pod is upgraded
+// +k8s:openapi-gen=true
+type TiFlashUpgradeCheck struct {
+	// MaxPendingPeerRegions is the max number of Regions with pending peers on
+	// the upgraded store, i.e. the peers that have not applied the Raft logs of
+	// their leaders
+	// Defaults to 0
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxPendingPeerRegions *int32 `json:"maxPendingPeerRegions,omitempty"`
+
+	// MaxApplyingSnapshots is the max number of snapshots the upgraded store
+	// is receiving or applying
+	// Defaults to 0
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxApplyingSnapshots *int32 `json:"maxApplyingSnapshots,omitempty"`
+
+	// Timeout is how long the upgraded store may fail the checks after its Pod
+	// is ready, the next Pod is upgraded anyway after it, in the format of Go
+	// Duration.
+	// Defaults to 30m
+	// +optional
+	Timeout *string `json:"timeout,omitempty"`
 }
 
 // TiCDCSpec contains details of TiCDC members
@@ -582,6 +1857,21 @@ type TiCDCSpec struct {
 	// Defaults to Kubernetes default storage class.
 	// +optional
 	StorageClassName *string `json:"storageClassName,omitempty"`
+
+	// UpgradePaused freezes the partition of the StatefulSet of TiCDC, so that
+	// no more Pods are upgraded until it is unset. The Pod being upgraded is
+	// not interrupted.
+	// Optional: Defaults to false
+	// +optional
+	UpgradePaused bool `json:"upgradePaused,omitempty"`
+
+	// GracefulShutdownTimeout makes the upgrade of a TiCDC Pod wait for the
+	// capture to resign the owner and to move its tables to the other
+	// captures before the Pod is restarted, for at most the duration, in the
+	// format of Go Duration. "0s" restarts the Pod without waiting.
+	// Optional: Defaults to 10m
+	// +optional
+	GracefulShutdownTimeout *string `json:"gracefulShutdownTimeout,omitempty"`
 }
 
 // TiCDCConfig is the configuration of tidbcdc
@@ -662,6 +1952,10 @@ type TiDBSpec struct {
 	// +optional
 	MaxFailoverCount *int32 `json:"maxFailoverCount,omitempty"`
 
+	// Failover tunes the auto-failover of the component
+	// +optional
+	Failover *FailoverSpec `json:"failover,omitempty"`
+
 	// Whether output the slow log in an separate sidecar container
 	// Optional: Defaults to true
 	// +optional
@@ -690,6 +1984,16 @@ type TiDBSpec struct {
 	// +optional
 	Config *TiDBConfigWraper `json:"config,omitempty"`
 
+	// PerOrdinalConfig overrides the Configuration of the tidb-servers of some
+	// ordinals, keyed by the ordinal, e.g. to try out a change on one TiDB before
+	// rolling it out to all. The override is merged into `config`, which must be
+	// set, and the other tidb-servers keep running with `config`. Only the Pods
+	// of the ordinals whose override changes are restarted, except that setting
+	// the field the first time, even to an empty map, or unsetting it rolls all
+	// the Pods once to mount the overrides.
+	// +optional
+	PerOrdinalConfig map[string]*TiDBConfigWraper `json:"perOrdinalConfig,omitempty"`
+
 	// Lifecycle describes actions that the management system should take in response to container lifecycle
 	// events. For the PostStart and PreStop lifecycle handlers, management of the container blocks
 	// until the action is complete, unless the container process fails, in which case the handler is aborted.
@@ -708,6 +2012,152 @@ type TiDBSpec struct {
 	// the default behavior is like setting type as "tcp"
 	// +optional
 	ReadinessProbe *TiDBProbe `json:"readinessProbe,omitempty"`
+
+	// UpgradeStabilizationGate holds the rolling upgrade after each upgraded
+	// Pod until a key metric of the Pod returns to the baseline.
+	// Optional: Defaults to nil, which means only the readiness is waited for
+	// +optional
+	UpgradeStabilizationGate *MetricStabilizationGate `json:"upgradeStabilizationGate,omitempty"`
+
+	// UpgradeConcurrency is the max number of TiDB Pods restarted at the same
+	// time in a rolling upgrade. At least one healthy Pod is kept serving, and
+	// the Pods are evicted so the PodDisruptionBudgets are respected.
+	// Defaults to 1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	UpgradeConcurrency *int32 `json:"upgradeConcurrency,omitempty"`
+
+	// BlueGreenSwitch rolls out changes of the TiDB Pod template by bringing up
+	// a green TiDB StatefulSet with the new template alongside the current (blue)
+	// one and switching the TiDB Service to it once all its Pods are ready,
+	// instead of upgrading the Pods one by one. The blue StatefulSet is then torn
+	// down and recreated with the new template, and the Service is switched back
+	// to it before the green StatefulSet is deleted.
+	// Optional: Defaults to false
+	// +optional
+	BlueGreenSwitch bool `json:"blueGreenSwitch,omitempty"`
+
+	// PlacementPolicies are the placement policies the operator creates in TiDB
+	// over SQL once all the TiDB members are ready, and alters back if they
+	// drift from the spec. The placement policies not listed are not touched.
+	// +optional
+	PlacementPolicies []PlacementPolicy `json:"placementPolicies,omitempty"`
+
+	// ConfigProfile is the name of the TidbClusterConfigProfile in the same
+	// namespace whose system variables the operator sets in TiDB over SQL
+	// once all the TiDB members are ready.
+	// +optional
+	ConfigProfile string `json:"configProfile,omitempty"`
+
+	// SystemVariables are the TiDB global system variables the operator sets
+	// over SQL, keyed by the variable name. They take precedence over the ones
+	// of the ConfigProfile, except for its locked system variables.
+	// +optional
+	SystemVariables map[string]string `json:"systemVariables,omitempty"`
+
+	// AuditLog loads the audit plugin of TiDB, which writes the audit log to a
+	// volume shared with an optional log shipping sidecar. The audit log is
+	// enabled by the operator over SQL once all the TiDB members are ready.
+	// Requires TiDB v4.0.0 or later.
+	// Optional: Defaults to nil, which means the audit log is not managed
+	// +optional
+	AuditLog *TiDBAuditLogSpec `json:"auditLog,omitempty"`
+
+	// SQLSecretName is the name of the Secret holding the `user` and `password`
+	// the operator connects to TiDB with to manage the placement policies, the
+	// system variables and the TiFlash replicas of the tables.
+	// Optional: Defaults to "", which means none of them is synced
+	// +optional
+	SQLSecretName string `json:"sqlSecretName,omitempty"`
+
+	// IncompatibleDataPolicy decides what happens when a TiDB Pod fails to
+	// start as the data is bootstrapped by an incompatible version, e.g. after
+	// a downgrade. The failure is always reported in the TiDBDataIncompatible
+	// condition and an Event. Rollback also aborts the ongoing upgrade of TiDB
+	// and rolls the failed Pod back to the previous revision.
+	// Optional: Defaults to Report
+	// +optional
+	IncompatibleDataPolicy *IncompatibleDataPolicy `json:"incompatibleDataPolicy,omitempty"`
+
+	// ScaleInDrainTimeout makes the scale-in of a TiDB Pod wait for the client
+	// connections to the Pod to be closed before the StatefulSet is scaled in,
+	// for at most the duration, in the format of Go Duration. TiDB keeps
+	// accepting connections in the wait, the PreScaleIn hook can be used to
+	// remove the Pod from the load balancer before it. The connections of the
+	// user in sqlSecretName, which the operator keeps itself, are not counted.
+	// Optional: Defaults to nil, which means the scale-in does not wait
+	// +optional
+	ScaleInDrainTimeout *string `json:"scaleInDrainTimeout,omitempty"`
+
+	// ScaleHooks are called before a member is removed and after a member is
+	// added or removed, so that external systems can react to the changes of
+	// the topology.
+	// Optional: Defaults to nil
+	// +optional
+	ScaleHooks *ScaleHooks `json:"scaleHooks,omitempty"`
+
+	// UpgradeHooks are the Jobs run before a Pod is upgraded and after it
+	// becomes healthy.
+	// Optional: Defaults to nil
+	// +optional
+	UpgradeHooks *UpgradeHooks `json:"upgradeHooks,omitempty"`
+
+	// UpgradePolicy is the policy of the rolling upgrade of TiDB, e.g. a
+	// canary Pod verified before the other Pods are upgraded.
+	// Optional: Defaults to nil
+	// +optional
+	UpgradePolicy *TiDBUpgradePolicy `json:"upgradePolicy,omitempty"`
+
+	// UpgradePaused freezes the partition of the StatefulSet of TiDB, so that
+	// no more Pods are upgraded until it is unset. The Pod being upgraded is
+	// not interrupted.
+	// Optional: Defaults to false
+	// +optional
+	UpgradePaused bool `json:"upgradePaused,omitempty"`
+}
+
+// TiDBUpgradePolicy is the policy of the rolling upgrade of TiDB
+// +k8s:openapi-gen=true
+type TiDBUpgradePolicy struct {
+	// Canary upgrades a single TiDB Pod first and pauses the rolling upgrade
+	// until the Pod passes the verification. The upgrade stays paused while
+	// the verification fails, so that the change can be reverted, and the
+	// failure is reported in the TiDBCanaryFailed condition.
+	// Optional: Defaults to nil, which means there is no canary
+	// +optional
+	Canary *TiDBCanaryPolicy `json:"canary,omitempty"`
+}
+
+// TiDBCanaryPolicy is the verification of the canary Pod of a TiDB upgrade
+// +k8s:openapi-gen=true
+type TiDBCanaryPolicy struct {
+	// PauseDuration is how long the rolling upgrade is paused after the
+	// canary Pod is ready before the Pod is verified, in the format of Go
+	// Duration.
+	// Defaults to 1m
+	// +optional
+	PauseDuration *string `json:"pauseDuration,omitempty"`
+
+	// SQLProbe is the SQL statement run on the canary Pod with the user in
+	// sqlSecretName, the verification fails if the statement fails.
+	// +optional
+	SQLProbe string `json:"sqlProbe,omitempty"`
+
+	// HTTPProbePath is the path on the status port of the canary Pod the
+	// operator GETs, e.g. /status, the verification fails if the response
+	// status is 4xx or 5xx.
+	// +optional
+	HTTPProbePath string `json:"httpProbePath,omitempty"`
+}
+
+// PlacementPolicy is a placement policy of TiDB
+// +k8s:openapi-gen=true
+type PlacementPolicy struct {
+	// Name is the name of the placement policy
+	Name string `json:"name"`
+	// Options are the placement options of the policy keyed by the option name,
+	// e.g. PRIMARY_REGION: us-east-1. The options of strings are quoted by the operator.
+	Options map[string]string `json:"options"`
 }
 
 const (
@@ -758,6 +2208,13 @@ type PumpSpec struct {
 	// +optional
 	Config *config.GenericConfig `json:"config,omitempty"`
 
+	// UpgradePaused freezes the partition of the StatefulSet of Pump, so that
+	// no more Pods are upgraded until it is unset. The Pod being upgraded is
+	// not interrupted.
+	// Optional: Defaults to false
+	// +optional
+	UpgradePaused bool `json:"upgradePaused,omitempty"`
+
 	// +k8s:openapi-gen=false
 	// For backward compatibility with helm chart
 	SetTimeZone *bool `json:"setTimeZone,omitempty"`
@@ -777,6 +2234,29 @@ type HelperSpec struct {
 	ImagePullPolicy *corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
 }
 
+// TiDBAuditLogSpec is the audit log of TiDB
+// +k8s:openapi-gen=true
+type TiDBAuditLogSpec struct {
+	// Plugin is the audit plugin loaded by TiDB, in the format of name-version.
+	// The plugin must be present in the plugin directory of the TiDB image.
+	// Defaults to audit-1
+	// +optional
+	Plugin string `json:"plugin,omitempty"`
+
+	// File is the name of the audit log file in the audit log volume, which is
+	// mounted to TiDB and the sidecar at /var/log/tidb-audit.
+	// Defaults to tidb-audit.log
+	// +optional
+	File string `json:"file,omitempty"`
+
+	// Sidecar ships the audit log, e.g. a fluent-bit container. The audit log
+	// volume is mounted to it and the path of the audit log is set to its
+	// AUDIT_LOG_FILE environment variable.
+	// Optional: Defaults to nil, which means the audit log is only kept in the Pod
+	// +optional
+	Sidecar *corev1.Container `json:"sidecar,omitempty"`
+}
+
 // TiDBSlowLogTailerSpec represents an optional log tailer sidecar with TiDB
 // +k8s:openapi-gen=true
 type TiDBSlowLogTailerSpec struct {
@@ -831,6 +2311,7 @@ type ComponentSpec struct {
 	PriorityClassName *string `json:"priorityClassName,omitempty"`
 
 	// SchedulerName of the component. Override the cluster-level one if present
+	// HA scheduling is only enforced for Pods scheduled by tidb-scheduler
 	// Optional: Defaults to cluster-level setting
 	// +optional
 	SchedulerName *string `json:"schedulerName,omitempty"`
@@ -859,6 +2340,15 @@ type ComponentSpec struct {
 	// +optional
 	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
 
+	// SecurityContext of the main container of the component, e.g. to set
+	// readOnlyRootFilesystem or drop capabilities. The privileged flag of TiKV and
+	// TiFlash defaults to the cluster-level setting if it is not set, and a writable
+	// emptyDir is mounted at /tmp if the root filesystem is read-only.
+	// It is not applied to the init containers that need the privileges, e.g. the
+	// sysctl init container.
+	// +optional
+	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+
 	// ConfigUpdateStrategy of the component. Override the cluster-level updateStrategy if present
 	// Optional: Defaults to cluster-level setting
 	// +optional
@@ -919,6 +2409,44 @@ type ComponentSpec struct {
 	// +listType=map
 	// +listMapKey=topologyKey
 	TopologySpreadConstraints []TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// FailoverTopologySpreadConstraints describes how the Pods provisioned by failover
+	// ought to spread across topology domains. The replacement Pods prefer the domains
+	// holding the fewest Pods of the component, so that they rebalance the component
+	// instead of clustering in one domain. The constraints of a replacement Pod are
+	// generated with WhenUnsatisfiable set to ScheduleAnyway, so that the failover is
+	// never blocked by the domain that failed.
+	// This field is only honored by PD, TiKV, TiFlash and TiDB, and requires the pod
+	// admission webhook.
+	// Optional: Defaults to the TopologySpreadConstraints of the component
+	// +optional
+	// +listType=map
+	// +listMapKey=topologyKey
+	FailoverTopologySpreadConstraints []TopologySpreadConstraint `json:"failoverTopologySpreadConstraints,omitempty"`
+
+	// EphemeralStorage sets the ephemeral storage request and limit of the component container
+	// and the size limit of the emptyDir volumes created for it.
+	// It overrides the ephemeral-storage in requests and limits if present.
+	// +optional
+	EphemeralStorage *EphemeralStorageSpec `json:"ephemeralStorage,omitempty"`
+}
+
+// EphemeralStorageSpec describes the ephemeral storage usage of a component
+// +k8s:openapi-gen=true
+type EphemeralStorageSpec struct {
+	// Request is the ephemeral storage request of the component container
+	// +optional
+	Request *resource.Quantity `json:"request,omitempty"`
+
+	// Limit is the ephemeral storage limit of the component container,
+	// the pod is evicted if its usage exceeds the limit
+	// +optional
+	Limit *resource.Quantity `json:"limit,omitempty"`
+
+	// EmptyDirSizeLimit is the size limit of the emptyDir volumes created by the
+	// operator for the component, e.g. the slow log volume of TiDB
+	// +optional
+	EmptyDirSizeLimit *resource.Quantity `json:"emptyDirSizeLimit,omitempty"`
 }
 
 // ServiceSpec specifies the service object in k8s
@@ -1010,6 +2538,35 @@ type PDStatus struct {
 	FailureMembers  map[string]PDFailureMember `json:"failureMembers,omitempty"`
 	UnjoinedMembers map[string]UnjoinedMember  `json:"unjoinedMembers,omitempty"`
 	Image           string                     `json:"image,omitempty"`
+	// Balance is the progress of the region and leader balance of PD
+	// +optional
+	Balance *PDBalanceStatus `json:"balance,omitempty"`
+	// EtcdDefragTime is the last time the etcd embedded in each PD member is
+	// defragmented, keyed by the member name
+	// +optional
+	EtcdDefragTime map[string]metav1.Time `json:"etcdDefragTime,omitempty"`
+	// LostPVCSince is the time the data PVC of each PD Pod is found lost, i.e.
+	// the PVC or the PV bound to it is gone, keyed by the Pod name
+	// +optional
+	LostPVCSince map[string]metav1.Time `json:"lostPVCSince,omitempty"`
+	// Upgrade is the progress of the ongoing rolling upgrade
+	// +optional
+	Upgrade *UpgradeProgress `json:"upgrade,omitempty"`
+}
+
+// PDBalanceStatus is the progress of the region and leader balance of PD.
+// Tools can wait for the balance to quiesce by checking PendingOperators is 0.
+type PDBalanceStatus struct {
+	// PendingOperators is the number of operators PD is running
+	PendingOperators int32 `json:"pendingOperators"`
+	// LeaderBalanceProgress is the min leader count of the Up stores over the
+	// max one, in percent. 100 means the leaders are evenly distributed.
+	LeaderBalanceProgress int32 `json:"leaderBalanceProgress"`
+	// RegionBalanceProgress is the min region count of the Up stores over the
+	// max one, in percent. 100 means the regions are evenly distributed.
+	RegionBalanceProgress int32 `json:"regionBalanceProgress"`
+	// LastUpdateTime is the last time the status is updated
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
 }
 
 // PDMember is PD member
@@ -1022,6 +2579,12 @@ type PDMember struct {
 	Health    bool   `json:"health"`
 	// Last time the health transitioned from one to another.
 	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// IsLearner indicates the member is a learner that has not been promoted to a voter
+	// +optional
+	IsLearner bool `json:"isLearner,omitempty"`
+	// LearnerSince is the time the member was first found to be a learner
+	// +optional
+	LearnerSince *metav1.Time `json:"learnerSince,omitempty"`
 }
 
 // PDFailureMember is the pd failure member information
@@ -1031,7 +2594,10 @@ type PDFailureMember struct {
 	PVCUID        types.UID              `json:"pvcUID,omitempty"`
 	PVCUIDSet     map[types.UID]struct{} `json:"pvcUIDSet,omitempty"`
 	MemberDeleted bool                   `json:"memberDeleted,omitempty"`
-	CreatedAt     metav1.Time            `json:"createdAt,omitempty"`
+	// PodRecreated indicates the Pod of the member is recreated with its PVC
+	// kept, as the FailoverPVCPolicy is Reuse, the member is not deleted
+	PodRecreated bool        `json:"podRecreated,omitempty"`
+	CreatedAt    metav1.Time `json:"createdAt,omitempty"`
 }
 
 // UnjoinedMember is the pd unjoin cluster member information
@@ -1050,6 +2616,69 @@ type TiDBStatus struct {
 	FailureMembers           map[string]TiDBFailureMember `json:"failureMembers,omitempty"`
 	ResignDDLOwnerRetryCount int32                        `json:"resignDDLOwnerRetryCount,omitempty"`
 	Image                    string                       `json:"image,omitempty"`
+	// BlueGreen is the progress of the ongoing blue/green switch of the TiDB tier
+	// +optional
+	BlueGreen *TiDBBlueGreenStatus `json:"blueGreen,omitempty"`
+	// PlacementPolicies are the states of the placement policies in the spec,
+	// keyed by the policy name
+	// +optional
+	PlacementPolicies map[string]PlacementPolicyStatus `json:"placementPolicies,omitempty"`
+	// SystemVariables are the states of the system variables of the config
+	// profile and the spec, keyed by the variable name
+	// +optional
+	SystemVariables map[string]SystemVariableStatus `json:"systemVariables,omitempty"`
+	// IncompatibleDataPods are the Pods failing to start as the data is
+	// incompatible with their version of TiDB
+	// +optional
+	IncompatibleDataPods []string `json:"incompatibleDataPods,omitempty"`
+	// Upgrade is the progress of the ongoing rolling upgrade
+	// +optional
+	Upgrade *UpgradeProgress `json:"upgrade,omitempty"`
+}
+
+// PlacementPolicyStatus is the state of a placement policy of TiDB
+type PlacementPolicyStatus struct {
+	// Synced indicates whether the placement policy in TiDB matches the spec
+	Synced bool `json:"synced"`
+	// Message is the error of the last sync if it is not synced
+	// +optional
+	Message string `json:"message,omitempty"`
+	// LastSyncTime is the last time the placement policy is checked
+	// +optional
+	LastSyncTime metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+// SystemVariableStatus is the state of a global system variable of TiDB
+type SystemVariableStatus struct {
+	// Value is the desired value of the system variable
+	Value string `json:"value"`
+	// Synced indicates whether the system variable in TiDB has the desired value
+	Synced bool `json:"synced"`
+	// Message is the error of the last sync if it is not synced
+	// +optional
+	Message string `json:"message,omitempty"`
+	// LastSyncTime is the last time the system variable is checked
+	// +optional
+	LastSyncTime metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+// TiDBBlueGreenPhase is the phase of a blue/green switch of the TiDB tier
+type TiDBBlueGreenPhase string
+
+const (
+	// TiDBBlueGreenGreenPending means the green StatefulSet is created and the
+	// TiDB Service is switched to it when all its Pods are ready
+	TiDBBlueGreenGreenPending TiDBBlueGreenPhase = "GreenPending"
+	// TiDBBlueGreenGreenActive means the TiDB Service is switched to the green
+	// StatefulSet and the blue one is recreated with the new template
+	TiDBBlueGreenGreenActive TiDBBlueGreenPhase = "GreenActive"
+)
+
+// TiDBBlueGreenStatus is the status of a blue/green switch of the TiDB tier
+type TiDBBlueGreenStatus struct {
+	Phase TiDBBlueGreenPhase `json:"phase"`
+	// Last time the phase transitioned from one to another.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
 }
 
 // TiDBMember is TiDB member
@@ -1079,6 +2708,19 @@ type TiKVStatus struct {
 	TombstoneStores map[string]TiKVStore        `json:"tombstoneStores,omitempty"`
 	FailureStores   map[string]TiKVFailureStore `json:"failureStores,omitempty"`
 	Image           string                      `json:"image,omitempty"`
+	// EvictLeaderStores are the IDs of the stores that the operator has added
+	// an evict leader scheduler for, they are used to remove the schedulers
+	// left behind by an interrupted upgrade.
+	// +optional
+	EvictLeaderStores []string `json:"evictLeaderStores,omitempty"`
+	// StoreMigrations are the progress of migrating the stores off the nodes
+	// in the tikv.tidb.pingcap.com/migrate-off-nodes(-selector) annotation,
+	// keyed by the Pod name.
+	// +optional
+	StoreMigrations map[string]TiKVStoreMigration `json:"storeMigrations,omitempty"`
+	// Upgrade is the progress of the ongoing rolling upgrade
+	// +optional
+	Upgrade *UpgradeProgress `json:"upgrade,omitempty"`
 }
 
 // TiFlashStatus is TiFlash status
@@ -1091,6 +2733,35 @@ type TiFlashStatus struct {
 	TombstoneStores map[string]TiKVStore        `json:"tombstoneStores,omitempty"`
 	FailureStores   map[string]TiKVFailureStore `json:"failureStores,omitempty"`
 	Image           string                      `json:"image,omitempty"`
+	// TableReplicas are the states of the TiFlash replicas of the tables in
+	// the spec, keyed by `database.table`
+	// +optional
+	TableReplicas map[string]TiFlashTableReplicaStatus `json:"tableReplicas,omitempty"`
+	// Upgrade is the progress of the ongoing rolling upgrade
+	// +optional
+	Upgrade *UpgradeProgress `json:"upgrade,omitempty"`
+}
+
+// TiFlashTableReplicaStatus is the state of the TiFlash replicas of a table
+type TiFlashTableReplicaStatus struct {
+	// Synced indicates whether the number of TiFlash replicas of the table
+	// matches the spec
+	Synced bool `json:"synced"`
+	// Replicas is the number of TiFlash replicas of the table in TiDB
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+	// Available indicates whether the TiFlash replicas of the table are available
+	// +optional
+	Available bool `json:"available,omitempty"`
+	// Progress is the replication progress of the TiFlash replicas, from 0 to 1
+	// +optional
+	Progress string `json:"progress,omitempty"`
+	// Message is the reason why the table is not synced
+	// +optional
+	Message string `json:"message,omitempty"`
+	// LastSyncTime is the last time the TiFlash replicas of the table are checked
+	// +optional
+	LastSyncTime metav1.Time `json:"lastSyncTime,omitempty"`
 }
 
 // TiCDCStatus is TiCDC status
@@ -1099,6 +2770,9 @@ type TiCDCStatus struct {
 	Phase       MemberPhase             `json:"phase,omitempty"`
 	StatefulSet *apps.StatefulSetStatus `json:"statefulSet,omitempty"`
 	Captures    map[string]TiCDCCapture `json:"captures,omitempty"`
+	// Upgrade is the progress of the ongoing rolling upgrade
+	// +optional
+	Upgrade *UpgradeProgress `json:"upgrade,omitempty"`
 }
 
 // TiCDCCapture is TiCDC Capture status
@@ -1119,13 +2793,92 @@ type TiKVStore struct {
 	State       string `json:"state"`
 	// Last time the health transitioned from one to another.
 	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// DecommissionProgress is the progress of moving the regions off the
+	// store after it is deleted in the scale-in, only recorded for TiFlash
+	// +optional
+	DecommissionProgress *StoreDecommissionProgress `json:"decommissionProgress,omitempty"`
+}
+
+// StoreDecommissionProgress is the progress of decommissioning a store
+type StoreDecommissionProgress struct {
+	// StartTime is the time the store is deleted
+	StartTime metav1.Time `json:"startTime"`
+	// InitialRegions is the count of the regions on the store when it is deleted
+	InitialRegions int32 `json:"initialRegions"`
+	// RemainingRegions is the count of the regions left on the store
+	RemainingRegions int32 `json:"remainingRegions"`
+	// EstimatedCompletionTime is estimated from the rate the regions are
+	// moved off the store since it is deleted
+	// +optional
+	EstimatedCompletionTime *metav1.Time `json:"estimatedCompletionTime,omitempty"`
+}
+
+// UpgradePodPhase is the phase of a Pod in the rolling upgrade of its component
+type UpgradePodPhase string
+
+const (
+	// UpgradePodPending means the Pod is not upgraded yet
+	UpgradePodPending UpgradePodPhase = "Pending"
+	// UpgradePodUpgrading means the Pod is being recreated at the new
+	// revision, or it's at the new revision but not ready yet
+	UpgradePodUpgrading UpgradePodPhase = "Upgrading"
+	// UpgradePodUpgraded means the Pod is at the new revision and ready
+	UpgradePodUpgraded UpgradePodPhase = "Upgraded"
+)
+
+// UpgradeProgress is the progress of the rolling upgrade of a component
+type UpgradeProgress struct {
+	// Revision is the StatefulSet revision the component is upgraded to
+	Revision string `json:"revision"`
+	// CurrentPartition is the partition of the StatefulSet, the Pods with an
+	// ordinal not less than it are upgraded
+	CurrentPartition int32 `json:"currentPartition"`
+	// Replicas is the number of the Pods of the component
+	Replicas int32 `json:"replicas"`
+	// UpgradedReplicas is the number of the Pods at the revision and ready
+	UpgradedReplicas int32 `json:"upgradedReplicas"`
+	// StartTime is the time the upgrade to the revision starts
+	StartTime metav1.Time `json:"startTime"`
+	// EstimatedCompletionTime is estimated from the rate the Pods are
+	// upgraded since the upgrade starts
+	// +optional
+	EstimatedCompletionTime *metav1.Time `json:"estimatedCompletionTime,omitempty"`
+	// Pods are the phases of the Pods in the upgrade, keyed by the Pod name
+	// +optional
+	Pods map[string]UpgradePodPhase `json:"pods,omitempty"`
+}
+
+// TiKVStoreMigrationPhase is the phase of migrating a TiKV store off its node
+type TiKVStoreMigrationPhase string
+
+const (
+	// TiKVStoreMigrationOffline means the store is deleted from PD and its
+	// regions are being moved to the other stores
+	TiKVStoreMigrationOffline TiKVStoreMigrationPhase = "Offline"
+	// TiKVStoreMigrationRecreating means the store becomes tombstone, the Pod
+	// and its PVCs are deleted and a new store is being started
+	TiKVStoreMigrationRecreating TiKVStoreMigrationPhase = "Recreating"
+	// TiKVStoreMigrationCompleted means the new store of the Pod is up
+	TiKVStoreMigrationCompleted TiKVStoreMigrationPhase = "Completed"
+)
+
+// TiKVStoreMigration is the progress of migrating a TiKV store off its node
+type TiKVStoreMigration struct {
+	StoreID  string                  `json:"storeID"`
+	NodeName string                  `json:"nodeName"`
+	Phase    TiKVStoreMigrationPhase `json:"phase"`
+	// Last time the phase transitioned from one to another.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
 }
 
 // TiKVFailureStore is the tikv failure store information
 type TiKVFailureStore struct {
-	PodName   string      `json:"podName,omitempty"`
-	StoreID   string      `json:"storeID,omitempty"`
-	CreatedAt metav1.Time `json:"createdAt,omitempty"`
+	PodName string `json:"podName,omitempty"`
+	StoreID string `json:"storeID,omitempty"`
+	// PVCRecreated indicates the store is deleted from PD and the Pod and
+	// the PVC of the store are deleted, as the FailoverPVCPolicy is Recreate
+	PVCRecreated bool        `json:"pvcRecreated,omitempty"`
+	CreatedAt    metav1.Time `json:"createdAt,omitempty"`
 }
 
 // PumpNodeStatus represents the status saved in etcd.
@@ -1146,6 +2899,9 @@ type PumpStatus struct {
 	Phase       MemberPhase             `json:"phase,omitempty"`
 	StatefulSet *apps.StatefulSetStatus `json:"statefulSet,omitempty"`
 	Members     []*PumpNodeStatus       `json:"members,omitempty"`
+	// Upgrade is the progress of the ongoing rolling upgrade
+	// +optional
+	Upgrade *UpgradeProgress `json:"upgrade,omitempty"`
 }
 
 // TiDBTLSClient can enable TLS connection between TiDB server and MySQL client
@@ -1549,11 +3305,28 @@ type BackupStatus struct {
 	BackupSize int64 `json:"backupSize"`
 	// CommitTs is the snapshot time point of tidb cluster.
 	CommitTs string `json:"commitTs"`
+	// GCLifeTime is the tidb_gc_life_time raised by the operator for the backup
+	// +optional
+	GCLifeTime *BackupGCLifeTimeStatus `json:"gcLifeTime,omitempty"`
 	// Phase is a user readable state inferred from the underlying Backup conditions
 	Phase      BackupConditionType `json:"phase"`
 	Conditions []BackupCondition   `json:"conditions"`
 }
 
+// BackupGCLifeTimeStatus is the tidb_gc_life_time of the cluster raised by the
+// operator to keep the data of a running backup from GC.
+// The backups of a cluster that overlap share the same original value, which
+// is restored when the last of them completes.
+type BackupGCLifeTimeStatus struct {
+	// Original is the tidb_gc_life_time of the cluster before it is raised
+	Original string `json:"original"`
+	// Raised is the tidb_gc_life_time set for the backup
+	Raised string `json:"raised"`
+	// Released is true once the backup no longer needs the raised tidb_gc_life_time
+	// +optional
+	Released bool `json:"released,omitempty"`
+}
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -1918,6 +3691,14 @@ type DMClusterSpec struct {
 	// +listType=map
 	// +listMapKey=topologyKey
 	TopologySpreadConstraints []TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// MaintenanceWindow limits when the upgrades and the scales of the
+	// components may go on. Outside the window, the upgrade of the next Pod
+	// and the scales are queued and reported in the WaitingForMaintenanceWindow
+	// condition, the Pod being upgraded is not interrupted.
+	// Optional: Defaults to nil, which means the operations may start at any time
+	// +optional
+	MaintenanceWindow *MaintenanceWindow `json:"maintenanceWindow,omitempty"`
 }
 
 // DMClusterStatus represents the current status of a dm cluster.
@@ -2075,6 +3856,10 @@ const (
 	// - All Master members are healthy.
 	// - All Worker pods are up.
 	DMClusterReady DMClusterConditionType = "Ready"
+	// DMClusterWaitingForMaintenanceWindow indicates that an upgrade or a
+	// scale is queued until `.spec.maintenanceWindow` opens, the message
+	// tells the component and when the window opens.
+	DMClusterWaitingForMaintenanceWindow DMClusterConditionType = "WaitingForMaintenanceWindow"
 )
 
 // MasterStatus is dm-master status
@@ -2125,14 +3910,29 @@ type WorkerMember struct {
 	Name  string `json:"name,omitempty"`
 	Addr  string `json:"addr,omitempty"`
 	Stage string `json:"stage"`
+	// Source is the upstream source bound to the dm-worker, it is kept after
+	// the dm-worker goes offline so that the source can be transferred to
+	// another dm-worker in failover
+	Source string `json:"source,omitempty"`
 	// Last time the health transitioned from one to another.
 	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// StuckSince is since when the subtasks on the bound dm-worker are paused
+	// by errors, the dm-worker is failed over if it is stuck for longer than
+	// the failover period
+	StuckSince *metav1.Time `json:"stuckSince,omitempty"`
 }
 
 // WorkerFailureMember is the dm-worker failure member information
 type WorkerFailureMember struct {
 	PodName   string      `json:"podName,omitempty"`
 	CreatedAt metav1.Time `json:"createdAt,omitempty"`
+	// Source is the upstream source bound to the dm-worker when it failed
+	Source string `json:"source,omitempty"`
+	// SourceTransferredTo is the dm-worker the source is transferred to
+	SourceTransferredTo string `json:"sourceTransferredTo,omitempty"`
+	// SourceTransferDeferred is whether the source is left to dm-master to
+	// rebind, as the OpenAPI of dm-master to transfer it is disabled
+	SourceTransferDeferred bool `json:"sourceTransferDeferred,omitempty"`
 }
 
 // StorageVolume configures additional PVC template for StatefulSets and volumeMount for pods that mount this PVC.
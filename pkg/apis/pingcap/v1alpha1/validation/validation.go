@@ -16,11 +16,14 @@ package validation
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -28,6 +31,7 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/prometheus/common/model"
+	"github.com/robfig/cron"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	apivalidation "k8s.io/apimachinery/pkg/api/validation"
@@ -36,6 +40,11 @@ import (
 	utilnet "k8s.io/utils/net"
 )
 
+// MaxMinorVersionSkip is the max number of TiDB minor versions an upgrade may
+// skip, e.g. v5.2 to v5.4 skips 1 minor version. It is set by the flag of the
+// admission webhook, a negative value disables the check.
+var MaxMinorVersionSkip = 1
+
 // ValidateTidbCluster validates a TidbCluster, it performs basic validation for all TidbClusters despite it is legacy
 // or not
 func ValidateTidbCluster(tc *v1alpha1.TidbCluster) field.ErrorList {
@@ -46,6 +55,9 @@ func ValidateTidbCluster(tc *v1alpha1.TidbCluster) field.ErrorList {
 	allErrs = append(allErrs, validateAnnotations(tc.ObjectMeta.Annotations, fldPath.Child("annotations"))...)
 	// validate spec
 	allErrs = append(allErrs, validateTiDBClusterSpec(&tc.Spec, field.NewPath("spec"))...)
+	if tc.Spec.TiDB != nil && tc.Spec.TiDB.AuditLog != nil {
+		allErrs = append(allErrs, validateTiDBAuditLog(tc.Spec.TiDB.AuditLog, tc.TiDBVersion(), field.NewPath("spec", "tidb", "auditLog"))...)
+	}
 	return allErrs
 }
 
@@ -81,7 +93,7 @@ func ValidateTidbMonitor(monitor *v1alpha1.TidbMonitor) field.ErrorList {
 func validateAnnotations(anns map[string]string, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 	allErrs = append(allErrs, apivalidation.ValidateAnnotations(anns, fldPath)...)
-	for _, key := range []string{label.AnnPDDeleteSlots, label.AnnTiDBDeleteSlots, label.AnnTiKVDeleteSlots, label.AnnTiFlashDeleteSlots} {
+	for _, key := range []string{label.AnnPDDeleteSlots, label.AnnTiDBDeleteSlots, label.AnnTiKVDeleteSlots, label.AnnTiFlashDeleteSlots, label.AnnTiCDCDeleteSlots, label.AnnPumpDeleteSlots} {
 		allErrs = append(allErrs, validateDeleteSlots(anns, key, fldPath.Child(key))...)
 	}
 	return allErrs
@@ -121,6 +133,171 @@ func validateTiDBClusterSpec(spec *v1alpha1.TidbClusterSpec, fldPath *field.Path
 	if spec.PDAddresses != nil {
 		allErrs = append(allErrs, validatePDAddresses(spec.PDAddresses, fldPath.Child("pdAddresses"))...)
 	}
+	if spec.TiKVTiFlashAntiAffinity != nil {
+		allErrs = append(allErrs, validateCrossComponentAntiAffinity(spec.TiKVTiFlashAntiAffinity, fldPath.Child("tikvTiFlashAntiAffinity"))...)
+	}
+	if spec.StalePVCPolicy != nil {
+		allErrs = append(allErrs, validateStalePVCPolicy(*spec.StalePVCPolicy, fldPath.Child("stalePVCPolicy"))...)
+	}
+	if spec.SnapshotBeforeDeletingPVC != nil {
+		allErrs = append(allErrs, validateTimeDurationStr(spec.SnapshotBeforeDeletingPVC.Retention, fldPath.Child("snapshotBeforeDeletingPVC", "retention"))...)
+	}
+	if spec.UpgradeCrashLoopPolicy != nil {
+		allErrs = append(allErrs, validateTimeDurationStr(spec.UpgradeCrashLoopPolicy.Threshold, fldPath.Child("upgradeCrashLoopPolicy", "threshold"))...)
+	}
+	if spec.PodTemplateWebhook != nil {
+		allErrs = append(allErrs, validatePodTemplateWebhook(spec.PodTemplateWebhook, fldPath.Child("podTemplateWebhook"))...)
+	}
+	if spec.PVCResizeFailurePolicy != nil {
+		allErrs = append(allErrs, validateTimeDurationStr(spec.PVCResizeFailurePolicy.FileSystemResizeTimeout, fldPath.Child("pvcResizeFailurePolicy", "fileSystemResizeTimeout"))...)
+	}
+	allErrs = append(allErrs, validateTimeDurationStr(spec.PVCDeferDeletePeriod, fldPath.Child("pvcDeferDeletePeriod"))...)
+	if spec.PodRestartPolicy != nil {
+		if spec.PodRestartPolicy.Threshold != nil && *spec.PodRestartPolicy.Threshold < 1 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("podRestartPolicy", "threshold"), *spec.PodRestartPolicy.Threshold, "must be greater than 0"))
+		}
+		allErrs = append(allErrs, validateTimeDurationStr(spec.PodRestartPolicy.Window, fldPath.Child("podRestartPolicy", "window"))...)
+	}
+	if spec.RolloutStallPolicy != nil {
+		allErrs = append(allErrs, validateTimeDurationStr(spec.RolloutStallPolicy.Threshold, fldPath.Child("rolloutStallPolicy", "threshold"))...)
+	}
+	if spec.ScalePolicy != nil && spec.ScalePolicy.MaxScaleVelocity != nil {
+		velocity := spec.ScalePolicy.MaxScaleVelocity
+		fldPath := fldPath.Child("scalePolicy", "maxScaleVelocity")
+		if velocity.Members < 1 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("members"), velocity.Members, "must be greater than 0"))
+		}
+		allErrs = append(allErrs, validateTimeDurationStr(velocity.Window, fldPath.Child("window"))...)
+	}
+	if spec.MaintenanceWindow != nil {
+		allErrs = append(allErrs, validateMaintenanceWindow(spec.MaintenanceWindow, fldPath.Child("maintenanceWindow"))...)
+	}
+	if spec.UpgradePolicy != nil && spec.UpgradePolicy.PreChecks != nil {
+		allErrs = append(allErrs, validateUpgradePreChecks(spec.UpgradePolicy.PreChecks, fldPath.Child("upgradePolicy", "preChecks"))...)
+	}
+	if spec.UpgradePolicy != nil && spec.UpgradePolicy.AutoRollback != nil {
+		allErrs = append(allErrs, validateTimeDurationStr(spec.UpgradePolicy.AutoRollback.Threshold, fldPath.Child("upgradePolicy", "autoRollback", "threshold"))...)
+	}
+	return allErrs
+}
+
+func validateMaintenanceWindow(window *v1alpha1.MaintenanceWindow, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if _, err := cron.ParseStandard(window.Schedule); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("schedule"), window.Schedule, fmt.Sprintf("must be a standard 5-field cron expression, e.g. 0 2 * * 6: %v", err)))
+	}
+	allErrs = append(allErrs, validateTimeDurationStr(&window.Duration, fldPath.Child("duration"))...)
+	return allErrs
+}
+
+func validateUpgradePreChecks(checks *v1alpha1.UpgradePreChecks, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if checks.MaxDownPeerRegions != nil && *checks.MaxDownPeerRegions < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxDownPeerRegions"), *checks.MaxDownPeerRegions, "must not be negative"))
+	}
+	if checks.MaxPendingPeerRegions != nil && *checks.MaxPendingPeerRegions < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxPendingPeerRegions"), *checks.MaxPendingPeerRegions, "must not be negative"))
+	}
+	if checks.MaxUnhealthyStores != nil && *checks.MaxUnhealthyStores < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxUnhealthyStores"), *checks.MaxUnhealthyStores, "must not be negative"))
+	}
+	return allErrs
+}
+
+func validatePodTemplateWebhook(webhook *v1alpha1.PodTemplateWebhook, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	u, err := url.Parse(webhook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("url"), webhook.URL, "must be an absolute http or https URL"))
+	}
+	if webhook.TimeoutSeconds != nil && *webhook.TimeoutSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("timeoutSeconds"), *webhook.TimeoutSeconds, "must be greater than 0"))
+	}
+	if webhook.FailurePolicy != nil {
+		switch *webhook.FailurePolicy {
+		case v1alpha1.PodTemplateWebhookFailurePolicyFail, v1alpha1.PodTemplateWebhookFailurePolicyIgnore:
+		default:
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("failurePolicy"), *webhook.FailurePolicy,
+				[]string{string(v1alpha1.PodTemplateWebhookFailurePolicyFail), string(v1alpha1.PodTemplateWebhookFailurePolicyIgnore)}))
+		}
+	}
+	return allErrs
+}
+
+// validateFailover validates the failover thresholds of a component
+func validateFailover(maxFailoverCount *int32, failover *v1alpha1.FailoverSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if maxFailoverCount != nil && *maxFailoverCount < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxFailoverCount"), *maxFailoverCount, "must be greater than or equal to 0"))
+	}
+	if failover == nil {
+		return allErrs
+	}
+	allErrs = append(allErrs, validateTimeDurationStr(failover.DetectionPeriod, fldPath.Child("failover", "detectionPeriod"))...)
+	if failover.MaxFailoverCount != nil && *failover.MaxFailoverCount < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("failover", "maxFailoverCount"), *failover.MaxFailoverCount, "must be greater than or equal to 0"))
+	}
+	return allErrs
+}
+
+func validateScaleHooks(hooks *v1alpha1.ScaleHooks, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if hooks.PreScaleIn != nil {
+		allErrs = append(allErrs, validateScaleHook(hooks.PreScaleIn, fldPath.Child("preScaleIn"))...)
+	}
+	if hooks.PostScale != nil {
+		allErrs = append(allErrs, validateScaleHook(hooks.PostScale, fldPath.Child("postScale"))...)
+	}
+	return allErrs
+}
+
+func validateScaleHook(hook *v1alpha1.ScaleHook, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if (hook.HTTP == nil) == (hook.Job == nil) {
+		return append(allErrs, field.Invalid(fldPath, hook, "exactly one of http and job must be set"))
+	}
+	if hook.HTTP != nil {
+		u, err := url.Parse(hook.HTTP.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("http", "url"), hook.HTTP.URL, "must be an absolute http or https URL"))
+		}
+		if hook.HTTP.TimeoutSeconds != nil && *hook.HTTP.TimeoutSeconds <= 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("http", "timeoutSeconds"), *hook.HTTP.TimeoutSeconds, "must be greater than 0"))
+		}
+	}
+	if hook.Job != nil && hook.Job.Image == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("job", "image"), "image of the hook job must be set"))
+	}
+	return allErrs
+}
+
+func validateUpgradeHooks(hooks *v1alpha1.UpgradeHooks, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if hooks.PreUpgrade != nil {
+		allErrs = append(allErrs, validateUpgradeHook(hooks.PreUpgrade, fldPath.Child("preUpgrade"))...)
+	}
+	if hooks.PostUpgrade != nil {
+		allErrs = append(allErrs, validateUpgradeHook(hooks.PostUpgrade, fldPath.Child("postUpgrade"))...)
+	}
+	return allErrs
+}
+
+func validateUpgradeHook(hook *v1alpha1.UpgradeHook, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if hook.Job == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("job"), "job of the hook must be set"))
+	} else if hook.Job.Image == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("job", "image"), "image of the hook job must be set"))
+	}
+	if hook.TimeoutSeconds != nil && *hook.TimeoutSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("timeoutSeconds"), *hook.TimeoutSeconds, "must be greater than 0"))
+	}
+	switch hook.FailurePolicy {
+	case "", v1alpha1.UpgradeHookFailurePolicyFail, v1alpha1.UpgradeHookFailurePolicyIgnore:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("failurePolicy"), hook.FailurePolicy,
+			[]string{string(v1alpha1.UpgradeHookFailurePolicyFail), string(v1alpha1.UpgradeHookFailurePolicyIgnore)}))
+	}
 	return allErrs
 }
 
@@ -139,6 +316,154 @@ func validatePDSpec(spec *v1alpha1.PDSpec, fldPath *field.Path) field.ErrorList
 	if len(spec.StorageVolumes) > 0 {
 		allErrs = append(allErrs, validateStorageVolumes(spec.StorageVolumes, fldPath.Child("storageVolumes"))...)
 	}
+	allErrs = append(allErrs, validatePDSchedulers(spec.Schedulers, fldPath.Child("schedulers"))...)
+	if spec.RegionSize != nil {
+		allErrs = append(allErrs, validatePDRegionSize(spec.RegionSize, fldPath.Child("regionSize"))...)
+	}
+	if spec.BalanceLimits != nil {
+		allErrs = append(allErrs, validatePDBalanceLimits(spec.BalanceLimits, fldPath.Child("balanceLimits"))...)
+	}
+	allErrs = append(allErrs, validateTimeDurationStr(spec.LearnerTimeout, fldPath.Child("learnerTimeout"))...)
+	allErrs = append(allErrs, validateTimeDurationStr(spec.LostPVCGracePeriod, fldPath.Child("lostPVCGracePeriod"))...)
+	if spec.FailoverPVCPolicy != nil {
+		allErrs = append(allErrs, validateFailoverPVCPolicy(*spec.FailoverPVCPolicy, fldPath.Child("failoverPVCPolicy"))...)
+	}
+	allErrs = append(allErrs, validateTimeDurationStr(spec.EtcdDefragInterval, fldPath.Child("etcdDefragInterval"))...)
+	allErrs = append(allErrs, validatePDScaleInLeaderTransferTimeout(spec.ScaleInLeaderTransferTimeout, fldPath.Child("scaleInLeaderTransferTimeout"))...)
+	if spec.UpgradeStabilizationGate != nil {
+		allErrs = append(allErrs, validateMetricStabilizationGate(spec.UpgradeStabilizationGate, fldPath.Child("upgradeStabilizationGate"))...)
+	}
+	allErrs = append(allErrs, validateFailover(spec.MaxFailoverCount, spec.Failover, fldPath)...)
+	if spec.ScaleHooks != nil {
+		allErrs = append(allErrs, validateScaleHooks(spec.ScaleHooks, fldPath.Child("scaleHooks"))...)
+	}
+	if spec.UpgradeHooks != nil {
+		allErrs = append(allErrs, validateUpgradeHooks(spec.UpgradeHooks, fldPath.Child("upgradeHooks"))...)
+	}
+	allErrs = append(allErrs, validatePDGroups(spec.Groups, fldPath.Child("groups"))...)
+	return allErrs
+}
+
+// validatePDGroups validates the PD groups have unique DNS label names
+func validatePDGroups(groups []v1alpha1.PDGroup, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	names := map[string]bool{}
+	for i, group := range groups {
+		idxPath := fldPath.Index(i)
+		for _, msg := range validation.IsDNS1123Label(group.Name) {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("name"), group.Name, msg))
+		}
+		if names[group.Name] {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), group.Name))
+		}
+		names[group.Name] = true
+		if group.Replicas < 0 {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("replicas"), group.Replicas, "must be greater than or equal to 0"))
+		}
+	}
+	return allErrs
+}
+
+// maxPDScaleInLeaderTransferTimeout is the max time the reconcile is blocked
+// to wait for the PD leader transfer in a scale-in
+const maxPDScaleInLeaderTransferTimeout = time.Minute
+
+// validatePDScaleInLeaderTransferTimeout validates the wait for the PD leader
+// transfer in a scale-in
+func validatePDScaleInLeaderTransferTimeout(timeout *string, fldPath *field.Path) field.ErrorList {
+	allErrs := validateTimeDurationStr(timeout, fldPath)
+	if len(allErrs) > 0 || timeout == nil {
+		return allErrs
+	}
+	if d, _ := time.ParseDuration(*timeout); d > maxPDScaleInLeaderTransferTimeout {
+		allErrs = append(allErrs, field.Invalid(fldPath, *timeout, fmt.Sprintf("must not exceed %v", maxPDScaleInLeaderTransferTimeout)))
+	}
+	return allErrs
+}
+
+func validateFailoverPVCPolicy(policy v1alpha1.FailoverPVCPolicy, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	switch policy {
+	case v1alpha1.FailoverPVCPolicyReuse, v1alpha1.FailoverPVCPolicyRecreate:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath, policy,
+			[]string{string(v1alpha1.FailoverPVCPolicyReuse), string(v1alpha1.FailoverPVCPolicyRecreate)}))
+	}
+	return allErrs
+}
+
+func validateStalePVCPolicy(policy v1alpha1.StalePVCPolicy, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	switch policy {
+	case v1alpha1.StalePVCPolicyReuse, v1alpha1.StalePVCPolicyBlock, v1alpha1.StalePVCPolicyWipe:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath, policy,
+			[]string{string(v1alpha1.StalePVCPolicyReuse), string(v1alpha1.StalePVCPolicyBlock), string(v1alpha1.StalePVCPolicyWipe)}))
+	}
+	return allErrs
+}
+
+func validatePDSchedulers(schedulers []v1alpha1.PDScheduler, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	names := map[string]bool{}
+	for i, scheduler := range schedulers {
+		idxPath := fldPath.Index(i).Child("name")
+		if len(scheduler.Name) == 0 {
+			allErrs = append(allErrs, field.Required(idxPath, "scheduler name must not be empty"))
+			continue
+		}
+		if names[scheduler.Name] {
+			allErrs = append(allErrs, field.Duplicate(idxPath, scheduler.Name))
+		}
+		names[scheduler.Name] = true
+	}
+	return allErrs
+}
+
+func validatePDRegionSize(regionSize *v1alpha1.PDRegionSizeConfig, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	parse := func(size *string, fldPath *field.Path) uint64 {
+		if size == nil {
+			return 0
+		}
+		n, err := v1alpha1.ParseRegionSize(*size)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath, *size, err.Error()))
+			return 0
+		}
+		if n < v1alpha1.MinRegionSize || n > v1alpha1.MaxRegionSize {
+			allErrs = append(allErrs, field.Invalid(fldPath, *size, "must be within [1MiB, 10GiB]"))
+			return 0
+		}
+		return n
+	}
+	maxSize := parse(regionSize.RegionMaxSize, fldPath.Child("regionMaxSize"))
+	splitSize := parse(regionSize.RegionSplitSize, fldPath.Child("regionSplitSize"))
+	if maxSize > 0 && splitSize > 0 && maxSize < splitSize {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("regionMaxSize"), *regionSize.RegionMaxSize, "must not be smaller than regionSplitSize"))
+	}
+	return allErrs
+}
+
+func validatePDBalanceLimits(limits *v1alpha1.PDBalanceLimits, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	positive := func(limit *int32, fldPath *field.Path) {
+		if limit != nil && *limit <= 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath, *limit, "must be greater than 0"))
+		}
+	}
+	nonnegative := func(limit *int32, fldPath *field.Path) {
+		if limit != nil && *limit < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath, *limit, "must not be negative"))
+		}
+	}
+	positive(limits.MaxPendingPeerCount, fldPath.Child("maxPendingPeerCount"))
+	positive(limits.MaxSnapshotCount, fldPath.Child("maxSnapshotCount"))
+	nonnegative(limits.LeaderScheduleLimit, fldPath.Child("leaderScheduleLimit"))
+	nonnegative(limits.RegionScheduleLimit, fldPath.Child("regionScheduleLimit"))
+	nonnegative(limits.ReplicaScheduleLimit, fldPath.Child("replicaScheduleLimit"))
+	nonnegative(limits.MergeScheduleLimit, fldPath.Child("mergeScheduleLimit"))
+	nonnegative(limits.HotRegionScheduleLimit, fldPath.Child("hotRegionScheduleLimit"))
 	return allErrs
 }
 
@@ -157,6 +482,17 @@ func validatePDAddresses(arrayOfAddresses []string, fldPath *field.Path) field.E
 	return allErrs
 }
 
+func validateCrossComponentAntiAffinity(anti *v1alpha1.CrossComponentAntiAffinity, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	switch anti.Type {
+	case "", v1alpha1.AntiAffinityTypePreferred, v1alpha1.AntiAffinityTypeRequired:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("type"), anti.Type,
+			[]string{string(v1alpha1.AntiAffinityTypePreferred), string(v1alpha1.AntiAffinityTypeRequired)}))
+	}
+	return allErrs
+}
+
 func validateTiKVSpec(spec *v1alpha1.TiKVSpec, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 	allErrs = append(allErrs, validateComponentSpec(&spec.ComponentSpec, fldPath)...)
@@ -167,7 +503,91 @@ func validateTiKVSpec(spec *v1alpha1.TiKVSpec, fldPath *field.Path) field.ErrorL
 	if len(spec.StorageVolumes) > 0 {
 		allErrs = append(allErrs, validateStorageVolumes(spec.StorageVolumes, fldPath.Child("storageVolumes"))...)
 	}
+	if len(spec.WalStorageSize) > 0 {
+		allErrs = append(allErrs, validateTiKVWalStorage(spec, fldPath)...)
+	}
+	if spec.PerOrdinalConfig != nil {
+		allErrs = append(allErrs, validateTiKVPerOrdinalConfig(spec, fldPath)...)
+	}
 	allErrs = append(allErrs, validateTimeDurationStr(spec.EvictLeaderTimeout, fldPath.Child("evictLeaderTimeout"))...)
+	if spec.EvictLeaderBackoff != nil {
+		allErrs = append(allErrs, validateTimeDurationStr(spec.EvictLeaderBackoff.Interval, fldPath.Child("evictLeaderBackoff", "interval"))...)
+		allErrs = append(allErrs, validateTimeDurationStr(spec.EvictLeaderBackoff.MaxInterval, fldPath.Child("evictLeaderBackoff", "maxInterval"))...)
+	}
+	allErrs = append(allErrs, validateTimeDurationStr(spec.StoreHeartbeatStaleThreshold, fldPath.Child("storeHeartbeatStaleThreshold"))...)
+	allErrs = append(allErrs, validateTimeDurationStr(spec.ScaleInStoreEmptyTimeout, fldPath.Child("scaleInStoreEmptyTimeout"))...)
+	if spec.MaxConcurrentEvictLeaders != nil && *spec.MaxConcurrentEvictLeaders < 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxConcurrentEvictLeaders"), *spec.MaxConcurrentEvictLeaders, "must be greater than 0"))
+	}
+	if spec.UpgradePolicy != nil && spec.UpgradePolicy.MaxUnavailable != nil && *spec.UpgradePolicy.MaxUnavailable < 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("upgradePolicy", "maxUnavailable"), *spec.UpgradePolicy.MaxUnavailable, "must be greater than 0"))
+	}
+	if spec.StorageCheck != nil {
+		allErrs = append(allErrs, validateStorageCheck(spec.StorageCheck, fldPath.Child("storageCheck"))...)
+	}
+	if spec.Ports != nil {
+		allErrs = append(allErrs, validateTiKVPorts(spec.Ports, fldPath.Child("ports"))...)
+	}
+	if spec.UpgradeStabilizationGate != nil {
+		allErrs = append(allErrs, validateMetricStabilizationGate(spec.UpgradeStabilizationGate, fldPath.Child("upgradeStabilizationGate"))...)
+	}
+	allErrs = append(allErrs, validateFailover(spec.MaxFailoverCount, spec.Failover, fldPath)...)
+	if spec.FailoverPVCPolicy != nil {
+		allErrs = append(allErrs, validateFailoverPVCPolicy(*spec.FailoverPVCPolicy, fldPath.Child("failoverPVCPolicy"))...)
+	}
+	if spec.ScaleHooks != nil {
+		allErrs = append(allErrs, validateScaleHooks(spec.ScaleHooks, fldPath.Child("scaleHooks"))...)
+	}
+	if spec.UpgradeHooks != nil {
+		allErrs = append(allErrs, validateUpgradeHooks(spec.UpgradeHooks, fldPath.Child("upgradeHooks"))...)
+	}
+	return allErrs
+}
+
+func validateMetricStabilizationGate(gate *v1alpha1.MetricStabilizationGate, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if _, err := url.ParseRequestURI(gate.PrometheusURL); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("prometheusURL"), gate.PrometheusURL, err.Error()))
+	}
+	if strings.TrimSpace(gate.Query) == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("query"), "query must not be empty"))
+	}
+	if _, err := strconv.ParseFloat(gate.Threshold, 64); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("threshold"), gate.Threshold, "must be a number"))
+	}
+	allErrs = append(allErrs, validateTimeDurationStr(gate.Timeout, fldPath.Child("timeout"))...)
+	return allErrs
+}
+
+func validateTiKVPorts(ports *v1alpha1.TiKVPorts, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	server, status := v1alpha1.DefaultTiKVServerPort, v1alpha1.DefaultTiKVStatusPort
+	if ports.Server != nil {
+		server = *ports.Server
+		for _, msg := range validation.IsValidPortNum(int(server)) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("server"), server, msg))
+		}
+	}
+	if ports.Status != nil {
+		status = *ports.Status
+		for _, msg := range validation.IsValidPortNum(int(status)) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("status"), status, msg))
+		}
+	}
+	if server == status {
+		allErrs = append(allErrs, field.Duplicate(fldPath.Child("status"), status))
+	}
+	return allErrs
+}
+
+func validateStorageCheck(check *v1alpha1.StorageCheckSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if check.MinWriteThroughputMB < 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("minWriteThroughputMB"), check.MinWriteThroughputMB, "must be greater than 0"))
+	}
+	if check.SizeMB != nil && *check.SizeMB < 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("sizeMB"), *check.SizeMB, "must be greater than 0"))
+	}
 	return allErrs
 }
 
@@ -179,6 +599,43 @@ func validateTiFlashSpec(spec *v1alpha1.TiFlashSpec, fldPath *field.Path) field.
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("spec.StorageClaims"),
 			spec.StorageClaims, "storageClaims should be configured at least one item."))
 	}
+	allErrs = append(allErrs, validateTiFlashTableReplicas(spec.TableReplicas, spec.Replicas, fldPath.Child("tableReplicas"))...)
+	allErrs = append(allErrs, validateFailover(spec.MaxFailoverCount, spec.Failover, fldPath)...)
+	if spec.FailoverPVCPolicy != nil {
+		allErrs = append(allErrs, validateFailoverPVCPolicy(*spec.FailoverPVCPolicy, fldPath.Child("failoverPVCPolicy"))...)
+	}
+	if spec.ScaleHooks != nil {
+		allErrs = append(allErrs, validateScaleHooks(spec.ScaleHooks, fldPath.Child("scaleHooks"))...)
+	}
+	if spec.UpgradeHooks != nil {
+		allErrs = append(allErrs, validateUpgradeHooks(spec.UpgradeHooks, fldPath.Child("upgradeHooks"))...)
+	}
+	if spec.UpgradeCheck != nil {
+		allErrs = append(allErrs, validateTimeDurationStr(spec.UpgradeCheck.Timeout, fldPath.Child("upgradeCheck", "timeout"))...)
+	}
+	return allErrs
+}
+
+func validateTiFlashTableReplicas(tableReplicas []v1alpha1.TiFlashTableReplica, stores int32, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	tables := map[string]bool{}
+	for i, tableReplica := range tableReplicas {
+		idxPath := fldPath.Index(i)
+		if tableReplica.Database == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("database"), "database is required"))
+		}
+		if tableReplica.Table == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("table"), "table is required"))
+		}
+		table := strings.ToLower(tableReplica.Database + "." + tableReplica.Table)
+		if tables[table] {
+			allErrs = append(allErrs, field.Duplicate(idxPath, tableReplica.Database+"."+tableReplica.Table))
+		}
+		tables[table] = true
+		if tableReplica.Replicas < 0 || tableReplica.Replicas > stores {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("replicas"), tableReplica.Replicas, fmt.Sprintf("must be between 0 and the number of TiFlash replicas %d", stores)))
+		}
+	}
 	return allErrs
 }
 
@@ -188,6 +645,7 @@ func validateTiCDCSpec(spec *v1alpha1.TiCDCSpec, fldPath *field.Path) field.Erro
 	if len(spec.StorageVolumes) > 0 {
 		allErrs = append(allErrs, validateStorageVolumes(spec.StorageVolumes, fldPath.Child("storageVolumes"))...)
 	}
+	allErrs = append(allErrs, validateTimeDurationStr(spec.GracefulShutdownTimeout, fldPath.Child("gracefulShutdownTimeout"))...)
 	return allErrs
 }
 
@@ -254,6 +712,119 @@ func validateTiDBSpec(spec *v1alpha1.TiDBSpec, fldPath *field.Path) field.ErrorL
 	if spec.ShouldSeparateSlowLog() && spec.SlowLogVolumeName != "" {
 		allErrs = append(allErrs, validateSlowQueryLogVolume(spec.SlowLogVolumeName, spec.StorageVolumes, spec.AdditionalVolumes, spec.AdditionalVolumeMounts, fldPath)...)
 	}
+	if spec.UpgradeStabilizationGate != nil {
+		allErrs = append(allErrs, validateMetricStabilizationGate(spec.UpgradeStabilizationGate, fldPath.Child("upgradeStabilizationGate"))...)
+	}
+	if spec.UpgradeConcurrency != nil && *spec.UpgradeConcurrency < 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("upgradeConcurrency"), *spec.UpgradeConcurrency, "must be greater than 0"))
+	}
+	if len(spec.PlacementPolicies) > 0 {
+		allErrs = append(allErrs, validatePlacementPolicies(spec.PlacementPolicies, fldPath.Child("placementPolicies"))...)
+	}
+	if len(spec.SystemVariables) > 0 {
+		allErrs = append(allErrs, validateSystemVariables(spec.SystemVariables, fldPath.Child("systemVariables"))...)
+	}
+	if spec.IncompatibleDataPolicy != nil {
+		allErrs = append(allErrs, validateIncompatibleDataPolicy(*spec.IncompatibleDataPolicy, fldPath.Child("incompatibleDataPolicy"))...)
+	}
+	if spec.PerOrdinalConfig != nil {
+		allErrs = append(allErrs, validateTiDBPerOrdinalConfig(spec, fldPath)...)
+	}
+	allErrs = append(allErrs, validateTimeDurationStr(spec.ScaleInDrainTimeout, fldPath.Child("scaleInDrainTimeout"))...)
+	allErrs = append(allErrs, validateFailover(spec.MaxFailoverCount, spec.Failover, fldPath)...)
+	if spec.ScaleHooks != nil {
+		allErrs = append(allErrs, validateScaleHooks(spec.ScaleHooks, fldPath.Child("scaleHooks"))...)
+	}
+	if spec.UpgradeHooks != nil {
+		allErrs = append(allErrs, validateUpgradeHooks(spec.UpgradeHooks, fldPath.Child("upgradeHooks"))...)
+	}
+	if spec.UpgradePolicy != nil && spec.UpgradePolicy.Canary != nil {
+		allErrs = append(allErrs, validateTimeDurationStr(spec.UpgradePolicy.Canary.PauseDuration, fldPath.Child("upgradePolicy", "canary", "pauseDuration"))...)
+	}
+	return allErrs
+}
+
+func validateIncompatibleDataPolicy(policy v1alpha1.IncompatibleDataPolicy, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	switch policy {
+	case v1alpha1.IncompatibleDataPolicyReport, v1alpha1.IncompatibleDataPolicyRollback:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath, policy,
+			[]string{string(v1alpha1.IncompatibleDataPolicyReport), string(v1alpha1.IncompatibleDataPolicyRollback)}))
+	}
+	return allErrs
+}
+
+var auditLogPluginRegexp = regexp.MustCompile(`^[A-Za-z0-9_]+-[0-9]+$`)
+
+// validateTiDBAuditLog validates the audit log of TiDB, which requires TiDB
+// v4.0.0 or later. The version is not checked if it is not a semantic
+// version, e.g. latest or nightly.
+func validateTiDBAuditLog(auditLog *v1alpha1.TiDBAuditLogSpec, version string, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if v, err := semver.NewVersion(version); err == nil && v.Major() < 4 {
+		allErrs = append(allErrs, field.Forbidden(fldPath, fmt.Sprintf("audit log is not supported by TiDB %s, requires v4.0.0 or later", version)))
+	}
+	if auditLog.Plugin != "" && !auditLogPluginRegexp.MatchString(auditLog.Plugin) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("plugin"), auditLog.Plugin, "must be in the format of name-version, e.g. audit-1"))
+	}
+	if auditLog.File != "" && (strings.Contains(auditLog.File, "/") || auditLog.File == "." || auditLog.File == "..") {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("file"), auditLog.File, "must be a file name without directory"))
+	}
+	if auditLog.Sidecar != nil {
+		if len(auditLog.Sidecar.Image) == 0 {
+			allErrs = append(allErrs, field.Required(fldPath.Child("sidecar", "image"), "empty image"))
+		}
+	}
+	return allErrs
+}
+
+// validateSystemVariables validates the syntax of the TiDB system variables,
+// the names of which are case-insensitive
+func validateSystemVariables(variables map[string]string, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	names := map[string]bool{}
+	for name, value := range variables {
+		if err := v1alpha1.ValidateSystemVariable(name, value); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(name), value, err.Error()))
+		}
+		if names[strings.ToLower(name)] {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Key(name), name))
+		}
+		names[strings.ToLower(name)] = true
+	}
+	return allErrs
+}
+
+var placementPolicyNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_]{1,64}$`)
+
+// validatePlacementPolicies validates the syntax of the TiDB placement policies
+func validatePlacementPolicies(policies []v1alpha1.PlacementPolicy, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	names := map[string]bool{}
+	for i, policy := range policies {
+		idxPath := fldPath.Index(i)
+		name := strings.ToLower(policy.Name)
+		if !placementPolicyNameRegexp.MatchString(policy.Name) {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("name"), policy.Name, "must consist of at most 64 letters, digits or underscores"))
+		} else if names[name] {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), policy.Name))
+		}
+		names[name] = true
+		if len(policy.Options) == 0 {
+			allErrs = append(allErrs, field.Required(idxPath.Child("options"), "at least one placement option is required"))
+		}
+		options := map[string]bool{}
+		for option, value := range policy.Options {
+			if err := v1alpha1.ValidatePlacementPolicyOption(option, value); err != nil {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("options").Key(option), value, err.Error()))
+			}
+			if options[strings.ToUpper(option)] {
+				allErrs = append(allErrs, field.Duplicate(idxPath.Child("options").Key(option), option))
+			}
+			options[strings.ToUpper(option)] = true
+		}
+	}
 	return allErrs
 }
 
@@ -276,6 +847,9 @@ func validateDMClusterSpec(spec *v1alpha1.DMClusterSpec, fldPath *field.Path) fi
 	if spec.Worker != nil {
 		allErrs = append(allErrs, validateWorkerSpec(spec.Worker, fldPath.Child("worker"))...)
 	}
+	if spec.MaintenanceWindow != nil {
+		allErrs = append(allErrs, validateMaintenanceWindow(spec.MaintenanceWindow, fldPath.Child("maintenanceWindow"))...)
+	}
 	return allErrs
 }
 
@@ -308,6 +882,40 @@ func validateComponentSpec(spec *v1alpha1.ComponentSpec, fldPath *field.Path) fi
 	// TODO validate other fields
 	allErrs = append(allErrs, validateEnv(spec.Env, fldPath.Child("env"))...)
 	allErrs = append(allErrs, validateAdditionalContainers(spec.AdditionalContainers, fldPath.Child("additionalContainers"))...)
+	allErrs = append(allErrs, validateEphemeralStorage(spec.EphemeralStorage, fldPath.Child("ephemeralStorage"))...)
+	allErrs = append(allErrs, validateRestartAt(spec.Annotations, fldPath.Child("annotations"))...)
+	return allErrs
+}
+
+// validateRestartAt validates the restart-at annotation is a RFC3339 time
+func validateRestartAt(annotations map[string]string, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if value, ok := annotations[label.AnnRestartAt]; ok {
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(label.AnnRestartAt), value, "must be a RFC3339 time"))
+		}
+	}
+	return allErrs
+}
+
+// validateEphemeralStorage validates the ephemeral storage quantities are non-negative
+// and the request does not exceed the limit
+func validateEphemeralStorage(es *v1alpha1.EphemeralStorageSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if es == nil {
+		return allErrs
+	}
+	validateNonNegative := func(q *resource.Quantity, name string) {
+		if q != nil && q.Sign() < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child(name), q.String(), "must be greater than or equal to 0"))
+		}
+	}
+	validateNonNegative(es.Request, "request")
+	validateNonNegative(es.Limit, "limit")
+	validateNonNegative(es.EmptyDirSizeLimit, "emptyDirSizeLimit")
+	if es.Request != nil && es.Limit != nil && es.Request.Cmp(*es.Limit) > 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("request"), es.Request.String(), "must be less than or equal to limit"))
+	}
 	return allErrs
 }
 
@@ -339,6 +947,55 @@ func validateStorageVolumes(storageVolumes []v1alpha1.StorageVolume, fldPath *fi
 	return allErrs
 }
 
+// validateTiKVWalStorage validates the separate WAL storage of TiKV
+func validateTiKVWalStorage(spec *v1alpha1.TiKVSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if _, err := resource.ParseQuantity(spec.WalStorageSize); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("walStorageSize"), spec.WalStorageSize, err.Error()))
+	}
+	for i, storageVolume := range spec.StorageVolumes {
+		if storageVolume.Name == v1alpha1.TiKVWalStorageVolumeName {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("storageVolumes").Index(i).Child("name"), storageVolume.Name,
+				"the name is reserved for the WAL storage when walStorageSize is set"))
+		}
+	}
+	return allErrs
+}
+
+// validateTiKVPerOrdinalConfig validates the config overrides of TiKV ordinals
+func validateTiKVPerOrdinalConfig(spec *v1alpha1.TiKVSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if spec.Config == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("config"), "config must be set when perOrdinalConfig is set"))
+	}
+	for key := range spec.PerOrdinalConfig {
+		allErrs = append(allErrs, validateConfigOrdinal(key, fldPath.Child("perOrdinalConfig"))...)
+	}
+	return allErrs
+}
+
+// validateTiDBPerOrdinalConfig validates the config overrides of TiDB ordinals
+func validateTiDBPerOrdinalConfig(spec *v1alpha1.TiDBSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if spec.Config == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("config"), "config must be set when perOrdinalConfig is set"))
+	}
+	for key := range spec.PerOrdinalConfig {
+		allErrs = append(allErrs, validateConfigOrdinal(key, fldPath.Child("perOrdinalConfig"))...)
+	}
+	return allErrs
+}
+
+// validateConfigOrdinal validates the key of perOrdinalConfig, which must be
+// an ordinal in the canonical form as in the Pod name, e.g. "1" but not "01"
+func validateConfigOrdinal(key string, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if ordinal, err := strconv.ParseInt(key, 10, 32); err != nil || ordinal < 0 || strconv.FormatInt(ordinal, 10) != key {
+		allErrs = append(allErrs, field.Invalid(fldPath.Key(key), key, "must be a non-negative ordinal without leading zeros"))
+	}
+	return allErrs
+}
+
 func validateSlowQueryLogVolume(slowLogVolumeName string, storageVolumes []v1alpha1.StorageVolume, additionalVolumes []corev1.Volume, AdditionalVolumeMounts []corev1.VolumeMount, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 	for _, volume := range storageVolumes {
@@ -471,11 +1128,77 @@ func ValidateUpdateTidbCluster(old, tc *v1alpha1.TidbCluster) field.ErrorList {
 			"The instance must not be mutate or set value other than the cluster name"))
 	}
 	allErrs = append(allErrs, validateUpdatePDConfig(old.Spec.PD.Config, tc.Spec.PD.Config, field.NewPath("spec.pd.config"))...)
+	allErrs = append(allErrs, validateUpdatePDReplicas(old, tc, field.NewPath("spec.pd.replicas"))...)
+	allErrs = append(allErrs, validateUpdatePDGroups(old, tc, field.NewPath("spec.pd.groups"))...)
+	allErrs = append(allErrs, validateUpdateTiKVWalStorageSize(old, tc, field.NewPath("spec.tikv.walStorageSize"))...)
+	if old.Spec.TiKV != nil && tc.Spec.TiKV != nil && old.TiKVServerPort() != tc.TiKVServerPort() {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec.tikv.ports.server"), "the server port of TiKV must not be changed"))
+	}
+	allErrs = append(allErrs, validateUpdateVersionSkip(old, tc, field.NewPath("spec"))...)
 	allErrs = append(allErrs, disallowUsingLegacyAPIInNewCluster(old, tc)...)
 
 	return allErrs
 }
 
+// validateUpdateVersionSkip disallows the upgrade of the components skipping
+// more than MaxMinorVersionSkip minor versions, unless the cluster has the
+// annotation tidb.pingcap.com/force-version-skip. The versions not in semver,
+// e.g. latest or nightly, are not checked.
+func validateUpdateVersionSkip(old, tc *v1alpha1.TidbCluster, path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if MaxMinorVersionSkip < 0 || tc.ForceVersionSkip() {
+		return allErrs
+	}
+	components := []struct {
+		name       string
+		oldVersion string
+		version    string
+	}{
+		{"pd", old.PDVersion(), tc.PDVersion()},
+		{"tikv", old.TiKVVersion(), tc.TiKVVersion()},
+		{"tiflash", old.TiFlashVersion(), tc.TiFlashVersion()},
+		{"tidb", old.TiDBVersion(), tc.TiDBVersion()},
+	}
+	for _, c := range components {
+		if c.oldVersion == "" || c.version == "" || c.oldVersion == c.version {
+			continue
+		}
+		from, err := semver.NewVersion(c.oldVersion)
+		if err != nil {
+			continue
+		}
+		to, err := semver.NewVersion(c.version)
+		if err != nil {
+			continue
+		}
+		if skipped := minorVersionsSkipped(from, to); skipped > MaxMinorVersionSkip {
+			allErrs = append(allErrs, field.Forbidden(path.Child(c.name),
+				fmt.Sprintf("the upgrade from %s to %s skips more than %d minor versions, upgrade step by step or set annotation %s to upgrade anyway", c.oldVersion, c.version, MaxMinorVersionSkip, label.AnnForceVersionSkip)))
+		}
+	}
+	return allErrs
+}
+
+// minorVersionsSkipped returns the number of minor versions skipped by the
+// upgrade. The upgrade to the next major version skips the minor versions
+// before the target one, e.g. v5.4 to v6.1 skips v6.0, and the upgrade across
+// more major versions skips an unknown number of minor versions.
+func minorVersionsSkipped(from, to *semver.Version) int {
+	switch {
+	case to.Major() == from.Major():
+		if to.Minor() <= from.Minor() {
+			return 0
+		}
+		return int(to.Minor()-from.Minor()) - 1
+	case to.Major() == from.Major()+1:
+		return int(to.Minor())
+	case to.Major() > from.Major():
+		return math.MaxInt32
+	default:
+		return 0
+	}
+}
+
 // For now we limit some validations only in Create phase to keep backward compatibility
 // TODO(aylei): call this in ValidateTidbCluster after we deprecated the old versions of helm chart officially
 func validateNewTidbClusterSpec(spec *v1alpha1.TidbClusterSpec, path *field.Path) field.ErrorList {
@@ -541,6 +1264,77 @@ func disallowUsingLegacyAPIInNewCluster(old, tc *v1alpha1.TidbCluster) field.Err
 	return allErrs
 }
 
+// validateUpdatePDReplicas disallows scaling PD to an even number of replicas
+// unless it is allowed by the annotation, the quorum of an even number of
+// members tolerates no more failures than the one of one member less.
+func validateUpdatePDReplicas(old, tc *v1alpha1.TidbCluster, path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if old.Spec.PD == nil || tc.Spec.PD == nil || old.Spec.PD.Replicas == tc.Spec.PD.Replicas {
+		return allErrs
+	}
+	replicas := tc.Spec.PD.Replicas
+	if replicas > 0 && replicas%2 == 0 && !tc.AllowEvenPDReplicas() {
+		allErrs = append(allErrs, field.Invalid(path, replicas,
+			fmt.Sprintf("an even number of PD replicas does not tolerate more failures than %d replicas, set annotation %s to scale to it anyway", replicas-1, label.AnnAllowEvenPDReplicas)))
+	}
+	return allErrs
+}
+
+// validateUpdatePDGroups disallows removing a PD group before it is scaled in
+// to 0 replicas, so that its members leave the PD cluster through the scaler
+func validateUpdatePDGroups(old, tc *v1alpha1.TidbCluster, path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if old.Spec.PD == nil {
+		return allErrs
+	}
+	names := map[string]bool{}
+	if tc.Spec.PD != nil {
+		for _, group := range tc.Spec.PD.Groups {
+			names[group.Name] = true
+		}
+	}
+	for _, group := range old.Spec.PD.Groups {
+		if !names[group.Name] && group.Replicas > 0 {
+			allErrs = append(allErrs, field.Forbidden(path, fmt.Sprintf("group %s must be scaled in to 0 replicas before it is removed", group.Name)))
+		}
+	}
+	return allErrs
+}
+
+// validateUpdateTiKVWalStorageSize disallows adding, removing or decreasing
+// the size of the WAL storage of TiKV, as the volumeClaimTemplates of the
+// StatefulSet can not be changed and the persistent volumes can not be shrunk
+func validateUpdateTiKVWalStorageSize(old, tc *v1alpha1.TidbCluster, path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if old.Spec.TiKV == nil {
+		return allErrs
+	}
+	if old.Spec.TiKV.WalStorageSize == "" {
+		if tc.Spec.TiKV != nil && tc.Spec.TiKV.WalStorageSize != "" {
+			allErrs = append(allErrs, field.Forbidden(path, "the WAL storage of TiKV must not be added to an existing cluster"))
+		}
+		return allErrs
+	}
+	oldSize, err := resource.ParseQuantity(old.Spec.TiKV.WalStorageSize)
+	if err != nil {
+		return allErrs
+	}
+	if tc.Spec.TiKV == nil || tc.Spec.TiKV.WalStorageSize == "" {
+		allErrs = append(allErrs, field.Forbidden(path, "the WAL storage of TiKV must not be removed"))
+		return allErrs
+	}
+	size, err := resource.ParseQuantity(tc.Spec.TiKV.WalStorageSize)
+	if err != nil {
+		// reported by the basic validation
+		return allErrs
+	}
+	if size.Cmp(oldSize) < 0 {
+		allErrs = append(allErrs, field.Invalid(path, tc.Spec.TiKV.WalStorageSize,
+			fmt.Sprintf("must not be less than the current size %s, the WAL storage of TiKV can not be shrunk", old.Spec.TiKV.WalStorageSize)))
+	}
+	return allErrs
+}
+
 func validateUpdatePDConfig(old, conf *v1alpha1.PDConfigWraper, path *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 	// for newly created cluster, both old and new are non-nil, guaranteed by validation
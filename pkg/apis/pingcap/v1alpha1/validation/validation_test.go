@@ -455,6 +455,7 @@ func TestValidateDMCluster(t *testing.T) {
 		version           string
 		masterReplicas    int32
 		masterStorageSize string
+		maintenanceWindow *v1alpha1.MaintenanceWindow
 		expectedError     string
 	}{
 		{
@@ -468,6 +469,14 @@ func TestValidateDMCluster(t *testing.T) {
 			masterReplicas: 3,
 			expectedError:  "storageSize must not be empty",
 		},
+		{
+			name:              "invalid maintenance window",
+			version:           "nightly",
+			masterReplicas:    3,
+			masterStorageSize: "10Gi",
+			maintenanceWindow: &v1alpha1.MaintenanceWindow{Schedule: "0 2 * *", Duration: "4h"},
+			expectedError:     "must be a standard 5-field cron expression",
+		},
 		{
 			name:              "correct configuration",
 			version:           "nightly",
@@ -482,6 +491,7 @@ func TestValidateDMCluster(t *testing.T) {
 			dc.Spec.Version = tt.version
 			dc.Spec.Master.Replicas = tt.masterReplicas
 			dc.Spec.Master.StorageSize = tt.masterStorageSize
+			dc.Spec.MaintenanceWindow = tt.maintenanceWindow
 			err := ValidateDMCluster(dc)
 			if tt.expectedError != "" {
 				g.Expect(len(err)).Should(Equal(1))
@@ -656,3 +666,827 @@ func TestValidatePDAddresses(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateCrossComponentAntiAffinity(t *testing.T) {
+	successCases := []v1alpha1.CrossComponentAntiAffinity{
+		{},
+		{Type: v1alpha1.AntiAffinityTypePreferred},
+		{Type: v1alpha1.AntiAffinityTypeRequired, TopologyKey: "topology.kubernetes.io/zone"},
+	}
+
+	for _, c := range successCases {
+		errs := validateCrossComponentAntiAffinity(&c, field.NewPath("tikvTiFlashAntiAffinity"))
+		if len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := []v1alpha1.CrossComponentAntiAffinity{
+		{Type: "Required"},
+		{Type: "soft"},
+	}
+
+	for _, c := range errorCases {
+		errs := validateCrossComponentAntiAffinity(&c, field.NewPath("tikvTiFlashAntiAffinity"))
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %s", c.Type)
+		}
+	}
+}
+
+func TestValidatePDSchedulers(t *testing.T) {
+	successCases := [][]v1alpha1.PDScheduler{
+		nil,
+		{
+			{Name: "balance-region-scheduler"},
+			{Name: "balance-hot-region-scheduler"},
+		},
+	}
+
+	for _, c := range successCases {
+		errs := validatePDSchedulers(c, field.NewPath("schedulers"))
+		if len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := [][]v1alpha1.PDScheduler{
+		{
+			{Name: ""},
+		},
+		{
+			{Name: "balance-region-scheduler"},
+			{Name: "balance-region-scheduler"},
+		},
+	}
+
+	for _, c := range errorCases {
+		errs := validatePDSchedulers(c, field.NewPath("schedulers"))
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %v", c)
+		}
+	}
+}
+
+func TestValidatePDRegionSize(t *testing.T) {
+	successCases := []v1alpha1.PDRegionSizeConfig{
+		{},
+		{RegionMaxSize: pointer.StringPtr("144MiB"), RegionSplitSize: pointer.StringPtr("96MiB")},
+		{RegionMaxSize: pointer.StringPtr("10GiB")},
+		{RegionSplitSize: pointer.StringPtr("1048576")},
+		{RegionMaxSize: pointer.StringPtr("256MB"), RegionSplitSize: pointer.StringPtr("256MiB")},
+	}
+
+	for _, c := range successCases {
+		errs := validatePDRegionSize(&c, field.NewPath("regionSize"))
+		if len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := []v1alpha1.PDRegionSizeConfig{
+		{RegionMaxSize: pointer.StringPtr("144 megabytes")},
+		{RegionMaxSize: pointer.StringPtr("-1MiB")},
+		{RegionMaxSize: pointer.StringPtr("512KiB")},
+		{RegionSplitSize: pointer.StringPtr("11GiB")},
+		{RegionMaxSize: pointer.StringPtr("64MiB"), RegionSplitSize: pointer.StringPtr("96MiB")},
+	}
+
+	for _, c := range errorCases {
+		errs := validatePDRegionSize(&c, field.NewPath("regionSize"))
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %v", c)
+		}
+	}
+}
+
+func TestValidatePDBalanceLimits(t *testing.T) {
+	successCases := []v1alpha1.PDBalanceLimits{
+		{},
+		{MaxPendingPeerCount: pointer.Int32Ptr(64), MaxSnapshotCount: pointer.Int32Ptr(8)},
+		{LeaderScheduleLimit: pointer.Int32Ptr(0), RegionScheduleLimit: pointer.Int32Ptr(4096)},
+		{ReplicaScheduleLimit: pointer.Int32Ptr(64), MergeScheduleLimit: pointer.Int32Ptr(0), HotRegionScheduleLimit: pointer.Int32Ptr(4)},
+	}
+
+	for _, c := range successCases {
+		errs := validatePDBalanceLimits(&c, field.NewPath("balanceLimits"))
+		if len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := []v1alpha1.PDBalanceLimits{
+		{MaxPendingPeerCount: pointer.Int32Ptr(0)},
+		{MaxSnapshotCount: pointer.Int32Ptr(-1)},
+		{RegionScheduleLimit: pointer.Int32Ptr(-1)},
+		{MergeScheduleLimit: pointer.Int32Ptr(-8)},
+	}
+
+	for _, c := range errorCases {
+		errs := validatePDBalanceLimits(&c, field.NewPath("balanceLimits"))
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %v", c)
+		}
+	}
+}
+
+func TestValidatePDScaleInLeaderTransferTimeout(t *testing.T) {
+	for _, timeout := range []*string{nil, pointer.StringPtr("10s"), pointer.StringPtr("1m")} {
+		if errs := validatePDScaleInLeaderTransferTimeout(timeout, field.NewPath("scaleInLeaderTransferTimeout")); len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+	for _, timeout := range []string{"", "10", "0s", "-5s", "90s"} {
+		if errs := validatePDScaleInLeaderTransferTimeout(&timeout, field.NewPath("scaleInLeaderTransferTimeout")); len(errs) == 0 {
+			t.Errorf("expected failure for %q", timeout)
+		}
+	}
+}
+
+func TestValidateUpdatePDReplicas(t *testing.T) {
+	newTC := func(replicas int32, anns map[string]string) *v1alpha1.TidbCluster {
+		return &v1alpha1.TidbCluster{
+			ObjectMeta: metav1.ObjectMeta{Annotations: anns},
+			Spec:       v1alpha1.TidbClusterSpec{PD: &v1alpha1.PDSpec{Replicas: replicas}},
+		}
+	}
+	allowed := map[string]string{label.AnnAllowEvenPDReplicas: "true"}
+	tests := []struct {
+		name   string
+		old    *v1alpha1.TidbCluster
+		tc     *v1alpha1.TidbCluster
+		expect bool
+	}{
+		{name: "scale to odd", old: newTC(3, nil), tc: newTC(5, nil), expect: true},
+		{name: "scale to even", old: newTC(3, nil), tc: newTC(4, nil), expect: false},
+		{name: "scale to even with annotation", old: newTC(3, nil), tc: newTC(4, allowed), expect: true},
+		{name: "even replicas unchanged", old: newTC(4, nil), tc: newTC(4, nil), expect: true},
+		{name: "scale to zero", old: newTC(3, nil), tc: newTC(0, nil), expect: true},
+	}
+	for _, tt := range tests {
+		errs := validateUpdatePDReplicas(tt.old, tt.tc, field.NewPath("spec.pd.replicas"))
+		if tt.expect != (len(errs) == 0) {
+			t.Errorf("%s: expected success %v, got errors %v", tt.name, tt.expect, errs)
+		}
+	}
+}
+
+func TestValidateUpdateVersionSkip(t *testing.T) {
+	newTC := func(version string, anns map[string]string) *v1alpha1.TidbCluster {
+		return &v1alpha1.TidbCluster{
+			ObjectMeta: metav1.ObjectMeta{Annotations: anns},
+			Spec: v1alpha1.TidbClusterSpec{
+				Version: version,
+				PD:      &v1alpha1.PDSpec{BaseImage: "pingcap/pd"},
+				TiDB:    &v1alpha1.TiDBSpec{BaseImage: "pingcap/tidb"},
+			},
+		}
+	}
+	forced := map[string]string{label.AnnForceVersionSkip: "true"}
+	tests := []struct {
+		name   string
+		old    *v1alpha1.TidbCluster
+		tc     *v1alpha1.TidbCluster
+		max    int
+		expect bool
+	}{
+		{name: "patch upgrade", old: newTC("v5.4.0", nil), tc: newTC("v5.4.3", nil), max: 1, expect: true},
+		{name: "skip 1 minor version", old: newTC("v5.2.0", nil), tc: newTC("v5.4.0", nil), max: 1, expect: true},
+		{name: "skip 2 minor versions", old: newTC("v5.1.0", nil), tc: newTC("v5.4.0", nil), max: 1, expect: false},
+		{name: "upgrade to the next major version", old: newTC("v5.4.0", nil), tc: newTC("v6.1.0", nil), max: 1, expect: true},
+		{name: "skip minor versions of the next major version", old: newTC("v6.5.0", nil), tc: newTC("v7.5.0", nil), max: 1, expect: false},
+		{name: "skip a major version", old: newTC("v5.4.0", nil), tc: newTC("v7.1.0", nil), max: 1, expect: false},
+		{name: "skip with annotation", old: newTC("v5.4.0", nil), tc: newTC("v7.1.0", forced), max: 1, expect: true},
+		{name: "skip with the check disabled", old: newTC("v5.4.0", nil), tc: newTC("v7.1.0", nil), max: -1, expect: true},
+		{name: "no skip allowed", old: newTC("v5.2.0", nil), tc: newTC("v5.4.0", nil), max: 0, expect: false},
+		{name: "downgrade", old: newTC("v7.1.0", nil), tc: newTC("v5.4.0", nil), max: 1, expect: true},
+		{name: "version not in semver", old: newTC("v5.4.0", nil), tc: newTC("nightly", nil), max: 1, expect: true},
+	}
+	defer func(max int) { MaxMinorVersionSkip = max }(MaxMinorVersionSkip)
+	for _, tt := range tests {
+		MaxMinorVersionSkip = tt.max
+		errs := validateUpdateVersionSkip(tt.old, tt.tc, field.NewPath("spec"))
+		if tt.expect != (len(errs) == 0) {
+			t.Errorf("%s: expected success %v, got errors %v", tt.name, tt.expect, errs)
+		}
+	}
+}
+
+func TestValidatePDGroups(t *testing.T) {
+	successCases := [][]v1alpha1.PDGroup{
+		nil,
+		{{Name: "az-a", Replicas: 2}, {Name: "az-b", Replicas: 0, StorageClassName: pointer.StringPtr("local")}},
+	}
+	for _, c := range successCases {
+		if errs := validatePDGroups(c, field.NewPath("groups")); len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := [][]v1alpha1.PDGroup{
+		{{Name: "", Replicas: 1}},
+		{{Name: "AZ_A", Replicas: 1}},
+		{{Name: "az-a", Replicas: 1}, {Name: "az-a", Replicas: 2}},
+		{{Name: "az-a", Replicas: -1}},
+	}
+	for _, c := range errorCases {
+		if errs := validatePDGroups(c, field.NewPath("groups")); len(errs) == 0 {
+			t.Errorf("expected failure for %v", c)
+		}
+	}
+}
+
+func TestValidateUpdatePDGroups(t *testing.T) {
+	newTC := func(groups ...v1alpha1.PDGroup) *v1alpha1.TidbCluster {
+		return &v1alpha1.TidbCluster{Spec: v1alpha1.TidbClusterSpec{PD: &v1alpha1.PDSpec{Groups: groups}}}
+	}
+	if errs := validateUpdatePDGroups(newTC(v1alpha1.PDGroup{Name: "az-a", Replicas: 0}), newTC(), field.NewPath("spec.pd.groups")); len(errs) > 0 {
+		t.Errorf("expected success for removing an empty group: %v", errs)
+	}
+	if errs := validateUpdatePDGroups(newTC(v1alpha1.PDGroup{Name: "az-a", Replicas: 1}), newTC(v1alpha1.PDGroup{Name: "az-a", Replicas: 3}), field.NewPath("spec.pd.groups")); len(errs) > 0 {
+		t.Errorf("expected success for scaling a group: %v", errs)
+	}
+	if errs := validateUpdatePDGroups(newTC(v1alpha1.PDGroup{Name: "az-a", Replicas: 1}), newTC(), field.NewPath("spec.pd.groups")); len(errs) == 0 {
+		t.Errorf("expected failure for removing a group with members")
+	}
+}
+
+func TestValidateScaleHooks(t *testing.T) {
+	httpHook := func(url string) *v1alpha1.ScaleHook {
+		return &v1alpha1.ScaleHook{HTTP: &v1alpha1.HTTPScaleHook{URL: url}}
+	}
+	successCases := []v1alpha1.ScaleHooks{
+		{},
+		{PreScaleIn: httpHook("https://lb.example.com/scale")},
+		{
+			PreScaleIn: &v1alpha1.ScaleHook{Job: &v1alpha1.JobScaleHook{Image: "cmdb-sync:v1", Command: []string{"/sync"}}},
+			PostScale:  httpHook("http://cmdb.default.svc:8080/topology"),
+		},
+	}
+	for _, c := range successCases {
+		if errs := validateScaleHooks(&c, field.NewPath("scaleHooks")); len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := []v1alpha1.ScaleHooks{
+		{PreScaleIn: &v1alpha1.ScaleHook{}},
+		{PostScale: &v1alpha1.ScaleHook{HTTP: &v1alpha1.HTTPScaleHook{URL: "https://lb"}, Job: &v1alpha1.JobScaleHook{Image: "busybox"}}},
+		{PostScale: httpHook("lb.example.com/scale")},
+		{PreScaleIn: &v1alpha1.ScaleHook{HTTP: &v1alpha1.HTTPScaleHook{URL: "https://lb", TimeoutSeconds: pointer.Int32Ptr(0)}}},
+		{PreScaleIn: &v1alpha1.ScaleHook{Job: &v1alpha1.JobScaleHook{}}},
+	}
+	for _, c := range errorCases {
+		if errs := validateScaleHooks(&c, field.NewPath("scaleHooks")); len(errs) == 0 {
+			t.Errorf("expected failure for %v", c)
+		}
+	}
+}
+
+func TestValidateUpgradeHooks(t *testing.T) {
+	jobHook := &v1alpha1.JobScaleHook{Image: "tidb-warmup:v1", Command: []string{"/warmup"}}
+	successCases := []v1alpha1.UpgradeHooks{
+		{},
+		{PreUpgrade: &v1alpha1.UpgradeHook{Job: jobHook}},
+		{
+			PreUpgrade:  &v1alpha1.UpgradeHook{Job: jobHook, TimeoutSeconds: pointer.Int32Ptr(60), FailurePolicy: v1alpha1.UpgradeHookFailurePolicyFail},
+			PostUpgrade: &v1alpha1.UpgradeHook{Job: jobHook, FailurePolicy: v1alpha1.UpgradeHookFailurePolicyIgnore},
+		},
+	}
+	for _, c := range successCases {
+		if errs := validateUpgradeHooks(&c, field.NewPath("upgradeHooks")); len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := []v1alpha1.UpgradeHooks{
+		{PreUpgrade: &v1alpha1.UpgradeHook{}},
+		{PostUpgrade: &v1alpha1.UpgradeHook{Job: &v1alpha1.JobScaleHook{}}},
+		{PostUpgrade: &v1alpha1.UpgradeHook{Job: jobHook, TimeoutSeconds: pointer.Int32Ptr(0)}},
+		{PreUpgrade: &v1alpha1.UpgradeHook{Job: jobHook, FailurePolicy: "Retry"}},
+	}
+	for _, c := range errorCases {
+		if errs := validateUpgradeHooks(&c, field.NewPath("upgradeHooks")); len(errs) == 0 {
+			t.Errorf("expected failure for %v", c)
+		}
+	}
+}
+
+func TestValidateFailover(t *testing.T) {
+	successCases := []struct {
+		maxFailoverCount *int32
+		failover         *v1alpha1.FailoverSpec
+	}{
+		{},
+		{maxFailoverCount: pointer.Int32Ptr(0)},
+		{maxFailoverCount: pointer.Int32Ptr(3), failover: &v1alpha1.FailoverSpec{}},
+		{failover: &v1alpha1.FailoverSpec{DetectionPeriod: pointer.StringPtr("30m"), MaxFailoverCount: pointer.Int32Ptr(1)}},
+	}
+	for _, c := range successCases {
+		if errs := validateFailover(c.maxFailoverCount, c.failover, field.NewPath("tikv")); len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := []struct {
+		maxFailoverCount *int32
+		failover         *v1alpha1.FailoverSpec
+	}{
+		{maxFailoverCount: pointer.Int32Ptr(-1)},
+		{failover: &v1alpha1.FailoverSpec{DetectionPeriod: pointer.StringPtr("5")}},
+		{failover: &v1alpha1.FailoverSpec{DetectionPeriod: pointer.StringPtr("0s")}},
+		{failover: &v1alpha1.FailoverSpec{MaxFailoverCount: pointer.Int32Ptr(-1)}},
+	}
+	for _, c := range errorCases {
+		if errs := validateFailover(c.maxFailoverCount, c.failover, field.NewPath("tikv")); len(errs) == 0 {
+			t.Errorf("expected failure for %v", c.failover)
+		}
+	}
+}
+
+func TestValidateFailoverPVCPolicy(t *testing.T) {
+	for _, policy := range []v1alpha1.FailoverPVCPolicy{v1alpha1.FailoverPVCPolicyReuse, v1alpha1.FailoverPVCPolicyRecreate} {
+		if errs := validateFailoverPVCPolicy(policy, field.NewPath("failoverPVCPolicy")); len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+	for _, policy := range []v1alpha1.FailoverPVCPolicy{"", "reuse", "Delete"} {
+		if errs := validateFailoverPVCPolicy(policy, field.NewPath("failoverPVCPolicy")); len(errs) == 0 {
+			t.Errorf("expected failure for %q", policy)
+		}
+	}
+}
+
+func TestValidateStalePVCPolicy(t *testing.T) {
+	for _, policy := range []v1alpha1.StalePVCPolicy{v1alpha1.StalePVCPolicyReuse, v1alpha1.StalePVCPolicyBlock, v1alpha1.StalePVCPolicyWipe} {
+		if errs := validateStalePVCPolicy(policy, field.NewPath("stalePVCPolicy")); len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+	for _, policy := range []v1alpha1.StalePVCPolicy{"", "wipe", "Delete"} {
+		if errs := validateStalePVCPolicy(policy, field.NewPath("stalePVCPolicy")); len(errs) == 0 {
+			t.Errorf("expected failure for %q", policy)
+		}
+	}
+}
+
+func TestValidateIncompatibleDataPolicy(t *testing.T) {
+	for _, policy := range []v1alpha1.IncompatibleDataPolicy{v1alpha1.IncompatibleDataPolicyReport, v1alpha1.IncompatibleDataPolicyRollback} {
+		if errs := validateIncompatibleDataPolicy(policy, field.NewPath("incompatibleDataPolicy")); len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+	for _, policy := range []v1alpha1.IncompatibleDataPolicy{"", "rollback", "Ignore"} {
+		if errs := validateIncompatibleDataPolicy(policy, field.NewPath("incompatibleDataPolicy")); len(errs) == 0 {
+			t.Errorf("expected failure for %q", policy)
+		}
+	}
+}
+
+func TestValidateTiDBAuditLog(t *testing.T) {
+	successCases := []struct {
+		auditLog v1alpha1.TiDBAuditLogSpec
+		version  string
+	}{
+		{v1alpha1.TiDBAuditLogSpec{}, "v5.2.1"},
+		{v1alpha1.TiDBAuditLogSpec{}, "latest"},
+		{v1alpha1.TiDBAuditLogSpec{Plugin: "audit-2", File: "audit.log"}, "v4.0.0"},
+		{v1alpha1.TiDBAuditLogSpec{Sidecar: &corev1.Container{Image: "fluent/fluent-bit:1.8"}}, "nightly"},
+	}
+
+	for _, c := range successCases {
+		errs := validateTiDBAuditLog(&c.auditLog, c.version, field.NewPath("auditLog"))
+		if len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := []struct {
+		auditLog v1alpha1.TiDBAuditLogSpec
+		version  string
+	}{
+		{v1alpha1.TiDBAuditLogSpec{}, "v3.0.20"},
+		{v1alpha1.TiDBAuditLogSpec{Plugin: "audit"}, "v5.2.1"},
+		{v1alpha1.TiDBAuditLogSpec{File: "../audit.log"}, "v5.2.1"},
+		{v1alpha1.TiDBAuditLogSpec{Sidecar: &corev1.Container{Name: "shipper"}}, "v5.2.1"},
+	}
+
+	for _, c := range errorCases {
+		errs := validateTiDBAuditLog(&c.auditLog, c.version, field.NewPath("auditLog"))
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %v", c)
+		}
+	}
+}
+
+func TestValidateStorageCheck(t *testing.T) {
+	successCases := []v1alpha1.StorageCheckSpec{
+		{MinWriteThroughputMB: 100},
+		{MinWriteThroughputMB: 100, SizeMB: pointer.Int32Ptr(128)},
+	}
+
+	for _, c := range successCases {
+		errs := validateStorageCheck(&c, field.NewPath("storageCheck"))
+		if len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := []v1alpha1.StorageCheckSpec{
+		{MinWriteThroughputMB: 0},
+		{MinWriteThroughputMB: 100, SizeMB: pointer.Int32Ptr(0)},
+	}
+
+	for _, c := range errorCases {
+		errs := validateStorageCheck(&c, field.NewPath("storageCheck"))
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %v", c)
+		}
+	}
+}
+
+func TestValidateTiKVWalStorage(t *testing.T) {
+	successCases := []v1alpha1.TiKVSpec{
+		{WalStorageSize: "10Gi"},
+		{WalStorageSize: "10Gi", StorageVolumes: []v1alpha1.StorageVolume{{Name: "raft", StorageSize: "1Gi"}}},
+	}
+
+	for _, c := range successCases {
+		errs := validateTiKVWalStorage(&c, field.NewPath("tikv"))
+		if len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := []v1alpha1.TiKVSpec{
+		{WalStorageSize: "10 Gi"},
+		{WalStorageSize: "10Gi", StorageVolumes: []v1alpha1.StorageVolume{{Name: "wal", StorageSize: "1Gi"}}},
+	}
+
+	for _, c := range errorCases {
+		errs := validateTiKVWalStorage(&c, field.NewPath("tikv"))
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %v", c)
+		}
+	}
+}
+
+func TestValidateUpdateTiKVWalStorageSize(t *testing.T) {
+	newTC := func(size string) *v1alpha1.TidbCluster {
+		return &v1alpha1.TidbCluster{Spec: v1alpha1.TidbClusterSpec{TiKV: &v1alpha1.TiKVSpec{WalStorageSize: size}}}
+	}
+	successCases := [][2]string{
+		{"", ""},
+		{"10Gi", "10Gi"},
+		{"10Gi", "20Gi"},
+	}
+	for _, c := range successCases {
+		if errs := validateUpdateTiKVWalStorageSize(newTC(c[0]), newTC(c[1]), field.NewPath("spec.tikv.walStorageSize")); len(errs) > 0 {
+			t.Errorf("expected success for updating from %q to %q: %v", c[0], c[1], errs)
+		}
+	}
+
+	errorCases := [][2]string{
+		{"", "10Gi"},
+		{"10Gi", ""},
+		{"10Gi", "5Gi"},
+	}
+	for _, c := range errorCases {
+		if errs := validateUpdateTiKVWalStorageSize(newTC(c[0]), newTC(c[1]), field.NewPath("spec.tikv.walStorageSize")); len(errs) == 0 {
+			t.Errorf("expected failure for updating from %q to %q", c[0], c[1])
+		}
+	}
+}
+
+func TestValidateMaintenanceWindow(t *testing.T) {
+	successCases := []v1alpha1.MaintenanceWindow{
+		{Schedule: "0 2 * * 6", Duration: "4h"},
+		{Schedule: "30 1 * * 1-5", Duration: "90m"},
+	}
+
+	for _, c := range successCases {
+		errs := validateMaintenanceWindow(&c, field.NewPath("maintenanceWindow"))
+		if len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := []v1alpha1.MaintenanceWindow{
+		{Schedule: "0 2 * *", Duration: "4h"},
+		{Schedule: "0 2 * * 6 *", Duration: "4h"},
+		{Schedule: "61 2 * * 6", Duration: "4h"},
+		{Schedule: "0 2 * * x", Duration: "4h"},
+		{Schedule: "0 2 * * 6", Duration: "4"},
+	}
+
+	for _, c := range errorCases {
+		errs := validateMaintenanceWindow(&c, field.NewPath("maintenanceWindow"))
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %v", c)
+		}
+	}
+}
+
+func TestValidateTiKVPerOrdinalConfig(t *testing.T) {
+	successCases := []v1alpha1.TiKVSpec{
+		{Config: v1alpha1.NewTiKVConfig(), PerOrdinalConfig: map[string]*v1alpha1.TiKVConfigWraper{"0": v1alpha1.NewTiKVConfig()}},
+		{Config: v1alpha1.NewTiKVConfig(), PerOrdinalConfig: map[string]*v1alpha1.TiKVConfigWraper{"2": v1alpha1.NewTiKVConfig(), "10": v1alpha1.NewTiKVConfig()}},
+	}
+
+	for _, c := range successCases {
+		errs := validateTiKVPerOrdinalConfig(&c, field.NewPath("tikv"))
+		if len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := []v1alpha1.TiKVSpec{
+		{PerOrdinalConfig: map[string]*v1alpha1.TiKVConfigWraper{"0": v1alpha1.NewTiKVConfig()}},
+		{Config: v1alpha1.NewTiKVConfig(), PerOrdinalConfig: map[string]*v1alpha1.TiKVConfigWraper{"tikv-0": v1alpha1.NewTiKVConfig()}},
+		{Config: v1alpha1.NewTiKVConfig(), PerOrdinalConfig: map[string]*v1alpha1.TiKVConfigWraper{"-1": v1alpha1.NewTiKVConfig()}},
+		{Config: v1alpha1.NewTiKVConfig(), PerOrdinalConfig: map[string]*v1alpha1.TiKVConfigWraper{"01": v1alpha1.NewTiKVConfig()}},
+		{Config: v1alpha1.NewTiKVConfig(), PerOrdinalConfig: map[string]*v1alpha1.TiKVConfigWraper{"+1": v1alpha1.NewTiKVConfig()}},
+	}
+
+	for _, c := range errorCases {
+		errs := validateTiKVPerOrdinalConfig(&c, field.NewPath("tikv"))
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %v", c)
+		}
+	}
+}
+
+func TestValidateTiDBPerOrdinalConfig(t *testing.T) {
+	successCases := []v1alpha1.TiDBSpec{
+		{Config: v1alpha1.NewTiDBConfig(), PerOrdinalConfig: map[string]*v1alpha1.TiDBConfigWraper{}},
+		{Config: v1alpha1.NewTiDBConfig(), PerOrdinalConfig: map[string]*v1alpha1.TiDBConfigWraper{"0": v1alpha1.NewTiDBConfig(), "10": v1alpha1.NewTiDBConfig()}},
+	}
+
+	for _, c := range successCases {
+		errs := validateTiDBPerOrdinalConfig(&c, field.NewPath("tidb"))
+		if len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := []v1alpha1.TiDBSpec{
+		{PerOrdinalConfig: map[string]*v1alpha1.TiDBConfigWraper{}},
+		{Config: v1alpha1.NewTiDBConfig(), PerOrdinalConfig: map[string]*v1alpha1.TiDBConfigWraper{"tidb-0": v1alpha1.NewTiDBConfig()}},
+		{Config: v1alpha1.NewTiDBConfig(), PerOrdinalConfig: map[string]*v1alpha1.TiDBConfigWraper{"00": v1alpha1.NewTiDBConfig()}},
+	}
+
+	for _, c := range errorCases {
+		errs := validateTiDBPerOrdinalConfig(&c, field.NewPath("tidb"))
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %v", c)
+		}
+	}
+}
+
+func TestValidatePlacementPolicies(t *testing.T) {
+	successCases := [][]v1alpha1.PlacementPolicy{
+		{{Name: "p1", Options: map[string]string{"PRIMARY_REGION": "us-east-1", "REGIONS": "us-east-1,us-west-1"}}},
+		{
+			{Name: "p1", Options: map[string]string{"followers": "2"}},
+			{Name: "ssd_only", Options: map[string]string{"CONSTRAINTS": "[+disk=ssd]"}},
+		},
+	}
+
+	for _, c := range successCases {
+		errs := validatePlacementPolicies(c, field.NewPath("placementPolicies"))
+		if len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := [][]v1alpha1.PlacementPolicy{
+		{{Name: "p-1", Options: map[string]string{"FOLLOWERS": "2"}}},
+		{{Name: "p1"}},
+		{{Name: "p1", Options: map[string]string{"REPLICAS": "3"}}},
+		{{Name: "p1", Options: map[string]string{"FOLLOWERS": "two"}}},
+		{{Name: "p1", Options: map[string]string{"FOLLOWERS": "2", "followers": "3"}}},
+		{
+			{Name: "p1", Options: map[string]string{"FOLLOWERS": "2"}},
+			{Name: "P1", Options: map[string]string{"FOLLOWERS": "3"}},
+		},
+	}
+
+	for _, c := range errorCases {
+		errs := validatePlacementPolicies(c, field.NewPath("placementPolicies"))
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %v", c)
+		}
+	}
+}
+
+func TestValidateSystemVariables(t *testing.T) {
+	successCases := []map[string]string{
+		{"tidb_txn_mode": "pessimistic"},
+		{"max_connections": "1000", "sql_mode": "STRICT_TRANS_TABLES,NO_ZERO_DATE"},
+	}
+
+	for _, c := range successCases {
+		errs := validateSystemVariables(c, field.NewPath("systemVariables"))
+		if len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := []map[string]string{
+		{"tidb-txn-mode": "pessimistic"},
+		{"tidb_txn_mode": "'; DROP DATABASE test; --"},
+		{"sql_mode": "\\"},
+		{"max_connections": "1000", "MAX_CONNECTIONS": "10"},
+	}
+
+	for _, c := range errorCases {
+		errs := validateSystemVariables(c, field.NewPath("systemVariables"))
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %v", c)
+		}
+	}
+}
+
+func TestValidateTiFlashTableReplicas(t *testing.T) {
+	successCases := [][]v1alpha1.TiFlashTableReplica{
+		{{Database: "test", Table: "t1", Replicas: 2}},
+		{
+			{Database: "test", Table: "t1", Replicas: 0},
+			{Database: "test", Table: "t2", Replicas: 1},
+		},
+	}
+
+	for _, c := range successCases {
+		errs := validateTiFlashTableReplicas(c, 2, field.NewPath("tableReplicas"))
+		if len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := [][]v1alpha1.TiFlashTableReplica{
+		{{Table: "t1", Replicas: 1}},
+		{{Database: "test", Replicas: 1}},
+		{{Database: "test", Table: "t1", Replicas: -1}},
+		{{Database: "test", Table: "t1", Replicas: 3}},
+		{
+			{Database: "test", Table: "t1", Replicas: 1},
+			{Database: "TEST", Table: "T1", Replicas: 2},
+		},
+	}
+
+	for _, c := range errorCases {
+		errs := validateTiFlashTableReplicas(c, 2, field.NewPath("tableReplicas"))
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %v", c)
+		}
+	}
+}
+
+func TestValidatePodTemplateWebhook(t *testing.T) {
+	policy := func(p v1alpha1.PodTemplateWebhookFailurePolicy) *v1alpha1.PodTemplateWebhookFailurePolicy {
+		return &p
+	}
+	successCases := []v1alpha1.PodTemplateWebhook{
+		{URL: "https://mutator.platform.svc/tidb"},
+		{URL: "http://10.0.0.1:8080", TimeoutSeconds: pointer.Int32Ptr(3)},
+		{URL: "http://10.0.0.1:8080", FailurePolicy: policy(v1alpha1.PodTemplateWebhookFailurePolicyIgnore)},
+	}
+
+	for _, c := range successCases {
+		errs := validatePodTemplateWebhook(&c, field.NewPath("podTemplateWebhook"))
+		if len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := []v1alpha1.PodTemplateWebhook{
+		{},
+		{URL: "mutator.platform.svc"},
+		{URL: "ftp://mutator.platform.svc"},
+		{URL: "https://mutator.platform.svc", TimeoutSeconds: pointer.Int32Ptr(0)},
+		{URL: "https://mutator.platform.svc", FailurePolicy: policy("Retry")},
+	}
+
+	for _, c := range errorCases {
+		errs := validatePodTemplateWebhook(&c, field.NewPath("podTemplateWebhook"))
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %v", c)
+		}
+	}
+}
+
+func TestValidateTiKVPorts(t *testing.T) {
+	successCases := []v1alpha1.TiKVPorts{
+		{},
+		{Server: pointer.Int32Ptr(20161)},
+		{Server: pointer.Int32Ptr(30160), Status: pointer.Int32Ptr(30180)},
+	}
+
+	for _, c := range successCases {
+		errs := validateTiKVPorts(&c, field.NewPath("ports"))
+		if len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := []v1alpha1.TiKVPorts{
+		{Server: pointer.Int32Ptr(0)},
+		{Status: pointer.Int32Ptr(65536)},
+		{Server: pointer.Int32Ptr(20180)},
+		{Server: pointer.Int32Ptr(30160), Status: pointer.Int32Ptr(30160)},
+	}
+
+	for _, c := range errorCases {
+		errs := validateTiKVPorts(&c, field.NewPath("ports"))
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %v", c)
+		}
+	}
+}
+
+func TestValidateEphemeralStorage(t *testing.T) {
+	quantity := func(s string) *resource.Quantity {
+		q := resource.MustParse(s)
+		return &q
+	}
+	successCases := []*v1alpha1.EphemeralStorageSpec{
+		nil,
+		{Request: quantity("1Gi")},
+		{Request: quantity("1Gi"), Limit: quantity("1Gi"), EmptyDirSizeLimit: quantity("512Mi")},
+	}
+
+	for _, c := range successCases {
+		errs := validateEphemeralStorage(c, field.NewPath("ephemeralStorage"))
+		if len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := []*v1alpha1.EphemeralStorageSpec{
+		{Request: quantity("-1Gi")},
+		{EmptyDirSizeLimit: quantity("-1")},
+		{Request: quantity("2Gi"), Limit: quantity("1Gi")},
+	}
+
+	for _, c := range errorCases {
+		errs := validateEphemeralStorage(c, field.NewPath("ephemeralStorage"))
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %v", c)
+		}
+	}
+}
+
+func TestValidateRestartAt(t *testing.T) {
+	successCases := []map[string]string{
+		nil,
+		{"foo": "bar"},
+		{label.AnnRestartAt: "2021-06-01T08:00:00Z"},
+	}
+
+	for _, c := range successCases {
+		errs := validateRestartAt(c, field.NewPath("annotations"))
+		if len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := []map[string]string{
+		{label.AnnRestartAt: ""},
+		{label.AnnRestartAt: "2021-06-01 08:00:00"},
+	}
+
+	for _, c := range errorCases {
+		errs := validateRestartAt(c, field.NewPath("annotations"))
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %v", c)
+		}
+	}
+}
+
+func TestValidateMetricStabilizationGate(t *testing.T) {
+	successCases := []v1alpha1.MetricStabilizationGate{
+		{PrometheusURL: "http://prometheus:9090", Query: "up", Threshold: "1"},
+		{PrometheusURL: "http://prometheus:9090", Query: "up", Threshold: "0.05", Timeout: pointer.StringPtr("5m")},
+	}
+
+	for _, c := range successCases {
+		errs := validateMetricStabilizationGate(&c, field.NewPath("upgradeStabilizationGate"))
+		if len(errs) > 0 {
+			t.Errorf("expected success: %v", errs)
+		}
+	}
+
+	errorCases := []v1alpha1.MetricStabilizationGate{
+		{PrometheusURL: "prometheus", Query: "up", Threshold: "1"},
+		{PrometheusURL: "http://prometheus:9090", Query: " ", Threshold: "1"},
+		{PrometheusURL: "http://prometheus:9090", Query: "up", Threshold: "low"},
+		{PrometheusURL: "http://prometheus:9090", Query: "up", Threshold: "1", Timeout: pointer.StringPtr("5")},
+	}
+
+	for _, c := range errorCases {
+		errs := validateMetricStabilizationGate(&c, field.NewPath("upgradeStabilizationGate"))
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %v", c)
+		}
+	}
+}
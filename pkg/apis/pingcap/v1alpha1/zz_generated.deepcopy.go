@@ -25,10 +25,28 @@ import (
 	v1 "k8s.io/api/core/v1"
 	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
 	v1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	types "k8s.io/apimachinery/pkg/types"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AbortedUpgrade) DeepCopyInto(out *AbortedUpgrade) {
+	*out = *in
+	in.AbortedAt.DeepCopyInto(&out.AbortedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AbortedUpgrade.
+func (in *AbortedUpgrade) DeepCopy() *AbortedUpgrade {
+	if in == nil {
+		return nil
+	}
+	out := new(AbortedUpgrade)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AutoResource) DeepCopyInto(out *AutoResource) {
 	*out = *in
@@ -170,6 +188,22 @@ func (in *BackupCondition) DeepCopy() *BackupCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupGCLifeTimeStatus) DeepCopyInto(out *BackupGCLifeTimeStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupGCLifeTimeStatus.
+func (in *BackupGCLifeTimeStatus) DeepCopy() *BackupGCLifeTimeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupGCLifeTimeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupList) DeepCopyInto(out *BackupList) {
 	*out = *in
@@ -412,6 +446,11 @@ func (in *BackupStatus) DeepCopyInto(out *BackupStatus) {
 	*out = *in
 	in.TimeStarted.DeepCopyInto(&out.TimeStarted)
 	in.TimeCompleted.DeepCopyInto(&out.TimeCompleted)
+	if in.GCLifeTime != nil {
+		in, out := &in.GCLifeTime, &out.GCLifeTime
+		*out = new(BackupGCLifeTimeStatus)
+		**out = **in
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]BackupCondition, len(*in))
@@ -625,6 +664,47 @@ func (in *ClusterRef) DeepCopy() *ClusterRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterTopology) DeepCopyInto(out *ClusterTopology) {
+	*out = *in
+	if in.PD != nil {
+		in, out := &in.PD, &out.PD
+		*out = make([]PodTopology, len(*in))
+		copy(*out, *in)
+	}
+	if in.TiKV != nil {
+		in, out := &in.TiKV, &out.TiKV
+		*out = make([]PodTopology, len(*in))
+		copy(*out, *in)
+	}
+	if in.TiDB != nil {
+		in, out := &in.TiDB, &out.TiDB
+		*out = make([]PodTopology, len(*in))
+		copy(*out, *in)
+	}
+	if in.TiFlash != nil {
+		in, out := &in.TiFlash, &out.TiFlash
+		*out = make([]PodTopology, len(*in))
+		copy(*out, *in)
+	}
+	if in.TruncatedComponents != nil {
+		in, out := &in.TruncatedComponents, &out.TruncatedComponents
+		*out = make([]MemberType, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterTopology.
+func (in *ClusterTopology) DeepCopy() *ClusterTopology {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterTopology)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CommonConfig) DeepCopyInto(out *CommonConfig) {
 	*out = *in
@@ -822,6 +902,11 @@ func (in *ComponentSpec) DeepCopyInto(out *ComponentSpec) {
 		*out = new(v1.PodSecurityContext)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(v1.SecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ConfigUpdateStrategy != nil {
 		in, out := &in.ConfigUpdateStrategy, &out.ConfigUpdateStrategy
 		*out = new(ConfigUpdateStrategy)
@@ -872,6 +957,16 @@ func (in *ComponentSpec) DeepCopyInto(out *ComponentSpec) {
 		*out = make([]TopologySpreadConstraint, len(*in))
 		copy(*out, *in)
 	}
+	if in.FailoverTopologySpreadConstraints != nil {
+		in, out := &in.FailoverTopologySpreadConstraints, &out.FailoverTopologySpreadConstraints
+		*out = make([]TopologySpreadConstraint, len(*in))
+		copy(*out, *in)
+	}
+	if in.EphemeralStorage != nil {
+		in, out := &in.EphemeralStorage, &out.EphemeralStorage
+		*out = new(EphemeralStorageSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -979,6 +1074,7 @@ func (in *CrdKinds) DeepCopyInto(out *CrdKinds) {
 	in.TiDBMonitor.DeepCopyInto(&out.TiDBMonitor)
 	in.TiDBInitializer.DeepCopyInto(&out.TiDBInitializer)
 	in.TidbClusterAutoScaler.DeepCopyInto(&out.TidbClusterAutoScaler)
+	in.TidbClusterConfigProfile.DeepCopyInto(&out.TidbClusterConfigProfile)
 	return
 }
 
@@ -992,6 +1088,22 @@ func (in *CrdKinds) DeepCopy() *CrdKinds {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CrossComponentAntiAffinity) DeepCopyInto(out *CrossComponentAntiAffinity) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CrossComponentAntiAffinity.
+func (in *CrossComponentAntiAffinity) DeepCopy() *CrossComponentAntiAffinity {
+	if in == nil {
+		return nil
+	}
+	out := new(CrossComponentAntiAffinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DMCluster) DeepCopyInto(out *DMCluster) {
 	*out = *in
@@ -1159,6 +1271,11 @@ func (in *DMClusterSpec) DeepCopyInto(out *DMClusterSpec) {
 		*out = make([]TopologySpreadConstraint, len(*in))
 		copy(*out, *in)
 	}
+	if in.MaintenanceWindow != nil {
+		in, out := &in.MaintenanceWindow, &out.MaintenanceWindow
+		*out = new(MaintenanceWindow)
+		**out = **in
+	}
 	return
 }
 
@@ -1461,6 +1578,63 @@ func (in *DumplingConfig) DeepCopy() *DumplingConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EphemeralStorageSpec) DeepCopyInto(out *EphemeralStorageSpec) {
+	*out = *in
+	if in.Request != nil {
+		in, out := &in.Request, &out.Request
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Limit != nil {
+		in, out := &in.Limit, &out.Limit
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.EmptyDirSizeLimit != nil {
+		in, out := &in.EmptyDirSizeLimit, &out.EmptyDirSizeLimit
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EphemeralStorageSpec.
+func (in *EphemeralStorageSpec) DeepCopy() *EphemeralStorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EphemeralStorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EvictLeaderBackoff) DeepCopyInto(out *EvictLeaderBackoff) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(string)
+		**out = **in
+	}
+	if in.MaxInterval != nil {
+		in, out := &in.MaxInterval, &out.MaxInterval
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EvictLeaderBackoff.
+func (in *EvictLeaderBackoff) DeepCopy() *EvictLeaderBackoff {
+	if in == nil {
+		return nil
+	}
+	out := new(EvictLeaderBackoff)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Experimental) DeepCopyInto(out *Experimental) {
 	*out = *in
@@ -1525,6 +1699,47 @@ func (in *ExternalEndpoint) DeepCopy() *ExternalEndpoint {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FailoverSpec) DeepCopyInto(out *FailoverSpec) {
+	*out = *in
+	if in.DetectionPeriod != nil {
+		in, out := &in.DetectionPeriod, &out.DetectionPeriod
+		*out = new(string)
+		**out = **in
+	}
+	if in.MaxFailoverCount != nil {
+		in, out := &in.MaxFailoverCount, &out.MaxFailoverCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RecoverByDefault != nil {
+		in, out := &in.RecoverByDefault, &out.RecoverByDefault
+		*out = new(bool)
+		**out = **in
+	}
+	if in.SnapshotBeforeDelete != nil {
+		in, out := &in.SnapshotBeforeDelete, &out.SnapshotBeforeDelete
+		*out = new(bool)
+		**out = **in
+	}
+	if in.EndpointCheck != nil {
+		in, out := &in.EndpointCheck, &out.EndpointCheck
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FailoverSpec.
+func (in *FailoverSpec) DeepCopy() *FailoverSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FailoverSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FileLogConfig) DeepCopyInto(out *FileLogConfig) {
 	*out = *in
@@ -2012,6 +2227,32 @@ func (in *GrafanaSpec) DeepCopy() *GrafanaSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPScaleHook) DeepCopyInto(out *HTTPScaleHook) {
+	*out = *in
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPScaleHook.
+func (in *HTTPScaleHook) DeepCopy() *HTTPScaleHook {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPScaleHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HelperSpec) DeepCopyInto(out *HelperSpec) {
 	*out = *in
@@ -2164,6 +2405,32 @@ func (in *IsolationRead) DeepCopy() *IsolationRead {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobScaleHook) DeepCopyInto(out *JobScaleHook) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobScaleHook.
+func (in *JobScaleHook) DeepCopy() *JobScaleHook {
+	if in == nil {
+		return nil
+	}
+	out := new(JobScaleHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LocalStorageProvider) DeepCopyInto(out *LocalStorageProvider) {
 	*out = *in
@@ -2275,6 +2542,22 @@ func (in *LogTailerSpec) DeepCopy() *LogTailerSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MasterConfig) DeepCopyInto(out *MasterConfig) {
 	*out = *in
@@ -2531,6 +2814,27 @@ func (in *MasterStatus) DeepCopy() *MasterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricStabilizationGate) DeepCopyInto(out *MetricStabilizationGate) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricStabilizationGate.
+func (in *MetricStabilizationGate) DeepCopy() *MetricStabilizationGate {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricStabilizationGate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MonitorContainer) DeepCopyInto(out *MonitorContainer) {
 	*out = *in
@@ -2688,50 +2992,118 @@ func (in *OpenTracingSampler) DeepCopy() *OpenTracingSampler {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PDConfig) DeepCopyInto(out *PDConfig) {
+func (in *PDBalanceLimits) DeepCopyInto(out *PDBalanceLimits) {
 	*out = *in
-	if in.ForceNewCluster != nil {
-		in, out := &in.ForceNewCluster, &out.ForceNewCluster
-		*out = new(bool)
+	if in.MaxPendingPeerCount != nil {
+		in, out := &in.MaxPendingPeerCount, &out.MaxPendingPeerCount
+		*out = new(int32)
 		**out = **in
 	}
-	if in.EnableGRPCGateway != nil {
-		in, out := &in.EnableGRPCGateway, &out.EnableGRPCGateway
-		*out = new(bool)
+	if in.MaxSnapshotCount != nil {
+		in, out := &in.MaxSnapshotCount, &out.MaxSnapshotCount
+		*out = new(int32)
 		**out = **in
 	}
-	if in.InitialClusterToken != nil {
-		in, out := &in.InitialClusterToken, &out.InitialClusterToken
-		*out = new(string)
+	if in.LeaderScheduleLimit != nil {
+		in, out := &in.LeaderScheduleLimit, &out.LeaderScheduleLimit
+		*out = new(int32)
 		**out = **in
 	}
-	if in.LeaderLease != nil {
-		in, out := &in.LeaderLease, &out.LeaderLease
-		*out = new(int64)
+	if in.RegionScheduleLimit != nil {
+		in, out := &in.RegionScheduleLimit, &out.RegionScheduleLimit
+		*out = new(int32)
 		**out = **in
 	}
-	if in.Log != nil {
-		in, out := &in.Log, &out.Log
-		*out = new(PDLogConfig)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.LogFileDeprecated != nil {
-		in, out := &in.LogFileDeprecated, &out.LogFileDeprecated
-		*out = new(string)
+	if in.ReplicaScheduleLimit != nil {
+		in, out := &in.ReplicaScheduleLimit, &out.ReplicaScheduleLimit
+		*out = new(int32)
 		**out = **in
 	}
-	if in.LogLevelDeprecated != nil {
-		in, out := &in.LogLevelDeprecated, &out.LogLevelDeprecated
-		*out = new(string)
+	if in.MergeScheduleLimit != nil {
+		in, out := &in.MergeScheduleLimit, &out.MergeScheduleLimit
+		*out = new(int32)
 		**out = **in
 	}
-	if in.TsoSaveInterval != nil {
-		in, out := &in.TsoSaveInterval, &out.TsoSaveInterval
-		*out = new(string)
+	if in.HotRegionScheduleLimit != nil {
+		in, out := &in.HotRegionScheduleLimit, &out.HotRegionScheduleLimit
+		*out = new(int32)
 		**out = **in
 	}
-	if in.Metric != nil {
-		in, out := &in.Metric, &out.Metric
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PDBalanceLimits.
+func (in *PDBalanceLimits) DeepCopy() *PDBalanceLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(PDBalanceLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PDBalanceStatus) DeepCopyInto(out *PDBalanceStatus) {
+	*out = *in
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PDBalanceStatus.
+func (in *PDBalanceStatus) DeepCopy() *PDBalanceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PDBalanceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PDConfig) DeepCopyInto(out *PDConfig) {
+	*out = *in
+	if in.ForceNewCluster != nil {
+		in, out := &in.ForceNewCluster, &out.ForceNewCluster
+		*out = new(bool)
+		**out = **in
+	}
+	if in.EnableGRPCGateway != nil {
+		in, out := &in.EnableGRPCGateway, &out.EnableGRPCGateway
+		*out = new(bool)
+		**out = **in
+	}
+	if in.InitialClusterToken != nil {
+		in, out := &in.InitialClusterToken, &out.InitialClusterToken
+		*out = new(string)
+		**out = **in
+	}
+	if in.LeaderLease != nil {
+		in, out := &in.LeaderLease, &out.LeaderLease
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Log != nil {
+		in, out := &in.Log, &out.Log
+		*out = new(PDLogConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LogFileDeprecated != nil {
+		in, out := &in.LogFileDeprecated, &out.LogFileDeprecated
+		*out = new(string)
+		**out = **in
+	}
+	if in.LogLevelDeprecated != nil {
+		in, out := &in.LogLevelDeprecated, &out.LogLevelDeprecated
+		*out = new(string)
+		**out = **in
+	}
+	if in.TsoSaveInterval != nil {
+		in, out := &in.TsoSaveInterval, &out.TsoSaveInterval
+		*out = new(string)
+		**out = **in
+	}
+	if in.Metric != nil {
+		in, out := &in.Metric, &out.Metric
 		*out = new(PDMetricConfig)
 		(*in).DeepCopyInto(*out)
 	}
@@ -2881,6 +3253,34 @@ func (in *PDFailureMember) DeepCopy() *PDFailureMember {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PDGroup) DeepCopyInto(out *PDGroup) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PDGroup.
+func (in *PDGroup) DeepCopy() *PDGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(PDGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in PDLabelPropertyConfig) DeepCopyInto(out *PDLabelPropertyConfig) {
 	{
@@ -2973,6 +3373,10 @@ func (in *PDLogConfig) DeepCopy() *PDLogConfig {
 func (in *PDMember) DeepCopyInto(out *PDMember) {
 	*out = *in
 	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	if in.LearnerSince != nil {
+		in, out := &in.LearnerSince, &out.LearnerSince
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -3063,6 +3467,32 @@ func (in *PDNamespaceConfig) DeepCopy() *PDNamespaceConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PDRegionSizeConfig) DeepCopyInto(out *PDRegionSizeConfig) {
+	*out = *in
+	if in.RegionMaxSize != nil {
+		in, out := &in.RegionMaxSize, &out.RegionMaxSize
+		*out = new(string)
+		**out = **in
+	}
+	if in.RegionSplitSize != nil {
+		in, out := &in.RegionSplitSize, &out.RegionSplitSize
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PDRegionSizeConfig.
+func (in *PDRegionSizeConfig) DeepCopy() *PDRegionSizeConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PDRegionSizeConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PDReplicationConfig) DeepCopyInto(out *PDReplicationConfig) {
 	*out = *in
@@ -3254,6 +3684,26 @@ func (in *PDScheduleConfig) DeepCopy() *PDScheduleConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PDScheduler) DeepCopyInto(out *PDScheduler) {
+	*out = *in
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PDScheduler.
+func (in *PDScheduler) DeepCopy() *PDScheduler {
+	if in == nil {
+		return nil
+	}
+	out := new(PDScheduler)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PDSchedulerConfig) DeepCopyInto(out *PDSchedulerConfig) {
 	*out = *in
@@ -3384,6 +3834,11 @@ func (in *PDSpec) DeepCopyInto(out *PDSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Failover != nil {
+		in, out := &in.Failover, &out.Failover
+		*out = new(FailoverSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.StorageClassName != nil {
 		in, out := &in.StorageClassName, &out.StorageClassName
 		*out = new(string)
@@ -3416,6 +3871,90 @@ func (in *PDSpec) DeepCopyInto(out *PDSpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Schedulers != nil {
+		in, out := &in.Schedulers, &out.Schedulers
+		*out = make([]PDScheduler, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RegionSize != nil {
+		in, out := &in.RegionSize, &out.RegionSize
+		*out = new(PDRegionSizeConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BalanceLimits != nil {
+		in, out := &in.BalanceLimits, &out.BalanceLimits
+		*out = new(PDBalanceLimits)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LearnerTimeout != nil {
+		in, out := &in.LearnerTimeout, &out.LearnerTimeout
+		*out = new(string)
+		**out = **in
+	}
+	if in.RecreateStuckLearner != nil {
+		in, out := &in.RecreateStuckLearner, &out.RecreateStuckLearner
+		*out = new(bool)
+		**out = **in
+	}
+	if in.JoinAsLearner != nil {
+		in, out := &in.JoinAsLearner, &out.JoinAsLearner
+		*out = new(bool)
+		**out = **in
+	}
+	if in.LostPVCGracePeriod != nil {
+		in, out := &in.LostPVCGracePeriod, &out.LostPVCGracePeriod
+		*out = new(string)
+		**out = **in
+	}
+	if in.RecoverLostPVC != nil {
+		in, out := &in.RecoverLostPVC, &out.RecoverLostPVC
+		*out = new(bool)
+		**out = **in
+	}
+	if in.FailoverPVCPolicy != nil {
+		in, out := &in.FailoverPVCPolicy, &out.FailoverPVCPolicy
+		*out = new(FailoverPVCPolicy)
+		**out = **in
+	}
+	if in.UpgradeStabilizationGate != nil {
+		in, out := &in.UpgradeStabilizationGate, &out.UpgradeStabilizationGate
+		*out = new(MetricStabilizationGate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EtcdDefragInterval != nil {
+		in, out := &in.EtcdDefragInterval, &out.EtcdDefragInterval
+		*out = new(string)
+		**out = **in
+	}
+	if in.ScaleInLeaderTransferTimeout != nil {
+		in, out := &in.ScaleInLeaderTransferTimeout, &out.ScaleInLeaderTransferTimeout
+		*out = new(string)
+		**out = **in
+	}
+	if in.ScaleHooks != nil {
+		in, out := &in.ScaleHooks, &out.ScaleHooks
+		*out = new(ScaleHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpgradeHooks != nil {
+		in, out := &in.UpgradeHooks, &out.UpgradeHooks
+		*out = new(UpgradeHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]PDGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LeaderTransferPriority != nil {
+		in, out := &in.LeaderTransferPriority, &out.LeaderTransferPriority
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -3466,6 +4005,30 @@ func (in *PDStatus) DeepCopyInto(out *PDStatus) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.Balance != nil {
+		in, out := &in.Balance, &out.Balance
+		*out = new(PDBalanceStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EtcdDefragTime != nil {
+		in, out := &in.EtcdDefragTime, &out.EtcdDefragTime
+		*out = make(map[string]metav1.Time, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.LostPVCSince != nil {
+		in, out := &in.LostPVCSince, &out.LostPVCSince
+		*out = make(map[string]metav1.Time, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(UpgradeProgress)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -3527,6 +4090,70 @@ func (in PDStoreLabels) DeepCopy() PDStoreLabels {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PVCResizeFailurePolicy) DeepCopyInto(out *PVCResizeFailurePolicy) {
+	*out = *in
+	if in.FileSystemResizeTimeout != nil {
+		in, out := &in.FileSystemResizeTimeout, &out.FileSystemResizeTimeout
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PVCResizeFailurePolicy.
+func (in *PVCResizeFailurePolicy) DeepCopy() *PVCResizeFailurePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PVCResizeFailurePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PVCSnapshot) DeepCopyInto(out *PVCSnapshot) {
+	*out = *in
+	in.CreatedAt.DeepCopyInto(&out.CreatedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PVCSnapshot.
+func (in *PVCSnapshot) DeepCopy() *PVCSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(PVCSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PVCSnapshotSpec) DeepCopyInto(out *PVCSnapshotSpec) {
+	*out = *in
+	if in.VolumeSnapshotClassName != nil {
+		in, out := &in.VolumeSnapshotClassName, &out.VolumeSnapshotClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PVCSnapshotSpec.
+func (in *PVCSnapshotSpec) DeepCopy() *PVCSnapshotSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PVCSnapshotSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Performance) DeepCopyInto(out *Performance) {
 	*out = *in
@@ -3644,12 +4271,52 @@ func (in *PessimisticTxn) DeepCopyInto(out *PessimisticTxn) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PessimisticTxn.
-func (in *PessimisticTxn) DeepCopy() *PessimisticTxn {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PessimisticTxn.
+func (in *PessimisticTxn) DeepCopy() *PessimisticTxn {
+	if in == nil {
+		return nil
+	}
+	out := new(PessimisticTxn)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementPolicy) DeepCopyInto(out *PlacementPolicy) {
+	*out = *in
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementPolicy.
+func (in *PlacementPolicy) DeepCopy() *PlacementPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementPolicyStatus) DeepCopyInto(out *PlacementPolicyStatus) {
+	*out = *in
+	in.LastSyncTime.DeepCopyInto(&out.LastSyncTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementPolicyStatus.
+func (in *PlacementPolicyStatus) DeepCopy() *PlacementPolicyStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(PessimisticTxn)
+	out := new(PlacementPolicyStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -3711,6 +4378,79 @@ func (in *Plugin) DeepCopy() *Plugin {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodRestartPolicy) DeepCopyInto(out *PodRestartPolicy) {
+	*out = *in
+	if in.Threshold != nil {
+		in, out := &in.Threshold, &out.Threshold
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Window != nil {
+		in, out := &in.Window, &out.Window
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodRestartPolicy.
+func (in *PodRestartPolicy) DeepCopy() *PodRestartPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PodRestartPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodTemplateWebhook) DeepCopyInto(out *PodTemplateWebhook) {
+	*out = *in
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailurePolicy != nil {
+		in, out := &in.FailurePolicy, &out.FailurePolicy
+		*out = new(PodTemplateWebhookFailurePolicy)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodTemplateWebhook.
+func (in *PodTemplateWebhook) DeepCopy() *PodTemplateWebhook {
+	if in == nil {
+		return nil
+	}
+	out := new(PodTemplateWebhook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodTopology) DeepCopyInto(out *PodTopology) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodTopology.
+func (in *PodTopology) DeepCopy() *PodTopology {
+	if in == nil {
+		return nil
+	}
+	out := new(PodTopology)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PreparedPlanCache) DeepCopyInto(out *PreparedPlanCache) {
 	*out = *in
@@ -4061,6 +4801,11 @@ func (in *PumpStatus) DeepCopyInto(out *PumpStatus) {
 			}
 		}
 	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(UpgradeProgress)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -4368,6 +5113,27 @@ func (in *RestoreStatus) DeepCopy() *RestoreStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutStallPolicy) DeepCopyInto(out *RolloutStallPolicy) {
+	*out = *in
+	if in.Threshold != nil {
+		in, out := &in.Threshold, &out.Threshold
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStallPolicy.
+func (in *RolloutStallPolicy) DeepCopy() *RolloutStallPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutStallPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *S3StorageProvider) DeepCopyInto(out *S3StorageProvider) {
 	*out = *in
@@ -4412,6 +5178,117 @@ func (in *SafeTLSConfig) DeepCopy() *SafeTLSConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaleHook) DeepCopyInto(out *ScaleHook) {
+	*out = *in
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(HTTPScaleHook)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Job != nil {
+		in, out := &in.Job, &out.Job
+		*out = new(JobScaleHook)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleHook.
+func (in *ScaleHook) DeepCopy() *ScaleHook {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaleHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaleHooks) DeepCopyInto(out *ScaleHooks) {
+	*out = *in
+	if in.PreScaleIn != nil {
+		in, out := &in.PreScaleIn, &out.PreScaleIn
+		*out = new(ScaleHook)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PostScale != nil {
+		in, out := &in.PostScale, &out.PostScale
+		*out = new(ScaleHook)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleHooks.
+func (in *ScaleHooks) DeepCopy() *ScaleHooks {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaleHooks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalePolicy) DeepCopyInto(out *ScalePolicy) {
+	*out = *in
+	if in.MaxScaleVelocity != nil {
+		in, out := &in.MaxScaleVelocity, &out.MaxScaleVelocity
+		*out = new(ScaleVelocity)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalePolicy.
+func (in *ScalePolicy) DeepCopy() *ScalePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaleRecord) DeepCopyInto(out *ScaleRecord) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleRecord.
+func (in *ScaleRecord) DeepCopy() *ScaleRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaleRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaleVelocity) DeepCopyInto(out *ScaleVelocity) {
+	*out = *in
+	if in.Window != nil {
+		in, out := &in.Window, &out.Window
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleVelocity.
+func (in *ScaleVelocity) DeepCopy() *ScaleVelocity {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaleVelocity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretOrConfigMap) DeepCopyInto(out *SecretOrConfigMap) {
 	*out = *in
@@ -4576,6 +5453,23 @@ func (in *ServiceSpec) DeepCopy() *ServiceSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StalledPod) DeepCopyInto(out *StalledPod) {
+	*out = *in
+	in.PendingSince.DeepCopyInto(&out.PendingSince)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StalledPod.
+func (in *StalledPod) DeepCopy() *StalledPod {
+	if in == nil {
+		return nil
+	}
+	out := new(StalledPod)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Status) DeepCopyInto(out *Status) {
 	*out = *in
@@ -4658,6 +5552,27 @@ func (in *StmtSummary) DeepCopy() *StmtSummary {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageCheckSpec) DeepCopyInto(out *StorageCheckSpec) {
+	*out = *in
+	if in.SizeMB != nil {
+		in, out := &in.SizeMB, &out.SizeMB
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageCheckSpec.
+func (in *StorageCheckSpec) DeepCopy() *StorageCheckSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageCheckSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageClaim) DeepCopyInto(out *StorageClaim) {
 	*out = *in
@@ -4732,6 +5647,44 @@ func (in *StorageVolume) DeepCopy() *StorageVolume {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StoreDecommissionProgress) DeepCopyInto(out *StoreDecommissionProgress) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.EstimatedCompletionTime != nil {
+		in, out := &in.EstimatedCompletionTime, &out.EstimatedCompletionTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StoreDecommissionProgress.
+func (in *StoreDecommissionProgress) DeepCopy() *StoreDecommissionProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(StoreDecommissionProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SystemVariableStatus) DeepCopyInto(out *SystemVariableStatus) {
+	*out = *in
+	in.LastSyncTime.DeepCopyInto(&out.LastSyncTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SystemVariableStatus.
+func (in *SystemVariableStatus) DeepCopy() *SystemVariableStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SystemVariableStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TLSCluster) DeepCopyInto(out *TLSCluster) {
 	*out = *in
@@ -4893,6 +5846,11 @@ func (in *TiCDCSpec) DeepCopyInto(out *TiCDCSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.GracefulShutdownTimeout != nil {
+		in, out := &in.GracefulShutdownTimeout, &out.GracefulShutdownTimeout
+		*out = new(string)
+		**out = **in
+	}
 	return
 }
 
@@ -4921,6 +5879,11 @@ func (in *TiCDCStatus) DeepCopyInto(out *TiCDCStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(UpgradeProgress)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -4929,28 +5892,87 @@ func (in *TiCDCStatus) DeepCopy() *TiCDCStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(TiCDCStatus)
+	out := new(TiCDCStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiDBAccessConfig) DeepCopyInto(out *TiDBAccessConfig) {
+	*out = *in
+	if in.TLSClientSecretName != nil {
+		in, out := &in.TLSClientSecretName, &out.TLSClientSecretName
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiDBAccessConfig.
+func (in *TiDBAccessConfig) DeepCopy() *TiDBAccessConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TiDBAccessConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiDBAuditLogSpec) DeepCopyInto(out *TiDBAuditLogSpec) {
+	*out = *in
+	if in.Sidecar != nil {
+		in, out := &in.Sidecar, &out.Sidecar
+		*out = new(v1.Container)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiDBAuditLogSpec.
+func (in *TiDBAuditLogSpec) DeepCopy() *TiDBAuditLogSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TiDBAuditLogSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiDBBlueGreenStatus) DeepCopyInto(out *TiDBBlueGreenStatus) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiDBBlueGreenStatus.
+func (in *TiDBBlueGreenStatus) DeepCopy() *TiDBBlueGreenStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TiDBBlueGreenStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *TiDBAccessConfig) DeepCopyInto(out *TiDBAccessConfig) {
+func (in *TiDBCanaryPolicy) DeepCopyInto(out *TiDBCanaryPolicy) {
 	*out = *in
-	if in.TLSClientSecretName != nil {
-		in, out := &in.TLSClientSecretName, &out.TLSClientSecretName
+	if in.PauseDuration != nil {
+		in, out := &in.PauseDuration, &out.PauseDuration
 		*out = new(string)
 		**out = **in
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiDBAccessConfig.
-func (in *TiDBAccessConfig) DeepCopy() *TiDBAccessConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiDBCanaryPolicy.
+func (in *TiDBCanaryPolicy) DeepCopy() *TiDBCanaryPolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(TiDBAccessConfig)
+	out := new(TiDBCanaryPolicy)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -5364,6 +6386,11 @@ func (in *TiDBSpec) DeepCopyInto(out *TiDBSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Failover != nil {
+		in, out := &in.Failover, &out.Failover
+		*out = new(FailoverSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.SeparateSlowLog != nil {
 		in, out := &in.SeparateSlowLog, &out.SeparateSlowLog
 		*out = new(bool)
@@ -5389,6 +6416,21 @@ func (in *TiDBSpec) DeepCopyInto(out *TiDBSpec) {
 		*out = new(TiDBConfigWraper)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.PerOrdinalConfig != nil {
+		in, out := &in.PerOrdinalConfig, &out.PerOrdinalConfig
+		*out = make(map[string]*TiDBConfigWraper, len(*in))
+		for key, val := range *in {
+			var outVal *TiDBConfigWraper
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(TiDBConfigWraper)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
 	if in.Lifecycle != nil {
 		in, out := &in.Lifecycle, &out.Lifecycle
 		*out = new(v1.Lifecycle)
@@ -5411,6 +6453,60 @@ func (in *TiDBSpec) DeepCopyInto(out *TiDBSpec) {
 		*out = new(TiDBProbe)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.UpgradeStabilizationGate != nil {
+		in, out := &in.UpgradeStabilizationGate, &out.UpgradeStabilizationGate
+		*out = new(MetricStabilizationGate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpgradeConcurrency != nil {
+		in, out := &in.UpgradeConcurrency, &out.UpgradeConcurrency
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PlacementPolicies != nil {
+		in, out := &in.PlacementPolicies, &out.PlacementPolicies
+		*out = make([]PlacementPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SystemVariables != nil {
+		in, out := &in.SystemVariables, &out.SystemVariables
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.AuditLog != nil {
+		in, out := &in.AuditLog, &out.AuditLog
+		*out = new(TiDBAuditLogSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IncompatibleDataPolicy != nil {
+		in, out := &in.IncompatibleDataPolicy, &out.IncompatibleDataPolicy
+		*out = new(IncompatibleDataPolicy)
+		**out = **in
+	}
+	if in.ScaleInDrainTimeout != nil {
+		in, out := &in.ScaleInDrainTimeout, &out.ScaleInDrainTimeout
+		*out = new(string)
+		**out = **in
+	}
+	if in.ScaleHooks != nil {
+		in, out := &in.ScaleHooks, &out.ScaleHooks
+		*out = new(ScaleHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpgradeHooks != nil {
+		in, out := &in.UpgradeHooks, &out.UpgradeHooks
+		*out = new(UpgradeHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpgradePolicy != nil {
+		in, out := &in.UpgradePolicy, &out.UpgradePolicy
+		*out = new(TiDBUpgradePolicy)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -5446,6 +6542,35 @@ func (in *TiDBStatus) DeepCopyInto(out *TiDBStatus) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.BlueGreen != nil {
+		in, out := &in.BlueGreen, &out.BlueGreen
+		*out = new(TiDBBlueGreenStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PlacementPolicies != nil {
+		in, out := &in.PlacementPolicies, &out.PlacementPolicies
+		*out = make(map[string]PlacementPolicyStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.SystemVariables != nil {
+		in, out := &in.SystemVariables, &out.SystemVariables
+		*out = make(map[string]SystemVariableStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.IncompatibleDataPods != nil {
+		in, out := &in.IncompatibleDataPods, &out.IncompatibleDataPods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(UpgradeProgress)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -5475,6 +6600,27 @@ func (in *TiDBTLSClient) DeepCopy() *TiDBTLSClient {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiDBUpgradePolicy) DeepCopyInto(out *TiDBUpgradePolicy) {
+	*out = *in
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(TiDBCanaryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiDBUpgradePolicy.
+func (in *TiDBUpgradePolicy) DeepCopy() *TiDBUpgradePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(TiDBUpgradePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TiFlashCommonConfigWraper) DeepCopyInto(out *TiFlashCommonConfigWraper) {
 	*out = *in
@@ -5582,6 +6728,11 @@ func (in *TiFlashSpec) DeepCopyInto(out *TiFlashSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Failover != nil {
+		in, out := &in.Failover, &out.Failover
+		*out = new(FailoverSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.StorageClaims != nil {
 		in, out := &in.StorageClaims, &out.StorageClaims
 		*out = make([]StorageClaim, len(*in))
@@ -5599,6 +6750,31 @@ func (in *TiFlashSpec) DeepCopyInto(out *TiFlashSpec) {
 		*out = new(LogTailerSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.FailoverPVCPolicy != nil {
+		in, out := &in.FailoverPVCPolicy, &out.FailoverPVCPolicy
+		*out = new(FailoverPVCPolicy)
+		**out = **in
+	}
+	if in.TableReplicas != nil {
+		in, out := &in.TableReplicas, &out.TableReplicas
+		*out = make([]TiFlashTableReplica, len(*in))
+		copy(*out, *in)
+	}
+	if in.ScaleHooks != nil {
+		in, out := &in.ScaleHooks, &out.ScaleHooks
+		*out = new(ScaleHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpgradeHooks != nil {
+		in, out := &in.UpgradeHooks, &out.UpgradeHooks
+		*out = new(UpgradeHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpgradeCheck != nil {
+		in, out := &in.UpgradeCheck, &out.UpgradeCheck
+		*out = new(TiFlashUpgradeCheck)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -5648,6 +6824,18 @@ func (in *TiFlashStatus) DeepCopyInto(out *TiFlashStatus) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.TableReplicas != nil {
+		in, out := &in.TableReplicas, &out.TableReplicas
+		*out = make(map[string]TiFlashTableReplicaStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(UpgradeProgress)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -5661,6 +6849,70 @@ func (in *TiFlashStatus) DeepCopy() *TiFlashStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiFlashTableReplica) DeepCopyInto(out *TiFlashTableReplica) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiFlashTableReplica.
+func (in *TiFlashTableReplica) DeepCopy() *TiFlashTableReplica {
+	if in == nil {
+		return nil
+	}
+	out := new(TiFlashTableReplica)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiFlashTableReplicaStatus) DeepCopyInto(out *TiFlashTableReplicaStatus) {
+	*out = *in
+	in.LastSyncTime.DeepCopyInto(&out.LastSyncTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiFlashTableReplicaStatus.
+func (in *TiFlashTableReplicaStatus) DeepCopy() *TiFlashTableReplicaStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TiFlashTableReplicaStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiFlashUpgradeCheck) DeepCopyInto(out *TiFlashUpgradeCheck) {
+	*out = *in
+	if in.MaxPendingPeerRegions != nil {
+		in, out := &in.MaxPendingPeerRegions, &out.MaxPendingPeerRegions
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxApplyingSnapshots != nil {
+		in, out := &in.MaxApplyingSnapshots, &out.MaxApplyingSnapshots
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiFlashUpgradeCheck.
+func (in *TiFlashUpgradeCheck) DeepCopy() *TiFlashUpgradeCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(TiFlashUpgradeCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TiKVBackupConfig) DeepCopyInto(out *TiKVBackupConfig) {
 	*out = *in
@@ -6649,6 +7901,32 @@ func (in *TiKVPessimisticTxn) DeepCopy() *TiKVPessimisticTxn {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiKVPorts) DeepCopyInto(out *TiKVPorts) {
+	*out = *in
+	if in.Server != nil {
+		in, out := &in.Server, &out.Server
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiKVPorts.
+func (in *TiKVPorts) DeepCopy() *TiKVPorts {
+	if in == nil {
+		return nil
+	}
+	out := new(TiKVPorts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TiKVRaftDBConfig) DeepCopyInto(out *TiKVRaftDBConfig) {
 	*out = *in
@@ -7102,6 +8380,22 @@ func (in *TiKVReadPoolConfig) DeepCopy() *TiKVReadPoolConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiKVScalePolicy) DeepCopyInto(out *TiKVScalePolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiKVScalePolicy.
+func (in *TiKVScalePolicy) DeepCopy() *TiKVScalePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(TiKVScalePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TiKVSecurityConfig) DeepCopyInto(out *TiKVSecurityConfig) {
 	*out = *in
@@ -7403,6 +8697,11 @@ func (in *TiKVSpec) DeepCopyInto(out *TiKVSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Failover != nil {
+		in, out := &in.Failover, &out.Failover
+		*out = new(FailoverSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.SeparateRocksDBLog != nil {
 		in, out := &in.SeparateRocksDBLog, &out.SeparateRocksDBLog
 		*out = new(bool)
@@ -7428,6 +8727,26 @@ func (in *TiKVSpec) DeepCopyInto(out *TiKVSpec) {
 		*out = new(TiKVConfigWraper)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.PerOrdinalConfig != nil {
+		in, out := &in.PerOrdinalConfig, &out.PerOrdinalConfig
+		*out = make(map[string]*TiKVConfigWraper, len(*in))
+		for key, val := range *in {
+			var outVal *TiKVConfigWraper
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(TiKVConfigWraper)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.FailoverPVCPolicy != nil {
+		in, out := &in.FailoverPVCPolicy, &out.FailoverPVCPolicy
+		*out = new(FailoverPVCPolicy)
+		**out = **in
+	}
 	if in.MountClusterClientSecret != nil {
 		in, out := &in.MountClusterClientSecret, &out.MountClusterClientSecret
 		*out = new(bool)
@@ -7438,6 +8757,26 @@ func (in *TiKVSpec) DeepCopyInto(out *TiKVSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.EvictLeaderBackoff != nil {
+		in, out := &in.EvictLeaderBackoff, &out.EvictLeaderBackoff
+		*out = new(EvictLeaderBackoff)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaxConcurrentEvictLeaders != nil {
+		in, out := &in.MaxConcurrentEvictLeaders, &out.MaxConcurrentEvictLeaders
+		*out = new(int32)
+		**out = **in
+	}
+	if in.StorageCheck != nil {
+		in, out := &in.StorageCheck, &out.StorageCheck
+		*out = new(StorageCheckSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StoreHeartbeatStaleThreshold != nil {
+		in, out := &in.StoreHeartbeatStaleThreshold, &out.StoreHeartbeatStaleThreshold
+		*out = new(string)
+		**out = **in
+	}
 	if in.StorageVolumes != nil {
 		in, out := &in.StorageVolumes, &out.StorageVolumes
 		*out = make([]StorageVolume, len(*in))
@@ -7445,11 +8784,51 @@ func (in *TiKVSpec) DeepCopyInto(out *TiKVSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.WalStorageClassName != nil {
+		in, out := &in.WalStorageClassName, &out.WalStorageClassName
+		*out = new(string)
+		**out = **in
+	}
 	if in.StoreLabels != nil {
 		in, out := &in.StoreLabels, &out.StoreLabels
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = new(TiKVPorts)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpgradeStabilizationGate != nil {
+		in, out := &in.UpgradeStabilizationGate, &out.UpgradeStabilizationGate
+		*out = new(MetricStabilizationGate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ScaleInStoreEmptyTimeout != nil {
+		in, out := &in.ScaleInStoreEmptyTimeout, &out.ScaleInStoreEmptyTimeout
+		*out = new(string)
+		**out = **in
+	}
+	if in.ScaleHooks != nil {
+		in, out := &in.ScaleHooks, &out.ScaleHooks
+		*out = new(ScaleHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpgradeHooks != nil {
+		in, out := &in.UpgradeHooks, &out.UpgradeHooks
+		*out = new(UpgradeHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ScalePolicy != nil {
+		in, out := &in.ScalePolicy, &out.ScalePolicy
+		*out = new(TiKVScalePolicy)
+		**out = **in
+	}
+	if in.UpgradePolicy != nil {
+		in, out := &in.UpgradePolicy, &out.UpgradePolicy
+		*out = new(TiKVUpgradePolicy)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -7499,6 +8878,23 @@ func (in *TiKVStatus) DeepCopyInto(out *TiKVStatus) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.EvictLeaderStores != nil {
+		in, out := &in.EvictLeaderStores, &out.EvictLeaderStores
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StoreMigrations != nil {
+		in, out := &in.StoreMigrations, &out.StoreMigrations
+		*out = make(map[string]TiKVStoreMigration, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(UpgradeProgress)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -7623,6 +9019,11 @@ func (in *TiKVStorageReadPoolConfig) DeepCopy() *TiKVStorageReadPoolConfig {
 func (in *TiKVStore) DeepCopyInto(out *TiKVStore) {
 	*out = *in
 	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	if in.DecommissionProgress != nil {
+		in, out := &in.DecommissionProgress, &out.DecommissionProgress
+		*out = new(StoreDecommissionProgress)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -7636,6 +9037,23 @@ func (in *TiKVStore) DeepCopy() *TiKVStore {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiKVStoreMigration) DeepCopyInto(out *TiKVStoreMigration) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiKVStoreMigration.
+func (in *TiKVStoreMigration) DeepCopy() *TiKVStoreMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(TiKVStoreMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TiKVTitanCfConfig) DeepCopyInto(out *TiKVTitanCfConfig) {
 	*out = *in
@@ -7774,12 +9192,33 @@ func (in *TiKVUnifiedReadPoolConfig) DeepCopyInto(out *TiKVUnifiedReadPoolConfig
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiKVUnifiedReadPoolConfig.
-func (in *TiKVUnifiedReadPoolConfig) DeepCopy() *TiKVUnifiedReadPoolConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiKVUnifiedReadPoolConfig.
+func (in *TiKVUnifiedReadPoolConfig) DeepCopy() *TiKVUnifiedReadPoolConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TiKVUnifiedReadPoolConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TiKVUpgradePolicy) DeepCopyInto(out *TiKVUpgradePolicy) {
+	*out = *in
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TiKVUpgradePolicy.
+func (in *TiKVUpgradePolicy) DeepCopy() *TiKVUpgradePolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(TiKVUnifiedReadPoolConfig)
+	out := new(TiKVUpgradePolicy)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -7998,6 +9437,94 @@ func (in *TidbClusterCondition) DeepCopy() *TidbClusterCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TidbClusterConfigProfile) DeepCopyInto(out *TidbClusterConfigProfile) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TidbClusterConfigProfile.
+func (in *TidbClusterConfigProfile) DeepCopy() *TidbClusterConfigProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(TidbClusterConfigProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TidbClusterConfigProfile) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TidbClusterConfigProfileList) DeepCopyInto(out *TidbClusterConfigProfileList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]TidbClusterConfigProfile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TidbClusterConfigProfileList.
+func (in *TidbClusterConfigProfileList) DeepCopy() *TidbClusterConfigProfileList {
+	if in == nil {
+		return nil
+	}
+	out := new(TidbClusterConfigProfileList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TidbClusterConfigProfileList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TidbClusterConfigProfileSpec) DeepCopyInto(out *TidbClusterConfigProfileSpec) {
+	*out = *in
+	if in.SystemVariables != nil {
+		in, out := &in.SystemVariables, &out.SystemVariables
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LockedSystemVariables != nil {
+		in, out := &in.LockedSystemVariables, &out.LockedSystemVariables
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TidbClusterConfigProfileSpec.
+func (in *TidbClusterConfigProfileSpec) DeepCopy() *TidbClusterConfigProfileSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TidbClusterConfigProfileSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TidbClusterList) DeepCopyInto(out *TidbClusterList) {
 	*out = *in
@@ -8179,6 +9706,66 @@ func (in *TidbClusterSpec) DeepCopyInto(out *TidbClusterSpec) {
 		*out = make([]TopologySpreadConstraint, len(*in))
 		copy(*out, *in)
 	}
+	if in.TiKVTiFlashAntiAffinity != nil {
+		in, out := &in.TiKVTiFlashAntiAffinity, &out.TiKVTiFlashAntiAffinity
+		*out = new(CrossComponentAntiAffinity)
+		**out = **in
+	}
+	if in.SnapshotBeforeDeletingPVC != nil {
+		in, out := &in.SnapshotBeforeDeletingPVC, &out.SnapshotBeforeDeletingPVC
+		*out = new(PVCSnapshotSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StalePVCPolicy != nil {
+		in, out := &in.StalePVCPolicy, &out.StalePVCPolicy
+		*out = new(StalePVCPolicy)
+		**out = **in
+	}
+	if in.UpgradeCrashLoopPolicy != nil {
+		in, out := &in.UpgradeCrashLoopPolicy, &out.UpgradeCrashLoopPolicy
+		*out = new(UpgradeCrashLoopPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodTemplateWebhook != nil {
+		in, out := &in.PodTemplateWebhook, &out.PodTemplateWebhook
+		*out = new(PodTemplateWebhook)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PVCResizeFailurePolicy != nil {
+		in, out := &in.PVCResizeFailurePolicy, &out.PVCResizeFailurePolicy
+		*out = new(PVCResizeFailurePolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PVCDeferDeletePeriod != nil {
+		in, out := &in.PVCDeferDeletePeriod, &out.PVCDeferDeletePeriod
+		*out = new(string)
+		**out = **in
+	}
+	if in.PodRestartPolicy != nil {
+		in, out := &in.PodRestartPolicy, &out.PodRestartPolicy
+		*out = new(PodRestartPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RolloutStallPolicy != nil {
+		in, out := &in.RolloutStallPolicy, &out.RolloutStallPolicy
+		*out = new(RolloutStallPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ScalePolicy != nil {
+		in, out := &in.ScalePolicy, &out.ScalePolicy
+		*out = new(ScalePolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaintenanceWindow != nil {
+		in, out := &in.MaintenanceWindow, &out.MaintenanceWindow
+		*out = new(MaintenanceWindow)
+		**out = **in
+	}
+	if in.UpgradePolicy != nil {
+		in, out := &in.UpgradePolicy, &out.UpgradePolicy
+		*out = new(UpgradePolicy)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -8213,6 +9800,73 @@ func (in *TidbClusterStatus) DeepCopyInto(out *TidbClusterStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Topology != nil {
+		in, out := &in.Topology, &out.Topology
+		*out = new(ClusterTopology)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastSyncFailureTime != nil {
+		in, out := &in.LastSyncFailureTime, &out.LastSyncFailureTime
+		*out = (*in).DeepCopy()
+	}
+	if in.VolumeSnapshots != nil {
+		in, out := &in.VolumeSnapshots, &out.VolumeSnapshots
+		*out = make(map[string]PVCSnapshot, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.AbortedUpgrades != nil {
+		in, out := &in.AbortedUpgrades, &out.AbortedUpgrades
+		*out = make(map[MemberType]AbortedUpgrade, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.FlappingPods != nil {
+		in, out := &in.FlappingPods, &out.FlappingPods
+		*out = make(map[MemberType][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.StalledPods != nil {
+		in, out := &in.StalledPods, &out.StalledPods
+		*out = make(map[string]StalledPod, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.RecentScales != nil {
+		in, out := &in.RecentScales, &out.RecentScales
+		*out = make([]ScaleRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ScaleDryRunPlans != nil {
+		in, out := &in.ScaleDryRunPlans, &out.ScaleDryRunPlans
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
 	return
 }
 
@@ -8712,6 +10366,185 @@ func (in *UnjoinedMember) DeepCopy() *UnjoinedMember {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeAutoRollback) DeepCopyInto(out *UpgradeAutoRollback) {
+	*out = *in
+	if in.Threshold != nil {
+		in, out := &in.Threshold, &out.Threshold
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeAutoRollback.
+func (in *UpgradeAutoRollback) DeepCopy() *UpgradeAutoRollback {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeAutoRollback)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeCrashLoopPolicy) DeepCopyInto(out *UpgradeCrashLoopPolicy) {
+	*out = *in
+	if in.Threshold != nil {
+		in, out := &in.Threshold, &out.Threshold
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeCrashLoopPolicy.
+func (in *UpgradeCrashLoopPolicy) DeepCopy() *UpgradeCrashLoopPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeCrashLoopPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeHook) DeepCopyInto(out *UpgradeHook) {
+	*out = *in
+	if in.Job != nil {
+		in, out := &in.Job, &out.Job
+		*out = new(JobScaleHook)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeHook.
+func (in *UpgradeHook) DeepCopy() *UpgradeHook {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeHooks) DeepCopyInto(out *UpgradeHooks) {
+	*out = *in
+	if in.PreUpgrade != nil {
+		in, out := &in.PreUpgrade, &out.PreUpgrade
+		*out = new(UpgradeHook)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PostUpgrade != nil {
+		in, out := &in.PostUpgrade, &out.PostUpgrade
+		*out = new(UpgradeHook)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeHooks.
+func (in *UpgradeHooks) DeepCopy() *UpgradeHooks {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeHooks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradePolicy) DeepCopyInto(out *UpgradePolicy) {
+	*out = *in
+	if in.PreChecks != nil {
+		in, out := &in.PreChecks, &out.PreChecks
+		*out = new(UpgradePreChecks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AutoRollback != nil {
+		in, out := &in.AutoRollback, &out.AutoRollback
+		*out = new(UpgradeAutoRollback)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradePolicy.
+func (in *UpgradePolicy) DeepCopy() *UpgradePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradePreChecks) DeepCopyInto(out *UpgradePreChecks) {
+	*out = *in
+	if in.MaxDownPeerRegions != nil {
+		in, out := &in.MaxDownPeerRegions, &out.MaxDownPeerRegions
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxPendingPeerRegions != nil {
+		in, out := &in.MaxPendingPeerRegions, &out.MaxPendingPeerRegions
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxUnhealthyStores != nil {
+		in, out := &in.MaxUnhealthyStores, &out.MaxUnhealthyStores
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradePreChecks.
+func (in *UpgradePreChecks) DeepCopy() *UpgradePreChecks {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradePreChecks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeProgress) DeepCopyInto(out *UpgradeProgress) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.EstimatedCompletionTime != nil {
+		in, out := &in.EstimatedCompletionTime, &out.EstimatedCompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make(map[string]UpgradePodPhase, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeProgress.
+func (in *UpgradeProgress) DeepCopy() *UpgradeProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *User) DeepCopyInto(out *User) {
 	*out = *in
@@ -8801,6 +10634,10 @@ func (in *WorkerFailureMember) DeepCopy() *WorkerFailureMember {
 func (in *WorkerMember) DeepCopyInto(out *WorkerMember) {
 	*out = *in
 	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	if in.StuckSince != nil {
+		in, out := &in.StuckSince, &out.StuckSince
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -155,6 +155,15 @@ func (c *GenericConfig) SetIfNil(key string, value interface{}) {
 	set(c.MP, key, value)
 }
 
+// Merge sets the values in o to c, the tables in both are merged recursively
+// and the other values in c are overwritten by the ones in o
+func (c *GenericConfig) Merge(o *GenericConfig) {
+	if o == nil {
+		return
+	}
+	merge(c.MP, deepcopy.Copy(o.MP).(map[string]interface{}))
+}
+
 func (c *GenericConfig) Get(key string) (value *Value) {
 	if c == nil {
 		return nil
@@ -319,6 +328,23 @@ func set(ms map[string]interface{}, key string, value interface{}) {
 	set(vMap, ks[1], value)
 }
 
+func merge(dst, src map[string]interface{}) {
+	for k, v := range src {
+		srcMap, ok := strKeyMap(v).(map[string]interface{})
+		if !ok {
+			dst[k] = v
+			continue
+		}
+		dstMap, ok := strKeyMap(dst[k]).(map[string]interface{})
+		if !ok {
+			dst[k] = srcMap
+			continue
+		}
+		merge(dstMap, srcMap)
+		dst[k] = dstMap
+	}
+}
+
 func get(ms map[string]interface{}, key string) (value interface{}) {
 	ks := strings.SplitN(key, ".", 2)
 	if len(ks) == 1 {
@@ -208,6 +208,42 @@ func TestSetIfNil(t *testing.T) {
 	g.Expect(c.Get("nil_key").MustInt()).Should(Equal(v))
 }
 
+func TestMerge(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := New(map[string]interface{}{
+		"a": 1,
+		"t": map[string]interface{}{
+			"b": "b",
+			"c": "c",
+		},
+	})
+	o := New(map[string]interface{}{
+		"d": 4,
+		"t": map[string]interface{}{
+			"c": "cc",
+			"n": map[string]interface{}{"e": true},
+		},
+	})
+	c.Merge(o)
+	g.Expect(c.Inner()).To(Equal(map[string]interface{}{
+		"a": 1,
+		"d": 4,
+		"t": map[string]interface{}{
+			"b": "b",
+			"c": "cc",
+			"n": map[string]interface{}{"e": true},
+		},
+	}))
+
+	// the merged values are not shared with o
+	c.Set("t.n.e", false)
+	g.Expect(o.Get("t.n.e").Interface()).To(Equal(true))
+
+	c.Merge(nil)
+	g.Expect(c.Get("t.c").MustString()).To(Equal("cc"))
+}
+
 func TestDel(t *testing.T) {
 	g := NewGomegaWithT(t)
 	kv := map[string]int64{
@@ -0,0 +1,237 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pingcap/tidb-operator/cmd/backup-manager/app/constants"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+	"k8s.io/kubernetes/pkg/util/slice"
+)
+
+// raiseGCLifeTime raises the tidb_gc_life_time of the cluster to cover the
+// backup before its job is created, if the operator can access the cluster
+// with SQL. The backups that overlap share the original value recorded by
+// the first of them. The backup is recorded as a holder before the value is
+// raised, so a backup released at the same time does not restore it.
+func (bm *backupManager) raiseGCLifeTime(backup *v1alpha1.Backup, tc *v1alpha1.TidbCluster) (string, error) {
+	ns := backup.GetNamespace()
+	name := backup.GetName()
+	if tc.Spec.TiDB == nil || tc.Spec.TiDB.SQLSecretName == "" {
+		return "", nil
+	}
+	bm.gcLifeTimeLock.Lock()
+	defer bm.gcLifeTimeLock.Unlock()
+	if status := backup.Status.GCLifeTime; status != nil {
+		if status.Released {
+			return "", nil
+		}
+		// the backup is recorded as a holder, but raising the value may have failed
+		raised, err := time.ParseDuration(status.Raised)
+		if err != nil {
+			return "ParseGCLifeTimeFailed", fmt.Errorf("backup %s/%s parse raised tidb_gc_life_time %s failed, err: %v", ns, name, status.Raised, err)
+		}
+		return bm.setGCLifeTime(backup, tc, raised)
+	}
+
+	value := constants.TikvGCLifeTime
+	if backup.Spec.TikvGCLifeTime != nil {
+		value = *backup.Spec.TikvGCLifeTime
+	}
+	lifeTime, err := time.ParseDuration(value)
+	if err != nil {
+		return "ParseTikvGCLifeTimeFailed", fmt.Errorf("backup %s/%s parse tikvGCLifeTime %s failed, err: %v", ns, name, value, err)
+	}
+
+	holders, err := bm.gcLifeTimeHolders(backup)
+	if err != nil {
+		return "ListBackupsFailed", err
+	}
+	current, err := bm.deps.TiDBControl.GetGCLifeTime(tc)
+	if err != nil {
+		return "GetGCLifeTimeFailed", fmt.Errorf("backup %s/%s get tidb_gc_life_time of cluster %s/%s failed, err: %v", ns, name, tc.GetNamespace(), tc.GetName(), err)
+	}
+
+	status := &v1alpha1.BackupGCLifeTimeStatus{Original: current.String(), Raised: current.String()}
+	if len(holders) > 0 {
+		// the current value is raised by the running backups
+		status.Original = holders[0].Status.GCLifeTime.Original
+	}
+	if lifeTime > current {
+		status.Raised = lifeTime.String()
+	}
+	if err := bm.updateGCLifeTimeStatus(backup, status); err != nil {
+		return "UpdateBackupStatusFailed", err
+	}
+	return bm.setGCLifeTime(backup, tc, lifeTime)
+}
+
+// setGCLifeTime raises the tidb_gc_life_time of the cluster to lifeTime if it is lower
+func (bm *backupManager) setGCLifeTime(backup *v1alpha1.Backup, tc *v1alpha1.TidbCluster, lifeTime time.Duration) (string, error) {
+	ns := backup.GetNamespace()
+	name := backup.GetName()
+	current, err := bm.deps.TiDBControl.GetGCLifeTime(tc)
+	if err != nil {
+		return "GetGCLifeTimeFailed", fmt.Errorf("backup %s/%s get tidb_gc_life_time of cluster %s/%s failed, err: %v", ns, name, tc.GetNamespace(), tc.GetName(), err)
+	}
+	if lifeTime <= current {
+		return "", nil
+	}
+	if err := bm.deps.TiDBControl.SetGCLifeTime(tc, lifeTime); err != nil {
+		return "SetGCLifeTimeFailed", fmt.Errorf("backup %s/%s set tidb_gc_life_time of cluster %s/%s to %s failed, err: %v", ns, name, tc.GetNamespace(), tc.GetName(), lifeTime, err)
+	}
+	klog.Infof("backup %s/%s raises tidb_gc_life_time of cluster %s/%s from %s to %s", ns, name, tc.GetNamespace(), tc.GetName(), current, lifeTime)
+	return "", nil
+}
+
+// updateGCLifeTimeStatus records the GC life time status of the backup. It
+// does not retry on conflict, as the status is computed from the other
+// backups at the version of the backup that was read, the backup is requeued
+// to compute it again instead.
+// The protection finalizer is kept on the backup holding the raised value,
+// so that it is released even if the backup is deleted.
+func (bm *backupManager) updateGCLifeTimeStatus(backup *v1alpha1.Backup, status *v1alpha1.BackupGCLifeTimeStatus) error {
+	ns := backup.GetNamespace()
+	name := backup.GetName()
+	newBackup := backup.DeepCopy()
+	newBackup.Status.GCLifeTime = status
+	if !status.Released && !slice.ContainsString(newBackup.Finalizers, label.BackupProtectionFinalizer, nil) {
+		newBackup.Finalizers = append(newBackup.Finalizers, label.BackupProtectionFinalizer)
+	}
+	if status.Released && !v1alpha1.IsCleanCandidate(newBackup) {
+		// the finalizer of the backup to clean is removed once it is cleaned
+		newBackup.Finalizers = slice.RemoveString(newBackup.Finalizers, label.BackupProtectionFinalizer, nil)
+	}
+	updated, err := bm.deps.Clientset.PingcapV1alpha1().Backups(ns).Update(context.TODO(), newBackup, metav1.UpdateOptions{})
+	if errors.IsConflict(err) {
+		return controller.RequeueErrorf("backup %s/%s is modified while updating the GC life time status, err: %v", ns, name, err)
+	}
+	if err != nil {
+		return fmt.Errorf("backup %s/%s update the GC life time status failed, err: %v", ns, name, err)
+	}
+	backup.ResourceVersion = updated.ResourceVersion
+	backup.Finalizers = updated.Finalizers
+	backup.Status.GCLifeTime = status
+	return nil
+}
+
+// releaseGCLifeTime releases the tidb_gc_life_time raised for the backup once
+// it is complete, failed or deleted. It returns true if the backup is released.
+// The last backup of the cluster that holds the raised value restores the
+// original value, unless the value has been lowered since it was raised.
+func (bm *backupManager) releaseGCLifeTime(backup *v1alpha1.Backup) (bool, error) {
+	if !v1alpha1.IsBackupHoldingGCLifeTime(backup) {
+		return false, nil
+	}
+	if backup.DeletionTimestamp == nil && !v1alpha1.IsBackupComplete(backup) && !v1alpha1.IsBackupFailed(backup) {
+		return false, nil
+	}
+	bm.gcLifeTimeLock.Lock()
+	defer bm.gcLifeTimeLock.Unlock()
+
+	holders, err := bm.gcLifeTimeHolders(backup)
+	if err != nil {
+		return false, err
+	}
+	if len(holders) == 0 {
+		if err := bm.restoreGCLifeTime(backup); err != nil {
+			return false, err
+		}
+	}
+
+	status := *backup.Status.GCLifeTime
+	status.Released = true
+	return true, bm.updateGCLifeTimeStatus(backup, &status)
+}
+
+// restoreGCLifeTime restores the tidb_gc_life_time of the cluster of the backup to the original value
+func (bm *backupManager) restoreGCLifeTime(backup *v1alpha1.Backup) error {
+	ns := backup.GetNamespace()
+	name := backup.GetName()
+	status := backup.Status.GCLifeTime
+
+	tcNamespace, tcName := backupCluster(backup)
+	tc, err := bm.deps.TiDBClusterLister.TidbClusters(tcNamespace).Get(tcName)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if tc.Spec.TiDB == nil || tc.Spec.TiDB.SQLSecretName == "" {
+		klog.Warningf("backup %s/%s can not restore tidb_gc_life_time of cluster %s/%s to %s without SQL access", ns, name, tcNamespace, tcName, status.Original)
+		return nil
+	}
+
+	original, err := time.ParseDuration(status.Original)
+	if err != nil {
+		return err
+	}
+	raised, err := time.ParseDuration(status.Raised)
+	if err != nil {
+		return err
+	}
+	current, err := bm.deps.TiDBControl.GetGCLifeTime(tc)
+	if err != nil {
+		return fmt.Errorf("backup %s/%s get tidb_gc_life_time of cluster %s/%s failed, err: %v", ns, name, tcNamespace, tcName, err)
+	}
+	if current < raised || current == original {
+		klog.Infof("backup %s/%s keeps tidb_gc_life_time %s of cluster %s/%s", ns, name, current, tcNamespace, tcName)
+		return nil
+	}
+	if err := bm.deps.TiDBControl.SetGCLifeTime(tc, original); err != nil {
+		return fmt.Errorf("backup %s/%s restore tidb_gc_life_time of cluster %s/%s to %s failed, err: %v", ns, name, tcNamespace, tcName, original, err)
+	}
+	klog.Infof("backup %s/%s restores tidb_gc_life_time of cluster %s/%s from %s to %s", ns, name, tcNamespace, tcName, current, original)
+	return nil
+}
+
+// gcLifeTimeHolders returns the other backups of the same cluster that hold
+// the raised tidb_gc_life_time. They are listed from the API server instead
+// of the lister, which may not have seen the holders recorded just now.
+func (bm *backupManager) gcLifeTimeHolders(backup *v1alpha1.Backup) ([]*v1alpha1.Backup, error) {
+	backups, err := bm.deps.Clientset.PingcapV1alpha1().Backups(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("list backups failed, err: %v", err)
+	}
+	tcNamespace, tcName := backupCluster(backup)
+	var holders []*v1alpha1.Backup
+	for i := range backups.Items {
+		b := &backups.Items[i]
+		if (b.Namespace == backup.Namespace && b.Name == backup.Name) || b.Spec.BR == nil || !v1alpha1.IsBackupHoldingGCLifeTime(b) {
+			continue
+		}
+		if ns, name := backupCluster(b); ns == tcNamespace && name == tcName {
+			holders = append(holders, b)
+		}
+	}
+	return holders, nil
+}
+
+// backupCluster returns the namespace and name of the cluster of the BR backup
+func backupCluster(backup *v1alpha1.Backup) (string, string) {
+	ns := backup.GetNamespace()
+	if backup.Spec.BR.ClusterNamespace != "" {
+		ns = backup.Spec.BR.ClusterNamespace
+	}
+	return ns, backup.Spec.BR.Cluster
+}
@@ -16,6 +16,7 @@ package backup
 import (
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
@@ -36,6 +37,9 @@ type backupManager struct {
 	deps          *controller.Dependencies
 	backupCleaner BackupCleaner
 	statusUpdater controller.BackupConditionUpdaterInterface
+	// gcLifeTimeLock serializes raising and releasing the tidb_gc_life_time,
+	// so that the backups holding it don't change while they are listed
+	gcLifeTimeLock sync.Mutex
 }
 
 // NewBackupManager return backupManager
@@ -49,6 +53,10 @@ func NewBackupManager(deps *controller.Dependencies) backup.BackupManager {
 }
 
 func (bm *backupManager) Sync(backup *v1alpha1.Backup) error {
+	if released, err := bm.releaseGCLifeTime(backup); err != nil || released {
+		return err
+	}
+
 	// because a finalizer is installed on the backup on creation, when backup is deleted,
 	// backup.DeletionTimestamp will be set, controller will be informed with an onUpdate event,
 	// this is the moment that we can do clean up work.
@@ -74,6 +82,7 @@ func (bm *backupManager) syncBackupJob(backup *v1alpha1.Backup) error {
 	backupJobName := backup.GetBackupJobName()
 
 	var err error
+	var tc *v1alpha1.TidbCluster
 	if backup.Spec.BR == nil {
 		err = backuputil.ValidateBackup(backup, "")
 	} else {
@@ -82,7 +91,6 @@ func (bm *backupManager) syncBackupJob(backup *v1alpha1.Backup) error {
 			backupNamespace = backup.Spec.BR.ClusterNamespace
 		}
 
-		var tc *v1alpha1.TidbCluster
 		tc, err = bm.deps.TiDBClusterLister.TidbClusters(backupNamespace).Get(backup.Spec.BR.Cluster)
 		if err != nil {
 			reason := fmt.Sprintf("failed to fetch tidbcluster %s/%s", backupNamespace, backup.Spec.BR.Cluster)
@@ -147,6 +155,20 @@ func (bm *backupManager) syncBackupJob(backup *v1alpha1.Backup) error {
 		}
 
 	} else {
+		reason, err = bm.raiseGCLifeTime(backup, tc)
+		if controller.IsRequeueError(err) {
+			return err
+		}
+		if err != nil {
+			bm.statusUpdater.Update(backup, &v1alpha1.BackupCondition{
+				Type:    v1alpha1.BackupRetryFailed,
+				Status:  corev1.ConditionTrue,
+				Reason:  reason,
+				Message: err.Error(),
+			}, nil)
+			return err
+		}
+
 		// not found backup job, so we need to create it
 		job, reason, err = bm.makeBackupJob(backup)
 		if err != nil {
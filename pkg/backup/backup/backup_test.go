@@ -22,13 +22,17 @@ import (
 
 	"github.com/onsi/gomega"
 	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/backup/testutils"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned/fake"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	core "k8s.io/client-go/testing"
 	"k8s.io/utils/pointer"
 )
 
@@ -366,3 +370,99 @@ func TestClean(t *testing.T) {
 	}
 
 }
+
+func TestBackupManagerGCLifeTime(t *testing.T) {
+	g := NewGomegaWithT(t)
+	helper := newHelper(t)
+	defer helper.Close()
+	deps := helper.Deps
+	bm := NewBackupManager(deps).(*backupManager)
+	tidbControl := deps.TiDBControl.(*controller.FakeTiDBControl)
+	tidbControl.GCLifeTime = 10 * time.Minute
+
+	backups := genValidBRBackups()[:2]
+	for i, backup := range backups {
+		backup.Spec.BR.Cluster = "tidb"
+		backup.Spec.TikvGCLifeTime = pointer.StringPtr(fmt.Sprintf("%dh", i+1))
+		_, err := deps.Clientset.PingcapV1alpha1().Backups(backup.Namespace).Create(context.TODO(), backup, metav1.CreateOptions{})
+		g.Expect(err).Should(BeNil())
+		helper.CreateSecret(backup)
+	}
+	helper.CreateTC("ns", "tidb")
+	tc, err := deps.Clientset.PingcapV1alpha1().TidbClusters("ns").Get(context.TODO(), "tidb", metav1.GetOptions{})
+	g.Expect(err).Should(BeNil())
+	tc.Spec.TiDB.SQLSecretName = "tidb-sql"
+	_, err = deps.Clientset.PingcapV1alpha1().TidbClusters("ns").Update(context.TODO(), tc, metav1.UpdateOptions{})
+	g.Expect(err).Should(BeNil())
+	g.Eventually(func() string {
+		tc, _ := deps.TiDBClusterLister.TidbClusters("ns").Get("tidb")
+		return tc.Spec.TiDB.SQLSecretName
+	}, time.Second*10).Should(Equal("tidb-sql"))
+
+	// getBackup returns the backup once the lister is synced with the given GC life time status
+	getBackup := func(name string, status *v1alpha1.BackupGCLifeTimeStatus) *v1alpha1.Backup {
+		var backup *v1alpha1.Backup
+		g.Eventually(func() *v1alpha1.BackupGCLifeTimeStatus {
+			backup, err = deps.BackupLister.Backups("ns").Get(name)
+			g.Expect(err).Should(BeNil())
+			return backup.Status.GCLifeTime
+		}, time.Second*10).Should(Equal(status))
+		return backup.DeepCopy()
+	}
+	complete := func(backup *v1alpha1.Backup) {
+		v1alpha1.UpdateBackupCondition(&backup.Status, &v1alpha1.BackupCondition{Type: v1alpha1.BackupComplete, Status: corev1.ConditionTrue})
+	}
+
+	// the backup modified concurrently is requeued without raising the GC life time
+	conflicted := false
+	deps.Clientset.(*fake.Clientset).PrependReactor("update", "backups", func(action core.Action) (bool, runtime.Object, error) {
+		if conflicted {
+			return false, nil, nil
+		}
+		conflicted = true
+		return true, nil, errors.NewConflict(v1alpha1.Resource("backups"), backups[0].Name, fmt.Errorf("conflict"))
+	})
+	err = bm.syncBackupJob(backups[0])
+	g.Expect(controller.IsRequeueError(err)).Should(BeTrue())
+	g.Expect(tidbControl.GCLifeTime).Should(Equal(10 * time.Minute))
+
+	// the first backup raises the GC life time
+	g.Expect(bm.syncBackupJob(backups[0])).Should(Succeed())
+	g.Expect(tidbControl.GCLifeTime).Should(Equal(time.Hour))
+	backup0 := getBackup(backups[0].Name, &v1alpha1.BackupGCLifeTimeStatus{Original: "10m0s", Raised: "1h0m0s"})
+	g.Expect(backup0.Finalizers).Should(ContainElement(label.BackupProtectionFinalizer))
+
+	// the overlapping backup raises it further and keeps the original value,
+	// the holder is listed from the API server without waiting for the lister
+	g.Expect(bm.syncBackupJob(backups[1])).Should(Succeed())
+	g.Expect(tidbControl.GCLifeTime).Should(Equal(2 * time.Hour))
+	backup1 := getBackup(backups[1].Name, &v1alpha1.BackupGCLifeTimeStatus{Original: "10m0s", Raised: "2h0m0s"})
+
+	// nothing is released while the backup is running
+	released, err := bm.releaseGCLifeTime(backup0)
+	g.Expect(err).Should(BeNil())
+	g.Expect(released).Should(BeFalse())
+
+	// the GC life time is kept while the other backup is running
+	complete(backup0)
+	released, err = bm.releaseGCLifeTime(backup0)
+	g.Expect(err).Should(BeNil())
+	g.Expect(released).Should(BeTrue())
+	g.Expect(tidbControl.GCLifeTime).Should(Equal(2 * time.Hour))
+	getBackup(backups[0].Name, &v1alpha1.BackupGCLifeTimeStatus{Original: "10m0s", Raised: "1h0m0s", Released: true})
+
+	// the last backup restores the original value once it is deleted
+	backup1.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	released, err = bm.releaseGCLifeTime(backup1)
+	g.Expect(err).Should(BeNil())
+	g.Expect(released).Should(BeTrue())
+	g.Expect(tidbControl.GCLifeTime).Should(Equal(10 * time.Minute))
+	backup1 = getBackup(backups[1].Name, &v1alpha1.BackupGCLifeTimeStatus{Original: "10m0s", Raised: "2h0m0s", Released: true})
+	// the backup kept on deletion only has the finalizer for the GC life time
+	g.Expect(backup1.Finalizers).ShouldNot(ContainElement(label.BackupProtectionFinalizer))
+
+	// a released backup is not released again
+	released, err = bm.releaseGCLifeTime(backup1)
+	g.Expect(err).Should(BeNil())
+	g.Expect(released).Should(BeFalse())
+}
@@ -84,8 +84,17 @@ func (c *Client) getURL(addr string) string {
 	return fmt.Sprintf("%s://%s", scheme, addr)
 }
 
-func (c *Client) getOfflineURL(addr string, nodeID string) string {
-	return fmt.Sprintf("%s/state/%s/close", c.getURL(addr), nodeID)
+func (c *Client) getStateURL(addr string, nodeID string, action string) string {
+	return fmt.Sprintf("%s/state/%s/%s", c.getURL(addr), nodeID, action)
+}
+
+// commitStatus is the node status with the max commit ts, it is not part of
+// PumpNodeStatus as it is updated continuously.
+type commitStatus struct {
+	NodeID      string `json:"nodeId"`
+	Host        string `json:"host"`
+	State       string `json:"state"`
+	MaxCommitTS int64  `json:"maxCommitTS"`
 }
 
 // StatusResp represents the response of status api.
@@ -233,8 +242,60 @@ func (c *Client) nodeStatus(ctx context.Context, ty string) (status []*v1alpha1.
 	return
 }
 
-func (c *Client) offline(addr string, nodeID string) error {
-	url := c.getOfflineURL(c.hookAddr(addr), nodeID)
+// IsPumpConsumed check if the binlogs of the pump are consumed by all the
+// online drainers.
+func (c *Client) IsPumpConsumed(ctx context.Context, addr string) (bool, error) {
+	pumps, err := c.commitStatus(ctx, "pumps")
+	if err != nil {
+		return false, err
+	}
+	var pump *commitStatus
+	for _, s := range pumps {
+		if s.Host == addr {
+			pump = s
+			break
+		}
+	}
+	if pump == nil {
+		return false, errors.Errorf("pumps node for address %s not found", addr)
+	}
+
+	drainers, err := c.commitStatus(ctx, "drainers")
+	if err != nil {
+		return false, err
+	}
+	for _, s := range drainers {
+		if s.State == "online" && s.MaxCommitTS < pump.MaxCommitTS {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (c *Client) commitStatus(ctx context.Context, ty string) (status []*commitStatus, err error) {
+	key := fmt.Sprintf("/tidb-binlog/v1/%s", ty)
+
+	resp, err := c.etcdClient.KV.Get(ctx, key, clientv3.WithPrefix())
+	if err != nil {
+		return nil, errors.AddStack(err)
+	}
+
+	for _, kv := range resp.Kvs {
+		var s commitStatus
+		err = json.Unmarshal(kv.Value, &s)
+		if err != nil {
+			return nil, errors.Annotatef(err, "key: %s, data: %s", string(kv.Key), string(kv.Value))
+		}
+
+		status = append(status, &s)
+	}
+
+	return
+}
+
+// changeState sends the action of binlogctl, such as close or pause, to the node.
+func (c *Client) changeState(addr string, nodeID string, action string) error {
+	url := c.getStateURL(c.hookAddr(addr), nodeID, action)
 	req, err := http.NewRequest("PUT", url, nil)
 	if err != nil {
 		return errors.AddStack(err)
@@ -275,7 +336,17 @@ func (c *Client) OfflinePump(ctx context.Context, addr string) error {
 	if err != nil {
 		return err
 	}
-	return c.offline(addr, nodeID)
+	return c.changeState(addr, nodeID, "close")
+}
+
+// PausePump pause a pump, the pump stops writing binlogs and the drainers
+// keep consuming the binlogs written before.
+func (c *Client) PausePump(ctx context.Context, addr string) error {
+	nodeID, err := c.nodeID(ctx, addr, "pumps")
+	if err != nil {
+		return err
+	}
+	return c.changeState(addr, nodeID, "pause")
 }
 
 // OfflineDrainer offline a drainer.
@@ -284,5 +355,5 @@ func (c *Client) OfflineDrainer(ctx context.Context, addr string) error {
 	if err != nil {
 		return err
 	}
-	return c.offline(addr, nodeID)
+	return c.changeState(addr, nodeID, "close")
 }
@@ -53,6 +53,10 @@ func (c *FakePingcapV1alpha1) TidbClusterAutoScalers(namespace string) v1alpha1.
 	return &FakeTidbClusterAutoScalers{c, namespace}
 }
 
+func (c *FakePingcapV1alpha1) TidbClusterConfigProfiles(namespace string) v1alpha1.TidbClusterConfigProfileInterface {
+	return &FakeTidbClusterConfigProfiles{c, namespace}
+}
+
 func (c *FakePingcapV1alpha1) TidbInitializers(namespace string) v1alpha1.TidbInitializerInterface {
 	return &FakeTidbInitializers{c, namespace}
 }
@@ -30,6 +30,7 @@ type PingcapV1alpha1Interface interface {
 	RestoresGetter
 	TidbClustersGetter
 	TidbClusterAutoScalersGetter
+	TidbClusterConfigProfilesGetter
 	TidbInitializersGetter
 	TidbMonitorsGetter
 }
@@ -67,6 +68,10 @@ func (c *PingcapV1alpha1Client) TidbClusterAutoScalers(namespace string) TidbClu
 	return newTidbClusterAutoScalers(c, namespace)
 }
 
+func (c *PingcapV1alpha1Client) TidbClusterConfigProfiles(namespace string) TidbClusterConfigProfileInterface {
+	return newTidbClusterConfigProfiles(c, namespace)
+}
+
 func (c *PingcapV1alpha1Client) TidbInitializers(namespace string) TidbInitializerInterface {
 	return newTidbInitializers(c, namespace)
 }
@@ -35,6 +35,8 @@ type Interface interface {
 	TidbClusters() TidbClusterInformer
 	// TidbClusterAutoScalers returns a TidbClusterAutoScalerInformer.
 	TidbClusterAutoScalers() TidbClusterAutoScalerInformer
+	// TidbClusterConfigProfiles returns a TidbClusterConfigProfileInformer.
+	TidbClusterConfigProfiles() TidbClusterConfigProfileInformer
 	// TidbInitializers returns a TidbInitializerInformer.
 	TidbInitializers() TidbInitializerInformer
 	// TidbMonitors returns a TidbMonitorInformer.
@@ -87,6 +89,11 @@ func (v *version) TidbClusterAutoScalers() TidbClusterAutoScalerInformer {
 	return &tidbClusterAutoScalerInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// TidbClusterConfigProfiles returns a TidbClusterConfigProfileInformer.
+func (v *version) TidbClusterConfigProfiles() TidbClusterConfigProfileInformer {
+	return &tidbClusterConfigProfileInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // TidbInitializers returns a TidbInitializerInformer.
 func (v *version) TidbInitializers() TidbInitializerInformer {
 	return &tidbInitializerInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
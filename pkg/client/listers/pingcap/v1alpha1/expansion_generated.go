@@ -71,6 +71,14 @@ type TidbClusterAutoScalerListerExpansion interface{}
 // TidbClusterAutoScalerNamespaceLister.
 type TidbClusterAutoScalerNamespaceListerExpansion interface{}
 
+// TidbClusterConfigProfileListerExpansion allows custom methods to be added to
+// TidbClusterConfigProfileLister.
+type TidbClusterConfigProfileListerExpansion interface{}
+
+// TidbClusterConfigProfileNamespaceListerExpansion allows custom methods to be added to
+// TidbClusterConfigProfileNamespaceLister.
+type TidbClusterConfigProfileNamespaceListerExpansion interface{}
+
 // TidbInitializerListerExpansion allows custom methods to be added to
 // TidbInitializerLister.
 type TidbInitializerListerExpansion interface{}
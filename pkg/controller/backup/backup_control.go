@@ -112,7 +112,9 @@ func needToAddFinalizer(backup *v1alpha1.Backup) bool {
 }
 
 func needToRemoveFinalizer(backup *v1alpha1.Backup) bool {
-	return v1alpha1.IsCleanCandidate(backup) && isDeletionCandidate(backup) &&
+	// the backup holding the raised tidb_gc_life_time removes the finalizer
+	// itself once it is released
+	return v1alpha1.IsCleanCandidate(backup) && isDeletionCandidate(backup) && !v1alpha1.IsBackupHoldingGCLifeTime(backup) &&
 		(v1alpha1.IsBackupClean(backup) || v1alpha1.NeedNotClean(backup))
 }
 
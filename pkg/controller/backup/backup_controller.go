@@ -154,6 +154,13 @@ func (c *Controller) updateBackup(cur interface{}) {
 		return
 	}
 
+	if v1alpha1.IsBackupHoldingGCLifeTime(newBackup) && (v1alpha1.IsBackupComplete(newBackup) || v1alpha1.IsBackupFailed(newBackup)) {
+		// the backup is done, release the tidb_gc_life_time raised for it
+		klog.V(4).Infof("backup %s/%s is done and holds the raised tidb_gc_life_time, enqueue", ns, name)
+		c.enqueueBackup(newBackup)
+		return
+	}
+
 	if v1alpha1.IsBackupInvalid(newBackup) {
 		klog.V(4).Infof("backup %s/%s is invalid, skipping.", ns, name)
 		return
@@ -17,8 +17,10 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"time"
 
 	"github.com/dustin/go-humanize"
+	perrors "github.com/pingcap/errors"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/scheme"
 	"github.com/pingcap/tidb-operator/pkg/util"
@@ -28,6 +30,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/retry"
@@ -62,6 +65,9 @@ var (
 // RequeueError is used to requeue the item, this error type should't be considered as a real error
 type RequeueError struct {
 	s string
+	// after is how long to wait before the item is requeued, 0 means the
+	// rate limiter of the queue decides
+	after time.Duration
 }
 
 func (re *RequeueError) Error() string {
@@ -70,7 +76,14 @@ func (re *RequeueError) Error() string {
 
 // RequeueErrorf returns a RequeueError
 func RequeueErrorf(format string, a ...interface{}) error {
-	return &RequeueError{fmt.Sprintf(format, a...)}
+	return &RequeueError{s: fmt.Sprintf(format, a...)}
+}
+
+// RequeueAfterErrorf returns a RequeueError requeuing the item after the
+// duration instead of the backoff of the queue, a duration of 0 is the same
+// as RequeueErrorf
+func RequeueAfterErrorf(after time.Duration, format string, a ...interface{}) error {
+	return &RequeueError{s: fmt.Sprintf(format, a...), after: after}
 }
 
 // IsRequeueError returns whether err is a RequeueError
@@ -79,6 +92,27 @@ func IsRequeueError(err error) bool {
 	return ok
 }
 
+// RequeueAfter returns how long to wait before requeuing the item for the
+// RequeueErrors found in err, 0 if the backoff of the queue decides, which is
+// the case when err is or aggregates any error that is not a RequeueError
+func RequeueAfter(err error) time.Duration {
+	var after time.Duration
+	errs := []error{err}
+	if agg, ok := err.(utilerrors.Aggregate); ok {
+		errs = utilerrors.Flatten(agg).Errors()
+	}
+	for _, e := range errs {
+		re, ok := perrors.Find(e, IsRequeueError).(*RequeueError)
+		if !ok || re.after <= 0 {
+			return 0
+		}
+		if after == 0 || re.after < after {
+			after = re.after
+		}
+	}
+	return after
+}
+
 // IgnoreError is used to ignore this item, this error type should't be considered as a real error, no need to requeue
 type IgnoreError struct {
 	s string
@@ -288,6 +322,16 @@ func TiDBPeerMemberName(clusterName string) string {
 	return fmt.Sprintf("%s-tidb-peer", clusterName)
 }
 
+// TiDBGreenMemberName returns the name of the green tidb statefulset of a blue/green switch
+func TiDBGreenMemberName(clusterName string) string {
+	return fmt.Sprintf("%s-tidb-green", clusterName)
+}
+
+// TiDBGreenPeerMemberName returns the headless service name of the green tidb statefulset
+func TiDBGreenPeerMemberName(clusterName string) string {
+	return fmt.Sprintf("%s-tidb-green-peer", clusterName)
+}
+
 // PumpMemberName returns pump member name
 func PumpMemberName(clusterName string) string {
 	return fmt.Sprintf("%s-pump", clusterName)
@@ -386,6 +430,28 @@ func ContainerResource(req corev1.ResourceRequirements) corev1.ResourceRequireme
 	return *trimmed
 }
 
+// ContainerResourceWithEphemeralStorage returns ContainerResource of the
+// ResourceRequirements with the ephemeral storage request and limit overridden by es
+func ContainerResourceWithEphemeralStorage(req corev1.ResourceRequirements, es *v1alpha1.EphemeralStorageSpec) corev1.ResourceRequirements {
+	res := ContainerResource(req)
+	if es == nil {
+		return res
+	}
+	if es.Request != nil {
+		if res.Requests == nil {
+			res.Requests = corev1.ResourceList{}
+		}
+		res.Requests[corev1.ResourceEphemeralStorage] = es.Request.DeepCopy()
+	}
+	if es.Limit != nil {
+		if res.Limits == nil {
+			res.Limits = corev1.ResourceList{}
+		}
+		res.Limits[corev1.ResourceEphemeralStorage] = es.Limit.DeepCopy()
+	}
+	return res
+}
+
 // MemberConfigMapName returns the default ConfigMap name of the specified member type
 // Deprecated
 // TODO: remove after helm get totally abandoned
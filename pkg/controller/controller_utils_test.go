@@ -16,7 +16,9 @@ package controller
 import (
 	"fmt"
 	"testing"
+	"time"
 
+	perrors "github.com/pingcap/errors"
 	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -28,6 +30,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 )
 
 func TestRequeueError(t *testing.T) {
@@ -37,6 +40,16 @@ func TestRequeueError(t *testing.T) {
 	g.Expect(IsRequeueError(err)).To(BeTrue())
 	g.Expect(err.Error()).To(Equal("i am a requeue error"))
 	g.Expect(IsRequeueError(fmt.Errorf("i am not a requeue error"))).To(BeFalse())
+	g.Expect(RequeueAfter(err)).To(BeZero())
+
+	err = RequeueAfterErrorf(time.Minute, "i am a requeue %s", "error")
+	g.Expect(IsRequeueError(err)).To(BeTrue())
+	g.Expect(RequeueAfter(err)).To(Equal(time.Minute))
+	g.Expect(RequeueAfter(perrors.Annotate(err, "annotated"))).To(Equal(time.Minute))
+	g.Expect(RequeueAfter(fmt.Errorf("i am not a requeue error"))).To(BeZero())
+	g.Expect(RequeueAfter(utilerrors.NewAggregate([]error{err, RequeueAfterErrorf(time.Second, "sooner")}))).To(Equal(time.Second))
+	g.Expect(RequeueAfter(utilerrors.NewAggregate([]error{err, RequeueErrorf("backoff")}))).To(BeZero())
+	g.Expect(RequeueAfter(utilerrors.NewAggregate([]error{err, fmt.Errorf("i am not a requeue error")}))).To(BeZero())
 }
 
 func TestIgnoreError(t *testing.T) {
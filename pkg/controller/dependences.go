@@ -70,6 +70,11 @@ type CLIConfig struct {
 	RenewDeadline         time.Duration
 	RetryPeriod           time.Duration
 	WaitDuration          time.Duration
+	// TiKVNodeNotReadyFailoverPeriod is how long the node of a TiKV store
+	// which lost its heartbeats must be NotReady, or the store must lose its
+	// heartbeats if its node is deleted, before the store is failed over
+	// without waiting for it to be Down, 0 disables it
+	TiKVNodeNotReadyFailoverPeriod time.Duration
 	// ResyncDuration is the resync time of informer
 	ResyncDuration time.Duration
 	// Defines whether tidb operator run in test mode, test mode is
@@ -83,6 +88,35 @@ type CLIConfig struct {
 	// Selector is used to filter CR labels to decide
 	// what resources should be watched and synced by controller
 	Selector string
+	// SyncFailureBaseDelay and SyncFailureMaxDelay bound the delay before
+	// syncing a cluster that failed to sync in a row
+	SyncFailureBaseDelay time.Duration
+	SyncFailureMaxDelay  time.Duration
+	// TiDBClientPool limits the connections to each TiDB instance
+	TiDBClientPool TiDBClientPoolConfig
+	// ReadinessEndpointEnabled is the key to indicate whether the operator
+	// serves the readiness of TidbClusters over HTTP
+	ReadinessEndpointEnabled bool
+	// PDReadLoadBalancing is the key to indicate whether the read requests
+	// to PD are load-balanced across the PD followers
+	PDReadLoadBalancing bool
+	// TracingOTLPEndpoint is the OTLP/HTTP endpoint of the OpenTelemetry
+	// collector the traces of the reconciles are exported to, empty disables
+	// tracing
+	TracingOTLPEndpoint string
+	// TracingExportInterval is how often the spans are exported
+	TracingExportInterval time.Duration
+	// PDDeletionLimit is the max number of stores and members deleted from
+	// the PD of a cluster in PDDeletionWindow, 0 means no limit
+	PDDeletionLimit  int
+	PDDeletionWindow time.Duration
+	// FailoverNotificationWebhook is the URL the failover notifications are
+	// POSTed to as JSON, empty means recording them as Events
+	FailoverNotificationWebhook string
+	// TiDBSchedulerName is the name of the scheduler extender deployed with
+	// the operator that implements the HA scheduling, the Pods scheduled by
+	// other schedulers never release the scheduling locks
+	TiDBSchedulerName string
 }
 
 // DefaultCLIConfig returns the default command line configuration
@@ -105,6 +139,17 @@ func DefaultCLIConfig() *CLIConfig {
 		TiDBBackupManagerImage: "pingcap/tidb-backup-manager:latest",
 		TiDBDiscoveryImage:     "pingcap/tidb-operator:latest",
 		Selector:               "",
+		SyncFailureBaseDelay:   5 * time.Second,
+		SyncFailureMaxDelay:    5 * time.Minute,
+		TracingExportInterval:  5 * time.Second,
+		PDDeletionWindow:       time.Minute,
+		TiDBSchedulerName:      v1alpha1.TiDBSchedulerName,
+		TiDBClientPool: TiDBClientPoolConfig{
+			MaxOpenConns:    5,
+			MaxIdleConns:    2,
+			IdleConnTimeout: 90 * time.Second,
+			MaxConnLifetime: 5 * time.Minute,
+		},
 	}
 }
 
@@ -120,6 +165,7 @@ func (c *CLIConfig) AddFlag(_ *flag.FlagSet) {
 	flag.BoolVar(&c.AutoFailover, "auto-failover", c.AutoFailover, "Auto failover")
 	flag.DurationVar(&c.PDFailoverPeriod, "pd-failover-period", c.PDFailoverPeriod, "PD failover period default(5m)")
 	flag.DurationVar(&c.TiKVFailoverPeriod, "tikv-failover-period", c.TiKVFailoverPeriod, "TiKV failover period default(5m)")
+	flag.DurationVar(&c.TiKVNodeNotReadyFailoverPeriod, "tikv-node-not-ready-failover-period", c.TiKVNodeNotReadyFailoverPeriod, "How long the node of a TiKV store which lost its heartbeats must be NotReady, or the store must lose its heartbeats if its node is deleted, before the store is failed over without waiting for it to be Down, 0 disables it and it is at least 1m")
 	flag.DurationVar(&c.TiFlashFailoverPeriod, "tiflash-failover-period", c.TiFlashFailoverPeriod, "TiFlash failover period default(5m)")
 	flag.DurationVar(&c.TiDBFailoverPeriod, "tidb-failover-period", c.TiDBFailoverPeriod, "TiDB failover period")
 	flag.DurationVar(&c.MasterFailoverPeriod, "dm-master-failover-period", c.MasterFailoverPeriod, "dm-master failover period")
@@ -131,6 +177,20 @@ func (c *CLIConfig) AddFlag(_ *flag.FlagSet) {
 	flag.StringVar(&c.TiDBDiscoveryImage, "tidb-discovery-image", c.TiDBDiscoveryImage, "The image of the tidb discovery service")
 	flag.BoolVar(&c.PodWebhookEnabled, "pod-webhook-enabled", false, "Whether Pod admission webhook is enabled")
 	flag.StringVar(&c.Selector, "selector", c.Selector, "Selector (label query) to filter on, supports '=', '==', and '!='")
+	flag.DurationVar(&c.SyncFailureBaseDelay, "sync-failure-base-delay", c.SyncFailureBaseDelay, "The delay before syncing a cluster again after it fails to sync, it doubles with each consecutive failure")
+	flag.DurationVar(&c.SyncFailureMaxDelay, "sync-failure-max-delay", c.SyncFailureMaxDelay, "The max delay before syncing a cluster again after it fails to sync in a row")
+	flag.IntVar(&c.TiDBClientPool.MaxOpenConns, "tidb-client-max-open-conns", c.TiDBClientPool.MaxOpenConns, "The max number of connections to each TiDB instance, 0 means no limit")
+	flag.IntVar(&c.TiDBClientPool.MaxIdleConns, "tidb-client-max-idle-conns", c.TiDBClientPool.MaxIdleConns, "The max number of idle connections kept to each TiDB instance")
+	flag.DurationVar(&c.TiDBClientPool.IdleConnTimeout, "tidb-client-idle-conn-timeout", c.TiDBClientPool.IdleConnTimeout, "How long an idle HTTP connection to a TiDB instance is kept before it is closed, it is not a lifetime of the connections")
+	flag.DurationVar(&c.TiDBClientPool.MaxConnLifetime, "tidb-client-max-conn-lifetime", c.TiDBClientPool.MaxConnLifetime, "The max time a SQL connection to a TiDB instance is reused before it is closed, 0 means no limit")
+	flag.BoolVar(&c.PDReadLoadBalancing, "pd-read-load-balancing", c.PDReadLoadBalancing, "Whether to load-balance the read requests to PD that only report the state of the cluster across the healthy PD followers, the other requests are always served by the PD leader")
+	flag.StringVar(&c.TracingOTLPEndpoint, "tracing-otlp-endpoint", c.TracingOTLPEndpoint, "The OTLP/HTTP endpoint of the OpenTelemetry collector the traces of the reconciles are exported to, e.g. http://otel-collector:4318, empty disables tracing")
+	flag.DurationVar(&c.TracingExportInterval, "tracing-export-interval", c.TracingExportInterval, "How often the spans of the reconciles are exported to the OpenTelemetry collector")
+	flag.IntVar(&c.PDDeletionLimit, "pd-deletion-limit", c.PDDeletionLimit, "The max number of stores and members deleted from the PD of a cluster in the pd-deletion-window, the other deletions are deferred, 0 means no limit")
+	flag.DurationVar(&c.PDDeletionWindow, "pd-deletion-window", c.PDDeletionWindow, "The time window the pd-deletion-limit applies to")
+	flag.StringVar(&c.FailoverNotificationWebhook, "failover-notification-webhook", c.FailoverNotificationWebhook, "The URL the notifications of the failovers, i.e. a member is marked as failed, replaced or recovered, are POSTed to as JSON, empty means recording them as Events with the reason FailoverNotification")
+	flag.StringVar(&c.TiDBSchedulerName, "tidb-scheduler-name", c.TiDBSchedulerName, "The name of the scheduler deployed with tidb-operator that implements the HA scheduling, the HA scheduling locks of the Pods scheduled by other schedulers are released by the operator")
+	flag.BoolVar(&c.ReadinessEndpointEnabled, "readiness-endpoint-enabled", c.ReadinessEndpointEnabled, "Whether to serve the readiness of TidbClusters and their components derived from the status at /readiness/{namespace}/{name}[/{component}]")
 
 	// see https://pkg.go.dev/k8s.io/client-go/tools/leaderelection#LeaderElectionConfig for the config
 	flag.DurationVar(&c.LeaseDuration, "leader-lease-duration", c.LeaseDuration, "leader-lease-duration is the duration that non-leader candidates will wait to force acquire leadership")
@@ -173,6 +233,11 @@ type Controls struct {
 	CDCControl         TiCDCControlInterface
 	TiDBControl        TiDBControlInterface
 	BackupControl      BackupControlInterface
+	PrometheusControl  PrometheusControlInterface
+	PodTemplateControl PodTemplateControlInterface
+	ScaleHookControl   ScaleHookControlInterface
+	PDDeletionLimiter  PDDeletionLimiter
+	FailoverNotifier   FailoverNotifier
 }
 
 // Dependencies is used to store all shared dependent resources to avoid
@@ -191,27 +256,29 @@ type Dependencies struct {
 	Recorder                       record.EventRecorder
 
 	// Listers
-	ServiceLister               corelisterv1.ServiceLister
-	EndpointLister              corelisterv1.EndpointsLister
-	PVCLister                   corelisterv1.PersistentVolumeClaimLister
-	PVLister                    corelisterv1.PersistentVolumeLister
-	PodLister                   corelisterv1.PodLister
-	NodeLister                  corelisterv1.NodeLister
-	SecretLister                corelisterv1.SecretLister
-	ConfigMapLister             corelisterv1.ConfigMapLister
-	StatefulSetLister           appslisters.StatefulSetLister
-	DeploymentLister            appslisters.DeploymentLister
-	JobLister                   batchlisters.JobLister
-	IngressLister               extensionslister.IngressLister
-	StorageClassLister          storagelister.StorageClassLister
-	TiDBClusterLister           listers.TidbClusterLister
-	TiDBClusterAutoScalerLister listers.TidbClusterAutoScalerLister
-	DMClusterLister             listers.DMClusterLister
-	BackupLister                listers.BackupLister
-	RestoreLister               listers.RestoreLister
-	BackupScheduleLister        listers.BackupScheduleLister
-	TiDBInitializerLister       listers.TidbInitializerLister
-	TiDBMonitorLister           listers.TidbMonitorLister
+	ServiceLister                  corelisterv1.ServiceLister
+	EndpointLister                 corelisterv1.EndpointsLister
+	PVCLister                      corelisterv1.PersistentVolumeClaimLister
+	PVLister                       corelisterv1.PersistentVolumeLister
+	PodLister                      corelisterv1.PodLister
+	NodeLister                     corelisterv1.NodeLister
+	SecretLister                   corelisterv1.SecretLister
+	ResourceQuotaLister            corelisterv1.ResourceQuotaLister
+	ConfigMapLister                corelisterv1.ConfigMapLister
+	StatefulSetLister              appslisters.StatefulSetLister
+	DeploymentLister               appslisters.DeploymentLister
+	JobLister                      batchlisters.JobLister
+	IngressLister                  extensionslister.IngressLister
+	StorageClassLister             storagelister.StorageClassLister
+	TiDBClusterLister              listers.TidbClusterLister
+	TiDBClusterAutoScalerLister    listers.TidbClusterAutoScalerLister
+	DMClusterLister                listers.DMClusterLister
+	BackupLister                   listers.BackupLister
+	RestoreLister                  listers.RestoreLister
+	BackupScheduleLister           listers.BackupScheduleLister
+	TiDBInitializerLister          listers.TidbInitializerLister
+	TiDBMonitorLister              listers.TidbMonitorLister
+	TiDBClusterConfigProfileLister listers.TidbClusterConfigProfileLister
 
 	// Controls
 	Controls
@@ -227,7 +294,7 @@ func newRealControls(
 	recorder record.EventRecorder) Controls {
 	// Shared variables to construct `Dependencies` and some of its fields
 	var (
-		pdControl         = pdapi.NewDefaultPDControl(kubeClientset)
+		pdControl         = newPDControl(cliCfg, kubeClientset)
 		tikvControl       = tikvapi.NewDefaultTiKVControl(kubeClientset)
 		tiflashControl    = tiflashapi.NewDefaultTiFlashControl(kubeClientset)
 		masterControl     = dmapi.NewDefaultMasterControl(kubeClientset)
@@ -262,11 +329,23 @@ func newRealControls(
 		TiDBClusterControl: NewRealTidbClusterControl(clientset, tidbClusterLister, recorder),
 		DMClusterControl:   NewRealDMClusterControl(clientset, dmClusterLister, recorder),
 		CDCControl:         NewDefaultTiCDCControl(kubeClientset),
-		TiDBControl:        NewDefaultTiDBControl(kubeClientset),
+		TiDBControl:        NewDefaultTiDBControlWithPool(kubeClientset, kubeInformerFactory.Core().V1().Secrets().Lister(), cliCfg.TiDBClientPool),
 		BackupControl:      NewRealBackupControl(clientset, recorder),
+		PrometheusControl:  NewDefaultPrometheusControl(),
+		PodTemplateControl: NewDefaultPodTemplateControl(),
+		ScaleHookControl:   NewDefaultScaleHookControl(),
+		PDDeletionLimiter:  NewPDDeletionLimiter(cliCfg.PDDeletionLimit, cliCfg.PDDeletionWindow),
+		FailoverNotifier:   NewFailoverNotifier(cliCfg.FailoverNotificationWebhook, recorder),
 	}
 }
 
+func newPDControl(cliCfg *CLIConfig, kubeClientset kubernetes.Interface) pdapi.PDControlInterface {
+	if cliCfg.PDReadLoadBalancing {
+		return pdapi.NewReadBalancedPDControl(kubeClientset)
+	}
+	return pdapi.NewDefaultPDControl(kubeClientset)
+}
+
 func newDependencies(
 	cliCfg *CLIConfig,
 	clientset versioned.Interface,
@@ -309,27 +388,29 @@ func newDependencies(
 		Recorder:                       recorder,
 
 		// Listers
-		ServiceLister:               kubeInformerFactory.Core().V1().Services().Lister(),
-		EndpointLister:              kubeInformerFactory.Core().V1().Endpoints().Lister(),
-		PVCLister:                   kubeInformerFactory.Core().V1().PersistentVolumeClaims().Lister(),
-		PVLister:                    pvLister,
-		PodLister:                   kubeInformerFactory.Core().V1().Pods().Lister(),
-		NodeLister:                  nodeLister,
-		SecretLister:                kubeInformerFactory.Core().V1().Secrets().Lister(),
-		ConfigMapLister:             labelFilterKubeInformerFactory.Core().V1().ConfigMaps().Lister(),
-		StatefulSetLister:           kubeInformerFactory.Apps().V1().StatefulSets().Lister(),
-		DeploymentLister:            kubeInformerFactory.Apps().V1().Deployments().Lister(),
-		StorageClassLister:          scLister,
-		JobLister:                   kubeInformerFactory.Batch().V1().Jobs().Lister(),
-		IngressLister:               kubeInformerFactory.Extensions().V1beta1().Ingresses().Lister(),
-		TiDBClusterLister:           informerFactory.Pingcap().V1alpha1().TidbClusters().Lister(),
-		TiDBClusterAutoScalerLister: informerFactory.Pingcap().V1alpha1().TidbClusterAutoScalers().Lister(),
-		DMClusterLister:             informerFactory.Pingcap().V1alpha1().DMClusters().Lister(),
-		BackupLister:                informerFactory.Pingcap().V1alpha1().Backups().Lister(),
-		RestoreLister:               informerFactory.Pingcap().V1alpha1().Restores().Lister(),
-		BackupScheduleLister:        informerFactory.Pingcap().V1alpha1().BackupSchedules().Lister(),
-		TiDBInitializerLister:       informerFactory.Pingcap().V1alpha1().TidbInitializers().Lister(),
-		TiDBMonitorLister:           informerFactory.Pingcap().V1alpha1().TidbMonitors().Lister(),
+		ServiceLister:                  kubeInformerFactory.Core().V1().Services().Lister(),
+		EndpointLister:                 kubeInformerFactory.Core().V1().Endpoints().Lister(),
+		PVCLister:                      kubeInformerFactory.Core().V1().PersistentVolumeClaims().Lister(),
+		PVLister:                       pvLister,
+		PodLister:                      kubeInformerFactory.Core().V1().Pods().Lister(),
+		NodeLister:                     nodeLister,
+		SecretLister:                   kubeInformerFactory.Core().V1().Secrets().Lister(),
+		ResourceQuotaLister:            kubeInformerFactory.Core().V1().ResourceQuotas().Lister(),
+		ConfigMapLister:                labelFilterKubeInformerFactory.Core().V1().ConfigMaps().Lister(),
+		StatefulSetLister:              kubeInformerFactory.Apps().V1().StatefulSets().Lister(),
+		DeploymentLister:               kubeInformerFactory.Apps().V1().Deployments().Lister(),
+		StorageClassLister:             scLister,
+		JobLister:                      kubeInformerFactory.Batch().V1().Jobs().Lister(),
+		IngressLister:                  kubeInformerFactory.Extensions().V1beta1().Ingresses().Lister(),
+		TiDBClusterLister:              informerFactory.Pingcap().V1alpha1().TidbClusters().Lister(),
+		TiDBClusterAutoScalerLister:    informerFactory.Pingcap().V1alpha1().TidbClusterAutoScalers().Lister(),
+		DMClusterLister:                informerFactory.Pingcap().V1alpha1().DMClusters().Lister(),
+		BackupLister:                   informerFactory.Pingcap().V1alpha1().Backups().Lister(),
+		RestoreLister:                  informerFactory.Pingcap().V1alpha1().Restores().Lister(),
+		BackupScheduleLister:           informerFactory.Pingcap().V1alpha1().BackupSchedules().Lister(),
+		TiDBInitializerLister:          informerFactory.Pingcap().V1alpha1().TidbInitializers().Lister(),
+		TiDBMonitorLister:              informerFactory.Pingcap().V1alpha1().TidbMonitors().Lister(),
+		TiDBClusterConfigProfileLister: informerFactory.Pingcap().V1alpha1().TidbClusterConfigProfiles().Lister(),
 	}
 }
 
@@ -393,9 +474,14 @@ func newFakeControl(kubeClientset kubernetes.Interface, informerFactory informer
 		TiFlashControl:     tiflashapi.NewFakeTiFlashControl(kubeClientset),
 		DMMasterControl:    dmapi.NewFakeMasterControl(kubeClientset),
 		TiDBClusterControl: NewFakeTidbClusterControl(informerFactory.Pingcap().V1alpha1().TidbClusters()),
-		CDCControl:         NewDefaultTiCDCControl(kubeClientset), // TODO: no fake control?
+		CDCControl:         NewFakeTiCDCControl(),
 		TiDBControl:        NewFakeTiDBControl(),
 		BackupControl:      NewFakeBackupControl(informerFactory.Pingcap().V1alpha1().Backups()),
+		PrometheusControl:  NewFakePrometheusControl(),
+		PodTemplateControl: NewFakePodTemplateControl(),
+		ScaleHookControl:   NewFakeScaleHookControl(),
+		PDDeletionLimiter:  NewPDDeletionLimiter(0, 0),
+		FailoverNotifier:   NewFakeFailoverNotifier(),
 	}
 }
 
@@ -0,0 +1,194 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+)
+
+// FailoverEventType is the type of a failover notification
+type FailoverEventType string
+
+const (
+	// FailoverEventMemberFailed is sent when a member is marked as failed,
+	// for TiKV, TiFlash and TiDB a new replica is added for it at the same time
+	FailoverEventMemberFailed FailoverEventType = "MemberFailed"
+	// FailoverEventTriggered is sent when a failure member is replaced, e.g. a
+	// failure PD member is deleted to be recreated, or the slot of a TiKV
+	// store on a gone node is deleted
+	FailoverEventTriggered FailoverEventType = "FailoverTriggered"
+	// FailoverEventRecovered is sent when the failure members are recovered
+	// after all the desired members of the component are healthy again
+	FailoverEventRecovered FailoverEventType = "FailoverRecovered"
+
+	// FailoverNotificationReason is the reason of the Events of the failover
+	// notifications
+	FailoverNotificationReason = "FailoverNotification"
+
+	// failoverNotificationQueueSize is the max number of notifications waiting
+	// to be sent, the notifications exceeding it are recorded as Events
+	failoverNotificationQueueSize = 256
+	// failoverNotificationTimeout is the timeout of a request to the webhook
+	failoverNotificationTimeout = 10 * time.Second
+)
+
+// FailoverEvent is the notification of a failover, it is POSTed to the webhook
+// as JSON
+type FailoverEvent struct {
+	Type      FailoverEventType `json:"type"`
+	Namespace string            `json:"namespace"`
+	Cluster   string            `json:"cluster"`
+	Component string            `json:"component"`
+	// Member is the Pod name of the member, it is empty if the event is
+	// about the whole component, e.g. FailoverRecovered
+	Member  string      `json:"member,omitempty"`
+	Message string      `json:"message"`
+	Time    metav1.Time `json:"time"`
+	// EventRecorded indicates the failover has recorded an Event of its own
+	// for the notification, so it is not recorded as an Event again
+	EventRecorded bool `json:"-"`
+}
+
+// FailoverNotifier notifies the failovers of the clusters, so that paging
+// systems can integrate with them without scraping the logs
+type FailoverNotifier interface {
+	// Notify sends the notification of the cluster without blocking, the
+	// namespace, cluster and time of the event are filled by it
+	Notify(tc *v1alpha1.TidbCluster, event FailoverEvent)
+}
+
+type failoverNotification struct {
+	tc    *v1alpha1.TidbCluster
+	event FailoverEvent
+}
+
+// realFailoverNotifier POSTs the notifications to a webhook in the background,
+// or records them as Events if the webhook is not configured. A notification
+// failed to be POSTed is recorded as an Event as well. The notifications the
+// failovers have recorded an Event for are never recorded again.
+type realFailoverNotifier struct {
+	url        string
+	httpClient *http.Client
+	recorder   record.EventRecorder
+
+	queue chan failoverNotification
+}
+
+// NewFailoverNotifier returns a FailoverNotifier which POSTs the notifications
+// to the webhook url, an empty url means recording them as Events with the
+// FailoverNotificationReason
+func NewFailoverNotifier(url string, recorder record.EventRecorder) FailoverNotifier {
+	n := &realFailoverNotifier{
+		url:        url,
+		httpClient: &http.Client{Timeout: failoverNotificationTimeout},
+		recorder:   recorder,
+	}
+	if url != "" {
+		n.queue = make(chan failoverNotification, failoverNotificationQueueSize)
+		go n.run()
+	}
+	return n
+}
+
+func (n *realFailoverNotifier) Notify(tc *v1alpha1.TidbCluster, event FailoverEvent) {
+	event.Namespace = tc.GetNamespace()
+	event.Cluster = tc.GetName()
+	event.Time = metav1.Now()
+	if n.url == "" {
+		n.record(tc, event)
+		return
+	}
+	// the sync goroutine keeps mutating tc, so a copy of it is queued to be
+	// recorded by the sender goroutine
+	select {
+	case n.queue <- failoverNotification{tc: tc.DeepCopy(), event: event}:
+	default:
+		klog.Warningf("failover notification: the queue is full, record %s of %s/%s as an event", event.Type, event.Namespace, event.Cluster)
+		n.record(tc, event)
+	}
+}
+
+func (n *realFailoverNotifier) run() {
+	for notification := range n.queue {
+		if err := n.send(notification.event); err != nil {
+			klog.Warningf("failover notification: failed to send %s of %s/%s to %s, error: %v", notification.event.Type, notification.event.Namespace, notification.event.Cluster, n.url, err)
+			n.record(notification.tc, notification.event)
+		}
+	}
+}
+
+func (n *realFailoverNotifier) send(event FailoverEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	resp, err := n.httpClient.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func (n *realFailoverNotifier) record(tc *v1alpha1.TidbCluster, event FailoverEvent) {
+	if event.EventRecorded {
+		return
+	}
+	eventType := corev1.EventTypeWarning
+	if event.Type == FailoverEventRecovered {
+		eventType = corev1.EventTypeNormal
+	}
+	n.recorder.Eventf(tc, eventType, FailoverNotificationReason, "[%s] %s: %s", event.Type, event.Component, event.Message)
+}
+
+// FakeFailoverNotifier keeps the notifications in memory, it is used in tests
+type FakeFailoverNotifier struct {
+	mutex  sync.Mutex
+	events []FailoverEvent
+}
+
+// NewFakeFailoverNotifier returns a FakeFailoverNotifier
+func NewFakeFailoverNotifier() *FakeFailoverNotifier {
+	return &FakeFailoverNotifier{}
+}
+
+func (n *FakeFailoverNotifier) Notify(tc *v1alpha1.TidbCluster, event FailoverEvent) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	event.Namespace = tc.GetNamespace()
+	event.Cluster = tc.GetName()
+	event.Time = metav1.Now()
+	n.events = append(n.events, event)
+}
+
+// Events returns the notifications in the order they are sent
+func (n *FakeFailoverNotifier) Events() []FailoverEvent {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return append([]FailoverEvent(nil), n.events...)
+}
@@ -0,0 +1,87 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestFailoverNotifier(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := &v1alpha1.TidbCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "tc"}}
+	event := FailoverEvent{
+		Type:      FailoverEventMemberFailed,
+		Component: "tikv",
+		Member:    "tc-tikv-1",
+		Message:   "store[1] is Down",
+	}
+
+	// the notification is recorded as an Event without the webhook
+	recorder := record.NewFakeRecorder(10)
+	NewFailoverNotifier("", recorder).Notify(tc, event)
+	g.Expect(recorder.Events).To(Receive(Equal("Warning FailoverNotification [MemberFailed] tikv: store[1] is Down")))
+
+	// the notification the failover has recorded an Event for is not recorded again
+	recorded := event
+	recorded.EventRecorded = true
+	NewFailoverNotifier("", recorder).Notify(tc, recorded)
+	g.Expect(recorder.Events).NotTo(Receive())
+
+	// the notification is POSTed to the webhook
+	type request struct {
+		method      string
+		contentType string
+		body        []byte
+	}
+	received := make(chan request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received <- request{method: r.Method, contentType: r.Header.Get("Content-Type"), body: body}
+	}))
+	defer server.Close()
+	recorder = record.NewFakeRecorder(10)
+	NewFailoverNotifier(server.URL, recorder).Notify(tc, event)
+	var req request
+	g.Eventually(received, 5*time.Second).Should(Receive(&req))
+	g.Expect(req.method).To(Equal(http.MethodPost))
+	g.Expect(req.contentType).To(Equal("application/json"))
+	var e FailoverEvent
+	g.Expect(json.Unmarshal(req.body, &e)).To(Succeed())
+	g.Expect(e.Type).To(Equal(FailoverEventMemberFailed))
+	g.Expect(e.Namespace).To(Equal("ns"))
+	g.Expect(e.Cluster).To(Equal("tc"))
+	g.Expect(e.Member).To(Equal("tc-tikv-1"))
+	g.Expect(e.Time.IsZero()).To(BeFalse())
+	g.Consistently(recorder.Events, 100*time.Millisecond).ShouldNot(Receive())
+
+	// the notification failed to be POSTed is recorded as an Event
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	recorder = record.NewFakeRecorder(10)
+	NewFailoverNotifier(failing.URL, recorder).Notify(tc, FailoverEvent{Type: FailoverEventRecovered, Component: "pd", Message: "1 failure members are recovered"})
+	g.Eventually(recorder.Events, 5*time.Second).Should(Receive(Equal("Normal FailoverNotification [FailoverRecovered] pd: 1 failure members are recovered")))
+}
@@ -19,6 +19,7 @@ import (
 	"crypto/x509"
 	"fmt"
 	"net/http"
+	"sync"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/util"
@@ -29,10 +30,26 @@ import (
 
 type httpClient struct {
 	kubeCli kubernetes.Interface
+	// transport is shared by the requests to the clusters without TLS,
+	// http.DefaultTransport is used if it is nil
+	transport http.RoundTripper
+
+	// tlsTransports are cloned from transport with the client certificates of
+	// the clusters with TLS, so that their connections are pooled as well.
+	// They are keyed by the cluster, and replaced once the client Secret
+	// changes, e.g. the certificates are rotated.
+	tlsMutex      sync.Mutex
+	tlsTransports map[string]*tlsTransport
+}
+
+// tlsTransport is the pooled transport of a cluster with TLS
+type tlsTransport struct {
+	resourceVersion string
+	transport       *http.Transport
 }
 
 func (c *httpClient) getHTTPClient(tc *v1alpha1.TidbCluster) (*http.Client, error) {
-	httpClient := &http.Client{Timeout: timeout}
+	httpClient := &http.Client{Timeout: timeout, Transport: c.transport}
 	if !tc.IsTLSClusterEnabled() {
 		return httpClient, nil
 	}
@@ -62,7 +79,43 @@ func (c *httpClient) getHTTPClient(tc *v1alpha1.TidbCluster) (*http.Client, erro
 		RootCAs:      rootCAs,
 		Certificates: []tls.Certificate{tlsCert},
 	}
-	httpClient.Transport = &http.Transport{TLSClientConfig: config, DisableKeepAlives: true}
-
+	pooled, ok := c.transport.(*http.Transport)
+	if !ok {
+		httpClient.Transport = &http.Transport{TLSClientConfig: config, DisableKeepAlives: true}
+		return httpClient, nil
+	}
+	httpClient.Transport = c.getTLSTransport(fmt.Sprintf("%s/%s", ns, tcName), secret.GetResourceVersion(), pooled, config)
 	return httpClient, nil
 }
+
+// getTLSTransport returns the pooled transport of the cluster with TLS, it is
+// cloned from the shared transport with the TLS config, and the one of an
+// outdated Secret is closed
+func (c *httpClient) getTLSTransport(key, resourceVersion string, pooled *http.Transport, config *tls.Config) *http.Transport {
+	c.tlsMutex.Lock()
+	defer c.tlsMutex.Unlock()
+	if cached, ok := c.tlsTransports[key]; ok {
+		if cached.resourceVersion == resourceVersion {
+			return cached.transport
+		}
+		cached.transport.CloseIdleConnections()
+	}
+	if c.tlsTransports == nil {
+		c.tlsTransports = map[string]*tlsTransport{}
+	}
+	transport := pooled.Clone()
+	transport.TLSClientConfig = config
+	c.tlsTransports[key] = &tlsTransport{resourceVersion: resourceVersion, transport: transport}
+	return transport
+}
+
+// forgetTLSTransport closes and drops the pooled transport of the deleted
+// cluster with TLS
+func (c *httpClient) forgetTLSTransport(key string) {
+	c.tlsMutex.Lock()
+	defer c.tlsMutex.Unlock()
+	if cached, ok := c.tlsTransports[key]; ok {
+		cached.transport.CloseIdleConnections()
+		delete(c.tlsTransports, key)
+	}
+}
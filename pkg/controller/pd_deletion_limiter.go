@@ -0,0 +1,102 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+// PDDeletionLimiter limits how many stores and members are deleted from the PD
+// of each cluster in a sliding time window, so that a mass scale-in or node
+// pool replacement doesn't overwhelm the scheduling of PD
+type PDDeletionLimiter interface {
+	// Reserve reserves the deletion of the store or member with the id from
+	// the PD of the cluster. If the limit is reached, it returns false and how
+	// long to wait before the next deletion is allowed. Deleting the same id
+	// again within the window is allowed without counting it again.
+	Reserve(tc *v1alpha1.TidbCluster, id string) (bool, time.Duration)
+}
+
+type pdDeletion struct {
+	id   string
+	time time.Time
+}
+
+type realPDDeletionLimiter struct {
+	limit  int
+	window time.Duration
+
+	mutex sync.Mutex
+	// deletions are the deletions within the window of each PD cluster in
+	// the order they are reserved, keyed by namespace/name
+	deletions map[string][]pdDeletion
+	now       func() time.Time
+}
+
+// NewPDDeletionLimiter returns a PDDeletionLimiter which allows at most limit
+// deletions from the PD of each cluster in the window, a limit less than or
+// equal to 0 means no limit
+func NewPDDeletionLimiter(limit int, window time.Duration) PDDeletionLimiter {
+	return &realPDDeletionLimiter{
+		limit:     limit,
+		window:    window,
+		deletions: map[string][]pdDeletion{},
+		now:       time.Now,
+	}
+}
+
+func (l *realPDDeletionLimiter) Reserve(tc *v1alpha1.TidbCluster, id string) (bool, time.Duration) {
+	if l.limit <= 0 {
+		return true, 0
+	}
+	key := pdClusterKey(tc)
+	now := l.now()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	var deletions []pdDeletion
+	for _, d := range l.deletions[key] {
+		if now.Sub(d.time) < l.window {
+			deletions = append(deletions, d)
+		}
+	}
+	l.deletions[key] = deletions
+
+	for _, d := range deletions {
+		if d.id == id {
+			return true, 0
+		}
+	}
+	if len(deletions) >= l.limit {
+		return false, deletions[0].time.Add(l.window).Sub(now)
+	}
+	l.deletions[key] = append(deletions, pdDeletion{id: id, time: now})
+	return true, 0
+}
+
+// pdClusterKey returns the key of the cluster whose PD serves the cluster
+func pdClusterKey(tc *v1alpha1.TidbCluster) string {
+	if tc.HeterogeneousWithoutLocalPD() {
+		ns := tc.Spec.Cluster.Namespace
+		if ns == "" {
+			ns = tc.GetNamespace()
+		}
+		return fmt.Sprintf("%s/%s", ns, tc.Spec.Cluster.Name)
+	}
+	return fmt.Sprintf("%s/%s", tc.GetNamespace(), tc.GetName())
+}
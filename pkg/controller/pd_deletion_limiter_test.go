@@ -0,0 +1,69 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPDDeletionLimiter(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := &v1alpha1.TidbCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "tc"}}
+	other := &v1alpha1.TidbCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "other"}}
+	heterogeneous := &v1alpha1.TidbCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "heterogeneous"},
+		Spec:       v1alpha1.TidbClusterSpec{Cluster: &v1alpha1.TidbClusterRef{Name: "tc"}},
+	}
+
+	unlimited := NewPDDeletionLimiter(0, time.Minute)
+	for _, id := range []string{"store/1", "store/2", "store/3"} {
+		ok, _ := unlimited.Reserve(tc, id)
+		g.Expect(ok).To(BeTrue())
+	}
+
+	now := time.Now()
+	limiter := NewPDDeletionLimiter(2, time.Minute).(*realPDDeletionLimiter)
+	limiter.now = func() time.Time { return now }
+
+	ok, _ := limiter.Reserve(tc, "store/1")
+	g.Expect(ok).To(BeTrue())
+	now = now.Add(10 * time.Second)
+	ok, _ = limiter.Reserve(tc, "member/tc-pd-2")
+	g.Expect(ok).To(BeTrue())
+
+	ok, wait := limiter.Reserve(tc, "store/2")
+	g.Expect(ok).To(BeFalse())
+	g.Expect(wait).To(Equal(50 * time.Second))
+	// retrying a reserved deletion is allowed
+	ok, _ = limiter.Reserve(tc, "store/1")
+	g.Expect(ok).To(BeTrue())
+	// the heterogeneous cluster shares the PD of the cluster it references
+	ok, _ = limiter.Reserve(heterogeneous, "store/3")
+	g.Expect(ok).To(BeFalse())
+	ok, _ = limiter.Reserve(other, "store/1")
+	g.Expect(ok).To(BeTrue())
+
+	now = now.Add(50 * time.Second)
+	ok, _ = limiter.Reserve(tc, "store/2")
+	g.Expect(ok).To(BeTrue())
+	ok, wait = limiter.Reserve(heterogeneous, "store/3")
+	g.Expect(ok).To(BeFalse())
+	g.Expect(wait).To(Equal(10 * time.Second))
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
 	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -40,6 +41,8 @@ type PodControlInterface interface {
 	// TODO change this to UpdatePod
 	UpdateMetaInfo(*v1alpha1.TidbCluster, *corev1.Pod) (*corev1.Pod, error)
 	DeletePod(runtime.Object, *corev1.Pod) error
+	// EvictPod deletes the Pod through the eviction API, which respects the PodDisruptionBudgets
+	EvictPod(runtime.Object, *corev1.Pod) error
 	UpdatePod(runtime.Object, *corev1.Pod) (*corev1.Pod, error)
 }
 
@@ -224,6 +227,31 @@ func (c *realPodControl) DeletePod(controller runtime.Object, pod *corev1.Pod) e
 	return err
 }
 
+func (c *realPodControl) EvictPod(controller runtime.Object, pod *corev1.Pod) error {
+	controllerMo, ok := controller.(metav1.Object)
+	if !ok {
+		return fmt.Errorf("%T is not a metav1.Object, cannot call setControllerReference", controller)
+	}
+	kind := controller.GetObjectKind().GroupVersionKind().Kind
+	name := controllerMo.GetName()
+	namespace := controllerMo.GetNamespace()
+
+	podName := pod.GetName()
+	preconditions := metav1.Preconditions{UID: &pod.UID, ResourceVersion: &pod.ResourceVersion}
+	eviction := &policyv1beta1.Eviction{
+		ObjectMeta:    metav1.ObjectMeta{Name: podName, Namespace: namespace},
+		DeleteOptions: &metav1.DeleteOptions{Preconditions: &preconditions},
+	}
+	err := c.kubeCli.CoreV1().Pods(namespace).Evict(context.TODO(), eviction)
+	if err != nil {
+		klog.Errorf("failed to evict Pod: [%s/%s], %s: %s, %v", namespace, podName, kind, namespace, err)
+	} else {
+		klog.V(4).Infof("evict Pod: [%s/%s] successfully, %s: %s", namespace, podName, kind, namespace)
+	}
+	c.recordPodEvent("evict", kind, name, controller, podName, err)
+	return err
+}
+
 func (c *realPodControl) recordPodEvent(verb, kind, name string, object runtime.Object, podName string, err error) {
 	if err == nil {
 		reason := fmt.Sprintf("Successful%s", strings.Title(verb))
@@ -256,6 +284,7 @@ type FakePodControl struct {
 	PodIndexer        cache.Indexer
 	updatePodTracker  RequestTracker
 	deletePodTracker  RequestTracker
+	evictPodTracker   RequestTracker
 	getClusterTracker RequestTracker
 	getMemberTracker  RequestTracker
 	getStoreTracker   RequestTracker
@@ -270,6 +299,7 @@ func NewFakePodControl(podInformer coreinformers.PodInformer) *FakePodControl {
 		RequestTracker{},
 		RequestTracker{},
 		RequestTracker{},
+		RequestTracker{},
 	}
 }
 
@@ -283,6 +313,11 @@ func (c *FakePodControl) SetDeletePodError(err error, after int) {
 	c.deletePodTracker.SetError(err).SetAfter(after)
 }
 
+// SetEvictPodError sets the error attributes of evictPodTracker
+func (c *FakePodControl) SetEvictPodError(err error, after int) {
+	c.evictPodTracker.SetError(err).SetAfter(after)
+}
+
 // SetGetClusterError sets the error attributes of getClusterTracker
 func (c *FakePodControl) SetGetClusterError(err error, after int) {
 	c.getStoreTracker.SetError(err).SetAfter(after)
@@ -343,6 +378,16 @@ func (c *FakePodControl) DeletePod(_ runtime.Object, pod *corev1.Pod) error {
 	return c.PodIndexer.Delete(pod)
 }
 
+func (c *FakePodControl) EvictPod(_ runtime.Object, pod *corev1.Pod) error {
+	defer c.evictPodTracker.Inc()
+	if c.evictPodTracker.ErrorReady() {
+		defer c.evictPodTracker.Reset()
+		return c.evictPodTracker.GetError()
+	}
+
+	return c.PodIndexer.Delete(pod)
+}
+
 func (c *FakePodControl) UpdatePod(_ runtime.Object, pod *corev1.Pod) (*corev1.Pod, error) {
 	defer c.updatePodTracker.Inc()
 	if c.updatePodTracker.ErrorReady() {
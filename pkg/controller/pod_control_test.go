@@ -333,6 +333,27 @@ func TestPodControlUpdatePodConflictSuccess(t *testing.T) {
 	g.Expect(updatePod.Labels["a"]).To(Equal("b"))
 }
 
+func TestPodControlEvictPod(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tc := newTidbCluster()
+	pod := newPod(tc)
+	fakeClient, pdControl, podLister, _, recorder := newFakeClientRecorderAndPDControl()
+	control := NewRealPodControl(fakeClient, pdControl, podLister, recorder)
+	disruptionAllowed := true
+	fakeClient.AddReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		g.Expect(action.GetSubresource()).To(Equal("eviction"))
+		if !disruptionAllowed {
+			return true, nil, apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+		}
+		return true, nil, nil
+	})
+	g.Expect(control.EvictPod(tc, pod)).To(Succeed())
+
+	disruptionAllowed = false
+	err := control.EvictPod(tc, pod)
+	g.Expect(apierrors.IsTooManyRequests(err)).To(BeTrue())
+}
+
 func newFakeClientRecorderAndPDControl() (*fake.Clientset, *pdapi.FakePDControl, corelisters.PodLister, cache.Indexer, *record.FakeRecorder) {
 	fakeClient := &fake.Clientset{}
 	kubeCli := kubefake.NewSimpleClientset()
@@ -0,0 +1,135 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	httputil "github.com/pingcap/tidb-operator/pkg/util/http"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PodTemplateReview is the request and response body of the Pod template webhook
+type PodTemplateReview struct {
+	// Namespace and Cluster are the namespace and name of the TidbCluster
+	Namespace string `json:"namespace"`
+	Cluster   string `json:"cluster"`
+	// Component is the component of the Pod template, e.g. tikv
+	Component string `json:"component"`
+	// Template is the Pod template generated by the operator in the request,
+	// and the mutated Pod template in the response
+	Template corev1.PodTemplateSpec `json:"template"`
+}
+
+// PodTemplateControlInterface is the interface that knows how to call the Pod template webhook
+type PodTemplateControlInterface interface {
+	// MutatePodTemplate calls the Pod template webhook of the cluster with the
+	// Pod template of the component and returns the mutated Pod template
+	MutatePodTemplate(tc *v1alpha1.TidbCluster, component v1alpha1.MemberType, template *corev1.PodTemplateSpec) (*corev1.PodTemplateSpec, error)
+}
+
+// defaultPodTemplateControl is the default implementation of PodTemplateControlInterface.
+type defaultPodTemplateControl struct {
+	mutex sync.Mutex
+	// httpClients are the HTTP clients keyed by the timeout and the CA bundle,
+	// so that the connections to the webhooks are reused across the syncs
+	httpClients map[string]*http.Client
+}
+
+// NewDefaultPodTemplateControl returns a defaultPodTemplateControl instance
+func NewDefaultPodTemplateControl() PodTemplateControlInterface {
+	return &defaultPodTemplateControl{httpClients: map[string]*http.Client{}}
+}
+
+// getHTTPClient returns the HTTP client with the timeout and the CA bundle
+func (c *defaultPodTemplateControl) getHTTPClient(timeout time.Duration, caBundle []byte) (*http.Client, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := fmt.Sprintf("%s/%s", timeout, caBundle)
+	if httpClient, ok := c.httpClients[key]; ok {
+		return httpClient, nil
+	}
+	httpClient := &http.Client{Timeout: timeout}
+	if len(caBundle) > 0 {
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(caBundle) {
+			return nil, fmt.Errorf("failed to load the CA bundle of the pod template webhook")
+		}
+		httpClient.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: rootCAs}}
+	}
+	c.httpClients[key] = httpClient
+	return httpClient, nil
+}
+
+func (c *defaultPodTemplateControl) MutatePodTemplate(tc *v1alpha1.TidbCluster, component v1alpha1.MemberType, template *corev1.PodTemplateSpec) (*corev1.PodTemplateSpec, error) {
+	webhook := tc.Spec.PodTemplateWebhook
+	if webhook == nil {
+		return template, nil
+	}
+
+	httpClient, err := c.getHTTPClient(tc.PodTemplateWebhookTimeout(), webhook.CABundle)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(&PodTemplateReview{
+		Namespace: tc.GetNamespace(),
+		Cluster:   tc.GetName(),
+		Component: component.String(),
+		Template:  *template,
+	})
+	if err != nil {
+		return nil, err
+	}
+	body, err := httputil.PostBodyOK(httpClient, webhook.URL, bytes.NewBuffer(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to call the pod template webhook %s: %v", webhook.URL, err)
+	}
+	review := &PodTemplateReview{}
+	if err := json.Unmarshal(body, review); err != nil {
+		return nil, fmt.Errorf("failed to decode the response of the pod template webhook %s: %v", webhook.URL, err)
+	}
+	return &review.Template, nil
+}
+
+// FakePodTemplateControl is a fake implementation of PodTemplateControlInterface.
+type FakePodTemplateControl struct {
+	// Components are the components whose Pod templates are mutated, in order
+	Components []v1alpha1.MemberType
+	// MutateFn mutates the Pod templates if it is set
+	MutateFn func(component v1alpha1.MemberType, template *corev1.PodTemplateSpec) error
+}
+
+// NewFakePodTemplateControl returns a FakePodTemplateControl instance
+func NewFakePodTemplateControl() *FakePodTemplateControl {
+	return &FakePodTemplateControl{}
+}
+
+func (c *FakePodTemplateControl) MutatePodTemplate(_ *v1alpha1.TidbCluster, component v1alpha1.MemberType, template *corev1.PodTemplateSpec) (*corev1.PodTemplateSpec, error) {
+	c.Components = append(c.Components, component)
+	if c.MutateFn != nil {
+		if err := c.MutateFn(component, template); err != nil {
+			return nil, err
+		}
+	}
+	return template, nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodTemplateControlMutatePodTemplate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	svc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		g.Expect(request.Method).To(Equal("POST"))
+		review := &PodTemplateReview{}
+		g.Expect(json.NewDecoder(request.Body).Decode(review)).To(Succeed())
+		g.Expect(review.Namespace).To(Equal(metav1.NamespaceDefault))
+		g.Expect(review.Cluster).To(Equal("demo"))
+		g.Expect(review.Component).To(Equal("tikv"))
+		if review.Template.Labels["fail"] != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		review.Template.Spec.Containers = append(review.Template.Spec.Containers, corev1.Container{Name: "sidecar"})
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		json.NewEncoder(w).Encode(review)
+	}))
+	defer svc.Close()
+
+	tc := &v1alpha1.TidbCluster{ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: metav1.NamespaceDefault}}
+	template := &corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "tikv"}}},
+	}
+	control := NewDefaultPodTemplateControl()
+
+	// the template is returned as it is without the webhook
+	mutated, err := control.MutatePodTemplate(tc, v1alpha1.TiKVMemberType, template)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(mutated).To(Equal(template))
+
+	tc.Spec.PodTemplateWebhook = &v1alpha1.PodTemplateWebhook{URL: svc.URL}
+	mutated, err = control.MutatePodTemplate(tc, v1alpha1.TiKVMemberType, template)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(mutated.Spec.Containers).To(HaveLen(2))
+	g.Expect(mutated.Spec.Containers[1].Name).To(Equal("sidecar"))
+
+	template.Labels["fail"] = "true"
+	_, err = control.MutatePodTemplate(tc, v1alpha1.TiKVMemberType, template)
+	g.Expect(err).To(HaveOccurred())
+
+	// the HTTP client is reused across the calls
+	g.Expect(control.(*defaultPodTemplateControl).httpClients).To(HaveLen(1))
+}
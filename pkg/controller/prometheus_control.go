@@ -0,0 +1,132 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// PrometheusControlInterface is the interface that knows how to query Prometheus
+type PrometheusControlInterface interface {
+	// QueryScalar evaluates an instant query and returns the value of the first sample
+	QueryScalar(prometheusURL string, query string) (float64, error)
+}
+
+type promQueryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+type promSample struct {
+	Value []interface{} `json:"value"`
+}
+
+// defaultPrometheusControl is the default implementation of PrometheusControlInterface.
+type defaultPrometheusControl struct {
+	httpClient *http.Client
+}
+
+// NewDefaultPrometheusControl returns a defaultPrometheusControl instance
+func NewDefaultPrometheusControl() PrometheusControlInterface {
+	return &defaultPrometheusControl{httpClient: &http.Client{Timeout: timeout}}
+}
+
+func (c *defaultPrometheusControl) QueryScalar(prometheusURL string, query string) (float64, error) {
+	apiURL := fmt.Sprintf("%s/api/v1/query?%s", strings.TrimSuffix(prometheusURL, "/"), url.Values{"query": []string{query}}.Encode())
+	body, err := getBodyOK(c.httpClient, apiURL)
+	if err != nil {
+		return 0, err
+	}
+	return parsePromQueryResponse(body)
+}
+
+// parsePromQueryResponse returns the value of the first sample of a scalar or vector result
+func parsePromQueryResponse(body []byte) (float64, error) {
+	resp := promQueryResponse{}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0, err
+	}
+	if resp.Status != "success" {
+		return 0, fmt.Errorf("prometheus query failed: %s", resp.Error)
+	}
+
+	var value []interface{}
+	switch resp.Data.ResultType {
+	case "scalar":
+		if err := json.Unmarshal(resp.Data.Result, &value); err != nil {
+			return 0, err
+		}
+	case "vector":
+		samples := []promSample{}
+		if err := json.Unmarshal(resp.Data.Result, &samples); err != nil {
+			return 0, err
+		}
+		if len(samples) == 0 {
+			return 0, fmt.Errorf("prometheus query returns no sample")
+		}
+		value = samples[0].Value
+	default:
+		return 0, fmt.Errorf("unsupported result type %q of prometheus query", resp.Data.ResultType)
+	}
+
+	if len(value) != 2 {
+		return 0, fmt.Errorf("unexpected sample %v of prometheus query", value)
+	}
+	s, ok := value[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected sample %v of prometheus query", value)
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// FakePrometheusControl is a fake implementation of PrometheusControlInterface.
+type FakePrometheusControl struct {
+	values map[string]float64
+	err    error
+}
+
+// NewFakePrometheusControl returns a FakePrometheusControl instance
+func NewFakePrometheusControl() *FakePrometheusControl {
+	return &FakePrometheusControl{values: map[string]float64{}}
+}
+
+// SetValue sets the value returned for the query
+func (c *FakePrometheusControl) SetValue(query string, value float64) {
+	c.values[query] = value
+}
+
+// SetError sets the error returned for all queries
+func (c *FakePrometheusControl) SetError(err error) {
+	c.err = err
+}
+
+func (c *FakePrometheusControl) QueryScalar(_ string, query string) (float64, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	value, ok := c.values[query]
+	if !ok {
+		return 0, fmt.Errorf("prometheus query returns no sample")
+	}
+	return value, nil
+}
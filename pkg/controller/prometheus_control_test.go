@@ -0,0 +1,74 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestPrometheusControlQueryScalar(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cases := []struct {
+		caseName string
+		response string
+		expect   float64
+		err      bool
+	}{
+		{
+			caseName: "scalar",
+			response: `{"status":"success","data":{"resultType":"scalar","result":[1622505600,"0.5"]}}`,
+			expect:   0.5,
+		},
+		{
+			caseName: "vector",
+			response: `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"instance":"tikv-0"},"value":[1622505600,"12.25"]}]}}`,
+			expect:   12.25,
+		},
+		{
+			caseName: "empty vector",
+			response: `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+			err:      true,
+		},
+		{
+			caseName: "query failed",
+			response: `{"status":"error","error":"parse error"}`,
+			err:      true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Log(c.caseName)
+		svc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+			g.Expect(request.URL.Path).To(Equal("/api/v1/query"))
+			g.Expect(request.URL.Query().Get("query")).To(Equal(`up{pod="tikv-0"}`))
+			w.Header().Set("Content-Type", ContentTypeJSON)
+			w.Write([]byte(c.response))
+		}))
+
+		control := NewDefaultPrometheusControl()
+		value, err := control.QueryScalar(svc.URL+"/", `up{pod="tikv-0"}`)
+		if c.err {
+			g.Expect(err).To(HaveOccurred())
+		} else {
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(value).To(Equal(c.expect))
+		}
+		svc.Close()
+	}
+}
@@ -29,3 +29,23 @@ func NewControllerRateLimiter(baseDelay, maxDelay time.Duration) wq.RateLimiter
 		&wq.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
 	)
 }
+
+// SyncFailureBackoff returns the delay before the next sync of an object that
+// failed to sync for the given times in a row. The delay starts from baseDelay
+// and doubles with each failure, capped at maxDelay.
+func SyncFailureBackoff(failures int32, baseDelay, maxDelay time.Duration) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	backoff := baseDelay
+	for i := int32(1); i < failures; i++ {
+		backoff *= 2
+		if backoff >= maxDelay {
+			return maxDelay
+		}
+	}
+	if backoff > maxDelay {
+		return maxDelay
+	}
+	return backoff
+}
@@ -0,0 +1,41 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSyncFailureBackoff(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		failures int32
+		expect   time.Duration
+	}{
+		{failures: 0, expect: 0},
+		{failures: 1, expect: 5 * time.Second},
+		{failures: 2, expect: 10 * time.Second},
+		{failures: 4, expect: 40 * time.Second},
+		{failures: 7, expect: 5 * time.Minute},
+		{failures: 100, expect: 5 * time.Minute},
+	}
+
+	for _, test := range tests {
+		g.Expect(SyncFailureBackoff(test.failures, 5*time.Second, 5*time.Minute)).To(Equal(test.expect), "failures: %d", test.failures)
+	}
+}
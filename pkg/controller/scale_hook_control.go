@@ -0,0 +1,116 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	httputil "github.com/pingcap/tidb-operator/pkg/util/http"
+)
+
+// ScaleHookReview is the request body of the HTTP scale hooks
+type ScaleHookReview struct {
+	// Namespace and Cluster are the namespace and name of the TidbCluster
+	Namespace string `json:"namespace"`
+	Cluster   string `json:"cluster"`
+	// Component is the component being scaled, e.g. tikv
+	Component string `json:"component"`
+	// Action is the scale the hook is called for, PreScaleIn, PostScaleIn or PostScaleOut
+	Action string `json:"action"`
+	// Ordinal and PodName are the ordinal and the Pod of the member being scaled
+	Ordinal int32  `json:"ordinal"`
+	PodName string `json:"podName"`
+}
+
+// ScaleHookControlInterface is the interface that knows how to call the HTTP scale hooks
+type ScaleHookControlInterface interface {
+	// CallScaleHook POSTs the review to the HTTP scale hook and returns an
+	// error if the hook can not be called or responds with an error status
+	CallScaleHook(tc *v1alpha1.TidbCluster, hook *v1alpha1.HTTPScaleHook, review *ScaleHookReview) error
+}
+
+// defaultScaleHookControl is the default implementation of ScaleHookControlInterface.
+type defaultScaleHookControl struct {
+	lock sync.Mutex
+	// transports trust the CA bundles of the hooks and are keyed by the CA
+	// bundle, so that the connections to the hooks are reused across the calls
+	transports map[string]*http.Transport
+}
+
+// NewDefaultScaleHookControl returns a defaultScaleHookControl instance
+func NewDefaultScaleHookControl() ScaleHookControlInterface {
+	return &defaultScaleHookControl{transports: map[string]*http.Transport{}}
+}
+
+func (c *defaultScaleHookControl) CallScaleHook(_ *v1alpha1.TidbCluster, hook *v1alpha1.HTTPScaleHook, review *ScaleHookReview) error {
+	httpClient := &http.Client{Timeout: hook.Timeout()}
+	if len(hook.CABundle) > 0 {
+		transport, err := c.transport(hook.CABundle)
+		if err != nil {
+			return err
+		}
+		httpClient.Transport = transport
+	}
+
+	data, err := json.Marshal(review)
+	if err != nil {
+		return err
+	}
+	if _, err := httputil.PostBodyOK(httpClient, hook.URL, bytes.NewBuffer(data)); err != nil {
+		return fmt.Errorf("failed to call the scale hook %s: %v", hook.URL, err)
+	}
+	return nil
+}
+
+// transport returns the shared transport trusting the CA bundle, the calls
+// without a CA bundle share http.DefaultTransport
+func (c *defaultScaleHookControl) transport(caBundle []byte) (*http.Transport, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if transport, ok := c.transports[string(caBundle)]; ok {
+		return transport, nil
+	}
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(caBundle) {
+		return nil, fmt.Errorf("failed to load the CA bundle of the scale hook")
+	}
+	transport := &http.Transport{TLSClientConfig: &tls.Config{RootCAs: rootCAs}}
+	c.transports[string(caBundle)] = transport
+	return transport, nil
+}
+
+// FakeScaleHookControl is a fake implementation of ScaleHookControlInterface.
+type FakeScaleHookControl struct {
+	// Reviews are the reviews the hooks are called with, in order
+	Reviews []ScaleHookReview
+	// Err is returned by the calls if it is set
+	Err error
+}
+
+// NewFakeScaleHookControl returns a FakeScaleHookControl instance
+func NewFakeScaleHookControl() *FakeScaleHookControl {
+	return &FakeScaleHookControl{}
+}
+
+func (c *FakeScaleHookControl) CallScaleHook(_ *v1alpha1.TidbCluster, _ *v1alpha1.HTTPScaleHook, review *ScaleHookReview) error {
+	c.Reviews = append(c.Reviews, *review)
+	return c.Err
+}
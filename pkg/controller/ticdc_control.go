@@ -14,10 +14,14 @@
 package controller
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	httputil "github.com/pingcap/tidb-operator/pkg/util/http"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -31,6 +35,20 @@ type CaptureStatus struct {
 type TiCDCControlInterface interface {
 	// GetStatus returns ticdc's status
 	GetStatus(tc *v1alpha1.TidbCluster, ordinal int32) (*CaptureStatus, error)
+	// DrainCapture moves the tables away from the capture and returns the
+	// number of tables remaining on it
+	DrainCapture(tc *v1alpha1.TidbCluster, ordinal int32) (tableCount int, err error)
+	// ResignOwner makes the capture resign the owner if it is the owner, it
+	// returns true if the capture is not the owner
+	ResignOwner(tc *v1alpha1.TidbCluster, ordinal int32) (ok bool, err error)
+}
+
+type drainCaptureRequest struct {
+	CaptureID string `json:"capture_id"`
+}
+
+type drainCaptureResponse struct {
+	CurrentTableCount int `json:"current_table_count"`
 }
 
 // defaultTiCDCControl is default implementation of TiCDCControlInterface.
@@ -63,6 +81,91 @@ func (c *defaultTiCDCControl) GetStatus(tc *v1alpha1.TidbCluster, ordinal int32)
 	return &status, err
 }
 
+func (c *defaultTiCDCControl) DrainCapture(tc *v1alpha1.TidbCluster, ordinal int32) (int, error) {
+	httpClient, err := c.getHTTPClient(tc)
+	if err != nil {
+		return 0, err
+	}
+	status, err := c.GetStatus(tc, ordinal)
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := json.Marshal(drainCaptureRequest{CaptureID: status.ID})
+	if err != nil {
+		return 0, err
+	}
+	baseURL := c.getBaseURL(tc, ordinal)
+	url := fmt.Sprintf("%s/api/v1/captures/drain", baseURL)
+	body, notFound, err := sendRequest(httpClient, "PUT", url, data)
+	if notFound {
+		// the version of TiCDC does not support draining the capture
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	resp := drainCaptureResponse{}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0, err
+	}
+	return resp.CurrentTableCount, nil
+}
+
+func (c *defaultTiCDCControl) ResignOwner(tc *v1alpha1.TidbCluster, ordinal int32) (bool, error) {
+	httpClient, err := c.getHTTPClient(tc)
+	if err != nil {
+		return false, err
+	}
+	status, err := c.GetStatus(tc, ordinal)
+	if err != nil {
+		return false, err
+	}
+	if !status.IsOwner {
+		return true, nil
+	}
+
+	baseURL := c.getBaseURL(tc, ordinal)
+	url := fmt.Sprintf("%s/api/v1/owner/resign", baseURL)
+	_, notFound, err := sendRequest(httpClient, "POST", url, nil)
+	if notFound {
+		// the version of TiCDC does not support resigning the owner
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	// the ownership is moved to another capture asynchronously
+	return false, nil
+}
+
+// sendRequest sends the request with the body and returns the response body,
+// notFound is true if the API is not found.
+func sendRequest(httpClient *http.Client, method, apiURL string, data []byte) (body []byte, notFound bool, err error) {
+	req, err := http.NewRequest(method, apiURL, bytes.NewReader(data))
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer httputil.DeferClose(res.Body)
+	body, err = ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return nil, true, fmt.Errorf("Error response %s:%v URL %s", string(body), res.StatusCode, apiURL)
+	}
+	if res.StatusCode >= 400 {
+		return nil, false, fmt.Errorf("Error response %s:%v URL %s", string(body), res.StatusCode, apiURL)
+	}
+	return body, false, nil
+}
+
 func (c *defaultTiCDCControl) getBaseURL(tc *v1alpha1.TidbCluster, ordinal int32) string {
 	if c.testURL != "" {
 		return c.testURL
@@ -78,7 +181,9 @@ func (c *defaultTiCDCControl) getBaseURL(tc *v1alpha1.TidbCluster, ordinal int32
 
 // FakeTiCDCControl is a fake implementation of TiCDCControlInterface.
 type FakeTiCDCControl struct {
-	status *CaptureStatus
+	status     *CaptureStatus
+	tableCount int
+	drainErr   error
 }
 
 // NewFakeTiCDCControl returns a FakeTiCDCControl instance
@@ -90,3 +195,31 @@ func NewFakeTiCDCControl() *FakeTiCDCControl {
 func (c *FakeTiCDCControl) SetStatus(status *CaptureStatus) {
 	c.status = status
 }
+
+// GetStatus returns the status set by SetStatus
+func (c *FakeTiCDCControl) GetStatus(tc *v1alpha1.TidbCluster, ordinal int32) (*CaptureStatus, error) {
+	if c.status == nil {
+		return nil, fmt.Errorf("status of ticdc %d is not set", ordinal)
+	}
+	return c.status, nil
+}
+
+// SetDrainCapture sets the result of DrainCapture
+func (c *FakeTiCDCControl) SetDrainCapture(tableCount int, err error) {
+	c.tableCount = tableCount
+	c.drainErr = err
+}
+
+// DrainCapture returns the result set by SetDrainCapture
+func (c *FakeTiCDCControl) DrainCapture(tc *v1alpha1.TidbCluster, ordinal int32) (int, error) {
+	return c.tableCount, c.drainErr
+}
+
+// ResignOwner resigns the owner of the status set by SetStatus
+func (c *FakeTiCDCControl) ResignOwner(tc *v1alpha1.TidbCluster, ordinal int32) (bool, error) {
+	if c.status == nil || !c.status.IsOwner {
+		return true, nil
+	}
+	c.status.IsOwner = false
+	return false, nil
+}
@@ -0,0 +1,162 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestTiCDCDrainCapture(t *testing.T) {
+	cases := []struct {
+		name        string
+		drainStatus int
+		tableCount  int
+		errExpected bool
+	}{
+		{
+			name:        "tables are moving",
+			drainStatus: http.StatusAccepted,
+			tableCount:  3,
+		},
+		{
+			name:        "the capture is drained",
+			drainStatus: http.StatusAccepted,
+			tableCount:  0,
+		},
+		{
+			name:        "draining is not supported",
+			drainStatus: http.StatusNotFound,
+			tableCount:  0,
+		},
+		{
+			name:        "draining fails",
+			drainStatus: http.StatusServiceUnavailable,
+			errExpected: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+				w.Header().Set("Content-Type", ContentTypeJSON)
+				switch request.URL.Path {
+				case "/status":
+					data, _ := json.Marshal(CaptureStatus{ID: "capture-1", IsOwner: false})
+					w.Write(data)
+				case "/api/v1/captures/drain":
+					g.Expect(request.Method).To(Equal("PUT"))
+					body, err := ioutil.ReadAll(request.Body)
+					g.Expect(err).NotTo(HaveOccurred())
+					g.Expect(string(body)).To(Equal(`{"capture_id":"capture-1"}`))
+					w.WriteHeader(c.drainStatus)
+					data, _ := json.Marshal(drainCaptureResponse{CurrentTableCount: c.tableCount})
+					w.Write(data)
+				default:
+					t.Errorf("unexpected request %s %s", request.Method, request.URL.Path)
+				}
+			})
+			defer svc.Close()
+
+			control := NewDefaultTiCDCControl(&fake.Clientset{})
+			control.testURL = svc.URL
+			tableCount, err := control.DrainCapture(getTidbCluster(), 0)
+			if c.errExpected {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(tableCount).To(Equal(c.tableCount))
+		})
+	}
+}
+
+func TestTiCDCResignOwner(t *testing.T) {
+	cases := []struct {
+		name         string
+		isOwner      bool
+		resignStatus int
+		resigned     bool
+		ok           bool
+		errExpected  bool
+	}{
+		{
+			name:    "the capture is not the owner",
+			isOwner: false,
+			ok:      true,
+		},
+		{
+			name:         "the owner resigns",
+			isOwner:      true,
+			resignStatus: http.StatusAccepted,
+			resigned:     true,
+			ok:           false,
+		},
+		{
+			name:         "resigning is not supported",
+			isOwner:      true,
+			resignStatus: http.StatusNotFound,
+			resigned:     true,
+			ok:           true,
+		},
+		{
+			name:         "resigning fails",
+			isOwner:      true,
+			resignStatus: http.StatusInternalServerError,
+			resigned:     true,
+			errExpected:  true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			resigned := false
+			svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+				w.Header().Set("Content-Type", ContentTypeJSON)
+				switch request.URL.Path {
+				case "/status":
+					data, _ := json.Marshal(CaptureStatus{ID: "capture-1", IsOwner: c.isOwner})
+					w.Write(data)
+				case "/api/v1/owner/resign":
+					g.Expect(request.Method).To(Equal("POST"))
+					resigned = true
+					w.WriteHeader(c.resignStatus)
+				default:
+					t.Errorf("unexpected request %s %s", request.Method, request.URL.Path)
+				}
+			})
+			defer svc.Close()
+
+			control := NewDefaultTiCDCControl(&fake.Clientset{})
+			control.testURL = svc.URL
+			ok, err := control.ResignOwner(getTidbCluster(), 0)
+			g.Expect(resigned).To(Equal(c.resigned))
+			if c.errExpected {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(ok).To(Equal(c.ok))
+		})
+	}
+}
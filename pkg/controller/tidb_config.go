@@ -0,0 +1,62 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+// configNameRegexp matches the dotted names of the config items, e.g.
+// raftstore.raft-log-gc-threshold
+var configNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+func (c *defaultTiDBControl) SetConfig(tc *v1alpha1.TidbCluster, component, name string, value interface{}) error {
+	literal, err := configLiteral(component, name, value)
+	if err != nil {
+		return err
+	}
+	db, err := c.openDB(tc)
+	if err != nil {
+		return err
+	}
+
+	// the component, name and value are validated, so they are safe to be put in the statement
+	_, err = db.Exec(fmt.Sprintf("SET CONFIG %s `%s` = %s", component, name, literal))
+	return err
+}
+
+// configLiteral validates the config item and returns its value as a SQL literal
+func configLiteral(component, name string, value interface{}) (string, error) {
+	if component != "pd" && component != "tikv" {
+		return "", fmt.Errorf("config of %s can't be set online", component)
+	}
+	if !configNameRegexp.MatchString(name) {
+		return "", fmt.Errorf("config item %s must consist of lower case letters, digits, dots, dashes or underscores", name)
+	}
+	switch v := value.(type) {
+	case string:
+		if strings.ContainsAny(v, "'\\\n") {
+			return "", fmt.Errorf("config item %s must not contain quotes, backslashes or newlines", name)
+		}
+		return fmt.Sprintf("'%s'", v), nil
+	case bool, int64, float64:
+		return fmt.Sprintf("%v", v), nil
+	default:
+		return "", fmt.Errorf("config item %s of type %T can't be set online", name, value)
+	}
+}
@@ -18,12 +18,15 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	httputil "github.com/pingcap/tidb-operator/pkg/util/http"
 	"github.com/pingcap/tidb/config"
 	"k8s.io/client-go/kubernetes"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
 )
 
 const (
@@ -37,28 +40,105 @@ type DBInfo struct {
 	IsOwner bool `json:"is_owner"`
 }
 
+// tidbStatus is the response of the status API of TiDB
+type tidbStatus struct {
+	Connections int `json:"connections"`
+}
+
 // TiDBControlInterface is the interface that knows how to manage tidb peers
 type TiDBControlInterface interface {
 	// GetHealth returns tidb's health info
 	GetHealth(tc *v1alpha1.TidbCluster, ordinal int32) (bool, error)
+	// GetConnections returns the number of the client connections to the tidb,
+	// the connections of the user in spec.tidb.sqlSecretName are not counted
+	GetConnections(tc *v1alpha1.TidbCluster, ordinal int32) (int, error)
 	// Get TIDB info return tidb's DBInfo
 	GetInfo(tc *v1alpha1.TidbCluster, ordinal int32) (*DBInfo, error)
 	// GetSettings return the TiDB instance settings
 	GetSettings(tc *v1alpha1.TidbCluster, ordinal int32) (*config.Config, error)
+	// GetPlacementPolicy returns the options of the placement policy, or nil if it does not exist
+	GetPlacementPolicy(tc *v1alpha1.TidbCluster, name string) (map[string]string, error)
+	// SetPlacementPolicy creates the placement policy, or alters it if it exists
+	SetPlacementPolicy(tc *v1alpha1.TidbCluster, policy *v1alpha1.PlacementPolicy, exists bool) error
+	// GetTiFlashReplica returns the TiFlash replica of the table, or nil if the table does not exist
+	GetTiFlashReplica(tc *v1alpha1.TidbCluster, database, table string) (*TiFlashReplica, error)
+	// SetTiFlashReplica sets the number of TiFlash replicas of the table
+	SetTiFlashReplica(tc *v1alpha1.TidbCluster, database, table string, replicas int32) error
+	// GetGCLifeTime returns the tidb_gc_life_time of the cluster
+	GetGCLifeTime(tc *v1alpha1.TidbCluster) (time.Duration, error)
+	// SetGCLifeTime sets the tidb_gc_life_time of the cluster
+	SetGCLifeTime(tc *v1alpha1.TidbCluster, lifeTime time.Duration) error
+	// GetSystemVariable returns the value of the global system variable
+	GetSystemVariable(tc *v1alpha1.TidbCluster, name string) (string, error)
+	// SetSystemVariable sets the value of the global system variable
+	SetSystemVariable(tc *v1alpha1.TidbCluster, name, value string) error
+	// SetConfig sets the config item of all the PD or TiKV instances online
+	SetConfig(tc *v1alpha1.TidbCluster, component, name string, value interface{}) error
+	// ProbeSQL runs the SQL statement on the tidb of the ordinal
+	ProbeSQL(tc *v1alpha1.TidbCluster, ordinal int32, query string) error
+	// ProbeHTTP GETs the path on the status port of the tidb of the ordinal
+	ProbeHTTP(tc *v1alpha1.TidbCluster, ordinal int32, path string) error
+	// ForgetCluster closes and drops the connections kept to the deleted cluster
+	ForgetCluster(namespace, tcName string)
 }
 
 // defaultTiDBControl is default implementation of TiDBControlInterface.
 type defaultTiDBControl struct {
 	httpClient
+	// secretLister gets the Secrets of the SQL users and the TLS clients, the
+	// Secrets are got from the API server if it is nil
+	secretLister corelisterv1.SecretLister
+	// pool limits the connections of the SQL connection pools, the defaults
+	// of database/sql are used if it is nil
+	pool *TiDBClientPoolConfig
+
+	// dbs are the SQL connection pools by the TiDB address
+	dbMutex sync.Mutex
+	dbs     map[string]*sqlDB
+	// sqlTLSKeys are the keys of the registered TLS configs of the MySQL driver
+	sqlTLSKeys map[string]bool
 	// for unit test only
 	testURL string
 }
 
+// TiDBClientPoolConfig limits the connections the operator keeps to each TiDB instance
+type TiDBClientPoolConfig struct {
+	// MaxOpenConns is the max number of connections to a TiDB instance, 0 means no limit
+	MaxOpenConns int
+	// MaxIdleConns is the max number of idle connections kept to a TiDB instance
+	MaxIdleConns int
+	// IdleConnTimeout is how long an idle HTTP connection is kept before it is
+	// closed. It is not a lifetime, the connections in use are never closed by it.
+	IdleConnTimeout time.Duration
+	// MaxConnLifetime is the max time a SQL connection is reused, 0 means the
+	// connections are reused forever. The HTTP connections have no lifetime,
+	// they are closed once they are idle for IdleConnTimeout.
+	MaxConnLifetime time.Duration
+}
+
 // NewDefaultTiDBControl returns a defaultTiDBControl instance
 func NewDefaultTiDBControl(kubeCli kubernetes.Interface) *defaultTiDBControl {
 	return &defaultTiDBControl{httpClient: httpClient{kubeCli: kubeCli}}
 }
 
+// NewDefaultTiDBControlWithPool returns a defaultTiDBControl instance whose
+// connections to the TiDB instances are limited by the pool config
+func NewDefaultTiDBControlWithPool(kubeCli kubernetes.Interface, secretLister corelisterv1.SecretLister, pool TiDBClientPoolConfig) *defaultTiDBControl {
+	return &defaultTiDBControl{
+		httpClient:   httpClient{kubeCli: kubeCli, transport: newPooledTransport(pool)},
+		secretLister: secretLister,
+		pool:         &pool,
+	}
+}
+
+func newPooledTransport(pool TiDBClientPoolConfig) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxConnsPerHost = pool.MaxOpenConns
+	transport.MaxIdleConnsPerHost = pool.MaxIdleConns
+	transport.IdleConnTimeout = pool.IdleConnTimeout
+	return transport
+}
+
 func (c *defaultTiDBControl) GetHealth(tc *v1alpha1.TidbCluster, ordinal int32) (bool, error) {
 	httpClient, err := c.getHTTPClient(tc)
 	if err != nil {
@@ -71,6 +151,31 @@ func (c *defaultTiDBControl) GetHealth(tc *v1alpha1.TidbCluster, ordinal int32)
 	return err == nil, nil
 }
 
+func (c *defaultTiDBControl) GetConnections(tc *v1alpha1.TidbCluster, ordinal int32) (int, error) {
+	if tc.Spec.TiDB != nil && tc.Spec.TiDB.SQLSecretName != "" {
+		// the operator keeps its own connections to TiDB with the user, which
+		// are counted by the status API
+		return c.getClientConnections(tc, ordinal)
+	}
+
+	httpClient, err := c.getHTTPClient(tc)
+	if err != nil {
+		return 0, err
+	}
+
+	baseURL := c.getBaseURL(tc, ordinal)
+	url := fmt.Sprintf("%s/status", baseURL)
+	body, err := getBodyOK(httpClient, url)
+	if err != nil {
+		return 0, err
+	}
+	status := tidbStatus{}
+	if err := json.Unmarshal(body, &status); err != nil {
+		return 0, err
+	}
+	return status.Connections, nil
+}
+
 func (c *defaultTiDBControl) GetInfo(tc *v1alpha1.TidbCluster, ordinal int32) (*DBInfo, error) {
 	httpClient, err := c.getHTTPClient(tc)
 	if err != nil {
@@ -168,12 +273,48 @@ func (c *defaultTiDBControl) getBaseURL(tc *v1alpha1.TidbCluster, ordinal int32)
 	return fmt.Sprintf("%s://%s.%s.%s:10080", scheme, hostName, TiDBPeerMemberName(tcName), ns)
 }
 
+func (c *defaultTiDBControl) ForgetCluster(namespace, tcName string) {
+	c.forgetTLSTransport(fmt.Sprintf("%s/%s", namespace, tcName))
+	c.forgetDBs(namespace, tcName)
+}
+
 // FakeTiDBControl is a fake implementation of TiDBControlInterface.
 type FakeTiDBControl struct {
 	healthInfo   map[string]bool
 	tiDBInfo     *DBInfo
 	getInfoError error
 	tidbConfig   *config.Config
+	// PlacementPolicies are the options of the placement policies in TiDB
+	PlacementPolicies map[string]map[string]string
+	// PlacementPolicySets counts the placement policies created or altered
+	PlacementPolicySets int
+	// SQLError is returned by the SQL statements managing the placement
+	// policies, the TiFlash replicas and the system variables
+	SQLError error
+	// TiFlashReplicas are the TiFlash replicas of the tables in TiDB keyed by
+	// `database.table`, the tables not in it do not exist
+	TiFlashReplicas map[string]*TiFlashReplica
+	// TiFlashReplicaSets counts the TiFlash replicas set
+	TiFlashReplicaSets int
+	// GCLifeTime is the tidb_gc_life_time of the cluster
+	GCLifeTime time.Duration
+	// SystemVariables are the global system variables of the cluster keyed
+	// by the variable name in lower case
+	SystemVariables map[string]string
+	// SystemVariableSets counts the global system variables set
+	SystemVariableSets int
+	// Configs are the config items set online keyed by the component and
+	// the name, e.g. tikv.raftstore.raft-log-gc-threshold
+	Configs map[string]interface{}
+	// Connections are the numbers of the client connections to the TiDB
+	// Pods keyed by the Pod name
+	Connections map[string]int
+	// ProbeError is returned by the SQL and HTTP probes
+	ProbeError error
+	// Probes are the SQL statements and HTTP paths probed
+	Probes []string
+	// ForgottenClusters are the clusters forgotten keyed by namespace/name
+	ForgottenClusters []string
 }
 
 // NewFakeTiDBControl returns a FakeTiDBControl instance
@@ -197,6 +338,11 @@ func (c *FakeTiDBControl) GetHealth(tc *v1alpha1.TidbCluster, ordinal int32) (bo
 	return false, nil
 }
 
+func (c *FakeTiDBControl) GetConnections(tc *v1alpha1.TidbCluster, ordinal int32) (int, error) {
+	podName := fmt.Sprintf("%s-%d", TiDBMemberName(tc.GetName()), ordinal)
+	return c.Connections[podName], nil
+}
+
 func (c *FakeTiDBControl) GetInfo(tc *v1alpha1.TidbCluster, ordinal int32) (*DBInfo, error) {
 	return c.tiDBInfo, c.getInfoError
 }
@@ -204,3 +350,97 @@ func (c *FakeTiDBControl) GetInfo(tc *v1alpha1.TidbCluster, ordinal int32) (*DBI
 func (c *FakeTiDBControl) GetSettings(tc *v1alpha1.TidbCluster, ordinal int32) (*config.Config, error) {
 	return c.tidbConfig, c.getInfoError
 }
+
+func (c *FakeTiDBControl) GetPlacementPolicy(tc *v1alpha1.TidbCluster, name string) (map[string]string, error) {
+	if c.SQLError != nil {
+		return nil, c.SQLError
+	}
+	return c.PlacementPolicies[strings.ToLower(name)], nil
+}
+
+func (c *FakeTiDBControl) SetPlacementPolicy(tc *v1alpha1.TidbCluster, policy *v1alpha1.PlacementPolicy, exists bool) error {
+	if c.SQLError != nil {
+		return c.SQLError
+	}
+	if c.PlacementPolicies == nil {
+		c.PlacementPolicies = map[string]map[string]string{}
+	}
+	c.PlacementPolicies[strings.ToLower(policy.Name)] = policy.NormalizedOptions()
+	c.PlacementPolicySets++
+	return nil
+}
+
+func (c *FakeTiDBControl) GetTiFlashReplica(tc *v1alpha1.TidbCluster, database, table string) (*TiFlashReplica, error) {
+	if c.SQLError != nil {
+		return nil, c.SQLError
+	}
+	return c.TiFlashReplicas[database+"."+table], nil
+}
+
+func (c *FakeTiDBControl) SetTiFlashReplica(tc *v1alpha1.TidbCluster, database, table string, replicas int32) error {
+	if c.SQLError != nil {
+		return c.SQLError
+	}
+	replica, ok := c.TiFlashReplicas[database+"."+table]
+	if !ok {
+		return fmt.Errorf("table %s.%s doesn't exist", database, table)
+	}
+	replica.Count = replicas
+	replica.Available = false
+	replica.Progress = 0
+	c.TiFlashReplicaSets++
+	return nil
+}
+
+func (c *FakeTiDBControl) GetGCLifeTime(tc *v1alpha1.TidbCluster) (time.Duration, error) {
+	return c.GCLifeTime, nil
+}
+
+func (c *FakeTiDBControl) SetGCLifeTime(tc *v1alpha1.TidbCluster, lifeTime time.Duration) error {
+	c.GCLifeTime = lifeTime
+	return nil
+}
+
+func (c *FakeTiDBControl) GetSystemVariable(tc *v1alpha1.TidbCluster, name string) (string, error) {
+	if c.SQLError != nil {
+		return "", c.SQLError
+	}
+	return c.SystemVariables[strings.ToLower(name)], nil
+}
+
+func (c *FakeTiDBControl) SetSystemVariable(tc *v1alpha1.TidbCluster, name, value string) error {
+	if c.SQLError != nil {
+		return c.SQLError
+	}
+	if c.SystemVariables == nil {
+		c.SystemVariables = map[string]string{}
+	}
+	c.SystemVariables[strings.ToLower(name)] = value
+	c.SystemVariableSets++
+	return nil
+}
+
+func (c *FakeTiDBControl) SetConfig(tc *v1alpha1.TidbCluster, component, name string, value interface{}) error {
+	if _, err := configLiteral(component, name, value); err != nil {
+		return err
+	}
+	if c.Configs == nil {
+		c.Configs = map[string]interface{}{}
+	}
+	c.Configs[component+"."+name] = value
+	return nil
+}
+
+func (c *FakeTiDBControl) ProbeSQL(tc *v1alpha1.TidbCluster, ordinal int32, query string) error {
+	c.Probes = append(c.Probes, query)
+	return c.ProbeError
+}
+
+func (c *FakeTiDBControl) ProbeHTTP(tc *v1alpha1.TidbCluster, ordinal int32, path string) error {
+	c.Probes = append(c.Probes, path)
+	return c.ProbeError
+}
+
+func (c *FakeTiDBControl) ForgetCluster(namespace, tcName string) {
+	c.ForgottenClusters = append(c.ForgottenClusters, namespace+"/"+tcName)
+}
@@ -18,16 +18,21 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/util"
 	"github.com/pingcap/tidb/config"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/fake"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
 	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
 )
 
 const (
@@ -141,6 +146,25 @@ func TestHealth(t *testing.T) {
 	}
 }
 
+func TestGetConnections(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+		g.Expect(request.Method).To(Equal("GET"), "check method")
+		g.Expect(request.URL.Path).To(Equal("/status"), "check url")
+
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		w.Write([]byte(`{"connections":3,"version":"5.7.25-TiDB-v5.2.0","git_hash":"abc"}`))
+	})
+	defer svc.Close()
+
+	control := NewDefaultTiDBControl(&fake.Clientset{})
+	control.testURL = svc.URL
+	connections, err := control.GetConnections(getTidbCluster(), 0)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(connections).To(Equal(3))
+}
+
 func TestInfo(t *testing.T) {
 	g := NewGomegaWithT(t)
 
@@ -305,6 +329,163 @@ func TestGetHTTPClient(t *testing.T) {
 	}
 }
 
+func TestNewDefaultTiDBControlWithPool(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pool := TiDBClientPoolConfig{
+		MaxOpenConns:    3,
+		MaxIdleConns:    1,
+		IdleConnTimeout: 30 * time.Second,
+	}
+	control := NewDefaultTiDBControlWithPool(&fake.Clientset{}, nil, pool)
+	transport, ok := control.transport.(*http.Transport)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(transport.MaxConnsPerHost).To(Equal(3))
+	g.Expect(transport.MaxIdleConnsPerHost).To(Equal(1))
+	g.Expect(transport.IdleConnTimeout).To(Equal(30 * time.Second))
+
+	// the pool is shared by the requests to the clusters without TLS
+	httpClient, err := control.getHTTPClient(getTidbCluster())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(httpClient.Transport).To(BeIdenticalTo(control.transport))
+
+	// the pool is not used by default
+	control = NewDefaultTiDBControl(&fake.Clientset{})
+	g.Expect(control.transport).To(BeNil())
+}
+
+func TestGetHTTPClientWithPoolAndTLS(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	resourceVersion := "1"
+	fakeClient := &fake.Clientset{}
+	fakeClient.AddReactor("get", "secrets", func(action core.Action) (bool, runtime.Object, error) {
+		return true, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "name", Namespace: "ns", ResourceVersion: resourceVersion},
+			Data: map[string][]byte{
+				corev1.TLSCertKey:              []byte(certData),
+				corev1.TLSPrivateKeyKey:        []byte(keyData),
+				corev1.ServiceAccountRootCAKey: []byte(caData),
+			},
+		}, nil
+	})
+	control := NewDefaultTiDBControlWithPool(fakeClient, nil, TiDBClientPoolConfig{MaxOpenConns: 3, MaxIdleConns: 1, IdleConnTimeout: 30 * time.Second})
+	tc := getTidbCluster()
+	tc.Spec.TLSCluster = &v1alpha1.TLSCluster{Enabled: true}
+
+	// the transport of the cluster with TLS is pooled with the same limits
+	httpClient, err := control.getHTTPClient(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	transport, ok := httpClient.Transport.(*http.Transport)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(transport).NotTo(BeIdenticalTo(control.transport))
+	g.Expect(transport.TLSClientConfig).NotTo(BeNil())
+	g.Expect(transport.DisableKeepAlives).To(BeFalse())
+	g.Expect(transport.MaxConnsPerHost).To(Equal(3))
+	g.Expect(transport.MaxIdleConnsPerHost).To(Equal(1))
+	g.Expect(transport.IdleConnTimeout).To(Equal(30 * time.Second))
+
+	// the transport is reused
+	again, err := control.getHTTPClient(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(again.Transport).To(BeIdenticalTo(transport))
+
+	// the transport is replaced once the Secret changes
+	resourceVersion = "2"
+	rotated, err := control.getHTTPClient(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rotated.Transport).NotTo(BeIdenticalTo(transport))
+	g.Expect(control.tlsTransports).To(HaveLen(1))
+
+	// the transport is dropped once the cluster is deleted
+	control.ForgetCluster(tc.Namespace, tc.Name)
+	g.Expect(control.tlsTransports).To(BeEmpty())
+}
+
+func TestOpenDBAt(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := getTidbCluster()
+	tc.Spec.TiDB.SQLSecretName = "sql-secret"
+	tc.Spec.TiDB.TLSClient = &v1alpha1.TiDBTLSClient{Enabled: true}
+	sqlSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sql-secret", Namespace: tc.Namespace, ResourceVersion: "1"},
+		Data:       map[string][]byte{"user": []byte("operator"), "password": []byte("pass1")},
+	}
+	tlsSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: util.TiDBClientTLSSecretName(tc.Name), Namespace: tc.Namespace, ResourceVersion: "1"},
+		Data: map[string][]byte{
+			corev1.TLSCertKey:              []byte(certData),
+			corev1.TLSPrivateKeyKey:        []byte(keyData),
+			corev1.ServiceAccountRootCAKey: []byte(caData),
+		},
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	g.Expect(indexer.Add(sqlSecret)).To(Succeed())
+	g.Expect(indexer.Add(tlsSecret)).To(Succeed())
+
+	// the Secrets are got from the lister only
+	control := NewDefaultTiDBControlWithPool(&fake.Clientset{}, corelisterv1.NewSecretLister(indexer), TiDBClientPoolConfig{MaxOpenConns: 3, MaxIdleConns: 1})
+	db, err := control.openDB(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(db.Stats().MaxOpenConnections).To(Equal(3))
+	cached := control.dbs["default/demo/demo-tidb.default:4000"]
+	g.Expect(cached).NotTo(BeNil())
+	g.Expect(cached.tlsKey).To(Equal("tidb-operator.default.demo.1"))
+	g.Expect(cached.dsn).To(ContainSubstring("operator:pass1@tcp(demo-tidb.default:4000)"))
+	g.Expect(cached.dsn).To(ContainSubstring("tls=tidb-operator.default.demo.1"))
+
+	// the pool is reused
+	again, err := control.openDB(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(again).To(BeIdenticalTo(db))
+
+	// the pool of each pod is kept separately
+	podDB, err := control.openPodDB(tc, 1)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(podDB).NotTo(BeIdenticalTo(db))
+	g.Expect(control.dbs).To(HaveKey("default/demo/demo-tidb-1.demo-tidb-peer.default:4000"))
+
+	// the pool is reopened after the password and the certificates are rotated
+	sqlSecret = sqlSecret.DeepCopy()
+	sqlSecret.Data["password"] = []byte("pass2")
+	g.Expect(indexer.Update(sqlSecret)).To(Succeed())
+	tlsSecret = tlsSecret.DeepCopy()
+	tlsSecret.ResourceVersion = "2"
+	g.Expect(indexer.Update(tlsSecret)).To(Succeed())
+	reopened, err := control.openDB(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(reopened).NotTo(BeIdenticalTo(db))
+	cached = control.dbs["default/demo/demo-tidb.default:4000"]
+	g.Expect(cached.dsn).To(ContainSubstring("operator:pass2@"))
+	g.Expect(cached.tlsKey).To(Equal("tidb-operator.default.demo.2"))
+
+	// TLS is not used if it is skipped by the annotation
+	tc.Annotations = map[string]string{label.AnnSkipTLSWhenConnectTiDB: "true"}
+	_, err = control.openDB(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(control.dbs["default/demo/demo-tidb.default:4000"].tlsKey).To(BeEmpty())
+
+	// the pools are closed once the cluster is deleted
+	tc.Annotations = nil
+	_, err = control.openDB(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(control.sqlTLSKeys).To(HaveKey("tidb-operator.default.demo.2"))
+	other := getTidbCluster()
+	other.Name = "other"
+	_, err = control.openDB(other)
+	g.Expect(err).NotTo(HaveOccurred())
+	control.ForgetCluster(tc.Namespace, tc.Name)
+	g.Expect(control.dbs).To(HaveLen(1))
+	g.Expect(control.dbs).To(HaveKey("default/other/other-tidb.default:4000"))
+	g.Expect(control.sqlTLSKeys).NotTo(HaveKey("tidb-operator.default.demo.2"))
+
+	// the missing Secret is an error
+	g.Expect(indexer.Delete(sqlSecret)).To(Succeed())
+	_, err = control.openDB(tc)
+	g.Expect(err).To(HaveOccurred())
+}
+
 func getTidbCluster() *v1alpha1.TidbCluster {
 	return &v1alpha1.TidbCluster{
 		TypeMeta: metav1.TypeMeta{
@@ -337,3 +518,57 @@ func fakeSecret(fakeClient *fake.Clientset) {
 		}, nil
 	})
 }
+
+func TestParsePlacementPolicyOptions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	options, err := parsePlacementPolicyOptions("CREATE PLACEMENT POLICY `p1` PRIMARY_REGION=\"us-east-1\" REGIONS=\"us-east-1,us-west-1\" FOLLOWERS=2")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(options).To(Equal(map[string]string{
+		"PRIMARY_REGION": "us-east-1",
+		"REGIONS":        "us-east-1,us-west-1",
+		"FOLLOWERS":      "2",
+	}))
+
+	options, err = parsePlacementPolicyOptions("CREATE PLACEMENT POLICY `ssd` CONSTRAINTS=\"{\\\"+disk=ssd\\\": 1}\"")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(options).To(Equal(map[string]string{"CONSTRAINTS": `{"+disk=ssd": 1}`}))
+
+	options, err = parsePlacementPolicyOptions("CREATE PLACEMENT POLICY `empty`")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(options).To(BeEmpty())
+
+	_, err = parsePlacementPolicyOptions("CREATE PLACEMENT POLICY p1")
+	g.Expect(err).To(HaveOccurred())
+	_, err = parsePlacementPolicyOptions("CREATE PLACEMENT POLICY `p1` REGIONS=\"us-east-1")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestConfigLiteral(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		component string
+		name      string
+		value     interface{}
+		literal   string
+		valid     bool
+	}{
+		{component: "tikv", name: "raftstore.raft-log-gc-threshold", value: int64(100), literal: "100", valid: true},
+		{component: "tikv", name: "rocksdb.writecf.block-cache-size", value: "2GB", literal: "'2GB'", valid: true},
+		{component: "pd", name: "schedule.enable-location-replacement", value: false, literal: "false", valid: true},
+		{component: "tidb", name: "oom-action", value: "log", valid: false},
+		{component: "tikv", name: "raftstore`", value: int64(1), valid: false},
+		{component: "tikv", name: "storage.reserve-space", value: "1GB'; DROP", valid: false},
+		{component: "tikv", name: "storage.labels", value: []interface{}{}, valid: false},
+	}
+	for _, test := range tests {
+		literal, err := configLiteral(test.component, test.name, test.value)
+		if !test.valid {
+			g.Expect(err).To(HaveOccurred(), "%s %s", test.component, test.name)
+			continue
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(literal).To(Equal(test.literal))
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+func (c *defaultTiDBControl) GetGCLifeTime(tc *v1alpha1.TidbCluster) (time.Duration, error) {
+	db, err := c.openDB(tc)
+	if err != nil {
+		return 0, err
+	}
+
+	var value string
+	if err := db.QueryRow("SELECT @@GLOBAL.tidb_gc_life_time").Scan(&value); err != nil {
+		return 0, err
+	}
+	return time.ParseDuration(value)
+}
+
+func (c *defaultTiDBControl) SetGCLifeTime(tc *v1alpha1.TidbCluster, lifeTime time.Duration) error {
+	db, err := c.openDB(tc)
+	if err != nil {
+		return err
+	}
+
+	// the duration is formatted by Go, so it is safe to be put in the statement
+	_, err = db.Exec(fmt.Sprintf("SET GLOBAL tidb_gc_life_time = '%s'", lifeTime))
+	return err
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+func (c *defaultTiDBControl) GetPlacementPolicy(tc *v1alpha1.TidbCluster, name string) (map[string]string, error) {
+	db, err := c.openDB(tc)
+	if err != nil {
+		return nil, err
+	}
+
+	var policyName, createStmt string
+	err = db.QueryRow(fmt.Sprintf("SHOW CREATE PLACEMENT POLICY `%s`", name)).Scan(&policyName, &createStmt)
+	if err != nil {
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == errPlacementPolicyNotExists {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return parsePlacementPolicyOptions(createStmt)
+}
+
+func (c *defaultTiDBControl) SetPlacementPolicy(tc *v1alpha1.TidbCluster, policy *v1alpha1.PlacementPolicy, exists bool) error {
+	db, err := c.openDB(tc)
+	if err != nil {
+		return err
+	}
+
+	verb := "CREATE"
+	if exists {
+		verb = "ALTER"
+	}
+	// the name and options are validated, so they are safe to be put in the statement
+	_, err = db.Exec(fmt.Sprintf("%s PLACEMENT POLICY `%s` %s", verb, policy.Name, policy.SQLOptions()))
+	return err
+}
+
+// errPlacementPolicyNotExists is the error code of TiDB when the placement policy does not exist
+const errPlacementPolicyNotExists = 8239
+
+// parsePlacementPolicyOptions parses the options from the statement returned
+// by SHOW CREATE PLACEMENT POLICY, e.g.
+// CREATE PLACEMENT POLICY `p1` PRIMARY_REGION="us-east-1" REGIONS="us-east-1,us-west-1" FOLLOWERS=2
+func parsePlacementPolicyOptions(stmt string) (map[string]string, error) {
+	rest := strings.TrimSpace(stmt)
+	// skip CREATE PLACEMENT POLICY [IF NOT EXISTS] `name`
+	start := strings.Index(rest, "`")
+	if start < 0 {
+		return nil, fmt.Errorf("unexpected placement policy statement %q", stmt)
+	}
+	end := strings.Index(rest[start+1:], "`")
+	if end < 0 {
+		return nil, fmt.Errorf("unexpected placement policy statement %q", stmt)
+	}
+	rest = rest[start+end+2:]
+
+	options := map[string]string{}
+	for {
+		rest = strings.TrimSpace(rest)
+		if rest == "" {
+			return options, nil
+		}
+		eq := strings.Index(rest, "=")
+		if eq <= 0 {
+			return nil, fmt.Errorf("unexpected placement option %q in statement %q", rest, stmt)
+		}
+		name := strings.ToUpper(strings.TrimSpace(rest[:eq]))
+		rest = strings.TrimSpace(rest[eq+1:])
+
+		var value strings.Builder
+		if strings.HasPrefix(rest, `"`) {
+			i := 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				value.WriteByte(rest[i])
+			}
+			if i >= len(rest) {
+				return nil, fmt.Errorf("unterminated placement option %s in statement %q", name, stmt)
+			}
+			rest = rest[i+1:]
+		} else {
+			i := strings.IndexAny(rest, " \t\n")
+			if i < 0 {
+				i = len(rest)
+			}
+			value.WriteString(rest[:i])
+			rest = rest[i:]
+		}
+		options[name] = value.String()
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+func (c *defaultTiDBControl) ProbeSQL(tc *v1alpha1.TidbCluster, ordinal int32, query string) error {
+	db, err := c.openPodDB(tc, ordinal)
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+	}
+	return rows.Err()
+}
+
+func (c *defaultTiDBControl) ProbeHTTP(tc *v1alpha1.TidbCluster, ordinal int32, path string) error {
+	httpClient, err := c.getHTTPClient(tc)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/%s", c.getBaseURL(tc, ordinal), strings.TrimPrefix(path, "/"))
+	_, err = getBodyOK(httpClient, url)
+	return err
+}
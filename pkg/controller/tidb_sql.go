@@ -0,0 +1,190 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/util"
+	"github.com/pingcap/tidb-operator/pkg/util/crypto"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// tidbSQLPort is the port of the MySQL protocol of TiDB
+	tidbSQLPort = 4000
+	// sqlSecretUserKey and sqlSecretPasswordKey are the keys of the user and
+	// password in the Secret of spec.tidb.sqlSecretName
+	sqlSecretUserKey     = "user"
+	sqlSecretPasswordKey = "password"
+)
+
+// sqlDB is the connection pool to a TiDB address, it is reopened once the DSN
+// changes, e.g. the password or the client certificates are rotated
+type sqlDB struct {
+	dsn    string
+	tlsKey string
+	db     *sql.DB
+}
+
+// openDB returns the connection pool to the TiDB Service with the user in
+// spec.tidb.sqlSecretName, the pool is shared and must not be closed
+func (c *defaultTiDBControl) openDB(tc *v1alpha1.TidbCluster) (*sql.DB, error) {
+	return c.openDBAt(tc, fmt.Sprintf("%s.%s:%d", TiDBMemberName(tc.GetName()), tc.GetNamespace(), tidbSQLPort))
+}
+
+// openPodDB returns the connection pool to the TiDB Pod of the ordinal with
+// the user in spec.tidb.sqlSecretName, the pool is shared and must not be closed
+func (c *defaultTiDBControl) openPodDB(tc *v1alpha1.TidbCluster, ordinal int32) (*sql.DB, error) {
+	tcName := tc.GetName()
+	return c.openDBAt(tc, fmt.Sprintf("%s-%d.%s.%s:%d", TiDBMemberName(tcName), ordinal, TiDBPeerMemberName(tcName), tc.GetNamespace(), tidbSQLPort))
+}
+
+func (c *defaultTiDBControl) openDBAt(tc *v1alpha1.TidbCluster, addr string) (*sql.DB, error) {
+	ns := tc.GetNamespace()
+	cfg := mysql.NewConfig()
+	cfg.User = "root"
+	if name := tc.Spec.TiDB.SQLSecretName; name != "" {
+		secret, err := c.getSecret(ns, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get secret %s/%s, error: %v", ns, name, err)
+		}
+		cfg.User = string(secret.Data[sqlSecretUserKey])
+		cfg.Passwd = string(secret.Data[sqlSecretPasswordKey])
+	}
+	cfg.Net = "tcp"
+	cfg.Addr = addr
+	cfg.Timeout = timeout
+	cfg.ReadTimeout = timeout
+	cfg.WriteTimeout = timeout
+	if tc.Spec.TiDB.IsTLSClientEnabled() && !tc.SkipTLSWhenConnectTiDB() {
+		tlsKey, err := c.registerSQLTLSConfig(tc)
+		if err != nil {
+			return nil, err
+		}
+		cfg.TLSConfig = tlsKey
+	}
+	dsn := cfg.FormatDSN()
+
+	c.dbMutex.Lock()
+	defer c.dbMutex.Unlock()
+	if c.dbs == nil {
+		c.dbs = map[string]*sqlDB{}
+	}
+	key := fmt.Sprintf("%s/%s/%s", ns, tc.GetName(), addr)
+	if cached, ok := c.dbs[key]; ok {
+		if cached.dsn == dsn {
+			return cached.db, nil
+		}
+		cached.db.Close()
+		if cached.tlsKey != "" && cached.tlsKey != cfg.TLSConfig {
+			mysql.DeregisterTLSConfig(cached.tlsKey)
+			delete(c.sqlTLSKeys, cached.tlsKey)
+		}
+		delete(c.dbs, key)
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if c.pool != nil {
+		db.SetMaxOpenConns(c.pool.MaxOpenConns)
+		db.SetMaxIdleConns(c.pool.MaxIdleConns)
+		db.SetConnMaxLifetime(c.pool.MaxConnLifetime)
+	}
+	c.dbs[key] = &sqlDB{dsn: dsn, tlsKey: cfg.TLSConfig, db: db}
+	return db, nil
+}
+
+// forgetDBs closes the connection pools to the deleted cluster and deregisters
+// their TLS configs
+func (c *defaultTiDBControl) forgetDBs(namespace, tcName string) {
+	c.dbMutex.Lock()
+	defer c.dbMutex.Unlock()
+	prefix := fmt.Sprintf("%s/%s/", namespace, tcName)
+	for key, cached := range c.dbs {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		cached.db.Close()
+		if cached.tlsKey != "" && c.sqlTLSKeys[cached.tlsKey] {
+			mysql.DeregisterTLSConfig(cached.tlsKey)
+			delete(c.sqlTLSKeys, cached.tlsKey)
+		}
+		delete(c.dbs, key)
+	}
+}
+
+// registerSQLTLSConfig registers the TLS config of the MySQL protocol built
+// from the TiDB client Secret of the cluster, and returns its key. The key
+// changes with the Secret, so that the connections are reopened once the
+// certificates are rotated.
+func (c *defaultTiDBControl) registerSQLTLSConfig(tc *v1alpha1.TidbCluster) (string, error) {
+	ns := tc.GetNamespace()
+	secretName := util.TiDBClientTLSSecretName(tc.GetName())
+	secret, err := c.getSecret(ns, secretName)
+	if err != nil {
+		return "", fmt.Errorf("failed to get secret %s/%s, error: %v", ns, secretName, err)
+	}
+	tlsKey := fmt.Sprintf("tidb-operator.%s.%s.%s", ns, tc.GetName(), secret.GetResourceVersion())
+	c.dbMutex.Lock()
+	defer c.dbMutex.Unlock()
+	if c.sqlTLSKeys[tlsKey] {
+		return tlsKey, nil
+	}
+
+	config, err := crypto.LoadTlsConfigFromSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	if err := mysql.RegisterTLSConfig(tlsKey, config); err != nil {
+		return "", err
+	}
+	if c.sqlTLSKeys == nil {
+		c.sqlTLSKeys = map[string]bool{}
+	}
+	c.sqlTLSKeys[tlsKey] = true
+	return tlsKey, nil
+}
+
+// getSecret gets the Secret from the lister if it is set, otherwise from the
+// API server
+func (c *defaultTiDBControl) getSecret(ns, name string) (*corev1.Secret, error) {
+	if c.secretLister != nil {
+		return c.secretLister.Secrets(ns).Get(name)
+	}
+	return c.kubeCli.CoreV1().Secrets(ns).Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+// getClientConnections returns the number of the connections to the TiDB Pod
+// of the ordinal except the ones of the user of the operator. The process
+// list of TiDB only has the connections to the instance queried.
+func (c *defaultTiDBControl) getClientConnections(tc *v1alpha1.TidbCluster, ordinal int32) (int, error) {
+	db, err := c.openPodDB(tc, ordinal)
+	if err != nil {
+		return 0, err
+	}
+	var connections int
+	row := db.QueryRow("SELECT COUNT(*) FROM INFORMATION_SCHEMA.PROCESSLIST WHERE USER <> SUBSTRING_INDEX(CURRENT_USER(), '@', 1)")
+	if err := row.Scan(&connections); err != nil {
+		return 0, err
+	}
+	return connections, nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+func (c *defaultTiDBControl) GetSystemVariable(tc *v1alpha1.TidbCluster, name string) (string, error) {
+	if err := v1alpha1.ValidateSystemVariable(name, ""); err != nil {
+		return "", err
+	}
+	db, err := c.openDB(tc)
+	if err != nil {
+		return "", err
+	}
+
+	var value string
+	if err := db.QueryRow(fmt.Sprintf("SELECT @@GLOBAL.%s", name)).Scan(&value); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+func (c *defaultTiDBControl) SetSystemVariable(tc *v1alpha1.TidbCluster, name, value string) error {
+	if err := v1alpha1.ValidateSystemVariable(name, value); err != nil {
+		return err
+	}
+	db, err := c.openDB(tc)
+	if err != nil {
+		return err
+	}
+
+	// the name and value are validated, so they are safe to be put in the statement
+	_, err = db.Exec(fmt.Sprintf("SET GLOBAL %s = '%s'", name, value))
+	return err
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+// TiFlashReplica is the TiFlash replica of a table in TiDB
+type TiFlashReplica struct {
+	// Count is the number of TiFlash replicas of the table, 0 means the table has no TiFlash replica
+	Count int32
+	// Available indicates whether the TiFlash replicas can serve queries
+	Available bool
+	// Progress is the replication progress, from 0 to 1
+	Progress float64
+}
+
+func (c *defaultTiDBControl) GetTiFlashReplica(tc *v1alpha1.TidbCluster, database, table string) (*TiFlashReplica, error) {
+	db, err := c.openDB(tc)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables int
+	err = db.QueryRow("SELECT COUNT(*) FROM information_schema.tables WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?", database, table).Scan(&tables)
+	if err != nil {
+		return nil, err
+	}
+	if tables == 0 {
+		return nil, nil
+	}
+
+	replica := &TiFlashReplica{}
+	err = db.QueryRow("SELECT REPLICA_COUNT, AVAILABLE, PROGRESS FROM information_schema.tiflash_replica WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?", database, table).
+		Scan(&replica.Count, &replica.Available, &replica.Progress)
+	if err == sql.ErrNoRows {
+		return &TiFlashReplica{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return replica, nil
+}
+
+func (c *defaultTiDBControl) SetTiFlashReplica(tc *v1alpha1.TidbCluster, database, table string, replicas int32) error {
+	db, err := c.openDB(tc)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s.%s SET TIFLASH REPLICA %d", quoteIdentifier(database), quoteIdentifier(table), replicas))
+	return err
+}
+
+// quoteIdentifier quotes the identifier with backticks to be put in a statement
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
@@ -15,6 +15,7 @@ package tidbcluster
 
 import (
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/manager/member"
 	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
@@ -33,6 +34,12 @@ var _ TidbClusterConditionUpdater = &tidbClusterConditionUpdater{}
 
 func (u *tidbClusterConditionUpdater) Update(tc *v1alpha1.TidbCluster) error {
 	u.updateReadyCondition(tc)
+	u.updateUpgradeBlockedCondition(tc)
+	// the operations waiting for the window go on once it's open, even if
+	// they are reverted before
+	if member.MaintenanceWindowOpen(tc) {
+		member.ResolveMaintenanceWindowCondition(tc)
+	}
 	// in the future, we may return error when we need to Kubernetes API, etc.
 	return nil
 }
@@ -79,3 +86,23 @@ func (u *tidbClusterConditionUpdater) updateReadyCondition(tc *v1alpha1.TidbClus
 	cond := utiltidbcluster.NewTidbClusterCondition(v1alpha1.TidbClusterReady, status, reason, message)
 	utiltidbcluster.SetTidbClusterCondition(&tc.Status, *cond)
 }
+
+// updateUpgradeBlockedCondition resolves the UpgradeBlocked condition once no
+// StatefulSet is upgrading, e.g. the blocked upgrade is reverted, as the
+// checks are only run before upgrading.
+func (u *tidbClusterConditionUpdater) updateUpgradeBlockedCondition(tc *v1alpha1.TidbCluster) {
+	cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterUpgradeBlocked)
+	if cond == nil || cond.Status != v1.ConditionTrue {
+		return
+	}
+	for _, status := range []*appsv1.StatefulSetStatus{tc.Status.TiCDC.StatefulSet, tc.Status.Pump.StatefulSet} {
+		if status != nil && status.CurrentRevision != status.UpdateRevision {
+			return
+		}
+	}
+	if !allStatefulSetsAreUpToDate(tc) {
+		return
+	}
+	utiltidbcluster.UpdateTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+		v1alpha1.TidbClusterUpgradeBlocked, v1.ConditionFalse, utiltidbcluster.UpgradeNotInProgress, "no upgrade is in progress"))
+}
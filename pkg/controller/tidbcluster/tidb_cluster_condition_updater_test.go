@@ -360,3 +360,37 @@ func TestTidbClusterConditionUpdater_Ready(t *testing.T) {
 		})
 	}
 }
+
+func TestTidbClusterConditionUpdater_UpgradeBlocked(t *testing.T) {
+	tests := []struct {
+		name       string
+		tikv       *appsv1.StatefulSetStatus
+		wantStatus v1.ConditionStatus
+	}{
+		{
+			name:       "the blocked upgrade is pending",
+			tikv:       &appsv1.StatefulSetStatus{CurrentRevision: "1", UpdateRevision: "2"},
+			wantStatus: v1.ConditionTrue,
+		},
+		{
+			name:       "the blocked upgrade is reverted",
+			tikv:       &appsv1.StatefulSetStatus{CurrentRevision: "1", UpdateRevision: "1"},
+			wantStatus: v1.ConditionFalse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := &v1alpha1.TidbCluster{}
+			tc.Status.TiKV.StatefulSet = tt.tikv
+			utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+				v1alpha1.TidbClusterUpgradeBlocked, v1.ConditionTrue, "UpgradeBlocked", "tikv upgrade can't start"))
+			conditionUpdater := &tidbClusterConditionUpdater{}
+			conditionUpdater.Update(tc)
+			cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterUpgradeBlocked)
+			if diff := cmp.Diff(tt.wantStatus, cond.Status); diff != "" {
+				t.Errorf("unexpected status (-want, +got): %s", diff)
+			}
+		})
+	}
+}
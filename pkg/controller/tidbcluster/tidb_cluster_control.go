@@ -14,6 +14,7 @@
 package tidbcluster
 
 import (
+	perrors "github.com/pingcap/errors"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1/defaulting"
 	v1alpha1validation "github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1/validation"
@@ -21,8 +22,10 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/manager"
 	"github.com/pingcap/tidb-operator/pkg/manager/member"
 	"github.com/pingcap/tidb-operator/pkg/metrics"
+	"github.com/pingcap/tidb-operator/pkg/tracing"
 	v1 "k8s.io/api/core/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	errorutils "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog"
@@ -45,6 +48,7 @@ func NewDefaultTidbClusterControl(
 	tidbMemberManager manager.Manager,
 	reclaimPolicyManager manager.Manager,
 	metaManager manager.Manager,
+	labelMigrationManager manager.Manager,
 	orphanPodsCleaner member.OrphanPodsCleaner,
 	pvcCleaner member.PVCCleanerInterface,
 	pvcResizer member.PVCResizerInterface,
@@ -62,6 +66,7 @@ func NewDefaultTidbClusterControl(
 		tidbMemberManager:        tidbMemberManager,
 		reclaimPolicyManager:     reclaimPolicyManager,
 		metaManager:              metaManager,
+		labelMigrationManager:    labelMigrationManager,
 		orphanPodsCleaner:        orphanPodsCleaner,
 		pvcCleaner:               pvcCleaner,
 		pvcResizer:               pvcResizer,
@@ -82,6 +87,7 @@ type defaultTidbClusterControl struct {
 	tidbMemberManager        manager.Manager
 	reclaimPolicyManager     manager.Manager
 	metaManager              manager.Manager
+	labelMigrationManager    manager.Manager
 	orphanPodsCleaner        member.OrphanPodsCleaner
 	pvcCleaner               member.PVCCleanerInterface
 	pvcResizer               member.PVCResizerInterface
@@ -101,12 +107,16 @@ func (c *defaultTidbClusterControl) UpdateTidbCluster(tc *v1alpha1.TidbCluster)
 		return nil // fatal error, no need to retry on invalid object
 	}
 
+	span := tracing.Start(tc.GetNamespace(), tc.GetName(), "reconcile")
 	var errs []error
+	defer func() { span.End(errorutils.NewAggregate(errs)) }()
 	oldStatus := tc.Status.DeepCopy()
 
-	if err := c.updateTidbCluster(tc); err != nil {
+	err := c.updateTidbCluster(tc)
+	if err != nil {
 		errs = append(errs, err)
 	}
+	recordSyncResult(tc, err)
 
 	if err := c.conditionUpdater.Update(tc); err != nil {
 		errs = append(errs, err)
@@ -122,6 +132,37 @@ func (c *defaultTidbClusterControl) UpdateTidbCluster(tc *v1alpha1.TidbCluster)
 	return errorutils.NewAggregate(errs)
 }
 
+// recordSyncResult tracks the consecutive failed syncs in the status, the
+// requeue errors are not counted as they are expected while waiting for the
+// cluster to converge. The failures of an older generation are not counted,
+// so the backoff starts over once the spec is changed.
+func recordSyncResult(tc *v1alpha1.TidbCluster, err error) {
+	if err == nil {
+		tc.Status.ConsecutiveSyncFailures = 0
+		tc.Status.LastSyncFailureTime = nil
+		tc.Status.LastSyncFailureGeneration = 0
+		return
+	}
+	if perrors.Find(err, controller.IsRequeueError) != nil {
+		return
+	}
+	if tc.Status.LastSyncFailureGeneration != tc.Generation {
+		tc.Status.ConsecutiveSyncFailures = 0
+	}
+	now := metav1.Now()
+	tc.Status.ConsecutiveSyncFailures++
+	tc.Status.LastSyncFailureTime = &now
+	tc.Status.LastSyncFailureGeneration = tc.Generation
+}
+
+// traceStep runs a step of the reconcile in a span named after it
+func traceStep(tc *v1alpha1.TidbCluster, step string, sync func(*v1alpha1.TidbCluster) error) error {
+	span := tracing.Start(tc.GetNamespace(), tc.GetName(), "sync "+step, tracing.Attribute{Key: tracing.ComponentKey, Value: step})
+	err := sync(tc)
+	span.End(err)
+	return err
+}
+
 func (c *defaultTidbClusterControl) validate(tc *v1alpha1.TidbCluster) bool {
 	errs := v1alpha1validation.ValidateTidbCluster(tc)
 	if len(errs) > 0 {
@@ -140,7 +181,13 @@ func (c *defaultTidbClusterControl) defaulting(tc *v1alpha1.TidbCluster) {
 func (c *defaultTidbClusterControl) updateTidbCluster(tc *v1alpha1.TidbCluster) error {
 	c.recordMetrics(tc)
 	// syncing all PVs managed by operator's reclaim policy to Retain
-	if err := c.reclaimPolicyManager.Sync(tc); err != nil {
+	if err := traceStep(tc, "reclaim-policy", c.reclaimPolicyManager.Sync); err != nil {
+		return err
+	}
+
+	// migrating the ownership labels of the pods and pvcs left by an older operator to the current scheme,
+	// so that they are still found by the label selectors in the following steps
+	if err := traceStep(tc, "label-migration", c.labelMigrationManager.Sync); err != nil {
 		return err
 	}
 
@@ -157,7 +204,9 @@ func (c *defaultTidbClusterControl) updateTidbCluster(tc *v1alpha1.TidbCluster)
 	}
 
 	// reconcile TiDB discovery service
-	if err := c.discoveryManager.Reconcile(tc); err != nil {
+	if err := traceStep(tc, "discovery", func(tc *v1alpha1.TidbCluster) error {
+		return c.discoveryManager.Reconcile(tc)
+	}); err != nil {
 		return err
 	}
 
@@ -172,7 +221,7 @@ func (c *defaultTidbClusterControl) updateTidbCluster(tc *v1alpha1.TidbCluster)
 	//   - upgrade the pd cluster
 	//   - scale out/in the pd cluster
 	//   - failover the pd cluster
-	if err := c.pdMemberManager.Sync(tc); err != nil {
+	if err := traceStep(tc, v1alpha1.PDMemberType.String(), c.pdMemberManager.Sync); err != nil {
 		return err
 	}
 
@@ -185,7 +234,7 @@ func (c *defaultTidbClusterControl) updateTidbCluster(tc *v1alpha1.TidbCluster)
 	//   - upgrade the tiflash cluster
 	//   - scale out/in the tiflash cluster
 	//   - failover the tiflash cluster
-	if err := c.tiflashMemberManager.Sync(tc); err != nil {
+	if err := traceStep(tc, v1alpha1.TiFlashMemberType.String(), c.tiflashMemberManager.Sync); err != nil {
 		return err
 	}
 
@@ -198,12 +247,12 @@ func (c *defaultTidbClusterControl) updateTidbCluster(tc *v1alpha1.TidbCluster)
 	//   - upgrade the tikv cluster
 	//   - scale out/in the tikv cluster
 	//   - failover the tikv cluster
-	if err := c.tikvMemberManager.Sync(tc); err != nil {
+	if err := traceStep(tc, v1alpha1.TiKVMemberType.String(), c.tikvMemberManager.Sync); err != nil {
 		return err
 	}
 
 	// syncing the pump cluster
-	if err := c.pumpMemberManager.Sync(tc); err != nil {
+	if err := traceStep(tc, v1alpha1.PumpMemberType.String(), c.pumpMemberManager.Sync); err != nil {
 		return err
 	}
 
@@ -215,14 +264,14 @@ func (c *defaultTidbClusterControl) updateTidbCluster(tc *v1alpha1.TidbCluster)
 	//   - upgrade the tidb cluster
 	//   - scale out/in the tidb cluster
 	//   - failover the tidb cluster
-	if err := c.tidbMemberManager.Sync(tc); err != nil {
+	if err := traceStep(tc, v1alpha1.TiDBMemberType.String(), c.tidbMemberManager.Sync); err != nil {
 		return err
 	}
 
 	//   - waiting for the pd cluster available(pd cluster is in quorum)
 	//   - create or update ticdc deployment
 	//   - sync ticdc cluster status from pd to TidbCluster object
-	if err := c.ticdcMemberManager.Sync(tc); err != nil {
+	if err := traceStep(tc, v1alpha1.TiCDCMemberType.String(), c.ticdcMemberManager.Sync); err != nil {
 		return err
 	}
 
@@ -230,7 +279,7 @@ func (c *defaultTidbClusterControl) updateTidbCluster(tc *v1alpha1.TidbCluster)
 	//   - label.StoreIDLabelKey
 	//   - label.MemberIDLabelKey
 	//   - label.NamespaceLabelKey
-	if err := c.metaManager.Sync(tc); err != nil {
+	if err := traceStep(tc, "meta", c.metaManager.Sync); err != nil {
 		return err
 	}
 
@@ -246,13 +295,13 @@ func (c *defaultTidbClusterControl) updateTidbCluster(tc *v1alpha1.TidbCluster)
 	}
 
 	// resize PVC if necessary
-	if err := c.pvcResizer.Resize(tc); err != nil {
+	if err := traceStep(tc, "pvc-resize", c.pvcResizer.Resize); err != nil {
 		return err
 	}
 
 	// syncing the some tidbcluster status attributes
 	// 	- sync tidbmonitor reference
-	return c.tidbClusterStatusManager.Sync(tc)
+	return traceStep(tc, "status", c.tidbClusterStatusManager.Sync)
 }
 
 func (c *defaultTidbClusterControl) recordMetrics(tc *v1alpha1.TidbCluster) {
@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	mm "github.com/pingcap/tidb-operator/pkg/manager/member"
 	"github.com/pingcap/tidb-operator/pkg/manager/meta"
+	"github.com/pingcap/tidb-operator/pkg/tracing"
 	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
@@ -281,6 +282,76 @@ func TestTidbClusterControlUpdateTidbCluster(t *testing.T) {
 	}
 }
 
+func TestTidbClusterControlRecordSyncFailures(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForTidbClusterControl()
+	control, _, _, pdMemberManager, _, _, _, _, _ := newFakeTidbClusterControl()
+
+	// failures escalate the count
+	pdMemberManager.SetSyncError(fmt.Errorf("pd member manager sync error"))
+	g.Expect(control.UpdateTidbCluster(tc)).To(HaveOccurred())
+	g.Expect(control.UpdateTidbCluster(tc)).To(HaveOccurred())
+	g.Expect(tc.Status.ConsecutiveSyncFailures).To(Equal(int32(2)))
+	g.Expect(tc.Status.LastSyncFailureTime).NotTo(BeNil())
+
+	// requeue errors are not counted
+	pdMemberManager.SetSyncError(controller.RequeueErrorf("pd is upgrading"))
+	g.Expect(control.UpdateTidbCluster(tc)).To(HaveOccurred())
+	g.Expect(tc.Status.ConsecutiveSyncFailures).To(Equal(int32(2)))
+
+	// the count starts over once the spec is changed
+	pdMemberManager.SetSyncError(fmt.Errorf("pd member manager sync error"))
+	tc.Generation++
+	g.Expect(control.UpdateTidbCluster(tc)).To(HaveOccurred())
+	g.Expect(tc.Status.ConsecutiveSyncFailures).To(Equal(int32(1)))
+	g.Expect(tc.Status.LastSyncFailureGeneration).To(Equal(tc.Generation))
+
+	// a successful sync resets the count
+	pdMemberManager.SetSyncError(nil)
+	g.Expect(control.UpdateTidbCluster(tc)).NotTo(HaveOccurred())
+	g.Expect(tc.Status.ConsecutiveSyncFailures).To(Equal(int32(0)))
+	g.Expect(tc.Status.LastSyncFailureTime).To(BeNil())
+}
+
+func TestTidbClusterControlTracing(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	exporter := tracing.NewInMemoryExporter()
+	tracing.SetTracer(tracing.NewTracer(exporter))
+	defer tracing.SetTracer(nil)
+
+	tc := newTidbClusterForTidbClusterControl()
+	control, _, _, _, tikvMemberManager, _, _, _, _ := newFakeTidbClusterControl()
+	tikvMemberManager.SetSyncError(fmt.Errorf("tikv member manager sync error"))
+	g.Expect(control.UpdateTidbCluster(tc)).To(HaveOccurred())
+
+	spans := exporter.Spans()
+	g.Expect(spans).NotTo(BeEmpty())
+	root := spans[len(spans)-1]
+	g.Expect(root.Name).To(Equal("reconcile"))
+	g.Expect(root.ParentSpanID).To(BeEmpty())
+	g.Expect(root.Error).To(ContainSubstring("tikv member manager sync error"))
+	ns, _ := root.Attribute(tracing.NamespaceKey)
+	g.Expect(ns).To(Equal(tc.GetNamespace()))
+	name, _ := root.Attribute(tracing.ClusterKey)
+	g.Expect(name).To(Equal(tc.GetName()))
+
+	steps := map[string]*tracing.SpanData{}
+	for _, span := range spans[:len(spans)-1] {
+		g.Expect(span.TraceID).To(Equal(root.TraceID))
+		g.Expect(span.ParentSpanID).To(Equal(root.SpanID))
+		steps[span.Name] = span
+	}
+	g.Expect(steps).To(HaveKey("sync pd"))
+	g.Expect(steps).To(HaveKey("sync tikv"))
+	g.Expect(steps).NotTo(HaveKey("sync tidb"))
+	g.Expect(steps["sync pd"].Error).To(BeEmpty())
+	g.Expect(steps["sync tikv"].Error).To(Equal("tikv member manager sync error"))
+	component, _ := steps["sync tikv"].Attribute(tracing.ComponentKey)
+	g.Expect(component).To(Equal("tikv"))
+}
+
 func TestTidbClusterStatusEquality(t *testing.T) {
 	g := NewGomegaWithT(t)
 	tcStatus := v1alpha1.TidbClusterStatus{}
@@ -314,6 +385,7 @@ func newFakeTidbClusterControl() (
 	tidbMemberManager := mm.NewFakeTiDBMemberManager()
 	reclaimPolicyManager := meta.NewFakeReclaimPolicyManager()
 	metaManager := meta.NewFakeMetaManager()
+	labelMigrationManager := meta.NewFakeLabelMigrationManager()
 	orphanPodCleaner := mm.NewFakeOrphanPodsCleaner()
 	pvcCleaner := mm.NewFakePVCCleaner()
 	pumpMemberManager := mm.NewFakePumpMemberManager()
@@ -329,6 +401,7 @@ func newFakeTidbClusterControl() (
 		tidbMemberManager,
 		reclaimPolicyManager,
 		metaManager,
+		labelMigrationManager,
 		orphanPodCleaner,
 		pvcCleaner,
 		pvcResizer,
@@ -14,10 +14,14 @@
 package tidbcluster
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	perrors "github.com/pingcap/errors"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	mm "github.com/pingcap/tidb-operator/pkg/manager/member"
@@ -25,6 +29,8 @@ import (
 	apps "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
@@ -40,6 +46,9 @@ type Controller struct {
 	control ControlInterface
 	// tidbclusters that need to be synced.
 	queue workqueue.RateLimitingInterface
+	// processedNonces records the last reconcile-now nonce processed for each
+	// tidbcluster key, so a nonce still seen in the cache is not processed twice.
+	processedNonces sync.Map
 }
 
 // NewController creates a tidbcluster controller.
@@ -53,10 +62,11 @@ func NewController(deps *controller.Dependencies) *Controller {
 			mm.NewTiDBMemberManager(deps, mm.NewTiDBScaler(deps), mm.NewTiDBUpgrader(deps), mm.NewTiDBFailover(deps)),
 			meta.NewReclaimPolicyManager(deps),
 			meta.NewMetaManager(deps),
+			meta.NewLabelMigrationManager(deps),
 			mm.NewOrphanPodsCleaner(deps),
 			mm.NewRealPVCCleaner(deps),
 			mm.NewPVCResizer(deps),
-			mm.NewPumpMemberManager(deps, mm.NewPumpScaler(deps)),
+			mm.NewPumpMemberManager(deps, mm.NewPumpScaler(deps), mm.NewPumpUpgrader(deps)),
 			mm.NewTiFlashMemberManager(deps, mm.NewTiFlashFailover(deps), mm.NewTiFlashScaler(deps), mm.NewTiFlashUpgrader(deps)),
 			mm.NewTiCDCMemberManager(deps, mm.NewTiCDCScaler(deps), mm.NewTiCDCUpgrader(deps)),
 			mm.NewTidbDiscoveryManager(deps),
@@ -75,7 +85,7 @@ func NewController(deps *controller.Dependencies) *Controller {
 	tidbClusterInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: c.enqueueTidbCluster,
 		UpdateFunc: func(old, cur interface{}) {
-			c.enqueueTidbCluster(cur)
+			c.updateTidbCluster(old, cur)
 		},
 		DeleteFunc: c.enqueueTidbCluster,
 	})
@@ -86,6 +96,13 @@ func NewController(deps *controller.Dependencies) *Controller {
 		},
 		DeleteFunc: c.deleteStatefulSet,
 	})
+	deps.InformerFactory.Pingcap().V1alpha1().TidbClusterConfigProfiles().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.enqueueConfigProfileClusters,
+		UpdateFunc: func(old, cur interface{}) {
+			c.enqueueConfigProfileClusters(cur)
+		},
+		DeleteFunc: c.enqueueConfigProfileClusters,
+	})
 
 	return c
 }
@@ -125,6 +142,11 @@ func (c *Controller) processNextWorkItem() bool {
 		} else {
 			utilruntime.HandleError(fmt.Errorf("TidbCluster: %v, sync failed %v, requeuing", key.(string), err))
 		}
+		if after := controller.RequeueAfter(err); after > 0 {
+			c.queue.Forget(key)
+			c.queue.AddAfter(key, after)
+			return true
+		}
 		c.queue.AddRateLimited(key)
 	} else {
 		c.queue.Forget(key)
@@ -146,19 +168,84 @@ func (c *Controller) sync(key string) error {
 	tc, err := c.deps.TiDBClusterLister.TidbClusters(ns).Get(name)
 	if errors.IsNotFound(err) {
 		klog.Infof("TidbCluster has been deleted %v", key)
+		c.deps.TiDBControl.ForgetCluster(ns, name)
 		return nil
 	}
 	if err != nil {
 		return err
 	}
 
-	return c.syncTidbCluster(tc.DeepCopy())
+	nonce := tc.Annotations[label.AnnReconcileNow]
+	reconcileNow := nonce != "" && !c.isNonceProcessed(key, nonce)
+	if delay := c.syncFailureDelay(tc); delay > 0 && !reconcileNow {
+		klog.V(4).Infof("TidbCluster %q failed to sync %d times in a row, delay the next sync for %v", key, tc.Status.ConsecutiveSyncFailures, delay)
+		c.queue.AddAfter(key, delay)
+		return nil
+	}
+
+	err = c.syncTidbCluster(tc.DeepCopy())
+	if reconcileNow {
+		c.processedNonces.Store(key, nonce)
+		c.clearReconcileNow(tc, nonce)
+	}
+	return err
+}
+
+func (c *Controller) isNonceProcessed(key, nonce string) bool {
+	processed, ok := c.processedNonces.Load(key)
+	return ok && processed.(string) == nonce
+}
+
+// clearReconcileNow removes the reconcile-now annotation of the tidbcluster
+// after the reconcile it requests is done. The annotation is left alone if it
+// is changed to a new nonce in the meantime, which requests another reconcile.
+func (c *Controller) clearReconcileNow(tc *v1alpha1.TidbCluster, nonce string) {
+	path := "/metadata/annotations/" + strings.ReplaceAll(strings.ReplaceAll(label.AnnReconcileNow, "~", "~0"), "/", "~1")
+	data := fmt.Sprintf(`[{"op":"test","path":%q,"value":%q},{"op":"remove","path":%q}]`, path, nonce, path)
+	_, err := c.deps.Clientset.PingcapV1alpha1().TidbClusters(tc.Namespace).Patch(context.TODO(), tc.Name, types.JSONPatchType, []byte(data), metav1.PatchOptions{})
+	if err != nil {
+		// the nonce is recorded as processed, so the annotation is not processed again even if it is not removed
+		klog.V(4).Infof("TidbCluster: [%s/%s] failed to remove annotation %s, error: %v", tc.Namespace, tc.Name, label.AnnReconcileNow, err)
+		return
+	}
+	klog.Infof("TidbCluster: [%s/%s] reconciled on request %s=%s", tc.Namespace, tc.Name, label.AnnReconcileNow, nonce)
+}
+
+// syncFailureDelay returns how long the sync of the tidbcluster should be
+// delayed, so a cluster that keeps failing is not synced too often by the
+// events triggered by itself. The sync is not delayed once the spec is
+// changed after the last failure.
+func (c *Controller) syncFailureDelay(tc *v1alpha1.TidbCluster) time.Duration {
+	if tc.Status.ConsecutiveSyncFailures <= 0 || tc.Status.LastSyncFailureTime == nil ||
+		tc.Status.LastSyncFailureGeneration != tc.Generation {
+		return 0
+	}
+	backoff := controller.SyncFailureBackoff(tc.Status.ConsecutiveSyncFailures, c.deps.CLIConfig.SyncFailureBaseDelay, c.deps.CLIConfig.SyncFailureMaxDelay)
+	return time.Until(tc.Status.LastSyncFailureTime.Add(backoff))
 }
 
 func (c *Controller) syncTidbCluster(tc *v1alpha1.TidbCluster) error {
 	return c.control.UpdateTidbCluster(tc)
 }
 
+// updateTidbCluster enqueues the updated tidbcluster. When a new reconcile-now
+// nonce is set, the backoff of the failed syncs is dropped as well, so the
+// tidbcluster is reconciled right now.
+func (c *Controller) updateTidbCluster(old, cur interface{}) {
+	oldTc, ok1 := old.(*v1alpha1.TidbCluster)
+	curTc, ok2 := cur.(*v1alpha1.TidbCluster)
+	if ok1 && ok2 {
+		nonce := curTc.Annotations[label.AnnReconcileNow]
+		if nonce != "" && nonce != oldTc.Annotations[label.AnnReconcileNow] {
+			if key, err := cache.MetaNamespaceKeyFunc(curTc); err == nil {
+				klog.Infof("TidbCluster %q is requested to reconcile now, nonce %s", key, nonce)
+				c.queue.Forget(key)
+			}
+		}
+	}
+	c.enqueueTidbCluster(cur)
+}
+
 // enqueueTidbCluster enqueues the given tidbcluster in the work queue.
 func (c *Controller) enqueueTidbCluster(obj interface{}) {
 	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
@@ -169,6 +256,29 @@ func (c *Controller) enqueueTidbCluster(obj interface{}) {
 	c.queue.Add(key)
 }
 
+// enqueueConfigProfileClusters enqueues the tidbclusters referencing the config profile
+func (c *Controller) enqueueConfigProfileClusters(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	profile, ok := obj.(*v1alpha1.TidbClusterConfigProfile)
+	if !ok {
+		utilruntime.HandleError(fmt.Errorf("object is not a config profile %+v", obj))
+		return
+	}
+	tcs, err := c.deps.TiDBClusterLister.TidbClusters(profile.Namespace).List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to list tidbclusters for config profile %s/%s: %v", profile.Namespace, profile.Name, err))
+		return
+	}
+	for _, tc := range tcs {
+		if tc.Spec.TiDB != nil && tc.Spec.TiDB.ConfigProfile == profile.Name {
+			klog.V(4).Infof("TidbClusterConfigProfile %s/%s changed, TidbCluster: %s/%s", profile.Namespace, profile.Name, tc.Namespace, tc.Name)
+			c.enqueueTidbCluster(tc)
+		}
+	}
+}
+
 // addStatefulSet adds the tidbcluster for the statefulset to the sync queue
 func (c *Controller) addStatefulSet(obj interface{}) {
 	set := obj.(*apps.StatefulSet)
@@ -14,12 +14,14 @@
 package tidbcluster
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
 	"time"
 
 	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	apps "k8s.io/api/apps/v1"
@@ -47,6 +49,128 @@ func TestTidbClusterControllerEnqueueTidbClusterFailed(t *testing.T) {
 	g.Expect(tcc.queue.Len()).To(Equal(0))
 }
 
+func TestTidbClusterControllerEnqueueConfigProfileClusters(t *testing.T) {
+	g := NewGomegaWithT(t)
+	deps := controller.NewFakeDependencies()
+	tcc := NewController(deps)
+	tcc.control = NewFakeTidbClusterControlInterface()
+	tcIndexer := deps.InformerFactory.Pingcap().V1alpha1().TidbClusters().Informer().GetIndexer()
+
+	tc := newTidbCluster()
+	tc.Spec.TiDB.ConfigProfile = "baseline"
+	g.Expect(tcIndexer.Add(tc)).To(Succeed())
+	other := newTidbCluster()
+	other.Name = "other"
+	g.Expect(tcIndexer.Add(other)).To(Succeed())
+
+	profile := &v1alpha1.TidbClusterConfigProfile{
+		ObjectMeta: metav1.ObjectMeta{Name: "baseline", Namespace: tc.Namespace},
+	}
+	tcc.enqueueConfigProfileClusters(profile)
+	g.Expect(tcc.queue.Len()).To(Equal(1))
+	key, _ := tcc.queue.Get()
+	g.Expect(key).To(Equal(tc.Namespace + "/" + tc.Name))
+	tcc.queue.Done(key)
+
+	tcc.enqueueConfigProfileClusters(cache.DeletedFinalStateUnknown{Key: "default/baseline", Obj: profile})
+	g.Expect(tcc.queue.Len()).To(Equal(1))
+}
+
+func TestTidbClusterControllerUpdateTidbCluster(t *testing.T) {
+	g := NewGomegaWithT(t)
+	type testcase struct {
+		name              string
+		oldNonce          string
+		curNonce          string
+		expectNumRequeues int
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+		oldTc := newTidbCluster()
+		if test.oldNonce != "" {
+			oldTc.Annotations = map[string]string{label.AnnReconcileNow: test.oldNonce}
+		}
+		curTc := oldTc.DeepCopy()
+		curTc.Annotations = map[string]string{}
+		if test.curNonce != "" {
+			curTc.Annotations[label.AnnReconcileNow] = test.curNonce
+		}
+
+		tcc := NewController(controller.NewFakeDependencies())
+		tcc.control = NewFakeTidbClusterControlInterface()
+		key, err := cache.MetaNamespaceKeyFunc(curTc)
+		g.Expect(err).NotTo(HaveOccurred())
+		// the previous sync failed
+		tcc.queue.AddRateLimited(key)
+
+		tcc.updateTidbCluster(oldTc, curTc)
+		g.Expect(tcc.queue.Len()).To(Equal(1))
+		g.Expect(tcc.queue.NumRequeues(key)).To(Equal(test.expectNumRequeues))
+	}
+
+	tests := []testcase{
+		{
+			name:              "no reconcile-now request",
+			expectNumRequeues: 1,
+		},
+		{
+			name:              "new reconcile-now nonce",
+			curNonce:          "1",
+			expectNumRequeues: 0,
+		},
+		{
+			name:              "changed reconcile-now nonce",
+			oldNonce:          "1",
+			curNonce:          "2",
+			expectNumRequeues: 0,
+		},
+		{
+			name:              "identical reconcile-now nonce",
+			oldNonce:          "1",
+			curNonce:          "1",
+			expectNumRequeues: 1,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
+func TestTidbClusterControllerReconcileNow(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbCluster()
+	tc.Annotations = map[string]string{label.AnnReconcileNow: "1"}
+	now := metav1.Now()
+	tc.Status.ConsecutiveSyncFailures = 3
+	tc.Status.LastSyncFailureTime = &now
+
+	fakeDeps := controller.NewFakeDependencies()
+	tcc := NewController(fakeDeps)
+	tcControl := NewFakeTidbClusterControlInterface()
+	tcControl.SetUpdateTCError(fmt.Errorf("update tidb cluster failed"))
+	tcc.control = tcControl
+	tcIndexer := fakeDeps.InformerFactory.Pingcap().V1alpha1().TidbClusters().Informer().GetIndexer()
+	g.Expect(tcIndexer.Add(tc)).To(Succeed())
+	_, err := fakeDeps.Clientset.PingcapV1alpha1().TidbClusters(tc.Namespace).Create(context.TODO(), tc, metav1.CreateOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	key, err := cache.MetaNamespaceKeyFunc(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// the backoff of the failed syncs is bypassed
+	err = tcc.sync(key)
+	g.Expect(err).To(HaveOccurred())
+	updated, err := fakeDeps.Clientset.PingcapV1alpha1().TidbClusters(tc.Namespace).Get(context.TODO(), tc.Name, metav1.GetOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(updated.Annotations).NotTo(HaveKey(label.AnnReconcileNow))
+
+	// the processed nonce still in the cache is not processed again
+	err = tcc.sync(key)
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
 func TestTidbClusterControllerAddStatefulSet(t *testing.T) {
 	g := NewGomegaWithT(t)
 	type testcase struct {
@@ -198,6 +322,7 @@ func TestTidbClusterControllerSync(t *testing.T) {
 	g := NewGomegaWithT(t)
 	type testcase struct {
 		name                     string
+		update                   func(*v1alpha1.TidbCluster)
 		addTcToIndexer           bool
 		errWhenUpdateTidbCluster bool
 		errExpectFn              func(*GomegaWithT, error)
@@ -207,6 +332,9 @@ func TestTidbClusterControllerSync(t *testing.T) {
 		t.Log(test.name)
 
 		tc := newTidbCluster()
+		if test.update != nil {
+			test.update(tc)
+		}
 		fakeDeps := controller.NewFakeDependencies()
 		tcc := NewController(fakeDeps)
 		tcIndexer := fakeDeps.InformerFactory.Pingcap().V1alpha1().TidbClusters().Informer().GetIndexer()
@@ -228,6 +356,13 @@ func TestTidbClusterControllerSync(t *testing.T) {
 		if test.errExpectFn != nil {
 			test.errExpectFn(g, err)
 		}
+		// the connections to the deleted cluster are dropped
+		forgotten := fakeDeps.TiDBControl.(*controller.FakeTiDBControl).ForgottenClusters
+		if test.addTcToIndexer {
+			g.Expect(forgotten).To(BeEmpty())
+		} else {
+			g.Expect(forgotten).To(Equal([]string{key}))
+		}
 	}
 
 	tests := []testcase{
@@ -256,6 +391,49 @@ func TestTidbClusterControllerSync(t *testing.T) {
 				g.Expect(strings.Contains(err.Error(), "update tidb cluster failed")).To(Equal(true))
 			},
 		},
+		{
+			name: "sync is delayed after consecutive failures",
+			update: func(tc *v1alpha1.TidbCluster) {
+				now := metav1.Now()
+				tc.Generation = 2
+				tc.Status.ConsecutiveSyncFailures = 3
+				tc.Status.LastSyncFailureTime = &now
+				tc.Status.LastSyncFailureGeneration = 2
+			},
+			addTcToIndexer:           true,
+			errWhenUpdateTidbCluster: true,
+			errExpectFn: func(g *GomegaWithT, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+			},
+		},
+		{
+			name: "sync is not delayed once the spec is changed",
+			update: func(tc *v1alpha1.TidbCluster) {
+				now := metav1.Now()
+				tc.Generation = 3
+				tc.Status.ConsecutiveSyncFailures = 3
+				tc.Status.LastSyncFailureTime = &now
+				tc.Status.LastSyncFailureGeneration = 2
+			},
+			addTcToIndexer:           true,
+			errWhenUpdateTidbCluster: true,
+			errExpectFn: func(g *GomegaWithT, err error) {
+				g.Expect(err).To(HaveOccurred())
+			},
+		},
+		{
+			name: "sync is not delayed once the backoff passes",
+			update: func(tc *v1alpha1.TidbCluster) {
+				last := metav1.NewTime(time.Now().Add(-time.Hour))
+				tc.Status.ConsecutiveSyncFailures = 3
+				tc.Status.LastSyncFailureTime = &last
+			},
+			addTcToIndexer:           true,
+			errWhenUpdateTidbCluster: true,
+			errExpectFn: func(g *GomegaWithT, err error) {
+				g.Expect(err).To(HaveOccurred())
+			},
+		},
 	}
 
 	for i := range tests {
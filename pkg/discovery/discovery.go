@@ -20,6 +20,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned"
 	"github.com/pingcap/tidb-operator/pkg/dmapi"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
@@ -28,6 +29,11 @@ import (
 	"k8s.io/klog"
 )
 
+// learnerInitialClusterArg is the prefix of the start args of a PD member
+// added as a learner, the start script writes the initial cluster after it to
+// the join file of the member
+const learnerInitialClusterArg = "--learner-initial-cluster="
+
 // TiDBDiscovery helps new PD and dm-master member to discover all other members in cluster bootstrap phase.
 type TiDBDiscovery interface {
 	Discover(string) (string, error)
@@ -121,6 +127,22 @@ func (d *tidbDiscovery) Discover(advertisePeerUrl string) (string, error) {
 		return fmt.Sprintf("--initial-cluster=%s=%s://%s", podName, tc.Scheme(), advertisePeerUrl), nil
 	}
 
+	if tc.PDJoinAsLearner() && tc.Spec.Cluster == nil {
+		// the member name is the FQDN if tc.Spec.ClusterDomain is set, see below
+		name := podName
+		if len(tc.Spec.ClusterDomain) > 0 {
+			name = strArr[0]
+		}
+		args, err := d.joinAsLearner(tc, name, fmt.Sprintf("%s://%s", tc.Scheme(), advertisePeerUrl))
+		if err != nil {
+			return "", err
+		}
+		if args != "" {
+			delete(currentCluster.peers, podName)
+			return args, nil
+		}
+	}
+
 	var pdClients []pdapi.PDClient
 
 	if tc.Spec.PD != nil {
@@ -172,6 +194,59 @@ func (d *tidbDiscovery) Discover(advertisePeerUrl string) (string, error) {
 	return fmt.Sprintf("--join=%s", strings.Join(membersArr, ",")), nil
 }
 
+// joinAsLearner adds the PD member at the peer URL to the etcd embedded in PD
+// as a learner, and returns the args to start it with the initial cluster of
+// all the etcd members. The learner added before is reused if the member fails
+// to start. Empty args are returned if the member has started before, e.g. its
+// data is lost, in which case it joins by --join as usual.
+func (d *tidbDiscovery) joinAsLearner(tc *v1alpha1.TidbCluster, name, peerURL string) (string, error) {
+	etcdClient, err := d.pdControl.GetPDEtcdClient(pdapi.Namespace(tc.GetNamespace()), tc.GetName(), tc.IsTLSClusterEnabled())
+	if err != nil {
+		return "", err
+	}
+	defer etcdClient.Close()
+
+	members, err := etcdClient.ListMembers()
+	if err != nil {
+		return "", err
+	}
+	var self *pdapi.EtcdMember
+	for _, member := range members {
+		for _, url := range member.PeerURLs {
+			if url == peerURL {
+				self = member
+			}
+		}
+	}
+	if self != nil && self.Name != "" {
+		return "", nil
+	}
+	if self == nil {
+		if self, err = etcdClient.AddLearner(peerURL); err != nil {
+			return "", err
+		}
+		klog.Infof("pd member %s of cluster %s/%s is added as a learner", name, tc.GetNamespace(), tc.GetName())
+		if members, err = etcdClient.ListMembers(); err != nil {
+			return "", err
+		}
+	}
+
+	var cluster []string
+	for _, member := range members {
+		memberName := member.Name
+		if member.ID == self.ID {
+			memberName = name
+		} else if memberName == "" {
+			// a member not started yet, its name is not checked by etcd
+			memberName = fmt.Sprintf("%x", member.ID)
+		}
+		for _, url := range member.PeerURLs {
+			cluster = append(cluster, fmt.Sprintf("%s=%s", memberName, url))
+		}
+	}
+	return learnerInitialClusterArg + strings.Join(cluster, ","), nil
+}
+
 func (d *tidbDiscovery) DiscoverDM(advertisePeerUrl string) (string, error) {
 	d.lock.Lock()
 	defer d.lock.Unlock()
@@ -30,6 +30,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
 )
 
 func TestDiscoveryDiscovery(t *testing.T) {
@@ -533,6 +534,53 @@ func TestDiscoveryDiscovery(t *testing.T) {
 	}
 }
 
+func TestDiscoveryDiscoverAsLearner(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cli := fake.NewSimpleClientset()
+	kubeCli := kubefake.NewSimpleClientset()
+	fakePDControl := pdapi.NewFakePDControl(kubeCli)
+	tc := newTC()
+	tc.Spec.PD.JoinAsLearner = pointer.BoolPtr(true)
+	cli.PingcapV1alpha1().TidbClusters(tc.Namespace).Create(context.TODO(), tc, metav1.CreateOptions{})
+
+	peerURL := func(ordinal int) string {
+		return fmt.Sprintf("http://demo-pd-%d.demo-pd-peer.default.svc:2380", ordinal)
+	}
+	etcdClient := &pdapi.FakePDEtcdClient{}
+	for i := 0; i < 3; i++ {
+		etcdClient.Members = append(etcdClient.Members, &pdapi.EtcdMember{ID: uint64(i + 10), Name: fmt.Sprintf("demo-pd-%d", i), PeerURLs: []string{peerURL(i)}})
+	}
+	fakePDControl.SetPDEtcdClient(pdapi.Namespace(tc.Namespace), tc.Name, etcdClient)
+	pdClient := pdapi.NewFakePDClient()
+	fakePDControl.SetPDClient(pdapi.Namespace(tc.Namespace), tc.Name, pdClient)
+	pdClient.AddReaction(pdapi.GetMembersActionType, func(action *pdapi.Action) (interface{}, error) {
+		return &pdapi.MembersInfo{Members: []*pdpb.Member{{Name: "demo-pd-0", PeerUrls: []string{peerURL(0)}}}}, nil
+	})
+
+	td := NewTiDBDiscovery(fakePDControl, dmapi.NewFakeMasterControl(kubeCli), cli, kubeCli)
+	os.Setenv("MY_POD_NAMESPACE", "default")
+	expected := fmt.Sprintf("--learner-initial-cluster=demo-pd-0=%s,demo-pd-1=%s,demo-pd-2=%s,demo-pd-3=%s", peerURL(0), peerURL(1), peerURL(2), peerURL(3))
+
+	// the new member is added as a learner
+	args, err := td.Discover("demo-pd-3.demo-pd-peer.default.svc:2380")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(args).To(Equal(expected))
+	g.Expect(etcdClient.Members).To(HaveLen(4))
+	g.Expect(etcdClient.Members[3].IsLearner).To(BeTrue())
+
+	// the learner is reused if the member fails to start
+	args, err = td.Discover("demo-pd-3.demo-pd-peer.default.svc:2380")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(args).To(Equal(expected))
+	g.Expect(etcdClient.Members).To(HaveLen(4))
+
+	// the member started before joins as usual
+	args, err = td.Discover("demo-pd-2.demo-pd-peer.default.svc:2380")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(args).To(Equal("--join=http://demo-pd-0.demo-pd-peer.default.svc:2379"))
+}
+
 func TestDiscoveryDMDiscovery(t *testing.T) {
 	g := NewGomegaWithT(t)
 
@@ -14,9 +14,12 @@
 package dmapi
 
 import (
+	"bytes"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"time"
 
@@ -36,13 +39,34 @@ type MasterClient interface {
 	EvictLeader() error
 	DeleteMaster(name string) error
 	DeleteWorker(name string) error
+	// TransferSource binds the upstream source to the free worker, the tasks
+	// of the source are moved to the worker with it. It's served by the
+	// OpenAPI of dm-master, ErrOpenAPIDisabled is returned if it's disabled.
+	TransferSource(source, worker string) error
+	// GetSubTasks returns the status of the subtasks of all the tasks. It's
+	// served by the OpenAPI of dm-master, ErrOpenAPIDisabled is returned if
+	// it's disabled.
+	GetSubTasks() ([]*SubTaskStatus, error)
 }
 
 var (
 	membersPrefix = "apis/v1alpha1/members"
 	leaderPrefix  = "apis/v1alpha1/leader"
+	sourcesPrefix = "api/v1/sources"
+	tasksPrefix   = "api/v1/tasks"
 )
 
+const (
+	// SubTaskStagePaused is the stage of the subtask paused by the user or by
+	// an error, the error is reported in its ErrorMsg
+	SubTaskStagePaused = "Paused"
+)
+
+// ErrOpenAPIDisabled is returned by the calls of the OpenAPI of dm-master,
+// e.g. TransferSource, as it is only served with `openapi = true` in the
+// config of dm-master
+var ErrOpenAPIDisabled = errors.New("the OpenAPI of dm-master is not enabled, set openapi = true in the config of dm-master")
+
 type RespHeader struct {
 	Result bool   `json:"result,omitempty"`
 	Msg    string `json:"msg,omitempty"`
@@ -63,6 +87,26 @@ type WorkersInfo struct {
 	Source string `json:"source,omitempty"`
 }
 
+// SubTaskStatus is the status of the subtask of a task on the worker the
+// source of the subtask is bound to
+type SubTaskStatus struct {
+	Name       string `json:"name"`
+	SourceName string `json:"source_name"`
+	WorkerName string `json:"worker_name"`
+	Stage      string `json:"stage"`
+	ErrorMsg   string `json:"error_msg,omitempty"`
+}
+
+type taskList struct {
+	Data []struct {
+		Name string `json:"name"`
+	} `json:"data"`
+}
+
+type subTaskStatusList struct {
+	Data []*SubTaskStatus `json:"data"`
+}
+
 type MembersMaster struct {
 	Msg     string         `json:"msg,omitempty"`
 	Masters []*MastersInfo `json:"masters,omitempty"`
@@ -225,6 +269,83 @@ func (c *masterClient) DeleteWorker(name string) error {
 	return c.deleteMember(query)
 }
 
+type transferSourceReq struct {
+	WorkerName string `json:"worker_name"`
+}
+
+func (c *masterClient) TransferSource(source, worker string) error {
+	apiURL := fmt.Sprintf("%s/%s/%s/transfer", c.url, sourcesPrefix, source)
+	data, err := json.Marshal(transferSourceReq{WorkerName: worker})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to transfer source %s to worker %s, err: %s", source, worker, err)
+	}
+	defer httputil.DeferClose(res.Body)
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	// the routes of the OpenAPI are not registered if it is disabled
+	if res.StatusCode == http.StatusNotFound {
+		return ErrOpenAPIDisabled
+	}
+	if res.StatusCode >= 400 {
+		return fmt.Errorf("unable to transfer source %s to worker %s, error response %v: %s", source, worker, res.StatusCode, body)
+	}
+	return nil
+}
+
+// getOpenAPI gets the resource of the OpenAPI of dm-master into v
+func (c *masterClient) getOpenAPI(apiURL string, v interface{}) error {
+	res, err := c.httpClient.Get(apiURL)
+	if err != nil {
+		return err
+	}
+	defer httputil.DeferClose(res.Body)
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	// the routes of the OpenAPI are not registered if it is disabled
+	if res.StatusCode == http.StatusNotFound {
+		return ErrOpenAPIDisabled
+	}
+	if res.StatusCode >= 400 {
+		return fmt.Errorf("error response %v: %s", res.StatusCode, body)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("unable to unmarshal resp: %s, err: %s", body, err)
+	}
+	return nil
+}
+
+func (c *masterClient) GetSubTasks() ([]*SubTaskStatus, error) {
+	tasks := &taskList{}
+	if err := c.getOpenAPI(fmt.Sprintf("%s/%s", c.url, tasksPrefix), tasks); err != nil {
+		if err == ErrOpenAPIDisabled {
+			return nil, err
+		}
+		return nil, fmt.Errorf("unable to list tasks, err: %s", err)
+	}
+	var subTasks []*SubTaskStatus
+	for _, task := range tasks.Data {
+		status := &subTaskStatusList{}
+		if err := c.getOpenAPI(fmt.Sprintf("%s/%s/%s/status", c.url, tasksPrefix, task.Name), status); err != nil {
+			return nil, fmt.Errorf("unable to get the status of task %s, err: %s", task.Name, err)
+		}
+		subTasks = append(subTasks, status.Data...)
+	}
+	return subTasks, nil
+}
+
 // NewMasterClient returns a new MasterClient
 func NewMasterClient(url string, timeout time.Duration, tlsConfig *tls.Config, disableKeepalive bool) MasterClient {
 	return &masterClient{
@@ -208,3 +208,73 @@ func TestDeleteMember(t *testing.T) {
 		g.Expect(err).NotTo(HaveOccurred())
 	}
 }
+
+func TestTransferSource(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+		g.Expect(request.Method).To(Equal("POST"), "check method")
+		g.Expect(request.URL.Path).To(Equal(fmt.Sprintf("/%s/mysql-replica-01/transfer", sourcesPrefix)), "check url")
+
+		req := &transferSourceReq{}
+		g.Expect(json.NewDecoder(request.Body).Decode(req)).To(Succeed())
+		if req.WorkerName != "dm-worker-2" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error_msg":"worker not found"}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	defer svc.Close()
+
+	masterClient := NewMasterClient(svc.URL, DefaultTimeout, &tls.Config{}, false)
+	g.Expect(masterClient.TransferSource("mysql-replica-01", "dm-worker-2")).To(Succeed())
+	err := masterClient.TransferSource("mysql-replica-01", "dm-worker-3")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("worker not found"))
+}
+
+func TestTransferSourceOpenAPIDisabled(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+		http.NotFound(w, request)
+	})
+	defer svc.Close()
+
+	masterClient := NewMasterClient(svc.URL, DefaultTimeout, &tls.Config{}, false)
+	g.Expect(masterClient.TransferSource("mysql-replica-01", "dm-worker-2")).To(Equal(ErrOpenAPIDisabled))
+}
+
+func TestGetSubTasks(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+		g.Expect(request.Method).To(Equal("GET"), "check method")
+		switch request.URL.Path {
+		case "/" + tasksPrefix:
+			w.Write([]byte(`{"total":1,"data":[{"name":"task-1"}]}`))
+		case fmt.Sprintf("/%s/task-1/status", tasksPrefix):
+			w.Write([]byte(`{"total":2,"data":[{"name":"task-1","source_name":"mysql-01","worker_name":"dm-worker-0","stage":"Running"},` +
+				`{"name":"task-1","source_name":"mysql-02","worker_name":"dm-worker-1","stage":"Paused","error_msg":"connection refused"}]}`))
+		default:
+			http.NotFound(w, request)
+		}
+	})
+	defer svc.Close()
+
+	masterClient := NewMasterClient(svc.URL, DefaultTimeout, &tls.Config{}, false)
+	subTasks, err := masterClient.GetSubTasks()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(subTasks).To(Equal([]*SubTaskStatus{
+		{Name: "task-1", SourceName: "mysql-01", WorkerName: "dm-worker-0", Stage: "Running"},
+		{Name: "task-1", SourceName: "mysql-02", WorkerName: "dm-worker-1", Stage: SubTaskStagePaused, ErrorMsg: "connection refused"},
+	}))
+
+	disabled := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+		http.NotFound(w, request)
+	})
+	defer disabled.Close()
+	_, err = NewMasterClient(disabled.URL, DefaultTimeout, &tls.Config{}, false).GetSubTasks()
+	g.Expect(err).To(Equal(ErrOpenAPIDisabled))
+}
@@ -20,12 +20,14 @@ import (
 type ActionType string
 
 const (
-	GetMastersActionType   ActionType = "GetMasters"
-	GetWorkersActionType   ActionType = "GetWorkers"
-	GetLeaderActionType    ActionType = "GetLeader"
-	EvictLeaderActionType  ActionType = "EvictLeader"
-	DeleteMasterActionType ActionType = "DeleteMaster"
-	DeleteWorkerActionType ActionType = "DeleteWorker"
+	GetMastersActionType     ActionType = "GetMasters"
+	GetWorkersActionType     ActionType = "GetWorkers"
+	GetLeaderActionType      ActionType = "GetLeader"
+	EvictLeaderActionType    ActionType = "EvictLeader"
+	DeleteMasterActionType   ActionType = "DeleteMaster"
+	DeleteWorkerActionType   ActionType = "DeleteWorker"
+	TransferSourceActionType ActionType = "TransferSource"
+	GetSubTasksActionType    ActionType = "GetSubTasks"
 )
 
 type NotFoundReaction struct {
@@ -113,3 +115,19 @@ func (c *FakeMasterClient) DeleteWorker(_ string) error {
 	_, err := c.fakeAPI(DeleteWorkerActionType, action)
 	return err
 }
+
+func (c *FakeMasterClient) TransferSource(source, worker string) error {
+	action := &Action{Name: worker, Labels: map[string]string{"source": source}}
+	_, err := c.fakeAPI(TransferSourceActionType, action)
+	return err
+}
+
+func (c *FakeMasterClient) GetSubTasks() ([]*SubTaskStatus, error) {
+	action := &Action{}
+	result, err := c.fakeAPI(GetSubTasksActionType, action)
+	if err != nil {
+		return nil, err
+	}
+	subTasks, _ := result.([]*SubTaskStatus)
+	return subTasks, nil
+}
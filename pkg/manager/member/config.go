@@ -69,7 +69,8 @@ func updateConfigMapIfNeed(
 			desired.Name = inUseName
 		}
 		return nil
-	case v1alpha1.ConfigUpdateStrategyRollingUpdate:
+	case v1alpha1.ConfigUpdateStrategyRollingUpdate, v1alpha1.ConfigUpdateStrategyInPlacePreferred:
+		// InPlacePreferred falls back to RollingUpdate if the changes are not applied online
 		existing, err := cmLister.ConfigMaps(desired.Namespace).Get(inUseName)
 		if err != nil {
 			if errors.IsNotFound(err) {
@@ -0,0 +1,343 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/apis/util/config"
+	"github.com/pingcap/tidb-operator/pkg/apis/util/toml"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog"
+)
+
+const (
+	// configUpdatedOnlineReason is the reason of the Events of the config items
+	// applied online
+	configUpdatedOnlineReason = "ConfigUpdatedOnline"
+	// configUpdateOnlineFailedReason is the reason of the Events of the config
+	// items failed to be applied online, which are rolled out by rolling update
+	configUpdateOnlineFailedReason = "ConfigUpdateOnlineFailed"
+)
+
+// configFileKey is the key of the TOML config in the ConfigMaps of PD, TiKV and TiDB
+const configFileKey = "config-file"
+
+// onlineConfigItems are the patterns of the dotted names of the config items
+// of PD and TiKV that support online change, see matchConfigName for the
+// wildcards. PD items are updated by the PD API, TiKV items are updated by
+// SET CONFIG in TiDB.
+var onlineConfigItems = map[v1alpha1.MemberType][]string{
+	v1alpha1.PDMemberType: {
+		"log.level",
+		"schedule.*",
+		"replication.*",
+		"replication-mode.*",
+		"replication-mode.*.*",
+		"pd-server.use-region-storage",
+		"pd-server.max-gap-reset-ts",
+		"pd-server.key-type",
+		"pd-server.metric-storage",
+		"pd-server.flow-round-by-digit",
+	},
+	v1alpha1.TiKVMemberType: {
+		"raftstore.*",
+		"coprocessor.*",
+		"pessimistic-txn.wait-for-lock-timeout",
+		"pessimistic-txn.wake-up-delay-duration",
+		"pessimistic-txn.pipelined",
+		"gc.ratio-threshold",
+		"gc.batch-keys",
+		"gc.max-write-bytes-per-sec",
+		"gc.enable-compaction-filter",
+		"gc.compaction-filter-skip-version-check",
+		"split.qps-threshold",
+		"split.split-balance-score",
+		"split.split-contained-score",
+		"server.grpc-memory-pool-quota",
+		"backup.num-threads",
+		"storage.block-cache.capacity",
+		"*db.max-total-wal-size",
+		"*db.max-background-jobs",
+		"*db.max-open-files",
+		"*db.compaction-readahead-size",
+		"*db.bytes-per-sync",
+		"*db.wal-bytes-per-sync",
+		"*db.writable-file-max-buffer-size",
+		"*db.*.block-cache-size",
+		"*db.*.write-buffer-size",
+		"*db.*.max-write-buffer-number",
+		"*db.*.max-bytes-for-level-base",
+		"*db.*.target-file-size-base",
+		"*db.*.level0-file-num-compaction-trigger",
+		"*db.*.level0-slowdown-writes-trigger",
+		"*db.*.level0-stop-writes-trigger",
+		"*db.*.max-compaction-bytes",
+		"*db.*.max-bytes-for-level-multiplier",
+		"*db.*.disable-auto-compactions",
+		"*db.*.soft-pending-compaction-bytes-limit",
+		"*db.*.hard-pending-compaction-bytes-limit",
+		"*db.*.titan.blob-run-mode",
+	},
+}
+
+// tidbOnlineConfigVariables are the config items of TiDB that support online
+// change, mapped to the global system variables they are updated by
+var tidbOnlineConfigVariables = map[string]string{
+	"mem-quota-query":                   "tidb_mem_quota_query",
+	"oom-action":                        "tidb_mem_oom_action",
+	"performance.committer-concurrency": "tidb_committer_concurrency",
+	"performance.run-auto-analyze":      "tidb_enable_auto_analyze",
+	"prepared-plan-cache.enabled":       "tidb_enable_prepared_plan_cache",
+}
+
+// syncConfigOnline returns the strategy to update the ConfigMap of the
+// component with. If the strategy is InPlacePreferred and all the config
+// items changed from the ConfigMap in use support online change, they are
+// applied online and InPlace is returned, so that the ConfigMap is updated
+// without restarting the Pods. Otherwise InPlacePreferred falls back to
+// RollingUpdate, as well as a failure to apply the items online, which is
+// recorded as an Event.
+func syncConfigOnline(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType,
+	strategy v1alpha1.ConfigUpdateStrategy, inUseName string, desired *corev1.ConfigMap) (v1alpha1.ConfigUpdateStrategy, error) {
+	if strategy != v1alpha1.ConfigUpdateStrategyInPlacePreferred {
+		return strategy, nil
+	}
+	if inUseName == "" {
+		return v1alpha1.ConfigUpdateStrategyRollingUpdate, nil
+	}
+	existing, err := deps.ConfigMapLister.ConfigMaps(desired.Namespace).Get(inUseName)
+	if errors.IsNotFound(err) {
+		return v1alpha1.ConfigUpdateStrategyRollingUpdate, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("syncConfigOnline: failed to get configmap %s/%s, error: %v", desired.Namespace, inUseName, err)
+	}
+
+	changed, ok, err := onlineConfigChanges(tc, memberType, existing, desired)
+	if err != nil {
+		return "", err
+	}
+	if !ok || len(changed) == 0 {
+		return v1alpha1.ConfigUpdateStrategyRollingUpdate, nil
+	}
+	names := sortedConfigNames(changed)
+	if err := applyConfigOnline(deps, tc, memberType, changed); err != nil {
+		msg := fmt.Sprintf("failed to update %s config items %s online, fall back to rolling update, error: %v", memberType, strings.Join(names, ", "), err)
+		klog.Warningf("tidbcluster: [%s/%s] %s", tc.GetNamespace(), tc.GetName(), msg)
+		deps.Recorder.Event(tc, corev1.EventTypeWarning, configUpdateOnlineFailedReason, msg)
+		return v1alpha1.ConfigUpdateStrategyRollingUpdate, nil
+	}
+
+	msg := fmt.Sprintf("%s config items %s are updated online", memberType, strings.Join(names, ", "))
+	klog.Infof("tidbcluster: [%s/%s]'s %s", tc.GetNamespace(), tc.GetName(), msg)
+	deps.Recorder.Event(tc, corev1.EventTypeNormal, configUpdatedOnlineReason, msg)
+	return v1alpha1.ConfigUpdateStrategyInPlace, nil
+}
+
+// onlineConfigChanges returns the config items changed from the existing
+// ConfigMap to the desired one, and whether all the changes can be applied
+// online. The changes can't be applied online if anything other than the
+// TOML config is changed, an item is removed, or an item is overridden for
+// some ordinals, as the items are applied to all the instances online.
+func onlineConfigChanges(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, existing, desired *corev1.ConfigMap) (map[string]interface{}, bool, error) {
+	for k, v := range desired.Data {
+		if k != configFileKey && existing.Data[k] != v {
+			return nil, false, nil
+		}
+	}
+	if len(existing.Data) != len(desired.Data) {
+		return nil, false, nil
+	}
+	if memberType == v1alpha1.TiKVMemberType && tc.Spec.TiDB == nil {
+		// TiKV config is updated by TiDB
+		return nil, false, nil
+	}
+
+	oldItems, err := flattenConfig(existing.Data[configFileKey])
+	if err != nil {
+		return nil, false, fmt.Errorf("onlineConfigChanges: failed to parse config of configmap %s/%s, error: %v", existing.Namespace, existing.Name, err)
+	}
+	newItems, err := flattenConfig(desired.Data[configFileKey])
+	if err != nil {
+		return nil, false, fmt.Errorf("onlineConfigChanges: failed to parse config of configmap %s/%s, error: %v", desired.Namespace, desired.Name, err)
+	}
+	for name := range oldItems {
+		if _, exist := newItems[name]; !exist {
+			return nil, false, nil
+		}
+	}
+
+	overridden, err := perOrdinalConfigNames(tc, memberType)
+	if err != nil {
+		return nil, false, err
+	}
+
+	changed := map[string]interface{}{}
+	for name, value := range newItems {
+		if old, exist := oldItems[name]; exist && reflect.DeepEqual(old, value) {
+			continue
+		}
+		if !supportsOnlineChange(memberType, name, value) || overridden[name] {
+			return nil, false, nil
+		}
+		changed[name] = value
+	}
+	return changed, true, nil
+}
+
+// perOrdinalConfigNames returns the dotted names of the config items of the
+// component overridden for some ordinals
+func perOrdinalConfigNames(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType) (map[string]bool, error) {
+	names := map[string]bool{}
+	overrides := map[string]*config.GenericConfig{}
+	switch {
+	case memberType == v1alpha1.TiKVMemberType && tc.Spec.TiKV != nil:
+		for ordinal, override := range tc.Spec.TiKV.PerOrdinalConfig {
+			if override != nil {
+				overrides[ordinal] = override.GenericConfig
+			}
+		}
+	case memberType == v1alpha1.TiDBMemberType && tc.Spec.TiDB != nil:
+		for ordinal, override := range tc.Spec.TiDB.PerOrdinalConfig {
+			if override != nil {
+				overrides[ordinal] = override.GenericConfig
+			}
+		}
+	}
+	for ordinal, override := range overrides {
+		data, err := override.MarshalTOML()
+		if err != nil {
+			return nil, fmt.Errorf("perOrdinalConfigNames: failed to marshal the %s config of ordinal %s, error: %v", memberType, ordinal, err)
+		}
+		items, err := flattenConfig(string(data))
+		if err != nil {
+			return nil, err
+		}
+		for name := range items {
+			names[name] = true
+		}
+	}
+	return names, nil
+}
+
+// flattenConfig returns the items of the TOML config keyed by their dotted names
+func flattenConfig(data string) (map[string]interface{}, error) {
+	config := map[string]interface{}{}
+	if err := toml.Unmarshal([]byte(data), &config); err != nil {
+		return nil, err
+	}
+	items := map[string]interface{}{}
+	var flatten func(prefix string, m map[string]interface{})
+	flatten = func(prefix string, m map[string]interface{}) {
+		for k, v := range m {
+			if sub, ok := v.(map[string]interface{}); ok {
+				flatten(prefix+k+".", sub)
+				continue
+			}
+			items[prefix+k] = v
+		}
+	}
+	flatten("", config)
+	return items, nil
+}
+
+// supportsOnlineChange returns whether the config item of the component can
+// be changed online to the value
+func supportsOnlineChange(memberType v1alpha1.MemberType, name string, value interface{}) bool {
+	switch value.(type) {
+	case string, bool, int64, float64:
+	default:
+		return false
+	}
+	if memberType == v1alpha1.TiDBMemberType {
+		_, ok := tidbOnlineConfigVariables[name]
+		return ok
+	}
+	for _, pattern := range onlineConfigItems[memberType] {
+		if matchConfigName(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchConfigName returns whether the dotted name matches the pattern, a "*"
+// segment of the pattern matches any segment, and a segment like "*db"
+// matches any segment with the suffix
+func matchConfigName(pattern, name string) bool {
+	patternSegments := strings.Split(pattern, ".")
+	nameSegments := strings.Split(name, ".")
+	if len(patternSegments) != len(nameSegments) {
+		return false
+	}
+	for i, p := range patternSegments {
+		if strings.HasPrefix(p, "*") {
+			if !strings.HasSuffix(nameSegments[i], p[1:]) {
+				return false
+			}
+			continue
+		}
+		if p != nameSegments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// applyConfigOnline applies the changed config items of the component online
+func applyConfigOnline(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, changed map[string]interface{}) error {
+	switch memberType {
+	case v1alpha1.PDMemberType:
+		return controller.GetPDClient(deps.PDControl, tc).UpdateConfig(changed)
+	case v1alpha1.TiKVMemberType:
+		for _, name := range sortedConfigNames(changed) {
+			if err := deps.TiDBControl.SetConfig(tc, string(memberType), name, changed[name]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case v1alpha1.TiDBMemberType:
+		for _, name := range sortedConfigNames(changed) {
+			value := fmt.Sprintf("%v", changed[name])
+			if b, ok := changed[name].(bool); ok {
+				value = "OFF"
+				if b {
+					value = "ON"
+				}
+			}
+			if err := deps.TiDBControl.SetSystemVariable(tc, tidbOnlineConfigVariables[name], value); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("config of %s can't be updated online", memberType)
+	}
+}
+
+func sortedConfigNames(items map[string]interface{}) []string {
+	names := make([]string, 0, len(items))
+	for name := range items {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
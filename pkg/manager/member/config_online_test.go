@@ -0,0 +1,221 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+)
+
+func TestMatchConfigName(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{pattern: "raftstore.*", name: "raftstore.raft-log-gc-threshold", match: true},
+		{pattern: "raftstore.*", name: "raftstore", match: false},
+		{pattern: "*db.max-open-files", name: "raftdb.max-open-files", match: true},
+		{pattern: "*db.max-open-files", name: "rocksdb.max-open-files", match: true},
+		{pattern: "*db.max-open-files", name: "storage.max-open-files", match: false},
+		{pattern: "*db.*.block-cache-size", name: "rocksdb.writecf.block-cache-size", match: true},
+		{pattern: "*db.*.block-cache-size", name: "rocksdb.block-cache-size", match: false},
+	}
+	for _, test := range tests {
+		g.Expect(matchConfigName(test.pattern, test.name)).To(Equal(test.match), "%s %s", test.pattern, test.name)
+	}
+}
+
+func TestSyncConfigOnline(t *testing.T) {
+	tests := []struct {
+		name       string
+		memberType v1alpha1.MemberType
+		strategy   v1alpha1.ConfigUpdateStrategy
+		oldConfig  string
+		newConfig  string
+		oldScript  string
+		expected   v1alpha1.ConfigUpdateStrategy
+		configs    map[string]interface{}
+		variables  map[string]string
+		pdConfig   map[string]interface{}
+		pdErr      error
+		// override is the TOML config overridden for an ordinal of TiKV
+		override string
+		events   []string
+	}{
+		{
+			name:       "rolling update",
+			memberType: v1alpha1.TiKVMemberType,
+			strategy:   v1alpha1.ConfigUpdateStrategyRollingUpdate,
+			oldConfig:  "[raftstore]\nraft-log-gc-threshold = 50\n",
+			newConfig:  "[raftstore]\nraft-log-gc-threshold = 100\n",
+			expected:   v1alpha1.ConfigUpdateStrategyRollingUpdate,
+			events:     []string{},
+		},
+		{
+			name:       "tikv items changed online",
+			memberType: v1alpha1.TiKVMemberType,
+			strategy:   v1alpha1.ConfigUpdateStrategyInPlacePreferred,
+			oldConfig:  "[raftstore]\nraft-log-gc-threshold = 50\n[rocksdb.writecf]\nblock-cache-size = \"1GB\"\n",
+			newConfig:  "[raftstore]\nraft-log-gc-threshold = 100\n[rocksdb.writecf]\nblock-cache-size = \"2GB\"\n",
+			expected:   v1alpha1.ConfigUpdateStrategyInPlace,
+			configs: map[string]interface{}{
+				"tikv.raftstore.raft-log-gc-threshold":  int64(100),
+				"tikv.rocksdb.writecf.block-cache-size": "2GB",
+			},
+			events: []string{"Normal ConfigUpdatedOnline tikv config items raftstore.raft-log-gc-threshold, rocksdb.writecf.block-cache-size are updated online"},
+		},
+		{
+			name:       "tikv item not supporting online change",
+			memberType: v1alpha1.TiKVMemberType,
+			strategy:   v1alpha1.ConfigUpdateStrategyInPlacePreferred,
+			oldConfig:  "[raftstore]\nraft-log-gc-threshold = 50\n",
+			newConfig:  "[raftstore]\nraft-log-gc-threshold = 100\n[storage]\nreserve-space = \"2GB\"\n",
+			expected:   v1alpha1.ConfigUpdateStrategyRollingUpdate,
+			events:     []string{},
+		},
+		{
+			name:       "tikv item removed",
+			memberType: v1alpha1.TiKVMemberType,
+			strategy:   v1alpha1.ConfigUpdateStrategyInPlacePreferred,
+			oldConfig:  "[raftstore]\nraft-log-gc-threshold = 50\n",
+			newConfig:  "",
+			expected:   v1alpha1.ConfigUpdateStrategyRollingUpdate,
+			events:     []string{},
+		},
+		{
+			name:       "startup script changed",
+			memberType: v1alpha1.TiKVMemberType,
+			strategy:   v1alpha1.ConfigUpdateStrategyInPlacePreferred,
+			oldConfig:  "[raftstore]\nraft-log-gc-threshold = 50\n",
+			newConfig:  "[raftstore]\nraft-log-gc-threshold = 100\n",
+			oldScript:  "old",
+			expected:   v1alpha1.ConfigUpdateStrategyRollingUpdate,
+			events:     []string{},
+		},
+		{
+			name:       "tidb items changed online",
+			memberType: v1alpha1.TiDBMemberType,
+			strategy:   v1alpha1.ConfigUpdateStrategyInPlacePreferred,
+			oldConfig:  "oom-action = \"log\"\n[performance]\nrun-auto-analyze = true\n",
+			newConfig:  "oom-action = \"cancel\"\n[performance]\nrun-auto-analyze = false\n",
+			expected:   v1alpha1.ConfigUpdateStrategyInPlace,
+			variables: map[string]string{
+				"tidb_mem_oom_action":      "cancel",
+				"tidb_enable_auto_analyze": "OFF",
+			},
+			events: []string{"Normal ConfigUpdatedOnline tidb config items oom-action, performance.run-auto-analyze are updated online"},
+		},
+		{
+			name:       "pd items changed online",
+			memberType: v1alpha1.PDMemberType,
+			strategy:   v1alpha1.ConfigUpdateStrategyInPlacePreferred,
+			oldConfig:  "[schedule]\nleader-schedule-limit = 4\n",
+			newConfig:  "[schedule]\nleader-schedule-limit = 8\n",
+			expected:   v1alpha1.ConfigUpdateStrategyInPlace,
+			pdConfig:   map[string]interface{}{"schedule.leader-schedule-limit": int64(8)},
+			events:     []string{"Normal ConfigUpdatedOnline pd config items schedule.leader-schedule-limit are updated online"},
+		},
+		{
+			name:       "pd items failed to be changed online",
+			memberType: v1alpha1.PDMemberType,
+			strategy:   v1alpha1.ConfigUpdateStrategyInPlacePreferred,
+			oldConfig:  "[schedule]\nleader-schedule-limit = 4\n",
+			newConfig:  "[schedule]\nleader-schedule-limit = 8\n",
+			expected:   v1alpha1.ConfigUpdateStrategyRollingUpdate,
+			pdConfig:   map[string]interface{}{"schedule.leader-schedule-limit": int64(8)},
+			pdErr:      fmt.Errorf("pd is unavailable"),
+			events:     []string{"Warning ConfigUpdateOnlineFailed failed to update pd config items schedule.leader-schedule-limit online, fall back to rolling update, error: pd is unavailable"},
+		},
+		{
+			name:       "tikv item overridden for an ordinal",
+			memberType: v1alpha1.TiKVMemberType,
+			strategy:   v1alpha1.ConfigUpdateStrategyInPlacePreferred,
+			oldConfig:  "[raftstore]\nraft-log-gc-threshold = 50\n",
+			newConfig:  "[raftstore]\nraft-log-gc-threshold = 100\n",
+			override:   "[raftstore]\nraft-log-gc-threshold = 80\n",
+			expected:   v1alpha1.ConfigUpdateStrategyRollingUpdate,
+			events:     []string{},
+		},
+		{
+			name:       "tikv item not overridden for an ordinal",
+			memberType: v1alpha1.TiKVMemberType,
+			strategy:   v1alpha1.ConfigUpdateStrategyInPlacePreferred,
+			oldConfig:  "[raftstore]\nraft-log-gc-threshold = 50\n",
+			newConfig:  "[raftstore]\nraft-log-gc-threshold = 100\n",
+			override:   "[gc]\nbatch-keys = 256\n",
+			expected:   v1alpha1.ConfigUpdateStrategyInPlace,
+			configs: map[string]interface{}{
+				"tikv.raftstore.raft-log-gc-threshold": int64(100),
+			},
+			events: []string{"Normal ConfigUpdatedOnline tikv config items raftstore.raft-log-gc-threshold are updated online"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			deps := controller.NewFakeDependencies()
+			tc := newTidbClusterForPD()
+			tc.Spec.TiDB = &v1alpha1.TiDBSpec{}
+			var pdConfig map[string]interface{}
+			pdClient := controller.NewFakePDClient(deps.PDControl.(*pdapi.FakePDControl), tc)
+			pdClient.AddReaction(pdapi.UpdateConfigActionType, func(action *pdapi.Action) (interface{}, error) {
+				pdConfig = action.Config
+				return nil, test.pdErr
+			})
+			if test.override != "" {
+				override := v1alpha1.NewTiKVConfig()
+				g.Expect(override.UnmarshalTOML([]byte(test.override))).To(Succeed())
+				tc.Spec.TiKV.Config = v1alpha1.NewTiKVConfig()
+				tc.Spec.TiKV.PerOrdinalConfig = map[string]*v1alpha1.TiKVConfigWraper{"1": override}
+			}
+
+			script := "script"
+			existing := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-cm-1234", Namespace: corev1.NamespaceDefault},
+				Data:       map[string]string{configFileKey: test.oldConfig, "startup-script": script},
+			}
+			if test.oldScript != "" {
+				existing.Data["startup-script"] = test.oldScript
+			}
+			g.Expect(deps.LabelFilterKubeInformerFactory.Core().V1().ConfigMaps().Informer().GetIndexer().Add(existing)).To(Succeed())
+			desired := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-cm", Namespace: corev1.NamespaceDefault},
+				Data:       map[string]string{configFileKey: test.newConfig, "startup-script": script},
+			}
+
+			strategy, err := syncConfigOnline(deps, tc, test.memberType, test.strategy, existing.Name, desired)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(strategy).To(Equal(test.expected))
+			tidbControl := deps.TiDBControl.(*controller.FakeTiDBControl)
+			g.Expect(tidbControl.Configs).To(Equal(test.configs))
+			g.Expect(tidbControl.SystemVariables).To(Equal(test.variables))
+			g.Expect(pdConfig).To(Equal(test.pdConfig))
+			g.Expect(collectEvents(deps.Recorder.(*record.FakeRecorder).Events)).To(Equal(test.events))
+		})
+	}
+}
@@ -618,7 +618,7 @@ func getNewMasterSetForDMCluster(dc *v1alpha1.DMCluster, cm *corev1.ConfigMap) (
 			},
 		},
 		VolumeMounts: volMounts,
-		Resources:    controller.ContainerResource(dc.Spec.Master.ResourceRequirements),
+		Resources:    controller.ContainerResourceWithEphemeralStorage(dc.Spec.Master.ResourceRequirements, dc.Spec.Master.EphemeralStorage),
 	}
 	env := []corev1.EnvVar{
 		{
@@ -662,6 +662,7 @@ func getNewMasterSetForDMCluster(dc *v1alpha1.DMCluster, cm *corev1.ConfigMap) (
 	masterContainer.Env = util.AppendEnv(env, baseMasterSpec.Env())
 	podSpec.Volumes = append(vols, baseMasterSpec.AdditionalVolumes()...)
 	podSpec.Containers = append([]corev1.Container{masterContainer}, baseMasterSpec.AdditionalContainers()...)
+	setMainContainerSecurityContext(&podSpec, baseMasterSpec, v1alpha1.DMMasterMemberType.String(), nil)
 
 	masterSet := &apps.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
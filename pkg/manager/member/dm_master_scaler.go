@@ -20,6 +20,7 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/metrics"
 
 	apps "k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -32,14 +33,17 @@ type masterScaler struct {
 
 // NewMasterScaler returns a DMScaler
 func NewMasterScaler(deps *controller.Dependencies) Scaler {
-	return &masterScaler{
+	return withScaleMetrics(v1alpha1.DMMasterMemberType, &masterScaler{
 		generalScaler: generalScaler{
 			deps: deps,
 		},
-	}
+	})
 }
 
 func (s *masterScaler) Scale(meta metav1.Object, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	if s.deferScaleToMaintenanceWindow(meta, v1alpha1.DMMasterMemberType, oldSet, newSet) {
+		return nil
+	}
 	scaling, _, _, _ := scaleOne(oldSet, newSet)
 	if scaling > 0 {
 		return s.ScaleOut(meta, oldSet, newSet)
@@ -162,6 +166,7 @@ func (s *masterScaler) ScaleIn(meta metav1.Object, oldSet *apps.StatefulSet, new
 	}
 	klog.Infof("dm-master scale in: set pvc %s/%s annotation: %s to %s",
 		ns, pvcName, label.AnnPVCDeferDeleting, now)
+	recordPVCDeferOperation(dc, v1alpha1.DMMasterMemberType.String(), metrics.PVCDeferOperationMark)
 
 	setReplicasAndDeleteSlots(newSet, replicas, deleteSlots)
 	return nil
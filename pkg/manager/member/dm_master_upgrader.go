@@ -100,6 +100,9 @@ func (u *masterUpgrader) gracefulUpgrade(dc *v1alpha1.DMCluster, oldSet *apps.St
 		//	return nil
 		//}
 
+		if waitForDMMaintenanceWindow(dc, v1alpha1.DMMasterMemberType, "upgrade") {
+			return nil
+		}
 		return u.upgradeMasterPod(dc, i, newSet)
 	}
 
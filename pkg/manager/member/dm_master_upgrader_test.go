@@ -16,6 +16,7 @@ package member
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -27,6 +28,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	utildmcluster "github.com/pingcap/tidb-operator/pkg/util/dmcluster"
 	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/pointer"
@@ -34,6 +36,9 @@ import (
 
 func TestMasterUpgraderUpgrade(t *testing.T) {
 	g := NewGomegaWithT(t)
+	// 2021-01-02 is a Saturday
+	maintenanceWindowNow = func() time.Time { return time.Date(2021, 1, 2, 1, 0, 0, 0, time.UTC) }
+	defer func() { maintenanceWindowNow = time.Now }()
 
 	type testcase struct {
 		name              string
@@ -105,6 +110,25 @@ func TestMasterUpgraderUpgrade(t *testing.T) {
 				g.Expect(newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(pointer.Int32Ptr(1)))
 			},
 		},
+		{
+			name: "outside the maintenance window",
+			changeFn: func(dc *v1alpha1.DMCluster) {
+				dc.Status.Master.Synced = true
+				dc.Spec.MaintenanceWindow = &v1alpha1.MaintenanceWindow{Schedule: "0 2 * * 6", Duration: "4h"}
+			},
+			changePods:        nil,
+			changeOldSet:      nil,
+			transferLeaderErr: false,
+			errExpectFn: func(g *GomegaWithT, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+			},
+			expectFn: func(g *GomegaWithT, dc *v1alpha1.DMCluster, newSet *apps.StatefulSet) {
+				g.Expect(newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(pointer.Int32Ptr(2)))
+				cond := utildmcluster.GetDMClusterCondition(dc.Status, v1alpha1.DMClusterWaitingForMaintenanceWindow)
+				g.Expect(cond).NotTo(BeNil())
+				g.Expect(cond.Message).To(Equal("dm-master upgrade is queued until the maintenance window opens at 2021-01-02T02:00:00Z"))
+			},
+		},
 		{
 			name: "modify oldSet update strategy to OnDelete",
 			changeFn: func(dc *v1alpha1.DMCluster) {
@@ -15,10 +15,12 @@ package member
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/dmapi"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog"
@@ -38,16 +40,23 @@ func (f *workerFailover) Failover(dc *v1alpha1.DMCluster) error {
 	dcName := dc.GetName()
 
 	for podName, worker := range dc.Status.Worker.Members {
-		if worker.LastTransitionTime.IsZero() {
-			continue
-		}
 		if !isWorkerPodDesired(dc, podName) {
 			// we should ignore the store record of deleted pod, otherwise the
 			// record of deleted pod may be added back to failure stores
 			// (before it enters into Offline/Tombstone state)
 			continue
 		}
-		deadline := worker.LastTransitionTime.Add(f.deps.CLIConfig.WorkerFailoverPeriod)
+		var msg string
+		switch {
+		case worker.Stage == v1alpha1.DMWorkerStateOffline && !worker.LastTransitionTime.IsZero() &&
+			time.Now().After(worker.LastTransitionTime.Add(f.deps.CLIConfig.WorkerFailoverPeriod)):
+			msg = fmt.Sprintf("worker[%s/%s] is Offline", ns, worker.Name)
+		case worker.Stage == v1alpha1.DMWorkerStateBound && worker.StuckSince != nil &&
+			time.Now().After(worker.StuckSince.Add(f.deps.CLIConfig.WorkerFailoverPeriod)):
+			msg = fmt.Sprintf("worker[%s/%s] is stuck, its subtasks are paused by errors", ns, worker.Name)
+		default:
+			continue
+		}
 		exist := false
 		for _, failureWorker := range dc.Status.Worker.FailureMembers {
 			if failureWorker.PodName == podName {
@@ -55,7 +64,7 @@ func (f *workerFailover) Failover(dc *v1alpha1.DMCluster) error {
 				break
 			}
 		}
-		if worker.Stage == v1alpha1.DMWorkerStateOffline && time.Now().After(deadline) && !exist {
+		if !exist {
 			if dc.Status.Worker.FailureMembers == nil {
 				dc.Status.Worker.FailureMembers = map[string]v1alpha1.WorkerFailureMember{}
 			}
@@ -63,21 +72,114 @@ func (f *workerFailover) Failover(dc *v1alpha1.DMCluster) error {
 				maxFailoverCount := *dc.Spec.Worker.MaxFailoverCount
 				if len(dc.Status.Worker.FailureMembers) >= int(maxFailoverCount) {
 					klog.Warningf("%s/%s failure workers count reached the limit: %d", ns, dcName, *dc.Spec.Worker.MaxFailoverCount)
-					return nil
+					return f.transferSources(dc)
 				}
 				dc.Status.Worker.FailureMembers[podName] = v1alpha1.WorkerFailureMember{
 					PodName:   podName,
 					CreatedAt: metav1.Now(),
+					Source:    worker.Source,
 				}
-				msg := fmt.Sprintf("worker[%s/%s] is Offline", ns, worker.Name)
 				f.deps.Recorder.Event(dc, corev1.EventTypeWarning, unHealthEventReason, fmt.Sprintf(unHealthEventMsgPattern, "worker", podName, msg))
 			}
 		}
 	}
 
+	return f.transferSources(dc)
+}
+
+// transferSources transfers the sources bound to the failure workers to the
+// free workers, e.g. the replacements scaled out for them, so that the tasks
+// of the sources are resumed there. The sources rebound by dm-master itself are
+// only recorded.
+func (f *workerFailover) transferSources(dc *v1alpha1.DMCluster) error {
+	ns := dc.GetNamespace()
+	dcName := dc.GetName()
+
+	var freeWorkers []string
+	for name, worker := range dc.Status.Worker.Members {
+		if _, failed := dc.Status.Worker.FailureMembers[name]; !failed && worker.Stage == v1alpha1.DMWorkerStateFree {
+			freeWorkers = append(freeWorkers, name)
+		}
+	}
+	sort.Strings(freeWorkers)
+
+	for podName, failureWorker := range dc.Status.Worker.FailureMembers {
+		if failureWorker.Source == "" || failureWorker.SourceTransferredTo != "" {
+			continue
+		}
+
+		for name, worker := range dc.Status.Worker.Members {
+			if _, failed := dc.Status.Worker.FailureMembers[name]; !failed && worker.Stage == v1alpha1.DMWorkerStateBound && worker.Source == failureWorker.Source {
+				failureWorker.SourceTransferredTo = name
+				break
+			}
+		}
+		if failureWorker.SourceTransferredTo == "" {
+			if len(freeWorkers) == 0 {
+				klog.Infof("%s/%s no free worker to transfer source %s of failure worker %s to, wait for the replacement", ns, dcName, failureWorker.Source, podName)
+				continue
+			}
+			worker := freeWorkers[0]
+			if failureWorker.SourceTransferDeferred {
+				klog.V(4).Infof("%s/%s source %s of failure worker %s is deferred to dm-master to rebind", ns, dcName, failureWorker.Source, podName)
+				continue
+			}
+			if err := controller.GetMasterClient(f.deps.DMMasterControl, dc).TransferSource(failureWorker.Source, worker); err != nil {
+				if err == dmapi.ErrOpenAPIDisabled {
+					// dm-master rebinds the source to a free worker by itself
+					// later, which is recorded above once it's done
+					klog.Warningf("%s/%s failed to transfer source %s of failure worker %s to worker %s: %v", ns, dcName, failureWorker.Source, podName, worker, err)
+					f.deps.Recorder.Eventf(dc, corev1.EventTypeWarning, "WorkerSourceTransferFailed", "source %s of failure worker %s is not transferred to worker %s, wait for dm-master to rebind it: %v", failureWorker.Source, podName, worker, err)
+					failureWorker.SourceTransferDeferred = true
+					dc.Status.Worker.FailureMembers[podName] = failureWorker
+					continue
+				}
+				return fmt.Errorf("failed to transfer source %s of failure worker %s/%s to worker %s, error: %v", failureWorker.Source, ns, podName, worker, err)
+			}
+			freeWorkers = freeWorkers[1:]
+			failureWorker.SourceTransferredTo = worker
+			f.deps.Recorder.Eventf(dc, corev1.EventTypeNormal, "WorkerSourceTransferred", "source %s of failure worker %s transferred to worker %s", failureWorker.Source, podName, worker)
+		}
+		klog.Infof("%s/%s source %s of failure worker %s is transferred to worker %s", ns, dcName, failureWorker.Source, podName, failureWorker.SourceTransferredTo)
+		dc.Status.Worker.FailureMembers[podName] = failureWorker
+	}
 	return nil
 }
 
+// syncStuckWorkers sets the StuckSince of the bound workers whose subtasks are
+// paused by errors, and clears it of the others. The StuckSince in workers is
+// kept as it is if the subtasks can't be listed.
+func syncStuckWorkers(dc *v1alpha1.DMCluster, dmClient dmapi.MasterClient, workers map[string]v1alpha1.WorkerMember) {
+	ns := dc.GetNamespace()
+	dcName := dc.GetName()
+
+	subTasks, err := dmClient.GetSubTasks()
+	if err == dmapi.ErrOpenAPIDisabled {
+		klog.V(4).Infof("%s/%s OpenAPI of dm-master is disabled, skip detecting the stuck workers", ns, dcName)
+		subTasks = nil
+	} else if err != nil {
+		klog.Warningf("%s/%s failed to get the subtasks to detect the stuck workers: %v", ns, dcName, err)
+		return
+	}
+
+	stuck := map[string]bool{}
+	for _, subTask := range subTasks {
+		if subTask.Stage == dmapi.SubTaskStagePaused && subTask.ErrorMsg != "" {
+			stuck[subTask.WorkerName] = true
+		}
+	}
+	for name, worker := range workers {
+		if worker.Stage != v1alpha1.DMWorkerStateBound || !stuck[name] {
+			worker.StuckSince = nil
+		} else if worker.StuckSince == nil {
+			now := metav1.Now()
+			worker.StuckSince = &now
+			klog.Infof("%s/%s worker %s is stuck, its subtasks are paused by errors", ns, dcName, name)
+		}
+		workers[name] = worker
+	}
+}
+
 func (f *workerFailover) Recover(dc *v1alpha1.DMCluster) {
 	dc.Status.Worker.FailureMembers = nil
 	klog.Infof("dm-worker recover: clear FailureWorkers, %s/%s", dc.GetNamespace(), dc.GetName())
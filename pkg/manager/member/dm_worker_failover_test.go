@@ -14,13 +14,16 @@
 package member
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
 	. "github.com/onsi/gomega"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/dmapi"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 )
 
@@ -66,6 +69,31 @@ func TestWorkerFailoverFailover(t *testing.T) {
 				g.Expect(len(dc.Status.Worker.FailureMembers)).To(Equal(0))
 			},
 		},
+		{
+			name: "dm-worker is stuck",
+			update: func(dc *v1alpha1.DMCluster) {
+				dc.Status.Worker.Members = map[string]v1alpha1.WorkerMember{
+					"1": {
+						Stage:      v1alpha1.DMWorkerStateBound,
+						Name:       "dm-worker-1",
+						Source:     "mysql-replica-01",
+						StuckSince: &metav1.Time{Time: time.Now().Add(-70 * time.Minute)},
+					},
+					"2": {
+						Stage:      v1alpha1.DMWorkerStateBound,
+						Name:       "dm-worker-2",
+						Source:     "mysql-replica-02",
+						StuckSince: &metav1.Time{Time: time.Now().Add(-30 * time.Minute)},
+					},
+				}
+			},
+			err: false,
+			expectFn: func(t *testing.T, dc *v1alpha1.DMCluster) {
+				g := NewGomegaWithT(t)
+				g.Expect(len(dc.Status.Worker.FailureMembers)).To(Equal(1))
+				g.Expect(dc.Status.Worker.FailureMembers["1"].Source).To(Equal("mysql-replica-01"))
+			},
+		},
 		{
 			name: "deadline not exceed",
 			update: func(dc *v1alpha1.DMCluster) {
@@ -291,3 +319,174 @@ func TestWorkerFailoverFailover(t *testing.T) {
 		})
 	}
 }
+
+func TestWorkerFailoverTransferSources(t *testing.T) {
+	tests := []struct {
+		name             string
+		members          map[string]v1alpha1.WorkerMember
+		transferErr      error
+		err              bool
+		expectTransfer   string
+		expectTransferTo string
+		expectEvent      string
+		expectDeferred   bool
+	}{
+		{
+			name: "the source is transferred to the free worker",
+			members: map[string]v1alpha1.WorkerMember{
+				"dm-worker-1": {Stage: v1alpha1.DMWorkerStateFree, Name: "dm-worker-1"},
+				"dm-worker-2": {Stage: v1alpha1.DMWorkerStateBound, Name: "dm-worker-2", Source: "mysql-replica-02"},
+			},
+			expectTransfer:   "dm-worker-1",
+			expectTransferTo: "dm-worker-1",
+		},
+		{
+			name: "no free worker to transfer the source to",
+			members: map[string]v1alpha1.WorkerMember{
+				"dm-worker-2": {Stage: v1alpha1.DMWorkerStateBound, Name: "dm-worker-2", Source: "mysql-replica-02"},
+			},
+		},
+		{
+			name: "the source is rebound by dm-master",
+			members: map[string]v1alpha1.WorkerMember{
+				"dm-worker-1": {Stage: v1alpha1.DMWorkerStateFree, Name: "dm-worker-1"},
+				"dm-worker-2": {Stage: v1alpha1.DMWorkerStateBound, Name: "dm-worker-2", Source: "mysql-replica-01"},
+			},
+			expectTransferTo: "dm-worker-2",
+		},
+		{
+			name: "failed to transfer the source",
+			members: map[string]v1alpha1.WorkerMember{
+				"dm-worker-1": {Stage: v1alpha1.DMWorkerStateFree, Name: "dm-worker-1"},
+			},
+			transferErr:    fmt.Errorf("failed to transfer source"),
+			err:            true,
+			expectTransfer: "dm-worker-1",
+		},
+		{
+			name: "the openapi of dm-master is disabled",
+			members: map[string]v1alpha1.WorkerMember{
+				"dm-worker-1": {Stage: v1alpha1.DMWorkerStateFree, Name: "dm-worker-1"},
+			},
+			transferErr:    dmapi.ErrOpenAPIDisabled,
+			expectTransfer: "dm-worker-1",
+			expectEvent:    "Warning WorkerSourceTransferFailed",
+			expectDeferred: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			dc := newDMClusterForMaster()
+			dc.Spec.Worker.Replicas = 3
+			dc.Spec.Worker.MaxFailoverCount = pointer.Int32Ptr(3)
+			dc.Status.Worker.Members = tt.members
+			dc.Status.Worker.Members["dm-worker-0"] = v1alpha1.WorkerMember{
+				Stage:              v1alpha1.DMWorkerStateOffline,
+				Name:               "dm-worker-0",
+				Source:             "mysql-replica-01",
+				LastTransitionTime: metav1.Time{Time: time.Now().Add(-2 * time.Hour)},
+			}
+
+			fakeDeps := controller.NewFakeDependencies()
+			fakeDeps.CLIConfig.WorkerFailoverPeriod = 1 * time.Hour
+			masterClient := controller.NewFakeMasterClient(fakeDeps.DMMasterControl.(*dmapi.FakeMasterControl), dc)
+			transferred := ""
+			masterClient.AddReaction(dmapi.TransferSourceActionType, func(action *dmapi.Action) (interface{}, error) {
+				g.Expect(action.Labels["source"]).To(Equal("mysql-replica-01"))
+				transferred = action.Name
+				return nil, tt.transferErr
+			})
+			workerFailover := &workerFailover{deps: fakeDeps}
+
+			err := workerFailover.Failover(dc)
+			if tt.err {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			g.Expect(transferred).To(Equal(tt.expectTransfer))
+			g.Expect(dc.Status.Worker.FailureMembers).To(HaveKey("dm-worker-0"))
+			failureWorker := dc.Status.Worker.FailureMembers["dm-worker-0"]
+			g.Expect(failureWorker.Source).To(Equal("mysql-replica-01"))
+			g.Expect(failureWorker.SourceTransferredTo).To(Equal(tt.expectTransferTo))
+			g.Expect(failureWorker.SourceTransferDeferred).To(Equal(tt.expectDeferred))
+			events := collectEvents(fakeDeps.Recorder.(*record.FakeRecorder).Events)
+			if tt.expectEvent != "" {
+				g.Expect(events).To(ContainElement(HavePrefix(tt.expectEvent)))
+			}
+
+			if tt.expectDeferred {
+				// the deferred transfer is neither retried nor recorded again
+				transferred = ""
+				g.Expect(workerFailover.Failover(dc)).To(Succeed())
+				g.Expect(transferred).To(BeEmpty())
+				g.Expect(collectEvents(fakeDeps.Recorder.(*record.FakeRecorder).Events)).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestSyncStuckWorkers(t *testing.T) {
+	stuckSince := metav1.NewTime(time.Now().Add(-time.Hour))
+	tests := []struct {
+		name        string
+		subTasks    []*dmapi.SubTaskStatus
+		err         error
+		expectStuck map[string]bool
+		expectKept  bool
+	}{
+		{
+			name: "the subtasks are paused by errors",
+			subTasks: []*dmapi.SubTaskStatus{
+				{Name: "task", SourceName: "mysql-replica-01", WorkerName: "dm-worker-0", Stage: dmapi.SubTaskStagePaused, ErrorMsg: "connection refused"},
+				{Name: "task", SourceName: "mysql-replica-02", WorkerName: "dm-worker-1", Stage: dmapi.SubTaskStagePaused, ErrorMsg: "connection refused"},
+				{Name: "task", SourceName: "mysql-replica-03", WorkerName: "dm-worker-2", Stage: dmapi.SubTaskStagePaused},
+			},
+			expectStuck: map[string]bool{"dm-worker-0": true, "dm-worker-1": true},
+			expectKept:  true,
+		},
+		{
+			name: "the subtasks are running",
+			subTasks: []*dmapi.SubTaskStatus{
+				{Name: "task", SourceName: "mysql-replica-01", WorkerName: "dm-worker-0", Stage: "Running"},
+			},
+			expectStuck: map[string]bool{},
+		},
+		{
+			name:        "the openapi of dm-master is disabled",
+			err:         dmapi.ErrOpenAPIDisabled,
+			expectStuck: map[string]bool{},
+		},
+		{
+			name:        "failed to get the subtasks",
+			err:         fmt.Errorf("failed to get the subtasks"),
+			expectStuck: map[string]bool{"dm-worker-0": true},
+			expectKept:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			dc := newDMClusterForMaster()
+			fakeDeps := controller.NewFakeDependencies()
+			masterClient := controller.NewFakeMasterClient(fakeDeps.DMMasterControl.(*dmapi.FakeMasterControl), dc)
+			masterClient.AddReaction(dmapi.GetSubTasksActionType, func(action *dmapi.Action) (interface{}, error) {
+				return tt.subTasks, tt.err
+			})
+			workers := map[string]v1alpha1.WorkerMember{
+				"dm-worker-0": {Name: "dm-worker-0", Stage: v1alpha1.DMWorkerStateBound, StuckSince: &stuckSince},
+				"dm-worker-1": {Name: "dm-worker-1", Stage: v1alpha1.DMWorkerStateBound},
+				"dm-worker-2": {Name: "dm-worker-2", Stage: v1alpha1.DMWorkerStateBound},
+			}
+
+			syncStuckWorkers(dc, masterClient, workers)
+			for name, worker := range workers {
+				g.Expect(worker.StuckSince != nil).To(Equal(tt.expectStuck[name]), name)
+			}
+			if tt.expectKept {
+				g.Expect(workers["dm-worker-0"].StuckSince).To(Equal(&stuckSince))
+			}
+		})
+	}
+}
@@ -217,6 +217,16 @@ func (m *workerMemberManager) syncWorkerStatefulSetForDMCluster(dc *v1alpha1.DMC
 		}
 	}
 
+	// dm-worker is upgraded by the native rolling update of the statefulset,
+	// so the new template is kept back until the maintenance window opens
+	if !templateEqual(newSts, oldSts) && waitForDMMaintenanceWindow(dc, v1alpha1.DMWorkerMemberType, "upgrade") {
+		_, podSpec, err := GetLastAppliedConfig(oldSts)
+		if err != nil {
+			return err
+		}
+		newSts.Spec.Template.Spec = *podSpec
+	}
+
 	return UpdateStatefulSet(m.deps.StatefulSetControl, dc, newSts, oldSts)
 }
 
@@ -252,16 +262,23 @@ func (m *workerMemberManager) syncDMClusterStatus(dc *v1alpha1.DMCluster, set *a
 	for _, worker := range workersInfo {
 		name := worker.Name
 		status := v1alpha1.WorkerMember{
-			Name:  name,
-			Addr:  worker.Addr,
-			Stage: worker.Stage,
+			Name:   name,
+			Addr:   worker.Addr,
+			Stage:  worker.Stage,
+			Source: worker.Source,
 		}
 
 		oldWorkerMember, exist := dc.Status.Worker.Members[name]
+		// dm-master unbinds the source of the worker gone offline, keep it
+		// so that it can be transferred in failover
+		if exist && status.Source == "" && status.Stage == v1alpha1.DMWorkerStateOffline {
+			status.Source = oldWorkerMember.Source
+		}
 
 		status.LastTransitionTime = metav1.Now()
 		if exist && status.Stage == oldWorkerMember.Stage {
 			status.LastTransitionTime = oldWorkerMember.LastTransitionTime
+			status.StuckSince = oldWorkerMember.StuckSince
 		}
 
 		workerStatus[name] = status
@@ -277,6 +294,8 @@ func (m *workerMemberManager) syncDMClusterStatus(dc *v1alpha1.DMCluster, set *a
 		}
 	}
 
+	syncStuckWorkers(dc, dmClient, workerStatus)
+
 	dc.Status.Worker.Synced = true
 	dc.Status.Worker.Members = workerStatus
 	dc.Status.Worker.Image = ""
@@ -428,7 +447,7 @@ func getNewWorkerSetForDMCluster(dc *v1alpha1.DMCluster, cm *corev1.ConfigMap) (
 			},
 		},
 		VolumeMounts: volMounts,
-		Resources:    controller.ContainerResource(dc.Spec.Worker.ResourceRequirements),
+		Resources:    controller.ContainerResourceWithEphemeralStorage(dc.Spec.Worker.ResourceRequirements, dc.Spec.Worker.EphemeralStorage),
 	}
 	env := []corev1.EnvVar{
 		{
@@ -472,6 +491,7 @@ func getNewWorkerSetForDMCluster(dc *v1alpha1.DMCluster, cm *corev1.ConfigMap) (
 	workerContainer.Env = util.AppendEnv(env, baseWorkerSpec.Env())
 	podSpec.Volumes = append(vols, baseWorkerSpec.AdditionalVolumes()...)
 	podSpec.Containers = append([]corev1.Container{workerContainer}, baseWorkerSpec.AdditionalContainers()...)
+	setMainContainerSecurityContext(&podSpec, baseWorkerSpec, v1alpha1.DMWorkerMemberType.String(), nil)
 
 	workerSet := &apps.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
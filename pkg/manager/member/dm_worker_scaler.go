@@ -21,6 +21,7 @@ import (
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/metrics"
 	apps "k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog"
@@ -32,14 +33,17 @@ type workerScaler struct {
 
 // NewWorkerScaler returns a DMScaler
 func NewWorkerScaler(deps *controller.Dependencies) Scaler {
-	return &workerScaler{
+	return withScaleMetrics(v1alpha1.DMWorkerMemberType, &workerScaler{
 		generalScaler: generalScaler{
 			deps: deps,
 		},
-	}
+	})
 }
 
 func (s *workerScaler) Scale(meta metav1.Object, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	if s.deferScaleToMaintenanceWindow(meta, v1alpha1.DMWorkerMemberType, oldSet, newSet) {
+		return nil
+	}
 	scaling, _, _, _ := scaleOne(oldSet, newSet)
 	if scaling > 0 {
 		return s.ScaleOut(meta, oldSet, newSet)
@@ -121,6 +125,7 @@ func (s *workerScaler) ScaleIn(meta metav1.Object, oldSet *apps.StatefulSet, new
 	}
 	klog.Infof("dm-worker scale in: set pvc %s/%s annotation: %s to %s",
 		ns, pvcName, label.AnnPVCDeferDeleting, now)
+	recordPVCDeferOperation(dc, v1alpha1.DMWorkerMemberType.String(), metrics.PVCDeferOperationMark)
 
 	setReplicasAndDeleteSlots(newSet, replicas, deleteSlots)
 	return nil
@@ -0,0 +1,231 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	utildmcluster "github.com/pingcap/tidb-operator/pkg/util/dmcluster"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
+	"github.com/robfig/cron"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+// maintenanceWindowReason is the reason of the WaitingForMaintenanceWindow
+// condition and of the blocked scales
+const maintenanceWindowReason = "WaitingForMaintenanceWindow"
+
+// maintenanceWindowNow returns the current time, it's a variable to be
+// replaced in the tests
+var maintenanceWindowNow = time.Now
+
+// waitForMaintenanceWindow returns whether the operation of the component,
+// e.g. "upgrade" or "scale", has to wait as it's outside the
+// spec.maintenanceWindow of the tc, in which case the WaitingForMaintenanceWindow
+// condition is set. An invalid schedule never opens the window, so that no
+// disruptive operation starts by mistake.
+func waitForMaintenanceWindow(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, operation string) bool {
+	msg := maintenanceWindowWait(tc.Spec.MaintenanceWindow, "tidbcluster", tc, memberType, operation)
+	syncMaintenanceWindowCondition(tc, memberType, operation, msg)
+	return msg != nil
+}
+
+// waitForDMMaintenanceWindow is waitForMaintenanceWindow for the components
+// of a dc
+func waitForDMMaintenanceWindow(dc *v1alpha1.DMCluster, memberType v1alpha1.MemberType, operation string) bool {
+	msg := maintenanceWindowWait(dc.Spec.MaintenanceWindow, "dmcluster", dc, memberType, operation)
+	syncDMMaintenanceWindowCondition(dc, memberType, operation, msg)
+	return msg != nil
+}
+
+// maintenanceWindowWait returns why the operation has to wait for the
+// window, or nil if it may go on now
+func maintenanceWindowWait(window *v1alpha1.MaintenanceWindow, kind string, meta metav1.Object, memberType v1alpha1.MemberType, operation string) *string {
+	if window == nil {
+		return nil
+	}
+
+	now := maintenanceWindowNow().UTC()
+	open, next, err := inMaintenanceWindow(window, now)
+	if err != nil {
+		msg := fmt.Sprintf("%s %s is queued as the maintenance window is invalid: %v", memberType, operation, err)
+		klog.Errorf("%s: [%s/%s]'s %s", kind, meta.GetNamespace(), meta.GetName(), msg)
+		return &msg
+	}
+	if open {
+		return nil
+	}
+
+	msg := fmt.Sprintf("%s %s is queued until the maintenance window opens at %s", memberType, operation, next.Format(time.RFC3339))
+	klog.Infof("%s: [%s/%s]'s %s", kind, meta.GetNamespace(), meta.GetName(), msg)
+	return &msg
+}
+
+// inMaintenanceWindow returns whether now is in the window, and when the
+// window opens next if it's not.
+func inMaintenanceWindow(window *v1alpha1.MaintenanceWindow, now time.Time) (bool, time.Time, error) {
+	schedule, err := cron.ParseStandard(window.Schedule)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("invalid schedule %q: %v", window.Schedule, err)
+	}
+	duration, err := time.ParseDuration(window.Duration)
+	if err != nil || duration <= 0 {
+		return false, time.Time{}, fmt.Errorf("invalid duration %q", window.Duration)
+	}
+	// the window is open if it opened within the duration before now
+	if start := schedule.Next(now.Add(-duration)); !start.After(now) {
+		return true, start, nil
+	}
+	return false, schedule.Next(now), nil
+}
+
+// MaintenanceWindowOpen returns whether the operations of the components of
+// the tc may go on now, i.e. the tc has no spec.maintenanceWindow or it's open.
+func MaintenanceWindowOpen(tc *v1alpha1.TidbCluster) bool {
+	if tc.Spec.MaintenanceWindow == nil {
+		return true
+	}
+	open, _, err := inMaintenanceWindow(tc.Spec.MaintenanceWindow, maintenanceWindowNow().UTC())
+	return err == nil && open
+}
+
+// syncMaintenanceWindowCondition sets the entry of the operation of the
+// component in the message of the WaitingForMaintenanceWindow condition if
+// msg is not nil. Otherwise the window is open for all the operations, so the
+// condition set before is resolved.
+func syncMaintenanceWindowCondition(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, operation string, msg *string) {
+	cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterWaitingForMaintenanceWindow)
+	if msg != nil {
+		current := ""
+		if cond != nil && cond.Status == corev1.ConditionTrue {
+			current = cond.Message
+		}
+		utiltidbcluster.UpdateTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+			v1alpha1.TidbClusterWaitingForMaintenanceWindow, corev1.ConditionTrue, maintenanceWindowReason,
+			maintenanceWindowMessage(current, memberType, operation, *msg)))
+		return
+	}
+	ResolveMaintenanceWindowCondition(tc)
+}
+
+// ResolveMaintenanceWindowCondition sets the WaitingForMaintenanceWindow
+// condition False if it's True, it's called when the window is open.
+func ResolveMaintenanceWindowCondition(tc *v1alpha1.TidbCluster) {
+	if cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterWaitingForMaintenanceWindow); cond != nil && cond.Status == corev1.ConditionTrue {
+		utiltidbcluster.UpdateTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+			v1alpha1.TidbClusterWaitingForMaintenanceWindow, corev1.ConditionFalse, "MaintenanceWindowOpen", "no operation is waiting for the maintenance window"))
+	}
+}
+
+// syncDMMaintenanceWindowCondition is syncMaintenanceWindowCondition for a dc
+func syncDMMaintenanceWindowCondition(dc *v1alpha1.DMCluster, memberType v1alpha1.MemberType, operation string, msg *string) {
+	cond := utildmcluster.GetDMClusterCondition(dc.Status, v1alpha1.DMClusterWaitingForMaintenanceWindow)
+	if msg != nil {
+		current := ""
+		if cond != nil && cond.Status == corev1.ConditionTrue {
+			current = cond.Message
+		}
+		utildmcluster.UpdateDMClusterCondition(&dc.Status, *utildmcluster.NewDMClusterCondition(
+			v1alpha1.DMClusterWaitingForMaintenanceWindow, corev1.ConditionTrue, maintenanceWindowReason,
+			maintenanceWindowMessage(current, memberType, operation, *msg)))
+		return
+	}
+	if cond != nil && cond.Status == corev1.ConditionTrue {
+		utildmcluster.UpdateDMClusterCondition(&dc.Status, *utildmcluster.NewDMClusterCondition(
+			v1alpha1.DMClusterWaitingForMaintenanceWindow, corev1.ConditionFalse, "MaintenanceWindowOpen", "no operation is waiting for the maintenance window"))
+	}
+}
+
+// maintenanceWindowMessage returns the message of the condition with the
+// entry of the operation of the component replaced by msg. The entries are
+// separated by "; " and keyed by the component and the operation they begin
+// with, so that the operations waiting do not overwrite each other.
+func maintenanceWindowMessage(current string, memberType v1alpha1.MemberType, operation, msg string) string {
+	key := fmt.Sprintf("%s %s ", memberType, operation)
+	entries := []string{msg}
+	for _, entry := range strings.Split(current, "; ") {
+		if entry != "" && !strings.HasPrefix(entry, key) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, "; ")
+}
+
+// deferScaleToMaintenanceWindow returns whether the StatefulSet is scaling
+// outside the maintenance window of the tc or the dc, in which case the
+// replicas of the new StatefulSet are reset to scale in a later sync. A
+// scale-in that has begun is never deferred, so that the member is not left
+// halfway removed until the window opens again.
+func (s *generalScaler) deferScaleToMaintenanceWindow(meta metav1.Object, memberType v1alpha1.MemberType, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) bool {
+	scaling, ordinal, _, _ := scaleOne(oldSet, newSet)
+	if scaling == 0 {
+		return false
+	}
+	var wait bool
+	switch cluster := meta.(type) {
+	case *v1alpha1.TidbCluster:
+		if scaling < 0 && scaleInBegun(cluster, memberType, fmt.Sprintf("%s-%d", oldSet.Name, ordinal)) {
+			return false
+		}
+		wait = waitForMaintenanceWindow(cluster, memberType, "scale")
+	case *v1alpha1.DMCluster:
+		wait = waitForDMMaintenanceWindow(cluster, memberType, "scale")
+	}
+	if !wait {
+		return false
+	}
+	resetReplicas(newSet, oldSet)
+	recordScaleBlocked(meta, memberType, maintenanceWindowReason)
+	return true
+}
+
+// scaleInBegun returns whether the scale-in of the member has begun, i.e. it
+// is recorded in the recent scales of the tc, or its store is being or has
+// been deleted from PD
+func scaleInBegun(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, podName string) bool {
+	for _, record := range tc.Status.RecentScales {
+		if record.Component == memberType && record.PodName == podName && record.ScaleIn {
+			return true
+		}
+	}
+
+	var stores, tombstoneStores map[string]v1alpha1.TiKVStore
+	switch memberType {
+	case v1alpha1.TiKVMemberType:
+		stores, tombstoneStores = tc.Status.TiKV.Stores, tc.Status.TiKV.TombstoneStores
+	case v1alpha1.TiFlashMemberType:
+		stores, tombstoneStores = tc.Status.TiFlash.Stores, tc.Status.TiFlash.TombstoneStores
+	default:
+		return false
+	}
+	for _, store := range stores {
+		if store.PodName == podName && store.State == v1alpha1.TiKVStateOffline {
+			return true
+		}
+	}
+	for _, store := range tombstoneStores {
+		if store.PodName == podName {
+			return true
+		}
+	}
+	return false
+}
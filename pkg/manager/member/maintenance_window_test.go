@@ -0,0 +1,241 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	utildmcluster "github.com/pingcap/tidb-operator/pkg/util/dmcluster"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
+)
+
+func TestGeneralScalerDeferScaleToMaintenanceWindow(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// 2021-01-02 is a Saturday
+	saturday := time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		window     *v1alpha1.MaintenanceWindow
+		now        time.Time
+		memberType v1alpha1.MemberType
+		changeFn   func(*v1alpha1.TidbCluster)
+		replicas   int32
+		deferred   bool
+		cond       corev1.ConditionStatus
+		message    string
+	}{
+		{
+			name:     "no maintenance window",
+			now:      saturday,
+			replicas: 6,
+		},
+		{
+			name:     "before the window",
+			window:   &v1alpha1.MaintenanceWindow{Schedule: "0 2 * * 6", Duration: "4h"},
+			now:      saturday.Add(time.Hour),
+			replicas: 6,
+			deferred: true,
+			cond:     corev1.ConditionTrue,
+			message:  "pd scale is queued until the maintenance window opens at 2021-01-02T02:00:00Z",
+		},
+		{
+			name:     "in the window",
+			window:   &v1alpha1.MaintenanceWindow{Schedule: "0 2 * * 6", Duration: "4h"},
+			now:      saturday.Add(5 * time.Hour),
+			replicas: 4,
+		},
+		{
+			name:     "after the window",
+			window:   &v1alpha1.MaintenanceWindow{Schedule: "0 2 * * 6", Duration: "4h"},
+			now:      saturday.Add(6 * time.Hour),
+			replicas: 4,
+			deferred: true,
+			cond:     corev1.ConditionTrue,
+			message:  "pd scale is queued until the maintenance window opens at 2021-01-09T02:00:00Z",
+		},
+		{
+			name:     "invalid schedule",
+			window:   &v1alpha1.MaintenanceWindow{Schedule: "0 2 * *", Duration: "4h"},
+			now:      saturday.Add(3 * time.Hour),
+			replicas: 6,
+			deferred: true,
+			cond:     corev1.ConditionTrue,
+		},
+		{
+			name:       "the begun tikv scale-in is not deferred",
+			window:     &v1alpha1.MaintenanceWindow{Schedule: "0 2 * * 6", Duration: "4h"},
+			now:        saturday.Add(6 * time.Hour),
+			memberType: v1alpha1.TiKVMemberType,
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{
+					"5": {ID: "5", PodName: "scaler-4", State: v1alpha1.TiKVStateOffline},
+				}
+			},
+			replicas: 4,
+		},
+		{
+			name:       "the deleted tiflash store is scaled in",
+			window:     &v1alpha1.MaintenanceWindow{Schedule: "0 2 * * 6", Duration: "4h"},
+			now:        saturday.Add(6 * time.Hour),
+			memberType: v1alpha1.TiFlashMemberType,
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiFlash.TombstoneStores = map[string]v1alpha1.TiKVStore{
+					"5": {ID: "5", PodName: "scaler-4", State: v1alpha1.TiKVStateTombstone},
+				}
+			},
+			replicas: 4,
+		},
+		{
+			name:   "the recorded scale-in is not deferred",
+			window: &v1alpha1.MaintenanceWindow{Schedule: "0 2 * * 6", Duration: "4h"},
+			now:    saturday.Add(6 * time.Hour),
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.RecentScales = []v1alpha1.ScaleRecord{{Component: v1alpha1.PDMemberType, PodName: "scaler-4", ScaleIn: true}}
+			},
+			replicas: 4,
+		},
+		{
+			name:       "the up tikv store is not scaled in",
+			window:     &v1alpha1.MaintenanceWindow{Schedule: "0 2 * * 6", Duration: "4h"},
+			now:        saturday.Add(6 * time.Hour),
+			memberType: v1alpha1.TiKVMemberType,
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{
+					"5": {ID: "5", PodName: "scaler-4", State: v1alpha1.TiKVStateUp},
+				}
+			},
+			replicas: 4,
+			deferred: true,
+			cond:     corev1.ConditionTrue,
+			message:  "tikv scale is queued until the maintenance window opens at 2021-01-09T02:00:00Z",
+		},
+		{
+			name:     "not scaling",
+			window:   &v1alpha1.MaintenanceWindow{Schedule: "0 2 * * 6", Duration: "4h"},
+			now:      saturday.Add(time.Hour),
+			replicas: 5,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			maintenanceWindowNow = func() time.Time { return test.now }
+			defer func() { maintenanceWindowNow = time.Now }()
+
+			deps := controller.NewFakeDependencies()
+			scaler := &generalScaler{deps: deps}
+			tc := newTidbClusterForPD()
+			tc.Spec.MaintenanceWindow = test.window
+			if test.changeFn != nil {
+				test.changeFn(tc)
+			}
+			memberType := test.memberType
+			if memberType == "" {
+				memberType = v1alpha1.PDMemberType
+			}
+
+			oldSet := newStatefulSetForPDScale()
+			newSet := oldSet.DeepCopy()
+			newSet.Spec.Replicas = pointer.Int32Ptr(test.replicas)
+
+			g.Expect(scaler.deferScaleToMaintenanceWindow(tc, memberType, oldSet, newSet)).To(Equal(test.deferred))
+			if test.deferred {
+				g.Expect(*newSet.Spec.Replicas).To(Equal(int32(5)))
+			} else {
+				g.Expect(*newSet.Spec.Replicas).To(Equal(test.replicas))
+			}
+			cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterWaitingForMaintenanceWindow)
+			if test.cond == "" {
+				g.Expect(cond).To(BeNil())
+				return
+			}
+			g.Expect(cond.Status).To(Equal(test.cond))
+			if test.message != "" {
+				g.Expect(cond.Message).To(Equal(test.message))
+			}
+		})
+	}
+}
+
+func TestGeneralScalerDeferDMScaleToMaintenanceWindow(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// 2021-01-02 is a Saturday
+	maintenanceWindowNow = func() time.Time { return time.Date(2021, 1, 2, 1, 0, 0, 0, time.UTC) }
+	defer func() { maintenanceWindowNow = time.Now }()
+
+	deps := controller.NewFakeDependencies()
+	scaler := &generalScaler{deps: deps}
+	dc := newDMClusterForWorker()
+	dc.Spec.MaintenanceWindow = &v1alpha1.MaintenanceWindow{Schedule: "0 2 * * 6", Duration: "4h"}
+
+	oldSet := newStatefulSetForPDScale()
+	newSet := oldSet.DeepCopy()
+	newSet.Spec.Replicas = pointer.Int32Ptr(6)
+
+	g.Expect(scaler.deferScaleToMaintenanceWindow(dc, v1alpha1.DMWorkerMemberType, oldSet, newSet)).To(BeTrue())
+	g.Expect(*newSet.Spec.Replicas).To(Equal(int32(5)))
+	cond := utildmcluster.GetDMClusterCondition(dc.Status, v1alpha1.DMClusterWaitingForMaintenanceWindow)
+	g.Expect(cond).NotTo(BeNil())
+	g.Expect(cond.Message).To(Equal("dm-worker scale is queued until the maintenance window opens at 2021-01-02T02:00:00Z"))
+
+	maintenanceWindowNow = func() time.Time { return time.Date(2021, 1, 2, 3, 0, 0, 0, time.UTC) }
+	newSet.Spec.Replicas = pointer.Int32Ptr(6)
+	g.Expect(scaler.deferScaleToMaintenanceWindow(dc, v1alpha1.DMWorkerMemberType, oldSet, newSet)).To(BeFalse())
+	g.Expect(*newSet.Spec.Replicas).To(Equal(int32(6)))
+	cond = utildmcluster.GetDMClusterCondition(dc.Status, v1alpha1.DMClusterWaitingForMaintenanceWindow)
+	g.Expect(cond.Status).To(Equal(corev1.ConditionFalse))
+}
+
+func TestWaitForMaintenanceWindowMessage(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// 2021-01-02 is a Saturday
+	maintenanceWindowNow = func() time.Time { return time.Date(2021, 1, 2, 1, 0, 0, 0, time.UTC) }
+	defer func() { maintenanceWindowNow = time.Now }()
+
+	tc := newTidbClusterForPD()
+	tc.Spec.MaintenanceWindow = &v1alpha1.MaintenanceWindow{Schedule: "0 2 * * 6", Duration: "4h"}
+
+	// the operations waiting are kept in the message by component
+	g.Expect(waitForMaintenanceWindow(tc, v1alpha1.TiKVMemberType, "upgrade")).To(BeTrue())
+	g.Expect(waitForMaintenanceWindow(tc, v1alpha1.PDMemberType, "scale")).To(BeTrue())
+	g.Expect(waitForMaintenanceWindow(tc, v1alpha1.TiKVMemberType, "upgrade")).To(BeTrue())
+	cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterWaitingForMaintenanceWindow)
+	g.Expect(cond.Status).To(Equal(corev1.ConditionTrue))
+	g.Expect(cond.Message).To(Equal("pd scale is queued until the maintenance window opens at 2021-01-02T02:00:00Z; " +
+		"tikv upgrade is queued until the maintenance window opens at 2021-01-02T02:00:00Z"))
+	g.Expect(MaintenanceWindowOpen(tc)).To(BeFalse())
+
+	// nothing waits once the window opens
+	maintenanceWindowNow = func() time.Time { return time.Date(2021, 1, 2, 3, 0, 0, 0, time.UTC) }
+	g.Expect(MaintenanceWindowOpen(tc)).To(BeTrue())
+	ResolveMaintenanceWindowCondition(tc)
+	cond = utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterWaitingForMaintenanceWindow)
+	g.Expect(cond.Status).To(Equal(corev1.ConditionFalse))
+
+	// the message of the next wait does not carry the entries resolved
+	maintenanceWindowNow = func() time.Time { return time.Date(2021, 1, 2, 7, 0, 0, 0, time.UTC) }
+	g.Expect(waitForMaintenanceWindow(tc, v1alpha1.TiDBMemberType, "restart")).To(BeTrue())
+	cond = utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterWaitingForMaintenanceWindow)
+	g.Expect(cond.Message).To(Equal("tidb restart is queued until the maintenance window opens at 2021-01-09T02:00:00Z"))
+}
@@ -0,0 +1,166 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+const (
+	// ordinalConfigVolumeName is the name of the volume of the config
+	// overridden for some ordinals by spec.<component>.perOrdinalConfig
+	ordinalConfigVolumeName = "ordinal-config"
+)
+
+// ordinalConfigMapName returns the name of the ConfigMap of the config
+// overridden for some ordinals. Unlike the ConfigMap of the component, the
+// name has no hash suffix so that a change of the overrides does not roll
+// all the Pods.
+func ordinalConfigMapName(tcName string, memberType v1alpha1.MemberType) string {
+	return fmt.Sprintf("%s-ordinal-%s", tcName, memberType)
+}
+
+// ordinalConfigKey returns the key of the config overridden for the ordinal
+// in the ordinal ConfigMap, which is also the name of the mounted file
+func ordinalConfigKey(ordinal string) string {
+	return fmt.Sprintf("config-%s.toml", ordinal)
+}
+
+// ordinalConfigMountPath returns the directory the ordinal ConfigMap is mounted at
+func ordinalConfigMountPath(memberType v1alpha1.MemberType) string {
+	return fmt.Sprintf("/etc/%s-ordinal", memberType)
+}
+
+// ordinalConfigVolume returns the volume and the mount of the ordinal
+// ConfigMap. The ConfigMap is optional so that the Pods can start before
+// it is created.
+func ordinalConfigVolume(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType) (corev1.Volume, corev1.VolumeMount) {
+	optional := true
+	vol := corev1.Volume{
+		Name: ordinalConfigVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: ordinalConfigMapName(tc.Name, memberType),
+				},
+				Optional: &optional,
+			},
+		},
+	}
+	mount := corev1.VolumeMount{Name: ordinalConfigVolumeName, ReadOnly: true, MountPath: ordinalConfigMountPath(memberType)}
+	return vol, mount
+}
+
+// syncOrdinalConfigMap syncs the ConfigMap of the config overridden for some
+// ordinals, data is keyed by the ordinal or nil if the config is not
+// overridden. The ConfigMap is removed if data is nil.
+//
+// The Pods of the ordinals whose config is changed are annotated with
+// tidb.pingcap.com/restart-at before the ConfigMap is updated, so they are
+// restarted gracefully one by one by syncPodRestarts and the other Pods keep
+// running.
+func syncOrdinalConfigMap(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, data map[string]string) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	name := ordinalConfigMapName(tcName, memberType)
+
+	existing, err := deps.ConfigMapLister.ConfigMaps(ns).Get(name)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("syncOrdinalConfigMap: failed to get configmap %s/%s, error: %v", ns, name, err)
+	}
+	if errors.IsNotFound(err) {
+		existing = nil
+	}
+
+	if data == nil {
+		if existing == nil {
+			return nil
+		}
+		return deps.ConfigMapControl.DeleteConfigMap(tc, existing)
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       ns,
+			Labels:          label.New().Instance(tc.GetInstanceName()).Component(memberType.String()).Labels(),
+			OwnerReferences: []metav1.OwnerReference{controller.GetOwnerRef(tc)},
+		},
+		Data: map[string]string{},
+	}
+	for ordinal, confText := range data {
+		cm.Data[ordinalConfigKey(ordinal)] = confText
+	}
+
+	if existing != nil {
+		// the Pods not created yet mount the new config anyway
+		var changed []string
+		for ordinal := range data {
+			if existing.Data[ordinalConfigKey(ordinal)] != cm.Data[ordinalConfigKey(ordinal)] {
+				changed = append(changed, ordinal)
+			}
+		}
+		for key := range existing.Data {
+			if _, ok := cm.Data[key]; !ok {
+				// the override of the ordinal is removed
+				changed = append(changed, strings.TrimSuffix(strings.TrimPrefix(key, "config-"), ".toml"))
+			}
+		}
+		sort.Strings(changed)
+		for _, ordinal := range changed {
+			if err := requestOrdinalRestart(deps, tc, memberType, ordinal); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = deps.TypedControl.CreateOrUpdateConfigMap(tc, cm)
+	return err
+}
+
+// requestOrdinalRestart annotates the Pod of the ordinal with
+// tidb.pingcap.com/restart-at to restart it with the changed config
+func requestOrdinalRestart(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, ordinal string) error {
+	ns := tc.GetNamespace()
+	podName := fmt.Sprintf("%s-%s-%s", tc.GetName(), memberType, ordinal)
+	pod, err := deps.PodLister.Pods(ns).Get(podName)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("requestOrdinalRestart: failed to get pod %s/%s, error: %v", ns, podName, err)
+	}
+
+	pod = pod.DeepCopy()
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Annotations[label.AnnRestartAt] = podRestartNow().Format(time.RFC3339)
+	if _, err := deps.PodControl.UpdatePod(tc, pod); err != nil {
+		return fmt.Errorf("requestOrdinalRestart: failed to request the restart of pod %s/%s, error: %v", ns, podName, err)
+	}
+	klog.Infof("tidbcluster: [%s/%s]'s %s pod %s is requested to restart as the config overridden for it is changed", ns, tc.GetName(), memberType, podName)
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+)
+
+func TestSyncOrdinalConfigMap(t *testing.T) {
+	defer func(now func() time.Time) { podRestartNow = now }(podRestartNow)
+	podRestartNow = func() time.Time { return podRestartTestNow }
+
+	tests := []struct {
+		name      string
+		existing  map[string]string
+		data      map[string]string
+		restarted []string
+	}{
+		{
+			name:      "the configmap is created",
+			existing:  nil,
+			data:      map[string]string{"1": "a"},
+			restarted: nil,
+		},
+		{
+			name:      "only the pods of the changed ordinals are restarted",
+			existing:  map[string]string{"config-1.toml": "a", "config-2.toml": "b"},
+			data:      map[string]string{"1": "a", "2": "c"},
+			restarted: []string{"test-tikv-2"},
+		},
+		{
+			name:      "the pods of the added and removed ordinals are restarted",
+			existing:  map[string]string{"config-1.toml": "a"},
+			data:      map[string]string{"0": "a", "5": "b"},
+			restarted: []string{"test-tikv-0", "test-tikv-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			deps := controller.NewFakeDependencies()
+			tc := newTidbClusterForTiKV()
+			for i := 0; i < 3; i++ {
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      fmt.Sprintf("test-tikv-%d", i),
+						Namespace: tc.Namespace,
+					},
+				}
+				g.Expect(deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer().Add(pod)).To(Succeed())
+			}
+			if tt.existing != nil {
+				cm := &corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-ordinal-tikv", Namespace: tc.Namespace},
+					Data:       tt.existing,
+				}
+				g.Expect(deps.LabelFilterKubeInformerFactory.Core().V1().ConfigMaps().Informer().GetIndexer().Add(cm)).To(Succeed())
+			}
+
+			g.Expect(syncOrdinalConfigMap(deps, tc, v1alpha1.TiKVMemberType, tt.data)).To(Succeed())
+
+			var restarted []string
+			for i := 0; i < 3; i++ {
+				pod, err := deps.PodLister.Pods(tc.Namespace).Get(fmt.Sprintf("test-tikv-%d", i))
+				g.Expect(err).NotTo(HaveOccurred())
+				if at, ok := pod.Annotations[label.AnnRestartAt]; ok {
+					g.Expect(at).To(Equal(podRestartTestNow.Format(time.RFC3339)))
+					restarted = append(restarted, pod.Name)
+				}
+			}
+			g.Expect(restarted).To(Equal(tt.restarted))
+
+			cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test-ordinal-tikv", Namespace: tc.Namespace}}
+			g.Expect(deps.GenericControl.(*controller.FakeGenericControl).FakeCli.Get(context.TODO(), client.ObjectKeyFromObject(cm), cm)).To(Succeed())
+			g.Expect(cm.Data).To(HaveLen(len(tt.data)))
+			for ordinal, confText := range tt.data {
+				g.Expect(cm.Data[ordinalConfigKey(ordinal)]).To(Equal(confText))
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+// syncPDBalanceStatus reports the pending operators of PD and how balanced the
+// leaders and regions are across the Up stores, so tools can wait for PD to
+// quiesce after scaling. The status is updated while PD has pending operators,
+// and once more when they drop to 0.
+func syncPDBalanceStatus(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	pdClient := controller.GetPDClient(deps.PDControl, tc)
+	count, err := pdClient.GetOperatorCount()
+	if err != nil {
+		klog.V(4).Infof("pd: failed to get operators of cluster %s/%s, error: %v", ns, tcName, err)
+		return
+	}
+	if count == 0 && (tc.Status.PD.Balance == nil || tc.Status.PD.Balance.PendingOperators == 0) {
+		// the balance has quiesced and is reported already
+		return
+	}
+
+	storesInfo, err := pdClient.GetStores()
+	if err != nil {
+		klog.V(4).Infof("pd: failed to get stores of cluster %s/%s, error: %v", ns, tcName, err)
+		return
+	}
+	leaderProgress, regionProgress := pdBalanceProgress(storesInfo)
+	tc.Status.PD.Balance = &v1alpha1.PDBalanceStatus{
+		PendingOperators:      int32(count),
+		LeaderBalanceProgress: leaderProgress,
+		RegionBalanceProgress: regionProgress,
+		LastUpdateTime:        metav1.Now(),
+	}
+}
+
+// pdBalanceProgress returns the min leader count and region count of the Up
+// stores over the max ones, in percent.
+func pdBalanceProgress(storesInfo *pdapi.StoresInfo) (int32, int32) {
+	var leaderCounts, regionCounts []int
+	for _, store := range storesInfo.Stores {
+		if store.Store == nil || store.Status == nil || store.Store.StateName != v1alpha1.TiKVStateUp {
+			continue
+		}
+		leaderCounts = append(leaderCounts, store.Status.LeaderCount)
+		regionCounts = append(regionCounts, store.Status.RegionCount)
+	}
+	return balanceProgress(leaderCounts), balanceProgress(regionCounts)
+}
+
+func balanceProgress(counts []int) int32 {
+	if len(counts) == 0 {
+		return 100
+	}
+	min, max := counts[0], counts[0]
+	for _, c := range counts[1:] {
+		if c < min {
+			min = c
+		}
+		if c > max {
+			max = c
+		}
+	}
+	if max == 0 {
+		return 100
+	}
+	return int32(min * 100 / max)
+}
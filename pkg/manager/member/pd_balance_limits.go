@@ -0,0 +1,138 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+)
+
+// pdBalanceLimitsSyncFailedReason is the reason of the Events of the failures
+// to sync the balance limits of PD
+const pdBalanceLimitsSyncFailedReason = "PDBalanceLimitsSyncFailed"
+
+// pdBalanceLimitItem is a balance limit managed by `.spec.pd.balanceLimits`,
+// only these items of the PD config are pushed to PD
+type pdBalanceLimitItem struct {
+	item    string
+	desired func(limits *v1alpha1.PDBalanceLimits) *int32
+	current func(config *pdapi.PDScheduleConfig) *uint64
+}
+
+var pdBalanceLimitItems = []pdBalanceLimitItem{
+	{
+		item:    "schedule.max-pending-peer-count",
+		desired: func(limits *v1alpha1.PDBalanceLimits) *int32 { return limits.MaxPendingPeerCount },
+		current: func(config *pdapi.PDScheduleConfig) *uint64 { return config.MaxPendingPeerCount },
+	},
+	{
+		item:    "schedule.max-snapshot-count",
+		desired: func(limits *v1alpha1.PDBalanceLimits) *int32 { return limits.MaxSnapshotCount },
+		current: func(config *pdapi.PDScheduleConfig) *uint64 { return config.MaxSnapshotCount },
+	},
+	{
+		item:    "schedule.leader-schedule-limit",
+		desired: func(limits *v1alpha1.PDBalanceLimits) *int32 { return limits.LeaderScheduleLimit },
+		current: func(config *pdapi.PDScheduleConfig) *uint64 { return config.LeaderScheduleLimit },
+	},
+	{
+		item:    "schedule.region-schedule-limit",
+		desired: func(limits *v1alpha1.PDBalanceLimits) *int32 { return limits.RegionScheduleLimit },
+		current: func(config *pdapi.PDScheduleConfig) *uint64 { return config.RegionScheduleLimit },
+	},
+	{
+		item:    "schedule.replica-schedule-limit",
+		desired: func(limits *v1alpha1.PDBalanceLimits) *int32 { return limits.ReplicaScheduleLimit },
+		current: func(config *pdapi.PDScheduleConfig) *uint64 { return config.ReplicaScheduleLimit },
+	},
+	{
+		item:    "schedule.merge-schedule-limit",
+		desired: func(limits *v1alpha1.PDBalanceLimits) *int32 { return limits.MergeScheduleLimit },
+		current: func(config *pdapi.PDScheduleConfig) *uint64 { return config.MergeScheduleLimit },
+	},
+	{
+		item:    "schedule.hot-region-schedule-limit",
+		desired: func(limits *v1alpha1.PDBalanceLimits) *int32 { return limits.HotRegionScheduleLimit },
+		current: func(config *pdapi.PDScheduleConfig) *uint64 { return config.HotRegionScheduleLimit },
+	},
+}
+
+// syncPDBalanceLimits pushes `.spec.pd.balanceLimits` to PD through its config
+// API. The limits in PD are compared with the spec in each round, so the drift,
+// e.g. a change by pd-ctl, is corrected. The limits not set in the spec are
+// left as they are.
+//
+// The balance limits are a best-effort tuning, so the failures are recorded as
+// Events and the limits are synced again in the next round.
+func syncPDBalanceLimits(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	if tc.Spec.PD == nil || tc.Spec.PD.BalanceLimits == nil {
+		return
+	}
+	if tc.Spec.Paused {
+		klog.V(4).Infof("tidb cluster %s/%s is paused, skip syncing pd balance limits", ns, tcName)
+		return
+	}
+	if !tc.Status.PD.Synced {
+		klog.V(4).Infof("tidb cluster %s/%s pd status is not synced, skip syncing pd balance limits", ns, tcName)
+		return
+	}
+
+	if err := updatePDBalanceLimits(deps, tc); err != nil {
+		klog.Warningf("pd: failed to sync balance limits of %s/%s, error: %v", ns, tcName, err)
+		deps.Recorder.Event(tc, corev1.EventTypeWarning, pdBalanceLimitsSyncFailedReason, fmt.Sprintf("failed to sync pd balance limits: %v", err))
+	}
+}
+
+func updatePDBalanceLimits(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	pdCli := controller.GetPDClient(deps.PDControl, tc)
+	config, err := pdCli.GetConfig()
+	if err != nil {
+		return err
+	}
+	schedule := config.Schedule
+	if schedule == nil {
+		schedule = &pdapi.PDScheduleConfig{}
+	}
+
+	items := map[string]interface{}{}
+	for _, limit := range pdBalanceLimitItems {
+		desired := limit.desired(tc.Spec.PD.BalanceLimits)
+		if desired == nil {
+			continue
+		}
+		if current := limit.current(schedule); current != nil && *current == uint64(*desired) {
+			continue
+		}
+		items[limit.item] = *desired
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	if err := pdCli.UpdateConfig(items); err != nil {
+		return err
+	}
+	klog.Infof("pd: update balance limits of %s/%s to %v successfully", ns, tcName, items)
+	return nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+)
+
+func TestSyncPDBalanceLimits(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name         string
+		limits       *v1alpha1.PDBalanceLimits
+		synced       bool
+		current      *pdapi.PDScheduleConfig
+		updateErr    bool
+		expectFailed bool
+		expectItems  map[string]interface{}
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+		fakeDeps := controller.NewFakeDependencies()
+		recorder := record.NewFakeRecorder(10)
+		fakeDeps.Recorder = recorder
+		pdControl := fakeDeps.PDControl.(*pdapi.FakePDControl)
+
+		tc := newTidbClusterForPD()
+		tc.Spec.PD.BalanceLimits = test.limits
+		tc.Status.PD.Synced = test.synced
+
+		pdClient := controller.NewFakePDClient(pdControl, tc)
+		pdClient.AddReaction(pdapi.GetConfigActionType, func(action *pdapi.Action) (interface{}, error) {
+			return &pdapi.PDConfigFromAPI{Schedule: test.current}, nil
+		})
+		var items map[string]interface{}
+		pdClient.AddReaction(pdapi.UpdateConfigActionType, func(action *pdapi.Action) (interface{}, error) {
+			if test.updateErr {
+				return nil, fmt.Errorf("failed to update config")
+			}
+			items = action.Config
+			return nil, nil
+		})
+
+		syncPDBalanceLimits(fakeDeps, tc)
+		if test.expectFailed {
+			g.Expect(recorder.Events).To(HaveLen(1))
+		} else {
+			g.Expect(recorder.Events).To(BeEmpty())
+		}
+		if test.expectItems == nil {
+			g.Expect(items).To(BeNil())
+		} else {
+			g.Expect(items).To(Equal(test.expectItems))
+		}
+	}
+
+	uint64Ptr := func(i uint64) *uint64 {
+		return &i
+	}
+
+	tests := []testcase{
+		{
+			name:    "balance limits are not managed",
+			synced:  true,
+			current: &pdapi.PDScheduleConfig{MaxPendingPeerCount: uint64Ptr(16)},
+		},
+		{
+			name:   "pd is not synced",
+			limits: &v1alpha1.PDBalanceLimits{MaxPendingPeerCount: pointer.Int32Ptr(64)},
+		},
+		{
+			name: "push the balance limits",
+			limits: &v1alpha1.PDBalanceLimits{
+				MaxPendingPeerCount: pointer.Int32Ptr(64),
+				RegionScheduleLimit: pointer.Int32Ptr(4096),
+				MergeScheduleLimit:  pointer.Int32Ptr(0),
+			},
+			synced: true,
+			current: &pdapi.PDScheduleConfig{
+				MaxPendingPeerCount: uint64Ptr(16),
+				RegionScheduleLimit: uint64Ptr(2048),
+				MergeScheduleLimit:  uint64Ptr(8),
+			},
+			expectItems: map[string]interface{}{
+				"schedule.max-pending-peer-count": int32(64),
+				"schedule.region-schedule-limit":  int32(4096),
+				"schedule.merge-schedule-limit":   int32(0),
+			},
+		},
+		{
+			name: "correct the drifted limit only",
+			limits: &v1alpha1.PDBalanceLimits{
+				MaxPendingPeerCount: pointer.Int32Ptr(64),
+				MaxSnapshotCount:    pointer.Int32Ptr(8),
+			},
+			synced: true,
+			current: &pdapi.PDScheduleConfig{
+				MaxPendingPeerCount: uint64Ptr(64),
+				MaxSnapshotCount:    uint64Ptr(3),
+			},
+			expectItems: map[string]interface{}{
+				"schedule.max-snapshot-count": int32(8),
+			},
+		},
+		{
+			name:   "the limits are in sync",
+			limits: &v1alpha1.PDBalanceLimits{LeaderScheduleLimit: pointer.Int32Ptr(4)},
+			synced: true,
+			current: &pdapi.PDScheduleConfig{
+				LeaderScheduleLimit: uint64Ptr(4),
+				// the limits not in the spec are not managed
+				HotRegionScheduleLimit: uint64Ptr(16),
+			},
+		},
+		{
+			name:   "the schedule config is not reported by pd",
+			limits: &v1alpha1.PDBalanceLimits{ReplicaScheduleLimit: pointer.Int32Ptr(32)},
+			synced: true,
+			expectItems: map[string]interface{}{
+				"schedule.replica-schedule-limit": int32(32),
+			},
+		},
+		{
+			name:         "failed to push the balance limits",
+			limits:       &v1alpha1.PDBalanceLimits{MaxSnapshotCount: pointer.Int32Ptr(8)},
+			synced:       true,
+			updateErr:    true,
+			expectFailed: true,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newStoreInfoForBalance(id uint64, state string, leaders, regions int) *pdapi.StoreInfo {
+	return &pdapi.StoreInfo{
+		Store: &pdapi.MetaStore{
+			Store:     &metapb.Store{Id: id},
+			StateName: state,
+		},
+		Status: &pdapi.StoreStatus{
+			LeaderCount: leaders,
+			RegionCount: regions,
+		},
+	}
+}
+
+func TestSyncPDBalanceStatus(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name          string
+		balance       *v1alpha1.PDBalanceStatus
+		operators     int
+		operatorsErr  error
+		stores        []*pdapi.StoreInfo
+		expectBalance *v1alpha1.PDBalanceStatus
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+		fakeDeps := controller.NewFakeDependencies()
+		pdControl := fakeDeps.PDControl.(*pdapi.FakePDControl)
+
+		tc := newTidbClusterForPD()
+		tc.Status.PD.Balance = test.balance
+
+		pdClient := controller.NewFakePDClient(pdControl, tc)
+		pdClient.AddReaction(pdapi.GetOperatorCountActionType, func(action *pdapi.Action) (interface{}, error) {
+			return test.operators, test.operatorsErr
+		})
+		pdClient.AddReaction(pdapi.GetStoresActionType, func(action *pdapi.Action) (interface{}, error) {
+			return &pdapi.StoresInfo{Count: len(test.stores), Stores: test.stores}, nil
+		})
+
+		syncPDBalanceStatus(fakeDeps, tc)
+
+		if test.expectBalance == nil {
+			g.Expect(tc.Status.PD.Balance).To(BeNil())
+			return
+		}
+		g.Expect(tc.Status.PD.Balance).NotTo(BeNil())
+		g.Expect(tc.Status.PD.Balance.PendingOperators).To(Equal(test.expectBalance.PendingOperators))
+		g.Expect(tc.Status.PD.Balance.LeaderBalanceProgress).To(Equal(test.expectBalance.LeaderBalanceProgress))
+		g.Expect(tc.Status.PD.Balance.RegionBalanceProgress).To(Equal(test.expectBalance.RegionBalanceProgress))
+		if test.expectBalance.LastUpdateTime.IsZero() {
+			g.Expect(tc.Status.PD.Balance.LastUpdateTime.IsZero()).To(BeFalse())
+		} else {
+			g.Expect(tc.Status.PD.Balance.LastUpdateTime).To(Equal(test.expectBalance.LastUpdateTime))
+		}
+	}
+
+	lastUpdateTime := metav1.NewTime(time.Now().Add(-time.Minute))
+	tests := []*testcase{
+		{
+			name:      "no pending operators and never balancing",
+			operators: 0,
+			stores: []*pdapi.StoreInfo{
+				newStoreInfoForBalance(1, v1alpha1.TiKVStateUp, 10, 30),
+			},
+			expectBalance: nil,
+		},
+		{
+			name:      "pending operators after scaling out",
+			operators: 12,
+			stores: []*pdapi.StoreInfo{
+				newStoreInfoForBalance(1, v1alpha1.TiKVStateUp, 40, 100),
+				newStoreInfoForBalance(2, v1alpha1.TiKVStateUp, 40, 100),
+				newStoreInfoForBalance(3, v1alpha1.TiKVStateUp, 10, 25),
+				newStoreInfoForBalance(4, v1alpha1.TiKVStateTombstone, 0, 0),
+			},
+			expectBalance: &v1alpha1.PDBalanceStatus{
+				PendingOperators:      12,
+				LeaderBalanceProgress: 25,
+				RegionBalanceProgress: 25,
+			},
+		},
+		{
+			name:      "pending operators drop to 0",
+			balance:   &v1alpha1.PDBalanceStatus{PendingOperators: 3, LeaderBalanceProgress: 90, RegionBalanceProgress: 80, LastUpdateTime: lastUpdateTime},
+			operators: 0,
+			stores: []*pdapi.StoreInfo{
+				newStoreInfoForBalance(1, v1alpha1.TiKVStateUp, 40, 100),
+				newStoreInfoForBalance(2, v1alpha1.TiKVStateUp, 40, 100),
+			},
+			expectBalance: &v1alpha1.PDBalanceStatus{
+				PendingOperators:      0,
+				LeaderBalanceProgress: 100,
+				RegionBalanceProgress: 100,
+			},
+		},
+		{
+			name:      "balance has quiesced",
+			balance:   &v1alpha1.PDBalanceStatus{PendingOperators: 0, LeaderBalanceProgress: 100, RegionBalanceProgress: 100, LastUpdateTime: lastUpdateTime},
+			operators: 0,
+			stores: []*pdapi.StoreInfo{
+				newStoreInfoForBalance(1, v1alpha1.TiKVStateUp, 40, 100),
+				newStoreInfoForBalance(2, v1alpha1.TiKVStateUp, 10, 100),
+			},
+			expectBalance: &v1alpha1.PDBalanceStatus{
+				PendingOperators:      0,
+				LeaderBalanceProgress: 100,
+				RegionBalanceProgress: 100,
+				LastUpdateTime:        lastUpdateTime,
+			},
+		},
+		{
+			name:         "failed to get operators",
+			balance:      &v1alpha1.PDBalanceStatus{PendingOperators: 3, LeaderBalanceProgress: 90, RegionBalanceProgress: 80, LastUpdateTime: lastUpdateTime},
+			operatorsErr: fmt.Errorf("failed to get operators"),
+			expectBalance: &v1alpha1.PDBalanceStatus{
+				PendingOperators:      3,
+				LeaderBalanceProgress: 90,
+				RegionBalanceProgress: 80,
+				LastUpdateTime:        lastUpdateTime,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		testFn(test, t)
+	}
+}
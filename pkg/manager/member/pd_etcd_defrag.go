@@ -0,0 +1,117 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+// pdEtcdDefragFailedReason is the reason of the Events of the failures to
+// defragment the etcd embedded in PD
+const pdEtcdDefragFailedReason = "PDEtcdDefragFailed"
+
+// syncPDEtcdDefrag defragments the etcd embedded in the PD member whose etcd
+// is defragmented least recently, if it is not defragmented within the
+// interval. At most one member is defragmented in a round, so that the members
+// are defragmented one at a time. The PD leader is skipped as defragmenting
+// blocks the member, it is defragmented once it is no longer the leader.
+//
+// The defragmentation is a best-effort maintenance, so the failures are
+// recorded as Events and the member is defragmented again in the next round.
+func syncPDEtcdDefrag(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	interval := tc.PDEtcdDefragInterval()
+	if interval == 0 {
+		tc.Status.PD.EtcdDefragTime = nil
+		return
+	}
+	if tc.Spec.Paused {
+		klog.V(4).Infof("tidb cluster %s/%s is paused, skip defragmenting pd etcd", ns, tcName)
+		return
+	}
+	if !tc.Status.PD.Synced || tc.Status.PD.Phase != v1alpha1.NormalPhase || len(tc.Status.PD.FailureMembers) > 0 {
+		klog.V(4).Infof("tidb cluster %s/%s's pd is not stable, skip defragmenting pd etcd", ns, tcName)
+		return
+	}
+
+	// forget the members that no longer exist
+	for name := range tc.Status.PD.EtcdDefragTime {
+		if _, ok := tc.Status.PD.Members[name]; !ok {
+			delete(tc.Status.PD.EtcdDefragTime, name)
+		}
+	}
+
+	names := make([]string, 0, len(tc.Status.PD.Members))
+	for name, member := range tc.Status.PD.Members {
+		if !member.Health {
+			klog.V(4).Infof("tidb cluster %s/%s's pd member %s is unhealthy, skip defragmenting pd etcd", ns, tcName, name)
+			return
+		}
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ti := tc.Status.PD.EtcdDefragTime[names[i]]
+		tj := tc.Status.PD.EtcdDefragTime[names[j]]
+		if !ti.Equal(&tj) {
+			return ti.Before(&tj)
+		}
+		return names[i] < names[j]
+	})
+
+	now := metav1.Now()
+	for _, name := range names {
+		last := tc.Status.PD.EtcdDefragTime[name]
+		if now.Sub(last.Time) < interval {
+			// the others are defragmented more recently
+			return
+		}
+		if name == tc.Status.PD.Leader.Name {
+			continue
+		}
+
+		if err := defragmentPDEtcd(deps, tc, name); err != nil {
+			klog.Warningf("pd: failed to defragment the etcd of pd member %s of %s/%s, error: %v", name, ns, tcName, err)
+			deps.Recorder.Event(tc, corev1.EventTypeWarning, pdEtcdDefragFailedReason, fmt.Sprintf("failed to defragment the etcd of pd member %s: %v", name, err))
+			return
+		}
+		if tc.Status.PD.EtcdDefragTime == nil {
+			tc.Status.PD.EtcdDefragTime = map[string]metav1.Time{}
+		}
+		tc.Status.PD.EtcdDefragTime[name] = metav1.Now()
+		return
+	}
+}
+
+func defragmentPDEtcd(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, name string) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	pdEtcdClient, err := deps.PDControl.GetPDEtcdClient(pdapi.Namespace(ns), tcName, tc.IsTLSClusterEnabled())
+	if err != nil {
+		return fmt.Errorf("failed to get pd etcd client, error: %v", err)
+	}
+	defer pdEtcdClient.Close()
+	klog.Infof("tidb cluster %s/%s defragments the etcd of pd member %s", ns, tcName, name)
+	return pdEtcdClient.Defragment(tc.Status.PD.Members[name].ClientURL)
+}
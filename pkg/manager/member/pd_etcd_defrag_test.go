@@ -0,0 +1,103 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+)
+
+func TestSyncPDEtcdDefrag(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	tc := newTidbClusterForPD()
+	tc.Spec.PD.EtcdDefragInterval = pointer.StringPtr("24h")
+	tc.Status.PD.Synced = true
+	tc.Status.PD.Phase = v1alpha1.NormalPhase
+	tc.Status.PD.Members = map[string]v1alpha1.PDMember{}
+	clientURL := func(ordinal int32) string {
+		return fmt.Sprintf("http://%s:2379", PdPodName(tc.GetName(), ordinal))
+	}
+	for i := int32(0); i < 3; i++ {
+		name := PdPodName(tc.GetName(), i)
+		tc.Status.PD.Members[name] = v1alpha1.PDMember{Name: name, ClientURL: clientURL(i), Health: true}
+	}
+	tc.Status.PD.Leader = tc.Status.PD.Members[PdPodName(tc.GetName(), 0)]
+
+	etcdClient := &pdapi.FakePDEtcdClient{}
+	deps.PDControl.(*pdapi.FakePDControl).SetPDEtcdClient(pdapi.Namespace(tc.GetNamespace()), tc.GetName(), etcdClient)
+	sync := func() {
+		syncPDEtcdDefrag(deps, tc)
+	}
+
+	// the members are defragmented one at a time and the leader is skipped
+	sync()
+	g.Expect(etcdClient.Defragmented).To(Equal([]string{clientURL(1)}))
+	g.Expect(tc.Status.PD.EtcdDefragTime).To(HaveKey(PdPodName(tc.GetName(), 1)))
+	sync()
+	g.Expect(etcdClient.Defragmented).To(Equal([]string{clientURL(1), clientURL(2)}))
+	sync()
+	g.Expect(etcdClient.Defragmented).To(Equal([]string{clientURL(1), clientURL(2)}))
+
+	// the former leader is defragmented once the leader is transferred
+	tc.Status.PD.Leader = tc.Status.PD.Members[PdPodName(tc.GetName(), 2)]
+	sync()
+	g.Expect(etcdClient.Defragmented).To(Equal([]string{clientURL(1), clientURL(2), clientURL(0)}))
+	sync()
+	g.Expect(etcdClient.Defragmented).To(HaveLen(3))
+
+	// the member is defragmented again after the interval
+	tc.Status.PD.EtcdDefragTime[PdPodName(tc.GetName(), 1)] = metav1.NewTime(time.Now().Add(-25 * time.Hour))
+	sync()
+	g.Expect(etcdClient.Defragmented).To(HaveLen(4))
+	g.Expect(etcdClient.Defragmented[3]).To(Equal(clientURL(1)))
+
+	// a failure is recorded as an event and the member is retried in the next round
+	recorder := deps.Recorder.(*record.FakeRecorder)
+	tc.Status.PD.EtcdDefragTime[PdPodName(tc.GetName(), 0)] = metav1.NewTime(time.Now().Add(-25 * time.Hour))
+	etcdClient.DefragmentError = fmt.Errorf("context deadline exceeded")
+	sync()
+	g.Expect(etcdClient.Defragmented).To(HaveLen(4))
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(HavePrefix("Warning PDEtcdDefragFailed failed to defragment the etcd of pd member " + PdPodName(tc.GetName(), 0)))
+	etcdClient.DefragmentError = nil
+	sync()
+	g.Expect(etcdClient.Defragmented).To(HaveLen(5))
+	g.Expect(etcdClient.Defragmented[4]).To(Equal(clientURL(0)))
+
+	// nothing is defragmented while a member is unhealthy
+	tc.Status.PD.EtcdDefragTime = nil
+	member := tc.Status.PD.Members[PdPodName(tc.GetName(), 1)]
+	member.Health = false
+	tc.Status.PD.Members[member.Name] = member
+	sync()
+	g.Expect(etcdClient.Defragmented).To(HaveLen(5))
+
+	// the status is cleared once disabled
+	tc.Status.PD.EtcdDefragTime = map[string]metav1.Time{member.Name: metav1.Now()}
+	tc.Spec.PD.EtcdDefragInterval = nil
+	sync()
+	g.Expect(tc.Status.PD.EtcdDefragTime).To(BeNil())
+}
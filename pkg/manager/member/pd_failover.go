@@ -57,6 +57,7 @@ func (f *pdFailover) Failover(tc *v1alpha1.TidbCluster) error {
 	if tc.Status.PD.FailureMembers == nil {
 		tc.Status.PD.FailureMembers = map[string]v1alpha1.PDFailureMember{}
 	}
+	f.removeRecoveredRecreatedMembers(tc)
 
 	inQuorum, healthCount := f.isPDInQuorum(tc)
 	if !inQuorum {
@@ -65,9 +66,13 @@ func (f *pdFailover) Failover(tc *v1alpha1.TidbCluster) error {
 			ns, tcName, healthCount, tc.PDStsDesiredReplicas(), tc.Spec.PD.Replicas, len(tc.Status.PD.FailureMembers))
 	}
 
+	maxFailoverCount := int32(0)
+	if count := tc.MaxFailoverCount(v1alpha1.PDMemberType); count != nil {
+		maxFailoverCount = *count
+	}
 	pdDeletedFailureReplicas := tc.GetPDDeletedFailureReplicas()
-	if pdDeletedFailureReplicas >= *tc.Spec.PD.MaxFailoverCount {
-		klog.Errorf("PD failover replicas (%d) reaches the limit (%d), skip failover", pdDeletedFailureReplicas, *tc.Spec.PD.MaxFailoverCount)
+	if pdDeletedFailureReplicas >= maxFailoverCount {
+		klog.Errorf("PD failover replicas (%d) reaches the limit (%d), skip failover", pdDeletedFailureReplicas, maxFailoverCount)
 		return nil
 	}
 
@@ -82,6 +87,13 @@ func (f *pdFailover) Failover(tc *v1alpha1.TidbCluster) error {
 }
 
 func (f *pdFailover) Recover(tc *v1alpha1.TidbCluster) {
+	if len(tc.Status.PD.FailureMembers) > 0 {
+		f.deps.FailoverNotifier.Notify(tc, controller.FailoverEvent{
+			Type:      controller.FailoverEventRecovered,
+			Component: v1alpha1.PDMemberType.String(),
+			Message:   fmt.Sprintf("%d failure members are recovered", len(tc.Status.PD.FailureMembers)),
+		})
+	}
 	tc.Status.PD.FailureMembers = nil
 	klog.Infof("pd failover: clearing pd failoverMembers, %s/%s", tc.GetNamespace(), tc.GetName())
 }
@@ -101,7 +113,7 @@ func (f *pdFailover) tryToMarkAPeerAsFailure(tc *v1alpha1.TidbCluster) error {
 		if tc.Status.PD.FailureMembers == nil {
 			tc.Status.PD.FailureMembers = map[string]v1alpha1.PDFailureMember{}
 		}
-		failoverDeadline := pdMember.LastTransitionTime.Add(f.deps.CLIConfig.PDFailoverPeriod)
+		failoverDeadline := pdMember.LastTransitionTime.Add(tc.FailoverPeriod(v1alpha1.PDMemberType, f.deps.CLIConfig.PDFailoverPeriod))
 		_, exist := tc.Status.PD.FailureMembers[pdName]
 
 		if pdMember.Health || time.Now().Before(failoverDeadline) || exist {
@@ -133,6 +145,12 @@ func (f *pdFailover) tryToMarkAPeerAsFailure(tc *v1alpha1.TidbCluster) error {
 			MemberDeleted: false,
 			CreatedAt:     metav1.Now(),
 		}
+		f.deps.FailoverNotifier.Notify(tc, controller.FailoverEvent{
+			Type:      controller.FailoverEventMemberFailed,
+			Component: v1alpha1.PDMemberType.String(),
+			Member:    podName,
+			Message:   fmt.Sprintf("member %s(%s) is unhealthy", podName, pdMember.ID),
+		})
 		return controller.RequeueErrorf("marking Pod: %s/%s pd member: %s as failure", ns, podName, pdMember.Name)
 	}
 
@@ -151,7 +169,9 @@ func (f *pdFailover) tryToDeleteAFailureMember(tc *v1alpha1.TidbCluster) error {
 
 	for pdName := range tc.Status.PD.FailureMembers {
 		pdMember := tc.Status.PD.FailureMembers[pdName]
-		if !pdMember.MemberDeleted {
+		// the member whose Pod is recreated is deleted if it is still
+		// unhealthy after the policy is changed to Recreate
+		if !pdMember.MemberDeleted && (!pdMember.PodRecreated || tc.PDFailoverPVCPolicy() == v1alpha1.FailoverPVCPolicyRecreate) {
 			failureMember = &pdMember
 			failurePodName = strings.Split(pdName, ".")[0]
 			failurePDName = pdName
@@ -162,6 +182,38 @@ func (f *pdFailover) tryToDeleteAFailureMember(tc *v1alpha1.TidbCluster) error {
 		klog.Infof("No PD FailureMembers to delete for tc %s/%s", ns, tcName)
 		return nil
 	}
+	if tc.PDFailoverPVCPolicy() == v1alpha1.FailoverPVCPolicyReuse {
+		return f.tryToRecreateAFailurePod(tc, failurePDName, failurePodName)
+	}
+	if ok, reason := canRemovePDMember(tc, failurePDName); !ok {
+		return controller.RequeueErrorf("pd failover[tryToDeleteAFailureMember]: can't delete member %s/%s, %s", ns, failurePodName, reason)
+	}
+
+	// the PVCs are selected by the Pod name, which works for the Pods of both
+	// spec.pd and the PD groups
+	pvcs, err := pdPodPVCs(f.deps, tc, failurePodName)
+	if err != nil {
+		return fmt.Errorf("pd failover[tryToDeleteAFailureMember]: failed to get PVCs for pod %s/%s, error: %s", ns, failurePodName, err)
+	}
+	var failurePVCs []*apiv1.PersistentVolumeClaim
+	for _, pvc := range pvcs {
+		_, pvcUIDExist := failureMember.PVCUIDSet[pvc.GetUID()]
+		// for backward compatibility, if there exists failureMembers and user upgrades operator to newer version
+		// there will be failure member structures with PVCUID set from api server, we should handle this as pvcUIDExist == true
+		if pvc.GetUID() == failureMember.PVCUID {
+			pvcUIDExist = true
+		}
+		if pvc.DeletionTimestamp == nil && pvcUIDExist {
+			failurePVCs = append(failurePVCs, pvc)
+		}
+	}
+	if err := reservePDDeletion(f.deps, tc, "member", failurePDName); err != nil {
+		return err
+	}
+	// snapshot the PVCs before the member is deleted, so that the data can be restored if needed
+	if err := snapshotPVCsBeforeDeletion(f.deps, tc, tc.PDFailoverSnapshot(), failurePVCs); err != nil {
+		return err
+	}
 
 	memberID, err := strconv.ParseUint(failureMember.MemberID, 10, 64)
 	if err != nil {
@@ -174,6 +226,13 @@ func (f *pdFailover) tryToDeleteAFailureMember(tc *v1alpha1.TidbCluster) error {
 	}
 	klog.Infof("pd failover[tryToDeleteAFailureMember]: delete member %s/%s(%d) successfully", ns, failurePodName, memberID)
 	f.deps.Recorder.Eventf(tc, apiv1.EventTypeWarning, "PDMemberDeleted", "failure member %s/%s(%d) deleted from PD cluster", ns, failurePodName, memberID)
+	f.deps.FailoverNotifier.Notify(tc, controller.FailoverEvent{
+		Type:          controller.FailoverEventTriggered,
+		Component:     v1alpha1.PDMemberType.String(),
+		Member:        failurePodName,
+		Message:       fmt.Sprintf("failure member %s(%d) is deleted from PD cluster to be recreated", failurePodName, memberID),
+		EventRecorded: true,
+	})
 
 	// The order of old PVC deleting and the new Pod creating is not guaranteed by Kubernetes.
 	// If new Pod is created before old PVCs are deleted, the Statefulset will try to use the old PVCs and skip creating new PVCs.
@@ -197,33 +256,12 @@ func (f *pdFailover) tryToDeleteAFailureMember(tc *v1alpha1.TidbCluster) error {
 		klog.Infof("pd failover[tryToDeleteAFailureMember]: failure pod %s/%s not found, skip", ns, failurePodName)
 	}
 
-	ordinal, err := util.GetOrdinalFromPodName(failurePodName)
-	if err != nil {
-		return fmt.Errorf("pd failover[tryToDeleteAFailureMember]: failed to parse ordinal from Pod name for %s/%s, error: %s", ns, failurePodName, err)
-	}
-	pvcSelector, err := GetPVCSelectorForPod(tc, v1alpha1.PDMemberType, ordinal)
-	if err != nil {
-		return fmt.Errorf("pd failover[tryToDeleteAFailureMember]: failed to get PVC selector for Pod %s/%s, error: %s", ns, failurePodName, err)
-	}
-	pvcs, err := f.deps.PVCLister.PersistentVolumeClaims(ns).List(pvcSelector)
-	if err != nil && !errors.IsNotFound(err) {
-		return fmt.Errorf("pd failover[tryToDeleteAFailureMember]: failed to get PVCs for pod %s/%s, error: %s", ns, failurePodName, err)
-	}
-
-	for _, pvc := range pvcs {
-		_, pvcUIDExist := failureMember.PVCUIDSet[pvc.GetUID()]
-		// for backward compatibility, if there exists failureMembers and user upgrades operator to newer version
-		// there will be failure member structures with PVCUID set from api server, we should handle this as pvcUIDExist == true
-		if pvc.GetUID() == failureMember.PVCUID {
-			pvcUIDExist = true
-		}
-		if pvc.DeletionTimestamp == nil && pvcUIDExist {
-			if err := f.deps.PVCControl.DeletePVC(tc, pvc); err != nil {
-				klog.Errorf("pd failover[tryToDeleteAFailureMember]: failed to delete PVC: %s/%s, error: %s", ns, pvc.Name, err)
-				return err
-			}
-			klog.Infof("pd failover[tryToDeleteAFailureMember]: delete PVC %s/%s successfully", ns, pvc.Name)
+	for _, pvc := range failurePVCs {
+		if err := f.deps.PVCControl.DeletePVC(tc, pvc); err != nil {
+			klog.Errorf("pd failover[tryToDeleteAFailureMember]: failed to delete PVC: %s/%s, error: %s", ns, pvc.Name, err)
+			return err
 		}
+		klog.Infof("pd failover[tryToDeleteAFailureMember]: delete PVC %s/%s successfully", ns, pvc.Name)
 	}
 
 	setMemberDeleted(tc, failurePDName)
@@ -237,10 +275,69 @@ func (f *pdFailover) isPodDesired(tc *v1alpha1.TidbCluster, podName string) bool
 		klog.Errorf("unexpected pod name %q: %v", podName, err)
 		return false
 	}
+	if isPDGroupMember(tc, podName) {
+		// the Pods of a PD group are the ordinals below its replicas
+		for _, group := range pdGroups(tc) {
+			if group.podName(ordinal) == podName {
+				return ordinal < group.spec.Replicas
+			}
+		}
+		return false
+	}
 	return ordinals.Has(ordinal)
 }
 
 func (f *pdFailover) RemoveUndesiredFailures(tc *v1alpha1.TidbCluster) {
+	for key, failureMember := range tc.Status.PD.FailureMembers {
+		if !f.isPodDesired(tc, failureMember.PodName) {
+			// If the replicas are decreased or the pods are deleted by using
+			// advanced statefulset delete slots feature, we should remove the
+			// record of undesired pods, otherwise an extra replacement pod will
+			// be created. The member of the pod is deleted by the PD scaler.
+			delete(tc.Status.PD.FailureMembers, key)
+			klog.Infof("pd failover: remove undesired failure member %s of %s/%s", key, tc.GetNamespace(), tc.GetName())
+		}
+	}
+}
+
+// tryToRecreateAFailurePod deletes the Pod of a failure member and keeps the
+// member and its PVC, so that the Pod is recreated by the StatefulSet and the
+// member restarts with its data. No replacement member is added for it.
+func (f *pdFailover) tryToRecreateAFailurePod(tc *v1alpha1.TidbCluster, failurePDName, failurePodName string) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	pod, err := f.deps.PodLister.Pods(ns).Get(failurePodName)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("pd failover[tryToRecreateAFailurePod]: failed to get pod %s/%s for tc %s/%s, error: %s", ns, failurePodName, ns, tcName, err)
+	}
+	if pod != nil && pod.DeletionTimestamp == nil {
+		if err := f.deps.PodControl.DeletePod(tc, pod); err != nil {
+			return err
+		}
+		klog.Infof("pd failover[tryToRecreateAFailurePod]: delete pod %s/%s with its PVC kept successfully", ns, failurePodName)
+		f.deps.Recorder.Eventf(tc, apiv1.EventTypeWarning, "PDPodRecreated", "pod %s/%s of failure member recreated with its PVC kept", ns, failurePodName)
+	}
+
+	failureMember := tc.Status.PD.FailureMembers[failurePDName]
+	failureMember.PodRecreated = true
+	tc.Status.PD.FailureMembers[failurePDName] = failureMember
+	return nil
+}
+
+// removeRecoveredRecreatedMembers removes the failure members whose Pods are
+// recreated with their PVCs kept and which are healthy again, so that they
+// are failed over again if they become unhealthy later
+func (f *pdFailover) removeRecoveredRecreatedMembers(tc *v1alpha1.TidbCluster) {
+	for pdName, failureMember := range tc.Status.PD.FailureMembers {
+		if !failureMember.PodRecreated {
+			continue
+		}
+		if pdMember, ok := tc.Status.PD.Members[pdName]; ok && pdMember.Health {
+			delete(tc.Status.PD.FailureMembers, pdName)
+			klog.Infof("pd failover: failure member %s/%s recovered after its pod is recreated", tc.GetNamespace(), pdName)
+		}
+	}
 }
 
 func setMemberDeleted(tc *v1alpha1.TidbCluster, pdName string) {
@@ -252,23 +349,19 @@ func setMemberDeleted(tc *v1alpha1.TidbCluster, pdName string) {
 
 // is healthy PD more than a half
 func (f *pdFailover) isPDInQuorum(tc *v1alpha1.TidbCluster) (bool, int) {
-	healthCount := 0
 	ns := tc.GetNamespace()
 	for podName, pdMember := range tc.Status.PD.Members {
-		if pdMember.Health {
-			healthCount++
-		} else {
+		if !pdMember.Health {
 			f.deps.Recorder.Eventf(tc, apiv1.EventTypeWarning, "PDMemberUnhealthy", "%s/%s(%s) is unhealthy", ns, podName, pdMember.ID)
 		}
 	}
 	for _, pdMember := range tc.Status.PD.PeerMembers {
-		if pdMember.Health {
-			healthCount++
-		} else {
+		if !pdMember.Health {
 			f.deps.Recorder.Eventf(tc, apiv1.EventTypeWarning, "PDPeerMemberUnhealthy", "%s(%s) is unhealthy", pdMember.Name, pdMember.ID)
 		}
 	}
-	return healthCount > (len(tc.Status.PD.Members)+len(tc.Status.PD.PeerMembers))/2, healthCount
+	healthCount, total := pdMemberHealth(tc)
+	return healthCount > total/2, healthCount
 }
 
 type fakePDFailover struct{}
@@ -525,6 +525,80 @@ func TestPDFailoverFailover(t *testing.T) {
 				g.Expect(events[1]).To(ContainSubstring("failure member default/test-pd-1(12891273174085095651) deleted from PD cluster"))
 			},
 		},
+		{
+			name: "has one not ready member, and exceed deadline, failover PVC policy is Recreate",
+			update: func(tc *v1alpha1.TidbCluster) {
+				oneNotReadyMemberAndAFailureMember(tc)
+				policy := v1alpha1.FailoverPVCPolicyRecreate
+				tc.Spec.PD.FailoverPVCPolicy = &policy
+			},
+			maxFailoverCount: 3,
+			hasPVC:           true,
+			hasPod:           true,
+			errExpectFn:      errExpectNil,
+			expectFn: func(tc *v1alpha1.TidbCluster, pf *pdFailover) {
+				pd1Name := ordinalPodName(v1alpha1.PDMemberType, tc.GetName(), 1)
+				pvcName := ordinalPVCName(v1alpha1.PDMemberType, controller.PDMemberName(tc.GetName()), 1)
+				pd1 := tc.Status.PD.FailureMembers[pd1Name]
+				g.Expect(pd1.MemberDeleted).To(BeTrue())
+				g.Expect(pd1.PodRecreated).To(BeFalse())
+				g.Expect(tc.GetPDDeletedFailureReplicas()).To(Equal(int32(1)))
+				_, err := pf.deps.PodLister.Pods(metav1.NamespaceDefault).Get(pd1Name)
+				g.Expect(errors.IsNotFound(err)).To(BeTrue())
+				_, err = pf.deps.PVCLister.PersistentVolumeClaims(metav1.NamespaceDefault).Get(pvcName + "-1")
+				g.Expect(errors.IsNotFound(err)).To(BeTrue())
+				_, err = pf.deps.PVCLister.PersistentVolumeClaims(metav1.NamespaceDefault).Get(pvcName + "-2")
+				g.Expect(errors.IsNotFound(err)).To(BeTrue())
+				events := collectEvents(recorder.Events)
+				g.Expect(events).To(HaveLen(2))
+				g.Expect(events[1]).To(ContainSubstring("failure member default/test-pd-1(12891273174085095651) deleted from PD cluster"))
+			},
+		},
+		{
+			name: "has one not ready member, and exceed deadline, failover PVC policy is Reuse",
+			update: func(tc *v1alpha1.TidbCluster) {
+				oneNotReadyMemberAndAFailureMember(tc)
+				policy := v1alpha1.FailoverPVCPolicyReuse
+				tc.Spec.PD.FailoverPVCPolicy = &policy
+			},
+			maxFailoverCount: 3,
+			hasPVC:           true,
+			hasPod:           true,
+			// the member must not be deleted from PD
+			delMemberFailed: true,
+			errExpectFn:     errExpectNil,
+			expectFn: func(tc *v1alpha1.TidbCluster, pf *pdFailover) {
+				pd1Name := ordinalPodName(v1alpha1.PDMemberType, tc.GetName(), 1)
+				pvcName := ordinalPVCName(v1alpha1.PDMemberType, controller.PDMemberName(tc.GetName()), 1)
+				pd1 := tc.Status.PD.FailureMembers[pd1Name]
+				g.Expect(pd1.MemberDeleted).To(BeFalse())
+				g.Expect(pd1.PodRecreated).To(BeTrue())
+				// no replacement member is added
+				g.Expect(tc.GetPDDeletedFailureReplicas()).To(Equal(int32(0)))
+				g.Expect(tc.PDStsDesiredReplicas()).To(Equal(int32(3)))
+				_, err := pf.deps.PodLister.Pods(metav1.NamespaceDefault).Get(pd1Name)
+				g.Expect(errors.IsNotFound(err)).To(BeTrue())
+				_, err = pf.deps.PVCLister.PersistentVolumeClaims(metav1.NamespaceDefault).Get(pvcName + "-1")
+				g.Expect(err).NotTo(HaveOccurred())
+				_, err = pf.deps.PVCLister.PersistentVolumeClaims(metav1.NamespaceDefault).Get(pvcName + "-2")
+				g.Expect(err).NotTo(HaveOccurred())
+				events := collectEvents(recorder.Events)
+				g.Expect(events).To(HaveLen(2))
+				g.Expect(events[1]).To(ContainSubstring("pod default/test-pd-1 of failure member recreated with its PVC kept"))
+
+				// the recreated member is waited for, and not failed over again
+				g.Expect(pf.Failover(tc)).To(Succeed())
+				g.Expect(tc.Status.PD.FailureMembers).To(HaveLen(1))
+				g.Expect(collectEvents(recorder.Events)).To(HaveLen(1))
+
+				// the record is removed once the recreated member is healthy again
+				pdMember := tc.Status.PD.Members[pd1Name]
+				pdMember.Health = true
+				tc.Status.PD.Members[pd1Name] = pdMember
+				g.Expect(pf.Failover(tc)).To(Succeed())
+				g.Expect(tc.Status.PD.FailureMembers).To(BeEmpty())
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -706,6 +780,62 @@ func TestPDFailoverRecovery(t *testing.T) {
 	}
 }
 
+func TestPDFailoverRemoveUndesiredFailures(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name     string
+		update   func(*v1alpha1.TidbCluster)
+		expectFn func(*v1alpha1.TidbCluster)
+	}
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+		tc := newTidbClusterForPD()
+		test.update(tc)
+
+		pdFailover, _, _, _, _, _ := newFakePDFailover()
+		pdFailover.RemoveUndesiredFailures(tc)
+		test.expectFn(tc)
+	}
+	tests := []testcase{
+		{
+			name: "two failure members, user don't modify the replicas",
+			update: func(tc *v1alpha1.TidbCluster) {
+				twoFailureMembers(tc)
+				tc.Spec.PD.Replicas = 3
+			},
+			expectFn: func(tc *v1alpha1.TidbCluster) {
+				g.Expect(tc.Status.PD.FailureMembers).To(HaveLen(2))
+			},
+		},
+		{
+			name: "two failure members, user decrease the replicas to 1",
+			update: func(tc *v1alpha1.TidbCluster) {
+				twoFailureMembers(tc)
+				tc.Spec.PD.Replicas = 1
+			},
+			expectFn: func(tc *v1alpha1.TidbCluster) {
+				g.Expect(tc.Status.PD.FailureMembers).To(HaveLen(1))
+				g.Expect(tc.Status.PD.FailureMembers).To(HaveKey(ordinalPodName(v1alpha1.PDMemberType, tc.GetName(), 0)))
+			},
+		},
+		{
+			name: "one failure member, user decrease the replicas to 1",
+			update: func(tc *v1alpha1.TidbCluster) {
+				oneFailureMember(tc)
+				tc.Spec.PD.Replicas = 1
+			},
+			expectFn: func(tc *v1alpha1.TidbCluster) {
+				g.Expect(tc.Status.PD.FailureMembers).To(BeEmpty())
+			},
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
+
 func newFakePDFailover() (*pdFailover, cache.Indexer, cache.Indexer, *pdapi.FakePDControl, *controller.FakePodControl, *controller.FakePVCControl) {
 	fakeDeps := controller.NewFakeDependencies()
 	pdFailover := &pdFailover{deps: fakeDeps}
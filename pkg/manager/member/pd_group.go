@@ -0,0 +1,505 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/advanced-statefulset/client/apis/apps/v1/helper"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog"
+	"k8s.io/utils/pointer"
+)
+
+// pdGroup is a group of spec.pd.groups, whose PD members are run in the
+// StatefulSet <cluster>-pd-<name> besides the StatefulSet of spec.pd. The
+// members of all the groups form one PD cluster with the members of spec.pd.
+type pdGroup struct {
+	tc   *v1alpha1.TidbCluster
+	spec v1alpha1.PDGroup
+}
+
+// pdGroups returns the PD groups of the cluster in the order of the spec
+func pdGroups(tc *v1alpha1.TidbCluster) []pdGroup {
+	if tc.Spec.PD == nil {
+		return nil
+	}
+	groups := make([]pdGroup, 0, len(tc.Spec.PD.Groups))
+	for _, spec := range tc.Spec.PD.Groups {
+		groups = append(groups, pdGroup{tc: tc, spec: spec})
+	}
+	return groups
+}
+
+// pdGroupOfSet returns the PD group run by the StatefulSet, it returns false
+// for the StatefulSet of spec.pd. A group removed from the spec is returned
+// with no replicas, so that its members are scaled in.
+func pdGroupOfSet(tc *v1alpha1.TidbCluster, set *apps.StatefulSet) (pdGroup, bool) {
+	name, ok := set.Labels[label.PDGroupLabelKey]
+	if !ok {
+		return pdGroup{}, false
+	}
+	for _, group := range pdGroups(tc) {
+		if group.spec.Name == name {
+			return group, true
+		}
+	}
+	return pdGroup{tc: tc, spec: v1alpha1.PDGroup{Name: name}}, true
+}
+
+// removedPDGroupSets returns the StatefulSets of the PD groups removed from
+// the spec
+func removedPDGroupSets(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) ([]*apps.StatefulSet, error) {
+	selector, err := label.New().Instance(tc.GetInstanceName()).PD().Selector()
+	if err != nil {
+		return nil, err
+	}
+	sets, err := deps.StatefulSetLister.StatefulSets(tc.GetNamespace()).List(selector)
+	if err != nil {
+		return nil, fmt.Errorf("removedPDGroupSets: failed to list pd sts for cluster %s/%s, selector: %s, error: %s", tc.GetNamespace(), tc.GetName(), selector, err)
+	}
+	names := map[string]bool{}
+	for _, group := range pdGroups(tc) {
+		names[group.spec.Name] = true
+	}
+	var removed []*apps.StatefulSet
+	for _, set := range sets {
+		if name, ok := set.Labels[label.PDGroupLabelKey]; ok && !names[name] {
+			removed = append(removed, set)
+		}
+	}
+	sort.Slice(removed, func(i, j int) bool { return removed[i].Name < removed[j].Name })
+	return removed, nil
+}
+
+// isPDGroupMember returns whether the PD member of the name is run by a PD
+// group, including the groups removed from the spec, the name is either the
+// Pod name or the FQDN of the Pod
+func isPDGroupMember(tc *v1alpha1.TidbCluster, name string) bool {
+	podName := strings.Split(name, ".")[0]
+	prefix := controller.PDMemberName(tc.GetName()) + "-"
+	if !strings.HasPrefix(podName, prefix) {
+		return false
+	}
+	// the Pods of spec.pd are <cluster>-pd-<ordinal>, and the ones of the
+	// groups are <cluster>-pd-<group>-<ordinal>
+	rest := strings.TrimPrefix(podName, prefix)
+	i := strings.LastIndex(rest, "-")
+	if i <= 0 {
+		return false
+	}
+	_, err := strconv.ParseInt(rest[i+1:], 10, 32)
+	return err == nil
+}
+
+func (g pdGroup) setName() string {
+	return fmt.Sprintf("%s-%s", controller.PDMemberName(g.tc.GetName()), g.spec.Name)
+}
+
+func (g pdGroup) podName(ordinal int32) string {
+	return fmt.Sprintf("%s-%d", g.setName(), ordinal)
+}
+
+// memberName returns the PD member name of the Pod of the ordinal, see PdName
+func (g pdGroup) memberName(ordinal int32) string {
+	if len(g.tc.Spec.ClusterDomain) > 0 {
+		return fmt.Sprintf("%s.%s.%s.svc.%s", g.podName(ordinal), controller.PDPeerMemberName(g.tc.GetName()), g.tc.GetNamespace(), g.tc.Spec.ClusterDomain)
+	}
+	return g.podName(ordinal)
+}
+
+// deleteSlotsAnnKey returns the key of the delete-slots annotation of the
+// group in the cluster, the delete slots of spec.pd are not shared with it
+func (g pdGroup) deleteSlotsAnnKey() string {
+	return fmt.Sprintf("%s-%s.tidb.pingcap.com/delete-slots", label.PDLabelVal, g.spec.Name)
+}
+
+// newStatefulSet returns the StatefulSet of the group from the StatefulSet of
+// spec.pd. The Pods of the group are labeled with the group, and are not
+// rolled until the partition is lowered by upgradeGroup.
+func (g pdGroup) newStatefulSet(pdSet *apps.StatefulSet) *apps.StatefulSet {
+	set := pdSet.DeepCopy()
+	set.Name = g.setName()
+	set.Labels[label.PDGroupLabelKey] = g.spec.Name
+	delete(set.Annotations, label.AnnPDDeleteSlots)
+	delete(set.Annotations, helper.DeleteSlotsAnn)
+	delete(set.Annotations, LastAppliedConfigAnnotation)
+	if val, ok := g.tc.Annotations[g.deleteSlotsAnnKey()]; ok {
+		if set.Annotations == nil {
+			set.Annotations = map[string]string{}
+		}
+		set.Annotations[helper.DeleteSlotsAnn] = val
+	}
+	set.Spec.Replicas = pointer.Int32Ptr(g.spec.Replicas)
+	set.Spec.Selector.MatchLabels[label.PDGroupLabelKey] = g.spec.Name
+	set.Spec.Template.Labels[label.PDGroupLabelKey] = g.spec.Name
+
+	if len(g.spec.NodeSelector) > 0 {
+		if set.Spec.Template.Spec.NodeSelector == nil {
+			set.Spec.Template.Spec.NodeSelector = map[string]string{}
+		}
+		for k, v := range g.spec.NodeSelector {
+			set.Spec.Template.Spec.NodeSelector[k] = v
+		}
+	}
+	if g.spec.StorageClassName != nil {
+		for i := range set.Spec.VolumeClaimTemplates {
+			if set.Spec.VolumeClaimTemplates[i].Name == v1alpha1.PDMemberType.String() {
+				set.Spec.VolumeClaimTemplates[i].Spec.StorageClassName = g.spec.StorageClassName
+			}
+		}
+	}
+	if set.Spec.UpdateStrategy.Type == apps.RollingUpdateStatefulSetStrategyType {
+		set.Spec.UpdateStrategy.RollingUpdate = &apps.RollingUpdateStatefulSetStrategy{Partition: pointer.Int32Ptr(g.spec.Replicas)}
+	}
+	return set
+}
+
+// syncPDGroups syncs the StatefulSets of the PD groups from the StatefulSet of
+// spec.pd. A StatefulSet is created with no replicas, so that all the members
+// join the PD cluster through the scaler.
+func (m *pdMemberManager) syncPDGroups(tc *v1alpha1.TidbCluster, pdSet *apps.StatefulSet) error {
+	ns := tc.GetNamespace()
+	for _, group := range pdGroups(tc) {
+		oldSet, err := m.deps.StatefulSetLister.StatefulSets(ns).Get(group.setName())
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("syncPDGroups: fail to get sts %s for cluster %s/%s, error: %s", group.setName(), ns, tc.GetName(), err)
+		}
+
+		newSet := group.newStatefulSet(pdSet)
+		if errors.IsNotFound(err) {
+			newSet.Spec.Replicas = pointer.Int32Ptr(0)
+			if err := SetStatefulSetLastAppliedConfigAnnotation(newSet); err != nil {
+				return err
+			}
+			if err := m.deps.StatefulSetControl.CreateStatefulSet(tc, newSet); err != nil {
+				return err
+			}
+			continue
+		}
+
+		oldSet = oldSet.DeepCopy()
+		if err := m.scaler.Scale(tc, oldSet, newSet); err != nil {
+			return err
+		}
+		if err := m.upgradeGroup(tc, group, oldSet, newSet); err != nil {
+			return err
+		}
+		if err := updateStatefulSetAndRunPostScaleHook(m.deps, tc, v1alpha1.PDMemberType, newSet, oldSet); err != nil {
+			return err
+		}
+	}
+	return m.deleteRemovedPDGroups(tc)
+}
+
+// deleteRemovedPDGroups scales in the members of the PD groups removed from
+// the spec one at a time as the other groups, and deletes the StatefulSet of
+// a group after all its Pods are gone. The PVCs of the members are reclaimed
+// by the PVC cleaner as the ones of any scaled in member.
+func (m *pdMemberManager) deleteRemovedPDGroups(tc *v1alpha1.TidbCluster) error {
+	ns := tc.GetNamespace()
+	removed, err := removedPDGroupSets(m.deps, tc)
+	if err != nil {
+		return err
+	}
+	for _, oldSet := range removed {
+		if *oldSet.Spec.Replicas == 0 && oldSet.Status.Replicas == 0 {
+			klog.Infof("tidbcluster: [%s/%s]'s pd group %s is removed, delete statefulset %s", ns, tc.GetName(), oldSet.Labels[label.PDGroupLabelKey], oldSet.GetName())
+			if err := m.deps.StatefulSetControl.DeleteStatefulSet(tc, oldSet); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			continue
+		}
+
+		oldSet = oldSet.DeepCopy()
+		newSet := oldSet.DeepCopy()
+		newSet.Spec.Replicas = pointer.Int32Ptr(0)
+		if err := m.scaler.Scale(tc, oldSet, newSet); err != nil {
+			return err
+		}
+		if err := updateStatefulSetAndRunPostScaleHook(m.deps, tc, v1alpha1.PDMemberType, newSet, oldSet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upgradeGroup rolls the Pods of the PD group one at a time from the highest
+// ordinal by lowering the partition of the StatefulSet, and transfers the PD
+// leader away from a member before its Pod is rolled, as pdUpgrader does for
+// spec.pd. A group starts rolling after spec.pd finishes upgrading and
+// scaling, so that only one PD member restarts at a time.
+func (m *pdMemberManager) upgradeGroup(tc *v1alpha1.TidbCluster, group pdGroup, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	if newSet.Spec.UpdateStrategy.Type != apps.RollingUpdateStatefulSetStrategyType {
+		return nil
+	}
+	if oldSet.Spec.UpdateStrategy.Type == apps.OnDeleteStatefulSetStrategyType || oldSet.Spec.UpdateStrategy.RollingUpdate == nil {
+		// the update strategy is modified manually, see pdUpgrader
+		newSet.Spec.UpdateStrategy = oldSet.Spec.UpdateStrategy
+		klog.Warningf("tidbcluster: [%s/%s] pd group statefulset %s UpdateStrategy has been modified manually", ns, tcName, oldSet.GetName())
+		return nil
+	}
+	// no Pod is rolled unless the partition is lowered below
+	setUpgradePartition(newSet, helper.GetMaxPodOrdinal(*newSet.Spec.Replicas, newSet)+1)
+
+	if !templateEqual(newSet, oldSet) {
+		if tc.PDUpgrading() || tc.PDScaling() {
+			klog.Infof("tidbcluster: [%s/%s]'s pd status is %v, can not upgrade pd group %s", ns, tcName, tc.Status.PD.Phase, group.spec.Name)
+			_, podSpec, err := GetLastAppliedConfig(oldSet)
+			if err != nil {
+				return err
+			}
+			newSet.Spec.Template.Spec = *podSpec
+		}
+		return nil
+	}
+	if oldSet.Status.ObservedGeneration < oldSet.Generation {
+		return controller.RequeueErrorf("tidbcluster: [%s/%s]'s pd group statefulset %s is not observed yet", ns, tcName, oldSet.GetName())
+	}
+	updateRevision := oldSet.Status.UpdateRevision
+	if updateRevision == oldSet.Status.CurrentRevision {
+		return nil
+	}
+	if !tc.Status.PD.Synced {
+		return fmt.Errorf("tidbcluster: [%s/%s]'s pd status sync failed, can not upgrade pd group %s", ns, tcName, group.spec.Name)
+	}
+
+	podOrdinals := helper.GetPodOrdinals(*oldSet.Spec.Replicas, oldSet).List()
+	for _i := len(podOrdinals) - 1; _i >= 0; _i-- {
+		i := podOrdinals[_i]
+		podName := group.podName(i)
+		pod, err := m.deps.PodLister.Pods(ns).Get(podName)
+		if err != nil {
+			return fmt.Errorf("upgradeGroup: failed to get pod %s for cluster %s/%s, error: %s", podName, ns, tcName, err)
+		}
+		revision, exist := pod.Labels[apps.ControllerRevisionHashLabelKey]
+		if !exist {
+			return controller.RequeueErrorf("tidbcluster: [%s/%s]'s pd pod: [%s] has no label: %s", ns, tcName, podName, apps.ControllerRevisionHashLabelKey)
+		}
+		memberName := group.memberName(i)
+		statusName := memberName
+		if _, exist := tc.Status.PD.Members[statusName]; !exist {
+			statusName = podName
+		}
+
+		if revision == updateRevision {
+			if member, exist := tc.Status.PD.Members[statusName]; !exist || !member.Health {
+				return controller.RequeueErrorf("tidbcluster: [%s/%s]'s pd upgraded pod: [%s] is not ready", ns, tcName, podName)
+			}
+			continue
+		}
+
+		if upgradePaused(tc, v1alpha1.PDMemberType) || waitForMaintenanceWindow(tc, v1alpha1.PDMemberType, "upgrade") {
+			setUpgradePartition(newSet, *oldSet.Spec.UpdateStrategy.RollingUpdate.Partition)
+			return nil
+		}
+		if ok, reason := canRemovePDMember(tc, statusName); !ok {
+			return controller.RequeueErrorf("tidbcluster: [%s/%s]'s pd member: [%s] can't be upgraded now, %s", ns, tcName, memberName, reason)
+		}
+		if tc.Status.PD.Leader.Name == memberName || tc.Status.PD.Leader.Name == podName {
+			target := pdLeaderTransferPriorityTarget(tc, sets.NewString(podName))
+			if target == "" {
+				var names []string
+				for name, member := range tc.Status.PD.Members {
+					if member.Health && !member.IsLearner && name != statusName {
+						names = append(names, name)
+					}
+				}
+				if len(names) > 0 {
+					sort.Strings(names)
+					target = names[0]
+				}
+			}
+			if target != "" {
+				if err := controller.GetPDClient(m.deps.PDControl, tc).TransferPDLeader(target); err != nil {
+					klog.Errorf("pd upgrader: failed to transfer pd leader to: %s, %v", target, err)
+					return err
+				}
+				klog.Infof("pd upgrader: transfer pd leader to: %s successfully", target)
+				return controller.RequeueErrorf("tidbcluster: [%s/%s]'s pd member: [%s] is transferring leader to pd member: [%s]", ns, tcName, memberName, target)
+			}
+		}
+		setUpgradePartition(newSet, i)
+		return nil
+	}
+	return nil
+}
+
+// scalingPDSet returns the name of a PD StatefulSet the scale of the given
+// StatefulSet has to wait for, or "" if there is none. The StatefulSets are
+// ordered as spec.pd followed by spec.pd.groups and the groups removed from
+// the spec, a StatefulSet waits for the ones before it to reach their desired
+// replicas, and for the ones after it to finish creating or deleting their
+// Pods, so that only one PD member joins or leaves at a time.
+func scalingPDSet(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, setName string) (string, error) {
+	ns := tc.GetNamespace()
+	names := []string{controller.PDMemberName(tc.GetName())}
+	desired := map[string]int32{names[0]: tc.PDStsDesiredReplicas()}
+	for _, group := range pdGroups(tc) {
+		names = append(names, group.setName())
+		desired[group.setName()] = group.spec.Replicas
+	}
+	removed, err := removedPDGroupSets(deps, tc)
+	if err != nil {
+		return "", err
+	}
+	for _, set := range removed {
+		names = append(names, set.Name)
+		desired[set.Name] = 0
+	}
+
+	before := true
+	for _, name := range names {
+		if name == setName {
+			before = false
+			continue
+		}
+		set, err := deps.StatefulSetLister.StatefulSets(ns).Get(name)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("scalingPDSet: fail to get sts %s for cluster %s/%s, error: %s", name, ns, tc.GetName(), err)
+		}
+		if set.Status.Replicas != *set.Spec.Replicas || before && *set.Spec.Replicas != desired[name] {
+			return name, nil
+		}
+	}
+	return "", nil
+}
+
+// scaleGroup scales the StatefulSet of the PD group by one member at a time,
+// the members leave the PD cluster before their Pods are deleted as the ones
+// of spec.pd.
+func (s *pdScaler) scaleGroup(tc *v1alpha1.TidbCluster, group pdGroup, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	scaling, ordinal, replicas, deleteSlots := scaleOne(oldSet, newSet)
+	resetReplicas(newSet, oldSet)
+
+	if !tc.Status.PD.Synced {
+		return fmt.Errorf("TidbCluster: %s/%s's pd status sync failed, can't scale pd group %s now", ns, tcName, group.spec.Name)
+	}
+	podName := group.podName(ordinal)
+
+	if scaling > 0 {
+		// the members joined by the previous scale-out must be promoted to voters first
+		if learners := waitingPDLearners(tc); len(learners) > 0 {
+			return controller.RequeueErrorf("TidbCluster: %s/%s's pd members %s are not promoted to voters yet, can't scale out pd group %s now", ns, tcName, strings.Join(learners, ", "), group.spec.Name)
+		}
+		klog.Infof("scaling out pd group statefulset %s/%s, ordinal: %d (replicas: %d, delete slots: %v)", ns, oldSet.Name, ordinal, replicas, deleteSlots.List())
+		// the data of a member removed by a previous scale-in must not be reused
+		pvcs, err := pdPodPVCs(s.deps, tc, podName)
+		if err != nil {
+			return err
+		}
+		for _, pvc := range pvcs {
+			if _, ok := pvc.Annotations[label.AnnPVCDeferDeleting]; !ok {
+				continue
+			}
+			if err := s.deps.PVCControl.DeletePVC(tc, pvc); err != nil {
+				return err
+			}
+		}
+		setReplicasAndDeleteSlots(newSet, replicas, deleteSlots)
+		return nil
+	}
+
+	klog.Infof("scaling in pd group statefulset %s/%s, ordinal: %d (replicas: %d, delete slots: %v)", ns, oldSet.Name, ordinal, replicas, deleteSlots.List())
+	memberName := group.memberName(ordinal)
+	statusName := memberName
+	if _, exist := tc.Status.PD.Members[statusName]; !exist {
+		statusName = podName
+	}
+	if _, exist := tc.Status.PD.Members[statusName]; exist {
+		if ok, reason := canRemovePDMember(tc, statusName); !ok {
+			return controller.RequeueErrorf("tc[%s/%s]'s pd member %s can't be scaled in now, %s", ns, tcName, memberName, reason)
+		}
+		if err := reservePDDeletion(s.deps, tc, "member", memberName); err != nil {
+			return err
+		}
+
+		pdClient := controller.GetPDClient(s.deps.PDControl, tc)
+		leader, err := pdClient.GetPDLeader()
+		if err != nil {
+			return err
+		}
+		if leader.Name == memberName || leader.Name == podName {
+			target := pdLeaderTransferPriorityTarget(tc, sets.NewString(podName))
+			if target == "" {
+				var names []string
+				for name, member := range tc.Status.PD.Members {
+					if member.Health && name != statusName {
+						names = append(names, name)
+					}
+				}
+				if len(names) > 0 {
+					sort.Strings(names)
+					target = names[0]
+				}
+			}
+			if target != "" {
+				if err := pdClient.TransferPDLeader(target); err != nil {
+					return err
+				}
+				return controller.RequeueErrorf("tc[%s/%s]'s pd member %s is transferring pd leader, can't scale in now", ns, tcName, memberName)
+			}
+		}
+
+		if err := pdClient.DeleteMember(memberName); err != nil {
+			klog.Errorf("pdScaler.scaleGroup: failed to delete member %s, %v", memberName, err)
+			return err
+		}
+		klog.Infof("pdScaler.scaleGroup: delete member %s successfully", memberName)
+	}
+
+	pvcs, err := pdPodPVCs(s.deps, tc, podName)
+	if err != nil {
+		return err
+	}
+	for _, pvc := range pvcs {
+		if err := addDeferDeletingAnnoToPVC(tc, pvc, s.deps.PVCControl); err != nil {
+			return err
+		}
+	}
+
+	setReplicasAndDeleteSlots(newSet, replicas, deleteSlots)
+	return nil
+}
+
+// pdPodPVCs returns the PVCs of the PD Pod, of spec.pd or a PD group
+func pdPodPVCs(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, podName string) ([]*corev1.PersistentVolumeClaim, error) {
+	l := label.New().Instance(tc.GetInstanceName())
+	l[label.AnnPodNameKey] = podName
+	selector, err := l.Selector()
+	if err != nil {
+		return nil, err
+	}
+	pvcs, err := deps.PVCLister.PersistentVolumeClaims(tc.GetNamespace()).List(selector)
+	if err != nil {
+		return nil, fmt.Errorf("pdPodPVCs: failed to list pvcs of pod %s/%s, selector: %s, error: %s", tc.GetNamespace(), podName, selector, err)
+	}
+	return pvcs, nil
+}
@@ -0,0 +1,513 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/advanced-statefulset/client/apis/apps/v1/helper"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/pointer"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/features"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+)
+
+func newTidbClusterForPDGroup() *v1alpha1.TidbCluster {
+	tc := newTidbClusterForPD()
+	tc.Spec.PD.Groups = []v1alpha1.PDGroup{
+		{Name: "east", Replicas: 2, NodeSelector: map[string]string{"zone": "east"}, StorageClassName: pointer.StringPtr("ssd")},
+		{Name: "west", Replicas: 2},
+	}
+	return tc
+}
+
+func newStatefulSetForPDGroup(name string, replicas, current int32) *apps.StatefulSet {
+	return &apps.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: metav1.NamespaceDefault,
+		},
+		Spec: apps.StatefulSetSpec{
+			Replicas: pointer.Int32Ptr(replicas),
+		},
+		Status: apps.StatefulSetStatus{
+			Replicas: current,
+		},
+	}
+}
+
+func TestIsPDGroupMember(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPDGroup()
+	g.Expect(isPDGroupMember(tc, "test-pd-east-0")).To(BeTrue())
+	g.Expect(isPDGroupMember(tc, "test-pd-west-1.test-pd-peer.default.svc.cluster.local")).To(BeTrue())
+	g.Expect(isPDGroupMember(tc, "test-pd-0")).To(BeFalse())
+	// the members of a group removed from the spec are still scaled in
+	g.Expect(isPDGroupMember(tc, "test-pd-north-0")).To(BeTrue())
+	g.Expect(isPDGroupMember(tc, "test-pd-east-x")).To(BeFalse())
+}
+
+func TestPDGroupNewStatefulSet(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPDGroup()
+	tc.Annotations = map[string]string{
+		label.AnnPDDeleteSlots:                  "[1]",
+		"pd-east.tidb.pingcap.com/delete-slots": "[0]",
+	}
+	pdSet := &apps.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      controller.PDMemberName(tc.GetName()),
+			Namespace: tc.GetNamespace(),
+			Labels:    map[string]string{label.ComponentLabelKey: label.PDLabelVal},
+			Annotations: map[string]string{
+				helper.DeleteSlotsAnn:       "[1]",
+				LastAppliedConfigAnnotation: "{}",
+			},
+		},
+		Spec: apps.StatefulSetSpec{
+			Replicas: pointer.Int32Ptr(3),
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{label.ComponentLabelKey: label.PDLabelVal}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{label.ComponentLabelKey: label.PDLabelVal}},
+				Spec:       corev1.PodSpec{NodeSelector: map[string]string{"disk": "ssd"}},
+			},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
+				{ObjectMeta: metav1.ObjectMeta{Name: "pd"}},
+			},
+			UpdateStrategy: apps.StatefulSetUpdateStrategy{
+				Type:          apps.RollingUpdateStatefulSetStrategyType,
+				RollingUpdate: &apps.RollingUpdateStatefulSetStrategy{Partition: pointer.Int32Ptr(2)},
+			},
+		},
+	}
+
+	set := pdGroups(tc)[0].newStatefulSet(pdSet)
+	g.Expect(set.Name).To(Equal("test-pd-east"))
+	g.Expect(*set.Spec.Replicas).To(Equal(int32(2)))
+	g.Expect(set.Labels).To(HaveKeyWithValue(label.PDGroupLabelKey, "east"))
+	g.Expect(set.Spec.Selector.MatchLabels).To(HaveKeyWithValue(label.PDGroupLabelKey, "east"))
+	g.Expect(set.Spec.Template.Labels).To(HaveKeyWithValue(label.PDGroupLabelKey, "east"))
+	// the delete slots of spec.pd are not shared with the group
+	g.Expect(set.Annotations).To(HaveKeyWithValue(helper.DeleteSlotsAnn, "[0]"))
+	g.Expect(set.Annotations).NotTo(HaveKey(LastAppliedConfigAnnotation))
+	g.Expect(set.Spec.Template.Spec.NodeSelector).To(Equal(map[string]string{"disk": "ssd", "zone": "east"}))
+	g.Expect(*set.Spec.VolumeClaimTemplates[0].Spec.StorageClassName).To(Equal("ssd"))
+	// no Pod is rolled until the partition is lowered by upgradeGroup
+	g.Expect(*set.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(2)))
+
+	// the StatefulSet of spec.pd is not changed
+	g.Expect(pdSet.Labels).NotTo(HaveKey(label.PDGroupLabelKey))
+	g.Expect(pdSet.Annotations).To(HaveKeyWithValue(helper.DeleteSlotsAnn, "[1]"))
+	g.Expect(pdSet.Spec.Template.Spec.NodeSelector).To(Equal(map[string]string{"disk": "ssd"}))
+	g.Expect(pdSet.Spec.VolumeClaimTemplates[0].Spec.StorageClassName).To(BeNil())
+
+	group, ok := pdGroupOfSet(tc, set)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(group.spec.Name).To(Equal("east"))
+	_, ok = pdGroupOfSet(tc, pdSet)
+	g.Expect(ok).To(BeFalse())
+}
+
+func TestScalingPDSet(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		name     string
+		sets     []*apps.StatefulSet
+		setName  string
+		expected string
+	}{
+		{
+			name: "all sets are scaled",
+			sets: []*apps.StatefulSet{
+				newStatefulSetForPDGroup("test-pd", 3, 3),
+				newStatefulSetForPDGroup("test-pd-east", 2, 2),
+			},
+			setName:  "test-pd-west",
+			expected: "",
+		},
+		{
+			name: "the main set is not scaled to its desired replicas",
+			sets: []*apps.StatefulSet{
+				newStatefulSetForPDGroup("test-pd", 2, 2),
+				newStatefulSetForPDGroup("test-pd-east", 0, 0),
+			},
+			setName:  "test-pd-east",
+			expected: "test-pd",
+		},
+		{
+			name: "a later set does not wait for its desired replicas",
+			sets: []*apps.StatefulSet{
+				newStatefulSetForPDGroup("test-pd", 2, 2),
+				newStatefulSetForPDGroup("test-pd-east", 0, 0),
+			},
+			setName:  "test-pd",
+			expected: "",
+		},
+		{
+			name: "a later set is creating a pod",
+			sets: []*apps.StatefulSet{
+				newStatefulSetForPDGroup("test-pd", 3, 3),
+				newStatefulSetForPDGroup("test-pd-west", 1, 0),
+			},
+			setName:  "test-pd",
+			expected: "test-pd-west",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deps := controller.NewFakeDependencies()
+			indexer := deps.KubeInformerFactory.Apps().V1().StatefulSets().Informer().GetIndexer()
+			for _, set := range test.sets {
+				indexer.Add(set)
+			}
+
+			name, err := scalingPDSet(deps, newTidbClusterForPDGroup(), test.setName)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(name).To(Equal(test.expected))
+		})
+	}
+}
+
+func TestDeleteRemovedPDGroups(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pmm, _, _ := newFakePDMemberManager()
+	deps := pmm.deps
+	deleteFromIndexers(deps)
+	tc := newTidbClusterForPDGroup()
+	indexer := deps.KubeInformerFactory.Apps().V1().StatefulSets().Informer().GetIndexer()
+	for name, replicas := range map[string]int32{"west": 2, "north": 2, "south": 0} {
+		set := newStatefulSetForPDGroup("test-pd-"+name, replicas, replicas)
+		set.Labels = label.New().Instance(tc.GetInstanceName()).PD().Labels()
+		set.Labels[label.PDGroupLabelKey] = name
+		indexer.Add(set)
+	}
+
+	removed, err := removedPDGroupSets(deps, tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(removed).To(HaveLen(2))
+	g.Expect(removed[0].Name).To(Equal("test-pd-north"))
+	g.Expect(removed[1].Name).To(Equal("test-pd-south"))
+	group, ok := pdGroupOfSet(tc, removed[0])
+	g.Expect(ok).To(BeTrue())
+	g.Expect(group.spec.Replicas).To(BeZero())
+
+	g.Expect(pmm.deleteRemovedPDGroups(tc)).To(Succeed())
+	// the removed group with members is scaled in, the empty one is deleted
+	set, err := deps.StatefulSetLister.StatefulSets(tc.GetNamespace()).Get("test-pd-north")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(*set.Spec.Replicas).To(Equal(int32(1)))
+	_, err = deps.StatefulSetLister.StatefulSets(tc.GetNamespace()).Get("test-pd-south")
+	g.Expect(errors.IsNotFound(err)).To(BeTrue())
+	set, err = deps.StatefulSetLister.StatefulSets(tc.GetNamespace()).Get("test-pd-west")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(*set.Spec.Replicas).To(Equal(int32(2)))
+}
+
+func TestPDScalerScaleGroup(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		name        string
+		oldReplicas int32
+		newReplicas int32
+		isLeader    bool
+		errExpectFn func(*GomegaWithT, error)
+		replicas    int32
+		deleted     []string
+	}{
+		{
+			name:        "scale out",
+			oldReplicas: 1,
+			newReplicas: 2,
+			errExpectFn: errExpectNil,
+			replicas:    2,
+		},
+		{
+			name:        "scale in",
+			oldReplicas: 2,
+			newReplicas: 1,
+			errExpectFn: errExpectNil,
+			replicas:    1,
+			deleted:     []string{"test-pd-east-1"},
+		},
+		{
+			name:        "scale in the leader",
+			oldReplicas: 2,
+			newReplicas: 1,
+			isLeader:    true,
+			errExpectFn: errExpectRequeue,
+			replicas:    2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scaler, pdControl, pvcIndexer, _, _ := newFakePDScaler()
+
+			tc := newTidbClusterForPDGroup()
+			tc.Status.PD.Synced = true
+			normalPDMember(tc)
+			tc.Status.PD.Members["test-pd-east-0"] = v1alpha1.PDMember{Health: true}
+			tc.Status.PD.Members["test-pd-east-1"] = v1alpha1.PDMember{Health: true}
+			group := pdGroups(tc)[0]
+
+			pvc := &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pd-test-pd-east-1",
+					Namespace: tc.GetNamespace(),
+					Labels:    label.New().Instance(tc.GetInstanceName()).Labels(),
+				},
+			}
+			pvc.Labels[label.AnnPodNameKey] = "test-pd-east-1"
+			if test.oldReplicas < test.newReplicas {
+				pvc.Annotations = map[string]string{label.AnnPVCDeferDeleting: "2021-01-01T00:00:00Z"}
+			}
+			pvcIndexer.Add(pvc)
+
+			var deleted []string
+			var transferred []string
+			pdClient := controller.NewFakePDClient(pdControl, tc)
+			pdClient.AddReaction(pdapi.GetPDLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+				if test.isLeader {
+					return &pdpb.Member{Name: "test-pd-east-1"}, nil
+				}
+				return &pdpb.Member{Name: "test-pd-0"}, nil
+			})
+			pdClient.AddReaction(pdapi.DeleteMemberActionType, func(action *pdapi.Action) (interface{}, error) {
+				deleted = append(deleted, action.Name)
+				return nil, nil
+			})
+			pdClient.AddReaction(pdapi.TransferPDLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+				transferred = append(transferred, action.Name)
+				return nil, nil
+			})
+
+			oldSet := newStatefulSetForPDGroup(group.setName(), test.oldReplicas, test.oldReplicas)
+			newSet := newStatefulSetForPDGroup(group.setName(), test.newReplicas, test.oldReplicas)
+			err := scaler.scaleGroup(tc, group, oldSet, newSet)
+			test.errExpectFn(g, err)
+			g.Expect(*newSet.Spec.Replicas).To(Equal(test.replicas))
+			g.Expect(deleted).To(Equal(test.deleted))
+
+			pvcs, err := pdPodPVCs(scaler.deps, tc, "test-pd-east-1")
+			g.Expect(err).NotTo(HaveOccurred())
+			switch {
+			case test.oldReplicas < test.newReplicas:
+				// the PVC left by the previous scale-in is deleted
+				g.Expect(pvcs).To(BeEmpty())
+			case test.isLeader:
+				g.Expect(transferred).To(Equal([]string{"test-pd-0"}))
+				g.Expect(pvcs[0].Annotations).NotTo(HaveKey(label.AnnPVCDeferDeleting))
+			default:
+				g.Expect(pvcs[0].Annotations).To(HaveKey(label.AnnPVCDeferDeleting))
+			}
+		})
+	}
+}
+
+func TestPDGroupUpgrade(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		name             string
+		templateChanged  bool
+		pdUpgrading      bool
+		revisions        []string
+		unhealthy        bool
+		isLeader         bool
+		rolled           bool
+		errExpectFn      func(*GomegaWithT, error)
+		partition        int32
+		templateReverted bool
+		transferred      []string
+	}{
+		{
+			name:            "the new template is applied with the partition held",
+			templateChanged: true,
+			revisions:       []string{"old", "old"},
+			errExpectFn:     errExpectNil,
+			partition:       2,
+		},
+		{
+			name:             "the group waits for spec.pd to upgrade",
+			templateChanged:  true,
+			pdUpgrading:      true,
+			revisions:        []string{"old", "old"},
+			errExpectFn:      errExpectNil,
+			partition:        2,
+			templateReverted: true,
+		},
+		{
+			name:        "roll the highest ordinal",
+			revisions:   []string{"old", "old"},
+			errExpectFn: errExpectNil,
+			partition:   1,
+		},
+		{
+			name:        "roll the next ordinal",
+			revisions:   []string{"old", "new"},
+			errExpectFn: errExpectNil,
+			partition:   0,
+		},
+		{
+			name:        "wait for the rolled member to be healthy",
+			revisions:   []string{"old", "new"},
+			unhealthy:   true,
+			errExpectFn: errExpectRequeue,
+			partition:   2,
+		},
+		{
+			name:        "transfer the leader before rolling",
+			revisions:   []string{"old", "old"},
+			isLeader:    true,
+			errExpectFn: errExpectRequeue,
+			partition:   2,
+			transferred: []string{"test-pd-0"},
+		},
+		{
+			name:        "all the members are rolled",
+			revisions:   []string{"new", "new"},
+			rolled:      true,
+			errExpectFn: errExpectNil,
+			partition:   2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pmm, podIndexer, _ := newFakePDMemberManager()
+
+			tc := newTidbClusterForPDGroup()
+			tc.Status.PD.Synced = true
+			tc.Status.PD.Phase = v1alpha1.NormalPhase
+			if test.pdUpgrading {
+				tc.Status.PD.Phase = v1alpha1.UpgradePhase
+			}
+			normalPDMember(tc)
+			tc.Status.PD.Members["test-pd-east-0"] = v1alpha1.PDMember{Health: true}
+			tc.Status.PD.Members["test-pd-east-1"] = v1alpha1.PDMember{Health: !test.unhealthy}
+			tc.Status.PD.Leader = v1alpha1.PDMember{Name: "test-pd-0"}
+			if test.isLeader {
+				tc.Status.PD.Leader = v1alpha1.PDMember{Name: "test-pd-east-1"}
+			}
+			group := pdGroups(tc)[0]
+			for i, revision := range test.revisions {
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      group.podName(int32(i)),
+						Namespace: tc.GetNamespace(),
+						Labels:    map[string]string{apps.ControllerRevisionHashLabelKey: revision},
+					},
+				}
+				g.Expect(podIndexer.Add(pod)).To(Succeed())
+			}
+
+			var transferred []string
+			pdClient := controller.NewFakePDClient(pmm.deps.PDControl.(*pdapi.FakePDControl), tc)
+			pdClient.AddReaction(pdapi.TransferPDLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+				transferred = append(transferred, action.Name)
+				return nil, nil
+			})
+
+			oldSet := newStatefulSetForPDGroup(group.setName(), 2, 2)
+			oldSet.Spec.Template.Spec.Containers = []corev1.Container{{Name: "pd", Image: "pd:v1"}}
+			oldSet.Spec.UpdateStrategy = apps.StatefulSetUpdateStrategy{
+				Type:          apps.RollingUpdateStatefulSetStrategyType,
+				RollingUpdate: &apps.RollingUpdateStatefulSetStrategy{Partition: pointer.Int32Ptr(2)},
+			}
+			oldSet.Status.CurrentRevision = "old"
+			oldSet.Status.UpdateRevision = "new"
+			if test.rolled {
+				oldSet.Status.CurrentRevision = "new"
+			}
+			g.Expect(SetStatefulSetLastAppliedConfigAnnotation(oldSet)).To(Succeed())
+			newSet := oldSet.DeepCopy()
+			if test.templateChanged {
+				newSet.Spec.Template.Spec.Containers[0].Image = "pd:v2"
+			}
+
+			err := pmm.upgradeGroup(tc, group, oldSet, newSet)
+			test.errExpectFn(g, err)
+			g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(test.partition))
+			g.Expect(transferred).To(Equal(test.transferred))
+			if test.templateReverted {
+				g.Expect(newSet.Spec.Template.Spec.Containers[0].Image).To(Equal("pd:v1"))
+			}
+		})
+	}
+}
+
+func TestPDFailoverIsPodDesiredForGroups(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPDGroup()
+	tc.Spec.PD.Replicas = 3
+	f := &pdFailover{deps: controller.NewFakeDependencies()}
+	g.Expect(f.isPodDesired(tc, "test-pd-2")).To(BeTrue())
+	g.Expect(f.isPodDesired(tc, "test-pd-3")).To(BeFalse())
+	// the ordinals of a group are not the ordinals of spec.pd
+	g.Expect(f.isPodDesired(tc, "test-pd-east-1")).To(BeTrue())
+	g.Expect(f.isPodDesired(tc, "test-pd-east-2")).To(BeFalse())
+	g.Expect(f.isPodDesired(tc, "test-pd-west-1")).To(BeTrue())
+}
+
+func TestPDGroupPersistDeleteSlots(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	enabled := features.DefaultFeatureGate.Enabled(features.AdvancedStatefulSet)
+	defer features.DefaultFeatureGate.Set(fmt.Sprintf("AdvancedStatefulSet=%t", enabled))
+	features.DefaultFeatureGate.Set("AdvancedStatefulSet=true")
+
+	deps := controller.NewFakeDependencies()
+	tc := newTidbClusterForPDGroup()
+	_, err := deps.Clientset.PingcapV1alpha1().TidbClusters(tc.Namespace).Create(context.TODO(), tc, metav1.CreateOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	group := pdGroups(tc)[0]
+	oldSet := newStatefulSetForPDGroup(group.setName(), 2, 2)
+	oldSet.Labels = map[string]string{label.PDGroupLabelKey: group.spec.Name}
+	newSet := oldSet.DeepCopy()
+	newSet.Spec.Replicas = pointer.Int32Ptr(1)
+	podIndexer := deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+	podIndexer.Add(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: group.podName(0), Namespace: tc.Namespace}})
+	podIndexer.Add(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:        group.podName(1),
+		Namespace:   tc.Namespace,
+		Annotations: map[string]string{label.AnnScaleInProtected: label.AnnScaleInProtectedVal},
+	}})
+
+	g.Expect(skipScaleInProtectedPods(deps, tc, v1alpha1.PDMemberType, oldSet, newSet)).To(Succeed())
+	g.Expect(helper.GetDeleteSlots(newSet)).To(Equal(sets.NewInt32(0)))
+	// the delete slots of the group are persisted under the key of the group
+	persisted, err := deps.Clientset.PingcapV1alpha1().TidbClusters(tc.Namespace).Get(context.TODO(), tc.Name, metav1.GetOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(persisted.Annotations).To(HaveKeyWithValue(group.deleteSlotsAnnKey(), "[0]"))
+	g.Expect(persisted.Annotations).NotTo(HaveKey(label.AnnPDDeleteSlots))
+}
@@ -0,0 +1,212 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	"github.com/pingcap/tidb-operator/pkg/util"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+const (
+	// pdLearnerStuckReason is the reason of the PDLearnerStuck condition when
+	// some PD members stay learners for longer than the timeout
+	pdLearnerStuckReason = "LearnerNotPromoted"
+	// pdNoLearnerStuckReason is the reason of the PDLearnerStuck condition when
+	// no PD member is stuck in learner state
+	pdNoLearnerStuckReason = "NoLearnerStuck"
+)
+
+// syncPDLearners marks the PD members that are learners in the member list of
+// the etcd embedded in PD, and sets the PDLearnerStuck condition if some of
+// them have stayed learners for longer than the timeout.
+// previous is the PD members in the last status, to keep the time a member was
+// first found to be a learner.
+func syncPDLearners(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, previous map[string]v1alpha1.PDMember) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	if tc.HeterogeneousWithoutLocalPD() {
+		return
+	}
+	pdEtcdClient, err := deps.PDControl.GetPDEtcdClient(pdapi.Namespace(ns), tcName, tc.IsTLSClusterEnabled())
+	if err != nil {
+		klog.Warningf("pd: failed to get pd etcd client of cluster %s/%s, error: %v", ns, tcName, err)
+		return
+	}
+	defer pdEtcdClient.Close()
+	etcdMembers, err := pdEtcdClient.ListMembers()
+	if err != nil {
+		klog.Warningf("pd: failed to list etcd members of cluster %s/%s, error: %v", ns, tcName, err)
+		return
+	}
+
+	learners := map[string]bool{}
+	for _, etcdMember := range etcdMembers {
+		if !etcdMember.IsLearner {
+			continue
+		}
+		// the started learners joined by scale-out are promoted to voters,
+		// etcd refuses to promote a learner not in sync with the leader yet
+		if tc.PDJoinAsLearner() && etcdMember.Name != "" {
+			if err := pdEtcdClient.PromoteMember(etcdMember.ID); err != nil {
+				klog.Infof("pd: learner %s of cluster %s/%s can't be promoted yet: %v", etcdMember.Name, ns, tcName, err)
+			} else {
+				klog.Infof("pd: promote learner %s of cluster %s/%s to voter successfully", etcdMember.Name, ns, tcName)
+				deps.Recorder.Eventf(tc, corev1.EventTypeNormal, "PDLearnerPromoted", "learner %s promoted to voter", etcdMember.Name)
+				continue
+			}
+		}
+		learners[etcdMember.Name] = true
+	}
+
+	now := metav1.Now()
+	timeout := tc.PDLearnerTimeout()
+	var stuck []string
+	for name, member := range tc.Status.PD.Members {
+		if !learners[name] {
+			member.IsLearner = false
+			member.LearnerSince = nil
+			tc.Status.PD.Members[name] = member
+			continue
+		}
+		member.IsLearner = true
+		member.LearnerSince = &now
+		if old, exist := previous[name]; exist && old.IsLearner && old.LearnerSince != nil {
+			member.LearnerSince = old.LearnerSince
+		}
+		tc.Status.PD.Members[name] = member
+		if isPDLearnerStuck(member, timeout) {
+			stuck = append(stuck, name)
+		}
+	}
+
+	if len(stuck) > 0 {
+		sort.Strings(stuck)
+		msg := fmt.Sprintf("%s stay learners for longer than %s", strings.Join(stuck, ", "), timeout)
+		klog.Warningf("pd: %s in cluster %s/%s", msg, ns, tcName)
+		utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+			v1alpha1.TidbClusterPDLearnerStuck, corev1.ConditionTrue, pdLearnerStuckReason, msg))
+		return
+	}
+	utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+		v1alpha1.TidbClusterPDLearnerStuck, corev1.ConditionFalse, pdNoLearnerStuckReason, "no PD member is stuck in learner state"))
+}
+
+func isPDLearnerStuck(member v1alpha1.PDMember, timeout time.Duration) bool {
+	return member.IsLearner && member.LearnerSince != nil && time.Since(member.LearnerSince.Time) > timeout
+}
+
+// waitingPDLearners returns the PD members that are learners and are still
+// expected to be promoted to voters, i.e. not stuck yet, sorted by name
+func waitingPDLearners(tc *v1alpha1.TidbCluster) []string {
+	timeout := tc.PDLearnerTimeout()
+	var learners []string
+	for name, member := range tc.Status.PD.Members {
+		if member.IsLearner && !isPDLearnerStuck(member, timeout) {
+			learners = append(learners, name)
+		}
+	}
+	sort.Strings(learners)
+	return learners
+}
+
+// recreateStuckPDLearner removes a stuck learner from PD and deletes its Pod
+// and PVCs, then the StatefulSet recreates them and the member joins the
+// cluster again. Only one learner is recreated at a time.
+func recreateStuckPDLearner(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	timeout := tc.PDLearnerTimeout()
+
+	names := make([]string, 0, len(tc.Status.PD.Members))
+	for name := range tc.Status.PD.Members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		member := tc.Status.PD.Members[name]
+		if !isPDLearnerStuck(member, timeout) {
+			continue
+		}
+		podName := strings.Split(name, ".")[0]
+
+		ordinal, err := util.GetOrdinalFromPodName(podName)
+		if err != nil {
+			return fmt.Errorf("recreateStuckPDLearner: failed to parse ordinal from Pod name for %s/%s, error: %s", ns, podName, err)
+		}
+		pvcSelector, err := GetPVCSelectorForPod(tc, v1alpha1.PDMemberType, ordinal)
+		if err != nil {
+			return fmt.Errorf("recreateStuckPDLearner: failed to get PVC selector for Pod %s/%s, error: %s", ns, podName, err)
+		}
+		pvcs, err := deps.PVCLister.PersistentVolumeClaims(ns).List(pvcSelector)
+		if err != nil {
+			return fmt.Errorf("recreateStuckPDLearner: failed to get PVCs for pod %s/%s, error: %s", ns, podName, err)
+		}
+		if err := reservePDDeletion(deps, tc, "member", name); err != nil {
+			return err
+		}
+		if err := snapshotPVCsBeforeDeletion(deps, tc, tc.Spec.SnapshotBeforeDeletingPVC, pvcs); err != nil {
+			return err
+		}
+
+		memberID, err := strconv.ParseUint(member.ID, 10, 64)
+		if err != nil {
+			return err
+		}
+		if err := controller.GetPDClient(deps.PDControl, tc).DeleteMemberByID(memberID); err != nil {
+			return fmt.Errorf("recreateStuckPDLearner: failed to delete member %s/%s(%d), error: %v", ns, podName, memberID, err)
+		}
+		klog.Infof("recreateStuckPDLearner: delete stuck learner %s/%s(%d) successfully", ns, podName, memberID)
+		deps.Recorder.Eventf(tc, corev1.EventTypeWarning, "PDLearnerDeleted", "stuck learner %s/%s(%d) deleted from PD cluster", ns, podName, memberID)
+
+		// the PVCs are deleted so that the new Pod joins the cluster with empty
+		// data, the Pod pending on the deleted PVCs is cleaned by OrphanPodsCleaner
+		pod, err := deps.PodLister.Pods(ns).Get(podName)
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("recreateStuckPDLearner: failed to get pod %s/%s, error: %s", ns, podName, err)
+		}
+		if pod != nil && pod.DeletionTimestamp == nil {
+			if err := deps.PodControl.DeletePod(tc, pod); err != nil {
+				return err
+			}
+		}
+
+		for _, pvc := range pvcs {
+			if pvc.DeletionTimestamp != nil {
+				continue
+			}
+			if err := deps.PVCControl.DeletePVC(tc, pvc); err != nil {
+				return err
+			}
+		}
+
+		delete(tc.Status.PD.Members, name)
+		return controller.RequeueErrorf("tidbcluster: [%s/%s]'s pd learner %s is recreated", ns, tcName, name)
+	}
+	return nil
+}
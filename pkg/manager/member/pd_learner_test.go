@@ -0,0 +1,259 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	perrors "github.com/pingcap/errors"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/utils/pointer"
+)
+
+func TestSyncPDLearners(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pd0 := ordinalPodName(v1alpha1.PDMemberType, "test", 0)
+	pd1 := ordinalPodName(v1alpha1.PDMemberType, "test", 1)
+	longAgo := metav1.NewTime(time.Now().Add(-time.Hour))
+
+	type testcase struct {
+		name            string
+		learners        []string
+		previous        map[string]v1alpha1.PDMember
+		expectLearners  []string
+		expectSinceKept bool
+		expectStatus    corev1.ConditionStatus
+		expectReason    string
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+		fakeDeps := controller.NewFakeDependencies()
+		pdControl := fakeDeps.PDControl.(*pdapi.FakePDControl)
+
+		tc := newTidbClusterForPD()
+		etcdMembers := []*pdapi.EtcdMember{{ID: 0, Name: pd0}}
+		for _, name := range test.learners {
+			etcdMembers = append(etcdMembers, &pdapi.EtcdMember{ID: 1, Name: name, IsLearner: true})
+		}
+		pdControl.SetPDEtcdClient(pdapi.Namespace(tc.GetNamespace()), tc.GetName(), &pdapi.FakePDEtcdClient{Members: etcdMembers})
+		tc.Status.PD.Members = map[string]v1alpha1.PDMember{
+			pd0: {Name: pd0, ID: "0", Health: true},
+			pd1: {Name: pd1, ID: "1", Health: true},
+		}
+
+		syncPDLearners(fakeDeps, tc, test.previous)
+
+		var learners []string
+		for name, member := range tc.Status.PD.Members {
+			if member.IsLearner {
+				learners = append(learners, name)
+				g.Expect(member.LearnerSince).NotTo(BeNil())
+				g.Expect(member.LearnerSince.Equal(&longAgo)).To(Equal(test.expectSinceKept))
+			} else {
+				g.Expect(member.LearnerSince).To(BeNil())
+			}
+		}
+		g.Expect(learners).To(ConsistOf(test.expectLearners))
+
+		cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterPDLearnerStuck)
+		g.Expect(cond).NotTo(BeNil())
+		g.Expect(cond.Status).To(Equal(test.expectStatus))
+		g.Expect(cond.Reason).To(Equal(test.expectReason))
+	}
+
+	tests := []*testcase{
+		{
+			name:         "no learner",
+			expectStatus: corev1.ConditionFalse,
+			expectReason: pdNoLearnerStuckReason,
+		},
+		{
+			name:           "learner is found for the first time",
+			learners:       []string{pd1},
+			expectLearners: []string{pd1},
+			expectStatus:   corev1.ConditionFalse,
+			expectReason:   pdNoLearnerStuckReason,
+		},
+		{
+			name:     "learner is stuck",
+			learners: []string{pd1},
+			previous: map[string]v1alpha1.PDMember{
+				pd1: {Name: pd1, ID: "1", Health: true, IsLearner: true, LearnerSince: &longAgo},
+			},
+			expectLearners:  []string{pd1},
+			expectSinceKept: true,
+			expectStatus:    corev1.ConditionTrue,
+			expectReason:    pdLearnerStuckReason,
+		},
+		{
+			name: "learner is promoted",
+			previous: map[string]v1alpha1.PDMember{
+				pd1: {Name: pd1, ID: "1", Health: true, IsLearner: true, LearnerSince: &longAgo},
+			},
+			expectStatus: corev1.ConditionFalse,
+			expectReason: pdNoLearnerStuckReason,
+		},
+	}
+
+	for _, test := range tests {
+		testFn(test, t)
+	}
+}
+
+func TestSyncPDLearnersPromote(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pd0 := ordinalPodName(v1alpha1.PDMemberType, "test", 0)
+	pd1 := ordinalPodName(v1alpha1.PDMemberType, "test", 1)
+	pd2 := ordinalPodName(v1alpha1.PDMemberType, "test", 2)
+
+	fakeDeps := controller.NewFakeDependencies()
+	pdControl := fakeDeps.PDControl.(*pdapi.FakePDControl)
+	tc := newTidbClusterForPD()
+	tc.Spec.PD.JoinAsLearner = pointer.BoolPtr(true)
+	etcdClient := &pdapi.FakePDEtcdClient{
+		Members: []*pdapi.EtcdMember{
+			{ID: 0, Name: pd0},
+			{ID: 1, Name: pd1, IsLearner: true},
+			{ID: 2, Name: pd2, IsLearner: true},
+			// not started yet
+			{ID: 3, IsLearner: true},
+		},
+		NotReady: map[uint64]bool{2: true},
+	}
+	pdControl.SetPDEtcdClient(pdapi.Namespace(tc.GetNamespace()), tc.GetName(), etcdClient)
+	tc.Status.PD.Members = map[string]v1alpha1.PDMember{
+		pd0: {Name: pd0, ID: "0", Health: true},
+		pd1: {Name: pd1, ID: "1", Health: true},
+		pd2: {Name: pd2, ID: "2", Health: true},
+	}
+
+	syncPDLearners(fakeDeps, tc, nil)
+	g.Expect(etcdClient.Promoted).To(Equal([]uint64{1}))
+	g.Expect(tc.Status.PD.Members[pd1].IsLearner).To(BeFalse())
+	// the learner not in sync with the leader is promoted later
+	g.Expect(tc.Status.PD.Members[pd2].IsLearner).To(BeTrue())
+}
+
+func TestRecreateStuckPDLearner(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pd0 := ordinalPodName(v1alpha1.PDMemberType, "test", 0)
+	pd1 := ordinalPodName(v1alpha1.PDMemberType, "test", 1)
+
+	type testcase struct {
+		name          string
+		learnerSince  time.Time
+		snapshot      bool
+		expectDeleted bool
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+		fakeDeps := controller.NewFakeDependencies()
+		pdControl := fakeDeps.PDControl.(*pdapi.FakePDControl)
+		podIndexer := fakeDeps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+		pvcIndexer := fakeDeps.KubeInformerFactory.Core().V1().PersistentVolumeClaims().Informer().GetIndexer()
+
+		tc := newTidbClusterForPD()
+		tc.Spec.PD.RecreateStuckLearner = pointer.BoolPtr(true)
+		if test.snapshot {
+			tc.Spec.SnapshotBeforeDeletingPVC = &v1alpha1.PVCSnapshotSpec{}
+			fakeDeps.KubeClientset.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+				{
+					GroupVersion: "snapshot.storage.k8s.io/v1",
+					APIResources: []metav1.APIResource{{Name: "volumesnapshots", Kind: "VolumeSnapshot", Namespaced: true}},
+				},
+			}
+		}
+		since := metav1.NewTime(test.learnerSince)
+		tc.Status.PD.Members = map[string]v1alpha1.PDMember{
+			pd0: {Name: pd0, ID: "0", Health: true},
+			pd1: {Name: pd1, ID: "1", Health: true, IsLearner: true, LearnerSince: &since},
+		}
+
+		pod := newPodForPDFailover(tc, v1alpha1.PDMemberType, 1)
+		g.Expect(podIndexer.Add(pod)).To(Succeed())
+		pvc := newPVCForPDFailover(tc, v1alpha1.PDMemberType, 1)
+		pvc.Labels[label.AnnPodNameKey] = pod.GetName()
+		g.Expect(pvcIndexer.Add(pvc)).To(Succeed())
+
+		var deletedMemberID uint64
+		pdClient := controller.NewFakePDClient(pdControl, tc)
+		pdClient.AddReaction(pdapi.DeleteMemberByIDActionType, func(action *pdapi.Action) (interface{}, error) {
+			deletedMemberID = action.ID
+			return nil, nil
+		})
+
+		err := recreateStuckPDLearner(fakeDeps, tc)
+
+		_, podExists, _ := podIndexer.Get(pod)
+		_, pvcExists, _ := pvcIndexer.Get(pvc)
+		if test.snapshot {
+			// the member is kept until the snapshot of the PVC is cut
+			g.Expect(perrors.Find(err, controller.IsRequeueError)).NotTo(BeNil())
+			g.Expect(tc.Status.VolumeSnapshots).To(HaveKey(pvcSnapshotName(pvc)))
+			g.Expect(deletedMemberID).To(BeZero())
+			g.Expect(podExists).To(BeTrue())
+			g.Expect(pvcExists).To(BeTrue())
+			return
+		}
+		if test.expectDeleted {
+			g.Expect(perrors.Find(err, controller.IsRequeueError)).NotTo(BeNil())
+			g.Expect(deletedMemberID).To(Equal(uint64(1)))
+			g.Expect(podExists).To(BeFalse())
+			g.Expect(pvcExists).To(BeFalse())
+			g.Expect(tc.Status.PD.Members).NotTo(HaveKey(pd1))
+			return
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(deletedMemberID).To(BeZero())
+		g.Expect(podExists).To(BeTrue())
+		g.Expect(pvcExists).To(BeTrue())
+	}
+
+	tests := []*testcase{
+		{
+			name:          "learner is not stuck",
+			learnerSince:  time.Now(),
+			expectDeleted: false,
+		},
+		{
+			name:          "learner is stuck",
+			learnerSince:  time.Now().Add(-time.Hour),
+			expectDeleted: true,
+		},
+		{
+			name:          "learner is stuck and waiting for the snapshot of the PVC",
+			learnerSince:  time.Now().Add(-time.Hour),
+			snapshot:      true,
+			expectDeleted: false,
+		},
+	}
+
+	for _, test := range tests {
+		testFn(test, t)
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+// syncPDLostPVC records the PD Pods whose data PVC is lost in the status, i.e.
+// the PVC or the PV bound to it is gone after a disk failure. Such a member can
+// not recover with its old data, so once it stays lost for longer than the
+// grace period and RecoverLostPVC is enabled, it is removed from PD and its Pod
+// and PVC are recreated, then it joins the cluster again as a fresh member.
+// Only one member is recovered at a time, and only if the PD cluster keeps a
+// quorum without it.
+func syncPDLostPVC(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	lost := map[string]metav1.Time{}
+	for _, ordinal := range tc.PDStsDesiredOrdinals(true).List() {
+		podName := PdPodName(tcName, ordinal)
+		pod, err := deps.PodLister.Pods(ns).Get(podName)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("syncPDLostPVC: failed to get pod %s/%s, error: %s", ns, podName, err)
+		}
+		isLost, reason, err := isPDDataPVCLost(deps, pod)
+		if err != nil {
+			return err
+		}
+		if !isLost {
+			continue
+		}
+		since, ok := tc.Status.PD.LostPVCSince[podName]
+		if !ok {
+			since = metav1.Now()
+			klog.Warningf("syncPDLostPVC: the data PVC of pd pod %s/%s is lost, %s", ns, podName, reason)
+			deps.Recorder.Eventf(tc, corev1.EventTypeWarning, "PDPVCLost", "the data PVC of %s/%s is lost, %s", ns, podName, reason)
+		}
+		lost[podName] = since
+	}
+	if len(lost) == 0 {
+		tc.Status.PD.LostPVCSince = nil
+		return nil
+	}
+	tc.Status.PD.LostPVCSince = lost
+
+	if !tc.PDRecoverLostPVC() || tc.PDSplitBrain() {
+		return nil
+	}
+	gracePeriod := tc.PDLostPVCGracePeriod()
+	podNames := make([]string, 0, len(lost))
+	for podName := range lost {
+		podNames = append(podNames, podName)
+	}
+	sort.Strings(podNames)
+	for _, podName := range podNames {
+		if time.Since(lost[podName].Time) < gracePeriod {
+			continue
+		}
+		// the failure member is deleted and recreated by the failover
+		if isPDFailureMember(tc, podName) {
+			continue
+		}
+		return recoverPDLostPVC(deps, tc, podName)
+	}
+	return nil
+}
+
+// isPDDataPVCLost returns whether the data PVC of the PD Pod is lost, and why
+func isPDDataPVCLost(deps *controller.Dependencies, pod *corev1.Pod) (bool, string, error) {
+	ns := pod.GetNamespace()
+	for _, vol := range pod.Spec.Volumes {
+		if vol.Name != v1alpha1.PDMemberType.String() || vol.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvcName := vol.PersistentVolumeClaim.ClaimName
+		pvc, err := deps.PVCLister.PersistentVolumeClaims(ns).Get(pvcName)
+		if errors.IsNotFound(err) {
+			return true, fmt.Sprintf("PVC %s is not found", pvcName), nil
+		}
+		if err != nil {
+			return false, "", fmt.Errorf("isPDDataPVCLost: failed to get pvc %s/%s, error: %s", ns, pvcName, err)
+		}
+		if pvc.DeletionTimestamp != nil {
+			// the PVC is being deleted on purpose, e.g. by a previous recovery
+			return false, "", nil
+		}
+		if pvc.Status.Phase == corev1.ClaimLost {
+			return true, fmt.Sprintf("PVC %s is Lost", pvcName), nil
+		}
+		if pvc.Spec.VolumeName == "" || deps.PVLister == nil {
+			// the PV is not checked without the permission of the cluster
+			// scoped resources
+			continue
+		}
+		_, err = deps.PVLister.Get(pvc.Spec.VolumeName)
+		if errors.IsNotFound(err) {
+			return true, fmt.Sprintf("PV %s of PVC %s is not found", pvc.Spec.VolumeName, pvcName), nil
+		}
+		if err != nil {
+			return false, "", fmt.Errorf("isPDDataPVCLost: failed to get pv %s, error: %s", pvc.Spec.VolumeName, err)
+		}
+	}
+	return false, "", nil
+}
+
+func isPDFailureMember(tc *v1alpha1.TidbCluster, podName string) bool {
+	for _, failureMember := range tc.Status.PD.FailureMembers {
+		if failureMember.PodName == podName {
+			return true
+		}
+	}
+	return false
+}
+
+// recoverPDLostPVC removes the member of the PD Pod from PD, and deletes the
+// Pod and its lost PVC, so that the StatefulSet recreates them with empty data
+func recoverPDLostPVC(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, podName string) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	for name, member := range tc.Status.PD.Members {
+		if strings.Split(name, ".")[0] != podName {
+			continue
+		}
+		if ok, reason := canRemovePDMember(tc, name); !ok {
+			return controller.RequeueErrorf("syncPDLostPVC: can't remove member %s/%s that lost its PVC, %s", ns, podName, reason)
+		}
+		if err := reservePDDeletion(deps, tc, "member", name); err != nil {
+			return err
+		}
+		memberID, err := strconv.ParseUint(member.ID, 10, 64)
+		if err != nil {
+			return err
+		}
+		if err := controller.GetPDClient(deps.PDControl, tc).DeleteMemberByID(memberID); err != nil {
+			return fmt.Errorf("syncPDLostPVC: failed to delete member %s/%s(%d), error: %v", ns, podName, memberID, err)
+		}
+		klog.Infof("syncPDLostPVC: delete member %s/%s(%d) that lost its PVC successfully", ns, podName, memberID)
+		deps.Recorder.Eventf(tc, corev1.EventTypeWarning, "PDMemberDeleted", "member %s/%s(%d) that lost its PVC deleted from PD cluster", ns, podName, memberID)
+		delete(tc.Status.PD.Members, name)
+	}
+
+	pod, err := deps.PodLister.Pods(ns).Get(podName)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("syncPDLostPVC: failed to get pod %s/%s, error: %s", ns, podName, err)
+	}
+	if pod != nil {
+		for _, vol := range pod.Spec.Volumes {
+			if vol.Name != v1alpha1.PDMemberType.String() || vol.PersistentVolumeClaim == nil {
+				continue
+			}
+			pvc, err := deps.PVCLister.PersistentVolumeClaims(ns).Get(vol.PersistentVolumeClaim.ClaimName)
+			if errors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("syncPDLostPVC: failed to get pvc %s/%s, error: %s", ns, vol.PersistentVolumeClaim.ClaimName, err)
+			}
+			if pvc.DeletionTimestamp == nil {
+				if err := deps.PVCControl.DeletePVC(tc, pvc); err != nil {
+					return err
+				}
+			}
+		}
+		if pod.DeletionTimestamp == nil {
+			if err := deps.PodControl.DeletePod(tc, pod); err != nil {
+				return err
+			}
+		}
+	}
+
+	delete(tc.Status.PD.LostPVCSince, podName)
+	if len(tc.Status.PD.LostPVCSince) == 0 {
+		tc.Status.PD.LostPVCSince = nil
+	}
+	return controller.RequeueErrorf("tidbcluster: [%s/%s]'s pd member %s that lost its PVC is recreated", ns, tcName, podName)
+}
@@ -0,0 +1,221 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestSyncPDLostPVC(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pd1 := ordinalPodName(v1alpha1.PDMemberType, "test", 1)
+
+	type testcase struct {
+		name          string
+		pvcMissing    bool
+		pvMissing     bool
+		pvcLost       bool
+		pvcDeleting   bool
+		noPVLister    bool
+		recover       bool
+		lostSince     *metav1.Time
+		pd0Unhealthy  bool
+		expectLost    bool
+		expectRequeue bool
+		expectDeleted bool
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+		fakeDeps := controller.NewFakeDependencies()
+		pdControl := fakeDeps.PDControl.(*pdapi.FakePDControl)
+		podIndexer := fakeDeps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+		pvcIndexer := fakeDeps.KubeInformerFactory.Core().V1().PersistentVolumeClaims().Informer().GetIndexer()
+		pvIndexer := fakeDeps.KubeInformerFactory.Core().V1().PersistentVolumes().Informer().GetIndexer()
+
+		tc := newTidbClusterForPD()
+		tc.Spec.PD.RecoverLostPVC = pointer.BoolPtr(test.recover)
+		tc.Spec.PD.LostPVCGracePeriod = pointer.StringPtr("5m")
+		tc.Status.PD.Members = map[string]v1alpha1.PDMember{}
+		if test.lostSince != nil {
+			tc.Status.PD.LostPVCSince = map[string]metav1.Time{pd1: *test.lostSince}
+		}
+
+		var pod1 *corev1.Pod
+		var pvc1 *corev1.PersistentVolumeClaim
+		for ordinal := int32(0); ordinal < 3; ordinal++ {
+			name := ordinalPodName(v1alpha1.PDMemberType, "test", ordinal)
+			tc.Status.PD.Members[name] = v1alpha1.PDMember{
+				Name:   name,
+				ID:     fmt.Sprintf("%d", ordinal),
+				Health: ordinal != 1 && !(ordinal == 0 && test.pd0Unhealthy),
+			}
+
+			pvc := newPVCForPDFailover(tc, v1alpha1.PDMemberType, ordinal)
+			pod := newPodForPDFailover(tc, v1alpha1.PDMemberType, ordinal)
+			pod.Spec.Volumes = []corev1.Volume{{
+				Name: v1alpha1.PDMemberType.String(),
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.GetName()},
+				},
+			}}
+			g.Expect(podIndexer.Add(pod)).To(Succeed())
+			if ordinal == 1 {
+				pod1, pvc1 = pod, pvc
+				if test.pvcLost {
+					pvc.Status.Phase = corev1.ClaimLost
+				}
+				if test.pvcDeleting {
+					pvc.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+				}
+				if test.pvcMissing {
+					continue
+				}
+			}
+			g.Expect(pvcIndexer.Add(pvc)).To(Succeed())
+			if ordinal == 1 && test.pvMissing {
+				continue
+			}
+			g.Expect(pvIndexer.Add(&corev1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{Name: pvc.Spec.VolumeName}})).To(Succeed())
+		}
+
+		if test.noPVLister {
+			fakeDeps.PVLister = nil
+		}
+
+		var deletedMemberID *uint64
+		pdClient := controller.NewFakePDClient(pdControl, tc)
+		pdClient.AddReaction(pdapi.DeleteMemberByIDActionType, func(action *pdapi.Action) (interface{}, error) {
+			deletedMemberID = &action.ID
+			return nil, nil
+		})
+
+		err := syncPDLostPVC(fakeDeps, tc)
+		if test.expectRequeue {
+			g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+		} else {
+			g.Expect(err).NotTo(HaveOccurred())
+		}
+
+		_, podExists, _ := podIndexer.Get(pod1)
+		_, pvcExists, _ := pvcIndexer.Get(pvc1)
+		if test.expectDeleted {
+			g.Expect(deletedMemberID).NotTo(BeNil())
+			g.Expect(*deletedMemberID).To(Equal(uint64(1)))
+			g.Expect(podExists).To(BeFalse())
+			g.Expect(pvcExists).To(BeFalse())
+			g.Expect(tc.Status.PD.Members).NotTo(HaveKey(pd1))
+			g.Expect(tc.Status.PD.LostPVCSince).To(BeNil())
+			return
+		}
+		g.Expect(deletedMemberID).To(BeNil())
+		g.Expect(podExists).To(BeTrue())
+		if test.expectLost {
+			g.Expect(tc.Status.PD.LostPVCSince).To(HaveKey(pd1))
+			g.Expect(tc.Status.PD.LostPVCSince).To(HaveLen(1))
+			if test.lostSince != nil {
+				g.Expect(tc.Status.PD.LostPVCSince[pd1]).To(Equal(*test.lostSince))
+			}
+		} else {
+			g.Expect(tc.Status.PD.LostPVCSince).To(BeNil())
+		}
+	}
+
+	longAgo := metav1.NewTime(time.Now().Add(-time.Hour))
+	recently := metav1.NewTime(time.Now().Add(-time.Minute))
+	tests := []*testcase{
+		{
+			name:       "the pvc and pv are present",
+			recover:    true,
+			lostSince:  &longAgo,
+			expectLost: false,
+		},
+		{
+			name:       "the pv is gone",
+			pvMissing:  true,
+			expectLost: true,
+		},
+		{
+			name:       "the pvc is gone",
+			pvcMissing: true,
+			expectLost: true,
+		},
+		{
+			name:       "the pvc is lost",
+			pvcLost:    true,
+			expectLost: true,
+		},
+		{
+			name:        "the pvc being deleted is not lost",
+			pvcDeleting: true,
+			pvMissing:   true,
+			recover:     true,
+			lostSince:   &longAgo,
+			expectLost:  false,
+		},
+		{
+			name:       "the pv is not checked without the pv lister",
+			pvMissing:  true,
+			noPVLister: true,
+			recover:    true,
+			lostSince:  &longAgo,
+			expectLost: false,
+		},
+		{
+			name:       "the lost pvc is only reported without recovery",
+			pvMissing:  true,
+			lostSince:  &longAgo,
+			expectLost: true,
+		},
+		{
+			name:       "the pvc is lost within the grace period",
+			pvMissing:  true,
+			recover:    true,
+			lostSince:  &recently,
+			expectLost: true,
+		},
+		{
+			name:          "the member is re-bootstrapped after the grace period",
+			pvMissing:     true,
+			recover:       true,
+			lostSince:     &longAgo,
+			expectRequeue: true,
+			expectDeleted: true,
+		},
+		{
+			name:          "the member is kept if the pd cluster loses the quorum without it",
+			pvMissing:     true,
+			recover:       true,
+			lostSince:     &longAgo,
+			pd0Unhealthy:  true,
+			expectLost:    true,
+			expectRequeue: true,
+		},
+	}
+
+	for i := range tests {
+		testFn(tests[i], t)
+	}
+}
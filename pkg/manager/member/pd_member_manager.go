@@ -48,7 +48,8 @@ const (
 	tidbClientCertPath = "/var/lib/tidb-client-tls"
 
 	//find a better way to manage store only managed by pd in Operator
-	pdMemberLimitPattern = `%s-pd-\d+\.%s-pd-peer\.%s\.svc%s\:\d+`
+	// the members of the PD groups are named <cluster>-pd-<group>-<ordinal>
+	pdMemberLimitPattern = `%s-pd-(?:[a-z0-9-]+-)?\d+\.%s-pd-peer\.%s\.svc%s\:\d+`
 )
 
 type pdMemberManager struct {
@@ -85,7 +86,19 @@ func (m *pdMemberManager) Sync(tc *v1alpha1.TidbCluster) error {
 	}
 
 	// Sync PD StatefulSet
-	return m.syncPDStatefulSetForTidbCluster(tc)
+	if err := m.syncPDStatefulSetForTidbCluster(tc); err != nil {
+		return err
+	}
+
+	// Sync PD Schedulers
+	syncPDSchedulers(m.deps, tc)
+
+	// Sync PD balance limits
+	syncPDBalanceLimits(m.deps, tc)
+
+	// Defragment the etcd embedded in PD
+	syncPDEtcdDefrag(m.deps, tc)
+	return nil
 }
 
 func (m *pdMemberManager) syncPDServiceForTidbCluster(tc *v1alpha1.TidbCluster) error {
@@ -197,10 +210,19 @@ func (m *pdMemberManager) syncPDStatefulSetForTidbCluster(tc *v1alpha1.TidbClust
 	if err != nil {
 		return err
 	}
+
+	// Remove the failure members out of the desired ordinals before generating desired statefulset
+	if len(tc.Status.PD.FailureMembers) > 0 {
+		m.failover.RemoveUndesiredFailures(tc)
+	}
 	newPDSet, err := getNewPDSetForTidbCluster(tc, cm)
 	if err != nil {
 		return err
 	}
+	if err := mutatePodTemplate(m.deps, tc, v1alpha1.PDMemberType, newPDSet); err != nil {
+		return err
+	}
+	holdComponentRestartAt(tc, v1alpha1.PDMemberType, newPDSet, oldPDSet)
 	if setNotExist {
 		err = SetStatefulSetLastAppliedConfigAnnotation(newPDSet)
 		if err != nil {
@@ -213,6 +235,9 @@ func (m *pdMemberManager) syncPDStatefulSetForTidbCluster(tc *v1alpha1.TidbClust
 		return controller.RequeueErrorf("TidbCluster: [%s/%s], waiting for PD cluster running", ns, tcName)
 	}
 
+	// the Pods whose post-upgrade hooks are not run yet are kept in the new StatefulSet
+	keepPostUpgradeHookPods(oldPDSet, newPDSet)
+
 	// Force update takes precedence over scaling because force upgrade won't take effect when cluster gets stuck at scaling
 	if !tc.Status.PD.Synced && !templateEqual(newPDSet, oldPDSet) && (NeedForceUpgrade(tc.Annotations) || *oldPDSet.Spec.Replicas < 2) {
 		tc.Status.PD.Phase = v1alpha1.UpgradePhase
@@ -230,8 +255,18 @@ func (m *pdMemberManager) syncPDStatefulSetForTidbCluster(tc *v1alpha1.TidbClust
 		return err
 	}
 
-	if m.deps.CLIConfig.AutoFailover {
-		if m.shouldRecover(tc) {
+	// Report the Pods stuck in Pending and the Pods restarted too often
+	if err := syncRolloutStall(m.deps, tc, v1alpha1.PDMemberType); err != nil {
+		return err
+	}
+	if err := syncPodRestartFlapping(m.deps, tc, v1alpha1.PDMemberType); err != nil {
+		return err
+	}
+
+	// PD failover deletes PD members, which must not happen while the PD
+	// members do not agree on who are the members
+	if m.deps.CLIConfig.AutoFailover && !tc.PDSplitBrain() && !tc.FailoverPausedByRestarts(v1alpha1.PDMemberType) {
+		if tc.RecoverFailover(v1alpha1.PDMemberType) && m.shouldRecover(tc) {
 			m.failover.Recover(tc)
 		} else if tc.PDAllPodsStarted() && !tc.PDAllMembersReady() || tc.PDAutoFailovering() {
 			if err := m.failover.Failover(tc); err != nil {
@@ -240,13 +275,32 @@ func (m *pdMemberManager) syncPDStatefulSetForTidbCluster(tc *v1alpha1.TidbClust
 		}
 	}
 
+	if tc.PDRecreateStuckLearner() && !tc.PDSplitBrain() {
+		if err := recreateStuckPDLearner(m.deps, tc); err != nil {
+			return err
+		}
+	}
+
+	// Recover the PD members that lost their data PVC
+	if err := syncPDLostPVC(m.deps, tc); err != nil {
+		return err
+	}
+
 	if !templateEqual(newPDSet, oldPDSet) || tc.Status.PD.Phase == v1alpha1.UpgradePhase {
 		if err := m.upgrader.Upgrade(tc, oldPDSet, newPDSet); err != nil {
 			return err
 		}
+	} else if tc.Status.PD.Phase == v1alpha1.NormalPhase {
+		// Restart the Pods requested by the restart-at annotation when no upgrade or scaling is in progress
+		if err := syncPodRestarts(m.deps, tc, v1alpha1.PDMemberType); err != nil {
+			return err
+		}
 	}
 
-	return UpdateStatefulSet(m.deps.StatefulSetControl, tc, newPDSet, oldPDSet)
+	if err := updateStatefulSetAndRunPostScaleHook(m.deps, tc, v1alpha1.PDMemberType, newPDSet, oldPDSet); err != nil {
+		return err
+	}
+	return m.syncPDGroups(tc, newPDSet)
 }
 
 // shouldRecover checks whether we should perform recovery operation.
@@ -310,6 +364,10 @@ func (m *pdMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, set *a
 		tc.Status.PD.Phase = v1alpha1.NormalPhase
 	}
 
+	if err := syncUpgradeProgress(m.deps.PodLister, tc, v1alpha1.PDMemberType, set, tc.Status.PD.Phase, &tc.Status.PD.Upgrade); err != nil {
+		return err
+	}
+
 	pdClient := controller.GetPDClient(m.deps.PDControl, tc)
 
 	healthInfo, err := pdClient.GetHealth()
@@ -386,6 +444,7 @@ func (m *pdMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, set *a
 		}
 	}
 
+	previousMembers := tc.Status.PD.Members
 	tc.Status.PD.Synced = true
 	tc.Status.PD.Members = pdStatus
 	tc.Status.PD.PeerMembers = peerPDStatus
@@ -397,6 +456,15 @@ func (m *pdMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, set *a
 	if err := m.collectUnjoinedMembers(tc, set, pdStatus); err != nil {
 		return err
 	}
+	syncPDSplitBrainCondition(m.deps, tc)
+	syncPDLearners(m.deps, tc, previousMembers)
+	// a scale-out is not complete until the new members are promoted to
+	// voters, or stay learners for longer than the timeout
+	if tc.Status.PD.Phase == v1alpha1.NormalPhase && len(waitingPDLearners(tc)) > 0 {
+		tc.Status.PD.Phase = v1alpha1.ScalePhase
+	}
+	syncPDBalanceStatus(m.deps, tc)
+	syncComponentTopology(m.deps, tc, v1alpha1.PDMemberType, nil)
 	return nil
 }
 
@@ -419,7 +487,11 @@ func (m *pdMemberManager) syncPDConfigMap(tc *v1alpha1.TidbCluster, set *apps.St
 		})
 	}
 
-	err = updateConfigMapIfNeed(m.deps.ConfigMapLister, tc.BasePDSpec().ConfigUpdateStrategy(), inUseName, newCm)
+	strategy, err := syncConfigOnline(m.deps, tc, v1alpha1.PDMemberType, tc.BasePDSpec().ConfigUpdateStrategy(), inUseName, newCm)
+	if err != nil {
+		return nil, err
+	}
+	err = updateConfigMapIfNeed(m.deps.ConfigMapLister, strategy, inUseName, newCm)
 	if err != nil {
 		return nil, err
 	}
@@ -508,7 +580,7 @@ func getNewPDHeadlessServiceForTidbCluster(tc *v1alpha1.TidbCluster) *corev1.Ser
 }
 
 func (m *pdMemberManager) pdStatefulSetIsUpgrading(set *apps.StatefulSet, tc *v1alpha1.TidbCluster) (bool, error) {
-	if statefulSetIsUpgrading(set) {
+	if statefulSetIsUpgrading(set) || postUpgradeHooksPending(set) {
 		return true, nil
 	}
 	instanceName := tc.GetInstanceName()
@@ -705,7 +777,7 @@ func getNewPDSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap) (
 			},
 		},
 		VolumeMounts: volMounts,
-		Resources:    controller.ContainerResource(tc.Spec.PD.ResourceRequirements),
+		Resources:    controller.ContainerResourceWithEphemeralStorage(tc.Spec.PD.ResourceRequirements, tc.Spec.PD.EphemeralStorage),
 	}
 	env := []corev1.EnvVar{
 		{
@@ -749,6 +821,7 @@ func getNewPDSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap) (
 	pdContainer.Env = util.AppendEnv(env, basePDSpec.Env())
 	podSpec.Volumes = append(vols, basePDSpec.AdditionalVolumes()...)
 	podSpec.Containers = append([]corev1.Container{pdContainer}, basePDSpec.AdditionalContainers()...)
+	setMainContainerSecurityContext(&podSpec, basePDSpec, v1alpha1.PDMemberType.String(), nil)
 	podSpec.ServiceAccountName = tc.Spec.PD.ServiceAccount
 	if podSpec.ServiceAccountName == "" {
 		podSpec.ServiceAccountName = tc.Spec.ServiceAccount
@@ -949,6 +949,16 @@ func testAnnotations(t *testing.T, annotations map[string]string) func(sts *apps
 	}
 }
 
+func testImagePullPolicy(t *testing.T, policy corev1.PullPolicy, memberType v1alpha1.MemberType) func(sts *apps.StatefulSet) {
+	return func(sts *apps.StatefulSet) {
+		for _, c := range sts.Spec.Template.Spec.Containers {
+			if c.Name == memberType.String() && c.ImagePullPolicy != policy {
+				t.Errorf("unexpected image pull policy %v, want %v", c.ImagePullPolicy, policy)
+			}
+		}
+	}
+}
+
 func testContainerEnv(t *testing.T, env []corev1.EnvVar, memberType v1alpha1.MemberType) func(sts *apps.StatefulSet) {
 	return func(sts *apps.StatefulSet) {
 		got := []corev1.EnvVar{}
@@ -983,6 +993,7 @@ func testAdditionalVolumes(t *testing.T, additionalVolumes []corev1.Volume) func
 
 func TestGetNewPDSetForTidbCluster(t *testing.T) {
 	enable := true
+	pullAlways := corev1.PullAlways
 	asNonRoot := true
 	privileged := true
 	tests := []struct {
@@ -1006,6 +1017,48 @@ func TestGetNewPDSetForTidbCluster(t *testing.T) {
 			},
 			testSts: testHostNetwork(t, false, ""),
 		},
+		{
+			name: "pd uses the cluster-level scheduler name",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					SchedulerName: "custom-scheduler",
+					PD:            &v1alpha1.PDSpec{},
+					TiKV:          &v1alpha1.TiKVSpec{},
+					TiDB:          &v1alpha1.TiDBSpec{},
+				},
+			},
+			testSts: func(sts *apps.StatefulSet) {
+				g := NewGomegaWithT(t)
+				g.Expect(sts.Spec.Template.Spec.SchedulerName).To(Equal("custom-scheduler"))
+			},
+		},
+		{
+			name: "pd scheduler name overrides the cluster-level one",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					SchedulerName: v1alpha1.TiDBSchedulerName,
+					PD: &v1alpha1.PDSpec{
+						ComponentSpec: v1alpha1.ComponentSpec{
+							SchedulerName: pointer.StringPtr("custom-scheduler"),
+						},
+					},
+					TiKV: &v1alpha1.TiKVSpec{},
+					TiDB: &v1alpha1.TiDBSpec{},
+				},
+			},
+			testSts: func(sts *apps.StatefulSet) {
+				g := NewGomegaWithT(t)
+				g.Expect(sts.Spec.Template.Spec.SchedulerName).To(Equal("custom-scheduler"))
+			},
+		},
 		{
 			name: "pd network is host",
 			tc: v1alpha1.TidbCluster{
@@ -1781,6 +1834,42 @@ func TestGetNewPDSetForTidbCluster(t *testing.T) {
 				}))
 			},
 		},
+		{
+			name: "pd image pull policy overrides the cluster-level one",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					PD: &v1alpha1.PDSpec{
+						ComponentSpec: v1alpha1.ComponentSpec{
+							ImagePullPolicy: &pullAlways,
+						},
+					},
+					TiKV: &v1alpha1.TiKVSpec{},
+					TiDB: &v1alpha1.TiDBSpec{},
+				},
+			},
+			testSts: testImagePullPolicy(t, corev1.PullAlways, v1alpha1.PDMemberType),
+		},
+		{
+			name: "pd image pull policy defaults to the cluster-level one",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					PD:              &v1alpha1.PDSpec{},
+					TiKV:            &v1alpha1.TiKVSpec{},
+					TiDB:            &v1alpha1.TiDBSpec{},
+				},
+			},
+			testSts: testImagePullPolicy(t, corev1.PullIfNotPresent, v1alpha1.PDMemberType),
+		},
 		// TODO add more tests
 	}
 
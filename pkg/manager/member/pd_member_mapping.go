@@ -0,0 +1,96 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/advanced-statefulset/client/apis/apps/v1/helper"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/util"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// pdMemberMappingAmbiguousReason is the reason of the PDMemberMismatch
+	// condition when the PD members can not be mapped to the PD Pods one to one
+	pdMemberMappingAmbiguousReason = "AmbiguousMemberMapping"
+	// pdMemberMappingConsistentReason is the reason of the PDMemberMismatch
+	// condition when the PD members are mapped to the PD Pods one to one
+	pdMemberMappingConsistentReason = "ConsistentMemberMapping"
+)
+
+// checkPDMemberMapping maps the PD members reported by PD to the ordinals of
+// the PD StatefulSet and returns why the member of the Pod with the given
+// ordinal can not be determined, or an empty string if it can. The mapping is
+// ambiguous if two members map to the same Pod, or if no member maps to the
+// Pod while some members map to no Pod, e.g. a previous scale-in deleted the
+// member but not the Pod.
+func checkPDMemberMapping(tc *v1alpha1.TidbCluster, set *apps.StatefulSet, ordinal int32) string {
+	prefix := controller.PDMemberName(tc.GetName()) + "-"
+	ordinals := helper.GetPodOrdinals(*set.Spec.Replicas, set)
+
+	// ordinal -> names of the members of the Pod
+	mapped := map[int32][]string{}
+	var unmapped []string
+	for name := range tc.Status.PD.Members {
+		// the member name is either the Pod name or the FQDN of the Pod
+		podName := strings.Split(name, ".")[0]
+		// the members of the PD groups are run by other StatefulSets
+		if isPDGroupMember(tc, name) {
+			continue
+		}
+		if !strings.HasPrefix(podName, prefix) {
+			unmapped = append(unmapped, name)
+			continue
+		}
+		o, err := util.GetOrdinalFromPodName(podName)
+		if err != nil || !ordinals.Has(o) {
+			unmapped = append(unmapped, name)
+			continue
+		}
+		mapped[o] = append(mapped[o], name)
+	}
+
+	for o, names := range mapped {
+		if len(names) > 1 {
+			sort.Strings(names)
+			return fmt.Sprintf("members %s map to the same pod %s", strings.Join(names, ", "), PdPodName(tc.GetName(), o))
+		}
+	}
+	if len(mapped[ordinal]) == 0 && len(unmapped) > 0 {
+		sort.Strings(unmapped)
+		return fmt.Sprintf("pd reports %d members for %d pods, pod %s has no member while members %s map to no pod",
+			len(tc.Status.PD.Members), ordinals.Len(), PdPodName(tc.GetName(), ordinal), strings.Join(unmapped, ", "))
+	}
+	return ""
+}
+
+// syncPDMemberMappingCondition sets the PDMemberMismatch condition by the
+// result of checkPDMemberMapping and returns whether the mapping is ambiguous
+func syncPDMemberMappingCondition(tc *v1alpha1.TidbCluster, set *apps.StatefulSet, ordinal int32) bool {
+	if reason := checkPDMemberMapping(tc, set, ordinal); reason != "" {
+		utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+			v1alpha1.TidbClusterPDMemberMismatch, corev1.ConditionTrue, pdMemberMappingAmbiguousReason, reason))
+		return true
+	}
+	utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+		v1alpha1.TidbClusterPDMemberMismatch, corev1.ConditionFalse, pdMemberMappingConsistentReason, "the pd members map to the pd pods one to one"))
+	return false
+}
@@ -0,0 +1,91 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+// pdMemberHealth returns the number of healthy PD members and the number of
+// all the PD members, including the ones of the peer clusters.
+func pdMemberHealth(tc *v1alpha1.TidbCluster) (int, int) {
+	healthy := 0
+	for _, member := range tc.Status.PD.Members {
+		if member.Health {
+			healthy++
+		}
+	}
+	for _, member := range tc.Status.PD.PeerMembers {
+		if member.Health {
+			healthy++
+		}
+	}
+	return healthy, len(tc.Status.PD.Members) + len(tc.Status.PD.PeerMembers)
+}
+
+// findPDMember returns the PD member of the name in the current cluster or
+// the peer clusters.
+func findPDMember(tc *v1alpha1.TidbCluster, memberName string) (v1alpha1.PDMember, bool) {
+	if member, ok := tc.Status.PD.Members[memberName]; ok {
+		return member, true
+	}
+	if member, ok := tc.Status.PD.PeerMembers[memberName]; ok {
+		return member, true
+	}
+	return v1alpha1.PDMember{}, false
+}
+
+// canRemovePDMember returns whether the PD cluster still has a quorum of
+// healthy members after the member is removed, and the reason if not. It is
+// consulted by every path that removes a PD member, i.e. scale in and
+// failover, see canRestartPDMember for the upgrade and the restart.
+// Removing the last member is allowed, as the whole PD cluster is going away.
+func canRemovePDMember(tc *v1alpha1.TidbCluster, memberName string) (bool, string) {
+	healthy, total := pdMemberHealth(tc)
+	// the member not in the PD cluster changes nothing when it is removed
+	if member, ok := findPDMember(tc, memberName); ok {
+		if member.Health {
+			healthy--
+		}
+		total--
+	}
+	if total == 0 {
+		return true, ""
+	}
+	if healthy <= total/2 {
+		return false, fmt.Sprintf("only %d of the %d remaining PD members would be healthy without %s", healthy, total, memberName)
+	}
+	return true, ""
+}
+
+// canRestartPDMember returns whether the PD cluster keeps a quorum of healthy
+// members while the member is restarted, e.g. upgraded, and the reason if
+// not. Unlike a removed member, the member restarted still counts in the size
+// of the quorum. Restarting the only member is allowed, as it can't be
+// upgraded otherwise.
+func canRestartPDMember(tc *v1alpha1.TidbCluster, memberName string) (bool, string) {
+	healthy, total := pdMemberHealth(tc)
+	if member, ok := findPDMember(tc, memberName); ok && member.Health {
+		healthy--
+	}
+	if total <= 1 {
+		return true, ""
+	}
+	if healthy <= total/2 {
+		return false, fmt.Sprintf("only %d of the %d PD members would be healthy while %s restarts", healthy, total, memberName)
+	}
+	return true, ""
+}
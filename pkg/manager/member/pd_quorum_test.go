@@ -0,0 +1,165 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+func TestCanRemovePDMember(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name         string
+		members      map[string]bool
+		peerMembers  map[string]bool
+		memberName   string
+		expectRemove bool
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+		tc := newTidbClusterForPD()
+		tc.Status.PD.Members = map[string]v1alpha1.PDMember{}
+		for name, health := range test.members {
+			tc.Status.PD.Members[name] = v1alpha1.PDMember{Name: name, Health: health}
+		}
+		tc.Status.PD.PeerMembers = map[string]v1alpha1.PDMember{}
+		for name, health := range test.peerMembers {
+			tc.Status.PD.PeerMembers[name] = v1alpha1.PDMember{Name: name, Health: health}
+		}
+
+		ok, reason := canRemovePDMember(tc, test.memberName)
+		g.Expect(ok).To(Equal(test.expectRemove))
+		if ok {
+			g.Expect(reason).To(BeEmpty())
+		} else {
+			g.Expect(reason).NotTo(BeEmpty())
+		}
+	}
+
+	tests := []*testcase{
+		{
+			name:         "healthy majority",
+			members:      map[string]bool{"pd-0": true, "pd-1": true, "pd-2": true},
+			memberName:   "pd-2",
+			expectRemove: true,
+		},
+		{
+			name:         "marginal, an unhealthy member is removed",
+			members:      map[string]bool{"pd-0": true, "pd-1": true, "pd-2": false},
+			memberName:   "pd-2",
+			expectRemove: true,
+		},
+		{
+			name:         "marginal, a healthy member is removed from a cluster of 5",
+			members:      map[string]bool{"pd-0": true, "pd-1": true, "pd-2": true, "pd-3": true, "pd-4": false},
+			memberName:   "pd-3",
+			expectRemove: true,
+		},
+		{
+			name:         "would break quorum",
+			members:      map[string]bool{"pd-0": true, "pd-1": true, "pd-2": false},
+			memberName:   "pd-1",
+			expectRemove: false,
+		},
+		{
+			name:         "would break quorum counting peer members",
+			members:      map[string]bool{"pd-0": true, "pd-1": true},
+			peerMembers:  map[string]bool{"peer-pd-0": false, "peer-pd-1": false},
+			memberName:   "pd-1",
+			expectRemove: false,
+		},
+		{
+			name:         "quorum kept with healthy peer members",
+			members:      map[string]bool{"pd-0": true},
+			peerMembers:  map[string]bool{"peer-pd-0": true, "peer-pd-1": true},
+			memberName:   "pd-0",
+			expectRemove: true,
+		},
+		{
+			name:         "the last member",
+			members:      map[string]bool{"pd-0": true},
+			memberName:   "pd-0",
+			expectRemove: true,
+		},
+		{
+			name:         "member not in the cluster and quorum lost already",
+			members:      map[string]bool{"pd-0": true, "pd-1": false, "pd-2": false},
+			memberName:   "pd-3",
+			expectRemove: false,
+		},
+	}
+
+	for _, test := range tests {
+		testFn(test, t)
+	}
+}
+
+func TestCanRestartPDMember(t *testing.T) {
+	tests := []struct {
+		name          string
+		members       map[string]bool
+		memberName    string
+		expectRestart bool
+	}{
+		{
+			name:          "healthy majority",
+			members:       map[string]bool{"pd-0": true, "pd-1": true, "pd-2": true},
+			memberName:    "pd-2",
+			expectRestart: true,
+		},
+		{
+			name:          "an unhealthy member is restarted",
+			members:       map[string]bool{"pd-0": true, "pd-1": true, "pd-2": false},
+			memberName:    "pd-2",
+			expectRestart: true,
+		},
+		{
+			name:          "one of two members is restarted",
+			members:       map[string]bool{"pd-0": true, "pd-1": true},
+			memberName:    "pd-1",
+			expectRestart: false,
+		},
+		{
+			name:          "the member restarted still counts in the quorum",
+			members:       map[string]bool{"pd-0": true, "pd-1": true, "pd-2": true, "pd-3": false},
+			memberName:    "pd-2",
+			expectRestart: false,
+		},
+		{
+			name:          "the only member",
+			members:       map[string]bool{"pd-0": true},
+			memberName:    "pd-0",
+			expectRestart: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			tc := newTidbClusterForPD()
+			tc.Status.PD.Members = map[string]v1alpha1.PDMember{}
+			for name, health := range test.members {
+				tc.Status.PD.Members[name] = v1alpha1.PDMember{Name: name, Health: health}
+			}
+			ok, reason := canRestartPDMember(tc, test.memberName)
+			g.Expect(ok).To(Equal(test.expectRestart))
+			g.Expect(reason == "").To(Equal(ok))
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+)
+
+const (
+	tikvRegionMaxSizeItem   = "coprocessor.region-max-size"
+	tikvRegionSplitSizeItem = "coprocessor.region-split-size"
+
+	// regionSizeSyncFailedReason is the reason of the Events of the failures
+	// to push the Region size to TiKV
+	regionSizeSyncFailedReason = "RegionSizeSyncFailed"
+)
+
+// syncRegionSize pushes `.spec.pd.regionSize` to the Up TiKV stores through
+// their online config API, the Region size is the config of TiKV, which PD
+// schedules the Regions with. The sizes in each store are compared with the
+// spec in each round, so the drift, e.g. a change by tikv-ctl or a restart
+// with the size in the ConfigMap, is corrected. The sizes are compared in
+// bytes, as TiKV may report them in another unit.
+//
+// The Region size is not essential to the sync of TiKV, so the failures are
+// recorded as Events and the stores are synced again in the next round.
+func syncRegionSize(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	if tc.Spec.PD == nil || tc.Spec.PD.RegionSize == nil || tc.Spec.TiKV == nil {
+		return
+	}
+	if tc.Spec.Paused {
+		klog.V(4).Infof("tidb cluster %s/%s is paused, skip syncing region size", ns, tcName)
+		return
+	}
+	if !tc.Status.TiKV.Synced {
+		klog.V(4).Infof("tidb cluster %s/%s tikv status is not synced, skip syncing region size", ns, tcName)
+		return
+	}
+
+	for _, store := range tc.Status.TiKV.Stores {
+		if store.State != v1alpha1.TiKVStateUp {
+			continue
+		}
+		if err := syncStoreRegionSize(deps, tc, store.PodName); err != nil {
+			klog.Warningf("tikv: failed to sync region size of %s/%s store %s, error: %v", ns, tcName, store.ID, err)
+			deps.Recorder.Event(tc, corev1.EventTypeWarning, regionSizeSyncFailedReason, fmt.Sprintf("failed to sync region size of tikv %s: %v", store.PodName, err))
+		}
+	}
+}
+
+func syncStoreRegionSize(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, podName string) error {
+	tikvCli := deps.TiKVControl.GetTiKVPodClient(tc.GetNamespace(), tc.GetName(), podName, tc.TiKVStatusPort(), tc.IsTLSClusterEnabled())
+	config, err := tikvCli.GetConfig()
+	if err != nil {
+		return err
+	}
+	var currentMaxSize, currentSplitSize string
+	if config.Coprocessor != nil {
+		currentMaxSize = config.Coprocessor.RegionMaxSize
+		currentSplitSize = config.Coprocessor.RegionSplitSize
+	}
+
+	items := map[string]interface{}{}
+	if desired := tc.Spec.PD.RegionSize.RegionMaxSize; desired != nil && !regionSizeEqual(*desired, currentMaxSize) {
+		items[tikvRegionMaxSizeItem] = *desired
+	}
+	if desired := tc.Spec.PD.RegionSize.RegionSplitSize; desired != nil && !regionSizeEqual(*desired, currentSplitSize) {
+		items[tikvRegionSplitSizeItem] = *desired
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	if err := tikvCli.UpdateConfig(items); err != nil {
+		return err
+	}
+	klog.Infof("tikv: update region size of %s/%s %s to %v successfully", tc.GetNamespace(), tc.GetName(), podName, items)
+	return nil
+}
+
+// regionSizeEqual returns whether the two sizes are the same in bytes
+func regionSizeEqual(desired, current string) bool {
+	d, err := v1alpha1.ParseRegionSize(desired)
+	if err != nil {
+		return false
+	}
+	c, err := v1alpha1.ParseRegionSize(current)
+	if err != nil {
+		return false
+	}
+	return d == c
+}
@@ -0,0 +1,140 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/tikvapi"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+)
+
+func TestSyncRegionSize(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name        string
+		regionSize  *v1alpha1.PDRegionSizeConfig
+		synced      bool
+		current     *tikvapi.TiKVCoprocessorConfig
+		getErr      error
+		expectItems map[string]interface{}
+		expectEvent bool
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+		fakeDeps := controller.NewFakeDependencies()
+		recorder := record.NewFakeRecorder(10)
+		fakeDeps.Recorder = recorder
+
+		tc := newTidbClusterForPD()
+		tc.Spec.PD.RegionSize = test.regionSize
+		tc.Status.TiKV.Synced = test.synced
+		tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{
+			"1": {ID: "1", PodName: "test-tikv-0", State: v1alpha1.TiKVStateUp},
+			"2": {ID: "2", PodName: "test-tikv-1", State: v1alpha1.TiKVStateDown},
+		}
+
+		tikvClient := controller.NewFakeTiKVClient(fakeDeps.TiKVControl.(*tikvapi.FakeTiKVControl), tc, "test-tikv-0")
+		tikvClient.AddReaction(tikvapi.GetConfigActionType, func(action *tikvapi.Action) (interface{}, error) {
+			return &tikvapi.TiKVConfigFromAPI{Coprocessor: test.current}, test.getErr
+		})
+		var items map[string]interface{}
+		tikvClient.AddReaction(tikvapi.UpdateConfigActionType, func(action *tikvapi.Action) (interface{}, error) {
+			items = action.Config
+			return nil, nil
+		})
+
+		// the down store is skipped, it has no fake client
+		syncRegionSize(fakeDeps, tc)
+		if test.expectItems == nil {
+			g.Expect(items).To(BeNil())
+		} else {
+			g.Expect(items).To(Equal(test.expectItems))
+		}
+		if test.expectEvent {
+			g.Expect(recorder.Events).To(HaveLen(1))
+		} else {
+			g.Expect(recorder.Events).To(BeEmpty())
+		}
+	}
+
+	tests := []testcase{
+		{
+			name:    "region size is not managed",
+			synced:  true,
+			current: &tikvapi.TiKVCoprocessorConfig{RegionMaxSize: "144MiB", RegionSplitSize: "96MiB"},
+		},
+		{
+			name:       "tikv is not synced",
+			regionSize: &v1alpha1.PDRegionSizeConfig{RegionMaxSize: pointer.StringPtr("256MiB")},
+		},
+		{
+			name: "push the region size",
+			regionSize: &v1alpha1.PDRegionSizeConfig{
+				RegionMaxSize:   pointer.StringPtr("384MiB"),
+				RegionSplitSize: pointer.StringPtr("256MiB"),
+			},
+			synced:  true,
+			current: &tikvapi.TiKVCoprocessorConfig{RegionMaxSize: "144MiB", RegionSplitSize: "96MiB"},
+			expectItems: map[string]interface{}{
+				tikvRegionMaxSizeItem:   "384MiB",
+				tikvRegionSplitSizeItem: "256MiB",
+			},
+		},
+		{
+			name: "correct the drifted item only",
+			regionSize: &v1alpha1.PDRegionSizeConfig{
+				RegionMaxSize:   pointer.StringPtr("384MiB"),
+				RegionSplitSize: pointer.StringPtr("256MiB"),
+			},
+			synced:  true,
+			current: &tikvapi.TiKVCoprocessorConfig{RegionMaxSize: "384MiB", RegionSplitSize: "96MiB"},
+			expectItems: map[string]interface{}{
+				tikvRegionSplitSizeItem: "256MiB",
+			},
+		},
+		{
+			name:       "the same size in another unit",
+			regionSize: &v1alpha1.PDRegionSizeConfig{RegionMaxSize: pointer.StringPtr("1GiB")},
+			synced:     true,
+			current:    &tikvapi.TiKVCoprocessorConfig{RegionMaxSize: "1024MB"},
+		},
+		{
+			name:       "the size is not reported by tikv",
+			regionSize: &v1alpha1.PDRegionSizeConfig{RegionSplitSize: pointer.StringPtr("96MiB")},
+			synced:     true,
+			expectItems: map[string]interface{}{
+				tikvRegionSplitSizeItem: "96MiB",
+			},
+		},
+		{
+			name:        "the failure is recorded without blocking the sync",
+			regionSize:  &v1alpha1.PDRegionSizeConfig{RegionSplitSize: pointer.StringPtr("96MiB")},
+			synced:      true,
+			getErr:      fmt.Errorf("connection refused"),
+			expectEvent: true,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i], t)
+	}
+}
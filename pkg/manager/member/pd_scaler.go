@@ -15,33 +15,98 @@ package member
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/pingcap/advanced-statefulset/client/apis/apps/v1/helper"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/features"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
 	"github.com/pingcap/tidb-operator/pkg/util"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
 	apps "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog"
 )
 
 // TODO add e2e test specs
 
+// pdLeaderTransferPollInterval is the interval to check the PD leader when the
+// scale-in waits for the leader transfer
+var pdLeaderTransferPollInterval = time.Second
+
+// pdEvenReplicasReason is the reason of the Events of scaling PD out to an even number of replicas
+const pdEvenReplicasReason = "EvenPDReplicas"
+
 type pdScaler struct {
 	generalScaler
 }
 
 // NewPDScaler returns a Scaler
 func NewPDScaler(deps *controller.Dependencies) Scaler {
-	return &pdScaler{generalScaler: generalScaler{deps: deps}}
+	return withScaleMetrics(v1alpha1.PDMemberType, &pdScaler{generalScaler: generalScaler{deps: deps}})
 }
 
 func (s *pdScaler) Scale(meta metav1.Object, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	// the dry run of a scale changes nothing, including the cleanups below
+	if s.scaleDryRun(meta, v1alpha1.PDMemberType, oldSet, newSet) {
+		return nil
+	}
+	if err := clearCancelledPreScaleInHooks(s.deps, meta, newSet); err != nil {
+		return err
+	}
+	if err := skipScaleInProtectedPods(s.deps, meta, v1alpha1.PDMemberType, oldSet, newSet); err != nil {
+		return err
+	}
+	if s.deferScaleToMaintenanceWindow(meta, v1alpha1.PDMemberType, oldSet, newSet) {
+		return nil
+	}
+	if deferred, err := s.deferScale(meta, v1alpha1.PDMemberType, oldSet, newSet); err != nil || deferred {
+		return err
+	}
+	if err := s.checkScaleVelocity(meta, v1alpha1.PDMemberType, oldSet, newSet); err != nil {
+		return err
+	}
 	scaling, _, _, _ := scaleOne(oldSet, newSet)
+	if scaling != 0 {
+		if err := checkPDSplitBrain(meta, oldSet, newSet); err != nil {
+			return err
+		}
+		if tc, ok := meta.(*v1alpha1.TidbCluster); ok {
+			waitFor, err := scalingPDSet(s.deps, tc, oldSet.Name)
+			if err != nil {
+				return err
+			}
+			if waitFor != "" {
+				resetReplicas(newSet, oldSet)
+				return controller.RequeueErrorf("tidbcluster: [%s/%s]'s pd statefulset %s is scaling, skip scaling statefulset %s", tc.GetNamespace(), tc.GetName(), waitFor, oldSet.GetName())
+			}
+			if group, ok := pdGroupOfSet(tc, oldSet); ok {
+				s.recordScale(meta, v1alpha1.PDMemberType, oldSet, newSet)
+				return s.scaleGroup(tc, group, oldSet, newSet)
+			}
+		}
+	}
+	if tc, ok := meta.(*v1alpha1.TidbCluster); ok && scaling >= 0 {
+		// the scale-in of spec.pd blocked before is not wanted anymore
+		if _, isGroup := pdGroupOfSet(tc, oldSet); !isGroup {
+			syncPDScaleBlockedCondition(tc, nil)
+		}
+	}
 	if scaling > 0 {
+		if err := checkResourceQuota(s.deps, meta, oldSet, newSet); err != nil {
+			return err
+		}
+		s.recordScale(meta, v1alpha1.PDMemberType, oldSet, newSet)
 		return s.ScaleOut(meta, oldSet, newSet)
 	} else if scaling < 0 {
+		s.recordScale(meta, v1alpha1.PDMemberType, oldSet, newSet)
 		return s.ScaleIn(meta, oldSet, newSet)
 	}
 	return nil
@@ -59,6 +124,10 @@ func (s *pdScaler) ScaleOut(meta metav1.Object, oldSet *apps.StatefulSet, newSet
 	tcName := tc.GetName()
 
 	klog.Infof("scaling out pd statefulset %s/%s, ordinal: %d (replicas: %d, delete slots: %v)", oldSet.Namespace, oldSet.Name, ordinal, replicas, deleteSlots.List())
+	// the PVCs wiped are unmarked and used by the new member, the others are deleted
+	if err := s.checkStalePVC(tc, v1alpha1.PDMemberType, ordinal); err != nil {
+		return err
+	}
 	_, err := s.deleteDeferDeletingPVC(tc, v1alpha1.PDMemberType, ordinal)
 	if err != nil {
 		return err
@@ -68,6 +137,20 @@ func (s *pdScaler) ScaleOut(meta metav1.Object, oldSet *apps.StatefulSet, newSet
 		return fmt.Errorf("TidbCluster: %s/%s's pd status sync failed, can't scale out now", ns, tcName)
 	}
 
+	// an even number of members tolerates no more failures than one member
+	// less, the members added by failover beyond the replicas are not checked
+	if specReplicas := tc.Spec.PD.Replicas; replicas <= specReplicas && specReplicas%2 == 0 && !tc.AllowEvenPDReplicas() {
+		msg := fmt.Sprintf("pd can't be scaled out to an even number of replicas %d, set annotation %s to scale out anyway", specReplicas, label.AnnAllowEvenPDReplicas)
+		s.deps.Recorder.Event(tc, v1.EventTypeWarning, pdEvenReplicasReason, msg)
+		recordScaleBlocked(tc, v1alpha1.PDMemberType, pdEvenReplicasReason)
+		return controller.RequeueErrorf("TidbCluster: %s/%s's %s", ns, tcName, msg)
+	}
+
+	// the members joined by the previous scale-out must be promoted to voters first
+	if learners := waitingPDLearners(tc); len(learners) > 0 {
+		return controller.RequeueErrorf("TidbCluster: %s/%s's pd members %s are not promoted to voters yet, can't scale out now", ns, tcName, strings.Join(learners, ", "))
+	}
+
 	setReplicasAndDeleteSlots(newSet, replicas, deleteSlots)
 	return nil
 }
@@ -83,14 +166,21 @@ func (s *pdScaler) ScaleIn(meta metav1.Object, oldSet *apps.StatefulSet, newSet
 	ns := tc.GetNamespace()
 	tcName := tc.GetName()
 	_, ordinal, replicas, deleteSlots := scaleOne(oldSet, newSet)
+	desiredSet := newSet.DeepCopy()
 	resetReplicas(newSet, oldSet)
-	memberName := PdName(tcName, ordinal, tc.Namespace, tc.Spec.ClusterDomain)
-	pdPodName := PdPodName(tcName, ordinal)
 
 	if !tc.Status.PD.Synced {
 		return fmt.Errorf("TidbCluster: %s/%s's pd status sync failed, can't scale in now", ns, tcName)
 	}
 
+	pdClient := controller.GetPDClient(s.deps.PDControl, tc)
+	ordinal, deleteSlots, err := deferPDLeaderScaleIn(tc, pdClient, oldSet, desiredSet, ordinal, replicas, deleteSlots)
+	if err != nil {
+		return err
+	}
+	memberName := PdName(tcName, ordinal, tc.Namespace, tc.Spec.ClusterDomain)
+	pdPodName := PdPodName(tcName, ordinal)
+
 	klog.Infof("scaling in pd statefulset %s/%s, ordinal: %d (replicas: %d, delete slots: %v)", oldSet.Namespace, oldSet.Name, ordinal, replicas, deleteSlots.List())
 
 	if s.deps.CLIConfig.PodWebhookEnabled {
@@ -103,7 +193,27 @@ func (s *pdScaler) ScaleIn(meta metav1.Object, oldSet *apps.StatefulSet, newSet
 		return nil
 	}
 
-	pdClient := controller.GetPDClient(s.deps.PDControl, tc)
+	// never guess the member to delete if the members reported by PD do not
+	// match the Pods, e.g. a previous scale-in is partially done
+	if syncPDMemberMappingCondition(tc, oldSet, ordinal) {
+		return controller.RequeueErrorf("tc[%s/%s]'s pd members can't be mapped to pd pods, can't scale in pd pod %s now", ns, tcName, pdPodName)
+	}
+
+	statusName := memberName
+	if _, exist := tc.Status.PD.Members[statusName]; !exist {
+		statusName = pdPodName
+	}
+	if ok, reason := canRemovePDMember(tc, statusName); !ok {
+		return controller.RequeueErrorf("tc[%s/%s]'s pd member %s can't be scaled in now, %s", ns, tcName, memberName, reason)
+	}
+	if err := runPreScaleInHook(s.deps, tc, v1alpha1.PDMemberType, ordinal); err != nil {
+		return err
+	}
+	if err := reservePDDeletion(s.deps, tc, "member", memberName); err != nil {
+		recordScaleBlocked(tc, v1alpha1.PDMemberType, "PDDeletionDeferred")
+		return err
+	}
+
 	leader, err := pdClient.GetPDLeader()
 	if err != nil {
 		return err
@@ -112,7 +222,24 @@ func (s *pdScaler) ScaleIn(meta metav1.Object, oldSet *apps.StatefulSet, newSet
 	// If the PD StatefulSet would be scale-in to zero and no other members in the PD cluster,
 	// we would directly delete the member without the leader transferring
 	if leader.Name == memberName || leader.Name == pdPodName {
-		if *newSet.Spec.Replicas > 1 {
+		// the members being scaled in are never chosen by the priority
+		excluded := sets.NewString()
+		deletions := helper.GetPodOrdinals(*oldSet.Spec.Replicas, oldSet).Difference(helper.GetPodOrdinals(*desiredSet.Spec.Replicas, desiredSet))
+		for _, o := range deletions.Insert(ordinal).List() {
+			excluded.Insert(PdPodName(tcName, o))
+		}
+		if targetName := pdLeaderTransferPriorityTarget(tc, excluded); targetName != "" {
+			if err := pdClient.TransferPDLeader(targetName); err != nil {
+				return err
+			}
+			timeout := tc.PDScaleInLeaderTransferTimeout()
+			if timeout <= 0 {
+				return controller.RequeueErrorf("tc[%s/%s]'s pd pod[%s/%s] is transferring pd leader to %s, can't scale-in now", ns, tcName, ns, memberName, targetName)
+			}
+			if err := waitPDLeaderTransferred(tc, pdClient, timeout, memberName, pdPodName); err != nil {
+				return err
+			}
+		} else if *newSet.Spec.Replicas > 1 {
 			minOrdinal := helper.GetMinPodOrdinal(*newSet.Spec.Replicas, newSet)
 			targetOrdinal := helper.GetMaxPodOrdinal(*newSet.Spec.Replicas, newSet)
 			if ordinal > minOrdinal {
@@ -127,6 +254,11 @@ func (s *pdScaler) ScaleIn(meta metav1.Object, oldSet *apps.StatefulSet, newSet
 			if err != nil {
 				return err
 			}
+			if timeout := tc.PDScaleInLeaderTransferTimeout(); timeout > 0 {
+				if err := waitPDLeaderTransferred(tc, pdClient, timeout, memberName, pdPodName); err != nil {
+					return err
+				}
+			}
 		} else {
 			for _, member := range tc.Status.PD.PeerMembers {
 				if member.Health && member.Name != memberName {
@@ -134,7 +266,14 @@ func (s *pdScaler) ScaleIn(meta metav1.Object, oldSet *apps.StatefulSet, newSet
 					if err != nil {
 						return err
 					}
-					return controller.RequeueErrorf("tc[%s/%s]'s pd pod[%s/%s] is transferring pd leader,can't scale-in now", ns, tcName, ns, memberName)
+					timeout := tc.PDScaleInLeaderTransferTimeout()
+					if timeout <= 0 {
+						return controller.RequeueErrorf("tc[%s/%s]'s pd pod[%s/%s] is transferring pd leader,can't scale-in now", ns, tcName, ns, memberName)
+					}
+					if err := waitPDLeaderTransferred(tc, pdClient, timeout, memberName, pdPodName); err != nil {
+						return err
+					}
+					break
 				}
 			}
 		}
@@ -167,29 +306,151 @@ func (s *pdScaler) ScaleIn(meta metav1.Object, oldSet *apps.StatefulSet, newSet
 	return nil
 }
 
-func (s *pdScaler) preCheckUpMembers(tc *v1alpha1.TidbCluster, podName string) bool {
-	upComponents := 0
+// waitPDLeaderTransferred waits for at most the timeout until the PD leader is
+// not the member being scaled in, so that the member is deleted in the same
+// reconcile as the leader transfer.
+func waitPDLeaderTransferred(tc *v1alpha1.TidbCluster, pdClient pdapi.PDClient, timeout time.Duration, memberName, podName string) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	start := time.Now()
+	err := wait.PollImmediate(pdLeaderTransferPollInterval, timeout, func() (bool, error) {
+		leader, err := pdClient.GetPDLeader()
+		if err != nil {
+			klog.Warningf("tc[%s/%s] failed to get pd leader when waiting for the leader transfer, %v", ns, tcName, err)
+			return false, nil
+		}
+		return leader.Name != memberName && leader.Name != podName, nil
+	})
+	if err != nil {
+		return controller.RequeueErrorf("tc[%s/%s]'s pd leader is not transferred from member %s in %v, can't scale-in now", ns, tcName, memberName, timeout)
+	}
+	klog.Infof("tc[%s/%s]'s pd leader is transferred from member %s in %v", ns, tcName, memberName, time.Since(start))
+	return nil
+}
+
+// pdLeaderTransferPriorityTarget returns the first healthy PD member matching
+// an item of spec.pd.leaderTransferPriority by its Pod name or the zone of its
+// Pod, or "" if none matches. The members of the excluded Pods are skipped.
+func pdLeaderTransferPriorityTarget(tc *v1alpha1.TidbCluster, excluded sets.String) string {
+	if tc.Spec.PD == nil || len(tc.Spec.PD.LeaderTransferPriority) == 0 {
+		return ""
+	}
+	zones := map[string]string{}
+	if tc.Status.Topology != nil {
+		for _, member := range tc.Status.Topology.PD {
+			zones[member.PodName] = member.Zone
+		}
+	}
+	names := make([]string, 0, len(tc.Status.PD.Members))
+	for name := range tc.Status.PD.Members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, item := range tc.Spec.PD.LeaderTransferPriority {
+		for _, name := range names {
+			member := tc.Status.PD.Members[name]
+			podName := strings.Split(name, ".")[0]
+			if !member.Health || member.IsLearner || excluded.Has(podName) {
+				continue
+			}
+			if item == podName || item == zones[podName] {
+				return name
+			}
+		}
+	}
+	return ""
+}
 
-	upComponents += len(tc.Status.TiKV.Stores) + len(tc.Status.TiFlash.Stores) + len(tc.Status.TiDB.Members)
+// deferPDLeaderScaleIn picks another ordinal to remove if the one chosen by
+// scaleOne is the PD leader and more ordinals are waiting to be removed, so
+// that the leader is removed last and PD leadership is transferred only once.
+// The order can only be changed when AdvancedStatefulSet is enabled.
+func deferPDLeaderScaleIn(tc *v1alpha1.TidbCluster, pdClient pdapi.PDClient, oldSet, desiredSet *apps.StatefulSet,
+	ordinal, replicas int32, deleteSlots sets.Int32) (int32, sets.Int32, error) {
+	if !features.DefaultFeatureGate.Enabled(features.AdvancedStatefulSet) {
+		return ordinal, deleteSlots, nil
+	}
+	actualOrdinals := helper.GetPodOrdinals(*oldSet.Spec.Replicas, oldSet)
+	deletions := actualOrdinals.Difference(helper.GetPodOrdinals(*desiredSet.Spec.Replicas, desiredSet))
+	if deletions.Len() <= 1 {
+		return ordinal, deleteSlots, nil
+	}
 
-	if tc.Status.TiCDC.StatefulSet != nil {
-		upComponents += int(tc.Status.TiCDC.StatefulSet.Replicas)
+	leader, err := pdClient.GetPDLeader()
+	if err != nil {
+		return ordinal, deleteSlots, err
+	}
+	tcName := tc.GetName()
+	if leader.Name != PdName(tcName, ordinal, tc.Namespace, tc.Spec.ClusterDomain) && leader.Name != PdPodName(tcName, ordinal) {
+		return ordinal, deleteSlots, nil
 	}
 
-	if tc.Status.Pump.StatefulSet != nil {
-		upComponents += int(tc.Status.Pump.StatefulSet.Replicas)
+	candidates := deletions.List()
+	for i := len(candidates) - 1; i >= 0; i-- {
+		candidate := candidates[i]
+		if candidate == ordinal {
+			continue
+		}
+		slots := helper.GetDeleteSlots(oldSet)
+		slots.Insert(candidate)
+		slots = normalizeDeleteSlots(replicas, slots, helper.GetDeleteSlots(desiredSet))
+		expected := actualOrdinals.Difference(sets.NewInt32(candidate))
+		if helper.GetPodOrdinalsFromReplicasAndDeleteSlots(replicas, slots).Equal(expected) {
+			klog.Infof("pd ordinal %d of tc %s/%s is the pd leader, scale in ordinal %d first", ordinal, tc.GetNamespace(), tcName, candidate)
+			return candidate, slots, nil
+		}
+	}
+	return ordinal, deleteSlots, nil
+}
+
+func (s *pdScaler) preCheckUpMembers(tc *v1alpha1.TidbCluster, podName string) bool {
+	var components []string
+	if len(tc.Status.TiKV.Stores) > 0 {
+		components = append(components, "TiKV")
+	}
+	if len(tc.Status.TiFlash.Stores) > 0 {
+		components = append(components, "TiFlash")
+	}
+	if len(tc.Status.TiDB.Members) > 0 {
+		components = append(components, "TiDB")
+	}
+	if tc.Status.TiCDC.StatefulSet != nil && tc.Status.TiCDC.StatefulSet.Replicas > 0 {
+		components = append(components, "TiCDC")
+	}
+	if tc.Status.Pump.StatefulSet != nil && tc.Status.Pump.StatefulSet.Replicas > 0 {
+		components = append(components, "Pump")
 	}
 
-	if upComponents != 0 && tc.Spec.PD.Replicas == 0 {
+	if len(components) != 0 && tc.Spec.PD.Replicas == 0 {
 		errMsg := fmt.Sprintf("The PD is in use by TidbCluster [%s/%s], can't scale in PD, podname %s", tc.GetNamespace(), tc.GetName(), podName)
 		klog.Error(errMsg)
 		s.deps.Recorder.Event(tc, v1.EventTypeWarning, "FailedScaleIn", errMsg)
+		syncPDScaleBlockedCondition(tc, components)
 		return false
 	}
 
+	syncPDScaleBlockedCondition(tc, nil)
 	return true
 }
 
+// syncPDScaleBlockedCondition sets the ScaleBlocked condition if scaling in PD
+// is blocked by the components, whose names are listed in the reason, e.g.
+// PDInUseByTiKVAndTiDB. The condition is set to false once it is not blocked.
+func syncPDScaleBlockedCondition(tc *v1alpha1.TidbCluster, components []string) {
+	if len(components) > 0 {
+		reason := fmt.Sprintf("PDInUseBy%s", strings.Join(components, "And"))
+		msg := fmt.Sprintf("pd can't be scaled in to 0 replicas as it is in use by %s", strings.ToLower(strings.Join(components, ", ")))
+		utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+			v1alpha1.TidbClusterScaleBlocked, v1.ConditionTrue, reason, msg))
+		return
+	}
+	if cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterScaleBlocked); cond != nil && cond.Status == v1.ConditionTrue {
+		utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+			v1alpha1.TidbClusterScaleBlocked, v1.ConditionFalse, "ScaleNotBlocked", "pd scale is not blocked"))
+	}
+}
+
 type fakePDScaler struct{}
 
 // NewFakePDScaler returns a fake Scaler
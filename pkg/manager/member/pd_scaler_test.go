@@ -14,21 +14,28 @@
 package member
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
 
 	. "github.com/onsi/gomega"
+	"github.com/pingcap/advanced-statefulset/client/apis/apps/v1/helper"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/features"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
 	apps "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 )
 
@@ -196,6 +203,185 @@ func TestPDScalerScaleOut(t *testing.T) {
 	}
 }
 
+func TestPDScalerScaleOutWaitsForLearnerPromotion(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPD()
+	normalPDMember(tc)
+	tc.Status.PD.Synced = true
+	scaler, pdControl, _, _, _ := newFakePDScaler()
+	pd4 := ordinalPodName(v1alpha1.PDMemberType, tc.GetName(), 4)
+	etcdClient := &pdapi.FakePDEtcdClient{}
+	pdControl.SetPDEtcdClient(pdapi.Namespace(tc.GetNamespace()), tc.GetName(), etcdClient)
+
+	scaleOut := func() (int32, error) {
+		oldSet := newStatefulSetForPDScale()
+		newSet := oldSet.DeepCopy()
+		newSet.Spec.Replicas = pointer.Int32Ptr(7)
+		err := scaler.ScaleOut(tc, oldSet, newSet)
+		return *newSet.Spec.Replicas, err
+	}
+
+	// the member joined by the previous scale-out is still a learner
+	etcdClient.Members = []*pdapi.EtcdMember{{ID: 4, Name: pd4, IsLearner: true}}
+	syncPDLearners(scaler.deps, tc, tc.Status.PD.Members)
+	g.Expect(tc.Status.PD.Members[pd4].IsLearner).To(BeTrue())
+	replicas, err := scaleOut()
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(replicas).To(Equal(int32(5)))
+
+	// the scale-out goes on once the learner is promoted to a voter
+	etcdClient.Members = []*pdapi.EtcdMember{{ID: 4, Name: pd4}}
+	syncPDLearners(scaler.deps, tc, tc.Status.PD.Members)
+	g.Expect(tc.Status.PD.Members[pd4].IsLearner).To(BeFalse())
+	replicas, err = scaleOut()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(replicas).To(Equal(int32(6)))
+
+	// or once the learner stays a learner for longer than the timeout
+	etcdClient.Members = []*pdapi.EtcdMember{{ID: 4, Name: pd4, IsLearner: true}}
+	longAgo := metav1.NewTime(time.Now().Add(-time.Hour))
+	previous := map[string]v1alpha1.PDMember{pd4: {Name: pd4, IsLearner: true, LearnerSince: &longAgo}}
+	syncPDLearners(scaler.deps, tc, previous)
+	cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterPDLearnerStuck)
+	g.Expect(cond).NotTo(BeNil())
+	g.Expect(cond.Status).To(Equal(corev1.ConditionTrue))
+	replicas, err = scaleOut()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(replicas).To(Equal(int32(6)))
+}
+
+func TestPDScalerScaleOutToEvenReplicas(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		name         string
+		specReplicas int32
+		annotations  map[string]string
+		changed      bool
+	}{
+		{name: "scale out to even replicas", specReplicas: 6},
+		{name: "scale out to even replicas with annotation", specReplicas: 6, annotations: map[string]string{label.AnnAllowEvenPDReplicas: "true"}, changed: true},
+		{name: "scale out through even replicas", specReplicas: 7, changed: true},
+		{name: "failover member beyond even replicas", specReplicas: 4, changed: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tc := newTidbClusterForPD()
+			normalPDMember(tc)
+			tc.Status.PD.Synced = true
+			tc.Spec.PD.Replicas = test.specReplicas
+			tc.Annotations = test.annotations
+			scaler, _, _, _, _ := newFakePDScaler()
+			recorder := scaler.deps.Recorder.(*record.FakeRecorder)
+
+			oldSet := newStatefulSetForPDScale()
+			newSet := oldSet.DeepCopy()
+			newSet.Spec.Replicas = pointer.Int32Ptr(7)
+			err := scaler.ScaleOut(tc, oldSet, newSet)
+			events := collectEvents(recorder.Events)
+			if test.changed {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(*newSet.Spec.Replicas).To(Equal(int32(6)))
+				g.Expect(events).To(BeEmpty())
+			} else {
+				g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+				g.Expect(*newSet.Spec.Replicas).To(Equal(int32(5)))
+				g.Expect(events).To(HaveLen(1))
+				g.Expect(events[0]).To(ContainSubstring(pdEvenReplicasReason))
+			}
+		})
+	}
+}
+
+func TestPDScalerScaleOutStalePVC(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for _, policy := range []v1alpha1.StalePVCPolicy{v1alpha1.StalePVCPolicyReuse, v1alpha1.StalePVCPolicyBlock, v1alpha1.StalePVCPolicyWipe} {
+		t.Run(string(policy), func(t *testing.T) {
+			tc := newTidbClusterForPD()
+			normalPDMember(tc)
+			tc.Status.PD.Synced = true
+			tc.Spec.PD.Replicas = 7
+			tc.Spec.StalePVCPolicy = &policy
+			scaler, _, pvcIndexer, _, _ := newFakePDScaler()
+			jobIndexer := scaler.deps.KubeInformerFactory.Batch().V1().Jobs().Informer().GetIndexer()
+
+			// the PVC of test-pd-5 is marked as defer deleting by a previous scale-in
+			pvc := &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "pd-test-pd-5",
+					Namespace:   tc.GetNamespace(),
+					Labels:      label.New().Instance(tc.GetInstanceName()).Labels(),
+					Annotations: map[string]string{label.AnnPVCDeferDeleting: time.Now().Format(time.RFC3339)},
+				},
+			}
+			pvc.Labels[label.AnnPodNameKey] = "test-pd-5"
+			pvcIndexer.Add(pvc)
+			_, err := scaler.deps.KubeClientset.CoreV1().PersistentVolumeClaims(pvc.Namespace).Create(context.TODO(), pvc, metav1.CreateOptions{})
+			g.Expect(err).NotTo(HaveOccurred())
+
+			scaleOut := func() (int32, error) {
+				oldSet := newStatefulSetForPDScale()
+				newSet := oldSet.DeepCopy()
+				newSet.Spec.Replicas = pointer.Int32Ptr(7)
+				err := scaler.ScaleOut(tc, oldSet, newSet)
+				return *newSet.Spec.Replicas, err
+			}
+
+			replicas, err := scaleOut()
+			switch policy {
+			case v1alpha1.StalePVCPolicyReuse:
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(replicas).To(Equal(int32(6)))
+				_, exists, err := pvcIndexer.GetByKey(tc.GetNamespace() + "/pd-test-pd-5")
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(exists).To(BeFalse())
+			case v1alpha1.StalePVCPolicyBlock:
+				g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+				g.Expect(replicas).To(Equal(int32(5)))
+				cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterStalePVC)
+				g.Expect(cond).NotTo(BeNil())
+				g.Expect(cond.Status).To(Equal(corev1.ConditionTrue))
+				g.Expect(cond.Message).To(ContainSubstring("pd-test-pd-5"))
+
+				// the PVC is deleted by the user
+				pvcIndexer.Delete(pvc)
+				replicas, err = scaleOut()
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(replicas).To(Equal(int32(6)))
+				cond = utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterStalePVC)
+				g.Expect(cond.Status).To(Equal(corev1.ConditionFalse))
+			case v1alpha1.StalePVCPolicyWipe:
+				g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+				g.Expect(replicas).To(Equal(int32(5)))
+				obj, exists, err := jobIndexer.GetByKey(tc.GetNamespace() + "/pd-test-pd-5-wipe")
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(exists).To(BeTrue())
+				job := obj.(*batchv1.Job)
+				g.Expect(job.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName).To(Equal("pd-test-pd-5"))
+
+				// the volume is wiped
+				job = job.DeepCopy()
+				job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+				jobIndexer.Update(job)
+				replicas, err = scaleOut()
+				g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+				g.Expect(replicas).To(Equal(int32(5)))
+
+				// the PVC is kept for the new member
+				replicas, err = scaleOut()
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(replicas).To(Equal(int32(6)))
+				obj, exists, err = pvcIndexer.GetByKey(tc.GetNamespace() + "/pd-test-pd-5")
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(exists).To(BeTrue())
+				g.Expect(obj.(*corev1.PersistentVolumeClaim).Annotations).NotTo(HaveKey(label.AnnPVCDeferDeleting))
+			}
+		})
+	}
+}
+
 func TestPDScalerScaleIn(t *testing.T) {
 	g := NewGomegaWithT(t)
 	type testcase struct {
@@ -208,6 +394,7 @@ func TestPDScalerScaleIn(t *testing.T) {
 		err              bool
 		changed          bool
 		isLeader         bool
+		breakQuorum      bool
 	}
 
 	testFn := func(test testcase, t *testing.T) {
@@ -291,6 +478,13 @@ func TestPDScalerScaleIn(t *testing.T) {
 		}
 
 		tc.Status.PD.Synced = !test.statusSyncFailed
+		if test.breakQuorum {
+			tc.Status.PD.Members = map[string]v1alpha1.PDMember{}
+			for i := int32(0); i < 5; i++ {
+				name := PdName(tc.GetName(), i, tc.Namespace, tc.Spec.ClusterDomain)
+				tc.Status.PD.Members[name] = v1alpha1.PDMember{Name: name, Health: i != 1 && i != 2}
+			}
+		}
 
 		err := scaler.ScaleIn(tc, oldSet, newSet)
 		if test.err {
@@ -372,6 +566,18 @@ func TestPDScalerScaleIn(t *testing.T) {
 			changed:          false,
 			isLeader:         false,
 		},
+		{
+			name:             "scale in would break pd quorum",
+			pdUpgrading:      false,
+			hasPVC:           true,
+			pvcUpdateErr:     false,
+			deleteMemberErr:  false,
+			statusSyncFailed: false,
+			err:              true,
+			changed:          false,
+			isLeader:         false,
+			breakQuorum:      true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -391,6 +597,7 @@ func TestPDScalerScaleInBlockByOtherComponents(t *testing.T) {
 		tiflash bool
 		ticdc   bool
 		pump    bool
+		reason  string
 	}
 
 	testFn := func(test testcase, t *testing.T) {
@@ -452,10 +659,15 @@ func TestPDScalerScaleInBlockByOtherComponents(t *testing.T) {
 		}
 
 		result := scaler.preCheckUpMembers(tc, "pd-1")
+		cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterScaleBlocked)
 		if test.tikv || test.tidb || test.tiflash || test.ticdc || test.pump {
 			g.Expect(result).To(BeFalse())
+			g.Expect(cond).NotTo(BeNil())
+			g.Expect(cond.Status).To(Equal(corev1.ConditionTrue))
+			g.Expect(cond.Reason).To(Equal(test.reason))
 		} else {
 			g.Expect(result).To(BeTrue())
+			g.Expect(cond).To(BeNil())
 		}
 	}
 
@@ -467,6 +679,7 @@ func TestPDScalerScaleInBlockByOtherComponents(t *testing.T) {
 			tiflash: false,
 			ticdc:   false,
 			pump:    false,
+			reason:  "PDInUseByTiKV",
 		},
 		{
 			name:    "tidb on",
@@ -475,6 +688,7 @@ func TestPDScalerScaleInBlockByOtherComponents(t *testing.T) {
 			tiflash: false,
 			ticdc:   false,
 			pump:    false,
+			reason:  "PDInUseByTiDB",
 		},
 		{
 			name:    "tiflash on",
@@ -483,6 +697,7 @@ func TestPDScalerScaleInBlockByOtherComponents(t *testing.T) {
 			tiflash: true,
 			ticdc:   false,
 			pump:    false,
+			reason:  "PDInUseByTiFlash",
 		},
 		{
 			name:    "ticdc on",
@@ -491,6 +706,7 @@ func TestPDScalerScaleInBlockByOtherComponents(t *testing.T) {
 			tiflash: false,
 			ticdc:   true,
 			pump:    false,
+			reason:  "PDInUseByTiCDC",
 		},
 		{
 			name:    "pump on",
@@ -499,6 +715,16 @@ func TestPDScalerScaleInBlockByOtherComponents(t *testing.T) {
 			tiflash: false,
 			ticdc:   false,
 			pump:    true,
+			reason:  "PDInUseByPump",
+		},
+		{
+			name:    "tikv and tidb on",
+			tikv:    true,
+			tidb:    true,
+			tiflash: false,
+			ticdc:   false,
+			pump:    false,
+			reason:  "PDInUseByTiKVAndTiDB",
 		},
 		{
 			name:    "all zero",
@@ -517,6 +743,338 @@ func TestPDScalerScaleInBlockByOtherComponents(t *testing.T) {
 	}
 }
 
+func TestPDScalerScaleInDefersLeader(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	enabled := features.DefaultFeatureGate.Enabled(features.AdvancedStatefulSet)
+	features.DefaultFeatureGate.Set("AdvancedStatefulSet=true")
+	defer features.DefaultFeatureGate.Set(fmt.Sprintf("AdvancedStatefulSet=%t", enabled))
+
+	tc := newTidbClusterForPD()
+	tc.Status.PD.Synced = true
+	scaler, pdControl, pvcIndexer, podIndexer, _ := newFakePDScaler()
+	for i := int32(0); i < 5; i++ {
+		pvc := _newPVCForStatefulSet(newStatefulSetForPDScale(), v1alpha1.PDMemberType, tc.GetName(), i)
+		pvcIndexer.Add(pvc)
+		podIndexer.Add(&corev1.Pod{
+			TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      PdPodName(tc.GetName(), i),
+				Namespace: corev1.NamespaceDefault,
+			},
+			Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{{
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.Name},
+					},
+				}},
+			},
+		})
+	}
+
+	leaderName := PdPodName(tc.GetName(), 4)
+	var deleted []string
+	pdClient := controller.NewFakePDClient(pdControl, tc)
+	pdClient.AddReaction(pdapi.GetPDLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+		return &pdpb.Member{Name: leaderName}, nil
+	})
+	pdClient.AddReaction(pdapi.TransferPDLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+		leaderName = action.Name
+		return nil, nil
+	})
+	pdClient.AddReaction(pdapi.DeleteMemberActionType, func(action *pdapi.Action) (interface{}, error) {
+		deleted = append(deleted, action.Name)
+		return nil, nil
+	})
+
+	oldSet := newStatefulSetForPDScale()
+	desiredSet := oldSet.DeepCopy()
+	desiredSet.Spec.Replicas = pointer.Int32Ptr(2)
+	for i := 0; i < 3; i++ {
+		newSet := desiredSet.DeepCopy()
+		err := scaler.ScaleIn(tc, oldSet, newSet)
+		g.Expect(err).NotTo(HaveOccurred())
+		oldSet = newSet
+	}
+
+	memberName := func(ordinal int32) string {
+		return PdName(tc.GetName(), ordinal, tc.Namespace, tc.Spec.ClusterDomain)
+	}
+	g.Expect(deleted).To(Equal([]string{memberName(3), memberName(2), memberName(4)}))
+	g.Expect(leaderName).NotTo(Equal(PdPodName(tc.GetName(), 4)))
+	g.Expect(helper.GetPodOrdinals(*oldSet.Spec.Replicas, oldSet).List()).To(Equal([]int32{0, 1}))
+}
+
+func TestPDScalerScaleInWaitsForLeaderTransfer(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	defer func(interval time.Duration) {
+		pdLeaderTransferPollInterval = interval
+	}(pdLeaderTransferPollInterval)
+	pdLeaderTransferPollInterval = 10 * time.Millisecond
+
+	type testcase struct {
+		name string
+		// the number of times the old leader is still reported after the transfer
+		transferDelay int
+		expectDeleted bool
+	}
+
+	testFn := func(test testcase, t *testing.T) {
+		tc := newTidbClusterForPD()
+		tc.Status.PD.Synced = true
+		tc.Spec.PD.ScaleInLeaderTransferTimeout = pointer.StringPtr("200ms")
+		scaler, pdControl, pvcIndexer, podIndexer, _ := newFakePDScaler()
+
+		oldSet := newStatefulSetForPDScale()
+		pvc := _newPVCForStatefulSet(oldSet, v1alpha1.PDMemberType, tc.GetName(), 4)
+		pvcIndexer.Add(pvc)
+		podIndexer.Add(&corev1.Pod{
+			TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      PdPodName(tc.GetName(), 4),
+				Namespace: corev1.NamespaceDefault,
+			},
+			Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{{
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.Name},
+					},
+				}},
+			},
+		})
+
+		leaderName := PdPodName(tc.GetName(), 4)
+		transferring := false
+		delay := test.transferDelay
+		var deleted []string
+		pdClient := controller.NewFakePDClient(pdControl, tc)
+		pdClient.AddReaction(pdapi.GetPDLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+			if transferring {
+				if delay == 0 {
+					leaderName = PdPodName(tc.GetName(), 0)
+				}
+				delay--
+			}
+			return &pdpb.Member{Name: leaderName}, nil
+		})
+		pdClient.AddReaction(pdapi.TransferPDLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+			transferring = true
+			return nil, nil
+		})
+		pdClient.AddReaction(pdapi.DeleteMemberActionType, func(action *pdapi.Action) (interface{}, error) {
+			deleted = append(deleted, action.Name)
+			return nil, nil
+		})
+
+		newSet := oldSet.DeepCopy()
+		newSet.Spec.Replicas = pointer.Int32Ptr(4)
+		err := scaler.ScaleIn(tc, oldSet, newSet)
+		if test.expectDeleted {
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(deleted).To(Equal([]string{PdName(tc.GetName(), 4, tc.Namespace, tc.Spec.ClusterDomain)}))
+			g.Expect(*newSet.Spec.Replicas).To(Equal(int32(4)))
+		} else {
+			g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+			g.Expect(deleted).To(BeEmpty())
+			g.Expect(*newSet.Spec.Replicas).To(Equal(int32(5)))
+		}
+	}
+
+	tests := []testcase{
+		{name: "leader is transferred in time", transferDelay: 3, expectDeleted: true},
+		{name: "leader is not transferred in time", transferDelay: 1000, expectDeleted: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFn(tt, t)
+		})
+	}
+}
+
+func TestPDLeaderTransferPriorityTarget(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPD()
+	normalPDMember(tc)
+	tc.Status.PD.Members["test-pd-2"] = v1alpha1.PDMember{Health: false}
+	tc.Status.PD.Members["test-pd-3"] = v1alpha1.PDMember{Health: true, IsLearner: true}
+	tc.Status.Topology = &v1alpha1.ClusterTopology{
+		PD: []v1alpha1.PodTopology{
+			{PodName: "test-pd-0", Zone: "zone-a"},
+			{PodName: "test-pd-1", Zone: "zone-b"},
+			{PodName: "test-pd-2", Zone: "zone-c"},
+			{PodName: "test-pd-3", Zone: "zone-c"},
+			{PodName: "test-pd-4", Zone: "zone-b"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		priority []string
+		excluded sets.String
+		expected string
+	}{
+		{name: "no priority", expected: ""},
+		{name: "pod name", priority: []string{"test-pd-4", "zone-a"}, expected: "test-pd-4"},
+		{name: "zone", priority: []string{"zone-b"}, expected: "test-pd-1"},
+		{name: "excluded member", priority: []string{"zone-b"}, excluded: sets.NewString("test-pd-1"), expected: "test-pd-4"},
+		{name: "unhealthy and learner members", priority: []string{"zone-c", "test-pd-0"}, expected: "test-pd-0"},
+		{name: "no member matches", priority: []string{"zone-d"}, expected: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tc.Spec.PD.LeaderTransferPriority = test.priority
+			excluded := test.excluded
+			if excluded == nil {
+				excluded = sets.NewString()
+			}
+			g.Expect(pdLeaderTransferPriorityTarget(tc, excluded)).To(Equal(test.expected))
+		})
+	}
+}
+
+func TestPDScalerScaleInTransfersLeaderByPriority(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPD()
+	tc.Status.PD.Synced = true
+	normalPDMember(tc)
+	tc.Spec.PD.LeaderTransferPriority = []string{"test-pd-3", "test-pd-2"}
+	scaler, pdControl, pvcIndexer, podIndexer, _ := newFakePDScaler()
+
+	oldSet := newStatefulSetForPDScale()
+	pvc := _newPVCForStatefulSet(oldSet, v1alpha1.PDMemberType, tc.GetName(), 4)
+	pvcIndexer.Add(pvc)
+	podIndexer.Add(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      PdPodName(tc.GetName(), 4),
+			Namespace: corev1.NamespaceDefault,
+		},
+	})
+
+	var transferred []string
+	pdClient := controller.NewFakePDClient(pdControl, tc)
+	pdClient.AddReaction(pdapi.GetPDLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+		return &pdpb.Member{Name: PdPodName(tc.GetName(), 4)}, nil
+	})
+	pdClient.AddReaction(pdapi.TransferPDLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+		transferred = append(transferred, action.Name)
+		return nil, nil
+	})
+
+	// test-pd-3 is scaled in as well, so the leader is transferred to test-pd-2
+	newSet := oldSet.DeepCopy()
+	newSet.Spec.Replicas = pointer.Int32Ptr(3)
+	err := scaler.ScaleIn(tc, oldSet, newSet)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(transferred).To(Equal([]string{"test-pd-2"}))
+	g.Expect(*newSet.Spec.Replicas).To(Equal(int32(5)))
+}
+
+func TestPDScalerScaleInMemberMismatch(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tcName := newTidbClusterForPD().GetName()
+	podName := func(ordinal int32) string {
+		return PdPodName(tcName, ordinal)
+	}
+	// the member name of a pod in a cluster with a cluster domain
+	fqdn := func(ordinal int32) string {
+		return PdName(tcName, ordinal, corev1.NamespaceDefault, "cluster.local")
+	}
+
+	type testcase struct {
+		name    string
+		members []string
+		abort   bool
+	}
+
+	tests := []testcase{
+		{
+			name:    "members match pods",
+			members: []string{podName(0), podName(1), podName(2), fqdn(3), podName(4)},
+		},
+		{
+			name:    "member of the pod is already deleted",
+			members: []string{podName(0), podName(1), podName(2), podName(3)},
+		},
+		{
+			name:    "member of the pod is unknown",
+			members: []string{podName(0), podName(1), podName(2), "stale-pd"},
+			abort:   true,
+		},
+		{
+			name:    "member of a removed pod",
+			members: []string{podName(0), podName(1), podName(2), podName(5)},
+			abort:   true,
+		},
+		{
+			name:    "two members map to the same pod",
+			members: []string{podName(0), podName(1), fqdn(1), podName(2), podName(4)},
+			abort:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.name)
+
+		tc := newTidbClusterForPD()
+		tc.Status.PD.Synced = true
+		tc.Status.PD.Members = map[string]v1alpha1.PDMember{}
+		for _, name := range test.members {
+			tc.Status.PD.Members[name] = v1alpha1.PDMember{Name: name, Health: true}
+		}
+
+		oldSet := newStatefulSetForPDScale()
+		newSet := oldSet.DeepCopy()
+		newSet.Spec.Replicas = pointer.Int32Ptr(4)
+
+		scaler, pdControl, pvcIndexer, podIndexer, _ := newFakePDScaler()
+		pvc := newScaleInPVCForStatefulSet(oldSet, v1alpha1.PDMemberType, tc.Name)
+		pvcIndexer.Add(pvc)
+		podIndexer.Add(&corev1.Pod{
+			TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      podName(4),
+				Namespace: corev1.NamespaceDefault,
+			},
+			Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{{
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.Name},
+					},
+				}},
+			},
+		})
+
+		var deleted []string
+		pdClient := controller.NewFakePDClient(pdControl, tc)
+		pdClient.AddReaction(pdapi.GetPDLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+			return &pdpb.Member{Name: podName(0)}, nil
+		})
+		pdClient.AddReaction(pdapi.DeleteMemberActionType, func(action *pdapi.Action) (interface{}, error) {
+			deleted = append(deleted, action.Name)
+			return nil, nil
+		})
+
+		err := scaler.ScaleIn(tc, oldSet, newSet)
+		cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterPDMemberMismatch)
+		g.Expect(cond).NotTo(BeNil())
+		if test.abort {
+			g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+			g.Expect(cond.Status).To(Equal(corev1.ConditionTrue))
+			g.Expect(deleted).To(BeEmpty())
+			g.Expect(*newSet.Spec.Replicas).To(Equal(int32(5)))
+		} else {
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cond.Status).To(Equal(corev1.ConditionFalse))
+			g.Expect(deleted).To(Equal([]string{podName(4)}))
+			g.Expect(*newSet.Spec.Replicas).To(Equal(int32(4)))
+		}
+	}
+}
+
 func newFakePDScaler() (*pdScaler, *pdapi.FakePDControl, cache.Indexer, cache.Indexer, *controller.FakePVCControl) {
 	fakeDeps := controller.NewFakeDependencies()
 	pdScaler := &pdScaler{generalScaler: generalScaler{deps: fakeDeps}}
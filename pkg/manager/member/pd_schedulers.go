@@ -0,0 +1,132 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog"
+)
+
+// pdSchedulersSyncFailedReason is the reason of the Events of the failures to
+// sync the schedulers of PD
+const pdSchedulersSyncFailedReason = "PDSchedulersSyncFailed"
+
+// syncPDSchedulers makes the schedulers of PD match `.spec.pd.schedulers`:
+//   - adds the schedulers missing in PD
+//   - updates the config items of the schedulers that differ from PD
+//   - removes the schedulers not listed, except the evict leader schedulers
+//     which are added and removed by the operator in upgrading
+//
+// The schedulers are not essential to the sync of PD, so the failures are
+// recorded as Events and the schedulers are synced again in the next round.
+func syncPDSchedulers(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	if tc.Spec.PD == nil || tc.Spec.PD.Schedulers == nil {
+		return
+	}
+	if tc.Spec.Paused {
+		klog.V(4).Infof("tidb cluster %s/%s is paused, skip syncing pd schedulers", ns, tcName)
+		return
+	}
+	if !tc.Status.PD.Synced {
+		klog.V(4).Infof("tidb cluster %s/%s pd status is not synced, skip syncing pd schedulers", ns, tcName)
+		return
+	}
+
+	if err := updatePDSchedulers(deps, tc); err != nil {
+		klog.Warningf("pd: failed to sync schedulers of %s/%s, error: %v", ns, tcName, err)
+		deps.Recorder.Event(tc, corev1.EventTypeWarning, pdSchedulersSyncFailedReason, fmt.Sprintf("failed to sync pd schedulers: %v", err))
+	}
+}
+
+func updatePDSchedulers(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	pdCli := controller.GetPDClient(deps.PDControl, tc)
+	current, err := pdCli.GetSchedulers()
+	if err != nil {
+		return err
+	}
+	existing := sets.NewString(current...)
+
+	desired := sets.NewString()
+	for _, scheduler := range tc.Spec.PD.Schedulers {
+		desired.Insert(scheduler.Name)
+		if !existing.Has(scheduler.Name) {
+			if err := pdCli.AddScheduler(scheduler.Name); err != nil {
+				return err
+			}
+			klog.Infof("pd: add scheduler %s for %s/%s successfully", scheduler.Name, ns, tcName)
+		}
+		if scheduler.Config == nil || len(scheduler.Config.Inner()) == 0 {
+			continue
+		}
+		config, err := pdCli.GetSchedulerConfig(scheduler.Name)
+		if err != nil {
+			return err
+		}
+		if schedulerConfigEqual(scheduler.Config.Inner(), config) {
+			continue
+		}
+		if err := pdCli.SetSchedulerConfig(scheduler.Name, scheduler.Config.Inner()); err != nil {
+			return err
+		}
+		klog.Infof("pd: update config of scheduler %s for %s/%s successfully", scheduler.Name, ns, tcName)
+	}
+
+	for _, name := range current {
+		if desired.Has(name) || pdapi.IsEvictLeaderScheduler(name) {
+			continue
+		}
+		if err := pdCli.RemoveScheduler(name); err != nil {
+			return err
+		}
+		klog.Infof("pd: remove scheduler %s for %s/%s successfully", name, ns, tcName)
+	}
+	return nil
+}
+
+// schedulerConfigEqual returns whether the config items in desired have the
+// same values in current. The values are compared in their JSON form, as the
+// numbers decoded from PD are float64 while the ones in spec may be int64.
+func schedulerConfigEqual(desired, current map[string]interface{}) bool {
+	for k, v := range desired {
+		cv, ok := current[k]
+		if !ok {
+			return false
+		}
+		d, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+		c, err := json.Marshal(cv)
+		if err != nil {
+			return false
+		}
+		if string(d) != string(c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/apis/util/config"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestSyncPDSchedulers(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name          string
+		schedulers    []v1alpha1.PDScheduler
+		synced        bool
+		current       []string
+		currentConfig map[string]interface{}
+		addErr        bool
+		expectFailed  bool
+		expectAdded   []string
+		expectRemoved []string
+		expectUpdated []string
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+		fakeDeps := controller.NewFakeDependencies()
+		recorder := record.NewFakeRecorder(10)
+		fakeDeps.Recorder = recorder
+		pdControl := fakeDeps.PDControl.(*pdapi.FakePDControl)
+
+		tc := newTidbClusterForPD()
+		tc.Spec.PD.Schedulers = test.schedulers
+		tc.Status.PD.Synced = test.synced
+
+		pdClient := controller.NewFakePDClient(pdControl, tc)
+		pdClient.AddReaction(pdapi.GetSchedulersActionType, func(action *pdapi.Action) (interface{}, error) {
+			return test.current, nil
+		})
+		pdClient.AddReaction(pdapi.GetSchedulerConfigActionType, func(action *pdapi.Action) (interface{}, error) {
+			return test.currentConfig, nil
+		})
+		var added, removed, updated []string
+		pdClient.AddReaction(pdapi.AddSchedulerActionType, func(action *pdapi.Action) (interface{}, error) {
+			if test.addErr {
+				return nil, fmt.Errorf("failed to add scheduler")
+			}
+			added = append(added, action.Name)
+			return nil, nil
+		})
+		pdClient.AddReaction(pdapi.RemoveSchedulerActionType, func(action *pdapi.Action) (interface{}, error) {
+			removed = append(removed, action.Name)
+			return nil, nil
+		})
+		pdClient.AddReaction(pdapi.SetSchedulerConfigActionType, func(action *pdapi.Action) (interface{}, error) {
+			updated = append(updated, action.Name)
+			return nil, nil
+		})
+
+		syncPDSchedulers(fakeDeps, tc)
+		if test.expectFailed {
+			g.Expect(recorder.Events).To(HaveLen(1))
+		} else {
+			g.Expect(recorder.Events).To(BeEmpty())
+		}
+		g.Expect(added).To(ConsistOf(test.expectAdded))
+		g.Expect(removed).To(ConsistOf(test.expectRemoved))
+		g.Expect(updated).To(ConsistOf(test.expectUpdated))
+	}
+
+	tests := []*testcase{
+		{
+			name:       "schedulers are not managed",
+			schedulers: nil,
+			synced:     true,
+			current:    []string{"balance-region-scheduler"},
+		},
+		{
+			name: "pd is not synced",
+			schedulers: []v1alpha1.PDScheduler{
+				{Name: "balance-region-scheduler"},
+			},
+			synced:  false,
+			current: []string{"label-scheduler"},
+		},
+		{
+			name: "add missing and remove unlisted schedulers",
+			schedulers: []v1alpha1.PDScheduler{
+				{Name: "balance-region-scheduler"},
+				{Name: "balance-leader-scheduler"},
+			},
+			synced:        true,
+			current:       []string{"balance-region-scheduler", "label-scheduler", "evict-leader-scheduler-1"},
+			expectAdded:   []string{"balance-leader-scheduler"},
+			expectRemoved: []string{"label-scheduler"},
+		},
+		{
+			name: "update the config that differs",
+			schedulers: []v1alpha1.PDScheduler{
+				{
+					Name:   "balance-hot-region-scheduler",
+					Config: config.New(map[string]interface{}{"min-hot-byte-rate": int64(200)}),
+				},
+			},
+			synced:        true,
+			current:       []string{"balance-hot-region-scheduler"},
+			currentConfig: map[string]interface{}{"min-hot-byte-rate": float64(100), "min-hot-key-rate": float64(10)},
+			expectUpdated: []string{"balance-hot-region-scheduler"},
+		},
+		{
+			name: "keep the config that is the same",
+			schedulers: []v1alpha1.PDScheduler{
+				{
+					Name:   "balance-hot-region-scheduler",
+					Config: config.New(map[string]interface{}{"min-hot-byte-rate": int64(100)}),
+				},
+			},
+			synced:        true,
+			current:       []string{"balance-hot-region-scheduler"},
+			currentConfig: map[string]interface{}{"min-hot-byte-rate": float64(100), "min-hot-key-rate": float64(10)},
+		},
+		{
+			name: "failed to add scheduler",
+			schedulers: []v1alpha1.PDScheduler{
+				{Name: "balance-leader-scheduler"},
+			},
+			synced:       true,
+			current:      []string{"label-scheduler"},
+			addErr:       true,
+			expectFailed: true,
+		},
+	}
+
+	for _, test := range tests {
+		testFn(test, t)
+	}
+}
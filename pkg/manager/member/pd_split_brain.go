@@ -0,0 +1,141 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+const (
+	// pdSplitBrainReason is the reason of the PDSplitBrain condition when
+	// the PD members report divergent views of the cluster
+	pdSplitBrainReason = "DivergentMemberViews"
+	// pdSplitBrainSuspectedReason is the reason of the PDSplitBrain condition
+	// when the PD members report divergent views for less than the grace period
+	pdSplitBrainSuspectedReason = "DivergentMemberViewsSuspected"
+	// pdConsistentReason is the reason of the PDSplitBrain condition when
+	// all the reachable PD members report the same view of the cluster
+	pdConsistentReason = "ConsistentMemberViews"
+
+	// pdSplitBrainGracePeriod is how long the PD members must keep reporting
+	// divergent views before the split brain is reported, so that the views
+	// differing for a while in a normal leader election are not reported
+	pdSplitBrainGracePeriod = time.Minute
+)
+
+// pdMemberView returns the view of the PD cluster seen by a PD member, two
+// members in the same cluster agree on the cluster ID, the leader and the members
+func pdMemberView(info *pdapi.MembersInfo) string {
+	var clusterID uint64
+	if info.Header != nil {
+		clusterID = info.Header.ClusterId
+	}
+	var leader string
+	if info.Leader != nil {
+		leader = info.Leader.GetName()
+	}
+	names := make([]string, 0, len(info.Members))
+	for _, member := range info.Members {
+		names = append(names, member.GetName())
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("cluster %d, leader %q, members [%s]", clusterID, leader, strings.Join(names, " "))
+}
+
+// syncPDSplitBrainCondition cross-checks the member lists reported by every
+// healthy PD member and sets the PDSplitBrain condition if they diverge, e.g.
+// a network partition leaves two groups of PD each serving as the cluster.
+// The condition is Unknown while the views diverge for less than
+// pdSplitBrainGracePeriod, and true once they keep diverging.
+// Scaling and PD failover are halted while the condition is true.
+func syncPDSplitBrainCondition(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	members := make([]v1alpha1.PDMember, 0, len(tc.Status.PD.Members)+len(tc.Status.PD.PeerMembers))
+	for _, member := range tc.Status.PD.Members {
+		members = append(members, member)
+	}
+	for _, member := range tc.Status.PD.PeerMembers {
+		members = append(members, member)
+	}
+
+	// view -> names of the members that report the view
+	views := map[string][]string{}
+	for _, member := range members {
+		if !member.Health || member.ClientURL == "" {
+			continue
+		}
+		pdClient := deps.PDControl.GetPeerPDClient(pdapi.Namespace(ns), tcName, tc.IsTLSClusterEnabled(), member.ClientURL, member.Name)
+		info, err := pdClient.GetMembers()
+		if err != nil {
+			klog.V(4).Infof("pd: failed to get members from %s of cluster %s/%s, error: %v", member.Name, ns, tcName, err)
+			continue
+		}
+		view := pdMemberView(info)
+		views[view] = append(views[view], member.Name)
+	}
+	if len(views) == 0 {
+		return
+	}
+
+	if len(views) > 1 {
+		groups := make([]string, 0, len(views))
+		for view, names := range views {
+			sort.Strings(names)
+			groups = append(groups, fmt.Sprintf("%s see %s", strings.Join(names, ", "), view))
+		}
+		sort.Strings(groups)
+		msg := fmt.Sprintf("PD members report divergent views: %s", strings.Join(groups, "; "))
+		cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterPDSplitBrain)
+		if cond == nil || cond.Status == corev1.ConditionFalse {
+			klog.Warningf("pd: members of cluster %s/%s report divergent views, wait %v before reporting split brain, %s", ns, tcName, pdSplitBrainGracePeriod, msg)
+			utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+				v1alpha1.TidbClusterPDSplitBrain, corev1.ConditionUnknown, pdSplitBrainSuspectedReason, msg))
+			return
+		}
+		if cond.Status == corev1.ConditionUnknown && time.Since(cond.LastTransitionTime.Time) < pdSplitBrainGracePeriod {
+			return
+		}
+		klog.Errorf("pd: split brain detected in cluster %s/%s, %s", ns, tcName, msg)
+		utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+			v1alpha1.TidbClusterPDSplitBrain, corev1.ConditionTrue, pdSplitBrainReason, msg))
+		return
+	}
+	utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+		v1alpha1.TidbClusterPDSplitBrain, corev1.ConditionFalse, pdConsistentReason, "all the reachable PD members report the same view"))
+}
+
+// checkPDSplitBrain returns a requeue error if meta is a TidbCluster whose PD
+// is split, so that the caller does not scale the cluster until it is resolved
+func checkPDSplitBrain(meta metav1.Object, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	tc, ok := meta.(*v1alpha1.TidbCluster)
+	if !ok || !tc.PDSplitBrain() {
+		return nil
+	}
+	resetReplicas(newSet, oldSet)
+	return controller.RequeueErrorf("tidbcluster: [%s/%s]'s pd is split, skip scaling statefulset %s", tc.GetNamespace(), tc.GetName(), oldSet.GetName())
+}
@@ -0,0 +1,188 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	perrors "github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func newMembersInfo(clusterID uint64, leader string, names ...string) *pdapi.MembersInfo {
+	info := &pdapi.MembersInfo{
+		Header: &pdpb.ResponseHeader{ClusterId: clusterID},
+		Leader: &pdpb.Member{Name: leader},
+	}
+	for _, name := range names {
+		info.Members = append(info.Members, &pdpb.Member{Name: name})
+	}
+	return info
+}
+
+func TestSyncPDSplitBrainCondition(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name         string
+		views        map[string]*pdapi.MembersInfo
+		unhealthy    string
+		condStatus   corev1.ConditionStatus
+		condSince    time.Duration
+		expectStatus corev1.ConditionStatus
+		expectReason string
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+		fakeDeps := controller.NewFakeDependencies()
+		pdControl := fakeDeps.PDControl.(*pdapi.FakePDControl)
+
+		tc := newTidbClusterForPD()
+		if test.condStatus != "" {
+			cond := utiltidbcluster.NewTidbClusterCondition(v1alpha1.TidbClusterPDSplitBrain, test.condStatus, pdSplitBrainSuspectedReason, "")
+			cond.LastTransitionTime = metav1.NewTime(time.Now().Add(-test.condSince))
+			utiltidbcluster.SetTidbClusterCondition(&tc.Status, *cond)
+		}
+		tc.Status.PD.Members = map[string]v1alpha1.PDMember{}
+		for name, info := range test.views {
+			tc.Status.PD.Members[name] = v1alpha1.PDMember{
+				Name:      name,
+				ClientURL: fmt.Sprintf("http://%s.test-pd-peer.default.svc:2379", name),
+				Health:    name != test.unhealthy,
+			}
+			info := info
+			pdClient := controller.NewFakePDClientWithAddress(pdControl, name)
+			pdClient.AddReaction(pdapi.GetMembersActionType, func(action *pdapi.Action) (interface{}, error) {
+				return info, nil
+			})
+		}
+
+		syncPDSplitBrainCondition(fakeDeps, tc)
+
+		cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterPDSplitBrain)
+		g.Expect(cond).NotTo(BeNil())
+		g.Expect(cond.Status).To(Equal(test.expectStatus))
+		g.Expect(cond.Reason).To(Equal(test.expectReason))
+		g.Expect(tc.PDSplitBrain()).To(Equal(test.expectStatus == corev1.ConditionTrue))
+	}
+
+	tests := []*testcase{
+		{
+			name: "all members report the same view",
+			views: map[string]*pdapi.MembersInfo{
+				"test-pd-0": newMembersInfo(1, "test-pd-0", "test-pd-0", "test-pd-1", "test-pd-2"),
+				"test-pd-1": newMembersInfo(1, "test-pd-0", "test-pd-2", "test-pd-1", "test-pd-0"),
+				"test-pd-2": newMembersInfo(1, "test-pd-0", "test-pd-0", "test-pd-1", "test-pd-2"),
+			},
+			expectStatus: corev1.ConditionFalse,
+			expectReason: pdConsistentReason,
+		},
+		{
+			name: "members report different leaders",
+			views: map[string]*pdapi.MembersInfo{
+				"test-pd-0": newMembersInfo(1, "test-pd-0", "test-pd-0", "test-pd-1", "test-pd-2"),
+				"test-pd-1": newMembersInfo(1, "test-pd-0", "test-pd-0", "test-pd-1", "test-pd-2"),
+				"test-pd-2": newMembersInfo(1, "test-pd-2", "test-pd-2"),
+			},
+			expectStatus: corev1.ConditionUnknown,
+			expectReason: pdSplitBrainSuspectedReason,
+		},
+		{
+			name: "members report different clusters",
+			views: map[string]*pdapi.MembersInfo{
+				"test-pd-0": newMembersInfo(1, "test-pd-0", "test-pd-0", "test-pd-1"),
+				"test-pd-1": newMembersInfo(2, "test-pd-0", "test-pd-0", "test-pd-1"),
+			},
+			expectStatus: corev1.ConditionUnknown,
+			expectReason: pdSplitBrainSuspectedReason,
+		},
+		{
+			name: "divergent views within the grace period are not reported",
+			views: map[string]*pdapi.MembersInfo{
+				"test-pd-0": newMembersInfo(1, "test-pd-0", "test-pd-0", "test-pd-1"),
+				"test-pd-1": newMembersInfo(1, "test-pd-1", "test-pd-0", "test-pd-1"),
+			},
+			condStatus:   corev1.ConditionUnknown,
+			condSince:    pdSplitBrainGracePeriod / 2,
+			expectStatus: corev1.ConditionUnknown,
+			expectReason: pdSplitBrainSuspectedReason,
+		},
+		{
+			name: "divergent views persisting across the grace period are reported",
+			views: map[string]*pdapi.MembersInfo{
+				"test-pd-0": newMembersInfo(1, "test-pd-0", "test-pd-0", "test-pd-1"),
+				"test-pd-1": newMembersInfo(1, "test-pd-1", "test-pd-0", "test-pd-1"),
+			},
+			condStatus:   corev1.ConditionUnknown,
+			condSince:    2 * pdSplitBrainGracePeriod,
+			expectStatus: corev1.ConditionTrue,
+			expectReason: pdSplitBrainReason,
+		},
+		{
+			name: "divergent views are resolved within the grace period",
+			views: map[string]*pdapi.MembersInfo{
+				"test-pd-0": newMembersInfo(1, "test-pd-1", "test-pd-0", "test-pd-1"),
+				"test-pd-1": newMembersInfo(1, "test-pd-1", "test-pd-0", "test-pd-1"),
+			},
+			condStatus:   corev1.ConditionUnknown,
+			condSince:    pdSplitBrainGracePeriod / 2,
+			expectStatus: corev1.ConditionFalse,
+			expectReason: pdConsistentReason,
+		},
+		{
+			name: "divergent member is not healthy",
+			views: map[string]*pdapi.MembersInfo{
+				"test-pd-0": newMembersInfo(1, "test-pd-0", "test-pd-0", "test-pd-1", "test-pd-2"),
+				"test-pd-1": newMembersInfo(1, "test-pd-0", "test-pd-0", "test-pd-1", "test-pd-2"),
+				"test-pd-2": newMembersInfo(1, "test-pd-2", "test-pd-2"),
+			},
+			unhealthy:    "test-pd-2",
+			expectStatus: corev1.ConditionFalse,
+			expectReason: pdConsistentReason,
+		},
+	}
+
+	for _, test := range tests {
+		testFn(test, t)
+	}
+}
+
+func TestCheckPDSplitBrain(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPD()
+	oldSet := newStatefulSetForPDScale()
+	newSet := oldSet.DeepCopy()
+	newSet.Spec.Replicas = pointer.Int32Ptr(*oldSet.Spec.Replicas + 1)
+
+	g.Expect(checkPDSplitBrain(tc, oldSet, newSet)).To(Succeed())
+	g.Expect(*newSet.Spec.Replicas).To(Equal(*oldSet.Spec.Replicas + 1))
+
+	utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+		v1alpha1.TidbClusterPDSplitBrain, corev1.ConditionTrue, pdSplitBrainReason, ""))
+	err := checkPDSplitBrain(tc, oldSet, newSet)
+	g.Expect(perrors.Find(err, controller.IsRequeueError)).NotTo(BeNil())
+	g.Expect(*newSet.Spec.Replicas).To(Equal(*oldSet.Spec.Replicas))
+}
@@ -20,6 +20,9 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog"
 )
 
@@ -55,12 +58,16 @@ func (u *pdUpgrader) gracefulUpgrade(tc *v1alpha1.TidbCluster, oldSet *apps.Stat
 		return nil
 	}
 
+	if kept, err := keepAbortedTemplate(tc, v1alpha1.PDMemberType, oldSet, newSet); err != nil || kept {
+		return err
+	}
 	tc.Status.PD.Phase = v1alpha1.UpgradePhase
 	if !templateEqual(newSet, oldSet) {
 		return nil
 	}
 
-	if tc.Status.PD.StatefulSet.UpdateRevision == tc.Status.PD.StatefulSet.CurrentRevision {
+	aborted := isUpgradeAborted(tc, v1alpha1.PDMemberType, tc.Status.PD.StatefulSet.UpdateRevision)
+	if tc.Status.PD.StatefulSet.UpdateRevision == tc.Status.PD.StatefulSet.CurrentRevision && !postUpgradeHooksPending(newSet) {
 		return nil
 	}
 
@@ -70,12 +77,19 @@ func (u *pdUpgrader) gracefulUpgrade(tc *v1alpha1.TidbCluster, oldSet *apps.Stat
 		// If we encounter this situation, we will let the native statefulset controller do the upgrade completely, which may be unsafe for upgrading pd.
 		// Therefore, in the production environment, we should try to avoid modifying the pd statefulset update strategy directly.
 		newSet.Spec.UpdateStrategy = oldSet.Spec.UpdateStrategy
+		setPostUpgradeHookPods(newSet, nil)
 		klog.Warningf("tidbcluster: [%s/%s] pd statefulset %s UpdateStrategy has been modified manually", ns, tcName, oldSet.GetName())
 		return nil
 	}
 
 	setUpgradePartition(newSet, *oldSet.Spec.UpdateStrategy.RollingUpdate.Partition)
+	if aborted {
+		klog.Infof("tidbcluster: [%s/%s]'s pd upgrade to revision %s is aborted", ns, tcName, tc.Status.PD.StatefulSet.UpdateRevision)
+		setPostUpgradeHookPods(newSet, nil)
+		return rollbackUpgradedPods(u.deps, tc, v1alpha1.PDMemberType, oldSet, newSet, tc.Status.PD.StatefulSet.UpdateRevision)
+	}
 	podOrdinals := helper.GetPodOrdinals(*oldSet.Spec.Replicas, oldSet).List()
+	var lastUpgradedPod *corev1.Pod
 	for _i := len(podOrdinals) - 1; _i >= 0; _i-- {
 		i := podOrdinals[_i]
 		podName := PdPodName(tcName, i)
@@ -91,39 +105,77 @@ func (u *pdUpgrader) gracefulUpgrade(tc *v1alpha1.TidbCluster, oldSet *apps.Stat
 
 		if revision == tc.Status.PD.StatefulSet.UpdateRevision {
 			if member, exist := tc.Status.PD.Members[PdName(tc.Name, i, tc.Namespace, tc.Spec.ClusterDomain)]; !exist || !member.Health {
+				if ok, err := abortUpgradeOnCrashLoop(u.deps, tc, v1alpha1.PDMemberType, pod, i, revision, newSet); err != nil || ok {
+					return err
+				}
+				if ok, err := rollbackUpgradeOnUnhealthy(u.deps, tc, v1alpha1.PDMemberType, pod, i, revision, newSet, memberUnhealthySince(member.LastTransitionTime, pod)); err != nil || ok {
+					return err
+				}
 				return controller.RequeueErrorf("tidbcluster: [%s/%s]'s pd upgraded pod: [%s] is not ready", ns, tcName, podName)
 			}
+			if err := runPostUpgradeHook(u.deps, tc, v1alpha1.PDMemberType, pod, newSet); err != nil {
+				return err
+			}
+			lastUpgradedPod = pod
 			continue
 		}
 
+		if upgradePaused(tc, v1alpha1.PDMemberType) || waitForMaintenanceWindow(tc, v1alpha1.PDMemberType, "upgrade") {
+			return nil
+		}
+		if _i == len(podOrdinals)-1 {
+			if err := checkUpgradePreChecks(u.deps, tc, v1alpha1.PDMemberType); err != nil {
+				return err
+			}
+		}
+		if err := checkUpgradeStabilization(u.deps, tc, tc.Spec.PD.UpgradeStabilizationGate, lastUpgradedPod); err != nil {
+			return err
+		}
+
 		if u.deps.CLIConfig.PodWebhookEnabled {
+			if err := runPreUpgradeHook(u.deps, tc, v1alpha1.PDMemberType, pod, newSet); err != nil {
+				return err
+			}
 			setUpgradePartition(newSet, i)
 			return nil
 		}
 
-		return u.upgradePDPod(tc, i, newSet)
+		return u.upgradePDPod(tc, pod, i, newSet)
 	}
 
 	return nil
 }
 
-func (u *pdUpgrader) upgradePDPod(tc *v1alpha1.TidbCluster, ordinal int32, newSet *apps.StatefulSet) error {
+func (u *pdUpgrader) upgradePDPod(tc *v1alpha1.TidbCluster, pod *corev1.Pod, ordinal int32, newSet *apps.StatefulSet) error {
 	ns := tc.GetNamespace()
 	tcName := tc.GetName()
 	upgradePdName := PdName(tcName, ordinal, tc.Namespace, tc.Spec.ClusterDomain)
 	upgradePodName := PdPodName(tcName, ordinal)
+	statusName := upgradePdName
+	if _, exist := tc.Status.PD.Members[statusName]; !exist {
+		statusName = upgradePodName
+	}
+	if ok, reason := canRestartPDMember(tc, statusName); !ok {
+		return controller.RequeueErrorf("tidbcluster: [%s/%s]'s pd member: [%s] can't be upgraded now, %s", ns, tcName, upgradePdName, reason)
+	}
 	if tc.Status.PD.Leader.Name == upgradePdName || tc.Status.PD.Leader.Name == upgradePodName {
-		var targetName string
-		if tc.PDStsActualReplicas() > 1 {
-			targetOrdinal := helper.GetMaxPodOrdinal(*newSet.Spec.Replicas, newSet)
-			if ordinal == targetOrdinal {
-				targetOrdinal = helper.GetMinPodOrdinal(*newSet.Spec.Replicas, newSet)
+		targetName := pdLeaderTransferPriorityTarget(tc, sets.NewString(upgradePodName))
+		if len(targetName) == 0 && tc.PDStsActualReplicas() > 1 {
+			// prefer the members upgraded already, so that the leader is
+			// not transferred again when the target is upgraded later
+			name, err := u.upgradedPDLeaderTarget(tc, ordinal, newSet)
+			if err != nil {
+				return err
 			}
-			targetName = PdName(tcName, targetOrdinal, tc.Namespace, tc.Spec.ClusterDomain)
-			if _, exist := tc.Status.PD.Members[targetName]; !exist {
-				targetName = PdPodName(tcName, targetOrdinal)
+			targetName = name
+			if len(targetName) == 0 {
+				targetOrdinal := helper.GetMaxPodOrdinal(*newSet.Spec.Replicas, newSet)
+				if ordinal == targetOrdinal {
+					targetOrdinal = helper.GetMinPodOrdinal(*newSet.Spec.Replicas, newSet)
+				}
+				targetName = pdMemberName(tc, targetOrdinal)
 			}
-		} else {
+		} else if len(targetName) == 0 {
 			for _, member := range tc.Status.PD.PeerMembers {
 				if member.Name != upgradePdName && member.Health {
 					targetName = member.Name
@@ -141,10 +193,56 @@ func (u *pdUpgrader) upgradePDPod(tc *v1alpha1.TidbCluster, ordinal int32, newSe
 			return controller.RequeueErrorf("tidbcluster: [%s/%s]'s pd member: [%s] is transferring leader to pd member: [%s]", ns, tcName, upgradePdName, targetName)
 		}
 	}
+	if err := runPreUpgradeHook(u.deps, tc, v1alpha1.PDMemberType, pod, newSet); err != nil {
+		return err
+	}
 	setUpgradePartition(newSet, ordinal)
 	return nil
 }
 
+// upgradedPDLeaderTarget returns the healthy PD member whose Pod is at the
+// update revision, preferring the highest ordinal and skipping the ordinal
+// being upgraded. It returns "" if there is none, e.g. the leader is the
+// first one to upgrade.
+func (u *pdUpgrader) upgradedPDLeaderTarget(tc *v1alpha1.TidbCluster, ordinal int32, newSet *apps.StatefulSet) (string, error) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	podOrdinals := helper.GetPodOrdinals(*newSet.Spec.Replicas, newSet).List()
+	for _i := len(podOrdinals) - 1; _i >= 0; _i-- {
+		i := podOrdinals[_i]
+		if i == ordinal {
+			continue
+		}
+		podName := PdPodName(tcName, i)
+		pod, err := u.deps.PodLister.Pods(ns).Get(podName)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("upgradedPDLeaderTarget: failed to get pod %s for cluster %s/%s, error: %s", podName, ns, tcName, err)
+		}
+		if pod.Labels[apps.ControllerRevisionHashLabelKey] != tc.Status.PD.StatefulSet.UpdateRevision {
+			continue
+		}
+		name := pdMemberName(tc, i)
+		if member, exist := tc.Status.PD.Members[name]; exist && member.Health && !member.IsLearner {
+			return name, nil
+		}
+	}
+	return "", nil
+}
+
+// pdMemberName returns the name of the PD member of the ordinal in the
+// status, it is the FQDN if the member registers with the cluster domain,
+// otherwise the Pod name.
+func pdMemberName(tc *v1alpha1.TidbCluster, ordinal int32) string {
+	name := PdName(tc.GetName(), ordinal, tc.Namespace, tc.Spec.ClusterDomain)
+	if _, exist := tc.Status.PD.Members[name]; !exist {
+		name = PdPodName(tc.GetName(), ordinal)
+	}
+	return name
+}
+
 func (u *pdUpgrader) transferPDLeaderTo(tc *v1alpha1.TidbCluster, targetName string) error {
 	return controller.GetPDClient(u.deps.PDControl, tc).TransferPDLeader(targetName)
 }
@@ -393,3 +393,62 @@ func getPods() []*corev1.Pod {
 	}
 	return pods
 }
+
+func TestPDUpgraderUpgradedLeaderTarget(t *testing.T) {
+	tests := []struct {
+		name       string
+		ordinal    int32
+		changeFn   func(*v1alpha1.TidbCluster)
+		changePods func(pods []*corev1.Pod)
+		expected   string
+	}{
+		{
+			name:     "upgraded member",
+			ordinal:  1,
+			expected: PdPodName(upgradeTcName, 2),
+		},
+		{
+			name:    "upgraded member is unhealthy",
+			ordinal: 1,
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				podName := PdPodName(upgradeTcName, 2)
+				tc.Status.PD.Members[podName] = v1alpha1.PDMember{Name: podName, Health: false}
+			},
+		},
+		{
+			name:    "the upgrading member is the only one upgraded",
+			ordinal: 2,
+		},
+		{
+			name:    "upgraded member of a lower ordinal",
+			ordinal: 2,
+			changePods: func(pods []*corev1.Pod) {
+				pods[0].Labels = label.New().Instance(upgradeInstanceName).PD().Labels()
+				pods[0].Labels[apps.ControllerRevisionHashLabelKey] = "2"
+			},
+			expected: PdPodName(upgradeTcName, 0),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			upgrader, _, _, podInformer := newPDUpgrader()
+			tc := newTidbClusterForPDUpgrader()
+			if test.changeFn != nil {
+				test.changeFn(tc)
+			}
+			pods := getPods()
+			if test.changePods != nil {
+				test.changePods(pods)
+			}
+			for i := range pods {
+				g.Expect(podInformer.Informer().GetIndexer().Add(pods[i])).To(Succeed())
+			}
+
+			name, err := upgrader.(*pdUpgrader).upgradedPDLeaderTarget(tc, test.ordinal, newStatefulSetForPDUpgrader())
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(name).To(Equal(test.expected))
+		})
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/util"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog"
+	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
+)
+
+// podRestartedReason is the reason of the Events of the Pods restarted by the
+// tidb.pingcap.com/restart-at annotation
+const podRestartedReason = "PodRestarted"
+
+// podRestartNow is the clock of the restart-at annotation, it is replaced in tests
+var podRestartNow = time.Now
+
+// syncPodRestarts restarts the Pods of the component requested by the
+// tidb.pingcap.com/restart-at annotation of the Pod, one by one from the
+// highest ordinal, in the same way as they are upgraded:
+//
+//  1. the restart waits until all the Pods of the component are ready and the
+//     maintenance window is open
+//  2. the PD leader is transferred away, the Region leaders of the TiKV store
+//     are evicted, or the TiCDC capture is drained
+//  3. the Pod is deleted and recreated by the StatefulSet
+//
+// The evict leader scheduler of the TiKV store is removed by
+// cleanupStaleEvictLeaderSchedulers once the Pod is recreated. It should only
+// be called when the component is not upgrading or scaling.
+func syncPodRestarts(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	selector, err := label.New().Instance(tc.GetInstanceName()).Component(memberType.String()).Selector()
+	if err != nil {
+		return err
+	}
+	pods, err := deps.PodLister.Pods(ns).List(selector)
+	if err != nil {
+		return fmt.Errorf("syncPodRestarts: failed to list pods for cluster %s/%s, selector %s, error: %s", ns, tcName, selector, err)
+	}
+
+	var target *corev1.Pod
+	targetOrdinal := int32(-1)
+	var restartAt string
+	var unready []string
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil || !podutil.IsPodReady(pod) {
+			unready = append(unready, pod.GetName())
+		}
+		at, ok := podRestartRequested(tc, memberType, pod)
+		if !ok {
+			continue
+		}
+		ordinal, err := util.GetOrdinalFromPodName(pod.GetName())
+		if err != nil {
+			continue
+		}
+		if ordinal > targetOrdinal {
+			target, targetOrdinal, restartAt = pod, ordinal, at
+		}
+	}
+	if target == nil {
+		return cancelRestartPreparations(deps, tc, memberType, pods, "")
+	}
+	// the preparations of the Pods whose restart is cancelled or superseded are undone
+	if err := cancelRestartPreparations(deps, tc, memberType, pods, target.GetName()); err != nil {
+		return err
+	}
+	if len(unready) > 0 {
+		sort.Strings(unready)
+		klog.Infof("tidbcluster: [%s/%s]'s %s pod %s is not restarted as pods %v are not ready", ns, tcName, memberType, target.GetName(), unready)
+		return nil
+	}
+	if waitForMaintenanceWindow(tc, memberType, "restart") {
+		return cancelRestartPreparations(deps, tc, memberType, pods, "")
+	}
+
+	ready, err := prepareRestartPod(deps, tc, memberType, target)
+	if err != nil || !ready {
+		return err
+	}
+	if err := deps.PodControl.DeletePod(tc, target); err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("%s pod %s is restarted as requested at %s", memberType, target.GetName(), restartAt)
+	klog.Infof("tidbcluster: [%s/%s]'s %s", ns, tcName, msg)
+	deps.Recorder.Event(tc, corev1.EventTypeNormal, podRestartedReason, msg)
+	return nil
+}
+
+// cancelRestartPreparations ends the leader eviction of the TiKV Pods and the
+// drain of the TiCDC Pods except the one named except, for the restarts that
+// are cancelled by removing the restart-at annotation or are deferred by the
+// maintenance window.
+func cancelRestartPreparations(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, pods []*corev1.Pod, except string) error {
+	switch memberType {
+	case v1alpha1.TiKVMemberType:
+		for _, pod := range pods {
+			if _, evicting := pod.Annotations[EvictLeaderBeginTime]; !evicting || pod.GetName() == except || pod.DeletionTimestamp != nil {
+				continue
+			}
+			if err := cancelEvictLeader(deps, tc, pod); err != nil {
+				return err
+			}
+		}
+	case v1alpha1.TiCDCMemberType:
+		return clearDrainBeginTime(deps, tc, memberType, except)
+	}
+	return nil
+}
+
+// holdComponentRestartAt keeps the restart-at annotation of the Pod template
+// of the existing StatefulSet until the time in spec.<component>.annotations
+// is reached, so that the rolling restart by the change of the annotation
+// begins at the requested time instead of at once.
+func holdComponentRestartAt(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, newSet, oldSet *apps.StatefulSet) {
+	if oldSet == nil {
+		return
+	}
+	value, ok := newSet.Spec.Template.Annotations[label.AnnRestartAt]
+	if !ok || value == oldSet.Spec.Template.Annotations[label.AnnRestartAt] {
+		return
+	}
+	restartAt, err := time.Parse(time.RFC3339, value)
+	if err != nil || !restartAt.After(podRestartNow()) {
+		return
+	}
+	if old, ok := oldSet.Spec.Template.Annotations[label.AnnRestartAt]; ok {
+		newSet.Spec.Template.Annotations[label.AnnRestartAt] = old
+	} else {
+		delete(newSet.Spec.Template.Annotations, label.AnnRestartAt)
+	}
+	klog.V(4).Infof("tidbcluster: [%s/%s]'s %s pods are restarted at %s", tc.GetNamespace(), tc.GetName(), memberType, value)
+}
+
+// podRestartRequested returns the restart-at annotation of the Pod if the
+// restart time is reached and the Pod was created before it. The annotation
+// inherited from spec.<component>.annotations is skipped, as the change of it
+// restarts the Pods by a rolling upgrade once its time is reached, see
+// holdComponentRestartAt.
+func podRestartRequested(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, pod *corev1.Pod) (string, bool) {
+	value, ok := pod.Annotations[label.AnnRestartAt]
+	if !ok || value == componentRestartAt(tc, memberType) {
+		return "", false
+	}
+	restartAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		klog.Warningf("tidbcluster: [%s/%s]'s pod %s has invalid annotation %s: %q, error: %v", tc.GetNamespace(), tc.GetName(), pod.GetName(), label.AnnRestartAt, value, err)
+		return "", false
+	}
+	if restartAt.After(podRestartNow()) || !restartAt.After(pod.CreationTimestamp.Time) {
+		return "", false
+	}
+	return value, true
+}
+
+// componentRestartAt returns the restart-at annotation of the Pod template of the component
+func componentRestartAt(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType) string {
+	var spec v1alpha1.ComponentAccessor
+	switch memberType {
+	case v1alpha1.PDMemberType:
+		spec = tc.BasePDSpec()
+	case v1alpha1.TiKVMemberType:
+		spec = tc.BaseTiKVSpec()
+	case v1alpha1.TiFlashMemberType:
+		spec = tc.BaseTiFlashSpec()
+	case v1alpha1.TiDBMemberType:
+		spec = tc.BaseTiDBSpec()
+	case v1alpha1.TiCDCMemberType:
+		spec = tc.BaseTiCDCSpec()
+	default:
+		return ""
+	}
+	return spec.Annotations()[label.AnnRestartAt]
+}
+
+// prepareRestartPod takes the leaders away from the Pod to restart and drains
+// the TiCDC capture of it, it returns true once the Pod can be deleted
+func prepareRestartPod(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, pod *corev1.Pod) (bool, error) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	podName := pod.GetName()
+
+	switch memberType {
+	case v1alpha1.PDMemberType:
+		ordinal, err := util.GetOrdinalFromPodName(podName)
+		if err != nil {
+			return false, err
+		}
+		memberName := pdMemberName(tc, ordinal)
+		if ok, reason := canRestartPDMember(tc, memberName); !ok {
+			klog.Infof("tidbcluster: [%s/%s]'s pd pod %s is not restarted, %s", ns, tcName, podName, reason)
+			return false, nil
+		}
+		if tc.Status.PD.Leader.Name != memberName && tc.Status.PD.Leader.Name != podName {
+			return true, nil
+		}
+		targetName := pdLeaderTransferPriorityTarget(tc, sets.NewString(podName))
+		if len(targetName) == 0 {
+			names := make([]string, 0, len(tc.Status.PD.Members))
+			for name := range tc.Status.PD.Members {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				member := tc.Status.PD.Members[name]
+				if name != memberName && member.Health && !member.IsLearner {
+					targetName = name
+					break
+				}
+			}
+		}
+		if len(targetName) == 0 {
+			// the only PD member is restarted like in the upgrade
+			return true, nil
+		}
+		if err := controller.GetPDClient(deps.PDControl, tc).TransferPDLeader(targetName); err != nil {
+			return false, fmt.Errorf("prepareRestartPod: failed to transfer pd leader of cluster %s/%s to %s, error: %v", ns, tcName, targetName, err)
+		}
+		klog.Infof("tidbcluster: [%s/%s]'s pd leader is transferred from %s to %s before restarting it", ns, tcName, memberName, targetName)
+		return false, nil
+	case v1alpha1.TiKVMemberType:
+		var store *v1alpha1.TiKVStore
+		for _, s := range tc.Status.TiKV.Stores {
+			if s.PodName == podName {
+				s := s
+				store = &s
+				break
+			}
+		}
+		_, evicting := pod.Annotations[EvictLeaderBeginTime]
+		if store == nil || !evicting && tc.TiKVSingleStore() {
+			return true, nil
+		}
+		if !evicting {
+			if err := checkTiKVRegionReplicas(deps, tc); err != nil {
+				if controller.IsRequeueError(err) {
+					klog.Infof("tidbcluster: [%s/%s]'s tikv pod %s is not restarted, %v", ns, tcName, podName, err)
+					return false, nil
+				}
+				return false, err
+			}
+			storeID, err := strconv.ParseUint(store.ID, 10, 64)
+			if err != nil {
+				return false, err
+			}
+			return false, beginEvictLeader(deps, tc, storeID, pod.DeepCopy())
+		}
+		if !isLeaderEvicted(deps, tc, pod) {
+			klog.V(4).Infof("tidbcluster: [%s/%s]'s tikv pod %s is evicting leaders before restarting it", ns, tcName, podName)
+			return false, nil
+		}
+		return true, nil
+	case v1alpha1.TiCDCMemberType:
+		ordinal, err := util.GetOrdinalFromPodName(podName)
+		if err != nil {
+			return false, err
+		}
+		if err := drainTiCDCCapture(deps, tc, pod, ordinal); err != nil {
+			if controller.IsRequeueError(err) {
+				klog.Infof("tidbcluster: [%s/%s]'s ticdc pod %s is not restarted, %v", ns, tcName, podName, err)
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	default:
+		return true, nil
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog"
+)
+
+const (
+	// podRestartFlappingReason is the reason of the PodRestartFlapping condition
+	podRestartFlappingReason = "PodRestartFlapping"
+	// podRestartStableReason is the reason of the PodRestartFlapping condition when no Pod is flapping
+	podRestartStableReason = "NoPodRestartFlapping"
+)
+
+// syncPodRestartFlapping records the Pods of the component restarted too often
+// according to the PodRestartPolicy in the status, reports them in the
+// PodRestartFlapping condition and emits an event for each newly flapping Pod.
+// The failover of the component consults the record through
+// tc.FailoverPausedByRestarts.
+func syncPodRestartFlapping(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	var flapping []string
+	if tc.Spec.PodRestartPolicy != nil {
+		selector, err := label.New().Instance(tc.GetInstanceName()).Component(memberType.String()).Selector()
+		if err != nil {
+			return err
+		}
+		pods, err := deps.PodLister.Pods(ns).List(selector)
+		if err != nil {
+			return fmt.Errorf("syncPodRestartFlapping: failed to list pods for cluster %s/%s, selector %s, error: %s", ns, tcName, selector, err)
+		}
+		threshold := tc.PodRestartThreshold()
+		window := tc.PodRestartWindow()
+		known := sets.NewString(tc.Status.FlappingPods[memberType]...)
+		for _, pod := range pods {
+			if !isPodRestartFlapping(pod, threshold, window) {
+				continue
+			}
+			flapping = append(flapping, pod.GetName())
+			if !known.Has(pod.GetName()) {
+				msg := fmt.Sprintf("%s Pod %s is restarted at least %d times and the last restart is within %v", memberType, pod.GetName(), threshold, window)
+				klog.Warningf("tidbcluster: [%s/%s]'s %s", ns, tcName, msg)
+				deps.Recorder.Event(tc, corev1.EventTypeWarning, podRestartFlappingReason, msg)
+			}
+		}
+	}
+
+	if len(flapping) > 0 {
+		sort.Strings(flapping)
+		if tc.Status.FlappingPods == nil {
+			tc.Status.FlappingPods = map[v1alpha1.MemberType][]string{}
+		}
+		tc.Status.FlappingPods[memberType] = flapping
+	} else if _, ok := tc.Status.FlappingPods[memberType]; ok {
+		klog.Infof("tidbcluster: [%s/%s]'s %s Pods stop flapping", ns, tcName, memberType)
+		delete(tc.Status.FlappingPods, memberType)
+		if len(tc.Status.FlappingPods) == 0 {
+			tc.Status.FlappingPods = nil
+		}
+	}
+
+	if len(tc.Status.FlappingPods) > 0 {
+		var msgs []string
+		for mt, pods := range tc.Status.FlappingPods {
+			msg := fmt.Sprintf("%s: %s", mt, strings.Join(pods, ","))
+			if tc.FailoverPausedByRestarts(mt) {
+				msg = fmt.Sprintf("%s, failover paused", msg)
+			}
+			msgs = append(msgs, msg)
+		}
+		sort.Strings(msgs)
+		utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+			v1alpha1.TidbClusterPodRestartFlapping, corev1.ConditionTrue, podRestartFlappingReason, strings.Join(msgs, "; ")))
+		return nil
+	}
+	if utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterPodRestartFlapping) != nil {
+		utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+			v1alpha1.TidbClusterPodRestartFlapping, corev1.ConditionFalse, podRestartStableReason, "no Pod is flapping"))
+	}
+	return nil
+}
+
+// isPodRestartFlapping returns whether a container of the Pod is restarted at
+// least threshold times and its last restart is within the window
+func isPodRestartFlapping(pod *corev1.Pod, threshold int32, window time.Duration) bool {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.RestartCount < threshold {
+			continue
+		}
+		terminated := cs.LastTerminationState.Terminated
+		if terminated != nil && time.Since(terminated.FinishedAt.Time) <= window {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestSyncPodRestartFlapping(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	podIndexer := deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+	recorder := deps.Recorder.(*record.FakeRecorder)
+	tc := newTidbClusterForTiKV()
+	tc.Spec.PodRestartPolicy = &v1alpha1.PodRestartPolicy{PauseFailover: true}
+
+	setPod := func(ordinal int32, restarts int32, lastRestart time.Duration) {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ordinalPodName(v1alpha1.TiKVMemberType, tc.GetName(), ordinal),
+				Namespace: tc.GetNamespace(),
+				Labels:    label.New().Instance(tc.GetInstanceName()).TiKV().Labels(),
+			},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:         v1alpha1.TiKVMemberType.String(),
+					RestartCount: restarts,
+					LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{FinishedAt: metav1.NewTime(time.Now().Add(-lastRestart))},
+					},
+				}},
+			},
+		}
+		g.Expect(podIndexer.Update(pod)).To(Succeed())
+	}
+	sync := func() *v1alpha1.TidbClusterCondition {
+		g.Expect(syncPodRestartFlapping(deps, tc, v1alpha1.TiKVMemberType)).To(Succeed())
+		return utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterPodRestartFlapping)
+	}
+	tikv0 := ordinalPodName(v1alpha1.TiKVMemberType, tc.GetName(), 0)
+
+	// a few restarts are tolerated
+	setPod(0, 2, time.Minute)
+	setPod(1, 1, time.Minute)
+	g.Expect(sync()).To(BeNil())
+	g.Expect(tc.FailoverPausedByRestarts(v1alpha1.TiKVMemberType)).To(BeFalse())
+
+	// a high restart count triggers the condition and pauses the failover
+	setPod(0, 5, time.Minute)
+	cond := sync()
+	g.Expect(cond).NotTo(BeNil())
+	g.Expect(cond.Status).To(Equal(corev1.ConditionTrue))
+	g.Expect(cond.Message).To(ContainSubstring(tikv0))
+	g.Expect(tc.Status.FlappingPods[v1alpha1.TiKVMemberType]).To(Equal([]string{tikv0}))
+	g.Expect(tc.FailoverPausedByRestarts(v1alpha1.TiKVMemberType)).To(BeTrue())
+	g.Expect(tc.FailoverPausedByRestarts(v1alpha1.PDMemberType)).To(BeFalse())
+	g.Expect(recorder.Events).To(HaveLen(1))
+
+	// the event is emitted only once for a flapping pod
+	sync()
+	g.Expect(recorder.Events).To(HaveLen(1))
+
+	// the failover is not paused if not configured
+	tc.Spec.PodRestartPolicy.PauseFailover = false
+	g.Expect(sync().Status).To(Equal(corev1.ConditionTrue))
+	g.Expect(tc.FailoverPausedByRestarts(v1alpha1.TiKVMemberType)).To(BeFalse())
+
+	// the pod stops flapping once it is not restarted within the window
+	setPod(0, 5, 20*time.Minute)
+	cond = sync()
+	g.Expect(cond.Status).To(Equal(corev1.ConditionFalse))
+	g.Expect(tc.Status.FlappingPods).To(BeNil())
+}
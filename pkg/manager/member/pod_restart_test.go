@@ -0,0 +1,354 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	"github.com/pingcap/tidb-operator/pkg/tikvapi"
+)
+
+var podRestartTestNow = time.Date(2021, 6, 1, 8, 0, 0, 0, time.UTC)
+
+func newPodForRestart(memberType v1alpha1.MemberType, ordinal int32, restartAt string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              ordinalPodName(memberType, upgradeTcName, ordinal),
+			Namespace:         corev1.NamespaceDefault,
+			Labels:            label.New().Instance(upgradeInstanceName).Component(memberType.String()).Labels(),
+			CreationTimestamp: metav1.NewTime(podRestartTestNow.Add(-time.Hour)),
+		},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	if len(restartAt) > 0 {
+		pod.Annotations = map[string]string{label.AnnRestartAt: restartAt}
+	}
+	return pod
+}
+
+func TestSyncPodRestarts(t *testing.T) {
+	defer func(now func() time.Time) { podRestartNow = now }(podRestartNow)
+	podRestartNow = func() time.Time { return podRestartTestNow }
+
+	past := podRestartTestNow.Add(-time.Minute).Format(time.RFC3339)
+	tests := []struct {
+		name      string
+		restartAt []string
+		changeFn  func(*v1alpha1.TidbCluster, []*corev1.Pod)
+		deleted   []string
+		events    []string
+	}{
+		{
+			name:      "no restart is requested",
+			restartAt: []string{"", ""},
+			deleted:   []string{},
+			events:    []string{},
+		},
+		{
+			name:      "the pod with the highest ordinal is restarted first",
+			restartAt: []string{past, past},
+			deleted:   []string{"upgrader-tidb-1"},
+			events:    []string{"Normal PodRestarted tidb pod upgrader-tidb-1 is restarted as requested at " + past},
+		},
+		{
+			name:      "the restart time is not reached",
+			restartAt: []string{"", podRestartTestNow.Add(time.Minute).Format(time.RFC3339)},
+			deleted:   []string{},
+			events:    []string{},
+		},
+		{
+			name:      "the pod is created after the restart time",
+			restartAt: []string{"", podRestartTestNow.Add(-2 * time.Hour).Format(time.RFC3339)},
+			deleted:   []string{},
+			events:    []string{},
+		},
+		{
+			name:      "the annotation is inherited from the component",
+			restartAt: []string{"", past},
+			changeFn: func(tc *v1alpha1.TidbCluster, _ []*corev1.Pod) {
+				tc.Spec.TiDB.Annotations = map[string]string{label.AnnRestartAt: past}
+			},
+			deleted: []string{},
+			events:  []string{},
+		},
+		{
+			name:      "another pod is not ready",
+			restartAt: []string{"", past},
+			changeFn: func(_ *v1alpha1.TidbCluster, pods []*corev1.Pod) {
+				pods[0].Status.Conditions[0].Status = corev1.ConditionFalse
+			},
+			deleted: []string{},
+			events:  []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			deps := controller.NewFakeDependencies()
+			tc := newTidbClusterForTiDBUpgrader()
+			var pods []*corev1.Pod
+			for i, restartAt := range test.restartAt {
+				pods = append(pods, newPodForRestart(v1alpha1.TiDBMemberType, int32(i), restartAt))
+			}
+			if test.changeFn != nil {
+				test.changeFn(tc, pods)
+			}
+			podIndexer := deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+			for _, pod := range pods {
+				g.Expect(podIndexer.Add(pod)).To(Succeed())
+			}
+
+			g.Expect(syncPodRestarts(deps, tc, v1alpha1.TiDBMemberType)).To(Succeed())
+			deleted := []string{}
+			for _, pod := range pods {
+				if _, err := deps.PodLister.Pods(pod.Namespace).Get(pod.Name); err != nil {
+					deleted = append(deleted, pod.Name)
+				}
+			}
+			g.Expect(deleted).To(Equal(test.deleted))
+			g.Expect(collectEvents(deps.Recorder.(*record.FakeRecorder).Events)).To(Equal(test.events))
+		})
+	}
+}
+
+func TestSyncPodRestartsTransferPDLeader(t *testing.T) {
+	g := NewGomegaWithT(t)
+	defer func(now func() time.Time) { podRestartNow = now }(podRestartNow)
+	podRestartNow = func() time.Time { return podRestartTestNow }
+
+	deps := controller.NewFakeDependencies()
+	tc := newTidbClusterForTiDBUpgrader()
+	tc.Status.PD.Members = map[string]v1alpha1.PDMember{}
+	for i := int32(0); i < 3; i++ {
+		name := PdPodName(upgradeTcName, i)
+		tc.Status.PD.Members[name] = v1alpha1.PDMember{Name: name, Health: true}
+		pod := newPodForRestart(v1alpha1.PDMemberType, i, "")
+		if i == 2 {
+			pod = newPodForRestart(v1alpha1.PDMemberType, i, podRestartTestNow.Add(-time.Minute).Format(time.RFC3339))
+		}
+		g.Expect(deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer().Add(pod)).To(Succeed())
+	}
+	tc.Status.PD.Leader = tc.Status.PD.Members["upgrader-pd-2"]
+
+	var target string
+	pdClient := controller.NewFakePDClient(deps.PDControl.(*pdapi.FakePDControl), tc)
+	pdClient.AddReaction(pdapi.TransferPDLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+		target = action.Name
+		return nil, nil
+	})
+
+	// the leader is transferred away before the restart
+	g.Expect(syncPodRestarts(deps, tc, v1alpha1.PDMemberType)).To(Succeed())
+	g.Expect(target).To(Equal("upgrader-pd-0"))
+	_, err := deps.PodLister.Pods(corev1.NamespaceDefault).Get("upgrader-pd-2")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	tc.Status.PD.Leader = tc.Status.PD.Members["upgrader-pd-0"]
+	g.Expect(syncPodRestarts(deps, tc, v1alpha1.PDMemberType)).To(Succeed())
+	_, err = deps.PodLister.Pods(corev1.NamespaceDefault).Get("upgrader-pd-2")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestSyncPodRestartsEvictTiKVLeader(t *testing.T) {
+	g := NewGomegaWithT(t)
+	defer func(now func() time.Time) { podRestartNow = now }(podRestartNow)
+	podRestartNow = func() time.Time { return podRestartTestNow }
+
+	deps := controller.NewFakeDependencies()
+	tc := newTidbClusterForTiDBUpgrader()
+	tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{}
+	podIndexer := deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+	for i := int32(0); i < 3; i++ {
+		podName := TikvPodName(upgradeTcName, i)
+		id := string(rune('1' + i))
+		tc.Status.TiKV.Stores[id] = v1alpha1.TiKVStore{ID: id, PodName: podName, State: v1alpha1.TiKVStateUp}
+		restartAt := ""
+		if i == 0 {
+			restartAt = podRestartTestNow.Add(-time.Minute).Format(time.RFC3339)
+		}
+		g.Expect(podIndexer.Add(newPodForRestart(v1alpha1.TiKVMemberType, i, restartAt))).To(Succeed())
+	}
+
+	var evicted uint64
+	pdClient := controller.NewFakePDClient(deps.PDControl.(*pdapi.FakePDControl), tc)
+	pdClient.AddReaction(pdapi.BeginEvictLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+		evicted = action.ID
+		return nil, nil
+	})
+	leaderCount := 10
+	tikvClient := controller.NewFakeTiKVClient(deps.TiKVControl.(*tikvapi.FakeTiKVControl), tc, "upgrader-tikv-0")
+	tikvClient.AddReaction(tikvapi.GetLeaderCountActionType, func(action *tikvapi.Action) (interface{}, error) {
+		return leaderCount, nil
+	})
+
+	// the leaders are evicted before the restart
+	g.Expect(syncPodRestarts(deps, tc, v1alpha1.TiKVMemberType)).To(Succeed())
+	g.Expect(evicted).To(Equal(uint64(1)))
+	g.Expect(tc.Status.TiKV.EvictLeaderStores).To(Equal([]string{"1"}))
+	pod, err := deps.PodLister.Pods(corev1.NamespaceDefault).Get("upgrader-tikv-0")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pod.Annotations).To(HaveKey(EvictLeaderBeginTime))
+
+	// the pod is not restarted until the leaders are evicted
+	g.Expect(syncPodRestarts(deps, tc, v1alpha1.TiKVMemberType)).To(Succeed())
+	_, err = deps.PodLister.Pods(corev1.NamespaceDefault).Get("upgrader-tikv-0")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	leaderCount = 0
+	g.Expect(syncPodRestarts(deps, tc, v1alpha1.TiKVMemberType)).To(Succeed())
+	_, err = deps.PodLister.Pods(corev1.NamespaceDefault).Get("upgrader-tikv-0")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestSyncPodRestartsCancelEvictTiKVLeader(t *testing.T) {
+	g := NewGomegaWithT(t)
+	defer func(now func() time.Time) { podRestartNow = now }(podRestartNow)
+	podRestartNow = func() time.Time { return podRestartTestNow }
+
+	deps := controller.NewFakeDependencies()
+	tc := newTidbClusterForTiDBUpgrader()
+	tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{}
+	podIndexer := deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+	for i := int32(0); i < 3; i++ {
+		podName := TikvPodName(upgradeTcName, i)
+		id := string(rune('1' + i))
+		tc.Status.TiKV.Stores[id] = v1alpha1.TiKVStore{ID: id, PodName: podName, State: v1alpha1.TiKVStateUp}
+		pod := newPodForRestart(v1alpha1.TiKVMemberType, i, "")
+		if i == 0 {
+			// the restart-at annotation is removed while the leaders are evicted
+			pod.Annotations = map[string]string{EvictLeaderBeginTime: podRestartTestNow.Format(time.RFC3339)}
+		}
+		g.Expect(podIndexer.Add(pod)).To(Succeed())
+	}
+
+	var ended []uint64
+	pdClient := controller.NewFakePDClient(deps.PDControl.(*pdapi.FakePDControl), tc)
+	pdClient.AddReaction(pdapi.EndEvictLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+		ended = append(ended, action.ID)
+		return nil, nil
+	})
+
+	g.Expect(syncPodRestarts(deps, tc, v1alpha1.TiKVMemberType)).To(Succeed())
+	g.Expect(ended).To(Equal([]uint64{1}))
+	pod, err := deps.PodLister.Pods(corev1.NamespaceDefault).Get("upgrader-tikv-0")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pod.Annotations).NotTo(HaveKey(EvictLeaderBeginTime))
+}
+
+func TestSyncPodRestartsDrainTiCDCCapture(t *testing.T) {
+	g := NewGomegaWithT(t)
+	defer func(now func() time.Time) { podRestartNow = now }(podRestartNow)
+	podRestartNow = func() time.Time { return podRestartTestNow }
+
+	deps := controller.NewFakeDependencies()
+	tc := newTidbClusterForTiDBUpgrader()
+	tc.Spec.TiCDC = &v1alpha1.TiCDCSpec{}
+	tc.Status.TiCDC.Captures = map[string]v1alpha1.TiCDCCapture{}
+	podIndexer := deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+	for i := int32(0); i < 2; i++ {
+		podName := ordinalPodName(v1alpha1.TiCDCMemberType, upgradeTcName, i)
+		tc.Status.TiCDC.Captures[podName] = v1alpha1.TiCDCCapture{PodName: podName}
+		restartAt := ""
+		if i == 1 {
+			restartAt = podRestartTestNow.Add(-time.Minute).Format(time.RFC3339)
+		}
+		g.Expect(podIndexer.Add(newPodForRestart(v1alpha1.TiCDCMemberType, i, restartAt))).To(Succeed())
+	}
+	podName := ordinalPodName(v1alpha1.TiCDCMemberType, upgradeTcName, 1)
+
+	// the capture begins draining before the restart
+	g.Expect(syncPodRestarts(deps, tc, v1alpha1.TiCDCMemberType)).To(Succeed())
+	pod, err := deps.PodLister.Pods(corev1.NamespaceDefault).Get(podName)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pod.Annotations).To(HaveKey(label.AnnDrainBeginTime))
+
+	// the drain is cleared once the restart is cancelled
+	pod = pod.DeepCopy()
+	delete(pod.Annotations, label.AnnRestartAt)
+	g.Expect(podIndexer.Update(pod)).To(Succeed())
+	g.Expect(syncPodRestarts(deps, tc, v1alpha1.TiCDCMemberType)).To(Succeed())
+	pod, err = deps.PodLister.Pods(corev1.NamespaceDefault).Get(podName)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pod.Annotations).NotTo(HaveKey(label.AnnDrainBeginTime))
+}
+
+func TestHoldComponentRestartAt(t *testing.T) {
+	defer func(now func() time.Time) { podRestartNow = now }(podRestartNow)
+	podRestartNow = func() time.Time { return podRestartTestNow }
+
+	past := podRestartTestNow.Add(-time.Minute).Format(time.RFC3339)
+	future := podRestartTestNow.Add(time.Minute).Format(time.RFC3339)
+	newSet := func(restartAt string) *apps.StatefulSet {
+		set := &apps.StatefulSet{}
+		set.Spec.Template.Annotations = map[string]string{"foo": "bar"}
+		if len(restartAt) > 0 {
+			set.Spec.Template.Annotations[label.AnnRestartAt] = restartAt
+		}
+		return set
+	}
+	tests := []struct {
+		name     string
+		oldSet   *apps.StatefulSet
+		newSet   *apps.StatefulSet
+		expected string
+	}{
+		{
+			name:     "the statefulset is created",
+			newSet:   newSet(future),
+			expected: future,
+		},
+		{
+			name:     "the restart time is not reached",
+			oldSet:   newSet(""),
+			newSet:   newSet(future),
+			expected: "",
+		},
+		{
+			name:     "the previous restart time is kept",
+			oldSet:   newSet(past),
+			newSet:   newSet(future),
+			expected: past,
+		},
+		{
+			name:     "the restart time is reached",
+			oldSet:   newSet(""),
+			newSet:   newSet(past),
+			expected: past,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			tc := newTidbClusterForTiDBUpgrader()
+			holdComponentRestartAt(tc, v1alpha1.TiKVMemberType, test.newSet, test.oldSet)
+			g.Expect(test.newSet.Spec.Template.Annotations[label.AnnRestartAt]).To(Equal(test.expected))
+			g.Expect(test.newSet.Spec.Template.Annotations).To(HaveKeyWithValue("foo", "bar"))
+		})
+	}
+}
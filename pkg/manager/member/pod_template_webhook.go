@@ -0,0 +1,107 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+)
+
+// podTemplateWebhookFailedReason is the reason of the Events of the ignored
+// failures to call the Pod template webhook
+const podTemplateWebhookFailedReason = "PodTemplateWebhookFailed"
+
+// mutatePodTemplate applies the mutations of the Pod template webhook of the
+// cluster to the Pod template of the StatefulSet. Only the containers, init
+// containers, volumes, labels and annotations added by the webhook are kept,
+// so the webhook can not change what the operator generates. A failure to
+// call the webhook fails the sync unless the failure policy is Ignore.
+func mutatePodTemplate(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, set *apps.StatefulSet) error {
+	if tc.Spec.PodTemplateWebhook == nil {
+		return nil
+	}
+	mutated, err := deps.PodTemplateControl.MutatePodTemplate(tc, memberType, set.Spec.Template.DeepCopy())
+	if err != nil {
+		err = fmt.Errorf("failed to mutate the pod template of %s for tidbcluster %s/%s: %v", memberType, tc.GetNamespace(), tc.GetName(), err)
+		if !tc.PodTemplateWebhookIgnoreFailure() {
+			return err
+		}
+		klog.Warningf("%v, the pod template is not mutated as the failure policy is Ignore", err)
+		deps.Recorder.Event(tc, corev1.EventTypeWarning, podTemplateWebhookFailedReason, err.Error())
+		return nil
+	}
+	mergePodTemplateMutation(&set.Spec.Template, mutated)
+	return nil
+}
+
+// mergePodTemplateMutation adds the containers, init containers, volumes,
+// labels and annotations that are in mutated but not in template to template
+func mergePodTemplateMutation(template, mutated *corev1.PodTemplateSpec) {
+	for k, v := range mutated.Labels {
+		if _, ok := template.Labels[k]; !ok {
+			if template.Labels == nil {
+				template.Labels = map[string]string{}
+			}
+			template.Labels[k] = v
+		}
+	}
+	for k, v := range mutated.Annotations {
+		if _, ok := template.Annotations[k]; !ok {
+			if template.Annotations == nil {
+				template.Annotations = map[string]string{}
+			}
+			template.Annotations[k] = v
+		}
+	}
+
+	spec := &template.Spec
+	for _, c := range mutated.Spec.Containers {
+		if !containerExists(spec.Containers, c.Name) {
+			spec.Containers = append(spec.Containers, c)
+		}
+	}
+	for _, c := range mutated.Spec.InitContainers {
+		if !containerExists(spec.InitContainers, c.Name) {
+			spec.InitContainers = append(spec.InitContainers, c)
+		}
+	}
+	for _, v := range mutated.Spec.Volumes {
+		if !volumeExists(spec.Volumes, v.Name) {
+			spec.Volumes = append(spec.Volumes, v)
+		}
+	}
+}
+
+func containerExists(containers []corev1.Container, name string) bool {
+	for _, c := range containers {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func volumeExists(volumes []corev1.Volume, name string) bool {
+	for _, v := range volumes {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestPDMemberManagerSyncPodTemplateWebhook(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPD()
+	tc.Spec.PodTemplateWebhook = &v1alpha1.PodTemplateWebhook{URL: "https://webhook.example.com/mutate"}
+	pmm, _, _ := newFakePDMemberManager()
+	webhook := pmm.deps.PodTemplateControl.(*controller.FakePodTemplateControl)
+	webhook.MutateFn = func(component v1alpha1.MemberType, template *corev1.PodTemplateSpec) error {
+		template.Labels["sidecar"] = "injected"
+		template.Labels[label.ComponentLabelKey] = "changed"
+		template.Annotations = map[string]string{"sidecar": "injected"}
+		template.Spec.Containers[0].Image = "changed"
+		template.Spec.Containers = append(template.Spec.Containers, corev1.Container{Name: "sidecar", Image: "sidecar"})
+		template.Spec.InitContainers = append(template.Spec.InitContainers, corev1.Container{Name: "init-sidecar", Image: "sidecar"})
+		template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{Name: "sidecar"})
+		return nil
+	}
+
+	err := pmm.Sync(tc)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(webhook.Components).To(Equal([]v1alpha1.MemberType{v1alpha1.PDMemberType}))
+
+	set, err := pmm.deps.StatefulSetLister.StatefulSets(tc.GetNamespace()).Get(controller.PDMemberName(tc.GetName()))
+	g.Expect(err).NotTo(HaveOccurred())
+	expected, err := getNewPDSetForTidbCluster(tc, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	template := set.Spec.Template
+
+	// the added containers, volumes, labels and annotations are kept
+	g.Expect(template.Labels).To(HaveKeyWithValue("sidecar", "injected"))
+	g.Expect(template.Annotations).To(HaveKeyWithValue("sidecar", "injected"))
+	g.Expect(template.Spec.Containers).To(HaveLen(len(expected.Spec.Template.Spec.Containers) + 1))
+	g.Expect(template.Spec.Containers[len(template.Spec.Containers)-1].Name).To(Equal("sidecar"))
+	g.Expect(template.Spec.InitContainers[len(template.Spec.InitContainers)-1].Name).To(Equal("init-sidecar"))
+	g.Expect(template.Spec.Volumes[len(template.Spec.Volumes)-1].Name).To(Equal("sidecar"))
+	// the changes to what the operator generates are dropped
+	g.Expect(template.Spec.Containers[0]).To(Equal(expected.Spec.Template.Spec.Containers[0]))
+	g.Expect(template.Labels).To(HaveKeyWithValue(label.ComponentLabelKey, label.PDLabelVal))
+
+	// a failed webhook call fails the sync
+	webhook.MutateFn = func(component v1alpha1.MemberType, template *corev1.PodTemplateSpec) error {
+		return fmt.Errorf("webhook is not available")
+	}
+	err = pmm.Sync(tc)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("webhook is not available"))
+
+	// unless the failure policy is Ignore
+	ignore := v1alpha1.PodTemplateWebhookFailurePolicyIgnore
+	tc.Spec.PodTemplateWebhook.FailurePolicy = &ignore
+	if err := pmm.Sync(tc); err != nil {
+		g.Expect(err.Error()).NotTo(ContainSubstring("webhook is not available"))
+	}
+
+	// the webhook is not called if it is not configured
+	webhook.Components = nil
+	tc.Spec.PodTemplateWebhook = nil
+	_ = pmm.Sync(tc)
+	g.Expect(webhook.Components).To(BeEmpty())
+}
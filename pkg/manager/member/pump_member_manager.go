@@ -35,6 +35,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/klog"
+	"k8s.io/utils/pointer"
 )
 
 const (
@@ -45,21 +46,25 @@ const (
 
 type binlogClient interface {
 	PumpNodeStatus(ctx context.Context) (status []*v1alpha1.PumpNodeStatus, err error)
+	PausePump(ctx context.Context, addr string) error
+	IsPumpConsumed(ctx context.Context, addr string) (bool, error)
 	Close() error
 }
 
 type pumpMemberManager struct {
-	deps   *controller.Dependencies
-	scaler Scaler
+	deps     *controller.Dependencies
+	scaler   Scaler
+	upgrader Upgrader
 	// only use for test
 	binlogClient binlogClient
 }
 
 // NewPumpMemberManager returns a controller to reconcile pump clusters
-func NewPumpMemberManager(deps *controller.Dependencies, scaler Scaler) manager.Manager {
+func NewPumpMemberManager(deps *controller.Dependencies, scaler Scaler, upgrader Upgrader) manager.Manager {
 	return &pumpMemberManager{
-		deps:   deps,
-		scaler: scaler,
+		deps:     deps,
+		scaler:   scaler,
+		upgrader: upgrader,
 	}
 }
 
@@ -101,6 +106,9 @@ func (m *pumpMemberManager) syncPumpStatefulSetForTidbCluster(tc *v1alpha1.TidbC
 	if err != nil {
 		return err
 	}
+	if err := mutatePodTemplate(m.deps, tc, v1alpha1.PumpMemberType, newSet); err != nil {
+		return err
+	}
 	if notFound {
 		err = SetStatefulSetLastAppliedConfigAnnotation(newSet)
 		if err != nil {
@@ -113,6 +121,12 @@ func (m *pumpMemberManager) syncPumpStatefulSetForTidbCluster(tc *v1alpha1.TidbC
 		return err
 	}
 
+	if templateEqual(newSet, oldSet) {
+		if err := resumePausedPumps(m.deps, tc); err != nil {
+			return err
+		}
+	}
+
 	// Wait for PD & TiKV upgrading done
 	if tc.Status.TiFlash.Phase == v1alpha1.UpgradePhase ||
 		tc.Status.PD.Phase == v1alpha1.UpgradePhase ||
@@ -124,6 +138,12 @@ func (m *pumpMemberManager) syncPumpStatefulSetForTidbCluster(tc *v1alpha1.TidbC
 		return nil
 	}
 
+	if !templateEqual(newSet, oldSet) || tc.Status.Pump.Phase == v1alpha1.UpgradePhase {
+		if err := m.upgrader.Upgrade(tc, oldSet, newSet); err != nil {
+			return err
+		}
+	}
+
 	return UpdateStatefulSet(m.deps.StatefulSetControl, tc, newSet, oldSet)
 }
 
@@ -174,6 +194,10 @@ func (m *pumpMemberManager) syncTiDBClusterStatus(tc *v1alpha1.TidbCluster, set
 		tc.Status.Pump.Phase = v1alpha1.NormalPhase
 	}
 
+	if err := syncUpgradeProgress(m.deps.PodLister, tc, v1alpha1.PumpMemberType, set, tc.Status.Pump.Phase, &tc.Status.Pump.Upgrade); err != nil {
+		return err
+	}
+
 	client, err := m.buildBinlogClient(tc, m.deps.PDControl)
 	if err != nil {
 		return err
@@ -321,6 +345,7 @@ func getNewPumpConfigMap(tc *v1alpha1.TidbCluster) (*corev1.ConfigMap, error) {
 func getNewPumpStatefulSet(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap) (*appsv1.StatefulSet, error) {
 	spec := tc.BasePumpSpec()
 	objMeta, stsLabels := getPumpMeta(tc, controller.PumpMemberName)
+	objMeta.Annotations = getStsAnnotations(tc.Annotations, label.PumpLabelVal)
 	replicas := tc.Spec.Pump.Replicas
 	storageClass := tc.Spec.Pump.StorageClassName
 	podLabels := util.CombineStringMap(stsLabels.Labels(), spec.Labels())
@@ -381,7 +406,7 @@ func getNewPumpStatefulSet(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap) (*app
 				Name:          "pump",
 				ContainerPort: 8250,
 			}},
-			Resources:    controller.ContainerResource(tc.Spec.Pump.ResourceRequirements),
+			Resources:    controller.ContainerResourceWithEphemeralStorage(tc.Spec.Pump.ResourceRequirements, tc.Spec.Pump.EphemeralStorage),
 			Env:          util.AppendEnv(envs, spec.Env()),
 			VolumeMounts: volumeMounts,
 			ReadinessProbe: &corev1.Probe{
@@ -447,6 +472,7 @@ func getNewPumpStatefulSet(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap) (*app
 	podSpec := spec.BuildPodSpec()
 	podSpec.Containers = containers
 	podSpec.Volumes = volumes
+	setMainContainerSecurityContext(&podSpec, spec, "pump", nil)
 	podSpec.ServiceAccountName = serviceAccountName
 	// TODO: change to set field in BuildPodSpec
 	podSpec.InitContainers = spec.InitContainers()
@@ -461,6 +487,14 @@ func getNewPumpStatefulSet(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap) (*app
 		Spec: podSpec,
 	}
 
+	updateStrategy := apps.StatefulSetUpdateStrategy{Type: spec.StatefulSetUpdateStrategy()}
+	if updateStrategy.Type == apps.RollingUpdateStatefulSetStrategyType {
+		// the partition is lowered by the pump upgrader once the pump to upgrade is paused
+		updateStrategy.RollingUpdate = &apps.RollingUpdateStatefulSetStrategy{
+			Partition: pointer.Int32Ptr(replicas),
+		}
+	}
+
 	return &appsv1.StatefulSet{
 		ObjectMeta: objMeta,
 		Spec: appsv1.StatefulSetSpec{
@@ -470,9 +504,7 @@ func getNewPumpStatefulSet(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap) (*app
 
 			Template:             podTemplate,
 			VolumeClaimTemplates: volumeClaims,
-			UpdateStrategy: apps.StatefulSetUpdateStrategy{
-				Type: spec.StatefulSetUpdateStrategy(),
-			},
+			UpdateStrategy:       updateStrategy,
 		},
 	}, nil
 }
@@ -435,6 +435,7 @@ func newFakePumpMemberManager() (*pumpMemberManager, *pumpFakeControls, *pumpFak
 	pmm := &pumpMemberManager{
 		deps:         fakeDeps,
 		scaler:       NewFakePumpScaler(),
+		upgrader:     NewFakePumpUpgrader(),
 		binlogClient: &fakeBinlogClient{},
 	}
 	controls := &pumpFakeControls{
@@ -763,12 +764,23 @@ func TestSyncTiDBClusterStatus(t *testing.T) {
 }
 
 type fakeBinlogClient struct {
+	paused   []string
+	consumed bool
 }
 
 func (c *fakeBinlogClient) PumpNodeStatus(ctx context.Context) (status []*v1alpha1.PumpNodeStatus, err error) {
 	return nil, nil
 }
 
+func (c *fakeBinlogClient) PausePump(ctx context.Context, addr string) error {
+	c.paused = append(c.paused, addr)
+	return nil
+}
+
+func (c *fakeBinlogClient) IsPumpConsumed(ctx context.Context, addr string) (bool, error) {
+	return c.consumed, nil
+}
+
 func (c *fakeBinlogClient) Close() error {
 	return nil
 }
@@ -37,15 +37,37 @@ type pumpScaler struct {
 }
 
 // NewPumpScaler returns a pump Scaler
-func NewPumpScaler(deps *controller.Dependencies) *pumpScaler {
-	return &pumpScaler{generalScaler: generalScaler{deps: deps}}
+func NewPumpScaler(deps *controller.Dependencies) Scaler {
+	return withScaleMetrics(v1alpha1.PumpMemberType, &pumpScaler{generalScaler: generalScaler{deps: deps}})
 }
 
 func (s *pumpScaler) Scale(meta metav1.Object, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	if s.scaleDryRun(meta, v1alpha1.PumpMemberType, oldSet, newSet) {
+		return nil
+	}
+	if s.deferScaleToMaintenanceWindow(meta, v1alpha1.PumpMemberType, oldSet, newSet) {
+		return nil
+	}
+	if deferred, err := s.deferScale(meta, v1alpha1.PumpMemberType, oldSet, newSet); err != nil || deferred {
+		return err
+	}
+	if err := s.checkScaleVelocity(meta, v1alpha1.PumpMemberType, oldSet, newSet); err != nil {
+		return err
+	}
 	scaling, _, _, _ := scaleOne(oldSet, newSet)
+	if scaling != 0 {
+		if err := checkPDSplitBrain(meta, oldSet, newSet); err != nil {
+			return err
+		}
+	}
 	if scaling > 0 {
+		if err := checkResourceQuota(s.deps, meta, oldSet, newSet); err != nil {
+			return err
+		}
+		s.recordScale(meta, v1alpha1.PumpMemberType, oldSet, newSet)
 		return s.ScaleOut(meta, oldSet, newSet)
 	} else if scaling < 0 {
+		s.recordScale(meta, v1alpha1.PumpMemberType, oldSet, newSet)
 		return s.ScaleIn(meta, oldSet, newSet)
 	}
 
@@ -122,6 +144,30 @@ func pumpAdvertiseAddr(pod *v1.Pod) string {
 	return ""
 }
 
+// pumpHookAddr returns the hook to access the pump by the advertise address.
+//
+// Since the advertise address may no contains the namespace
+// and operator do not run in the same namespace with tidb-cluster,
+// we can not use this advertise address to access pump.
+// so will add the namespace part to the address if need.
+func pumpHookAddr(ns string) func(addr string) string {
+	return func(addr string) string {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return addr
+		}
+
+		suffix := "." + ns
+
+		if strings.Contains(addr, suffix) {
+			return addr
+		}
+
+		host += suffix
+		return host + ":" + port
+	}
+}
+
 func (s *pumpScaler) ScaleIn(meta metav1.Object, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
 	ns := meta.GetNamespace()
 	tcName := meta.GetName()
@@ -161,25 +207,7 @@ func (s *pumpScaler) ScaleIn(meta metav1.Object, oldSet *apps.StatefulSet, newSe
 	}
 	defer client.Close()
 
-	// Since the advertise address may no contains the namespace
-	// and operator do not run in the same namespace with tidb-cluster,
-	// we can not use this advertise address to access pump.
-	// so will add the namespace part to the address if need.
-	client.HookAddr = func(addr string) string {
-		host, port, err := net.SplitHostPort(addr)
-		if err != nil {
-			return addr
-		}
-
-		suffix := "." + ns
-
-		if strings.Contains(addr, suffix) {
-			return addr
-		}
-
-		host += suffix
-		return host + ":" + port
-	}
+	client.HookAddr = pumpHookAddr(ns)
 
 	addr := pumpAdvertiseAddr(pod)
 
@@ -197,6 +225,13 @@ func (s *pumpScaler) ScaleIn(meta metav1.Object, oldSet *apps.StatefulSet, newSe
 			return controller.RequeueErrorf("Pump %s/%s is still in cluster, state: %s", ns, podName, node.State)
 		} else if node.State == "offline" {
 			klog.Infof("Pump %s/%s becomes offline", ns, podName)
+			consumed, err := client.IsPumpConsumed(context.TODO(), addr)
+			if err != nil {
+				return err
+			}
+			if !consumed {
+				return controller.RequeueErrorf("Pump %s/%s has binlogs not consumed by drainers", ns, podName)
+			}
 			pvcs, err := util.ResolvePVCFromPod(pod, s.deps.PVCLister)
 			if err != nil {
 				return fmt.Errorf("pumpScaler.ScaleIn: failed to get pvcs for pod %s/%s in tc %s/%s, error: %s", ns, pod.Name, ns, tcName, err)
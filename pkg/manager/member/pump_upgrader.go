@@ -0,0 +1,229 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pingcap/advanced-statefulset/client/apis/apps/v1/helper"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+)
+
+type pumpUpgrader struct {
+	deps *controller.Dependencies
+	// only use for test
+	binlogClient binlogClient
+}
+
+// NewPumpUpgrader returns a pump Upgrader
+func NewPumpUpgrader(deps *controller.Dependencies) Upgrader {
+	return &pumpUpgrader{
+		deps: deps,
+	}
+}
+
+func (u *pumpUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	// return nil when scale replicas to 0
+	if tc.Spec.Pump.Replicas == int32(0) {
+		return nil
+	}
+
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	tc.Status.Pump.Phase = v1alpha1.UpgradePhase
+	if !templateEqual(newSet, oldSet) {
+		return nil
+	}
+
+	if tc.Status.Pump.StatefulSet == nil {
+		return controller.RequeueErrorf("tidbcluster: [%s/%s]'s pump statefulset status is not synced yet", ns, tcName)
+	}
+	if tc.Status.Pump.StatefulSet.UpdateRevision == tc.Status.Pump.StatefulSet.CurrentRevision {
+		return nil
+	}
+
+	if oldSet.Spec.UpdateStrategy.Type == apps.OnDeleteStatefulSetStrategyType {
+		// Manually bypass tidb-operator to modify statefulset directly, let the native statefulset controller do the upgrade,
+		// the pumps are not paused before they are restarted in this situation.
+		newSet.Spec.UpdateStrategy = oldSet.Spec.UpdateStrategy
+		klog.Warningf("tidbcluster: [%s/%s] pump statefulset %s UpdateStrategy has been modified manually", ns, tcName, oldSet.GetName())
+		return nil
+	}
+
+	// the pump statefulsets created by the older versions have no partition,
+	// the upgrade of them starts from the highest ordinal as well
+	partition := *oldSet.Spec.Replicas
+	if oldSet.Spec.UpdateStrategy.RollingUpdate != nil && oldSet.Spec.UpdateStrategy.RollingUpdate.Partition != nil {
+		partition = *oldSet.Spec.UpdateStrategy.RollingUpdate.Partition
+	}
+	setUpgradePartition(newSet, partition)
+	podOrdinals := helper.GetPodOrdinals(*oldSet.Spec.Replicas, oldSet).List()
+	for _i := len(podOrdinals) - 1; _i >= 0; _i-- {
+		i := podOrdinals[_i]
+		podName := ordinalPodName(v1alpha1.PumpMemberType, tcName, i)
+		pod, err := u.deps.PodLister.Pods(ns).Get(podName)
+		if err != nil {
+			return fmt.Errorf("pumpUpgrader.Upgrade: failed to get pod %s for cluster %s/%s, error: %s", podName, ns, tcName, err)
+		}
+		revision, exist := pod.Labels[apps.ControllerRevisionHashLabelKey]
+		if !exist {
+			return controller.RequeueErrorf("tidbcluster: [%s/%s]'s pump pod: [%s] has no label: %s", ns, tcName, podName, apps.ControllerRevisionHashLabelKey)
+		}
+
+		if revision == tc.Status.Pump.StatefulSet.UpdateRevision {
+			if node := pumpNode(tc, pod); node == nil || node.State != "online" {
+				return controller.RequeueErrorf("tidbcluster: [%s/%s]'s pump upgraded pod: [%s] is not online", ns, tcName, podName)
+			}
+			continue
+		}
+		if upgradePaused(tc, v1alpha1.PumpMemberType) || waitForMaintenanceWindow(tc, v1alpha1.PumpMemberType, "upgrade") {
+			return nil
+		}
+		if _i == len(podOrdinals)-1 {
+			if err := checkUpgradePreChecks(u.deps, tc, v1alpha1.PumpMemberType); err != nil {
+				return err
+			}
+		}
+		if partition > i {
+			if err := u.pausePump(tc, pod); err != nil {
+				return err
+			}
+		}
+		setUpgradePartition(newSet, i)
+		return nil
+	}
+
+	return nil
+}
+
+// pausePump returns nil once the pump of the Pod is paused and its binlogs
+// are consumed by the drainers, otherwise the upgrade is requeued.
+func (u *pumpUpgrader) pausePump(tc *v1alpha1.TidbCluster, pod *corev1.Pod) error {
+	ns := tc.GetNamespace()
+	node := pumpNode(tc, pod)
+	if node == nil {
+		// the pump has not joined the cluster
+		return nil
+	}
+	online := 0
+	for _, member := range tc.Status.Pump.Members {
+		if member.State == "online" {
+			online++
+		}
+	}
+
+	client, err := u.buildBinlogClient(tc)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	switch node.State {
+	case "online":
+		if online <= 1 {
+			// there is no other pump to write the binlogs
+			return nil
+		}
+		if err := client.PausePump(context.TODO(), node.Host); err != nil {
+			return err
+		}
+		klog.Infof("pumpUpgrader.Upgrade: send pause request to pump %s/%s successfully", ns, pod.Name)
+		return controller.RequeueErrorf("Pump %s/%s is pausing, can't upgrade now", ns, pod.Name)
+	case "paused":
+		consumed, err := client.IsPumpConsumed(context.TODO(), node.Host)
+		if err != nil {
+			return err
+		}
+		if !consumed {
+			return controller.RequeueErrorf("Pump %s/%s has binlogs not consumed by drainers, can't upgrade now", ns, pod.Name)
+		}
+		klog.Infof("pumpUpgrader.Upgrade: the binlogs of pump %s/%s are consumed by drainers", ns, pod.Name)
+		return nil
+	case "pausing":
+		return controller.RequeueErrorf("Pump %s/%s is pausing, can't upgrade now", ns, pod.Name)
+	default:
+		return nil
+	}
+}
+
+// resumePausedPumps restarts the Pods of the paused pumps once no upgrade is in
+// progress, e.g. the upgrade is reverted after a pump was paused for it, since
+// nothing else resumes them. A pump rejoins the cluster online once it restarts.
+func resumePausedPumps(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) error {
+	ns := tc.GetNamespace()
+	status := tc.Status.Pump.StatefulSet
+	if status == nil || status.UpdateRevision != status.CurrentRevision {
+		return nil
+	}
+	selector, err := label.New().Instance(tc.GetInstanceName()).Pump().Selector()
+	if err != nil {
+		return err
+	}
+	pods, err := deps.PodLister.Pods(ns).List(selector)
+	if err != nil {
+		return fmt.Errorf("resumePausedPumps: failed to list pump pods for cluster %s/%s, selector %s, error: %v", ns, tc.GetName(), selector, err)
+	}
+	for _, pod := range pods {
+		if node := pumpNode(tc, pod); node == nil || node.State != "paused" || pod.DeletionTimestamp != nil {
+			continue
+		}
+		klog.Infof("tidbcluster: [%s/%s] restarts the paused pump %s as no upgrade is in progress", ns, tc.GetName(), pod.GetName())
+		if err := deps.PodControl.DeletePod(tc, pod); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *pumpUpgrader) buildBinlogClient(tc *v1alpha1.TidbCluster) (binlogClient, error) {
+	if u.binlogClient != nil {
+		return u.binlogClient, nil
+	}
+	client, err := buildBinlogClient(tc, u.deps.PDControl)
+	if err != nil {
+		return nil, err
+	}
+	client.HookAddr = pumpHookAddr(tc.GetNamespace())
+	return client, nil
+}
+
+// pumpNode returns the status of the pump of the Pod
+func pumpNode(tc *v1alpha1.TidbCluster, pod *corev1.Pod) *v1alpha1.PumpNodeStatus {
+	addr := pumpAdvertiseAddr(pod)
+	for _, node := range tc.Status.Pump.Members {
+		if node.Host == addr {
+			return node
+		}
+	}
+	return nil
+}
+
+type fakePumpUpgrader struct{}
+
+// NewFakePumpUpgrader returns a fake pump upgrader
+func NewFakePumpUpgrader() Upgrader {
+	return &fakePumpUpgrader{}
+}
+
+func (u *fakePumpUpgrader) Upgrade(tc *v1alpha1.TidbCluster, _ *apps.StatefulSet, _ *apps.StatefulSet) error {
+	tc.Status.Pump.Phase = v1alpha1.UpgradePhase
+	return nil
+}
@@ -0,0 +1,247 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestPumpUpgraderUpgrade(t *testing.T) {
+	tests := []struct {
+		name      string
+		states    []string
+		consumed  bool
+		changeFn  func(*v1alpha1.TidbCluster)
+		changeSet func(*apps.StatefulSet)
+		requeue   bool
+		paused    []string
+		partition int32
+	}{
+		{
+			name:      "the online pump is paused first",
+			states:    []string{"online", "online"},
+			requeue:   true,
+			paused:    []string{"upgrader-pump-0.upgrader-pump:8250"},
+			partition: 1,
+		},
+		{
+			name:      "the pausing pump is waited",
+			states:    []string{"pausing", "online"},
+			requeue:   true,
+			partition: 1,
+		},
+		{
+			name:      "the binlogs of the paused pump are not consumed",
+			states:    []string{"paused", "online"},
+			consumed:  false,
+			requeue:   true,
+			partition: 1,
+		},
+		{
+			name:      "the binlogs of the paused pump are consumed",
+			states:    []string{"paused", "online"},
+			consumed:  true,
+			partition: 0,
+		},
+		{
+			name:      "the upgraded pump is not online",
+			states:    []string{"online", "paused"},
+			requeue:   true,
+			partition: 1,
+		},
+		{
+			name:   "the only online pump is upgraded without pausing",
+			states: []string{"offline", "online"},
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.Pump.StatefulSet.UpdateRevision = "3"
+			},
+			changeSet: func(set *apps.StatefulSet) {
+				set.Spec.UpdateStrategy.RollingUpdate.Partition = pointer.Int32Ptr(2)
+			},
+			partition: 1,
+		},
+		{
+			name:   "the statefulset without partition is upgraded from the highest ordinal",
+			states: []string{"online", "online"},
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.Pump.StatefulSet.UpdateRevision = "3"
+			},
+			changeSet: func(set *apps.StatefulSet) {
+				set.Spec.UpdateStrategy.RollingUpdate = nil
+			},
+			requeue:   true,
+			paused:    []string{"upgrader-pump-1.upgrader-pump:8250"},
+			partition: 2,
+		},
+		{
+			name:   "the upgrade is paused",
+			states: []string{"online", "online"},
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.Pump.UpgradePaused = true
+			},
+			partition: 1,
+		},
+		{
+			name:   "the statefulset status is not synced",
+			states: []string{"online", "online"},
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.Pump.StatefulSet = nil
+			},
+			requeue:   true,
+			partition: 1,
+		},
+		{
+			name:      "the pump not in the cluster is upgraded",
+			states:    []string{"offline", "online"},
+			partition: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			deps := controller.NewFakeDependencies()
+			client := &fakeBinlogClient{consumed: test.consumed}
+			upgrader := &pumpUpgrader{deps: deps, binlogClient: client}
+			tc := newTidbClusterForPumpUpgrader(test.states)
+			if test.changeFn != nil {
+				test.changeFn(tc)
+			}
+			for i := int32(0); i < 2; i++ {
+				pod := newPumpPodForUpgrader(i, fmt.Sprint(i+1))
+				g.Expect(deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer().Add(pod)).To(Succeed())
+			}
+			oldSet := newStatefulSetForPumpUpgrader()
+			if test.changeSet != nil {
+				test.changeSet(oldSet)
+			}
+			SetStatefulSetLastAppliedConfigAnnotation(oldSet)
+			newSet := oldSet.DeepCopy()
+
+			err := upgrader.Upgrade(tc, oldSet, newSet)
+			if test.requeue {
+				g.Expect(controller.IsRequeueError(err)).To(BeTrue(), "%v", err)
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			g.Expect(client.paused).To(Equal(test.paused))
+			g.Expect(tc.Status.Pump.Phase).To(Equal(v1alpha1.UpgradePhase))
+			g.Expect(newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(pointer.Int32Ptr(test.partition)))
+		})
+	}
+}
+
+func TestResumePausedPumps(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	tc := newTidbClusterForPumpUpgrader([]string{"paused", "online"})
+	for i := int32(0); i < 2; i++ {
+		pod := newPumpPodForUpgrader(i, "1")
+		g.Expect(deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer().Add(pod)).To(Succeed())
+	}
+	podExists := func(ordinal int32) bool {
+		_, err := deps.PodLister.Pods(tc.GetNamespace()).Get(ordinalPodName(v1alpha1.PumpMemberType, upgradeTcName, ordinal))
+		return err == nil
+	}
+
+	// the paused pump is kept while the upgrade is in progress
+	g.Expect(resumePausedPumps(deps, tc)).To(Succeed())
+	g.Expect(podExists(0)).To(BeTrue())
+
+	// the paused pump is restarted once the upgrade is reverted
+	tc.Status.Pump.StatefulSet.UpdateRevision = "1"
+	g.Expect(resumePausedPumps(deps, tc)).To(Succeed())
+	g.Expect(podExists(0)).To(BeFalse())
+	g.Expect(podExists(1)).To(BeTrue())
+}
+
+func newTidbClusterForPumpUpgrader(states []string) *v1alpha1.TidbCluster {
+	tc := &v1alpha1.TidbCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      upgradeTcName,
+			Namespace: corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.TidbClusterSpec{
+			Pump: &v1alpha1.PumpSpec{Replicas: 2},
+		},
+		Status: v1alpha1.TidbClusterStatus{
+			Pump: v1alpha1.PumpStatus{
+				Phase: v1alpha1.NormalPhase,
+				StatefulSet: &apps.StatefulSetStatus{
+					CurrentRevision: "1",
+					UpdateRevision:  "2",
+				},
+			},
+		},
+	}
+	for i, state := range states {
+		name := ordinalPodName(v1alpha1.PumpMemberType, upgradeTcName, int32(i))
+		tc.Status.Pump.Members = append(tc.Status.Pump.Members, &v1alpha1.PumpNodeStatus{
+			NodeID: name,
+			Host:   fmt.Sprintf("%s.upgrader-pump:8250", name),
+			State:  state,
+		})
+	}
+	return tc
+}
+
+func newPumpPodForUpgrader(ordinal int32, revision string) *corev1.Pod {
+	labels := label.New().Instance(upgradeInstanceName).Pump().Labels()
+	labels[apps.ControllerRevisionHashLabelKey] = revision
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ordinalPodName(v1alpha1.PumpMemberType, upgradeTcName, ordinal),
+			Namespace: corev1.NamespaceDefault,
+			Labels:    labels,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:    "pump",
+					Command: []string{"/pump \\\n-advertise-addr=`echo ${HOSTNAME}`.upgrader-pump:8250 \\\n-data-dir=/data"},
+				},
+			},
+		},
+	}
+}
+
+func newStatefulSetForPumpUpgrader() *apps.StatefulSet {
+	return &apps.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      controller.PumpMemberName(upgradeTcName),
+			Namespace: corev1.NamespaceDefault,
+		},
+		Spec: apps.StatefulSetSpec{
+			Replicas: pointer.Int32Ptr(2),
+			UpdateStrategy: apps.StatefulSetUpdateStrategy{
+				Type: apps.RollingUpdateStatefulSetStrategyType,
+				RollingUpdate: &apps.RollingUpdateStatefulSetStrategy{
+					Partition: pointer.Int32Ptr(1),
+				},
+			},
+		},
+	}
+}
@@ -16,6 +16,7 @@ package member
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
@@ -37,6 +38,7 @@ const (
 	skipReasonPVCCleanerPVCNotBound              = "pvc cleaner: the pvc is not bound"
 	skipReasonPVCCleanerPVCNotHasPodNameAnn      = "pvc cleaner: pvc has no pod name annotation"
 	skipReasonPVCCleanerIsNotDeferDeletePVC      = "pvc cleaner: pvc has not been marked as defer delete pvc"
+	skipReasonPVCCleanerDeferDeletePeriod        = "pvc cleaner: pvc is kept in the defer delete period"
 	skipReasonPVCCleanerPVCeferencedByPod        = "pvc cleaner: pvc is still referenced by a pod"
 	skipReasonPVCCleanerNotFoundPV               = "pvc cleaner: not found pv bound to pvc"
 	skipReasonPVCCleanerPVCHasBeenDeleted        = "pvc cleaner: pvc has been deleted"
@@ -64,6 +66,9 @@ func (c *realPVCCleaner) Clean(meta metav1.Object) (map[string]string, error) {
 	if skipReason, err := c.cleanScheduleLock(meta); err != nil {
 		return skipReason, err
 	}
+	if tc, ok := meta.(*v1alpha1.TidbCluster); ok {
+		pruneVolumeSnapshots(c.deps, tc)
+	}
 	return c.reclaimPV(meta)
 }
 
@@ -96,7 +101,7 @@ func (c *realPVCCleaner) reclaimPV(meta metav1.Object) (map[string]string, error
 	for _, pvc := range pvcs {
 		pvcName := pvc.GetName()
 		l := label.Label(pvc.Labels)
-		if !(l.IsPD() || l.IsTiKV() || l.IsTiFlash() || l.IsDMMaster() || l.IsDMWorker()) {
+		if !(l.IsPD() || l.IsTiKV() || l.IsTiFlash() || l.IsTiDBGreen() || l.IsDMMaster() || l.IsDMWorker()) {
 			skipReason[pvcName] = skipReasonPVCCleanerIsNotTarget
 			continue
 		}
@@ -119,6 +124,12 @@ func (c *realPVCCleaner) reclaimPV(meta metav1.Object) (map[string]string, error
 			continue
 		}
 
+		if tc, ok := meta.(*v1alpha1.TidbCluster); ok && inPVCDeferDeletePeriod(tc, pvc) {
+			// PVC is kept to recover the data of a mistaken scale-in
+			skipReason[pvcName] = skipReasonPVCCleanerDeferDeletePeriod
+			continue
+		}
+
 		// PVC has been marked as defer delete PVC, try to reclaim the PV bound to this PVC
 		podName, exist := pvc.Annotations[label.AnnPodNameKey]
 		if !exist {
@@ -193,6 +204,22 @@ func (c *realPVCCleaner) reclaimPV(meta metav1.Object) (map[string]string, error
 	return skipReason, nil
 }
 
+// inPVCDeferDeletePeriod returns whether the PVC marked as defer deleting is
+// still in the spec.pvcDeferDeletePeriod of the tc since it's marked.
+func inPVCDeferDeletePeriod(tc *v1alpha1.TidbCluster, pvc *corev1.PersistentVolumeClaim) bool {
+	period := tc.PVCDeferDeletePeriod()
+	if period <= 0 {
+		return false
+	}
+	value := pvc.Annotations[label.AnnPVCDeferDeleting]
+	markedAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		klog.Warningf("tidbcluster %s/%s pvc %s has an invalid %s annotation %q, ignore the defer delete period", tc.Namespace, tc.Name, pvc.Name, label.AnnPVCDeferDeleting, value)
+		return false
+	}
+	return time.Since(markedAt) < period
+}
+
 // cleanScheduleLock cleans AnnPVCPodScheduling label if necessary.
 func (c *realPVCCleaner) cleanScheduleLock(meta metav1.Object) (map[string]string, error) {
 	ns := meta.GetNamespace()
@@ -215,7 +242,7 @@ func (c *realPVCCleaner) cleanScheduleLock(meta metav1.Object) (map[string]strin
 	for _, pvc := range pvcs {
 		pvcName := pvc.GetName()
 		l := label.Label(pvc.Labels)
-		if !(l.IsPD() || l.IsTiKV() || l.IsTiFlash() || l.IsDMMaster() || l.IsDMWorker()) {
+		if !(l.IsPD() || l.IsTiKV() || l.IsTiFlash() || l.IsTiDBGreen() || l.IsDMMaster() || l.IsDMWorker()) {
 			skipReason[pvcName] = skipReasonPVCCleanerIsNotTarget
 			continue
 		}
@@ -258,7 +285,11 @@ func (c *realPVCCleaner) cleanScheduleLock(meta metav1.Object) (map[string]strin
 			continue
 		}
 
-		if pvc.Status.Phase != corev1.ClaimBound || pod.Spec.NodeName == "" {
+		if pod.Spec.SchedulerName != "" && pod.Spec.SchedulerName != c.deps.CLIConfig.TiDBSchedulerName {
+			// The pod is scheduled by a custom scheduler that never releases the lock,
+			// clean it so it doesn't block the HA scheduling of other pods
+			klog.Infof("%s %s/%s pod %s is scheduled by %q, clean pvc %s pod schedule annotation", clusterType, ns, metaName, podName, pod.Spec.SchedulerName, pvcName)
+		} else if pvc.Status.Phase != corev1.ClaimBound || pod.Spec.NodeName == "" {
 			// This pod has not been scheduled yet, no need to clean up the pvc pod schedule annotation
 			klog.V(4).Infof("%s %s/%s pod %s has not been scheduled yet, skip clean pvc %s pod schedule annotation", clusterType, ns, metaName, podName, pvcName)
 			skipReason[pvcName] = skipReasonPVCCleanerPodWaitingForScheduling
@@ -39,21 +39,23 @@ func TestPVCCleanerReclaimPV(t *testing.T) {
 
 	tc := newTidbClusterForPD()
 	type testcase struct {
-		name             string
-		pvReclaimEnabled bool
-		pods             []*corev1.Pod
-		apiPods          []*corev1.Pod
-		pvcs             []*corev1.PersistentVolumeClaim
-		apiPvcs          []*corev1.PersistentVolumeClaim
-		pvs              []*corev1.PersistentVolume
-		getPodFailed     bool
-		patchPVFailed    bool
-		getPVCFailed     bool
-		deletePVCFailed  bool
-		expectFn         func(*GomegaWithT, map[string]string, *realPVCCleaner, error)
+		name              string
+		pvReclaimEnabled  bool
+		deferDeletePeriod *string
+		pods              []*corev1.Pod
+		apiPods           []*corev1.Pod
+		pvcs              []*corev1.PersistentVolumeClaim
+		apiPvcs           []*corev1.PersistentVolumeClaim
+		pvs               []*corev1.PersistentVolume
+		getPodFailed      bool
+		patchPVFailed     bool
+		getPVCFailed      bool
+		deletePVCFailed   bool
+		expectFn          func(*GomegaWithT, map[string]string, *realPVCCleaner, error)
 	}
 	testFn := func(test *testcase, t *testing.T) {
 		tc.Spec.EnablePVReclaim = pointer.BoolPtr(test.pvReclaimEnabled)
+		tc.Spec.PVCDeferDeletePeriod = test.deferDeletePeriod
 		pcc, fakeCli, podIndexer, pvcIndexer, pvcControl, pvIndexer, pvControl := newFakePVCCleaner()
 		if test.pods != nil {
 			for _, pod := range test.pods {
@@ -258,6 +260,58 @@ func TestPVCCleanerReclaimPV(t *testing.T) {
 				g.Expect(skipReason["pd-test-pd-0"]).To(Equal(skipReasonPVCCleanerIsNotDeferDeletePVC))
 			},
 		},
+		{
+			name:              "pvc is in the defer delete period",
+			pvReclaimEnabled:  true,
+			deferDeletePeriod: pointer.StringPtr("24h"),
+			pvcs: []*corev1.PersistentVolumeClaim{
+				{
+					TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: metav1.NamespaceDefault,
+						Name:      "pd-test-pd-0",
+						Labels:    label.New().Instance(tc.GetInstanceName()).PD().Labels(),
+						Annotations: map[string]string{
+							label.AnnPVCDeferDeleting: time.Now().Add(-time.Hour).Format(time.RFC3339),
+						},
+					},
+					Status: corev1.PersistentVolumeClaimStatus{
+						Phase: corev1.ClaimBound,
+					},
+				},
+			},
+			expectFn: func(g *GomegaWithT, skipReason map[string]string, _ *realPVCCleaner, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(len(skipReason)).To(Equal(1))
+				g.Expect(skipReason["pd-test-pd-0"]).To(Equal(skipReasonPVCCleanerDeferDeletePeriod))
+			},
+		},
+		{
+			name:              "pvc is out of the defer delete period",
+			pvReclaimEnabled:  true,
+			deferDeletePeriod: pointer.StringPtr("24h"),
+			pvcs: []*corev1.PersistentVolumeClaim{
+				{
+					TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: metav1.NamespaceDefault,
+						Name:      "pd-test-pd-0",
+						Labels:    label.New().Instance(tc.GetInstanceName()).PD().Labels(),
+						Annotations: map[string]string{
+							label.AnnPVCDeferDeleting: time.Now().Add(-25 * time.Hour).Format(time.RFC3339),
+						},
+					},
+					Status: corev1.PersistentVolumeClaimStatus{
+						Phase: corev1.ClaimBound,
+					},
+				},
+			},
+			expectFn: func(g *GomegaWithT, skipReason map[string]string, _ *realPVCCleaner, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(len(skipReason)).To(Equal(1))
+				g.Expect(skipReason["pd-test-pd-0"]).To(Equal(skipReasonPVCCleanerPVCNotHasPodNameAnn))
+			},
+		},
 		{
 			name:             "pvc not has pod name annotation",
 			pvReclaimEnabled: true,
@@ -857,12 +911,16 @@ func TestPVCCleanerCleanScheduleLock(t *testing.T) {
 		pods            []*corev1.Pod
 		pvcs            []*corev1.PersistentVolumeClaim
 		updatePVCFailed bool
+		schedulerName   string
 		expectFn        func(*GomegaWithT, map[string]string, *realPVCCleaner, error)
 	}
 	testFn := func(test *testcase, t *testing.T) {
 		t.Log(test.name)
 
 		pcc, _, podIndexer, pvcIndexer, pvcControl, _, _ := newFakePVCCleaner()
+		if test.schedulerName != "" {
+			pcc.deps.CLIConfig.TiDBSchedulerName = test.schedulerName
+		}
 		if test.pods != nil {
 			for _, pod := range test.pods {
 				podIndexer.Add(pod)
@@ -1087,6 +1145,79 @@ func TestPVCCleanerCleanScheduleLock(t *testing.T) {
 				g.Expect(skipReason["pd-test-pd-0"]).To(Equal(skipReasonPVCCleanerPodWaitingForScheduling))
 			},
 		},
+		{
+			name:          "pod scheduled by the configured tidb-scheduler is waiting for scheduling",
+			schedulerName: "tidb-scheduler-release",
+			pods: []*corev1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-pd-0",
+						Namespace: metav1.NamespaceDefault,
+						Labels:    label.New().Instance(tc.GetInstanceName()).PD().Labels(),
+					},
+					Spec: corev1.PodSpec{
+						SchedulerName: "tidb-scheduler-release",
+					},
+				},
+			},
+			pvcs: []*corev1.PersistentVolumeClaim{
+				{
+					TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: metav1.NamespaceDefault,
+						Name:      "pd-test-pd-0",
+						Labels:    label.New().Instance(tc.GetInstanceName()).PD().Labels(),
+						Annotations: map[string]string{
+							label.AnnPVCPodScheduling: "true",
+							label.AnnPodNameKey:       "test-pd-0",
+						},
+					},
+					Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+				},
+			},
+			expectFn: func(g *GomegaWithT, skipReason map[string]string, pcc *realPVCCleaner, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(skipReason["pd-test-pd-0"]).To(Equal(skipReasonPVCCleanerPodWaitingForScheduling))
+			},
+		},
+		{
+			name: "pod scheduled by a custom scheduler is waiting for scheduling",
+			pods: []*corev1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-pd-0",
+						Namespace: metav1.NamespaceDefault,
+						Labels:    label.New().Instance(tc.GetInstanceName()).PD().Labels(),
+					},
+					Spec: corev1.PodSpec{
+						SchedulerName: "custom-scheduler",
+					},
+				},
+			},
+			pvcs: []*corev1.PersistentVolumeClaim{
+				{
+					TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: metav1.NamespaceDefault,
+						Name:      "pd-test-pd-0",
+						Labels:    label.New().Instance(tc.GetInstanceName()).PD().Labels(),
+						Annotations: map[string]string{
+							label.AnnPVCPodScheduling: "true",
+							label.AnnPodNameKey:       "test-pd-0",
+						},
+					},
+					Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+				},
+			},
+			expectFn: func(g *GomegaWithT, skipReason map[string]string, pcc *realPVCCleaner, err error) {
+				g.Expect(len(skipReason)).To(Equal(0))
+				g.Expect(err).NotTo(HaveOccurred())
+				pvc, err := pcc.deps.PVCLister.PersistentVolumeClaims(metav1.NamespaceDefault).Get("pd-test-pd-0")
+				g.Expect(err).NotTo(HaveOccurred())
+				_, exist := pvc.Annotations[label.AnnPVCPodScheduling]
+				g.Expect(exist).To(BeFalse())
+			},
+		},
 		{
 			name: "pvc that need to remove the schedule lock but update pvc failed",
 			pods: []*corev1.Pod{
@@ -18,11 +18,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/util"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -59,6 +63,13 @@ import (
 //   recreated after the `FileSystemResizePending` condition becomes true.
 // - Shrinking volumes is not supported.
 //
+// Failures:
+//
+// The PVCs whose expansion failed, or which have waited for the file system
+// resize for longer than the timeout, are reported in the PVCResizeFailed
+// condition of the TidbCluster. A failed PVC is not expanded to another size
+// until pvcResizeRetryBackoff elapses since the failure.
+//
 type PVCResizerInterface interface {
 	Resize(*v1alpha1.TidbCluster) error
 	ResizeDM(*v1alpha1.DMCluster) error
@@ -74,6 +85,24 @@ var (
 
 	dmMasterRequirement = util.MustNewRequirement(label.ComponentLabelKey, selection.Equals, []string{label.DMMasterLabelVal})
 	dmWorkerRequirement = util.MustNewRequirement(label.ComponentLabelKey, selection.Equals, []string{label.DMWorkerLabelVal})
+
+	// pvcResizeRetryBackoff is how long a PVC whose expansion failed waits
+	// before it is expanded to another size
+	pvcResizeRetryBackoff = 10 * time.Minute
+)
+
+const (
+	// pvcControllerResizeError and pvcNodeResizeError are the conditions
+	// Kubernetes sets on a PVC when the expansion of its volume fails
+	pvcControllerResizeError corev1.PersistentVolumeClaimConditionType = "ControllerResizeError"
+	pvcNodeResizeError       corev1.PersistentVolumeClaimConditionType = "NodeResizeError"
+
+	// pvcResizeFailedReason is the reason of the PVCResizeFailed condition
+	// when the expansion of some PVCs failed
+	pvcResizeFailedReason = "ResizeFailed"
+	// pvcNoResizeFailureReason is the reason of the PVCResizeFailed condition
+	// when no PVC failed to expand
+	pvcNoResizeFailureReason = "NoResizeFailure"
 )
 
 type pvcResizer struct {
@@ -139,7 +168,7 @@ func (p *pvcResizer) Resize(tc *v1alpha1.TidbCluster) error {
 			key := fmt.Sprintf("%s-%s-%s", tikvMemberType, tc.Name, tikvMemberType)
 			pvcPrefix2Quantity[key] = quantity
 		}
-		for _, sv := range tc.Spec.TiKV.StorageVolumes {
+		for _, sv := range tikvStorageVolumes(tc.Spec.TiKV) {
 			key := fmt.Sprintf("%s-%s-%s-%s", tikvMemberType, sv.Name, tc.Name, tikvMemberType)
 			if quantity, err := resource.ParseQuantity(sv.StorageSize); err == nil {
 				pvcPrefix2Quantity[key] = quantity
@@ -193,9 +222,91 @@ func (p *pvcResizer) Resize(tc *v1alpha1.TidbCluster) error {
 			return err
 		}
 	}
+	return p.syncResizeFailures(tc, selector)
+}
+
+// syncResizeFailures reports the PVCs of the cluster whose expansion failed in
+// the PVCResizeFailed condition, and records since when they failed so they
+// are not expanded again before the backoff elapses.
+func (p *pvcResizer) syncResizeFailures(tc *v1alpha1.TidbCluster, selector labels.Selector) error {
+	ns := tc.GetNamespace()
+	pvcs, err := p.deps.PVCLister.PersistentVolumeClaims(ns).List(selector)
+	if err != nil {
+		return err
+	}
+
+	timeout := tc.PVCFileSystemResizeTimeout()
+	var failures []string
+	for _, pvc := range pvcs {
+		reason := pvcResizeFailure(pvc, timeout)
+		_, recorded := pvc.Annotations[label.AnnPVCResizeFailedAt]
+		if reason == "" {
+			if recorded {
+				if err := p.annotatePVCResizeFailedAt(pvc, nil); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		klog.Warningf("PVC %s/%s failed to expand: %s", ns, pvc.Name, reason)
+		if !recorded {
+			now := time.Now().Format(time.RFC3339)
+			if err := p.annotatePVCResizeFailedAt(pvc, &now); err != nil {
+				return err
+			}
+		}
+		failures = append(failures, fmt.Sprintf("%s: %s", pvc.Name, reason))
+	}
+
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		utiltidbcluster.UpdateTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+			v1alpha1.TidbClusterPVCResizeFailed, corev1.ConditionTrue, pvcResizeFailedReason, strings.Join(failures, "; ")))
+		return nil
+	}
+	if utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterPVCResizeFailed) != nil {
+		utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+			v1alpha1.TidbClusterPVCResizeFailed, corev1.ConditionFalse, pvcNoResizeFailureReason, "no PVC failed to expand"))
+	}
 	return nil
 }
 
+// pvcResizeFailure returns why the expansion of the PVC failed, or "" if it
+// did not fail
+func pvcResizeFailure(pvc *corev1.PersistentVolumeClaim, timeout time.Duration) string {
+	for _, cond := range pvc.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case pvcControllerResizeError, pvcNodeResizeError:
+			return fmt.Sprintf("%s: %s", cond.Type, cond.Message)
+		case corev1.PersistentVolumeClaimFileSystemResizePending:
+			if !cond.LastTransitionTime.IsZero() && time.Since(cond.LastTransitionTime.Time) > timeout {
+				return fmt.Sprintf("file system resize is pending for longer than %s, the volume may not support online expansion", timeout)
+			}
+		}
+	}
+	return ""
+}
+
+// annotatePVCResizeFailedAt records since when the expansion of the PVC
+// fails, or removes the record if failedAt is nil
+func (p *pvcResizer) annotatePVCResizeFailedAt(pvc *corev1.PersistentVolumeClaim, failedAt *string) error {
+	mergePatch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]*string{
+				label.AnnPVCResizeFailedAt: failedAt,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = p.deps.KubeClientset.CoreV1().PersistentVolumeClaims(pvc.Namespace).Patch(context.TODO(), pvc.Name, types.MergePatchType, mergePatch, metav1.PatchOptions{})
+	return err
+}
+
 // ResizeDM do things similar to Resize for TidbCluster
 func (p *pvcResizer) ResizeDM(dc *v1alpha1.DMCluster) error {
 	ns := dc.GetNamespace()
@@ -276,6 +387,10 @@ func (p *pvcResizer) patchPVCs(ns string, selector labels.Selector, pvcQuantityI
 		}
 
 		if quantityInSpec.Cmp(currentRequest) > 0 {
+			if failedAt, err := time.Parse(time.RFC3339, pvc.Annotations[label.AnnPVCResizeFailedAt]); err == nil && time.Since(failedAt) < pvcResizeRetryBackoff {
+				klog.V(4).Infof("PVC %s/%s failed to expand at %s, skip expanding it to %s until %s elapses", pvc.Namespace, pvc.Name, failedAt, quantityInSpec.String(), pvcResizeRetryBackoff)
+				continue
+			}
 			if p.deps.StorageClassLister != nil {
 				volumeExpansionSupported, err := p.isVolumeExpansionSupported(*pvc.Spec.StorageClassName)
 				if err != nil {
@@ -17,11 +17,14 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	. "github.com/onsi/gomega"
 	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
 	v1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -197,6 +200,29 @@ func TestPVCResizer(t *testing.T) {
 				newPVCWithStorage("tikv-log-tc-tikv-2", label.TiKVLabelVal, "sc", "2Gi"),
 			},
 		},
+		{
+			name: "resize TiKV WAL PVCs",
+			tc: &v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: v1.NamespaceDefault,
+					Name:      "tc",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					TiKV: &v1alpha1.TiKVSpec{
+						WalStorageSize: "2Gi",
+					},
+				},
+			},
+			sc: newStorageClass("sc", true),
+			pvcs: []*v1.PersistentVolumeClaim{
+				newPVCWithStorage("tikv-tc-tikv-0", label.TiKVLabelVal, "sc", "1Gi"),
+				newPVCWithStorage("tikv-wal-tc-tikv-0", label.TiKVLabelVal, "sc", "1Gi"),
+			},
+			wantPVCs: []*v1.PersistentVolumeClaim{
+				newPVCWithStorage("tikv-tc-tikv-0", label.TiKVLabelVal, "sc", "1Gi"),
+				newPVCWithStorage("tikv-wal-tc-tikv-0", label.TiKVLabelVal, "sc", "2Gi"),
+			},
+		},
 		{
 			name: "resize TiFlash PVCs",
 			tc: &v1alpha1.TidbCluster{
@@ -514,3 +540,95 @@ func TestDMPVCResizer(t *testing.T) {
 		})
 	}
 }
+
+func TestPVCResizerResizeFailure(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	newTC := func() *v1alpha1.TidbCluster {
+		return &v1alpha1.TidbCluster{
+			ObjectMeta: metav1.ObjectMeta{Namespace: v1.NamespaceDefault, Name: "tc"},
+			Spec: v1alpha1.TidbClusterSpec{
+				TiKV: &v1alpha1.TiKVSpec{
+					ResourceRequirements: v1.ResourceRequirements{
+						Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse("2Gi")},
+					},
+				},
+			},
+		}
+	}
+	newExpandingPVC := func(name string, condType v1.PersistentVolumeClaimConditionType, since time.Duration) *v1.PersistentVolumeClaim {
+		pvc := newPVCWithStorage(name, label.TiKVLabelVal, "sc", "2Gi")
+		pvc.Status.Capacity = v1.ResourceList{v1.ResourceStorage: resource.MustParse("1Gi")}
+		if condType != "" {
+			pvc.Status.Conditions = []v1.PersistentVolumeClaimCondition{{
+				Type:               condType,
+				Status:             v1.ConditionTrue,
+				Message:            "volume plugin does not support expansion",
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-since)),
+			}}
+		}
+		return pvc
+	}
+	resize := func(tc *v1alpha1.TidbCluster, pvcs ...*v1.PersistentVolumeClaim) *controller.Dependencies {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		fakeDeps := controller.NewFakeDependencies()
+		for _, pvc := range pvcs {
+			_, err := fakeDeps.KubeClientset.CoreV1().PersistentVolumeClaims(pvc.Namespace).Create(context.TODO(), pvc, metav1.CreateOptions{})
+			g.Expect(err).NotTo(HaveOccurred())
+		}
+		_, err := fakeDeps.KubeClientset.StorageV1().StorageClasses().Create(context.TODO(), newStorageClass("sc", true), metav1.CreateOptions{})
+		g.Expect(err).NotTo(HaveOccurred())
+		fakeDeps.KubeInformerFactory.Start(ctx.Done())
+		fakeDeps.KubeInformerFactory.WaitForCacheSync(ctx.Done())
+		g.Expect(NewPVCResizer(fakeDeps).Resize(tc)).To(Succeed())
+		return fakeDeps
+	}
+	getPVC := func(deps *controller.Dependencies, name string) *v1.PersistentVolumeClaim {
+		pvc, err := deps.KubeClientset.CoreV1().PersistentVolumeClaims(v1.NamespaceDefault).Get(context.TODO(), name, metav1.GetOptions{})
+		g.Expect(err).NotTo(HaveOccurred())
+		return pvc
+	}
+	request := func(pvc *v1.PersistentVolumeClaim) string {
+		q := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+		return q.String()
+	}
+
+	// the failed expansions are reported and recorded on the PVCs
+	tc := newTC()
+	deps := resize(tc,
+		newExpandingPVC("tikv-tc-tikv-0", pvcControllerResizeError, time.Minute),
+		newExpandingPVC("tikv-tc-tikv-1", v1.PersistentVolumeClaimFileSystemResizePending, time.Hour),
+		newExpandingPVC("tikv-tc-tikv-2", v1.PersistentVolumeClaimFileSystemResizePending, time.Minute),
+	)
+	cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterPVCResizeFailed)
+	g.Expect(cond).NotTo(BeNil())
+	g.Expect(cond.Status).To(Equal(v1.ConditionTrue))
+	g.Expect(cond.Message).To(ContainSubstring("tikv-tc-tikv-0: ControllerResizeError"))
+	g.Expect(cond.Message).To(ContainSubstring("tikv-tc-tikv-1: file system resize is pending"))
+	g.Expect(cond.Message).NotTo(ContainSubstring("tikv-tc-tikv-2"))
+	pvc := getPVC(deps, "tikv-tc-tikv-0")
+	g.Expect(request(pvc)).To(Equal("2Gi"))
+	g.Expect(pvc.Annotations).To(HaveKey(label.AnnPVCResizeFailedAt))
+	g.Expect(getPVC(deps, "tikv-tc-tikv-2").Annotations).NotTo(HaveKey(label.AnnPVCResizeFailedAt))
+
+	// the failed PVC is not expanded to another size within the backoff
+	tc.Spec.TiKV.Requests[v1.ResourceStorage] = resource.MustParse("3Gi")
+	pvc = newExpandingPVC("tikv-tc-tikv-0", pvcControllerResizeError, time.Minute)
+	pvc.Annotations = map[string]string{label.AnnPVCResizeFailedAt: time.Now().Add(-time.Minute).Format(time.RFC3339)}
+	deps = resize(tc, pvc)
+	g.Expect(request(getPVC(deps, "tikv-tc-tikv-0"))).To(Equal("2Gi"))
+
+	// but is expanded after the backoff
+	pvc.Annotations[label.AnnPVCResizeFailedAt] = time.Now().Add(-pvcResizeRetryBackoff - time.Minute).Format(time.RFC3339)
+	deps = resize(tc, pvc)
+	g.Expect(request(getPVC(deps, "tikv-tc-tikv-0"))).To(Equal("3Gi"))
+
+	// the record is removed once the PVC does not fail anymore
+	pvc = newPVCWithStorage("tikv-tc-tikv-0", label.TiKVLabelVal, "sc", "3Gi")
+	pvc.Annotations = map[string]string{label.AnnPVCResizeFailedAt: time.Now().Format(time.RFC3339)}
+	deps = resize(tc, pvc)
+	g.Expect(getPVC(deps, "tikv-tc-tikv-0").Annotations).NotTo(HaveKey(label.AnnPVCResizeFailedAt))
+	cond = utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterPVCResizeFailed)
+	g.Expect(cond.Status).To(Equal(v1.ConditionFalse))
+}
@@ -0,0 +1,198 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+)
+
+const (
+	volumeSnapshotGroup    = "snapshot.storage.k8s.io"
+	volumeSnapshotKind     = "VolumeSnapshot"
+	volumeSnapshotResource = "volumesnapshots"
+)
+
+// volumeSnapshotVersions are the served versions of the VolumeSnapshot API, by preference
+var volumeSnapshotVersions = []string{"v1", "v1beta1"}
+
+// getVolumeSnapshotGroupVersion returns the preferred VolumeSnapshot API
+// served by the Kubernetes cluster, or false if it is not served
+func getVolumeSnapshotGroupVersion(deps *controller.Dependencies) (schema.GroupVersion, bool) {
+	for _, version := range volumeSnapshotVersions {
+		gv := schema.GroupVersion{Group: volumeSnapshotGroup, Version: version}
+		resources, err := deps.KubeClientset.Discovery().ServerResourcesForGroupVersion(gv.String())
+		if err != nil {
+			continue
+		}
+		for _, r := range resources.APIResources {
+			if r.Name == volumeSnapshotResource {
+				return gv, true
+			}
+		}
+	}
+	return schema.GroupVersion{}, false
+}
+
+// pvcSnapshotName returns the name of the VolumeSnapshot of the PVC, the UID
+// tells apart the PVCs recreated with the same name
+func pvcSnapshotName(pvc *corev1.PersistentVolumeClaim) string {
+	uid := string(pvc.GetUID())
+	if len(uid) > 8 {
+		uid = uid[:8]
+	}
+	return fmt.Sprintf("%s-%s", pvc.GetName(), uid)
+}
+
+// snapshotPVCsBeforeDeletion takes a VolumeSnapshot of each PVC if the
+// snapshot spec, e.g. `.spec.snapshotBeforeDeletingPVC`, is set, and returns a requeue error until
+// all the snapshots are cut, i.e. `.status.creationTime` of the snapshots is set.
+// A snapshot reporting `.status.error` is skipped, it is recorded as failed in
+// the status and reported by an Event once.
+// The PVCs may be deleted if it returns nil.
+func snapshotPVCsBeforeDeletion(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, spec *v1alpha1.PVCSnapshotSpec, pvcs []*corev1.PersistentVolumeClaim) error {
+	if spec == nil || len(pvcs) == 0 {
+		return nil
+	}
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	gv, ok := getVolumeSnapshotGroupVersion(deps)
+	if !ok {
+		klog.Warningf("tidbcluster: [%s/%s] VolumeSnapshot API is not served, delete PVCs without snapshots", ns, tcName)
+		deps.Recorder.Event(tc, corev1.EventTypeWarning, "VolumeSnapshotUnsupported", "VolumeSnapshot API is not served, PVCs are deleted without snapshots")
+		return nil
+	}
+
+	var pending []string
+	for _, pvc := range pvcs {
+		if pvc.DeletionTimestamp != nil {
+			continue
+		}
+		name := pvcSnapshotName(pvc)
+		snapshot := &unstructured.Unstructured{}
+		snapshot.SetGroupVersionKind(gv.WithKind(volumeSnapshotKind))
+		err := deps.GenericClient.Get(context.TODO(), types.NamespacedName{Namespace: ns, Name: name}, snapshot)
+		if errors.IsNotFound(err) {
+			snapshot = newPVCSnapshot(tc, spec, pvc, gv, name)
+			if err := deps.GenericClient.Create(context.TODO(), snapshot); err != nil && !errors.IsAlreadyExists(err) {
+				return fmt.Errorf("snapshotPVCsBeforeDeletion: failed to create VolumeSnapshot %s/%s for PVC %s, error: %v", ns, name, pvc.GetName(), err)
+			}
+			klog.Infof("snapshotPVCsBeforeDeletion: create VolumeSnapshot %s/%s for PVC %s successfully", ns, name, pvc.GetName())
+			deps.Recorder.Eventf(tc, corev1.EventTypeNormal, "VolumeSnapshotCreated", "VolumeSnapshot %s/%s created for PVC %s before deletion", ns, name, pvc.GetName())
+		} else if err != nil {
+			return fmt.Errorf("snapshotPVCsBeforeDeletion: failed to get VolumeSnapshot %s/%s, error: %v", ns, name, err)
+		}
+
+		if tc.Status.VolumeSnapshots == nil {
+			tc.Status.VolumeSnapshots = map[string]v1alpha1.PVCSnapshot{}
+		}
+		recorded, ok := tc.Status.VolumeSnapshots[name]
+		if !ok {
+			recorded = v1alpha1.PVCSnapshot{
+				SnapshotName: name,
+				PVCUID:       pvc.GetUID(),
+				PVCName:      pvc.GetName(),
+				CreatedAt:    metav1.Now(),
+			}
+		}
+
+		creationTime, _, _ := unstructured.NestedString(snapshot.Object, "status", "creationTime")
+		_, failed, _ := unstructured.NestedMap(snapshot.Object, "status", "error")
+		if creationTime == "" && failed && !recorded.Failed {
+			msg, _, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message")
+			klog.Warningf("snapshotPVCsBeforeDeletion: VolumeSnapshot %s/%s for PVC %s failed: %s, delete the PVC without it", ns, name, pvc.GetName(), msg)
+			deps.Recorder.Eventf(tc, corev1.EventTypeWarning, "VolumeSnapshotFailed", "VolumeSnapshot %s/%s for PVC %s failed: %s, the PVC is deleted without it", ns, name, pvc.GetName(), msg)
+			recorded.Failed = true
+		}
+		tc.Status.VolumeSnapshots[name] = recorded
+		if creationTime == "" && !recorded.Failed {
+			pending = append(pending, name)
+		}
+	}
+
+	if len(pending) > 0 {
+		return controller.RequeueErrorf("tidbcluster: [%s/%s] waiting for VolumeSnapshots %v to be cut before deleting PVCs", ns, tcName, pending)
+	}
+	return nil
+}
+
+// pruneVolumeSnapshots deletes the VolumeSnapshots recorded in the status once
+// they are older than the retention of `.spec.snapshotBeforeDeletingPVC`, and
+// removes them from the status. It is best effort, a VolumeSnapshot failed to
+// be deleted is kept in the status and retried in the next sync.
+func pruneVolumeSnapshots(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) {
+	retention, ok := tc.VolumeSnapshotRetention()
+	if !ok || len(tc.Status.VolumeSnapshots) == 0 {
+		return
+	}
+	ns := tc.GetNamespace()
+
+	var gv *schema.GroupVersion
+	for name, recorded := range tc.Status.VolumeSnapshots {
+		if time.Since(recorded.CreatedAt.Time) < retention {
+			continue
+		}
+		if gv == nil {
+			served, ok := getVolumeSnapshotGroupVersion(deps)
+			if !ok {
+				return
+			}
+			gv = &served
+		}
+		snapshot := &unstructured.Unstructured{}
+		snapshot.SetGroupVersionKind(gv.WithKind(volumeSnapshotKind))
+		snapshot.SetNamespace(ns)
+		snapshot.SetName(name)
+		if err := deps.GenericClient.Delete(context.TODO(), snapshot); err != nil && !errors.IsNotFound(err) {
+			klog.Warningf("pruneVolumeSnapshots: failed to delete VolumeSnapshot %s/%s, error: %v", ns, name, err)
+			continue
+		}
+		klog.Infof("pruneVolumeSnapshots: delete VolumeSnapshot %s/%s of PVC %s as it is older than %s", ns, name, recorded.PVCName, retention)
+		delete(tc.Status.VolumeSnapshots, name)
+	}
+	if len(tc.Status.VolumeSnapshots) == 0 {
+		tc.Status.VolumeSnapshots = nil
+	}
+}
+
+func newPVCSnapshot(tc *v1alpha1.TidbCluster, snapshotSpec *v1alpha1.PVCSnapshotSpec, pvc *corev1.PersistentVolumeClaim, gv schema.GroupVersion, name string) *unstructured.Unstructured {
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(gv.WithKind(volumeSnapshotKind))
+	snapshot.SetNamespace(tc.GetNamespace())
+	snapshot.SetName(name)
+	snapshot.SetLabels(label.New().Instance(tc.GetInstanceName()).Labels())
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{
+			"persistentVolumeClaimName": pvc.GetName(),
+		},
+	}
+	if className := snapshotSpec.VolumeSnapshotClassName; className != nil {
+		spec["volumeSnapshotClassName"] = *className
+	}
+	snapshot.Object["spec"] = spec
+	return snapshot
+}
@@ -0,0 +1,239 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	perrors "github.com/pingcap/errors"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+)
+
+func TestSnapshotPVCsBeforeDeletion(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name          string
+		snapshotSpec  *v1alpha1.PVCSnapshotSpec
+		served        bool
+		creationTime  string
+		snapshotError string
+		expectRequeue bool
+		expectCreated bool
+	}
+
+	testFn := func(test *testcase) {
+		t.Log(test.name)
+		tc := newTidbClusterForPD()
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "pd-test-pd-0",
+				Namespace: tc.GetNamespace(),
+				UID:       types.UID("a1b2c3d4-0000-0000-0000-000000000000"),
+			},
+		}
+
+		deps := controller.NewFakeDependencies()
+		if test.served {
+			deps.KubeClientset.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+				{
+					GroupVersion: "snapshot.storage.k8s.io/v1beta1",
+					APIResources: []metav1.APIResource{{Name: "volumesnapshots", Kind: "VolumeSnapshot", Namespaced: true}},
+				},
+			}
+		}
+		if test.creationTime != "" || test.snapshotError != "" {
+			snapshot := &unstructured.Unstructured{}
+			snapshot.SetAPIVersion("snapshot.storage.k8s.io/v1beta1")
+			snapshot.SetKind("VolumeSnapshot")
+			snapshot.SetNamespace(tc.GetNamespace())
+			snapshot.SetName("pd-test-pd-0-a1b2c3d4")
+			unstructured.SetNestedField(snapshot.Object, "pd-test-pd-0", "spec", "source", "persistentVolumeClaimName")
+			if test.creationTime != "" {
+				unstructured.SetNestedField(snapshot.Object, test.creationTime, "status", "creationTime")
+			}
+			if test.snapshotError != "" {
+				unstructured.SetNestedField(snapshot.Object, test.snapshotError, "status", "error", "message")
+			}
+			g.Expect(deps.GenericClient.Create(context.TODO(), snapshot)).To(Succeed())
+		}
+
+		err := snapshotPVCsBeforeDeletion(deps, tc, test.snapshotSpec, []*corev1.PersistentVolumeClaim{pvc})
+		if test.expectRequeue {
+			g.Expect(perrors.Find(err, controller.IsRequeueError)).NotTo(BeNil())
+		} else {
+			g.Expect(err).NotTo(HaveOccurred())
+		}
+
+		snapshot := &unstructured.Unstructured{}
+		snapshot.SetAPIVersion("snapshot.storage.k8s.io/v1beta1")
+		snapshot.SetKind("VolumeSnapshot")
+		getErr := deps.GenericClient.Get(context.TODO(), types.NamespacedName{Namespace: tc.GetNamespace(), Name: "pd-test-pd-0-a1b2c3d4"}, snapshot)
+		if test.expectCreated {
+			g.Expect(getErr).NotTo(HaveOccurred())
+			source, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName")
+			g.Expect(source).To(Equal("pd-test-pd-0"))
+			if test.snapshotSpec.VolumeSnapshotClassName != nil {
+				className, _, _ := unstructured.NestedString(snapshot.Object, "spec", "volumeSnapshotClassName")
+				g.Expect(className).To(Equal(*test.snapshotSpec.VolumeSnapshotClassName))
+			}
+			g.Expect(tc.Status.VolumeSnapshots).To(HaveKey("pd-test-pd-0-a1b2c3d4"))
+			recorded := tc.Status.VolumeSnapshots["pd-test-pd-0-a1b2c3d4"]
+			g.Expect(recorded.SnapshotName).To(Equal("pd-test-pd-0-a1b2c3d4"))
+			g.Expect(recorded.PVCUID).To(Equal(pvc.GetUID()))
+			g.Expect(recorded.PVCName).To(Equal("pd-test-pd-0"))
+			g.Expect(recorded.Failed).To(Equal(test.snapshotError != ""))
+		} else {
+			g.Expect(getErr).To(HaveOccurred())
+			g.Expect(tc.Status.VolumeSnapshots).To(BeEmpty())
+		}
+	}
+
+	tests := []testcase{
+		{
+			name:          "snapshot is disabled",
+			snapshotSpec:  nil,
+			served:        true,
+			expectRequeue: false,
+			expectCreated: false,
+		},
+		{
+			name:          "VolumeSnapshot API is not served",
+			snapshotSpec:  &v1alpha1.PVCSnapshotSpec{},
+			served:        false,
+			expectRequeue: false,
+			expectCreated: false,
+		},
+		{
+			name:          "create snapshot and wait for it to be cut",
+			snapshotSpec:  &v1alpha1.PVCSnapshotSpec{VolumeSnapshotClassName: pointer.StringPtr("csi-snapclass")},
+			served:        true,
+			expectRequeue: true,
+			expectCreated: true,
+		},
+		{
+			name:          "snapshot is cut",
+			snapshotSpec:  &v1alpha1.PVCSnapshotSpec{},
+			served:        true,
+			creationTime:  "2021-06-01T00:00:00Z",
+			expectRequeue: false,
+			expectCreated: true,
+		},
+		{
+			name:          "snapshot failed",
+			snapshotSpec:  &v1alpha1.PVCSnapshotSpec{},
+			served:        true,
+			snapshotError: "the VolumeSnapshotClass is not found",
+			expectRequeue: false,
+			expectCreated: true,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i])
+	}
+}
+
+func TestSnapshotPVCsBeforeDeletionFailedEvent(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPD()
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pd-test-pd-0",
+			Namespace: tc.GetNamespace(),
+			UID:       types.UID("a1b2c3d4-0000-0000-0000-000000000000"),
+		},
+	}
+	deps := controller.NewFakeDependencies()
+	recorder := deps.Recorder.(*record.FakeRecorder)
+	deps.KubeClientset.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "snapshot.storage.k8s.io/v1",
+			APIResources: []metav1.APIResource{{Name: "volumesnapshots", Kind: "VolumeSnapshot", Namespaced: true}},
+		},
+	}
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetAPIVersion("snapshot.storage.k8s.io/v1")
+	snapshot.SetKind("VolumeSnapshot")
+	snapshot.SetNamespace(tc.GetNamespace())
+	snapshot.SetName("pd-test-pd-0-a1b2c3d4")
+	unstructured.SetNestedField(snapshot.Object, "failed to take snapshot", "status", "error", "message")
+	g.Expect(deps.GenericClient.Create(context.TODO(), snapshot)).To(Succeed())
+
+	// the failure is reported once
+	for i := 0; i < 3; i++ {
+		g.Expect(snapshotPVCsBeforeDeletion(deps, tc, &v1alpha1.PVCSnapshotSpec{}, []*corev1.PersistentVolumeClaim{pvc})).To(Succeed())
+	}
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(ContainSubstring("VolumeSnapshotFailed"))
+}
+
+func TestPruneVolumeSnapshots(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPD()
+	deps := controller.NewFakeDependencies()
+	deps.KubeClientset.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "snapshot.storage.k8s.io/v1",
+			APIResources: []metav1.APIResource{{Name: "volumesnapshots", Kind: "VolumeSnapshot", Namespaced: true}},
+		},
+	}
+	newSnapshot := func(name string, age time.Duration) {
+		snapshot := &unstructured.Unstructured{}
+		snapshot.SetAPIVersion("snapshot.storage.k8s.io/v1")
+		snapshot.SetKind("VolumeSnapshot")
+		snapshot.SetNamespace(tc.GetNamespace())
+		snapshot.SetName(name)
+		g.Expect(deps.GenericClient.Create(context.TODO(), snapshot)).To(Succeed())
+		if tc.Status.VolumeSnapshots == nil {
+			tc.Status.VolumeSnapshots = map[string]v1alpha1.PVCSnapshot{}
+		}
+		tc.Status.VolumeSnapshots[name] = v1alpha1.PVCSnapshot{SnapshotName: name, CreatedAt: metav1.NewTime(time.Now().Add(-age))}
+	}
+	newSnapshot("pd-test-pd-0-old", 48*time.Hour)
+	newSnapshot("pd-test-pd-0-new", time.Hour)
+	exists := func(name string) bool {
+		snapshot := &unstructured.Unstructured{}
+		snapshot.SetAPIVersion("snapshot.storage.k8s.io/v1")
+		snapshot.SetKind("VolumeSnapshot")
+		return deps.GenericClient.Get(context.TODO(), types.NamespacedName{Namespace: tc.GetNamespace(), Name: name}, snapshot) == nil
+	}
+
+	// the snapshots are kept without the retention
+	tc.Spec.SnapshotBeforeDeletingPVC = &v1alpha1.PVCSnapshotSpec{}
+	pruneVolumeSnapshots(deps, tc)
+	g.Expect(tc.Status.VolumeSnapshots).To(HaveLen(2))
+
+	// the snapshot older than the retention is deleted
+	tc.Spec.SnapshotBeforeDeletingPVC.Retention = pointer.StringPtr("24h")
+	pruneVolumeSnapshots(deps, tc)
+	g.Expect(tc.Status.VolumeSnapshots).To(HaveLen(1))
+	g.Expect(tc.Status.VolumeSnapshots).To(HaveKey("pd-test-pd-0-new"))
+	g.Expect(exists("pd-test-pd-0-old")).To(BeFalse())
+	g.Expect(exists("pd-test-pd-0-new")).To(BeTrue())
+}
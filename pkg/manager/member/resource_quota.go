@@ -0,0 +1,131 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog"
+)
+
+// podQuotaUsage returns the quota a new Pod of the StatefulSet and its PVCs
+// would use, keyed by the resource names of ResourceQuota
+func podQuotaUsage(set *apps.StatefulSet) corev1.ResourceList {
+	podSpec := set.Spec.Template.Spec
+	usage := corev1.ResourceList{
+		corev1.ResourcePods: resource.MustParse("1"),
+	}
+
+	// like the scheduler, the requests of a Pod is the max of the sum of the
+	// containers and any of the init containers
+	add := func(name corev1.ResourceName, q resource.Quantity) {
+		total := usage[name]
+		total.Add(q)
+		usage[name] = total
+	}
+	for _, c := range podSpec.Containers {
+		for name, q := range c.Resources.Requests {
+			add(corev1.ResourceName("requests."+string(name)), q)
+		}
+		for name, q := range c.Resources.Limits {
+			add(corev1.ResourceName("limits."+string(name)), q)
+		}
+	}
+	for _, c := range podSpec.InitContainers {
+		for name, q := range c.Resources.Requests {
+			key := corev1.ResourceName("requests." + string(name))
+			if total, ok := usage[key]; !ok || q.Cmp(total) > 0 {
+				usage[key] = q
+			}
+		}
+		for name, q := range c.Resources.Limits {
+			key := corev1.ResourceName("limits." + string(name))
+			if total, ok := usage[key]; !ok || q.Cmp(total) > 0 {
+				usage[key] = q
+			}
+		}
+	}
+	// cpu and memory are shorthands of requests.cpu and requests.memory
+	if q, ok := usage[corev1.ResourceRequestsCPU]; ok {
+		usage[corev1.ResourceCPU] = q
+	}
+	if q, ok := usage[corev1.ResourceRequestsMemory]; ok {
+		usage[corev1.ResourceMemory] = q
+	}
+
+	for _, pvc := range set.Spec.VolumeClaimTemplates {
+		add(corev1.ResourcePersistentVolumeClaims, resource.MustParse("1"))
+		if q, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+			add(corev1.ResourceRequestsStorage, q)
+		}
+	}
+	return usage
+}
+
+// checkResourceQuota returns a requeue error and records an Event if scaling
+// out the StatefulSet by one Pod would exceed the remaining quota of any
+// ResourceQuota in the namespace, so that the scaler does not create a Pod
+// that stays Pending. The ResourceQuotas with scopes are not checked.
+func checkResourceQuota(deps *controller.Dependencies, meta metav1.Object, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	if deps.ResourceQuotaLister == nil {
+		return nil
+	}
+	ns := newSet.GetNamespace()
+	quotas, err := deps.ResourceQuotaLister.ResourceQuotas(ns).List(labels.Everything())
+	if err != nil {
+		klog.Warningf("failed to list resource quotas in namespace %s, skip checking the quota, error: %v", ns, err)
+		return nil
+	}
+
+	usage := podQuotaUsage(newSet)
+	var exceeded []string
+	for _, quota := range quotas {
+		if len(quota.Spec.Scopes) > 0 || quota.Spec.ScopeSelector != nil {
+			continue
+		}
+		for name, hard := range quota.Status.Hard {
+			need, ok := usage[name]
+			if !ok {
+				continue
+			}
+			remaining := hard.DeepCopy()
+			if used, ok := quota.Status.Used[name]; ok {
+				remaining.Sub(used)
+			}
+			if need.Cmp(remaining) > 0 {
+				exceeded = append(exceeded, fmt.Sprintf("%s/%s: %s needs %s, remaining %s", ns, quota.GetName(), name, need.String(), remaining.String()))
+			}
+		}
+	}
+	if len(exceeded) == 0 {
+		return nil
+	}
+
+	sort.Strings(exceeded)
+	resetReplicas(newSet, oldSet)
+	msg := fmt.Sprintf("scaling out statefulset %s/%s exceeds the resource quota, %s", ns, newSet.GetName(), strings.Join(exceeded, "; "))
+	if obj, ok := meta.(runtime.Object); ok {
+		deps.Recorder.Event(obj, corev1.EventTypeWarning, "ScaleOutExceedsQuota", msg)
+	}
+	return controller.RequeueErrorf(msg)
+}
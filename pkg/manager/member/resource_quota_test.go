@@ -0,0 +1,176 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	perrors "github.com/pingcap/errors"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+)
+
+func newStatefulSetForQuota() *apps.StatefulSet {
+	set := newStatefulSetForPDScale()
+	set.Spec.Template.Spec = corev1.PodSpec{
+		InitContainers: []corev1.Container{
+			{
+				Name: "init",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+				},
+			},
+		},
+		Containers: []corev1.Container{
+			{
+				Name: "pd",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("1"),
+						corev1.ResourceMemory: resource.MustParse("2Gi"),
+					},
+					Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")},
+				},
+			},
+			{
+				Name: "sidecar",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+				},
+			},
+		},
+	}
+	set.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{
+		{
+			Spec: corev1.PersistentVolumeClaimSpec{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+				},
+			},
+		},
+	}
+	return set
+}
+
+func TestPodQuotaUsage(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	usage := podQuotaUsage(newStatefulSetForQuota())
+	expected := map[corev1.ResourceName]string{
+		corev1.ResourcePods:                   "1",
+		corev1.ResourceRequestsCPU:            "4",
+		corev1.ResourceCPU:                    "4",
+		corev1.ResourceRequestsMemory:         "3Gi",
+		corev1.ResourceMemory:                 "3Gi",
+		corev1.ResourceLimitsMemory:           "4Gi",
+		corev1.ResourcePersistentVolumeClaims: "1",
+		corev1.ResourceRequestsStorage:        "10Gi",
+	}
+	g.Expect(usage).To(HaveLen(len(expected)))
+	for name, q := range expected {
+		got := usage[name]
+		g.Expect(got.Cmp(resource.MustParse(q))).To(BeZero(), "resource %s", name)
+	}
+}
+
+func TestCheckResourceQuota(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name      string
+		quota     *corev1.ResourceQuota
+		expectErr bool
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+		fakeDeps := controller.NewFakeDependencies()
+		quotaIndexer := fakeDeps.KubeInformerFactory.Core().V1().ResourceQuotas().Informer().GetIndexer()
+		if test.quota != nil {
+			g.Expect(quotaIndexer.Add(test.quota)).To(Succeed())
+		}
+
+		tc := newTidbClusterForPD()
+		oldSet := newStatefulSetForQuota()
+		newSet := oldSet.DeepCopy()
+		newSet.Spec.Replicas = pointer.Int32Ptr(*oldSet.Spec.Replicas + 1)
+
+		err := checkResourceQuota(fakeDeps, tc, oldSet, newSet)
+		events := collectEvents(fakeDeps.Recorder.(*record.FakeRecorder).Events)
+		if test.expectErr {
+			g.Expect(perrors.Find(err, controller.IsRequeueError)).NotTo(BeNil())
+			g.Expect(*newSet.Spec.Replicas).To(Equal(*oldSet.Spec.Replicas))
+			g.Expect(events).To(HaveLen(1))
+			g.Expect(events[0]).To(ContainSubstring("ScaleOutExceedsQuota"))
+			return
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(*newSet.Spec.Replicas).To(Equal(*oldSet.Spec.Replicas + 1))
+		g.Expect(events).To(BeEmpty())
+	}
+
+	newQuota := func(hard, used corev1.ResourceList) *corev1.ResourceQuota {
+		return &corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: metav1.NamespaceDefault},
+			Status:     corev1.ResourceQuotaStatus{Hard: hard, Used: used},
+		}
+	}
+
+	tests := []*testcase{
+		{
+			name: "no quota",
+		},
+		{
+			name: "quota allows the scale-out",
+			quota: newQuota(
+				corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("10"), corev1.ResourcePods: resource.MustParse("10")},
+				corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("6"), corev1.ResourcePods: resource.MustParse("5")},
+			),
+		},
+		{
+			name: "cpu quota blocks the scale-out",
+			quota: newQuota(
+				corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("10")},
+				corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("7")},
+			),
+			expectErr: true,
+		},
+		{
+			name: "storage quota blocks the scale-out",
+			quota: newQuota(
+				corev1.ResourceList{corev1.ResourceRequestsStorage: resource.MustParse("50Gi")},
+				corev1.ResourceList{corev1.ResourceRequestsStorage: resource.MustParse("50Gi")},
+			),
+			expectErr: true,
+		},
+		{
+			name: "scoped quota is not checked",
+			quota: func() *corev1.ResourceQuota {
+				quota := newQuota(corev1.ResourceList{corev1.ResourcePods: resource.MustParse("1")}, corev1.ResourceList{corev1.ResourcePods: resource.MustParse("1")})
+				quota.Spec.Scopes = []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeBestEffort}
+				return quota
+			}(),
+		},
+	}
+
+	for _, test := range tests {
+		testFn(test, t)
+	}
+}
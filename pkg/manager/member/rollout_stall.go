@@ -0,0 +1,167 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/klog"
+	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
+)
+
+const (
+	// rolloutStalledReason is the reason of the RolloutStalled condition
+	rolloutStalledReason = "PodPending"
+	// rolloutProgressingReason is the reason of the RolloutStalled condition when no Pod is stalled
+	rolloutProgressingReason = "NoPodPending"
+)
+
+// syncRolloutStall records the Pods of the component staying Pending for
+// longer than the threshold of the RolloutStallPolicy in the status, and
+// reports them in the RolloutStalled condition, so it is told why the rollout
+// does not make progress instead of waiting silently. The reason of a stalled
+// Pod is taken from its latest warning event, e.g. FailedScheduling, and an
+// event is emitted once a Pod is found stalled.
+func syncRolloutStall(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	selector, err := label.New().Instance(tc.GetInstanceName()).Component(memberType.String()).Selector()
+	if err != nil {
+		return err
+	}
+	pods, err := deps.PodLister.Pods(ns).List(selector)
+	if err != nil {
+		return fmt.Errorf("syncRolloutStall: failed to list pods for cluster %s/%s, selector %s, error: %s", ns, tcName, selector, err)
+	}
+
+	threshold := tc.RolloutStallThreshold()
+	stalled := map[string]v1alpha1.StalledPod{}
+	for _, pod := range pods {
+		since := podPendingSince(pod)
+		if since == nil || time.Since(since.Time) < threshold {
+			continue
+		}
+		stalledPod := v1alpha1.StalledPod{Component: memberType, PendingSince: *since}
+		stalledPod.Reason, stalledPod.Message = podPendingReason(deps, pod)
+		stalled[pod.GetName()] = stalledPod
+		if _, ok := tc.Status.StalledPods[pod.GetName()]; !ok {
+			msg := fmt.Sprintf("%s Pod %s is Pending for longer than %v, %s: %s", memberType, pod.GetName(), threshold, stalledPod.Reason, stalledPod.Message)
+			klog.Warningf("tidbcluster: [%s/%s]'s rollout is stalled, %s", ns, tcName, msg)
+			deps.Recorder.Event(tc, corev1.EventTypeWarning, "RolloutStalled", msg)
+		}
+	}
+
+	for name, stalledPod := range tc.Status.StalledPods {
+		if _, ok := stalled[name]; stalledPod.Component == memberType && !ok {
+			klog.Infof("tidbcluster: [%s/%s]'s %s Pod %s is not Pending any more", ns, tcName, memberType, name)
+			delete(tc.Status.StalledPods, name)
+		}
+	}
+	if len(stalled) > 0 && tc.Status.StalledPods == nil {
+		tc.Status.StalledPods = map[string]v1alpha1.StalledPod{}
+	}
+	for name, stalledPod := range stalled {
+		tc.Status.StalledPods[name] = stalledPod
+	}
+	if len(tc.Status.StalledPods) == 0 {
+		tc.Status.StalledPods = nil
+	}
+
+	if len(tc.Status.StalledPods) > 0 {
+		var msgs []string
+		for name, stalledPod := range tc.Status.StalledPods {
+			msgs = append(msgs, fmt.Sprintf("%s Pod %s: %s: %s", stalledPod.Component, name, stalledPod.Reason, stalledPod.Message))
+		}
+		sort.Strings(msgs)
+		utiltidbcluster.UpdateTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+			v1alpha1.TidbClusterRolloutStalled, corev1.ConditionTrue, rolloutStalledReason, strings.Join(msgs, "; ")))
+		return nil
+	}
+	if utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterRolloutStalled) != nil {
+		utiltidbcluster.UpdateTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+			v1alpha1.TidbClusterRolloutStalled, corev1.ConditionFalse, rolloutProgressingReason, "no Pod is Pending"))
+	}
+	return nil
+}
+
+// podPendingSince returns since when the Pod is Pending, or nil if it is not.
+// The time the Pod fails to be scheduled is taken if it is not scheduled yet.
+func podPendingSince(pod *corev1.Pod) *metav1.Time {
+	if pod.Status.Phase != corev1.PodPending || pod.DeletionTimestamp != nil {
+		return nil
+	}
+	since := pod.CreationTimestamp
+	if _, cond := podutil.GetPodCondition(&pod.Status, corev1.PodScheduled); cond != nil && !cond.LastTransitionTime.IsZero() {
+		since = cond.LastTransitionTime
+	}
+	return &since
+}
+
+// podPendingReason returns why the Pod is Pending, which is the latest warning
+// event of the Pod, or its PodScheduled condition if no such event is found.
+// The events are only listed for the Pods Pending past the threshold, so they
+// are queried on demand rather than watched.
+func podPendingReason(deps *controller.Dependencies, pod *corev1.Pod) (string, string) {
+	selector := fields.Set{
+		"involvedObject.kind": "Pod",
+		"involvedObject.name": pod.GetName(),
+		"involvedObject.uid":  string(pod.GetUID()),
+		"type":                corev1.EventTypeWarning,
+	}.AsSelector().String()
+	events, err := deps.KubeClientset.CoreV1().Events(pod.GetNamespace()).List(context.TODO(), metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		klog.Warningf("failed to list events of pod %s/%s, error: %v", pod.GetNamespace(), pod.GetName(), err)
+		events = &corev1.EventList{}
+	}
+	var latest *corev1.Event
+	for i := range events.Items {
+		event := &events.Items[i]
+		if event.Type != corev1.EventTypeWarning || event.InvolvedObject.Kind != "Pod" ||
+			event.InvolvedObject.Name != pod.GetName() || event.InvolvedObject.UID != pod.GetUID() {
+			continue
+		}
+		if latest == nil || eventTime(event).After(eventTime(latest)) {
+			latest = event
+		}
+	}
+	if latest != nil {
+		return latest.Reason, latest.Message
+	}
+	if _, cond := podutil.GetPodCondition(&pod.Status, corev1.PodScheduled); cond != nil && cond.Status != corev1.ConditionTrue {
+		return cond.Reason, cond.Message
+	}
+	return "Pending", "the Pod is scheduled but its containers are not started"
+}
+
+func eventTime(event *corev1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	return event.FirstTimestamp.Time
+}
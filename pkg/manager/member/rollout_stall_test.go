@@ -0,0 +1,119 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+)
+
+func TestSyncRolloutStall(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	podIndexer := deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+	recorder := deps.Recorder.(*record.FakeRecorder)
+	tc := newTidbClusterForTiKV()
+	tc.Spec.RolloutStallPolicy = &v1alpha1.RolloutStallPolicy{Threshold: pointer.StringPtr("5m")}
+	tikv2 := ordinalPodName(v1alpha1.TiKVMemberType, tc.GetName(), 2)
+
+	setPod := func(phase corev1.PodPhase, pendingFor time.Duration) {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              tikv2,
+				Namespace:         tc.GetNamespace(),
+				UID:               types.UID("tikv-2"),
+				Labels:            label.New().Instance(tc.GetInstanceName()).TiKV().Labels(),
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+			},
+			Status: corev1.PodStatus{
+				Phase: phase,
+				Conditions: []corev1.PodCondition{{
+					Type:               corev1.PodScheduled,
+					Status:             corev1.ConditionFalse,
+					Reason:             corev1.PodReasonUnschedulable,
+					Message:            "0/3 nodes are available: 3 Insufficient cpu.",
+					LastTransitionTime: metav1.NewTime(time.Now().Add(-pendingFor)),
+				}},
+			},
+		}
+		g.Expect(podIndexer.Update(pod)).To(Succeed())
+	}
+	sync := func() *v1alpha1.TidbClusterCondition {
+		g.Expect(syncRolloutStall(deps, tc, v1alpha1.TiKVMemberType)).To(Succeed())
+		return utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterRolloutStalled)
+	}
+
+	// a pod pending for a while is not stalled yet
+	setPod(corev1.PodPending, time.Minute)
+	g.Expect(sync()).To(BeNil())
+	g.Expect(tc.Status.StalledPods).To(BeNil())
+
+	// the reason is taken from the PodScheduled condition without events
+	setPod(corev1.PodPending, 10*time.Minute)
+	cond := sync()
+	g.Expect(cond).NotTo(BeNil())
+	g.Expect(cond.Status).To(Equal(corev1.ConditionTrue))
+	g.Expect(cond.Message).To(ContainSubstring("Insufficient cpu"))
+	g.Expect(tc.Status.StalledPods).To(HaveKey(tikv2))
+	g.Expect(tc.Status.StalledPods[tikv2].Component).To(Equal(v1alpha1.TiKVMemberType))
+	g.Expect(tc.Status.StalledPods[tikv2].Reason).To(Equal(corev1.PodReasonUnschedulable))
+	g.Expect(recorder.Events).To(HaveLen(1))
+
+	// the reason is taken from the latest warning event of the pod
+	for i, msg := range []string{"0/3 nodes are available: 3 Insufficient cpu.", "0/3 nodes are available: 3 node(s) had taint {dedicated: tikv}."} {
+		event := &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: tikv2 + "." + string(rune('a'+i)), Namespace: tc.GetNamespace()},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: tikv2, Namespace: tc.GetNamespace(), UID: types.UID("tikv-2")},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "FailedScheduling",
+			Message:        msg,
+			LastTimestamp:  metav1.NewTime(time.Now().Add(time.Duration(i-2) * time.Minute)),
+		}
+		_, err := deps.KubeClientset.CoreV1().Events(tc.GetNamespace()).Create(context.TODO(), event, metav1.CreateOptions{})
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+	cond = sync()
+	g.Expect(cond.Message).To(ContainSubstring("FailedScheduling"))
+	g.Expect(cond.Message).To(ContainSubstring("had taint"))
+	g.Expect(tc.Status.StalledPods[tikv2].Reason).To(Equal("FailedScheduling"))
+	// the event is emitted only once for a stalled pod
+	g.Expect(recorder.Events).To(HaveLen(1))
+
+	// the stalled pods of other components are kept
+	tc.Status.StalledPods["test-pd-0"] = v1alpha1.StalledPod{Component: v1alpha1.PDMemberType}
+	setPod(corev1.PodRunning, 10*time.Minute)
+	cond = sync()
+	g.Expect(cond.Status).To(Equal(corev1.ConditionTrue))
+	g.Expect(tc.Status.StalledPods).To(HaveLen(1))
+	g.Expect(tc.Status.StalledPods).To(HaveKey("test-pd-0"))
+
+	// the condition is cleared once no pod is stalled
+	delete(tc.Status.StalledPods, "test-pd-0")
+	cond = sync()
+	g.Expect(cond.Status).To(Equal(corev1.ConditionFalse))
+	g.Expect(tc.Status.StalledPods).To(BeNil())
+}
@@ -0,0 +1,151 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/advanced-statefulset/client/apis/apps/v1/helper"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+// scaleDryRunReason is the reason of the Events reporting the planned scale actions
+const scaleDryRunReason = "ScaleDryRun"
+
+// scaleDryRun returns whether the tc is annotated with label.AnnScaleDryRun
+// and the StatefulSet is scaling, in which case the actions of the whole scale
+// are recorded in the status, reported by an Event when they change, and the
+// replicas of the new StatefulSet are reset, so that neither the StatefulSet
+// nor the members are changed.
+func (s *generalScaler) scaleDryRun(meta metav1.Object, memberType v1alpha1.MemberType, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) bool {
+	tc, ok := meta.(*v1alpha1.TidbCluster)
+	if !ok {
+		return false
+	}
+	if !tc.ScaleDryRun() {
+		tc.Status.ScaleDryRunPlans = nil
+		return false
+	}
+
+	var actions []string
+	current := oldSet.DeepCopy()
+	for {
+		scaling, ordinal, replicas, deleteSlots := scaleOne(current, newSet)
+		if scaling == 0 {
+			break
+		}
+		podName := fmt.Sprintf("%s-%d", oldSet.Name, ordinal)
+		if scaling > 0 {
+			actions = append(actions, s.scaleOutDryRunActions(tc, podName)...)
+		} else {
+			actions = append(actions, s.scaleInDryRunActions(tc, memberType, podName)...)
+		}
+		*current.Spec.Replicas = replicas
+		helper.SetDeleteSlots(current, deleteSlots)
+	}
+	if len(actions) == 0 {
+		delete(tc.Status.ScaleDryRunPlans, oldSet.Name)
+		if len(tc.Status.ScaleDryRunPlans) == 0 {
+			tc.Status.ScaleDryRunPlans = nil
+		}
+		return false
+	}
+
+	resetReplicas(newSet, oldSet)
+	recordScaleBlocked(tc, memberType, scaleDryRunReason)
+	msg := fmt.Sprintf("%s scale planned: %s", memberType, strings.Join(actions, "; "))
+	if planned, ok := tc.Status.ScaleDryRunPlans[oldSet.Name]; ok && strings.Join(planned, "; ") == strings.Join(actions, "; ") {
+		klog.V(4).Infof("tc[%s/%s] is in scale dry-run mode, statefulset %s is not changed, %s", tc.GetNamespace(), tc.GetName(), oldSet.Name, msg)
+		return true
+	}
+	if tc.Status.ScaleDryRunPlans == nil {
+		tc.Status.ScaleDryRunPlans = map[string][]string{}
+	}
+	tc.Status.ScaleDryRunPlans[oldSet.Name] = actions
+	klog.Infof("tc[%s/%s] is in scale dry-run mode, statefulset %s is not changed, %s", tc.GetNamespace(), tc.GetName(), oldSet.Name, msg)
+	s.deps.Recorder.Event(tc, corev1.EventTypeNormal, scaleDryRunReason, msg)
+	return true
+}
+
+func (s *generalScaler) scaleOutDryRunActions(tc *v1alpha1.TidbCluster, podName string) []string {
+	var actions []string
+	for _, pvc := range s.dryRunPVCs(tc, podName) {
+		if _, ok := pvc.Annotations[label.AnnPVCDeferDeleting]; ok {
+			actions = append(actions, fmt.Sprintf("delete pvc %s", pvc.Name))
+		}
+	}
+	return append(actions, fmt.Sprintf("create pod %s", podName))
+}
+
+func (s *generalScaler) scaleInDryRunActions(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, podName string) []string {
+	var actions []string
+	if hooks := tc.ScaleHooks(memberType); hooks != nil && hooks.PreScaleIn != nil {
+		actions = append(actions, fmt.Sprintf("call PreScaleIn hook for %s", podName))
+	}
+	switch memberType {
+	case v1alpha1.PDMemberType:
+		if strings.Split(tc.Status.PD.Leader.Name, ".")[0] == podName {
+			actions = append(actions, fmt.Sprintf("transfer pd leader from %s", podName))
+		}
+		actions = append(actions, fmt.Sprintf("delete pd member %s", podName))
+	case v1alpha1.TiKVMemberType:
+		if tc.TiKVEvictLeaderBeforeScaleIn() {
+			actions = append(actions, fmt.Sprintf("evict region leaders from %s", podName))
+		}
+		actions = append(actions, fmt.Sprintf("delete tikv store %s", dryRunStoreID(tc.Status.TiKV.Stores, podName)))
+	case v1alpha1.TiFlashMemberType:
+		actions = append(actions, fmt.Sprintf("delete tiflash store %s", dryRunStoreID(tc.Status.TiFlash.Stores, podName)))
+	case v1alpha1.TiDBMemberType:
+		if tc.TiDBScaleInDrainTimeout() > 0 {
+			actions = append(actions, fmt.Sprintf("drain connections of %s", podName))
+		}
+	}
+	for _, pvc := range s.dryRunPVCs(tc, podName) {
+		actions = append(actions, fmt.Sprintf("mark pvc %s defer deleting", pvc.Name))
+	}
+	return append(actions, fmt.Sprintf("delete pod %s", podName))
+}
+
+// dryRunPVCs returns the PVCs of the Pod, an error is only logged as the PVCs
+// are only used to report the planned actions.
+func (s *generalScaler) dryRunPVCs(tc *v1alpha1.TidbCluster, podName string) []*corev1.PersistentVolumeClaim {
+	l := label.New().Instance(tc.GetInstanceName())
+	l[label.AnnPodNameKey] = podName
+	selector, err := l.Selector()
+	if err != nil {
+		klog.Errorf("tc[%s/%s] assemble label selector failed, err: %v", tc.GetNamespace(), tc.GetName(), err)
+		return nil
+	}
+	pvcs, err := s.deps.PVCLister.PersistentVolumeClaims(tc.GetNamespace()).List(selector)
+	if err != nil {
+		klog.Errorf("tc[%s/%s] list pvc failed, selector: %s, err: %v", tc.GetNamespace(), tc.GetName(), selector, err)
+		return nil
+	}
+	return pvcs
+}
+
+func dryRunStoreID(stores map[string]v1alpha1.TiKVStore, podName string) string {
+	for _, store := range stores {
+		if store.PodName == podName {
+			return store.ID
+		}
+	}
+	return fmt.Sprintf("of %s", podName)
+}
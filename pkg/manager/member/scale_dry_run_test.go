@@ -0,0 +1,140 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+)
+
+func TestGeneralScalerScaleDryRun(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		name     string
+		dryRun   bool
+		replicas int32
+		events   []string
+	}{
+		{
+			name:     "dry-run is not enabled",
+			replicas: 3,
+			events:   []string{},
+		},
+		{
+			name:     "scale in",
+			dryRun:   true,
+			replicas: 3,
+			events: []string{
+				"Normal ScaleDryRun pd scale planned: transfer pd leader from test-pd-4; delete pd member test-pd-4; " +
+					"mark pvc pd-test-pd-4 defer deleting; delete pod test-pd-4; delete pd member test-pd-3; delete pod test-pd-3",
+			},
+		},
+		{
+			name:     "scale out",
+			dryRun:   true,
+			replicas: 6,
+			events: []string{
+				"Normal ScaleDryRun pd scale planned: delete pvc pd-test-pd-5; create pod test-pd-5",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deps := controller.NewFakeDependencies()
+			scaler := &generalScaler{deps: deps}
+			tc := newTidbClusterForPD()
+			tc.Status.PD.Leader.Name = "test-pd-4.test-pd-peer.default.svc"
+			if test.dryRun {
+				tc.Annotations = map[string]string{label.AnnScaleDryRun: ""}
+			}
+
+			pvcIndexer := deps.KubeInformerFactory.Core().V1().PersistentVolumeClaims().Informer().GetIndexer()
+			for podName, anns := range map[string]map[string]string{
+				"test-pd-4": nil,
+				"test-pd-5": {label.AnnPVCDeferDeleting: "2021-01-01T00:00:00Z"},
+			} {
+				pvc := &corev1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "pd-" + podName,
+						Namespace:   tc.GetNamespace(),
+						Labels:      label.New().Instance(tc.GetInstanceName()).Labels(),
+						Annotations: anns,
+					},
+				}
+				pvc.Labels[label.AnnPodNameKey] = podName
+				pvcIndexer.Add(pvc)
+			}
+
+			oldSet := newStatefulSetForPDGroup(controller.PDMemberName(tc.GetName()), 5, 5)
+			newSet := oldSet.DeepCopy()
+			newSet.Spec.Replicas = pointer.Int32Ptr(test.replicas)
+
+			g.Expect(scaler.scaleDryRun(tc, v1alpha1.PDMemberType, oldSet, newSet)).To(Equal(test.dryRun))
+			events := collectEvents(deps.Recorder.(*record.FakeRecorder).Events)
+			g.Expect(events).To(Equal(test.events))
+			if !test.dryRun {
+				g.Expect(*newSet.Spec.Replicas).To(Equal(test.replicas))
+				g.Expect(tc.Status.ScaleDryRunPlans).To(BeNil())
+				return
+			}
+			g.Expect(*newSet.Spec.Replicas).To(Equal(int32(5)))
+			g.Expect(tc.Status.ScaleDryRunPlans).To(HaveKey(oldSet.Name))
+
+			// the same plan is not reported again
+			newSet.Spec.Replicas = pointer.Int32Ptr(test.replicas)
+			g.Expect(scaler.scaleDryRun(tc, v1alpha1.PDMemberType, oldSet, newSet)).To(BeTrue())
+			g.Expect(collectEvents(deps.Recorder.(*record.FakeRecorder).Events)).To(BeEmpty())
+
+			// the plan is cleared once the scale is not wanted
+			g.Expect(scaler.scaleDryRun(tc, v1alpha1.PDMemberType, oldSet, oldSet.DeepCopy())).To(BeFalse())
+			g.Expect(tc.Status.ScaleDryRunPlans).To(BeNil())
+		})
+	}
+}
+
+func TestScaleDryRunChangesNothing(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	tc := newTidbClusterForPD()
+	tc.Annotations = map[string]string{label.AnnScaleDryRun: ""}
+	// the scale-in of the pod is cancelled after its PreScaleIn hook ran
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:        "test-pd-4",
+		Namespace:   tc.GetNamespace(),
+		Annotations: map[string]string{label.AnnPreScaleInHookDone: "true"},
+	}}
+	podIndexer := deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+	g.Expect(podIndexer.Add(pod)).To(Succeed())
+
+	oldSet := newStatefulSetForPDGroup(controller.PDMemberName(tc.GetName()), 5, 5)
+	newSet := oldSet.DeepCopy()
+	newSet.Spec.Replicas = pointer.Int32Ptr(6)
+	g.Expect(NewPDScaler(deps).Scale(tc, oldSet, newSet)).To(Succeed())
+	g.Expect(*newSet.Spec.Replicas).To(Equal(int32(5)))
+	pod, err := deps.PodLister.Pods(tc.GetNamespace()).Get("test-pd-4")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pod.Annotations).To(HaveKey(label.AnnPreScaleInHookDone))
+}
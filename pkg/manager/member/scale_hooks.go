@@ -0,0 +1,316 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pingcap/advanced-statefulset/client/apis/apps/v1/helper"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	apps "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	// scaleHookPreScaleIn is the action of the hook called before a member is scaled in
+	scaleHookPreScaleIn = "PreScaleIn"
+	// scaleHookPostScaleIn is the action of the hook called after a member is scaled in
+	scaleHookPostScaleIn = "PostScaleIn"
+	// scaleHookPostScaleOut is the action of the hook called after a member is scaled out
+	scaleHookPostScaleOut = "PostScaleOut"
+	// scaleHookFailedReason is the reason of the Events of the failed scale hooks
+	scaleHookFailedReason = "ScaleHookFailed"
+	// scaleHookComponent is the component label of the scale hook Jobs
+	scaleHookComponent = "scale-hook"
+	// scaleHookContainer is the name of the container of the scale hook Jobs
+	scaleHookContainer = "scale-hook"
+)
+
+// runPreScaleInHook runs the PreScaleIn hook of the component for the member of
+// the ordinal. It returns nil once the hook succeeds and marks the Pod so that
+// the hook is not run again in the following reconciles of the scale-in. A Job
+// hook is created on the first call and the scale-in is requeued until the Job
+// completes, a failed Job is deleted and retried.
+func runPreScaleInHook(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, ordinal int32) error {
+	hooks := tc.ScaleHooks(memberType)
+	if hooks == nil || hooks.PreScaleIn == nil {
+		return nil
+	}
+
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	podName := ordinalPodName(memberType, tcName, ordinal)
+	pod, err := deps.PodLister.Pods(ns).Get(podName)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("runPreScaleInHook: failed to get pod %s/%s for tc %s/%s, error: %s", ns, podName, ns, tcName, err)
+	}
+	if _, done := pod.Annotations[label.AnnPreScaleInHookDone]; done {
+		return nil
+	}
+
+	hook := hooks.PreScaleIn
+	if hook.HTTP != nil {
+		review := newScaleHookReview(tc, memberType, scaleHookPreScaleIn, ordinal)
+		if err := deps.Controls.ScaleHookControl.CallScaleHook(tc, hook.HTTP, review); err != nil {
+			msg := fmt.Sprintf("%s hook of %s pod %s failed: %v", scaleHookPreScaleIn, memberType, podName, err)
+			deps.Recorder.Event(tc, corev1.EventTypeWarning, scaleHookFailedReason, msg)
+			return controller.RequeueErrorf("tc[%s/%s]'s %s, can't scale in now", ns, tcName, msg)
+		}
+	} else if hook.Job != nil {
+		if err := runPreScaleInJob(deps, tc, hook.Job, memberType, ordinal); err != nil {
+			return err
+		}
+	}
+
+	pod = pod.DeepCopy()
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Annotations[label.AnnPreScaleInHookDone] = "true"
+	if _, err := deps.Controls.PodControl.UpdatePod(tc, pod); err != nil {
+		return err
+	}
+	klog.Infof("tc[%s/%s]'s %s hook of %s pod %s succeeded", ns, tcName, scaleHookPreScaleIn, memberType, podName)
+	return nil
+}
+
+// clearCancelledPreScaleInHooks removes the mark of the PreScaleIn hook from
+// the Pods desired by newSet, whose scale-in is cancelled after the hook ran,
+// so that the hook is run again if they are scaled in later. It is called
+// before newSet is changed by the scaler.
+func clearCancelledPreScaleInHooks(deps *controller.Dependencies, meta metav1.Object, newSet *apps.StatefulSet) error {
+	tc, ok := meta.(*v1alpha1.TidbCluster)
+	if !ok {
+		return nil
+	}
+	ns := tc.GetNamespace()
+	for ordinal := range helper.GetPodOrdinals(*newSet.Spec.Replicas, newSet) {
+		podName := fmt.Sprintf("%s-%d", newSet.Name, ordinal)
+		pod, err := deps.PodLister.Pods(ns).Get(podName)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("clearCancelledPreScaleInHooks: failed to get pod %s/%s for tc %s/%s, error: %s", ns, podName, ns, tc.GetName(), err)
+		}
+		if _, done := pod.Annotations[label.AnnPreScaleInHookDone]; !done {
+			continue
+		}
+		pod = pod.DeepCopy()
+		delete(pod.Annotations, label.AnnPreScaleInHookDone)
+		if _, err := deps.PodControl.UpdatePod(tc, pod); err != nil {
+			return err
+		}
+		klog.Infof("tc[%s/%s]'s scale-in of pod %s is cancelled, clear the mark of its %s hook", ns, tc.GetName(), podName, scaleHookPreScaleIn)
+	}
+	return nil
+}
+
+// runPreScaleInJob returns nil if the Job of the PreScaleIn hook completes and
+// deletes it, otherwise it creates the Job if it does not exist and requeues.
+func runPreScaleInJob(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, hook *v1alpha1.JobScaleHook,
+	memberType v1alpha1.MemberType, ordinal int32) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	podName := ordinalPodName(memberType, tcName, ordinal)
+	jobName := fmt.Sprintf("%s-pre-scale-in", podName)
+
+	job, err := deps.JobLister.Jobs(ns).Get(jobName)
+	if errors.IsNotFound(err) {
+		job = newScaleHookJob(tc, hook, memberType, scaleHookPreScaleIn, ordinal)
+		job.Name = jobName
+		if err := deps.Controls.JobControl.CreateJob(tc, job); err != nil {
+			return err
+		}
+		return controller.RequeueErrorf("tc[%s/%s]'s %s hook job %s of %s pod %s is created, can't scale in now", ns, tcName, scaleHookPreScaleIn, jobName, memberType, podName)
+	}
+	if err != nil {
+		return fmt.Errorf("runPreScaleInJob: failed to get job %s/%s for tc %s/%s, error: %s", ns, jobName, ns, tcName, err)
+	}
+
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			// delete the Job so that it is run again if the ordinal is scaled out and in later
+			return deps.Controls.JobControl.DeleteJob(tc, job)
+		case batchv1.JobFailed:
+			msg := fmt.Sprintf("%s hook job %s of %s pod %s failed: %s", scaleHookPreScaleIn, jobName, memberType, podName, c.Message)
+			deps.Recorder.Event(tc, corev1.EventTypeWarning, scaleHookFailedReason, msg)
+			if err := deps.Controls.JobControl.DeleteJob(tc, job); err != nil {
+				return err
+			}
+			return controller.RequeueErrorf("tc[%s/%s]'s %s, retry it", ns, tcName, msg)
+		}
+	}
+	return controller.RequeueErrorf("tc[%s/%s]'s %s hook job %s of %s pod %s is running, can't scale in now", ns, tcName, scaleHookPreScaleIn, jobName, memberType, podName)
+}
+
+// updateStatefulSetAndRunPostScaleHook updates the StatefulSet of the
+// component and runs the PostScale hook for the member scaled in or out by
+// the update once it succeeds, so that the hook sees the new topology. The
+// Jobs of the previous PostScale hooks are deleted once they finish.
+func updateStatefulSetAndRunPostScaleHook(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, newSet, oldSet *apps.StatefulSet) error {
+	// the Jobs of the PD groups are deleted with the ones of spec.pd
+	if _, isGroup := newSet.Labels[label.PDGroupLabelKey]; !isGroup {
+		if err := deletePostScaleHookJobs(deps, tc, memberType); err != nil {
+			return err
+		}
+	}
+	// computed before the update, which changes the replicas of oldSet
+	scaling, ordinal, _, _ := scaleOne(oldSet, newSet)
+	if err := UpdateStatefulSet(deps.StatefulSetControl, tc, newSet, oldSet); err != nil {
+		return err
+	}
+	switch {
+	case scaling > 0:
+		runPostScaleHook(deps, tc, memberType, scaleHookPostScaleOut, ordinal)
+	case scaling < 0:
+		runPostScaleHook(deps, tc, memberType, scaleHookPostScaleIn, ordinal)
+	}
+	return nil
+}
+
+// runPostScaleHook runs the PostScale hook of the component for the member of
+// the ordinal after it is scaled in or out. The scale is done at this time, so
+// the hook is not waited for and a failure is only reported as an Event.
+func runPostScaleHook(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, action string, ordinal int32) {
+	hooks := tc.ScaleHooks(memberType)
+	if hooks == nil || hooks.PostScale == nil {
+		return
+	}
+
+	podName := ordinalPodName(memberType, tc.GetName(), ordinal)
+	hook := hooks.PostScale
+	var err error
+	if hook.HTTP != nil {
+		err = deps.Controls.ScaleHookControl.CallScaleHook(tc, hook.HTTP, newScaleHookReview(tc, memberType, action, ordinal))
+	} else if hook.Job != nil {
+		job := newScaleHookJob(tc, hook.Job, memberType, action, ordinal)
+		suffix := "post-scale-out"
+		if action == scaleHookPostScaleIn {
+			suffix = "post-scale-in"
+		}
+		job.GenerateName = fmt.Sprintf("%s-%s-", podName, suffix)
+		job.Labels[label.PostScaleHookLabelKey] = memberType.String()
+		err = deps.Controls.JobControl.CreateJob(tc, job)
+	}
+	if err != nil {
+		msg := fmt.Sprintf("%s hook of %s pod %s failed: %v", action, memberType, podName, err)
+		klog.Warningf("tc[%s/%s]'s %s", tc.GetNamespace(), tc.GetName(), msg)
+		deps.Recorder.Event(tc, corev1.EventTypeWarning, scaleHookFailedReason, msg)
+	}
+}
+
+// deletePostScaleHookJobs deletes the finished Jobs of the PostScale hooks of
+// the component, which are not waited for. A failed Job is reported as an
+// Event before it is deleted.
+func deletePostScaleHookJobs(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType) error {
+	ns := tc.GetNamespace()
+	l := label.New().Instance(tc.GetInstanceName()).Component(scaleHookComponent)
+	l[label.PostScaleHookLabelKey] = memberType.String()
+	selector, err := l.Selector()
+	if err != nil {
+		return err
+	}
+	jobs, err := deps.JobLister.Jobs(ns).List(selector)
+	if err != nil {
+		return fmt.Errorf("deletePostScaleHookJobs: failed to list jobs for tc %s/%s, selector: %s, error: %s", ns, tc.GetName(), selector, err)
+	}
+	for _, job := range jobs {
+		for _, c := range job.Status.Conditions {
+			if c.Status != corev1.ConditionTrue || c.Type != batchv1.JobComplete && c.Type != batchv1.JobFailed {
+				continue
+			}
+			if c.Type == batchv1.JobFailed {
+				msg := fmt.Sprintf("PostScale hook job %s of %s failed: %s", job.GetName(), memberType, c.Message)
+				klog.Warningf("tc[%s/%s]'s %s", ns, tc.GetName(), msg)
+				deps.Recorder.Event(tc, corev1.EventTypeWarning, scaleHookFailedReason, msg)
+			}
+			if err := deps.Controls.JobControl.DeleteJob(tc, job); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			break
+		}
+	}
+	return nil
+}
+
+func newScaleHookReview(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, action string, ordinal int32) *controller.ScaleHookReview {
+	return &controller.ScaleHookReview{
+		Namespace: tc.GetNamespace(),
+		Cluster:   tc.GetName(),
+		Component: memberType.String(),
+		Action:    action,
+		Ordinal:   ordinal,
+		PodName:   ordinalPodName(memberType, tc.GetName(), ordinal),
+	}
+}
+
+// newScaleHookJob returns the Job running the scale hook
+func newScaleHookJob(tc *v1alpha1.TidbCluster, hook *v1alpha1.JobScaleHook, memberType v1alpha1.MemberType, action string, ordinal int32) *batchv1.Job {
+	env := []corev1.EnvVar{
+		{Name: "SCALE_NAMESPACE", Value: tc.GetNamespace()},
+		{Name: "SCALE_CLUSTER", Value: tc.GetName()},
+		{Name: "SCALE_COMPONENT", Value: memberType.String()},
+		{Name: "SCALE_ACTION", Value: action},
+		{Name: "SCALE_ORDINAL", Value: strconv.Itoa(int(ordinal))},
+		{Name: "SCALE_POD_NAME", Value: ordinalPodName(memberType, tc.GetName(), ordinal)},
+	}
+	return newHookJob(tc, hook, scaleHookComponent, scaleHookContainer, env)
+}
+
+// newHookJob returns the Job running the hook. Only the Job is labeled, the
+// Pods of the Job must not be selected as the Pods of the component.
+func newHookJob(tc *v1alpha1.TidbCluster, hook *v1alpha1.JobScaleHook, component, container string, env []corev1.EnvVar) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       tc.GetNamespace(),
+			Labels:          label.New().Instance(tc.GetInstanceName()).Component(component).Labels(),
+			OwnerReferences: []metav1.OwnerReference{controller.GetOwnerRef(tc)},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: pointer.Int32Ptr(0),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: hook.ServiceAccountName,
+					ImagePullSecrets:   tc.Spec.ImagePullSecrets,
+					Containers: []corev1.Container{
+						{
+							Name:    container,
+							Image:   hook.Image,
+							Command: hook.Command,
+							Args:    hook.Args,
+							Env:     env,
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,330 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+)
+
+func newScaleHookPod(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, ordinal int32) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ordinalPodName(memberType, tc.GetName(), ordinal),
+			Namespace: tc.GetNamespace(),
+		},
+	}
+}
+
+func TestRunPreScaleInHookHTTP(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		name        string
+		hookErr     error
+		done        bool
+		errExpectFn func(*GomegaWithT, error)
+		calls       int
+		annotated   bool
+	}{
+		{
+			name:        "hook succeeds",
+			errExpectFn: errExpectNil,
+			calls:       1,
+			annotated:   true,
+		},
+		{
+			name:        "hook fails",
+			hookErr:     fmt.Errorf("503 Service Unavailable"),
+			errExpectFn: errExpectRequeue,
+			calls:       1,
+		},
+		{
+			name:        "hook succeeded before",
+			done:        true,
+			errExpectFn: errExpectNil,
+			annotated:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deps := controller.NewFakeDependencies()
+			hookControl := deps.Controls.ScaleHookControl.(*controller.FakeScaleHookControl)
+			hookControl.Err = test.hookErr
+			recorder := deps.Recorder.(*record.FakeRecorder)
+
+			tc := newTidbClusterForPD()
+			tc.Spec.TiKV.ScaleHooks = &v1alpha1.ScaleHooks{
+				PreScaleIn: &v1alpha1.ScaleHook{HTTP: &v1alpha1.HTTPScaleHook{URL: "http://lb.example.com/hooks"}},
+			}
+			pod := newScaleHookPod(tc, v1alpha1.TiKVMemberType, 3)
+			if test.done {
+				pod.Annotations = map[string]string{label.AnnPreScaleInHookDone: "true"}
+			}
+			deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer().Add(pod)
+
+			err := runPreScaleInHook(deps, tc, v1alpha1.TiKVMemberType, 3)
+			test.errExpectFn(g, err)
+			g.Expect(hookControl.Reviews).To(HaveLen(test.calls))
+			if test.calls > 0 {
+				g.Expect(hookControl.Reviews[0]).To(Equal(controller.ScaleHookReview{
+					Namespace: tc.GetNamespace(),
+					Cluster:   tc.GetName(),
+					Component: "tikv",
+					Action:    scaleHookPreScaleIn,
+					Ordinal:   3,
+					PodName:   pod.GetName(),
+				}))
+			}
+
+			pod, err = deps.PodLister.Pods(tc.GetNamespace()).Get(pod.GetName())
+			g.Expect(err).NotTo(HaveOccurred())
+			_, annotated := pod.Annotations[label.AnnPreScaleInHookDone]
+			g.Expect(annotated).To(Equal(test.annotated))
+			if test.hookErr != nil {
+				events := collectEvents(recorder.Events)
+				g.Expect(events).To(HaveLen(1))
+				g.Expect(events[0]).To(ContainSubstring(scaleHookFailedReason))
+			}
+		})
+	}
+}
+
+func TestRunPreScaleInHookJob(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	recorder := deps.Recorder.(*record.FakeRecorder)
+	jobIndexer := deps.KubeInformerFactory.Batch().V1().Jobs().Informer().GetIndexer()
+
+	tc := newTidbClusterForPD()
+	tc.Spec.TiDB.ScaleHooks = &v1alpha1.ScaleHooks{
+		PreScaleIn: &v1alpha1.ScaleHook{Job: &v1alpha1.JobScaleHook{Image: "busybox", Command: []string{"/drain.sh"}}},
+	}
+	pod := newScaleHookPod(tc, v1alpha1.TiDBMemberType, 1)
+	deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer().Add(pod)
+
+	// the job is created and the scale-in waits for it
+	err := runPreScaleInHook(deps, tc, v1alpha1.TiDBMemberType, 1)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	job, err := deps.JobLister.Jobs(tc.GetNamespace()).Get(fmt.Sprintf("%s-pre-scale-in", pod.GetName()))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(job.OwnerReferences).To(HaveLen(1))
+	g.Expect(job.Spec.Template.Labels).To(BeEmpty())
+	container := job.Spec.Template.Spec.Containers[0]
+	g.Expect(container.Image).To(Equal("busybox"))
+	g.Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "SCALE_ACTION", Value: scaleHookPreScaleIn}))
+	g.Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "SCALE_POD_NAME", Value: pod.GetName()}))
+
+	// the job is running
+	err = runPreScaleInHook(deps, tc, v1alpha1.TiDBMemberType, 1)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+
+	// the job fails
+	failed := job.DeepCopy()
+	failed.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "BackoffLimitExceeded"}}
+	jobIndexer.Update(failed)
+	err = runPreScaleInHook(deps, tc, v1alpha1.TiDBMemberType, 1)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(ContainSubstring(scaleHookFailedReason))
+
+	// the job completes
+	complete := job.DeepCopy()
+	complete.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+	jobIndexer.Update(complete)
+	err = runPreScaleInHook(deps, tc, v1alpha1.TiDBMemberType, 1)
+	g.Expect(err).NotTo(HaveOccurred())
+	pod, err = deps.PodLister.Pods(tc.GetNamespace()).Get(pod.GetName())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pod.Annotations).To(HaveKey(label.AnnPreScaleInHookDone))
+}
+
+func TestRunPostScaleHook(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	hookControl := deps.Controls.ScaleHookControl.(*controller.FakeScaleHookControl)
+	recorder := deps.Recorder.(*record.FakeRecorder)
+
+	tc := newTidbClusterForPD()
+	runPostScaleHook(deps, tc, v1alpha1.PDMemberType, scaleHookPostScaleOut, 3)
+	g.Expect(hookControl.Reviews).To(BeEmpty())
+
+	tc.Spec.PD.ScaleHooks = &v1alpha1.ScaleHooks{
+		PostScale: &v1alpha1.ScaleHook{HTTP: &v1alpha1.HTTPScaleHook{URL: "http://cmdb.example.com/hooks"}},
+	}
+	runPostScaleHook(deps, tc, v1alpha1.PDMemberType, scaleHookPostScaleOut, 3)
+	g.Expect(hookControl.Reviews).To(HaveLen(1))
+	g.Expect(hookControl.Reviews[0].Action).To(Equal(scaleHookPostScaleOut))
+	g.Expect(collectEvents(recorder.Events)).To(BeEmpty())
+
+	// the failure of the hook does not fail the scale
+	hookControl.Err = fmt.Errorf("connection refused")
+	runPostScaleHook(deps, tc, v1alpha1.PDMemberType, scaleHookPostScaleIn, 3)
+	g.Expect(hookControl.Reviews).To(HaveLen(2))
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(ContainSubstring(scaleHookFailedReason))
+}
+
+func TestUpdateStatefulSetAndRunPostScaleHook(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	hookControl := deps.Controls.ScaleHookControl.(*controller.FakeScaleHookControl)
+	setControl := deps.StatefulSetControl.(*controller.FakeStatefulSetControl)
+	tc := newTidbClusterForPD()
+	tc.Spec.PD.ScaleHooks = &v1alpha1.ScaleHooks{
+		PostScale: &v1alpha1.ScaleHook{HTTP: &v1alpha1.HTTPScaleHook{URL: "http://cmdb.example.com/hooks"}},
+	}
+
+	// the hook is not run if the update fails
+	oldSet := newStatefulSetForPDScale()
+	newSet := oldSet.DeepCopy()
+	newSet.Spec.Replicas = pointer.Int32Ptr(6)
+	setControl.SetUpdateStatefulSetError(fmt.Errorf("conflict"), 0)
+	g.Expect(updateStatefulSetAndRunPostScaleHook(deps, tc, v1alpha1.PDMemberType, newSet, oldSet)).NotTo(Succeed())
+	g.Expect(hookControl.Reviews).To(BeEmpty())
+
+	oldSet = newStatefulSetForPDScale()
+	g.Expect(updateStatefulSetAndRunPostScaleHook(deps, tc, v1alpha1.PDMemberType, newSet, oldSet)).To(Succeed())
+	g.Expect(hookControl.Reviews).To(HaveLen(1))
+	g.Expect(hookControl.Reviews[0].Action).To(Equal(scaleHookPostScaleOut))
+	g.Expect(hookControl.Reviews[0].Ordinal).To(Equal(int32(5)))
+
+	oldSet = newStatefulSetForPDScale()
+	newSet = oldSet.DeepCopy()
+	newSet.Spec.Replicas = pointer.Int32Ptr(4)
+	g.Expect(updateStatefulSetAndRunPostScaleHook(deps, tc, v1alpha1.PDMemberType, newSet, oldSet)).To(Succeed())
+	g.Expect(hookControl.Reviews).To(HaveLen(2))
+	g.Expect(hookControl.Reviews[1].Action).To(Equal(scaleHookPostScaleIn))
+	g.Expect(hookControl.Reviews[1].Ordinal).To(Equal(int32(4)))
+
+	// no hook is run if nothing is scaled
+	oldSet = newStatefulSetForPDScale()
+	g.Expect(updateStatefulSetAndRunPostScaleHook(deps, tc, v1alpha1.PDMemberType, oldSet.DeepCopy(), oldSet)).To(Succeed())
+	g.Expect(hookControl.Reviews).To(HaveLen(2))
+}
+
+func TestClearCancelledPreScaleInHooks(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	podIndexer := deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+	tc := newTidbClusterForPD()
+	newSet := newStatefulSetForPDScale()
+	for _, ordinal := range []int32{4, 5} {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        fmt.Sprintf("%s-%d", newSet.Name, ordinal),
+				Namespace:   tc.GetNamespace(),
+				Annotations: map[string]string{label.AnnPreScaleInHookDone: "true"},
+			},
+		}
+		g.Expect(podIndexer.Add(pod)).To(Succeed())
+	}
+
+	g.Expect(clearCancelledPreScaleInHooks(deps, tc, newSet)).To(Succeed())
+
+	// the scale-in of the desired pod is cancelled
+	pod, err := deps.PodLister.Pods(tc.GetNamespace()).Get(fmt.Sprintf("%s-4", newSet.Name))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pod.Annotations).NotTo(HaveKey(label.AnnPreScaleInHookDone))
+	// the pod being scaled in keeps the mark
+	pod, err = deps.PodLister.Pods(tc.GetNamespace()).Get(fmt.Sprintf("%s-5", newSet.Name))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pod.Annotations).To(HaveKey(label.AnnPreScaleInHookDone))
+}
+
+// deletingJobControl deletes the Jobs from the indexer of the fake
+type deletingJobControl struct {
+	*controller.FakeJobControl
+}
+
+func (c deletingJobControl) DeleteJob(_ runtime.Object, job *batchv1.Job) error {
+	return c.JobIndexer.Delete(job)
+}
+
+func TestDeletePostScaleHookJobs(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	deps.Controls.JobControl = deletingJobControl{deps.Controls.JobControl.(*controller.FakeJobControl)}
+	recorder := deps.Recorder.(*record.FakeRecorder)
+	tc := newTidbClusterForPD()
+	tc.Spec.PD.ScaleHooks = &v1alpha1.ScaleHooks{
+		PostScale: &v1alpha1.ScaleHook{Job: &v1alpha1.JobScaleHook{Image: "busybox", Command: []string{"/notify.sh"}}},
+	}
+
+	runPostScaleHook(deps, tc, v1alpha1.PDMemberType, scaleHookPostScaleOut, 3)
+	jobs, err := deps.JobLister.Jobs(tc.GetNamespace()).List(labels.Everything())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(jobs).To(HaveLen(1))
+	g.Expect(jobs[0].Labels).To(HaveKeyWithValue(label.PostScaleHookLabelKey, v1alpha1.PDMemberType.String()))
+
+	jobIndexer := deps.KubeInformerFactory.Batch().V1().Jobs().Informer().GetIndexer()
+	conditions := map[string]batchv1.JobConditionType{
+		"test-pd-3-post-scale-out-a": "",
+		"test-pd-4-post-scale-out-b": batchv1.JobComplete,
+		"test-pd-4-post-scale-in-c":  batchv1.JobFailed,
+	}
+	jobIndexer.Delete(jobs[0])
+	for name, condition := range conditions {
+		job := newScaleHookJob(tc, tc.Spec.PD.ScaleHooks.PostScale.Job, v1alpha1.PDMemberType, scaleHookPostScaleOut, 3)
+		job.Name = name
+		job.Labels[label.PostScaleHookLabelKey] = v1alpha1.PDMemberType.String()
+		if condition != "" {
+			job.Status.Conditions = []batchv1.JobCondition{{Type: condition, Status: corev1.ConditionTrue, Message: "BackoffLimitExceeded"}}
+		}
+		jobIndexer.Add(job)
+	}
+	// the Job of a PreScaleIn hook is waited for and deleted by the scale-in
+	preScaleIn := newScaleHookJob(tc, tc.Spec.PD.ScaleHooks.PostScale.Job, v1alpha1.PDMemberType, scaleHookPreScaleIn, 4)
+	preScaleIn.Name = "test-pd-4-pre-scale-in"
+	preScaleIn.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+	jobIndexer.Add(preScaleIn)
+
+	g.Expect(deletePostScaleHookJobs(deps, tc, v1alpha1.TiKVMemberType)).To(Succeed())
+	jobs, err = deps.JobLister.Jobs(tc.GetNamespace()).List(labels.Everything())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(jobs).To(HaveLen(4))
+
+	g.Expect(deletePostScaleHookJobs(deps, tc, v1alpha1.PDMemberType)).To(Succeed())
+	jobs, err = deps.JobLister.Jobs(tc.GetNamespace()).List(labels.Everything())
+	g.Expect(err).NotTo(HaveOccurred())
+	var names []string
+	for _, job := range jobs {
+		names = append(names, job.Name)
+	}
+	g.Expect(names).To(ConsistOf("test-pd-3-post-scale-out-a", "test-pd-4-pre-scale-in"))
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(ContainSubstring("test-pd-4-post-scale-in-c"))
+}
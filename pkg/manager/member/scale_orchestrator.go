@@ -0,0 +1,162 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+// scaleDeferredReason is the reason of the Events of the scales deferred by
+// the scales of the other components
+const scaleDeferredReason = "ScaleDeferred"
+
+// scaleInOrder is the order the components are scaled in when the replicas of
+// several components are decreased at once. A component is scaled in after
+// the components depending on it, e.g. TiDB before TiKV and TiKV before PD, so
+// that PD keeps its quorum while the regions are moved off the deleted TiKV
+// stores. The components are scaled out in the reverse order.
+var scaleInOrder = []v1alpha1.MemberType{
+	v1alpha1.TiDBMemberType,
+	v1alpha1.TiCDCMemberType,
+	v1alpha1.PumpMemberType,
+	v1alpha1.TiFlashMemberType,
+	v1alpha1.TiKVMemberType,
+	v1alpha1.PDMemberType,
+}
+
+// deferScale returns whether the scale of the component is deferred until the
+// components ordered before it finish scaling in the same direction, in which
+// case the replicas of the new StatefulSet are reset and an Event names the
+// blocking component. Deferring is not an error, so that the components synced
+// after it in the same reconcile go on scaling. Only the StatefulSet of spec.pd
+// is checked for PD, not the groups. The order is ignored if the cluster is
+// annotated with tidb.pingcap.com/ignore-scale-order, e.g. when a TiKV
+// scale-in can never finish and blocks the PD scale-in.
+func (s *generalScaler) deferScale(meta metav1.Object, memberType v1alpha1.MemberType, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) (bool, error) {
+	tc, ok := meta.(*v1alpha1.TidbCluster)
+	if !ok {
+		return false, nil
+	}
+	if tc.Annotations[label.AnnIgnoreScaleOrder] == label.AnnIgnoreScaleOrderVal {
+		return false, nil
+	}
+	scaling, _, _, _ := scaleOne(oldSet, newSet)
+	if scaling == 0 {
+		return false, nil
+	}
+
+	var before []v1alpha1.MemberType
+	for i, t := range scaleInOrder {
+		if t != memberType {
+			continue
+		}
+		if scaling < 0 {
+			before = scaleInOrder[:i]
+		} else {
+			before = scaleInOrder[i+1:]
+		}
+		break
+	}
+	for _, t := range before {
+		other, err := componentScaling(s.deps, tc, t)
+		if err != nil {
+			return false, err
+		}
+		if other != scaling {
+			continue
+		}
+		direction := "out"
+		if scaling < 0 {
+			direction = "in"
+		}
+		msg := fmt.Sprintf("defer scaling %s %s until %s finishes scaling %s, annotate the cluster with %s=%s to scale it anyway",
+			direction, memberType, t, direction, label.AnnIgnoreScaleOrder, label.AnnIgnoreScaleOrderVal)
+		klog.Infof("tc[%s/%s]'s %s", tc.GetNamespace(), tc.GetName(), msg)
+		s.deps.Recorder.Event(tc, corev1.EventTypeWarning, scaleDeferredReason, msg)
+		resetReplicas(newSet, oldSet)
+		recordScaleBlocked(tc, memberType, scaleDeferredReason)
+		return true, nil
+	}
+	return false, nil
+}
+
+// componentScaling returns 1 if the StatefulSet of the component is being
+// scaled out to its desired replicas, -1 if it is being scaled in, and 0 if it
+// is not scaling or does not exist.
+func componentScaling(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType) (int, error) {
+	var setName string
+	var desired int32
+	tcName := tc.GetName()
+	switch memberType {
+	case v1alpha1.PDMemberType:
+		if tc.Spec.PD == nil {
+			return 0, nil
+		}
+		setName, desired = controller.PDMemberName(tcName), tc.PDStsDesiredReplicas()
+	case v1alpha1.TiKVMemberType:
+		if tc.Spec.TiKV == nil {
+			return 0, nil
+		}
+		setName, desired = controller.TiKVMemberName(tcName), tc.TiKVStsDesiredReplicas()
+	case v1alpha1.TiFlashMemberType:
+		if tc.Spec.TiFlash == nil {
+			return 0, nil
+		}
+		setName, desired = controller.TiFlashMemberName(tcName), tc.TiFlashStsDesiredReplicas()
+	case v1alpha1.TiDBMemberType:
+		if tc.Spec.TiDB == nil {
+			return 0, nil
+		}
+		setName, desired = controller.TiDBMemberName(tcName), tc.TiDBStsDesiredReplicas()
+	case v1alpha1.TiCDCMemberType:
+		if tc.Spec.TiCDC == nil {
+			return 0, nil
+		}
+		setName, desired = controller.TiCDCMemberName(tcName), tc.Spec.TiCDC.Replicas
+	case v1alpha1.PumpMemberType:
+		if tc.Spec.Pump == nil {
+			return 0, nil
+		}
+		setName, desired = controller.PumpMemberName(tcName), tc.Spec.Pump.Replicas
+	default:
+		return 0, nil
+	}
+
+	set, err := deps.StatefulSetLister.StatefulSets(tc.GetNamespace()).Get(setName)
+	if errors.IsNotFound(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("componentScaling: failed to get statefulset %s/%s for tc %s/%s, error: %s", tc.GetNamespace(), setName, tc.GetNamespace(), tcName, err)
+	}
+	if set.Spec.Replicas == nil {
+		return 0, nil
+	}
+	switch current := *set.Spec.Replicas; {
+	case desired > current:
+		return 1, nil
+	case desired < current:
+		return -1, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+)
+
+func TestGeneralScalerDeferScale(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		name        string
+		memberType  v1alpha1.MemberType
+		scaling     int32
+		pdScaling   int32
+		tikvScaling int32
+		ignoreOrder bool
+		deferred    bool
+	}{
+		{
+			name:        "pd is scaled in after tikv",
+			memberType:  v1alpha1.PDMemberType,
+			scaling:     -1,
+			tikvScaling: -1,
+			deferred:    true,
+		},
+		{
+			name:        "tikv is scaled in before pd",
+			memberType:  v1alpha1.TiKVMemberType,
+			scaling:     -1,
+			pdScaling:   -1,
+			tikvScaling: -1,
+		},
+		{
+			name:        "tikv is scaled out after pd",
+			memberType:  v1alpha1.TiKVMemberType,
+			scaling:     1,
+			pdScaling:   1,
+			tikvScaling: 1,
+			deferred:    true,
+		},
+		{
+			name:        "pd is scaled out before tikv",
+			memberType:  v1alpha1.PDMemberType,
+			scaling:     1,
+			pdScaling:   1,
+			tikvScaling: 1,
+		},
+		{
+			name:        "pd is scaled in while tikv is scaled in if the order is ignored",
+			memberType:  v1alpha1.PDMemberType,
+			scaling:     -1,
+			tikvScaling: -1,
+			ignoreOrder: true,
+		},
+		{
+			name:        "pd is scaled in while tikv is scaled out",
+			memberType:  v1alpha1.PDMemberType,
+			scaling:     -1,
+			pdScaling:   -1,
+			tikvScaling: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deps := controller.NewFakeDependencies()
+			recorder := record.NewFakeRecorder(10)
+			deps.Recorder = recorder
+			scaler := &generalScaler{deps: deps}
+			tc := newTidbClusterForPD()
+			tc.Spec.PD.Replicas = 5 + test.pdScaling
+			tc.Spec.TiKV.Replicas = 5 + test.tikvScaling
+			if test.ignoreOrder {
+				tc.Annotations = map[string]string{label.AnnIgnoreScaleOrder: label.AnnIgnoreScaleOrderVal}
+			}
+
+			indexer := deps.KubeInformerFactory.Apps().V1().StatefulSets().Informer().GetIndexer()
+			indexer.Add(newStatefulSetForPDGroup(controller.PDMemberName(tc.GetName()), 5, 5))
+			indexer.Add(newStatefulSetForPDGroup(controller.TiKVMemberName(tc.GetName()), 5, 5))
+
+			oldSet := newStatefulSetForPDScale()
+			newSet := oldSet.DeepCopy()
+			newSet.Spec.Replicas = pointer.Int32Ptr(5 + test.scaling)
+
+			deferred, err := scaler.deferScale(tc, test.memberType, oldSet, newSet)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(deferred).To(Equal(test.deferred))
+			events := collectEvents(recorder.Events)
+			if test.deferred {
+				g.Expect(*newSet.Spec.Replicas).To(Equal(int32(5)))
+				// the event names the blocking component
+				blocking := v1alpha1.TiKVMemberType
+				if test.memberType == v1alpha1.TiKVMemberType {
+					blocking = v1alpha1.PDMemberType
+				}
+				g.Expect(events).To(HaveLen(1))
+				g.Expect(events[0]).To(ContainSubstring(scaleDeferredReason))
+				g.Expect(events[0]).To(ContainSubstring("until %s finishes", blocking))
+			} else {
+				g.Expect(*newSet.Spec.Replicas).To(Equal(5 + test.scaling))
+				g.Expect(events).To(BeEmpty())
+			}
+		})
+	}
+}
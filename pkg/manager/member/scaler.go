@@ -14,7 +14,11 @@
 package member
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/pingcap/advanced-statefulset/client/apis/apps/v1/helper"
@@ -22,9 +26,13 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/features"
+	"github.com/pingcap/tidb-operator/pkg/metrics"
 	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog"
 )
@@ -49,6 +57,110 @@ type generalScaler struct {
 	deps *controller.Dependencies
 }
 
+// checkScaleVelocity enforces the MaxScaleVelocity of the ScalePolicy of the
+// cluster. The member being scaled is recorded in the status by recordScale
+// once its scale begins, and a new scale is requeued if the members recorded
+// in the window reach the limit. The scale of a recorded member is never
+// blocked, so that a begun scale-in is not left halfway. The records of the
+// component older than the window are pruned except the one of the member
+// being scaled.
+func (s *generalScaler) checkScaleVelocity(meta metav1.Object, memberType v1alpha1.MemberType, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	tc, ok := meta.(*v1alpha1.TidbCluster)
+	if !ok {
+		return nil
+	}
+	limit, window := tc.MaxScaleVelocity()
+	if limit <= 0 {
+		tc.Status.RecentScales = nil
+		return nil
+	}
+
+	target := scaleRecordTarget(memberType, oldSet, newSet)
+
+	now := time.Now()
+	var records []v1alpha1.ScaleRecord
+	begun := false
+	count := 0
+	for _, record := range tc.Status.RecentScales {
+		current := target != nil && isScaleRecordOf(record, target)
+		if current {
+			begun = true
+		}
+		recent := now.Sub(record.Time.Time) < window
+		if !recent && !current && record.Component == memberType && recordedSetName(record.PodName) == oldSet.Name {
+			continue
+		}
+		records = append(records, record)
+		if recent {
+			count++
+		}
+	}
+	tc.Status.RecentScales = records
+	if target == nil || begun {
+		return nil
+	}
+
+	if count >= limit {
+		msg := fmt.Sprintf("%s pod %s can't be scaled as %d members are added or removed in the last %v, the limit is %d",
+			memberType, target.PodName, count, window, limit)
+		s.deps.Recorder.Event(tc, corev1.EventTypeWarning, "ScaleVelocityExceeded", msg)
+		recordScaleBlocked(tc, memberType, "ScaleVelocityExceeded")
+		return controller.RequeueErrorf("tc[%s/%s]'s %s", tc.GetNamespace(), tc.GetName(), msg)
+	}
+	return nil
+}
+
+// recordScale records the member being scaled in the status for the
+// MaxScaleVelocity, it is called after all the checks before the scale pass
+// so that a blocked scale is not counted
+func (s *generalScaler) recordScale(meta metav1.Object, memberType v1alpha1.MemberType, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) {
+	tc, ok := meta.(*v1alpha1.TidbCluster)
+	if !ok {
+		return
+	}
+	if limit, _ := tc.MaxScaleVelocity(); limit <= 0 {
+		return
+	}
+	target := scaleRecordTarget(memberType, oldSet, newSet)
+	if target == nil {
+		return
+	}
+	for _, record := range tc.Status.RecentScales {
+		if isScaleRecordOf(record, target) {
+			return
+		}
+	}
+	target.Time = metav1.Now()
+	tc.Status.RecentScales = append(tc.Status.RecentScales, *target)
+}
+
+// scaleRecordTarget returns the record of the member being scaled, or nil if
+// the StatefulSet is not scaled
+func scaleRecordTarget(memberType v1alpha1.MemberType, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) *v1alpha1.ScaleRecord {
+	scaling, ordinal, _, _ := scaleOne(oldSet, newSet)
+	if scaling == 0 {
+		return nil
+	}
+	return &v1alpha1.ScaleRecord{
+		Component: memberType,
+		PodName:   fmt.Sprintf("%s-%d", oldSet.Name, ordinal),
+		ScaleIn:   scaling < 0,
+	}
+}
+
+// isScaleRecordOf returns whether the record is of the scale of the target
+func isScaleRecordOf(record v1alpha1.ScaleRecord, target *v1alpha1.ScaleRecord) bool {
+	return record.Component == target.Component && record.PodName == target.PodName && record.ScaleIn == target.ScaleIn
+}
+
+// recordedSetName returns the StatefulSet name of the Pod of a scale record
+func recordedSetName(podName string) string {
+	if i := strings.LastIndex(podName, "-"); i > 0 {
+		return podName[:i]
+	}
+	return podName
+}
+
 // TODO: change skipReason to event recorder as in TestPDFailoverFailover
 func (s *generalScaler) deleteDeferDeletingPVC(controller runtime.Object, memberType v1alpha1.MemberType, ordinal int32) (map[string]string, error) {
 	meta := controller.(metav1.Object)
@@ -92,6 +204,7 @@ func (s *generalScaler) deleteDeferDeletingPVC(controller runtime.Object, member
 			return skipReason, err
 		}
 		klog.Infof("Scale out: delete pvc %s/%s successfully", ns, pvcName)
+		recordPVCDeferOperation(meta, memberType.String(), metrics.PVCDeferOperationDelete)
 	}
 	return skipReason, nil
 }
@@ -135,6 +248,7 @@ func (s *generalScaler) updateDeferDeletingPVC(tc *v1alpha1.TidbCluster,
 		}
 		klog.Infof("Scale in: set pvc %s/%s annotation: %s to %s",
 			ns, pvcName, label.AnnPVCDeferDeleting, now)
+		recordPVCDeferOperation(tc, memberType.String(), metrics.PVCDeferOperationMark)
 	}
 	return nil
 }
@@ -230,3 +344,117 @@ func normalizeDeleteSlots(replicas int32, deleteSlots sets.Int32, desiredDeleteS
 	}
 	return deleteSlots
 }
+
+// skipScaleInProtectedPods keeps the Pods annotated with the scale-in
+// protection in newSet. With advanced StatefulSet, the protected ordinals that
+// would be scaled in are kept and the same number of unprotected ordinals are
+// scaled in instead by the delete slots of newSet, preferring the ordinals
+// without Pods and then the highest ones. Without advanced StatefulSet only
+// the highest ordinal can be scaled in, so the scale-in waits while it is
+// protected. The delete slots are persisted in the delete-slots annotation of
+// the TidbCluster as well, so that the desired ordinals of the component agree
+// with the StatefulSet.
+func skipScaleInProtectedPods(deps *controller.Dependencies, meta metav1.Object, memberType v1alpha1.MemberType, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	ns := meta.GetNamespace()
+	actualPodOrdinals := helper.GetPodOrdinals(*oldSet.Spec.Replicas, oldSet)
+	desiredPodOrdinals := helper.GetPodOrdinals(*newSet.Spec.Replicas, newSet)
+	deletions := actualPodOrdinals.Difference(desiredPodOrdinals)
+	if deletions.Len() == 0 {
+		return nil
+	}
+
+	protected := sets.NewInt32()
+	existing := sets.NewInt32()
+	for _, ordinal := range actualPodOrdinals.Union(desiredPodOrdinals).List() {
+		podName := fmt.Sprintf("%s-%d", oldSet.GetName(), ordinal)
+		pod, err := deps.PodLister.Pods(ns).Get(podName)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("skipScaleInProtectedPods: failed to get pod %s/%s for cluster %s/%s, error: %s", ns, podName, ns, meta.GetName(), err)
+		}
+		existing.Insert(ordinal)
+		if pod.Annotations[label.AnnScaleInProtected] == label.AnnScaleInProtectedVal {
+			protected.Insert(ordinal)
+		}
+	}
+
+	keep := deletions.Intersection(protected)
+	if keep.Len() == 0 {
+		return nil
+	}
+	if !features.DefaultFeatureGate.Enabled(features.AdvancedStatefulSet) {
+		if scaling, ordinal, _, _ := scaleOne(oldSet, newSet); scaling < 0 && protected.Has(ordinal) {
+			return controller.RequeueErrorf("cluster[%s/%s]'s pod %s-%d is protected from scale-in, scaling in another pod requires advanced StatefulSet",
+				ns, meta.GetName(), oldSet.GetName(), ordinal)
+		}
+		return nil
+	}
+
+	// the candidates are sorted by whether the Pod exists and then by the ordinal in descending order
+	candidates := desiredPodOrdinals.Difference(protected).List()
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if existing.Has(candidates[i]) != existing.Has(candidates[j]) {
+			return !existing.Has(candidates[i])
+		}
+		return candidates[i] > candidates[j]
+	})
+	if len(candidates) < keep.Len() {
+		return controller.RequeueErrorf("cluster[%s/%s]'s pods %v of statefulset %s are protected from scale-in, no other pod can be scaled in",
+			ns, meta.GetName(), keep.List(), oldSet.GetName())
+	}
+
+	ordinals := desiredPodOrdinals.Union(keep).Delete(candidates[:keep.Len()]...)
+	list := ordinals.List()
+	deleteSlots := sets.NewInt32()
+	for ordinal := int32(0); ordinal < list[len(list)-1]; ordinal++ {
+		if !ordinals.Has(ordinal) {
+			deleteSlots.Insert(ordinal)
+		}
+	}
+	if tc, ok := meta.(*v1alpha1.TidbCluster); ok {
+		key := deleteSlotsAnnKey(memberType.String())
+		if group, ok := pdGroupOfSet(tc, oldSet); ok {
+			key = group.deleteSlotsAnnKey()
+		}
+		if err := persistDeleteSlots(deps, tc, key, deleteSlots); err != nil {
+			return err
+		}
+	}
+	klog.Infof("cluster[%s/%s]'s pods %v of statefulset %s are protected from scale-in, scale in %v instead",
+		ns, meta.GetName(), keep.List(), oldSet.GetName(), candidates[:keep.Len()])
+	helper.SetDeleteSlots(newSet, deleteSlots)
+	return nil
+}
+
+// persistDeleteSlots patches the delete-slots annotation of the key in the
+// TidbCluster, as the annotations are not persisted by the update of the
+// status
+func persistDeleteSlots(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, key string, deleteSlots sets.Int32) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	value, err := json.Marshal(deleteSlots.List())
+	if err != nil {
+		return err
+	}
+	if tc.Annotations[key] == string(value) {
+		return nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{key: string(value)},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := deps.Clientset.PingcapV1alpha1().TidbClusters(ns).Patch(context.TODO(), tcName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("persistDeleteSlots: failed to set the delete slots %s=%v of tc %s/%s, error: %v", key, deleteSlots.List(), ns, tcName, err)
+	}
+	if tc.Annotations == nil {
+		tc.Annotations = map[string]string{}
+	}
+	tc.Annotations[key] = string(value)
+	return nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/metrics"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// scalerWithMetrics records the attempts of the Scaler to scale the component
+// and their durations. An attempt scales the StatefulSet by at most one
+// member, the calls without scaling are not recorded.
+type scalerWithMetrics struct {
+	Scaler
+	memberType v1alpha1.MemberType
+}
+
+// withScaleMetrics returns the Scaler recording the scale metrics of the component
+func withScaleMetrics(memberType v1alpha1.MemberType, scaler Scaler) Scaler {
+	return &scalerWithMetrics{Scaler: scaler, memberType: memberType}
+}
+
+func (s *scalerWithMetrics) Scale(meta metav1.Object, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	scaling, _, _, _ := scaleOne(oldSet, newSet)
+	if scaling == 0 {
+		return s.Scaler.Scale(meta, oldSet, newSet)
+	}
+	direction := "out"
+	if scaling < 0 {
+		direction = "in"
+	}
+
+	start := time.Now()
+	err := s.Scaler.Scale(meta, oldSet, newSet)
+	ns, name, component := meta.GetNamespace(), meta.GetName(), s.memberType.String()
+	metrics.ScaleDuration.WithLabelValues(ns, name, component, direction).Observe(time.Since(start).Seconds())
+
+	result := metrics.ScaleResultScaled
+	switch {
+	case controller.IsRequeueError(err):
+		result = metrics.ScaleResultBlocked
+	case err != nil:
+		result = metrics.ScaleResultFailed
+	case *newSet.Spec.Replicas == *oldSet.Spec.Replicas:
+		result = metrics.ScaleResultBlocked
+	}
+	metrics.ScaleAttempts.WithLabelValues(ns, name, component, direction, result).Inc()
+	return err
+}
+
+// recordScaleBlocked records that the scale of the component is blocked for the reason
+func recordScaleBlocked(meta metav1.Object, memberType v1alpha1.MemberType, reason string) {
+	metrics.ScaleBlocked.WithLabelValues(meta.GetNamespace(), meta.GetName(), memberType.String(), reason).Inc()
+}
+
+// recordPVCDeferOperation records that a PVC of the component is marked as
+// defer deleting or deleted
+func recordPVCDeferOperation(meta metav1.Object, component string, operation string) {
+	metrics.PVCDeferOperations.WithLabelValues(meta.GetNamespace(), meta.GetName(), component, operation).Inc()
+}
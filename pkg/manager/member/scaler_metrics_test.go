@@ -0,0 +1,111 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/metrics"
+)
+
+type fakeBlockingScaler struct {
+	fakePDScaler
+	err error
+}
+
+func (s *fakeBlockingScaler) Scale(meta metav1.Object, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	resetReplicas(newSet, oldSet)
+	return s.err
+}
+
+func TestScalerWithMetrics(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		name      string
+		scaler    Scaler
+		replicas  int32
+		direction string
+		result    string
+	}{
+		{
+			name:      "scale out",
+			scaler:    NewFakePDScaler(),
+			replicas:  6,
+			direction: "out",
+			result:    metrics.ScaleResultScaled,
+		},
+		{
+			name:      "scale in",
+			scaler:    NewFakePDScaler(),
+			replicas:  4,
+			direction: "in",
+			result:    metrics.ScaleResultScaled,
+		},
+		{
+			name:      "scale is deferred",
+			scaler:    &fakeBlockingScaler{},
+			replicas:  6,
+			direction: "out",
+			result:    metrics.ScaleResultBlocked,
+		},
+		{
+			name:      "scale is requeued",
+			scaler:    &fakeBlockingScaler{err: controller.RequeueErrorf("requeue")},
+			replicas:  4,
+			direction: "in",
+			result:    metrics.ScaleResultBlocked,
+		},
+		{
+			name:      "scale fails",
+			scaler:    &fakeBlockingScaler{err: fmt.Errorf("failed")},
+			replicas:  4,
+			direction: "in",
+			result:    metrics.ScaleResultFailed,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tc := newTidbClusterForPD()
+			tc.Name = fmt.Sprintf("metrics-%d", i)
+			oldSet := newStatefulSetForPDScale()
+			newSet := oldSet.DeepCopy()
+			newSet.Spec.Replicas = pointer.Int32Ptr(test.replicas)
+			durations := testutil.CollectAndCount(metrics.ScaleDuration)
+
+			withScaleMetrics(v1alpha1.PDMemberType, test.scaler).Scale(tc, oldSet, newSet)
+			attempts := metrics.ScaleAttempts.WithLabelValues(tc.Namespace, tc.Name, "pd", test.direction, test.result)
+			g.Expect(testutil.ToFloat64(attempts)).To(Equal(float64(1)))
+			g.Expect(testutil.CollectAndCount(metrics.ScaleDuration)).To(Equal(durations + 1))
+		})
+	}
+
+	// the syncs without scaling are not recorded
+	tc := newTidbClusterForPD()
+	tc.Name = "metrics-none"
+	set := newStatefulSetForPDScale()
+	durations := testutil.CollectAndCount(metrics.ScaleDuration)
+	g.Expect(withScaleMetrics(v1alpha1.PDMemberType, NewFakePDScaler()).Scale(tc, set, set.DeepCopy())).To(Succeed())
+	g.Expect(testutil.CollectAndCount(metrics.ScaleDuration)).To(Equal(durations))
+}
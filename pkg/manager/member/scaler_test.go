@@ -14,9 +14,11 @@
 package member
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	. "github.com/onsi/gomega"
@@ -638,6 +640,233 @@ func TestScaleOne(t *testing.T) {
 	}
 }
 
+func TestSkipScaleInProtectedPods(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		name              string
+		advanced          bool
+		desiredReplicas   int32
+		protected         []int32
+		errExpectFn       func(*GomegaWithT, error)
+		expectDeleteSlots sets.Int32
+	}{
+		{
+			name:              "no pod is protected",
+			advanced:          true,
+			desiredReplicas:   3,
+			errExpectFn:       errExpectNil,
+			expectDeleteSlots: sets.NewInt32(),
+		},
+		{
+			name:              "the protected pod is not scaled in",
+			advanced:          true,
+			desiredReplicas:   3,
+			protected:         []int32{1},
+			errExpectFn:       errExpectNil,
+			expectDeleteSlots: sets.NewInt32(),
+		},
+		{
+			name:              "another pod is scaled in instead",
+			advanced:          true,
+			desiredReplicas:   3,
+			protected:         []int32{4},
+			errExpectFn:       errExpectNil,
+			expectDeleteSlots: sets.NewInt32(2, 3),
+		},
+		{
+			name:              "other pods are scaled in instead",
+			advanced:          true,
+			desiredReplicas:   3,
+			protected:         []int32{2, 3, 4},
+			errExpectFn:       errExpectNil,
+			expectDeleteSlots: sets.NewInt32(0, 1),
+		},
+		{
+			name:              "all pods are protected",
+			advanced:          true,
+			desiredReplicas:   1,
+			protected:         []int32{0, 3},
+			errExpectFn:       errExpectRequeue,
+			expectDeleteSlots: sets.NewInt32(),
+		},
+		{
+			name:              "the highest pod is protected without advanced statefulset",
+			desiredReplicas:   3,
+			protected:         []int32{4},
+			errExpectFn:       errExpectRequeue,
+			expectDeleteSlots: sets.NewInt32(),
+		},
+		{
+			name:              "a lower pod is protected without advanced statefulset",
+			desiredReplicas:   3,
+			protected:         []int32{3},
+			errExpectFn:       errExpectNil,
+			expectDeleteSlots: sets.NewInt32(),
+		},
+	}
+
+	enabled := features.DefaultFeatureGate.Enabled(features.AdvancedStatefulSet)
+	defer features.DefaultFeatureGate.Set(fmt.Sprintf("AdvancedStatefulSet=%t", enabled))
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			features.DefaultFeatureGate.Set(fmt.Sprintf("AdvancedStatefulSet=%t", test.advanced))
+			deps := controller.NewFakeDependencies()
+			podIndexer := deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+
+			tc := newTidbClusterForPD()
+			tc.Spec.TiKV.Replicas = test.desiredReplicas
+			_, err := deps.Clientset.PingcapV1alpha1().TidbClusters(tc.Namespace).Create(context.TODO(), tc, metav1.CreateOptions{})
+			g.Expect(err).NotTo(HaveOccurred())
+			oldSet := newStatefulSetForPDScale()
+			oldSet.Name = controller.TiKVMemberName(tc.GetName())
+			newSet := oldSet.DeepCopy()
+			newSet.Spec.Replicas = pointer.Int32Ptr(test.desiredReplicas)
+			protected := sets.NewInt32(test.protected...)
+			for ordinal := int32(0); ordinal < *oldSet.Spec.Replicas; ordinal++ {
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      fmt.Sprintf("%s-%d", oldSet.Name, ordinal),
+						Namespace: tc.GetNamespace(),
+					},
+				}
+				if protected.Has(ordinal) {
+					pod.Annotations = map[string]string{label.AnnScaleInProtected: label.AnnScaleInProtectedVal}
+				}
+				podIndexer.Add(pod)
+			}
+
+			err = skipScaleInProtectedPods(deps, tc, v1alpha1.TiKVMemberType, oldSet, newSet)
+			test.errExpectFn(g, err)
+			g.Expect(*newSet.Spec.Replicas).To(Equal(test.desiredReplicas))
+			g.Expect(helper.GetDeleteSlots(newSet)).To(Equal(test.expectDeleteSlots))
+			if err != nil {
+				return
+			}
+			// the desired ordinals of the component agree with the StatefulSet
+			g.Expect(tc.TiKVStsDesiredOrdinals(true)).To(Equal(helper.GetPodOrdinals(test.desiredReplicas, newSet)))
+			persisted, err := deps.Clientset.PingcapV1alpha1().TidbClusters(tc.Namespace).Get(context.TODO(), tc.Name, metav1.GetOptions{})
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(persisted.Annotations[label.AnnTiKVDeleteSlots]).To(Equal(tc.Annotations[label.AnnTiKVDeleteSlots]))
+		})
+	}
+}
+
+func TestGeneralScalerCheckScaleVelocity(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	recent := metav1.NewTime(time.Now().Add(-time.Minute))
+	old := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	tests := []struct {
+		name        string
+		members     int32
+		replicas    int32
+		records     []v1alpha1.ScaleRecord
+		errExpectFn func(*GomegaWithT, error)
+		pods        []string
+	}{
+		{
+			name:        "scale is not limited",
+			replicas:    6,
+			records:     []v1alpha1.ScaleRecord{{Component: v1alpha1.TiKVMemberType, PodName: "scaler-4", Time: recent}},
+			errExpectFn: errExpectNil,
+		},
+		{
+			name:        "scale out under the limit",
+			members:     2,
+			replicas:    6,
+			records:     []v1alpha1.ScaleRecord{{Component: v1alpha1.TiDBMemberType, PodName: "test-tidb-1", Time: recent}},
+			errExpectFn: errExpectNil,
+			pods:        []string{"test-tidb-1", "scaler-5"},
+		},
+		{
+			name:     "scale in over the limit",
+			members:  2,
+			replicas: 4,
+			records: []v1alpha1.ScaleRecord{
+				{Component: v1alpha1.TiDBMemberType, PodName: "test-tidb-1", Time: recent},
+				{Component: v1alpha1.TiKVMemberType, PodName: "scaler-6", ScaleIn: true, Time: recent},
+			},
+			errExpectFn: errExpectRequeue,
+			pods:        []string{"test-tidb-1", "scaler-6"},
+		},
+		{
+			name:     "scale in of the member has begun",
+			members:  1,
+			replicas: 4,
+			records: []v1alpha1.ScaleRecord{
+				{Component: v1alpha1.TiKVMemberType, PodName: "scaler-4", ScaleIn: true, Time: old},
+				{Component: v1alpha1.TiDBMemberType, PodName: "test-tidb-1", Time: recent},
+			},
+			errExpectFn: errExpectNil,
+			pods:        []string{"scaler-4", "test-tidb-1"},
+		},
+		{
+			name:     "old records of the set are pruned",
+			members:  1,
+			replicas: 5,
+			records: []v1alpha1.ScaleRecord{
+				{Component: v1alpha1.TiKVMemberType, PodName: "scaler-5", ScaleIn: true, Time: old},
+				{Component: v1alpha1.TiDBMemberType, PodName: "test-tidb-1", Time: old},
+			},
+			errExpectFn: errExpectNil,
+			pods:        []string{"test-tidb-1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deps := controller.NewFakeDependencies()
+			scaler := &generalScaler{deps: deps}
+			tc := newTidbClusterForPD()
+			if test.members > 0 {
+				tc.Spec.ScalePolicy = &v1alpha1.ScalePolicy{MaxScaleVelocity: &v1alpha1.ScaleVelocity{Members: test.members}}
+			}
+			tc.Status.RecentScales = test.records
+
+			oldSet := newStatefulSetForPDScale()
+			newSet := oldSet.DeepCopy()
+			newSet.Spec.Replicas = pointer.Int32Ptr(test.replicas)
+			err := scaler.checkScaleVelocity(tc, v1alpha1.TiKVMemberType, oldSet, newSet)
+			test.errExpectFn(g, err)
+			if err == nil {
+				scaler.recordScale(tc, v1alpha1.TiKVMemberType, oldSet, newSet)
+			}
+
+			var pods []string
+			for _, record := range tc.Status.RecentScales {
+				pods = append(pods, record.PodName)
+			}
+			g.Expect(pods).To(Equal(test.pods))
+		})
+	}
+}
+
+func TestScaleVelocityRecordsAppliedScales(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	scaler := NewTiDBScaler(deps)
+	tc := newTidbClusterForPD()
+	tc.Spec.ScalePolicy = &v1alpha1.ScalePolicy{MaxScaleVelocity: &v1alpha1.ScaleVelocity{Members: 2}}
+	tc.Status.Conditions = []v1alpha1.TidbClusterCondition{{Type: v1alpha1.TidbClusterPDSplitBrain, Status: corev1.ConditionTrue}}
+
+	oldSet := newStatefulSetForPDScale()
+	newSet := oldSet.DeepCopy()
+	newSet.Spec.Replicas = pointer.Int32Ptr(6)
+
+	// the scale blocked by a later check is not recorded
+	err := scaler.Scale(tc, oldSet, newSet.DeepCopy())
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(tc.Status.RecentScales).To(BeEmpty())
+
+	// the scale is recorded once it begins
+	tc.Status.Conditions = nil
+	scaler.Scale(tc, oldSet, newSet.DeepCopy())
+	g.Expect(tc.Status.RecentScales).To(HaveLen(1))
+	g.Expect(tc.Status.RecentScales[0].PodName).To(Equal("scaler-5"))
+}
+
 func TestGeneralScalerDeleteMultiDeferDeletingPVC(t *testing.T) {
 	type testcase struct {
 		name         string
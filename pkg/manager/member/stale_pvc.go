@@ -0,0 +1,225 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	// stalePVCFoundReason is the reason of the StalePVC condition and Events when a scale-out is blocked
+	stalePVCFoundReason = "StalePVCFound"
+	// stalePVCWipeFailedReason is the reason of the Events of the failed cleanup Jobs
+	stalePVCWipeFailedReason = "StalePVCWipeFailed"
+	// stalePVCCleanupComponent is the component label of the cleanup Jobs
+	stalePVCCleanupComponent = "stale-pvc-cleanup"
+	// stalePVCMountPath is the path the stale PVC is mounted at in the cleanup Jobs
+	stalePVCMountPath = "/data"
+)
+
+// checkStalePVC handles the PVCs of the member of the ordinal being scaled out
+// that are marked as defer deleting by a previous scale-in, according to the
+// StalePVCPolicy of the tc. It must be called before deleteDeferDeletingPVC,
+// which deletes the PVCs still marked. It returns nil if the scale-out can go
+// on. The PVCs wiped are unmarked to be used by the new member, and the
+// scale-out is requeued until the PVC lister observes them unmarked, so that
+// deleteDeferDeletingPVC does not delete them.
+func (s *generalScaler) checkStalePVC(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, ordinal int32) error {
+	policy := tc.StalePVCPolicy()
+	if policy == v1alpha1.StalePVCPolicyReuse {
+		return nil
+	}
+
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	podName := ordinalPodName(memberType, tcName, ordinal)
+	pvcs, err := s.stalePVCs(tc, memberType, ordinal)
+	if err != nil {
+		return err
+	}
+	if len(pvcs) == 0 {
+		syncStalePVCCondition(tc, nil)
+		return nil
+	}
+
+	if policy == v1alpha1.StalePVCPolicyBlock {
+		var names []string
+		for _, pvc := range pvcs {
+			names = append(names, pvc.Name)
+		}
+		msg := fmt.Sprintf("%s pod %s can't be scaled out as PVCs %s are left by a previous scale-in, delete them to scale out", memberType, podName, strings.Join(names, ", "))
+		syncStalePVCCondition(tc, &msg)
+		s.deps.Recorder.Event(tc, corev1.EventTypeWarning, stalePVCFoundReason, msg)
+		recordScaleBlocked(tc, memberType, stalePVCFoundReason)
+		return controller.RequeueErrorf("tc[%s/%s]'s %s", ns, tcName, msg)
+	}
+
+	for _, pvc := range pvcs {
+		if pvc.DeletionTimestamp != nil {
+			// the new member must not start on the volume before it is released
+			return controller.RequeueErrorf("tc[%s/%s]'s %s pvc %s left by a previous scale-in is being deleted, can't scale out now", ns, tcName, memberType, pvc.Name)
+		}
+		// the PVC may be wiped and unmarked in a previous round that the
+		// lister has not observed yet
+		latest, err := s.deps.KubeClientset.CoreV1().PersistentVolumeClaims(ns).Get(context.TODO(), pvc.Name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("checkStalePVC: failed to get pvc %s/%s for tc %s/%s, error: %v", ns, pvc.Name, ns, tcName, err)
+		}
+		if _, ok := latest.Annotations[label.AnnPVCDeferDeleting]; !ok {
+			return controller.RequeueErrorf("tc[%s/%s]'s %s pvc %s is wiped, wait for the lister to observe it", ns, tcName, memberType, pvc.Name)
+		}
+		if err := s.wipeStalePVC(tc, memberType, latest); err != nil {
+			return err
+		}
+		// the PVC is kept for the new member instead of being deleted
+		latest = latest.DeepCopy()
+		delete(latest.Annotations, label.AnnPVCDeferDeleting)
+		if _, err := s.deps.PVCControl.UpdatePVC(tc, latest); err != nil {
+			return fmt.Errorf("checkStalePVC: failed to unmark the wiped pvc %s/%s for tc %s/%s, error: %v", ns, pvc.Name, ns, tcName, err)
+		}
+	}
+	syncStalePVCCondition(tc, nil)
+	return controller.RequeueErrorf("tc[%s/%s]'s %s pvcs of pod %s left by a previous scale-in are wiped, scale out in the next round", ns, tcName, memberType, podName)
+}
+
+// stalePVCs returns the PVCs of the member of the ordinal that are marked as
+// defer deleting, including the ones being deleted.
+func (s *generalScaler) stalePVCs(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, ordinal int32) ([]*corev1.PersistentVolumeClaim, error) {
+	ns := tc.GetNamespace()
+	selector, err := GetPVCSelectorForPod(tc, memberType, ordinal)
+	if err != nil {
+		return nil, fmt.Errorf("stalePVCs: tc %s/%s assemble label selector failed, err: %v", ns, tc.GetName(), err)
+	}
+	pvcs, err := s.deps.PVCLister.PersistentVolumeClaims(ns).List(selector)
+	if err != nil {
+		return nil, fmt.Errorf("stalePVCs: tc %s/%s list pvc failed, selector: %s, err: %v", ns, tc.GetName(), selector, err)
+	}
+
+	var stale []*corev1.PersistentVolumeClaim
+	for _, pvc := range pvcs {
+		if _, ok := pvc.Annotations[label.AnnPVCDeferDeleting]; ok {
+			stale = append(stale, pvc)
+		}
+	}
+	return stale, nil
+}
+
+// wipeStalePVC returns nil if the cleanup Job of the PVC completes and deletes
+// it, otherwise it creates the Job if it does not exist and requeues. A failed
+// Job is deleted and retried.
+func (s *generalScaler) wipeStalePVC(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, pvc *corev1.PersistentVolumeClaim) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	jobName := fmt.Sprintf("%s-wipe", pvc.Name)
+
+	job, err := s.deps.JobLister.Jobs(ns).Get(jobName)
+	if errors.IsNotFound(err) {
+		job = newStalePVCCleanupJob(tc, pvc)
+		job.Name = jobName
+		if err := s.deps.Controls.JobControl.CreateJob(tc, job); err != nil {
+			return err
+		}
+		return controller.RequeueErrorf("tc[%s/%s]'s cleanup job %s of %s pvc %s is created, can't scale out now", ns, tcName, jobName, memberType, pvc.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("wipeStalePVC: failed to get job %s/%s for tc %s/%s, error: %s", ns, jobName, ns, tcName, err)
+	}
+
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			klog.Infof("tc[%s/%s]'s %s pvc %s left by a previous scale-in is wiped", ns, tcName, memberType, pvc.Name)
+			return s.deps.Controls.JobControl.DeleteJob(tc, job)
+		case batchv1.JobFailed:
+			msg := fmt.Sprintf("cleanup job %s of %s pvc %s failed: %s", jobName, memberType, pvc.Name, c.Message)
+			s.deps.Recorder.Event(tc, corev1.EventTypeWarning, stalePVCWipeFailedReason, msg)
+			if err := s.deps.Controls.JobControl.DeleteJob(tc, job); err != nil {
+				return err
+			}
+			return controller.RequeueErrorf("tc[%s/%s]'s %s, retry it", ns, tcName, msg)
+		}
+	}
+	return controller.RequeueErrorf("tc[%s/%s]'s cleanup job %s of %s pvc %s is running, can't scale out now", ns, tcName, jobName, memberType, pvc.Name)
+}
+
+// newStalePVCCleanupJob returns the Job removing everything on the volume of
+// the PVC. Only the Job is labeled, the Pods of the Job must not be selected as
+// the Pods of the component.
+func newStalePVCCleanupJob(tc *v1alpha1.TidbCluster, pvc *corev1.PersistentVolumeClaim) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       tc.GetNamespace(),
+			Labels:          label.New().Instance(tc.GetInstanceName()).Component(stalePVCCleanupComponent).Labels(),
+			OwnerReferences: []metav1.OwnerReference{controller.GetOwnerRef(tc)},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: pointer.Int32Ptr(0),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy:    corev1.RestartPolicyNever,
+					ImagePullSecrets: tc.Spec.ImagePullSecrets,
+					Containers: []corev1.Container{
+						{
+							Name:            stalePVCCleanupComponent,
+							Image:           tc.HelperImage(),
+							ImagePullPolicy: tc.HelperImagePullPolicy(),
+							Command:         []string{"sh", "-c", fmt.Sprintf("rm -rf %[1]s/* %[1]s/.[!.]* %[1]s/..?*", stalePVCMountPath)},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "data", MountPath: stalePVCMountPath},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "data",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.Name},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// syncStalePVCCondition sets the StalePVC condition if msg is not nil,
+// otherwise it resolves the condition set before.
+func syncStalePVCCondition(tc *v1alpha1.TidbCluster, msg *string) {
+	if msg != nil {
+		utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+			v1alpha1.TidbClusterStalePVC, corev1.ConditionTrue, stalePVCFoundReason, *msg))
+		return
+	}
+	if cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterStalePVC); cond != nil && cond.Status == corev1.ConditionTrue {
+		utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+			v1alpha1.TidbClusterStalePVC, corev1.ConditionFalse, "StalePVCResolved", "no scale-out is blocked by stale PVCs"))
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+)
+
+const (
+	storageCheckContainerName = "storage-check"
+	defaultStorageCheckSizeMB = 64
+
+	// storageCheckSlowReason is the reason of the StorageSlow condition when
+	// some Pods are below the floor
+	storageCheckSlowReason = "WriteThroughputBelowFloor"
+	// storageCheckOKReason is the reason of the StorageSlow condition when
+	// all the measured Pods are above the floor
+	storageCheckOKReason = "WriteThroughputOK"
+
+	// storageCheckScript writes the data with dd and reports the summary line
+	// of dd, e.g. "67108864 bytes (64.0MB) copied, 0.5 seconds, 128.0MB/s",
+	// through the termination message of the init container. The check is
+	// best effort, a failure of dd is reported instead of blocking TiKV from
+	// starting.
+	storageCheckScript = `if dd if=/dev/zero of=%[1]s bs=1M count=%[2]d conv=fsync 2>/tmp/storage-check.log; then
+  tail -n 1 /tmp/storage-check.log > /dev/termination-log
+else
+  echo "%[3]s$(tail -n 1 /tmp/storage-check.log)" > /dev/termination-log
+fi
+rm -f %[1]s
+exit 0
+`
+	// storageCheckFailedPrefix is the prefix of the termination message of the
+	// storage check init container when dd fails
+	storageCheckFailedPrefix = "storage check failed: "
+)
+
+var ddThroughputPattern = regexp.MustCompile(`([0-9.]+) ?([kKMG]?)B/s`)
+
+// getStorageCheckContainer returns the init container that measures the write
+// throughput of the data volume
+func getStorageCheckContainer(tc *v1alpha1.TidbCluster, dataVol corev1.VolumeMount) corev1.Container {
+	sizeMB := int32(defaultStorageCheckSizeMB)
+	if tc.Spec.TiKV.StorageCheck.SizeMB != nil {
+		sizeMB = *tc.Spec.TiKV.StorageCheck.SizeMB
+	}
+	file := path.Join(dataVol.MountPath, ".storage-check")
+	return corev1.Container{
+		Name:            storageCheckContainerName,
+		Image:           tc.HelperImage(),
+		ImagePullPolicy: tc.HelperImagePullPolicy(),
+		Command: []string{
+			"sh",
+			"-c",
+			fmt.Sprintf(storageCheckScript, file, sizeMB, storageCheckFailedPrefix),
+		},
+		VolumeMounts: []corev1.VolumeMount{dataVol},
+		Resources:    controller.ContainerResource(tc.Spec.TiKV.ResourceRequirements),
+	}
+}
+
+// parseWriteThroughputMB parses the throughput in MB/s from the summary line of dd
+func parseWriteThroughputMB(msg string) (float64, error) {
+	if strings.HasPrefix(msg, storageCheckFailedPrefix) {
+		return 0, fmt.Errorf("%s", msg)
+	}
+	matches := ddThroughputPattern.FindStringSubmatch(msg)
+	if matches == nil {
+		return 0, fmt.Errorf("no throughput found in %q", msg)
+	}
+	value, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, err
+	}
+	switch matches[2] {
+	case "":
+		return value / 1000 / 1000, nil
+	case "k", "K":
+		return value / 1000, nil
+	case "G":
+		return value * 1000, nil
+	default:
+		return value, nil
+	}
+}
+
+// syncStorageCheckCondition sets the StorageSlow condition by the throughput
+// reported by the storage check init containers of the TiKV Pods
+func syncStorageCheckCondition(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) {
+	ns := tc.GetNamespace()
+
+	if tc.Spec.TiKV == nil || tc.Spec.TiKV.StorageCheck == nil {
+		utiltidbcluster.RemoveTidbClusterCondition(&tc.Status, v1alpha1.TidbClusterStorageSlow)
+		return
+	}
+
+	selector, err := label.New().Instance(tc.GetInstanceName()).TiKV().Selector()
+	if err != nil {
+		klog.Warningf("tikv: failed to build selector for %s/%s, error: %v", ns, tc.GetName(), err)
+		return
+	}
+	pods, err := deps.PodLister.Pods(ns).List(selector)
+	if err != nil {
+		klog.Warningf("tikv: failed to list pods for %s/%s, error: %v", ns, tc.GetName(), err)
+		return
+	}
+
+	floor := float64(tc.Spec.TiKV.StorageCheck.MinWriteThroughputMB)
+	var measured int
+	var slow []string
+	for _, pod := range pods {
+		for _, status := range pod.Status.InitContainerStatuses {
+			if status.Name != storageCheckContainerName || status.State.Terminated == nil {
+				continue
+			}
+			throughput, err := parseWriteThroughputMB(status.State.Terminated.Message)
+			if err != nil {
+				klog.V(4).Infof("tikv: failed to parse storage check result of pod %s/%s, error: %v", ns, pod.GetName(), err)
+				continue
+			}
+			measured++
+			if throughput < floor {
+				slow = append(slow, fmt.Sprintf("%s(%.1fMB/s)", pod.GetName(), throughput))
+			}
+		}
+	}
+	if measured == 0 {
+		return
+	}
+
+	if len(slow) > 0 {
+		sort.Strings(slow)
+		msg := fmt.Sprintf("write throughput of %s is below %dMB/s", strings.Join(slow, ", "), tc.Spec.TiKV.StorageCheck.MinWriteThroughputMB)
+		utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+			v1alpha1.TidbClusterStorageSlow, corev1.ConditionTrue, storageCheckSlowReason, msg))
+		return
+	}
+	utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+		v1alpha1.TidbClusterStorageSlow, corev1.ConditionFalse, storageCheckOKReason, "write throughput of all the measured pods is above the floor"))
+}
@@ -0,0 +1,145 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseWriteThroughputMB(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		msg    string
+		expect float64
+		err    bool
+	}{
+		{msg: "67108864 bytes (64.0MB) copied, 0.345 seconds, 185.5MB/s", expect: 185.5},
+		{msg: "67108864 bytes (67 MB, 64 MiB) copied, 0.5 s, 134 MB/s", expect: 134},
+		{msg: "67108864 bytes (67 MB, 64 MiB) copied, 0.05 s, 1.3 GB/s", expect: 1300},
+		{msg: "67108864 bytes (67 MB, 64 MiB) copied, 100 s, 671 kB/s", expect: 0.671},
+		{msg: "dd: can't open '/var/lib/tikv/.storage-check': Permission denied", err: true},
+		{msg: "storage check failed: 10485760 bytes (10 MB, 10 MiB) copied, 0.1 s, 100 MB/s", err: true},
+	}
+
+	for _, test := range tests {
+		throughput, err := parseWriteThroughputMB(test.msg)
+		if test.err {
+			g.Expect(err).To(HaveOccurred())
+			continue
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(throughput).To(BeNumerically("~", test.expect, 0.001))
+	}
+}
+
+func TestSyncStorageCheckCondition(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	newCheckedPod := func(tc *v1alpha1.TidbCluster, name string, msg string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: tc.Namespace,
+				Labels:    label.New().Instance(tc.GetInstanceName()).TiKV().Labels(),
+			},
+			Status: corev1.PodStatus{
+				InitContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name: storageCheckContainerName,
+						State: corev1.ContainerState{
+							Terminated: &corev1.ContainerStateTerminated{Message: msg},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	type testcase struct {
+		name         string
+		storageCheck *v1alpha1.StorageCheckSpec
+		messages     map[string]string
+		expectStatus corev1.ConditionStatus
+		expectReason string
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+		fakeDeps := controller.NewFakeDependencies()
+		podIndexer := fakeDeps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+
+		tc := newTidbClusterForPD()
+		tc.Spec.TiKV.StorageCheck = test.storageCheck
+		tc.Status.Conditions = []v1alpha1.TidbClusterCondition{
+			*utiltidbcluster.NewTidbClusterCondition(v1alpha1.TidbClusterStorageSlow, corev1.ConditionTrue, storageCheckSlowReason, ""),
+		}
+		for name, msg := range test.messages {
+			g.Expect(podIndexer.Add(newCheckedPod(tc, name, msg))).To(Succeed())
+		}
+
+		syncStorageCheckCondition(fakeDeps, tc)
+
+		cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterStorageSlow)
+		if test.expectStatus == "" {
+			g.Expect(cond).To(BeNil())
+			return
+		}
+		g.Expect(cond).NotTo(BeNil())
+		g.Expect(cond.Status).To(Equal(test.expectStatus))
+		g.Expect(cond.Reason).To(Equal(test.expectReason))
+	}
+
+	tests := []*testcase{
+		{
+			name:         "storage check is disabled",
+			storageCheck: nil,
+			messages: map[string]string{
+				"test-tikv-0": "67108864 bytes (64.0MB) copied, 0.345 seconds, 10.0MB/s",
+			},
+		},
+		{
+			name:         "throughput is below the floor",
+			storageCheck: &v1alpha1.StorageCheckSpec{MinWriteThroughputMB: 100},
+			messages: map[string]string{
+				"test-tikv-0": "67108864 bytes (64.0MB) copied, 0.345 seconds, 185.5MB/s",
+				"test-tikv-1": "67108864 bytes (64.0MB) copied, 6.4 seconds, 10.0MB/s",
+			},
+			expectStatus: corev1.ConditionTrue,
+			expectReason: storageCheckSlowReason,
+		},
+		{
+			name:         "throughput is above the floor",
+			storageCheck: &v1alpha1.StorageCheckSpec{MinWriteThroughputMB: 100},
+			messages: map[string]string{
+				"test-tikv-0": "67108864 bytes (64.0MB) copied, 0.345 seconds, 185.5MB/s",
+				"test-tikv-1": "67108864 bytes (67 MB, 64 MiB) copied, 0.05 s, 1.3 GB/s",
+			},
+			expectStatus: corev1.ConditionFalse,
+			expectReason: storageCheckOKReason,
+		},
+	}
+
+	for _, test := range tests {
+		testFn(test, t)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+)
+
+// recreateFailureStores recreates the failure TiKV or TiFlash stores if the
+// FailoverPVCPolicy of the component is Recreate: the failure store is
+// deleted from PD and, once it becomes tombstone, its Pod and PVCs are
+// deleted, so that the Pod joins the cluster again as a new store with an
+// empty volume. The failure stores that recover before they are deleted from
+// PD are kept.
+func recreateFailureStores(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	var policy v1alpha1.FailoverPVCPolicy
+	var failureStores map[string]v1alpha1.TiKVFailureStore
+	var stores, tombstoneStores map[string]v1alpha1.TiKVStore
+	switch memberType {
+	case v1alpha1.TiKVMemberType:
+		policy = tc.TiKVFailoverPVCPolicy()
+		failureStores, stores, tombstoneStores = tc.Status.TiKV.FailureStores, tc.Status.TiKV.Stores, tc.Status.TiKV.TombstoneStores
+	case v1alpha1.TiFlashMemberType:
+		policy = tc.TiFlashFailoverPVCPolicy()
+		failureStores, stores, tombstoneStores = tc.Status.TiFlash.FailureStores, tc.Status.TiFlash.Stores, tc.Status.TiFlash.TombstoneStores
+	default:
+		return fmt.Errorf("recreateFailureStores: unsupported member type %s", memberType)
+	}
+	if policy != v1alpha1.FailoverPVCPolicyRecreate {
+		return nil
+	}
+
+	keys := make([]string, 0, len(failureStores))
+	for key := range failureStores {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		failureStore := failureStores[key]
+		if failureStore.PVCRecreated {
+			continue
+		}
+		if _, ok := tombstoneStores[failureStore.StoreID]; ok {
+			if err := recreateStorePod(deps, tc, memberType, failureStore.PodName); err != nil {
+				return err
+			}
+			failureStore.PVCRecreated = true
+			failureStores[key] = failureStore
+			klog.Infof("%s failover: recreate pod %s/%s and its PVC of failure store %s", memberType, ns, failureStore.PodName, failureStore.StoreID)
+			deps.Recorder.Eventf(tc, corev1.EventTypeWarning, "FailureStorePVCRecreated", "pod %s/%s and its PVC of failure %s store %s recreated", ns, failureStore.PodName, memberType, failureStore.StoreID)
+			continue
+		}
+		store, ok := stores[failureStore.StoreID]
+		if !ok || !storeNeedsFailover(store.State) {
+			// the store is being deleted or has recovered
+			continue
+		}
+
+		id, err := strconv.ParseUint(failureStore.StoreID, 10, 64)
+		if err != nil {
+			return err
+		}
+		if err := reservePDDeletion(deps, tc, "store", failureStore.StoreID); err != nil {
+			return err
+		}
+		if err := controller.GetPDClient(deps.PDControl, tc).DeleteStore(id); err != nil {
+			return fmt.Errorf("%s failover: failed to delete failure store %d of pod %s/%s for tc %s/%s, error: %v", memberType, id, ns, failureStore.PodName, ns, tcName, err)
+		}
+		klog.Infof("%s failover: delete failure store %d of pod %s/%s to recreate its PVC", memberType, id, ns, failureStore.PodName)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+func TestRecreateFailureStores(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	f := newTiKVStoreMigrationFixture(g, []string{"node-0", "node-1", "node-2", "node-3"})
+	tc := f.tc
+	tikv3 := ordinalPodName(v1alpha1.TiKVMemberType, tc.GetName(), 3)
+	store := tc.Status.TiKV.Stores["4"]
+	store.State = v1alpha1.TiKVStateDown
+	tc.Status.TiKV.Stores["4"] = store
+	tc.Status.TiKV.FailureStores = map[string]v1alpha1.TiKVFailureStore{
+		"4": {PodName: tikv3, StoreID: "4"},
+	}
+	recreate := func() {
+		g.Expect(recreateFailureStores(f.deps, tc, v1alpha1.TiKVMemberType)).To(Succeed())
+	}
+
+	// the failure store is kept with the Reuse policy by default
+	recreate()
+	g.Expect(f.deletedStore).To(BeEmpty())
+
+	policy := v1alpha1.FailoverPVCPolicyRecreate
+	tc.Spec.TiKV.FailoverPVCPolicy = &policy
+
+	// the failure store is deleted from PD first
+	recreate()
+	g.Expect(f.deletedStore).To(Equal([]uint64{4}))
+	g.Expect(f.exists(f.podIndexer, tikv3)).To(BeTrue())
+
+	// the store is not deleted again while it is offline
+	store.State = v1alpha1.TiKVStateOffline
+	tc.Status.TiKV.Stores["4"] = store
+	recreate()
+	g.Expect(f.deletedStore).To(Equal([]uint64{4}))
+
+	// the pod and its PVC are deleted once the store becomes tombstone
+	delete(tc.Status.TiKV.Stores, "4")
+	store.State = v1alpha1.TiKVStateTombstone
+	tc.Status.TiKV.TombstoneStores = map[string]v1alpha1.TiKVStore{"4": store}
+	recreate()
+	g.Expect(tc.Status.TiKV.FailureStores["4"].PVCRecreated).To(BeTrue())
+	g.Expect(f.exists(f.podIndexer, tikv3)).To(BeFalse())
+	g.Expect(f.exists(f.pvcIndexer, "tikv-"+tikv3)).To(BeFalse())
+
+	// the recreated pod is not deleted again
+	f.addPod(g, tikv3, "node-4")
+	recreate()
+	g.Expect(f.exists(f.podIndexer, tikv3)).To(BeTrue())
+
+	// the failure store that recovers is kept
+	tc.Status.TiKV.FailureStores["1"] = v1alpha1.TiKVFailureStore{PodName: ordinalPodName(v1alpha1.TiKVMemberType, tc.GetName(), 0), StoreID: "1"}
+	recreate()
+	g.Expect(f.deletedStore).To(Equal([]uint64{4}))
+
+	// the failure TiFlash stores follow the policy of TiFlash
+	tc.Spec.TiFlash = &v1alpha1.TiFlashSpec{FailoverPVCPolicy: &policy}
+	tc.Status.TiFlash.Stores = map[string]v1alpha1.TiKVStore{"10": {ID: "10", PodName: "test-tiflash-0", State: v1alpha1.TiKVStateDown}}
+	tc.Status.TiFlash.FailureStores = map[string]v1alpha1.TiKVFailureStore{"10": {PodName: "test-tiflash-0", StoreID: "10"}}
+	g.Expect(recreateFailureStores(f.deps, tc, v1alpha1.TiFlashMemberType)).To(Succeed())
+	g.Expect(f.deletedStore).To(Equal([]uint64{4, 10}))
+}
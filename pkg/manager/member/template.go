@@ -49,7 +49,15 @@ then
 fi
 
 # Use HOSTNAME if POD_NAME is unset for backward compatibility.
-POD_NAME=${POD_NAME:-$HOSTNAME}{{ if .FormatClusterDomain }}
+POD_NAME=${POD_NAME:-$HOSTNAME}{{ if .PerOrdinalConfig }}
+
+# Use the config overridden for the ordinal of the pod if any
+config_file=/etc/tidb/tidb.toml
+if [[ -f /etc/tidb-ordinal/config-${POD_NAME##*-}.toml ]]
+then
+    config_file=/etc/tidb-ordinal/config-${POD_NAME##*-}.toml
+fi
+{{ end }}{{ if .FormatClusterDomain }}
 pd_url="{{ .Path }}"
 encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
 discovery_url="${CLUSTER_NAME}-discovery.${NAMESPACE}:10261"
@@ -67,7 +75,7 @@ ARGS="--store=tikv \
 --advertise-address=${POD_NAME}.${HEADLESS_SERVICE_NAME}.${NAMESPACE}.svc{{ .FormatClusterDomain }} \
 --host=0.0.0.0 \
 --path={{ .Path }} \{{ end }}
---config=/etc/tidb/tidb.toml
+--config={{ if .PerOrdinalConfig }}${config_file}{{ else }}/etc/tidb/tidb.toml{{ end }}
 "
 
 if [[ X${BINLOG_ENABLED:-} == Xtrue ]]
@@ -96,6 +104,8 @@ type TidbStartScriptModel struct {
 	PluginList      string
 	ClusterDomain   string
 	Path            string
+	// PerOrdinalConfig indicates whether the config of some ordinals is overridden
+	PerOrdinalConfig bool
 }
 
 func (t *TidbStartScriptModel) FormatClusterDomain() string {
@@ -179,6 +189,23 @@ ARGS="--data-dir={{ .DataDir }} \
 --config=/etc/pd/pd.toml \
 "
 
+if [[ ! -f {{ .DataDir }}/join ]] && [[ ! -d {{ .DataDir }}/member/wal ]]
+then
+until result=$(wget -qO- -T 3 http://${discovery_url}/new/${encoded_domain_url} 2>/dev/null); do
+echo "waiting for discovery service to return start args ..."
+sleep $((RANDOM % 5))
+done
+if [[ ${result} == --learner-initial-cluster=* ]]
+then
+# The member is added as a learner by the discovery service, it starts with
+# the initial cluster in the join file like a restarted member
+mkdir -p {{ .DataDir }}
+echo ${result#--learner-initial-cluster=} > {{ .DataDir }}/join
+else
+ARGS="${ARGS}${result}"
+fi
+fi
+
 if [[ -f {{ .DataDir }}/join ]]
 then
 # The content of the join file is:
@@ -188,13 +215,6 @@ then
 join=` + "`" + `cat {{ .DataDir }}/join | tr "," "\n" | awk -F'=' '{print $2}' | tr "\n" ","` + "`" + `
 join=${join%,}
 ARGS="${ARGS} --join=${join}"
-elif [[ ! -d {{ .DataDir }}/member/wal ]]
-then
-until result=$(wget -qO- -T 3 http://${discovery_url}/new/${encoded_domain_url} 2>/dev/null); do
-echo "waiting for discovery service to return start args ..."
-sleep $((RANDOM % 5))
-done
-ARGS="${ARGS}${result}"
 fi
 
 echo "starting pd-server ..."
@@ -249,7 +269,15 @@ then
 fi
 
 # Use HOSTNAME if POD_NAME is unset for backward compatibility.
-POD_NAME=${POD_NAME:-$HOSTNAME}{{ if .FormatClusterDomain }}
+POD_NAME=${POD_NAME:-$HOSTNAME}{{ if .PerOrdinalConfig }}
+
+# Use the config overridden for the ordinal of the pod if any
+config_file=/etc/tikv/tikv.toml
+if [[ -f /etc/tikv-ordinal/config-${POD_NAME##*-}.toml ]]
+then
+    config_file=/etc/tikv-ordinal/config-${POD_NAME##*-}.toml
+fi
+{{ end }}{{ if .FormatClusterDomain }}
 pd_url="{{ .PDAddress }}"
 encoded_domain_url=$(echo $pd_url | base64 | tr "\n" " " | sed "s/ //g")
 discovery_url="${CLUSTER_NAME}-discovery.${NAMESPACE}:10261"
@@ -262,13 +290,13 @@ done
 ARGS="--pd=${result} \
 {{ else }}
 ARGS="--pd={{ .PDAddress }} \{{ end }}
---advertise-addr=${POD_NAME}.${HEADLESS_SERVICE_NAME}.${NAMESPACE}.svc{{ .FormatClusterDomain }}:20160 \
---addr=0.0.0.0:20160 \
---status-addr=0.0.0.0:20180 \{{if .EnableAdvertiseStatusAddr }}
---advertise-status-addr={{ .AdvertiseStatusAddr }}:20180 \{{end}}
+--advertise-addr=${POD_NAME}.${HEADLESS_SERVICE_NAME}.${NAMESPACE}.svc{{ .FormatClusterDomain }}:{{ .ServerPort }} \
+--addr=0.0.0.0:{{ .ServerPort }} \
+--status-addr=0.0.0.0:{{ .StatusPort }} \{{if .EnableAdvertiseStatusAddr }}
+--advertise-status-addr={{ .AdvertiseStatusAddr }}:{{ .StatusPort }} \{{end}}
 --data-dir={{ .DataDir }} \
 --capacity=${CAPACITY} \
---config=/etc/tikv/tikv.toml
+--config={{ if .PerOrdinalConfig }}${config_file}{{ else }}/etc/tikv/tikv.toml{{ end }}
 "
 
 if [ ! -z "${STORE_LABELS:-}" ]; then
@@ -287,6 +315,10 @@ type TiKVStartScriptModel struct {
 	DataDir                   string
 	ClusterDomain             string
 	PDAddress                 string
+	ServerPort                int32
+	StatusPort                int32
+	// PerOrdinalConfig indicates whether the config of some ordinals is overridden
+	PerOrdinalConfig bool
 }
 
 func (t *TiKVStartScriptModel) FormatClusterDomain() string {
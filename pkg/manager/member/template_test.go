@@ -18,14 +18,16 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 )
 
 func TestRenderTiDBInitStartScript(t *testing.T) {
 	tests := []struct {
-		name          string
-		path          string
-		clusterDomain string
-		result        string
+		name             string
+		path             string
+		clusterDomain    string
+		perOrdinalConfig bool
+		result           string
 	}{
 		{
 			name:          "basic",
@@ -141,6 +143,69 @@ then
     ARGS="${ARGS} --log-slow-query=${SLOW_LOG_FILE:-}"
 fi
 
+echo "start tidb-server ..."
+echo "/tidb-server ${ARGS}"
+exec /tidb-server ${ARGS}
+`,
+		},
+		{
+			name:             "per ordinal config",
+			path:             "cluster01-pd:2379",
+			perOrdinalConfig: true,
+			result: `#!/bin/sh
+
+# This script is used to start tidb containers in kubernetes cluster
+
+# Use DownwardAPIVolumeFiles to store informations of the cluster:
+# https://kubernetes.io/docs/tasks/inject-data-application/downward-api-volume-expose-pod-information/#the-downward-api
+#
+#   runmode="normal/debug"
+#
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+    echo "entering debug mode."
+    tail -f /dev/null
+fi
+
+# Use HOSTNAME if POD_NAME is unset for backward compatibility.
+POD_NAME=${POD_NAME:-$HOSTNAME}
+
+# Use the config overridden for the ordinal of the pod if any
+config_file=/etc/tidb/tidb.toml
+if [[ -f /etc/tidb-ordinal/config-${POD_NAME##*-}.toml ]]
+then
+    config_file=/etc/tidb-ordinal/config-${POD_NAME##*-}.toml
+fi
+
+ARGS="--store=tikv \
+--advertise-address=${POD_NAME}.${HEADLESS_SERVICE_NAME}.${NAMESPACE}.svc \
+--host=0.0.0.0 \
+--path=cluster01-pd:2379 \
+--config=${config_file}
+"
+
+if [[ X${BINLOG_ENABLED:-} == Xtrue ]]
+then
+    ARGS="${ARGS} --enable-binlog=true"
+fi
+
+SLOW_LOG_FILE=${SLOW_LOG_FILE:-""}
+if [[ ! -z "${SLOW_LOG_FILE}" ]]
+then
+    ARGS="${ARGS} --log-slow-query=${SLOW_LOG_FILE:-}"
+fi
+
 echo "start tidb-server ..."
 echo "/tidb-server ${ARGS}"
 exec /tidb-server ${ARGS}
@@ -151,9 +216,10 @@ exec /tidb-server ${ARGS}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			model := TidbStartScriptModel{
-				EnablePlugin:  false,
-				ClusterDomain: tt.clusterDomain,
-				Path:          "cluster01-pd:2379",
+				EnablePlugin:     false,
+				ClusterDomain:    tt.clusterDomain,
+				Path:             "cluster01-pd:2379",
+				PerOrdinalConfig: tt.perOrdinalConfig,
 			}
 			script, err := RenderTiDBStartScript(&model)
 			if err != nil {
@@ -174,6 +240,7 @@ func TestRenderTiKVStartScript(t *testing.T) {
 		dataSubDir          string
 		result              string
 		clusterDomain       string
+		perOrdinalConfig    bool
 	}{
 		{
 			name:                "disable AdvertiseAddr",
@@ -398,6 +465,66 @@ if [ ! -z "${STORE_LABELS:-}" ]; then
   ARGS="${ARGS}${LABELS}"
 fi
 
+echo "starting tikv-server ..."
+echo "/tikv-server ${ARGS}"
+exec /tikv-server ${ARGS}
+`,
+		},
+		{
+			name:             "per ordinal config",
+			perOrdinalConfig: true,
+			result: `#!/bin/sh
+
+# This script is used to start tikv containers in kubernetes cluster
+
+# Use DownwardAPIVolumeFiles to store informations of the cluster:
+# https://kubernetes.io/docs/tasks/inject-data-application/downward-api-volume-expose-pod-information/#the-downward-api
+#
+#   runmode="normal/debug"
+#
+
+set -uo pipefail
+
+ANNOTATIONS="/etc/podinfo/annotations"
+
+if [[ ! -f "${ANNOTATIONS}" ]]
+then
+    echo "${ANNOTATIONS} does't exist, exiting."
+    exit 1
+fi
+source ${ANNOTATIONS} 2>/dev/null
+
+runmode=${runmode:-normal}
+if [[ X${runmode} == Xdebug ]]
+then
+	echo "entering debug mode."
+	tail -f /dev/null
+fi
+
+# Use HOSTNAME if POD_NAME is unset for backward compatibility.
+POD_NAME=${POD_NAME:-$HOSTNAME}
+
+# Use the config overridden for the ordinal of the pod if any
+config_file=/etc/tikv/tikv.toml
+if [[ -f /etc/tikv-ordinal/config-${POD_NAME##*-}.toml ]]
+then
+    config_file=/etc/tikv-ordinal/config-${POD_NAME##*-}.toml
+fi
+
+ARGS="--pd=http://${CLUSTER_NAME}-pd:2379 \
+--advertise-addr=${POD_NAME}.${HEADLESS_SERVICE_NAME}.${NAMESPACE}.svc:20160 \
+--addr=0.0.0.0:20160 \
+--status-addr=0.0.0.0:20180 \
+--data-dir=/var/lib/tikv \
+--capacity=${CAPACITY} \
+--config=${config_file}
+"
+
+if [ ! -z "${STORE_LABELS:-}" ]; then
+  LABELS=" --labels ${STORE_LABELS} "
+  ARGS="${ARGS}${LABELS}"
+fi
+
 echo "starting tikv-server ..."
 echo "/tikv-server ${ARGS}"
 exec /tikv-server ${ARGS}
@@ -413,6 +540,9 @@ exec /tikv-server ${ARGS}
 				AdvertiseStatusAddr:       tt.advertiseAddr,
 				DataDir:                   filepath.Join(tikvDataVolumeMountPath, tt.dataSubDir),
 				ClusterDomain:             tt.clusterDomain,
+				ServerPort:                v1alpha1.DefaultTiKVServerPort,
+				StatusPort:                v1alpha1.DefaultTiKVStatusPort,
+				PerOrdinalConfig:          tt.perOrdinalConfig,
 			}
 			script, err := RenderTiKVStartScript(&model)
 			if err != nil {
@@ -502,6 +632,23 @@ ARGS="--data-dir=/var/lib/pd \
 --config=/etc/pd/pd.toml \
 "
 
+if [[ ! -f /var/lib/pd/join ]] && [[ ! -d /var/lib/pd/member/wal ]]
+then
+until result=$(wget -qO- -T 3 http://${discovery_url}/new/${encoded_domain_url} 2>/dev/null); do
+echo "waiting for discovery service to return start args ..."
+sleep $((RANDOM % 5))
+done
+if [[ ${result} == --learner-initial-cluster=* ]]
+then
+# The member is added as a learner by the discovery service, it starts with
+# the initial cluster in the join file like a restarted member
+mkdir -p /var/lib/pd
+echo ${result#--learner-initial-cluster=} > /var/lib/pd/join
+else
+ARGS="${ARGS}${result}"
+fi
+fi
+
 if [[ -f /var/lib/pd/join ]]
 then
 # The content of the join file is:
@@ -511,13 +658,6 @@ then
 join=` + "`" + `cat /var/lib/pd/join | tr "," "\n" | awk -F'=' '{print $2}' | tr "\n" ","` + "`" + `
 join=${join%,}
 ARGS="${ARGS} --join=${join}"
-elif [[ ! -d /var/lib/pd/member/wal ]]
-then
-until result=$(wget -qO- -T 3 http://${discovery_url}/new/${encoded_domain_url} 2>/dev/null); do
-echo "waiting for discovery service to return start args ..."
-sleep $((RANDOM % 5))
-done
-ARGS="${ARGS}${result}"
 fi
 
 echo "starting pd-server ..."
@@ -596,6 +736,23 @@ ARGS="--data-dir=/var/lib/pd/data \
 --config=/etc/pd/pd.toml \
 "
 
+if [[ ! -f /var/lib/pd/data/join ]] && [[ ! -d /var/lib/pd/data/member/wal ]]
+then
+until result=$(wget -qO- -T 3 http://${discovery_url}/new/${encoded_domain_url} 2>/dev/null); do
+echo "waiting for discovery service to return start args ..."
+sleep $((RANDOM % 5))
+done
+if [[ ${result} == --learner-initial-cluster=* ]]
+then
+# The member is added as a learner by the discovery service, it starts with
+# the initial cluster in the join file like a restarted member
+mkdir -p /var/lib/pd/data
+echo ${result#--learner-initial-cluster=} > /var/lib/pd/data/join
+else
+ARGS="${ARGS}${result}"
+fi
+fi
+
 if [[ -f /var/lib/pd/data/join ]]
 then
 # The content of the join file is:
@@ -605,13 +762,6 @@ then
 join=` + "`" + `cat /var/lib/pd/data/join | tr "," "\n" | awk -F'=' '{print $2}' | tr "\n" ","` + "`" + `
 join=${join%,}
 ARGS="${ARGS} --join=${join}"
-elif [[ ! -d /var/lib/pd/data/member/wal ]]
-then
-until result=$(wget -qO- -T 3 http://${discovery_url}/new/${encoded_domain_url} 2>/dev/null); do
-echo "waiting for discovery service to return start args ..."
-sleep $((RANDOM % 5))
-done
-ARGS="${ARGS}${result}"
 fi
 
 echo "starting pd-server ..."
@@ -691,6 +841,23 @@ ARGS="--data-dir=/var/lib/pd/data \
 --config=/etc/pd/pd.toml \
 "
 
+if [[ ! -f /var/lib/pd/data/join ]] && [[ ! -d /var/lib/pd/data/member/wal ]]
+then
+until result=$(wget -qO- -T 3 http://${discovery_url}/new/${encoded_domain_url} 2>/dev/null); do
+echo "waiting for discovery service to return start args ..."
+sleep $((RANDOM % 5))
+done
+if [[ ${result} == --learner-initial-cluster=* ]]
+then
+# The member is added as a learner by the discovery service, it starts with
+# the initial cluster in the join file like a restarted member
+mkdir -p /var/lib/pd/data
+echo ${result#--learner-initial-cluster=} > /var/lib/pd/data/join
+else
+ARGS="${ARGS}${result}"
+fi
+fi
+
 if [[ -f /var/lib/pd/data/join ]]
 then
 # The content of the join file is:
@@ -700,13 +867,6 @@ then
 join=` + "`" + `cat /var/lib/pd/data/join | tr "," "\n" | awk -F'=' '{print $2}' | tr "\n" ","` + "`" + `
 join=${join%,}
 ARGS="${ARGS} --join=${join}"
-elif [[ ! -d /var/lib/pd/data/member/wal ]]
-then
-until result=$(wget -qO- -T 3 http://${discovery_url}/new/${encoded_domain_url} 2>/dev/null); do
-echo "waiting for discovery service to return start args ..."
-sleep $((RANDOM % 5))
-done
-ARGS="${ARGS}${result}"
 fi
 
 echo "starting pd-server ..."
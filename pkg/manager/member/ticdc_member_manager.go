@@ -165,6 +165,10 @@ func (m *ticdcMemberManager) syncStatefulSet(tc *v1alpha1.TidbCluster) error {
 	if err != nil {
 		return err
 	}
+	if err := mutatePodTemplate(m.deps, tc, v1alpha1.TiCDCMemberType, newSts); err != nil {
+		return err
+	}
+	holdComponentRestartAt(tc, v1alpha1.TiCDCMemberType, newSts, oldSts)
 
 	if stsNotExist {
 		if !tc.PDIsAvailable() {
@@ -195,6 +199,11 @@ func (m *ticdcMemberManager) syncStatefulSet(tc *v1alpha1.TidbCluster) error {
 		if err := m.ticdcUpgrader.Upgrade(tc, oldSts, newSts); err != nil {
 			return err
 		}
+	} else if tc.Status.TiCDC.Phase == v1alpha1.NormalPhase {
+		// Restart the Pods requested by the restart-at annotation when no upgrade or scaling is in progress
+		if err := syncPodRestarts(m.deps, tc, v1alpha1.TiCDCMemberType); err != nil {
+			return err
+		}
 	}
 
 	return UpdateStatefulSet(m.deps.StatefulSetControl, tc, newSts, oldSts)
@@ -220,6 +229,10 @@ func (m *ticdcMemberManager) syncTiCDCStatus(tc *v1alpha1.TidbCluster, sts *apps
 		tc.Status.TiCDC.Phase = v1alpha1.NormalPhase
 	}
 
+	if err := syncUpgradeProgress(m.deps.PodLister, tc, v1alpha1.TiCDCMemberType, sts, tc.Status.TiCDC.Phase, &tc.Status.TiCDC.Upgrade); err != nil {
+		return err
+	}
+
 	ticdcCaptures := map[string]v1alpha1.TiCDCCapture{}
 	for id := range helper.GetPodOrdinals(tc.Status.TiCDC.StatefulSet.Replicas, sts) {
 		podName := fmt.Sprintf("%s-%d", controller.TiCDCMemberName(tc.GetName()), id)
@@ -455,7 +468,7 @@ done
 			},
 		},
 		VolumeMounts: volMounts,
-		Resources:    controller.ContainerResource(tc.Spec.TiCDC.ResourceRequirements),
+		Resources:    controller.ContainerResourceWithEphemeralStorage(tc.Spec.TiCDC.ResourceRequirements, tc.Spec.TiCDC.EphemeralStorage),
 		Env:          util.AppendEnv(envs, baseTiCDCSpec.Env()),
 	}
 	if cm != nil {
@@ -500,6 +513,7 @@ done
 				}},
 		})
 	}
+	setMainContainerSecurityContext(&podSpec, baseTiCDCSpec, v1alpha1.TiCDCMemberType.String(), nil)
 
 	updateStrategy := apps.StatefulSetUpdateStrategy{}
 	if baseTiCDCSpec.StatefulSetUpdateStrategy() == apps.OnDeleteStatefulSetStrategyType {
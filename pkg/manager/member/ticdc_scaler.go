@@ -32,16 +32,38 @@ type ticdcScaler struct {
 }
 
 // NewTiCDCScaler returns a TiCDC Scaler.
-func NewTiCDCScaler(deps *controller.Dependencies) *ticdcScaler {
-	return &ticdcScaler{generalScaler: generalScaler{deps: deps}}
+func NewTiCDCScaler(deps *controller.Dependencies) Scaler {
+	return withScaleMetrics(v1alpha1.TiCDCMemberType, &ticdcScaler{generalScaler: generalScaler{deps: deps}})
 }
 
 // Scale scales in or out of the statefulset.
 func (s *ticdcScaler) Scale(meta metav1.Object, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	if s.scaleDryRun(meta, v1alpha1.TiCDCMemberType, oldSet, newSet) {
+		return nil
+	}
+	if s.deferScaleToMaintenanceWindow(meta, v1alpha1.TiCDCMemberType, oldSet, newSet) {
+		return nil
+	}
+	if deferred, err := s.deferScale(meta, v1alpha1.TiCDCMemberType, oldSet, newSet); err != nil || deferred {
+		return err
+	}
+	if err := s.checkScaleVelocity(meta, v1alpha1.TiCDCMemberType, oldSet, newSet); err != nil {
+		return err
+	}
 	scaling, _, _, _ := scaleOne(oldSet, newSet)
+	if scaling != 0 {
+		if err := checkPDSplitBrain(meta, oldSet, newSet); err != nil {
+			return err
+		}
+	}
 	if scaling > 0 {
+		if err := checkResourceQuota(s.deps, meta, oldSet, newSet); err != nil {
+			return err
+		}
+		s.recordScale(meta, v1alpha1.TiCDCMemberType, oldSet, newSet)
 		return s.ScaleOut(meta, oldSet, newSet)
 	} else if scaling < 0 {
+		s.recordScale(meta, v1alpha1.TiCDCMemberType, oldSet, newSet)
 		return s.ScaleIn(meta, oldSet, newSet)
 	}
 	return nil
@@ -15,11 +15,14 @@ package member
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/pingcap/advanced-statefulset/client/apis/apps/v1/helper"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/klog"
 )
 
@@ -55,6 +58,9 @@ func (u *ticdcUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulS
 			ns, tcName,
 			tc.Status.PD.Phase, tc.Status.TiKV.Phase, tc.Status.TiFlash.Phase,
 			tc.Status.Pump.Phase, tc.Status.TiDB.Phase)
+		if err := clearDrainBeginTime(u.deps, tc, v1alpha1.TiCDCMemberType, ""); err != nil {
+			return err
+		}
 		_, podSpec, err := GetLastAppliedConfig(oldSet)
 		if err != nil {
 			return err
@@ -69,7 +75,7 @@ func (u *ticdcUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulS
 	}
 
 	if tc.Status.TiCDC.StatefulSet.UpdateRevision == tc.Status.TiCDC.StatefulSet.CurrentRevision {
-		return nil
+		return clearDrainBeginTime(u.deps, tc, v1alpha1.TiCDCMemberType, "")
 	}
 
 	if oldSet.Spec.UpdateStrategy.Type == apps.OnDeleteStatefulSetStrategyType || oldSet.Spec.UpdateStrategy.RollingUpdate == nil {
@@ -102,9 +108,84 @@ func (u *ticdcUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulS
 			}
 			continue
 		}
+		if upgradePaused(tc, v1alpha1.TiCDCMemberType) || waitForMaintenanceWindow(tc, v1alpha1.TiCDCMemberType, "upgrade") {
+			return clearDrainBeginTime(u.deps, tc, v1alpha1.TiCDCMemberType, "")
+		}
+		if _i == len(podOrdinals)-1 {
+			if err := checkUpgradePreChecks(u.deps, tc, v1alpha1.TiCDCMemberType); err != nil {
+				return err
+			}
+		}
+		if *oldSet.Spec.UpdateStrategy.RollingUpdate.Partition > i {
+			if err := clearDrainBeginTime(u.deps, tc, v1alpha1.TiCDCMemberType, podName); err != nil {
+				return err
+			}
+			if err := drainTiCDCCapture(u.deps, tc, pod, i); err != nil {
+				return err
+			}
+		}
 		setUpgradePartition(newSet, i)
 		return nil
 	}
 
 	return nil
 }
+
+// drainTiCDCCapture returns nil once the TiCDC capture of the Pod is not the
+// owner and has no tables, or spec.ticdc.gracefulShutdownTimeout elapses since
+// the first call for the Pod, otherwise a requeue error is returned. The begin
+// time is cleared by clearDrainBeginTime if the upgrade or the restart stops
+// before the Pod is deleted.
+func drainTiCDCCapture(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, pod *corev1.Pod, ordinal int32) error {
+	timeout := tc.TiCDCGracefulShutdownTimeout()
+	if timeout <= 0 {
+		return nil
+	}
+	ns := tc.GetNamespace()
+	if _, exist := tc.Status.TiCDC.Captures[pod.Name]; !exist || len(tc.Status.TiCDC.Captures) <= 1 {
+		// there is no other capture to take over the tables
+		return nil
+	}
+
+	beginTime, draining := pod.Annotations[label.AnnDrainBeginTime]
+	if !draining {
+		pod = pod.DeepCopy()
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[label.AnnDrainBeginTime] = time.Now().Format(time.RFC3339)
+		if _, err := deps.PodControl.UpdatePod(tc, pod); err != nil {
+			return err
+		}
+		return controller.RequeueErrorf("TiCDC %s/%s begins draining the capture, can't delete it now", ns, pod.Name)
+	}
+
+	begin, err := time.Parse(time.RFC3339, beginTime)
+	if err != nil {
+		klog.Errorf("drainTiCDCCapture: failed to parse %s %q of pod %s/%s, error: %v", label.AnnDrainBeginTime, beginTime, ns, pod.Name, err)
+		return nil
+	}
+	if time.Since(begin) >= timeout {
+		msg := fmt.Sprintf("TiCDC Pod %s is not drained %v after the drain begins, delete the Pod anyway", pod.Name, timeout)
+		klog.Warningf("drainTiCDCCapture: tc[%s/%s]'s %s", ns, tc.GetName(), msg)
+		deps.Recorder.Event(tc, corev1.EventTypeWarning, "DrainTimeout", msg)
+		return nil
+	}
+
+	resigned, err := deps.CDCControl.ResignOwner(tc, ordinal)
+	if err != nil {
+		return controller.RequeueErrorf("TiCDC %s/%s failed to resign the owner, error: %v", ns, pod.Name, err)
+	}
+	if !resigned {
+		return controller.RequeueErrorf("TiCDC %s/%s is resigning the owner, can't delete it now", ns, pod.Name)
+	}
+	tableCount, err := deps.CDCControl.DrainCapture(tc, ordinal)
+	if err != nil {
+		return controller.RequeueErrorf("TiCDC %s/%s failed to drain the capture, error: %v", ns, pod.Name, err)
+	}
+	if tableCount > 0 {
+		return controller.RequeueErrorf("TiCDC %s/%s still has %d tables, can't delete it now", ns, pod.Name, tableCount)
+	}
+	klog.Infof("drainTiCDCCapture: TiCDC %s/%s is drained", ns, pod.Name)
+	return nil
+}
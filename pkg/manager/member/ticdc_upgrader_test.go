@@ -15,6 +15,7 @@ package member
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	"github.com/pingcap/tidb-operator/pkg/apis/label"
@@ -25,6 +26,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	podinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 )
 
@@ -78,6 +80,9 @@ func TestTiCDCUpgrader_Upgrade(t *testing.T) {
 	tests := []*testcase{
 		{
 			name: "normal",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiCDC.GracefulShutdownTimeout = pointer.StringPtr("0s")
+			},
 			expectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster, newSet *apps.StatefulSet) {
 				g.Expect(tc.Status.TiCDC.Phase).To(Equal(v1alpha1.UpgradePhase))
 				g.Expect(newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(pointer.Int32Ptr(0)))
@@ -271,6 +276,76 @@ func TestTiCDCUpgrader_Upgrade(t *testing.T) {
 
 }
 
+func TestTiCDCUpgraderDrainCapture(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	fakeDeps := controller.NewFakeDependencies()
+	upgrader := &ticdcUpgrader{fakeDeps}
+	cdcControl := fakeDeps.CDCControl.(*controller.FakeTiCDCControl)
+	cdcControl.SetStatus(&controller.CaptureStatus{ID: "capture-0", IsOwner: true})
+	cdcControl.SetDrainCapture(2, nil)
+	tc := newTidbClusterForTiCDCUpgrader()
+	podIndexer := fakeDeps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+	for _, pod := range getTiCDCPods() {
+		g.Expect(podIndexer.Add(pod)).To(Succeed())
+	}
+	oldSet := newStatefulSetForTiCDCUpgrader()
+	SetStatefulSetLastAppliedConfigAnnotation(oldSet)
+	upgrade := func() (*apps.StatefulSet, error) {
+		newSet := oldSet.DeepCopy()
+		err := upgrader.Upgrade(tc, oldSet, newSet)
+		return newSet, err
+	}
+
+	// the drain begins
+	newSet, err := upgrade()
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(pointer.Int32Ptr(1)))
+	pod, err := fakeDeps.PodLister.Pods(corev1.NamespaceDefault).Get("upgrader-ticdc-0")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pod.Annotations).To(HaveKey(label.AnnDrainBeginTime))
+
+	// the owner is resigned
+	_, err = upgrade()
+	g.Expect(err).To(MatchError(ContainSubstring("resigning the owner")))
+
+	// the tables are moving
+	_, err = upgrade()
+	g.Expect(err).To(MatchError(ContainSubstring("still has 2 tables")))
+
+	// the drain starts over after the upgrade is paused
+	tc.Spec.TiCDC.UpgradePaused = true
+	_, err = upgrade()
+	g.Expect(err).NotTo(HaveOccurred())
+	pod, err = fakeDeps.PodLister.Pods(corev1.NamespaceDefault).Get("upgrader-ticdc-0")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pod.Annotations).NotTo(HaveKey(label.AnnDrainBeginTime))
+	tc.Spec.TiCDC.UpgradePaused = false
+	_, err = upgrade()
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(err).To(MatchError(ContainSubstring("begins draining")))
+	_, err = upgrade()
+	g.Expect(err).To(MatchError(ContainSubstring("still has 2 tables")))
+
+	// the capture is drained
+	cdcControl.SetDrainCapture(0, nil)
+	newSet, err = upgrade()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(pointer.Int32Ptr(0)))
+
+	// the upgrade goes on after the timeout
+	cdcControl.SetDrainCapture(2, nil)
+	pod = pod.DeepCopy()
+	pod.Annotations[label.AnnDrainBeginTime] = time.Now().Add(-time.Hour).Format(time.RFC3339)
+	g.Expect(podIndexer.Update(pod)).To(Succeed())
+	newSet, err = upgrade()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(pointer.Int32Ptr(0)))
+	events := collectEvents(fakeDeps.Recorder.(*record.FakeRecorder).Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(ContainSubstring("Warning DrainTimeout"))
+}
+
 func newTiCDCUpgrader() (Upgrader, podinformers.PodInformer) {
 	fakeDeps := controller.NewFakeDependencies()
 	upgrader := &ticdcUpgrader{fakeDeps}
@@ -0,0 +1,88 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"path"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	defaultAuditLogVolume = "auditlog"
+	defaultAuditLogDir    = "/var/log/tidb-audit"
+	// defaultAuditLogSidecarName is the name of the audit log sidecar if it is not set
+	defaultAuditLogSidecarName = "auditlog"
+
+	tidbAuditEnabledVariable = "tidb_audit_enabled"
+	tidbAuditLogVariable     = "tidb_audit_log"
+)
+
+// tidbPlugins returns the plugins loaded by TiDB, including the audit plugin
+func tidbPlugins(tc *v1alpha1.TidbCluster) []string {
+	plugins := tc.Spec.TiDB.Plugins
+	auditPlugin := tc.Spec.TiDB.GetAuditLogPlugin()
+	if auditPlugin == "" {
+		return plugins
+	}
+	for _, plugin := range plugins {
+		if plugin == auditPlugin {
+			return plugins
+		}
+	}
+	return append(append([]string{}, plugins...), auditPlugin)
+}
+
+// tidbAuditLogFile returns the path of the audit log file in the Pods
+func tidbAuditLogFile(tc *v1alpha1.TidbCluster) string {
+	return path.Join(defaultAuditLogDir, tc.Spec.TiDB.GetAuditLogFile())
+}
+
+// tidbAuditLogSystemVariables returns the system variables enabling the audit
+// log, or nil if the audit log is not managed
+func tidbAuditLogSystemVariables(tc *v1alpha1.TidbCluster) map[string]string {
+	if tc.Spec.TiDB.AuditLog == nil {
+		return nil
+	}
+	return map[string]string{
+		tidbAuditEnabledVariable: "ON",
+		tidbAuditLogVariable:     tidbAuditLogFile(tc),
+	}
+}
+
+// buildTiDBAuditLog returns the audit log volume, its mount and the sidecar
+// shipping the audit log, the sidecar is nil if it is not set
+func buildTiDBAuditLog(tc *v1alpha1.TidbCluster) (corev1.Volume, corev1.VolumeMount, *corev1.Container) {
+	emptyDir := &corev1.EmptyDirVolumeSource{}
+	if es := tc.Spec.TiDB.EphemeralStorage; es != nil && es.EmptyDirSizeLimit != nil {
+		emptyDir.SizeLimit = es.EmptyDirSizeLimit
+	}
+	vol := corev1.Volume{
+		Name:         defaultAuditLogVolume,
+		VolumeSource: corev1.VolumeSource{EmptyDir: emptyDir},
+	}
+	volMount := corev1.VolumeMount{Name: defaultAuditLogVolume, MountPath: defaultAuditLogDir}
+
+	if tc.Spec.TiDB.AuditLog.Sidecar == nil {
+		return vol, volMount, nil
+	}
+	sidecar := tc.Spec.TiDB.AuditLog.Sidecar.DeepCopy()
+	if sidecar.Name == "" {
+		sidecar.Name = defaultAuditLogSidecarName
+	}
+	sidecar.VolumeMounts = append(sidecar.VolumeMounts, volMount)
+	sidecar.Env = append(sidecar.Env, corev1.EnvVar{Name: "AUDIT_LOG_FILE", Value: tidbAuditLogFile(tc)})
+	return vol, volMount, sidecar
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestTiDBAuditLog(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForTiDB()
+	tc.Spec.TiDB.Plugins = []string{"whitelist-1"}
+	tc.Spec.TiDB.Config = v1alpha1.NewTiDBConfig()
+
+	// nothing is generated if the audit log is not managed
+	g.Expect(tidbPlugins(tc)).To(Equal([]string{"whitelist-1"}))
+	g.Expect(tidbAuditLogSystemVariables(tc)).To(BeNil())
+	sts, err := getNewTiDBSetForTidbCluster(tc, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(podVolumeNames(sts.Spec.Template.Spec.Volumes)).NotTo(ContainElement(defaultAuditLogVolume))
+
+	// the audit plugin is loaded and the audit log is enabled
+	tc.Spec.TiDB.AuditLog = &v1alpha1.TiDBAuditLogSpec{}
+	g.Expect(tidbPlugins(tc)).To(Equal([]string{"whitelist-1", "audit-1"}))
+	g.Expect(tc.Spec.TiDB.Plugins).To(Equal([]string{"whitelist-1"}))
+	g.Expect(tidbAuditLogSystemVariables(tc)).To(Equal(map[string]string{
+		"tidb_audit_enabled": "ON",
+		"tidb_audit_log":     "/var/log/tidb-audit/tidb-audit.log",
+	}))
+	cm, err := getTiDBConfigMap(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cm.Data["startup-script"]).To(ContainSubstring("--plugin-load whitelist-1,audit-1"))
+
+	sts, err = getNewTiDBSetForTidbCluster(tc, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(podVolumeNames(sts.Spec.Template.Spec.Volumes)).To(ContainElement(defaultAuditLogVolume))
+	g.Expect(sts.Spec.Template.Spec.Containers).To(HaveLen(2))
+	tidb := sts.Spec.Template.Spec.Containers[1]
+	g.Expect(tidb.Name).To(Equal(v1alpha1.TiDBMemberType.String()))
+	g.Expect(tidb.VolumeMounts).To(ContainElement(corev1.VolumeMount{Name: defaultAuditLogVolume, MountPath: defaultAuditLogDir}))
+
+	// the sidecar ships the audit log
+	tc.Spec.TiDB.Plugins = []string{"audit-2"}
+	tc.Spec.TiDB.AuditLog = &v1alpha1.TiDBAuditLogSpec{
+		Plugin: "audit-2",
+		File:   "audit.log",
+		Sidecar: &corev1.Container{
+			Image: "fluent/fluent-bit:1.8",
+		},
+	}
+	g.Expect(tidbPlugins(tc)).To(Equal([]string{"audit-2"}))
+	sts, err = getNewTiDBSetForTidbCluster(tc, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(sts.Spec.Template.Spec.Containers).To(HaveLen(3))
+	sidecar := sts.Spec.Template.Spec.Containers[1]
+	g.Expect(sidecar.Name).To(Equal(defaultAuditLogSidecarName))
+	g.Expect(sidecar.Image).To(Equal("fluent/fluent-bit:1.8"))
+	g.Expect(sidecar.VolumeMounts).To(Equal([]corev1.VolumeMount{{Name: defaultAuditLogVolume, MountPath: defaultAuditLogDir}}))
+	g.Expect(sidecar.Env).To(Equal([]corev1.EnvVar{{Name: "AUDIT_LOG_FILE", Value: "/var/log/tidb-audit/audit.log"}}))
+	// the spec is not changed
+	g.Expect(tc.Spec.TiDB.AuditLog.Sidecar.Name).To(BeEmpty())
+	g.Expect(tc.Spec.TiDB.AuditLog.Sidecar.VolumeMounts).To(BeEmpty())
+}
+
+func TestSyncTiDBAuditLogSystemVariables(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	tidbControl := deps.TiDBControl.(*controller.FakeTiDBControl)
+	tc := newTidbClusterForTiDB()
+	tc.Status.TiDB.Members = map[string]v1alpha1.TiDBMember{}
+	for i := 0; i < int(tc.Spec.TiDB.Replicas); i++ {
+		name := fmt.Sprintf("test-tidb-%d", i)
+		tc.Status.TiDB.Members[name] = v1alpha1.TiDBMember{Name: name, Health: true}
+	}
+	tc.Spec.TiDB.AuditLog = &v1alpha1.TiDBAuditLogSpec{}
+	tc.Spec.TiDB.SQLSecretName = "tidb-sql"
+
+	// the audit log is enabled over SQL
+	syncTiDBSystemVariables(deps, tc)
+	g.Expect(tidbControl.SystemVariables).To(Equal(map[string]string{
+		"tidb_audit_enabled": "ON",
+		"tidb_audit_log":     "/var/log/tidb-audit/tidb-audit.log",
+	}))
+
+	// the system variables of the spec take precedence
+	tc.Spec.TiDB.SystemVariables = map[string]string{"tidb_audit_enabled": "OFF"}
+	syncTiDBSystemVariables(deps, tc)
+	g.Expect(tidbControl.SystemVariables["tidb_audit_enabled"]).To(Equal("OFF"))
+}
+
+func podVolumeNames(vols []corev1.Volume) []string {
+	var names []string
+	for _, vol := range vols {
+		names = append(names, vol.Name)
+	}
+	return names
+}
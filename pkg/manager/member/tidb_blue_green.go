@@ -0,0 +1,293 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+	"k8s.io/utils/pointer"
+)
+
+// syncTiDBBlueGreen drives the blue/green switch of the TiDB tier:
+//
+//  1. a change of the Pod template brings up the green StatefulSet with the new template
+//  2. once all green Pods are ready, the TiDB Service is switched to them and
+//     the blue StatefulSet is torn down
+//  3. the blue StatefulSet is recreated with the new template
+//  4. once all blue Pods are ready, the TiDB Service is switched back to them
+//     and the green StatefulSet is deleted
+//
+// It returns true if the switch is in progress, in which case the blue
+// StatefulSet must not be scaled or upgraded in this round.
+func (m *tidbMemberManager) syncTiDBBlueGreen(tc *v1alpha1.TidbCluster, oldSet, newSet *apps.StatefulSet) (bool, error) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	status := tc.Status.TiDB.BlueGreen
+	if status == nil {
+		if !tc.Spec.TiDB.BlueGreenSwitch || oldSet == nil || templateEqual(newSet, oldSet) {
+			return false, nil
+		}
+		klog.Infof("tidb cluster %s/%s starts a blue/green switch, bring up the green tidb statefulset", ns, tcName)
+		setTiDBBlueGreenPhase(tc, v1alpha1.TiDBBlueGreenGreenPending)
+	}
+
+	switch tc.Status.TiDB.BlueGreen.Phase {
+	case v1alpha1.TiDBBlueGreenGreenPending:
+		if oldSet != nil && templateEqual(newSet, oldSet) {
+			// the change is reverted before the switch, the blue tier is up to date
+			klog.Infof("tidb cluster %s/%s's blue tidb statefulset is up to date, abort the blue/green switch", ns, tcName)
+			tc.Status.TiDB.BlueGreen = nil
+			return false, m.deleteTiDBGreen(tc)
+		}
+		greenSet, err := m.syncTiDBGreen(tc, newSet)
+		if err != nil {
+			return true, err
+		}
+		if !tidbSetReady(greenSet) {
+			return true, controller.RequeueErrorf("tidb cluster %s/%s is waiting for the green tidb statefulset to be ready", ns, tcName)
+		}
+		klog.Infof("tidb cluster %s/%s's green tidb statefulset is ready, switch the tidb service to it", ns, tcName)
+		setTiDBBlueGreenPhase(tc, v1alpha1.TiDBBlueGreenGreenActive)
+		if err := m.syncTiDBService(tc); err != nil {
+			return true, err
+		}
+		return true, m.tearDownTiDBBlue(tc, oldSet)
+	case v1alpha1.TiDBBlueGreenGreenActive:
+		if oldSet == nil {
+			klog.Infof("tidb cluster %s/%s recreates the blue tidb statefulset with the new template", ns, tcName)
+			if err := SetStatefulSetLastAppliedConfigAnnotation(newSet); err != nil {
+				return true, err
+			}
+			return true, m.deps.StatefulSetControl.CreateStatefulSet(tc, newSet)
+		}
+		if !templateEqual(newSet, oldSet) {
+			return true, m.tearDownTiDBBlue(tc, oldSet)
+		}
+		if !tidbSetReady(oldSet) {
+			return true, controller.RequeueErrorf("tidb cluster %s/%s is waiting for the blue tidb statefulset to be ready", ns, tcName)
+		}
+		klog.Infof("tidb cluster %s/%s's blue tidb statefulset is ready, switch the tidb service back to it", ns, tcName)
+		tc.Status.TiDB.BlueGreen = nil
+		if err := m.syncTiDBService(tc); err != nil {
+			return true, err
+		}
+		return false, m.deleteTiDBGreen(tc)
+	default:
+		return true, fmt.Errorf("tidb cluster %s/%s has unknown blue/green phase %q", ns, tcName, tc.Status.TiDB.BlueGreen.Phase)
+	}
+}
+
+// syncTiDBGreen creates or updates the green StatefulSet and its headless Service
+func (m *tidbMemberManager) syncTiDBGreen(tc *v1alpha1.TidbCluster, newSet *apps.StatefulSet) (*apps.StatefulSet, error) {
+	ns := tc.GetNamespace()
+
+	newSvc := getNewTiDBGreenHeadlessService(tc)
+	if _, err := m.deps.ServiceLister.Services(ns).Get(newSvc.Name); errors.IsNotFound(err) {
+		if err := controller.SetServiceLastAppliedConfigAnnotation(newSvc); err != nil {
+			return nil, err
+		}
+		if err := m.deps.ServiceControl.CreateService(tc, newSvc); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("syncTiDBGreen: failed to get svc %s for cluster %s/%s, error: %s", newSvc.Name, ns, tc.GetName(), err)
+	}
+
+	newGreenSet := getNewTiDBGreenSet(tc, newSet)
+	oldGreenSet, err := m.deps.StatefulSetLister.StatefulSets(ns).Get(newGreenSet.Name)
+	if errors.IsNotFound(err) {
+		// the PVCs left by the last switch are not reused by the new green Pods
+		if err := m.deleteDeferDeletingTiDBGreenPVCs(tc); err != nil {
+			return nil, err
+		}
+		if err := SetStatefulSetLastAppliedConfigAnnotation(newGreenSet); err != nil {
+			return nil, err
+		}
+		return newGreenSet, m.deps.StatefulSetControl.CreateStatefulSet(tc, newGreenSet)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("syncTiDBGreen: failed to get sts %s for cluster %s/%s, error: %s", newGreenSet.Name, ns, tc.GetName(), err)
+	}
+	if templateEqual(newGreenSet, oldGreenSet) && *newGreenSet.Spec.Replicas == *oldGreenSet.Spec.Replicas {
+		return oldGreenSet, nil
+	}
+	// the green Pods do not serve yet, so they are updated all at once
+	return oldGreenSet, UpdateStatefulSet(m.deps.StatefulSetControl, tc, newGreenSet, oldGreenSet.DeepCopy())
+}
+
+// tearDownTiDBBlue deletes the blue StatefulSet whose Pods no longer serve
+func (m *tidbMemberManager) tearDownTiDBBlue(tc *v1alpha1.TidbCluster, blueSet *apps.StatefulSet) error {
+	if blueSet == nil {
+		return nil
+	}
+	if blueSet.DeletionTimestamp == nil {
+		klog.Infof("tidb cluster %s/%s tears down the blue tidb statefulset", tc.GetNamespace(), tc.GetName())
+		if err := m.deps.StatefulSetControl.DeleteStatefulSet(tc, blueSet); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return controller.RequeueErrorf("tidb cluster %s/%s is waiting for the blue tidb statefulset to be torn down", tc.GetNamespace(), tc.GetName())
+}
+
+// deleteTiDBGreen deletes the green StatefulSet and its headless Service
+func (m *tidbMemberManager) deleteTiDBGreen(tc *v1alpha1.TidbCluster) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	greenSet, err := m.deps.StatefulSetLister.StatefulSets(ns).Get(controller.TiDBGreenMemberName(tcName))
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		klog.Infof("tidb cluster %s/%s deletes the green tidb statefulset", ns, tcName)
+		if err := m.deps.StatefulSetControl.DeleteStatefulSet(tc, greenSet); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	if err := m.markTiDBGreenPVCsDeferDeleting(tc); err != nil {
+		return err
+	}
+
+	greenSvc, err := m.deps.ServiceLister.Services(ns).Get(controller.TiDBGreenPeerMemberName(tcName))
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if err := m.deps.ServiceControl.DeleteService(tc, greenSvc); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// markTiDBGreenPVCsDeferDeleting marks the PVCs of the deleted green
+// StatefulSet as defer deleting, so that the PVC cleaner reclaims them
+func (m *tidbMemberManager) markTiDBGreenPVCsDeferDeleting(tc *v1alpha1.TidbCluster) error {
+	pvcs, err := m.listTiDBGreenPVCs(tc)
+	if err != nil {
+		return err
+	}
+	for _, pvc := range pvcs {
+		if pvc.Annotations[label.AnnPVCDeferDeleting] != "" {
+			continue
+		}
+		if err := addDeferDeletingAnnoToPVC(tc, pvc.DeepCopy(), m.deps.PVCControl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteDeferDeletingTiDBGreenPVCs deletes the PVCs of the green StatefulSet
+// marked as defer deleting that are not reclaimed yet
+func (m *tidbMemberManager) deleteDeferDeletingTiDBGreenPVCs(tc *v1alpha1.TidbCluster) error {
+	pvcs, err := m.listTiDBGreenPVCs(tc)
+	if err != nil {
+		return err
+	}
+	for _, pvc := range pvcs {
+		if pvc.Annotations[label.AnnPVCDeferDeleting] == "" {
+			continue
+		}
+		klog.Infof("tidb cluster %s/%s deletes the defer deleting pvc %s of the green tidb statefulset", tc.GetNamespace(), tc.GetName(), pvc.GetName())
+		if err := m.deps.PVCControl.DeletePVC(tc, pvc); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *tidbMemberManager) listTiDBGreenPVCs(tc *v1alpha1.TidbCluster) ([]*corev1.PersistentVolumeClaim, error) {
+	ns := tc.GetNamespace()
+	selector, err := label.New().Instance(tc.GetInstanceName()).TiDBGreen().Selector()
+	if err != nil {
+		return nil, err
+	}
+	pvcs, err := m.deps.PVCLister.PersistentVolumeClaims(ns).List(selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the pvcs of the green tidb statefulset of cluster %s/%s, selector: %s, error: %v", ns, tc.GetName(), selector, err)
+	}
+	return pvcs, nil
+}
+
+// getNewTiDBGreenSet returns the green StatefulSet, which is the desired blue
+// StatefulSet with the name, headless Service and component label of the green tier
+func getNewTiDBGreenSet(tc *v1alpha1.TidbCluster, newSet *apps.StatefulSet) *apps.StatefulSet {
+	tcName := tc.GetName()
+	set := newSet.DeepCopy()
+	set.Name = controller.TiDBGreenMemberName(tcName)
+	set.Spec.ServiceName = controller.TiDBGreenPeerMemberName(tcName)
+	set.Labels[label.ComponentLabelKey] = label.TiDBGreenLabelVal
+	set.Spec.Selector.MatchLabels[label.ComponentLabelKey] = label.TiDBGreenLabelVal
+	set.Spec.Template.Labels[label.ComponentLabelKey] = label.TiDBGreenLabelVal
+	delete(set.Annotations, LastAppliedConfigAnnotation)
+	delete(set.Annotations, label.AnnTiDBDeleteSlots)
+	set.Spec.UpdateStrategy = apps.StatefulSetUpdateStrategy{
+		Type: apps.RollingUpdateStatefulSetStrategyType,
+		RollingUpdate: &apps.RollingUpdateStatefulSetStrategy{
+			Partition: pointer.Int32Ptr(0),
+		},
+	}
+	for i := range set.Spec.Template.Spec.Containers {
+		container := &set.Spec.Template.Spec.Containers[i]
+		for j := range container.Env {
+			if container.Env[j].Name == "HEADLESS_SERVICE_NAME" {
+				container.Env[j].Value = set.Spec.ServiceName
+			}
+		}
+	}
+	return set
+}
+
+// getNewTiDBGreenHeadlessService returns the headless Service of the green StatefulSet
+func getNewTiDBGreenHeadlessService(tc *v1alpha1.TidbCluster) *corev1.Service {
+	svc := getNewTiDBHeadlessServiceForTidbCluster(tc)
+	svc.Name = controller.TiDBGreenPeerMemberName(tc.GetName())
+	svc.Labels[label.ComponentLabelKey] = label.TiDBGreenLabelVal
+	svc.Spec.Selector[label.ComponentLabelKey] = label.TiDBGreenLabelVal
+	return svc
+}
+
+// tidbServiceSelector returns the selector of the TiDB Service, which selects
+// the green Pods while they serve in a blue/green switch
+func tidbServiceSelector(tc *v1alpha1.TidbCluster) label.Label {
+	if status := tc.Status.TiDB.BlueGreen; status != nil && status.Phase == v1alpha1.TiDBBlueGreenGreenActive {
+		return label.New().Instance(tc.GetInstanceName()).TiDBGreen()
+	}
+	return label.New().Instance(tc.GetInstanceName()).TiDB()
+}
+
+func setTiDBBlueGreenPhase(tc *v1alpha1.TidbCluster, phase v1alpha1.TiDBBlueGreenPhase) {
+	tc.Status.TiDB.BlueGreen = &v1alpha1.TiDBBlueGreenStatus{
+		Phase:              phase,
+		LastTransitionTime: metav1.Now(),
+	}
+}
+
+// tidbSetReady returns whether all Pods of the StatefulSet are updated and ready
+func tidbSetReady(set *apps.StatefulSet) bool {
+	return set.Status.ObservedGeneration >= set.Generation &&
+		set.Status.CurrentRevision == set.Status.UpdateRevision &&
+		set.Status.ReadyReplicas == *set.Spec.Replicas
+}
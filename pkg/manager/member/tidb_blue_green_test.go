@@ -0,0 +1,237 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// deletingStatefulSetControl deletes the StatefulSets from the indexer of the fake
+type deletingStatefulSetControl struct {
+	*controller.FakeStatefulSetControl
+}
+
+func (c deletingStatefulSetControl) DeleteStatefulSet(_ runtime.Object, set *apps.StatefulSet) error {
+	return c.SetIndexer.Delete(set)
+}
+
+// deletingServiceControl deletes the Services from the indexer of the fake
+type deletingServiceControl struct {
+	*controller.FakeServiceControl
+}
+
+func (c deletingServiceControl) DeleteService(_ runtime.Object, svc *corev1.Service) error {
+	return c.SvcIndexer.Delete(svc)
+}
+
+// deleteFromIndexers makes the fake StatefulSet and Service controls of deps
+// delete the objects from their indexers, so that the deletions are observed
+// through the listers
+func deleteFromIndexers(deps *controller.Dependencies) {
+	deps.StatefulSetControl = deletingStatefulSetControl{deps.StatefulSetControl.(*controller.FakeStatefulSetControl)}
+	deps.ServiceControl = deletingServiceControl{deps.ServiceControl.(*controller.FakeServiceControl)}
+}
+
+func TestTiDBBlueGreenSwitch(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForTiDB()
+	tc.Spec.TiDB.BlueGreenSwitch = true
+	tc.Spec.TiDB.Service = &v1alpha1.TiDBServiceSpec{}
+	tmm, _, _, indexers := newFakeTiDBMemberManager()
+	deleteFromIndexers(tmm.deps)
+	ns := tc.GetNamespace()
+
+	getSet := func(name string) *apps.StatefulSet {
+		set, err := tmm.deps.StatefulSetLister.StatefulSets(ns).Get(name)
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		return set
+	}
+	setReady := func(set *apps.StatefulSet) {
+		set.Status.ReadyReplicas = *set.Spec.Replicas
+		g.Expect(indexers.set.Update(set)).To(Succeed())
+	}
+	serviceComponent := func() string {
+		svc, err := tmm.deps.ServiceLister.Services(ns).Get(controller.TiDBMemberName(tc.GetName()))
+		g.Expect(err).NotTo(HaveOccurred())
+		return svc.Spec.Selector[label.ComponentLabelKey]
+	}
+	sync := func() (bool, error) {
+		newSet, err := getNewTiDBSetForTidbCluster(tc, nil)
+		g.Expect(err).NotTo(HaveOccurred())
+		return tmm.syncTiDBBlueGreen(tc, getSet(controller.TiDBMemberName(tc.GetName())), newSet)
+	}
+
+	blueSet, err := getNewTiDBSetForTidbCluster(tc, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(SetStatefulSetLastAppliedConfigAnnotation(blueSet)).To(Succeed())
+	g.Expect(indexers.set.Add(blueSet)).To(Succeed())
+	g.Expect(tmm.syncTiDBService(tc)).To(Succeed())
+
+	// nothing to do if the template is not changed
+	inProgress, err := sync()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(inProgress).To(BeFalse())
+	g.Expect(tc.Status.TiDB.BlueGreen).To(BeNil())
+
+	// the change of the template brings up the green statefulset
+	tc.Spec.TiDB.Image = "tidb:v2"
+	inProgress, err = sync()
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(inProgress).To(BeTrue())
+	g.Expect(tc.Status.TiDB.BlueGreen.Phase).To(Equal(v1alpha1.TiDBBlueGreenGreenPending))
+	greenSet := getSet(controller.TiDBGreenMemberName(tc.GetName()))
+	g.Expect(greenSet).NotTo(BeNil())
+	g.Expect(greenSet.Spec.Template.Labels[label.ComponentLabelKey]).To(Equal(label.TiDBGreenLabelVal))
+	g.Expect(tidbContainerImage(greenSet)).To(Equal("tidb:v2"))
+	g.Expect(greenSet.Spec.ServiceName).To(Equal(controller.TiDBGreenPeerMemberName(tc.GetName())))
+	_, err = tmm.deps.ServiceLister.Services(ns).Get(controller.TiDBGreenPeerMemberName(tc.GetName()))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(serviceComponent()).To(Equal(label.TiDBLabelVal))
+	pvcIndexer := tmm.deps.KubeInformerFactory.Core().V1().PersistentVolumeClaims().Informer().GetIndexer()
+	greenPVC := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "tidb-log-" + controller.TiDBGreenMemberName(tc.GetName()) + "-0",
+			Namespace: ns,
+			Labels:    label.New().Instance(tc.GetInstanceName()).TiDBGreen().Labels(),
+		},
+	}
+	g.Expect(pvcIndexer.Add(greenPVC)).To(Succeed())
+
+	// the service is switched to the green statefulset once it is ready and the blue one is torn down
+	setReady(greenSet)
+	inProgress, err = sync()
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(inProgress).To(BeTrue())
+	g.Expect(tc.Status.TiDB.BlueGreen.Phase).To(Equal(v1alpha1.TiDBBlueGreenGreenActive))
+	g.Expect(serviceComponent()).To(Equal(label.TiDBGreenLabelVal))
+	g.Expect(getSet(controller.TiDBMemberName(tc.GetName()))).To(BeNil())
+
+	// the blue statefulset is recreated with the new template
+	inProgress, err = sync()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(inProgress).To(BeTrue())
+	blueSet = getSet(controller.TiDBMemberName(tc.GetName()))
+	g.Expect(blueSet).NotTo(BeNil())
+	g.Expect(tidbContainerImage(blueSet)).To(Equal("tidb:v2"))
+
+	inProgress, err = sync()
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(inProgress).To(BeTrue())
+	g.Expect(serviceComponent()).To(Equal(label.TiDBGreenLabelVal))
+
+	// the service is switched back once the blue statefulset is ready and the green one is deleted
+	setReady(blueSet)
+	inProgress, err = sync()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(inProgress).To(BeFalse())
+	g.Expect(tc.Status.TiDB.BlueGreen).To(BeNil())
+	g.Expect(serviceComponent()).To(Equal(label.TiDBLabelVal))
+	g.Expect(getSet(controller.TiDBGreenMemberName(tc.GetName()))).To(BeNil())
+	_, err = tmm.deps.ServiceLister.Services(ns).Get(controller.TiDBGreenPeerMemberName(tc.GetName()))
+	g.Expect(errors.IsNotFound(err)).To(BeTrue())
+	// the pvcs of the green statefulset are left to the pvc cleaner
+	pvc, err := tmm.deps.PVCLister.PersistentVolumeClaims(ns).Get(greenPVC.Name)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pvc.Annotations).To(HaveKey(label.AnnPVCDeferDeleting))
+
+	// the pvcs not reclaimed yet are not reused by the next switch
+	tc.Spec.TiDB.Image = "tidb:v3"
+	_, err = sync()
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(getSet(controller.TiDBGreenMemberName(tc.GetName()))).NotTo(BeNil())
+	_, err = tmm.deps.PVCLister.PersistentVolumeClaims(ns).Get(greenPVC.Name)
+	g.Expect(errors.IsNotFound(err)).To(BeTrue())
+}
+
+func TestTiDBBlueGreenSwitchReverted(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForTiDB()
+	tc.Spec.TiDB.BlueGreenSwitch = true
+	tmm, _, _, indexers := newFakeTiDBMemberManager()
+	deleteFromIndexers(tmm.deps)
+
+	blueSet, err := getNewTiDBSetForTidbCluster(tc, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(SetStatefulSetLastAppliedConfigAnnotation(blueSet)).To(Succeed())
+	g.Expect(indexers.set.Add(blueSet)).To(Succeed())
+
+	tc.Spec.TiDB.Image = "tidb:v2"
+	newSet, err := getNewTiDBSetForTidbCluster(tc, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = tmm.syncTiDBBlueGreen(tc, blueSet, newSet)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	_, err = tmm.deps.StatefulSetLister.StatefulSets(tc.GetNamespace()).Get(controller.TiDBGreenMemberName(tc.GetName()))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// reverting the change before the switch deletes the green statefulset
+	tc.Spec.TiDB.Image = v1alpha1.TiDBMemberType.String()
+	newSet, err = getNewTiDBSetForTidbCluster(tc, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	inProgress, err := tmm.syncTiDBBlueGreen(tc, blueSet, newSet)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(inProgress).To(BeFalse())
+	g.Expect(tc.Status.TiDB.BlueGreen).To(BeNil())
+	_, err = tmm.deps.StatefulSetLister.StatefulSets(tc.GetNamespace()).Get(controller.TiDBGreenMemberName(tc.GetName()))
+	g.Expect(errors.IsNotFound(err)).To(BeTrue())
+}
+
+func TestGetNewTiDBGreenSet(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForTiDB()
+	blueSet, err := getNewTiDBSetForTidbCluster(tc, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	greenSet := getNewTiDBGreenSet(tc, blueSet)
+	g.Expect(greenSet.Name).To(Equal(controller.TiDBGreenMemberName(tc.GetName())))
+	g.Expect(greenSet.Spec.Selector.MatchLabels[label.ComponentLabelKey]).To(Equal(label.TiDBGreenLabelVal))
+	g.Expect(*greenSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(0)))
+	for _, c := range greenSet.Spec.Template.Spec.Containers {
+		for _, env := range c.Env {
+			if env.Name == "HEADLESS_SERVICE_NAME" {
+				g.Expect(env.Value).To(Equal(controller.TiDBGreenPeerMemberName(tc.GetName())))
+			}
+		}
+	}
+	// the blue statefulset is not changed
+	g.Expect(blueSet.Spec.Selector.MatchLabels[label.ComponentLabelKey]).To(Equal(label.TiDBLabelVal))
+	g.Expect(blueSet.Spec.Template.Labels[label.ComponentLabelKey]).To(Equal(label.TiDBLabelVal))
+
+	svc := getNewTiDBGreenHeadlessService(tc)
+	g.Expect(svc.Spec.Selector).To(Equal(greenSet.Spec.Template.Labels))
+}
+
+func tidbContainerImage(set *apps.StatefulSet) string {
+	for _, c := range set.Spec.Template.Spec.Containers {
+		if c.Name == v1alpha1.TiDBMemberType.String() {
+			return c.Image
+		}
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pingcap/advanced-statefulset/client/apis/apps/v1/helper"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
+)
+
+const (
+	// tidbCanaryFailedReason is the reason of the Events of the failed canary verifications
+	tidbCanaryFailedReason = "TiDBCanaryFailed"
+	// tidbCanaryVerifiedReason is the reason of the Events of the passed canary verifications
+	tidbCanaryVerifiedReason = "TiDBCanaryVerified"
+	// tidbCanaryStaleReason is the reason of the TiDBCanaryFailed condition
+	// resolved as the failed canary Pod is replaced or the upgrade is reverted
+	tidbCanaryStaleReason = "TiDBCanaryStale"
+)
+
+// tidbCanaryPending returns whether the TiDB upgrade has a canary that is not
+// verified yet, i.e. at most one Pod is covered by the partition. The partition
+// is only lowered to the second Pod after the canary is verified.
+func tidbCanaryPending(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSet) bool {
+	if tc.TiDBCanaryPolicy() == nil {
+		return false
+	}
+	partition := *oldSet.Spec.UpdateStrategy.RollingUpdate.Partition
+	covered := 0
+	for _, i := range helper.GetPodOrdinals(*oldSet.Spec.Replicas, oldSet).List() {
+		if i >= partition {
+			covered++
+		}
+	}
+	return covered <= 1
+}
+
+// verifyTiDBCanary returns a requeue error until the upgraded canary Pod has
+// been ready for the pause duration and passes the SQL and HTTP probes of the
+// canary policy, so that the rest of the Pods are not upgraded before the new
+// revision is verified on the canary.
+func verifyTiDBCanary(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, pod *corev1.Pod, ordinal int32) error {
+	canary := tc.TiDBCanaryPolicy()
+	if canary == nil || pod == nil {
+		return nil
+	}
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	// the failed canary is recreated, e.g. as the spec is changed
+	if cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterTiDBCanaryFailed); cond != nil &&
+		cond.Status == corev1.ConditionTrue && pod.CreationTimestamp.After(cond.LastTransitionTime.Time) {
+		resolveTiDBCanaryFailedCondition(tc, tidbCanaryStaleReason, fmt.Sprintf("the canary pod %s is recreated", pod.GetName()))
+	}
+
+	pause := tc.TiDBCanaryPauseDuration()
+	condition := podutil.GetPodReadyCondition(pod.Status)
+	if condition == nil || condition.Status != corev1.ConditionTrue {
+		return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tidb canary pod %s is not ready", ns, tcName, pod.GetName())
+	}
+	if ready := time.Since(condition.LastTransitionTime.Time); ready < pause {
+		return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tidb canary pod %s has been ready for %s, wait for %s before verifying it", ns, tcName, pod.GetName(), ready.Round(time.Second), pause)
+	}
+
+	if canary.SQLProbe != "" {
+		if err := deps.TiDBControl.ProbeSQL(tc, ordinal, canary.SQLProbe); err != nil {
+			msg := fmt.Sprintf("sql probe of canary pod %s failed, the upgrade is paused: %v", pod.GetName(), err)
+			syncTiDBCanaryFailedCondition(deps, tc, &msg)
+			return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tidb canary pod %s failed the sql probe, error: %v", ns, tcName, pod.GetName(), err)
+		}
+	}
+	if canary.HTTPProbePath != "" {
+		if err := deps.TiDBControl.ProbeHTTP(tc, ordinal, canary.HTTPProbePath); err != nil {
+			msg := fmt.Sprintf("http probe of canary pod %s failed, the upgrade is paused: %v", pod.GetName(), err)
+			syncTiDBCanaryFailedCondition(deps, tc, &msg)
+			return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tidb canary pod %s failed the http probe, error: %v", ns, tcName, pod.GetName(), err)
+		}
+	}
+
+	syncTiDBCanaryFailedCondition(deps, tc, nil)
+	klog.Infof("tidbcluster: [%s/%s]'s tidb canary pod %s is verified, continue upgrading", ns, tcName, pod.GetName())
+	deps.Recorder.Eventf(tc, corev1.EventTypeNormal, tidbCanaryVerifiedReason, "canary pod %s is verified, continue upgrading", pod.GetName())
+	return nil
+}
+
+// syncTiDBCanaryFailedCondition sets the TiDBCanaryFailed condition if msg is
+// not nil, otherwise it resolves the condition set before. The Warning Event
+// is only emitted when the failure changes, not on every sync while the
+// upgrade is paused.
+func syncTiDBCanaryFailedCondition(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, msg *string) {
+	if msg == nil {
+		resolveTiDBCanaryFailedCondition(tc, tidbCanaryVerifiedReason, "the canary pod passes the probes")
+		return
+	}
+	cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterTiDBCanaryFailed)
+	if cond == nil || cond.Status != corev1.ConditionTrue || cond.Message != *msg {
+		deps.Recorder.Event(tc, corev1.EventTypeWarning, tidbCanaryFailedReason, *msg)
+	}
+	utiltidbcluster.UpdateTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+		v1alpha1.TidbClusterTiDBCanaryFailed, corev1.ConditionTrue, tidbCanaryFailedReason, *msg))
+}
+
+// resolveTiDBCanaryFailedCondition sets the TiDBCanaryFailed condition False
+// for the reason if it's True
+func resolveTiDBCanaryFailedCondition(tc *v1alpha1.TidbCluster, reason, msg string) {
+	cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterTiDBCanaryFailed)
+	if cond == nil || cond.Status != corev1.ConditionTrue {
+		return
+	}
+	utiltidbcluster.UpdateTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+		v1alpha1.TidbClusterTiDBCanaryFailed, corev1.ConditionFalse, reason, msg))
+}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/util"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog"
@@ -43,29 +44,31 @@ func (f *tidbFailover) Failover(tc *v1alpha1.TidbCluster) error {
 
 	for _, tidbMember := range tc.Status.TiDB.Members {
 		_, exist := tc.Status.TiDB.FailureMembers[tidbMember.Name]
-		if exist && tidbMember.Health {
+		if unhealthy, _, _ := f.isMemberUnhealthy(tc, tidbMember); exist && !unhealthy {
 			delete(tc.Status.TiDB.FailureMembers, tidbMember.Name)
 			klog.Infof("tidb failover: delete %s from tidb failoverMembers", tidbMember.Name)
 		}
 	}
 
-	if tc.Spec.TiDB.MaxFailoverCount == nil || *tc.Spec.TiDB.MaxFailoverCount <= 0 {
+	count := tc.MaxFailoverCount(v1alpha1.TiDBMemberType)
+	if count == nil || *count <= 0 {
 		klog.Infof("tidb failover is disabled for %s/%s, skipped", tc.Namespace, tc.Name)
 		return nil
 	}
 
-	maxFailoverCount := *tc.Spec.TiDB.MaxFailoverCount
+	maxFailoverCount := *count
 	for _, tidbMember := range tc.Status.TiDB.Members {
 		_, exist := tc.Status.TiDB.FailureMembers[tidbMember.Name]
 		if exist {
 			continue
 		}
 
-		if tidbMember.Health {
+		unhealthy, since, msg := f.isMemberUnhealthy(tc, tidbMember)
+		if !unhealthy {
 			continue
 		}
 
-		deadline := tidbMember.LastTransitionTime.Add(f.deps.CLIConfig.TiDBFailoverPeriod)
+		deadline := since.Add(tc.FailoverPeriod(v1alpha1.TiDBMemberType, f.deps.CLIConfig.TiDBFailoverPeriod))
 		if time.Now().After(deadline) {
 			if len(tc.Status.TiDB.FailureMembers) >= int(maxFailoverCount) {
 				klog.Warningf("the failover count reaches the limit (%d), no more failover pods will be created", maxFailoverCount)
@@ -89,8 +92,14 @@ func (f *tidbFailover) Failover(tc *v1alpha1.TidbCluster) error {
 				PodName:   tidbMember.Name,
 				CreatedAt: metav1.Now(),
 			}
-			msg := fmt.Sprintf("tidb[%s] is unhealthy", tidbMember.Name)
 			f.deps.Recorder.Event(tc, corev1.EventTypeWarning, unHealthEventReason, fmt.Sprintf(unHealthEventMsgPattern, "tidb", tidbMember.Name, msg))
+			f.deps.FailoverNotifier.Notify(tc, controller.FailoverEvent{
+				Type:          controller.FailoverEventMemberFailed,
+				Component:     v1alpha1.TiDBMemberType.String(),
+				Member:        tidbMember.Name,
+				Message:       msg,
+				EventRecorded: true,
+			})
 			break
 		}
 	}
@@ -98,11 +107,99 @@ func (f *tidbFailover) Failover(tc *v1alpha1.TidbCluster) error {
 	return nil
 }
 
+// isMemberUnhealthy returns whether the TiDB member is unhealthy, since when
+// and why. A member healthy by the health API is unhealthy as well if
+// `.spec.tidb.failover.endpointCheck` is enabled and it is unreachable through
+// the Service.
+func (f *tidbFailover) isMemberUnhealthy(tc *v1alpha1.TidbCluster, tidbMember v1alpha1.TiDBMember) (bool, time.Time, string) {
+	if !tidbMember.Health {
+		return true, tidbMember.LastTransitionTime.Time, fmt.Sprintf("tidb[%s] is unhealthy", tidbMember.Name)
+	}
+	return isTiDBMemberUnreachable(f.deps, tc, tidbMember)
+}
+
+// isTiDBMemberUnreachable returns whether the TiDB member is unreachable
+// through the Service, since when and why, if
+// `.spec.tidb.failover.endpointCheck` is enabled. A member is unreachable if
+// its Pod is not Ready, e.g. by a readiness gate, or it is not ready in the
+// Endpoints of the Service.
+func isTiDBMemberUnreachable(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, tidbMember v1alpha1.TiDBMember) (bool, time.Time, string) {
+	if !tc.TiDBEndpointCheck() {
+		return false, time.Time{}, ""
+	}
+
+	ns := tc.GetNamespace()
+	pod, err := deps.PodLister.Pods(ns).Get(tidbMember.Name)
+	if err != nil {
+		// the member is failed over by the health API only if the Pod is gone
+		return false, time.Time{}, ""
+	}
+	_, ready := podutil.GetPodCondition(&pod.Status, corev1.PodReady)
+	if ready == nil {
+		return false, time.Time{}, ""
+	}
+	if ready.Status != corev1.ConditionTrue {
+		return true, ready.LastTransitionTime.Time, fmt.Sprintf("tidb[%s] is healthy but its pod is not ready", tidbMember.Name)
+	}
+
+	svcName := controller.TiDBMemberName(tc.GetName())
+	endpoints, err := deps.EndpointLister.Endpoints(ns).Get(svcName)
+	if err != nil {
+		return false, time.Time{}, ""
+	}
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.NotReadyAddresses {
+			if address.TargetRef != nil && address.TargetRef.Kind == "Pod" && address.TargetRef.Name == pod.GetName() {
+				return true, ready.LastTransitionTime.Time, fmt.Sprintf("tidb[%s] is healthy but not ready in the endpoints of service %s", tidbMember.Name, svcName)
+			}
+		}
+	}
+	return false, time.Time{}, ""
+}
+
+// anyTiDBMemberUnreachable returns whether a healthy TiDB member is
+// unreachable through the Service, see isTiDBMemberUnreachable.
+func anyTiDBMemberUnreachable(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) bool {
+	for _, tidbMember := range tc.Status.TiDB.Members {
+		if unreachable, _, _ := isTiDBMemberUnreachable(deps, tc, tidbMember); unreachable {
+			return true
+		}
+	}
+	return false
+}
+
 func (f *tidbFailover) Recover(tc *v1alpha1.TidbCluster) {
+	if len(tc.Status.TiDB.FailureMembers) > 0 {
+		f.deps.FailoverNotifier.Notify(tc, controller.FailoverEvent{
+			Type:      controller.FailoverEventRecovered,
+			Component: v1alpha1.TiDBMemberType.String(),
+			Message:   fmt.Sprintf("%d failure members are recovered", len(tc.Status.TiDB.FailureMembers)),
+		})
+	}
 	tc.Status.TiDB.FailureMembers = nil
 }
 
 func (f *tidbFailover) RemoveUndesiredFailures(tc *v1alpha1.TidbCluster) {
+	for key, failureMember := range tc.Status.TiDB.FailureMembers {
+		if !f.isPodDesired(tc, failureMember.PodName) {
+			// If the replicas are decreased or the pods are deleted by using
+			// advanced statefulset delete slots feature, we should remove the
+			// record of undesired pods, otherwise an extra replacement pod will
+			// be created.
+			delete(tc.Status.TiDB.FailureMembers, key)
+			klog.Infof("tidb failover: remove undesired failure member %s of %s/%s", key, tc.GetNamespace(), tc.GetName())
+		}
+	}
+}
+
+func (f *tidbFailover) isPodDesired(tc *v1alpha1.TidbCluster, podName string) bool {
+	ordinals := tc.TiDBStsDesiredOrdinals(true)
+	ordinal, err := util.GetOrdinalFromPodName(podName)
+	if err != nil {
+		klog.Errorf("unexpected pod name %q: %v", podName, err)
+		return false
+	}
+	return ordinals.Has(ordinal)
 }
 
 type fakeTiDBFailover struct {
@@ -16,6 +16,7 @@ package member
 import (
 	"context"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	"k8s.io/apimachinery/pkg/types"
@@ -328,6 +329,114 @@ func TestTiDBFailoverFailover(t *testing.T) {
 	}
 }
 
+func TestTiDBFailoverEndpointCheck(t *testing.T) {
+	tests := []struct {
+		name          string
+		endpointCheck bool
+		ready         bool
+		notReadySince time.Duration
+		notReadyInEps bool
+		failed        bool
+		expectFailed  bool
+	}{
+		{
+			name:          "the pod not ready is ignored without the endpoint check",
+			notReadySince: time.Hour,
+		},
+		{
+			name:          "the pod not ready is failed over",
+			endpointCheck: true,
+			notReadySince: time.Hour,
+			expectFailed:  true,
+		},
+		{
+			name:          "the pod not ready in the failover period is not failed over",
+			endpointCheck: true,
+			notReadySince: time.Minute,
+		},
+		{
+			name:          "the pod not ready in the endpoints is failed over",
+			endpointCheck: true,
+			ready:         true,
+			notReadySince: time.Hour,
+			notReadyInEps: true,
+			expectFailed:  true,
+		},
+		{
+			name:          "the failure member is kept while it is unreachable",
+			endpointCheck: true,
+			notReadySince: time.Minute,
+			failed:        true,
+			expectFailed:  true,
+		},
+		{
+			name:          "the failure member reachable again is removed",
+			endpointCheck: true,
+			ready:         true,
+			notReadySince: time.Hour,
+			failed:        true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			fakeDeps := controller.NewFakeDependencies()
+			fakeDeps.CLIConfig.TiDBFailoverPeriod = 5 * time.Minute
+			tc := newTidbClusterForTiDBFailover()
+			tc.Spec.TiDB.Failover = &v1alpha1.FailoverSpec{EndpointCheck: pointer.BoolPtr(test.endpointCheck)}
+			tc.Status.TiDB.Members = map[string]v1alpha1.TiDBMember{
+				"failover-tidb-0": {Name: "failover-tidb-0", Health: true},
+				"failover-tidb-1": {Name: "failover-tidb-1", Health: true},
+			}
+			if test.failed {
+				tc.Status.TiDB.FailureMembers = map[string]v1alpha1.TiDBFailureMember{
+					"failover-tidb-0": {PodName: "failover-tidb-0"},
+				}
+			}
+
+			ready := corev1.ConditionFalse
+			if test.ready {
+				ready = corev1.ConditionTrue
+			}
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "failover-tidb-0"},
+				Status: corev1.PodStatus{
+					Conditions: []corev1.PodCondition{
+						{Type: corev1.PodScheduled, Status: corev1.ConditionTrue},
+						{Type: corev1.PodReady, Status: ready, LastTransitionTime: metav1.Time{Time: time.Now().Add(-test.notReadySince)}},
+					},
+				},
+			}
+			g.Expect(fakeDeps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer().Add(pod)).To(Succeed())
+			if test.notReadyInEps {
+				endpoints := &corev1.Endpoints{
+					ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: controller.TiDBMemberName(tc.GetName())},
+					Subsets: []corev1.EndpointSubset{{
+						NotReadyAddresses: []corev1.EndpointAddress{{
+							IP:        "10.0.0.1",
+							TargetRef: &corev1.ObjectReference{Kind: "Pod", Namespace: corev1.NamespaceDefault, Name: "failover-tidb-0"},
+						}},
+					}},
+				}
+				g.Expect(fakeDeps.KubeInformerFactory.Core().V1().Endpoints().Informer().GetIndexer().Add(endpoints)).To(Succeed())
+			}
+
+			// the member manager calls Failover for the unreachable members
+			unreachable := test.endpointCheck && (!test.ready || test.notReadyInEps)
+			g.Expect(anyTiDBMemberUnreachable(fakeDeps, tc)).To(Equal(unreachable))
+
+			g.Expect(NewTiDBFailover(fakeDeps).Failover(tc)).To(Succeed())
+			if test.expectFailed {
+				g.Expect(tc.Status.TiDB.FailureMembers).To(HaveKey("failover-tidb-0"))
+			} else {
+				g.Expect(tc.Status.TiDB.FailureMembers).To(BeEmpty())
+			}
+		})
+	}
+}
+
 func TestTiDBFailoverRecover(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -497,6 +606,57 @@ func TestTiDBFailoverRecover(t *testing.T) {
 	}
 }
 
+func TestTiDBFailoverRemoveUndesiredFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		update   func(*v1alpha1.TidbCluster)
+		expectFn func(*GomegaWithT, *v1alpha1.TidbCluster)
+	}{
+		{
+			name: "failure member is in the desired ordinals",
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiDB.FailureMembers = map[string]v1alpha1.TiDBFailureMember{
+					"failover-tidb-1": {
+						PodName: "failover-tidb-1",
+					},
+				}
+			},
+			expectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster) {
+				g.Expect(tc.Status.TiDB.FailureMembers).To(HaveKey("failover-tidb-1"))
+			},
+		},
+		{
+			name: "user decrease the replicas below the failure member",
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiDB.Replicas = 1
+				tc.Status.TiDB.FailureMembers = map[string]v1alpha1.TiDBFailureMember{
+					"failover-tidb-0": {
+						PodName: "failover-tidb-0",
+					},
+					"failover-tidb-1": {
+						PodName: "failover-tidb-1",
+					},
+				}
+			},
+			expectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster) {
+				g.Expect(tc.Status.TiDB.FailureMembers).To(HaveLen(1))
+				g.Expect(tc.Status.TiDB.FailureMembers).To(HaveKey("failover-tidb-0"))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			tidbFailover := NewTiDBFailover(controller.NewFakeDependencies())
+			tc := newTidbClusterForTiDBFailover()
+			test.update(tc)
+			tidbFailover.RemoveUndesiredFailures(tc)
+			test.expectFn(g, tc)
+		})
+	}
+}
+
 func newTidbClusterForTiDBFailover() *v1alpha1.TidbCluster {
 	return &v1alpha1.TidbCluster{
 		TypeMeta: metav1.TypeMeta{
@@ -0,0 +1,251 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	// tidbDataIncompatibleReason is the reason of the TiDBDataIncompatible
+	// condition and the Events of the incompatible data
+	tidbDataIncompatibleReason = "TiDBDataIncompatible"
+	// tidbDataCompatibleReason is the reason of the TiDBDataIncompatible
+	// condition when no Pod fails for the incompatible data
+	tidbDataCompatibleReason = "TiDBDataCompatible"
+	// tidbLogTailLines is the number of the last lines of the log of the
+	// previous TiDB container searched for the incompatible data
+	tidbLogTailLines = 100
+)
+
+// tidbIncompatibleDataSignatures are the messages TiDB logs before it exits
+// when the data is bootstrapped by a version it can not run with, e.g. a newer
+// version before a downgrade. They are matched case-insensitively.
+var tidbIncompatibleDataSignatures = []string{
+	"cannot downgrade",
+	"downgrade is not supported",
+	"is lower than the bootstrapped version",
+	"incompatible bootstrap version",
+}
+
+// tidbPreviousLogs returns the last lines of the log of the previous TiDB
+// container of the Pod, which requires the get permission of pods/log. It is a
+// variable so that tests can fake the logs.
+var tidbPreviousLogs = func(deps *controller.Dependencies, pod *corev1.Pod) (string, error) {
+	req := deps.KubeClientset.CoreV1().Pods(pod.GetNamespace()).GetLogs(pod.GetName(), &corev1.PodLogOptions{
+		Container: v1alpha1.TiDBMemberType.String(),
+		Previous:  true,
+		TailLines: pointer.Int64Ptr(tidbLogTailLines),
+	})
+	stream, err := req.Stream(context.TODO())
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+	logs, err := ioutil.ReadAll(stream)
+	if err != nil {
+		return "", err
+	}
+	return string(logs), nil
+}
+
+// tidbLogSignature is the signature found in the log of the previous TiDB
+// container of a Pod, after the container restarted restartCount times
+type tidbLogSignature struct {
+	uid          types.UID
+	restartCount int32
+	signature    string
+}
+
+// tidbLogSignatureCache caches the signatures found in the logs of the
+// previous TiDB containers by cluster and Pod, so that the log of a crash
+// looping container is only fetched once each time it restarts
+type tidbLogSignatureCache struct {
+	mutex      sync.Mutex
+	signatures map[string]map[string]tidbLogSignature
+}
+
+func newTiDBLogSignatureCache() *tidbLogSignatureCache {
+	return &tidbLogSignatureCache{signatures: map[string]map[string]tidbLogSignature{}}
+}
+
+// load returns the signatures cached for the Pods of the cluster, a nil cache
+// caches nothing
+func (c *tidbLogSignatureCache) load(tcKey string) map[string]tidbLogSignature {
+	if c == nil {
+		return map[string]tidbLogSignature{}
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	signatures, ok := c.signatures[tcKey]
+	if !ok {
+		signatures = map[string]tidbLogSignature{}
+		c.signatures[tcKey] = signatures
+	}
+	return signatures
+}
+
+// syncTiDBIncompatibleData records the TiDB Pods failing to start as the data
+// is incompatible with their version in the status, reports them in the
+// TiDBDataIncompatible condition and emits an Event for each newly failing Pod.
+// The failure is recognized by the signatures in the termination message of the
+// TiDB container, or in the log of the previous container if the message is
+// empty and the container is in CrashLoopBackOff.
+func syncTiDBIncompatibleData(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, cache *tidbLogSignatureCache) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	selector, err := label.New().Instance(tc.GetInstanceName()).TiDB().Selector()
+	if err != nil {
+		return err
+	}
+	pods, err := deps.PodLister.Pods(ns).List(selector)
+	if err != nil {
+		return fmt.Errorf("syncTiDBIncompatibleData: failed to list pods for cluster %s/%s, selector %s, error: %s", ns, tcName, selector, err)
+	}
+
+	logSignatures := cache.load(fmt.Sprintf("%s/%s", ns, tcName))
+	podNames := sets.NewString()
+	for _, pod := range pods {
+		podNames.Insert(pod.GetName())
+	}
+	for podName := range logSignatures {
+		if !podNames.Has(podName) {
+			delete(logSignatures, podName)
+		}
+	}
+
+	known := sets.NewString(tc.Status.TiDB.IncompatibleDataPods...)
+	var failing []string
+	for _, pod := range pods {
+		signature := tidbIncompatibleDataSignature(deps, pod, logSignatures)
+		if signature == "" {
+			continue
+		}
+		failing = append(failing, pod.GetName())
+		if !known.Has(pod.GetName()) {
+			msg := fmt.Sprintf("TiDB Pod %s fails to start as the data is incompatible with its version: %q, "+
+				"run it with the version the data is bootstrapped by", pod.GetName(), signature)
+			klog.Warningf("tidbcluster: [%s/%s]'s %s", ns, tcName, msg)
+			deps.Recorder.Event(tc, corev1.EventTypeWarning, tidbDataIncompatibleReason, msg)
+		}
+	}
+
+	if len(failing) > 0 {
+		sort.Strings(failing)
+		tc.Status.TiDB.IncompatibleDataPods = failing
+		utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+			v1alpha1.TidbClusterTiDBDataIncompatible, corev1.ConditionTrue, tidbDataIncompatibleReason,
+			fmt.Sprintf("TiDB Pods fail to start as the data is incompatible with their version: %s", strings.Join(failing, ","))))
+		return nil
+	}
+	if len(tc.Status.TiDB.IncompatibleDataPods) > 0 {
+		klog.Infof("tidbcluster: [%s/%s]'s TiDB Pods stop failing for the incompatible data", ns, tcName)
+		tc.Status.TiDB.IncompatibleDataPods = nil
+	}
+	if utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterTiDBDataIncompatible) != nil {
+		utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+			v1alpha1.TidbClusterTiDBDataIncompatible, corev1.ConditionFalse, tidbDataCompatibleReason, "no TiDB Pod fails for the incompatible data"))
+	}
+	return nil
+}
+
+// tidbIncompatibleDataSignature returns the signature of the incompatible data
+// the TiDB container of the Pod exits with, or "" if it does not. The log of
+// the previous container is fetched only if it is not in logSignatures since
+// the container restarted last time.
+func tidbIncompatibleDataSignature(deps *controller.Dependencies, pod *corev1.Pod, logSignatures map[string]tidbLogSignature) string {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name != v1alpha1.TiDBMemberType.String() {
+			continue
+		}
+		// the container started with the data after the last failure
+		if cs.State.Running != nil {
+			return ""
+		}
+		terminated := cs.State.Terminated
+		if terminated == nil {
+			terminated = cs.LastTerminationState.Terminated
+		}
+		if terminated == nil || terminated.ExitCode == 0 {
+			return ""
+		}
+		if terminated.Message != "" {
+			return matchTiDBIncompatibleData(terminated.Message)
+		}
+		if cs.State.Waiting == nil || cs.State.Waiting.Reason != crashLoopBackOffReason {
+			return ""
+		}
+		if cached, ok := logSignatures[pod.GetName()]; ok && cached.uid == pod.GetUID() && cached.restartCount == cs.RestartCount {
+			return cached.signature
+		}
+		logSignature := tidbLogSignature{uid: pod.GetUID(), restartCount: cs.RestartCount}
+		logs, err := tidbPreviousLogs(deps, pod)
+		if err != nil {
+			// not retried until the container restarts again
+			klog.V(4).Infof("failed to get the log of the previous tidb container of pod %s/%s, error: %v", pod.GetNamespace(), pod.GetName(), err)
+		} else {
+			logSignature.signature = matchTiDBIncompatibleData(logs)
+		}
+		logSignatures[pod.GetName()] = logSignature
+		return logSignature.signature
+	}
+	return ""
+}
+
+// matchTiDBIncompatibleData returns the first line of the output containing a
+// signature of the incompatible data, or "" if there is none
+func matchTiDBIncompatibleData(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		lower := strings.ToLower(line)
+		for _, signature := range tidbIncompatibleDataSignatures {
+			if strings.Contains(lower, signature) {
+				return strings.TrimSpace(line)
+			}
+		}
+	}
+	return ""
+}
+
+// abortUpgradeOnIncompatibleData aborts the upgrade of TiDB and rolls the
+// upgraded Pod of the ordinal back to the previous revision if the Pod fails
+// for the incompatible data and the IncompatibleDataPolicy is Rollback. It
+// returns whether the upgrade is aborted.
+func abortUpgradeOnIncompatibleData(deps *controller.Dependencies, tc *v1alpha1.TidbCluster,
+	pod *corev1.Pod, ordinal int32, revision string, newSet *apps.StatefulSet) (bool, error) {
+	if tc.TiDBIncompatibleDataPolicy() != v1alpha1.IncompatibleDataPolicyRollback {
+		return false, nil
+	}
+	if !sets.NewString(tc.Status.TiDB.IncompatibleDataPods...).Has(pod.GetName()) {
+		return false, nil
+	}
+	msg := fmt.Sprintf("tidb Pod %s fails to start as the data is incompatible with revision %s", pod.GetName(), revision)
+	return true, abortUpgrade(deps, tc, v1alpha1.TiDBMemberType, pod, ordinal, revision, newSet, true, tidbDataIncompatibleReason, msg)
+}
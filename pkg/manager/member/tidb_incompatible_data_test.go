@@ -0,0 +1,193 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const incompatibleDataLog = `[2021/06/01 08:00:00.000 +00:00] [FATAL] [bootstrap.go:1234] ["cannot downgrade from version 71 to 68"]`
+
+func TestSyncTiDBIncompatibleData(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	podIndexer := deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+	recorder := deps.Recorder.(*record.FakeRecorder)
+	tc := newTidbClusterForTiDB()
+
+	logs := map[string]string{}
+	fetched := map[string]int{}
+	defer func(f func(*controller.Dependencies, *corev1.Pod) (string, error)) {
+		tidbPreviousLogs = f
+	}(tidbPreviousLogs)
+	tidbPreviousLogs = func(_ *controller.Dependencies, pod *corev1.Pod) (string, error) {
+		fetched[pod.GetName()]++
+		if log, ok := logs[pod.GetName()]; ok {
+			return log, nil
+		}
+		return "", fmt.Errorf("no log of pod %s", pod.GetName())
+	}
+
+	restartCount := int32(0)
+	setPod := func(ordinal int32, state corev1.ContainerState, last *corev1.ContainerStateTerminated) {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      tidbPodName(tc.GetName(), ordinal),
+				Namespace: tc.GetNamespace(),
+				Labels:    label.New().Instance(tc.GetInstanceName()).TiDB().Labels(),
+			},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:                 v1alpha1.TiDBMemberType.String(),
+					State:                state,
+					LastTerminationState: corev1.ContainerState{Terminated: last},
+					RestartCount:         restartCount,
+				}},
+			},
+		}
+		g.Expect(podIndexer.Update(pod)).To(Succeed())
+	}
+	running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	crashLooping := corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: crashLoopBackOffReason}}
+	cache := newTiDBLogSignatureCache()
+	sync := func() *v1alpha1.TidbClusterCondition {
+		g.Expect(syncTiDBIncompatibleData(deps, tc, cache)).To(Succeed())
+		return utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterTiDBDataIncompatible)
+	}
+	tidb0 := tidbPodName(tc.GetName(), 0)
+	tidb1 := tidbPodName(tc.GetName(), 1)
+
+	// other failures are not reported
+	setPod(0, crashLooping, &corev1.ContainerStateTerminated{ExitCode: 1, Message: "failed to connect to pd"})
+	setPod(1, running, nil)
+	g.Expect(sync()).To(BeNil())
+	g.Expect(recorder.Events).To(HaveLen(0))
+
+	// the signature in the termination message
+	setPod(0, crashLooping, &corev1.ContainerStateTerminated{ExitCode: 1, Message: "some output\n" + incompatibleDataLog})
+	cond := sync()
+	g.Expect(cond).NotTo(BeNil())
+	g.Expect(cond.Status).To(Equal(corev1.ConditionTrue))
+	g.Expect(cond.Reason).To(Equal(tidbDataIncompatibleReason))
+	g.Expect(cond.Message).To(ContainSubstring(tidb0))
+	g.Expect(tc.Status.TiDB.IncompatibleDataPods).To(Equal([]string{tidb0}))
+	g.Expect(recorder.Events).To(HaveLen(1))
+	event := <-recorder.Events
+	g.Expect(event).To(ContainSubstring(tidbDataIncompatibleReason))
+	g.Expect(event).To(ContainSubstring("cannot downgrade from version 71 to 68"))
+
+	// the event is emitted only once for a failing pod
+	sync()
+	g.Expect(recorder.Events).To(HaveLen(0))
+
+	// the log of the previous container is fetched once until the container restarts
+	setPod(1, crashLooping, &corev1.ContainerStateTerminated{ExitCode: 1})
+	sync()
+	g.Expect(tc.Status.TiDB.IncompatibleDataPods).To(Equal([]string{tidb0}))
+	logs[tidb1] = "some output\n" + incompatibleDataLog + "\n"
+	sync()
+	g.Expect(tc.Status.TiDB.IncompatibleDataPods).To(Equal([]string{tidb0}))
+	g.Expect(fetched[tidb1]).To(Equal(1))
+
+	// the signature in the log of the previous container
+	restartCount++
+	setPod(1, crashLooping, &corev1.ContainerStateTerminated{ExitCode: 1})
+	g.Expect(sync().Status).To(Equal(corev1.ConditionTrue))
+	g.Expect(tc.Status.TiDB.IncompatibleDataPods).To(Equal([]string{tidb0, tidb1}))
+	g.Expect(recorder.Events).To(HaveLen(1))
+	<-recorder.Events
+	sync()
+	g.Expect(tc.Status.TiDB.IncompatibleDataPods).To(Equal([]string{tidb0, tidb1}))
+	g.Expect(fetched[tidb1]).To(Equal(2))
+
+	// the log is not checked unless the container is in CrashLoopBackOff
+	setPod(1, corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}}, &corev1.ContainerStateTerminated{ExitCode: 1})
+	sync()
+	g.Expect(tc.Status.TiDB.IncompatibleDataPods).To(Equal([]string{tidb0}))
+
+	// the pods run with a compatible version
+	setPod(0, running, &corev1.ContainerStateTerminated{ExitCode: 1, Message: incompatibleDataLog})
+	setPod(1, running, nil)
+	cond = sync()
+	g.Expect(cond).NotTo(BeNil())
+	g.Expect(cond.Status).To(Equal(corev1.ConditionFalse))
+	g.Expect(tc.Status.TiDB.IncompatibleDataPods).To(BeNil())
+}
+
+func TestAbortUpgradeOnIncompatibleData(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name         string
+		policy       *v1alpha1.IncompatibleDataPolicy
+		incompatible bool
+		expectAbort  bool
+	}
+	rollback := v1alpha1.IncompatibleDataPolicyRollback
+	report := v1alpha1.IncompatibleDataPolicyReport
+
+	tests := []testcase{
+		{name: "policy is not set", incompatible: true, expectAbort: false},
+		{name: "policy is Report", policy: &report, incompatible: true, expectAbort: false},
+		{name: "data is compatible", policy: &rollback, incompatible: false, expectAbort: false},
+		{name: "roll back the incompatible pod", policy: &rollback, incompatible: true, expectAbort: true},
+	}
+
+	for _, test := range tests {
+		t.Log(test.name)
+		deps := controller.NewFakeDependencies()
+		podIndexer := deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+		tc := newTidbClusterForTiDB()
+		tc.Spec.TiDB.IncompatibleDataPolicy = test.policy
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: tidbPodName(tc.GetName(), 1), Namespace: tc.GetNamespace()},
+		}
+		g.Expect(podIndexer.Add(pod)).To(Succeed())
+		if test.incompatible {
+			tc.Status.TiDB.IncompatibleDataPods = []string{pod.GetName()}
+		}
+		newSet := &apps.StatefulSet{}
+		setUpgradePartition(newSet, 1)
+
+		aborted, err := abortUpgradeOnIncompatibleData(deps, tc, pod, 1, "new-revision", newSet)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(aborted).To(Equal(test.expectAbort))
+		if !test.expectAbort {
+			g.Expect(tc.Status.AbortedUpgrades).To(BeNil())
+			g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(1)))
+			continue
+		}
+		g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(2)))
+		g.Expect(tc.Status.AbortedUpgrades[v1alpha1.TiDBMemberType].Revision).To(Equal("new-revision"))
+		g.Expect(tc.Status.AbortedUpgrades[v1alpha1.TiDBMemberType].RolledBack).To(BeTrue())
+		cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterUpgradeAborted)
+		g.Expect(cond).NotTo(BeNil())
+		g.Expect(cond.Reason).To(Equal(tidbDataIncompatibleReason))
+		// the pod is deleted after the partition is persisted
+		_, err = deps.PodLister.Pods(pod.GetNamespace()).Get(pod.GetName())
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+}
@@ -59,6 +59,7 @@ type tidbMemberManager struct {
 	tidbUpgrader                 Upgrader
 	tidbFailover                 Failover
 	tidbStatefulSetIsUpgradingFn func(corelisters.PodLister, *apps.StatefulSet, *v1alpha1.TidbCluster) (bool, error)
+	tidbLogSignatures            *tidbLogSignatureCache
 }
 
 // NewTiDBMemberManager returns a *tidbMemberManager
@@ -69,6 +70,7 @@ func NewTiDBMemberManager(deps *controller.Dependencies, scaler Scaler, tidbUpgr
 		tidbUpgrader:                 tidbUpgrader,
 		tidbFailover:                 tidbFailover,
 		tidbStatefulSetIsUpgradingFn: tidbStatefulSetIsUpgrading,
+		tidbLogSignatures:            newTiDBLogSignatureCache(),
 	}
 }
 
@@ -108,7 +110,14 @@ func (m *tidbMemberManager) Sync(tc *v1alpha1.TidbCluster) error {
 	}
 
 	// Sync TiDB StatefulSet
-	return m.syncTiDBStatefulSetForTidbCluster(tc)
+	if err := m.syncTiDBStatefulSetForTidbCluster(tc); err != nil {
+		return err
+	}
+
+	syncTiDBPlacementPolicies(m.deps, tc)
+
+	syncTiDBSystemVariables(m.deps, tc)
+	return nil
 }
 
 func (m *tidbMemberManager) checkTLSClientCert(tc *v1alpha1.TidbCluster) error {
@@ -199,10 +208,23 @@ func (m *tidbMemberManager) syncTiDBStatefulSetForTidbCluster(tc *v1alpha1.TidbC
 		return err
 	}
 
+	// Remove the failure members out of the desired ordinals before generating desired statefulset
+	if len(tc.Status.TiDB.FailureMembers) > 0 {
+		m.tidbFailover.RemoveUndesiredFailures(tc)
+	}
+
 	newTiDBSet, err := getNewTiDBSetForTidbCluster(tc, cm)
 	if err != nil {
 		return err
 	}
+	if err := mutatePodTemplate(m.deps, tc, v1alpha1.TiDBMemberType, newTiDBSet); err != nil {
+		return err
+	}
+	holdComponentRestartAt(tc, v1alpha1.TiDBMemberType, newTiDBSet, oldTiDBSet)
+
+	if inProgress, err := m.syncTiDBBlueGreen(tc, oldTiDBSet, newTiDBSet); err != nil || inProgress {
+		return err
+	}
 
 	if setNotExist {
 		err = SetStatefulSetLastAppliedConfigAnnotation(newTiDBSet)
@@ -217,6 +239,9 @@ func (m *tidbMemberManager) syncTiDBStatefulSetForTidbCluster(tc *v1alpha1.TidbC
 		return nil
 	}
 
+	// the Pods whose post-upgrade hooks are not run yet are kept in the new StatefulSet
+	keepPostUpgradeHookPods(oldTiDBSet, newTiDBSet)
+
 	// Scaling takes precedence over upgrading because:
 	// - if a pod fails in the upgrading, users may want to delete it or add
 	//   new replicas
@@ -226,10 +251,22 @@ func (m *tidbMemberManager) syncTiDBStatefulSetForTidbCluster(tc *v1alpha1.TidbC
 		return err
 	}
 
-	if m.deps.CLIConfig.AutoFailover {
-		if m.shouldRecover(tc) {
+	// Report the Pods stuck in Pending, the Pods restarted too often and the
+	// Pods failing for the incompatible data
+	if err := syncRolloutStall(m.deps, tc, v1alpha1.TiDBMemberType); err != nil {
+		return err
+	}
+	if err := syncPodRestartFlapping(m.deps, tc, v1alpha1.TiDBMemberType); err != nil {
+		return err
+	}
+	if err := syncTiDBIncompatibleData(m.deps, tc, m.tidbLogSignatures); err != nil {
+		return err
+	}
+
+	if m.deps.CLIConfig.AutoFailover && !tc.FailoverPausedByRestarts(v1alpha1.TiDBMemberType) {
+		if tc.RecoverFailover(v1alpha1.TiDBMemberType) && m.shouldRecover(tc) {
 			m.tidbFailover.Recover(tc)
-		} else if tc.TiDBAllPodsStarted() && !tc.TiDBAllMembersReady() {
+		} else if tc.TiDBAllPodsStarted() && (!tc.TiDBAllMembersReady() || anyTiDBMemberUnreachable(m.deps, tc)) {
 			if err := m.tidbFailover.Failover(tc); err != nil {
 				return err
 			}
@@ -240,9 +277,18 @@ func (m *tidbMemberManager) syncTiDBStatefulSetForTidbCluster(tc *v1alpha1.TidbC
 		if err := m.tidbUpgrader.Upgrade(tc, oldTiDBSet, newTiDBSet); err != nil {
 			return err
 		}
+	} else {
+		// the failure of the canary is stale once the upgrade is reverted
+		resolveTiDBCanaryFailedCondition(tc, tidbCanaryStaleReason, "the tidb upgrade is not in progress")
+		if tc.Status.TiDB.Phase == v1alpha1.NormalPhase {
+			// Restart the Pods requested by the restart-at annotation when no upgrade or scaling is in progress
+			if err := syncPodRestarts(m.deps, tc, v1alpha1.TiDBMemberType); err != nil {
+				return err
+			}
+		}
 	}
 
-	return UpdateStatefulSet(m.deps.StatefulSetControl, tc, newTiDBSet, oldTiDBSet)
+	return updateStatefulSetAndRunPostScaleHook(m.deps, tc, v1alpha1.TiDBMemberType, newTiDBSet, oldTiDBSet)
 }
 
 func (m *tidbMemberManager) shouldRecover(tc *v1alpha1.TidbCluster) bool {
@@ -267,6 +313,11 @@ func (m *tidbMemberManager) shouldRecover(tc *v1alpha1.TidbCluster) bool {
 		if !ok || !status.Health {
 			return false
 		}
+		// the members failed over as unreachable through the Service are
+		// not recovered while they are ready but not in the Endpoints
+		if unreachable, _, _ := isTiDBMemberUnreachable(m.deps, tc, status); unreachable {
+			return false
+		}
 	}
 	return true
 }
@@ -366,10 +417,21 @@ func (m *tidbMemberManager) syncTiDBConfigMap(tc *v1alpha1.TidbCluster, set *app
 
 	klog.V(3).Info("get tidb in use config map name: ", inUseName)
 
-	err = updateConfigMapIfNeed(m.deps.ConfigMapLister, tc.BaseTiDBSpec().ConfigUpdateStrategy(), inUseName, newCm)
+	strategy, err := syncConfigOnline(m.deps, tc, v1alpha1.TiDBMemberType, tc.BaseTiDBSpec().ConfigUpdateStrategy(), inUseName, newCm)
 	if err != nil {
 		return nil, err
 	}
+	err = updateConfigMapIfNeed(m.deps.ConfigMapLister, strategy, inUseName, newCm)
+	if err != nil {
+		return nil, err
+	}
+	ordinalConfig, err := getTiDBOrdinalConfig(tc)
+	if err != nil {
+		return nil, err
+	}
+	if err := syncOrdinalConfigMap(m.deps, tc, v1alpha1.TiDBMemberType, ordinalConfig); err != nil {
+		return nil, err
+	}
 	return m.deps.TypedControl.CreateOrUpdateConfigMap(tc, newCm)
 }
 
@@ -379,28 +441,18 @@ func getTiDBConfigMap(tc *v1alpha1.TidbCluster) (*corev1.ConfigMap, error) {
 		return nil, nil
 	}
 
-	// override CA if tls enabled
-	if tc.IsTLSClusterEnabled() {
-		config.Set("security.cluster-ssl-ca", path.Join(clusterCertPath, tlsSecretRootCAKey))
-		config.Set("security.cluster-ssl-cert", path.Join(clusterCertPath, corev1.TLSCertKey))
-		config.Set("security.cluster-ssl-key", path.Join(clusterCertPath, corev1.TLSPrivateKeyKey))
-	}
-	if tc.Spec.TiDB.IsTLSClientEnabled() {
-		config.Set("security.ssl-ca", path.Join(serverCertPath, tlsSecretRootCAKey))
-		config.Set("security.ssl-cert", path.Join(serverCertPath, corev1.TLSCertKey))
-		config.Set("security.ssl-key", path.Join(serverCertPath, corev1.TLSPrivateKeyKey))
-	}
-	confText, err := config.MarshalTOML()
+	confText, err := renderTiDBConfig(tc, config)
 	if err != nil {
 		return nil, err
 	}
 
-	plugins := tc.Spec.TiDB.Plugins
+	plugins := tidbPlugins(tc)
 	tidbStartScriptModel := &TidbStartScriptModel{
-		EnablePlugin:    len(plugins) > 0,
-		PluginDirectory: "/plugins",
-		PluginList:      strings.Join(plugins, ","),
-		ClusterDomain:   tc.Spec.ClusterDomain,
+		EnablePlugin:     len(plugins) > 0,
+		PluginDirectory:  "/plugins",
+		PluginList:       strings.Join(plugins, ","),
+		ClusterDomain:    tc.Spec.ClusterDomain,
+		PerOrdinalConfig: tc.Spec.TiDB.PerOrdinalConfig != nil,
 	}
 
 	if tc.HeterogeneousWithoutLocalPD() {
@@ -415,7 +467,7 @@ func getTiDBConfigMap(tc *v1alpha1.TidbCluster) (*corev1.ConfigMap, error) {
 		return nil, err
 	}
 	data := map[string]string{
-		"config-file":    string(confText),
+		"config-file":    confText,
 		"startup-script": startScript,
 	}
 	name := controller.TiDBMemberName(tc.Name)
@@ -435,6 +487,49 @@ func getTiDBConfigMap(tc *v1alpha1.TidbCluster) (*corev1.ConfigMap, error) {
 	return cm, nil
 }
 
+// renderTiDBConfig sets the TLS paths in config and returns the config in TOML
+func renderTiDBConfig(tc *v1alpha1.TidbCluster, config *v1alpha1.TiDBConfigWraper) (string, error) {
+	// override CA if tls enabled
+	if tc.IsTLSClusterEnabled() {
+		config.Set("security.cluster-ssl-ca", path.Join(clusterCertPath, tlsSecretRootCAKey))
+		config.Set("security.cluster-ssl-cert", path.Join(clusterCertPath, corev1.TLSCertKey))
+		config.Set("security.cluster-ssl-key", path.Join(clusterCertPath, corev1.TLSPrivateKeyKey))
+	}
+	if tc.Spec.TiDB.IsTLSClientEnabled() {
+		config.Set("security.ssl-ca", path.Join(serverCertPath, tlsSecretRootCAKey))
+		config.Set("security.ssl-cert", path.Join(serverCertPath, corev1.TLSCertKey))
+		config.Set("security.ssl-key", path.Join(serverCertPath, corev1.TLSPrivateKeyKey))
+	}
+	confText, err := config.MarshalTOML()
+	if err != nil {
+		return "", err
+	}
+	return string(confText), nil
+}
+
+// getTiDBOrdinalConfig returns the config of TiDB overridden for some
+// ordinals in TOML keyed by the ordinal, or nil if the config is not
+// overridden
+func getTiDBOrdinalConfig(tc *v1alpha1.TidbCluster) (map[string]string, error) {
+	tidbSpec := tc.Spec.TiDB
+	if tidbSpec.Config == nil || tidbSpec.PerOrdinalConfig == nil {
+		return nil, nil
+	}
+	data := map[string]string{}
+	for ordinal, override := range tidbSpec.PerOrdinalConfig {
+		config := tidbSpec.Config.DeepCopy()
+		if override != nil {
+			config.Merge(override.GenericConfig)
+		}
+		confText, err := renderTiDBConfig(tc, config)
+		if err != nil {
+			return nil, err
+		}
+		data[ordinal] = confText
+	}
+	return data, nil
+}
+
 func getNewTiDBServiceOrNil(tc *v1alpha1.TidbCluster) *corev1.Service {
 
 	svcSpec := tc.Spec.TiDB.Service
@@ -483,7 +578,7 @@ func getNewTiDBServiceOrNil(tc *v1alpha1.TidbCluster) *corev1.Service {
 		Spec: corev1.ServiceSpec{
 			Type:     svcSpec.Type,
 			Ports:    ports,
-			Selector: tidbSelector.Labels(),
+			Selector: tidbServiceSelector(tc).Labels(),
 		},
 	}
 	if svcSpec.Type == corev1.ServiceTypeLoadBalancer {
@@ -582,6 +677,11 @@ func getNewTiDBSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 			}},
 		},
 	}
+	if tc.Spec.TiDB.PerOrdinalConfig != nil {
+		vol, mount := ordinalConfigVolume(tc, v1alpha1.TiDBMemberType)
+		vols = append(vols, vol)
+		volMounts = append(volMounts, mount)
+	}
 	if tc.IsTLSClusterEnabled() {
 		vols = append(vols, corev1.Volume{
 			Name: "tidb-tls", VolumeSource: corev1.VolumeSource{
@@ -653,10 +753,14 @@ func getNewTiDBSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 		var slowQueryLogVolumeMount corev1.VolumeMount
 		slowQueryLogVolumeName := tc.Spec.TiDB.SlowLogVolumeName
 		if slowQueryLogVolumeName == "" {
+			emptyDir := &corev1.EmptyDirVolumeSource{}
+			if es := tc.Spec.TiDB.EphemeralStorage; es != nil && es.EmptyDirSizeLimit != nil {
+				emptyDir.SizeLimit = es.EmptyDirSizeLimit
+			}
 			vols = append(vols, corev1.Volume{
 				Name: defaultSlowLogVolume,
 				VolumeSource: corev1.VolumeSource{
-					EmptyDir: &corev1.EmptyDirVolumeSource{},
+					EmptyDir: emptyDir,
 				},
 			})
 			slowQueryLogVolumeMount = corev1.VolumeMount{Name: defaultSlowLogVolume, MountPath: defaultSlowLogDir}
@@ -700,6 +804,16 @@ func getNewTiDBSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 		})
 	}
 
+	if tc.Spec.TiDB.AuditLog != nil {
+		// mount a shared volume for the audit log and ship it using the sidecar if set
+		auditLogVolume, auditLogVolumeMount, sidecar := buildTiDBAuditLog(tc)
+		vols = append(vols, auditLogVolume)
+		volMounts = append(volMounts, auditLogVolumeMount)
+		if sidecar != nil {
+			containers = append(containers, *sidecar)
+		}
+	}
+
 	envs := []corev1.EnvVar{
 		{
 			Name:  "CLUSTER_NAME",
@@ -757,7 +871,7 @@ func getNewTiDBSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 			},
 		},
 		VolumeMounts: volMounts,
-		Resources:    controller.ContainerResource(tc.Spec.TiDB.ResourceRequirements),
+		Resources:    controller.ContainerResourceWithEphemeralStorage(tc.Spec.TiDB.ResourceRequirements, tc.Spec.TiDB.EphemeralStorage),
 		Env:          util.AppendEnv(envs, baseTiDBSpec.Env()),
 		ReadinessProbe: &corev1.Probe{
 			Handler:             buildTiDBReadinessProbHandler(tc),
@@ -773,6 +887,7 @@ func getNewTiDBSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 	podSpec := baseTiDBSpec.BuildPodSpec()
 	podSpec.Containers = append(containers, baseTiDBSpec.AdditionalContainers()...)
 	podSpec.Volumes = append(vols, baseTiDBSpec.AdditionalVolumes()...)
+	setMainContainerSecurityContext(&podSpec, baseTiDBSpec, v1alpha1.TiDBMemberType.String(), nil)
 	podSpec.SecurityContext = podSecurityContext
 	podSpec.InitContainers = append(initContainers, baseTiDBSpec.InitContainers()...)
 	podSpec.ServiceAccountName = tc.Spec.TiDB.ServiceAccount
@@ -854,6 +969,10 @@ func (m *tidbMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, set
 		tc.Status.TiDB.Phase = v1alpha1.NormalPhase
 	}
 
+	if err := syncUpgradeProgress(m.deps.PodLister, tc, v1alpha1.TiDBMemberType, set, tc.Status.TiDB.Phase, &tc.Status.TiDB.Upgrade); err != nil {
+		return err
+	}
+
 	tidbStatus := map[string]v1alpha1.TiDBMember{}
 	for id := range helper.GetPodOrdinals(tc.Status.TiDB.StatefulSet.Replicas, set) {
 		name := fmt.Sprintf("%s-%d", controller.TiDBMemberName(tc.GetName()), id)
@@ -892,11 +1011,12 @@ func (m *tidbMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, set
 	if c != nil {
 		tc.Status.TiDB.Image = c.Image
 	}
+	syncComponentTopology(m.deps, tc, v1alpha1.TiDBMemberType, nil)
 	return nil
 }
 
 func tidbStatefulSetIsUpgrading(podLister corelisters.PodLister, set *apps.StatefulSet, tc *v1alpha1.TidbCluster) (bool, error) {
-	if statefulSetIsUpgrading(set) {
+	if statefulSetIsUpgrading(set) || postUpgradeHooksPending(set) {
 		return true, nil
 	}
 	selector, err := label.New().
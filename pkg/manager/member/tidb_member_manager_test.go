@@ -905,6 +905,7 @@ func TestGetNewTiDBHeadlessServiceForTidbCluster(t *testing.T) {
 
 func TestGetNewTiDBSetForTidbCluster(t *testing.T) {
 	enable := true
+	pullAlways := corev1.PullAlways
 	updateStrategy := v1alpha1.ConfigUpdateStrategyRollingUpdate
 	tests := []struct {
 		name    string
@@ -928,6 +929,61 @@ func TestGetNewTiDBSetForTidbCluster(t *testing.T) {
 			},
 			testSts: testHostNetwork(t, false, ""),
 		},
+		{
+			name: "tidb with ephemeral storage",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					TiDB: &v1alpha1.TiDBSpec{
+						ResourceRequirements: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:              resource.MustParse("1"),
+								corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+							},
+						},
+						ComponentSpec: v1alpha1.ComponentSpec{
+							EphemeralStorage: &v1alpha1.EphemeralStorageSpec{
+								Request:           resource.NewQuantity(2<<30, resource.BinarySI),
+								Limit:             resource.NewQuantity(4<<30, resource.BinarySI),
+								EmptyDirSizeLimit: resource.NewQuantity(1<<30, resource.BinarySI),
+							},
+						},
+					},
+					PD:   &v1alpha1.PDSpec{},
+					TiKV: &v1alpha1.TiKVSpec{},
+				},
+			},
+			testSts: func(sts *apps.StatefulSet) {
+				g := NewGomegaWithT(t)
+				var tidbContainer *corev1.Container
+				for i := range sts.Spec.Template.Spec.Containers {
+					if sts.Spec.Template.Spec.Containers[i].Name == v1alpha1.TiDBMemberType.String() {
+						tidbContainer = &sts.Spec.Template.Spec.Containers[i]
+					}
+				}
+				g.Expect(tidbContainer).NotTo(BeNil())
+				g.Expect(tidbContainer.Resources).To(Equal(corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:              resource.MustParse("1"),
+						corev1.ResourceEphemeralStorage: *resource.NewQuantity(2<<30, resource.BinarySI),
+					},
+					Limits: corev1.ResourceList{
+						corev1.ResourceEphemeralStorage: *resource.NewQuantity(4<<30, resource.BinarySI),
+					},
+				}))
+				var slowLogVolume *corev1.Volume
+				for i := range sts.Spec.Template.Spec.Volumes {
+					if sts.Spec.Template.Spec.Volumes[i].Name == defaultSlowLogVolume {
+						slowLogVolume = &sts.Spec.Template.Spec.Volumes[i]
+					}
+				}
+				g.Expect(slowLogVolume).NotTo(BeNil())
+				g.Expect(slowLogVolume.EmptyDir.SizeLimit.String()).To(Equal("1Gi"))
+			},
+		},
 		{
 			name: "tidb network is host",
 			tc: v1alpha1.TidbCluster{
@@ -1194,6 +1250,42 @@ func TestGetNewTiDBSetForTidbCluster(t *testing.T) {
 				}))
 			},
 		},
+		{
+			name: "tidb image pull policy overrides the cluster-level one",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					TiDB: &v1alpha1.TiDBSpec{
+						ComponentSpec: v1alpha1.ComponentSpec{
+							ImagePullPolicy: &pullAlways,
+						},
+					},
+					PD:   &v1alpha1.PDSpec{},
+					TiKV: &v1alpha1.TiKVSpec{},
+				},
+			},
+			testSts: testImagePullPolicy(t, corev1.PullAlways, v1alpha1.TiDBMemberType),
+		},
+		{
+			name: "tidb image pull policy defaults to the cluster-level one",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					TiDB:            &v1alpha1.TiDBSpec{},
+					PD:              &v1alpha1.PDSpec{},
+					TiKV:            &v1alpha1.TiKVSpec{},
+				},
+			},
+			testSts: testImagePullPolicy(t, corev1.PullIfNotPresent, v1alpha1.TiDBMemberType),
+		},
 		// TODO add more tests
 	}
 
@@ -2122,10 +2214,11 @@ func TestTiDBShouldRecover(t *testing.T) {
 		},
 	})
 	tests := []struct {
-		name string
-		tc   *v1alpha1.TidbCluster
-		pods []*v1.Pod
-		want bool
+		name      string
+		tc        *v1alpha1.TidbCluster
+		pods      []*v1.Pod
+		endpoints *v1.Endpoints
+		want      bool
 	}{
 		{
 			name: "should not recover if no failure members",
@@ -2248,6 +2341,54 @@ func TestTiDBShouldRecover(t *testing.T) {
 			pods: podsWithFailover,
 			want: true,
 		},
+		{
+			name: "should not recover if a member is not ready in the endpoints with endpointCheck",
+			tc: &v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "failover",
+					Namespace: v1.NamespaceDefault,
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					TiDB: &v1alpha1.TiDBSpec{
+						Replicas: 2,
+						Failover: &v1alpha1.FailoverSpec{EndpointCheck: pointer.BoolPtr(true)},
+					},
+				},
+				Status: v1alpha1.TidbClusterStatus{
+					TiDB: v1alpha1.TiDBStatus{
+						Members: map[string]v1alpha1.TiDBMember{
+							"failover-tidb-0": {
+								Name:   "failover-tidb-0",
+								Health: true,
+							},
+							"failover-tidb-1": {
+								Name:   "failover-tidb-1",
+								Health: true,
+							},
+						},
+						FailureMembers: map[string]v1alpha1.TiDBFailureMember{
+							"failover-tidb-0": {
+								PodName: "failover-tidb-0",
+							},
+						},
+					},
+				},
+			},
+			pods: pods,
+			endpoints: &v1.Endpoints{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "failover-tidb",
+					Namespace: v1.NamespaceDefault,
+				},
+				Subsets: []v1.EndpointSubset{{
+					NotReadyAddresses: []v1.EndpointAddress{{
+						IP:        "10.0.0.1",
+						TargetRef: &v1.ObjectReference{Kind: "Pod", Name: "failover-tidb-0"},
+					}},
+				}},
+			},
+			want: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -2258,6 +2399,9 @@ func TestTiDBShouldRecover(t *testing.T) {
 			for _, pod := range tt.pods {
 				fakeDeps.KubeClientset.CoreV1().Pods(pod.Namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
 			}
+			if tt.endpoints != nil {
+				fakeDeps.KubeClientset.CoreV1().Endpoints(tt.endpoints.Namespace).Create(context.TODO(), tt.endpoints, metav1.CreateOptions{})
+			}
 			kubeInformerFactory := fakeDeps.KubeInformerFactory
 			kubeInformerFactory.Start(ctx.Done())
 			kubeInformerFactory.WaitForCacheSync(ctx.Done())
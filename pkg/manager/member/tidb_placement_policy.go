@@ -0,0 +1,117 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	errorutils "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog"
+)
+
+const (
+	// tidbSQLSecretNotSetReason is the reason of the Events recorded when the
+	// objects managed through SQL are declared without `.spec.tidb.sqlSecretName`
+	tidbSQLSecretNotSetReason = "SQLSecretNotSet"
+	// tidbPlacementPolicySyncFailedReason is the reason of the Events of the
+	// failures to sync the placement policies
+	tidbPlacementPolicySyncFailedReason = "PlacementPolicySyncFailed"
+)
+
+// checkTiDBSQLSecret returns whether the Secret of the credentials the operator
+// connects to TiDB with is set. The objects managed through SQL are not synced
+// without it, rather than connecting as the root user without password.
+func checkTiDBSQLSecret(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, objects string) bool {
+	if tc.Spec.TiDB != nil && tc.Spec.TiDB.SQLSecretName != "" {
+		return true
+	}
+	klog.Warningf("tidb cluster %s/%s does not set sqlSecretName, skip syncing %s", tc.GetNamespace(), tc.GetName(), objects)
+	deps.Recorder.Event(tc, corev1.EventTypeWarning, tidbSQLSecretNotSetReason, fmt.Sprintf("sqlSecretName is not set, skip syncing %s", objects))
+	return false
+}
+
+// syncTiDBPlacementPolicies creates the placement policies in the spec that do
+// not exist in TiDB and alters the ones that drift from the spec, once all the
+// TiDB members are ready. The state of each policy is reported in the status.
+//
+// The placement policies are managed through SQL, so TiDB being unreachable
+// does not fail the sync of TiDB. The failures are recorded as Events and the
+// policies are synced again in the next round.
+func syncTiDBPlacementPolicies(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	policies := tc.Spec.TiDB.PlacementPolicies
+	if len(policies) == 0 {
+		tc.Status.TiDB.PlacementPolicies = nil
+		return
+	}
+	if tc.Spec.Paused {
+		klog.V(4).Infof("tidb cluster %s/%s is paused, skip syncing tidb placement policies", ns, tcName)
+		return
+	}
+	if !tc.TiDBAllMembersReady() {
+		klog.V(4).Infof("tidb cluster %s/%s is waiting for all tidb members to be ready to sync placement policies", ns, tcName)
+		return
+	}
+	if !checkTiDBSQLSecret(deps, tc, "placement policies") {
+		return
+	}
+
+	status := map[string]v1alpha1.PlacementPolicyStatus{}
+	var errs []error
+	for i := range policies {
+		policy := &policies[i]
+		err := syncTiDBPlacementPolicy(deps, tc, policy)
+		s := v1alpha1.PlacementPolicyStatus{Synced: err == nil, LastSyncTime: metav1.Now()}
+		if err != nil {
+			s.Message = err.Error()
+			errs = append(errs, fmt.Errorf("failed to sync placement policy %s of tidb cluster %s/%s, error: %v", policy.Name, ns, tcName, err))
+		}
+		status[policy.Name] = s
+	}
+	tc.Status.TiDB.PlacementPolicies = status
+	if err := errorutils.NewAggregate(errs); err != nil {
+		klog.Warning(err)
+		deps.Recorder.Event(tc, corev1.EventTypeWarning, tidbPlacementPolicySyncFailedReason, err.Error())
+	}
+}
+
+func syncTiDBPlacementPolicy(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, policy *v1alpha1.PlacementPolicy) error {
+	current, err := deps.TiDBControl.GetPlacementPolicy(tc, policy.Name)
+	if err != nil {
+		return err
+	}
+	desired := policy.NormalizedOptions()
+	if current != nil && reflect.DeepEqual(current, desired) {
+		return nil
+	}
+	if current == nil {
+		klog.Infof("tidb cluster %s/%s creates placement policy %s: %s", tc.GetNamespace(), tc.GetName(), policy.Name, policy.SQLOptions())
+	} else {
+		klog.Infof("tidb cluster %s/%s alters placement policy %s from %v to %s", tc.GetNamespace(), tc.GetName(), policy.Name, formatOptions(current), policy.SQLOptions())
+	}
+	return deps.TiDBControl.SetPlacementPolicy(tc, policy, current != nil)
+}
+
+func formatOptions(options map[string]string) string {
+	p := v1alpha1.PlacementPolicy{Options: options}
+	return strings.TrimSpace(p.SQLOptions())
+}
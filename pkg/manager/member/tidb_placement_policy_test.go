@@ -0,0 +1,94 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestSyncTiDBPlacementPolicies(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	tidbControl := deps.TiDBControl.(*controller.FakeTiDBControl)
+	tc := newTidbClusterForTiDB()
+	tc.Spec.TiDB.PlacementPolicies = []v1alpha1.PlacementPolicy{
+		{Name: "east", Options: map[string]string{"primary_region": "us-east-1", "REGIONS": "us-east-1,us-west-1"}},
+		{Name: "five", Options: map[string]string{"FOLLOWERS": "4"}},
+	}
+	recorder := deps.Recorder.(*record.FakeRecorder)
+	sync := func() {
+		syncTiDBPlacementPolicies(deps, tc)
+	}
+
+	// nothing is applied until all tidb members are ready
+	sync()
+	g.Expect(tidbControl.PlacementPolicySets).To(Equal(0))
+	g.Expect(tc.Status.TiDB.PlacementPolicies).To(BeNil())
+
+	tc.Status.TiDB.Members = map[string]v1alpha1.TiDBMember{}
+	for i := 0; i < int(tc.Spec.TiDB.Replicas); i++ {
+		name := fmt.Sprintf("test-tidb-%d", i)
+		tc.Status.TiDB.Members[name] = v1alpha1.TiDBMember{Name: name, Health: true}
+	}
+
+	// nothing is applied without the credentials to connect to tidb
+	sync()
+	g.Expect(tidbControl.PlacementPolicySets).To(Equal(0))
+	g.Expect(collectEvents(recorder.Events)).To(ConsistOf("Warning SQLSecretNotSet sqlSecretName is not set, skip syncing placement policies"))
+
+	// tidb being unreachable is reported in the status and as an event
+	tc.Spec.TiDB.SQLSecretName = "tidb-sql"
+	tidbControl.SQLError = fmt.Errorf("connection refused")
+	sync()
+	g.Expect(tc.Status.TiDB.PlacementPolicies["east"].Synced).To(BeFalse())
+	g.Expect(tc.Status.TiDB.PlacementPolicies["east"].Message).To(Equal("connection refused"))
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(HavePrefix("Warning PlacementPolicySyncFailed "))
+	tidbControl.SQLError = nil
+
+	// the missing policies are created
+	sync()
+	g.Expect(tidbControl.PlacementPolicySets).To(Equal(2))
+	g.Expect(tidbControl.PlacementPolicies["east"]).To(Equal(map[string]string{"PRIMARY_REGION": "us-east-1", "REGIONS": "us-east-1,us-west-1"}))
+	g.Expect(tc.Status.TiDB.PlacementPolicies).To(HaveLen(2))
+	g.Expect(tc.Status.TiDB.PlacementPolicies["east"].Synced).To(BeTrue())
+
+	// nothing to do if the policies are in sync
+	sync()
+	g.Expect(tidbControl.PlacementPolicySets).To(Equal(2))
+
+	// the drifted policy is altered back
+	tidbControl.PlacementPolicies["five"] = map[string]string{"FOLLOWERS": "2"}
+	sync()
+	g.Expect(tidbControl.PlacementPolicySets).To(Equal(3))
+	g.Expect(tidbControl.PlacementPolicies["five"]).To(Equal(map[string]string{"FOLLOWERS": "4"}))
+
+	// the status of the policies removed from the spec is dropped
+	tc.Spec.TiDB.PlacementPolicies = tc.Spec.TiDB.PlacementPolicies[:1]
+	sync()
+	g.Expect(tc.Status.TiDB.PlacementPolicies).To(HaveLen(1))
+	g.Expect(tc.Status.TiDB.PlacementPolicies).To(HaveKey("east"))
+
+	tc.Spec.TiDB.PlacementPolicies = nil
+	sync()
+	g.Expect(tc.Status.TiDB.PlacementPolicies).To(BeNil())
+}
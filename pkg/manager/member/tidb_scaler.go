@@ -15,13 +15,16 @@ package member
 
 import (
 	"fmt"
+	"time"
 
 	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/klog"
 
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/util"
@@ -32,16 +35,52 @@ type tidbScaler struct {
 }
 
 // NewTiDBScaler returns a TiDB Scaler.
-func NewTiDBScaler(deps *controller.Dependencies) *tidbScaler {
-	return &tidbScaler{generalScaler: generalScaler{deps: deps}}
+func NewTiDBScaler(deps *controller.Dependencies) Scaler {
+	return withScaleMetrics(v1alpha1.TiDBMemberType, &tidbScaler{generalScaler: generalScaler{deps: deps}})
 }
 
 // Scale scales in or out of the statefulset.
 func (s *tidbScaler) Scale(meta metav1.Object, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	// the dry run of a scale changes nothing, including the cleanups below
+	if s.scaleDryRun(meta, v1alpha1.TiDBMemberType, oldSet, newSet) {
+		return nil
+	}
+	if err := clearCancelledPreScaleInHooks(s.deps, meta, newSet); err != nil {
+		return err
+	}
+	if err := skipScaleInProtectedPods(s.deps, meta, v1alpha1.TiDBMemberType, oldSet, newSet); err != nil {
+		return err
+	}
+	if s.deferScaleToMaintenanceWindow(meta, v1alpha1.TiDBMemberType, oldSet, newSet) {
+		return nil
+	}
+	if deferred, err := s.deferScale(meta, v1alpha1.TiDBMemberType, oldSet, newSet); err != nil || deferred {
+		return err
+	}
+	if err := s.checkScaleVelocity(meta, v1alpha1.TiDBMemberType, oldSet, newSet); err != nil {
+		return err
+	}
 	scaling, _, _, _ := scaleOne(oldSet, newSet)
+	if scaling != 0 {
+		if err := checkPDSplitBrain(meta, oldSet, newSet); err != nil {
+			return err
+		}
+	}
+	if tc, ok := meta.(*v1alpha1.TidbCluster); ok && scaling >= 0 {
+		// the scale-in is cancelled, the drain starts over with the full
+		// timeout once it scales in again
+		if err := clearDrainBeginTime(s.deps, tc, v1alpha1.TiDBMemberType, ""); err != nil {
+			return err
+		}
+	}
 	if scaling > 0 {
+		if err := checkResourceQuota(s.deps, meta, oldSet, newSet); err != nil {
+			return err
+		}
+		s.recordScale(meta, v1alpha1.TiDBMemberType, oldSet, newSet)
 		return s.ScaleOut(meta, oldSet, newSet)
 	} else if scaling < 0 {
+		s.recordScale(meta, v1alpha1.TiDBMemberType, oldSet, newSet)
 		return s.ScaleIn(meta, oldSet, newSet)
 	}
 	return nil
@@ -91,11 +130,18 @@ func (s *tidbScaler) ScaleIn(meta metav1.Object, oldSet *apps.StatefulSet, newSe
 		return fmt.Errorf("tidbScaler.ScaleIn: failed to get pods %s for cluster %s/%s, error: %s", podName, ns, tcName, err)
 	}
 
+	tc, _ := meta.(*v1alpha1.TidbCluster)
+	if err := runPreScaleInHook(s.deps, tc, v1alpha1.TiDBMemberType, ordinal); err != nil {
+		return err
+	}
+	if err := s.drainConnections(tc, pod, ordinal); err != nil {
+		return err
+	}
+
 	pvcs, err := util.ResolvePVCFromPod(pod, s.deps.PVCLister)
 	if err != nil && !errors.IsNotFound(err) {
 		return fmt.Errorf("tidbScaler.ScaleIn: failed to get pvcs for pod %s/%s in tc %s/%s, error: %s", ns, pod.Name, ns, tcName, err)
 	}
-	tc, _ := meta.(*v1alpha1.TidbCluster)
 	for _, pvc := range pvcs {
 		if err := addDeferDeletingAnnoToPVC(tc, pvc, s.deps.PVCControl); err != nil {
 			return err
@@ -105,3 +151,50 @@ func (s *tidbScaler) ScaleIn(meta metav1.Object, oldSet *apps.StatefulSet, newSe
 	setReplicasAndDeleteSlots(newSet, replicas, deleteSlots)
 	return nil
 }
+
+// drainConnections returns nil once the client connections to the TiDB Pod
+// are closed or spec.tidb.scaleInDrainTimeout elapses since the first call for
+// the Pod, otherwise the scale-in is requeued. The connections of the operator
+// itself are not counted.
+func (s *tidbScaler) drainConnections(tc *v1alpha1.TidbCluster, pod *corev1.Pod, ordinal int32) error {
+	timeout := tc.TiDBScaleInDrainTimeout()
+	if timeout <= 0 {
+		return nil
+	}
+
+	ns := tc.GetNamespace()
+	beginTime, draining := pod.Annotations[label.AnnDrainBeginTime]
+	if !draining {
+		pod = pod.DeepCopy()
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[label.AnnDrainBeginTime] = time.Now().Format(time.RFC3339)
+		if _, err := s.deps.PodControl.UpdatePod(tc, pod); err != nil {
+			return err
+		}
+		return controller.RequeueErrorf("TiDB %s/%s begins draining the client connections, can't scale in now", ns, pod.Name)
+	}
+
+	begin, err := time.Parse(time.RFC3339, beginTime)
+	if err != nil {
+		klog.Errorf("tidbScaler.ScaleIn: failed to parse %s %q of pod %s/%s, error: %v", label.AnnDrainBeginTime, beginTime, ns, pod.Name, err)
+		return nil
+	}
+	if time.Since(begin) >= timeout {
+		msg := fmt.Sprintf("TiDB Pod %s still has client connections %v after the drain begins, scale in the Pod anyway", pod.Name, timeout)
+		klog.Warningf("tidbScaler.ScaleIn: tc[%s/%s]'s %s", ns, tc.GetName(), msg)
+		s.deps.Recorder.Event(tc, corev1.EventTypeWarning, "DrainTimeout", msg)
+		return nil
+	}
+
+	connections, err := s.deps.TiDBControl.GetConnections(tc, ordinal)
+	if err != nil {
+		return controller.RequeueErrorf("TiDB %s/%s failed to get the client connections, error: %v", ns, pod.Name, err)
+	}
+	if connections > 0 {
+		return controller.RequeueErrorf("TiDB %s/%s has %d client connections, can't scale in now", ns, pod.Name, connections)
+	}
+	klog.Infof("tidbScaler.ScaleIn: TiDB %s/%s has no client connections", ns, pod.Name)
+	return nil
+}
@@ -23,6 +23,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 
 	"github.com/pingcap/tidb-operator/pkg/apis/label"
@@ -311,6 +312,117 @@ func TestTiDBScalerScaleIn(t *testing.T) {
 	}
 }
 
+func TestTiDBScalerScaleInDrainsConnections(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		name        string
+		drainedFor  time.Duration
+		connections int
+		errExpectFn func(*GomegaWithT, error)
+		changed     bool
+		timeout     bool
+	}{
+		{
+			name:        "drain begins",
+			connections: 2,
+			errExpectFn: errExpectRequeue,
+		},
+		{
+			name:        "connections are not closed",
+			drainedFor:  time.Minute,
+			connections: 2,
+			errExpectFn: errExpectRequeue,
+		},
+		{
+			name:        "connections are closed",
+			drainedFor:  time.Minute,
+			errExpectFn: errExpectNil,
+			changed:     true,
+		},
+		{
+			name:        "drain times out",
+			drainedFor:  time.Hour,
+			connections: 2,
+			errExpectFn: errExpectNil,
+			changed:     true,
+			timeout:     true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tc := newTidbClusterForPD()
+			tc.Spec.TiDB.ScaleInDrainTimeout = pointer.StringPtr("10m")
+
+			oldSet := newStatefulSetForPDScale()
+			newSet := oldSet.DeepCopy()
+			newSet.Spec.Replicas = pointer.Int32Ptr(4)
+
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      tidbPodName(tc.GetName(), 4),
+					Namespace: corev1.NamespaceDefault,
+				},
+			}
+			if test.drainedFor > 0 {
+				pod.Annotations = map[string]string{label.AnnDrainBeginTime: time.Now().Add(-test.drainedFor).Format(time.RFC3339)}
+			}
+
+			scaler, _, podIndexer, _ := newFakeTiDBScaler()
+			podIndexer.Add(pod)
+			tidbControl := scaler.deps.TiDBControl.(*controller.FakeTiDBControl)
+			tidbControl.Connections = map[string]int{pod.Name: test.connections}
+			recorder := scaler.deps.Recorder.(*record.FakeRecorder)
+
+			err := scaler.ScaleIn(tc, oldSet, newSet)
+			test.errExpectFn(g, err)
+			if test.changed {
+				g.Expect(int(*newSet.Spec.Replicas)).To(Equal(4))
+			} else {
+				g.Expect(int(*newSet.Spec.Replicas)).To(Equal(5))
+			}
+
+			pod, err = scaler.deps.PodLister.Pods(pod.Namespace).Get(pod.Name)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(pod.Annotations).To(HaveKey(label.AnnDrainBeginTime))
+			events := collectEvents(recorder.Events)
+			if test.timeout {
+				g.Expect(events).To(HaveLen(1))
+				g.Expect(events[0]).To(ContainSubstring("DrainTimeout"))
+			} else {
+				g.Expect(events).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestTiDBScalerClearsDrainOnCancel(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPD()
+	tc.Spec.TiDB.ScaleInDrainTimeout = pointer.StringPtr("10m")
+	oldSet := newStatefulSetForPDScale()
+	newSet := oldSet.DeepCopy()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        tidbPodName(tc.GetName(), 4),
+			Namespace:   corev1.NamespaceDefault,
+			Labels:      label.New().Instance(tc.GetInstanceName()).TiDB().Labels(),
+			Annotations: map[string]string{label.AnnDrainBeginTime: time.Now().Add(-time.Minute).Format(time.RFC3339)},
+		},
+	}
+	scaler, _, podIndexer, _ := newFakeTiDBScaler()
+	podIndexer.Add(pod)
+
+	// the scale-in is cancelled by restoring the replicas
+	g.Expect(scaler.Scale(tc, oldSet, newSet)).To(Succeed())
+	pod, err := scaler.deps.PodLister.Pods(pod.Namespace).Get(pod.Name)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pod.Annotations).NotTo(HaveKey(label.AnnDrainBeginTime))
+}
+
 func newFakeTiDBScaler(resyncDuration ...time.Duration) (*tidbScaler, cache.Indexer, cache.Indexer, *controller.FakePVCControl) {
 	fakeDeps := controller.NewFakeDependencies()
 	if len(resyncDuration) > 0 {
@@ -0,0 +1,138 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	errorutils "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog"
+)
+
+// tidbSystemVariableSyncFailedReason is the reason of the Events of the
+// failures to sync the system variables
+const tidbSystemVariableSyncFailedReason = "SystemVariableSyncFailed"
+
+// syncTiDBSystemVariables sets the global system variables of the config
+// profile, the spec and the audit log that differ in TiDB, once all the TiDB
+// members are ready. The system variables of the spec take precedence over the
+// ones of the profile, and the state of each variable is reported in the status.
+//
+// The system variables are set through SQL, so TiDB being unreachable does not
+// fail the sync of TiDB. The failures are recorded as Events instead.
+func syncTiDBSystemVariables(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	profileName := tc.Spec.TiDB.ConfigProfile
+	if profileName == "" && len(tc.Spec.TiDB.SystemVariables) == 0 && tc.Spec.TiDB.AuditLog == nil {
+		tc.Status.TiDB.SystemVariables = nil
+		return
+	}
+	if tc.Spec.Paused {
+		klog.V(4).Infof("tidb cluster %s/%s is paused, skip syncing tidb system variables", ns, tcName)
+		return
+	}
+	if !tc.TiDBAllMembersReady() {
+		klog.V(4).Infof("tidb cluster %s/%s is waiting for all tidb members to be ready to sync system variables", ns, tcName)
+		return
+	}
+	if !checkTiDBSQLSecret(deps, tc, "system variables") {
+		return
+	}
+
+	if err := updateTiDBSystemVariables(deps, tc); err != nil {
+		klog.Warning(err)
+		deps.Recorder.Event(tc, corev1.EventTypeWarning, tidbSystemVariableSyncFailedReason, err.Error())
+	}
+}
+
+func updateTiDBSystemVariables(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	profileName := tc.Spec.TiDB.ConfigProfile
+	var profile *v1alpha1.TidbClusterConfigProfile
+	if profileName != "" {
+		var err error
+		profile, err = deps.TiDBClusterConfigProfileLister.TidbClusterConfigProfiles(ns).Get(profileName)
+		if err != nil {
+			return fmt.Errorf("failed to get config profile %s of tidb cluster %s/%s, error: %v", profileName, ns, tcName, err)
+		}
+	}
+	variables, err := v1alpha1.MergeSystemVariables(profile, tc.Spec.TiDB.SystemVariables)
+	if err != nil {
+		return fmt.Errorf("invalid system variables of tidb cluster %s/%s, error: %v", ns, tcName, err)
+	}
+	// the system variables enabling the audit log can be overridden by the spec and the profile
+	for name, value := range tidbAuditLogSystemVariables(tc) {
+		if _, ok := variables[name]; !ok {
+			variables[name] = value
+		}
+	}
+
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	status := map[string]v1alpha1.SystemVariableStatus{}
+	var errs []error
+	for _, name := range names {
+		value := variables[name]
+		err := syncTiDBSystemVariable(deps, tc, name, value)
+		s := v1alpha1.SystemVariableStatus{Value: value, Synced: err == nil, LastSyncTime: metav1.Now()}
+		if err != nil {
+			s.Message = err.Error()
+			errs = append(errs, fmt.Errorf("failed to sync system variable %s of tidb cluster %s/%s, error: %v", name, ns, tcName, err))
+		}
+		status[name] = s
+	}
+	tc.Status.TiDB.SystemVariables = status
+	return errorutils.NewAggregate(errs)
+}
+
+func syncTiDBSystemVariable(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, name, value string) error {
+	current, err := deps.TiDBControl.GetSystemVariable(tc, name)
+	if err != nil {
+		return err
+	}
+	if systemVariableValueEqual(current, value) {
+		return nil
+	}
+	klog.Infof("tidb cluster %s/%s sets system variable %s from %q to %q", tc.GetNamespace(), tc.GetName(), name, current, value)
+	return deps.TiDBControl.SetSystemVariable(tc, name, value)
+}
+
+// systemVariableValueEqual returns whether the value read from TiDB matches
+// the desired one, TiDB reads the boolean variables back as ON or OFF
+func systemVariableValueEqual(current, desired string) bool {
+	normalize := func(v string) string {
+		switch strings.ToUpper(v) {
+		case "1", "TRUE":
+			return "ON"
+		case "0", "FALSE":
+			return "OFF"
+		}
+		return strings.ToUpper(v)
+	}
+	return normalize(current) == normalize(desired)
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestSyncTiDBSystemVariables(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	tidbControl := deps.TiDBControl.(*controller.FakeTiDBControl)
+	profileIndexer := deps.InformerFactory.Pingcap().V1alpha1().TidbClusterConfigProfiles().Informer().GetIndexer()
+	tc := newTidbClusterForTiDB()
+	tc.Spec.TiDB.ConfigProfile = "baseline"
+	tc.Spec.TiDB.SystemVariables = map[string]string{"TiDB_Txn_Mode": "optimistic"}
+	recorder := deps.Recorder.(*record.FakeRecorder)
+	// sync returns the events recorded
+	sync := func() []string {
+		syncTiDBSystemVariables(deps, tc)
+		return collectEvents(recorder.Events)
+	}
+
+	// nothing is applied until all tidb members are ready
+	g.Expect(sync()).To(BeEmpty())
+	g.Expect(tidbControl.SystemVariableSets).To(Equal(0))
+	g.Expect(tc.Status.TiDB.SystemVariables).To(BeNil())
+
+	tc.Status.TiDB.Members = map[string]v1alpha1.TiDBMember{}
+	for i := 0; i < int(tc.Spec.TiDB.Replicas); i++ {
+		name := fmt.Sprintf("test-tidb-%d", i)
+		tc.Status.TiDB.Members[name] = v1alpha1.TiDBMember{Name: name, Health: true}
+	}
+
+	// nothing is applied without the credentials to connect to tidb
+	g.Expect(sync()).To(ConsistOf("Warning SQLSecretNotSet sqlSecretName is not set, skip syncing system variables"))
+	g.Expect(tidbControl.SystemVariableSets).To(Equal(0))
+
+	// the profile must exist
+	tc.Spec.TiDB.SQLSecretName = "tidb-sql"
+	g.Expect(sync()).To(ConsistOf(HavePrefix("Warning SystemVariableSyncFailed failed to get config profile baseline")))
+	g.Expect(tidbControl.SystemVariableSets).To(Equal(0))
+
+	profile := &v1alpha1.TidbClusterConfigProfile{
+		ObjectMeta: metav1.ObjectMeta{Name: "baseline", Namespace: tc.GetNamespace()},
+		Spec: v1alpha1.TidbClusterConfigProfileSpec{
+			SystemVariables: map[string]string{
+				"tidb_txn_mode":            "pessimistic",
+				"tidb_enable_async_commit": "1",
+				"max_connections":          "1000",
+			},
+			LockedSystemVariables: []string{"max_connections"},
+		},
+	}
+	g.Expect(profileIndexer.Add(profile)).To(Succeed())
+	tidbControl.SystemVariables = map[string]string{"tidb_enable_async_commit": "ON"}
+
+	// tidb being unreachable is reported in the status and as an event
+	tidbControl.SQLError = fmt.Errorf("connection refused")
+	g.Expect(sync()).To(ConsistOf(HavePrefix("Warning SystemVariableSyncFailed ")))
+	g.Expect(tc.Status.TiDB.SystemVariables["tidb_txn_mode"].Synced).To(BeFalse())
+	g.Expect(tc.Status.TiDB.SystemVariables["tidb_txn_mode"].Message).To(Equal("connection refused"))
+	tidbControl.SQLError = nil
+
+	// the variables of the profile are applied, overridden by the ones of the spec
+	g.Expect(sync()).To(BeEmpty())
+	g.Expect(tidbControl.SystemVariableSets).To(Equal(2))
+	g.Expect(tidbControl.SystemVariables).To(Equal(map[string]string{
+		"tidb_txn_mode":            "optimistic",
+		"tidb_enable_async_commit": "ON",
+		"max_connections":          "1000",
+	}))
+	g.Expect(tc.Status.TiDB.SystemVariables).To(HaveLen(3))
+	g.Expect(tc.Status.TiDB.SystemVariables["tidb_txn_mode"].Value).To(Equal("optimistic"))
+	g.Expect(tc.Status.TiDB.SystemVariables["tidb_txn_mode"].Synced).To(BeTrue())
+
+	// nothing to do if the variables are in sync
+	g.Expect(sync()).To(BeEmpty())
+	g.Expect(tidbControl.SystemVariableSets).To(Equal(2))
+
+	// the drifted variable is set back
+	tidbControl.SystemVariables["max_connections"] = "10"
+	g.Expect(sync()).To(BeEmpty())
+	g.Expect(tidbControl.SystemVariableSets).To(Equal(3))
+	g.Expect(tidbControl.SystemVariables["max_connections"]).To(Equal("1000"))
+
+	// a locked variable of the profile can not be overridden
+	tc.Spec.TiDB.SystemVariables["max_connections"] = "10"
+	g.Expect(sync()).To(ConsistOf(ContainSubstring("locked by config profile baseline")))
+	g.Expect(tidbControl.SystemVariableSets).To(Equal(3))
+	g.Expect(tidbControl.SystemVariables["max_connections"]).To(Equal("1000"))
+
+	tc.Spec.TiDB.SystemVariables = nil
+	tc.Spec.TiDB.ConfigProfile = ""
+	g.Expect(sync()).To(BeEmpty())
+	g.Expect(tc.Status.TiDB.SystemVariables).To(BeNil())
+}
@@ -20,6 +20,8 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/klog"
 )
 
@@ -63,12 +65,16 @@ func (u *tidbUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSe
 		return nil
 	}
 
+	if kept, err := keepAbortedTemplate(tc, v1alpha1.TiDBMemberType, oldSet, newSet); err != nil || kept {
+		return err
+	}
 	tc.Status.TiDB.Phase = v1alpha1.UpgradePhase
 	if !templateEqual(newSet, oldSet) {
 		return nil
 	}
 
-	if tc.Status.TiDB.StatefulSet.UpdateRevision == tc.Status.TiDB.StatefulSet.CurrentRevision {
+	aborted := isUpgradeAborted(tc, v1alpha1.TiDBMemberType, tc.Status.TiDB.StatefulSet.UpdateRevision)
+	if tc.Status.TiDB.StatefulSet.UpdateRevision == tc.Status.TiDB.StatefulSet.CurrentRevision && !postUpgradeHooksPending(newSet) {
 		return nil
 	}
 
@@ -78,12 +84,26 @@ func (u *tidbUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSe
 		// If we encounter this situation, we will let the native statefulset controller do the upgrade completely, which may be unsafe for upgrading tidb.
 		// Therefore, in the production environment, we should try to avoid modifying the tidb statefulset update strategy directly.
 		newSet.Spec.UpdateStrategy = oldSet.Spec.UpdateStrategy
+		setPostUpgradeHookPods(newSet, nil)
 		klog.Warningf("tidbcluster: [%s/%s] tidb statefulset %s UpdateStrategy has been modified manually", ns, tcName, oldSet.GetName())
 		return nil
 	}
 
 	setUpgradePartition(newSet, *oldSet.Spec.UpdateStrategy.RollingUpdate.Partition)
+	if aborted {
+		klog.Infof("tidbcluster: [%s/%s]'s tidb upgrade to revision %s is aborted", ns, tcName, tc.Status.TiDB.StatefulSet.UpdateRevision)
+		setPostUpgradeHookPods(newSet, nil)
+		return rollbackUpgradedPods(u.deps, tc, v1alpha1.TiDBMemberType, oldSet, newSet, tc.Status.TiDB.StatefulSet.UpdateRevision)
+	}
+	// the canary is upgraded alone, the rest of the Pods are upgraded
+	// concurrently after it is verified
+	if tc.TiDBUpgradeConcurrency() > 1 && !tidbCanaryPending(tc, oldSet) {
+		return u.upgradeTiDBPodsConcurrently(tc, oldSet, newSet)
+	}
 	podOrdinals := helper.GetPodOrdinals(*oldSet.Spec.Replicas, oldSet).List()
+	var lastUpgradedPod *corev1.Pod
+	var lastUpgradedOrdinal int32
+	upgraded := 0
 	for _i := len(podOrdinals) - 1; _i >= 0; _i-- {
 		i := podOrdinals[_i]
 		podName := tidbPodName(tcName, i)
@@ -98,10 +118,44 @@ func (u *tidbUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSe
 
 		if revision == tc.Status.TiDB.StatefulSet.UpdateRevision {
 			if member, exist := tc.Status.TiDB.Members[podName]; !exist || !member.Health {
+				if ok, err := abortUpgradeOnIncompatibleData(u.deps, tc, pod, i, revision, newSet); err != nil || ok {
+					return err
+				}
+				if ok, err := abortUpgradeOnCrashLoop(u.deps, tc, v1alpha1.TiDBMemberType, pod, i, revision, newSet); err != nil || ok {
+					return err
+				}
+				if ok, err := rollbackUpgradeOnUnhealthy(u.deps, tc, v1alpha1.TiDBMemberType, pod, i, revision, newSet, memberUnhealthySince(member.LastTransitionTime, pod)); err != nil || ok {
+					return err
+				}
 				return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tidb upgraded pod: [%s] is not ready", ns, tcName, podName)
 			}
+			if err := runPostUpgradeHook(u.deps, tc, v1alpha1.TiDBMemberType, pod, newSet); err != nil {
+				return err
+			}
+			lastUpgradedPod = pod
+			lastUpgradedOrdinal = i
+			upgraded++
 			continue
 		}
+		if upgradePaused(tc, v1alpha1.TiDBMemberType) || waitForMaintenanceWindow(tc, v1alpha1.TiDBMemberType, "upgrade") {
+			return nil
+		}
+		if _i == len(podOrdinals)-1 {
+			if err := checkUpgradePreChecks(u.deps, tc, v1alpha1.TiDBMemberType); err != nil {
+				return err
+			}
+		}
+		if err := checkUpgradeStabilization(u.deps, tc, tc.Spec.TiDB.UpgradeStabilizationGate, lastUpgradedPod); err != nil {
+			return err
+		}
+		if upgraded == 1 {
+			if err := verifyTiDBCanary(u.deps, tc, lastUpgradedPod, lastUpgradedOrdinal); err != nil {
+				return err
+			}
+		}
+		if err := runPreUpgradeHook(u.deps, tc, v1alpha1.TiDBMemberType, pod, newSet); err != nil {
+			return err
+		}
 		return u.upgradeTiDBPod(tc, i, newSet)
 	}
 
@@ -113,6 +167,130 @@ func (u *tidbUpgrader) upgradeTiDBPod(tc *v1alpha1.TidbCluster, ordinal int32, n
 	return nil
 }
 
+// upgradeTiDBPodsConcurrently upgrades up to UpgradeConcurrency TiDB Pods at the
+// same time. The partition is lowered to cover a batch of Pods first, and the
+// Pods of the batch are evicted in the next round, once the StatefulSet
+// recreates them from the update revision. A healthy Pod is only evicted if
+// another healthy Pod is left serving, otherwise it is left to the rolling
+// update of the StatefulSet.
+func (u *tidbUpgrader) upgradeTiDBPodsConcurrently(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	concurrency := tc.TiDBUpgradeConcurrency()
+	partition := *oldSet.Spec.UpdateStrategy.RollingUpdate.Partition
+
+	var hookErr error
+	var lastUpgradedPod *corev1.Pod
+	var upgrading []*corev1.Pod
+	var pending []int32
+	pendingPods := map[int32]*corev1.Pod{}
+	upgradingCount, healthyCount := 0, 0
+	podOrdinals := helper.GetPodOrdinals(*oldSet.Spec.Replicas, oldSet).List()
+	for _i := len(podOrdinals) - 1; _i >= 0; _i-- {
+		i := podOrdinals[_i]
+		podName := tidbPodName(tcName, i)
+		pod, err := u.deps.PodLister.Pods(ns).Get(podName)
+		if err != nil {
+			return fmt.Errorf("tidbUpgrader.Upgrade: failed to get pods %s for cluster %s/%s, error: %s", podName, ns, tcName, err)
+		}
+		revision, exist := pod.Labels[apps.ControllerRevisionHashLabelKey]
+		if !exist {
+			return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tidb pod: [%s] has no label: %s", ns, tcName, podName, apps.ControllerRevisionHashLabelKey)
+		}
+		healthy := tidbPodHealthy(tc, pod)
+		if healthy {
+			healthyCount++
+		}
+
+		switch {
+		case revision == tc.Status.TiDB.StatefulSet.UpdateRevision:
+			if !healthy {
+				if ok, err := abortUpgradeOnIncompatibleData(u.deps, tc, pod, i, revision, newSet); err != nil || ok {
+					return err
+				}
+				if ok, err := abortUpgradeOnCrashLoop(u.deps, tc, v1alpha1.TiDBMemberType, pod, i, revision, newSet); err != nil || ok {
+					return err
+				}
+				if ok, err := rollbackUpgradeOnUnhealthy(u.deps, tc, v1alpha1.TiDBMemberType, pod, i, revision, newSet, memberUnhealthySince(tc.Status.TiDB.Members[podName].LastTransitionTime, pod)); err != nil || ok {
+					return err
+				}
+				upgradingCount++
+				continue
+			}
+			// the next batch waits for the PostUpgrade hooks, the Pods
+			// being upgraded are not blocked by them
+			if err := runPostUpgradeHook(u.deps, tc, v1alpha1.TiDBMemberType, pod, newSet); err != nil && hookErr == nil {
+				hookErr = err
+			}
+			lastUpgradedPod = pod
+		case i >= partition:
+			// the Pod is in the batch being upgraded
+			upgradingCount++
+			if pod.DeletionTimestamp == nil {
+				upgrading = append(upgrading, pod)
+			}
+		default:
+			pending = append(pending, i)
+			pendingPods[i] = pod
+		}
+	}
+
+	for _, pod := range upgrading {
+		if tidbPodHealthy(tc, pod) {
+			if healthyCount <= 1 {
+				klog.Infof("tidbcluster: [%s/%s]'s tidb pod: [%s] is the last healthy pod, leave it to the rolling update", ns, tcName, pod.GetName())
+				continue
+			}
+			healthyCount--
+		}
+		if err := u.deps.PodControl.EvictPod(tc, pod); err != nil {
+			if errors.IsTooManyRequests(err) {
+				return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tidb pod: [%s] can not be evicted due to the disruption budget: %v", ns, tcName, pod.GetName(), err)
+			}
+			return fmt.Errorf("tidbUpgrader.Upgrade: failed to evict pod %s for cluster %s/%s, error: %s", pod.GetName(), ns, tcName, err)
+		}
+		klog.Infof("tidbcluster: [%s/%s]'s tidb pod: [%s] is evicted to be upgraded", ns, tcName, pod.GetName())
+	}
+
+	if hookErr != nil {
+		return hookErr
+	}
+	if len(pending) == 0 || upgradePaused(tc, v1alpha1.TiDBMemberType) || waitForMaintenanceWindow(tc, v1alpha1.TiDBMemberType, "upgrade") {
+		return nil
+	}
+	if upgradingCount >= concurrency {
+		return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tidb is upgrading %d pods", ns, tcName, upgradingCount)
+	}
+	if len(pending) == len(podOrdinals) {
+		if err := checkUpgradePreChecks(u.deps, tc, v1alpha1.TiDBMemberType); err != nil {
+			return err
+		}
+	}
+	if err := checkUpgradeStabilization(u.deps, tc, tc.Spec.TiDB.UpgradeStabilizationGate, lastUpgradedPod); err != nil {
+		return err
+	}
+	batch := concurrency - upgradingCount
+	if batch > len(pending) {
+		batch = len(pending)
+	}
+	// the PreUpgrade hooks of the batch are run at the same time
+	for _, i := range pending[:batch] {
+		if err := runPreUpgradeHook(u.deps, tc, v1alpha1.TiDBMemberType, pendingPods[i], newSet); err != nil && hookErr == nil {
+			hookErr = err
+		}
+	}
+	if hookErr != nil {
+		return hookErr
+	}
+	return u.upgradeTiDBPod(tc, pending[batch-1], newSet)
+}
+
+// tidbPodHealthy returns whether the TiDB Pod is healthy and not being deleted
+func tidbPodHealthy(tc *v1alpha1.TidbCluster, pod *corev1.Pod) bool {
+	member, exist := tc.Status.TiDB.Members[pod.GetName()]
+	return exist && member.Health && pod.DeletionTimestamp == nil
+}
+
 type fakeTiDBUpgrader struct{}
 
 // NewFakeTiDBUpgrader returns a fake tidb upgrader
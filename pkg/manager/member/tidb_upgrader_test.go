@@ -14,17 +14,22 @@
 package member
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
 	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	podinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 )
 
@@ -37,17 +42,26 @@ func TestTiDBUpgrader_Upgrade(t *testing.T) {
 		getLastAppliedConfigErr bool
 		errorExpect             bool
 		changeOldSet            func(set *apps.StatefulSet)
+		changePods              func(pods []*corev1.Pod)
+		metricValues            map[string]float64
 		expectFn                func(g *GomegaWithT, tc *v1alpha1.TidbCluster, newSet *apps.StatefulSet)
 	}
 
 	testFn := func(test *testcase, t *testing.T) {
 		t.Log(test.name)
 		upgrader, _, podInformer := newTiDBUpgrader()
+		promControl := upgrader.(*tidbUpgrader).deps.PrometheusControl.(*controller.FakePrometheusControl)
+		for query, value := range test.metricValues {
+			promControl.SetValue(query, value)
+		}
 		tc := newTidbClusterForTiDBUpgrader()
 		if test.changeFn != nil {
 			test.changeFn(tc)
 		}
 		pods := getTiDBPods()
+		if test.changePods != nil {
+			test.changePods(pods)
+		}
 		for _, pod := range pods {
 			podInformer.Informer().GetIndexer().Add(pod)
 		}
@@ -203,6 +217,128 @@ func TestTiDBUpgrader_Upgrade(t *testing.T) {
 				g.Expect(newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(pointer.Int32Ptr(1)))
 			},
 		},
+		{
+			name: "metric of upgraded pod is stabilized",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.PD.Phase = v1alpha1.NormalPhase
+				tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+				tc.Spec.TiDB.UpgradeStabilizationGate = &v1alpha1.MetricStabilizationGate{
+					PrometheusURL: "http://prometheus:9090",
+					Query:         `tidb_query_duration{pod="$POD_NAME"}`,
+					Threshold:     "0.5",
+				}
+			},
+			metricValues: map[string]float64{`tidb_query_duration{pod="upgrader-tidb-1"}`: 0.2},
+			expectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster, newSet *apps.StatefulSet) {
+				g.Expect(newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(pointer.Int32Ptr(0)))
+			},
+		},
+		{
+			name: "metric of upgraded pod is not stabilized",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.PD.Phase = v1alpha1.NormalPhase
+				tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+				tc.Spec.TiDB.UpgradeStabilizationGate = &v1alpha1.MetricStabilizationGate{
+					PrometheusURL: "http://prometheus:9090",
+					Query:         `tidb_query_duration{pod="$POD_NAME"}`,
+					Threshold:     "0.5",
+				}
+			},
+			metricValues: map[string]float64{`tidb_query_duration{pod="upgrader-tidb-1"}`: 1.5},
+			errorExpect:  true,
+			expectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster, newSet *apps.StatefulSet) {
+				g.Expect(newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(pointer.Int32Ptr(1)))
+			},
+		},
+		{
+			name: "upgraded pod is in CrashLoopBackOff for longer than the threshold",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.PD.Phase = v1alpha1.NormalPhase
+				tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+				tc.Spec.UpgradeCrashLoopPolicy = &v1alpha1.UpgradeCrashLoopPolicy{Threshold: pointer.StringPtr("5m")}
+				member := tc.Status.TiDB.Members[tidbPodName(upgradeTcName, 1)]
+				member.Health = false
+				tc.Status.TiDB.Members[tidbPodName(upgradeTcName, 1)] = member
+			},
+			changePods:  crashLoopTiDBPod(10 * time.Minute),
+			errorExpect: false,
+			expectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster, newSet *apps.StatefulSet) {
+				g.Expect(newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(pointer.Int32Ptr(1)))
+				g.Expect(tc.Status.AbortedUpgrades).To(HaveKey(v1alpha1.TiDBMemberType))
+				g.Expect(tc.Status.AbortedUpgrades[v1alpha1.TiDBMemberType].Revision).To(Equal("2"))
+				g.Expect(tc.Status.AbortedUpgrades[v1alpha1.TiDBMemberType].RolledBack).To(BeFalse())
+				cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterUpgradeAborted)
+				g.Expect(cond).NotTo(BeNil())
+				g.Expect(cond.Status).To(Equal(corev1.ConditionTrue))
+			},
+		},
+		{
+			name: "upgraded pod in CrashLoopBackOff is rolled back",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.PD.Phase = v1alpha1.NormalPhase
+				tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+				tc.Spec.UpgradeCrashLoopPolicy = &v1alpha1.UpgradeCrashLoopPolicy{Threshold: pointer.StringPtr("5m"), Rollback: true}
+				member := tc.Status.TiDB.Members[tidbPodName(upgradeTcName, 1)]
+				member.Health = false
+				tc.Status.TiDB.Members[tidbPodName(upgradeTcName, 1)] = member
+			},
+			changePods:  crashLoopTiDBPod(10 * time.Minute),
+			errorExpect: false,
+			expectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster, newSet *apps.StatefulSet) {
+				g.Expect(newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(pointer.Int32Ptr(2)))
+				g.Expect(tc.Status.AbortedUpgrades[v1alpha1.TiDBMemberType].RolledBack).To(BeTrue())
+			},
+		},
+		{
+			name: "upgraded pod is in CrashLoopBackOff within the threshold",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.PD.Phase = v1alpha1.NormalPhase
+				tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+				tc.Spec.UpgradeCrashLoopPolicy = &v1alpha1.UpgradeCrashLoopPolicy{Threshold: pointer.StringPtr("5m")}
+				member := tc.Status.TiDB.Members[tidbPodName(upgradeTcName, 1)]
+				member.Health = false
+				tc.Status.TiDB.Members[tidbPodName(upgradeTcName, 1)] = member
+			},
+			changePods:  crashLoopTiDBPod(time.Minute),
+			errorExpect: true,
+			expectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster, newSet *apps.StatefulSet) {
+				g.Expect(newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(pointer.Int32Ptr(1)))
+				g.Expect(tc.Status.AbortedUpgrades).To(BeEmpty())
+			},
+		},
+		{
+			name: "upgrade to the revision is aborted",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.PD.Phase = v1alpha1.NormalPhase
+				tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+				tc.Status.AbortedUpgrades = map[v1alpha1.MemberType]v1alpha1.AbortedUpgrade{
+					v1alpha1.TiDBMemberType: {PodName: tidbPodName(upgradeTcName, 1), Revision: "2"},
+				}
+			},
+			errorExpect: false,
+			expectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster, newSet *apps.StatefulSet) {
+				g.Expect(newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(pointer.Int32Ptr(1)))
+				g.Expect(tc.Status.AbortedUpgrades).To(HaveKey(v1alpha1.TiDBMemberType))
+			},
+		},
+		{
+			name: "upgrade aborted at another revision is retried",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.PD.Phase = v1alpha1.NormalPhase
+				tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+				tc.Status.AbortedUpgrades = map[v1alpha1.MemberType]v1alpha1.AbortedUpgrade{
+					v1alpha1.TiDBMemberType: {PodName: tidbPodName(upgradeTcName, 1), Revision: "3"},
+				}
+				utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+					v1alpha1.TidbClusterUpgradeAborted, corev1.ConditionTrue, upgradeAbortedReason, ""))
+			},
+			errorExpect: false,
+			expectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster, newSet *apps.StatefulSet) {
+				g.Expect(newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(pointer.Int32Ptr(0)))
+				g.Expect(tc.Status.AbortedUpgrades).To(BeEmpty())
+				g.Expect(utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterUpgradeAborted)).To(BeNil())
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -211,6 +347,227 @@ func TestTiDBUpgrader_Upgrade(t *testing.T) {
 
 }
 
+func TestTiDBUpgraderConcurrency(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	upgrader, _, podInformer := newTiDBUpgrader()
+	podControl := upgrader.(*tidbUpgrader).deps.PodControl.(*controller.FakePodControl)
+	tc := newTidbClusterForTiDBUpgrader()
+	tc.Spec.TiDB.Replicas = 4
+	tc.Spec.TiDB.UpgradeConcurrency = pointer.Int32Ptr(2)
+	tc.Status.PD.Phase = v1alpha1.NormalPhase
+	tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+	oldSet := newStatefulSetForTiDBUpgrader()
+	oldSet.Spec.Replicas = pointer.Int32Ptr(4)
+	SetStatefulSetLastAppliedConfigAnnotation(oldSet)
+
+	setPod := func(ordinal int32, revision string, healthy bool) {
+		name := tidbPodName(upgradeTcName, ordinal)
+		l := label.New().Instance(upgradeInstanceName).TiDB().Labels()
+		l[apps.ControllerRevisionHashLabelKey] = revision
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: corev1.NamespaceDefault, Labels: l}}
+		g.Expect(podInformer.Informer().GetIndexer().Add(pod)).To(Succeed())
+		tc.Status.TiDB.Members[name] = v1alpha1.TiDBMember{Name: name, Health: healthy}
+	}
+	podExists := func(ordinal int32) bool {
+		_, err := podInformer.Lister().Pods(corev1.NamespaceDefault).Get(tidbPodName(upgradeTcName, ordinal))
+		return err == nil
+	}
+	upgrade := func(partition int32) (*apps.StatefulSet, error) {
+		oldSet.Spec.UpdateStrategy.RollingUpdate.Partition = pointer.Int32Ptr(partition)
+		newSet := oldSet.DeepCopy()
+		return newSet, upgrader.Upgrade(tc, oldSet, newSet)
+	}
+	for i := int32(0); i < 4; i++ {
+		setPod(i, "1", true)
+	}
+
+	// the partition is lowered to cover a batch of pods
+	newSet, err := upgrade(4)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(2)))
+
+	// the pods of the batch are evicted
+	newSet, err = upgrade(2)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(2)))
+	g.Expect(podExists(3)).To(BeFalse())
+	g.Expect(podExists(2)).To(BeFalse())
+	g.Expect(podExists(1)).To(BeTrue())
+
+	// the next batch waits for the upgraded pods to be healthy
+	setPod(3, "2", false)
+	setPod(2, "2", false)
+	newSet, err = upgrade(2)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(2)))
+
+	setPod(3, "2", true)
+	setPod(2, "2", true)
+	newSet, err = upgrade(2)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(0)))
+
+	// the eviction is retried if it violates the disruption budget
+	podControl.SetEvictPodError(apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0), 0)
+	_, err = upgrade(0)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(podExists(1)).To(BeTrue())
+
+	// the last healthy pod is not evicted
+	setPod(3, "2", false)
+	setPod(2, "2", false)
+	_, err = upgrade(0)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(podExists(1)).To(BeFalse())
+	g.Expect(podExists(0)).To(BeTrue())
+}
+
+func TestTiDBUpgraderCanary(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	upgrader, tidbControl, podInformer := newTiDBUpgrader()
+	tc := newTidbClusterForTiDBUpgrader()
+	tc.Spec.TiDB.Replicas = 3
+	tc.Spec.TiDB.UpgradeConcurrency = pointer.Int32Ptr(2)
+	tc.Spec.TiDB.UpgradePolicy = &v1alpha1.TiDBUpgradePolicy{
+		Canary: &v1alpha1.TiDBCanaryPolicy{
+			PauseDuration: pointer.StringPtr("5m"),
+			SQLProbe:      "SELECT 1",
+			HTTPProbePath: "/status",
+		},
+	}
+	tc.Status.PD.Phase = v1alpha1.NormalPhase
+	tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+	oldSet := newStatefulSetForTiDBUpgrader()
+	oldSet.Spec.Replicas = pointer.Int32Ptr(3)
+	SetStatefulSetLastAppliedConfigAnnotation(oldSet)
+
+	setPod := func(ordinal int32, revision string, readySince time.Duration) {
+		name := tidbPodName(upgradeTcName, ordinal)
+		l := label.New().Instance(upgradeInstanceName).TiDB().Labels()
+		l[apps.ControllerRevisionHashLabelKey] = revision
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: corev1.NamespaceDefault, Labels: l}}
+		pod.Status.Conditions = []corev1.PodCondition{
+			{Type: corev1.PodReady, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(time.Now().Add(-readySince))},
+		}
+		g.Expect(podInformer.Informer().GetIndexer().Add(pod)).To(Succeed())
+		tc.Status.TiDB.Members[name] = v1alpha1.TiDBMember{Name: name, Health: true}
+	}
+	upgrade := func(partition int32) (*apps.StatefulSet, error) {
+		oldSet.Spec.UpdateStrategy.RollingUpdate.Partition = pointer.Int32Ptr(partition)
+		newSet := oldSet.DeepCopy()
+		return newSet, upgrader.Upgrade(tc, oldSet, newSet)
+	}
+	for i := int32(0); i < 3; i++ {
+		setPod(i, "1", time.Hour)
+	}
+
+	// the canary is upgraded alone even if the upgrade is concurrent
+	newSet, err := upgrade(3)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(2)))
+
+	// the upgrade is paused until the canary has been ready for the pause duration
+	setPod(2, "2", time.Minute)
+	newSet, err = upgrade(2)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(2)))
+	g.Expect(tidbControl.Probes).To(BeEmpty())
+
+	// the upgrade is paused while the canary fails the probes
+	setPod(2, "2", 10*time.Minute)
+	tidbControl.ProbeError = fmt.Errorf("connection refused")
+	newSet, err = upgrade(2)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(2)))
+	g.Expect(tidbControl.Probes).To(Equal([]string{"SELECT 1"}))
+	cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterTiDBCanaryFailed)
+	g.Expect(cond).NotTo(BeNil())
+	g.Expect(cond.Status).To(Equal(corev1.ConditionTrue))
+	g.Expect(cond.Message).To(Equal("sql probe of canary pod upgrader-tidb-2 failed, the upgrade is paused: connection refused"))
+	// the same failure is reported by a single event
+	_, err = upgrade(2)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	recorder := upgrader.(*tidbUpgrader).deps.Recorder.(*record.FakeRecorder)
+	g.Expect(collectEvents(recorder.Events)).To(HaveLen(1))
+	// a different failure updates the message of the condition
+	tidbControl.ProbeError = fmt.Errorf("access denied")
+	tidbControl.Probes = nil
+	_, err = upgrade(2)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	cond = utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterTiDBCanaryFailed)
+	g.Expect(cond.Message).To(Equal("sql probe of canary pod upgrader-tidb-2 failed, the upgrade is paused: access denied"))
+	g.Expect(collectEvents(recorder.Events)).To(HaveLen(1))
+
+	// the rest of the pods are upgraded concurrently after the canary is verified
+	tidbControl.ProbeError = nil
+	tidbControl.Probes = nil
+	newSet, err = upgrade(2)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(1)))
+	g.Expect(tidbControl.Probes).To(Equal([]string{"SELECT 1", "/status"}))
+	cond = utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterTiDBCanaryFailed)
+	g.Expect(cond.Status).To(Equal(corev1.ConditionFalse))
+
+	setPod(1, "2", time.Minute)
+	newSet, err = upgrade(1)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(0)))
+}
+
+func TestTiDBUpgraderPaused(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	upgrader, _, podInformer := newTiDBUpgrader()
+	tc := newTidbClusterForTiDBUpgrader()
+	tc.Spec.TiDB.Replicas = 3
+	tc.Status.PD.Phase = v1alpha1.NormalPhase
+	tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+	oldSet := newStatefulSetForTiDBUpgrader()
+	oldSet.Spec.Replicas = pointer.Int32Ptr(3)
+	oldSet.Spec.UpdateStrategy.RollingUpdate.Partition = pointer.Int32Ptr(2)
+	SetStatefulSetLastAppliedConfigAnnotation(oldSet)
+
+	for i, revision := range []string{"1", "1", "2"} {
+		name := tidbPodName(upgradeTcName, int32(i))
+		l := label.New().Instance(upgradeInstanceName).TiDB().Labels()
+		l[apps.ControllerRevisionHashLabelKey] = revision
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: corev1.NamespaceDefault, Labels: l}}
+		g.Expect(podInformer.Informer().GetIndexer().Add(pod)).To(Succeed())
+		tc.Status.TiDB.Members[name] = v1alpha1.TiDBMember{Name: name, Health: true}
+	}
+
+	// the partition is frozen while the upgrade is paused
+	tc.Spec.TiDB.UpgradePaused = true
+	newSet := oldSet.DeepCopy()
+	g.Expect(upgrader.Upgrade(tc, oldSet, newSet)).To(Succeed())
+	g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(2)))
+	g.Expect(tc.Status.TiDB.Phase).To(Equal(v1alpha1.UpgradePhase))
+
+	// the upgrade goes on once it is resumed
+	tc.Spec.TiDB.UpgradePaused = false
+	newSet = oldSet.DeepCopy()
+	g.Expect(upgrader.Upgrade(tc, oldSet, newSet)).To(Succeed())
+	g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(1)))
+}
+
+func crashLoopTiDBPod(since time.Duration) func(pods []*corev1.Pod) {
+	return func(pods []*corev1.Pod) {
+		for _, pod := range pods {
+			if pod.GetName() != tidbPodName(upgradeTcName, 1) {
+				continue
+			}
+			pod.Status.Conditions = []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: corev1.ConditionFalse, LastTransitionTime: metav1.NewTime(time.Now().Add(-since))},
+			}
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+				{Name: "tidb", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: crashLoopBackOffReason}}},
+			}
+		}
+	}
+}
+
 func newTiDBUpgrader() (Upgrader, *controller.FakeTiDBControl, podinformers.PodInformer) {
 	fakeDeps := controller.NewFakeDependencies()
 	upgrader := &tidbUpgrader{fakeDeps}
@@ -336,3 +693,30 @@ func getTiDBPods() []*corev1.Pod {
 	}
 	return pods
 }
+
+func TestVerifyTiDBCanaryResolvesStaleFailure(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	tc := newTidbClusterForTiDBUpgrader()
+	tc.Spec.TiDB.UpgradePolicy = &v1alpha1.TiDBUpgradePolicy{Canary: &v1alpha1.TiDBCanaryPolicy{}}
+	failedAt := time.Now().Add(-time.Hour)
+	tc.Status.Conditions = []v1alpha1.TidbClusterCondition{{
+		Type:               v1alpha1.TidbClusterTiDBCanaryFailed,
+		Status:             corev1.ConditionTrue,
+		Reason:             tidbCanaryFailedReason,
+		LastTransitionTime: metav1.NewTime(failedAt),
+	}}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:              tidbPodName(upgradeTcName, 2),
+		Namespace:         corev1.NamespaceDefault,
+		CreationTimestamp: metav1.NewTime(failedAt.Add(time.Minute)),
+	}}
+
+	// the canary recreated after the failure is not ready yet
+	err := verifyTiDBCanary(deps, tc, pod, 2)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterTiDBCanaryFailed)
+	g.Expect(cond.Status).To(Equal(corev1.ConditionFalse))
+	g.Expect(cond.Reason).To(Equal(tidbCanaryStaleReason))
+}
@@ -60,7 +60,7 @@ func (f *tiflashFailover) Failover(tc *v1alpha1.TidbCluster) error {
 			// (before it enters into Offline/Tombstone state)
 			continue
 		}
-		deadline := store.LastTransitionTime.Add(f.deps.CLIConfig.TiFlashFailoverPeriod)
+		deadline := store.LastTransitionTime.Add(tc.FailoverPeriod(v1alpha1.TiFlashMemberType, f.deps.CLIConfig.TiFlashFailoverPeriod))
 		exist := false
 		for _, failureStore := range tc.Status.TiFlash.FailureStores {
 			if failureStore.PodName == podName {
@@ -68,14 +68,14 @@ func (f *tiflashFailover) Failover(tc *v1alpha1.TidbCluster) error {
 				break
 			}
 		}
-		if store.State == v1alpha1.TiKVStateDown && time.Now().After(deadline) && !exist {
+		if storeNeedsFailover(store.State) && time.Now().After(deadline) && !exist {
 			if tc.Status.TiFlash.FailureStores == nil {
 				tc.Status.TiFlash.FailureStores = map[string]v1alpha1.TiKVFailureStore{}
 			}
-			if tc.Spec.TiFlash.MaxFailoverCount != nil && *tc.Spec.TiFlash.MaxFailoverCount > 0 {
-				maxFailoverCount := *tc.Spec.TiFlash.MaxFailoverCount
+			if count := tc.MaxFailoverCount(v1alpha1.TiFlashMemberType); count != nil && *count > 0 {
+				maxFailoverCount := *count
 				if len(tc.Status.TiFlash.FailureStores) >= int(maxFailoverCount) {
-					klog.Warningf("%s/%s TiFlash failure stores count reached the limit: %d", ns, tcName, tc.Spec.TiFlash.MaxFailoverCount)
+					klog.Warningf("%s/%s TiFlash failure stores count reached the limit: %d", ns, tcName, maxFailoverCount)
 					return nil
 				}
 				tc.Status.TiFlash.FailureStores[storeID] = v1alpha1.TiKVFailureStore{
@@ -85,6 +85,13 @@ func (f *tiflashFailover) Failover(tc *v1alpha1.TidbCluster) error {
 				}
 				msg := fmt.Sprintf("store [%s] is Down", store.ID)
 				f.deps.Recorder.Event(tc, corev1.EventTypeWarning, unHealthEventReason, fmt.Sprintf(unHealthEventMsgPattern, "tiflash", podName, msg))
+				f.deps.FailoverNotifier.Notify(tc, controller.FailoverEvent{
+					Type:          controller.FailoverEventMemberFailed,
+					Component:     v1alpha1.TiFlashMemberType.String(),
+					Member:        podName,
+					Message:       msg,
+					EventRecorded: true,
+				})
 			}
 		}
 	}
@@ -103,6 +110,13 @@ func (f *tiflashFailover) RemoveUndesiredFailures(tc *v1alpha1.TidbCluster) {
 }
 
 func (f *tiflashFailover) Recover(tc *v1alpha1.TidbCluster) {
+	if len(tc.Status.TiFlash.FailureStores) > 0 {
+		f.deps.FailoverNotifier.Notify(tc, controller.FailoverEvent{
+			Type:      controller.FailoverEventRecovered,
+			Component: v1alpha1.TiFlashMemberType.String(),
+			Message:   fmt.Sprintf("%d failure stores are recovered", len(tc.Status.TiFlash.FailureStores)),
+		})
+	}
 	tc.Status.TiFlash.FailureStores = nil
 	klog.Infof("TiFlash recover: clear FailureStores, %s/%s", tc.GetNamespace(), tc.GetName())
 }
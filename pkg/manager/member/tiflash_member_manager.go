@@ -81,7 +81,12 @@ func (m *tiflashMemberManager) Sync(tc *v1alpha1.TidbCluster) error {
 		return err
 	}
 
-	return m.syncStatefulSet(tc)
+	if err = m.syncStatefulSet(tc); err != nil {
+		return err
+	}
+
+	syncTiFlashTableReplicas(m.deps, tc)
+	return nil
 }
 
 func (m *tiflashMemberManager) enablePlacementRules(tc *v1alpha1.TidbCluster) error {
@@ -174,7 +179,7 @@ func (m *tiflashMemberManager) syncStatefulSet(tc *v1alpha1.TidbCluster) error {
 		m.failover.RemoveUndesiredFailures(tc)
 	}
 	if len(tc.Status.TiFlash.FailureStores) > 0 &&
-		tc.Spec.TiFlash.RecoverFailover &&
+		tc.RecoverFailover(v1alpha1.TiFlashMemberType) &&
 		shouldRecover(tc, label.TiFlashLabelVal, m.deps.PodLister) {
 		m.failover.Recover(tc)
 	}
@@ -183,6 +188,10 @@ func (m *tiflashMemberManager) syncStatefulSet(tc *v1alpha1.TidbCluster) error {
 	if err != nil {
 		return err
 	}
+	if err := mutatePodTemplate(m.deps, tc, v1alpha1.TiFlashMemberType, newSet); err != nil {
+		return err
+	}
+	holdComponentRestartAt(tc, v1alpha1.TiFlashMemberType, newSet, oldSet)
 	if setNotExist {
 		if !tc.PDIsAvailable() {
 			klog.Infof("TidbCluster: %s/%s, waiting for PD cluster running", ns, tcName)
@@ -200,6 +209,9 @@ func (m *tiflashMemberManager) syncStatefulSet(tc *v1alpha1.TidbCluster) error {
 		return nil
 	}
 
+	// the Pods whose post-upgrade hooks are not run yet are kept in the new StatefulSet
+	keepPostUpgradeHookPods(oldSet, newSet)
+
 	if _, err := m.setStoreLabelsForTiFlash(tc); err != nil {
 		return err
 	}
@@ -213,21 +225,38 @@ func (m *tiflashMemberManager) syncStatefulSet(tc *v1alpha1.TidbCluster) error {
 		return err
 	}
 
-	if m.deps.CLIConfig.AutoFailover && tc.Spec.TiFlash.MaxFailoverCount != nil {
+	// Report the Pods stuck in Pending and the Pods restarted too often
+	if err := syncRolloutStall(m.deps, tc, v1alpha1.TiFlashMemberType); err != nil {
+		return err
+	}
+	if err := syncPodRestartFlapping(m.deps, tc, v1alpha1.TiFlashMemberType); err != nil {
+		return err
+	}
+
+	if m.deps.CLIConfig.AutoFailover && tc.MaxFailoverCount(v1alpha1.TiFlashMemberType) != nil && !tc.FailoverPausedByRestarts(v1alpha1.TiFlashMemberType) {
 		if tc.TiFlashAllPodsStarted() && !tc.TiFlashAllStoresReady() {
 			if err := m.failover.Failover(tc); err != nil {
 				return err
 			}
 		}
+		// not gated by the readiness, as the failure store leaves the stores once it becomes tombstone
+		if err := recreateFailureStores(m.deps, tc, v1alpha1.TiFlashMemberType); err != nil {
+			return err
+		}
 	}
 
 	if !templateEqual(newSet, oldSet) || tc.Status.TiFlash.Phase == v1alpha1.UpgradePhase {
 		if err := m.upgrader.Upgrade(tc, oldSet, newSet); err != nil {
 			return err
 		}
+	} else if tc.Status.TiFlash.Phase == v1alpha1.NormalPhase {
+		// Restart the Pods requested by the restart-at annotation when no upgrade or scaling is in progress
+		if err := syncPodRestarts(m.deps, tc, v1alpha1.TiFlashMemberType); err != nil {
+			return err
+		}
 	}
 
-	return UpdateStatefulSet(m.deps.StatefulSetControl, tc, newSet, oldSet)
+	return updateStatefulSetAndRunPostScaleHook(m.deps, tc, v1alpha1.TiFlashMemberType, newSet, oldSet)
 }
 
 func (m *tiflashMemberManager) syncConfigMap(tc *v1alpha1.TidbCluster, set *apps.StatefulSet) (*corev1.ConfigMap, error) {
@@ -481,9 +510,6 @@ sed -i s/PD_ADDR/${result}/g /data0/proxy.toml
 		Image:           tc.TiFlashImage(),
 		ImagePullPolicy: baseTiFlashSpec.ImagePullPolicy(),
 		Command:         []string{"/bin/sh", "-c", "/tiflash/tiflash server --config-file /data0/config.toml"},
-		SecurityContext: &corev1.SecurityContext{
-			Privileged: tc.TiFlashContainerPrivilege(),
-		},
 		Ports: []corev1.ContainerPort{
 			{
 				Name:          "tiflash",
@@ -517,7 +543,7 @@ sed -i s/PD_ADDR/${result}/g /data0/proxy.toml
 			},
 		},
 		VolumeMounts: volMounts,
-		Resources:    controller.ContainerResource(tc.Spec.TiFlash.ResourceRequirements),
+		Resources:    controller.ContainerResourceWithEphemeralStorage(tc.Spec.TiFlash.ResourceRequirements, tc.Spec.TiFlash.EphemeralStorage),
 	}
 	podSpec := baseTiFlashSpec.BuildPodSpec()
 	if baseTiFlashSpec.HostNetwork() {
@@ -541,6 +567,7 @@ sed -i s/PD_ADDR/${result}/g /data0/proxy.toml
 	}
 	podSpec.Containers = append([]corev1.Container{tiflashContainer}, containers...)
 	podSpec.Containers = append(podSpec.Containers, baseTiFlashSpec.AdditionalContainers()...)
+	setMainContainerSecurityContext(&podSpec, baseTiFlashSpec, v1alpha1.TiFlashMemberType.String(), tc.TiFlashContainerPrivilege())
 	podSpec.ServiceAccountName = tc.Spec.TiFlash.ServiceAccount
 	if podSpec.ServiceAccountName == "" {
 		podSpec.ServiceAccountName = tc.Spec.ServiceAccount
@@ -657,6 +684,10 @@ func (m *tiflashMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, s
 		tc.Status.TiFlash.Phase = v1alpha1.NormalPhase
 	}
 
+	if err := syncUpgradeProgress(m.deps.PodLister, tc, v1alpha1.TiFlashMemberType, set, tc.Status.TiFlash.Phase, &tc.Status.TiFlash.Upgrade); err != nil {
+		return err
+	}
+
 	previousStores := tc.Status.TiFlash.Stores
 	previousPeerStores := tc.Status.TiFlash.PeerStores
 	stores := map[string]v1alpha1.TiKVStore{}
@@ -691,6 +722,11 @@ func (m *tiflashMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, s
 		if exist && status.State == oldStore.State {
 			status.LastTransitionTime = oldStore.LastTransitionTime
 		}
+		// the decommission progress is recorded by the scaler, keep it until
+		// the store becomes tombstone or is brought up again
+		if exist && status.State == v1alpha1.TiKVStateOffline {
+			status.DecommissionProgress = oldStore.DecommissionProgress
+		}
 
 		if store.Store != nil {
 			if pattern.Match([]byte(store.Store.Address)) {
@@ -728,6 +764,7 @@ func (m *tiflashMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, s
 	if c != nil {
 		tc.Status.TiFlash.Image = c.Image
 	}
+	syncComponentTopology(m.deps, tc, v1alpha1.TiFlashMemberType, storeIDsByPodName(stores))
 	return nil
 }
 
@@ -833,7 +870,7 @@ func (m *tiflashMemberManager) storeLabelsEqualNodeLabels(storeLabels []*metapb.
 }
 
 func tiflashStatefulSetIsUpgrading(podLister corelisters.PodLister, pdControl pdapi.PDControlInterface, set *apps.StatefulSet, tc *v1alpha1.TidbCluster) (bool, error) {
-	if statefulSetIsUpgrading(set) {
+	if statefulSetIsUpgrading(set) || postUpgradeHooksPending(set) {
 		return true, nil
 	}
 	instanceName := tc.GetInstanceName()
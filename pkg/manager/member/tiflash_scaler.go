@@ -33,14 +33,43 @@ type tiflashScaler struct {
 
 // NewTiFlashScaler returns a tiflash Scaler
 func NewTiFlashScaler(deps *controller.Dependencies) Scaler {
-	return &tiflashScaler{generalScaler: generalScaler{deps: deps}}
+	return withScaleMetrics(v1alpha1.TiFlashMemberType, &tiflashScaler{generalScaler: generalScaler{deps: deps}})
 }
 
 func (s *tiflashScaler) Scale(meta metav1.Object, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	// the dry run of a scale changes nothing, including the cleanups below
+	if s.scaleDryRun(meta, v1alpha1.TiFlashMemberType, oldSet, newSet) {
+		return nil
+	}
+	if err := clearCancelledPreScaleInHooks(s.deps, meta, newSet); err != nil {
+		return err
+	}
+	if err := skipScaleInProtectedPods(s.deps, meta, v1alpha1.TiFlashMemberType, oldSet, newSet); err != nil {
+		return err
+	}
+	if s.deferScaleToMaintenanceWindow(meta, v1alpha1.TiFlashMemberType, oldSet, newSet) {
+		return nil
+	}
+	if deferred, err := s.deferScale(meta, v1alpha1.TiFlashMemberType, oldSet, newSet); err != nil || deferred {
+		return err
+	}
+	if err := s.checkScaleVelocity(meta, v1alpha1.TiFlashMemberType, oldSet, newSet); err != nil {
+		return err
+	}
 	scaling, _, _, _ := scaleOne(oldSet, newSet)
+	if scaling != 0 {
+		if err := checkPDSplitBrain(meta, oldSet, newSet); err != nil {
+			return err
+		}
+	}
 	if scaling > 0 {
+		if err := checkResourceQuota(s.deps, meta, oldSet, newSet); err != nil {
+			return err
+		}
+		s.recordScale(meta, v1alpha1.TiFlashMemberType, oldSet, newSet)
 		return s.ScaleOut(meta, oldSet, newSet)
 	} else if scaling < 0 {
+		s.recordScale(meta, v1alpha1.TiFlashMemberType, oldSet, newSet)
 		return s.ScaleIn(meta, oldSet, newSet)
 	}
 	// we only sync auto scaler annotations when we are finishing syncing scaling
@@ -99,13 +128,29 @@ func (s *tiflashScaler) ScaleIn(meta metav1.Object, oldSet *apps.StatefulSet, ne
 			if err != nil {
 				return err
 			}
-			if state != v1alpha1.TiKVStateOffline {
+			action, err := getStoreScaleInAction(state)
+			if err != nil {
+				return fmt.Errorf("tiflash scale in: can't scale in tiflash %s/%s, store %d: %v", ns, podName, id, err)
+			}
+			if action == storeScaleInRemove {
+				// the store is moved to the tombstone stores in the next status sync
+				return controller.RequeueErrorf("TiFlash %s/%s store %d is tombstone, waiting for the status to be synced", ns, podName, id)
+			}
+			if action == storeScaleInDelete {
+				if err := runPreScaleInHook(s.deps, tc, v1alpha1.TiFlashMemberType, ordinal); err != nil {
+					return err
+				}
+				if err := reservePDDeletion(s.deps, tc, "store", store.ID); err != nil {
+					recordScaleBlocked(tc, v1alpha1.TiFlashMemberType, "PDDeletionDeferred")
+					return err
+				}
 				if err := controller.GetPDClient(s.deps.PDControl, tc).DeleteStore(id); err != nil {
 					klog.Errorf("tiflash scale in: failed to delete store %d, %v", id, err)
 					return err
 				}
 				klog.Infof("tiflash scale in: delete store %d for tiflash %s/%s successfully", id, ns, podName)
 			}
+			s.recordDecommissionProgress(tc, store, id)
 			return controller.RequeueErrorf("TiFlash %s/%s store %d is still in cluster, state: %s", ns, podName, id, state)
 		}
 	}
@@ -159,6 +204,40 @@ func (s *tiflashScaler) ScaleIn(meta metav1.Object, oldSet *apps.StatefulSet, ne
 	return fmt.Errorf("tiflash %s/%s no store found in cluster", ns, podName)
 }
 
+// recordDecommissionProgress records the regions left on the deleted store in
+// its status and estimates when they are all moved off from the rate they are
+// moved since the store is deleted, the removal of a TiFlash store with many
+// replicas may take hours.
+func (s *tiflashScaler) recordDecommissionProgress(tc *v1alpha1.TidbCluster, store v1alpha1.TiKVStore, id uint64) {
+	info, err := controller.GetPDClient(s.deps.PDControl, tc).GetStore(id)
+	if err != nil {
+		klog.Warningf("tiflash scale in: failed to get store %d of tc %s/%s, skip recording its decommission progress: %v", id, tc.GetNamespace(), tc.GetName(), err)
+		return
+	}
+	if info.Status == nil {
+		return
+	}
+
+	now := metav1.Now()
+	remaining := int32(info.Status.RegionCount)
+	progress := store.DecommissionProgress.DeepCopy()
+	if progress == nil {
+		progress = &v1alpha1.StoreDecommissionProgress{
+			StartTime:      now,
+			InitialRegions: remaining,
+		}
+	}
+	progress.RemainingRegions = remaining
+	progress.EstimatedCompletionTime = nil
+	if moved := progress.InitialRegions - remaining; moved > 0 {
+		elapsed := now.Sub(progress.StartTime.Time)
+		eta := metav1.NewTime(now.Add(time.Duration(float64(elapsed) * float64(remaining) / float64(moved))))
+		progress.EstimatedCompletionTime = &eta
+	}
+	store.DecommissionProgress = progress
+	tc.Status.TiFlash.Stores[store.ID] = store
+}
+
 type fakeTiFlashScaler struct{}
 
 // NewFakeTiFlashScaler returns a fake tiflash Scaler
@@ -0,0 +1,68 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+)
+
+func TestTiFlashScalerRecordDecommissionProgress(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	scaler := &tiflashScaler{generalScaler{deps: deps}}
+	tc := newTidbClusterForPD()
+	tc.Status.TiFlash.Stores = map[string]v1alpha1.TiKVStore{
+		"5": {
+			ID:      "5",
+			PodName: ordinalPodName(v1alpha1.TiFlashMemberType, tc.GetName(), 1),
+			State:   v1alpha1.TiKVStateUp,
+		},
+	}
+
+	regions := 100
+	pdClient := controller.NewFakePDClient(deps.PDControl.(*pdapi.FakePDControl), tc)
+	pdClient.AddReaction(pdapi.GetStoreActionType, func(action *pdapi.Action) (interface{}, error) {
+		return &pdapi.StoreInfo{Status: &pdapi.StoreStatus{RegionCount: regions}}, nil
+	})
+
+	// the store is deleted, no region is moved yet
+	scaler.recordDecommissionProgress(tc, tc.Status.TiFlash.Stores["5"], 5)
+	progress := tc.Status.TiFlash.Stores["5"].DecommissionProgress
+	g.Expect(progress).NotTo(BeNil())
+	g.Expect(progress.InitialRegions).To(Equal(int32(100)))
+	g.Expect(progress.RemainingRegions).To(Equal(int32(100)))
+	g.Expect(progress.EstimatedCompletionTime).To(BeNil())
+
+	// a quarter of the regions are moved in an hour
+	store := tc.Status.TiFlash.Stores["5"]
+	store.State = v1alpha1.TiKVStateOffline
+	store.DecommissionProgress.StartTime = metav1.NewTime(time.Now().Add(-time.Hour))
+	tc.Status.TiFlash.Stores["5"] = store
+	regions = 75
+	scaler.recordDecommissionProgress(tc, tc.Status.TiFlash.Stores["5"], 5)
+	progress = tc.Status.TiFlash.Stores["5"].DecommissionProgress
+	g.Expect(progress.InitialRegions).To(Equal(int32(100)))
+	g.Expect(progress.RemainingRegions).To(Equal(int32(75)))
+	g.Expect(progress.EstimatedCompletionTime).NotTo(BeNil())
+	g.Expect(progress.EstimatedCompletionTime.Time).To(BeTemporally("~", time.Now().Add(3*time.Hour), time.Minute))
+}
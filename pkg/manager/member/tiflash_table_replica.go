@@ -0,0 +1,108 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	errorutils "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog"
+)
+
+// tiflashTableReplicaSyncFailedReason is the reason of the Events of the
+// failures to sync the TiFlash replicas of the tables
+const tiflashTableReplicaSyncFailedReason = "TiFlashTableReplicaSyncFailed"
+
+// syncTiFlashTableReplicas sets the TiFlash replicas of the tables in the spec
+// that drift from the spec once all the TiDB members are ready, and reports
+// the replication progress of each table in the status. The tables that do
+// not exist yet are checked again in the next round.
+//
+// The TiFlash replicas are set through SQL, so TiDB being unreachable does not
+// fail the sync of TiFlash. The failures are recorded as Events instead.
+func syncTiFlashTableReplicas(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	tableReplicas := tc.Spec.TiFlash.TableReplicas
+	if len(tableReplicas) == 0 {
+		tc.Status.TiFlash.TableReplicas = nil
+		return
+	}
+	if tc.Spec.Paused {
+		klog.V(4).Infof("tiflash cluster %s/%s is paused, skip syncing the tiflash replicas of the tables", ns, tcName)
+		return
+	}
+	if !tc.TiDBAllMembersReady() {
+		klog.V(4).Infof("tidb cluster %s/%s is waiting for all tidb members to be ready to sync the tiflash replicas of the tables", ns, tcName)
+		return
+	}
+	if !checkTiDBSQLSecret(deps, tc, "tiflash replicas of the tables") {
+		return
+	}
+
+	status := map[string]v1alpha1.TiFlashTableReplicaStatus{}
+	var errs []error
+	for i := range tableReplicas {
+		tableReplica := &tableReplicas[i]
+		table := tiflashTableReplicaKey(tableReplica)
+		s, err := syncTiFlashTableReplica(deps, tc, tableReplica)
+		if err != nil {
+			s.Message = err.Error()
+			errs = append(errs, fmt.Errorf("failed to sync the tiflash replicas of table %s of tidb cluster %s/%s, error: %v", table, ns, tcName, err))
+		}
+		s.LastSyncTime = metav1.Now()
+		status[table] = s
+	}
+	tc.Status.TiFlash.TableReplicas = status
+	if err := errorutils.NewAggregate(errs); err != nil {
+		klog.Warning(err)
+		deps.Recorder.Event(tc, corev1.EventTypeWarning, tiflashTableReplicaSyncFailedReason, err.Error())
+	}
+}
+
+func syncTiFlashTableReplica(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, tableReplica *v1alpha1.TiFlashTableReplica) (v1alpha1.TiFlashTableReplicaStatus, error) {
+	table := tiflashTableReplicaKey(tableReplica)
+	replica, err := deps.TiDBControl.GetTiFlashReplica(tc, tableReplica.Database, tableReplica.Table)
+	if err != nil {
+		return v1alpha1.TiFlashTableReplicaStatus{}, err
+	}
+	if replica == nil {
+		klog.V(4).Infof("tidb cluster %s/%s's table %s does not exist, set its tiflash replicas once it is created", tc.GetNamespace(), tc.GetName(), table)
+		return v1alpha1.TiFlashTableReplicaStatus{Message: "table does not exist"}, nil
+	}
+	if replica.Count != tableReplica.Replicas {
+		klog.Infof("tidb cluster %s/%s sets the tiflash replicas of table %s from %d to %d", tc.GetNamespace(), tc.GetName(), table, replica.Count, tableReplica.Replicas)
+		if err := deps.TiDBControl.SetTiFlashReplica(tc, tableReplica.Database, tableReplica.Table, tableReplica.Replicas); err != nil {
+			return v1alpha1.TiFlashTableReplicaStatus{Replicas: replica.Count}, err
+		}
+		// the replication starts over, the progress is reported in the next round
+		return v1alpha1.TiFlashTableReplicaStatus{Synced: true, Replicas: tableReplica.Replicas}, nil
+	}
+	return v1alpha1.TiFlashTableReplicaStatus{
+		Synced:    true,
+		Replicas:  replica.Count,
+		Available: replica.Available,
+		Progress:  fmt.Sprintf("%.2f", replica.Progress),
+	}, nil
+}
+
+// tiflashTableReplicaKey returns the key of the table in the status
+func tiflashTableReplicaKey(tableReplica *v1alpha1.TiFlashTableReplica) string {
+	return tableReplica.Database + "." + tableReplica.Table
+}
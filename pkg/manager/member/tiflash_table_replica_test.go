@@ -0,0 +1,113 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestSyncTiFlashTableReplicas(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	tidbControl := deps.TiDBControl.(*controller.FakeTiDBControl)
+	tidbControl.TiFlashReplicas = map[string]*controller.TiFlashReplica{
+		"test.t1": {},
+	}
+	tc := newTidbClusterForTiDB()
+	tc.Spec.TiFlash = &v1alpha1.TiFlashSpec{
+		Replicas: 2,
+		TableReplicas: []v1alpha1.TiFlashTableReplica{
+			{Database: "test", Table: "t1", Replicas: 2},
+			{Database: "test", Table: "t2", Replicas: 1},
+		},
+	}
+	recorder := deps.Recorder.(*record.FakeRecorder)
+	sync := func() {
+		syncTiFlashTableReplicas(deps, tc)
+	}
+
+	// nothing is set until all tidb members are ready
+	sync()
+	g.Expect(tidbControl.TiFlashReplicaSets).To(Equal(0))
+	g.Expect(tc.Status.TiFlash.TableReplicas).To(BeNil())
+
+	tc.Status.TiDB.Members = map[string]v1alpha1.TiDBMember{}
+	for i := 0; i < int(tc.Spec.TiDB.Replicas); i++ {
+		name := fmt.Sprintf("test-tidb-%d", i)
+		tc.Status.TiDB.Members[name] = v1alpha1.TiDBMember{Name: name, Health: true}
+	}
+
+	// nothing is set without the credentials to connect to tidb
+	sync()
+	g.Expect(tidbControl.TiFlashReplicaSets).To(Equal(0))
+	g.Expect(collectEvents(recorder.Events)).To(ConsistOf("Warning SQLSecretNotSet sqlSecretName is not set, skip syncing tiflash replicas of the tables"))
+
+	// tidb being unreachable is reported in the status and as an event
+	tc.Spec.TiDB.SQLSecretName = "tidb-sql"
+	tidbControl.SQLError = fmt.Errorf("connection refused")
+	sync()
+	g.Expect(tc.Status.TiFlash.TableReplicas["test.t1"].Synced).To(BeFalse())
+	g.Expect(tc.Status.TiFlash.TableReplicas["test.t1"].Message).To(Equal("connection refused"))
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(HavePrefix("Warning TiFlashTableReplicaSyncFailed "))
+	tidbControl.SQLError = nil
+
+	// the replicas of the existing table are set, the missing table is reported
+	sync()
+	g.Expect(tidbControl.TiFlashReplicaSets).To(Equal(1))
+	g.Expect(tidbControl.TiFlashReplicas["test.t1"].Count).To(Equal(int32(2)))
+	g.Expect(tc.Status.TiFlash.TableReplicas["test.t1"].Synced).To(BeTrue())
+	g.Expect(tc.Status.TiFlash.TableReplicas["test.t2"].Synced).To(BeFalse())
+	g.Expect(tc.Status.TiFlash.TableReplicas["test.t2"].Message).To(Equal("table does not exist"))
+
+	// the progress of the replication is reported
+	tidbControl.TiFlashReplicas["test.t1"].Progress = 0.5
+	sync()
+	g.Expect(tidbControl.TiFlashReplicaSets).To(Equal(1))
+	g.Expect(tc.Status.TiFlash.TableReplicas["test.t1"].Progress).To(Equal("0.50"))
+	g.Expect(tc.Status.TiFlash.TableReplicas["test.t1"].Available).To(BeFalse())
+
+	tidbControl.TiFlashReplicas["test.t1"].Progress = 1
+	tidbControl.TiFlashReplicas["test.t1"].Available = true
+	sync()
+	g.Expect(tc.Status.TiFlash.TableReplicas["test.t1"].Progress).To(Equal("1.00"))
+	g.Expect(tc.Status.TiFlash.TableReplicas["test.t1"].Available).To(BeTrue())
+
+	// the table is set once it is created
+	tidbControl.TiFlashReplicas["test.t2"] = &controller.TiFlashReplica{}
+	sync()
+	g.Expect(tidbControl.TiFlashReplicaSets).To(Equal(2))
+	g.Expect(tidbControl.TiFlashReplicas["test.t2"].Count).To(Equal(int32(1)))
+	g.Expect(tc.Status.TiFlash.TableReplicas["test.t2"].Synced).To(BeTrue())
+
+	// the drifted replicas are set back
+	tidbControl.TiFlashReplicas["test.t1"].Count = 0
+	sync()
+	g.Expect(tidbControl.TiFlashReplicaSets).To(Equal(3))
+	g.Expect(tidbControl.TiFlashReplicas["test.t1"].Count).To(Equal(int32(2)))
+
+	// the status of the tables removed from the spec is dropped
+	tc.Spec.TiFlash.TableReplicas = tc.Spec.TiFlash.TableReplicas[:1]
+	sync()
+	g.Expect(tc.Status.TiFlash.TableReplicas).To(HaveLen(1))
+	g.Expect(tc.Status.TiFlash.TableReplicas).To(HaveKey("test.t1"))
+}
@@ -15,8 +15,11 @@ package member
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 
 	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/klog"
 	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
 
@@ -24,6 +27,7 @@ import (
 	"github.com/pingcap/advanced-statefulset/client/apis/apps/v1/helper"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
 	"github.com/pingcap/tidb-operator/pkg/tiflashapi"
 )
 
@@ -71,7 +75,7 @@ func (u *tiflashUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.Statefu
 		return nil
 	}
 
-	if tc.Status.TiFlash.StatefulSet.UpdateRevision == tc.Status.TiFlash.StatefulSet.CurrentRevision {
+	if tc.Status.TiFlash.StatefulSet.UpdateRevision == tc.Status.TiFlash.StatefulSet.CurrentRevision && !postUpgradeHooksPending(newSet) {
 		return nil
 	}
 
@@ -81,6 +85,7 @@ func (u *tiflashUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.Statefu
 		// If we encounter this situation, we will let the native statefulset controller do the upgrade completely, which may be unsafe for upgrading tikv.
 		// Therefore, in the production environment, we should try to avoid modifying the tikv statefulset update strategy directly.
 		newSet.Spec.UpdateStrategy = oldSet.Spec.UpdateStrategy
+		setPostUpgradeHookPods(newSet, nil)
 		klog.Warningf("tidbcluster: [%s/%s] TiFlash statefulset %s UpdateStrategy has been modified manually", ns, tcName, oldSet.GetName())
 		return nil
 	}
@@ -129,10 +134,27 @@ func (u *tiflashUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.Statefu
 					return controller.RequeueErrorf("tidbcluster: [%s/%s]'s upgraded TiFlash pod: [%s], store status is %s instead of Running", ns, tcName, podName, status)
 				}
 			}
+			if err := checkTiFlashStoreCaughtUp(u.deps, tc, store, pod); err != nil {
+				return err
+			}
+			if err := runPostUpgradeHook(u.deps, tc, v1alpha1.TiFlashMemberType, pod, newSet); err != nil {
+				return err
+			}
 
 			continue
 		}
 
+		if upgradePaused(tc, v1alpha1.TiFlashMemberType) || waitForMaintenanceWindow(tc, v1alpha1.TiFlashMemberType, "upgrade") {
+			return nil
+		}
+		if _i == len(podOrdinals)-1 {
+			if err := checkUpgradePreChecks(u.deps, tc, v1alpha1.TiFlashMemberType); err != nil {
+				return err
+			}
+		}
+		if err := runPreUpgradeHook(u.deps, tc, v1alpha1.TiFlashMemberType, pod, newSet); err != nil {
+			return err
+		}
 		setUpgradePartition(newSet, i)
 		return nil
 	}
@@ -140,6 +162,51 @@ func (u *tiflashUpgrader) Upgrade(tc *v1alpha1.TidbCluster, oldSet *apps.Statefu
 	return nil
 }
 
+// checkTiFlashStoreCaughtUp returns a requeue error if the upgraded TiFlash
+// store fails the checks of spec.tiflash.upgradeCheck, i.e. the Raft logs and
+// snapshots it missed while restarting are not applied yet. The checks are
+// skipped once the Pod has been ready for longer than the timeout.
+func checkTiFlashStoreCaughtUp(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, store *v1alpha1.TiKVStore, pod *corev1.Pod) error {
+	if tc.Spec.TiFlash == nil || tc.Spec.TiFlash.UpgradeCheck == nil {
+		return nil
+	}
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	podName := pod.GetName()
+	check := tc.Spec.TiFlash.UpgradeCheck
+
+	if _, cond := podutil.GetPodCondition(&pod.Status, corev1.PodReady); cond != nil {
+		if timeout := tc.TiFlashUpgradeCheckTimeout(); time.Since(cond.LastTransitionTime.Time) > timeout {
+			klog.Warningf("tidbcluster: [%s/%s]'s upgraded TiFlash pod: [%s] has been ready for more than %v, skip the upgrade checks", ns, tcName, podName, timeout)
+			return nil
+		}
+	}
+
+	storeID, err := strconv.ParseUint(store.ID, 10, 64)
+	if err != nil {
+		return err
+	}
+	pdClient := controller.GetPDClient(deps.PDControl, tc)
+	info, err := pdClient.GetStore(storeID)
+	if err != nil {
+		return fmt.Errorf("checkTiFlashStoreCaughtUp: failed to get store %d of tc %s/%s, error: %v", storeID, ns, tcName, err)
+	}
+	if info.Status != nil {
+		snapshots := int(info.Status.ReceivingSnapCount + info.Status.ApplyingSnapCount)
+		if max := int32Value(check.MaxApplyingSnapshots); snapshots > max {
+			return controller.RequeueErrorf("tidbcluster: [%s/%s]'s upgraded TiFlash pod: [%s] is receiving or applying %d snapshots, the max is %d", ns, tcName, podName, snapshots, max)
+		}
+	}
+	count, err := pdClient.GetStorePendingPeerRegionCount(storeID)
+	if err != nil {
+		return fmt.Errorf("checkTiFlashStoreCaughtUp: failed to get %s regions of store %d of tc %s/%s, error: %v", pdapi.RegionCheckPendingPeer, storeID, ns, tcName, err)
+	}
+	if max := int32Value(check.MaxPendingPeerRegions); count > max {
+		return controller.RequeueErrorf("tidbcluster: [%s/%s]'s upgraded TiFlash pod: [%s] is waiting for %d %s regions to catch up, the max is %d", ns, tcName, podName, count, pdapi.RegionCheckPendingPeer, max)
+	}
+	return nil
+}
+
 func getTiFlashStoreByOrdinal(name string, status v1alpha1.TiFlashStatus, ordinal int32) *v1alpha1.TiKVStore {
 	podName := TiFlashPodName(name, ordinal)
 	for _, store := range status.Stores {
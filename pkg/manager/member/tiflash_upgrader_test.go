@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"strconv"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	apps "k8s.io/api/apps/v1"
@@ -663,3 +664,96 @@ func NewFakeTiKVClient(control *tiflashapi.FakeTiFlashControl, tc *v1alpha1.Tidb
 	control.SetTiFlashPodClient(tc.Namespace, tc.Name, podName, client)
 	return client
 }
+
+func TestTiFlashUpgraderUpgradeCheck(t *testing.T) {
+	tests := []struct {
+		name         string
+		check        *v1alpha1.TiFlashUpgradeCheck
+		readySince   time.Duration
+		snapshots    uint32
+		pendingPeers int
+		partition    int32
+		requeue      bool
+	}{
+		{
+			name:         "no upgrade check",
+			readySince:   time.Second,
+			snapshots:    1,
+			pendingPeers: 1,
+			partition:    1,
+		},
+		{
+			name:       "the upgraded store is applying snapshots",
+			check:      &v1alpha1.TiFlashUpgradeCheck{},
+			readySince: time.Second,
+			snapshots:  1,
+			partition:  2,
+			requeue:    true,
+		},
+		{
+			name:         "the upgraded store is applying raft logs",
+			check:        &v1alpha1.TiFlashUpgradeCheck{},
+			readySince:   time.Second,
+			pendingPeers: 3,
+			partition:    2,
+			requeue:      true,
+		},
+		{
+			name:         "the pending peers are within the threshold",
+			check:        &v1alpha1.TiFlashUpgradeCheck{MaxPendingPeerRegions: pointer.Int32Ptr(5), MaxApplyingSnapshots: pointer.Int32Ptr(1)},
+			readySince:   time.Second,
+			snapshots:    1,
+			pendingPeers: 3,
+			partition:    1,
+		},
+		{
+			name:         "the upgraded store is ready for longer than the timeout",
+			check:        &v1alpha1.TiFlashUpgradeCheck{Timeout: pointer.StringPtr("1m")},
+			readySince:   2 * time.Minute,
+			snapshots:    1,
+			pendingPeers: 3,
+			partition:    1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			upgrader, pdControl, _, _, podInformer := newTiFlashUpgrader()
+			tc := newTidbClusterForTiFlashUpgrader()
+			tc.Spec.TiFlash.UpgradeCheck = test.check
+			tc.Status.PD.Phase = v1alpha1.NormalPhase
+			tc.Status.TiFlash.StatefulSet.CurrentReplicas = 2
+			tc.Status.TiFlash.StatefulSet.UpdatedReplicas = 1
+			oldSet := oldStatefulSetForTiFlashUpgrader()
+			SetStatefulSetLastAppliedConfigAnnotation(oldSet)
+			oldSet.Status.CurrentReplicas = 2
+			oldSet.Status.UpdatedReplicas = 1
+			oldSet.Spec.UpdateStrategy.RollingUpdate.Partition = pointer.Int32Ptr(2)
+			newSet := newStatefulSetForTiFlashUpgrader()
+
+			for _, pod := range getTiFlashPods(oldSet) {
+				pod.Status.Conditions[0].LastTransitionTime = metav1.NewTime(time.Now().Add(-test.readySince))
+				g.Expect(podInformer.Informer().GetIndexer().Add(pod)).To(Succeed())
+			}
+			pdClient := controller.NewFakePDClient(pdControl, tc)
+			pdClient.AddReaction(pdapi.GetStoreActionType, func(action *pdapi.Action) (interface{}, error) {
+				g.Expect(action.ID).To(Equal(uint64(3)))
+				return &pdapi.StoreInfo{Status: &pdapi.StoreStatus{ApplyingSnapCount: test.snapshots}}, nil
+			})
+			pdClient.AddReaction(pdapi.GetStorePendingPeerRegionCountActionType, func(action *pdapi.Action) (interface{}, error) {
+				g.Expect(action.ID).To(Equal(uint64(3)))
+				return test.pendingPeers, nil
+			})
+
+			err := upgrader.Upgrade(tc, oldSet, newSet)
+			if test.requeue {
+				g.Expect(controller.IsRequeueError(err)).To(BeTrue(), "%v", err)
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(test.partition))
+		})
+	}
+}
@@ -14,17 +14,28 @@
 package member
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/features"
 	"github.com/pingcap/tidb-operator/pkg/util"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog"
 )
 
+// minNodeNotReadyFailoverPeriod is the minimal time the node of a store must
+// be NotReady before the store is failed over, in case the node recovers from
+// a short outage
+const minNodeNotReadyFailoverPeriod = time.Minute
+
 type tikvFailover struct {
 	deps *controller.Dependencies
 }
@@ -59,7 +70,16 @@ func (f *tikvFailover) Failover(tc *v1alpha1.TidbCluster) error {
 			// (before it enters into Offline/Tombstone state)
 			continue
 		}
-		deadline := store.LastTransitionTime.Add(f.deps.CLIConfig.TiKVFailoverPeriod)
+		node, nodeGone := f.isNodeGone(tc, store)
+		if nodeGone && f.canDeleteSlots(tc) {
+			// the failure store record of the Pod, if any, is removed with
+			// the ordinal by RemoveUndesiredFailures
+			if err := f.deleteSlot(tc, store, node); err != nil {
+				return err
+			}
+			continue
+		}
+		deadline := store.LastTransitionTime.Add(tc.FailoverPeriod(v1alpha1.TiKVMemberType, f.deps.CLIConfig.TiKVFailoverPeriod))
 		exist := false
 		for _, failureStore := range tc.Status.TiKV.FailureStores {
 			if failureStore.PodName == podName {
@@ -67,14 +87,23 @@ func (f *tikvFailover) Failover(tc *v1alpha1.TidbCluster) error {
 				break
 			}
 		}
-		if store.State == v1alpha1.TiKVStateDown && time.Now().After(deadline) && !exist {
+		if exist {
+			continue
+		}
+		reason := ""
+		if storeNeedsFailover(store.State) && time.Now().After(deadline) {
+			reason = fmt.Sprintf("store[%s] is Down", store.ID)
+		} else if nodeGone {
+			reason = fmt.Sprintf("store[%s] is %s and its node %s is NotReady or deleted", store.ID, store.State, node)
+		}
+		if reason != "" {
 			if tc.Status.TiKV.FailureStores == nil {
 				tc.Status.TiKV.FailureStores = map[string]v1alpha1.TiKVFailureStore{}
 			}
-			if tc.Spec.TiKV.MaxFailoverCount != nil && *tc.Spec.TiKV.MaxFailoverCount > 0 {
-				maxFailoverCount := *tc.Spec.TiKV.MaxFailoverCount
+			if count := tc.MaxFailoverCount(v1alpha1.TiKVMemberType); count != nil && *count > 0 {
+				maxFailoverCount := *count
 				if len(tc.Status.TiKV.FailureStores) >= int(maxFailoverCount) {
-					klog.Warningf("%s/%s failure stores count reached the limit: %d", ns, tcName, tc.Spec.TiKV.MaxFailoverCount)
+					klog.Warningf("%s/%s failure stores count reached the limit: %d", ns, tcName, maxFailoverCount)
 					return nil
 				}
 				tc.Status.TiKV.FailureStores[storeID] = v1alpha1.TiKVFailureStore{
@@ -82,8 +111,14 @@ func (f *tikvFailover) Failover(tc *v1alpha1.TidbCluster) error {
 					StoreID:   store.ID,
 					CreatedAt: metav1.Now(),
 				}
-				msg := fmt.Sprintf("store[%s] is Down", store.ID)
-				f.deps.Recorder.Event(tc, corev1.EventTypeWarning, unHealthEventReason, fmt.Sprintf(unHealthEventMsgPattern, "tikv", podName, msg))
+				f.deps.Recorder.Event(tc, corev1.EventTypeWarning, unHealthEventReason, fmt.Sprintf(unHealthEventMsgPattern, "tikv", podName, reason))
+				f.deps.FailoverNotifier.Notify(tc, controller.FailoverEvent{
+					Type:          controller.FailoverEventMemberFailed,
+					Component:     v1alpha1.TiKVMemberType.String(),
+					Member:        podName,
+					Message:       reason,
+					EventRecorded: true,
+				})
 			}
 		}
 	}
@@ -91,6 +126,105 @@ func (f *tikvFailover) Failover(tc *v1alpha1.TidbCluster) error {
 	return nil
 }
 
+// isNodeGone returns the node of the store and whether the store will not
+// recover there, that is PD has lost the heartbeats of the store and the node
+// has been NotReady for the node NotReady failover period. A node deleted from
+// the API, e.g. by the cloud node controller, is gone once the store has lost
+// the heartbeats for the period.
+func (f *tikvFailover) isNodeGone(tc *v1alpha1.TidbCluster, store v1alpha1.TiKVStore) (string, bool) {
+	period := f.deps.CLIConfig.TiKVNodeNotReadyFailoverPeriod
+	if period <= 0 || f.deps.NodeLister == nil || !storeLostHeartbeats(store.State) {
+		return "", false
+	}
+	if period < minNodeNotReadyFailoverPeriod {
+		period = minNodeNotReadyFailoverPeriod
+	}
+
+	pod, err := f.deps.PodLister.Pods(tc.GetNamespace()).Get(store.PodName)
+	if err != nil || pod.Spec.NodeName == "" {
+		return "", false
+	}
+	node, err := f.deps.NodeLister.Get(pod.Spec.NodeName)
+	if errors.IsNotFound(err) {
+		return pod.Spec.NodeName, time.Since(store.LastTransitionTime.Time) >= period
+	}
+	if err != nil {
+		klog.V(4).Infof("failed to get node %s of tikv pod %s/%s: %v", pod.Spec.NodeName, tc.GetNamespace(), store.PodName, err)
+		return "", false
+	}
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			notReady := cond.Status != corev1.ConditionTrue
+			return node.Name, notReady && time.Since(cond.LastTransitionTime.Time) >= period
+		}
+	}
+	return "", false
+}
+
+// canDeleteSlots returns whether the stores whose nodes are gone are replaced
+// by deleting the ordinals of their Pods
+func (f *tikvFailover) canDeleteSlots(tc *v1alpha1.TidbCluster) bool {
+	count := tc.MaxFailoverCount(v1alpha1.TiKVMemberType)
+	return tc.Spec.TiKV.FailoverDeleteSlots &&
+		features.DefaultFeatureGate.Enabled(features.AdvancedStatefulSet) &&
+		count != nil && *count > 0
+}
+
+// deleteSlot adds the ordinal of the Pod of the store to the TiKV delete slots
+// of the TidbCluster, so that the scaler creates a new Pod with a new PVC and
+// then deletes the store and its Pod. It waits for the ordinals deleted before
+// whose stores are not removed yet, so that the stores are replaced one at a
+// time.
+func (f *tikvFailover) deleteSlot(tc *v1alpha1.TidbCluster, store v1alpha1.TiKVStore, node string) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	ordinal, err := util.GetOrdinalFromPodName(store.PodName)
+	if err != nil {
+		return err
+	}
+	deleteSlots := tc.TiKVDeleteSlots()
+	for _, s := range tc.Status.TiKV.Stores {
+		if o, err := util.GetOrdinalFromPodName(s.PodName); err == nil && deleteSlots.Has(o) {
+			klog.Infof("%s/%s tikv store %s of the deleted slot %d is not removed yet, skip replacing store %s", ns, tcName, s.ID, o, store.ID)
+			return nil
+		}
+	}
+
+	deleteSlots.Insert(ordinal)
+	value, err := json.Marshal(deleteSlots.List())
+	if err != nil {
+		return err
+	}
+	// the annotations are not persisted by the update of the status, so they
+	// are patched here before the failover is recorded
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{label.AnnTiKVDeleteSlots: string(value)},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := f.deps.Clientset.PingcapV1alpha1().TidbClusters(ns).Patch(context.TODO(), tcName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to delete slot %d of tikv store %s of %s/%s, error: %v", ordinal, store.ID, ns, tcName, err)
+	}
+	if tc.Annotations == nil {
+		tc.Annotations = map[string]string{}
+	}
+	tc.Annotations[label.AnnTiKVDeleteSlots] = string(value)
+	reason := fmt.Sprintf("store[%s] is %s and its node %s is NotReady, its slot %d is deleted", store.ID, store.State, node, ordinal)
+	f.deps.Recorder.Event(tc, corev1.EventTypeWarning, unHealthEventReason, fmt.Sprintf(unHealthEventMsgPattern, "tikv", store.PodName, reason))
+	f.deps.FailoverNotifier.Notify(tc, controller.FailoverEvent{
+		Type:          controller.FailoverEventTriggered,
+		Component:     v1alpha1.TiKVMemberType.String(),
+		Member:        store.PodName,
+		Message:       reason,
+		EventRecorded: true,
+	})
+	klog.Infof("%s/%s tikv store %s is replaced by deleting slot %d, delete slots: %s", ns, tcName, store.ID, ordinal, value)
+	return nil
+}
+
 func (f *tikvFailover) RemoveUndesiredFailures(tc *v1alpha1.TidbCluster) {
 	for key, failureStore := range tc.Status.TiKV.FailureStores {
 		if !f.isPodDesired(tc, failureStore.PodName) {
@@ -103,6 +237,13 @@ func (f *tikvFailover) RemoveUndesiredFailures(tc *v1alpha1.TidbCluster) {
 }
 
 func (f *tikvFailover) Recover(tc *v1alpha1.TidbCluster) {
+	if len(tc.Status.TiKV.FailureStores) > 0 {
+		f.deps.FailoverNotifier.Notify(tc, controller.FailoverEvent{
+			Type:      controller.FailoverEventRecovered,
+			Component: v1alpha1.TiKVMemberType.String(),
+			Message:   fmt.Sprintf("%d failure stores are recovered", len(tc.Status.TiKV.FailureStores)),
+		})
+	}
 	tc.Status.TiKV.FailureStores = nil
 	klog.Infof("TiKV recover: clear FailureStores, %s/%s", tc.GetNamespace(), tc.GetName())
 }
@@ -14,12 +14,17 @@
 package member
 
 import (
+	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/features"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
 )
@@ -53,6 +58,48 @@ func TestTiKVFailoverFailover(t *testing.T) {
 				g.Expect(len(tc.Status.TiKV.FailureStores)).To(Equal(2))
 			},
 		},
+		{
+			name: "deadline of the detection period in the spec exceeds",
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Failover = &v1alpha1.FailoverSpec{DetectionPeriod: pointer.StringPtr("20m")}
+				tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{
+					"1": {
+						State:              v1alpha1.TiKVStateDown,
+						PodName:            "tikv-1",
+						LastTransitionTime: metav1.Time{Time: time.Now().Add(-30 * time.Minute)},
+					},
+					"2": {
+						State:              v1alpha1.TiKVStateDown,
+						PodName:            "tikv-2",
+						LastTransitionTime: metav1.Time{Time: time.Now().Add(-10 * time.Minute)},
+					},
+				}
+			},
+			err: false,
+			expectFn: func(t *testing.T, tc *v1alpha1.TidbCluster) {
+				g := NewGomegaWithT(t)
+				g.Expect(tc.Status.TiKV.FailureStores).To(HaveLen(1))
+				g.Expect(tc.Status.TiKV.FailureStores).To(HaveKey("1"))
+			},
+		},
+		{
+			name: "max failover count in the spec takes precedence",
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.Failover = &v1alpha1.FailoverSpec{MaxFailoverCount: pointer.Int32Ptr(0)}
+				tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{
+					"1": {
+						State:              v1alpha1.TiKVStateDown,
+						PodName:            "tikv-1",
+						LastTransitionTime: metav1.Time{Time: time.Now().Add(-70 * time.Minute)},
+					},
+				}
+			},
+			err: false,
+			expectFn: func(t *testing.T, tc *v1alpha1.TidbCluster) {
+				g := NewGomegaWithT(t)
+				g.Expect(tc.Status.TiKV.FailureStores).To(BeEmpty())
+			},
+		},
 		{
 			name: "tikv state is not Down",
 			update: func(tc *v1alpha1.TidbCluster) {
@@ -302,3 +349,271 @@ func TestTiKVFailoverFailover(t *testing.T) {
 		})
 	}
 }
+
+func TestTiKVFailoverNodeNotReady(t *testing.T) {
+	tests := []struct {
+		name           string
+		period         time.Duration
+		state          string
+		nodeReady      corev1.ConditionStatus
+		notReadySince  time.Duration
+		nodeDeleted    bool
+		storeLostFor   time.Duration
+		expectFailover bool
+	}{
+		{
+			name:           "disconnected store on a NotReady node",
+			period:         5 * time.Minute,
+			state:          v1alpha1.TiKVStateDisconnected,
+			nodeReady:      corev1.ConditionFalse,
+			notReadySince:  10 * time.Minute,
+			expectFailover: true,
+		},
+		{
+			name:           "down store on an unknown node",
+			period:         5 * time.Minute,
+			state:          v1alpha1.TiKVStateDown,
+			nodeReady:      corev1.ConditionUnknown,
+			notReadySince:  10 * time.Minute,
+			expectFailover: true,
+		},
+		{
+			name:          "up store on a NotReady node",
+			period:        5 * time.Minute,
+			state:         v1alpha1.TiKVStateUp,
+			nodeReady:     corev1.ConditionFalse,
+			notReadySince: 10 * time.Minute,
+		},
+		{
+			name:          "disconnected store on a Ready node",
+			period:        5 * time.Minute,
+			state:         v1alpha1.TiKVStateDisconnected,
+			nodeReady:     corev1.ConditionTrue,
+			notReadySince: 10 * time.Minute,
+		},
+		{
+			name:          "node is NotReady for less than the period",
+			period:        5 * time.Minute,
+			state:         v1alpha1.TiKVStateDisconnected,
+			nodeReady:     corev1.ConditionFalse,
+			notReadySince: 3 * time.Minute,
+		},
+		{
+			name:          "node is NotReady for less than the minimal period",
+			period:        time.Second,
+			state:         v1alpha1.TiKVStateDisconnected,
+			nodeReady:     corev1.ConditionFalse,
+			notReadySince: 30 * time.Second,
+		},
+		{
+			name:           "disconnected store on a deleted node",
+			period:         5 * time.Minute,
+			state:          v1alpha1.TiKVStateDisconnected,
+			nodeDeleted:    true,
+			storeLostFor:   10 * time.Minute,
+			expectFailover: true,
+		},
+		{
+			name:         "store on a deleted node is lost for less than the period",
+			period:       5 * time.Minute,
+			state:        v1alpha1.TiKVStateDisconnected,
+			nodeDeleted:  true,
+			storeLostFor: 3 * time.Minute,
+		},
+		{
+			name:          "disabled",
+			state:         v1alpha1.TiKVStateDisconnected,
+			nodeReady:     corev1.ConditionFalse,
+			notReadySince: 10 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			tc := newTidbClusterForPD()
+			tc.Spec.TiKV.Replicas = 3
+			tc.Spec.TiKV.MaxFailoverCount = pointer.Int32Ptr(3)
+			podName := ordinalPodName(v1alpha1.TiKVMemberType, tc.GetName(), 1)
+			tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{
+				"1": {
+					ID:                 "1",
+					State:              tt.state,
+					PodName:            podName,
+					LastTransitionTime: metav1.Time{Time: time.Now().Add(-time.Minute)},
+				},
+			}
+			if tt.storeLostFor > 0 {
+				store := tc.Status.TiKV.Stores["1"]
+				store.LastTransitionTime = metav1.Time{Time: time.Now().Add(-tt.storeLostFor)}
+				tc.Status.TiKV.Stores["1"] = store
+			}
+
+			fakeDeps := controller.NewFakeDependencies()
+			fakeDeps.CLIConfig.TiKVFailoverPeriod = 1 * time.Hour
+			fakeDeps.CLIConfig.TiKVNodeNotReadyFailoverPeriod = tt.period
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: podName, Namespace: tc.GetNamespace()},
+				Spec:       corev1.PodSpec{NodeName: "node-1"},
+			}
+			g.Expect(fakeDeps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer().Add(pod)).To(Succeed())
+			node := &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+				Status: corev1.NodeStatus{
+					Conditions: []corev1.NodeCondition{{
+						Type:               corev1.NodeReady,
+						Status:             tt.nodeReady,
+						LastTransitionTime: metav1.Time{Time: time.Now().Add(-tt.notReadySince)},
+					}},
+				},
+			}
+			if !tt.nodeDeleted {
+				g.Expect(fakeDeps.KubeInformerFactory.Core().V1().Nodes().Informer().GetIndexer().Add(node)).To(Succeed())
+			}
+			tikvFailover := &tikvFailover{deps: fakeDeps}
+
+			g.Expect(tikvFailover.Failover(tc)).To(Succeed())
+			if tt.expectFailover {
+				g.Expect(tc.Status.TiKV.FailureStores).To(HaveKey("1"))
+			} else {
+				g.Expect(tc.Status.TiKV.FailureStores).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestTiKVFailoverDeleteSlots(t *testing.T) {
+	enabled := features.DefaultFeatureGate.Enabled(features.AdvancedStatefulSet)
+	defer features.DefaultFeatureGate.Set(fmt.Sprintf("AdvancedStatefulSet=%t", enabled))
+
+	tests := []struct {
+		name               string
+		advancedSts        bool
+		deleteSlots        string
+		expectDeleteSlots  string
+		expectFailureStore bool
+		expectNotification controller.FailoverEventType
+	}{
+		{
+			name:               "the ordinal of the store is deleted",
+			advancedSts:        true,
+			expectDeleteSlots:  "[1]",
+			expectNotification: controller.FailoverEventTriggered,
+		},
+		{
+			name:               "the ordinal is added to the delete slots",
+			advancedSts:        true,
+			deleteSlots:        "[5]",
+			expectDeleteSlots:  "[1,5]",
+			expectNotification: controller.FailoverEventTriggered,
+		},
+		{
+			name:              "the store of another deleted slot is not removed yet",
+			advancedSts:       true,
+			deleteSlots:       "[0]",
+			expectDeleteSlots: "[0]",
+		},
+		{
+			name:               "a failover Pod is added without the advanced statefulset",
+			expectFailureStore: true,
+			expectNotification: controller.FailoverEventMemberFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			features.DefaultFeatureGate.Set(fmt.Sprintf("AdvancedStatefulSet=%t", tt.advancedSts))
+
+			tc := newTidbClusterForPD()
+			tc.Spec.TiKV.Replicas = 3
+			tc.Spec.TiKV.MaxFailoverCount = pointer.Int32Ptr(3)
+			tc.Spec.TiKV.FailoverDeleteSlots = true
+			if tt.deleteSlots != "" {
+				tc.Annotations = map[string]string{label.AnnTiKVDeleteSlots: tt.deleteSlots}
+			}
+			podName := ordinalPodName(v1alpha1.TiKVMemberType, tc.GetName(), 1)
+			tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{
+				"1": {
+					ID:                 "1",
+					State:              v1alpha1.TiKVStateDown,
+					PodName:            podName,
+					LastTransitionTime: metav1.Time{Time: time.Now().Add(-time.Minute)},
+				},
+				"2": {
+					ID:                 "2",
+					State:              v1alpha1.TiKVStateOffline,
+					PodName:            ordinalPodName(v1alpha1.TiKVMemberType, tc.GetName(), 0),
+					LastTransitionTime: metav1.Time{Time: time.Now().Add(-time.Minute)},
+				},
+			}
+
+			fakeDeps := controller.NewFakeDependencies()
+			fakeDeps.CLIConfig.TiKVFailoverPeriod = 1 * time.Hour
+			fakeDeps.CLIConfig.TiKVNodeNotReadyFailoverPeriod = 5 * time.Minute
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: podName, Namespace: tc.GetNamespace()},
+				Spec:       corev1.PodSpec{NodeName: "node-1"},
+			}
+			g.Expect(fakeDeps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer().Add(pod)).To(Succeed())
+			node := &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+				Status: corev1.NodeStatus{
+					Conditions: []corev1.NodeCondition{{
+						Type:               corev1.NodeReady,
+						Status:             corev1.ConditionFalse,
+						LastTransitionTime: metav1.Time{Time: time.Now().Add(-10 * time.Minute)},
+					}},
+				},
+			}
+			g.Expect(fakeDeps.KubeInformerFactory.Core().V1().Nodes().Informer().GetIndexer().Add(node)).To(Succeed())
+			_, err := fakeDeps.Clientset.PingcapV1alpha1().TidbClusters(tc.Namespace).Create(context.TODO(), tc, metav1.CreateOptions{})
+			g.Expect(err).NotTo(HaveOccurred())
+			tikvFailover := &tikvFailover{deps: fakeDeps}
+
+			g.Expect(tikvFailover.Failover(tc)).To(Succeed())
+			g.Expect(tc.Annotations[label.AnnTiKVDeleteSlots]).To(Equal(tt.expectDeleteSlots))
+			// the delete slots are persisted, the status update does not
+			// persist the annotations
+			persisted, err := fakeDeps.Clientset.PingcapV1alpha1().TidbClusters(tc.Namespace).Get(context.TODO(), tc.Name, metav1.GetOptions{})
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(persisted.Annotations[label.AnnTiKVDeleteSlots]).To(Equal(tt.expectDeleteSlots))
+			if tt.expectFailureStore {
+				g.Expect(tc.Status.TiKV.FailureStores).To(HaveKey("1"))
+			} else {
+				g.Expect(tc.Status.TiKV.FailureStores).To(BeEmpty())
+			}
+			notifications := fakeDeps.FailoverNotifier.(*controller.FakeFailoverNotifier).Events()
+			if tt.expectNotification == "" {
+				g.Expect(notifications).To(BeEmpty())
+			} else {
+				g.Expect(notifications).To(HaveLen(1))
+				g.Expect(notifications[0].Type).To(Equal(tt.expectNotification))
+				g.Expect(notifications[0].Component).To(Equal("tikv"))
+				g.Expect(notifications[0].Member).To(Equal(podName))
+			}
+
+			// the next sync starts from the persisted TidbCluster, the slot
+			// is not deleted and notified again
+			persisted.Status = *tc.Status.DeepCopy()
+			g.Expect(tikvFailover.Failover(persisted)).To(Succeed())
+			g.Expect(persisted.Annotations[label.AnnTiKVDeleteSlots]).To(Equal(tt.expectDeleteSlots))
+			g.Expect(fakeDeps.FailoverNotifier.(*controller.FakeFailoverNotifier).Events()).To(HaveLen(len(notifications)))
+		})
+	}
+}
+
+func TestTiKVFailoverRemoveUndesiredFailures(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPD()
+	tc.Spec.TiKV.Replicas = 2
+	tc.Status.TiKV.FailureStores = map[string]v1alpha1.TiKVFailureStore{
+		"1": {PodName: "test-tikv-1", StoreID: "1"},
+		"3": {PodName: "test-tikv-3", StoreID: "3"},
+	}
+
+	tikvFailover := &tikvFailover{deps: controller.NewFakeDependencies()}
+	tikvFailover.RemoveUndesiredFailures(tc)
+
+	g.Expect(tc.Status.TiKV.FailureStores).To(HaveLen(1))
+	g.Expect(tc.Status.TiKV.FailureStores).To(HaveKey("1"))
+}
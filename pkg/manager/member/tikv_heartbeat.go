@@ -0,0 +1,67 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// storeHeartbeatStaleReason is the reason of the StoreHeartbeatStale condition
+	// when the heartbeats of some stores are stale
+	storeHeartbeatStaleReason = "HeartbeatStale"
+	// storeHeartbeatOKReason is the reason of the StoreHeartbeatStale condition
+	// when the heartbeats of all the stores are fresh
+	storeHeartbeatOKReason = "HeartbeatOK"
+)
+
+// syncStoreHeartbeatCondition sets the StoreHeartbeatStale condition by the
+// last heartbeats of the given stores, which are keyed by the store ID
+func syncStoreHeartbeatCondition(tc *v1alpha1.TidbCluster, stores map[string]*pdapi.StoreInfo, now time.Time) {
+	threshold := tc.TiKVStoreHeartbeatStaleThreshold()
+	if threshold <= 0 {
+		utiltidbcluster.RemoveTidbClusterCondition(&tc.Status, v1alpha1.TidbClusterStoreHeartbeatStale)
+		return
+	}
+
+	var stale []string
+	for id, store := range stores {
+		heartbeat := store.Status.LastHeartbeatTS
+		if heartbeat.IsZero() {
+			// the store has not reported any heartbeat yet
+			continue
+		}
+		if age := now.Sub(heartbeat); age > threshold {
+			stale = append(stale, fmt.Sprintf("%s(%s)", id, age.Round(time.Second)))
+		}
+	}
+
+	if len(stale) > 0 {
+		sort.Strings(stale)
+		msg := fmt.Sprintf("last heartbeat of store %s is older than %s", strings.Join(stale, ", "), threshold)
+		utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+			v1alpha1.TidbClusterStoreHeartbeatStale, corev1.ConditionTrue, storeHeartbeatStaleReason, msg))
+		return
+	}
+	utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+		v1alpha1.TidbClusterStoreHeartbeatStale, corev1.ConditionFalse, storeHeartbeatOKReason, "heartbeats of all the stores are fresh"))
+}
@@ -21,6 +21,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pingcap/advanced-statefulset/client/apis/apps/v1/helper"
 	"github.com/pingcap/kvproto/pkg/metapb"
@@ -45,6 +46,9 @@ const (
 	// tikvDataVolumeMountPath is the mount path for tikv data volume
 	tikvDataVolumeMountPath = "/var/lib/tikv"
 
+	// tikvWalVolumeMountPath is the mount path for the separate tikv WAL volume
+	tikvWalVolumeMountPath = "/var/lib/tikv-wal"
+
 	// tikvClusterCertPath is where the cert for inter-cluster communication stored (if any)
 	tikvClusterCertPath = "/var/lib/tikv-tls"
 
@@ -99,7 +103,7 @@ func (m *tikvMemberManager) Sync(tc *v1alpha1.TidbCluster) error {
 	svcList := []SvcConfig{
 		{
 			Name:       "peer",
-			Port:       20160,
+			Port:       tc.TiKVServerPort(),
 			Headless:   true,
 			SvcLabel:   func(l label.Label) label.Label { return l.TiKV() },
 			MemberName: controller.TiKVPeerMemberName,
@@ -110,7 +114,13 @@ func (m *tikvMemberManager) Sync(tc *v1alpha1.TidbCluster) error {
 			return err
 		}
 	}
-	return m.syncStatefulSetForTidbCluster(tc)
+	if err := m.syncStatefulSetForTidbCluster(tc); err != nil {
+		return err
+	}
+
+	// Sync the Region size of the Up stores
+	syncRegionSize(m.deps, tc)
+	return nil
 }
 
 func (m *tikvMemberManager) syncServiceForTidbCluster(tc *v1alpha1.TidbCluster, svcConfig SvcConfig) error {
@@ -188,7 +198,7 @@ func (m *tikvMemberManager) syncStatefulSetForTidbCluster(tc *v1alpha1.TidbClust
 		m.failover.RemoveUndesiredFailures(tc)
 	}
 	if len(tc.Status.TiKV.FailureStores) > 0 &&
-		tc.Spec.TiKV.RecoverFailover &&
+		tc.RecoverFailover(v1alpha1.TiKVMemberType) &&
 		shouldRecover(tc, label.TiKVLabelVal, m.deps.PodLister) {
 		m.failover.Recover(tc)
 	}
@@ -197,6 +207,10 @@ func (m *tikvMemberManager) syncStatefulSetForTidbCluster(tc *v1alpha1.TidbClust
 	if err != nil {
 		return err
 	}
+	if err := mutatePodTemplate(m.deps, tc, v1alpha1.TiKVMemberType, newSet); err != nil {
+		return err
+	}
+	holdComponentRestartAt(tc, v1alpha1.TiKVMemberType, newSet, oldSet)
 	if setNotExist {
 		err = SetStatefulSetLastAppliedConfigAnnotation(newSet)
 		if err != nil {
@@ -210,10 +224,23 @@ func (m *tikvMemberManager) syncStatefulSetForTidbCluster(tc *v1alpha1.TidbClust
 		return nil
 	}
 
+	// the Pods whose post-upgrade hooks are not run yet are kept in the new StatefulSet
+	keepPostUpgradeHookPods(oldSet, newSet)
+
 	if _, err := m.setStoreLabelsForTiKV(tc); err != nil {
 		return err
 	}
 
+	// Remove the evict leader schedulers left behind by an interrupted upgrade,
+	// it is best effort and must not block scaling or upgrading
+	if err := cleanupStaleEvictLeaderSchedulers(m.deps, tc, oldSet); err != nil {
+		klog.Warningf("tikv: failed to clean up stale evict leader schedulers of %s/%s, error: %v", ns, tcName, err)
+	}
+
+	if err := syncTiKVStoreMigration(m.deps, tc); err != nil {
+		return err
+	}
+
 	// Scaling takes precedence over upgrading because:
 	// - if a store fails in the upgrading, users may want to delete it or add
 	//   new replicas
@@ -223,24 +250,41 @@ func (m *tikvMemberManager) syncStatefulSetForTidbCluster(tc *v1alpha1.TidbClust
 		return err
 	}
 
+	// Report the Pods stuck in Pending and the Pods restarted too often
+	if err := syncRolloutStall(m.deps, tc, v1alpha1.TiKVMemberType); err != nil {
+		return err
+	}
+	if err := syncPodRestartFlapping(m.deps, tc, v1alpha1.TiKVMemberType); err != nil {
+		return err
+	}
+
 	// Perform failover logic if necessary. Note that this will only update
 	// TidbCluster status. The actual scaling performs in next sync loop (if a
 	// new replica needs to be added).
-	if m.deps.CLIConfig.AutoFailover && tc.Spec.TiKV.MaxFailoverCount != nil {
+	if m.deps.CLIConfig.AutoFailover && tc.MaxFailoverCount(v1alpha1.TiKVMemberType) != nil && !tc.FailoverPausedByRestarts(v1alpha1.TiKVMemberType) {
 		if tc.TiKVAllPodsStarted() && !tc.TiKVAllStoresReady() {
 			if err := m.failover.Failover(tc); err != nil {
 				return err
 			}
 		}
+		// not gated by the readiness, as the failure store leaves the stores once it becomes tombstone
+		if err := recreateFailureStores(m.deps, tc, v1alpha1.TiKVMemberType); err != nil {
+			return err
+		}
 	}
 
 	if !templateEqual(newSet, oldSet) || tc.Status.TiKV.Phase == v1alpha1.UpgradePhase {
 		if err := m.upgrader.Upgrade(tc, oldSet, newSet); err != nil {
 			return err
 		}
+	} else if tc.Status.TiKV.Phase == v1alpha1.NormalPhase {
+		// Restart the Pods requested by the restart-at annotation when no upgrade or scaling is in progress
+		if err := syncPodRestarts(m.deps, tc, v1alpha1.TiKVMemberType); err != nil {
+			return err
+		}
 	}
 
-	return UpdateStatefulSet(m.deps.StatefulSetControl, tc, newSet, oldSet)
+	return updateStatefulSetAndRunPostScaleHook(m.deps, tc, v1alpha1.TiKVMemberType, newSet, oldSet)
 }
 
 func (m *tikvMemberManager) syncTiKVConfigMap(tc *v1alpha1.TidbCluster, set *apps.StatefulSet) (*corev1.ConfigMap, error) {
@@ -260,10 +304,21 @@ func (m *tikvMemberManager) syncTiKVConfigMap(tc *v1alpha1.TidbCluster, set *app
 		})
 	}
 
-	err = updateConfigMapIfNeed(m.deps.ConfigMapLister, tc.BaseTiKVSpec().ConfigUpdateStrategy(), inUseName, newCm)
+	strategy, err := syncConfigOnline(m.deps, tc, v1alpha1.TiKVMemberType, tc.BaseTiKVSpec().ConfigUpdateStrategy(), inUseName, newCm)
 	if err != nil {
 		return nil, err
 	}
+	err = updateConfigMapIfNeed(m.deps.ConfigMapLister, strategy, inUseName, newCm)
+	if err != nil {
+		return nil, err
+	}
+	ordinalConfig, err := getTiKVOrdinalConfig(tc)
+	if err != nil {
+		return nil, err
+	}
+	if err := syncOrdinalConfigMap(m.deps, tc, v1alpha1.TiKVMemberType, ordinalConfig); err != nil {
+		return nil, err
+	}
 	return m.deps.TypedControl.CreateOrUpdateConfigMap(tc, newCm)
 }
 
@@ -308,6 +363,25 @@ func getNewServiceForTidbCluster(tc *v1alpha1.TidbCluster, svcConfig SvcConfig)
 	return &svc
 }
 
+// withTiKVZoneSpreadConstraint spreads the TiKV Pods evenly across the zones
+// for `.spec.tikv.scalePolicy.balanceZones` unless the constraints already
+// spread them by the zone label.
+func withTiKVZoneSpreadConstraint(tc *v1alpha1.TidbCluster, constraints []corev1.TopologySpreadConstraint) []corev1.TopologySpreadConstraint {
+	for _, c := range constraints {
+		if c.TopologyKey == corev1.LabelZoneFailureDomainStable || c.TopologyKey == corev1.LabelZoneFailureDomain {
+			return constraints
+		}
+	}
+	return append(constraints, corev1.TopologySpreadConstraint{
+		MaxSkew:           1,
+		TopologyKey:       corev1.LabelZoneFailureDomainStable,
+		WhenUnsatisfiable: corev1.DoNotSchedule,
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: label.New().Instance(tc.GetInstanceName()).TiKV().Labels(),
+		},
+	})
+}
+
 func getNewTiKVSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap) (*apps.StatefulSet, error) {
 	ns := tc.GetNamespace()
 	tcName := tc.GetName()
@@ -359,6 +433,11 @@ func getNewTiKVSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 			}},
 		},
 	}
+	if tc.Spec.TiKV.PerOrdinalConfig != nil {
+		vol, mount := ordinalConfigVolume(tc, v1alpha1.TiKVMemberType)
+		vols = append(vols, vol)
+		volMounts = append(volMounts, mount)
+	}
 	if tc.IsTLSClusterEnabled() {
 		vols = append(vols, corev1.Volume{
 			Name: "tikv-tls", VolumeSource: corev1.VolumeSource{
@@ -378,7 +457,7 @@ func getNewTiKVSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 		}
 	}
 	// handle StorageVolumes and AdditionalVolumeMounts in ComponentSpec
-	storageVolMounts, additionalPVCs := util.BuildStorageVolumeAndVolumeMount(tc.Spec.TiKV.StorageVolumes, tc.Spec.TiKV.StorageClassName, v1alpha1.TiKVMemberType)
+	storageVolMounts, additionalPVCs := util.BuildStorageVolumeAndVolumeMount(tikvStorageVolumes(tc.Spec.TiKV), tc.Spec.TiKV.StorageClassName, v1alpha1.TiKVMemberType)
 	volMounts = append(volMounts, storageVolMounts...)
 
 	sysctls := "sysctl -w"
@@ -419,6 +498,9 @@ func getNewTiKVSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 	if len(initContainers) > 0 {
 		podSecurityContext.Sysctls = []corev1.Sysctl{}
 	}
+	if tc.Spec.TiKV.StorageCheck != nil {
+		initContainers = append(initContainers, getStorageCheckContainer(tc, tikvDataVol))
+	}
 
 	storageRequest, err := controller.ParseStorageRequest(tc.Spec.TiKV.Requests)
 	if err != nil {
@@ -428,7 +510,7 @@ func getNewTiKVSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 	stsLabels := labelTiKV(tc)
 	podLabels := util.CombineStringMap(stsLabels.Labels(), baseTiKVSpec.Labels())
 	setName := controller.TiKVMemberName(tcName)
-	podAnnotations := util.CombineStringMap(controller.AnnProm(20180), baseTiKVSpec.Annotations())
+	podAnnotations := util.CombineStringMap(controller.AnnProm(tc.TiKVStatusPort()), baseTiKVSpec.Annotations())
 	stsAnnotations := getStsAnnotations(tc.Annotations, label.TiKVLabelVal)
 	capacity := controller.TiKVCapacity(tc.Spec.TiKV.Limits)
 	headlessSvcName := controller.TiKVPeerMemberName(tcName)
@@ -503,24 +585,21 @@ func getNewTiKVSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 		Image:           tc.TiKVImage(),
 		ImagePullPolicy: baseTiKVSpec.ImagePullPolicy(),
 		Command:         []string{"/bin/sh", "/usr/local/bin/tikv_start_script.sh"},
-		SecurityContext: &corev1.SecurityContext{
-			Privileged: tc.TiKVContainerPrivilege(),
-		},
 		Ports: []corev1.ContainerPort{
 			{
 				Name:          "server",
-				ContainerPort: int32(20160),
+				ContainerPort: tc.TiKVServerPort(),
 				Protocol:      corev1.ProtocolTCP,
 			},
 		},
 		VolumeMounts: volMounts,
-		Resources:    controller.ContainerResource(tc.Spec.TiKV.ResourceRequirements),
+		Resources:    controller.ContainerResourceWithEphemeralStorage(tc.Spec.TiKV.ResourceRequirements, tc.Spec.TiKV.EphemeralStorage),
 	}
 
 	if tc.Spec.TiKV.EnableNamedStatusPort {
 		kvStatusPort := corev1.ContainerPort{
 			Name:          "status",
-			ContainerPort: int32(20180),
+			ContainerPort: tc.TiKVStatusPort(),
 			Protocol:      corev1.ProtocolTCP,
 		}
 
@@ -528,6 +607,9 @@ func getNewTiKVSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 	}
 
 	podSpec := baseTiKVSpec.BuildPodSpec()
+	if tc.TiKVBalanceZones() {
+		podSpec.TopologySpreadConstraints = withTiKVZoneSpreadConstraint(tc, podSpec.TopologySpreadConstraints)
+	}
 	if baseTiKVSpec.HostNetwork() {
 		podSpec.DNSPolicy = corev1.DNSClusterFirstWithHostNet
 		env = append(env, corev1.EnvVar{
@@ -546,6 +628,7 @@ func getNewTiKVSetForTidbCluster(tc *v1alpha1.TidbCluster, cm *corev1.ConfigMap)
 	podSpec.SecurityContext = podSecurityContext
 	podSpec.InitContainers = append(initContainers, baseTiKVSpec.InitContainers()...)
 	podSpec.Containers = append(containers, baseTiKVSpec.AdditionalContainers()...)
+	setMainContainerSecurityContext(&podSpec, baseTiKVSpec, v1alpha1.TiKVMemberType.String(), tc.TiKVContainerPrivilege())
 	podSpec.ServiceAccountName = tc.Spec.TiKV.ServiceAccount
 	if podSpec.ServiceAccountName == "" {
 		podSpec.ServiceAccountName = tc.Spec.ServiceAccount
@@ -643,6 +726,9 @@ func getTikVConfigMap(tc *v1alpha1.TidbCluster) (*corev1.ConfigMap, error) {
 		EnableAdvertiseStatusAddr: false,
 		DataDir:                   filepath.Join(tikvDataVolumeMountPath, tc.Spec.TiKV.DataSubDir),
 		ClusterDomain:             tc.Spec.ClusterDomain,
+		ServerPort:                tc.TiKVServerPort(),
+		StatusPort:                tc.TiKVStatusPort(),
+		PerOrdinalConfig:          tc.Spec.TiKV.PerOrdinalConfig != nil,
 	}
 	if tc.Spec.EnableDynamicConfiguration != nil && *tc.Spec.EnableDynamicConfiguration {
 		scriptModel.AdvertiseStatusAddr = "${POD_NAME}.${HEADLESS_SERVICE_NAME}.${NAMESPACE}.svc" + controller.FormatClusterDomain(tc.Spec.ClusterDomain)
@@ -676,6 +762,22 @@ func labelTiKV(tc *v1alpha1.TidbCluster) label.Label {
 	return label.New().Instance(instanceName).TiKV()
 }
 
+// tikvStorageVolumes returns the additional storage volumes of TiKV,
+// including the separate WAL volume if Spec.TiKV.WalStorageSize is set
+func tikvStorageVolumes(spec *v1alpha1.TiKVSpec) []v1alpha1.StorageVolume {
+	if len(spec.WalStorageSize) == 0 {
+		return spec.StorageVolumes
+	}
+	volumes := make([]v1alpha1.StorageVolume, 0, len(spec.StorageVolumes)+1)
+	volumes = append(volumes, spec.StorageVolumes...)
+	return append(volumes, v1alpha1.StorageVolume{
+		Name:             v1alpha1.TiKVWalStorageVolumeName,
+		StorageClassName: spec.WalStorageClassName,
+		StorageSize:      spec.WalStorageSize,
+		MountPath:        tikvWalVolumeMountPath,
+	})
+}
+
 func (m *tikvMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, set *apps.StatefulSet) error {
 	if set == nil {
 		// skip if not created yet
@@ -703,11 +805,16 @@ func (m *tikvMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, set
 		tc.Status.TiKV.Phase = v1alpha1.NormalPhase
 	}
 
+	if err := syncUpgradeProgress(m.deps.PodLister, tc, v1alpha1.TiKVMemberType, set, tc.Status.TiKV.Phase, &tc.Status.TiKV.Upgrade); err != nil {
+		return err
+	}
+
 	previousStores := tc.Status.TiKV.Stores
 	previousPeerStores := tc.Status.TiKV.PeerStores
 	stores := map[string]v1alpha1.TiKVStore{}
 	peerStores := map[string]v1alpha1.TiKVStore{}
 	tombstoneStores := map[string]v1alpha1.TiKVStore{}
+	storesInfoByID := map[string]*pdapi.StoreInfo{}
 
 	pdCli := controller.GetPDClient(m.deps.PDControl, tc)
 	// This only returns Up/Down/Offline stores
@@ -748,6 +855,7 @@ func (m *tikvMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, set
 		if store.Store != nil {
 			if pattern.Match([]byte(store.Store.Address)) {
 				stores[status.ID] = *status
+				storesInfoByID[status.ID] = store
 			} else if util.MatchLabelFromStoreLabels(store.Store.Labels, label.TiKVLabelVal) {
 				peerStores[status.ID] = *status
 			}
@@ -768,6 +876,11 @@ func (m *tikvMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, set
 		if status == nil {
 			continue
 		}
+		// the time the store becomes tombstone bounds the wait for it to be empty in a scale-in
+		status.LastTransitionTime = metav1.Now()
+		if oldStore, exist := tc.Status.TiKV.TombstoneStores[status.ID]; exist && !oldStore.LastTransitionTime.IsZero() {
+			status.LastTransitionTime = oldStore.LastTransitionTime
+		}
 		tombstoneStores[status.ID] = *status
 	}
 
@@ -781,6 +894,9 @@ func (m *tikvMemberManager) syncTidbClusterStatus(tc *v1alpha1.TidbCluster, set
 	if c != nil {
 		tc.Status.TiKV.Image = c.Image
 	}
+	syncStorageCheckCondition(m.deps, tc)
+	syncStoreHeartbeatCondition(tc, storesInfoByID, time.Now())
+	syncComponentTopology(m.deps, tc, v1alpha1.TiKVMemberType, storeIDsByPodName(stores))
 	return nil
 }
 
@@ -893,7 +1009,7 @@ func (m *tikvMemberManager) storeLabelsEqualNodeLabels(storeLabels []*metapb.Sto
 }
 
 func tikvStatefulSetIsUpgrading(podLister corelisters.PodLister, pdControl pdapi.PDControlInterface, set *apps.StatefulSet, tc *v1alpha1.TidbCluster) (bool, error) {
-	if statefulSetIsUpgrading(set) {
+	if statefulSetIsUpgrading(set) || postUpgradeHooksPending(set) {
 		return true, nil
 	}
 	instanceName := tc.GetInstanceName()
@@ -27,6 +27,7 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/apis/util/toml"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
 	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
@@ -1561,6 +1562,83 @@ func TestTiKVMemberManagerSyncTidbClusterStatus(t *testing.T) {
 				g.Expect(tc.Status.TiKV.Synced).To(BeTrue())
 			},
 		},
+		{
+			name: "fresh store heartbeats",
+			updateTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.StoreHeartbeatStaleThreshold = pointer.StringPtr("1m")
+			},
+			upgradingFn: func(lister corelisters.PodLister, controlInterface pdapi.PDControlInterface, set *apps.StatefulSet, cluster *v1alpha1.TidbCluster) (bool, error) {
+				return false, nil
+			},
+			storeInfo: &pdapi.StoresInfo{
+				Stores: []*pdapi.StoreInfo{
+					newTiKVStoreInfoWithHeartbeat(1, time.Now().Add(-10*time.Second)),
+					newTiKVStoreInfoWithHeartbeat(2, time.Now()),
+				},
+			},
+			tombstoneStoreInfo: &pdapi.StoresInfo{
+				Stores: []*pdapi.StoreInfo{},
+			},
+			errExpectFn: errExpectNil,
+			tcExpectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster) {
+				cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterStoreHeartbeatStale)
+				g.Expect(cond).NotTo(BeNil())
+				g.Expect(cond.Status).To(Equal(corev1.ConditionFalse))
+			},
+		},
+		{
+			name: "stale store heartbeats",
+			updateTC: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.StoreHeartbeatStaleThreshold = pointer.StringPtr("1m")
+			},
+			upgradingFn: func(lister corelisters.PodLister, controlInterface pdapi.PDControlInterface, set *apps.StatefulSet, cluster *v1alpha1.TidbCluster) (bool, error) {
+				return false, nil
+			},
+			storeInfo: &pdapi.StoresInfo{
+				Stores: []*pdapi.StoreInfo{
+					newTiKVStoreInfoWithHeartbeat(1, time.Now().Add(-5*time.Minute)),
+					newTiKVStoreInfoWithHeartbeat(2, time.Now()),
+					// the store has not reported any heartbeat yet
+					newTiKVStoreInfoWithHeartbeat(3, time.Time{}),
+				},
+			},
+			tombstoneStoreInfo: &pdapi.StoresInfo{
+				Stores: []*pdapi.StoreInfo{},
+			},
+			errExpectFn: errExpectNil,
+			tcExpectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster) {
+				// the stale heartbeat does not change the state of the store
+				g.Expect(tc.Status.TiKV.Stores["1"].State).To(Equal(v1alpha1.TiKVStateUp))
+				cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterStoreHeartbeatStale)
+				g.Expect(cond).NotTo(BeNil())
+				g.Expect(cond.Status).To(Equal(corev1.ConditionTrue))
+				g.Expect(cond.Message).To(ContainSubstring("store 1(5m0s)"))
+				g.Expect(cond.Message).NotTo(ContainSubstring("2("))
+				g.Expect(cond.Message).NotTo(ContainSubstring("3("))
+			},
+		},
+		{
+			name: "store heartbeat check is disabled",
+			updateTC: func(tc *v1alpha1.TidbCluster) {
+				utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+					v1alpha1.TidbClusterStoreHeartbeatStale, corev1.ConditionTrue, storeHeartbeatStaleReason, ""))
+			},
+			upgradingFn: func(lister corelisters.PodLister, controlInterface pdapi.PDControlInterface, set *apps.StatefulSet, cluster *v1alpha1.TidbCluster) (bool, error) {
+				return false, nil
+			},
+			storeInfo: &pdapi.StoresInfo{
+				Stores: []*pdapi.StoreInfo{
+					newTiKVStoreInfoWithHeartbeat(1, time.Now().Add(-5*time.Minute)),
+				},
+			},
+			tombstoneStoreInfo: &pdapi.StoresInfo{
+				Stores: []*pdapi.StoreInfo{},
+			},
+			errExpectFn: errExpectNil,
+			tcExpectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster) {
+				g.Expect(utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterStoreHeartbeatStale)).To(BeNil())
+			},
+		},
 	}
 
 	for i := range tests {
@@ -1569,6 +1647,83 @@ func TestTiKVMemberManagerSyncTidbClusterStatus(t *testing.T) {
 	}
 }
 
+func newTiKVStoreInfoWithHeartbeat(id uint64, heartbeat time.Time) *pdapi.StoreInfo {
+	return &pdapi.StoreInfo{
+		Store: &pdapi.MetaStore{
+			Store: &metapb.Store{
+				Id:      id,
+				Address: fmt.Sprintf("%s-tikv-%d.%s-tikv-peer.%s.svc:20160", "test", id, "test", "default"),
+			},
+			StateName: v1alpha1.TiKVStateUp,
+		},
+		Status: &pdapi.StoreStatus{
+			LastHeartbeatTS: heartbeat,
+		},
+	}
+}
+
+func TestTiKVMemberManagerSyncWithPorts(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPD()
+	tc.Status.PD.Members = map[string]v1alpha1.PDMember{
+		"pd-0": {Name: "pd-0", Health: true},
+		"pd-1": {Name: "pd-1", Health: true},
+		"pd-2": {Name: "pd-2", Health: true},
+	}
+	tc.Status.PD.StatefulSet = &apps.StatefulSetStatus{ReadyReplicas: 3}
+	tc.Spec.EnableDynamicConfiguration = pointer.BoolPtr(true)
+	tc.Spec.TiKV.Config = v1alpha1.NewTiKVConfig()
+	tc.Spec.TiKV.EnableNamedStatusPort = true
+	tc.Spec.TiKV.Ports = &v1alpha1.TiKVPorts{
+		Server: pointer.Int32Ptr(30160),
+		Status: pointer.Int32Ptr(30180),
+	}
+
+	tkmm, _, _, pdClient, _, _ := newFakeTiKVMemberManager(tc)
+	pdClient.AddReaction(pdapi.GetConfigActionType, func(action *pdapi.Action) (interface{}, error) {
+		return &v1alpha1.PDConfig{}, nil
+	})
+	pdClient.AddReaction(pdapi.GetStoresActionType, func(action *pdapi.Action) (interface{}, error) {
+		return &pdapi.StoresInfo{}, nil
+	})
+	pdClient.AddReaction(pdapi.GetTombStoneStoresActionType, func(action *pdapi.Action) (interface{}, error) {
+		return &pdapi.StoresInfo{}, nil
+	})
+
+	g.Expect(tkmm.Sync(tc)).To(Succeed())
+
+	svc, err := tkmm.deps.ServiceLister.Services(tc.Namespace).Get(controller.TiKVPeerMemberName(tc.Name))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(svc.Spec.Ports).To(HaveLen(1))
+	g.Expect(svc.Spec.Ports[0].Port).To(Equal(int32(30160)))
+	g.Expect(svc.Spec.Ports[0].TargetPort.IntVal).To(Equal(int32(30160)))
+
+	set, err := tkmm.deps.StatefulSetLister.StatefulSets(tc.Namespace).Get(controller.TiKVMemberName(tc.Name))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(set.Spec.Template.Annotations).To(HaveKeyWithValue("prometheus.io/port", "30180"))
+	var ports []corev1.ContainerPort
+	for _, c := range set.Spec.Template.Spec.Containers {
+		if c.Name == v1alpha1.TiKVMemberType.String() {
+			ports = c.Ports
+		}
+	}
+	g.Expect(ports).To(ConsistOf(
+		corev1.ContainerPort{Name: "server", ContainerPort: 30160, Protocol: corev1.ProtocolTCP},
+		corev1.ContainerPort{Name: "status", ContainerPort: 30180, Protocol: corev1.ProtocolTCP},
+	))
+
+	cm, err := getTikVConfigMap(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	script := cm.Data["startup-script"]
+	g.Expect(script).To(ContainSubstring("--advertise-addr=${POD_NAME}.${HEADLESS_SERVICE_NAME}.${NAMESPACE}.svc:30160"))
+	g.Expect(script).To(ContainSubstring("--addr=0.0.0.0:30160"))
+	g.Expect(script).To(ContainSubstring("--status-addr=0.0.0.0:30180"))
+	g.Expect(script).To(ContainSubstring("--advertise-status-addr=${POD_NAME}.${HEADLESS_SERVICE_NAME}.${NAMESPACE}.svc:30180"))
+	g.Expect(script).NotTo(ContainSubstring("20160"))
+	g.Expect(script).NotTo(ContainSubstring("20180"))
+}
+
 func newFakeTiKVMemberManager(tc *v1alpha1.TidbCluster) (
 	*tikvMemberManager, *controller.FakeStatefulSetControl,
 	*controller.FakeServiceControl, *pdapi.FakePDClient, cache.Indexer, cache.Indexer) {
@@ -1670,6 +1825,7 @@ func TestGetNewTiKVServiceForTidbCluster(t *testing.T) {
 
 func TestGetNewTiKVSetForTidbCluster(t *testing.T) {
 	enable := true
+	pullAlways := corev1.PullAlways
 	tests := []struct {
 		name    string
 		tc      v1alpha1.TidbCluster
@@ -1926,6 +2082,125 @@ func TestGetNewTiKVSetForTidbCluster(t *testing.T) {
 				}))
 			},
 		},
+		{
+			name: "tikv spec securityContext",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					PD:   &v1alpha1.PDSpec{},
+					TiDB: &v1alpha1.TiDBSpec{},
+					TiKV: &v1alpha1.TiKVSpec{
+						ComponentSpec: v1alpha1.ComponentSpec{
+							Annotations: map[string]string{label.AnnSysctlInit: label.AnnSysctlInitVal},
+							PodSecurityContext: &corev1.PodSecurityContext{
+								Sysctls: []corev1.Sysctl{{Name: "net.core.somaxconn", Value: "32768"}},
+							},
+							SecurityContext: &corev1.SecurityContext{
+								ReadOnlyRootFilesystem: pointer.BoolPtr(true),
+								Capabilities:           &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+							},
+						},
+					},
+				},
+			},
+			testSts: func(sts *apps.StatefulSet) {
+				g := NewGomegaWithT(t)
+				c := sts.Spec.Template.Spec.Containers[0]
+				g.Expect(c.Name).To(Equal(v1alpha1.TiKVMemberType.String()))
+				g.Expect(c.SecurityContext).To(Equal(&corev1.SecurityContext{
+					Privileged:             pointer.BoolPtr(false),
+					ReadOnlyRootFilesystem: pointer.BoolPtr(true),
+					Capabilities:           &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+				}))
+				g.Expect(c.VolumeMounts).To(ContainElement(corev1.VolumeMount{Name: tmpVolumeName, MountPath: "/tmp"}))
+				// the sysctl init container keeps the privileges it needs
+				g.Expect(sts.Spec.Template.Spec.InitContainers).To(HaveLen(1))
+				g.Expect(*sts.Spec.Template.Spec.InitContainers[0].SecurityContext.Privileged).To(BeTrue())
+			},
+		},
+		{
+			name: "tikv spec walStorageSize",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+
+				Spec: v1alpha1.TidbClusterSpec{
+					PD:   &v1alpha1.PDSpec{},
+					TiDB: &v1alpha1.TiDBSpec{},
+					TiKV: &v1alpha1.TiKVSpec{
+						StorageClassName:    pointer.StringPtr("standard"),
+						WalStorageSize:      "2Gi",
+						WalStorageClassName: pointer.StringPtr("fast"),
+					},
+				},
+			},
+			testSts: func(sts *apps.StatefulSet) {
+				g := NewGomegaWithT(t)
+				q, _ := resource.ParseQuantity("2Gi")
+				g.Expect(sts.Spec.VolumeClaimTemplates).To(HaveLen(2))
+				g.Expect(sts.Spec.VolumeClaimTemplates[1]).To(Equal(v1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: v1alpha1.TiKVMemberType.String() + "-wal",
+					},
+					Spec: corev1.PersistentVolumeClaimSpec{
+						AccessModes: []corev1.PersistentVolumeAccessMode{
+							corev1.ReadWriteOnce,
+						},
+						StorageClassName: pointer.StringPtr("fast"),
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceStorage: q,
+							},
+						},
+					},
+				}))
+				index := len(sts.Spec.Template.Spec.Containers[0].VolumeMounts) - 1
+				g.Expect(sts.Spec.Template.Spec.Containers[0].VolumeMounts[index]).To(Equal(corev1.VolumeMount{
+					Name: fmt.Sprintf("%s-%s", v1alpha1.TiKVMemberType, "wal"), MountPath: tikvWalVolumeMountPath,
+				}))
+			},
+		},
+		{
+			name: "tikv image pull policy overrides the cluster-level one",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					TiKV: &v1alpha1.TiKVSpec{
+						ComponentSpec: v1alpha1.ComponentSpec{
+							ImagePullPolicy: &pullAlways,
+						},
+					},
+					PD:   &v1alpha1.PDSpec{},
+					TiDB: &v1alpha1.TiDBSpec{},
+				},
+			},
+			testSts: testImagePullPolicy(t, corev1.PullAlways, v1alpha1.TiKVMemberType),
+		},
+		{
+			name: "tikv image pull policy defaults to the cluster-level one",
+			tc: v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tc",
+					Namespace: "ns",
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					TiKV:            &v1alpha1.TiKVSpec{},
+					PD:              &v1alpha1.PDSpec{},
+					TiDB:            &v1alpha1.TiDBSpec{},
+				},
+			},
+			testSts: testImagePullPolicy(t, corev1.PullIfNotPresent, v1alpha1.TiKVMemberType),
+		},
 		// TODO add more tests
 	}
 
@@ -2290,6 +2565,26 @@ func TestTiKVInitContainers(t *testing.T) {
 	}
 }
 
+func TestWithTiKVZoneSpreadConstraint(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPD()
+	constraints := withTiKVZoneSpreadConstraint(tc, nil)
+	g.Expect(constraints).To(HaveLen(1))
+	g.Expect(constraints[0].TopologyKey).To(Equal(corev1.LabelZoneFailureDomainStable))
+	g.Expect(constraints[0].MaxSkew).To(Equal(int32(1)))
+	g.Expect(constraints[0].LabelSelector.MatchLabels).To(HaveKeyWithValue(label.ComponentLabelKey, label.TiKVLabelVal))
+
+	// the constraint on the zone label of the spec is kept
+	hostConstraint := corev1.TopologySpreadConstraint{MaxSkew: 1, TopologyKey: corev1.LabelHostname}
+	zoneConstraint := corev1.TopologySpreadConstraint{MaxSkew: 2, TopologyKey: corev1.LabelZoneFailureDomain}
+	constraints = withTiKVZoneSpreadConstraint(tc, []corev1.TopologySpreadConstraint{hostConstraint, zoneConstraint})
+	g.Expect(constraints).To(Equal([]corev1.TopologySpreadConstraint{hostConstraint, zoneConstraint}))
+	constraints = withTiKVZoneSpreadConstraint(tc, []corev1.TopologySpreadConstraint{hostConstraint})
+	g.Expect(constraints).To(HaveLen(2))
+	g.Expect(constraints[1].TopologyKey).To(Equal(corev1.LabelZoneFailureDomainStable))
+}
+
 func TestGetTiKVConfigMap(t *testing.T) {
 	g := NewGomegaWithT(t)
 	updateStrategy := v1alpha1.ConfigUpdateStrategyInPlace
@@ -2510,3 +2805,84 @@ func mustTiKVConfig(x interface{}) *v1alpha1.TiKVConfigWraper {
 
 	return c
 }
+
+func TestGetTiKVConfigMapWalStorage(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForTiKV()
+	tc.Spec.TiKV.Config = v1alpha1.NewTiKVConfig()
+	tc.Spec.TiKV.Config.Set("raftdb.wal-dir", "/var/lib/raft-wal")
+	tc.Spec.TiKV.WalStorageSize = "10Gi"
+
+	cm, err := getTikVConfigMap(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cm.Data["config-file"]).To(ContainSubstring(`wal-dir = "/var/lib/tikv-wal/rocksdb"`))
+	// the WAL directory set explicitly is kept
+	g.Expect(cm.Data["config-file"]).To(ContainSubstring(`wal-dir = "/var/lib/raft-wal"`))
+	// the WAL directories are not written back to the spec
+	g.Expect(tc.Spec.TiKV.Config.Get("rocksdb.wal-dir")).To(BeNil())
+
+	tc.Spec.TiKV.WalStorageSize = ""
+	cm, err = getTikVConfigMap(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cm.Data["config-file"]).NotTo(ContainSubstring("/var/lib/tikv-wal"))
+}
+
+func TestGetTiKVConfigMapPerOrdinalConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForTiKV()
+	tc.Spec.TiKV.Config = v1alpha1.NewTiKVConfig()
+	tc.Spec.TiKV.Config.Set("storage.block-cache.capacity", "8GB")
+	tc.Spec.TiKV.Config.Set("log-level", "info")
+	override := v1alpha1.NewTiKVConfig()
+	override.Set("storage.block-cache.capacity", "4GB")
+	tc.Spec.TiKV.PerOrdinalConfig = map[string]*v1alpha1.TiKVConfigWraper{"1": override}
+
+	cm, err := getTikVConfigMap(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	// the overrides are kept out of the ConfigMap of TiKV so that they do not roll all the Pods
+	g.Expect(cm.Data).To(HaveLen(2))
+	g.Expect(cm.Data["config-file"]).To(ContainSubstring(`capacity = "8GB"`))
+	g.Expect(cm.Data["startup-script"]).To(ContainSubstring("/etc/tikv-ordinal/config-${POD_NAME##*-}.toml"))
+
+	data, err := getTiKVOrdinalConfig(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(data).To(HaveLen(1))
+	// the override is merged into the base config
+	g.Expect(data["1"]).To(ContainSubstring(`capacity = "4GB"`))
+	g.Expect(data["1"]).To(ContainSubstring(`log-level = "info"`))
+	// the override is not written back to the spec
+	g.Expect(tc.Spec.TiKV.Config.Get("storage.block-cache.capacity").MustString()).To(Equal("8GB"))
+
+	// changing the overrides does not change the ConfigMap of TiKV
+	override.Set("storage.block-cache.capacity", "2GB")
+	newCm, err := getTikVConfigMap(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(newCm.Data).To(Equal(cm.Data))
+
+	set, err := getNewTiKVSetForTidbCluster(tc, cm)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(set.Spec.Template.Spec.Volumes).To(ContainElement(corev1.Volume{
+		Name: ordinalConfigVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "test-ordinal-tikv"},
+				Optional:             pointer.BoolPtr(true),
+			},
+		},
+	}))
+
+	tc.Spec.TiKV.PerOrdinalConfig = nil
+	cm, err = getTikVConfigMap(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cm.Data["startup-script"]).To(ContainSubstring("--config=/etc/tikv/tikv.toml"))
+	data, err = getTiKVOrdinalConfig(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(data).To(BeNil())
+	set, err = getNewTiKVSetForTidbCluster(tc, cm)
+	g.Expect(err).NotTo(HaveOccurred())
+	for _, vol := range set.Spec.Template.Spec.Volumes {
+		g.Expect(vol.Name).NotTo(Equal(ordinalConfigVolumeName))
+	}
+}
@@ -18,9 +18,11 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/pingcap/advanced-statefulset/client/apis/apps/v1/helper"
 	"github.com/pingcap/tidb-operator/pkg/apis/label"
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
 	"github.com/pingcap/tidb-operator/pkg/util"
 	apps "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
@@ -36,15 +38,47 @@ type tikvScaler struct {
 }
 
 // NewTiKVScaler returns a tikv Scaler
-func NewTiKVScaler(deps *controller.Dependencies) *tikvScaler {
-	return &tikvScaler{generalScaler: generalScaler{deps: deps}}
+func NewTiKVScaler(deps *controller.Dependencies) Scaler {
+	return withScaleMetrics(v1alpha1.TiKVMemberType, &tikvScaler{generalScaler: generalScaler{deps: deps}})
 }
 
 func (s *tikvScaler) Scale(meta metav1.Object, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	// the dry run of a scale changes nothing, including the cleanups below
+	if s.scaleDryRun(meta, v1alpha1.TiKVMemberType, oldSet, newSet) {
+		return nil
+	}
+	if err := clearCancelledPreScaleInHooks(s.deps, meta, newSet); err != nil {
+		return err
+	}
+	if err := s.clearCancelledScaleInEvictions(meta, newSet); err != nil {
+		return err
+	}
+	if err := skipScaleInProtectedPods(s.deps, meta, v1alpha1.TiKVMemberType, oldSet, newSet); err != nil {
+		return err
+	}
+	if s.deferScaleToMaintenanceWindow(meta, v1alpha1.TiKVMemberType, oldSet, newSet) {
+		return nil
+	}
+	if deferred, err := s.deferScale(meta, v1alpha1.TiKVMemberType, oldSet, newSet); err != nil || deferred {
+		return err
+	}
+	if err := s.checkScaleVelocity(meta, v1alpha1.TiKVMemberType, oldSet, newSet); err != nil {
+		return err
+	}
 	scaling, _, _, _ := scaleOne(oldSet, newSet)
+	if scaling != 0 {
+		if err := checkPDSplitBrain(meta, oldSet, newSet); err != nil {
+			return err
+		}
+	}
 	if scaling > 0 {
+		if err := checkResourceQuota(s.deps, meta, oldSet, newSet); err != nil {
+			return err
+		}
+		s.recordScale(meta, v1alpha1.TiKVMemberType, oldSet, newSet)
 		return s.ScaleOut(meta, oldSet, newSet)
 	} else if scaling < 0 {
+		s.recordScale(meta, v1alpha1.TiKVMemberType, oldSet, newSet)
 		return s.ScaleIn(meta, oldSet, newSet)
 	}
 	// we only sync auto scaler annotations when we are finishing syncing scaling
@@ -66,13 +100,21 @@ func (s *tikvScaler) ScaleOut(meta metav1.Object, oldSet *apps.StatefulSet, newS
 	default:
 		return fmt.Errorf("tikv.ScaleOut, failed to convert cluster %s/%s", meta.GetNamespace(), meta.GetName())
 	}
+	// the PVCs wiped are unmarked and used by the new store, the others are deleted first
+	tc := meta.(*v1alpha1.TidbCluster)
+	if err := s.checkStalePVC(tc, v1alpha1.TiKVMemberType, ordinal); err != nil {
+		return err
+	}
 	_, err := s.deps.PVCLister.PersistentVolumeClaims(meta.GetNamespace()).Get(pvcName)
 	if err == nil {
 		_, err = s.deleteDeferDeletingPVC(obj, v1alpha1.TiKVMemberType, ordinal)
 		if err != nil {
 			return err
 		}
-		return controller.RequeueErrorf("tikv.ScaleOut, cluster %s/%s ready to scale out, wait for next round", meta.GetNamespace(), meta.GetName())
+		// the PVC left with the Wipe policy is wiped and kept for the new store
+		if tc.StalePVCPolicy() != v1alpha1.StalePVCPolicyWipe {
+			return controller.RequeueErrorf("tikv.ScaleOut, cluster %s/%s ready to scale out, wait for next round", meta.GetNamespace(), meta.GetName())
+		}
 	} else if !errors.IsNotFound(err) {
 		return fmt.Errorf("tikv.ScaleOut, cluster %s/%s failed to fetch pvc informaiton, err:%v", meta.GetNamespace(), meta.GetName(), err)
 	}
@@ -121,13 +163,39 @@ func (s *tikvScaler) ScaleIn(meta metav1.Object, oldSet *apps.StatefulSet, newSe
 			if err != nil {
 				return err
 			}
-			if state != v1alpha1.TiKVStateOffline {
+			action, err := getStoreScaleInAction(state)
+			if err != nil {
+				return fmt.Errorf("tikvScaler.ScaleIn: can't scale in tikv %s/%s, store %d: %v", ns, podName, id, err)
+			}
+			if action == storeScaleInRemove {
+				// the store is moved to the tombstone stores in the next status sync
+				return controller.RequeueErrorf("TiKV %s/%s store %d is tombstone, waiting for the status to be synced", ns, podName, id)
+			}
+			if action == storeScaleInDelete {
+				if tc.TiKVBalanceZones() {
+					if err := s.checkZoneBalance(tc, podName); err != nil {
+						return err
+					}
+				}
+				if err := runPreScaleInHook(s.deps, tc, v1alpha1.TiKVMemberType, ordinal); err != nil {
+					return err
+				}
+				if tc.TiKVEvictLeaderBeforeScaleIn() {
+					if err := s.evictLeaderBeforeScaleIn(tc, pod, id); err != nil {
+						return err
+					}
+				}
+				if err := reservePDDeletion(s.deps, tc, "store", store.ID); err != nil {
+					recordScaleBlocked(tc, v1alpha1.TiKVMemberType, "PDDeletionDeferred")
+					return err
+				}
 				if err := controller.GetPDClient(s.deps.PDControl, tc).DeleteStore(id); err != nil {
 					klog.Errorf("tikvScaler.ScaleIn: failed to delete store %d, %v", id, err)
 					return err
 				}
 				klog.Infof("tikvScaler.ScaleIn: delete store %d for tikv %s/%s successfully", id, ns, podName)
 			}
+			// the PVCs must be kept until the store becomes tombstone
 			return controller.RequeueErrorf("TiKV %s/%s store %d is still in cluster, state: %s", ns, podName, id, state)
 		}
 	}
@@ -140,8 +208,10 @@ func (s *tikvScaler) ScaleIn(meta metav1.Object, oldSet *apps.StatefulSet, newSe
 				return err
 			}
 
-			// TODO: double check if store is really not in Up/Offline/Down state
 			klog.Infof("TiKV %s/%s store %d becomes tombstone", ns, podName, id)
+			if err := s.checkTombstoneStoreEmpty(tc, podName, id, store); err != nil {
+				return err
+			}
 
 			pvcs, err := util.ResolvePVCFromPod(pod, s.deps.PVCLister)
 			if err != nil {
@@ -200,12 +270,223 @@ func (s *tikvScaler) ScaleIn(meta metav1.Object, oldSet *apps.StatefulSet, newSe
 	return fmt.Errorf("TiKV %s/%s not found in cluster", ns, podName)
 }
 
+// evictLeaderBeforeScaleIn evicts the Region leaders from the store before it
+// is deleted, it returns nil once the Pod has no leaders or the eviction times
+// out. The evict leader scheduler is removed by cleanupStaleEvictLeaderSchedulers
+// once the store becomes tombstone or is deleted, or by
+// clearCancelledScaleInEvictions if the scale-in is cancelled.
+func (s *tikvScaler) evictLeaderBeforeScaleIn(tc *v1alpha1.TidbCluster, pod *v1.Pod, storeID uint64) error {
+	ns := tc.GetNamespace()
+	if _, evicting := pod.Annotations[EvictLeaderBeginTime]; evicting {
+		if !isLeaderEvicted(s.deps, tc, pod) {
+			return controller.RequeueAfterErrorf(evictLeaderRetryAfter(tc, pod), "TiKV %s/%s store %d is evicting leaders, can't scale in now", ns, pod.Name, storeID)
+		}
+		return nil
+	}
+
+	pods := []*v1.Pod{pod}
+	started, err := beginEvictLeaders(s.deps, tc, []uint64{storeID}, pods)
+	if err != nil {
+		return err
+	}
+	if len(started) == 0 {
+		return controller.RequeueErrorf("TiKV %s/%s store %d waits for the other stores to finish evicting leaders, can't scale in now", ns, pod.Name, storeID)
+	}
+	if _, ok := pods[0].Annotations[EvictLeaderBeginTime]; !ok {
+		// the store is already evicting leaders, e.g. for an upgrade
+		if err := setEvictLeaderBeginTime(s.deps, tc, pods[0].DeepCopy()); err != nil {
+			return err
+		}
+	}
+	return controller.RequeueErrorf("TiKV %s/%s store %d begins evicting leaders, can't scale in now", ns, pod.Name, storeID)
+}
+
+// clearCancelledScaleInEvictions cancels the leader eviction of the Pods
+// whose scale-in is cancelled, e.g. the replicas are raised back, otherwise
+// the evict leader schedulers of their stores are kept forever. The Pods
+// evicting leaders for an upgrade or a restart are left alone.
+func (s *tikvScaler) clearCancelledScaleInEvictions(meta metav1.Object, newSet *apps.StatefulSet) error {
+	tc, ok := meta.(*v1alpha1.TidbCluster)
+	if !ok || s.deps.CLIConfig.PodWebhookEnabled {
+		return nil
+	}
+	if tc.Status.TiKV.Phase == v1alpha1.UpgradePhase ||
+		tc.Status.TiKV.StatefulSet == nil || tc.Status.TiKV.StatefulSet.UpdateRevision != tc.Status.TiKV.StatefulSet.CurrentRevision {
+		return nil
+	}
+	ns := tc.GetNamespace()
+	for ordinal := range helper.GetPodOrdinals(*newSet.Spec.Replicas, newSet) {
+		podName := fmt.Sprintf("%s-%d", newSet.Name, ordinal)
+		pod, err := s.deps.PodLister.Pods(ns).Get(podName)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("clearCancelledScaleInEvictions: failed to get pod %s/%s for tc %s/%s, error: %s", ns, podName, ns, tc.GetName(), err)
+		}
+		if _, evicting := pod.Annotations[EvictLeaderBeginTime]; !evicting {
+			continue
+		}
+		if _, restarting := podRestartRequested(tc, v1alpha1.TiKVMemberType, pod); restarting {
+			continue
+		}
+		klog.Infof("tc[%s/%s]'s scale-in of tikv pod %s is cancelled, cancel its leader eviction", ns, tc.GetName(), podName)
+		if err := cancelEvictLeader(s.deps, tc, pod); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkZoneBalance returns a requeue error if deleting the store of the Pod
+// leaves the Up stores unbalanced across the zones, i.e. the zone of the store
+// does not have the most stores or the store is the last one of the zone.
+func (s *tikvScaler) checkZoneBalance(tc *v1alpha1.TidbCluster, podName string) error {
+	ns := tc.GetNamespace()
+	if tc.Annotations[label.AnnForceUnbalancedScaleIn] == label.AnnForceUnbalancedScaleInVal {
+		return nil
+	}
+	zones, err := tikvStoreZones(s.deps, tc)
+	if err != nil {
+		return err
+	}
+	zone := zones[podName]
+	if zone == "" {
+		klog.Warningf("tikvScaler.ScaleIn: zone of tikv %s/%s is unknown, skip checking the zone balance", ns, podName)
+		return nil
+	}
+
+	counts := map[string]int{}
+	maxCount := 0
+	for _, z := range zones {
+		if z == "" {
+			continue
+		}
+		counts[z]++
+		if counts[z] > maxCount {
+			maxCount = counts[z]
+		}
+	}
+	var reason string
+	switch {
+	case counts[zone] == 1:
+		reason = fmt.Sprintf("it is the last store of zone %s", zone)
+	case counts[zone] < maxCount:
+		reason = fmt.Sprintf("zone %s has %d stores, fewer than the other zones with %d stores", zone, counts[zone], maxCount)
+	default:
+		return nil
+	}
+	msg := fmt.Sprintf("can't scale in TiKV Pod %s as %s, annotate the cluster with %s=%s to scale in anyway",
+		podName, reason, label.AnnForceUnbalancedScaleIn, label.AnnForceUnbalancedScaleInVal)
+	s.deps.Recorder.Event(tc, v1.EventTypeWarning, "FailedScaleIn", msg)
+	return controller.RequeueErrorf("tc[%s/%s]'s %s", ns, tc.GetName(), msg)
+}
+
+// tikvStoreZones maps the Pod name of each Up store to the zone of the store.
+// The zone is the one of the node in the topology status, or the zone label of
+// the store in PD if the node has none.
+func tikvStoreZones(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) (map[string]string, error) {
+	podZones := map[string]string{}
+	if tc.Status.Topology != nil {
+		for _, member := range tc.Status.Topology.TiKV {
+			podZones[member.PodName] = member.Zone
+		}
+	}
+
+	zones := map[string]string{}
+	var storesInfo *pdapi.StoresInfo
+	for _, store := range tc.Status.TiKV.Stores {
+		if store.State != v1alpha1.TiKVStateUp {
+			continue
+		}
+		zone := podZones[store.PodName]
+		if zone == "" {
+			if storesInfo == nil {
+				info, err := controller.GetPDClient(deps.PDControl, tc).GetStores()
+				if err != nil {
+					return nil, fmt.Errorf("tikvStoreZones: failed to get stores of tc %s/%s, error: %v", tc.GetNamespace(), tc.GetName(), err)
+				}
+				storesInfo = info
+			}
+			zone = pdStoreZone(storesInfo, store.ID)
+		}
+		zones[store.PodName] = zone
+	}
+	return zones, nil
+}
+
+// pdStoreZone returns the zone label of the store in PD
+func pdStoreZone(storesInfo *pdapi.StoresInfo, id string) string {
+	for _, info := range storesInfo.Stores {
+		if info.Store == nil || info.Store.Store == nil || strconv.FormatUint(info.Store.Id, 10) != id {
+			continue
+		}
+		for _, l := range info.Store.Labels {
+			if l.Key == "zone" {
+				return l.Value
+			}
+		}
+	}
+	return ""
+}
+
+// checkTombstoneStoreEmpty checks that PD reports the store of the Pod in
+// Tombstone state and with no Regions, so that the StatefulSet is not scaled in
+// while the Regions are still migrated off the store. The check is skipped with
+// a warning Event once the store has been tombstone for longer than
+// `.spec.tikv.scaleInStoreEmptyTimeout`. The store removed from PD is empty.
+func (s *tikvScaler) checkTombstoneStoreEmpty(tc *v1alpha1.TidbCluster, podName string, id uint64, store v1alpha1.TiKVStore) error {
+	ns := tc.GetNamespace()
+	info, err := controller.GetPDClient(s.deps.PDControl, tc).GetStore(id)
+	if err == pdapi.ErrStoreNotFound {
+		klog.Infof("tikvScaler.ScaleIn: store %d of tikv %s/%s has been removed from PD", id, ns, podName)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("tikvScaler.ScaleIn: failed to get store %d of tikv %s/%s, error: %v", id, ns, podName, err)
+	}
+	state := ""
+	if info.Store != nil {
+		state = info.Store.StateName
+	}
+	regionCount := 0
+	if info.Status != nil {
+		regionCount = info.Status.RegionCount
+	}
+	if state == v1alpha1.TiKVStateTombstone && regionCount == 0 {
+		return nil
+	}
+
+	timeout := tc.TiKVScaleInStoreEmptyTimeout()
+	if time.Since(store.LastTransitionTime.Time) < timeout {
+		return controller.RequeueErrorf("TiKV %s/%s store %d is not empty yet, state: %s, region count: %d", ns, podName, id, state, regionCount)
+	}
+	msg := fmt.Sprintf("TiKV store %d of Pod %s still reports state %s and %d regions %v after it becomes tombstone, scale in the Pod anyway",
+		id, podName, state, regionCount, timeout)
+	klog.Warningf("tikvScaler.ScaleIn: tc[%s/%s]'s %s", ns, tc.GetName(), msg)
+	s.deps.Recorder.Event(tc, v1.EventTypeWarning, "StoreNotEmpty", msg)
+	return nil
+}
+
 func (s *tikvScaler) preCheckUpStores(tc *v1alpha1.TidbCluster, podName string) (bool, error) {
 	if !tc.TiKVBootStrapped() {
 		klog.Infof("TiKV of Cluster %s/%s is not bootstrapped yet, skip pre check when scale in TiKV", tc.Namespace, tc.Name)
 		return true, nil
 	}
 
+	// get the state of the store which is about to be scaled in
+	storeState := ""
+	for _, store := range tc.Status.TiKV.Stores {
+		if store.PodName == podName {
+			storeState = store.State
+		}
+	}
+	// only deleting the store moves its regions, the store that is already
+	// Offline or Tombstone, or has never joined, does not change the serving stores
+	if action, err := getStoreScaleInAction(storeState); err != nil || action != storeScaleInDelete {
+		return true, nil
+	}
+
 	pdClient := controller.GetPDClient(s.deps.PDControl, tc)
 	// get the number of stores whose state is up
 	upNumber := 0
@@ -217,20 +498,12 @@ func (s *tikvScaler) preCheckUpStores(tc *v1alpha1.TidbCluster, podName string)
 	// filter out TiFlash
 	for _, store := range storesInfo.Stores {
 		if store.Store != nil {
-			if store.Store.StateName == v1alpha1.TiKVStateUp && util.MatchLabelFromStoreLabels(store.Store.Labels, label.TiKVLabelVal) {
+			if storeServes(store.Store.StateName) && util.MatchLabelFromStoreLabels(store.Store.Labels, label.TiKVLabelVal) {
 				upNumber++
 			}
 		}
 	}
 
-	// get the state of the store which is about to be scaled in
-	storeState := ""
-	for _, store := range tc.Status.TiKV.Stores {
-		if store.PodName == podName {
-			storeState = store.State
-		}
-	}
-
 	config, err := pdClient.GetConfig()
 	if err != nil {
 		return false, err
@@ -242,7 +515,7 @@ func (s *tikvScaler) preCheckUpStores(tc *v1alpha1.TidbCluster, podName string)
 		s.deps.Recorder.Event(tc, v1.EventTypeWarning, "FailedScaleIn", errMsg)
 		return false, nil
 	} else if upNumber == int(maxReplicas) {
-		if storeState == v1alpha1.TiKVStateUp {
+		if storeServes(storeState) {
 			errMsg := fmt.Sprintf("can't scale in TiKV of TidbCluster [%s/%s], cause the number of up stores is equal to MaxReplicas in PD configuration(%d), and the store in Pod %s which is going to be deleted is up too", tc.GetNamespace(), tc.GetName(), maxReplicas, podName)
 			klog.Error(errMsg)
 			s.deps.Recorder.Event(tc, v1.EventTypeWarning, "FailedScaleIn", errMsg)
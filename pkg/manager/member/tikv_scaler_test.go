@@ -14,6 +14,7 @@
 package member
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -24,10 +25,14 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	"github.com/pingcap/tidb-operator/pkg/tikvapi"
+	apps "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 )
 
@@ -144,6 +149,77 @@ func TestTiKVScalerScaleOut(t *testing.T) {
 	}
 }
 
+func TestTiKVScalerScaleOutWipeStalePVC(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPD()
+	tc.Status.TiKV.BootStrapped = true
+	policy := v1alpha1.StalePVCPolicyWipe
+	tc.Spec.StalePVCPolicy = &policy
+	scaler, _, pvcIndexer, _, _ := newFakeTiKVScaler()
+	jobIndexer := scaler.deps.KubeInformerFactory.Batch().V1().Jobs().Informer().GetIndexer()
+
+	oldSet := newStatefulSetForPDScale()
+	oldSet.Name = fmt.Sprintf("%s-tikv", tc.Name)
+	pvc := newPVCForStatefulSet(oldSet, v1alpha1.TiKVMemberType, tc.Name)
+	pvc.Annotations = map[string]string{label.AnnPVCDeferDeleting: time.Now().Format(time.RFC3339)}
+	pvcIndexer.Add(pvc)
+	_, err := scaler.deps.KubeClientset.CoreV1().PersistentVolumeClaims(pvc.Namespace).Create(context.TODO(), pvc, metav1.CreateOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	scaleOut := func() (int32, error) {
+		newSet := oldSet.DeepCopy()
+		newSet.Spec.Replicas = pointer.Int32Ptr(7)
+		err := scaler.ScaleOut(tc, oldSet, newSet)
+		return *newSet.Spec.Replicas, err
+	}
+
+	// the deferred PVC is wiped instead of being deleted
+	replicas, err := scaleOut()
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(replicas).To(Equal(int32(5)))
+	obj, exists, err := jobIndexer.GetByKey(fmt.Sprintf("%s/%s-wipe", pvc.Namespace, pvc.Name))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(exists).To(BeTrue())
+	job := obj.(*batchv1.Job).DeepCopy()
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+	jobIndexer.Update(job)
+
+	// the wiped PVC is unmarked and the scale-out is requeued
+	replicas, err = scaleOut()
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(replicas).To(Equal(int32(5)))
+	obj, exists, err = pvcIndexer.GetByKey(fmt.Sprintf("%s/%s", pvc.Namespace, pvc.Name))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(exists).To(BeTrue())
+	unmarked := obj.(*corev1.PersistentVolumeClaim)
+	g.Expect(unmarked.Annotations).NotTo(HaveKey(label.AnnPVCDeferDeleting))
+
+	// the PVC still marked in the lister is neither wiped again nor deleted
+	_, err = scaler.deps.KubeClientset.CoreV1().PersistentVolumeClaims(pvc.Namespace).Update(context.TODO(), unmarked, metav1.UpdateOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	pvcIndexer.Update(pvc)
+	jobIndexer.Delete(job)
+	replicas, err = scaleOut()
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(replicas).To(Equal(int32(5)))
+	_, exists, err = pvcIndexer.GetByKey(fmt.Sprintf("%s/%s", pvc.Namespace, pvc.Name))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(exists).To(BeTrue())
+	_, exists, err = jobIndexer.GetByKey(fmt.Sprintf("%s/%s-wipe", pvc.Namespace, pvc.Name))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(exists).To(BeFalse())
+
+	// the wiped PVC is kept for the new store
+	pvcIndexer.Update(unmarked)
+	replicas, err = scaleOut()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(replicas).To(Equal(int32(6)))
+	_, exists, err = pvcIndexer.GetByKey(fmt.Sprintf("%s/%s", pvc.Namespace, pvc.Name))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(exists).To(BeTrue())
+}
+
 func TestTiKVScalerScaleIn(t *testing.T) {
 	g := NewGomegaWithT(t)
 	type testcase struct {
@@ -156,9 +232,13 @@ func TestTiKVScalerScaleIn(t *testing.T) {
 		isPodReady    bool
 		hasSynced     bool
 		pvcUpdateErr  bool
-		errExpectFn   func(*GomegaWithT, error)
-		changed       bool
-		getStoresFn   func(action *pdapi.Action) (interface{}, error)
+		// deletionLimited makes the deletion of the store exceed the deletion rate limit
+		deletionLimited bool
+		errExpectFn     func(*GomegaWithT, error)
+		changed         bool
+		getStoresFn     func(action *pdapi.Action) (interface{}, error)
+		getStoreFn      func(action *pdapi.Action) (interface{}, error)
+		expectEvents    []string
 	}
 
 	resyncDuration := time.Duration(0)
@@ -255,6 +335,10 @@ func TestTiKVScalerScaleIn(t *testing.T) {
 			}
 		}
 		pdClient.AddReaction(pdapi.GetStoresActionType, test.getStoresFn)
+		if test.getStoreFn == nil {
+			test.getStoreFn = emptyTombstoneStoreFn
+		}
+		pdClient.AddReaction(pdapi.GetStoreActionType, test.getStoreFn)
 
 		if test.delStoreErr {
 			pdClient.AddReaction(pdapi.DeleteStoreActionType, func(action *pdapi.Action) (interface{}, error) {
@@ -264,9 +348,25 @@ func TestTiKVScalerScaleIn(t *testing.T) {
 		if test.pvcUpdateErr {
 			pvcControl.SetUpdatePVCError(errors.NewInternalError(fmt.Errorf("API server failed")), 0)
 		}
+		if test.deletionLimited {
+			scaler.deps.PDDeletionLimiter = controller.NewPDDeletionLimiter(1, time.Minute)
+			scaler.deps.PDDeletionLimiter.Reserve(tc, "store/2")
+		}
 
 		err := scaler.ScaleIn(tc, oldSet, newSet)
 		test.errExpectFn(g, err)
+		if test.deletionLimited {
+			events := collectEvents(scaler.deps.Recorder.(*record.FakeRecorder).Events)
+			g.Expect(events).To(HaveLen(1))
+			g.Expect(events[0]).To(ContainSubstring("PDDeletionDeferred"))
+		}
+		if test.expectEvents != nil {
+			events := collectEvents(scaler.deps.Recorder.(*record.FakeRecorder).Events)
+			g.Expect(events).To(HaveLen(len(test.expectEvents)))
+			for i := range events {
+				g.Expect(events[i]).To(ContainSubstring(test.expectEvents[i]))
+			}
+		}
 		if test.changed {
 			g.Expect(int(*newSet.Spec.Replicas)).To(Equal(4))
 		} else {
@@ -329,6 +429,20 @@ func TestTiKVScalerScaleIn(t *testing.T) {
 			errExpectFn:   errExpectNotNil,
 			changed:       false,
 		},
+		{
+			name:            "store state is up, deletion of store is deferred by the rate limit",
+			tikvUpgrading:   false,
+			storeFun:        normalStoreFun,
+			delStoreErr:     true,
+			hasPVC:          true,
+			storeIDSynced:   true,
+			isPodReady:      true,
+			hasSynced:       true,
+			pvcUpdateErr:    false,
+			deletionLimited: true,
+			errExpectFn:     errExpectRequeue,
+			changed:         false,
+		},
 		{
 			name:          "tikv pod is not ready now, not sure if the status has been synced",
 			tikvUpgrading: false,
@@ -422,6 +536,66 @@ func TestTiKVScalerScaleIn(t *testing.T) {
 			errExpectFn:   errExpectNil,
 			changed:       true,
 		},
+		{
+			name:          "store state is tombstone, regions are not migrated yet",
+			tikvUpgrading: false,
+			storeFun: func(tc *v1alpha1.TidbCluster) {
+				tombstoneStoreFun(tc)
+				store := tc.Status.TiKV.TombstoneStores["1"]
+				store.LastTransitionTime = metav1.Now()
+				tc.Status.TiKV.TombstoneStores["1"] = store
+			},
+			delStoreErr:   false,
+			hasPVC:        true,
+			storeIDSynced: true,
+			isPodReady:    true,
+			hasSynced:     true,
+			pvcUpdateErr:  false,
+			getStoreFn:    nonEmptyTombstoneStoreFn,
+			errExpectFn:   errExpectRequeue,
+			changed:       false,
+		},
+		{
+			name:          "store state is tombstone, regions are not migrated after the timeout",
+			tikvUpgrading: false,
+			storeFun: func(tc *v1alpha1.TidbCluster) {
+				tombstoneStoreFun(tc)
+				store := tc.Status.TiKV.TombstoneStores["1"]
+				store.LastTransitionTime = metav1.Time{Time: time.Now().Add(-1 * time.Hour)}
+				tc.Status.TiKV.TombstoneStores["1"] = store
+			},
+			delStoreErr:   false,
+			hasPVC:        true,
+			storeIDSynced: true,
+			isPodReady:    true,
+			hasSynced:     true,
+			pvcUpdateErr:  false,
+			getStoreFn:    nonEmptyTombstoneStoreFn,
+			errExpectFn:   errExpectNil,
+			changed:       true,
+			expectEvents:  []string{"StoreNotEmpty"},
+		},
+		{
+			name:          "store state is tombstone, the store is removed from PD",
+			tikvUpgrading: false,
+			storeFun: func(tc *v1alpha1.TidbCluster) {
+				tombstoneStoreFun(tc)
+				store := tc.Status.TiKV.TombstoneStores["1"]
+				store.LastTransitionTime = metav1.Now()
+				tc.Status.TiKV.TombstoneStores["1"] = store
+			},
+			delStoreErr:   false,
+			hasPVC:        true,
+			storeIDSynced: true,
+			isPodReady:    true,
+			hasSynced:     true,
+			pvcUpdateErr:  false,
+			getStoreFn: func(action *pdapi.Action) (interface{}, error) {
+				return nil, pdapi.ErrStoreNotFound
+			},
+			errExpectFn: errExpectNil,
+			changed:     true,
+		},
 		{
 			name:          "store state is tombstone and store id not match",
 			tikvUpgrading: false,
@@ -543,6 +717,212 @@ func TestTiKVScalerScaleIn(t *testing.T) {
 	}
 }
 
+func TestTiKVScalerScaleInEvictsLeader(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPD()
+	tc.Spec.TiKV.ScalePolicy = &v1alpha1.TiKVScalePolicy{EvictLeaderBeforeScaleIn: true}
+	normalStoreFun(tc)
+
+	scaler, pdControl, _, podIndexer, _ := newFakeTiKVScaler()
+	podName := TikvPodName(tc.GetName(), 4)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      podName,
+			Namespace: corev1.NamespaceDefault,
+		},
+	}
+	podIndexer.Add(pod)
+
+	var evicted, deleted []uint64
+	pdClient := controller.NewFakePDClient(pdControl, tc)
+	pdClient.AddReaction(pdapi.BeginEvictLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+		evicted = append(evicted, action.ID)
+		return nil, nil
+	})
+	pdClient.AddReaction(pdapi.DeleteStoreActionType, func(action *pdapi.Action) (interface{}, error) {
+		deleted = append(deleted, action.ID)
+		return nil, nil
+	})
+	leaderCount := 5
+	tikvClient := controller.NewFakeTiKVClient(scaler.deps.TiKVControl.(*tikvapi.FakeTiKVControl), tc, podName)
+	tikvClient.AddReaction(tikvapi.GetLeaderCountActionType, func(action *tikvapi.Action) (interface{}, error) {
+		return leaderCount, nil
+	})
+
+	scaleIn := func() error {
+		oldSet := newStatefulSetForPDScale()
+		newSet := oldSet.DeepCopy()
+		newSet.Spec.Replicas = pointer.Int32Ptr(4)
+		return scaler.ScaleIn(tc, oldSet, newSet)
+	}
+
+	// the eviction begins
+	err := scaleIn()
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(evicted).To(Equal([]uint64{1}))
+	g.Expect(tc.Status.TiKV.EvictLeaderStores).To(Equal([]string{"1"}))
+	pod, err = scaler.deps.PodLister.Pods(corev1.NamespaceDefault).Get(podName)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pod.Annotations).To(HaveKey(EvictLeaderBeginTime))
+
+	// the store still has leaders
+	err = scaleIn()
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(err.Error()).To(ContainSubstring("is evicting leaders"))
+	g.Expect(deleted).To(BeEmpty())
+
+	// the store is deleted after all the leaders are evicted
+	leaderCount = 0
+	err = scaleIn()
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(evicted).To(Equal([]uint64{1}))
+	g.Expect(deleted).To(Equal([]uint64{1}))
+}
+
+func TestTiKVScalerClearsCancelledScaleInEviction(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPD()
+	tc.Spec.TiKV.ScalePolicy = &v1alpha1.TiKVScalePolicy{EvictLeaderBeforeScaleIn: true}
+	tc.Status.TiKV.StatefulSet = &apps.StatefulSetStatus{CurrentRevision: "1", UpdateRevision: "1"}
+	tc.Status.TiKV.EvictLeaderStores = []string{"1"}
+	normalStoreFun(tc)
+
+	scaler, pdControl, _, podIndexer, _ := newFakeTiKVScaler()
+	podName := TikvPodName(tc.GetName(), 4)
+	podIndexer.Add(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        podName,
+			Namespace:   corev1.NamespaceDefault,
+			Annotations: map[string]string{EvictLeaderBeginTime: time.Now().Format(time.RFC3339)},
+		},
+	})
+	var ended []uint64
+	pdClient := controller.NewFakePDClient(pdControl, tc)
+	pdClient.AddReaction(pdapi.EndEvictLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+		ended = append(ended, action.ID)
+		return nil, nil
+	})
+
+	oldSet := newStatefulSetForPDScale()
+	oldSet.Name = fmt.Sprintf("%s-tikv", tc.Name)
+	scale := func() error {
+		return scaler.Scale(tc, oldSet, oldSet.DeepCopy())
+	}
+
+	// the eviction of an upgrade is kept
+	tc.Status.TiKV.StatefulSet.UpdateRevision = "2"
+	g.Expect(scale()).To(Succeed())
+	g.Expect(ended).To(BeEmpty())
+
+	// the eviction of the scale-in cancelled is ended
+	tc.Status.TiKV.StatefulSet.UpdateRevision = "1"
+	g.Expect(scale()).To(Succeed())
+	g.Expect(ended).To(Equal([]uint64{1}))
+	g.Expect(tc.Status.TiKV.EvictLeaderStores).To(BeEmpty())
+	pod, err := scaler.deps.PodLister.Pods(corev1.NamespaceDefault).Get(podName)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pod.Annotations).NotTo(HaveKey(EvictLeaderBeginTime))
+}
+
+func TestTiKVScalerScaleInBalancesZones(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		name        string
+		zones       []string
+		pdZone      string
+		force       bool
+		errExpectFn func(*GomegaWithT, error)
+		deleted     bool
+	}{
+		{
+			name:        "zone of the store has the most stores",
+			zones:       []string{"a", "a", "b", "b", "a"},
+			errExpectFn: errExpectRequeue,
+			deleted:     true,
+		},
+		{
+			name:        "zone of the store has fewer stores",
+			zones:       []string{"a", "a", "a", "b", "b"},
+			errExpectFn: errExpectRequeue,
+		},
+		{
+			name:        "store is the last one of its zone",
+			zones:       []string{"a", "a", "b", "b", "c"},
+			errExpectFn: errExpectRequeue,
+		},
+		{
+			name:        "scale in is forced",
+			zones:       []string{"a", "a", "b", "b", "c"},
+			force:       true,
+			errExpectFn: errExpectRequeue,
+			deleted:     true,
+		},
+		{
+			name:        "zone of the store is from PD",
+			zones:       []string{"a", "a", "b", "b", ""},
+			pdZone:      "b",
+			errExpectFn: errExpectRequeue,
+			deleted:     true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tc := newTidbClusterForPD()
+			tc.Spec.TiKV.ScalePolicy = &v1alpha1.TiKVScalePolicy{BalanceZones: true}
+			if test.force {
+				tc.Annotations = map[string]string{label.AnnForceUnbalancedScaleIn: label.AnnForceUnbalancedScaleInVal}
+			}
+			normalStoreFun(tc)
+			tc.Status.Topology = &v1alpha1.ClusterTopology{}
+			for i, zone := range test.zones {
+				tc.Status.Topology.TiKV = append(tc.Status.Topology.TiKV, v1alpha1.PodTopology{
+					PodName: TikvPodName(tc.GetName(), int32(i)),
+					Zone:    zone,
+				})
+			}
+
+			scaler, pdControl, _, podIndexer, _ := newFakeTiKVScaler()
+			podIndexer.Add(&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      TikvPodName(tc.GetName(), 4),
+					Namespace: corev1.NamespaceDefault,
+				},
+			})
+			recorder := scaler.deps.Recorder.(*record.FakeRecorder)
+
+			var deleted bool
+			pdClient := controller.NewFakePDClient(pdControl, tc)
+			pdClient.AddReaction(pdapi.GetStoresActionType, func(action *pdapi.Action) (interface{}, error) {
+				return &pdapi.StoresInfo{Stores: []*pdapi.StoreInfo{
+					{Store: &pdapi.MetaStore{Store: &metapb.Store{Id: 1, Labels: []*metapb.StoreLabel{{Key: "zone", Value: test.pdZone}}}}},
+				}}, nil
+			})
+			pdClient.AddReaction(pdapi.DeleteStoreActionType, func(action *pdapi.Action) (interface{}, error) {
+				deleted = true
+				return nil, nil
+			})
+
+			oldSet := newStatefulSetForPDScale()
+			newSet := oldSet.DeepCopy()
+			newSet.Spec.Replicas = pointer.Int32Ptr(4)
+			err := scaler.ScaleIn(tc, oldSet, newSet)
+			test.errExpectFn(g, err)
+			g.Expect(deleted).To(Equal(test.deleted))
+			events := collectEvents(recorder.Events)
+			if test.deleted {
+				g.Expect(events).To(BeEmpty())
+			} else {
+				g.Expect(events).To(HaveLen(1))
+				g.Expect(events[0]).To(ContainSubstring(label.AnnForceUnbalancedScaleIn))
+			}
+		})
+	}
+}
+
 func newFakeTiKVScaler(resyncDuration ...time.Duration) (*tikvScaler, *pdapi.FakePDControl, cache.Indexer, cache.Indexer, *controller.FakePVCControl) {
 	fakeDeps := controller.NewFakeDependencies()
 	if len(resyncDuration) > 0 {
@@ -590,6 +970,22 @@ func notReadyStoreFun(tc *v1alpha1.TidbCluster) {
 	delete(tc.Status.TiKV.Stores, "1")
 }
 
+// emptyTombstoneStoreFn reports the store to PD as tombstone without regions
+func emptyTombstoneStoreFn(action *pdapi.Action) (interface{}, error) {
+	return &pdapi.StoreInfo{
+		Store:  &pdapi.MetaStore{StateName: v1alpha1.TiKVStateTombstone, Store: &metapb.Store{Id: action.ID}},
+		Status: &pdapi.StoreStatus{},
+	}, nil
+}
+
+// nonEmptyTombstoneStoreFn reports the store to PD as tombstone with regions
+func nonEmptyTombstoneStoreFn(action *pdapi.Action) (interface{}, error) {
+	return &pdapi.StoreInfo{
+		Store:  &pdapi.MetaStore{StateName: v1alpha1.TiKVStateTombstone, Store: &metapb.Store{Id: action.ID}},
+		Status: &pdapi.StoreStatus{RegionCount: 3},
+	}, nil
+}
+
 func tombstoneStoreFun(tc *v1alpha1.TidbCluster) {
 	notReadyStoreFun(tc)
 
@@ -0,0 +1,305 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog"
+)
+
+// syncTiKVStoreMigration migrates the TiKV stores off the nodes in the
+// tikv.tidb.pingcap.com/migrate-off-nodes(-selector) annotation one by one:
+//
+//  1. the store is deleted from PD, which moves its regions to the other stores
+//  2. once the store becomes tombstone, the Pod and its PVCs are deleted so
+//     that the Pod is recreated with empty data on another node, the pod
+//     admission webhook keeps the recreated Pod off the node
+//  3. once the new store of the Pod is up, the next store is migrated
+//
+// A migration is only started if all the stores are up, no failover is in
+// progress and the other stores can hold all the replicas of the regions.
+// The migration in progress is persisted in status and is always carried
+// through, even if the annotation is removed, as its store is half evicted.
+func syncTiKVStoreMigration(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	for podName, migration := range tc.Status.TiKV.StoreMigrations {
+		if migration.Phase != v1alpha1.TiKVStoreMigrationCompleted {
+			return continueTiKVStoreMigration(deps, tc, podName, migration)
+		}
+	}
+
+	nodes, err := getTiKVMigrateOffNodes(deps, tc)
+	if err != nil {
+		return err
+	}
+	if nodes == nil {
+		tc.Status.TiKV.StoreMigrations = nil
+		return nil
+	}
+
+	selector, err := label.New().Instance(tc.GetInstanceName()).TiKV().Selector()
+	if err != nil {
+		return err
+	}
+	pods, err := deps.PodLister.Pods(ns).List(selector)
+	if err != nil {
+		return fmt.Errorf("syncTiKVStoreMigration: failed to list pods for cluster %s/%s, error: %s", ns, tcName, err)
+	}
+	pod := nextTiKVPodToMigrate(tc, pods, nodes)
+	if pod == nil {
+		return nil
+	}
+	storeID := ""
+	for _, store := range tc.Status.TiKV.Stores {
+		if store.PodName == pod.Name {
+			storeID = store.ID
+			break
+		}
+	}
+	if len(storeID) == 0 {
+		klog.Infof("syncTiKVStoreMigration: store of pod %s/%s is not found, skip migrating it", ns, pod.Name)
+		return nil
+	}
+	reason, err := checkTiKVStoreMigrationSafety(deps, tc)
+	if err != nil {
+		return err
+	}
+	if len(reason) > 0 {
+		klog.Infof("syncTiKVStoreMigration: store %s of pod %s/%s is not migrated, %s", storeID, ns, pod.Name, reason)
+		return nil
+	}
+
+	id, err := strconv.ParseUint(storeID, 10, 64)
+	if err != nil {
+		return err
+	}
+	if err := reservePDDeletion(deps, tc, "store", storeID); err != nil {
+		return err
+	}
+	if err := controller.GetPDClient(deps.PDControl, tc).DeleteStore(id); err != nil {
+		return fmt.Errorf("syncTiKVStoreMigration: failed to delete store %d of pod %s/%s, error: %v", id, ns, pod.Name, err)
+	}
+	klog.Infof("syncTiKVStoreMigration: migrate store %d of pod %s/%s off node %s", id, ns, pod.Name, pod.Spec.NodeName)
+	deps.Recorder.Eventf(tc, corev1.EventTypeNormal, "StoreMigrationStarted", "migrate store %d of pod %s/%s off node %s", id, ns, pod.Name, pod.Spec.NodeName)
+	setTiKVStoreMigration(tc, pod.Name, v1alpha1.TiKVStoreMigration{
+		StoreID:  storeID,
+		NodeName: pod.Spec.NodeName,
+		Phase:    v1alpha1.TiKVStoreMigrationOffline,
+	})
+	return nil
+}
+
+// continueTiKVStoreMigration moves the migration in progress to the next phase
+func continueTiKVStoreMigration(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, podName string, migration v1alpha1.TiKVStoreMigration) error {
+	ns := tc.GetNamespace()
+
+	switch migration.Phase {
+	case v1alpha1.TiKVStoreMigrationOffline:
+		if _, ok := tc.Status.TiKV.TombstoneStores[migration.StoreID]; !ok {
+			klog.V(4).Infof("syncTiKVStoreMigration: waiting for store %s of pod %s/%s to become tombstone", migration.StoreID, ns, podName)
+			return nil
+		}
+		// the phase is persisted before the Pod is deleted, so the pod
+		// admission webhook sees it when the Pod is recreated
+		migration.Phase = v1alpha1.TiKVStoreMigrationRecreating
+		setTiKVStoreMigration(tc, podName, migration)
+		return nil
+	case v1alpha1.TiKVStoreMigrationRecreating:
+		for _, store := range tc.Status.TiKV.Stores {
+			if store.PodName == podName && store.ID != migration.StoreID && store.State == v1alpha1.TiKVStateUp {
+				klog.Infof("syncTiKVStoreMigration: store %s of pod %s/%s is migrated to store %s", migration.StoreID, ns, podName, store.ID)
+				deps.Recorder.Eventf(tc, corev1.EventTypeNormal, "StoreMigrationCompleted", "store %s of pod %s/%s is migrated off node %s", migration.StoreID, ns, podName, migration.NodeName)
+				migration.Phase = v1alpha1.TiKVStoreMigrationCompleted
+				setTiKVStoreMigration(tc, podName, migration)
+				return nil
+			}
+		}
+		pod, err := deps.PodLister.Pods(ns).Get(podName)
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("syncTiKVStoreMigration: failed to get pod %s/%s, error: %s", ns, podName, err)
+		}
+		// the Pod still on the node is either the old one or a replacement
+		// created without the node anti-affinity, e.g. the pod admission
+		// webhook is disabled, it is recreated until it lands on another node
+		if pod != nil && pod.Spec.NodeName == migration.NodeName {
+			return recreateStorePod(deps, tc, v1alpha1.TiKVMemberType, podName)
+		}
+		klog.V(4).Infof("syncTiKVStoreMigration: waiting for the new store of pod %s/%s to be up", ns, podName)
+		return nil
+	default:
+		return fmt.Errorf("syncTiKVStoreMigration: pod %s/%s has unknown store migration phase %q", ns, podName, migration.Phase)
+	}
+}
+
+// recreateStorePod deletes the TiKV or TiFlash Pod and its PVCs so that the Pod
+// is recreated with empty data, the Pod pending on the deleted PVCs is cleaned
+// by OrphanPodsCleaner
+func recreateStorePod(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, podName string) error {
+	ns := tc.GetNamespace()
+
+	ordinal, err := util.GetOrdinalFromPodName(podName)
+	if err != nil {
+		return fmt.Errorf("recreateStorePod: failed to parse ordinal from Pod name for %s/%s, error: %s", ns, podName, err)
+	}
+	pvcSelector, err := GetPVCSelectorForPod(tc, memberType, ordinal)
+	if err != nil {
+		return fmt.Errorf("recreateStorePod: failed to get PVC selector for Pod %s/%s, error: %s", ns, podName, err)
+	}
+	pvcs, err := deps.PVCLister.PersistentVolumeClaims(ns).List(pvcSelector)
+	if err != nil {
+		return fmt.Errorf("recreateStorePod: failed to get PVCs for pod %s/%s, error: %s", ns, podName, err)
+	}
+	if err := snapshotPVCsBeforeDeletion(deps, tc, tc.Spec.SnapshotBeforeDeletingPVC, pvcs); err != nil {
+		return err
+	}
+
+	pod, err := deps.PodLister.Pods(ns).Get(podName)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("recreateStorePod: failed to get pod %s/%s, error: %s", ns, podName, err)
+	}
+	if pod != nil && pod.DeletionTimestamp == nil {
+		if err := deps.PodControl.DeletePod(tc, pod); err != nil {
+			return err
+		}
+	}
+	for _, pvc := range pvcs {
+		if pvc.DeletionTimestamp != nil {
+			continue
+		}
+		if err := deps.PVCControl.DeletePVC(tc, pvc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkTiKVStoreMigrationSafety returns the reason why a store can not be
+// migrated now, or an empty string if it is safe
+func checkTiKVStoreMigrationSafety(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) (string, error) {
+	if tc.Status.TiKV.Phase != v1alpha1.NormalPhase {
+		return fmt.Sprintf("tikv is in %s phase", tc.Status.TiKV.Phase), nil
+	}
+	if len(tc.Status.TiKV.FailureStores) > 0 {
+		return "tikv failover is in progress", nil
+	}
+
+	upNumber := 0
+	for _, stores := range []map[string]v1alpha1.TiKVStore{tc.Status.TiKV.Stores, tc.Status.TiKV.PeerStores} {
+		for _, store := range stores {
+			if store.State != v1alpha1.TiKVStateUp {
+				return fmt.Sprintf("store %s of pod %s is %s", store.ID, store.PodName, store.State), nil
+			}
+			upNumber++
+		}
+	}
+
+	config, err := controller.GetPDClient(deps.PDControl, tc).GetConfig()
+	if err != nil {
+		return "", err
+	}
+	if config.Replication != nil && config.Replication.MaxReplicas != nil {
+		maxReplicas := int(*config.Replication.MaxReplicas)
+		if upNumber-1 < maxReplicas {
+			return fmt.Sprintf("the other %d up stores can not hold %d replicas", upNumber-1, maxReplicas), nil
+		}
+	}
+	return "", nil
+}
+
+// nextTiKVPodToMigrate returns the desired Pod with the largest ordinal that
+// runs on the given nodes and is not migrated yet
+func nextTiKVPodToMigrate(tc *v1alpha1.TidbCluster, pods []*corev1.Pod, nodes sets.String) *corev1.Pod {
+	desiredOrdinals := tc.TiKVStsDesiredOrdinals(true)
+	var candidates []*corev1.Pod
+	for _, pod := range pods {
+		if !nodes.Has(pod.Spec.NodeName) {
+			continue
+		}
+		if _, ok := tc.Status.TiKV.StoreMigrations[pod.Name]; ok {
+			continue
+		}
+		ordinal, err := util.GetOrdinalFromPodName(pod.Name)
+		if err != nil || !desiredOrdinals.Has(ordinal) {
+			continue
+		}
+		candidates = append(candidates, pod)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		oi, _ := util.GetOrdinalFromPodName(candidates[i].Name)
+		oj, _ := util.GetOrdinalFromPodName(candidates[j].Name)
+		return oi > oj
+	})
+	return candidates[0]
+}
+
+// getTiKVMigrateOffNodes returns the names of the nodes to migrate the stores
+// off, or nil if no migration is requested
+func getTiKVMigrateOffNodes(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) (sets.String, error) {
+	names, namesOK := tc.Annotations[label.AnnTiKVMigrateOffNodes]
+	selectorStr, selectorOK := tc.Annotations[label.AnnTiKVMigrateOffNodeSelector]
+	if !namesOK && !selectorOK {
+		return nil, nil
+	}
+
+	nodes := sets.NewString()
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); len(name) > 0 {
+			nodes.Insert(name)
+		}
+	}
+	if selectorOK && deps.NodeLister == nil {
+		klog.Infof("Node lister is unavailable, skip the annotation %s of TiDB cluster %s/%s. This may be caused by no relevant permissions", label.AnnTiKVMigrateOffNodeSelector, tc.Namespace, tc.Name)
+		return nodes, nil
+	}
+	if selectorOK {
+		selector, err := labels.Parse(selectorStr)
+		if err != nil {
+			return nil, fmt.Errorf("syncTiKVStoreMigration: failed to parse annotation %s of cluster %s/%s, error: %v", label.AnnTiKVMigrateOffNodeSelector, tc.GetNamespace(), tc.GetName(), err)
+		}
+		nodeList, err := deps.NodeLister.List(selector)
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range nodeList {
+			nodes.Insert(node.Name)
+		}
+	}
+	return nodes, nil
+}
+
+func setTiKVStoreMigration(tc *v1alpha1.TidbCluster, podName string, migration v1alpha1.TiKVStoreMigration) {
+	if tc.Status.TiKV.StoreMigrations == nil {
+		tc.Status.TiKV.StoreMigrations = map[string]v1alpha1.TiKVStoreMigration{}
+	}
+	migration.LastTransitionTime = metav1.Now()
+	tc.Status.TiKV.StoreMigrations[podName] = migration
+}
@@ -0,0 +1,270 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+type tikvStoreMigrationFixture struct {
+	deps         *controller.Dependencies
+	tc           *v1alpha1.TidbCluster
+	podIndexer   cache.Indexer
+	pvcIndexer   cache.Indexer
+	nodeIndexer  cache.Indexer
+	deletedStore []uint64
+}
+
+// newTiKVStoreMigrationFixture returns a cluster with 4 stores and 3 replicas,
+// the store ID of tikv-i is i+1 and tikv-i runs on the node in nodes[i]
+func newTiKVStoreMigrationFixture(g *GomegaWithT, nodes []string) *tikvStoreMigrationFixture {
+	f := &tikvStoreMigrationFixture{deps: controller.NewFakeDependencies()}
+	f.podIndexer = f.deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+	f.pvcIndexer = f.deps.KubeInformerFactory.Core().V1().PersistentVolumeClaims().Informer().GetIndexer()
+	f.nodeIndexer = f.deps.KubeInformerFactory.Core().V1().Nodes().Informer().GetIndexer()
+
+	f.tc = newTidbClusterForTiKV()
+	f.tc.Spec.TiKV.Replicas = int32(len(nodes))
+	f.tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+	f.tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{}
+	for i, node := range nodes {
+		id := fmt.Sprintf("%d", i+1)
+		podName := ordinalPodName(v1alpha1.TiKVMemberType, f.tc.GetName(), int32(i))
+		f.tc.Status.TiKV.Stores[id] = v1alpha1.TiKVStore{ID: id, PodName: podName, State: v1alpha1.TiKVStateUp}
+		f.addPod(g, podName, node)
+	}
+
+	maxReplicas := uint64(3)
+	pdClient := controller.NewFakePDClient(f.deps.PDControl.(*pdapi.FakePDControl), f.tc)
+	pdClient.AddReaction(pdapi.GetConfigActionType, func(action *pdapi.Action) (interface{}, error) {
+		return &pdapi.PDConfigFromAPI{
+			Replication: &pdapi.PDReplicationConfig{MaxReplicas: &maxReplicas},
+		}, nil
+	})
+	pdClient.AddReaction(pdapi.DeleteStoreActionType, func(action *pdapi.Action) (interface{}, error) {
+		f.deletedStore = append(f.deletedStore, action.ID)
+		return nil, nil
+	})
+	return f
+}
+
+func (f *tikvStoreMigrationFixture) addPod(g *GomegaWithT, podName, node string) {
+	l := label.New().Instance(f.tc.GetInstanceName()).TiKV()
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: podName, Namespace: f.tc.GetNamespace(), Labels: l.Copy()},
+		Spec:       corev1.PodSpec{NodeName: node},
+	}
+	g.Expect(f.podIndexer.Add(pod)).To(Succeed())
+
+	pvcLabels := l.Copy()
+	pvcLabels[label.AnnPodNameKey] = podName
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "tikv-" + podName, Namespace: f.tc.GetNamespace(), Labels: pvcLabels},
+	}
+	g.Expect(f.pvcIndexer.Add(pvc)).To(Succeed())
+}
+
+func (f *tikvStoreMigrationFixture) exists(indexer cache.Indexer, name string) bool {
+	_, exists, _ := indexer.GetByKey(fmt.Sprintf("%s/%s", f.tc.GetNamespace(), name))
+	return exists
+}
+
+func TestSyncTiKVStoreMigration(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	f := newTiKVStoreMigrationFixture(g, []string{"new-0", "old-1", "new-2", "old-3"})
+	tc := f.tc
+	tikv1 := ordinalPodName(v1alpha1.TiKVMemberType, tc.GetName(), 1)
+	tikv3 := ordinalPodName(v1alpha1.TiKVMemberType, tc.GetName(), 3)
+	sync := func() {
+		g.Expect(syncTiKVStoreMigration(f.deps, tc)).To(Succeed())
+	}
+
+	// nothing to do without the annotation
+	sync()
+	g.Expect(f.deletedStore).To(BeEmpty())
+	g.Expect(tc.Status.TiKV.StoreMigrations).To(BeNil())
+
+	tc.Annotations = map[string]string{label.AnnTiKVMigrateOffNodes: "old-1, old-3"}
+
+	// the store of the pod with the largest ordinal is migrated first
+	sync()
+	g.Expect(f.deletedStore).To(Equal([]uint64{4}))
+	g.Expect(tc.Status.TiKV.StoreMigrations).To(HaveKey(tikv3))
+	g.Expect(tc.Status.TiKV.StoreMigrations[tikv3].Phase).To(Equal(v1alpha1.TiKVStoreMigrationOffline))
+	g.Expect(tc.Status.TiKV.StoreMigrations[tikv3].NodeName).To(Equal("old-3"))
+
+	// the next store is not migrated while the store is offline
+	store := tc.Status.TiKV.Stores["4"]
+	store.State = v1alpha1.TiKVStateOffline
+	tc.Status.TiKV.Stores["4"] = store
+	sync()
+	g.Expect(f.deletedStore).To(Equal([]uint64{4}))
+	g.Expect(tc.Status.TiKV.StoreMigrations[tikv3].Phase).To(Equal(v1alpha1.TiKVStoreMigrationOffline))
+	g.Expect(f.exists(f.podIndexer, tikv3)).To(BeTrue())
+
+	// the pod and its PVC are deleted once the store becomes tombstone and
+	// the phase is persisted
+	delete(tc.Status.TiKV.Stores, "4")
+	store.State = v1alpha1.TiKVStateTombstone
+	tc.Status.TiKV.TombstoneStores = map[string]v1alpha1.TiKVStore{"4": store}
+	sync()
+	g.Expect(tc.Status.TiKV.StoreMigrations[tikv3].Phase).To(Equal(v1alpha1.TiKVStoreMigrationRecreating))
+	g.Expect(f.exists(f.podIndexer, tikv3)).To(BeTrue())
+	sync()
+	g.Expect(f.exists(f.podIndexer, tikv3)).To(BeFalse())
+	g.Expect(f.exists(f.pvcIndexer, "tikv-"+tikv3)).To(BeFalse())
+
+	// the pod recreated on the old node is recreated again
+	f.addPod(g, tikv3, "old-3")
+	sync()
+	g.Expect(f.exists(f.podIndexer, tikv3)).To(BeFalse())
+
+	// the next store is not migrated until the new store is up
+	f.addPod(g, tikv3, "new-3")
+	sync()
+	g.Expect(tc.Status.TiKV.StoreMigrations[tikv3].Phase).To(Equal(v1alpha1.TiKVStoreMigrationRecreating))
+	tc.Status.TiKV.Stores["5"] = v1alpha1.TiKVStore{ID: "5", PodName: tikv3, State: v1alpha1.TiKVStateUp}
+	sync()
+	g.Expect(tc.Status.TiKV.StoreMigrations[tikv3].Phase).To(Equal(v1alpha1.TiKVStoreMigrationCompleted))
+	g.Expect(f.deletedStore).To(Equal([]uint64{4}))
+
+	sync()
+	g.Expect(f.deletedStore).To(Equal([]uint64{4, 2}))
+	g.Expect(tc.Status.TiKV.StoreMigrations[tikv1].Phase).To(Equal(v1alpha1.TiKVStoreMigrationOffline))
+
+	// the migration in progress is carried through after the annotation is removed
+	delete(tc.Annotations, label.AnnTiKVMigrateOffNodes)
+	sync()
+	g.Expect(tc.Status.TiKV.StoreMigrations[tikv1].Phase).To(Equal(v1alpha1.TiKVStoreMigrationOffline))
+	delete(tc.Status.TiKV.Stores, "2")
+	tc.Status.TiKV.TombstoneStores["2"] = v1alpha1.TiKVStore{ID: "2", PodName: tikv1, State: v1alpha1.TiKVStateTombstone}
+	sync()
+	sync()
+	g.Expect(tc.Status.TiKV.StoreMigrations[tikv1].Phase).To(Equal(v1alpha1.TiKVStoreMigrationRecreating))
+	g.Expect(f.exists(f.podIndexer, tikv1)).To(BeFalse())
+	f.addPod(g, tikv1, "new-1")
+	tc.Status.TiKV.Stores["6"] = v1alpha1.TiKVStore{ID: "6", PodName: tikv1, State: v1alpha1.TiKVStateUp}
+	sync()
+	g.Expect(tc.Status.TiKV.StoreMigrations[tikv1].Phase).To(Equal(v1alpha1.TiKVStoreMigrationCompleted))
+
+	// the progress is cleared once all the migrations are completed
+	sync()
+	g.Expect(tc.Status.TiKV.StoreMigrations).To(BeNil())
+}
+
+func TestSyncTiKVStoreMigrationNodeSelector(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	f := newTiKVStoreMigrationFixture(g, []string{"node-0", "node-1", "node-2", "node-3"})
+	for i := 0; i < 4; i++ {
+		pool := "new"
+		if i == 2 {
+			pool = "old"
+		}
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("node-%d", i), Labels: map[string]string{"pool": pool}}}
+		g.Expect(f.nodeIndexer.Add(node)).To(Succeed())
+	}
+	f.tc.Annotations = map[string]string{label.AnnTiKVMigrateOffNodeSelector: "pool=old"}
+
+	g.Expect(syncTiKVStoreMigration(f.deps, f.tc)).To(Succeed())
+	g.Expect(f.deletedStore).To(Equal([]uint64{3}))
+
+	// the selector is parsed once the migration in progress is completed
+	f.tc.Status.TiKV.StoreMigrations = nil
+	f.tc.Annotations[label.AnnTiKVMigrateOffNodeSelector] = "pool in (old"
+	g.Expect(syncTiKVStoreMigration(f.deps, f.tc)).NotTo(Succeed())
+
+	// the selector is skipped without the node lister
+	f.deps.NodeLister = nil
+	nodes, err := getTiKVMigrateOffNodes(f.deps, f.tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(nodes.List()).To(BeEmpty())
+}
+
+func TestSyncTiKVStoreMigrationSafety(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name        string
+		nodes       []string
+		update      func(tc *v1alpha1.TidbCluster)
+		expectStart bool
+	}
+
+	tests := []testcase{
+		{
+			name:        "safe to migrate",
+			nodes:       []string{"new-0", "new-1", "new-2", "old-3"},
+			expectStart: true,
+		},
+		{
+			name:  "tikv is upgrading",
+			nodes: []string{"new-0", "new-1", "new-2", "old-3"},
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiKV.Phase = v1alpha1.UpgradePhase
+			},
+		},
+		{
+			name:  "tikv failover is in progress",
+			nodes: []string{"new-0", "new-1", "new-2", "old-3"},
+			update: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiKV.FailureStores = map[string]v1alpha1.TiKVFailureStore{
+					"1": {PodName: ordinalPodName(v1alpha1.TiKVMemberType, tc.GetName(), 0), StoreID: "1"},
+				}
+			},
+		},
+		{
+			name:  "another store is down",
+			nodes: []string{"new-0", "new-1", "new-2", "old-3"},
+			update: func(tc *v1alpha1.TidbCluster) {
+				store := tc.Status.TiKV.Stores["1"]
+				store.State = v1alpha1.TiKVStateDown
+				tc.Status.TiKV.Stores["1"] = store
+			},
+		},
+		{
+			name:  "the other stores can not hold the replicas",
+			nodes: []string{"new-0", "new-1", "old-2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.name)
+		f := newTiKVStoreMigrationFixture(g, test.nodes)
+		if test.update != nil {
+			test.update(f.tc)
+		}
+		f.tc.Annotations = map[string]string{label.AnnTiKVMigrateOffNodes: "old-2,old-3"}
+
+		g.Expect(syncTiKVStoreMigration(f.deps, f.tc)).To(Succeed())
+		if test.expectStart {
+			g.Expect(f.deletedStore).To(HaveLen(1))
+			g.Expect(f.tc.Status.TiKV.StoreMigrations).To(HaveLen(1))
+		} else {
+			g.Expect(f.deletedStore).To(BeEmpty())
+			g.Expect(f.tc.Status.TiKV.StoreMigrations).To(BeNil())
+		}
+	}
+}
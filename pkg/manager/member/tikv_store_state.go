@@ -0,0 +1,72 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+)
+
+// storeScaleInAction is what scaling in does with the store of the Pod to be
+// removed, it is decided by the state of the store in PD
+type storeScaleInAction string
+
+const (
+	// storeScaleInDelete asks PD to delete the Up, Disconnected or Down store, which becomes
+	// Offline while its regions are moved to the other stores
+	storeScaleInDelete storeScaleInAction = "Delete"
+	// storeScaleInWait waits for the Offline store to become Tombstone, the
+	// store must keep its data until then
+	storeScaleInWait storeScaleInAction = "Wait"
+	// storeScaleInRemove removes the Pod of the Tombstone store and defers
+	// deleting its PVCs
+	storeScaleInRemove storeScaleInAction = "Remove"
+)
+
+// getStoreScaleInAction returns the scale-in action of a store in the state.
+// The state of a store which is not found in the status is empty, in which
+// case the caller decides by the Pod.
+func getStoreScaleInAction(state string) (storeScaleInAction, error) {
+	switch state {
+	case v1alpha1.TiKVStateUp, v1alpha1.TiKVStateDisconnected, v1alpha1.TiKVStateDown:
+		return storeScaleInDelete, nil
+	case v1alpha1.TiKVStateOffline:
+		return storeScaleInWait, nil
+	case v1alpha1.TiKVStateTombstone:
+		return storeScaleInRemove, nil
+	default:
+		return "", fmt.Errorf("unknown store state %q", state)
+	}
+}
+
+// storeNeedsFailover returns whether a store in the state is failed and a
+// new store should be created for it once the failover period is exceeded.
+// Offline stores are being decommissioned and Tombstone stores are removed,
+// neither of them is replaced.
+func storeNeedsFailover(state string) bool {
+	return state == v1alpha1.TiKVStateDown
+}
+
+// storeLostHeartbeats returns whether PD has lost the heartbeats of a store in
+// the state, it may come back unless its node is gone
+func storeLostHeartbeats(state string) bool {
+	return state == v1alpha1.TiKVStateDisconnected || state == v1alpha1.TiKVStateDown
+}
+
+// storeServes returns whether a store in the state serves regions, only the
+// stores which serve count for the replicas of the regions
+func storeServes(state string) bool {
+	return state == v1alpha1.TiKVStateUp
+}
@@ -0,0 +1,163 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestGetStoreScaleInAction(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for state, expect := range map[string]storeScaleInAction{
+		v1alpha1.TiKVStateUp:           storeScaleInDelete,
+		v1alpha1.TiKVStateDisconnected: storeScaleInDelete,
+		v1alpha1.TiKVStateDown:         storeScaleInDelete,
+		v1alpha1.TiKVStateOffline:      storeScaleInWait,
+		v1alpha1.TiKVStateTombstone:    storeScaleInRemove,
+	} {
+		action, err := getStoreScaleInAction(state)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(action).To(Equal(expect), state)
+	}
+	_, err := getStoreScaleInAction("Serving")
+	g.Expect(err).To(HaveOccurred())
+
+	g.Expect(storeNeedsFailover(v1alpha1.TiKVStateDown)).To(BeTrue())
+	g.Expect(storeNeedsFailover(v1alpha1.TiKVStateOffline)).To(BeFalse())
+	g.Expect(storeLostHeartbeats(v1alpha1.TiKVStateDisconnected)).To(BeTrue())
+	g.Expect(storeLostHeartbeats(v1alpha1.TiKVStateUp)).To(BeFalse())
+	g.Expect(storeServes(v1alpha1.TiKVStateUp)).To(BeTrue())
+	g.Expect(storeServes(v1alpha1.TiKVStateDown)).To(BeFalse())
+}
+
+func TestTiKVScalerScaleInStoreState(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name          string
+		state         string
+		tombstone     bool
+		expectDelete  bool
+		expectChanged bool
+		errExpectFn   func(*GomegaWithT, error)
+	}
+	tests := []testcase{
+		{
+			// the other stores can't hold the replicas of the up store
+			name:        "up",
+			state:       v1alpha1.TiKVStateUp,
+			errExpectFn: errExpectNil,
+		},
+		{
+			name:         "down",
+			state:        v1alpha1.TiKVStateDown,
+			expectDelete: true,
+			errExpectFn:  errExpectRequeue,
+		},
+		{
+			name:        "offline",
+			state:       v1alpha1.TiKVStateOffline,
+			errExpectFn: errExpectRequeue,
+		},
+		{
+			name:          "tombstone",
+			tombstone:     true,
+			expectChanged: true,
+			errExpectFn:   errExpectNil,
+		},
+		{
+			name:        "unknown state",
+			state:       "Serving",
+			errExpectFn: errExpectNotNil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.name)
+
+		tc := newTidbClusterForPD()
+		tc.Status.TiKV.BootStrapped = true
+		if test.tombstone {
+			tombstoneStoreFun(tc)
+		} else {
+			normalStoreFun(tc)
+			store := tc.Status.TiKV.Stores["1"]
+			store.State = test.state
+			tc.Status.TiKV.Stores["1"] = store
+		}
+
+		oldSet := newStatefulSetForPDScale()
+		newSet := oldSet.DeepCopy()
+		newSet.Spec.Replicas = pointer.Int32Ptr(4)
+
+		scaler, pdControl, pvcIndexer, podIndexer, _ := newFakeTiKVScaler()
+		pvc := newScaleInPVCForStatefulSet(oldSet, v1alpha1.TiKVMemberType, tc.Name)
+		g.Expect(pvcIndexer.Add(pvc)).To(Succeed())
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              TikvPodName(tc.GetName(), 4),
+				Namespace:         corev1.NamespaceDefault,
+				CreationTimestamp: metav1.Time{Time: time.Now().Add(-1 * time.Hour)},
+				Labels:            map[string]string{label.StoreIDLabelKey: "1"},
+			},
+			Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{{
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.Name},
+					},
+				}},
+			},
+		}
+		readyPodFunc(pod)
+		g.Expect(podIndexer.Add(pod)).To(Succeed())
+
+		pdClient := controller.NewFakePDClient(pdControl, tc)
+		pdClient.AddReaction(pdapi.GetConfigActionType, func(action *pdapi.Action) (interface{}, error) {
+			var replicas uint64 = 3
+			return &pdapi.PDConfigFromAPI{Replication: &pdapi.PDReplicationConfig{MaxReplicas: &replicas}}, nil
+		})
+		// there are only as many up stores as the replicas
+		pdClient.AddReaction(pdapi.GetStoresActionType, func(action *pdapi.Action) (interface{}, error) {
+			store := &pdapi.StoreInfo{Store: &pdapi.MetaStore{StateName: v1alpha1.TiKVStateUp, Store: &metapb.Store{}}}
+			return &pdapi.StoresInfo{Count: 3, Stores: []*pdapi.StoreInfo{store, store, store}}, nil
+		})
+		pdClient.AddReaction(pdapi.GetStoreActionType, emptyTombstoneStoreFn)
+		deleted := false
+		pdClient.AddReaction(pdapi.DeleteStoreActionType, func(action *pdapi.Action) (interface{}, error) {
+			deleted = true
+			return nil, nil
+		})
+
+		err := scaler.ScaleIn(tc, oldSet, newSet)
+		test.errExpectFn(g, err)
+		g.Expect(deleted).To(Equal(test.expectDelete))
+		if test.expectChanged {
+			g.Expect(*newSet.Spec.Replicas).To(Equal(int32(4)))
+		} else {
+			g.Expect(*newSet.Spec.Replicas).To(Equal(int32(5)))
+		}
+	}
+}
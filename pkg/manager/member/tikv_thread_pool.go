@@ -0,0 +1,60 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// tikvThreadPools maps the TiKV thread pool config keys to the function
+// deriving their sizes from the number of CPUs
+var tikvThreadPools = map[string]func(cpus int64) int64{
+	"raftstore.store-pool-size":          func(cpus int64) int64 { return clampInt64(cpus/4, 1, 8) },
+	"raftstore.apply-pool-size":          func(cpus int64) int64 { return clampInt64(cpus/4, 1, 8) },
+	"server.grpc-concurrency":            func(cpus int64) int64 { return clampInt64(cpus/2, 1, 8) },
+	"readpool.unified.max-thread-count":  func(cpus int64) int64 { return clampInt64(cpus*4/5, 1, cpus) },
+	"storage.scheduler-worker-pool-size": func(cpus int64) int64 { return clampInt64(cpus/2, 1, 8) },
+}
+
+// setTiKVThreadPoolSizes sets the thread pool sizes derived from the CPU limit
+// in the config if they are not set, it does nothing if the CPU limit is not set.
+func setTiKVThreadPoolSizes(config *v1alpha1.TiKVConfigWraper, limits corev1.ResourceList) {
+	cpus := tikvCPULimit(limits)
+	if cpus == 0 {
+		return
+	}
+	for key, size := range tikvThreadPools {
+		config.SetIfNil(key, size(cpus))
+	}
+}
+
+// tikvCPULimit returns the CPU limit rounded up to whole CPUs, or 0 if it is not set
+func tikvCPULimit(limits corev1.ResourceList) int64 {
+	q, ok := limits[corev1.ResourceCPU]
+	if !ok || q.Sign() <= 0 {
+		return 0
+	}
+	return (q.MilliValue() + 999) / 1000
+}
+
+func clampInt64(v, min, max int64) int64 {
+	if v > max {
+		v = max
+	}
+	if v < min {
+		v = min
+	}
+	return v
+}
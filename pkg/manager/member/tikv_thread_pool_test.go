@@ -0,0 +1,131 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestSetTiKVThreadPoolSizes(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name     string
+		limits   corev1.ResourceList
+		config   map[string]interface{}
+		expected map[string]int64
+	}
+	tests := []testcase{
+		{
+			name:     "no cpu limit",
+			limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("16Gi")},
+			expected: map[string]int64{},
+		},
+		{
+			name:   "fractional cpu limit",
+			limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+			expected: map[string]int64{
+				"raftstore.store-pool-size":          1,
+				"raftstore.apply-pool-size":          1,
+				"server.grpc-concurrency":            1,
+				"readpool.unified.max-thread-count":  1,
+				"storage.scheduler-worker-pool-size": 1,
+			},
+		},
+		{
+			name:   "8 cpus",
+			limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("8")},
+			expected: map[string]int64{
+				"raftstore.store-pool-size":          2,
+				"raftstore.apply-pool-size":          2,
+				"server.grpc-concurrency":            4,
+				"readpool.unified.max-thread-count":  6,
+				"storage.scheduler-worker-pool-size": 4,
+			},
+		},
+		{
+			name:   "64 cpus",
+			limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("64")},
+			expected: map[string]int64{
+				"raftstore.store-pool-size":          8,
+				"raftstore.apply-pool-size":          8,
+				"server.grpc-concurrency":            8,
+				"readpool.unified.max-thread-count":  51,
+				"storage.scheduler-worker-pool-size": 8,
+			},
+		},
+		{
+			name:   "explicit config is kept",
+			limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("16")},
+			config: map[string]interface{}{
+				"raftstore": map[string]interface{}{"store-pool-size": int64(3)},
+				"server":    map[string]interface{}{"grpc-concurrency": int64(6)},
+			},
+			expected: map[string]int64{
+				"raftstore.store-pool-size":          3,
+				"raftstore.apply-pool-size":          4,
+				"server.grpc-concurrency":            6,
+				"readpool.unified.max-thread-count":  12,
+				"storage.scheduler-worker-pool-size": 8,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.name)
+		config := v1alpha1.NewTiKVConfig()
+		for k, v := range test.config {
+			config.Set(k, v)
+		}
+		setTiKVThreadPoolSizes(config, test.limits)
+		for key := range tikvThreadPools {
+			value := config.Get(key)
+			expected, ok := test.expected[key]
+			if !ok {
+				g.Expect(value).To(BeNil(), key)
+				continue
+			}
+			g.Expect(value).NotTo(BeNil(), key)
+			g.Expect(value.MustInt()).To(Equal(expected), key)
+		}
+	}
+}
+
+func TestGetTiKVConfigMapAutoTuneThreadPools(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForTiKV()
+	tc.Spec.TiKV.Config = v1alpha1.NewTiKVConfig()
+	tc.Spec.TiKV.Config.Set("raftstore.store-pool-size", 3)
+	tc.Spec.TiKV.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("16")}
+	tc.Spec.TiKV.AutoTuneThreadPools = true
+
+	cm, err := getTikVConfigMap(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cm.Data["config-file"]).To(ContainSubstring("store-pool-size = 3"))
+	g.Expect(cm.Data["config-file"]).To(ContainSubstring("apply-pool-size = 4"))
+	g.Expect(cm.Data["config-file"]).To(ContainSubstring("grpc-concurrency = 8"))
+	// the derived sizes are not written back to the spec
+	g.Expect(tc.Spec.TiKV.Config.Get("raftstore.apply-pool-size")).To(BeNil())
+
+	tc.Spec.TiKV.AutoTuneThreadPools = false
+	cm, err = getTikVConfigMap(tc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cm.Data["config-file"]).NotTo(ContainSubstring("apply-pool-size"))
+}
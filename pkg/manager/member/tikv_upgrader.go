@@ -22,9 +22,12 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/controller"
 	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	"github.com/pingcap/tidb-operator/pkg/util"
 	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog"
 	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
 )
@@ -88,12 +91,16 @@ func (u *tikvUpgrader) Upgrade(meta metav1.Object, oldSet *apps.StatefulSet, new
 		return fmt.Errorf("cluster: [%s/%s]'s tikv status sync failed, can not to be upgraded", ns, tcName)
 	}
 
+	if kept, err := keepAbortedTemplate(tc, v1alpha1.TiKVMemberType, oldSet, newSet); err != nil || kept {
+		return err
+	}
 	status.Phase = v1alpha1.UpgradePhase
 	if !templateEqual(newSet, oldSet) {
 		return nil
 	}
 
-	if status.StatefulSet.UpdateRevision == status.StatefulSet.CurrentRevision {
+	aborted := isUpgradeAborted(tc, v1alpha1.TiKVMemberType, status.StatefulSet.UpdateRevision)
+	if status.StatefulSet.UpdateRevision == status.StatefulSet.CurrentRevision && !postUpgradeHooksPending(newSet) {
 		return nil
 	}
 
@@ -103,12 +110,26 @@ func (u *tikvUpgrader) Upgrade(meta metav1.Object, oldSet *apps.StatefulSet, new
 		// If we encounter this situation, we will let the native statefulset controller do the upgrade completely, which may be unsafe for upgrading tikv.
 		// Therefore, in the production environment, we should try to avoid modifying the tikv statefulset update strategy directly.
 		newSet.Spec.UpdateStrategy = oldSet.Spec.UpdateStrategy
+		setPostUpgradeHookPods(newSet, nil)
 		klog.Warningf("tidbcluster: [%s/%s] tikv statefulset %s UpdateStrategy has been modified manually", ns, tcName, oldSet.GetName())
 		return nil
 	}
 
 	setUpgradePartition(newSet, *oldSet.Spec.UpdateStrategy.RollingUpdate.Partition)
+	if aborted {
+		klog.Infof("tidbcluster: [%s/%s]'s tikv upgrade to revision %s is aborted", ns, tcName, status.StatefulSet.UpdateRevision)
+		setPostUpgradeHookPods(newSet, nil)
+		return rollbackUpgradedPods(u.deps, tc, v1alpha1.TiKVMemberType, oldSet, newSet, status.StatefulSet.UpdateRevision)
+	}
+	if tc.TiKVUpgradeMaxUnavailable() > 1 {
+		if !u.deps.CLIConfig.PodWebhookEnabled {
+			return u.upgradeTiKVPodsConcurrently(tc, oldSet, newSet)
+		}
+		klog.Warningf("tidbcluster: [%s/%s]'s tikv upgradePolicy.maxUnavailable %d is ignored as the pod admission webhook is enabled, upgrade tikv pods one by one",
+			ns, tcName, tc.TiKVUpgradeMaxUnavailable())
+	}
 	podOrdinals := helper.GetPodOrdinals(*oldSet.Spec.Replicas, oldSet).List()
+	var lastUpgradedPod *corev1.Pod
 	for _i := len(podOrdinals) - 1; _i >= 0; _i-- {
 		i := podOrdinals[_i]
 		store := getStoreByOrdinal(meta.GetName(), *status, i)
@@ -129,9 +150,18 @@ func (u *tikvUpgrader) Upgrade(meta metav1.Object, oldSet *apps.StatefulSet, new
 		if revision == status.StatefulSet.UpdateRevision {
 
 			if !podutil.IsPodReady(pod) {
+				if ok, err := abortUpgradeOnCrashLoop(u.deps, tc, v1alpha1.TiKVMemberType, pod, i, revision, newSet); err != nil || ok {
+					return err
+				}
+				if ok, err := rollbackUpgradeOnUnhealthy(u.deps, tc, v1alpha1.TiKVMemberType, pod, i, revision, newSet, podUnhealthySince(pod)); err != nil || ok {
+					return err
+				}
 				return controller.RequeueErrorf("tidbcluster: [%s/%s]'s upgraded tikv pod: [%s] is not ready", ns, tcName, podName)
 			}
 			if store.State != v1alpha1.TiKVStateUp {
+				if ok, err := rollbackUpgradeOnUnhealthy(u.deps, tc, v1alpha1.TiKVMemberType, pod, i, revision, newSet, memberUnhealthySince(store.LastTransitionTime, pod)); err != nil || ok {
+					return err
+				}
 				return controller.RequeueErrorf("tidbcluster: [%s/%s]'s upgraded tikv pod: [%s] is not all ready", ns, tcName, podName)
 			}
 
@@ -145,11 +175,33 @@ func (u *tikvUpgrader) Upgrade(meta metav1.Object, oldSet *apps.StatefulSet, new
 					return err
 				}
 			}
+			if err := runPostUpgradeHook(u.deps, tc, v1alpha1.TiKVMemberType, pod, newSet); err != nil {
+				return err
+			}
 
+			lastUpgradedPod = pod
 			continue
 		}
 
+		// the leader eviction of the pod being upgraded is not interrupted
+		if _, evicting := pod.Annotations[EvictLeaderBeginTime]; !evicting {
+			if upgradePaused(tc, v1alpha1.TiKVMemberType) || waitForMaintenanceWindow(tc, v1alpha1.TiKVMemberType, "upgrade") {
+				return nil
+			}
+			if _i == len(podOrdinals)-1 {
+				if err := checkUpgradePreChecks(u.deps, tc, v1alpha1.TiKVMemberType); err != nil {
+					return err
+				}
+			}
+		}
+		if err := checkUpgradeStabilization(u.deps, tc, tc.Spec.TiKV.UpgradeStabilizationGate, lastUpgradedPod); err != nil {
+			return err
+		}
+
 		if u.deps.CLIConfig.PodWebhookEnabled {
+			if err := runPreUpgradeHook(u.deps, tc, v1alpha1.TiKVMemberType, pod, newSet); err != nil {
+				return err
+			}
 			setUpgradePartition(newSet, i)
 			return nil
 		}
@@ -160,6 +212,233 @@ func (u *tikvUpgrader) Upgrade(meta metav1.Object, oldSet *apps.StatefulSet, new
 	return nil
 }
 
+// upgradeTiKVPodsConcurrently upgrades up to MaxUnavailable TiKV Pods in
+// the same zone at the same time. The leaders are evicted from the stores of
+// a batch first, then the partition is lowered to cover the batch, and the
+// Pods of the batch are evicted in the next round, so that the StatefulSet
+// recreates them from the update revision at the same time. A batch is only
+// started when all the upgraded Pods are up and PD reports no Region missing
+// peers or with down peers.
+func (u *tikvUpgrader) upgradeTiKVPodsConcurrently(tc *v1alpha1.TidbCluster, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	partition := *oldSet.Spec.UpdateStrategy.RollingUpdate.Partition
+
+	var notReadyErr error
+	var lastUpgradedPod *corev1.Pod
+	var upgrading []*corev1.Pod
+	var pending []int32
+	pendingPods := map[int32]*corev1.Pod{}
+	podOrdinals := helper.GetPodOrdinals(*oldSet.Spec.Replicas, oldSet).List()
+	for _i := len(podOrdinals) - 1; _i >= 0; _i-- {
+		i := podOrdinals[_i]
+		store := getStoreByOrdinal(tcName, tc.Status.TiKV, i)
+		if store == nil {
+			setUpgradePartition(newSet, i)
+			continue
+		}
+		podName := TikvPodName(tcName, i)
+		pod, err := u.deps.PodLister.Pods(ns).Get(podName)
+		if err != nil {
+			return fmt.Errorf("tikvUpgrader.Upgrade: failed to get pods %s for cluster %s/%s, error: %s", podName, ns, tcName, err)
+		}
+		revision, exist := pod.Labels[apps.ControllerRevisionHashLabelKey]
+		if !exist {
+			return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tikv pod: [%s] has no label: %s", ns, tcName, podName, apps.ControllerRevisionHashLabelKey)
+		}
+
+		switch {
+		case revision == tc.Status.TiKV.StatefulSet.UpdateRevision:
+			if !podutil.IsPodReady(pod) {
+				if ok, err := abortUpgradeOnCrashLoop(u.deps, tc, v1alpha1.TiKVMemberType, pod, i, revision, newSet); err != nil || ok {
+					return err
+				}
+				if ok, err := rollbackUpgradeOnUnhealthy(u.deps, tc, v1alpha1.TiKVMemberType, pod, i, revision, newSet, podUnhealthySince(pod)); err != nil || ok {
+					return err
+				}
+				if notReadyErr == nil {
+					notReadyErr = controller.RequeueErrorf("tidbcluster: [%s/%s]'s upgraded tikv pod: [%s] is not ready", ns, tcName, podName)
+				}
+				continue
+			}
+			if store.State != v1alpha1.TiKVStateUp {
+				if ok, err := rollbackUpgradeOnUnhealthy(u.deps, tc, v1alpha1.TiKVMemberType, pod, i, revision, newSet, memberUnhealthySince(store.LastTransitionTime, pod)); err != nil || ok {
+					return err
+				}
+				if notReadyErr == nil {
+					notReadyErr = controller.RequeueErrorf("tidbcluster: [%s/%s]'s upgraded tikv pod: [%s] is not all ready", ns, tcName, podName)
+				}
+				continue
+			}
+			storeID, err := strconv.ParseUint(store.ID, 10, 64)
+			if err != nil {
+				return err
+			}
+			if err := endEvictLeaderbyStoreID(u.deps, tc, storeID); err != nil {
+				return err
+			}
+			if err := runPostUpgradeHook(u.deps, tc, v1alpha1.TiKVMemberType, pod, newSet); err != nil && notReadyErr == nil {
+				notReadyErr = err
+			}
+			lastUpgradedPod = pod
+		case i >= partition:
+			// the Pod is in the batch being upgraded
+			if pod.DeletionTimestamp == nil {
+				upgrading = append(upgrading, pod)
+			}
+		default:
+			pending = append(pending, i)
+			pendingPods[i] = pod
+		}
+	}
+
+	for _, pod := range upgrading {
+		if err := u.deps.PodControl.EvictPod(tc, pod); err != nil {
+			if errors.IsTooManyRequests(err) {
+				return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tikv pod: [%s] can not be evicted due to the disruption budget: %v", ns, tcName, pod.GetName(), err)
+			}
+			return fmt.Errorf("tikvUpgrader.Upgrade: failed to evict pod %s for cluster %s/%s, error: %s", pod.GetName(), ns, tcName, err)
+		}
+		klog.Infof("tidbcluster: [%s/%s]'s tikv pod: [%s] is evicted to be upgraded", ns, tcName, pod.GetName())
+	}
+	if notReadyErr != nil {
+		return notReadyErr
+	}
+	if len(upgrading) > 0 {
+		return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tikv is upgrading %d pods", ns, tcName, len(upgrading))
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	// the leader eviction of the batch being upgraded is not interrupted
+	if _, evicting := pendingPods[pending[0]].Annotations[EvictLeaderBeginTime]; !evicting {
+		if upgradePaused(tc, v1alpha1.TiKVMemberType) || waitForMaintenanceWindow(tc, v1alpha1.TiKVMemberType, "upgrade") {
+			return nil
+		}
+		if len(pending) == len(podOrdinals) {
+			if err := checkUpgradePreChecks(u.deps, tc, v1alpha1.TiKVMemberType); err != nil {
+				return err
+			}
+		}
+		if err := checkUpgradeStabilization(u.deps, tc, tc.Spec.TiKV.UpgradeStabilizationGate, lastUpgradedPod); err != nil {
+			return err
+		}
+	}
+	if err := checkTiKVRegionReplicas(u.deps, tc); err != nil {
+		return err
+	}
+
+	zones, err := tikvStoreZones(u.deps, tc)
+	if err != nil {
+		return err
+	}
+	batch := tikvUpgradeBatch(tcName, pending, zones, int(tc.TiKVUpgradeMaxUnavailable()))
+	storeIDs := make([]uint64, 0, len(batch))
+	for _, i := range batch {
+		store := getStoreByOrdinal(tcName, tc.Status.TiKV, i)
+		storeID, err := strconv.ParseUint(store.ID, 10, 64)
+		if err != nil {
+			return err
+		}
+		storeIDs = append(storeIDs, storeID)
+	}
+	// the leaders of the batch are evicted at the same time up to the max
+	// concurrency, the batch is cut to the stores evicting leaders so that it
+	// is not blocked by the stores left for the next round
+	batchPods := make([]*corev1.Pod, 0, len(batch))
+	for _, i := range batch {
+		batchPods = append(batchPods, pendingPods[i])
+	}
+	started, err := beginEvictLeaders(u.deps, tc, storeIDs, batchPods)
+	if err != nil {
+		return err
+	}
+	if len(started) == 0 {
+		return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tikv waits for the other stores to finish evicting leaders", ns, tcName)
+	}
+	batch = batch[:len(started)]
+
+	evicted := true
+	var retryAfter time.Duration
+	for j, i := range batch {
+		pod := pendingPods[i]
+		if batchPods[j] != pod {
+			// the Pod begins evicting leaders just now
+			evicted = false
+			continue
+		}
+		if _, evicting := pod.Annotations[EvictLeaderBeginTime]; !evicting {
+			if err := setEvictLeaderBeginTime(u.deps, tc, pod); err != nil {
+				return err
+			}
+			evicted = false
+			continue
+		}
+		if !u.readyToUpgrade(pod, tc) {
+			evicted = false
+			if after := evictLeaderRetryAfter(tc, pod); retryAfter == 0 || after < retryAfter {
+				retryAfter = after
+			}
+		}
+	}
+	if !evicted {
+		return controller.RequeueAfterErrorf(retryAfter, "tidbcluster: [%s/%s]'s tikv is evicting leader of %d pods", ns, tcName, len(batch))
+	}
+	// the PreUpgrade hooks of the batch are run at the same time
+	var hookErr error
+	for _, i := range batch {
+		if err := runPreUpgradeHook(u.deps, tc, v1alpha1.TiKVMemberType, pendingPods[i], newSet); err != nil && hookErr == nil {
+			hookErr = err
+		}
+	}
+	if hookErr != nil {
+		return hookErr
+	}
+	setUpgradePartition(newSet, batch[len(batch)-1])
+	return nil
+}
+
+// tikvUpgradeBatch returns the ordinals of the next batch of TiKV Pods to
+// upgrade from the pending ordinals in descending order. The batch has at most
+// maxUnavailable Pods, all of them in the zone of the first one. PD places at
+// most one peer of a Region in a zone, so the batch takes down at most one
+// peer of each Region. The batch ends before a Pod in another zone or in an
+// unknown zone, so that the partition covers exactly the batch.
+func tikvUpgradeBatch(tcName string, pending []int32, zones map[string]string, maxUnavailable int) []int32 {
+	batch := []int32{pending[0]}
+	batchZone := zones[TikvPodName(tcName, pending[0])]
+	if batchZone == "" {
+		return batch
+	}
+	for _, i := range pending[1:] {
+		if len(batch) >= maxUnavailable {
+			break
+		}
+		if zones[TikvPodName(tcName, i)] != batchZone {
+			break
+		}
+		batch = append(batch, i)
+	}
+	return batch
+}
+
+// checkTiKVRegionReplicas returns a requeue error if PD reports Regions
+// missing peers or with down peers, so that no more replicas are taken down.
+func checkTiKVRegionReplicas(deps *controller.Dependencies, tc *v1alpha1.TidbCluster) error {
+	pdClient := controller.GetPDClient(deps.PDControl, tc)
+	for _, check := range []string{pdapi.RegionCheckMissPeer, pdapi.RegionCheckDownPeer} {
+		count, err := pdClient.GetRegionCountByCheck(check)
+		if err != nil {
+			return fmt.Errorf("checkTiKVRegionReplicas: failed to get %s regions of tc %s/%s, error: %v", check, tc.GetNamespace(), tc.GetName(), err)
+		}
+		if count > 0 {
+			return controller.RequeueErrorf("tidbcluster: [%s/%s] has %d %s regions, can't upgrade tikv pods now", tc.GetNamespace(), tc.GetName(), count, check)
+		}
+	}
+	return nil
+}
+
 func (u *tikvUpgrader) upgradeTiKVPod(tc *v1alpha1.TidbCluster, ordinal int32, newSet *apps.StatefulSet) error {
 	ns := tc.GetNamespace()
 	tcName := tc.GetName()
@@ -176,16 +455,29 @@ func (u *tikvUpgrader) upgradeTiKVPod(tc *v1alpha1.TidbCluster, ordinal int32, n
 				return err
 			}
 			_, evicting := upgradePod.Annotations[EvictLeaderBeginTime]
+			if !evicting && tc.TiKVSingleStore() {
+				klog.Infof("tikv upgrader: only one store in %s/%s, skip evicting leader of store %d", ns, tcName, storeID)
+				if err := runPreUpgradeHook(u.deps, tc, v1alpha1.TiKVMemberType, upgradePod, newSet); err != nil {
+					return err
+				}
+				setUpgradePartition(newSet, ordinal)
+				return nil
+			}
 			if !evicting {
-				return u.beginEvictLeader(tc, storeID, upgradePod)
+				return beginEvictLeader(u.deps, tc, storeID, upgradePod)
 			}
 
 			if u.readyToUpgrade(upgradePod, tc) {
+				// the PreUpgrade hook is run after the leaders are evicted,
+				// as the eviction updates the Pod as well
+				if err := runPreUpgradeHook(u.deps, tc, v1alpha1.TiKVMemberType, upgradePod, newSet); err != nil {
+					return err
+				}
 				setUpgradePartition(newSet, ordinal)
 				return nil
 			}
 
-			return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tikv pod: [%s] is evicting leader", ns, tcName, upgradePodName)
+			return controller.RequeueAfterErrorf(evictLeaderRetryAfter(tc, upgradePod), "tidbcluster: [%s/%s]'s tikv pod: [%s] is evicting leader", ns, tcName, upgradePodName)
 		}
 	}
 
@@ -193,6 +485,12 @@ func (u *tikvUpgrader) upgradeTiKVPod(tc *v1alpha1.TidbCluster, ordinal int32, n
 }
 
 func (u *tikvUpgrader) readyToUpgrade(upgradePod *corev1.Pod, tc *v1alpha1.TidbCluster) bool {
+	return isLeaderEvicted(u.deps, tc, upgradePod)
+}
+
+// isLeaderEvicted returns whether the TiKV Pod has no Region leaders, or the
+// eviction started at the EvictLeaderBeginTime annotation of the Pod times out.
+func isLeaderEvicted(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, upgradePod *corev1.Pod) bool {
 	evictLeaderTimeout := tc.TiKVEvictLeaderTimeout()
 
 	if evictLeaderBeginTimeStr, evicting := upgradePod.Annotations[EvictLeaderBeginTime]; evicting {
@@ -208,7 +506,7 @@ func (u *tikvUpgrader) readyToUpgrade(upgradePod *corev1.Pod, tc *v1alpha1.TidbC
 	}
 
 	tlsEnabled := tc.IsTLSClusterEnabled()
-	leaderCount, err := u.deps.TiKVControl.GetTiKVPodClient(tc.Namespace, tc.Name, upgradePod.Name, tlsEnabled).GetLeaderCount()
+	leaderCount, err := deps.TiKVControl.GetTiKVPodClient(tc.Namespace, tc.Name, upgradePod.Name, tc.TiKVStatusPort(), tlsEnabled).GetLeaderCount()
 	if err != nil {
 		klog.Warningf("Fail to get region leader count for Pod %s/%s, error: %v", upgradePod.Namespace, upgradePod.Name, err)
 		return false
@@ -224,32 +522,112 @@ func (u *tikvUpgrader) readyToUpgrade(upgradePod *corev1.Pod, tc *v1alpha1.TidbC
 	return false
 }
 
-func (u *tikvUpgrader) beginEvictLeader(tc *v1alpha1.TidbCluster, storeID uint64, pod *corev1.Pod) error {
-	ns := tc.GetNamespace()
-	podName := pod.GetName()
-	err := controller.GetPDClient(u.deps.PDControl, tc).BeginEvictLeader(storeID)
+// evictLeaderRetryAfter returns how long to wait before checking the leaders
+// of the TiKV Pod being evicted again by spec.tikv.evictLeaderBackoff, 0 means
+// the backoff of the controller is used. The wait is the time since the
+// eviction began, bounded by the interval and the max interval.
+func evictLeaderRetryAfter(tc *v1alpha1.TidbCluster, pod *corev1.Pod) time.Duration {
+	interval, maxInterval := tc.TiKVEvictLeaderBackoff()
+	if interval == 0 {
+		return 0
+	}
+	beginTime, err := time.Parse(time.RFC3339, pod.Annotations[EvictLeaderBeginTime])
 	if err != nil {
-		klog.Errorf("tikv upgrader: failed to begin evict leader: %d, %s/%s, %v",
-			storeID, ns, podName, err)
+		return interval
+	}
+	after := time.Since(beginTime)
+	if after < interval {
+		return interval
+	}
+	if after > maxInterval {
+		return maxInterval
+	}
+	return after
+}
+
+// beginEvictLeader records the begin time in the EvictLeaderBeginTime
+// annotation of the TiKV Pod and then adds the evict leader scheduler of the
+// store, so that the scheduler added is always marked by the annotation
+func beginEvictLeader(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, storeID uint64, pod *corev1.Pod) error {
+	pod = pod.DeepCopy()
+	if err := setEvictLeaderBeginTime(deps, tc, pod); err != nil {
 		return err
 	}
-	klog.Infof("tikv upgrader: begin evict leader: %d, %s/%s successfully", storeID, ns, podName)
+	return addEvictLeaderScheduler(deps, tc, storeID, pod)
+}
+
+// addEvictLeaderScheduler adds the evict leader scheduler of the store of the
+// TiKV Pod with the EvictLeaderBeginTime annotation. The annotation is removed
+// if the scheduler is not added, so that the eviction begins again.
+func addEvictLeaderScheduler(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, storeID uint64, pod *corev1.Pod) error {
+	ns := tc.GetNamespace()
+	podName := pod.GetName()
+	// record the store before adding the scheduler, so the scheduler can always
+	// be told apart from the ones added by users
+	recordEvictLeaderStore(tc, storeID)
+	err := controller.GetPDClient(deps.PDControl, tc).BeginEvictLeader(storeID)
+	if err == nil {
+		klog.Infof("tikv: begin evict leader: %d, %s/%s successfully", storeID, ns, podName)
+		return nil
+	}
+	klog.Errorf("tikv: failed to begin evict leader: %d, %s/%s, %v", storeID, ns, podName, err)
+	delete(pod.Annotations, EvictLeaderBeginTime)
+	if _, updateErr := deps.PodControl.UpdatePod(tc, pod); updateErr != nil {
+		klog.Errorf("tikv: failed to remove pod %s/%s annotation %s, %v", ns, podName, EvictLeaderBeginTime, updateErr)
+	}
+	return err
+}
+
+// setEvictLeaderBeginTime records the begin time of the leader eviction in the
+// EvictLeaderBeginTime annotation of the TiKV Pod, which also keeps the evict
+// leader scheduler of its store from being cleaned up as stale.
+func setEvictLeaderBeginTime(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, pod *corev1.Pod) error {
+	ns := tc.GetNamespace()
+	podName := pod.GetName()
 	if pod.Annotations == nil {
 		pod.Annotations = map[string]string{}
 	}
 	now := time.Now().Format(time.RFC3339)
 	pod.Annotations[EvictLeaderBeginTime] = now
-	_, err = u.deps.PodControl.UpdatePod(tc, pod)
+	_, err := deps.PodControl.UpdatePod(tc, pod)
 	if err != nil {
-		klog.Errorf("tikv upgrader: failed to set pod %s/%s annotation %s to %s, %v",
+		klog.Errorf("tikv: failed to set pod %s/%s annotation %s to %s, %v",
 			ns, podName, EvictLeaderBeginTime, now, err)
 		return err
 	}
-	klog.Infof("tikv upgrader: set pod %s/%s annotation %s to %s successfully",
+	klog.Infof("tikv: set pod %s/%s annotation %s to %s successfully",
 		ns, podName, EvictLeaderBeginTime, now)
 	return nil
 }
 
+// cancelEvictLeader removes the evict leader scheduler of the store of the
+// TiKV Pod and then the EvictLeaderBeginTime annotation of the Pod, for the
+// leader eviction whose scale-in or restart is cancelled.
+func cancelEvictLeader(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, pod *corev1.Pod) error {
+	ns := tc.GetNamespace()
+	for _, store := range tc.Status.TiKV.Stores {
+		if store.PodName != pod.GetName() {
+			continue
+		}
+		storeID, err := strconv.ParseUint(store.ID, 10, 64)
+		if err != nil {
+			return err
+		}
+		if err := endEvictLeaderbyStoreID(deps, tc, storeID); err != nil {
+			return err
+		}
+		break
+	}
+	pod = pod.DeepCopy()
+	delete(pod.Annotations, EvictLeaderBeginTime)
+	if _, err := deps.PodControl.UpdatePod(tc, pod); err != nil {
+		klog.Errorf("tikv: failed to remove pod %s/%s annotation %s, %v", ns, pod.GetName(), EvictLeaderBeginTime, err)
+		return err
+	}
+	klog.Infof("tikv: the leader eviction of pod %s/%s is cancelled", ns, pod.GetName())
+	return nil
+}
+
 func endEvictLeader(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, ordinal int32) error {
 	store := getStoreByOrdinal(tc.GetName(), tc.Status.TiKV, ordinal)
 	if store == nil {
@@ -282,9 +660,208 @@ func endEvictLeaderbyStoreID(deps *controller.Dependencies, tc *v1alpha1.TidbClu
 		return err
 	}
 	klog.Infof("tikv: end evict leader for store: %d of %s/%s successfully", storeID, tc.Namespace, tc.Name)
+	forgetEvictLeaderStore(tc, storeID)
+	return nil
+}
+
+// recordEvictLeaderStore records the store in the status as one the operator
+// adds an evict leader scheduler for.
+func recordEvictLeaderStore(tc *v1alpha1.TidbCluster, storeID uint64) {
+	id := strconv.FormatUint(storeID, 10)
+	for _, s := range tc.Status.TiKV.EvictLeaderStores {
+		if s == id {
+			return
+		}
+	}
+	tc.Status.TiKV.EvictLeaderStores = append(tc.Status.TiKV.EvictLeaderStores, id)
+}
+
+// forgetEvictLeaderStore removes the store from the status after its evict
+// leader scheduler is removed.
+func forgetEvictLeaderStore(tc *v1alpha1.TidbCluster, storeID uint64) {
+	id := strconv.FormatUint(storeID, 10)
+	var stores []string
+	for _, s := range tc.Status.TiKV.EvictLeaderStores {
+		if s != id {
+			stores = append(stores, s)
+		}
+	}
+	tc.Status.TiKV.EvictLeaderStores = stores
+}
+
+// maxEvictLeaderStores returns how many stores are allowed to evict region
+// leaders at the same time. It is bounded by the configured limit and kept
+// below the number of Up stores, so there are always stores left to take the
+// leaders.
+func maxEvictLeaderStores(tc *v1alpha1.TidbCluster) int {
+	upStores := 0
+	for _, store := range tc.Status.TiKV.Stores {
+		if store.State == v1alpha1.TiKVStateUp {
+			upStores++
+		}
+	}
+	limit := tc.TiKVMaxConcurrentEvictLeaders()
+	if limit > upStores-1 {
+		limit = upStores - 1
+	}
+	if limit < 0 {
+		limit = 0
+	}
+	return limit
+}
+
+// beginEvictLeaders installs evict leader schedulers on the given stores in
+// batch, for maintenance that takes several stores down at once. The stores
+// already evicting leaders count against the concurrency limit, the stores
+// over the limit are left for the next round. It returns the stores evicting
+// leaders after the call, in the order their schedulers are installed.
+// The EvictLeaderBeginTime annotation of the Pod of a store, which is in pods
+// at the same index, is set before its scheduler is installed, and the Pod is
+// replaced with the updated copy in pods.
+func beginEvictLeaders(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, storeIDs []uint64, pods []*corev1.Pod) ([]uint64, error) {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	evicting := sets.NewString(tc.Status.TiKV.EvictLeaderStores...)
+	limit := maxEvictLeaderStores(tc)
+
+	var started []uint64
+	for i, storeID := range storeIDs {
+		id := strconv.FormatUint(storeID, 10)
+		if evicting.Has(id) {
+			started = append(started, storeID)
+			continue
+		}
+		if evicting.Len() >= limit {
+			klog.Infof("tikv: %d stores are evicting leaders in %s/%s, delay evicting leader of store %d", evicting.Len(), ns, tcName, storeID)
+			break
+		}
+		pod := pods[i].DeepCopy()
+		if _, ok := pod.Annotations[EvictLeaderBeginTime]; !ok {
+			if err := setEvictLeaderBeginTime(deps, tc, pod); err != nil {
+				return started, err
+			}
+		}
+		pods[i] = pod
+		if err := addEvictLeaderScheduler(deps, tc, storeID, pod); err != nil {
+			return started, err
+		}
+		evicting.Insert(id)
+		started = append(started, storeID)
+	}
+	return started, nil
+}
+
+// endEvictLeaders removes the evict leader schedulers of the given stores in
+// the reverse order they are installed by beginEvictLeaders.
+func endEvictLeaders(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, storeIDs []uint64) error {
+	for i := len(storeIDs) - 1; i >= 0; i-- {
+		if err := endEvictLeaderbyStoreID(deps, tc, storeIDs[i]); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// cleanupStaleEvictLeaderSchedulers removes the evict leader schedulers that
+// the operator added for the stores that are not being upgraded. Such schedulers
+// are left behind if the operator crashes in the middle of an upgrade, and the
+// store would never take region leaders again if they are not removed.
+// Only the schedulers recorded in the status are removed, the ones added by
+// users or by the pod admission webhook are left alone.
+func cleanupStaleEvictLeaderSchedulers(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, set *apps.StatefulSet) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	if !tc.TiKVBootStrapped() || len(tc.Status.TiKV.EvictLeaderStores) == 0 {
+		return nil
+	}
+
+	pdCli := controller.GetPDClient(deps.PDControl, tc)
+	schedulers, err := pdCli.GetEvictLeaderSchedulers()
+	if err != nil {
+		return err
+	}
+	installed := sets.NewString(schedulers...)
+
+	for _, id := range append([]string(nil), tc.Status.TiKV.EvictLeaderStores...) {
+		storeID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			klog.Warningf("tikv: failed to parse evict leader store ID %s of %s/%s", id, ns, tcName)
+			continue
+		}
+		if !installed.Has(pdapi.GetLeaderEvictSchedulerStr(storeID)) {
+			// the scheduler has been removed by others
+			forgetEvictLeaderStore(tc, storeID)
+			continue
+		}
+		if !isEvictLeaderStoreStale(deps, tc, set, id) {
+			continue
+		}
+		klog.Infof("tikv: evict leader scheduler of store %d in %s/%s is stale, remove it", storeID, ns, tcName)
+		if err := endEvictLeaderbyStoreID(deps, tc, storeID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isEvictLeaderStoreStale returns whether the evict leader scheduler of the
+// store is not needed by an ongoing upgrade or scale-in. The scheduler of a
+// store scaled in is stale once the store is tombstone or deleted from PD. A
+// scheduler is needed if the Pod of the store is evicting leaders, or if the
+// Pod is the one being upgraded.
+// The Pod being upgraded is told by the partition of the StatefulSet instead of
+// the annotation, as the annotation is added after the scheduler and may not
+// be seen yet.
+func isEvictLeaderStoreStale(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, set *apps.StatefulSet, id string) bool {
+	ns := tc.GetNamespace()
+
+	store, ok := tc.Status.TiKV.Stores[id]
+	if !ok {
+		if _, tombstone := tc.Status.TiKV.TombstoneStores[id]; tombstone {
+			return true
+		}
+		// the store is deleted if it's missing from the synced status
+		return tc.Status.TiKV.Synced
+	}
+	pod, err := deps.PodLister.Pods(ns).Get(store.PodName)
+	if err != nil {
+		// the Pod may be recreating, leave it to the next round
+		klog.V(4).Infof("tikv: failed to get pod %s/%s for store %s, skip checking its evict leader scheduler, error: %v", ns, store.PodName, id, err)
+		return false
+	}
+	if _, evicting := pod.Annotations[EvictLeaderBeginTime]; evicting {
+		return false
+	}
+
+	if tc.Status.TiKV.Phase == v1alpha1.UpgradePhase {
+		if set == nil || set.Spec.UpdateStrategy.RollingUpdate == nil || set.Spec.UpdateStrategy.RollingUpdate.Partition == nil {
+			return false
+		}
+		ordinal, err := util.GetOrdinalFromPodName(pod.GetName())
+		if err != nil {
+			return false
+		}
+		// the upgrader evicts leaders of the batch of Pods right below the
+		// partition, and ends the schedulers once the Pods above are upgraded
+		partition := *set.Spec.UpdateStrategy.RollingUpdate.Partition
+		if ordinal >= partition {
+			return false
+		}
+		below := 0
+		for _, i := range helper.GetPodOrdinals(*set.Spec.Replicas, set).List() {
+			if i > ordinal && i < partition {
+				below++
+			}
+		}
+		if below < int(tc.TiKVUpgradeMaxUnavailable()) {
+			return false
+		}
+	}
+	return true
+}
+
 func getStoreByOrdinal(name string, status v1alpha1.TiKVStatus, ordinal int32) *v1alpha1.TiKVStore {
 	podName := TikvPodName(name, ordinal)
 	for _, store := range status.Stores {
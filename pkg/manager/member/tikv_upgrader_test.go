@@ -205,6 +205,59 @@ func TestTiKVUpgraderUpgrade(t *testing.T) {
 				g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(2)))
 			},
 		},
+		{
+			name: "skip evicting leader when there is only one store",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.PD.Phase = v1alpha1.NormalPhase
+				tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+				tc.Status.TiKV.Synced = true
+				delete(tc.Status.TiKV.Stores, "1")
+				delete(tc.Status.TiKV.Stores, "2")
+			},
+			changeOldSet: func(oldSet *apps.StatefulSet) {
+				SetStatefulSetLastAppliedConfigAnnotation(oldSet)
+			},
+			changePods:          nil,
+			beginEvictLeaderErr: true,
+			endEvictLeaderErr:   false,
+			updatePodErr:        false,
+			errExpectFn: func(g *GomegaWithT, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+			},
+			expectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster, newSet *apps.StatefulSet, pods map[string]*corev1.Pod) {
+				g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(2)))
+				_, exist := pods[TikvPodName(upgradeTcName, 2)].Annotations[EvictLeaderBeginTime]
+				g.Expect(exist).To(BeFalse())
+			},
+		},
+		{
+			name: "evict leader when the other stores are down",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.PD.Phase = v1alpha1.NormalPhase
+				tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+				tc.Status.TiKV.Synced = true
+				for _, id := range []string{"1", "2"} {
+					store := tc.Status.TiKV.Stores[id]
+					store.State = v1alpha1.TiKVStateDown
+					tc.Status.TiKV.Stores[id] = store
+				}
+			},
+			changeOldSet: func(oldSet *apps.StatefulSet) {
+				SetStatefulSetLastAppliedConfigAnnotation(oldSet)
+			},
+			changePods:          nil,
+			beginEvictLeaderErr: false,
+			endEvictLeaderErr:   false,
+			updatePodErr:        false,
+			errExpectFn: func(g *GomegaWithT, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+			},
+			expectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster, newSet *apps.StatefulSet, pods map[string]*corev1.Pod) {
+				g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(3)))
+				_, exist := pods[TikvPodName(upgradeTcName, 2)].Annotations[EvictLeaderBeginTime]
+				g.Expect(exist).To(BeTrue())
+			},
+		},
 		{
 			name: "to upgrade the pod which ordinal is 1",
 			changeFn: func(tc *v1alpha1.TidbCluster) {
@@ -399,6 +452,7 @@ func TestTiKVUpgraderUpgrade(t *testing.T) {
 				g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(2)))
 				_, exist := pods[TikvPodName(upgradeTcName, 1)].Annotations[EvictLeaderBeginTime]
 				g.Expect(exist).To(BeTrue())
+				g.Expect(tc.Status.TiKV.EvictLeaderStores).To(ConsistOf("2"))
 			},
 		},
 		{
@@ -668,6 +722,393 @@ func TestTiKVUpgraderUpgrade(t *testing.T) {
 	}
 }
 
+func TestCleanupStaleEvictLeaderSchedulers(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name            string
+		changeFn        func(*v1alpha1.TidbCluster)
+		changePods      func([]*corev1.Pod)
+		changeSet       func(*apps.StatefulSet)
+		schedulers      []string
+		endEvictErr     bool
+		errExpectFn     func(*GomegaWithT, error)
+		expectStoreIDs  []uint64
+		expectEvictList []string
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+		fakeDeps := controller.NewFakeDependencies()
+		pdControl := fakeDeps.PDControl.(*pdapi.FakePDControl)
+		podInformer := fakeDeps.KubeInformerFactory.Core().V1().Pods()
+
+		tc := newTidbClusterForTiKVUpgrader()
+		tc.Status.TiKV.EvictLeaderStores = []string{"1", "2", "3"}
+		if test.changeFn != nil {
+			test.changeFn(tc)
+		}
+
+		pdClient := controller.NewFakePDClient(pdControl, tc)
+		pdClient.AddReaction(pdapi.GetEvictLeaderSchedulersActionType, func(action *pdapi.Action) (interface{}, error) {
+			return test.schedulers, nil
+		})
+		var endedStoreIDs []uint64
+		pdClient.AddReaction(pdapi.EndEvictLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+			if test.endEvictErr {
+				return nil, fmt.Errorf("failed to end evict leader")
+			}
+			endedStoreIDs = append(endedStoreIDs, action.ID)
+			return nil, nil
+		})
+
+		set := oldStatefulSetForTiKVUpgrader()
+		if test.changeSet != nil {
+			test.changeSet(set)
+		}
+		tikvPods := getTiKVPods(oldStatefulSetForTiKVUpgrader())
+		if test.changePods != nil {
+			test.changePods(tikvPods)
+		}
+		for _, pod := range tikvPods {
+			podInformer.Informer().GetIndexer().Add(pod)
+		}
+
+		err := cleanupStaleEvictLeaderSchedulers(fakeDeps, tc, set)
+		test.errExpectFn(g, err)
+		g.Expect(endedStoreIDs).To(ConsistOf(test.expectStoreIDs))
+		g.Expect(tc.Status.TiKV.EvictLeaderStores).To(ConsistOf(test.expectEvictList))
+	}
+
+	tests := []*testcase{
+		{
+			name:            "no evict leader schedulers",
+			schedulers:      nil,
+			errExpectFn:     errExpectNil,
+			expectEvictList: nil,
+		},
+		{
+			name: "stale schedulers are removed when tikv is not upgrading",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+			},
+			schedulers:      []string{"evict-leader-scheduler-1", "evict-leader-scheduler-3"},
+			errExpectFn:     errExpectNil,
+			expectStoreIDs:  []uint64{1, 3},
+			expectEvictList: nil,
+		},
+		{
+			name: "schedulers not added by the operator are ignored",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+				tc.Status.TiKV.EvictLeaderStores = []string{"2"}
+			},
+			schedulers:      []string{"evict-leader-scheduler-1", "evict-leader-scheduler-2", "evict-leader-scheduler-100"},
+			errExpectFn:     errExpectNil,
+			expectStoreIDs:  []uint64{2},
+			expectEvictList: nil,
+		},
+		{
+			name: "nothing is done if no store is recorded",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+				tc.Status.TiKV.EvictLeaderStores = nil
+			},
+			schedulers:      []string{"evict-leader-scheduler-1"},
+			errExpectFn:     errExpectNil,
+			expectStoreIDs:  nil,
+			expectEvictList: nil,
+		},
+		{
+			name: "scheduler of the deleted store is removed",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+				tc.Status.TiKV.EvictLeaderStores = []string{"100"}
+			},
+			schedulers:      []string{"evict-leader-scheduler-100"},
+			errExpectFn:     errExpectNil,
+			expectStoreIDs:  []uint64{100},
+			expectEvictList: nil,
+		},
+		{
+			name: "scheduler of the missing store is kept if the status is not synced",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+				tc.Status.TiKV.Synced = false
+				tc.Status.TiKV.EvictLeaderStores = []string{"100"}
+			},
+			schedulers:      []string{"evict-leader-scheduler-100"},
+			errExpectFn:     errExpectNil,
+			expectStoreIDs:  nil,
+			expectEvictList: []string{"100"},
+		},
+		{
+			name: "scheduler of the store scaled in is removed once it's tombstone",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiKV.EvictLeaderStores = []string{"3"}
+				tc.Status.TiKV.TombstoneStores = map[string]v1alpha1.TiKVStore{"3": tc.Status.TiKV.Stores["3"]}
+				delete(tc.Status.TiKV.Stores, "3")
+			},
+			changePods: func(pods []*corev1.Pod) {
+				pods[2].Annotations = map[string]string{EvictLeaderBeginTime: time.Now().Format(time.RFC3339)}
+			},
+			schedulers:      []string{"evict-leader-scheduler-3"},
+			errExpectFn:     errExpectNil,
+			expectStoreIDs:  []uint64{3},
+			expectEvictList: nil,
+		},
+		{
+			name: "scheduler of the store evicting leaders is kept",
+			changePods: func(pods []*corev1.Pod) {
+				pods[1].Annotations = map[string]string{EvictLeaderBeginTime: time.Now().Format(time.RFC3339)}
+			},
+			schedulers:      []string{"evict-leader-scheduler-1", "evict-leader-scheduler-2"},
+			errExpectFn:     errExpectNil,
+			expectStoreIDs:  []uint64{1},
+			expectEvictList: []string{"2"},
+		},
+		{
+			name: "scheduler of the store being upgraded is kept before the annotation is seen",
+			changeSet: func(set *apps.StatefulSet) {
+				set.Spec.UpdateStrategy.RollingUpdate.Partition = pointer.Int32Ptr(2)
+			},
+			schedulers:      []string{"evict-leader-scheduler-1", "evict-leader-scheduler-2", "evict-leader-scheduler-3"},
+			errExpectFn:     errExpectNil,
+			expectStoreIDs:  []uint64{1},
+			expectEvictList: []string{"2", "3"},
+		},
+		{
+			name: "schedulers of the batch being upgraded are kept before the annotations are seen",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Spec.TiKV.UpgradePolicy = &v1alpha1.TiKVUpgradePolicy{MaxUnavailable: pointer.Int32Ptr(2)}
+			},
+			schedulers:      []string{"evict-leader-scheduler-1", "evict-leader-scheduler-2", "evict-leader-scheduler-3"},
+			errExpectFn:     errExpectNil,
+			expectStoreIDs:  []uint64{1},
+			expectEvictList: []string{"2", "3"},
+		},
+		{
+			name: "scheduler is kept when the partition is unknown in upgrading",
+			changeSet: func(set *apps.StatefulSet) {
+				set.Spec.UpdateStrategy.RollingUpdate = nil
+			},
+			schedulers:      []string{"evict-leader-scheduler-1"},
+			errExpectFn:     errExpectNil,
+			expectStoreIDs:  nil,
+			expectEvictList: []string{"1"},
+		},
+		{
+			name: "scheduler of the upgraded store is removed after upgrading",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+			},
+			changePods: func(pods []*corev1.Pod) {
+				pods[2].Labels[apps.ControllerRevisionHashLabelKey] = "2"
+			},
+			schedulers:      []string{"evict-leader-scheduler-3"},
+			errExpectFn:     errExpectNil,
+			expectStoreIDs:  []uint64{3},
+			expectEvictList: nil,
+		},
+		{
+			name: "scheduler of the store whose pod is missing is kept",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+			},
+			changePods: func(pods []*corev1.Pod) {
+				pods[1].Name = "other-pod"
+			},
+			schedulers:      []string{"evict-leader-scheduler-2"},
+			errExpectFn:     errExpectNil,
+			expectStoreIDs:  nil,
+			expectEvictList: []string{"2"},
+		},
+		{
+			name: "failed to end evict leader",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiKV.Phase = v1alpha1.NormalPhase
+			},
+			schedulers:      []string{"evict-leader-scheduler-1"},
+			endEvictErr:     true,
+			errExpectFn:     errExpectNotNil,
+			expectStoreIDs:  nil,
+			expectEvictList: []string{"1", "2", "3"},
+		},
+		{
+			name: "tikv is not bootstrapped",
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.TiKV.BootStrapped = false
+			},
+			schedulers:      []string{"evict-leader-scheduler-1"},
+			errExpectFn:     errExpectNil,
+			expectStoreIDs:  nil,
+			expectEvictList: []string{"1", "2", "3"},
+		},
+	}
+
+	for _, test := range tests {
+		testFn(test, t)
+	}
+}
+
+func TestTiKVUpgraderMaxUnavailable(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	upgrader, pdControl, _, podInformer, tikvControl := newTiKVUpgrader()
+	tc := newTidbClusterForTiKVUpgrader()
+	tc.Spec.TiKV.Replicas = 4
+	tc.Spec.TiKV.UpgradePolicy = &v1alpha1.TiKVUpgradePolicy{MaxUnavailable: pointer.Int32Ptr(2)}
+	tc.Status.TiKV.Stores["4"] = v1alpha1.TiKVStore{ID: "4", PodName: TikvPodName(upgradeTcName, 3), State: v1alpha1.TiKVStateUp}
+	tc.Status.Topology = &v1alpha1.ClusterTopology{}
+	for i, zone := range []string{"a", "a", "b", "b"} {
+		tc.Status.Topology.TiKV = append(tc.Status.Topology.TiKV, v1alpha1.PodTopology{PodName: TikvPodName(upgradeTcName, int32(i)), Zone: zone})
+	}
+	oldSet := oldStatefulSetForTiKVUpgrader()
+	oldSet.Spec.Replicas = pointer.Int32Ptr(4)
+	oldSet.Status.Replicas = 4
+	oldSet.Status.CurrentReplicas = 4
+	SetStatefulSetLastAppliedConfigAnnotation(oldSet)
+
+	pdClient := controller.NewFakePDClient(pdControl, tc)
+	var evicting []string
+	pdClient.AddReaction(pdapi.BeginEvictLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+		evicting = append(evicting, strconv.FormatUint(action.ID, 10))
+		return nil, nil
+	})
+	pdClient.AddReaction(pdapi.EndEvictLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+		return nil, nil
+	})
+	missPeers := 0
+	pdClient.AddReaction(pdapi.GetRegionCountByCheckActionType, func(action *pdapi.Action) (interface{}, error) {
+		if action.Name == pdapi.RegionCheckMissPeer {
+			return missPeers, nil
+		}
+		return 0, nil
+	})
+	for i := int32(0); i < 4; i++ {
+		tikvClient := controller.NewFakeTiKVClient(tikvControl, tc, TikvPodName(upgradeTcName, i))
+		tikvClient.AddReaction(tikvapi.GetLeaderCountActionType, func(action *tikvapi.Action) (interface{}, error) {
+			return 0, nil
+		})
+	}
+	for _, pod := range getTiKVPods(oldSet) {
+		g.Expect(podInformer.Informer().GetIndexer().Add(pod)).To(Succeed())
+	}
+	podExists := func(ordinal int32) bool {
+		_, err := podInformer.Lister().Pods(corev1.NamespaceDefault).Get(TikvPodName(upgradeTcName, ordinal))
+		return err == nil
+	}
+	upgrade := func(partition int32) (*apps.StatefulSet, error) {
+		oldSet.Spec.UpdateStrategy.RollingUpdate.Partition = pointer.Int32Ptr(partition)
+		newSet := oldSet.DeepCopy()
+		return newSet, upgrader.Upgrade(tc, oldSet, newSet)
+	}
+
+	// the leaders are evicted from a batch of stores in the same zone
+	newSet, err := upgrade(4)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(4)))
+	g.Expect(evicting).To(ConsistOf("4", "3"))
+
+	// the partition is lowered to cover the batch once the leaders are evicted
+	newSet, err = upgrade(4)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(2)))
+
+	// the pods of the batch are evicted
+	_, err = upgrade(2)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(podExists(3)).To(BeFalse())
+	g.Expect(podExists(2)).To(BeFalse())
+	g.Expect(podExists(1)).To(BeTrue())
+
+	// the next batch waits for the regions missing peers
+	for _, pod := range getTiKVPods(oldSet)[2:] {
+		pod.Labels[apps.ControllerRevisionHashLabelKey] = "2"
+		g.Expect(podInformer.Informer().GetIndexer().Add(pod)).To(Succeed())
+	}
+	missPeers = 1
+	evicting = nil
+	_, err = upgrade(2)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(evicting).To(BeEmpty())
+
+	missPeers = 0
+	_, err = upgrade(2)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(evicting).To(ConsistOf("2", "1"))
+}
+
+func TestTiKVUpgradeBatch(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	zones := map[string]string{}
+	for i, zone := range []string{"a", "a", "b", "b", "", "b"} {
+		zones[TikvPodName(upgradeTcName, int32(i))] = zone
+	}
+	// the batch is cut before a pod in another zone
+	g.Expect(tikvUpgradeBatch(upgradeTcName, []int32{3, 2, 1, 0}, zones, 3)).To(Equal([]int32{3, 2}))
+	g.Expect(tikvUpgradeBatch(upgradeTcName, []int32{3, 2, 1, 0}, zones, 1)).To(Equal([]int32{3}))
+	g.Expect(tikvUpgradeBatch(upgradeTcName, []int32{1, 0}, zones, 3)).To(Equal([]int32{1, 0}))
+	// the pods in an unknown zone are upgraded alone
+	g.Expect(tikvUpgradeBatch(upgradeTcName, []int32{5, 4, 3}, zones, 3)).To(Equal([]int32{5}))
+	g.Expect(tikvUpgradeBatch(upgradeTcName, []int32{4, 3}, zones, 3)).To(Equal([]int32{4}))
+}
+
+func TestTiKVUpgraderMaxConcurrentEvictLeaders(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	upgrader, pdControl, _, podInformer, tikvControl := newTiKVUpgrader()
+	tc := newTidbClusterForTiKVUpgrader()
+	tc.Spec.TiKV.UpgradePolicy = &v1alpha1.TiKVUpgradePolicy{MaxUnavailable: pointer.Int32Ptr(2)}
+	tc.Spec.TiKV.MaxConcurrentEvictLeaders = pointer.Int32Ptr(1)
+	tc.Status.Topology = &v1alpha1.ClusterTopology{}
+	for i, zone := range []string{"a", "b", "b"} {
+		tc.Status.Topology.TiKV = append(tc.Status.Topology.TiKV, v1alpha1.PodTopology{PodName: TikvPodName(upgradeTcName, int32(i)), Zone: zone})
+	}
+	oldSet := oldStatefulSetForTiKVUpgrader()
+	SetStatefulSetLastAppliedConfigAnnotation(oldSet)
+
+	pdClient := controller.NewFakePDClient(pdControl, tc)
+	var evicting []string
+	pdClient.AddReaction(pdapi.BeginEvictLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+		evicting = append(evicting, strconv.FormatUint(action.ID, 10))
+		return nil, nil
+	})
+	pdClient.AddReaction(pdapi.GetRegionCountByCheckActionType, func(action *pdapi.Action) (interface{}, error) {
+		return 0, nil
+	})
+	for i := int32(0); i < 3; i++ {
+		tikvClient := controller.NewFakeTiKVClient(tikvControl, tc, TikvPodName(upgradeTcName, i))
+		tikvClient.AddReaction(tikvapi.GetLeaderCountActionType, func(action *tikvapi.Action) (interface{}, error) {
+			return 0, nil
+		})
+	}
+	for _, pod := range getTiKVPods(oldSet) {
+		g.Expect(podInformer.Informer().GetIndexer().Add(pod)).To(Succeed())
+	}
+	upgrade := func() (*apps.StatefulSet, error) {
+		newSet := oldSet.DeepCopy()
+		return newSet, upgrader.Upgrade(tc, oldSet, newSet)
+	}
+
+	// the batch is cut to the stores allowed to evict leaders at the same time
+	newSet, err := upgrade()
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(3)))
+	g.Expect(evicting).To(ConsistOf("3"))
+	g.Expect(tc.Status.TiKV.EvictLeaderStores).To(ConsistOf("3"))
+	pod, err := podInformer.Lister().Pods(corev1.NamespaceDefault).Get(TikvPodName(upgradeTcName, 2))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pod.Annotations).To(HaveKey(EvictLeaderBeginTime))
+
+	// only the pod evicting leaders is upgraded
+	newSet, err = upgrade()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(2)))
+	g.Expect(evicting).To(ConsistOf("3"))
+}
+
 func newTiKVUpgrader() (TiKVUpgrader, *pdapi.FakePDControl, *controller.FakePodControl, podinformers.PodInformer, *tikvapi.FakeTiKVControl) {
 	fakeDeps := controller.NewFakeDependencies()
 	pdControl := fakeDeps.PDControl.(*pdapi.FakePDControl)
@@ -812,3 +1253,189 @@ func getTiKVPods(set *apps.StatefulSet) []*corev1.Pod {
 	}
 	return pods
 }
+
+func TestBeginEvictLeaderMarksPodFirst(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for _, beginErr := range []error{nil, fmt.Errorf("PD is unavailable")} {
+		fakeDeps := controller.NewFakeDependencies()
+		tc := newTidbClusterForTiKVUpgrader()
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: TikvPodName(upgradeTcName, 0), Namespace: corev1.NamespaceDefault}}
+		podIndexer := fakeDeps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+		g.Expect(podIndexer.Add(pod)).To(Succeed())
+		getPod := func() *corev1.Pod {
+			pod, err := fakeDeps.PodLister.Pods(pod.Namespace).Get(pod.Name)
+			g.Expect(err).NotTo(HaveOccurred())
+			return pod
+		}
+
+		pdClient := controller.NewFakePDClient(fakeDeps.PDControl.(*pdapi.FakePDControl), tc)
+		pdClient.AddReaction(pdapi.BeginEvictLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+			// the Pod is marked before the scheduler is added
+			g.Expect(getPod().Annotations).To(HaveKey(EvictLeaderBeginTime))
+			return nil, beginErr
+		})
+
+		err := beginEvictLeader(fakeDeps, tc, 1, pod)
+		if beginErr == nil {
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(getPod().Annotations).To(HaveKey(EvictLeaderBeginTime))
+		} else {
+			// the eviction begins again if the scheduler is not added
+			g.Expect(err).To(Equal(beginErr))
+			g.Expect(getPod().Annotations).NotTo(HaveKey(EvictLeaderBeginTime))
+		}
+		g.Expect(pod.Annotations).NotTo(HaveKey(EvictLeaderBeginTime))
+	}
+}
+
+func TestBeginAndEndEvictLeaders(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type testcase struct {
+		name           string
+		maxConcurrent  *int32
+		evictingStores []string
+		storeIDs       []uint64
+		expectStarted  []uint64
+		expectBegun    []uint64
+	}
+
+	testFn := func(test *testcase, t *testing.T) {
+		t.Log(test.name)
+		fakeDeps := controller.NewFakeDependencies()
+		pdControl := fakeDeps.PDControl.(*pdapi.FakePDControl)
+
+		tc := newTidbClusterForTiKVUpgrader()
+		tc.Spec.TiKV.MaxConcurrentEvictLeaders = test.maxConcurrent
+		tc.Status.TiKV.EvictLeaderStores = test.evictingStores
+
+		pdClient := controller.NewFakePDClient(pdControl, tc)
+		var begunStoreIDs, endedStoreIDs []uint64
+		pdClient.AddReaction(pdapi.BeginEvictLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+			begunStoreIDs = append(begunStoreIDs, action.ID)
+			return nil, nil
+		})
+		pdClient.AddReaction(pdapi.EndEvictLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+			endedStoreIDs = append(endedStoreIDs, action.ID)
+			return nil, nil
+		})
+
+		var pods []*corev1.Pod
+		for _, id := range test.storeIDs {
+			pods = append(pods, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("tikv-%d", id), Namespace: corev1.NamespaceDefault}})
+		}
+		started, err := beginEvictLeaders(fakeDeps, tc, test.storeIDs, pods)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(started).To(Equal(test.expectStarted))
+		g.Expect(begunStoreIDs).To(Equal(test.expectBegun))
+		for i, id := range test.storeIDs {
+			// the Pods of the stores begun are marked before their schedulers are added
+			begun := false
+			for _, b := range test.expectBegun {
+				begun = begun || b == id
+			}
+			_, marked := pods[i].Annotations[EvictLeaderBeginTime]
+			g.Expect(marked).To(Equal(begun))
+		}
+
+		err = endEvictLeaders(fakeDeps, tc, started)
+		g.Expect(err).NotTo(HaveOccurred())
+		var reversed []uint64
+		for i := len(started) - 1; i >= 0; i-- {
+			reversed = append(reversed, started[i])
+		}
+		g.Expect(endedStoreIDs).To(Equal(reversed))
+		for _, id := range started {
+			g.Expect(tc.Status.TiKV.EvictLeaderStores).NotTo(ContainElement(strconv.FormatUint(id, 10)))
+		}
+	}
+
+	tests := []*testcase{
+		{
+			name:          "default limit evicts one store at a time",
+			storeIDs:      []uint64{1, 2, 3},
+			expectStarted: []uint64{1},
+			expectBegun:   []uint64{1},
+		},
+		{
+			name:          "evict leaders of stores up to the limit",
+			maxConcurrent: pointer.Int32Ptr(2),
+			storeIDs:      []uint64{1, 2, 3},
+			expectStarted: []uint64{1, 2},
+			expectBegun:   []uint64{1, 2},
+		},
+		{
+			name:          "limit is kept below the number of up stores",
+			maxConcurrent: pointer.Int32Ptr(5),
+			storeIDs:      []uint64{1, 2, 3},
+			expectStarted: []uint64{1, 2},
+			expectBegun:   []uint64{1, 2},
+		},
+		{
+			name:           "stores already evicting count against the limit",
+			maxConcurrent:  pointer.Int32Ptr(2),
+			evictingStores: []string{"3"},
+			storeIDs:       []uint64{1, 2},
+			expectStarted:  []uint64{1},
+			expectBegun:    []uint64{1},
+		},
+		{
+			name:           "stores already evicting are not evicted again",
+			maxConcurrent:  pointer.Int32Ptr(2),
+			evictingStores: []string{"1"},
+			storeIDs:       []uint64{1, 2, 3},
+			expectStarted:  []uint64{1, 2},
+			expectBegun:    []uint64{2},
+		},
+	}
+
+	for _, test := range tests {
+		testFn(test, t)
+	}
+}
+
+func TestEvictLeaderRetryAfter(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		name    string
+		backoff *v1alpha1.EvictLeaderBackoff
+		elapsed time.Duration
+		after   time.Duration
+	}{
+		{
+			name:    "no backoff",
+			elapsed: time.Minute,
+		},
+		{
+			name:    "eviction just began",
+			backoff: &v1alpha1.EvictLeaderBackoff{},
+			elapsed: time.Second,
+			after:   5 * time.Second,
+		},
+		{
+			name:    "wait doubles",
+			backoff: &v1alpha1.EvictLeaderBackoff{},
+			elapsed: 20 * time.Second,
+			after:   20 * time.Second,
+		},
+		{
+			name:    "wait is bounded",
+			backoff: &v1alpha1.EvictLeaderBackoff{Interval: pointer.StringPtr("1s"), MaxInterval: pointer.StringPtr("10s")},
+			elapsed: time.Hour,
+			after:   10 * time.Second,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tc := newTidbClusterForTiKVUpgrader()
+			tc.Spec.TiKV.EvictLeaderBackoff = test.backoff
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{EvictLeaderBeginTime: time.Now().Add(-test.elapsed).Format(time.RFC3339)},
+			}}
+			g.Expect(evictLeaderRetryAfter(tc, pod)).To(BeNumerically("~", test.after, time.Second))
+		})
+	}
+}
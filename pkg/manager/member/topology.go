@@ -0,0 +1,116 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"sort"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+)
+
+// syncComponentTopology records the node, zone and store of each Pod of the
+// component into the topology status of the tidb cluster.
+// storeIDs maps the Pod name to the ID of the store running in it, it can be nil
+// for the components without stores.
+// The topology is informational, so the failures are logged and the previous
+// topology of the component is kept rather than failing the status sync.
+func syncComponentTopology(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, storeIDs map[string]string) {
+	ns := tc.GetNamespace()
+	selector, err := label.New().Instance(tc.GetInstanceName()).Component(memberType.String()).Selector()
+	if err != nil {
+		klog.Warningf("syncComponentTopology: failed to get %s selector for cluster %s/%s, keep the previous topology, error: %v", memberType, ns, tc.GetName(), err)
+		return
+	}
+	pods, err := deps.PodLister.Pods(ns).List(selector)
+	if err != nil {
+		klog.Warningf("syncComponentTopology: failed to list %s pods for cluster %s/%s, selector %s, keep the previous topology, error: %v", memberType, ns, tc.GetName(), selector, err)
+		return
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
+
+	truncated := len(pods) > v1alpha1.MaxTopologyMembers
+	if truncated {
+		pods = pods[:v1alpha1.MaxTopologyMembers]
+	}
+
+	members := make([]v1alpha1.PodTopology, 0, len(pods))
+	for _, pod := range pods {
+		member := v1alpha1.PodTopology{
+			PodName:  pod.Name,
+			NodeName: pod.Spec.NodeName,
+			StoreID:  storeIDs[pod.Name],
+		}
+		if member.NodeName != "" && deps.NodeLister != nil {
+			node, err := deps.NodeLister.Get(member.NodeName)
+			if err != nil {
+				klog.V(4).Infof("failed to get node %s of pod %s/%s, skip setting its zone, error: %v", member.NodeName, ns, pod.Name, err)
+			} else {
+				member.Zone = getNodeZone(node)
+			}
+		}
+		members = append(members, member)
+	}
+
+	if tc.Status.Topology == nil {
+		tc.Status.Topology = &v1alpha1.ClusterTopology{}
+	}
+	topology := tc.Status.Topology
+	switch memberType {
+	case v1alpha1.PDMemberType:
+		topology.PD = members
+	case v1alpha1.TiKVMemberType:
+		topology.TiKV = members
+	case v1alpha1.TiDBMemberType:
+		topology.TiDB = members
+	case v1alpha1.TiFlashMemberType:
+		topology.TiFlash = members
+	default:
+		klog.Warningf("syncComponentTopology: unsupported member type %s", memberType)
+		return
+	}
+
+	var truncatedComponents []v1alpha1.MemberType
+	for _, mt := range topology.TruncatedComponents {
+		if mt != memberType {
+			truncatedComponents = append(truncatedComponents, mt)
+		}
+	}
+	if truncated {
+		truncatedComponents = append(truncatedComponents, memberType)
+	}
+	topology.TruncatedComponents = truncatedComponents
+}
+
+// getNodeZone returns the zone of the node from its well-known topology labels
+func getNodeZone(node *corev1.Node) string {
+	if zone, ok := node.Labels[corev1.LabelZoneFailureDomainStable]; ok {
+		return zone
+	}
+	return node.Labels[corev1.LabelZoneFailureDomain]
+}
+
+// storeIDsByPodName maps the Pod name of each store to the store ID
+func storeIDsByPodName(stores map[string]v1alpha1.TiKVStore) map[string]string {
+	storeIDs := make(map[string]string, len(stores))
+	for _, store := range stores {
+		storeIDs[store.PodName] = store.ID
+	}
+	return storeIDs
+}
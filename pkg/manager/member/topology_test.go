@@ -0,0 +1,138 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSyncComponentTopology(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	newTopologyPod := func(tc *v1alpha1.TidbCluster, name string, nodeName string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: tc.Namespace,
+				Labels:    label.New().Instance(tc.GetInstanceName()).TiKV().Labels(),
+			},
+			Spec: corev1.PodSpec{NodeName: nodeName},
+		}
+	}
+
+	type testcase struct {
+		name     string
+		podCount int
+		changeFn func(*v1alpha1.TidbCluster)
+		expectFn func(*GomegaWithT, *v1alpha1.TidbCluster)
+	}
+
+	tests := []testcase{
+		{
+			name:     "map tikv pods to nodes, zones and stores",
+			podCount: 3,
+			expectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster) {
+				g.Expect(tc.Status.Topology).NotTo(BeNil())
+				g.Expect(tc.Status.Topology.TiKV).To(Equal([]v1alpha1.PodTopology{
+					{PodName: "test-tikv-0", NodeName: "node-0", Zone: "zone-0", StoreID: "1"},
+					{PodName: "test-tikv-1", NodeName: "node-1", Zone: "zone-1", StoreID: "2"},
+					{PodName: "test-tikv-2", NodeName: "node-2", Zone: "zone-0"},
+				}))
+				g.Expect(tc.Status.Topology.TruncatedComponents).To(BeEmpty())
+			},
+		},
+		{
+			name:     "keep other components untouched",
+			podCount: 1,
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.Topology = &v1alpha1.ClusterTopology{
+					PD: []v1alpha1.PodTopology{{PodName: "test-pd-0", NodeName: "node-0"}},
+				}
+			},
+			expectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster) {
+				g.Expect(tc.Status.Topology.PD).To(HaveLen(1))
+				g.Expect(tc.Status.Topology.TiKV).To(HaveLen(1))
+			},
+		},
+		{
+			name:     "truncate large components",
+			podCount: v1alpha1.MaxTopologyMembers + 1,
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.Topology = &v1alpha1.ClusterTopology{
+					TruncatedComponents: []v1alpha1.MemberType{v1alpha1.TiDBMemberType},
+				}
+			},
+			expectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster) {
+				g.Expect(tc.Status.Topology.TiKV).To(HaveLen(v1alpha1.MaxTopologyMembers))
+				g.Expect(tc.Status.Topology.TruncatedComponents).To(ConsistOf(v1alpha1.TiDBMemberType, v1alpha1.TiKVMemberType))
+			},
+		},
+		{
+			name:     "clear the truncated mark",
+			podCount: 1,
+			changeFn: func(tc *v1alpha1.TidbCluster) {
+				tc.Status.Topology = &v1alpha1.ClusterTopology{
+					TruncatedComponents: []v1alpha1.MemberType{v1alpha1.TiKVMemberType},
+				}
+			},
+			expectFn: func(g *GomegaWithT, tc *v1alpha1.TidbCluster) {
+				g.Expect(tc.Status.Topology.TruncatedComponents).To(BeEmpty())
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.name)
+		deps := controller.NewFakeDependencies()
+		podIndexer := deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+		nodeIndexer := deps.KubeInformerFactory.Core().V1().Nodes().Informer().GetIndexer()
+
+		tc := newTidbClusterForPD()
+		tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{
+			"1": {ID: "1", PodName: "test-tikv-0"},
+			"2": {ID: "2", PodName: "test-tikv-1"},
+		}
+		if test.changeFn != nil {
+			test.changeFn(tc)
+		}
+		for i := 0; i < 2; i++ {
+			nodeIndexer.Add(&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   fmt.Sprintf("node-%d", i),
+					Labels: map[string]string{corev1.LabelZoneFailureDomainStable: fmt.Sprintf("zone-%d", i)},
+				},
+			})
+		}
+		nodeIndexer.Add(&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "node-2",
+				Labels: map[string]string{corev1.LabelZoneFailureDomain: "zone-0"},
+			},
+		})
+		for i := 0; i < test.podCount; i++ {
+			podIndexer.Add(newTopologyPod(tc, TikvPodName(tc.Name, int32(i)), fmt.Sprintf("node-%d", i%3)))
+		}
+
+		syncComponentTopology(deps, tc, v1alpha1.TiKVMemberType, storeIDsByPodName(tc.Status.TiKV.Stores))
+		test.expectFn(g, tc)
+	}
+}
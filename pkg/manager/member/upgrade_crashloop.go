@@ -0,0 +1,135 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
+)
+
+const (
+	// crashLoopBackOffReason is the waiting reason of a container in CrashLoopBackOff
+	crashLoopBackOffReason = "CrashLoopBackOff"
+	// upgradeAbortedReason is the reason of the UpgradeAborted condition
+	upgradeAbortedReason = "PodCrashLoopBackOff"
+)
+
+// crashLoopBackOffSince returns since when the Pod is in CrashLoopBackOff, or
+// nil if it is not. The time the Pod becomes not ready is taken, as the
+// CrashLoopBackOff state itself does not carry a time.
+func crashLoopBackOffSince(pod *corev1.Pod) *time.Time {
+	crashLooping := false
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.State.Waiting != nil && cs.State.Waiting.Reason == crashLoopBackOffReason {
+			crashLooping = true
+			break
+		}
+	}
+	if !crashLooping {
+		return nil
+	}
+	since := pod.CreationTimestamp.Time
+	if cond := podutil.GetPodReadyCondition(pod.Status); cond != nil && cond.Status != corev1.ConditionTrue && !cond.LastTransitionTime.IsZero() {
+		since = cond.LastTransitionTime.Time
+	}
+	return &since
+}
+
+// isUpgradeAborted returns whether the upgrade of the component to the
+// revision is aborted. The record of an aborted upgrade to another revision
+// is removed, as the spec is changed and the upgrade can be retried.
+func isUpgradeAborted(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, revision string) bool {
+	aborted, ok := tc.Status.AbortedUpgrades[memberType]
+	if !ok {
+		return false
+	}
+	if aborted.Revision == revision {
+		return true
+	}
+	klog.Infof("tidbcluster: [%s/%s]'s %s is upgraded to a new revision %s, forget the upgrade aborted at revision %s",
+		tc.GetNamespace(), tc.GetName(), memberType, revision, aborted.Revision)
+	delete(tc.Status.AbortedUpgrades, memberType)
+	if len(tc.Status.AbortedUpgrades) == 0 {
+		tc.Status.AbortedUpgrades = nil
+		utiltidbcluster.RemoveTidbClusterCondition(&tc.Status, v1alpha1.TidbClusterUpgradeAborted)
+	}
+	return false
+}
+
+// abortUpgradeOnCrashLoop aborts the upgrade of the component if the upgraded
+// Pod of the ordinal is in CrashLoopBackOff for longer than the threshold of
+// the UpgradeCrashLoopPolicy, and rolls the Pod back to the previous revision
+// if configured. It returns whether the upgrade is aborted.
+func abortUpgradeOnCrashLoop(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType,
+	pod *corev1.Pod, ordinal int32, revision string, newSet *apps.StatefulSet) (bool, error) {
+	policy := tc.Spec.UpgradeCrashLoopPolicy
+	if policy == nil {
+		return false, nil
+	}
+	since := crashLoopBackOffSince(pod)
+	if since == nil {
+		return false, nil
+	}
+	threshold := tc.UpgradeCrashLoopThreshold()
+	if time.Since(*since) < threshold {
+		return false, nil
+	}
+
+	msg := fmt.Sprintf("%s Pod %s is in CrashLoopBackOff for longer than %v after upgraded to revision %s", memberType, pod.GetName(), threshold, revision)
+	return true, abortUpgrade(deps, tc, memberType, pod, ordinal, revision, newSet, policy.Rollback, upgradeAbortedReason, msg)
+}
+
+// abortUpgrade aborts the upgrade of the component to the revision for the
+// reason, and rolls the upgraded Pod of the ordinal back to the previous
+// revision if rollback is true. The Pod is rolled back by moving the partition
+// above it, and deleting it by rollbackUpgradedPods once the partition is
+// persisted, so the Pod is recreated from the current revision of the
+// StatefulSet.
+func abortUpgrade(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType,
+	pod *corev1.Pod, ordinal int32, revision string, newSet *apps.StatefulSet, rollback bool, reason, msg string) error {
+	if rollback {
+		setUpgradePartition(newSet, ordinal+1)
+		msg = fmt.Sprintf("%s, rolling back to the previous revision", msg)
+	}
+	klog.Errorf("tidbcluster: [%s/%s]'s upgrade is aborted, %s", tc.GetNamespace(), tc.GetName(), msg)
+	deps.Recorder.Event(tc, corev1.EventTypeWarning, "UpgradeAborted", msg)
+
+	// the template of newSet is the one of the revision being upgraded to
+	templateHash, err := Sha256Sum(newSet.Spec.Template.Spec)
+	if err != nil {
+		return err
+	}
+	if tc.Status.AbortedUpgrades == nil {
+		tc.Status.AbortedUpgrades = map[v1alpha1.MemberType]v1alpha1.AbortedUpgrade{}
+	}
+	tc.Status.AbortedUpgrades[memberType] = v1alpha1.AbortedUpgrade{
+		PodName:      pod.GetName(),
+		Revision:     revision,
+		RolledBack:   rollback,
+		AbortedAt:    metav1.Now(),
+		TemplateHash: templateHash,
+	}
+	utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+		v1alpha1.TidbClusterUpgradeAborted, corev1.ConditionTrue, reason, msg))
+	return nil
+}
@@ -0,0 +1,275 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/advanced-statefulset/client/apis/apps/v1/helper"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/util"
+	apps "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog"
+)
+
+const (
+	// upgradeHookPreUpgrade is the action of the hook run before a Pod is upgraded
+	upgradeHookPreUpgrade = "PreUpgrade"
+	// upgradeHookPostUpgrade is the action of the hook run after an upgraded Pod becomes healthy
+	upgradeHookPostUpgrade = "PostUpgrade"
+	// upgradeHookFailedReason is the reason of the Events of the failed upgrade hooks
+	upgradeHookFailedReason = "UpgradeHookFailed"
+	// upgradeHookComponent is the component label of the upgrade hook Jobs
+	upgradeHookComponent = "upgrade-hook"
+	// upgradeHookContainer is the name of the container of the upgrade hook Jobs
+	upgradeHookContainer = "upgrade-hook"
+)
+
+// runPreUpgradeHook runs the PreUpgrade hook of the component for the Pod
+// about to be upgraded. It returns nil once the hook succeeds, or fails with
+// the Ignore failure policy, and marks the Pod with the target revision so
+// that the hook is not run again in the following reconciles of the upgrade,
+// but is run again if the upgrade is cancelled and a later one upgrades the
+// Pod to another revision. The Pod is recorded in the
+// new StatefulSet as well, so that the PostUpgrade hook is run once the Pod is
+// upgraded, even if the StatefulSet finishes the upgrade before the operator
+// sees the Pod become healthy.
+func runPreUpgradeHook(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType,
+	pod *corev1.Pod, newSet *apps.StatefulSet) error {
+	hooks := tc.UpgradeHooks(memberType)
+	if hooks == nil {
+		return nil
+	}
+
+	revision := upgradeTargetRevision(tc, memberType)
+	if hooks.PreUpgrade != nil && !upgradeHookDone(pod, label.AnnPreUpgradeHookDone, revision) {
+		if err := runUpgradeHookJob(deps, tc, hooks.PreUpgrade, memberType, upgradeHookPreUpgrade, pod); err != nil {
+			return err
+		}
+		if err := markUpgradeHookDone(deps, tc, pod, label.AnnPreUpgradeHookDone, revision); err != nil {
+			return err
+		}
+		klog.Infof("tc[%s/%s]'s %s hook of %s pod %s succeeded", tc.GetNamespace(), tc.GetName(), upgradeHookPreUpgrade, memberType, pod.GetName())
+	}
+
+	if hooks.PostUpgrade != nil {
+		pods := postUpgradeHookPods(newSet)
+		pods.Insert(pod.GetName())
+		setPostUpgradeHookPods(newSet, pods)
+	}
+	return nil
+}
+
+// runPostUpgradeHook runs the PostUpgrade hook of the component for the
+// upgraded healthy Pod if it is recorded in the new StatefulSet by
+// runPreUpgradeHook. It returns nil once the hook succeeds, or fails with the
+// Ignore failure policy, and the Pod is removed from the StatefulSet then.
+func runPostUpgradeHook(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType,
+	pod *corev1.Pod, newSet *apps.StatefulSet) error {
+	pods := postUpgradeHookPods(newSet)
+	if !pods.Has(pod.GetName()) {
+		return nil
+	}
+
+	// the hook is skipped if it is removed in the middle of the upgrade
+	hooks := tc.UpgradeHooks(memberType)
+	revision := upgradeTargetRevision(tc, memberType)
+	if hooks != nil && hooks.PostUpgrade != nil && !upgradeHookDone(pod, label.AnnPostUpgradeHookDone, revision) {
+		if err := runUpgradeHookJob(deps, tc, hooks.PostUpgrade, memberType, upgradeHookPostUpgrade, pod); err != nil {
+			return err
+		}
+		// the StatefulSet may not be updated in this reconcile, mark the Pod
+		// so that the hook is not run again
+		if err := markUpgradeHookDone(deps, tc, pod, label.AnnPostUpgradeHookDone, revision); err != nil {
+			return err
+		}
+		klog.Infof("tc[%s/%s]'s %s hook of %s pod %s succeeded", tc.GetNamespace(), tc.GetName(), upgradeHookPostUpgrade, memberType, pod.GetName())
+	}
+
+	pods.Delete(pod.GetName())
+	setPostUpgradeHookPods(newSet, pods)
+	return nil
+}
+
+// runUpgradeHookJob returns nil if the Job of the upgrade hook completes, or
+// fails with the Ignore failure policy, and deletes it. Otherwise it creates
+// the Job if it does not exist and requeues. A failed Job, or one running
+// longer than the timeout, is reported by an Event and deleted, so that it is
+// retried with the Fail failure policy.
+func runUpgradeHookJob(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, hook *v1alpha1.UpgradeHook,
+	memberType v1alpha1.MemberType, action string, pod *corev1.Pod) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	podName := pod.GetName()
+	suffix := "pre-upgrade"
+	if action == upgradeHookPostUpgrade {
+		suffix = "post-upgrade"
+	}
+	jobName := fmt.Sprintf("%s-%s", podName, suffix)
+
+	job, err := deps.JobLister.Jobs(ns).Get(jobName)
+	if errors.IsNotFound(err) {
+		job, err = newUpgradeHookJob(tc, hook.Job, memberType, action, podName)
+		if err != nil {
+			return err
+		}
+		job.Name = jobName
+		if err := deps.Controls.JobControl.CreateJob(tc, job); err != nil {
+			return err
+		}
+		return controller.RequeueErrorf("tc[%s/%s]'s %s hook job %s of %s pod %s is created, can't upgrade now", ns, tcName, action, jobName, memberType, podName)
+	}
+	if err != nil {
+		return fmt.Errorf("runUpgradeHookJob: failed to get job %s/%s for tc %s/%s, error: %s", ns, jobName, ns, tcName, err)
+	}
+	if job.DeletionTimestamp != nil {
+		return controller.RequeueErrorf("tc[%s/%s]'s %s hook job %s of %s pod %s is being deleted, can't upgrade now", ns, tcName, action, jobName, memberType, podName)
+	}
+
+	var failure string
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			// delete the Job so that it is run again in the next upgrade
+			return deps.Controls.JobControl.DeleteJob(tc, job)
+		case batchv1.JobFailed:
+			failure = c.Message
+		}
+	}
+	if failure == "" {
+		if job.Status.StartTime == nil || time.Since(job.Status.StartTime.Time) <= hook.Timeout() {
+			return controller.RequeueErrorf("tc[%s/%s]'s %s hook job %s of %s pod %s is running, can't upgrade now", ns, tcName, action, jobName, memberType, podName)
+		}
+		failure = fmt.Sprintf("not completed in %s", hook.Timeout())
+	}
+
+	msg := fmt.Sprintf("%s hook job %s of %s pod %s failed: %s", action, jobName, memberType, podName, failure)
+	deps.Recorder.Event(tc, corev1.EventTypeWarning, upgradeHookFailedReason, msg)
+	if err := deps.Controls.JobControl.DeleteJob(tc, job); err != nil {
+		return err
+	}
+	if hook.FailureIgnored() {
+		klog.Warningf("tc[%s/%s]'s %s, ignore it as the failure policy is %s", ns, tcName, msg, v1alpha1.UpgradeHookFailurePolicyIgnore)
+		return nil
+	}
+	return controller.RequeueErrorf("tc[%s/%s]'s %s, retry it", ns, tcName, msg)
+}
+
+// upgradeTargetRevision returns the revision the component is upgraded to
+func upgradeTargetRevision(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType) string {
+	var status *apps.StatefulSetStatus
+	switch memberType {
+	case v1alpha1.PDMemberType:
+		status = tc.Status.PD.StatefulSet
+	case v1alpha1.TiKVMemberType:
+		status = tc.Status.TiKV.StatefulSet
+	case v1alpha1.TiFlashMemberType:
+		status = tc.Status.TiFlash.StatefulSet
+	case v1alpha1.TiDBMemberType:
+		status = tc.Status.TiDB.StatefulSet
+	}
+	if status == nil {
+		return ""
+	}
+	return status.UpdateRevision
+}
+
+// upgradeHookDone returns whether the Pod is marked with the annotation key
+// for the revision, a mark left by a cancelled upgrade to another revision
+// does not count
+func upgradeHookDone(pod *corev1.Pod, key string, revision string) bool {
+	value, ok := pod.Annotations[key]
+	return ok && value == revision
+}
+
+// markUpgradeHookDone annotates the Pod with the annotation key for the revision
+func markUpgradeHookDone(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, pod *corev1.Pod, key string, revision string) error {
+	pod = pod.DeepCopy()
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Annotations[key] = revision
+	_, err := deps.Controls.PodControl.UpdatePod(tc, pod)
+	return err
+}
+
+func newUpgradeHookJob(tc *v1alpha1.TidbCluster, hook *v1alpha1.JobScaleHook, memberType v1alpha1.MemberType, action string, podName string) (*batchv1.Job, error) {
+	ordinal, err := util.GetOrdinalFromPodName(podName)
+	if err != nil {
+		return nil, err
+	}
+	env := []corev1.EnvVar{
+		{Name: "UPGRADE_NAMESPACE", Value: tc.GetNamespace()},
+		{Name: "UPGRADE_CLUSTER", Value: tc.GetName()},
+		{Name: "UPGRADE_COMPONENT", Value: memberType.String()},
+		{Name: "UPGRADE_ACTION", Value: action},
+		{Name: "UPGRADE_ORDINAL", Value: strconv.Itoa(int(ordinal))},
+		{Name: "UPGRADE_POD_NAME", Value: podName},
+	}
+	return newHookJob(tc, hook, upgradeHookComponent, upgradeHookContainer, env), nil
+}
+
+// postUpgradeHookPods returns the Pods recorded in the StatefulSet whose
+// PostUpgrade hooks are not run yet
+func postUpgradeHookPods(set *apps.StatefulSet) sets.String {
+	pods := sets.NewString()
+	if value := set.Annotations[label.AnnPostUpgradeHookPods]; value != "" {
+		pods.Insert(strings.Split(value, ",")...)
+	}
+	return pods
+}
+
+func setPostUpgradeHookPods(set *apps.StatefulSet, pods sets.String) {
+	if pods.Len() == 0 {
+		delete(set.Annotations, label.AnnPostUpgradeHookPods)
+		return
+	}
+	if set.Annotations == nil {
+		set.Annotations = map[string]string{}
+	}
+	set.Annotations[label.AnnPostUpgradeHookPods] = strings.Join(pods.List(), ",")
+}
+
+// postUpgradeHooksPending returns whether the PostUpgrade hooks of some
+// upgraded Pods are not run yet, the component is kept in the UpgradePhase
+// until they are run.
+func postUpgradeHooksPending(set *apps.StatefulSet) bool {
+	return set.Annotations[label.AnnPostUpgradeHookPods] != ""
+}
+
+// keepPostUpgradeHookPods carries the Pods whose PostUpgrade hooks are not run
+// yet over to the new StatefulSet, which is generated without them. The Pods
+// out of the replicas of the new StatefulSet are dropped.
+func keepPostUpgradeHookPods(oldSet *apps.StatefulSet, newSet *apps.StatefulSet) {
+	pods := postUpgradeHookPods(oldSet)
+	ordinals := helper.GetPodOrdinals(*newSet.Spec.Replicas, newSet)
+	for _, podName := range pods.List() {
+		if ordinal, err := util.GetOrdinalFromPodName(podName); err != nil || !ordinals.Has(ordinal) {
+			pods.Delete(podName)
+		}
+	}
+	setPostUpgradeHookPods(newSet, pods)
+}
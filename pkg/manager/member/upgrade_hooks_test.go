@@ -0,0 +1,233 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	apps "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+)
+
+func TestRunPreUpgradeHook(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	recorder := deps.Recorder.(*record.FakeRecorder)
+	jobIndexer := deps.KubeInformerFactory.Batch().V1().Jobs().Informer().GetIndexer()
+
+	tc := newTidbClusterForPD()
+	pod := newScaleHookPod(tc, v1alpha1.TiDBMemberType, 1)
+	deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer().Add(pod)
+	newSet := &apps.StatefulSet{}
+
+	// nothing is done without the hooks
+	err := runPreUpgradeHook(deps, tc, v1alpha1.TiDBMemberType, pod, newSet)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(postUpgradeHooksPending(newSet)).To(BeFalse())
+
+	tc.Spec.TiDB.UpgradeHooks = &v1alpha1.UpgradeHooks{
+		PreUpgrade:  &v1alpha1.UpgradeHook{Job: &v1alpha1.JobScaleHook{Image: "busybox", Command: []string{"/offline.sh"}}},
+		PostUpgrade: &v1alpha1.UpgradeHook{Job: &v1alpha1.JobScaleHook{Image: "busybox", Command: []string{"/online.sh"}}},
+	}
+
+	// the job is created and the upgrade waits for it
+	err = runPreUpgradeHook(deps, tc, v1alpha1.TiDBMemberType, pod, newSet)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	job, err := deps.JobLister.Jobs(tc.GetNamespace()).Get(fmt.Sprintf("%s-pre-upgrade", pod.GetName()))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(job.OwnerReferences).To(HaveLen(1))
+	container := job.Spec.Template.Spec.Containers[0]
+	g.Expect(container.Name).To(Equal(upgradeHookContainer))
+	g.Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "UPGRADE_ACTION", Value: upgradeHookPreUpgrade}))
+	g.Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "UPGRADE_ORDINAL", Value: "1"}))
+	g.Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "UPGRADE_POD_NAME", Value: pod.GetName()}))
+
+	// the job is running
+	err = runPreUpgradeHook(deps, tc, v1alpha1.TiDBMemberType, pod, newSet)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(collectEvents(recorder.Events)).To(BeEmpty())
+
+	// the job runs longer than the timeout
+	timeout := job.DeepCopy()
+	timeout.Status.StartTime = &metav1.Time{Time: time.Now().Add(-time.Hour)}
+	jobIndexer.Update(timeout)
+	err = runPreUpgradeHook(deps, tc, v1alpha1.TiDBMemberType, pod, newSet)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	events := collectEvents(recorder.Events)
+	g.Expect(events).To(HaveLen(1))
+	g.Expect(events[0]).To(ContainSubstring(upgradeHookFailedReason))
+	g.Expect(postUpgradeHooksPending(newSet)).To(BeFalse())
+
+	// the job completes
+	complete := job.DeepCopy()
+	complete.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+	jobIndexer.Update(complete)
+	err = runPreUpgradeHook(deps, tc, v1alpha1.TiDBMemberType, pod, newSet)
+	g.Expect(err).NotTo(HaveOccurred())
+	pod, err = deps.PodLister.Pods(tc.GetNamespace()).Get(pod.GetName())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pod.Annotations).To(HaveKeyWithValue(label.AnnPreUpgradeHookDone, ""))
+	g.Expect(newSet.Annotations[label.AnnPostUpgradeHookPods]).To(Equal(pod.GetName()))
+
+	// the hook is not run again in the same upgrade
+	jobIndexer.Delete(complete)
+	err = runPreUpgradeHook(deps, tc, v1alpha1.TiDBMemberType, pod, newSet)
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = deps.JobLister.Jobs(tc.GetNamespace()).Get(fmt.Sprintf("%s-pre-upgrade", pod.GetName()))
+	g.Expect(err).To(HaveOccurred())
+
+	// the mark left by a cancelled upgrade does not skip the hook of the next one
+	tc.Status.TiDB.StatefulSet = &apps.StatefulSetStatus{UpdateRevision: "tidb-rev2"}
+	err = runPreUpgradeHook(deps, tc, v1alpha1.TiDBMemberType, pod, newSet)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	_, err = deps.JobLister.Jobs(tc.GetNamespace()).Get(fmt.Sprintf("%s-pre-upgrade", pod.GetName()))
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
+func TestRunPreUpgradeHookFailurePolicy(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		name          string
+		failurePolicy v1alpha1.UpgradeHookFailurePolicy
+		errExpectFn   func(*GomegaWithT, error)
+		annotated     bool
+	}{
+		{
+			name:        "the failure fails the upgrade by default",
+			errExpectFn: errExpectRequeue,
+		},
+		{
+			name:          "the failure fails the upgrade",
+			failurePolicy: v1alpha1.UpgradeHookFailurePolicyFail,
+			errExpectFn:   errExpectRequeue,
+		},
+		{
+			name:          "the failure is ignored",
+			failurePolicy: v1alpha1.UpgradeHookFailurePolicyIgnore,
+			errExpectFn:   errExpectNil,
+			annotated:     true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deps := controller.NewFakeDependencies()
+			recorder := deps.Recorder.(*record.FakeRecorder)
+
+			tc := newTidbClusterForPD()
+			tc.Spec.TiKV.UpgradeHooks = &v1alpha1.UpgradeHooks{
+				PreUpgrade: &v1alpha1.UpgradeHook{
+					Job:           &v1alpha1.JobScaleHook{Image: "busybox"},
+					FailurePolicy: test.failurePolicy,
+				},
+			}
+			pod := newScaleHookPod(tc, v1alpha1.TiKVMemberType, 2)
+			deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer().Add(pod)
+			job, err := newUpgradeHookJob(tc, tc.Spec.TiKV.UpgradeHooks.PreUpgrade.Job, v1alpha1.TiKVMemberType, upgradeHookPreUpgrade, pod.GetName())
+			g.Expect(err).NotTo(HaveOccurred())
+			job.Name = fmt.Sprintf("%s-pre-upgrade", pod.GetName())
+			job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "BackoffLimitExceeded"}}
+			deps.KubeInformerFactory.Batch().V1().Jobs().Informer().GetIndexer().Add(job)
+
+			err = runPreUpgradeHook(deps, tc, v1alpha1.TiKVMemberType, pod, &apps.StatefulSet{})
+			test.errExpectFn(g, err)
+			events := collectEvents(recorder.Events)
+			g.Expect(events).To(HaveLen(1))
+			g.Expect(events[0]).To(ContainSubstring("BackoffLimitExceeded"))
+
+			pod, err = deps.PodLister.Pods(tc.GetNamespace()).Get(pod.GetName())
+			g.Expect(err).NotTo(HaveOccurred())
+			_, annotated := pod.Annotations[label.AnnPreUpgradeHookDone]
+			g.Expect(annotated).To(Equal(test.annotated))
+		})
+	}
+}
+
+func TestRunPostUpgradeHook(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	jobIndexer := deps.KubeInformerFactory.Batch().V1().Jobs().Informer().GetIndexer()
+
+	tc := newTidbClusterForPD()
+	tc.Spec.PD.UpgradeHooks = &v1alpha1.UpgradeHooks{
+		PostUpgrade: &v1alpha1.UpgradeHook{Job: &v1alpha1.JobScaleHook{Image: "busybox"}},
+	}
+	pod0 := newScaleHookPod(tc, v1alpha1.PDMemberType, 0)
+	pod1 := newScaleHookPod(tc, v1alpha1.PDMemberType, 1)
+	deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer().Add(pod0)
+	deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer().Add(pod1)
+	newSet := &apps.StatefulSet{}
+	setPostUpgradeHookPods(newSet, sets.NewString(pod1.GetName()))
+
+	// the hook is not run for the Pod not recorded
+	err := runPostUpgradeHook(deps, tc, v1alpha1.PDMemberType, pod0, newSet)
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = deps.JobLister.Jobs(tc.GetNamespace()).Get(fmt.Sprintf("%s-post-upgrade", pod0.GetName()))
+	g.Expect(err).To(HaveOccurred())
+
+	// the job is created
+	err = runPostUpgradeHook(deps, tc, v1alpha1.PDMemberType, pod1, newSet)
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	job, err := deps.JobLister.Jobs(tc.GetNamespace()).Get(fmt.Sprintf("%s-post-upgrade", pod1.GetName()))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(job.Spec.Template.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "UPGRADE_ACTION", Value: upgradeHookPostUpgrade}))
+	g.Expect(postUpgradeHooksPending(newSet)).To(BeTrue())
+
+	// the job completes and the Pod is removed from the StatefulSet
+	complete := job.DeepCopy()
+	complete.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+	jobIndexer.Update(complete)
+	err = runPostUpgradeHook(deps, tc, v1alpha1.PDMemberType, pod1, newSet)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(postUpgradeHooksPending(newSet)).To(BeFalse())
+	pod1, err = deps.PodLister.Pods(tc.GetNamespace()).Get(pod1.GetName())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pod1.Annotations).To(HaveKey(label.AnnPostUpgradeHookDone))
+
+	// the hook is not run again for the Pod marked
+	setPostUpgradeHookPods(newSet, sets.NewString(pod1.GetName()))
+	jobIndexer.Delete(complete)
+	err = runPostUpgradeHook(deps, tc, v1alpha1.PDMemberType, pod1, newSet)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(postUpgradeHooksPending(newSet)).To(BeFalse())
+}
+
+func TestKeepPostUpgradeHookPods(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	oldSet := &apps.StatefulSet{}
+	setPostUpgradeHookPods(oldSet, sets.NewString("upgrader-tidb-0", "upgrader-tidb-3"))
+	newSet := &apps.StatefulSet{Spec: apps.StatefulSetSpec{Replicas: pointer.Int32Ptr(3)}}
+	keepPostUpgradeHookPods(oldSet, newSet)
+	g.Expect(newSet.Annotations[label.AnnPostUpgradeHookPods]).To(Equal("upgrader-tidb-0"))
+
+	setPostUpgradeHookPods(oldSet, nil)
+	keepPostUpgradeHookPods(oldSet, newSet)
+	g.Expect(postUpgradeHooksPending(newSet)).To(BeFalse())
+}
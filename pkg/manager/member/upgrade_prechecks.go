@@ -0,0 +1,119 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// upgradeBlockedReason is the reason of the UpgradeBlocked condition and Events
+const upgradeBlockedReason = "UpgradeBlocked"
+
+// checkUpgradePreChecks returns a requeue error if the cluster health
+// reported by PD fails the checks of spec.upgradePolicy.preChecks, in which
+// case the UpgradeBlocked condition is set. It's called before the first Pod
+// of the component is upgraded. The Warning Event is only emitted when the
+// failures change, and the condition is resolved by the condition updater of
+// the TidbCluster controller once the upgrade is reverted.
+func checkUpgradePreChecks(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType) error {
+	if tc.Spec.UpgradePolicy == nil || tc.Spec.UpgradePolicy.PreChecks == nil {
+		syncUpgradeBlockedCondition(tc, nil)
+		return nil
+	}
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	checks := tc.Spec.UpgradePolicy.PreChecks
+
+	var failures []string
+	if unhealthy, max := unhealthyMembers(tc), int32Value(checks.MaxUnhealthyStores); len(unhealthy) > max {
+		failures = append(failures, fmt.Sprintf("stores or pd members %s are unhealthy, the max is %d", strings.Join(unhealthy, ", "), max))
+	}
+	pdClient := controller.GetPDClient(deps.PDControl, tc)
+	for _, check := range []struct {
+		name string
+		max  *int32
+	}{
+		{name: pdapi.RegionCheckDownPeer, max: checks.MaxDownPeerRegions},
+		{name: pdapi.RegionCheckPendingPeer, max: checks.MaxPendingPeerRegions},
+	} {
+		count, err := pdClient.GetRegionCountByCheck(check.name)
+		if err != nil {
+			return fmt.Errorf("checkUpgradePreChecks: failed to get %s regions of tc %s/%s, error: %v", check.name, ns, tcName, err)
+		}
+		if max := int32Value(check.max); count > max {
+			failures = append(failures, fmt.Sprintf("there are %d %s regions, the max is %d", count, check.name, max))
+		}
+	}
+	if len(failures) == 0 {
+		syncUpgradeBlockedCondition(tc, nil)
+		return nil
+	}
+
+	msg := fmt.Sprintf("%s upgrade can't start as %s", memberType, strings.Join(failures, "; "))
+	if cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterUpgradeBlocked); cond == nil ||
+		cond.Status != corev1.ConditionTrue || cond.Message != msg {
+		deps.Recorder.Event(tc, corev1.EventTypeWarning, upgradeBlockedReason, msg)
+	}
+	syncUpgradeBlockedCondition(tc, &msg)
+	return controller.RequeueErrorf("tidbcluster: [%s/%s]'s %s", ns, tcName, msg)
+}
+
+// unhealthyMembers returns the names of the TiKV and TiFlash stores that are
+// not Up and of the PD members that are not healthy
+func unhealthyMembers(tc *v1alpha1.TidbCluster) []string {
+	var names []string
+	for _, member := range tc.Status.PD.Members {
+		if !member.Health {
+			names = append(names, member.Name)
+		}
+	}
+	for _, stores := range []map[string]v1alpha1.TiKVStore{tc.Status.TiKV.Stores, tc.Status.TiFlash.Stores} {
+		for _, store := range stores {
+			if store.State != v1alpha1.TiKVStateUp {
+				names = append(names, store.PodName)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func int32Value(v *int32) int {
+	if v == nil {
+		return 0
+	}
+	return int(*v)
+}
+
+// syncUpgradeBlockedCondition sets the UpgradeBlocked condition if msg is not
+// nil, otherwise it resolves the condition set before.
+func syncUpgradeBlockedCondition(tc *v1alpha1.TidbCluster, msg *string) {
+	if msg != nil {
+		utiltidbcluster.UpdateTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+			v1alpha1.TidbClusterUpgradeBlocked, corev1.ConditionTrue, upgradeBlockedReason, *msg))
+		return
+	}
+	if cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterUpgradeBlocked); cond != nil && cond.Status == corev1.ConditionTrue {
+		utiltidbcluster.SetTidbClusterCondition(&tc.Status, *utiltidbcluster.NewTidbClusterCondition(
+			v1alpha1.TidbClusterUpgradeBlocked, corev1.ConditionFalse, "UpgradePreChecksPassed", "the checks before starting the upgrades pass"))
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
+)
+
+func TestCheckUpgradePreChecks(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		name         string
+		checks       *v1alpha1.UpgradePreChecks
+		downPeers    int
+		pendingPeers int
+		storeState   string
+		blocked      bool
+		events       []string
+	}{
+		{
+			name:       "no pre-checks",
+			downPeers:  1,
+			storeState: v1alpha1.TiKVStateDown,
+			events:     []string{},
+		},
+		{
+			name:       "all checks pass",
+			checks:     &v1alpha1.UpgradePreChecks{},
+			storeState: v1alpha1.TiKVStateUp,
+			events:     []string{},
+		},
+		{
+			name:         "pending peers below the threshold",
+			checks:       &v1alpha1.UpgradePreChecks{MaxPendingPeerRegions: pointer.Int32Ptr(10)},
+			pendingPeers: 10,
+			storeState:   v1alpha1.TiKVStateUp,
+			events:       []string{},
+		},
+		{
+			name:         "down and pending peers",
+			checks:       &v1alpha1.UpgradePreChecks{MaxPendingPeerRegions: pointer.Int32Ptr(10)},
+			downPeers:    2,
+			pendingPeers: 11,
+			storeState:   v1alpha1.TiKVStateUp,
+			blocked:      true,
+			events: []string{
+				"Warning UpgradeBlocked tikv upgrade can't start as there are 2 down-peer regions, the max is 0; there are 11 pending-peer regions, the max is 10",
+			},
+		},
+		{
+			name:       "store is down",
+			checks:     &v1alpha1.UpgradePreChecks{},
+			storeState: v1alpha1.TiKVStateDown,
+			blocked:    true,
+			events: []string{
+				"Warning UpgradeBlocked tikv upgrade can't start as stores or pd members test-tikv-1 are unhealthy, the max is 0",
+			},
+		},
+		{
+			name:       "store is down within the threshold",
+			checks:     &v1alpha1.UpgradePreChecks{MaxUnhealthyStores: pointer.Int32Ptr(1)},
+			storeState: v1alpha1.TiKVStateDown,
+			events:     []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deps := controller.NewFakeDependencies()
+			tc := newTidbClusterForPD()
+			tc.Spec.UpgradePolicy = &v1alpha1.UpgradePolicy{PreChecks: test.checks}
+			tc.Status.PD.Members = map[string]v1alpha1.PDMember{
+				"test-pd-0": {Name: "test-pd-0", Health: true},
+			}
+			tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{
+				"1": {ID: "1", PodName: "test-tikv-0", State: v1alpha1.TiKVStateUp},
+				"2": {ID: "2", PodName: "test-tikv-1", State: test.storeState},
+			}
+
+			pdClient := controller.NewFakePDClient(deps.PDControl.(*pdapi.FakePDControl), tc)
+			pdClient.AddReaction(pdapi.GetRegionCountByCheckActionType, func(action *pdapi.Action) (interface{}, error) {
+				if action.Name == pdapi.RegionCheckDownPeer {
+					return test.downPeers, nil
+				}
+				return test.pendingPeers, nil
+			})
+
+			err := checkUpgradePreChecks(deps, tc, v1alpha1.TiKVMemberType)
+			events := collectEvents(deps.Recorder.(*record.FakeRecorder).Events)
+			g.Expect(events).To(Equal(test.events))
+			cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterUpgradeBlocked)
+			if test.blocked {
+				g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+				g.Expect(cond.Status).To(Equal(corev1.ConditionTrue))
+				// the same failures are reported by a single event
+				err = checkUpgradePreChecks(deps, tc, v1alpha1.TiKVMemberType)
+				g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+				g.Expect(collectEvents(deps.Recorder.(*record.FakeRecorder).Events)).To(BeEmpty())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cond).To(BeNil())
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pingcap/advanced-statefulset/client/apis/apps/v1/helper"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	apps "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
+)
+
+// syncUpgradeProgress records the progress of the rolling upgrade of the
+// StatefulSet of the component in status.<component>.upgrade, which is
+// cleared when the component is not in the UpgradePhase. The StartTime is
+// kept as long as the component is upgraded to the same revision, the
+// completion time is estimated from the rate the Pods are upgraded since then,
+// and recomputed only when the number of upgraded Pods changes.
+func syncUpgradeProgress(podLister corelisters.PodLister, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType,
+	set *apps.StatefulSet, phase v1alpha1.MemberPhase, status **v1alpha1.UpgradeProgress) error {
+	if phase != v1alpha1.UpgradePhase {
+		*status = nil
+		return nil
+	}
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	replicas := set.Status.Replicas
+	if set.Spec.Replicas != nil {
+		replicas = *set.Spec.Replicas
+	}
+	now := metav1.Now()
+	revision := set.Status.UpdateRevision
+	progress := &v1alpha1.UpgradeProgress{
+		Revision:  revision,
+		Replicas:  replicas,
+		StartTime: now,
+		Pods:      map[string]v1alpha1.UpgradePodPhase{},
+	}
+	if last := *status; last != nil && last.Revision == revision {
+		progress.StartTime = last.StartTime
+	}
+	if set.Spec.UpdateStrategy.RollingUpdate != nil && set.Spec.UpdateStrategy.RollingUpdate.Partition != nil {
+		progress.CurrentPartition = *set.Spec.UpdateStrategy.RollingUpdate.Partition
+	}
+
+	for ordinal := range helper.GetPodOrdinals(replicas, set) {
+		podName := ordinalPodName(memberType, tcName, ordinal)
+		pod, err := podLister.Pods(ns).Get(podName)
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("syncUpgradeProgress: failed to get pod %s for cluster %s/%s, error: %s", podName, ns, tcName, err)
+		}
+		podPhase := v1alpha1.UpgradePodUpgrading
+		switch {
+		case pod == nil || pod.DeletionTimestamp != nil:
+			// the Pod is being recreated
+		case pod.Labels[apps.ControllerRevisionHashLabelKey] != revision:
+			if ordinal < progress.CurrentPartition {
+				podPhase = v1alpha1.UpgradePodPending
+			}
+		case podutil.IsPodReady(pod):
+			podPhase = v1alpha1.UpgradePodUpgraded
+			progress.UpgradedReplicas++
+		}
+		progress.Pods[podName] = podPhase
+	}
+
+	// the estimation is only updated once more Pods are upgraded, so that the
+	// status is not rewritten by every sync
+	last := *status
+	if last != nil && last.Revision == revision && last.UpgradedReplicas == progress.UpgradedReplicas && last.Replicas == progress.Replicas {
+		progress.EstimatedCompletionTime = last.EstimatedCompletionTime
+	} else if upgraded := progress.UpgradedReplicas; upgraded > 0 && upgraded < progress.Replicas {
+		elapsed := now.Sub(progress.StartTime.Time)
+		eta := metav1.NewTime(now.Add(time.Duration(float64(elapsed) * float64(progress.Replicas-upgraded) / float64(upgraded))))
+		progress.EstimatedCompletionTime = &eta
+	}
+	*status = progress
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+)
+
+func TestSyncUpgradeProgress(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	podIndexer := deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+	tc := newTidbClusterForTiDBUpgrader()
+	set := newStatefulSetForTiDBUpgrader()
+	set.Spec.Replicas = pointer.Int32Ptr(4)
+	set.Spec.UpdateStrategy.RollingUpdate.Partition = pointer.Int32Ptr(1)
+	set.Status.UpdateRevision = "2"
+
+	// Pod 3 is being recreated
+	g.Expect(podIndexer.Add(newTiDBPodForRollback(0, "1", true, time.Hour))).To(Succeed())
+	g.Expect(podIndexer.Add(newTiDBPodForRollback(1, "2", false, time.Second))).To(Succeed())
+	g.Expect(podIndexer.Add(newTiDBPodForRollback(2, "2", true, time.Minute))).To(Succeed())
+
+	startTime := metav1.NewTime(time.Now().Add(-10 * time.Minute).Truncate(time.Second))
+	tc.Status.TiDB.Upgrade = &v1alpha1.UpgradeProgress{Revision: "2", StartTime: startTime}
+	g.Expect(syncUpgradeProgress(deps.PodLister, tc, v1alpha1.TiDBMemberType, set, v1alpha1.UpgradePhase, &tc.Status.TiDB.Upgrade)).To(Succeed())
+	progress := tc.Status.TiDB.Upgrade
+	g.Expect(progress.Revision).To(Equal("2"))
+	g.Expect(progress.CurrentPartition).To(Equal(int32(1)))
+	g.Expect(progress.Replicas).To(Equal(int32(4)))
+	g.Expect(progress.UpgradedReplicas).To(Equal(int32(1)))
+	g.Expect(progress.StartTime).To(Equal(startTime))
+	g.Expect(progress.Pods).To(Equal(map[string]v1alpha1.UpgradePodPhase{
+		"upgrader-tidb-0": v1alpha1.UpgradePodPending,
+		"upgrader-tidb-1": v1alpha1.UpgradePodUpgrading,
+		"upgrader-tidb-2": v1alpha1.UpgradePodUpgraded,
+		"upgrader-tidb-3": v1alpha1.UpgradePodUpgrading,
+	}))
+	// 3 Pods are left after 1 Pod is upgraded in 10 minutes
+	g.Expect(progress.EstimatedCompletionTime).NotTo(BeNil())
+	g.Expect(progress.EstimatedCompletionTime.Sub(time.Now())).To(BeNumerically("~", 30*time.Minute, time.Minute))
+
+	// the estimation is kept until more Pods are upgraded
+	eta := *progress.EstimatedCompletionTime
+	g.Expect(syncUpgradeProgress(deps.PodLister, tc, v1alpha1.TiDBMemberType, set, v1alpha1.UpgradePhase, &tc.Status.TiDB.Upgrade)).To(Succeed())
+	g.Expect(*tc.Status.TiDB.Upgrade.EstimatedCompletionTime).To(Equal(eta))
+	g.Expect(podIndexer.Update(newTiDBPodForRollback(1, "2", true, time.Minute))).To(Succeed())
+	g.Expect(syncUpgradeProgress(deps.PodLister, tc, v1alpha1.TiDBMemberType, set, v1alpha1.UpgradePhase, &tc.Status.TiDB.Upgrade)).To(Succeed())
+	g.Expect(tc.Status.TiDB.Upgrade.UpgradedReplicas).To(Equal(int32(2)))
+	// 2 Pods are left after 2 Pods are upgraded in 10 minutes
+	g.Expect(tc.Status.TiDB.Upgrade.EstimatedCompletionTime.Sub(time.Now())).To(BeNumerically("~", 10*time.Minute, time.Minute))
+
+	// the upgrade to another revision starts over
+	set.Status.UpdateRevision = "3"
+	g.Expect(syncUpgradeProgress(deps.PodLister, tc, v1alpha1.TiDBMemberType, set, v1alpha1.UpgradePhase, &tc.Status.TiDB.Upgrade)).To(Succeed())
+	g.Expect(tc.Status.TiDB.Upgrade.StartTime.Time).To(BeTemporally(">", startTime.Time))
+	g.Expect(tc.Status.TiDB.Upgrade.UpgradedReplicas).To(BeZero())
+	g.Expect(tc.Status.TiDB.Upgrade.EstimatedCompletionTime).To(BeNil())
+
+	// the progress is cleared after the upgrade
+	g.Expect(syncUpgradeProgress(deps.PodLister, tc, v1alpha1.TiDBMemberType, set, v1alpha1.NormalPhase, &tc.Status.TiDB.Upgrade)).To(Succeed())
+	g.Expect(tc.Status.TiDB.Upgrade).To(BeNil())
+}
@@ -0,0 +1,285 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pingcap/advanced-statefulset/client/apis/apps/v1/helper"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/util"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
+)
+
+const (
+	// upgradedPodUnhealthyReason is the reason of the UpgradeAborted condition
+	// when an upgrade is rolled back by spec.upgradePolicy.autoRollback
+	upgradedPodUnhealthyReason = "UpgradedPodUnhealthy"
+	// upgradeRolledBackReason is the reason of the Events of the Pods rolled back
+	upgradeRolledBackReason = "UpgradeRolledBack"
+)
+
+// rollbackUpgradeOnUnhealthy aborts the upgrade of the component and rolls
+// the upgraded Pod of the ordinal back to the previous revision if it fails
+// the health checks for longer than the threshold of
+// spec.upgradePolicy.autoRollback, counted from since. It's called for the
+// upgraded Pods found unhealthy, and returns whether the upgrade is aborted. The other upgraded
+// Pods are rolled back by rollbackUpgradedPods.
+func rollbackUpgradeOnUnhealthy(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType,
+	pod *corev1.Pod, ordinal int32, revision string, newSet *apps.StatefulSet, since time.Time) (bool, error) {
+	threshold := tc.UpgradeAutoRollbackThreshold()
+	if threshold <= 0 {
+		return false, nil
+	}
+	if time.Since(since) < threshold {
+		return false, nil
+	}
+
+	msg := fmt.Sprintf("%s Pod %s fails the health checks for longer than %v after upgraded to revision %s", memberType, pod.GetName(), threshold, revision)
+	return true, abortUpgrade(deps, tc, memberType, pod, ordinal, revision, newSet, true, upgradedPodUnhealthyReason, msg)
+}
+
+// podUnhealthySince returns since when the Pod is unhealthy, which is the
+// time it's created or its readiness changes last.
+func podUnhealthySince(pod *corev1.Pod) time.Time {
+	since := pod.CreationTimestamp.Time
+	if cond := podutil.GetPodReadyCondition(pod.Status); cond != nil && !cond.LastTransitionTime.IsZero() {
+		since = cond.LastTransitionTime.Time
+	}
+	return since
+}
+
+// memberUnhealthySince returns since when the member of the Pod is unhealthy
+// by the last transition time of the member, e.g. the state of a TiKV store
+// or the health of a PD member. The Pod may stay ready while the member is
+// unhealthy, so the time of the Pod is taken only if the member has no
+// transition time, e.g. it's not found.
+func memberUnhealthySince(lastTransitionTime metav1.Time, pod *corev1.Pod) time.Time {
+	if lastTransitionTime.IsZero() {
+		return podUnhealthySince(pod)
+	}
+	return lastTransitionTime.Time
+}
+
+// rollbackUpgradedPods rolls the Pods of the aborted upgrade to the revision
+// back to the previous revision one by one if spec.upgradePolicy.autoRollback
+// is set and the failing Pod is rolled back. The partition is moved above all
+// the Pods, and the Pod of the revision with the highest ordinal is deleted to
+// be recreated from the current revision of the StatefulSet once all the other
+// Pods not of the revision are ready. The Region leaders of a TiKV Pod are
+// evicted before it's deleted, and the template of the StatefulSet is reverted
+// to the current revision after all the Pods are rolled back. Without
+// spec.upgradePolicy.autoRollback, only the failing Pod is rolled back.
+func rollbackUpgradedPods(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType,
+	oldSet *apps.StatefulSet, newSet *apps.StatefulSet, revision string) error {
+	aborted := tc.Status.AbortedUpgrades[memberType]
+	if !aborted.RolledBack {
+		return nil
+	}
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	if tc.UpgradeAutoRollbackThreshold() <= 0 {
+		// only the failing Pod is rolled back, the partition above it is set
+		// when the upgrade is aborted
+		pod, err := deps.PodLister.Pods(ns).Get(aborted.PodName)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			return fmt.Errorf("rollbackUpgradedPods: failed to get pod %s for cluster %s/%s, error: %s", aborted.PodName, ns, tcName, err)
+		}
+		if pod.Labels[apps.ControllerRevisionHashLabelKey] != revision {
+			return nil
+		}
+		return rollbackUpgradedPod(deps, tc, memberType, oldSet, pod, revision)
+	}
+
+	podOrdinals := helper.GetPodOrdinals(*oldSet.Spec.Replicas, oldSet).List()
+	if len(podOrdinals) == 0 {
+		return nil
+	}
+	setUpgradePartition(newSet, podOrdinals[len(podOrdinals)-1]+1)
+	var upgraded *corev1.Pod
+	for _, ordinal := range podOrdinals {
+		podName := ordinalPodName(memberType, tcName, ordinal)
+		pod, err := deps.PodLister.Pods(ns).Get(podName)
+		if err != nil {
+			return fmt.Errorf("rollbackUpgradedPods: failed to get pod %s for cluster %s/%s, error: %s", podName, ns, tcName, err)
+		}
+		if pod.Labels[apps.ControllerRevisionHashLabelKey] == revision {
+			// roll the Pod of the highest ordinal back first
+			upgraded = pod
+			continue
+		}
+		if !podutil.IsPodReady(pod) {
+			return controller.RequeueErrorf("tidbcluster: [%s/%s]'s %s pod: [%s] is not ready, wait for it before rolling back the next pod", ns, tcName, memberType, podName)
+		}
+		if memberType == v1alpha1.TiKVMemberType {
+			if err := endEvictLeaderAfterRollback(deps, tc, ordinal); err != nil {
+				return err
+			}
+		}
+	}
+	if upgraded == nil {
+		return revertAbortedTemplate(deps, oldSet, newSet)
+	}
+	return rollbackUpgradedPod(deps, tc, memberType, oldSet, upgraded, revision)
+}
+
+// rollbackUpgradedPod deletes the Pod of the revision to be recreated from the
+// current revision of the StatefulSet. The Pod is deleted only after the
+// partition above it is persisted in oldSet, otherwise it would be recreated
+// from the revision again.
+func rollbackUpgradedPod(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType,
+	oldSet *apps.StatefulSet, upgraded *corev1.Pod, revision string) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	podName := upgraded.GetName()
+	if upgraded.DeletionTimestamp != nil {
+		return controller.RequeueErrorf("tidbcluster: [%s/%s]'s %s pod: [%s] is being rolled back", ns, tcName, memberType, podName)
+	}
+	ordinal, err := util.GetOrdinalFromPodName(podName)
+	if err != nil {
+		return err
+	}
+	if rolling := oldSet.Spec.UpdateStrategy.RollingUpdate; rolling == nil || rolling.Partition == nil || *rolling.Partition <= ordinal {
+		return controller.RequeueErrorf("tidbcluster: [%s/%s]'s %s pod: [%s] is rolled back after the partition above it is persisted", ns, tcName, memberType, podName)
+	}
+	if memberType == v1alpha1.TiKVMemberType {
+		if err := evictLeaderBeforeRollback(deps, tc, upgraded); err != nil {
+			return err
+		}
+	}
+	if err := deps.PodControl.DeletePod(tc, upgraded); err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("%s Pod %s is rolled back from revision %s to the previous revision", memberType, podName, revision)
+	klog.Infof("tidbcluster: [%s/%s]'s %s", ns, tcName, msg)
+	deps.Recorder.Event(tc, corev1.EventTypeNormal, upgradeRolledBackReason, msg)
+	return controller.RequeueErrorf("tidbcluster: [%s/%s]'s %s", ns, tcName, msg)
+}
+
+// evictLeaderBeforeRollback evicts the Region leaders of the store of the
+// upgraded TiKV Pod before it's deleted to be rolled back, the same as before
+// it's upgraded. It returns a requeue error until the leaders are evicted or
+// the eviction times out. A store not Up has no leaders to evict.
+func evictLeaderBeforeRollback(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, pod *corev1.Pod) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+	podName := pod.GetName()
+	for _, store := range tc.Status.TiKV.Stores {
+		if store.PodName != podName {
+			continue
+		}
+		if store.State != v1alpha1.TiKVStateUp {
+			return nil
+		}
+		storeID, err := strconv.ParseUint(store.ID, 10, 64)
+		if err != nil {
+			return err
+		}
+		if _, evicting := pod.Annotations[EvictLeaderBeginTime]; !evicting {
+			if tc.TiKVSingleStore() {
+				klog.Infof("tikv rollback: only one store in %s/%s, skip evicting leader of store %d", ns, tcName, storeID)
+				return nil
+			}
+			if err := beginEvictLeader(deps, tc, storeID, pod); err != nil {
+				return err
+			}
+			return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tikv pod: [%s] is evicting leaders before rolled back", ns, tcName, podName)
+		}
+		if !isLeaderEvicted(deps, tc, pod) {
+			return controller.RequeueErrorf("tidbcluster: [%s/%s]'s tikv pod: [%s] is evicting leaders before rolled back", ns, tcName, podName)
+		}
+		return nil
+	}
+	return nil
+}
+
+// endEvictLeaderAfterRollback removes the evict leader scheduler of the store
+// of the TiKV Pod of the ordinal after it's rolled back and Up again.
+func endEvictLeaderAfterRollback(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, ordinal int32) error {
+	store := getStoreByOrdinal(tc.GetName(), tc.Status.TiKV, ordinal)
+	if store == nil || store.State != v1alpha1.TiKVStateUp {
+		return nil
+	}
+	for _, id := range tc.Status.TiKV.EvictLeaderStores {
+		if id == store.ID {
+			return endEvictLeader(deps, tc, ordinal)
+		}
+	}
+	return nil
+}
+
+// revertAbortedTemplate reverts the Pod template of the StatefulSet to the one
+// of the current revision after all the Pods of the aborted upgrade are rolled
+// back, so the Pods recreated or scaled out later are not created from the
+// aborted revision either. The template is then kept by keepAbortedTemplate.
+func revertAbortedTemplate(deps *controller.Dependencies, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) error {
+	ns := oldSet.GetNamespace()
+	revision := oldSet.Status.CurrentRevision
+	if revision == "" || revision == oldSet.Status.UpdateRevision {
+		return nil
+	}
+	cr, err := deps.KubeClientset.AppsV1().ControllerRevisions(ns).Get(context.TODO(), revision, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("revertAbortedTemplate: failed to get controller revision %s/%s, error: %s", ns, revision, err)
+	}
+	// the data of a StatefulSet revision is the patch of its Pod template
+	set := &apps.StatefulSet{}
+	if err := json.Unmarshal(cr.Data.Raw, set); err != nil {
+		return fmt.Errorf("revertAbortedTemplate: failed to decode controller revision %s/%s, error: %s", ns, revision, err)
+	}
+	newSet.Spec.Template = set.Spec.Template
+	klog.Infof("statefulset %s/%s: revert the pod template to the current revision %s", ns, newSet.GetName(), revision)
+	return nil
+}
+
+// keepAbortedTemplate keeps the Pod template of the StatefulSet reverted by
+// revertAbortedTemplate as long as the spec renders the same template as the
+// aborted revision, so the upgrade is not started again. It returns whether
+// the template is kept, in which case the upgrade is skipped.
+func keepAbortedTemplate(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, oldSet *apps.StatefulSet, newSet *apps.StatefulSet) (bool, error) {
+	aborted, ok := tc.Status.AbortedUpgrades[memberType]
+	if !ok || !aborted.RolledBack || aborted.TemplateHash == "" {
+		return false, nil
+	}
+	// the template is not reverted yet
+	if templateEqual(newSet, oldSet) {
+		return false, nil
+	}
+	templateHash, err := Sha256Sum(newSet.Spec.Template.Spec)
+	if err != nil {
+		return false, err
+	}
+	if templateHash != aborted.TemplateHash {
+		return false, nil
+	}
+	spec, _, err := GetLastAppliedConfig(oldSet)
+	if err != nil {
+		return false, err
+	}
+	newSet.Spec.Template = spec.Template
+	return true, nil
+}
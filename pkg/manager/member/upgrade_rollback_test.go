@@ -0,0 +1,315 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/pdapi"
+	"github.com/pingcap/tidb-operator/pkg/tikvapi"
+	utiltidbcluster "github.com/pingcap/tidb-operator/pkg/util/tidbcluster"
+)
+
+func newTiDBPodForRollback(ordinal int32, revision string, ready bool, since time.Duration) *corev1.Pod {
+	l := label.New().Instance(upgradeInstanceName).TiDB().Labels()
+	l[apps.ControllerRevisionHashLabelKey] = revision
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: tidbPodName(upgradeTcName, ordinal), Namespace: corev1.NamespaceDefault, Labels: l},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: status, LastTransitionTime: metav1.NewTime(time.Now().Add(-since))},
+			},
+		},
+	}
+}
+
+// createControllerRevisionForRollback creates the ControllerRevision of the
+// StatefulSet revision with the Pod template
+func createControllerRevisionForRollback(deps *controller.Dependencies, revision string, template corev1.PodTemplateSpec) error {
+	data, err := json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"template": template}})
+	if err != nil {
+		return err
+	}
+	_, err = deps.KubeClientset.AppsV1().ControllerRevisions(corev1.NamespaceDefault).Create(context.TODO(), &apps.ControllerRevision{
+		ObjectMeta: metav1.ObjectMeta{Name: revision, Namespace: corev1.NamespaceDefault},
+		Data:       runtime.RawExtension{Raw: data},
+	}, metav1.CreateOptions{})
+	return err
+}
+
+func TestRollbackUpgradeOnUnhealthy(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		name       string
+		rollback   *v1alpha1.UpgradeAutoRollback
+		since      time.Duration
+		rolledBack bool
+	}{
+		{
+			name:  "auto rollback is not set",
+			since: time.Hour,
+		},
+		{
+			name:     "unhealthy within the threshold",
+			rollback: &v1alpha1.UpgradeAutoRollback{},
+			since:    5 * time.Minute,
+		},
+		{
+			name:       "unhealthy for longer than the default threshold",
+			rollback:   &v1alpha1.UpgradeAutoRollback{},
+			since:      11 * time.Minute,
+			rolledBack: true,
+		},
+		{
+			name:     "unhealthy within the threshold set",
+			rollback: &v1alpha1.UpgradeAutoRollback{Threshold: pointer.StringPtr("30m")},
+			since:    11 * time.Minute,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deps := controller.NewFakeDependencies()
+			podIndexer := deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+			tc := newTidbClusterForTiDBUpgrader()
+			tc.Spec.UpgradePolicy = &v1alpha1.UpgradePolicy{AutoRollback: test.rollback}
+			newSet := newStatefulSetForTiDBUpgrader()
+			setUpgradePartition(newSet, 1)
+			pod := newTiDBPodForRollback(1, "2", false, test.since)
+			g.Expect(podIndexer.Add(pod)).To(Succeed())
+
+			aborted, err := rollbackUpgradeOnUnhealthy(deps, tc, v1alpha1.TiDBMemberType, pod, 1, "2", newSet, podUnhealthySince(pod))
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(aborted).To(Equal(test.rolledBack))
+			cond := utiltidbcluster.GetTidbClusterCondition(tc.Status, v1alpha1.TidbClusterUpgradeAborted)
+			if !test.rolledBack {
+				g.Expect(cond).To(BeNil())
+				g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(1)))
+				return
+			}
+			g.Expect(cond.Reason).To(Equal(upgradedPodUnhealthyReason))
+			g.Expect(tc.Status.AbortedUpgrades[v1alpha1.TiDBMemberType].RolledBack).To(BeTrue())
+			g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(2)))
+			// the pod is deleted after the partition is persisted
+			_, exist, err := podIndexer.Get(pod)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(exist).To(BeTrue())
+		})
+	}
+}
+
+func TestRollbackUpgradedPods(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	podIndexer := deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+	tc := newTidbClusterForTiDBUpgrader()
+	tc.Spec.UpgradePolicy = &v1alpha1.UpgradePolicy{AutoRollback: &v1alpha1.UpgradeAutoRollback{}}
+	tc.Status.AbortedUpgrades = map[v1alpha1.MemberType]v1alpha1.AbortedUpgrade{
+		v1alpha1.TiDBMemberType: {PodName: tidbPodName(upgradeTcName, 1), Revision: "2", RolledBack: true},
+	}
+	oldSet := newStatefulSetForTiDBUpgrader()
+	oldSet.Spec.Replicas = pointer.Int32Ptr(3)
+
+	// the failing Pod 1 is recreated from the previous revision, and Pod 2
+	// is still at the revision of the aborted upgrade
+	g.Expect(podIndexer.Add(newTiDBPodForRollback(0, "1", true, time.Hour))).To(Succeed())
+	g.Expect(podIndexer.Add(newTiDBPodForRollback(1, "1", false, time.Second))).To(Succeed())
+	g.Expect(podIndexer.Add(newTiDBPodForRollback(2, "2", true, time.Hour))).To(Succeed())
+
+	// wait for the Pod rolled back to be ready
+	newSet := oldSet.DeepCopy()
+	err := rollbackUpgradedPods(deps, tc, v1alpha1.TiDBMemberType, oldSet, newSet, "2")
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(*newSet.Spec.UpdateStrategy.RollingUpdate.Partition).To(Equal(int32(3)))
+	g.Expect(podIndexer.ListKeys()).To(HaveLen(3))
+
+	// the next Pod is not rolled back until the partition above it is persisted
+	g.Expect(podIndexer.Update(newTiDBPodForRollback(1, "1", true, time.Second))).To(Succeed())
+	newSet = oldSet.DeepCopy()
+	err = rollbackUpgradedPods(deps, tc, v1alpha1.TiDBMemberType, oldSet, newSet, "2")
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(podIndexer.ListKeys()).To(HaveLen(3))
+
+	// roll the next Pod back
+	setUpgradePartition(oldSet, 3)
+	newSet = oldSet.DeepCopy()
+	err = rollbackUpgradedPods(deps, tc, v1alpha1.TiDBMemberType, oldSet, newSet, "2")
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(podIndexer.ListKeys()).To(HaveLen(2))
+	g.Expect(collectEvents(deps.Recorder.(*record.FakeRecorder).Events)).To(Equal([]string{
+		"Normal UpgradeRolledBack tidb Pod upgrader-tidb-2 is rolled back from revision 2 to the previous revision",
+	}))
+
+	// all Pods are rolled back, the template is reverted to the current revision
+	template := corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "tidb", Image: "tidb-previous-image"}}}}
+	g.Expect(createControllerRevisionForRollback(deps, "1", template)).To(Succeed())
+	g.Expect(podIndexer.Add(newTiDBPodForRollback(2, "1", true, time.Second))).To(Succeed())
+	newSet = oldSet.DeepCopy()
+	g.Expect(rollbackUpgradedPods(deps, tc, v1alpha1.TiDBMemberType, oldSet, newSet, "2")).To(Succeed())
+	g.Expect(newSet.Spec.Template).To(Equal(template))
+}
+
+func TestRollbackAbortedPod(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	podIndexer := deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+	tc := newTidbClusterForTiDBUpgrader()
+	tc.Status.AbortedUpgrades = map[v1alpha1.MemberType]v1alpha1.AbortedUpgrade{
+		v1alpha1.TiDBMemberType: {PodName: tidbPodName(upgradeTcName, 1), Revision: "2", RolledBack: true},
+	}
+	oldSet := newStatefulSetForTiDBUpgrader()
+	g.Expect(podIndexer.Add(newTiDBPodForRollback(1, "2", false, time.Hour))).To(Succeed())
+
+	// the Pod is not deleted until the partition above it is persisted
+	err := rollbackUpgradedPods(deps, tc, v1alpha1.TiDBMemberType, oldSet, oldSet.DeepCopy(), "2")
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(podIndexer.ListKeys()).To(HaveLen(1))
+
+	setUpgradePartition(oldSet, 2)
+	err = rollbackUpgradedPods(deps, tc, v1alpha1.TiDBMemberType, oldSet, oldSet.DeepCopy(), "2")
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(podIndexer.ListKeys()).To(BeEmpty())
+
+	// the Pod recreated from the previous revision is left alone
+	g.Expect(podIndexer.Add(newTiDBPodForRollback(1, "1", false, time.Second))).To(Succeed())
+	g.Expect(rollbackUpgradedPods(deps, tc, v1alpha1.TiDBMemberType, oldSet, oldSet.DeepCopy(), "2")).To(Succeed())
+	g.Expect(podIndexer.ListKeys()).To(HaveLen(1))
+}
+
+func TestRollbackUpgradedTiKVPods(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	podIndexer := deps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+	tc := newTidbClusterForTiKVUpgrader()
+	tc.Spec.UpgradePolicy = &v1alpha1.UpgradePolicy{AutoRollback: &v1alpha1.UpgradeAutoRollback{}}
+	tc.Status.AbortedUpgrades = map[v1alpha1.MemberType]v1alpha1.AbortedUpgrade{
+		v1alpha1.TiKVMemberType: {PodName: TikvPodName(upgradeTcName, 1), Revision: "2", RolledBack: true},
+	}
+	oldSet := oldStatefulSetForTiKVUpgrader()
+	setUpgradePartition(oldSet, 3)
+	pods := getTiKVPods(oldSet)
+	pods[2].Labels[apps.ControllerRevisionHashLabelKey] = "2"
+	for _, pod := range pods {
+		g.Expect(podIndexer.Add(pod)).To(Succeed())
+	}
+
+	pdClient := controller.NewFakePDClient(deps.PDControl.(*pdapi.FakePDControl), tc)
+	var evicting []uint64
+	pdClient.AddReaction(pdapi.BeginEvictLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+		evicting = append(evicting, action.ID)
+		return nil, nil
+	})
+	pdClient.AddReaction(pdapi.EndEvictLeaderActionType, func(action *pdapi.Action) (interface{}, error) {
+		return nil, nil
+	})
+	leaderCount := 10
+	tikvClient := controller.NewFakeTiKVClient(deps.TiKVControl.(*tikvapi.FakeTiKVControl), tc, TikvPodName(upgradeTcName, 2))
+	tikvClient.AddReaction(tikvapi.GetLeaderCountActionType, func(action *tikvapi.Action) (interface{}, error) {
+		return leaderCount, nil
+	})
+	podExists := func(ordinal int32) bool {
+		_, exist, err := podIndexer.GetByKey(corev1.NamespaceDefault + "/" + TikvPodName(upgradeTcName, ordinal))
+		g.Expect(err).NotTo(HaveOccurred())
+		return exist
+	}
+
+	// the leaders are evicted before the Pod is rolled back
+	newSet := oldSet.DeepCopy()
+	err := rollbackUpgradedPods(deps, tc, v1alpha1.TiKVMemberType, oldSet, newSet, "2")
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(evicting).To(Equal([]uint64{3}))
+	g.Expect(podExists(2)).To(BeTrue())
+
+	newSet = oldSet.DeepCopy()
+	err = rollbackUpgradedPods(deps, tc, v1alpha1.TiKVMemberType, oldSet, newSet, "2")
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(podExists(2)).To(BeTrue())
+
+	leaderCount = 0
+	newSet = oldSet.DeepCopy()
+	err = rollbackUpgradedPods(deps, tc, v1alpha1.TiKVMemberType, oldSet, newSet, "2")
+	g.Expect(controller.IsRequeueError(err)).To(BeTrue())
+	g.Expect(podExists(2)).To(BeFalse())
+
+	// the eviction ends after the Pod is rolled back, and the template is
+	// reverted to the current revision
+	template := corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "tikv", Image: "tikv-previous-image"}}}}
+	g.Expect(createControllerRevisionForRollback(deps, "1", template)).To(Succeed())
+	g.Expect(podIndexer.Add(getTiKVPods(oldSet)[2])).To(Succeed())
+	newSet = oldSet.DeepCopy()
+	g.Expect(rollbackUpgradedPods(deps, tc, v1alpha1.TiKVMemberType, oldSet, newSet, "2")).To(Succeed())
+	g.Expect(tc.Status.TiKV.EvictLeaderStores).To(BeEmpty())
+	g.Expect(newSet.Spec.Template).To(Equal(template))
+}
+
+func TestKeepAbortedTemplate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	aborted := newStatefulSetForTiKVUpgrader()
+	templateHash, err := Sha256Sum(aborted.Spec.Template.Spec)
+	g.Expect(err).NotTo(HaveOccurred())
+	tc := newTidbClusterForTiKVUpgrader()
+	tc.Status.AbortedUpgrades = map[v1alpha1.MemberType]v1alpha1.AbortedUpgrade{
+		v1alpha1.TiKVMemberType: {Revision: "2", RolledBack: true, TemplateHash: templateHash},
+	}
+	reverted := newStatefulSetForTiKVUpgrader()
+	reverted.Spec.Template.Spec.Containers[0].Image = "tikv-previous-image"
+
+	// the template is not reverted yet
+	oldSet := aborted.DeepCopy()
+	g.Expect(SetStatefulSetLastAppliedConfigAnnotation(oldSet)).To(Succeed())
+	newSet := aborted.DeepCopy()
+	kept, err := keepAbortedTemplate(tc, v1alpha1.TiKVMemberType, oldSet, newSet)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(kept).To(BeFalse())
+
+	// the reverted template is kept while the spec renders the aborted one
+	oldSet = reverted.DeepCopy()
+	g.Expect(SetStatefulSetLastAppliedConfigAnnotation(oldSet)).To(Succeed())
+	newSet = aborted.DeepCopy()
+	kept, err = keepAbortedTemplate(tc, v1alpha1.TiKVMemberType, oldSet, newSet)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(kept).To(BeTrue())
+	g.Expect(newSet.Spec.Template.Spec).To(Equal(reverted.Spec.Template.Spec))
+
+	// the spec is changed to another template
+	newSet = aborted.DeepCopy()
+	newSet.Spec.Template.Spec.Containers[0].Image = "tikv-fixed-image"
+	kept, err = keepAbortedTemplate(tc, v1alpha1.TiKVMemberType, oldSet, newSet)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(kept).To(BeFalse())
+	g.Expect(newSet.Spec.Template.Spec.Containers[0].Image).To(Equal("tikv-fixed-image"))
+}
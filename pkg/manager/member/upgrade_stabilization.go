@@ -0,0 +1,69 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
+)
+
+// defaultStabilizationTimeout is how long to wait for the metric to stabilize by default
+const defaultStabilizationTimeout = 10 * time.Minute
+
+// checkUpgradeStabilization returns a requeue error until the metric of the
+// gate for the upgraded pod drops to the threshold, so that the next pod is
+// not upgraded before the previous one recovers. It returns nil if the gate
+// is nil or the timeout since the pod became ready is exceeded.
+func checkUpgradeStabilization(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, gate *v1alpha1.MetricStabilizationGate, pod *corev1.Pod) error {
+	if gate == nil || pod == nil {
+		return nil
+	}
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	timeout := defaultStabilizationTimeout
+	if gate.Timeout != nil {
+		if d, err := time.ParseDuration(*gate.Timeout); err == nil {
+			timeout = d
+		} else {
+			klog.Warningf("tidbcluster: [%s/%s] invalid stabilization timeout %s, use default %s", ns, tcName, *gate.Timeout, defaultStabilizationTimeout)
+		}
+	}
+	if condition := podutil.GetPodReadyCondition(pod.Status); condition != nil && time.Since(condition.LastTransitionTime.Time) > timeout {
+		klog.Warningf("tidbcluster: [%s/%s]'s upgraded pod %s is not stabilized in %s, continue upgrading", ns, tcName, pod.GetName(), timeout)
+		deps.Recorder.Eventf(tc, corev1.EventTypeWarning, "MetricNotStabilized", "metric of upgraded pod %s is not stabilized in %s, continue upgrading", pod.GetName(), timeout)
+		return nil
+	}
+
+	threshold, err := strconv.ParseFloat(gate.Threshold, 64)
+	if err != nil {
+		return err
+	}
+	query := strings.NewReplacer("$POD_NAME", pod.GetName(), "$NAMESPACE", pod.GetNamespace()).Replace(gate.Query)
+	value, err := deps.PrometheusControl.QueryScalar(gate.PrometheusURL, query)
+	if err != nil {
+		return controller.RequeueErrorf("tidbcluster: [%s/%s] failed to query the metric of upgraded pod %s, error: %v", ns, tcName, pod.GetName(), err)
+	}
+	if value > threshold {
+		return controller.RequeueErrorf("tidbcluster: [%s/%s]'s upgraded pod %s is not stabilized, metric %v is above threshold %v", ns, tcName, pod.GetName(), value, threshold)
+	}
+	return nil
+}
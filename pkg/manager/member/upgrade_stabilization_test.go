@@ -0,0 +1,119 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	perrors "github.com/pingcap/errors"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestCheckUpgradeStabilization(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	const query = `tikv_raftstore_apply_log_duration_seconds{namespace="$NAMESPACE",pod="$POD_NAME"}`
+	const expandedQuery = `tikv_raftstore_apply_log_duration_seconds{namespace="default",pod="test-tikv-1"}`
+
+	type testcase struct {
+		name          string
+		gate          *v1alpha1.MetricStabilizationGate
+		readySince    time.Time
+		value         *float64
+		queryErr      error
+		expectRequeue bool
+	}
+
+	testFn := func(test *testcase) {
+		t.Log(test.name)
+		deps := controller.NewFakeDependencies()
+		promControl := deps.PrometheusControl.(*controller.FakePrometheusControl)
+		if test.value != nil {
+			promControl.SetValue(expandedQuery, *test.value)
+		}
+		if test.queryErr != nil {
+			promControl.SetError(test.queryErr)
+		}
+		tc := newTidbClusterForPD()
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-tikv-1", Namespace: "default"},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{
+					{Type: corev1.PodReady, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(test.readySince)},
+				},
+			},
+		}
+
+		err := checkUpgradeStabilization(deps, tc, test.gate, pod)
+		if test.expectRequeue {
+			g.Expect(perrors.Find(err, controller.IsRequeueError)).NotTo(BeNil())
+		} else {
+			g.Expect(err).NotTo(HaveOccurred())
+		}
+	}
+
+	gate := &v1alpha1.MetricStabilizationGate{
+		PrometheusURL: "http://prometheus:9090",
+		Query:         query,
+		Threshold:     "0.05",
+		Timeout:       pointer.StringPtr("5m"),
+	}
+	tests := []testcase{
+		{
+			name:          "gate is not set",
+			gate:          nil,
+			readySince:    time.Now(),
+			expectRequeue: false,
+		},
+		{
+			name:          "metric is stabilized",
+			gate:          gate,
+			readySince:    time.Now(),
+			value:         pointer.Float64Ptr(0.01),
+			expectRequeue: false,
+		},
+		{
+			name:          "metric is not stabilized",
+			gate:          gate,
+			readySince:    time.Now(),
+			value:         pointer.Float64Ptr(0.2),
+			expectRequeue: true,
+		},
+		{
+			name:          "failed to query the metric",
+			gate:          gate,
+			readySince:    time.Now(),
+			queryErr:      fmt.Errorf("connection refused"),
+			expectRequeue: true,
+		},
+		{
+			name:          "metric is not stabilized after the timeout",
+			gate:          gate,
+			readySince:    time.Now().Add(-10 * time.Minute),
+			value:         pointer.Float64Ptr(0.2),
+			expectRequeue: false,
+		},
+	}
+
+	for i := range tests {
+		testFn(&tests[i])
+	}
+}
@@ -16,6 +16,7 @@ package member
 import (
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	apps "k8s.io/api/apps/v1"
+	"k8s.io/klog"
 )
 
 // Upgrader implements the logic for upgrading the tidb cluster.
@@ -28,3 +29,14 @@ type Upgrader interface {
 type DMUpgrader interface {
 	Upgrade(*v1alpha1.DMCluster, *apps.StatefulSet, *apps.StatefulSet) error
 }
+
+// upgradePaused returns whether the upgrade of the component is paused by
+// spec.<component>.upgradePaused, in which case the partition of the
+// StatefulSet is kept as it is.
+func upgradePaused(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType) bool {
+	if !tc.UpgradePaused(memberType) {
+		return false
+	}
+	klog.Infof("tidbcluster: [%s/%s]'s %s upgrade is paused", tc.GetNamespace(), tc.GetName(), memberType)
+	return true
+}
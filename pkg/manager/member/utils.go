@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
 	"github.com/pingcap/tidb-operator/pkg/apis/util/toml"
 	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/metrics"
 	"github.com/pingcap/tidb-operator/pkg/util"
 	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -46,6 +47,10 @@ const (
 	ImagePullBackOff = "ImagePullBackOff"
 	// ErrImagePull is the pod state of image pull failed
 	ErrImagePull = "ErrImagePull"
+
+	// tmpVolumeName is the name of the emptyDir mounted at /tmp of the main
+	// container if its root filesystem is read-only
+	tmpVolumeName = "tmp"
 )
 
 func annotationsMountVolume() (corev1.VolumeMount, corev1.Volume) {
@@ -224,28 +229,39 @@ func getStsAnnotations(tcAnns map[string]string, component string) map[string]st
 	}
 
 	// ensure the delete-slots annotation
-	var key string
+	key := deleteSlotsAnnKey(component)
+	if key == "" {
+		return anns
+	}
+	if val, ok := tcAnns[key]; ok {
+		anns[helper.DeleteSlotsAnn] = val
+	}
+
+	return anns
+}
+
+// deleteSlotsAnnKey returns the key of the delete-slots annotation of the
+// given component in the cluster, or empty if the component has none
+func deleteSlotsAnnKey(component string) string {
 	switch component {
 	case label.PDLabelVal:
-		key = label.AnnPDDeleteSlots
+		return label.AnnPDDeleteSlots
 	case label.TiDBLabelVal:
-		key = label.AnnTiDBDeleteSlots
+		return label.AnnTiDBDeleteSlots
 	case label.TiKVLabelVal:
-		key = label.AnnTiKVDeleteSlots
+		return label.AnnTiKVDeleteSlots
 	case label.TiFlashLabelVal:
-		key = label.AnnTiFlashDeleteSlots
+		return label.AnnTiFlashDeleteSlots
+	case label.TiCDCLabelVal:
+		return label.AnnTiCDCDeleteSlots
+	case label.PumpLabelVal:
+		return label.AnnPumpDeleteSlots
 	case label.DMMasterLabelVal:
-		key = label.AnnDMMasterDeleteSlots
+		return label.AnnDMMasterDeleteSlots
 	case label.DMWorkerLabelVal:
-		key = label.AnnDMWorkerDeleteSlots
-	default:
-		return anns
+		return label.AnnDMWorkerDeleteSlots
 	}
-	if val, ok := tcAnns[key]; ok {
-		anns[helper.DeleteSlotsAnn] = val
-	}
-
-	return anns
+	return ""
 }
 
 // MapContainers index containers of Pod by container name in favor of looking up
@@ -321,14 +337,55 @@ func findContainerByName(sts *apps.StatefulSet, containerName string) *corev1.Co
 	return nil
 }
 
+// setMainContainerSecurityContext sets the SecurityContext of the main container
+// of the component. The privileged flag defaults to the given one if it is not set
+// in the spec, and a writable emptyDir is mounted at /tmp if the root filesystem
+// is read-only, because the components write temporary files there.
+func setMainContainerSecurityContext(podSpec *corev1.PodSpec, spec v1alpha1.ComponentAccessor, containerName string, privileged *bool) {
+	var container *corev1.Container
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == containerName {
+			container = &podSpec.Containers[i]
+			break
+		}
+	}
+	if container == nil {
+		return
+	}
+
+	sc := spec.SecurityContext().DeepCopy()
+	if privileged != nil {
+		if sc == nil {
+			sc = &corev1.SecurityContext{}
+		}
+		if sc.Privileged == nil {
+			sc.Privileged = privileged
+		}
+	}
+	container.SecurityContext = sc
+
+	if sc == nil || sc.ReadOnlyRootFilesystem == nil || !*sc.ReadOnlyRootFilesystem {
+		return
+	}
+	for _, mount := range container.VolumeMounts {
+		if mount.MountPath == "/tmp" {
+			return
+		}
+	}
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: tmpVolumeName, MountPath: "/tmp"})
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name:         tmpVolumeName,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+}
+
 func getTikVConfigMapForTiKVSpec(tikvSpec *v1alpha1.TiKVSpec, tc *v1alpha1.TidbCluster, scriptModel *TiKVStartScriptModel) (*corev1.ConfigMap, error) {
 	config := tikvSpec.Config
-	if tc.IsTLSClusterEnabled() {
-		config.Set("security.ca-path", path.Join(tikvClusterCertPath, tlsSecretRootCAKey))
-		config.Set("security.cert-path", path.Join(tikvClusterCertPath, corev1.TLSCertKey))
-		config.Set("security.key-path", path.Join(tikvClusterCertPath, corev1.TLSPrivateKeyKey))
+	if tikvSpec.AutoTuneThreadPools || len(tikvSpec.WalStorageSize) > 0 {
+		// derive the config in a copy so it is not written back to the spec
+		config = config.DeepCopy()
 	}
-	confText, err := config.MarshalTOML()
+	confText, err := renderTiKVConfig(tikvSpec, tc, config)
 	if err != nil {
 		return nil, err
 	}
@@ -338,13 +395,59 @@ func getTikVConfigMapForTiKVSpec(tikvSpec *v1alpha1.TiKVSpec, tc *v1alpha1.TidbC
 	}
 	cm := &corev1.ConfigMap{
 		Data: map[string]string{
-			"config-file":    transformTiKVConfigMap(string(confText), tc),
+			"config-file":    confText,
 			"startup-script": startScript,
 		},
 	}
 	return cm, nil
 }
 
+// getTiKVOrdinalConfig returns the config of TiKV overridden for some
+// ordinals in TOML keyed by the ordinal, or nil if the config is not
+// overridden
+func getTiKVOrdinalConfig(tc *v1alpha1.TidbCluster) (map[string]string, error) {
+	tikvSpec := tc.Spec.TiKV
+	if tikvSpec.Config == nil || tikvSpec.PerOrdinalConfig == nil {
+		return nil, nil
+	}
+	data := map[string]string{}
+	for ordinal, override := range tikvSpec.PerOrdinalConfig {
+		config := tikvSpec.Config.DeepCopy()
+		if override != nil {
+			config.Merge(override.GenericConfig)
+		}
+		confText, err := renderTiKVConfig(tikvSpec, tc, config)
+		if err != nil {
+			return nil, err
+		}
+		data[ordinal] = confText
+	}
+	return data, nil
+}
+
+// renderTiKVConfig derives the settings managed by the operator in config and
+// returns the config in TOML
+func renderTiKVConfig(tikvSpec *v1alpha1.TiKVSpec, tc *v1alpha1.TidbCluster, config *v1alpha1.TiKVConfigWraper) (string, error) {
+	if tikvSpec.AutoTuneThreadPools {
+		setTiKVThreadPoolSizes(config, tikvSpec.Limits)
+	}
+	if len(tikvSpec.WalStorageSize) > 0 {
+		// keep the WAL on the separate volume unless the WAL directories are set explicitly
+		config.SetIfNil("rocksdb.wal-dir", path.Join(tikvWalVolumeMountPath, "rocksdb"))
+		config.SetIfNil("raftdb.wal-dir", path.Join(tikvWalVolumeMountPath, "raftdb"))
+	}
+	if tc.IsTLSClusterEnabled() {
+		config.Set("security.ca-path", path.Join(tikvClusterCertPath, tlsSecretRootCAKey))
+		config.Set("security.cert-path", path.Join(tikvClusterCertPath, corev1.TLSCertKey))
+		config.Set("security.key-path", path.Join(tikvClusterCertPath, corev1.TLSPrivateKeyKey))
+	}
+	confText, err := config.MarshalTOML()
+	if err != nil {
+		return "", err
+	}
+	return transformTiKVConfigMap(string(confText), tc), nil
+}
+
 // shouldRecover checks whether we should perform recovery operation.
 func shouldRecover(tc *v1alpha1.TidbCluster, component string, podLister corelisters.PodLister) bool {
 	var stores map[string]v1alpha1.TiKVStore
@@ -508,9 +611,51 @@ func addDeferDeletingAnnoToPVC(tc *v1alpha1.TidbCluster, pvc *corev1.PersistentV
 		return err
 	}
 	klog.Infof("set PVC %s/%s annotation %q to %q successfully", tc.Namespace, pvc.Name, label.AnnPVCDeferDeleting, now)
+	recordPVCDeferOperation(tc, pvc.Labels[label.ComponentLabelKey], metrics.PVCDeferOperationMark)
+	return nil
+}
+
+// clearDrainBeginTime removes the AnnDrainBeginTime annotation of the Pods of
+// the component except the one named except, so that a drain interrupted,
+// e.g. by pausing the upgrade or by the maintenance window closing, starts
+// over with the full timeout instead of restarting the Pod without draining.
+func clearDrainBeginTime(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType, except string) error {
+	ns := tc.GetNamespace()
+	selector, err := label.New().Instance(tc.GetInstanceName()).Component(memberType.String()).Selector()
+	if err != nil {
+		return err
+	}
+	pods, err := deps.PodLister.Pods(ns).List(selector)
+	if err != nil {
+		return fmt.Errorf("clearDrainBeginTime: failed to list pods for cluster %s/%s, selector %s, error: %s", ns, tc.GetName(), selector, err)
+	}
+	for _, pod := range pods {
+		if _, draining := pod.Annotations[label.AnnDrainBeginTime]; !draining || pod.Name == except {
+			continue
+		}
+		pod = pod.DeepCopy()
+		delete(pod.Annotations, label.AnnDrainBeginTime)
+		if _, err := deps.PodControl.UpdatePod(tc, pod); err != nil {
+			return err
+		}
+		klog.Infof("tidbcluster: [%s/%s]'s %s pod %s is not drained any more, clear its annotation %s", ns, tc.GetName(), memberType, pod.Name, label.AnnDrainBeginTime)
+	}
 	return nil
 }
 
+// reservePDDeletion reserves the deletion of a store or member from PD. If
+// the deletion rate limit is reached, it records an Event and returns a
+// requeue error, so that the deletion is deferred.
+func reservePDDeletion(deps *controller.Dependencies, tc *v1alpha1.TidbCluster, kind string, id string) error {
+	ok, wait := deps.PDDeletionLimiter.Reserve(tc, fmt.Sprintf("%s/%s", kind, id))
+	if ok {
+		return nil
+	}
+	wait = wait.Round(time.Second)
+	deps.Recorder.Eventf(tc, corev1.EventTypeNormal, "PDDeletionDeferred", "deleting %s %s from PD is deferred for %s by the deletion rate limit", kind, id, wait)
+	return controller.RequeueErrorf("tidbcluster: [%s/%s]'s deletion of %s %s from PD is deferred for %s by the rate limit", tc.GetNamespace(), tc.GetName(), kind, id, wait)
+}
+
 // GetPVCSelectorForPod compose a PVC selector from a tc/dm-cluster member pod at ordinal position
 func GetPVCSelectorForPod(controller runtime.Object, memberType v1alpha1.MemberType, ordinal int32) (labels.Selector, error) {
 	meta := controller.(metav1.Object)
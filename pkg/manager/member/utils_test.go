@@ -161,6 +161,20 @@ func TestGetStsAnnotations(t *testing.T) {
 			component: label.PDLabelVal,
 			expected:  map[string]string{},
 		},
+		{
+			name: "pump",
+			tc: &v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						label.AnnPumpDeleteSlots: "[0]",
+					},
+				},
+			},
+			component: label.PumpLabelVal,
+			expected: map[string]string{
+				helper.DeleteSlotsAnn: "[0]",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -839,3 +853,88 @@ func TestMemberPodName(t *testing.T) {
 		})
 	}
 }
+
+func TestTemplateEqualImagePullPolicy(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPD()
+	oldSet, err := getNewPDSetForTidbCluster(tc, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(SetStatefulSetLastAppliedConfigAnnotation(oldSet)).To(Succeed())
+
+	newSet, err := getNewPDSetForTidbCluster(tc, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(templateEqual(newSet, oldSet)).To(BeTrue())
+
+	// changing the image pull policy of the component rolls the Pods
+	pullAlways := corev1.PullAlways
+	tc.Spec.PD.ImagePullPolicy = &pullAlways
+	newSet, err = getNewPDSetForTidbCluster(tc, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(templateEqual(newSet, oldSet)).To(BeFalse())
+}
+
+func TestSetMainContainerSecurityContext(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	readOnly := true
+	privileged := false
+	restricted := &corev1.SecurityContext{
+		ReadOnlyRootFilesystem: &readOnly,
+		Capabilities:           &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+	}
+
+	type testcase struct {
+		name         string
+		sc           *corev1.SecurityContext
+		privileged   *bool
+		expectSC     *corev1.SecurityContext
+		expectTmpDir bool
+	}
+	tests := []testcase{
+		{
+			name: "not set",
+		},
+		{
+			name:       "privileged flag defaults to the given one",
+			privileged: &privileged,
+			expectSC:   &corev1.SecurityContext{Privileged: &privileged},
+		},
+		{
+			name:         "read-only root filesystem",
+			sc:           restricted,
+			expectSC:     restricted,
+			expectTmpDir: true,
+		},
+		{
+			name:       "privileged flag set in the spec is kept",
+			sc:         &corev1.SecurityContext{Privileged: &readOnly},
+			privileged: &privileged,
+			expectSC:   &corev1.SecurityContext{Privileged: &readOnly},
+		},
+	}
+
+	for _, test := range tests {
+		t.Log(test.name)
+		tc := newTidbClusterForTiKV()
+		tc.Spec.TiKV.SecurityContext = test.sc
+		podSpec := corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "tikv"}, {Name: "sidecar"}},
+			Volumes:    []corev1.Volume{{Name: "data"}},
+		}
+
+		setMainContainerSecurityContext(&podSpec, tc.BaseTiKVSpec(), "tikv", test.privileged)
+		g.Expect(podSpec.Containers[0].SecurityContext).To(Equal(test.expectSC))
+		g.Expect(podSpec.Containers[1].SecurityContext).To(BeNil())
+		if test.expectTmpDir {
+			g.Expect(podSpec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{Name: tmpVolumeName, MountPath: "/tmp"}))
+			g.Expect(podSpec.Volumes).To(HaveLen(2))
+			g.Expect(podSpec.Volumes[1].EmptyDir).NotTo(BeNil())
+		} else {
+			g.Expect(podSpec.Containers[0].VolumeMounts).To(BeEmpty())
+			g.Expect(podSpec.Volumes).To(HaveLen(1))
+		}
+		// the spec is not changed
+		g.Expect(tc.Spec.TiKV.SecurityContext).To(Equal(test.sc))
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package meta
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	"github.com/pingcap/tidb-operator/pkg/manager"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog"
+)
+
+// ownershipLabelKeys are the labels by which the operator looks up the Pods
+// and PVCs of a component, e.g. in scaling and failover
+var ownershipLabelKeys = []string{
+	label.NameLabelKey,
+	label.ManagedByLabelKey,
+	label.InstanceLabelKey,
+	label.ComponentLabelKey,
+}
+
+// labelMigrationComponents are the components whose Pods and PVCs are
+// migrated, with the name of their StatefulSets
+var labelMigrationComponents = []struct {
+	component string
+	setName   func(tcName string) string
+}{
+	{label.PDLabelVal, controller.PDMemberName},
+	{label.TiKVLabelVal, controller.TiKVMemberName},
+	{label.TiFlashLabelVal, controller.TiFlashMemberName},
+	{label.TiDBLabelVal, controller.TiDBMemberName},
+	{label.PumpLabelVal, controller.PumpMemberName},
+	{label.TiCDCLabelVal, controller.TiCDCMemberName},
+}
+
+type labelMigrationManager struct {
+	deps *controller.Dependencies
+}
+
+// NewLabelMigrationManager returns a *labelMigrationManager, which adds the
+// missing ownership labels of the current label scheme to the Pods and PVCs of
+// a cluster, e.g. the ones left by an older operator, so that they are still
+// found by the label selectors of the operator.
+//
+// A Pod is owned by the cluster if it is controlled by a StatefulSet of the
+// cluster, and a PVC is owned if it is named after a volume claim template of
+// such a StatefulSet. The objects labeled with another instance are skipped.
+// The labels are only added, the existing ones and the ones matched by the
+// selector of the StatefulSet are never changed, so that the Pods are not
+// orphaned from the StatefulSet, whose selector is immutable.
+func NewLabelMigrationManager(deps *controller.Dependencies) manager.Manager {
+	return &labelMigrationManager{
+		deps: deps,
+	}
+}
+
+func (m *labelMigrationManager) Sync(tc *v1alpha1.TidbCluster) error {
+	ns := tc.GetNamespace()
+	tcName := tc.GetName()
+
+	pods, err := m.deps.PodLister.Pods(ns).List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("labelMigrationManager.Sync: failed to list pods for cluster %s/%s, error: %v", ns, tcName, err)
+	}
+	pvcs, err := m.deps.PVCLister.PersistentVolumeClaims(ns).List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("labelMigrationManager.Sync: failed to list pvcs for cluster %s/%s, error: %v", ns, tcName, err)
+	}
+
+	for _, c := range labelMigrationComponents {
+		setName := c.setName(tcName)
+		set, err := m.deps.StatefulSetLister.StatefulSets(ns).Get(setName)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("labelMigrationManager.Sync: failed to get sts %s for cluster %s/%s, error: %v", setName, ns, tcName, err)
+		}
+		desired := label.New().Instance(tc.GetInstanceName()).Component(c.component)
+		var selector map[string]string
+		if set.Spec.Selector != nil {
+			selector = set.Spec.Selector.MatchLabels
+		}
+
+		for _, pod := range pods {
+			ref := metav1.GetControllerOf(pod)
+			if ref == nil || ref.Kind != "StatefulSet" || ref.Name != setName {
+				continue
+			}
+			newLabels, stale := migrateOwnershipLabels(pod.Labels, desired, selector)
+			if !stale {
+				continue
+			}
+			newPod := pod.DeepCopy()
+			newPod.Labels = newLabels
+			if _, err := m.deps.PodControl.UpdatePod(tc, newPod); err != nil {
+				return err
+			}
+			klog.Infof("labelMigrationManager: migrate the labels of pod %s/%s from %v to %v", ns, pod.Name, pod.Labels, newLabels)
+		}
+
+		var pvcNameRegexps []*regexp.Regexp
+		for _, tpl := range set.Spec.VolumeClaimTemplates {
+			pvcNameRegexps = append(pvcNameRegexps, regexp.MustCompile(fmt.Sprintf("^%s-%s-[0-9]+$", regexp.QuoteMeta(tpl.Name), regexp.QuoteMeta(setName))))
+		}
+		for _, pvc := range pvcs {
+			if !matchAny(pvcNameRegexps, pvc.Name) {
+				continue
+			}
+			newLabels, stale := migrateOwnershipLabels(pvc.Labels, desired, nil)
+			if !stale {
+				continue
+			}
+			newPVC := pvc.DeepCopy()
+			newPVC.Labels = newLabels
+			if _, err := m.deps.PVCControl.UpdatePVC(tc, newPVC); err != nil {
+				return err
+			}
+			klog.Infof("labelMigrationManager: migrate the labels of pvc %s/%s from %v to %v", ns, pvc.Name, pvc.Labels, newLabels)
+		}
+	}
+	return nil
+}
+
+// migrateOwnershipLabels returns the labels with the missing ownership labels
+// added, and whether any of them is missing. The existing labels and the keys
+// of the selector are never changed, and the labels of another instance are
+// never migrated.
+func migrateOwnershipLabels(current map[string]string, desired label.Label, selector map[string]string) (map[string]string, bool) {
+	if instance, ok := current[label.InstanceLabelKey]; ok && instance != desired[label.InstanceLabelKey] {
+		return current, false
+	}
+	var missing []string
+	for _, key := range ownershipLabelKeys {
+		if _, ok := current[key]; ok {
+			continue
+		}
+		if _, ok := selector[key]; ok {
+			continue
+		}
+		missing = append(missing, key)
+	}
+	if len(missing) == 0 {
+		return current, false
+	}
+	newLabels := make(map[string]string, len(current)+len(missing))
+	for k, v := range current {
+		newLabels[k] = v
+	}
+	for _, key := range missing {
+		newLabels[key] = desired[key]
+	}
+	return newLabels, true
+}
+
+func matchAny(regexps []*regexp.Regexp, s string) bool {
+	for _, r := range regexps {
+		if r.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+var _ manager.Manager = &labelMigrationManager{}
+
+type FakeLabelMigrationManager struct {
+	err error
+}
+
+func NewFakeLabelMigrationManager() *FakeLabelMigrationManager {
+	return &FakeLabelMigrationManager{}
+}
+
+func (m *FakeLabelMigrationManager) SetSyncError(err error) {
+	m.err = err
+}
+
+func (m *FakeLabelMigrationManager) Sync(_ *v1alpha1.TidbCluster) error {
+	return m.err
+}
@@ -0,0 +1,130 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package meta
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestLabelMigrationManagerSync(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForMeta()
+	ns := tc.GetNamespace()
+	setName := controller.TiKVMemberName(tc.GetName())
+	set := &apps.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: setName, Namespace: ns},
+		Spec: apps.StatefulSetSpec{
+			// the selector of the StatefulSet created by an older operator
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{
+				label.InstanceLabelKey:  tc.GetInstanceName(),
+				label.ComponentLabelKey: label.TiKVLabelVal,
+			}},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "tikv"}}},
+		},
+	}
+	ownedBy := func(setName string) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{Kind: "StatefulSet", Name: setName, Controller: pointer.BoolPtr(true)}}
+	}
+	oldLabels := func() map[string]string {
+		return map[string]string{
+			label.InstanceLabelKey:  tc.GetInstanceName(),
+			label.ComponentLabelKey: label.TiKVLabelVal,
+			label.StoreIDLabelKey:   "1",
+		}
+	}
+	currentLabels := label.New().Instance(tc.GetInstanceName()).TiKV().Labels()
+	renamed := oldLabels()
+	renamed[label.NameLabelKey] = "tidb-cluster-legacy"
+
+	pods := []*corev1.Pod{
+		// owned with old labels
+		{ObjectMeta: metav1.ObjectMeta{Name: setName + "-0", Namespace: ns, Labels: oldLabels(), OwnerReferences: ownedBy(setName)}},
+		// owned without labels, the labels of the selector are not added
+		{ObjectMeta: metav1.ObjectMeta{Name: setName + "-1", Namespace: ns, OwnerReferences: ownedBy(setName)}},
+		// not owned by the cluster
+		{ObjectMeta: metav1.ObjectMeta{Name: "other-0", Namespace: ns, Labels: oldLabels(), OwnerReferences: ownedBy("other")}},
+	}
+	otherInstance := oldLabels()
+	otherInstance[label.InstanceLabelKey] = "other"
+	pvcs := []*corev1.PersistentVolumeClaim{
+		// owned with old labels
+		{ObjectMeta: metav1.ObjectMeta{Name: "tikv-" + setName + "-0", Namespace: ns, Labels: oldLabels()}},
+		// owned with the current labels
+		{ObjectMeta: metav1.ObjectMeta{Name: "tikv-" + setName + "-1", Namespace: ns, Labels: currentLabels}},
+		// labeled with another instance
+		{ObjectMeta: metav1.ObjectMeta{Name: "tikv-" + setName + "-2", Namespace: ns, Labels: otherInstance}},
+		// not named after the volume claim template
+		{ObjectMeta: metav1.ObjectMeta{Name: "tikv-" + setName + "-backup", Namespace: ns, Labels: oldLabels()}},
+		// owned with a label of another value, which is kept
+		{ObjectMeta: metav1.ObjectMeta{Name: "tikv-" + setName + "-3", Namespace: ns, Labels: renamed}},
+	}
+
+	fakeDeps := controller.NewFakeDependencies()
+	podIndexer := fakeDeps.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+	pvcIndexer := fakeDeps.KubeInformerFactory.Core().V1().PersistentVolumeClaims().Informer().GetIndexer()
+	setIndexer := fakeDeps.KubeInformerFactory.Apps().V1().StatefulSets().Informer().GetIndexer()
+	g.Expect(setIndexer.Add(set)).To(Succeed())
+	for _, pod := range pods {
+		g.Expect(podIndexer.Add(pod)).To(Succeed())
+	}
+	for _, pvc := range pvcs {
+		g.Expect(pvcIndexer.Add(pvc)).To(Succeed())
+	}
+
+	m := NewLabelMigrationManager(fakeDeps)
+	g.Expect(m.Sync(tc)).To(Succeed())
+
+	getPod := func(name string) *corev1.Pod {
+		pod, err := fakeDeps.PodLister.Pods(ns).Get(name)
+		g.Expect(err).NotTo(HaveOccurred())
+		return pod
+	}
+	getPVC := func(name string) *corev1.PersistentVolumeClaim {
+		pvc, err := fakeDeps.PVCLister.PersistentVolumeClaims(ns).Get(name)
+		g.Expect(err).NotTo(HaveOccurred())
+		return pvc
+	}
+	migrated := label.Label(currentLabels).Copy()
+	migrated[label.StoreIDLabelKey] = "1"
+	renamedMigrated := label.Label(renamed).Copy()
+	renamedMigrated[label.ManagedByLabelKey] = currentLabels[label.ManagedByLabelKey]
+
+	g.Expect(getPod(setName + "-0").Labels).To(Equal(map[string]string(migrated)))
+	g.Expect(getPod(setName + "-1").Labels).To(Equal(map[string]string{
+		label.NameLabelKey:      currentLabels[label.NameLabelKey],
+		label.ManagedByLabelKey: currentLabels[label.ManagedByLabelKey],
+	}))
+	g.Expect(getPod("other-0").Labels).To(Equal(oldLabels()))
+	g.Expect(getPVC("tikv-" + setName + "-0").Labels).To(Equal(map[string]string(migrated)))
+	g.Expect(getPVC("tikv-" + setName + "-1").Labels).To(Equal(currentLabels))
+	g.Expect(getPVC("tikv-" + setName + "-2").Labels).To(Equal(otherInstance))
+	g.Expect(getPVC("tikv-" + setName + "-backup").Labels).To(Equal(oldLabels()))
+	g.Expect(getPVC("tikv-" + setName + "-3").Labels).To(Equal(map[string]string(renamedMigrated)))
+
+	// the selectors of the operator find the migrated objects
+	selector, err := label.New().Instance(tc.GetInstanceName()).TiKV().Selector()
+	g.Expect(err).NotTo(HaveOccurred())
+	found, err := fakeDeps.PVCLister.PersistentVolumeClaims(ns).List(selector)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(found).To(HaveLen(2))
+}
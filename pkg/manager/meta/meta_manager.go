@@ -65,6 +65,7 @@ func (m *metaManager) Sync(tc *v1alpha1.TidbCluster) error {
 			// Currently PD/TiKV/TiFlash/Pump must uses PV
 			mustUsePV = true
 		case label.TiDBLabelVal,
+			label.TiDBGreenLabelVal,
 			label.TiCDCLabelVal:
 			// Currently TiDB/TiCDC maybe uses PV
 			mustUsePV = false
@@ -20,6 +20,10 @@ import (
 // RegisterMetrics registers all metrics of tidb-operator.
 func RegisterMetrics() {
 	prometheus.MustRegister(ClusterSpecReplicas)
+	prometheus.MustRegister(ScaleAttempts)
+	prometheus.MustRegister(ScaleDuration)
+	prometheus.MustRegister(ScaleBlocked)
+	prometheus.MustRegister(PVCDeferOperations)
 }
 
 // Label constants.
@@ -27,4 +31,8 @@ const (
 	LabelNamespace = "namespace"
 	LabelName      = "name"
 	LabelComponent = "component"
+	LabelDirection = "direction"
+	LabelResult    = "result"
+	LabelReason    = "reason"
+	LabelOperation = "operation"
 )
@@ -0,0 +1,71 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Values of LabelResult of the scale attempts.
+const (
+	// ScaleResultScaled means the replicas of the StatefulSet are changed
+	ScaleResultScaled = "scaled"
+	// ScaleResultBlocked means the scale is deferred or requeued
+	ScaleResultBlocked = "blocked"
+	// ScaleResultFailed means the scale fails with an error
+	ScaleResultFailed = "failed"
+)
+
+// Values of LabelOperation of the PVC defer operations.
+const (
+	// PVCDeferOperationMark marks a PVC as defer deleting on scale-in
+	PVCDeferOperationMark = "mark"
+	// PVCDeferOperationDelete deletes a PVC marked as defer deleting on scale-out
+	PVCDeferOperationDelete = "delete"
+)
+
+var (
+	ScaleAttempts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb_operator",
+			Subsystem: "scaler",
+			Name:      "attempts_total",
+			Help:      "Total number of attempts to scale each component by one member",
+		}, []string{LabelNamespace, LabelName, LabelComponent, LabelDirection, LabelResult})
+
+	ScaleDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "tidb_operator",
+			Subsystem: "scaler",
+			Name:      "duration_seconds",
+			Help:      "Bucketed histogram of the time spent by each attempt to scale a component",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+		}, []string{LabelNamespace, LabelName, LabelComponent, LabelDirection})
+
+	ScaleBlocked = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb_operator",
+			Subsystem: "scaler",
+			Name:      "blocked_total",
+			Help:      "Total number of times the scale of each component is blocked, by reason",
+		}, []string{LabelNamespace, LabelName, LabelComponent, LabelReason})
+
+	PVCDeferOperations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tidb_operator",
+			Subsystem: "scaler",
+			Name:      "pvc_defer_operations_total",
+			Help:      "Total number of PVCs of each component marked as defer deleting or deleted by the scalers",
+		}, []string{LabelNamespace, LabelName, LabelComponent, LabelOperation})
+)
@@ -23,25 +23,34 @@ import (
 type ActionType string
 
 const (
-	GetHealthActionType                ActionType = "GetHealth"
-	GetConfigActionType                ActionType = "GetConfig"
-	GetClusterActionType               ActionType = "GetCluster"
-	GetMembersActionType               ActionType = "GetMembers"
-	GetStoresActionType                ActionType = "GetStores"
-	GetTombStoneStoresActionType       ActionType = "GetTombStoneStores"
-	GetStoreActionType                 ActionType = "GetStore"
-	DeleteStoreActionType              ActionType = "DeleteStore"
-	SetStoreStateActionType            ActionType = "SetStoreState"
-	DeleteMemberByIDActionType         ActionType = "DeleteMemberByID"
-	DeleteMemberActionType             ActionType = "DeleteMember "
-	SetStoreLabelsActionType           ActionType = "SetStoreLabels"
-	UpdateReplicationActionType        ActionType = "UpdateReplicationConfig"
-	BeginEvictLeaderActionType         ActionType = "BeginEvictLeader"
-	EndEvictLeaderActionType           ActionType = "EndEvictLeader"
-	GetEvictLeaderSchedulersActionType ActionType = "GetEvictLeaderSchedulers"
-	GetPDLeaderActionType              ActionType = "GetPDLeader"
-	TransferPDLeaderActionType         ActionType = "TransferPDLeader"
-	GetAutoscalingPlansActionType      ActionType = "GetAutoscalingPlans"
+	GetHealthActionType                      ActionType = "GetHealth"
+	GetConfigActionType                      ActionType = "GetConfig"
+	GetClusterActionType                     ActionType = "GetCluster"
+	GetMembersActionType                     ActionType = "GetMembers"
+	GetStoresActionType                      ActionType = "GetStores"
+	GetTombStoneStoresActionType             ActionType = "GetTombStoneStores"
+	GetStoreActionType                       ActionType = "GetStore"
+	DeleteStoreActionType                    ActionType = "DeleteStore"
+	SetStoreStateActionType                  ActionType = "SetStoreState"
+	DeleteMemberByIDActionType               ActionType = "DeleteMemberByID"
+	DeleteMemberActionType                   ActionType = "DeleteMember "
+	SetStoreLabelsActionType                 ActionType = "SetStoreLabels"
+	UpdateReplicationActionType              ActionType = "UpdateReplicationConfig"
+	UpdateConfigActionType                   ActionType = "UpdateConfig"
+	BeginEvictLeaderActionType               ActionType = "BeginEvictLeader"
+	EndEvictLeaderActionType                 ActionType = "EndEvictLeader"
+	GetEvictLeaderSchedulersActionType       ActionType = "GetEvictLeaderSchedulers"
+	GetPDLeaderActionType                    ActionType = "GetPDLeader"
+	TransferPDLeaderActionType               ActionType = "TransferPDLeader"
+	GetAutoscalingPlansActionType            ActionType = "GetAutoscalingPlans"
+	GetSchedulersActionType                  ActionType = "GetSchedulers"
+	AddSchedulerActionType                   ActionType = "AddScheduler"
+	RemoveSchedulerActionType                ActionType = "RemoveScheduler"
+	GetSchedulerConfigActionType             ActionType = "GetSchedulerConfig"
+	SetSchedulerConfigActionType             ActionType = "SetSchedulerConfig"
+	GetOperatorCountActionType               ActionType = "GetOperatorCount"
+	GetRegionCountByCheckActionType          ActionType = "GetRegionCountByCheck"
+	GetStorePendingPeerRegionCountActionType ActionType = "GetStorePendingPeerRegionCount"
 )
 
 type NotFoundReaction struct {
@@ -57,6 +66,7 @@ type Action struct {
 	Name        string
 	Labels      map[string]string
 	Replication PDReplicationConfig
+	Config      map[string]interface{}
 }
 
 type Reaction func(action *Action) (interface{}, error)
@@ -207,6 +217,15 @@ func (c *FakePDClient) UpdateReplicationConfig(config PDReplicationConfig) error
 	return nil
 }
 
+func (c *FakePDClient) UpdateConfig(items map[string]interface{}) error {
+	if reaction, ok := c.reactions[UpdateConfigActionType]; ok {
+		action := &Action{Config: items}
+		_, err := reaction(action)
+		return err
+	}
+	return nil
+}
+
 func (c *FakePDClient) BeginEvictLeader(storeID uint64) error {
 	if reaction, ok := c.reactions[BeginEvictLeaderActionType]; ok {
 		action := &Action{ID: storeID}
@@ -260,3 +279,86 @@ func (c *FakePDClient) GetAutoscalingPlans(strategy Strategy) ([]Plan, error) {
 	}
 	return nil, nil
 }
+
+func (c *FakePDClient) GetSchedulers() ([]string, error) {
+	if reaction, ok := c.reactions[GetSchedulersActionType]; ok {
+		action := &Action{}
+		result, err := reaction(action)
+		schedulers, _ := result.([]string)
+		return schedulers, err
+	}
+	return nil, nil
+}
+
+func (c *FakePDClient) AddScheduler(name string) error {
+	if reaction, ok := c.reactions[AddSchedulerActionType]; ok {
+		action := &Action{Name: name}
+		_, err := reaction(action)
+		return err
+	}
+	return nil
+}
+
+func (c *FakePDClient) RemoveScheduler(name string) error {
+	if reaction, ok := c.reactions[RemoveSchedulerActionType]; ok {
+		action := &Action{Name: name}
+		_, err := reaction(action)
+		return err
+	}
+	return nil
+}
+
+func (c *FakePDClient) GetSchedulerConfig(name string) (map[string]interface{}, error) {
+	if reaction, ok := c.reactions[GetSchedulerConfigActionType]; ok {
+		action := &Action{Name: name}
+		result, err := reaction(action)
+		config, _ := result.(map[string]interface{})
+		return config, err
+	}
+	return nil, nil
+}
+
+func (c *FakePDClient) SetSchedulerConfig(name string, config map[string]interface{}) error {
+	if reaction, ok := c.reactions[SetSchedulerConfigActionType]; ok {
+		action := &Action{Name: name, Config: config}
+		_, err := reaction(action)
+		return err
+	}
+	return nil
+}
+
+func (c *FakePDClient) GetOperatorCount() (int, error) {
+	if reaction, ok := c.reactions[GetOperatorCountActionType]; ok {
+		action := &Action{}
+		result, err := reaction(action)
+		if err != nil {
+			return 0, err
+		}
+		return result.(int), nil
+	}
+	return 0, nil
+}
+
+func (c *FakePDClient) GetRegionCountByCheck(check string) (int, error) {
+	if reaction, ok := c.reactions[GetRegionCountByCheckActionType]; ok {
+		action := &Action{Name: check}
+		result, err := reaction(action)
+		if err != nil {
+			return 0, err
+		}
+		return result.(int), nil
+	}
+	return 0, nil
+}
+
+func (c *FakePDClient) GetStorePendingPeerRegionCount(storeID uint64) (int, error) {
+	if reaction, ok := c.reactions[GetStorePendingPeerRegionCountActionType]; ok {
+		action := &Action{ID: storeID}
+		result, err := reaction(action)
+		if err != nil {
+			return 0, err
+		}
+		return result.(int), nil
+	}
+	return 0, nil
+}
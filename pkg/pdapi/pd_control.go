@@ -18,7 +18,9 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
+	"time"
 
+	"github.com/pingcap/tidb-operator/pkg/tracing"
 	"github.com/pingcap/tidb-operator/pkg/util"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog"
@@ -48,6 +50,12 @@ type defaultPDControl struct {
 	mutex     sync.Mutex
 	pdClients map[string]PDClient
 
+	// readBalancing indicates whether the read requests of the clients
+	// returned by GetPDClient and GetClusterRefPDClient are load-balanced
+	// across the PD followers
+	readBalancing       bool
+	readBalancedClients map[string]*readBalancedPDClientEntry
+
 	etcdmutex     sync.Mutex
 	pdEtcdClients map[string]PDEtcdClient
 }
@@ -65,6 +73,19 @@ func NewDefaultPDControl(kubeCli kubernetes.Interface) PDControlInterface {
 	return &defaultPDControl{kubeCli: kubeCli, pdClients: map[string]PDClient{}, pdEtcdClients: map[string]PDEtcdClient{}}
 }
 
+// NewReadBalancedPDControl returns a defaultPDControl instance whose PD clients
+// load-balance the observability read requests across the healthy PD followers
+// and keep the other requests on the PD leader
+func NewReadBalancedPDControl(kubeCli kubernetes.Interface) PDControlInterface {
+	return &defaultPDControl{
+		kubeCli:             kubeCli,
+		pdClients:           map[string]PDClient{},
+		pdEtcdClients:       map[string]PDEtcdClient{},
+		readBalancing:       true,
+		readBalancedClients: map[string]*readBalancedPDClientEntry{},
+	}
+}
+
 func (c *defaultPDControl) GetEndpoints(namespace Namespace, tcName string, tlsEnabled bool) (endpoints []string, tlsConfig *tls.Config, err error) {
 	if tlsEnabled {
 		tlsConfig, err = GetTLSConfig(c.kubeCli, namespace, tcName, util.ClusterClientTLSSecretName(tcName))
@@ -120,7 +141,12 @@ func (c *defaultPDControl) GetPDClient(namespace Namespace, tcName string, tlsEn
 		scheme = "https"
 	}
 
-	return c.GetPeerPDClient(namespace, tcName, tlsEnabled, PdClientURL(namespace, tcName, scheme), pdClientKey(scheme, namespace, tcName))
+	clientName := pdClientKey(scheme, namespace, tcName)
+	clientURL := PdClientURL(namespace, tcName, scheme)
+	if c.readBalancing {
+		return c.getReadBalancedPDClient(namespace, tcName, tlsEnabled, clientURL, clientName)
+	}
+	return c.GetPeerPDClient(namespace, tcName, tlsEnabled, clientURL, clientName)
 }
 
 func (pdc *defaultPDControl) GetClusterRefPDClient(namespace Namespace, tcName string, clusterDomain string, tlsEnabled bool) PDClient {
@@ -129,31 +155,97 @@ func (pdc *defaultPDControl) GetClusterRefPDClient(namespace Namespace, tcName s
 		scheme = "https"
 	}
 
-	return pdc.GetPeerPDClient(namespace, tcName, tlsEnabled, ClusterRefPDClientUrl(namespace, tcName, scheme, clusterDomain), ClusterRefpdClientKey(scheme, namespace, tcName, clusterDomain))
+	clientName := ClusterRefpdClientKey(scheme, namespace, tcName, clusterDomain)
+	clientURL := ClusterRefPDClientUrl(namespace, tcName, scheme, clusterDomain)
+	if pdc.readBalancing {
+		return pdc.getReadBalancedPDClient(namespace, tcName, tlsEnabled, clientURL, clientName)
+	}
+	return pdc.GetPeerPDClient(namespace, tcName, tlsEnabled, clientURL, clientName)
 }
 
-func (pdc *defaultPDControl) GetPeerPDClient(namespace Namespace, tcName string, tlsEnabled bool, clientURL string, clientName string) PDClient {
+// tlsReadBalancedClientTTL is how long a read-balanced client with TLS is
+// reused, it is rebuilt afterwards to load the rotated certificates
+const tlsReadBalancedClientTTL = 5 * time.Minute
+
+// readBalancedPDClientEntry is a cached read-balanced client, the clients
+// with TLS expire after tlsReadBalancedClientTTL
+type readBalancedPDClientEntry struct {
+	client     PDClient
+	expireTime time.Time
+}
+
+// getReadBalancedPDClient returns the client of the PD service that
+// load-balances the read requests. The client of the cluster is cached, so
+// that the discovered endpoints and the connections to the followers are
+// reused across the syncs.
+func (pdc *defaultPDControl) getReadBalancedPDClient(namespace Namespace, tcName string, tlsEnabled bool, clientURL string, clientName string) PDClient {
+	pdc.mutex.Lock()
+	entry, ok := pdc.readBalancedClients[clientName]
+	pdc.mutex.Unlock()
+	if ok && (entry.expireTime.IsZero() || time.Now().Before(entry.expireTime)) {
+		return entry.client
+	}
+
+	primary := pdc.GetPeerPDClient(namespace, tcName, tlsEnabled, clientURL, clientName)
+	newClient := func(url string) PDClient {
+		// the requests sent to the followers must be allowed to be served by
+		// them, otherwise they are proxied to the leader. The header is set on
+		// the client before it is wrapped by the tracing.
+		return withTracing(namespace, tcName, allowFollowerHandle(pdc.newUntracedPDClient(namespace, tcName, tlsEnabled, url)))
+	}
+	entry = &readBalancedPDClientEntry{client: NewReadBalancedPDClient(primary, newClient)}
+	if tlsEnabled {
+		entry.expireTime = time.Now().Add(tlsReadBalancedClientTTL)
+	}
+
 	pdc.mutex.Lock()
 	defer pdc.mutex.Unlock()
+	pdc.readBalancedClients[clientName] = entry
+	return entry.client
+}
 
-	var tlsConfig *tls.Config
-	var err error
+func (pdc *defaultPDControl) GetPeerPDClient(namespace Namespace, tcName string, tlsEnabled bool, clientURL string, clientName string) PDClient {
+	pdc.mutex.Lock()
+	defer pdc.mutex.Unlock()
 
 	if tlsEnabled {
-		tlsConfig, err = GetTLSConfig(pdc.kubeCli, namespace, tcName, util.ClusterClientTLSSecretName(tcName))
-		if err != nil {
-			klog.Errorf("Unable to get tls config for tidb cluster %q in %s, pd client may not work: %v", tcName, namespace, err)
-			return &pdClient{url: clientURL, httpClient: &http.Client{Timeout: DefaultTimeout}}
-		}
-
-		return NewPDClient(clientURL, DefaultTimeout, tlsConfig)
+		return pdc.newPDClient(namespace, tcName, tlsEnabled, clientURL)
 	}
 	if _, ok := pdc.pdClients[clientName]; !ok {
-		pdc.pdClients[clientName] = NewPDClient(clientURL, DefaultTimeout, nil)
+		pdc.pdClients[clientName] = pdc.newPDClient(namespace, tcName, tlsEnabled, clientURL)
 	}
 	return pdc.pdClients[clientName]
 }
 
+// newPDClient returns a new client of the PD url, the client with TLS loads
+// the certificates from the client Secret of the cluster
+func (pdc *defaultPDControl) newPDClient(namespace Namespace, tcName string, tlsEnabled bool, clientURL string) PDClient {
+	return withTracing(namespace, tcName, pdc.newUntracedPDClient(namespace, tcName, tlsEnabled, clientURL))
+}
+
+// newUntracedPDClient returns a new client of the PD url whose requests are
+// not recorded by the tracing
+func (pdc *defaultPDControl) newUntracedPDClient(namespace Namespace, tcName string, tlsEnabled bool, clientURL string) PDClient {
+	if !tlsEnabled {
+		return NewPDClient(clientURL, DefaultTimeout, nil)
+	}
+	tlsConfig, err := GetTLSConfig(pdc.kubeCli, namespace, tcName, util.ClusterClientTLSSecretName(tcName))
+	if err != nil {
+		klog.Errorf("Unable to get tls config for tidb cluster %q in %s, pd client may not work: %v", tcName, namespace, err)
+		return &pdClient{url: clientURL, httpClient: &http.Client{Timeout: DefaultTimeout}}
+	}
+	return NewPDClient(clientURL, DefaultTimeout, tlsConfig)
+}
+
+// withTracing records the requests sent by the client as the spans of the
+// cluster if tracing is enabled
+func withTracing(namespace Namespace, tcName string, client PDClient) PDClient {
+	if c, ok := client.(*pdClient); ok && tracing.Enabled() {
+		c.httpClient.Transport = tracing.WrapPDTransport(string(namespace), tcName, c.httpClient.Transport)
+	}
+	return client
+}
+
 // pdClientKey returns the pd client key
 func pdClientKey(scheme string, namespace Namespace, clusterName string) string {
 	return fmt.Sprintf("%s.%s.%s", scheme, clusterName, string(namespace))
@@ -197,7 +289,7 @@ type FakePDControl struct {
 
 func NewFakePDControl(kubeCli kubernetes.Interface) *FakePDControl {
 	return &FakePDControl{
-		defaultPDControl{kubeCli: kubeCli, pdClients: map[string]PDClient{}},
+		defaultPDControl{kubeCli: kubeCli, pdClients: map[string]PDClient{}, pdEtcdClients: map[string]PDEtcdClient{}},
 	}
 }
 
@@ -209,6 +301,19 @@ func (fpc *FakePDControl) SetPDClientWithClusterDomain(namespace Namespace, tcNa
 	fpc.defaultPDControl.pdClients[ClusterRefpdClientKey("http", namespace, tcName, tcClusterDomain)] = pdclient
 }
 
+func (fpc *FakePDControl) SetPDEtcdClient(namespace Namespace, tcName string, pdEtcdClient PDEtcdClient) {
+	fpc.defaultPDControl.pdEtcdClients[pdEtcdClientKey(namespace, tcName, false)] = pdEtcdClient
+}
+
+// GetPDEtcdClient returns the PD etcd client set by SetPDEtcdClient, or an
+// empty FakePDEtcdClient if it is not set.
+func (fpc *FakePDControl) GetPDEtcdClient(namespace Namespace, tcName string, tlsEnabled bool) (PDEtcdClient, error) {
+	if pdEtcdClient, ok := fpc.defaultPDControl.pdEtcdClients[pdEtcdClientKey(namespace, tcName, false)]; ok {
+		return pdEtcdClient, nil
+	}
+	return &FakePDEtcdClient{}, nil
+}
+
 func (fpc *FakePDControl) SetPDClientWithAddress(peerURL string, pdclient PDClient) {
 	fpc.defaultPDControl.pdClients[peerURL] = pdclient
 }
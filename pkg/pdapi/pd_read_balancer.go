@@ -0,0 +1,297 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pdapi
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"k8s.io/klog"
+)
+
+const (
+	// endpointsRefreshInterval is how long the PD endpoints discovered by a
+	// readBalancedPDClient are used before they are refreshed
+	endpointsRefreshInterval = 30 * time.Second
+	// allowFollowerHandleHeader is the header that allows a PD follower to
+	// serve a read request by itself instead of proxying it to the leader
+	allowFollowerHandleHeader = "PD-Allow-follower-handle"
+)
+
+// readBalancedPDClient sends the read requests that only report the state of
+// the cluster, GetCluster and GetOperatorCount, to the healthy PD followers in
+// turn and the write requests to the PD leader, so that the leader is not the only
+// member serving the operator. A follower may serve stale data, so the other
+// read requests, e.g. the stores the scale-in and upgrade decisions are made
+// on, are sent by the primary client and served by the leader.
+//
+// The endpoints are discovered with the primary client, which is also used
+// if they are unknown or a request to a follower fails.
+type readBalancedPDClient struct {
+	// primary is the client of the PD service, requests sent by it are
+	// forwarded by PD to the leader
+	PDClient
+	newClient func(url string) PDClient
+
+	mutex       sync.Mutex
+	clients     map[string]PDClient
+	leader      string
+	followers   []string
+	next        int
+	refreshTime time.Time
+}
+
+// NewReadBalancedPDClient returns a PDClient that load-balances the read
+// requests across the healthy PD followers and keeps the write requests on
+// the PD leader.
+func NewReadBalancedPDClient(primary PDClient, newClient func(url string) PDClient) PDClient {
+	return &readBalancedPDClient{
+		PDClient:  primary,
+		newClient: newClient,
+		clients:   map[string]PDClient{},
+	}
+}
+
+// allowFollowerHandleTransport sets the allowFollowerHandleHeader on the
+// requests it sends, PD ignores the header on the write requests
+type allowFollowerHandleTransport struct {
+	http.RoundTripper
+}
+
+func (t *allowFollowerHandleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(allowFollowerHandleHeader, "true")
+	return t.RoundTripper.RoundTrip(req)
+}
+
+// allowFollowerHandle makes the requests of the client served by the PD
+// member it is sent to even if the member is a follower
+func allowFollowerHandle(client PDClient) PDClient {
+	if c, ok := client.(*pdClient); ok {
+		transport := c.httpClient.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		c.httpClient.Transport = &allowFollowerHandleTransport{RoundTripper: transport}
+	}
+	return client
+}
+
+// refresh discovers the leader and the healthy followers if they are stale,
+// it must be called with the mutex held
+func (c *readBalancedPDClient) refresh() {
+	if time.Since(c.refreshTime) < endpointsRefreshInterval {
+		return
+	}
+	c.refreshTime = time.Now()
+	c.leader = ""
+	c.followers = nil
+
+	members, err := c.PDClient.GetMembers()
+	if err != nil {
+		klog.Warningf("failed to get pd members to balance the requests, send them to the pd service: %v", err)
+		return
+	}
+	healthInfo, err := c.PDClient.GetHealth()
+	if err != nil {
+		klog.Warningf("failed to get pd health to balance the requests, send them to the pd service: %v", err)
+		return
+	}
+	healthy := map[uint64]bool{}
+	for _, h := range healthInfo.Healths {
+		healthy[h.MemberID] = h.Health
+	}
+
+	var leaderID uint64
+	if members.Leader != nil && len(members.Leader.ClientUrls) > 0 {
+		leaderID = members.Leader.MemberId
+		c.leader = members.Leader.ClientUrls[0]
+	}
+	for _, member := range members.Members {
+		if member.MemberId == leaderID || !healthy[member.MemberId] || len(member.ClientUrls) == 0 {
+			continue
+		}
+		c.followers = append(c.followers, member.ClientUrls[0])
+	}
+}
+
+// invalidate forces the endpoints to be refreshed before the next request
+func (c *readBalancedPDClient) invalidate() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.refreshTime = time.Time{}
+}
+
+func (c *readBalancedPDClient) client(url string) PDClient {
+	if _, ok := c.clients[url]; !ok {
+		c.clients[url] = c.newClient(url)
+	}
+	return c.clients[url]
+}
+
+// reader returns the client of the next healthy follower, or nil if there is none
+func (c *readBalancedPDClient) reader() PDClient {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.refresh()
+	if len(c.followers) == 0 {
+		return nil
+	}
+	url := c.followers[c.next%len(c.followers)]
+	c.next++
+	return c.client(url)
+}
+
+// writer returns the client of the leader, or the primary client if the
+// leader is unknown
+func (c *readBalancedPDClient) writer() PDClient {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.refresh()
+	if c.leader == "" {
+		return c.PDClient
+	}
+	return c.client(c.leader)
+}
+
+// read sends the read request to a follower, and falls back to the primary
+// client if it fails
+func (c *readBalancedPDClient) read(fn func(client PDClient) error) error {
+	if client := c.reader(); client != nil {
+		if err := fn(client); err == nil {
+			return nil
+		}
+		c.invalidate()
+	}
+	return fn(c.PDClient)
+}
+
+// write sends the write request to the leader, a failure invalidates the
+// endpoints in case the leader has changed
+func (c *readBalancedPDClient) write(fn func(client PDClient) error) error {
+	err := fn(c.writer())
+	if err != nil {
+		c.invalidate()
+	}
+	return err
+}
+
+// the read requests below only report the state of the cluster, so they may
+// be served by the followers
+
+func (c *readBalancedPDClient) GetCluster() (result *metapb.Cluster, err error) {
+	err = c.read(func(client PDClient) error {
+		result, err = client.GetCluster()
+		return err
+	})
+	return
+}
+
+func (c *readBalancedPDClient) GetOperatorCount() (result int, err error) {
+	err = c.read(func(client PDClient) error {
+		result, err = client.GetOperatorCount()
+		return err
+	})
+	return
+}
+
+func (c *readBalancedPDClient) SetStoreLabels(storeID uint64, labels map[string]string) (result bool, err error) {
+	err = c.write(func(client PDClient) error {
+		result, err = client.SetStoreLabels(storeID, labels)
+		return err
+	})
+	return
+}
+
+func (c *readBalancedPDClient) UpdateReplicationConfig(config PDReplicationConfig) error {
+	return c.write(func(client PDClient) error {
+		return client.UpdateReplicationConfig(config)
+	})
+}
+
+func (c *readBalancedPDClient) UpdateConfig(items map[string]interface{}) error {
+	return c.write(func(client PDClient) error {
+		return client.UpdateConfig(items)
+	})
+}
+
+func (c *readBalancedPDClient) DeleteStore(storeID uint64) error {
+	return c.write(func(client PDClient) error {
+		return client.DeleteStore(storeID)
+	})
+}
+
+func (c *readBalancedPDClient) SetStoreState(storeID uint64, state string) error {
+	return c.write(func(client PDClient) error {
+		return client.SetStoreState(storeID, state)
+	})
+}
+
+func (c *readBalancedPDClient) DeleteMember(name string) error {
+	return c.write(func(client PDClient) error {
+		return client.DeleteMember(name)
+	})
+}
+
+func (c *readBalancedPDClient) DeleteMemberByID(memberID uint64) error {
+	return c.write(func(client PDClient) error {
+		return client.DeleteMemberByID(memberID)
+	})
+}
+
+func (c *readBalancedPDClient) BeginEvictLeader(storeID uint64) error {
+	return c.write(func(client PDClient) error {
+		return client.BeginEvictLeader(storeID)
+	})
+}
+
+func (c *readBalancedPDClient) EndEvictLeader(storeID uint64) error {
+	return c.write(func(client PDClient) error {
+		return client.EndEvictLeader(storeID)
+	})
+}
+
+func (c *readBalancedPDClient) TransferPDLeader(name string) error {
+	return c.write(func(client PDClient) error {
+		return client.TransferPDLeader(name)
+	})
+}
+
+func (c *readBalancedPDClient) GetAutoscalingPlans(strategy Strategy) (result []Plan, err error) {
+	err = c.write(func(client PDClient) error {
+		result, err = client.GetAutoscalingPlans(strategy)
+		return err
+	})
+	return
+}
+
+func (c *readBalancedPDClient) AddScheduler(name string) error {
+	return c.write(func(client PDClient) error {
+		return client.AddScheduler(name)
+	})
+}
+
+func (c *readBalancedPDClient) RemoveScheduler(name string) error {
+	return c.write(func(client PDClient) error {
+		return client.RemoveScheduler(name)
+	})
+}
+
+func (c *readBalancedPDClient) SetSchedulerConfig(name string, config map[string]interface{}) error {
+	return c.write(func(client PDClient) error {
+		return client.SetSchedulerConfig(name, config)
+	})
+}
@@ -0,0 +1,190 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pdapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fakePDMembers serves the PD API of a PD cluster, member i listens at
+// servers[i] and member 0 is the leader
+type fakePDMembers struct {
+	mutex    sync.Mutex
+	servers  []*httptest.Server
+	requests map[string][]string
+	healthy  []bool
+	// followerHandled counts the requests that allow the follower to handle them
+	followerHandled map[string]int
+}
+
+func newFakePDMembers(g *GomegaWithT, n int) *fakePDMembers {
+	f := &fakePDMembers{requests: map[string][]string{}, followerHandled: map[string]int{}}
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("pd-%d", i)
+		f.healthy = append(f.healthy, true)
+		f.servers = append(f.servers, httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			f.mutex.Lock()
+			defer f.mutex.Unlock()
+			f.requests[name] = append(f.requests[name], r.Method+" "+r.URL.Path)
+			if r.Header.Get(allowFollowerHandleHeader) == "true" {
+				f.followerHandled[name]++
+			}
+			var resp interface{}
+			switch r.URL.Path {
+			case "/" + membersPrefix:
+				members := &MembersInfo{}
+				for i, s := range f.servers {
+					members.Members = append(members.Members, &pdpb.Member{Name: fmt.Sprintf("pd-%d", i), MemberId: uint64(i + 1), ClientUrls: []string{s.URL}})
+				}
+				members.Leader = members.Members[0]
+				resp = members
+			case "/" + healthPrefix:
+				healths := []MemberHealth{}
+				for i, s := range f.servers {
+					healths = append(healths, MemberHealth{Name: fmt.Sprintf("pd-%d", i), MemberID: uint64(i + 1), ClientUrls: []string{s.URL}, Health: f.healthy[i]})
+				}
+				resp = healths
+			case "/" + storesPrefix:
+				resp = &StoresInfo{}
+			case "/" + operatorsPrefix:
+				resp = []interface{}{}
+			}
+			data, err := json.Marshal(resp)
+			g.Expect(err).NotTo(HaveOccurred())
+			w.Write(data)
+		})))
+	}
+	return f
+}
+
+func (f *fakePDMembers) close() {
+	for _, s := range f.servers {
+		s.Close()
+	}
+}
+
+func (f *fakePDMembers) count(name, request string) int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	n := 0
+	for _, r := range f.requests[name] {
+		if r == request {
+			n++
+		}
+	}
+	return n
+}
+
+func TestReadBalancedPDClient(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	f := newFakePDMembers(g, 3)
+	defer f.close()
+	// the pd service is served by a follower
+	client := NewReadBalancedPDClient(NewPDClient(f.servers[1].URL, DefaultTimeout, nil), func(url string) PDClient {
+		return NewPDClient(url, DefaultTimeout, nil)
+	})
+	getOperators := "GET /" + operatorsPrefix
+	getStores := "GET /" + storesPrefix
+
+	// the observability reads are spread across the followers
+	for i := 0; i < 4; i++ {
+		_, err := client.GetOperatorCount()
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+	g.Expect(f.count("pd-0", getOperators)).To(Equal(0))
+	g.Expect(f.count("pd-1", getOperators)).To(Equal(2))
+	g.Expect(f.count("pd-2", getOperators)).To(Equal(2))
+
+	// the other reads are sent by the primary client
+	_, err := client.GetStores()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(f.count("pd-1", getStores)).To(Equal(1))
+	g.Expect(f.count("pd-2", getStores)).To(Equal(0))
+
+	// the writes are sent to the leader
+	transferLeader := "POST /" + pdLeaderTransferPrefix + "/pd-1"
+	g.Expect(client.TransferPDLeader("pd-1")).To(Succeed())
+	g.Expect(f.count("pd-0", transferLeader)).To(Equal(1))
+	g.Expect(f.count("pd-1", transferLeader)).To(Equal(0))
+	g.Expect(f.count("pd-2", transferLeader)).To(Equal(0))
+}
+
+func TestReadBalancedPDClientAllowFollowerHandle(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	f := newFakePDMembers(g, 3)
+	defer f.close()
+	client := NewReadBalancedPDClient(NewPDClient(f.servers[0].URL, DefaultTimeout, nil), func(url string) PDClient {
+		return allowFollowerHandle(NewPDClient(url, DefaultTimeout, nil))
+	})
+
+	_, err := client.GetOperatorCount()
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = client.GetOperatorCount()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(f.followerHandled["pd-1"]).To(Equal(1))
+	g.Expect(f.followerHandled["pd-2"]).To(Equal(1))
+	// the requests to discover the endpoints are sent by the primary client
+	g.Expect(f.followerHandled["pd-0"]).To(Equal(0))
+}
+
+func TestReadBalancedPDControlCache(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pdc := NewReadBalancedPDControl(fake.NewSimpleClientset()).(*defaultPDControl)
+	client := pdc.GetPDClient(Namespace("default"), "demo", false)
+	g.Expect(pdc.GetPDClient(Namespace("default"), "demo", false)).To(BeIdenticalTo(client))
+
+	// the client with TLS is cached until it expires
+	tlsClient := pdc.GetPDClient(Namespace("default"), "demo", true)
+	g.Expect(pdc.GetPDClient(Namespace("default"), "demo", true)).To(BeIdenticalTo(tlsClient))
+	pdc.readBalancedClients[pdClientKey("https", Namespace("default"), "demo")].expireTime = time.Now().Add(-time.Second)
+	g.Expect(pdc.GetPDClient(Namespace("default"), "demo", true)).NotTo(BeIdenticalTo(tlsClient))
+}
+
+func TestReadBalancedPDClientUnhealthyFollower(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	f := newFakePDMembers(g, 3)
+	defer f.close()
+	f.healthy[2] = false
+	client := NewReadBalancedPDClient(NewPDClient(f.servers[0].URL, DefaultTimeout, nil), func(url string) PDClient {
+		return NewPDClient(url, DefaultTimeout, nil)
+	})
+	getOperators := "GET /" + operatorsPrefix
+
+	for i := 0; i < 2; i++ {
+		_, err := client.GetOperatorCount()
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+	g.Expect(f.count("pd-1", getOperators)).To(Equal(2))
+	g.Expect(f.count("pd-2", getOperators)).To(Equal(0))
+
+	// the read falls back to the pd service if the follower fails
+	f.servers[1].Close()
+	_, err := client.GetOperatorCount()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(f.count("pd-0", getOperators)).To(Equal(1))
+}
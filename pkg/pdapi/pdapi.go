@@ -18,6 +18,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -40,6 +41,10 @@ const (
 	tiKVNotBootstrapped  = `TiKV cluster not bootstrapped, please start TiKV first"`
 )
 
+// ErrStoreNotFound is returned by GetStore if the store does not exist in PD,
+// e.g. it has been removed after it becomes tombstone
+var ErrStoreNotFound = errors.New("store not found")
+
 // GetTLSConfig returns *tls.Config for given TiDB cluster.
 func GetTLSConfig(kubeCli kubernetes.Interface, namespace Namespace, tcName string, secretName string) (*tls.Config, error) {
 	secret, err := kubeCli.CoreV1().Secrets(string(namespace)).Get(context.Background(), secretName, types.GetOptions{})
@@ -64,13 +69,16 @@ type PDClient interface {
 	GetStores() (*StoresInfo, error)
 	// GetTombStoneStores lists all tombstone stores from cluster
 	GetTombStoneStores() (*StoresInfo, error)
-	// GetStore gets a TiKV store for a specific store id from cluster
+	// GetStore gets a TiKV store for a specific store id from cluster,
+	// ErrStoreNotFound is returned if the store does not exist
 	GetStore(storeID uint64) (*StoreInfo, error)
 	// storeLabelsEqualNodeLabels compares store labels with node labels
 	// for historic reasons, PD stores TiKV labels as []*StoreLabel which is a key-value pair slice
 	SetStoreLabels(storeID uint64, labels map[string]string) (bool, error)
 	// UpdateReplicationConfig updates the replication config
 	UpdateReplicationConfig(config PDReplicationConfig) error
+	// UpdateConfig updates the config items, keyed by their dotted names, e.g. schedule.leader-schedule-limit
+	UpdateConfig(items map[string]interface{}) error
 	// DeleteStore deletes a TiKV store from cluster
 	DeleteStore(storeID uint64) error
 	// SetStoreState sets store to specified state.
@@ -92,6 +100,24 @@ type PDClient interface {
 	TransferPDLeader(name string) error
 	// GetAutoscalingPlans returns the scaling plan for the cluster
 	GetAutoscalingPlans(strategy Strategy) ([]Plan, error)
+	// GetSchedulers returns the names of all the schedulers
+	GetSchedulers() ([]string, error)
+	// AddScheduler adds a scheduler by name
+	AddScheduler(name string) error
+	// RemoveScheduler removes a scheduler by name
+	RemoveScheduler(name string) error
+	// GetSchedulerConfig returns the config of a scheduler
+	GetSchedulerConfig(name string) (map[string]interface{}, error)
+	// SetSchedulerConfig updates the config of a scheduler
+	SetSchedulerConfig(name string, config map[string]interface{}) error
+	// GetOperatorCount returns the number of operators PD is running
+	GetOperatorCount() (int, error)
+	// GetRegionCountByCheck returns the number of the regions failing the
+	// check, e.g. the regions missing peers for RegionCheckMissPeer
+	GetRegionCountByCheck(check string) (int, error)
+	// GetStorePendingPeerRegionCount returns the number of the regions with
+	// a pending peer on the store
+	GetStorePendingPeerRegionCount(storeID uint64) (int, error)
 }
 
 var (
@@ -109,6 +135,18 @@ var (
 	// config API, available since PD v3.1.0.
 	evictLeaderSchedulerConfigPrefix = "pd/api/v1/scheduler-config/evict-leader-scheduler/list"
 	autoscalingPrefix                = "autoscaling"
+	schedulerConfigPrefix            = "pd/api/v1/scheduler-config"
+	operatorsPrefix                  = "pd/api/v1/operators"
+	regionsCheckPrefix               = "pd/api/v1/regions/check"
+)
+
+const (
+	// RegionCheckMissPeer checks the regions with fewer peers than the max replicas
+	RegionCheckMissPeer = "miss-peer"
+	// RegionCheckDownPeer checks the regions with peers that do not respond
+	RegionCheckDownPeer = "down-peer"
+	// RegionCheckPendingPeer checks the regions with peers that fall behind the leader
+	RegionCheckPendingPeer = "pending-peer"
 )
 
 // pdClient is default implementation of PDClient
@@ -333,10 +371,21 @@ func (c *pdClient) GetTombStoneStores() (*StoresInfo, error) {
 
 func (c *pdClient) GetStore(storeID uint64) (*StoreInfo, error) {
 	apiURL := fmt.Sprintf("%s/%s/%d", c.url, storePrefix, storeID)
-	body, err := httputil.GetBodyOK(c.httpClient, apiURL)
+	res, err := c.httpClient.Get(apiURL)
 	if err != nil {
 		return nil, err
 	}
+	defer httputil.DeferClose(res.Body)
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrStoreNotFound
+	}
+	if res.StatusCode >= 400 {
+		return nil, fmt.Errorf("Error response %v URL %s,body response: %s", res.StatusCode, apiURL, string(body))
+	}
 	storeInfo := &StoreInfo{}
 	err = json.Unmarshal(body, storeInfo)
 	if err != nil {
@@ -507,6 +556,16 @@ func (c *pdClient) UpdateReplicationConfig(config PDReplicationConfig) error {
 	return fmt.Errorf("failed %v to update replication: %v", res.StatusCode, err)
 }
 
+func (c *pdClient) UpdateConfig(items map[string]interface{}) error {
+	apiURL := fmt.Sprintf("%s/%s", c.url, configPrefix)
+	data, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	_, err = httputil.PostBodyOK(c.httpClient, apiURL, bytes.NewBuffer(data))
+	return err
+}
+
 func (c *pdClient) BeginEvictLeader(storeID uint64) error {
 	leaderEvictInfo := getLeaderEvictSchedulerInfo(storeID)
 	apiURL := fmt.Sprintf("%s/%s", c.url, schedulersPrefix)
@@ -535,7 +594,7 @@ func (c *pdClient) BeginEvictLeader(storeID uint64) error {
 		return err
 	}
 	for _, s := range evictLeaderSchedulers {
-		if s == getLeaderEvictSchedulerStr(storeID) {
+		if s == GetLeaderEvictSchedulerStr(storeID) {
 			return nil
 		}
 	}
@@ -545,7 +604,7 @@ func (c *pdClient) BeginEvictLeader(storeID uint64) error {
 }
 
 func (c *pdClient) EndEvictLeader(storeID uint64) error {
-	sName := getLeaderEvictSchedulerStr(storeID)
+	sName := GetLeaderEvictSchedulerStr(storeID)
 	apiURL := fmt.Sprintf("%s/%s/%s", c.url, schedulersPrefix, sName)
 	req, err := http.NewRequest("DELETE", apiURL, nil)
 	if err != nil {
@@ -700,12 +759,137 @@ func (c *pdClient) GetAutoscalingPlans(strategy Strategy) ([]Plan, error) {
 	return plans, nil
 }
 
+func (c *pdClient) GetSchedulers() ([]string, error) {
+	apiURL := fmt.Sprintf("%s/%s", c.url, schedulersPrefix)
+	body, err := httputil.GetBodyOK(c.httpClient, apiURL)
+	if err != nil {
+		return nil, err
+	}
+	var schedulers []string
+	err = json.Unmarshal(body, &schedulers)
+	if err != nil {
+		return nil, err
+	}
+	return schedulers, nil
+}
+
+func (c *pdClient) AddScheduler(name string) error {
+	apiURL := fmt.Sprintf("%s/%s", c.url, schedulersPrefix)
+	data, err := json.Marshal(&schedulerInfo{Name: name})
+	if err != nil {
+		return err
+	}
+	_, err = httputil.PostBodyOK(c.httpClient, apiURL, bytes.NewBuffer(data))
+	return err
+}
+
+func (c *pdClient) RemoveScheduler(name string) error {
+	apiURL := fmt.Sprintf("%s/%s/%s", c.url, schedulersPrefix, name)
+	_, err := httputil.DeleteBodyOK(c.httpClient, apiURL)
+	return err
+}
+
+func (c *pdClient) GetSchedulerConfig(name string) (map[string]interface{}, error) {
+	apiURL := fmt.Sprintf("%s/%s/%s/list", c.url, schedulerConfigPrefix, name)
+	body, err := httputil.GetBodyOK(c.httpClient, apiURL)
+	if err != nil {
+		return nil, err
+	}
+	config := map[string]interface{}{}
+	err = json.Unmarshal(body, &config)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (c *pdClient) SetSchedulerConfig(name string, config map[string]interface{}) error {
+	apiURL := fmt.Sprintf("%s/%s/%s/config", c.url, schedulerConfigPrefix, name)
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	_, err = httputil.PostBodyOK(c.httpClient, apiURL, bytes.NewBuffer(data))
+	return err
+}
+
+func (c *pdClient) GetOperatorCount() (int, error) {
+	apiURL := fmt.Sprintf("%s/%s", c.url, operatorsPrefix)
+	body, err := httputil.GetBodyOK(c.httpClient, apiURL)
+	if err != nil {
+		return 0, err
+	}
+	// the format of the operators varies between PD versions, only the count is needed
+	var operators []json.RawMessage
+	err = json.Unmarshal(body, &operators)
+	if err != nil {
+		return 0, err
+	}
+	return len(operators), nil
+}
+
+// regionsCount is the count of the regions returned by the region check API
+type regionsCount struct {
+	Count int `json:"count"`
+}
+
+func (c *pdClient) GetRegionCountByCheck(check string) (int, error) {
+	apiURL := fmt.Sprintf("%s/%s/%s", c.url, regionsCheckPrefix, check)
+	body, err := httputil.GetBodyOK(c.httpClient, apiURL)
+	if err != nil {
+		return 0, err
+	}
+	regions := &regionsCount{}
+	err = json.Unmarshal(body, regions)
+	if err != nil {
+		return 0, err
+	}
+	return regions.Count, nil
+}
+
+type pendingPeerRegions struct {
+	Regions []struct {
+		PendingPeers []struct {
+			StoreID uint64 `json:"store_id"`
+		} `json:"pending_peers"`
+	} `json:"regions"`
+}
+
+func (c *pdClient) GetStorePendingPeerRegionCount(storeID uint64) (int, error) {
+	apiURL := fmt.Sprintf("%s/%s/%s", c.url, regionsCheckPrefix, RegionCheckPendingPeer)
+	body, err := httputil.GetBodyOK(c.httpClient, apiURL)
+	if err != nil {
+		return 0, err
+	}
+	regions := &pendingPeerRegions{}
+	err = json.Unmarshal(body, regions)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, region := range regions.Regions {
+		for _, peer := range region.PendingPeers {
+			if peer.StoreID == storeID {
+				count++
+				break
+			}
+		}
+	}
+	return count, nil
+}
+
+// IsEvictLeaderScheduler returns whether the scheduler is an evict leader scheduler
+func IsEvictLeaderScheduler(name string) bool {
+	return strings.HasPrefix(name, evictSchedulerLeader)
+}
+
 func getLeaderEvictSchedulerInfo(storeID uint64) *schedulerInfo {
 	return &schedulerInfo{"evict-leader-scheduler", storeID}
 }
 
-func getLeaderEvictSchedulerStr(storeID uint64) string {
-	return fmt.Sprintf("%s-%d", "evict-leader-scheduler", storeID)
+// GetLeaderEvictSchedulerStr returns the name of the evict leader scheduler of the store
+func GetLeaderEvictSchedulerStr(storeID uint64) string {
+	return fmt.Sprintf("%s-%d", evictSchedulerLeader, storeID)
 }
 
 // TiKVNotBootstrappedError represents that TiKV cluster is not bootstrapped yet
@@ -304,6 +304,50 @@ func TestGetStore(t *testing.T) {
 		g.Expect(err).NotTo(HaveOccurred())
 		g.Expect(result).To(Equal(store))
 	}
+
+	// the store removed from PD is not found
+	svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`"store 2 not found"`))
+	})
+	defer svc.Close()
+	_, err = NewPDClient(svc.URL, DefaultTimeout, &tls.Config{}).GetStore(2)
+	g.Expect(err).To(Equal(ErrStoreNotFound))
+}
+
+func TestGetStorePendingPeerRegionCount(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	resp := []byte(`
+{
+	"count": 3,
+	"regions": [
+		{"id": 2, "pending_peers": [{"id": 5, "store_id": 1}, {"id": 6, "store_id": 4}]},
+		{"id": 3, "pending_peers": [{"id": 7, "store_id": 4}]},
+		{"id": 8, "pending_peers": [{"id": 9, "store_id": 1}]}
+	]
+}
+`)
+	svc := getClientServer(func(w http.ResponseWriter, request *http.Request) {
+		g.Expect(request.Method).To(Equal("GET"), "test method")
+		g.Expect(request.URL.Path).To(Equal(fmt.Sprintf("/%s/%s", regionsCheckPrefix, RegionCheckPendingPeer)), "test url")
+
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		w.Write(resp)
+	})
+	defer svc.Close()
+
+	pdClient := NewPDClient(svc.URL, DefaultTimeout, &tls.Config{})
+	// only the regions with a pending peer on the store are counted
+	count, err := pdClient.GetStorePendingPeerRegionCount(1)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(count).To(Equal(2))
+	count, err = pdClient.GetStorePendingPeerRegionCount(4)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(count).To(Equal(2))
+	count, err = pdClient.GetStorePendingPeerRegionCount(3)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(count).To(Equal(0))
 }
 
 func TestSetStoreLabels(t *testing.T) {
@@ -774,6 +818,100 @@ func TestGeneric(t *testing.T) {
 			wantPath:    fmt.Sprintf("/%s/%s", pdLeaderTransferPrefix, "foo"),
 			checkResult: checkNoError,
 		},
+		{
+			name:   "GetSchedulers",
+			method: "GetSchedulers",
+			resp: []byte(`
+[
+	"balance-region-scheduler",
+	"evict-leader-scheduler-1"
+]
+`),
+			statusCode:  http.StatusOK,
+			wantMethod:  "GET",
+			wantPath:    fmt.Sprintf("/%s", schedulersPrefix),
+			checkResult: checkNoError,
+		},
+		{
+			name:   "GetOperatorCount",
+			method: "GetOperatorCount",
+			resp: []byte(`
+[
+	"balance-region {mv peer: store [1] to [4]} (kind:region, region:2(1,1), createAt:2021-06-01 08:00:00 +0000 UTC, startAt:2021-06-01 08:00:00 +0000 UTC, currentStep:0, steps:[add learner peer 5 on store 4])"
+]
+`),
+			statusCode:  http.StatusOK,
+			wantMethod:  "GET",
+			wantPath:    fmt.Sprintf("/%s", operatorsPrefix),
+			checkResult: checkNoError,
+		},
+		{
+			name:   "GetRegionCountByCheck",
+			method: "GetRegionCountByCheck",
+			args: []reflect.Value{
+				reflect.ValueOf(RegionCheckMissPeer),
+			},
+			resp: []byte(`
+{
+	"count": 0,
+	"regions": []
+}
+`),
+			statusCode:  http.StatusOK,
+			wantMethod:  "GET",
+			wantPath:    fmt.Sprintf("/%s/%s", regionsCheckPrefix, RegionCheckMissPeer),
+			checkResult: checkNoError,
+		},
+		{
+			name:   "AddScheduler",
+			method: "AddScheduler",
+			args: []reflect.Value{
+				reflect.ValueOf("balance-region-scheduler"),
+			},
+			statusCode:  http.StatusOK,
+			wantMethod:  "POST",
+			wantPath:    fmt.Sprintf("/%s", schedulersPrefix),
+			checkResult: checkNoError,
+		},
+		{
+			name:   "RemoveScheduler",
+			method: "RemoveScheduler",
+			args: []reflect.Value{
+				reflect.ValueOf("balance-region-scheduler"),
+			},
+			statusCode:  http.StatusOK,
+			wantMethod:  "DELETE",
+			wantPath:    fmt.Sprintf("/%s/balance-region-scheduler", schedulersPrefix),
+			checkResult: checkNoError,
+		},
+		{
+			name:   "GetSchedulerConfig",
+			method: "GetSchedulerConfig",
+			args: []reflect.Value{
+				reflect.ValueOf("balance-hot-region-scheduler"),
+			},
+			resp: []byte(`
+{
+	"min-hot-byte-rate": 100
+}
+`),
+			statusCode:  http.StatusOK,
+			wantMethod:  "GET",
+			wantPath:    fmt.Sprintf("/%s/balance-hot-region-scheduler/list", schedulerConfigPrefix),
+			checkResult: checkNoError,
+		},
+		{
+			name:   "SetSchedulerConfig",
+			method: "SetSchedulerConfig",
+			args: []reflect.Value{
+				reflect.ValueOf("balance-hot-region-scheduler"),
+				reflect.ValueOf(map[string]interface{}{"min-hot-byte-rate": 100}),
+			},
+			statusCode:  http.StatusOK,
+			wantMethod:  "POST",
+			wantPath:    fmt.Sprintf("/%s/balance-hot-region-scheduler/config", schedulerConfigPrefix),
+			checkResult: checkNoError,
+		},
 	}
 
 	for _, tt := range tests {
@@ -16,17 +16,31 @@ package pdapi
 import (
 	"context"
 	"crypto/tls"
+	"fmt"
 	"time"
 
 	etcdclientv3 "go.etcd.io/etcd/clientv3"
 	etcdclientv3util "go.etcd.io/etcd/clientv3/clientv3util"
 )
 
+// etcdDefragmentTimeout is the timeout of defragmenting an etcd member, which
+// blocks the member and takes longer than the other requests
+const etcdDefragmentTimeout = time.Minute
+
 type KeyValue struct {
 	Key   string
 	Value []byte
 }
 
+// EtcdMember is a member of the etcd cluster embedded in PD. The name of a
+// member is empty until it is started.
+type EtcdMember struct {
+	ID        uint64
+	Name      string
+	PeerURLs  []string
+	IsLearner bool
+}
+
 type PDEtcdClient interface {
 	// Get the specific kvs.
 	// if prefix is true will return all kvs with the specified key as prefix
@@ -37,6 +51,17 @@ type PDEtcdClient interface {
 	PutTTLKey(key, value string, ttl int64) error
 	// DeleteKey will delete key from the target pd etcd cluster
 	DeleteKey(key string) error
+	// ListMembers lists the members of the target pd etcd cluster
+	ListMembers() ([]*EtcdMember, error)
+	// AddLearner adds a learner with the peer URL to the target pd etcd cluster,
+	// the member started at the peer URL joins the cluster as the learner
+	AddLearner(peerURL string) (*EtcdMember, error)
+	// PromoteMember promotes the learner to a voter, which fails if the
+	// learner is not in sync with the leader yet
+	PromoteMember(id uint64) error
+	// Defragment defragments the backend database of the etcd member serving
+	// at the client URL endpoint to reclaim space
+	Defragment(endpoint string) error
 	// Close will close the etcd connection
 	Close() error
 }
@@ -121,3 +146,115 @@ func (c *pdEtcdClient) DeleteKey(key string) error {
 	}
 	return nil
 }
+
+func (c *pdEtcdClient) ListMembers() ([]*EtcdMember, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	resp, err := c.etcdClient.MemberList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]*EtcdMember, 0, len(resp.Members))
+	for _, member := range resp.Members {
+		members = append(members, &EtcdMember{
+			ID:        member.ID,
+			Name:      member.Name,
+			PeerURLs:  member.PeerURLs,
+			IsLearner: member.IsLearner,
+		})
+	}
+	return members, nil
+}
+
+func (c *pdEtcdClient) AddLearner(peerURL string) (*EtcdMember, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	resp, err := c.etcdClient.MemberAddAsLearner(ctx, []string{peerURL})
+	if err != nil {
+		return nil, err
+	}
+	return &EtcdMember{
+		ID:        resp.Member.ID,
+		PeerURLs:  resp.Member.PeerURLs,
+		IsLearner: true,
+	}, nil
+}
+
+func (c *pdEtcdClient) PromoteMember(id uint64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	_, err := c.etcdClient.MemberPromote(ctx, id)
+	return err
+}
+
+func (c *pdEtcdClient) Defragment(endpoint string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdDefragmentTimeout)
+	defer cancel()
+	_, err := c.etcdClient.Defragment(ctx, endpoint)
+	return err
+}
+
+// FakePDEtcdClient is a fake implementation of PDEtcdClient that only serves
+// the member list and records the defragmented endpoints and the promoted
+// members. The learners are added to the members and the members in
+// NotReady can't be promoted.
+type FakePDEtcdClient struct {
+	Members      []*EtcdMember
+	Defragmented []string
+	Promoted     []uint64
+	NotReady     map[uint64]bool
+	// DefragmentError is returned by Defragment if set
+	DefragmentError error
+}
+
+func (c *FakePDEtcdClient) Get(key string, prefix bool) (kvs []*KeyValue, err error) {
+	return nil, nil
+}
+
+func (c *FakePDEtcdClient) PutKey(key, value string) error {
+	return nil
+}
+
+func (c *FakePDEtcdClient) PutTTLKey(key, value string, ttl int64) error {
+	return nil
+}
+
+func (c *FakePDEtcdClient) DeleteKey(key string) error {
+	return nil
+}
+
+func (c *FakePDEtcdClient) ListMembers() ([]*EtcdMember, error) {
+	return c.Members, nil
+}
+
+func (c *FakePDEtcdClient) AddLearner(peerURL string) (*EtcdMember, error) {
+	member := &EtcdMember{ID: uint64(len(c.Members) + 1), PeerURLs: []string{peerURL}, IsLearner: true}
+	c.Members = append(c.Members, member)
+	return member, nil
+}
+
+func (c *FakePDEtcdClient) PromoteMember(id uint64) error {
+	if c.NotReady[id] {
+		return fmt.Errorf("etcdserver: can only promote a learner member which is in sync with leader")
+	}
+	for _, member := range c.Members {
+		if member.ID == id {
+			member.IsLearner = false
+		}
+	}
+	c.Promoted = append(c.Promoted, id)
+	return nil
+}
+
+func (c *FakePDEtcdClient) Defragment(endpoint string) error {
+	if c.DefragmentError != nil {
+		return c.DefragmentError
+	}
+	c.Defragmented = append(c.Defragmented, endpoint)
+	return nil
+}
+
+func (c *FakePDEtcdClient) Close() error {
+	return nil
+}
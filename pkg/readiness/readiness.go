@@ -0,0 +1,142 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package readiness
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	listers "github.com/pingcap/tidb-operator/pkg/client/listers/pingcap/v1alpha1"
+	apps "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog"
+)
+
+// PathPrefix is the HTTP path prefix of the readiness endpoint, the readiness
+// of a TidbCluster is served at PathPrefix/{namespace}/{name} and the readiness
+// of one of its components at PathPrefix/{namespace}/{name}/{component}
+const PathPrefix = "/readiness/"
+
+// ClusterReadiness is the readiness of a TidbCluster assessed by the operator
+type ClusterReadiness struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Ready     bool   `json:"ready"`
+	// Components maps the deployed components to their readiness
+	Components map[string]bool `json:"components"`
+}
+
+// GetClusterReadiness derives the readiness of each deployed component of the
+// TidbCluster from its status, the cluster is ready if all of them are ready
+func GetClusterReadiness(tc *v1alpha1.TidbCluster) *ClusterReadiness {
+	components := map[string]bool{}
+	if tc.Spec.PD != nil {
+		components[v1alpha1.PDMemberType.String()] = tc.PDAllMembersReady()
+	}
+	if tc.Spec.TiKV != nil {
+		components[v1alpha1.TiKVMemberType.String()] = tc.TiKVAllStoresReady()
+	}
+	if tc.Spec.TiDB != nil {
+		components[v1alpha1.TiDBMemberType.String()] = tc.TiDBAllMembersReady()
+	}
+	if tc.Spec.TiFlash != nil {
+		components[v1alpha1.TiFlashMemberType.String()] = tc.TiFlashAllStoresReady()
+	}
+	if tc.Spec.TiCDC != nil {
+		components[v1alpha1.TiCDCMemberType.String()] = statefulSetReady(tc.Status.TiCDC.StatefulSet, tc.Spec.TiCDC.Replicas)
+	}
+	if tc.Spec.Pump != nil {
+		components[v1alpha1.PumpMemberType.String()] = statefulSetReady(tc.Status.Pump.StatefulSet, tc.Spec.Pump.Replicas)
+	}
+
+	ready := true
+	for _, r := range components {
+		ready = ready && r
+	}
+	return &ClusterReadiness{
+		Namespace:  tc.GetNamespace(),
+		Name:       tc.GetName(),
+		Ready:      ready,
+		Components: components,
+	}
+}
+
+func statefulSetReady(status *apps.StatefulSetStatus, replicas int32) bool {
+	if status == nil {
+		return replicas == 0
+	}
+	return status.ReadyReplicas >= replicas
+}
+
+type handler struct {
+	lister listers.TidbClusterLister
+	synced func() bool
+}
+
+// NewHandler returns the handler of the readiness endpoint, which responds
+// 200 if the TidbCluster or the component is ready and 503 otherwise, with
+// the ClusterReadiness in JSON as the body. synced returns whether the cache
+// of the lister is synced, the handler responds 503 until it is.
+func NewHandler(lister listers.TidbClusterLister, synced func() bool) http.Handler {
+	return &handler{lister: lister, synced: synced}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(req.URL.Path, PathPrefix), "/"), "/")
+	if len(parts) != 2 && len(parts) != 3 {
+		http.Error(w, "the path must be "+PathPrefix+"{namespace}/{name}[/{component}]", http.StatusNotFound)
+		return
+	}
+	if !h.synced() {
+		http.Error(w, "the cache of TidbClusters is not synced", http.StatusServiceUnavailable)
+		return
+	}
+
+	tc, err := h.lister.TidbClusters(parts[0]).Get(parts[1])
+	if errors.IsNotFound(err) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	readiness := GetClusterReadiness(tc)
+	ready := readiness.Ready
+	if len(parts) == 3 {
+		r, ok := readiness.Components[parts[2]]
+		if !ok {
+			http.Error(w, "component "+parts[2]+" is not deployed", http.StatusNotFound)
+			return
+		}
+		ready = r
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if ready {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(readiness); err != nil {
+		klog.Errorf("failed to write the readiness of tidbcluster %s/%s: %v", parts[0], parts[1], err)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package readiness
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/controller"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newReadyTidbCluster() *v1alpha1.TidbCluster {
+	tc := &v1alpha1.TidbCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "basic", Namespace: "default"},
+		Spec: v1alpha1.TidbClusterSpec{
+			PD:    &v1alpha1.PDSpec{Replicas: 1},
+			TiKV:  &v1alpha1.TiKVSpec{Replicas: 1},
+			TiDB:  &v1alpha1.TiDBSpec{Replicas: 1},
+			TiCDC: &v1alpha1.TiCDCSpec{Replicas: 1},
+		},
+	}
+	tc.Status.PD.Members = map[string]v1alpha1.PDMember{"basic-pd-0": {Name: "basic-pd-0", Health: true}}
+	tc.Status.TiKV.Stores = map[string]v1alpha1.TiKVStore{"1": {ID: "1", State: v1alpha1.TiKVStateUp}}
+	tc.Status.TiDB.Members = map[string]v1alpha1.TiDBMember{"basic-tidb-0": {Name: "basic-tidb-0", Health: true}}
+	tc.Status.TiCDC.StatefulSet = &apps.StatefulSetStatus{ReadyReplicas: 1}
+	return tc
+}
+
+func TestGetClusterReadiness(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newReadyTidbCluster()
+	r := GetClusterReadiness(tc)
+	g.Expect(r.Ready).To(BeTrue())
+	g.Expect(r.Components).To(Equal(map[string]bool{"pd": true, "tikv": true, "tidb": true, "ticdc": true}))
+
+	tc.Status.TiKV.Stores["1"] = v1alpha1.TiKVStore{ID: "1", State: v1alpha1.TiKVStateDown}
+	tc.Status.TiCDC.StatefulSet = nil
+	r = GetClusterReadiness(tc)
+	g.Expect(r.Ready).To(BeFalse())
+	g.Expect(r.Components).To(Equal(map[string]bool{"pd": true, "tikv": false, "tidb": true, "ticdc": false}))
+}
+
+func TestReadinessHandler(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deps := controller.NewFakeDependencies()
+	indexer := deps.InformerFactory.Pingcap().V1alpha1().TidbClusters().Informer().GetIndexer()
+	synced := false
+	h := NewHandler(deps.TiDBClusterLister, func() bool { return synced })
+
+	get := func(path string) (int, *ClusterReadiness) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Header().Get("Content-Type") != "application/json" {
+			return w.Code, nil
+		}
+		r := &ClusterReadiness{}
+		g.Expect(json.Unmarshal(w.Body.Bytes(), r)).To(Succeed())
+		return w.Code, r
+	}
+
+	code, r := get("/readiness/default/basic")
+	g.Expect(code).To(Equal(http.StatusServiceUnavailable))
+	g.Expect(r).To(BeNil())
+
+	synced = true
+	code, _ = get("/readiness/default/basic")
+	g.Expect(code).To(Equal(http.StatusNotFound))
+	code, _ = get("/readiness/default")
+	g.Expect(code).To(Equal(http.StatusNotFound))
+
+	tc := newReadyTidbCluster()
+	g.Expect(indexer.Add(tc)).To(Succeed())
+	code, r = get("/readiness/default/basic")
+	g.Expect(code).To(Equal(http.StatusOK))
+	g.Expect(r.Ready).To(BeTrue())
+	code, _ = get("/readiness/default/basic/tikv")
+	g.Expect(code).To(Equal(http.StatusOK))
+	code, _ = get("/readiness/default/basic/tiflash")
+	g.Expect(code).To(Equal(http.StatusNotFound))
+
+	// the endpoint reflects the change of the status
+	tc = tc.DeepCopy()
+	tc.Status.TiKV.Stores["1"] = v1alpha1.TiKVStore{ID: "1", State: v1alpha1.TiKVStateDown}
+	g.Expect(indexer.Update(tc)).To(Succeed())
+	code, r = get("/readiness/default/basic")
+	g.Expect(code).To(Equal(http.StatusServiceUnavailable))
+	g.Expect(r.Ready).To(BeFalse())
+	g.Expect(r.Components["tikv"]).To(BeFalse())
+	code, _ = get("/readiness/default/basic/tikv")
+	g.Expect(code).To(Equal(http.StatusServiceUnavailable))
+	code, _ = get("/readiness/default/basic/tidb")
+	g.Expect(code).To(Equal(http.StatusOK))
+}
@@ -14,13 +14,16 @@
 package tikvapi
 
 import (
+	"bytes"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
 	"strconv"
 	"time"
 
+	httputil "github.com/pingcap/tidb-operator/pkg/util/http"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/prom2json"
 	"k8s.io/klog"
@@ -31,11 +34,30 @@ const (
 	metricNameRegionCount = "tikv_raftstore_region_count"
 	labelNameLeaderCount  = "leader"
 	metricsPrefix         = "metrics"
+	configPrefix          = "config"
 )
 
+// TiKVConfigFromAPI is the part of the config of TiKV got from its status API
+type TiKVConfigFromAPI struct {
+	Coprocessor *TiKVCoprocessorConfig `json:"coprocessor,omitempty"`
+}
+
+// TiKVCoprocessorConfig is the Region size related config of TiKV
+type TiKVCoprocessorConfig struct {
+	// RegionMaxSize is the max size of a Region, e.g. 144MiB
+	RegionMaxSize string `json:"region-max-size,omitempty"`
+	// RegionSplitSize is the size of the Regions split from a large Region, e.g. 96MiB
+	RegionSplitSize string `json:"region-split-size,omitempty"`
+}
+
 // TiKVClient provides tikv server's api
 type TiKVClient interface {
 	GetLeaderCount() (int, error)
+	// GetConfig returns the config of the TiKV
+	GetConfig() (*TiKVConfigFromAPI, error)
+	// UpdateConfig updates the config items of the TiKV online, keyed by
+	// their dotted names, e.g. coprocessor.region-max-size
+	UpdateConfig(items map[string]interface{}) error
 }
 
 // tikvClient is default implementation of TiKVClient
@@ -70,6 +92,29 @@ func (c *tikvClient) GetLeaderCount() (int, error) {
 	return 0, fmt.Errorf("metric %s{type=\"%s\"} not found for %s", metricNameRegionCount, labelNameLeaderCount, apiURL)
 }
 
+func (c *tikvClient) GetConfig() (*TiKVConfigFromAPI, error) {
+	apiURL := fmt.Sprintf("%s/%s", c.url, configPrefix)
+	body, err := httputil.GetBodyOK(c.httpClient, apiURL)
+	if err != nil {
+		return nil, err
+	}
+	config := &TiKVConfigFromAPI{}
+	if err := json.Unmarshal(body, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (c *tikvClient) UpdateConfig(items map[string]interface{}) error {
+	apiURL := fmt.Sprintf("%s/%s", c.url, configPrefix)
+	data, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	_, err = httputil.PostBodyOK(c.httpClient, apiURL, bytes.NewBuffer(data))
+	return err
+}
+
 // NewTiKVClient returns a new TiKVClient
 func NewTiKVClient(url string, timeout time.Duration, tlsConfig *tls.Config, disableKeepalive bool) TiKVClient {
 	return &tikvClient{
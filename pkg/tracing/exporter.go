@@ -0,0 +1,261 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+)
+
+const (
+	// otlpTracesPath is the path of the OTLP/HTTP traces API
+	otlpTracesPath = "/v1/traces"
+	// otlpQueueSize is the max number of spans waiting to be sent, the spans
+	// exceeding it are dropped
+	otlpQueueSize = 2048
+	// otlpBatchSize is the max number of spans sent in a request
+	otlpBatchSize = 512
+	// otlpTimeout is the timeout of a request to the collector
+	otlpTimeout = 10 * time.Second
+
+	serviceName = "tidb-controller-manager"
+	scopeName   = "github.com/pingcap/tidb-operator"
+)
+
+// otlpExporter sends the spans in batches to an OpenTelemetry collector with
+// the OTLP/HTTP protocol in the JSON encoding.
+// TODO: replace it with the otlptracehttp exporter of the OpenTelemetry SDK
+// once the etcd client is upgraded to v3.5. The OTLP exporters of the SDK
+// require google.golang.org/grpc v1.37+, which the etcd v3.4 clientv3 used by
+// pdapi does not build with, and the releases working with grpc v1.27 speak
+// an OTLP version the collectors no longer accept.
+type otlpExporter struct {
+	url        string
+	interval   time.Duration
+	httpClient *http.Client
+
+	queue    chan *SpanData
+	flush    chan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewOTLPExporter returns an Exporter that sends the spans to the OTLP/HTTP
+// endpoint of an OpenTelemetry collector, e.g. http://otel-collector:4318,
+// every interval or once a batch is full
+func NewOTLPExporter(endpoint string, interval time.Duration) Exporter {
+	e := &otlpExporter{
+		url:        strings.TrimSuffix(endpoint, "/") + otlpTracesPath,
+		interval:   interval,
+		httpClient: &http.Client{Timeout: otlpTimeout},
+		queue:      make(chan *SpanData, otlpQueueSize),
+		flush:      make(chan chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+func (e *otlpExporter) ExportSpan(span *SpanData) {
+	select {
+	case e.queue <- span:
+	default:
+		klog.V(4).Infof("tracing: the span queue is full, drop span %s", span.Name)
+	}
+}
+
+func (e *otlpExporter) Shutdown() {
+	e.stopOnce.Do(func() {
+		done := make(chan struct{})
+		e.flush <- done
+		<-done
+	})
+}
+
+func (e *otlpExporter) run() {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	var batch []*SpanData
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.send(batch); err != nil {
+			klog.Warningf("tracing: failed to send %d spans to %s, error: %v", len(batch), e.url, err)
+		}
+		batch = nil
+	}
+	for {
+		select {
+		case span := <-e.queue:
+			batch = append(batch, span)
+			if len(batch) >= otlpBatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case done := <-e.flush:
+			for len(e.queue) > 0 {
+				batch = append(batch, <-e.queue)
+			}
+			send()
+			close(done)
+			return
+		}
+	}
+}
+
+func (e *otlpExporter) send(batch []*SpanData) error {
+	body, err := json.Marshal(newOTLPRequest(batch))
+	if err != nil {
+		return err
+	}
+	resp, err := e.httpClient.Post(e.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// The following types are the JSON encoding of the OTLP
+// ExportTraceServiceRequest, see
+// https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/trace/v1/trace.proto
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	otlpSpanKindInternal = 1
+	otlpSpanKindClient   = 3
+	otlpStatusCodeOk     = 1
+	otlpStatusCodeError  = 2
+)
+
+func newOTLPRequest(batch []*SpanData) *otlpRequest {
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, data := range batch {
+		span := otlpSpan{
+			TraceID:           data.TraceID,
+			SpanID:            data.SpanID,
+			ParentSpanID:      data.ParentSpanID,
+			Name:              data.Name,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(data.StartTime.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(data.EndTime.UnixNano(), 10),
+			Status:            otlpStatus{Code: otlpStatusCodeOk},
+		}
+		if _, ok := data.Attribute(HTTPMethodKey); ok {
+			span.Kind = otlpSpanKindClient
+		}
+		for _, attr := range data.Attributes {
+			span.Attributes = append(span.Attributes, otlpAttribute{Key: attr.Key, Value: otlpAnyValue{StringValue: attr.Value}})
+		}
+		if data.Error != "" {
+			span.Status = otlpStatus{Code: otlpStatusCodeError, Message: data.Error}
+		}
+		spans = append(spans, span)
+	}
+	return &otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: []otlpAttribute{{Key: "service.name", Value: otlpAnyValue{StringValue: serviceName}}},
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: scopeName},
+				Spans: spans,
+			}},
+		}},
+	}
+}
+
+// InMemoryExporter keeps the spans in memory, it is used in tests
+type InMemoryExporter struct {
+	mutex sync.Mutex
+	spans []*SpanData
+}
+
+// NewInMemoryExporter returns an InMemoryExporter
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+func (e *InMemoryExporter) ExportSpan(span *SpanData) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+func (e *InMemoryExporter) Shutdown() {}
+
+// Spans returns the exported spans in the order they ended
+func (e *InMemoryExporter) Spans() []*SpanData {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]*SpanData(nil), e.spans...)
+}
@@ -0,0 +1,242 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing records the reconciles of the operator as OpenTelemetry
+// spans. A reconcile of a cluster is the root span, its steps are the child
+// spans, and the PD API and Kubernetes API requests sent during a step are
+// the leaf spans.
+//
+// The reconciles don't carry a context, so the spans are linked by the
+// cluster they belong to: a cluster is reconciled by one worker at a time,
+// and the innermost span started for it is the parent of the next one.
+//
+// Tracing is disabled unless a Tracer is set with SetTracer, in which case
+// the spans are nil and all the operations on them are no-ops.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// The attribute keys of the spans
+const (
+	NamespaceKey  = "tidbcluster.namespace"
+	ClusterKey    = "tidbcluster.name"
+	ComponentKey  = "tidbcluster.component"
+	HTTPMethodKey = "http.method"
+	HTTPURLKey    = "http.url"
+	HTTPStatusKey = "http.status_code"
+)
+
+// Attribute is a key-value pair attached to a span
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// SpanData is a finished span handed to the Exporter
+type SpanData struct {
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Name         string
+	StartTime    time.Time
+	EndTime      time.Time
+	Attributes   []Attribute
+	// Error is the message of the error the span ended with, empty if it succeeded
+	Error string
+}
+
+// Attribute returns the value of the attribute with the key, and whether it is set
+func (d *SpanData) Attribute(key string) (string, bool) {
+	for _, attr := range d.Attributes {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// Exporter sends the finished spans to a tracing backend
+type Exporter interface {
+	// ExportSpan queues the span to be sent, it must not block
+	ExportSpan(span *SpanData)
+	// Shutdown sends the queued spans and stops the exporter
+	Shutdown()
+}
+
+// Tracer creates the spans and hands them to the Exporter when they end
+type Tracer struct {
+	exporter Exporter
+
+	mutex sync.Mutex
+	// active is the innermost unfinished span of each cluster, keyed by
+	// namespace/name
+	active map[string]*Span
+}
+
+// NewTracer returns a Tracer that exports the spans with the exporter
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter, active: map[string]*Span{}}
+}
+
+var globalTracer *Tracer
+
+// SetTracer sets the Tracer used by the operator, nil disables tracing. It
+// must be called before the controllers and clients are started.
+func SetTracer(t *Tracer) {
+	globalTracer = t
+}
+
+// Enabled returns whether tracing is enabled
+func Enabled() bool {
+	return globalTracer != nil
+}
+
+// Shutdown sends the queued spans of the Tracer used by the operator
+func Shutdown() {
+	if globalTracer != nil {
+		globalTracer.exporter.Shutdown()
+	}
+}
+
+// Span is an unfinished span, a nil Span is a no-op
+type Span struct {
+	tracer *Tracer
+	key    string
+	parent *Span
+	data   SpanData
+}
+
+// Start starts a span of the cluster as the child of its innermost active
+// span, or as a root span if there is none. The span becomes the innermost
+// active span of the cluster until it ends, so it must be ended by the
+// goroutine reconciling the cluster.
+func Start(namespace, name, spanName string, attrs ...Attribute) *Span {
+	t := globalTracer
+	if t == nil {
+		return nil
+	}
+	key := clusterKey(namespace, name)
+	attrs = append([]Attribute{{Key: NamespaceKey, Value: namespace}, {Key: ClusterKey, Value: name}}, attrs...)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	span := t.newSpan(t.active[key], spanName, attrs)
+	span.key = key
+	t.active[key] = span
+	return span
+}
+
+// startLeaf starts a span as the child of the span, or as a root span if it
+// is nil, without making it active
+func (t *Tracer) startLeaf(parent *Span, spanName string, attrs []Attribute) *Span {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.newSpan(parent, spanName, attrs)
+}
+
+// newSpan must be called with the mutex held
+func (t *Tracer) newSpan(parent *Span, spanName string, attrs []Attribute) *Span {
+	span := &Span{
+		tracer: t,
+		parent: parent,
+		data: SpanData{
+			SpanID:     newID(8),
+			Name:       spanName,
+			StartTime:  time.Now(),
+			Attributes: attrs,
+		},
+	}
+	if parent != nil {
+		span.data.TraceID = parent.data.TraceID
+		span.data.ParentSpanID = parent.data.SpanID
+	} else {
+		span.data.TraceID = newID(16)
+	}
+	return span
+}
+
+// activeSpan returns the innermost active span of the cluster
+func (t *Tracer) activeSpan(key string) *Span {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.active[key]
+}
+
+// activeSpanInNamespace returns the innermost active span of the only cluster
+// being reconciled in the namespace, nil if there are none or several
+func (t *Tracer) activeSpanInNamespace(namespace string) *Span {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	var found *Span
+	for _, span := range t.active {
+		ns, _ := span.data.Attribute(NamespaceKey)
+		if ns != namespace {
+			continue
+		}
+		if found != nil {
+			return nil
+		}
+		found = span
+	}
+	return found
+}
+
+// SetAttributes adds the attributes to the span
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.data.Attributes = append(s.data.Attributes, attrs...)
+}
+
+// End finishes the span with the error it failed with, if any, and exports it
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.data.EndTime = time.Now()
+	if err != nil {
+		s.data.Error = err.Error()
+	}
+	if s.key != "" {
+		t := s.tracer
+		t.mutex.Lock()
+		if t.active[s.key] == s {
+			if s.parent != nil {
+				t.active[s.key] = s.parent
+			} else {
+				delete(t.active, s.key)
+			}
+		}
+		t.mutex.Unlock()
+	}
+	data := s.data
+	s.tracer.exporter.ExportSpan(&data)
+}
+
+func clusterKey(namespace, name string) string {
+	return fmt.Sprintf("%s/%s", namespace, name)
+}
+
+func newID(n int) string {
+	b := make([]byte, n)
+	// crypto/rand.Read never fails on the supported platforms
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,143 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestTracingDisabled(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	SetTracer(nil)
+	g.Expect(Enabled()).To(BeFalse())
+	span := Start("ns", "tc", "reconcile")
+	g.Expect(span).To(BeNil())
+	// the operations on a nil span are no-ops
+	span.SetAttributes(Attribute{Key: "k", Value: "v"})
+	span.End(fmt.Errorf("error"))
+	g.Expect(WrapPDTransport("ns", "tc", http.DefaultTransport)).To(BeIdenticalTo(http.DefaultTransport))
+	g.Expect(WrapKubeTransport(http.DefaultTransport)).To(BeIdenticalTo(http.DefaultTransport))
+}
+
+func TestTracingSpans(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	exporter := NewInMemoryExporter()
+	SetTracer(NewTracer(exporter))
+	defer SetTracer(nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/pd/api/v1/members" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	pdClient := &http.Client{Transport: WrapPDTransport("ns", "tc", nil)}
+	kubeClient := &http.Client{Transport: WrapKubeTransport(http.DefaultTransport)}
+
+	root := Start("ns", "tc", "reconcile")
+	other := Start("ns", "other", "reconcile")
+	step := Start("ns", "tc", "sync pd", Attribute{Key: ComponentKey, Value: "pd"})
+	_, err := pdClient.Get(server.URL + "/pd/api/v1/members")
+	g.Expect(err).NotTo(HaveOccurred())
+	step.End(nil)
+	_, err = pdClient.Get(server.URL + "/pd/api/v1/stores")
+	g.Expect(err).NotTo(HaveOccurred())
+	other.End(nil)
+	_, err = kubeClient.Get(server.URL + "/api/v1/namespaces/ns/pods")
+	g.Expect(err).NotTo(HaveOccurred())
+	root.End(fmt.Errorf("requeue"))
+	_, err = kubeClient.Get(server.URL + "/api/v1/namespaces/ns/pods")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	spans := exporter.Spans()
+	g.Expect(spans).To(HaveLen(7))
+	byName := func(name string) []*SpanData {
+		var found []*SpanData
+		for _, span := range spans {
+			if span.Name == name {
+				found = append(found, span)
+			}
+		}
+		return found
+	}
+	rootData := spans[5]
+	g.Expect(rootData.Name).To(Equal("reconcile"))
+	g.Expect(rootData.ParentSpanID).To(BeEmpty())
+	g.Expect(rootData.Error).To(Equal("requeue"))
+
+	stepData := byName("sync pd")[0]
+	g.Expect(stepData.TraceID).To(Equal(rootData.TraceID))
+	g.Expect(stepData.ParentSpanID).To(Equal(rootData.SpanID))
+
+	members := byName("pd GET /pd/api/v1/members")[0]
+	g.Expect(members.ParentSpanID).To(Equal(stepData.SpanID))
+	g.Expect(members.Error).To(ContainSubstring("500"))
+	status, _ := members.Attribute(HTTPStatusKey)
+	g.Expect(status).To(Equal("500"))
+	// the innermost active span is the root after the step ends
+	stores := byName("pd GET /pd/api/v1/stores")[0]
+	g.Expect(stores.ParentSpanID).To(Equal(rootData.SpanID))
+	g.Expect(stores.Error).To(BeEmpty())
+
+	pods := byName("kubernetes GET /api/v1/namespaces/ns/pods")
+	g.Expect(pods).To(HaveLen(2))
+	// linked to the only cluster being reconciled in the namespace
+	g.Expect(pods[0].ParentSpanID).To(Equal(rootData.SpanID))
+	g.Expect(pods[1].ParentSpanID).To(BeEmpty())
+}
+
+func TestOTLPExporter(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	requests := make(chan *otlpRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.URL.Path).To(Equal(otlpTracesPath))
+		body, err := ioutil.ReadAll(r.Body)
+		g.Expect(err).NotTo(HaveOccurred())
+		req := &otlpRequest{}
+		g.Expect(json.Unmarshal(body, req)).To(Succeed())
+		requests <- req
+	}))
+	defer server.Close()
+
+	exporter := NewOTLPExporter(server.URL+"/", time.Hour)
+	SetTracer(NewTracer(exporter))
+	defer SetTracer(nil)
+	root := Start("ns", "tc", "reconcile")
+	Start("ns", "tc", "sync pd").End(fmt.Errorf("failed"))
+	root.End(nil)
+	Shutdown()
+
+	var req *otlpRequest
+	g.Eventually(requests).Should(Receive(&req))
+	g.Expect(req.ResourceSpans).To(HaveLen(1))
+	g.Expect(req.ResourceSpans[0].Resource.Attributes[0].Value.StringValue).To(Equal(serviceName))
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	g.Expect(spans).To(HaveLen(2))
+	g.Expect(spans[0].Name).To(Equal("sync pd"))
+	g.Expect(spans[0].ParentSpanID).To(Equal(spans[1].SpanID))
+	g.Expect(spans[0].TraceID).To(HaveLen(32))
+	g.Expect(spans[0].Status).To(Equal(otlpStatus{Code: otlpStatusCodeError, Message: "failed"}))
+	g.Expect(spans[1].Status).To(Equal(otlpStatus{Code: otlpStatusCodeOk}))
+}
@@ -0,0 +1,118 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// transport records a span for each request sent by a client
+type transport struct {
+	tracer    *Tracer
+	component string
+	// parent returns the parent of the span of a request
+	parent func(req *http.Request) *Span
+	attrs  func(req *http.Request) []Attribute
+	next   http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attrs := append(t.attrs(req),
+		Attribute{Key: ComponentKey, Value: t.component},
+		Attribute{Key: HTTPMethodKey, Value: req.Method},
+		Attribute{Key: HTTPURLKey, Value: req.URL.String()},
+	)
+	span := t.tracer.startLeaf(t.parent(req), fmt.Sprintf("%s %s %s", t.component, req.Method, req.URL.Path), attrs)
+	resp, err := t.next.RoundTrip(req)
+	if err == nil {
+		span.SetAttributes(Attribute{Key: HTTPStatusKey, Value: strconv.Itoa(resp.StatusCode)})
+		if resp.StatusCode >= http.StatusBadRequest {
+			span.data.Error = resp.Status
+		}
+	}
+	span.End(err)
+	return resp, err
+}
+
+// WrapPDTransport returns a RoundTripper that records the requests sent to
+// the PD of the cluster as the children of its innermost active span. It
+// returns rt itself if tracing is disabled.
+func WrapPDTransport(namespace, name string, rt http.RoundTripper) http.RoundTripper {
+	t := globalTracer
+	if t == nil {
+		return rt
+	}
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	key := clusterKey(namespace, name)
+	return &transport{
+		tracer:    t,
+		component: "pd",
+		parent: func(_ *http.Request) *Span {
+			return t.activeSpan(key)
+		},
+		attrs: func(_ *http.Request) []Attribute {
+			return []Attribute{{Key: NamespaceKey, Value: namespace}, {Key: ClusterKey, Value: name}}
+		},
+		next: rt,
+	}
+}
+
+// WrapKubeTransport returns a RoundTripper that records the requests sent to
+// the Kubernetes API server. A namespaced request is recorded as the child
+// of the innermost active span of the cluster being reconciled in its
+// namespace, if there is exactly one, otherwise as a root span. It returns
+// rt itself if tracing is disabled.
+//
+// It is meant to be used with rest.Config.Wrap.
+func WrapKubeTransport(rt http.RoundTripper) http.RoundTripper {
+	t := globalTracer
+	if t == nil {
+		return rt
+	}
+	return &transport{
+		tracer:    t,
+		component: "kubernetes",
+		parent: func(req *http.Request) *Span {
+			ns := requestNamespace(req)
+			if ns == "" {
+				return nil
+			}
+			return t.activeSpanInNamespace(ns)
+		},
+		attrs: func(req *http.Request) []Attribute {
+			if ns := requestNamespace(req); ns != "" {
+				return []Attribute{{Key: NamespaceKey, Value: ns}}
+			}
+			return nil
+		},
+		next: rt,
+	}
+}
+
+// requestNamespace returns the namespace in the path of a Kubernetes API
+// request, e.g. /api/v1/namespaces/{namespace}/pods
+func requestNamespace(req *http.Request) string {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	for i := 0; i < len(parts)-1; i++ {
+		if parts[i] == "namespaces" {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
@@ -137,7 +137,7 @@ func deleteSlotAnns(tc *v1alpha1.TidbCluster) map[string]string {
 	if tc == nil || tc.Annotations == nil {
 		return anns
 	}
-	for _, key := range []string{label.AnnPDDeleteSlots, label.AnnTiDBDeleteSlots, label.AnnTiKVDeleteSlots, label.AnnTiFlashDeleteSlots} {
+	for _, key := range []string{label.AnnPDDeleteSlots, label.AnnTiDBDeleteSlots, label.AnnTiKVDeleteSlots, label.AnnTiFlashDeleteSlots, label.AnnTiCDCDeleteSlots, label.AnnPumpDeleteSlots} {
 		if v, ok := tc.Annotations[key]; ok {
 			anns[key] = v
 		}
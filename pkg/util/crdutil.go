@@ -59,6 +59,13 @@ var (
 		Description: "The desired replicas number of PD cluster",
 		JSONPath:    ".spec.pd.replicas",
 	}
+	tidbClusterPDUpgradedColumn = extensionsobj.CustomResourceColumnDefinition{
+		Name:        "PD-Upgraded",
+		Type:        "integer",
+		Description: "The upgraded replicas number of PD cluster in the ongoing upgrade",
+		JSONPath:    ".status.pd.upgrade.upgradedReplicas",
+		Priority:    1,
+	}
 	tidbClusterTiKVColumn = extensionsobj.CustomResourceColumnDefinition{
 		Name:        "TiKV",
 		Type:        "string",
@@ -83,6 +90,13 @@ var (
 		Description: "The desired replicas number of TiKV cluster",
 		JSONPath:    ".spec.tikv.replicas",
 	}
+	tidbClusterTiKVUpgradedColumn = extensionsobj.CustomResourceColumnDefinition{
+		Name:        "TiKV-Upgraded",
+		Type:        "integer",
+		Description: "The upgraded replicas number of TiKV cluster in the ongoing upgrade",
+		JSONPath:    ".status.tikv.upgrade.upgradedReplicas",
+		Priority:    1,
+	}
 	tidbClusterTiDBColumn = extensionsobj.CustomResourceColumnDefinition{
 		Name:        "TiDB",
 		Type:        "string",
@@ -101,6 +115,13 @@ var (
 		Description: "The desired replicas number of TiDB cluster",
 		JSONPath:    ".spec.tidb.replicas",
 	}
+	tidbClusterTiDBUpgradedColumn = extensionsobj.CustomResourceColumnDefinition{
+		Name:        "TiDB-Upgraded",
+		Type:        "integer",
+		Description: "The upgraded replicas number of TiDB cluster in the ongoing upgrade",
+		JSONPath:    ".status.tidb.upgrade.upgradedReplicas",
+		Priority:    1,
+	}
 	dmClusteradditionalPrinterColumns []extensionsobj.CustomResourceColumnDefinition
 	dmClusterReadyColumn              = extensionsobj.CustomResourceColumnDefinition{
 		Name:     "Ready",
@@ -319,9 +340,9 @@ var (
 func init() {
 	tidbClusteradditionalPrinterColumns = append(tidbClusteradditionalPrinterColumns,
 		tidbClusterReadyColumn,
-		tidbClusterPDColumn, tidbClusterPDStorageColumn, tidbClusterPDReadyColumn, tidbClusterPDDesireColumn,
-		tidbClusterTiKVColumn, tidbClusterTiKVStorageColumn, tidbClusterTiKVReadyColumn, tidbClusterTiKVDesireColumn,
-		tidbClusterTiDBColumn, tidbClusterTiDBReadyColumn, tidbClusterTiDBDesireColumn, tidbClusterStatusMessageColumn, ageColumn)
+		tidbClusterPDColumn, tidbClusterPDStorageColumn, tidbClusterPDReadyColumn, tidbClusterPDDesireColumn, tidbClusterPDUpgradedColumn,
+		tidbClusterTiKVColumn, tidbClusterTiKVStorageColumn, tidbClusterTiKVReadyColumn, tidbClusterTiKVDesireColumn, tidbClusterTiKVUpgradedColumn,
+		tidbClusterTiDBColumn, tidbClusterTiDBReadyColumn, tidbClusterTiDBDesireColumn, tidbClusterTiDBUpgradedColumn, tidbClusterStatusMessageColumn, ageColumn)
 	dmClusteradditionalPrinterColumns = append(dmClusteradditionalPrinterColumns,
 		dmClusterReadyColumn,
 		dmClusterMasterColumn, dmClusterMasterStorageColumn, dmClusterMasterReadyColumn, dmClusterMasterDesireColumn,
@@ -371,6 +392,8 @@ func GetCrdKindFromKindName(kindName string) (v1alpha1.CrdKind, error) {
 		return v1alpha1.DefaultCrdKinds.TiDBInitializer, nil
 	case v1alpha1.TidbClusterAutoScalerKindKey:
 		return v1alpha1.DefaultCrdKinds.TidbClusterAutoScaler, nil
+	case v1alpha1.TidbClusterConfigProfileKindKey:
+		return v1alpha1.DefaultCrdKinds.TidbClusterConfigProfile, nil
 	default:
 		return v1alpha1.CrdKind{}, errors.New("unknown CrdKind Name")
 	}
@@ -56,6 +56,8 @@ func TestGetCrdKindFromKindName(t *testing.T) {
 		Should(Equal(v1alpha1.DefaultCrdKinds.TiDBInitializer))
 	g.Expect(GetCrdKindFromKindName("TidbClusterAutoScaler")).
 		Should(Equal(v1alpha1.DefaultCrdKinds.TidbClusterAutoScaler))
+	g.Expect(GetCrdKindFromKindName("TidbClusterConfigProfile")).
+		Should(Equal(v1alpha1.DefaultCrdKinds.TidbClusterConfigProfile))
 	_, err := GetCrdKindFromKindName("pingcap")
 	g.Expect(err).
 		Should(MatchError("unknown CrdKind Name"))
@@ -68,6 +68,21 @@ func SetDMClusterCondition(status *v1alpha1.DMClusterStatus, condition v1alpha1.
 	status.Conditions = append(newConditions, condition)
 }
 
+// UpdateDMClusterCondition is like SetDMClusterCondition, but it also updates the condition
+// if only its message changes, for the conditions whose message carries the details.
+func UpdateDMClusterCondition(status *v1alpha1.DMClusterStatus, condition v1alpha1.DMClusterCondition) {
+	currentCond := GetDMClusterCondition(*status, condition.Type)
+	if currentCond != nil && currentCond.Status == condition.Status && currentCond.Reason == condition.Reason && currentCond.Message == condition.Message {
+		return
+	}
+	// Do not update lastTransitionTime if the status of the condition doesn't change.
+	if currentCond != nil && currentCond.Status == condition.Status {
+		condition.LastTransitionTime = currentCond.LastTransitionTime
+	}
+	newConditions := filterOutCondition(status.Conditions, condition.Type)
+	status.Conditions = append(newConditions, condition)
+}
+
 // filterOutCondition returns a new slice of tidbcluster conditions without conditions with the provided type.
 func filterOutCondition(conditions []v1alpha1.DMClusterCondition, condType v1alpha1.DMClusterConditionType) []v1alpha1.DMClusterCondition {
 	var newConditions []v1alpha1.DMClusterCondition
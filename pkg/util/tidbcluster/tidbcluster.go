@@ -34,6 +34,8 @@ const (
 	TiDBUnhealthy = "TiDBUnhealthy"
 	// TiFlashStoreNotUp is added when one of tiflash stores is not up.
 	TiFlashStoreNotUp = "TiFlashStoreNotUp"
+	// UpgradeNotInProgress is added when the upgrade blocked is reverted.
+	UpgradeNotInProgress = "UpgradeNotInProgress"
 )
 
 // NewTidbClusterCondition creates a new tidbcluster condition.
@@ -74,6 +76,26 @@ func SetTidbClusterCondition(status *v1alpha1.TidbClusterStatus, condition v1alp
 	status.Conditions = append(newConditions, condition)
 }
 
+// UpdateTidbClusterCondition is like SetTidbClusterCondition, but it also updates the condition
+// if only its message changes, for the conditions whose message carries the details.
+func UpdateTidbClusterCondition(status *v1alpha1.TidbClusterStatus, condition v1alpha1.TidbClusterCondition) {
+	currentCond := GetTidbClusterCondition(*status, condition.Type)
+	if currentCond != nil && currentCond.Status == condition.Status && currentCond.Reason == condition.Reason && currentCond.Message == condition.Message {
+		return
+	}
+	// Do not update lastTransitionTime if the status of the condition doesn't change.
+	if currentCond != nil && currentCond.Status == condition.Status {
+		condition.LastTransitionTime = currentCond.LastTransitionTime
+	}
+	newConditions := filterOutCondition(status.Conditions, condition.Type)
+	status.Conditions = append(newConditions, condition)
+}
+
+// RemoveTidbClusterCondition removes the condition with the provided type.
+func RemoveTidbClusterCondition(status *v1alpha1.TidbClusterStatus, condType v1alpha1.TidbClusterConditionType) {
+	status.Conditions = filterOutCondition(status.Conditions, condType)
+}
+
 // filterOutCondition returns a new slice of tidbcluster conditions without conditions with the provided type.
 func filterOutCondition(conditions []v1alpha1.TidbClusterCondition, condType v1alpha1.TidbClusterConditionType) []v1alpha1.TidbClusterCondition {
 	var newConditions []v1alpha1.TidbClusterCondition
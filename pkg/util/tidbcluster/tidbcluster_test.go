@@ -71,3 +71,36 @@ func TestCondition(t *testing.T) {
 	getc = GetTidbClusterReadyCondition(status)
 	g.Expect(getc).Should(Equal(c3))
 }
+
+func TestUpdateCondition(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := NewTidbClusterCondition(v1alpha1.TidbClusterReady, v1.ConditionTrue, StatfulSetNotUpToDate, "message1")
+	status := v1alpha1.TidbClusterStatus{
+		Conditions: []v1alpha1.TidbClusterCondition{*c},
+	}
+
+	// not updated if nothing changes
+	c1 := NewTidbClusterCondition(v1alpha1.TidbClusterReady, v1.ConditionTrue, StatfulSetNotUpToDate, "message1")
+	for c1.LastTransitionTime.Equal(&c.LastTransitionTime) {
+		c1.LastTransitionTime = metav1.NewTime(time.Now())
+	}
+	UpdateTidbClusterCondition(&status, *c1)
+	g.Expect(GetTidbClusterCondition(status, v1alpha1.TidbClusterReady)).Should(Equal(c))
+
+	// the message is updated even if the status and reason do not change
+	c2 := NewTidbClusterCondition(v1alpha1.TidbClusterReady, v1.ConditionTrue, StatfulSetNotUpToDate, "message2")
+	for c2.LastTransitionTime.Equal(&c.LastTransitionTime) {
+		c2.LastTransitionTime = metav1.NewTime(time.Now())
+	}
+	UpdateTidbClusterCondition(&status, *c2)
+	getc := GetTidbClusterCondition(status, v1alpha1.TidbClusterReady)
+	g.Expect(getc.Message).Should(Equal(c2.Message))
+	g.Expect(getc.LastTransitionTime).Should(Equal(c.LastTransitionTime))
+
+	// status change from True -> False
+	c3 := NewTidbClusterCondition(v1alpha1.TidbClusterReady, v1.ConditionFalse, StatfulSetNotUpToDate, "message3")
+	UpdateTidbClusterCondition(&status, *c3)
+	g.Expect(GetTidbClusterCondition(status, v1alpha1.TidbClusterReady)).Should(Equal(c3))
+	g.Expect(len(status.Conditions)).Should(Equal(1))
+}
@@ -111,6 +111,12 @@ func GetPodOrdinals(tc *v1alpha1.TidbCluster, memberType v1alpha1.MemberType) (s
 	} else if memberType == v1alpha1.TiFlashMemberType {
 		ann = label.AnnTiFlashDeleteSlots
 		replicas = tc.Spec.TiFlash.Replicas
+	} else if memberType == v1alpha1.TiCDCMemberType {
+		ann = label.AnnTiCDCDeleteSlots
+		replicas = tc.Spec.TiCDC.Replicas
+	} else if memberType == v1alpha1.PumpMemberType {
+		ann = label.AnnPumpDeleteSlots
+		replicas = tc.Spec.Pump.Replicas
 	} else {
 		return nil, fmt.Errorf("unknown member type %v", memberType)
 	}
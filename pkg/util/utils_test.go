@@ -163,6 +163,23 @@ func TestGetPodOrdinals(t *testing.T) {
 			memberType:  v1alpha1.TiDBMemberType,
 			deleteSlots: sets.NewInt32(0, 3, 4),
 		},
+		{
+			name: "ticdc delete slots",
+			tc: &v1alpha1.TidbCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						label.AnnTiCDCDeleteSlots: "[1]",
+					},
+				},
+				Spec: v1alpha1.TidbClusterSpec{
+					TiCDC: &v1alpha1.TiCDCSpec{
+						Replicas: 3,
+					},
+				},
+			},
+			memberType:  v1alpha1.TiCDCMemberType,
+			deleteSlots: sets.NewInt32(0, 2, 3),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,181 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pod
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	operatorUtils "github.com/pingcap/tidb-operator/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog"
+)
+
+// failoverSpreadWeight is the weight of the preferred node affinity pushing
+// a failover replacement to the under-populated topology domains
+const failoverSpreadWeight = 100
+
+// failoverTopologySpread generates the topology spread of the pod if it is a
+// replacement provisioned by failover, so that it is pushed to the topology
+// domains holding the fewest pods of the component given the current placement
+func (pc *PodAdmissionControl) failoverTopologySpread(tc *v1alpha1.TidbCluster, pod *corev1.Pod) error {
+	var spec v1alpha1.ComponentAccessor
+	var desired, failover sets.Int32
+	l := label.Label(pod.Labels)
+	switch {
+	case l.IsPD() && tc.Spec.PD != nil:
+		spec, desired, failover = tc.BasePDSpec(), tc.PDStsDesiredOrdinals(true), tc.PDStsDesiredOrdinals(false)
+	case l.IsTiKV() && tc.Spec.TiKV != nil:
+		spec, desired, failover = tc.BaseTiKVSpec(), tc.TiKVStsDesiredOrdinals(true), tc.TiKVStsDesiredOrdinals(false)
+	case l.IsTiFlash() && tc.Spec.TiFlash != nil:
+		spec, desired, failover = tc.BaseTiFlashSpec(), tc.TiFlashStsDesiredOrdinals(true), tc.TiFlashStsDesiredOrdinals(false)
+	case l.IsTiDB() && tc.Spec.TiDB != nil:
+		spec, desired, failover = tc.BaseTiDBSpec(), tc.TiDBStsDesiredOrdinals(true), tc.TiDBStsDesiredOrdinals(false)
+	default:
+		return nil
+	}
+
+	ordinal, err := operatorUtils.GetOrdinalFromPodName(pod.Name)
+	if err != nil {
+		return nil
+	}
+	if desired.Has(ordinal) || !failover.Has(ordinal) {
+		return nil
+	}
+	constraints := spec.FailoverTopologySpreadConstraints()
+	if len(constraints) == 0 {
+		return nil
+	}
+
+	selector, err := label.New().Instance(tc.GetInstanceName()).Component(l[label.ComponentLabelKey]).Selector()
+	if err != nil {
+		return err
+	}
+	podList, err := pc.podLister.Pods(tc.Namespace).List(selector)
+	if err != nil {
+		return fmt.Errorf("failed to list pods of tc[%s/%s], error: %v", tc.Namespace, tc.Name, err)
+	}
+	nodes, err := pc.nodeLister.List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("failed to list nodes for tc[%s/%s], error: %v", tc.Namespace, tc.Name, err)
+	}
+	var pods []*corev1.Pod
+	for _, p := range podList {
+		if p.Name != pod.Name {
+			pods = append(pods, p)
+		}
+	}
+
+	spreads, terms := rebalanceTopologySpread(constraints, pods, nodes)
+	klog.Infof("tc[%s/%s]'s failover pod %s is spread by %v, preferring %v", tc.Namespace, tc.Name, pod.Name, spreads, terms)
+	for _, spread := range spreads {
+		replaced := false
+		for i := range pod.Spec.TopologySpreadConstraints {
+			if pod.Spec.TopologySpreadConstraints[i].TopologyKey == spread.TopologyKey {
+				pod.Spec.TopologySpreadConstraints[i] = spread
+				replaced = true
+			}
+		}
+		if !replaced {
+			pod.Spec.TopologySpreadConstraints = append(pod.Spec.TopologySpreadConstraints, spread)
+		}
+	}
+	if len(terms) > 0 {
+		if pod.Spec.Affinity == nil {
+			pod.Spec.Affinity = &corev1.Affinity{}
+		}
+		if pod.Spec.Affinity.NodeAffinity == nil {
+			pod.Spec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+		}
+		nodeAffinity := pod.Spec.Affinity.NodeAffinity
+		nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution, terms...)
+	}
+	return nil
+}
+
+// rebalanceTopologySpread computes the topology spread constraints of a failover
+// replacement, and the preferred node affinity terms pushing it to the topology
+// domains holding the fewest of the given pods. The constraints never block the
+// scheduling, as the domain that failed may hold the fewest pods.
+func rebalanceTopologySpread(constraints []corev1.TopologySpreadConstraint, pods []*corev1.Pod, nodes []*corev1.Node) ([]corev1.TopologySpreadConstraint, []corev1.PreferredSchedulingTerm) {
+	nodeByName := map[string]*corev1.Node{}
+	for _, node := range nodes {
+		nodeByName[node.Name] = node
+	}
+
+	var spreads []corev1.TopologySpreadConstraint
+	var terms []corev1.PreferredSchedulingTerm
+	for _, constraint := range constraints {
+		spread := *constraint.DeepCopy()
+		spread.WhenUnsatisfiable = corev1.ScheduleAnyway
+		spreads = append(spreads, spread)
+
+		counts := map[string]int{}
+		for _, node := range nodes {
+			if node.Spec.Unschedulable {
+				continue
+			}
+			if domain, ok := node.Labels[constraint.TopologyKey]; ok {
+				counts[domain] = 0
+			}
+		}
+		for _, pod := range pods {
+			node, ok := nodeByName[pod.Spec.NodeName]
+			if !ok || pod.DeletionTimestamp != nil {
+				continue
+			}
+			if domain, ok := node.Labels[constraint.TopologyKey]; ok {
+				if _, ok := counts[domain]; ok {
+					counts[domain]++
+				}
+			}
+		}
+		if len(counts) == 0 {
+			continue
+		}
+
+		min := -1
+		for _, count := range counts {
+			if min < 0 || count < min {
+				min = count
+			}
+		}
+		var domains []string
+		for domain, count := range counts {
+			if count == min {
+				domains = append(domains, domain)
+			}
+		}
+		// the pods are balanced across the domains already
+		if len(domains) == len(counts) {
+			continue
+		}
+		sort.Strings(domains)
+		terms = append(terms, corev1.PreferredSchedulingTerm{
+			Weight: failoverSpreadWeight,
+			Preference: corev1.NodeSelectorTerm{
+				MatchExpressions: []corev1.NodeSelectorRequirement{{
+					Key:      constraint.TopologyKey,
+					Operator: corev1.NodeSelectorOpIn,
+					Values:   domains,
+				}},
+			},
+		})
+	}
+	return spreads, terms
+}
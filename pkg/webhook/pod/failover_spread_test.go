@@ -0,0 +1,130 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pod
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/label"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/client/clientset/versioned/fake"
+	"github.com/pingcap/tidb-operator/pkg/manager/member"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const zoneKey = "topology.kubernetes.io/zone"
+
+func TestFailoverTopologySpread(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	kubeCli := kubefake.NewSimpleClientset()
+	cli := fake.NewSimpleClientset()
+	pc := newPodAdmissionControl(nil, kubeCli, cli)
+
+	tc := newTidbClusterForPodAdmissionControl(pdReplicas, tikvReplicas)
+	tc.Spec.TiKV.TopologySpreadConstraints = []v1alpha1.TopologySpreadConstraint{{TopologyKey: zoneKey}}
+	tc.Status.TiKV.FailureStores = map[string]v1alpha1.TiKVFailureStore{
+		"1": {PodName: member.TikvPodName(tcName, 1), StoreID: "1"},
+	}
+
+	// the pods and nodes are read from the listers
+	nodeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	podIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	pc.nodeLister = corelisters.NewNodeLister(nodeIndexer)
+	pc.podLister = corelisters.NewPodLister(podIndexer)
+	for node, zone := range map[string]string{"node-a": "a", "node-b": "b", "node-c": "c"} {
+		g.Expect(nodeIndexer.Add(&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: node, Labels: map[string]string{zoneKey: zone}},
+		})).To(Succeed())
+	}
+	// zone c is under-populated as tikv-0 and tikv-1 are both in zone a
+	for ordinal, node := range []string{"node-a", "node-a", "node-b"} {
+		g.Expect(podIndexer.Add(newTiKVPodOnNode(tc, int32(ordinal), node))).To(Succeed())
+	}
+
+	// the regular pods are not changed
+	pod := newTiKVPodOnNode(tc, 2, "")
+	g.Expect(pc.failoverTopologySpread(tc, pod)).To(Succeed())
+	g.Expect(pod.Spec.TopologySpreadConstraints).To(BeEmpty())
+	g.Expect(pod.Spec.Affinity).To(BeNil())
+
+	// the failover replacement is pushed to the under-populated zone
+	pod = newTiKVPodOnNode(tc, 3, "")
+	pod.Spec.TopologySpreadConstraints = tc.BaseTiKVSpec().TopologySpreadConstraints()
+	g.Expect(pc.failoverTopologySpread(tc, pod)).To(Succeed())
+	g.Expect(pod.Spec.TopologySpreadConstraints).To(HaveLen(1))
+	g.Expect(pod.Spec.TopologySpreadConstraints[0].TopologyKey).To(Equal(zoneKey))
+	g.Expect(pod.Spec.TopologySpreadConstraints[0].WhenUnsatisfiable).To(Equal(corev1.ScheduleAnyway))
+	g.Expect(pod.Spec.TopologySpreadConstraints[0].LabelSelector.MatchLabels).To(HaveKeyWithValue(label.ComponentLabelKey, label.TiKVLabelVal))
+	g.Expect(pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(Equal([]corev1.PreferredSchedulingTerm{{
+		Weight: failoverSpreadWeight,
+		Preference: corev1.NodeSelectorTerm{
+			MatchExpressions: []corev1.NodeSelectorRequirement{{Key: zoneKey, Operator: corev1.NodeSelectorOpIn, Values: []string{"c"}}},
+		},
+	}}))
+
+	// the failover topology spread constraints take precedence
+	tc.Spec.TiKV.FailoverTopologySpreadConstraints = []v1alpha1.TopologySpreadConstraint{{TopologyKey: "rack"}}
+	pod = newTiKVPodOnNode(tc, 3, "")
+	g.Expect(pc.failoverTopologySpread(tc, pod)).To(Succeed())
+	g.Expect(pod.Spec.TopologySpreadConstraints).To(HaveLen(1))
+	g.Expect(pod.Spec.TopologySpreadConstraints[0].TopologyKey).To(Equal("rack"))
+	g.Expect(pod.Spec.Affinity).To(BeNil())
+}
+
+func TestRebalanceTopologySpread(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	constraints := []corev1.TopologySpreadConstraint{{MaxSkew: 1, TopologyKey: zoneKey, WhenUnsatisfiable: corev1.DoNotSchedule}}
+	newNode := func(name, zone string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{zoneKey: zone}}}
+	}
+	newPod := func(node string) *corev1.Pod {
+		return &corev1.Pod{Spec: corev1.PodSpec{NodeName: node}}
+	}
+	nodes := []*corev1.Node{newNode("node-a", "a"), newNode("node-b", "b"), newNode("node-c", "c"), newNode("node-d", "d")}
+
+	// the pods are balanced
+	spreads, terms := rebalanceTopologySpread(constraints, []*corev1.Pod{newPod("node-a"), newPod("node-b"), newPod("node-c"), newPod("node-d")}, nodes)
+	g.Expect(spreads).To(HaveLen(1))
+	g.Expect(spreads[0].WhenUnsatisfiable).To(Equal(corev1.ScheduleAnyway))
+	g.Expect(constraints[0].WhenUnsatisfiable).To(Equal(corev1.DoNotSchedule))
+	g.Expect(terms).To(BeEmpty())
+
+	// all the zones holding the fewest pods are preferred
+	_, terms = rebalanceTopologySpread(constraints, []*corev1.Pod{newPod("node-a"), newPod("node-a"), newPod("node-c")}, nodes)
+	g.Expect(terms).To(HaveLen(1))
+	g.Expect(terms[0].Preference.MatchExpressions[0].Values).To(Equal([]string{"b", "d"}))
+
+	// the zones of unschedulable nodes are not preferred
+	nodes[1].Spec.Unschedulable = true
+	_, terms = rebalanceTopologySpread(constraints, []*corev1.Pod{newPod("node-a"), newPod("node-a"), newPod("node-c")}, nodes)
+	g.Expect(terms[0].Preference.MatchExpressions[0].Values).To(Equal([]string{"d"}))
+}
+
+func newTiKVPodOnNode(tc *v1alpha1.TidbCluster, ordinal int32, node string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      member.TikvPodName(tcName, ordinal),
+			Namespace: namespace,
+			Labels:    label.New().Instance(tc.GetInstanceName()).TiKV().Labels(),
+		},
+		Spec: corev1.PodSpec{NodeName: node},
+	}
+}
@@ -30,12 +30,10 @@ import (
 	"k8s.io/klog"
 )
 
-// mutatePod mutates the pod by setting hotRegion label if the pod is created by AutoScaling
+// mutatePod mutates the pod by setting hotRegion label if the pod is created by AutoScaling,
+// by generating the topology spread of the pod if it is provisioned by failover,
+// and by keeping the TiKV pod recreated by a store migration off the old node
 func (pc *PodAdmissionControl) mutatePod(ar *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
-	if !features.DefaultFeatureGate.Enabled(features.AutoScaling) {
-		return util.ARSuccess()
-	}
-
 	pod := &corev1.Pod{}
 	if err := json.Unmarshal(ar.Object.Raw, pod); err != nil {
 		return util.ARFail(err)
@@ -45,7 +43,10 @@ func (pc *PodAdmissionControl) mutatePod(ar *admissionv1beta1.AdmissionRequest)
 	if !l.IsManagedByTiDBOperator() {
 		return util.ARSuccess()
 	}
-	if !l.IsTiKV() {
+	hotRegion := features.DefaultFeatureGate.Enabled(features.AutoScaling) && l.IsTiKV()
+	failover := ar.Operation == admissionv1beta1.Create && (l.IsPD() || l.IsTiKV() || l.IsTiFlash() || l.IsTiDB())
+	migration := ar.Operation == admissionv1beta1.Create && l.IsTiKV()
+	if !hotRegion && !failover && !migration {
 		return util.ARSuccess()
 	}
 	tcName, exist := pod.Labels[label.InstanceLabelKey]
@@ -54,7 +55,7 @@ func (pc *PodAdmissionControl) mutatePod(ar *admissionv1beta1.AdmissionRequest)
 	}
 	namespace := ar.Namespace
 
-	tc, err := pc.operatorCli.PingcapV1alpha1().TidbClusters(namespace).Get(context.TODO(), tcName, metav1.GetOptions{})
+	tc, err := pc.tcLister.TidbClusters(namespace).Get(tcName)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			return util.ARSuccess()
@@ -62,8 +63,18 @@ func (pc *PodAdmissionControl) mutatePod(ar *admissionv1beta1.AdmissionRequest)
 		return util.ARFail(err)
 	}
 
-	if err := pc.tikvHotRegionSchedule(tc, pod); err != nil {
-		return util.ARFail(err)
+	if hotRegion {
+		if err := pc.tikvHotRegionSchedule(tc, pod); err != nil {
+			return util.ARFail(err)
+		}
+	}
+	if failover {
+		if err := pc.failoverTopologySpread(tc, pod); err != nil {
+			return util.ARFail(err)
+		}
+	}
+	if migration {
+		storeMigrationAntiAffinity(tc, pod)
 	}
 
 	patch, err := util.CreateJsonPatch(original, pod)
@@ -41,8 +41,10 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
+	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	eventv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog"
@@ -62,6 +64,10 @@ type PodAdmissionControl struct {
 	serviceAccounts sets.String
 	// tc lister
 	tcLister v1alpha1listers.TidbClusterLister
+	// pod lister of the pods managed by tidb-operator
+	podLister corelisters.PodLister
+	// node lister
+	nodeLister corelisters.NodeLister
 	// recorder to send event
 	recorder record.EventRecorder
 }
@@ -385,11 +391,22 @@ func (a *PodAdmissionControl) initialize(cli versioned.Interface, kubeCli kubern
 	// informer factory
 	informerFactory := informers.NewSharedInformerFactoryWithOptions(cli, a.resyncDuration)
 
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeCli, a.resyncDuration)
+	// only the pods managed by tidb-operator are cached
+	podInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeCli, a.resyncDuration,
+		kubeinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = fmt.Sprintf("%s=%s", label.ManagedByLabelKey, label.TiDBOperator)
+		}))
+
 	// initialize listers
 	a.tcLister = informerFactory.Pingcap().V1alpha1().TidbClusters().Lister()
+	a.podLister = podInformerFactory.Core().V1().Pods().Lister()
+	a.nodeLister = kubeInformerFactory.Core().V1().Nodes().Lister()
 
 	// Start informer factories after all controller are initialized.
 	informerFactory.Start(stopCh)
+	kubeInformerFactory.Start(stopCh)
+	podInformerFactory.Start(stopCh)
 
 	// Wait for all started informers' cache were synced.
 	for v, synced := range informerFactory.WaitForCacheSync(wait.NeverStop) {
@@ -397,6 +414,16 @@ func (a *PodAdmissionControl) initialize(cli versioned.Interface, kubeCli kubern
 			klog.Fatalf("error syncing informer for %v", v)
 		}
 	}
+	for v, synced := range kubeInformerFactory.WaitForCacheSync(wait.NeverStop) {
+		if !synced {
+			klog.Fatalf("error syncing informer for %v", v)
+		}
+	}
+	for v, synced := range podInformerFactory.WaitForCacheSync(wait.NeverStop) {
+		if !synced {
+			klog.Fatalf("error syncing informer for %v", v)
+		}
+	}
 
 	a.initialized = true
 	return nil
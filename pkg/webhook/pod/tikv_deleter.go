@@ -52,7 +52,12 @@ func (pc *PodAdmissionControl) admitDeleteTiKVPods(payload *admitPayload) *admis
 
 	switch controllerKind {
 	case v1alpha1.TiDBClusterKind:
-		expectedAddress = fmt.Sprintf("%s.%s-tikv-peer.%s.svc:20160", name, controllerName, namespace)
+		tc, ok := payload.controller.(*v1alpha1.TidbCluster)
+		if !ok {
+			klog.V(4).Infof("tikv pod[%s/%s]'s controller is not tidbcluster, admit to be deleted", namespace, name)
+			return util.ARSuccess()
+		}
+		expectedAddress = fmt.Sprintf("%s.%s-tikv-peer.%s.svc:%d", name, controllerName, namespace, tc.TiKVServerPort())
 	default:
 		// unreachable
 		klog.V(4).Infof("tikv pod[%s/%s] controlled by unknown controllerKind[%s], admite to delete", namespace, name, controllerKind)
@@ -197,6 +202,11 @@ func (pc *PodAdmissionControl) admitDeleteUpTiKVPodDuringUpgrading(payload *admi
 		return util.ARSuccess()
 	}
 
+	if tc.TiKVSingleStore() {
+		klog.Infof("Only one TiKV store in the cluster, skip evicting region leader for Pod %s/%s", namespace, name)
+		return util.ARSuccess()
+	}
+
 	controllerName := tc.GetName()
 	controllerKind := payload.controller.GetObjectKind().GroupVersionKind().Kind
 
@@ -0,0 +1,62 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pod
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pingcap/tidb-operator/pkg/apis/pingcap/v1alpha1"
+	"github.com/pingcap/tidb-operator/pkg/manager/member"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestStoreMigrationAntiAffinity(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tc := newTidbClusterForPodAdmissionControl(pdReplicas, tikvReplicas)
+	tc.Status.TiKV.StoreMigrations = map[string]v1alpha1.TiKVStoreMigration{
+		member.TikvPodName(tcName, 0): {StoreID: "1", NodeName: "node-a", Phase: v1alpha1.TiKVStoreMigrationOffline},
+		member.TikvPodName(tcName, 1): {StoreID: "2", NodeName: "node-b", Phase: v1alpha1.TiKVStoreMigrationRecreating},
+	}
+	notIn := corev1.NodeSelectorRequirement{Key: corev1.LabelHostname, Operator: corev1.NodeSelectorOpNotIn, Values: []string{"node-b"}}
+
+	// the pods not being recreated by a migration are not changed
+	for _, ordinal := range []int32{0, 2} {
+		pod := newTiKVPodOnNode(tc, ordinal, "")
+		storeMigrationAntiAffinity(tc, pod)
+		g.Expect(pod.Spec.Affinity).To(BeNil())
+	}
+
+	// the recreated pod is kept off the old node
+	pod := newTiKVPodOnNode(tc, 1, "")
+	storeMigrationAntiAffinity(tc, pod)
+	g.Expect(pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms).To(Equal([]corev1.NodeSelectorTerm{
+		{MatchExpressions: []corev1.NodeSelectorRequirement{notIn}},
+	}))
+
+	// the requirement is added to every existing term
+	pod = newTiKVPodOnNode(tc, 1, "")
+	in := corev1.NodeSelectorRequirement{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"tikv"}}
+	pod.Spec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{in}}, {}},
+		},
+	}}
+	storeMigrationAntiAffinity(tc, pod)
+	g.Expect(pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms).To(Equal([]corev1.NodeSelectorTerm{
+		{MatchExpressions: []corev1.NodeSelectorRequirement{in, notIn}},
+		{MatchExpressions: []corev1.NodeSelectorRequirement{notIn}},
+	}))
+}
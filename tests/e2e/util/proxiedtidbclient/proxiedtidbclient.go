@@ -42,6 +42,10 @@ func (p *proxiedTiDBClient) GetHealth(tc *v1alpha1.TidbCluster, ordinal int32) (
 	panic("implement when necessary")
 }
 
+func (p *proxiedTiDBClient) GetConnections(tc *v1alpha1.TidbCluster, ordinal int32) (int, error) {
+	panic("implement when necessary")
+}
+
 func (p *proxiedTiDBClient) GetInfo(tc *v1alpha1.TidbCluster, ordinal int32) (*controller.DBInfo, error) {
 	panic("implement when necessary")
 }
@@ -93,6 +97,53 @@ func (p *proxiedTiDBClient) GetSettings(tc *v1alpha1.TidbCluster, ordinal int32)
 	return &info, nil
 }
 
+func (p *proxiedTiDBClient) GetPlacementPolicy(tc *v1alpha1.TidbCluster, name string) (map[string]string, error) {
+	panic("implement when necessary")
+}
+
+func (p *proxiedTiDBClient) SetPlacementPolicy(tc *v1alpha1.TidbCluster, policy *v1alpha1.PlacementPolicy, exists bool) error {
+	panic("implement when necessary")
+}
+
+func (p *proxiedTiDBClient) GetTiFlashReplica(tc *v1alpha1.TidbCluster, database, table string) (*controller.TiFlashReplica, error) {
+	panic("implement when necessary")
+}
+
+func (p *proxiedTiDBClient) SetTiFlashReplica(tc *v1alpha1.TidbCluster, database, table string, replicas int32) error {
+	panic("implement when necessary")
+}
+
+func (p *proxiedTiDBClient) GetGCLifeTime(tc *v1alpha1.TidbCluster) (time.Duration, error) {
+	panic("implement when necessary")
+}
+
+func (p *proxiedTiDBClient) SetGCLifeTime(tc *v1alpha1.TidbCluster, lifeTime time.Duration) error {
+	panic("implement when necessary")
+}
+
 func NewProxiedTiDBClient(fw portforward.PortForward, caCert []byte) controller.TiDBControlInterface {
 	return &proxiedTiDBClient{fw: fw, httpClient: &http.Client{Timeout: 5 * time.Second}, caCert: caCert}
 }
+
+func (p *proxiedTiDBClient) GetSystemVariable(tc *v1alpha1.TidbCluster, name string) (string, error) {
+	panic("implement when necessary")
+}
+
+func (p *proxiedTiDBClient) SetSystemVariable(tc *v1alpha1.TidbCluster, name, value string) error {
+	panic("implement when necessary")
+}
+
+func (p *proxiedTiDBClient) SetConfig(tc *v1alpha1.TidbCluster, component, name string, value interface{}) error {
+	panic("implement when necessary")
+}
+
+func (p *proxiedTiDBClient) ProbeSQL(tc *v1alpha1.TidbCluster, ordinal int32, query string) error {
+	panic("implement when necessary")
+}
+
+func (p *proxiedTiDBClient) ProbeHTTP(tc *v1alpha1.TidbCluster, ordinal int32, path string) error {
+	panic("implement when necessary")
+}
+
+func (p *proxiedTiDBClient) ForgetCluster(namespace, tcName string) {
+}